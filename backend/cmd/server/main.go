@@ -19,7 +19,9 @@ import (
 	_ "github.com/Wei-Shaw/sub2api/ent/runtime"
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/handler"
+	"github.com/Wei-Shaw/sub2api/internal/repository"
 	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/Wei-Shaw/sub2api/internal/setup"
 	"github.com/Wei-Shaw/sub2api/internal/web"
 
@@ -67,6 +69,7 @@ func main() {
 	// Parse command line flags
 	setupMode := flag.Bool("setup", false, "Run setup wizard in CLI mode")
 	showVersion := flag.Bool("version", false, "Show version information")
+	reencryptSecrets := flag.Bool("reencrypt-secrets", false, "Re-encrypt stored credentials/proxy passwords/secret settings with the active master key and exit")
 	flag.Parse()
 
 	if *showVersion {
@@ -82,6 +85,14 @@ func main() {
 		return
 	}
 
+	// Online re-encryption / key rotation
+	if *reencryptSecrets {
+		if err := runReencryptSecrets(); err != nil {
+			log.Fatalf("Re-encrypt secrets failed: %v", err)
+		}
+		return
+	}
+
 	// Check if setup is needed
 	if setup.NeedsSetup() {
 		// Check if auto-setup is enabled (for Docker deployment)
@@ -102,6 +113,37 @@ func main() {
 	runMainServer()
 }
 
+// runReencryptSecrets 同步执行一次敏感数据重加密（主密钥轮换或启用加密后迁移存量数据），服务可保持运行。
+func runReencryptSecrets() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	client, sqlDB, err := repository.InitEnt(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := client.Close(); err != nil {
+			log.Printf("Failed to close db: %v", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	keyring, err := repository.ProvideSecretKeyring(cfg)
+	if err != nil {
+		return err
+	}
+	svc := service.NewSecretReencryptionService(repository.NewSecretReencryptionRepository(sqlDB, keyring), keyring, cfg)
+	results, err := svc.RunAll(ctx)
+	for _, r := range results {
+		log.Printf("%s: scanned=%d rewritten=%d failed=%d", r.Target, r.Scanned, r.Rewritten, r.Failed)
+	}
+	return err
+}
+
 func runSetupServer() {
 	r := gin.New()
 	r.Use(middleware.Recovery())
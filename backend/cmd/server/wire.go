@@ -71,6 +71,22 @@ func provideCleanup(
 	tokenRefresh *service.TokenRefreshService,
 	accountExpiry *service.AccountExpiryService,
 	usageCleanup *service.UsageCleanupService,
+	usageExport *service.UsageExportService,
+	payment *service.PaymentService,
+	subscriptionPlan *service.SubscriptionPlanService,
+	notification *service.NotificationService,
+	statement *service.UserStatementService,
+	priceOverride *service.ModelPriceOverrideService,
+	rateMultiplier *service.RateMultiplierService,
+	usageTag *service.UsageTagService,
+	usageAnomaly *service.UsageAnomalyService,
+	secretReencryption *service.SecretReencryptionService,
+	geminiResource *service.GeminiResourceService,
+	stickyStandby *service.StickyStandbyService,
+	guardrail *service.GuardrailService,
+	promptTemplate *service.PromptTemplateService,
+	configReload *service.ConfigReloadService,
+	billingOutbox *service.BillingOutboxService,
 	pricing *service.PricingService,
 	emailQueue *service.EmailQueueService,
 	billingCache *service.BillingCacheService,
@@ -130,6 +146,102 @@ func provideCleanup(
 				}
 				return nil
 			}},
+			{"UsageExportService", func() error {
+				if usageExport != nil {
+					usageExport.Stop()
+				}
+				return nil
+			}},
+			{"PaymentService", func() error {
+				if payment != nil {
+					payment.Stop()
+				}
+				return nil
+			}},
+			{"SubscriptionPlanService", func() error {
+				if subscriptionPlan != nil {
+					subscriptionPlan.Stop()
+				}
+				return nil
+			}},
+			{"NotificationService", func() error {
+				if notification != nil {
+					notification.Stop()
+				}
+				return nil
+			}},
+			{"UserStatementService", func() error {
+				if statement != nil {
+					statement.Stop()
+				}
+				return nil
+			}},
+			{"ModelPriceOverrideService", func() error {
+				if priceOverride != nil {
+					priceOverride.Stop()
+				}
+				return nil
+			}},
+			{"RateMultiplierService", func() error {
+				if rateMultiplier != nil {
+					rateMultiplier.Stop()
+				}
+				return nil
+			}},
+			{"UsageTagService", func() error {
+				if usageTag != nil {
+					usageTag.Stop()
+				}
+				return nil
+			}},
+			{"UsageAnomalyService", func() error {
+				if usageAnomaly != nil {
+					usageAnomaly.Stop()
+				}
+				return nil
+			}},
+			{"SecretReencryptionService", func() error {
+				if secretReencryption != nil {
+					secretReencryption.Stop()
+				}
+				return nil
+			}},
+			{"GeminiResourceService", func() error {
+				if geminiResource != nil {
+					geminiResource.Stop()
+				}
+				return nil
+			}},
+			{"StickyStandbyService", func() error {
+				if stickyStandby != nil {
+					stickyStandby.Stop()
+				}
+				return nil
+			}},
+			{"GuardrailService", func() error {
+				if guardrail != nil {
+					guardrail.Stop()
+				}
+				return nil
+			}},
+			{"PromptTemplateService", func() error {
+				if promptTemplate != nil {
+					promptTemplate.Stop()
+				}
+				return nil
+			}},
+			{"ConfigReloadService", func() error {
+				if configReload != nil {
+					configReload.Stop()
+				}
+				return nil
+			}},
+			{"BillingOutboxService", func() error {
+				if billingOutbox != nil {
+					billingOutbox.Stop()
+				}
+				return nil
+			}},
 			{"TokenRefreshService", func() error {
 				tokenRefresh.Stop()
 				return nil
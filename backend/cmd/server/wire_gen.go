@@ -43,7 +43,11 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 		return nil, err
 	}
 	userRepository := repository.NewUserRepository(client, db)
-	settingRepository := repository.NewSettingRepository(client)
+	keyring, err := repository.ProvideSecretKeyring(configConfig)
+	if err != nil {
+		return nil, err
+	}
+	settingRepository := repository.NewSettingRepository(client, keyring)
 	settingService := service.NewSettingService(settingRepository, configConfig)
 	redisClient := repository.ProvideRedis(configConfig)
 	emailCache := repository.NewEmailCache(redisClient)
@@ -54,7 +58,8 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	promoCodeRepository := repository.NewPromoCodeRepository(client)
 	billingCache := repository.NewBillingCache(redisClient)
 	userSubscriptionRepository := repository.NewUserSubscriptionRepository(client)
-	billingCacheService := service.NewBillingCacheService(billingCache, userRepository, userSubscriptionRepository, configConfig)
+	organizationRepository := repository.NewOrganizationRepository(db)
+	billingCacheService := service.NewBillingCacheService(billingCache, userRepository, userSubscriptionRepository, organizationRepository, configConfig)
 	apiKeyRepository := repository.NewAPIKeyRepository(client)
 	groupRepository := repository.NewGroupRepository(client, db)
 	apiKeyCache := repository.NewAPIKeyCache(redisClient)
@@ -66,27 +71,51 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	authHandler := handler.NewAuthHandler(configConfig, authService, userService, settingService, promoService)
 	userHandler := handler.NewUserHandler(userService)
 	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
-	usageLogRepository := repository.NewUsageLogRepository(client, db)
+	usageLogRepository := repository.NewUsageLogRepository(client, db, keyring)
 	usageService := service.NewUsageService(usageLogRepository, userRepository, client, apiKeyAuthCacheInvalidator)
-	usageHandler := handler.NewUsageHandler(usageService, apiKeyService)
+	usageExportRepository := repository.NewUsageExportRepository(client, db, keyring)
+	timingWheelService, err := service.ProvideTimingWheelService()
+	if err != nil {
+		return nil, err
+	}
+	usageExportService := service.ProvideUsageExportService(usageExportRepository, timingWheelService, configConfig)
+	usageHandler := handler.NewUsageHandler(usageService, apiKeyService, usageExportService)
 	redeemCodeRepository := repository.NewRedeemCodeRepository(client)
 	subscriptionService := service.NewSubscriptionService(groupRepository, userSubscriptionRepository, billingCacheService)
 	redeemCache := repository.NewRedeemCache(redisClient)
 	redeemService := service.NewRedeemService(redeemCodeRepository, userRepository, subscriptionService, redeemCache, billingCacheService, client, apiKeyAuthCacheInvalidator)
 	redeemHandler := handler.NewRedeemHandler(redeemService)
 	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService)
+	paymentOrderRepository := repository.NewPaymentOrderRepository(db)
+	paymentProviders := repository.ProvidePaymentProviders(configConfig)
+	paymentService := service.ProvidePaymentService(paymentOrderRepository, userRepository, groupRepository, userSubscriptionRepository, subscriptionService, billingCacheService, apiKeyAuthCacheInvalidator, client, paymentProviders, timingWheelService, configConfig)
+	paymentHandler := handler.NewPaymentHandler(paymentService)
+	subscriptionPlanRepository := repository.NewSubscriptionPlanRepository(db)
+	subscriptionPlanService := service.ProvideSubscriptionPlanService(subscriptionPlanRepository, userRepository, groupRepository, userSubscriptionRepository, subscriptionService, billingCacheService, apiKeyAuthCacheInvalidator, emailService, client, timingWheelService, configConfig)
+	subscriptionPlanHandler := handler.NewSubscriptionPlanHandler(subscriptionPlanService)
+	notificationRepository := repository.NewNotificationRepository(db)
+	notificationWebhookSender := repository.NewNotificationWebhookSender(configConfig)
+	notificationService := service.ProvideNotificationService(notificationRepository, emailQueueService, notificationWebhookSender, timingWheelService, configConfig)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	userStatementRepository := repository.NewUserStatementRepository(db)
 	dashboardAggregationRepository := repository.NewDashboardAggregationRepository(db)
+	userStatementService := service.ProvideUserStatementService(userStatementRepository, dashboardAggregationRepository, settingService, emailService, timingWheelService, configConfig)
+	statementHandler := handler.NewStatementHandler(userStatementService)
+	usageTagRepository := repository.NewUsageTagRepository(db)
+	usageTagService := service.ProvideUsageTagService(usageTagRepository, userRepository, timingWheelService)
+	usageTagHandler := handler.NewUsageTagHandler(usageTagService)
+	organizationService := service.NewOrganizationService(organizationRepository, userRepository, usageLogRepository, apiKeyService, billingCacheService, apiKeyAuthCacheInvalidator, client)
+	organizationHandler := handler.NewOrganizationHandler(organizationService)
+	resellerRepository := repository.NewResellerRepository(db)
+	resellerService := service.NewResellerService(resellerRepository, userRepository, redeemCodeRepository, redeemService, billingCacheService, apiKeyAuthCacheInvalidator, client, configConfig)
+	resellerHandler := handler.NewResellerHandler(resellerService)
 	dashboardStatsCache := repository.NewDashboardCache(redisClient, configConfig)
 	dashboardService := service.NewDashboardService(usageLogRepository, dashboardAggregationRepository, dashboardStatsCache, configConfig)
-	timingWheelService, err := service.ProvideTimingWheelService()
-	if err != nil {
-		return nil, err
-	}
 	dashboardAggregationService := service.ProvideDashboardAggregationService(dashboardAggregationRepository, timingWheelService, configConfig)
 	dashboardHandler := admin.NewDashboardHandler(dashboardService, dashboardAggregationService)
 	schedulerCache := repository.NewSchedulerCache(redisClient)
-	accountRepository := repository.NewAccountRepository(client, db, schedulerCache)
-	proxyRepository := repository.NewProxyRepository(client, db)
+	accountRepository := repository.NewAccountRepository(client, db, schedulerCache, keyring)
+	proxyRepository := repository.NewProxyRepository(client, db, keyring)
 	proxyExitInfoProber := repository.NewProxyExitInfoProber(configConfig)
 	proxyLatencyCache := repository.NewProxyLatencyCache(redisClient)
 	adminService := service.NewAdminService(userRepository, groupRepository, accountRepository, proxyRepository, apiKeyRepository, redeemCodeRepository, billingCacheService, proxyExitInfoProber, proxyLatencyCache, apiKeyAuthCacheInvalidator)
@@ -137,13 +166,23 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	if err != nil {
 		return nil, err
 	}
-	billingService := service.NewBillingService(configConfig, pricingService)
+	modelPriceOverrideRepository := repository.NewModelPriceOverrideRepository(db)
+	modelPriceOverrideService := service.ProvideModelPriceOverrideService(modelPriceOverrideRepository, settingRepository, timingWheelService)
+	billingService := service.NewBillingService(configConfig, pricingService, modelPriceOverrideService)
 	identityService := service.NewIdentityService(identityCache)
 	deferredService := service.ProvideDeferredService(accountRepository, timingWheelService)
 	claudeTokenProvider := service.NewClaudeTokenProvider(accountRepository, geminiTokenCache, oAuthService)
-	gatewayService := service.NewGatewayService(accountRepository, groupRepository, usageLogRepository, userRepository, userSubscriptionRepository, gatewayCache, configConfig, schedulerSnapshotService, concurrencyService, billingService, rateLimitService, billingCacheService, identityService, httpUpstream, deferredService, claudeTokenProvider, sessionLimitCache)
+	billingOutboxRepository := repository.NewBillingOutboxRepository(db)
+	billingOutboxService := service.ProvideBillingOutboxService(billingOutboxRepository, usageLogRepository, userRepository, userSubscriptionRepository, resellerService, billingCacheService, client, timingWheelService)
+	rateMultiplierRuleRepository := repository.NewRateMultiplierRuleRepository(db)
+	rateMultiplierService := service.ProvideRateMultiplierService(rateMultiplierRuleRepository, groupRepository, userRepository, apiKeyRepository, timingWheelService, configConfig)
+	stickySessionRepository := repository.NewStickySessionRepository(db)
+	stickyStandbyCache := repository.NewStickyStandbyCache(redisClient)
+	stickyStandbyService := service.ProvideStickyStandbyService(stickySessionRepository, stickyStandbyCache, billingService, configConfig)
+	requestHedgeService := service.NewRequestHedgeService(configConfig)
+	gatewayService := service.NewGatewayService(accountRepository, groupRepository, usageLogRepository, userRepository, userSubscriptionRepository, gatewayCache, configConfig, schedulerSnapshotService, concurrencyService, billingService, rateLimitService, billingCacheService, identityService, httpUpstream, deferredService, claudeTokenProvider, sessionLimitCache, resellerService, billingOutboxService, rateMultiplierService, stickyStandbyService, requestHedgeService)
 	openAITokenProvider := service.NewOpenAITokenProvider(accountRepository, geminiTokenCache, openAIOAuthService)
-	openAIGatewayService := service.NewOpenAIGatewayService(accountRepository, usageLogRepository, userRepository, userSubscriptionRepository, gatewayCache, configConfig, schedulerSnapshotService, concurrencyService, billingService, rateLimitService, billingCacheService, httpUpstream, deferredService, openAITokenProvider)
+	openAIGatewayService := service.NewOpenAIGatewayService(accountRepository, usageLogRepository, userRepository, userSubscriptionRepository, gatewayCache, configConfig, schedulerSnapshotService, concurrencyService, billingService, rateLimitService, billingCacheService, httpUpstream, deferredService, openAITokenProvider, resellerService, billingOutboxService, rateMultiplierService)
 	geminiMessagesCompatService := service.NewGeminiMessagesCompatService(accountRepository, groupRepository, gatewayCache, schedulerSnapshotService, geminiTokenProvider, rateLimitService, httpUpstream, antigravityGatewayService, configConfig)
 	opsService := service.NewOpsService(opsRepository, settingRepository, configConfig, accountRepository, concurrencyService, gatewayService, openAIGatewayService, geminiMessagesCompatService, antigravityGatewayService)
 	settingHandler := admin.NewSettingHandler(settingService, emailService, turnstileService, opsService)
@@ -156,16 +195,47 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	adminSubscriptionHandler := admin.NewSubscriptionHandler(subscriptionService)
 	usageCleanupRepository := repository.NewUsageCleanupRepository(client, db)
 	usageCleanupService := service.ProvideUsageCleanupService(usageCleanupRepository, timingWheelService, dashboardAggregationService, configConfig)
-	adminUsageHandler := admin.NewUsageHandler(usageService, apiKeyService, adminService, usageCleanupService)
+	adminUsageHandler := admin.NewUsageHandler(usageService, apiKeyService, adminService, usageCleanupService, usageExportService)
 	userAttributeDefinitionRepository := repository.NewUserAttributeDefinitionRepository(client)
 	userAttributeValueRepository := repository.NewUserAttributeValueRepository(client)
 	userAttributeService := service.NewUserAttributeService(userAttributeDefinitionRepository, userAttributeValueRepository)
 	userAttributeHandler := admin.NewUserAttributeHandler(userAttributeService)
-	adminHandlers := handler.ProvideAdminHandlers(dashboardHandler, adminUserHandler, groupHandler, accountHandler, oAuthHandler, openAIOAuthHandler, geminiOAuthHandler, antigravityOAuthHandler, proxyHandler, adminRedeemHandler, promoHandler, settingHandler, opsHandler, systemHandler, adminSubscriptionHandler, adminUsageHandler, userAttributeHandler)
-	gatewayHandler := handler.NewGatewayHandler(gatewayService, geminiMessagesCompatService, antigravityGatewayService, userService, concurrencyService, billingCacheService, configConfig)
-	openAIGatewayHandler := handler.NewOpenAIGatewayHandler(openAIGatewayService, concurrencyService, billingCacheService, configConfig)
+	adminPaymentHandler := admin.NewPaymentHandler(paymentService)
+	adminSubscriptionPlanHandler := admin.NewSubscriptionPlanHandler(subscriptionPlanService)
+	adminOrganizationHandler := admin.NewOrganizationHandler(organizationService)
+	adminResellerHandler := admin.NewResellerHandler(resellerService)
+	pricingHandler := admin.NewPricingHandler(modelPriceOverrideService, billingService, adminService)
+	rateMultiplierHandler := admin.NewRateMultiplierHandler(rateMultiplierService)
+	adminUsageTagHandler := admin.NewUsageTagHandler(usageTagService)
+	usageAnomalyRepository := repository.NewUsageAnomalyRepository(db)
+	usageAnomalyService := service.ProvideUsageAnomalyService(usageAnomalyRepository, dashboardAggregationRepository, apiKeyService, notificationService, opsService, emailQueueService, timingWheelService, configConfig)
+	usageAnomalyHandler := admin.NewUsageAnomalyHandler(usageAnomalyService)
+	billingOutboxHandler := admin.NewBillingOutboxHandler(billingOutboxService)
+	anthropicFileRepository := repository.NewAnthropicFileRepository(db)
+	anthropicFileService := service.NewAnthropicFileService(anthropicFileRepository, accountRepository, gatewayService, configConfig)
+	anthropicFileHandler := admin.NewAnthropicFileHandler(anthropicFileService)
+	stickySessionHandler := admin.NewStickySessionHandler(stickyStandbyService)
+	requestHedgeHandler := admin.NewRequestHedgeHandler(requestHedgeService)
+	guardrailRepository := repository.NewGuardrailRepository(db)
+	guardrailHookClient := repository.NewGuardrailHookClient(configConfig)
+	guardrailService := service.ProvideGuardrailService(guardrailRepository, guardrailHookClient, timingWheelService, configConfig)
+	guardrailHandler := admin.NewGuardrailHandler(guardrailService)
+	promptTemplateRepository := repository.NewPromptTemplateRepository(db)
+	promptTemplateService := service.ProvidePromptTemplateService(promptTemplateRepository, timingWheelService)
+	promptTemplateHandler := admin.NewPromptTemplateHandler(promptTemplateService)
+	configReloadService := service.ProvideConfigReloadService(configConfig)
+	configHandler := admin.NewConfigHandler(configReloadService)
+	adminHandlers := handler.ProvideAdminHandlers(dashboardHandler, adminUserHandler, groupHandler, accountHandler, oAuthHandler, openAIOAuthHandler, geminiOAuthHandler, antigravityOAuthHandler, proxyHandler, adminRedeemHandler, promoHandler, settingHandler, opsHandler, systemHandler, adminSubscriptionHandler, adminUsageHandler, userAttributeHandler, adminPaymentHandler, adminSubscriptionPlanHandler, adminOrganizationHandler, adminResellerHandler, pricingHandler, rateMultiplierHandler, adminUsageTagHandler, usageAnomalyHandler, billingOutboxHandler, anthropicFileHandler, stickySessionHandler, requestHedgeHandler, guardrailHandler, promptTemplateHandler, configHandler)
+	costHoldCache := repository.NewCostHoldCache(redisClient)
+	costHoldService := service.NewCostHoldService(costHoldCache, billingService, billingCacheService, rateMultiplierService, resellerService, configConfig)
+	geminiResourceRepository := repository.NewGeminiResourceRepository(db)
+	geminiResourceService := service.ProvideGeminiResourceService(geminiResourceRepository, accountRepository, geminiMessagesCompatService, gatewayService, configConfig)
+	gatewayHandler := handler.NewGatewayHandler(gatewayService, geminiMessagesCompatService, antigravityGatewayService, userService, concurrencyService, billingCacheService, costHoldService, usageTagService, geminiResourceService, anthropicFileService, guardrailService, promptTemplateService, configConfig)
+	openAIRealtimeDialer := repository.NewOpenAIRealtimeDialer(configConfig)
+	openAIRealtimeService := service.NewOpenAIRealtimeService(openAIGatewayService, openAIRealtimeDialer, configConfig)
+	openAIGatewayHandler := handler.NewOpenAIGatewayHandler(openAIGatewayService, concurrencyService, billingCacheService, costHoldService, usageTagService, openAIRealtimeService, guardrailService, promptTemplateService, configConfig)
 	handlerSettingHandler := handler.ProvideSettingHandler(settingService, buildInfo)
-	handlers := handler.ProvideHandlers(authHandler, userHandler, apiKeyHandler, usageHandler, redeemHandler, subscriptionHandler, adminHandlers, gatewayHandler, openAIGatewayHandler, handlerSettingHandler)
+	handlers := handler.ProvideHandlers(authHandler, userHandler, apiKeyHandler, usageHandler, redeemHandler, subscriptionHandler, paymentHandler, subscriptionPlanHandler, notificationHandler, statementHandler, usageTagHandler, organizationHandler, resellerHandler, adminHandlers, gatewayHandler, openAIGatewayHandler, handlerSettingHandler)
 	jwtAuthMiddleware := middleware.NewJWTAuthMiddleware(authService, userService)
 	adminAuthMiddleware := middleware.NewAdminAuthMiddleware(authService, userService, settingService)
 	apiKeyAuthMiddleware := middleware.NewAPIKeyAuthMiddleware(apiKeyService, subscriptionService, configConfig)
@@ -178,7 +248,9 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	opsScheduledReportService := service.ProvideOpsScheduledReportService(opsService, userService, emailService, redisClient, configConfig)
 	tokenRefreshService := service.ProvideTokenRefreshService(accountRepository, oAuthService, openAIOAuthService, geminiOAuthService, antigravityOAuthService, compositeTokenCacheInvalidator, configConfig)
 	accountExpiryService := service.ProvideAccountExpiryService(accountRepository)
-	v := provideCleanup(client, redisClient, opsMetricsCollector, opsAggregationService, opsAlertEvaluatorService, opsCleanupService, opsScheduledReportService, schedulerSnapshotService, tokenRefreshService, accountExpiryService, usageCleanupService, pricingService, emailQueueService, billingCacheService, oAuthService, openAIOAuthService, geminiOAuthService, antigravityOAuthService)
+	secretReencryptionRepository := repository.NewSecretReencryptionRepository(db, keyring)
+	secretReencryptionService := service.ProvideSecretReencryptionService(secretReencryptionRepository, keyring, configConfig)
+	v := provideCleanup(client, redisClient, opsMetricsCollector, opsAggregationService, opsAlertEvaluatorService, opsCleanupService, opsScheduledReportService, schedulerSnapshotService, tokenRefreshService, accountExpiryService, usageCleanupService, usageExportService, paymentService, subscriptionPlanService, notificationService, userStatementService, modelPriceOverrideService, rateMultiplierService, usageTagService, usageAnomalyService, secretReencryptionService, geminiResourceService, stickyStandbyService, guardrailService, promptTemplateService, configReloadService, billingOutboxService, pricingService, emailQueueService, billingCacheService, oAuthService, openAIOAuthService, geminiOAuthService, antigravityOAuthService)
 	application := &Application{
 		Server:  httpServer,
 		Cleanup: v,
@@ -212,6 +284,22 @@ func provideCleanup(
 	tokenRefresh *service.TokenRefreshService,
 	accountExpiry *service.AccountExpiryService,
 	usageCleanup *service.UsageCleanupService,
+	usageExport *service.UsageExportService,
+	payment *service.PaymentService,
+	subscriptionPlan *service.SubscriptionPlanService,
+	notification *service.NotificationService,
+	statement *service.UserStatementService,
+	priceOverride *service.ModelPriceOverrideService,
+	rateMultiplier *service.RateMultiplierService,
+	usageTag *service.UsageTagService,
+	usageAnomaly *service.UsageAnomalyService,
+	secretReencryption *service.SecretReencryptionService,
+	geminiResource *service.GeminiResourceService,
+	stickyStandby *service.StickyStandbyService,
+	guardrail *service.GuardrailService,
+	promptTemplate *service.PromptTemplateService,
+	configReload *service.ConfigReloadService,
+	billingOutbox *service.BillingOutboxService,
 	pricing *service.PricingService,
 	emailQueue *service.EmailQueueService,
 	billingCache *service.BillingCacheService,
@@ -270,6 +358,102 @@ func provideCleanup(
 				}
 				return nil
 			}},
+			{"UsageExportService", func() error {
+				if usageExport != nil {
+					usageExport.Stop()
+				}
+				return nil
+			}},
+			{"PaymentService", func() error {
+				if payment != nil {
+					payment.Stop()
+				}
+				return nil
+			}},
+			{"SubscriptionPlanService", func() error {
+				if subscriptionPlan != nil {
+					subscriptionPlan.Stop()
+				}
+				return nil
+			}},
+			{"NotificationService", func() error {
+				if notification != nil {
+					notification.Stop()
+				}
+				return nil
+			}},
+			{"UserStatementService", func() error {
+				if statement != nil {
+					statement.Stop()
+				}
+				return nil
+			}},
+			{"ModelPriceOverrideService", func() error {
+				if priceOverride != nil {
+					priceOverride.Stop()
+				}
+				return nil
+			}},
+			{"RateMultiplierService", func() error {
+				if rateMultiplier != nil {
+					rateMultiplier.Stop()
+				}
+				return nil
+			}},
+			{"UsageTagService", func() error {
+				if usageTag != nil {
+					usageTag.Stop()
+				}
+				return nil
+			}},
+			{"UsageAnomalyService", func() error {
+				if usageAnomaly != nil {
+					usageAnomaly.Stop()
+				}
+				return nil
+			}},
+			{"SecretReencryptionService", func() error {
+				if secretReencryption != nil {
+					secretReencryption.Stop()
+				}
+				return nil
+			}},
+			{"GeminiResourceService", func() error {
+				if geminiResource != nil {
+					geminiResource.Stop()
+				}
+				return nil
+			}},
+			{"StickyStandbyService", func() error {
+				if stickyStandby != nil {
+					stickyStandby.Stop()
+				}
+				return nil
+			}},
+			{"GuardrailService", func() error {
+				if guardrail != nil {
+					guardrail.Stop()
+				}
+				return nil
+			}},
+			{"PromptTemplateService", func() error {
+				if promptTemplate != nil {
+					promptTemplate.Stop()
+				}
+				return nil
+			}},
+			{"ConfigReloadService", func() error {
+				if configReload != nil {
+					configReload.Stop()
+				}
+				return nil
+			}},
+			{"BillingOutboxService", func() error {
+				if billingOutbox != nil {
+					billingOutbox.Stop()
+				}
+				return nil
+			}},
 			{"TokenRefreshService", func() error {
 				tokenRefresh.Stop()
 				return nil
@@ -60,6 +60,14 @@ type Group struct {
 	ModelRouting map[string][]int64 `json:"model_routing,omitempty"`
 	// 是否启用模型路由配置
 	ModelRoutingEnabled bool `json:"model_routing_enabled,omitempty"`
+	// 预扣费用时允许透支的额度 (USD)
+	OverdraftUsd float64 `json:"overdraft_usd,omitempty"`
+	// count_tokens 计数方式: upstream/local/local_fallback
+	CountTokensMode string `json:"count_tokens_mode,omitempty"`
+	// 首字节过慢时是否向第二个账号发送对冲请求
+	HedgeEnabled bool `json:"hedge_enabled,omitempty"`
+	// 模型降级链配置：触发条件 + 模型模式 -> 有序降级模型列表
+	ModelFallback json.RawMessage `json:"model_fallback,omitempty"`
 	// Edges holds the relations/edges for other nodes in the graph.
 	// The values are being populated by the GroupQuery when eager-loading is set.
 	Edges        GroupEdges `json:"edges"`
@@ -166,15 +174,15 @@ func (*Group) scanValues(columns []string) ([]any, error) {
 	values := make([]any, len(columns))
 	for i := range columns {
 		switch columns[i] {
-		case group.FieldModelRouting:
+		case group.FieldModelRouting, group.FieldModelFallback:
 			values[i] = new([]byte)
-		case group.FieldIsExclusive, group.FieldClaudeCodeOnly, group.FieldModelRoutingEnabled:
+		case group.FieldIsExclusive, group.FieldClaudeCodeOnly, group.FieldModelRoutingEnabled, group.FieldHedgeEnabled:
 			values[i] = new(sql.NullBool)
-		case group.FieldRateMultiplier, group.FieldDailyLimitUsd, group.FieldWeeklyLimitUsd, group.FieldMonthlyLimitUsd, group.FieldImagePrice1k, group.FieldImagePrice2k, group.FieldImagePrice4k:
+		case group.FieldRateMultiplier, group.FieldDailyLimitUsd, group.FieldWeeklyLimitUsd, group.FieldMonthlyLimitUsd, group.FieldImagePrice1k, group.FieldImagePrice2k, group.FieldImagePrice4k, group.FieldOverdraftUsd:
 			values[i] = new(sql.NullFloat64)
 		case group.FieldID, group.FieldDefaultValidityDays, group.FieldFallbackGroupID:
 			values[i] = new(sql.NullInt64)
-		case group.FieldName, group.FieldDescription, group.FieldStatus, group.FieldPlatform, group.FieldSubscriptionType:
+		case group.FieldName, group.FieldDescription, group.FieldStatus, group.FieldPlatform, group.FieldSubscriptionType, group.FieldCountTokensMode:
 			values[i] = new(sql.NullString)
 		case group.FieldCreatedAt, group.FieldUpdatedAt, group.FieldDeletedAt:
 			values[i] = new(sql.NullTime)
@@ -336,6 +344,32 @@ func (_m *Group) assignValues(columns []string, values []any) error {
 			} else if value.Valid {
 				_m.ModelRoutingEnabled = value.Bool
 			}
+		case group.FieldOverdraftUsd:
+			if value, ok := values[i].(*sql.NullFloat64); !ok {
+				return fmt.Errorf("unexpected type %T for field overdraft_usd", values[i])
+			} else if value.Valid {
+				_m.OverdraftUsd = value.Float64
+			}
+		case group.FieldCountTokensMode:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field count_tokens_mode", values[i])
+			} else if value.Valid {
+				_m.CountTokensMode = value.String
+			}
+		case group.FieldHedgeEnabled:
+			if value, ok := values[i].(*sql.NullBool); !ok {
+				return fmt.Errorf("unexpected type %T for field hedge_enabled", values[i])
+			} else if value.Valid {
+				_m.HedgeEnabled = value.Bool
+			}
+		case group.FieldModelFallback:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field model_fallback", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.ModelFallback); err != nil {
+					return fmt.Errorf("unmarshal field model_fallback: %w", err)
+				}
+			}
 		default:
 			_m.selectValues.Set(columns[i], values[i])
 		}
@@ -492,6 +526,18 @@ func (_m *Group) String() string {
 	builder.WriteString(", ")
 	builder.WriteString("model_routing_enabled=")
 	builder.WriteString(fmt.Sprintf("%v", _m.ModelRoutingEnabled))
+	builder.WriteString(", ")
+	builder.WriteString("overdraft_usd=")
+	builder.WriteString(fmt.Sprintf("%v", _m.OverdraftUsd))
+	builder.WriteString(", ")
+	builder.WriteString("count_tokens_mode=")
+	builder.WriteString(_m.CountTokensMode)
+	builder.WriteString(", ")
+	builder.WriteString("hedge_enabled=")
+	builder.WriteString(fmt.Sprintf("%v", _m.HedgeEnabled))
+	builder.WriteString(", ")
+	builder.WriteString("model_fallback=")
+	builder.WriteString(fmt.Sprintf("%v", _m.ModelFallback))
 	builder.WriteByte(')')
 	return builder.String()
 }
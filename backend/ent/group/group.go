@@ -57,6 +57,14 @@ const (
 	FieldModelRouting = "model_routing"
 	// FieldModelRoutingEnabled holds the string denoting the model_routing_enabled field in the database.
 	FieldModelRoutingEnabled = "model_routing_enabled"
+	// FieldOverdraftUsd holds the string denoting the overdraft_usd field in the database.
+	FieldOverdraftUsd = "overdraft_usd"
+	// FieldCountTokensMode holds the string denoting the count_tokens_mode field in the database.
+	FieldCountTokensMode = "count_tokens_mode"
+	// FieldHedgeEnabled holds the string denoting the hedge_enabled field in the database.
+	FieldHedgeEnabled = "hedge_enabled"
+	// FieldModelFallback holds the string denoting the model_fallback field in the database.
+	FieldModelFallback = "model_fallback"
 	// EdgeAPIKeys holds the string denoting the api_keys edge name in mutations.
 	EdgeAPIKeys = "api_keys"
 	// EdgeRedeemCodes holds the string denoting the redeem_codes edge name in mutations.
@@ -153,6 +161,10 @@ var Columns = []string{
 	FieldFallbackGroupID,
 	FieldModelRouting,
 	FieldModelRoutingEnabled,
+	FieldOverdraftUsd,
+	FieldCountTokensMode,
+	FieldHedgeEnabled,
+	FieldModelFallback,
 }
 
 var (
@@ -212,6 +224,14 @@ var (
 	DefaultClaudeCodeOnly bool
 	// DefaultModelRoutingEnabled holds the default value on creation for the "model_routing_enabled" field.
 	DefaultModelRoutingEnabled bool
+	// DefaultOverdraftUsd holds the default value on creation for the "overdraft_usd" field.
+	DefaultOverdraftUsd float64
+	// DefaultCountTokensMode holds the default value on creation for the "count_tokens_mode" field.
+	DefaultCountTokensMode string
+	// CountTokensModeValidator is a validator for the "count_tokens_mode" field. It is called by the builders before save.
+	CountTokensModeValidator func(string) error
+	// DefaultHedgeEnabled holds the default value on creation for the "hedge_enabled" field.
+	DefaultHedgeEnabled bool
 )
 
 // OrderOption defines the ordering options for the Group queries.
@@ -322,6 +342,21 @@ func ByModelRoutingEnabled(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldModelRoutingEnabled, opts...).ToFunc()
 }
 
+// ByOverdraftUsd orders the results by the overdraft_usd field.
+func ByOverdraftUsd(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldOverdraftUsd, opts...).ToFunc()
+}
+
+// ByCountTokensMode orders the results by the count_tokens_mode field.
+func ByCountTokensMode(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldCountTokensMode, opts...).ToFunc()
+}
+
+// ByHedgeEnabled orders the results by the hedge_enabled field.
+func ByHedgeEnabled(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldHedgeEnabled, opts...).ToFunc()
+}
+
 // ByAPIKeysCount orders the results by api_keys count.
 func ByAPIKeysCount(opts ...sql.OrderTermOption) OrderOption {
 	return func(s *sql.Selector) {
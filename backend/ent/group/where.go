@@ -155,6 +155,21 @@ func ModelRoutingEnabled(v bool) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldModelRoutingEnabled, v))
 }
 
+// OverdraftUsd applies equality check predicate on the "overdraft_usd" field. It's identical to OverdraftUsdEQ.
+func OverdraftUsd(v float64) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldOverdraftUsd, v))
+}
+
+// CountTokensMode applies equality check predicate on the "count_tokens_mode" field. It's identical to CountTokensModeEQ.
+func CountTokensMode(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldCountTokensMode, v))
+}
+
+// HedgeEnabled applies equality check predicate on the "hedge_enabled" field. It's identical to HedgeEnabledEQ.
+func HedgeEnabled(v bool) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldHedgeEnabled, v))
+}
+
 // CreatedAtEQ applies the EQ predicate on the "created_at" field.
 func CreatedAtEQ(v time.Time) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldCreatedAt, v))
@@ -1090,6 +1105,131 @@ func ModelRoutingEnabledNEQ(v bool) predicate.Group {
 	return predicate.Group(sql.FieldNEQ(FieldModelRoutingEnabled, v))
 }
 
+// OverdraftUsdEQ applies the EQ predicate on the "overdraft_usd" field.
+func OverdraftUsdEQ(v float64) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldOverdraftUsd, v))
+}
+
+// OverdraftUsdNEQ applies the NEQ predicate on the "overdraft_usd" field.
+func OverdraftUsdNEQ(v float64) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldOverdraftUsd, v))
+}
+
+// OverdraftUsdIn applies the In predicate on the "overdraft_usd" field.
+func OverdraftUsdIn(vs ...float64) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldOverdraftUsd, vs...))
+}
+
+// OverdraftUsdNotIn applies the NotIn predicate on the "overdraft_usd" field.
+func OverdraftUsdNotIn(vs ...float64) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldOverdraftUsd, vs...))
+}
+
+// OverdraftUsdGT applies the GT predicate on the "overdraft_usd" field.
+func OverdraftUsdGT(v float64) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldOverdraftUsd, v))
+}
+
+// OverdraftUsdGTE applies the GTE predicate on the "overdraft_usd" field.
+func OverdraftUsdGTE(v float64) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldOverdraftUsd, v))
+}
+
+// OverdraftUsdLT applies the LT predicate on the "overdraft_usd" field.
+func OverdraftUsdLT(v float64) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldOverdraftUsd, v))
+}
+
+// OverdraftUsdLTE applies the LTE predicate on the "overdraft_usd" field.
+func OverdraftUsdLTE(v float64) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldOverdraftUsd, v))
+}
+
+// CountTokensModeEQ applies the EQ predicate on the "count_tokens_mode" field.
+func CountTokensModeEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldCountTokensMode, v))
+}
+
+// CountTokensModeNEQ applies the NEQ predicate on the "count_tokens_mode" field.
+func CountTokensModeNEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldCountTokensMode, v))
+}
+
+// CountTokensModeIn applies the In predicate on the "count_tokens_mode" field.
+func CountTokensModeIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldCountTokensMode, vs...))
+}
+
+// CountTokensModeNotIn applies the NotIn predicate on the "count_tokens_mode" field.
+func CountTokensModeNotIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldCountTokensMode, vs...))
+}
+
+// CountTokensModeGT applies the GT predicate on the "count_tokens_mode" field.
+func CountTokensModeGT(v string) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldCountTokensMode, v))
+}
+
+// CountTokensModeGTE applies the GTE predicate on the "count_tokens_mode" field.
+func CountTokensModeGTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldCountTokensMode, v))
+}
+
+// CountTokensModeLT applies the LT predicate on the "count_tokens_mode" field.
+func CountTokensModeLT(v string) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldCountTokensMode, v))
+}
+
+// CountTokensModeLTE applies the LTE predicate on the "count_tokens_mode" field.
+func CountTokensModeLTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldCountTokensMode, v))
+}
+
+// CountTokensModeContains applies the Contains predicate on the "count_tokens_mode" field.
+func CountTokensModeContains(v string) predicate.Group {
+	return predicate.Group(sql.FieldContains(FieldCountTokensMode, v))
+}
+
+// CountTokensModeHasPrefix applies the HasPrefix predicate on the "count_tokens_mode" field.
+func CountTokensModeHasPrefix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasPrefix(FieldCountTokensMode, v))
+}
+
+// CountTokensModeHasSuffix applies the HasSuffix predicate on the "count_tokens_mode" field.
+func CountTokensModeHasSuffix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasSuffix(FieldCountTokensMode, v))
+}
+
+// CountTokensModeEqualFold applies the EqualFold predicate on the "count_tokens_mode" field.
+func CountTokensModeEqualFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldEqualFold(FieldCountTokensMode, v))
+}
+
+// CountTokensModeContainsFold applies the ContainsFold predicate on the "count_tokens_mode" field.
+func CountTokensModeContainsFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldContainsFold(FieldCountTokensMode, v))
+}
+
+// HedgeEnabledEQ applies the EQ predicate on the "hedge_enabled" field.
+func HedgeEnabledEQ(v bool) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldHedgeEnabled, v))
+}
+
+// HedgeEnabledNEQ applies the NEQ predicate on the "hedge_enabled" field.
+func HedgeEnabledNEQ(v bool) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldHedgeEnabled, v))
+}
+
+// ModelFallbackIsNil applies the IsNil predicate on the "model_fallback" field.
+func ModelFallbackIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldModelFallback))
+}
+
+// ModelFallbackNotNil applies the NotNil predicate on the "model_fallback" field.
+func ModelFallbackNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldModelFallback))
+}
+
 // HasAPIKeys applies the HasEdge predicate on the "api_keys" edge.
 func HasAPIKeys() predicate.Group {
 	return predicate.Group(func(s *sql.Selector) {
@@ -4,6 +4,7 @@ package ent
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -306,6 +307,54 @@ func (_c *GroupCreate) SetNillableModelRoutingEnabled(v *bool) *GroupCreate {
 	return _c
 }
 
+// SetOverdraftUsd sets the "overdraft_usd" field.
+func (_c *GroupCreate) SetOverdraftUsd(v float64) *GroupCreate {
+	_c.mutation.SetOverdraftUsd(v)
+	return _c
+}
+
+// SetNillableOverdraftUsd sets the "overdraft_usd" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableOverdraftUsd(v *float64) *GroupCreate {
+	if v != nil {
+		_c.SetOverdraftUsd(*v)
+	}
+	return _c
+}
+
+// SetCountTokensMode sets the "count_tokens_mode" field.
+func (_c *GroupCreate) SetCountTokensMode(v string) *GroupCreate {
+	_c.mutation.SetCountTokensMode(v)
+	return _c
+}
+
+// SetNillableCountTokensMode sets the "count_tokens_mode" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableCountTokensMode(v *string) *GroupCreate {
+	if v != nil {
+		_c.SetCountTokensMode(*v)
+	}
+	return _c
+}
+
+// SetHedgeEnabled sets the "hedge_enabled" field.
+func (_c *GroupCreate) SetHedgeEnabled(v bool) *GroupCreate {
+	_c.mutation.SetHedgeEnabled(v)
+	return _c
+}
+
+// SetNillableHedgeEnabled sets the "hedge_enabled" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableHedgeEnabled(v *bool) *GroupCreate {
+	if v != nil {
+		_c.SetHedgeEnabled(*v)
+	}
+	return _c
+}
+
+// SetModelFallback sets the "model_fallback" field.
+func (_c *GroupCreate) SetModelFallback(v json.RawMessage) *GroupCreate {
+	_c.mutation.SetModelFallback(v)
+	return _c
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_c *GroupCreate) AddAPIKeyIDs(ids ...int64) *GroupCreate {
 	_c.mutation.AddAPIKeyIDs(ids...)
@@ -479,6 +528,18 @@ func (_c *GroupCreate) defaults() error {
 		v := group.DefaultModelRoutingEnabled
 		_c.mutation.SetModelRoutingEnabled(v)
 	}
+	if _, ok := _c.mutation.OverdraftUsd(); !ok {
+		v := group.DefaultOverdraftUsd
+		_c.mutation.SetOverdraftUsd(v)
+	}
+	if _, ok := _c.mutation.CountTokensMode(); !ok {
+		v := group.DefaultCountTokensMode
+		_c.mutation.SetCountTokensMode(v)
+	}
+	if _, ok := _c.mutation.HedgeEnabled(); !ok {
+		v := group.DefaultHedgeEnabled
+		_c.mutation.SetHedgeEnabled(v)
+	}
 	return nil
 }
 
@@ -537,6 +598,20 @@ func (_c *GroupCreate) check() error {
 	if _, ok := _c.mutation.ModelRoutingEnabled(); !ok {
 		return &ValidationError{Name: "model_routing_enabled", err: errors.New(`ent: missing required field "Group.model_routing_enabled"`)}
 	}
+	if _, ok := _c.mutation.OverdraftUsd(); !ok {
+		return &ValidationError{Name: "overdraft_usd", err: errors.New(`ent: missing required field "Group.overdraft_usd"`)}
+	}
+	if _, ok := _c.mutation.CountTokensMode(); !ok {
+		return &ValidationError{Name: "count_tokens_mode", err: errors.New(`ent: missing required field "Group.count_tokens_mode"`)}
+	}
+	if v, ok := _c.mutation.CountTokensMode(); ok {
+		if err := group.CountTokensModeValidator(v); err != nil {
+			return &ValidationError{Name: "count_tokens_mode", err: fmt.Errorf(`ent: validator failed for field "Group.count_tokens_mode": %w`, err)}
+		}
+	}
+	if _, ok := _c.mutation.HedgeEnabled(); !ok {
+		return &ValidationError{Name: "hedge_enabled", err: errors.New(`ent: missing required field "Group.hedge_enabled"`)}
+	}
 	return nil
 }
 
@@ -648,6 +723,22 @@ func (_c *GroupCreate) createSpec() (*Group, *sqlgraph.CreateSpec) {
 		_spec.SetField(group.FieldModelRoutingEnabled, field.TypeBool, value)
 		_node.ModelRoutingEnabled = value
 	}
+	if value, ok := _c.mutation.OverdraftUsd(); ok {
+		_spec.SetField(group.FieldOverdraftUsd, field.TypeFloat64, value)
+		_node.OverdraftUsd = value
+	}
+	if value, ok := _c.mutation.CountTokensMode(); ok {
+		_spec.SetField(group.FieldCountTokensMode, field.TypeString, value)
+		_node.CountTokensMode = value
+	}
+	if value, ok := _c.mutation.HedgeEnabled(); ok {
+		_spec.SetField(group.FieldHedgeEnabled, field.TypeBool, value)
+		_node.HedgeEnabled = value
+	}
+	if value, ok := _c.mutation.ModelFallback(); ok {
+		_spec.SetField(group.FieldModelFallback, field.TypeJSON, value)
+		_node.ModelFallback = value
+	}
 	if nodes := _c.mutation.APIKeysIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1158,6 +1249,66 @@ func (u *GroupUpsert) UpdateModelRoutingEnabled() *GroupUpsert {
 	return u
 }
 
+// SetOverdraftUsd sets the "overdraft_usd" field.
+func (u *GroupUpsert) SetOverdraftUsd(v float64) *GroupUpsert {
+	u.Set(group.FieldOverdraftUsd, v)
+	return u
+}
+
+// UpdateOverdraftUsd sets the "overdraft_usd" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateOverdraftUsd() *GroupUpsert {
+	u.SetExcluded(group.FieldOverdraftUsd)
+	return u
+}
+
+// AddOverdraftUsd adds v to the "overdraft_usd" field.
+func (u *GroupUpsert) AddOverdraftUsd(v float64) *GroupUpsert {
+	u.Add(group.FieldOverdraftUsd, v)
+	return u
+}
+
+// SetCountTokensMode sets the "count_tokens_mode" field.
+func (u *GroupUpsert) SetCountTokensMode(v string) *GroupUpsert {
+	u.Set(group.FieldCountTokensMode, v)
+	return u
+}
+
+// UpdateCountTokensMode sets the "count_tokens_mode" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateCountTokensMode() *GroupUpsert {
+	u.SetExcluded(group.FieldCountTokensMode)
+	return u
+}
+
+// SetHedgeEnabled sets the "hedge_enabled" field.
+func (u *GroupUpsert) SetHedgeEnabled(v bool) *GroupUpsert {
+	u.Set(group.FieldHedgeEnabled, v)
+	return u
+}
+
+// UpdateHedgeEnabled sets the "hedge_enabled" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateHedgeEnabled() *GroupUpsert {
+	u.SetExcluded(group.FieldHedgeEnabled)
+	return u
+}
+
+// SetModelFallback sets the "model_fallback" field.
+func (u *GroupUpsert) SetModelFallback(v json.RawMessage) *GroupUpsert {
+	u.Set(group.FieldModelFallback, v)
+	return u
+}
+
+// UpdateModelFallback sets the "model_fallback" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateModelFallback() *GroupUpsert {
+	u.SetExcluded(group.FieldModelFallback)
+	return u
+}
+
+// ClearModelFallback clears the value of the "model_fallback" field.
+func (u *GroupUpsert) ClearModelFallback() *GroupUpsert {
+	u.SetNull(group.FieldModelFallback)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -1616,6 +1767,76 @@ func (u *GroupUpsertOne) UpdateModelRoutingEnabled() *GroupUpsertOne {
 	})
 }
 
+// SetOverdraftUsd sets the "overdraft_usd" field.
+func (u *GroupUpsertOne) SetOverdraftUsd(v float64) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetOverdraftUsd(v)
+	})
+}
+
+// AddOverdraftUsd adds v to the "overdraft_usd" field.
+func (u *GroupUpsertOne) AddOverdraftUsd(v float64) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddOverdraftUsd(v)
+	})
+}
+
+// UpdateOverdraftUsd sets the "overdraft_usd" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateOverdraftUsd() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateOverdraftUsd()
+	})
+}
+
+// SetCountTokensMode sets the "count_tokens_mode" field.
+func (u *GroupUpsertOne) SetCountTokensMode(v string) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetCountTokensMode(v)
+	})
+}
+
+// UpdateCountTokensMode sets the "count_tokens_mode" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateCountTokensMode() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateCountTokensMode()
+	})
+}
+
+// SetHedgeEnabled sets the "hedge_enabled" field.
+func (u *GroupUpsertOne) SetHedgeEnabled(v bool) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetHedgeEnabled(v)
+	})
+}
+
+// UpdateHedgeEnabled sets the "hedge_enabled" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateHedgeEnabled() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateHedgeEnabled()
+	})
+}
+
+// SetModelFallback sets the "model_fallback" field.
+func (u *GroupUpsertOne) SetModelFallback(v json.RawMessage) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelFallback(v)
+	})
+}
+
+// UpdateModelFallback sets the "model_fallback" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateModelFallback() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelFallback()
+	})
+}
+
+// ClearModelFallback clears the value of the "model_fallback" field.
+func (u *GroupUpsertOne) ClearModelFallback() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearModelFallback()
+	})
+}
+
 // Exec executes the query.
 func (u *GroupUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -2240,6 +2461,76 @@ func (u *GroupUpsertBulk) UpdateModelRoutingEnabled() *GroupUpsertBulk {
 	})
 }
 
+// SetOverdraftUsd sets the "overdraft_usd" field.
+func (u *GroupUpsertBulk) SetOverdraftUsd(v float64) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetOverdraftUsd(v)
+	})
+}
+
+// AddOverdraftUsd adds v to the "overdraft_usd" field.
+func (u *GroupUpsertBulk) AddOverdraftUsd(v float64) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddOverdraftUsd(v)
+	})
+}
+
+// UpdateOverdraftUsd sets the "overdraft_usd" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateOverdraftUsd() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateOverdraftUsd()
+	})
+}
+
+// SetCountTokensMode sets the "count_tokens_mode" field.
+func (u *GroupUpsertBulk) SetCountTokensMode(v string) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetCountTokensMode(v)
+	})
+}
+
+// UpdateCountTokensMode sets the "count_tokens_mode" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateCountTokensMode() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateCountTokensMode()
+	})
+}
+
+// SetHedgeEnabled sets the "hedge_enabled" field.
+func (u *GroupUpsertBulk) SetHedgeEnabled(v bool) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetHedgeEnabled(v)
+	})
+}
+
+// UpdateHedgeEnabled sets the "hedge_enabled" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateHedgeEnabled() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateHedgeEnabled()
+	})
+}
+
+// SetModelFallback sets the "model_fallback" field.
+func (u *GroupUpsertBulk) SetModelFallback(v json.RawMessage) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelFallback(v)
+	})
+}
+
+// UpdateModelFallback sets the "model_fallback" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateModelFallback() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelFallback()
+	})
+}
+
+// ClearModelFallback clears the value of the "model_fallback" field.
+func (u *GroupUpsertBulk) ClearModelFallback() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearModelFallback()
+	})
+}
+
 // Exec executes the query.
 func (u *GroupUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
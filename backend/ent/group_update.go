@@ -4,12 +4,14 @@ package ent
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	"entgo.io/ent/dialect/sql"
 	"entgo.io/ent/dialect/sql/sqlgraph"
+	"entgo.io/ent/dialect/sql/sqljson"
 	"entgo.io/ent/schema/field"
 	"github.com/Wei-Shaw/sub2api/ent/account"
 	"github.com/Wei-Shaw/sub2api/ent/apikey"
@@ -421,6 +423,73 @@ func (_u *GroupUpdate) SetNillableModelRoutingEnabled(v *bool) *GroupUpdate {
 	return _u
 }
 
+// SetOverdraftUsd sets the "overdraft_usd" field.
+func (_u *GroupUpdate) SetOverdraftUsd(v float64) *GroupUpdate {
+	_u.mutation.ResetOverdraftUsd()
+	_u.mutation.SetOverdraftUsd(v)
+	return _u
+}
+
+// SetNillableOverdraftUsd sets the "overdraft_usd" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableOverdraftUsd(v *float64) *GroupUpdate {
+	if v != nil {
+		_u.SetOverdraftUsd(*v)
+	}
+	return _u
+}
+
+// AddOverdraftUsd adds value to the "overdraft_usd" field.
+func (_u *GroupUpdate) AddOverdraftUsd(v float64) *GroupUpdate {
+	_u.mutation.AddOverdraftUsd(v)
+	return _u
+}
+
+// SetCountTokensMode sets the "count_tokens_mode" field.
+func (_u *GroupUpdate) SetCountTokensMode(v string) *GroupUpdate {
+	_u.mutation.SetCountTokensMode(v)
+	return _u
+}
+
+// SetNillableCountTokensMode sets the "count_tokens_mode" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableCountTokensMode(v *string) *GroupUpdate {
+	if v != nil {
+		_u.SetCountTokensMode(*v)
+	}
+	return _u
+}
+
+// SetHedgeEnabled sets the "hedge_enabled" field.
+func (_u *GroupUpdate) SetHedgeEnabled(v bool) *GroupUpdate {
+	_u.mutation.SetHedgeEnabled(v)
+	return _u
+}
+
+// SetNillableHedgeEnabled sets the "hedge_enabled" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableHedgeEnabled(v *bool) *GroupUpdate {
+	if v != nil {
+		_u.SetHedgeEnabled(*v)
+	}
+	return _u
+}
+
+// SetModelFallback sets the "model_fallback" field.
+func (_u *GroupUpdate) SetModelFallback(v json.RawMessage) *GroupUpdate {
+	_u.mutation.SetModelFallback(v)
+	return _u
+}
+
+// AppendModelFallback appends value to the "model_fallback" field.
+func (_u *GroupUpdate) AppendModelFallback(v json.RawMessage) *GroupUpdate {
+	_u.mutation.AppendModelFallback(v)
+	return _u
+}
+
+// ClearModelFallback clears the value of the "model_fallback" field.
+func (_u *GroupUpdate) ClearModelFallback() *GroupUpdate {
+	_u.mutation.ClearModelFallback()
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *GroupUpdate) AddAPIKeyIDs(ids ...int64) *GroupUpdate {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -706,6 +775,11 @@ func (_u *GroupUpdate) check() error {
 			return &ValidationError{Name: "subscription_type", err: fmt.Errorf(`ent: validator failed for field "Group.subscription_type": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.CountTokensMode(); ok {
+		if err := group.CountTokensModeValidator(v); err != nil {
+			return &ValidationError{Name: "count_tokens_mode", err: fmt.Errorf(`ent: validator failed for field "Group.count_tokens_mode": %w`, err)}
+		}
+	}
 	return nil
 }
 
@@ -838,6 +912,29 @@ func (_u *GroupUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if value, ok := _u.mutation.ModelRoutingEnabled(); ok {
 		_spec.SetField(group.FieldModelRoutingEnabled, field.TypeBool, value)
 	}
+	if value, ok := _u.mutation.OverdraftUsd(); ok {
+		_spec.SetField(group.FieldOverdraftUsd, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedOverdraftUsd(); ok {
+		_spec.AddField(group.FieldOverdraftUsd, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.CountTokensMode(); ok {
+		_spec.SetField(group.FieldCountTokensMode, field.TypeString, value)
+	}
+	if value, ok := _u.mutation.HedgeEnabled(); ok {
+		_spec.SetField(group.FieldHedgeEnabled, field.TypeBool, value)
+	}
+	if value, ok := _u.mutation.ModelFallback(); ok {
+		_spec.SetField(group.FieldModelFallback, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedModelFallback(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, group.FieldModelFallback, value)
+		})
+	}
+	if _u.mutation.ModelFallbackCleared() {
+		_spec.ClearField(group.FieldModelFallback, field.TypeJSON)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1539,6 +1636,73 @@ func (_u *GroupUpdateOne) SetNillableModelRoutingEnabled(v *bool) *GroupUpdateOn
 	return _u
 }
 
+// SetOverdraftUsd sets the "overdraft_usd" field.
+func (_u *GroupUpdateOne) SetOverdraftUsd(v float64) *GroupUpdateOne {
+	_u.mutation.ResetOverdraftUsd()
+	_u.mutation.SetOverdraftUsd(v)
+	return _u
+}
+
+// SetNillableOverdraftUsd sets the "overdraft_usd" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableOverdraftUsd(v *float64) *GroupUpdateOne {
+	if v != nil {
+		_u.SetOverdraftUsd(*v)
+	}
+	return _u
+}
+
+// AddOverdraftUsd adds value to the "overdraft_usd" field.
+func (_u *GroupUpdateOne) AddOverdraftUsd(v float64) *GroupUpdateOne {
+	_u.mutation.AddOverdraftUsd(v)
+	return _u
+}
+
+// SetCountTokensMode sets the "count_tokens_mode" field.
+func (_u *GroupUpdateOne) SetCountTokensMode(v string) *GroupUpdateOne {
+	_u.mutation.SetCountTokensMode(v)
+	return _u
+}
+
+// SetNillableCountTokensMode sets the "count_tokens_mode" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableCountTokensMode(v *string) *GroupUpdateOne {
+	if v != nil {
+		_u.SetCountTokensMode(*v)
+	}
+	return _u
+}
+
+// SetHedgeEnabled sets the "hedge_enabled" field.
+func (_u *GroupUpdateOne) SetHedgeEnabled(v bool) *GroupUpdateOne {
+	_u.mutation.SetHedgeEnabled(v)
+	return _u
+}
+
+// SetNillableHedgeEnabled sets the "hedge_enabled" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableHedgeEnabled(v *bool) *GroupUpdateOne {
+	if v != nil {
+		_u.SetHedgeEnabled(*v)
+	}
+	return _u
+}
+
+// SetModelFallback sets the "model_fallback" field.
+func (_u *GroupUpdateOne) SetModelFallback(v json.RawMessage) *GroupUpdateOne {
+	_u.mutation.SetModelFallback(v)
+	return _u
+}
+
+// AppendModelFallback appends value to the "model_fallback" field.
+func (_u *GroupUpdateOne) AppendModelFallback(v json.RawMessage) *GroupUpdateOne {
+	_u.mutation.AppendModelFallback(v)
+	return _u
+}
+
+// ClearModelFallback clears the value of the "model_fallback" field.
+func (_u *GroupUpdateOne) ClearModelFallback() *GroupUpdateOne {
+	_u.mutation.ClearModelFallback()
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *GroupUpdateOne) AddAPIKeyIDs(ids ...int64) *GroupUpdateOne {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -1837,6 +2001,11 @@ func (_u *GroupUpdateOne) check() error {
 			return &ValidationError{Name: "subscription_type", err: fmt.Errorf(`ent: validator failed for field "Group.subscription_type": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.CountTokensMode(); ok {
+		if err := group.CountTokensModeValidator(v); err != nil {
+			return &ValidationError{Name: "count_tokens_mode", err: fmt.Errorf(`ent: validator failed for field "Group.count_tokens_mode": %w`, err)}
+		}
+	}
 	return nil
 }
 
@@ -1986,6 +2155,29 @@ func (_u *GroupUpdateOne) sqlSave(ctx context.Context) (_node *Group, err error)
 	if value, ok := _u.mutation.ModelRoutingEnabled(); ok {
 		_spec.SetField(group.FieldModelRoutingEnabled, field.TypeBool, value)
 	}
+	if value, ok := _u.mutation.OverdraftUsd(); ok {
+		_spec.SetField(group.FieldOverdraftUsd, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedOverdraftUsd(); ok {
+		_spec.AddField(group.FieldOverdraftUsd, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.CountTokensMode(); ok {
+		_spec.SetField(group.FieldCountTokensMode, field.TypeString, value)
+	}
+	if value, ok := _u.mutation.HedgeEnabled(); ok {
+		_spec.SetField(group.FieldHedgeEnabled, field.TypeBool, value)
+	}
+	if value, ok := _u.mutation.ModelFallback(); ok {
+		_spec.SetField(group.FieldModelFallback, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedModelFallback(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, group.FieldModelFallback, value)
+		})
+	}
+	if _u.mutation.ModelFallbackCleared() {
+		_spec.ClearField(group.FieldModelFallback, field.TypeJSON)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
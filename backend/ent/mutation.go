@@ -74,6 +74,8 @@ type APIKeyMutation struct {
 	appendip_whitelist []string
 	ip_blacklist       *[]string
 	appendip_blacklist []string
+	organization_id    *int64
+	addorganization_id *int64
 	clearedFields      map[string]struct{}
 	user               *int64
 	cleareduser        bool
@@ -629,6 +631,76 @@ func (m *APIKeyMutation) ResetIPBlacklist() {
 	delete(m.clearedFields, apikey.FieldIPBlacklist)
 }
 
+// SetOrganizationID sets the "organization_id" field.
+func (m *APIKeyMutation) SetOrganizationID(i int64) {
+	m.organization_id = &i
+	m.addorganization_id = nil
+}
+
+// OrganizationID returns the value of the "organization_id" field in the mutation.
+func (m *APIKeyMutation) OrganizationID() (r int64, exists bool) {
+	v := m.organization_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldOrganizationID returns the old "organization_id" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldOrganizationID(ctx context.Context) (v *int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldOrganizationID is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldOrganizationID requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldOrganizationID: %w", err)
+	}
+	return oldValue.OrganizationID, nil
+}
+
+// AddOrganizationID adds i to the "organization_id" field.
+func (m *APIKeyMutation) AddOrganizationID(i int64) {
+	if m.addorganization_id != nil {
+		*m.addorganization_id += i
+	} else {
+		m.addorganization_id = &i
+	}
+}
+
+// AddedOrganizationID returns the value that was added to the "organization_id" field in this mutation.
+func (m *APIKeyMutation) AddedOrganizationID() (r int64, exists bool) {
+	v := m.addorganization_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearOrganizationID clears the value of the "organization_id" field.
+func (m *APIKeyMutation) ClearOrganizationID() {
+	m.organization_id = nil
+	m.addorganization_id = nil
+	m.clearedFields[apikey.FieldOrganizationID] = struct{}{}
+}
+
+// OrganizationIDCleared returns if the "organization_id" field was cleared in this mutation.
+func (m *APIKeyMutation) OrganizationIDCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldOrganizationID]
+	return ok
+}
+
+// ResetOrganizationID resets all changes to the "organization_id" field.
+func (m *APIKeyMutation) ResetOrganizationID() {
+	m.organization_id = nil
+	m.addorganization_id = nil
+	delete(m.clearedFields, apikey.FieldOrganizationID)
+}
+
 // ClearUser clears the "user" edge to the User entity.
 func (m *APIKeyMutation) ClearUser() {
 	m.cleareduser = true
@@ -771,7 +843,7 @@ func (m *APIKeyMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *APIKeyMutation) Fields() []string {
-	fields := make([]string, 0, 10)
+	fields := make([]string, 0, 11)
 	if m.created_at != nil {
 		fields = append(fields, apikey.FieldCreatedAt)
 	}
@@ -802,6 +874,9 @@ func (m *APIKeyMutation) Fields() []string {
 	if m.ip_blacklist != nil {
 		fields = append(fields, apikey.FieldIPBlacklist)
 	}
+	if m.organization_id != nil {
+		fields = append(fields, apikey.FieldOrganizationID)
+	}
 	return fields
 }
 
@@ -830,6 +905,8 @@ func (m *APIKeyMutation) Field(name string) (ent.Value, bool) {
 		return m.IPWhitelist()
 	case apikey.FieldIPBlacklist:
 		return m.IPBlacklist()
+	case apikey.FieldOrganizationID:
+		return m.OrganizationID()
 	}
 	return nil, false
 }
@@ -859,6 +936,8 @@ func (m *APIKeyMutation) OldField(ctx context.Context, name string) (ent.Value,
 		return m.OldIPWhitelist(ctx)
 	case apikey.FieldIPBlacklist:
 		return m.OldIPBlacklist(ctx)
+	case apikey.FieldOrganizationID:
+		return m.OldOrganizationID(ctx)
 	}
 	return nil, fmt.Errorf("unknown APIKey field %s", name)
 }
@@ -938,6 +1017,13 @@ func (m *APIKeyMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetIPBlacklist(v)
 		return nil
+	case apikey.FieldOrganizationID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetOrganizationID(v)
+		return nil
 	}
 	return fmt.Errorf("unknown APIKey field %s", name)
 }
@@ -946,6 +1032,9 @@ func (m *APIKeyMutation) SetField(name string, value ent.Value) error {
 // this mutation.
 func (m *APIKeyMutation) AddedFields() []string {
 	var fields []string
+	if m.addorganization_id != nil {
+		fields = append(fields, apikey.FieldOrganizationID)
+	}
 	return fields
 }
 
@@ -954,6 +1043,8 @@ func (m *APIKeyMutation) AddedFields() []string {
 // was not set, or was not defined in the schema.
 func (m *APIKeyMutation) AddedField(name string) (ent.Value, bool) {
 	switch name {
+	case apikey.FieldOrganizationID:
+		return m.AddedOrganizationID()
 	}
 	return nil, false
 }
@@ -963,6 +1054,13 @@ func (m *APIKeyMutation) AddedField(name string) (ent.Value, bool) {
 // type.
 func (m *APIKeyMutation) AddField(name string, value ent.Value) error {
 	switch name {
+	case apikey.FieldOrganizationID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddOrganizationID(v)
+		return nil
 	}
 	return fmt.Errorf("unknown APIKey numeric field %s", name)
 }
@@ -983,6 +1081,9 @@ func (m *APIKeyMutation) ClearedFields() []string {
 	if m.FieldCleared(apikey.FieldIPBlacklist) {
 		fields = append(fields, apikey.FieldIPBlacklist)
 	}
+	if m.FieldCleared(apikey.FieldOrganizationID) {
+		fields = append(fields, apikey.FieldOrganizationID)
+	}
 	return fields
 }
 
@@ -1009,6 +1110,9 @@ func (m *APIKeyMutation) ClearField(name string) error {
 	case apikey.FieldIPBlacklist:
 		m.ClearIPBlacklist()
 		return nil
+	case apikey.FieldOrganizationID:
+		m.ClearOrganizationID()
+		return nil
 	}
 	return fmt.Errorf("unknown APIKey nullable field %s", name)
 }
@@ -1047,6 +1151,9 @@ func (m *APIKeyMutation) ResetField(name string) error {
 	case apikey.FieldIPBlacklist:
 		m.ResetIPBlacklist()
 		return nil
+	case apikey.FieldOrganizationID:
+		m.ResetOrganizationID()
+		return nil
 	}
 	return fmt.Errorf("unknown APIKey field %s", name)
 }
@@ -3869,6 +3976,12 @@ type GroupMutation struct {
 	addfallback_group_id     *int64
 	model_routing            *map[string][]int64
 	model_routing_enabled    *bool
+	overdraft_usd            *float64
+	addoverdraft_usd         *float64
+	count_tokens_mode        *string
+	hedge_enabled            *bool
+	model_fallback           *json.RawMessage
+	appendmodel_fallback     json.RawMessage
 	clearedFields            map[string]struct{}
 	api_keys                 map[int64]struct{}
 	removedapi_keys          map[int64]struct{}
@@ -5064,6 +5177,199 @@ func (m *GroupMutation) ResetModelRoutingEnabled() {
 	m.model_routing_enabled = nil
 }
 
+// SetOverdraftUsd sets the "overdraft_usd" field.
+func (m *GroupMutation) SetOverdraftUsd(f float64) {
+	m.overdraft_usd = &f
+	m.addoverdraft_usd = nil
+}
+
+// OverdraftUsd returns the value of the "overdraft_usd" field in the mutation.
+func (m *GroupMutation) OverdraftUsd() (r float64, exists bool) {
+	v := m.overdraft_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldOverdraftUsd returns the old "overdraft_usd" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldOverdraftUsd(ctx context.Context) (v float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldOverdraftUsd is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldOverdraftUsd requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldOverdraftUsd: %w", err)
+	}
+	return oldValue.OverdraftUsd, nil
+}
+
+// AddOverdraftUsd adds f to the "overdraft_usd" field.
+func (m *GroupMutation) AddOverdraftUsd(f float64) {
+	if m.addoverdraft_usd != nil {
+		*m.addoverdraft_usd += f
+	} else {
+		m.addoverdraft_usd = &f
+	}
+}
+
+// AddedOverdraftUsd returns the value that was added to the "overdraft_usd" field in this mutation.
+func (m *GroupMutation) AddedOverdraftUsd() (r float64, exists bool) {
+	v := m.addoverdraft_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ResetOverdraftUsd resets all changes to the "overdraft_usd" field.
+func (m *GroupMutation) ResetOverdraftUsd() {
+	m.overdraft_usd = nil
+	m.addoverdraft_usd = nil
+}
+
+// SetCountTokensMode sets the "count_tokens_mode" field.
+func (m *GroupMutation) SetCountTokensMode(s string) {
+	m.count_tokens_mode = &s
+}
+
+// CountTokensMode returns the value of the "count_tokens_mode" field in the mutation.
+func (m *GroupMutation) CountTokensMode() (r string, exists bool) {
+	v := m.count_tokens_mode
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldCountTokensMode returns the old "count_tokens_mode" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldCountTokensMode(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldCountTokensMode is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldCountTokensMode requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldCountTokensMode: %w", err)
+	}
+	return oldValue.CountTokensMode, nil
+}
+
+// ResetCountTokensMode resets all changes to the "count_tokens_mode" field.
+func (m *GroupMutation) ResetCountTokensMode() {
+	m.count_tokens_mode = nil
+}
+
+// SetHedgeEnabled sets the "hedge_enabled" field.
+func (m *GroupMutation) SetHedgeEnabled(b bool) {
+	m.hedge_enabled = &b
+}
+
+// HedgeEnabled returns the value of the "hedge_enabled" field in the mutation.
+func (m *GroupMutation) HedgeEnabled() (r bool, exists bool) {
+	v := m.hedge_enabled
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldHedgeEnabled returns the old "hedge_enabled" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldHedgeEnabled(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldHedgeEnabled is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldHedgeEnabled requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldHedgeEnabled: %w", err)
+	}
+	return oldValue.HedgeEnabled, nil
+}
+
+// ResetHedgeEnabled resets all changes to the "hedge_enabled" field.
+func (m *GroupMutation) ResetHedgeEnabled() {
+	m.hedge_enabled = nil
+}
+
+// SetModelFallback sets the "model_fallback" field.
+func (m *GroupMutation) SetModelFallback(jm json.RawMessage) {
+	m.model_fallback = &jm
+	m.appendmodel_fallback = nil
+}
+
+// ModelFallback returns the value of the "model_fallback" field in the mutation.
+func (m *GroupMutation) ModelFallback() (r json.RawMessage, exists bool) {
+	v := m.model_fallback
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldModelFallback returns the old "model_fallback" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldModelFallback(ctx context.Context) (v json.RawMessage, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldModelFallback is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldModelFallback requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldModelFallback: %w", err)
+	}
+	return oldValue.ModelFallback, nil
+}
+
+// AppendModelFallback adds jm to the "model_fallback" field.
+func (m *GroupMutation) AppendModelFallback(jm json.RawMessage) {
+	m.appendmodel_fallback = append(m.appendmodel_fallback, jm...)
+}
+
+// AppendedModelFallback returns the list of values that were appended to the "model_fallback" field in this mutation.
+func (m *GroupMutation) AppendedModelFallback() (json.RawMessage, bool) {
+	if len(m.appendmodel_fallback) == 0 {
+		return nil, false
+	}
+	return m.appendmodel_fallback, true
+}
+
+// ClearModelFallback clears the value of the "model_fallback" field.
+func (m *GroupMutation) ClearModelFallback() {
+	m.model_fallback = nil
+	m.appendmodel_fallback = nil
+	m.clearedFields[group.FieldModelFallback] = struct{}{}
+}
+
+// ModelFallbackCleared returns if the "model_fallback" field was cleared in this mutation.
+func (m *GroupMutation) ModelFallbackCleared() bool {
+	_, ok := m.clearedFields[group.FieldModelFallback]
+	return ok
+}
+
+// ResetModelFallback resets all changes to the "model_fallback" field.
+func (m *GroupMutation) ResetModelFallback() {
+	m.model_fallback = nil
+	m.appendmodel_fallback = nil
+	delete(m.clearedFields, group.FieldModelFallback)
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by ids.
 func (m *GroupMutation) AddAPIKeyIDs(ids ...int64) {
 	if m.api_keys == nil {
@@ -5422,7 +5728,7 @@ func (m *GroupMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *GroupMutation) Fields() []string {
-	fields := make([]string, 0, 21)
+	fields := make([]string, 0, 25)
 	if m.created_at != nil {
 		fields = append(fields, group.FieldCreatedAt)
 	}
@@ -5486,6 +5792,18 @@ func (m *GroupMutation) Fields() []string {
 	if m.model_routing_enabled != nil {
 		fields = append(fields, group.FieldModelRoutingEnabled)
 	}
+	if m.overdraft_usd != nil {
+		fields = append(fields, group.FieldOverdraftUsd)
+	}
+	if m.count_tokens_mode != nil {
+		fields = append(fields, group.FieldCountTokensMode)
+	}
+	if m.hedge_enabled != nil {
+		fields = append(fields, group.FieldHedgeEnabled)
+	}
+	if m.model_fallback != nil {
+		fields = append(fields, group.FieldModelFallback)
+	}
 	return fields
 }
 
@@ -5536,6 +5854,14 @@ func (m *GroupMutation) Field(name string) (ent.Value, bool) {
 		return m.ModelRouting()
 	case group.FieldModelRoutingEnabled:
 		return m.ModelRoutingEnabled()
+	case group.FieldOverdraftUsd:
+		return m.OverdraftUsd()
+	case group.FieldCountTokensMode:
+		return m.CountTokensMode()
+	case group.FieldHedgeEnabled:
+		return m.HedgeEnabled()
+	case group.FieldModelFallback:
+		return m.ModelFallback()
 	}
 	return nil, false
 }
@@ -5587,6 +5913,14 @@ func (m *GroupMutation) OldField(ctx context.Context, name string) (ent.Value, e
 		return m.OldModelRouting(ctx)
 	case group.FieldModelRoutingEnabled:
 		return m.OldModelRoutingEnabled(ctx)
+	case group.FieldOverdraftUsd:
+		return m.OldOverdraftUsd(ctx)
+	case group.FieldCountTokensMode:
+		return m.OldCountTokensMode(ctx)
+	case group.FieldHedgeEnabled:
+		return m.OldHedgeEnabled(ctx)
+	case group.FieldModelFallback:
+		return m.OldModelFallback(ctx)
 	}
 	return nil, fmt.Errorf("unknown Group field %s", name)
 }
@@ -5743,6 +6077,34 @@ func (m *GroupMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetModelRoutingEnabled(v)
 		return nil
+	case group.FieldOverdraftUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetOverdraftUsd(v)
+		return nil
+	case group.FieldCountTokensMode:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetCountTokensMode(v)
+		return nil
+	case group.FieldHedgeEnabled:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetHedgeEnabled(v)
+		return nil
+	case group.FieldModelFallback:
+		v, ok := value.(json.RawMessage)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetModelFallback(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Group field %s", name)
 }
@@ -5778,6 +6140,9 @@ func (m *GroupMutation) AddedFields() []string {
 	if m.addfallback_group_id != nil {
 		fields = append(fields, group.FieldFallbackGroupID)
 	}
+	if m.addoverdraft_usd != nil {
+		fields = append(fields, group.FieldOverdraftUsd)
+	}
 	return fields
 }
 
@@ -5804,6 +6169,8 @@ func (m *GroupMutation) AddedField(name string) (ent.Value, bool) {
 		return m.AddedImagePrice4k()
 	case group.FieldFallbackGroupID:
 		return m.AddedFallbackGroupID()
+	case group.FieldOverdraftUsd:
+		return m.AddedOverdraftUsd()
 	}
 	return nil, false
 }
@@ -5876,6 +6243,13 @@ func (m *GroupMutation) AddField(name string, value ent.Value) error {
 		}
 		m.AddFallbackGroupID(v)
 		return nil
+	case group.FieldOverdraftUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddOverdraftUsd(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Group numeric field %s", name)
 }
@@ -5914,6 +6288,9 @@ func (m *GroupMutation) ClearedFields() []string {
 	if m.FieldCleared(group.FieldModelRouting) {
 		fields = append(fields, group.FieldModelRouting)
 	}
+	if m.FieldCleared(group.FieldModelFallback) {
+		fields = append(fields, group.FieldModelFallback)
+	}
 	return fields
 }
 
@@ -5958,6 +6335,9 @@ func (m *GroupMutation) ClearField(name string) error {
 	case group.FieldModelRouting:
 		m.ClearModelRouting()
 		return nil
+	case group.FieldModelFallback:
+		m.ClearModelFallback()
+		return nil
 	}
 	return fmt.Errorf("unknown Group nullable field %s", name)
 }
@@ -6029,6 +6409,18 @@ func (m *GroupMutation) ResetField(name string) error {
 	case group.FieldModelRoutingEnabled:
 		m.ResetModelRoutingEnabled()
 		return nil
+	case group.FieldOverdraftUsd:
+		m.ResetOverdraftUsd()
+		return nil
+	case group.FieldCountTokensMode:
+		m.ResetCountTokensMode()
+		return nil
+	case group.FieldHedgeEnabled:
+		m.ResetHedgeEnabled()
+		return nil
+	case group.FieldModelFallback:
+		m.ResetModelFallback()
+		return nil
 	}
 	return fmt.Errorf("unknown Group field %s", name)
 }
@@ -8869,27 +9261,29 @@ func (m *ProxyMutation) ResetEdge(name string) error {
 // RedeemCodeMutation represents an operation that mutates the RedeemCode nodes in the graph.
 type RedeemCodeMutation struct {
 	config
-	op               Op
-	typ              string
-	id               *int64
-	code             *string
-	_type            *string
-	value            *float64
-	addvalue         *float64
-	status           *string
-	used_at          *time.Time
-	notes            *string
-	created_at       *time.Time
-	validity_days    *int
-	addvalidity_days *int
-	clearedFields    map[string]struct{}
-	user             *int64
-	cleareduser      bool
-	group            *int64
-	clearedgroup     bool
-	done             bool
-	oldValue         func(context.Context) (*RedeemCode, error)
-	predicates       []predicate.RedeemCode
+	op                Op
+	typ               string
+	id                *int64
+	code              *string
+	_type             *string
+	value             *float64
+	addvalue          *float64
+	status            *string
+	used_at           *time.Time
+	notes             *string
+	created_at        *time.Time
+	validity_days     *int
+	addvalidity_days  *int
+	issuer_user_id    *int64
+	addissuer_user_id *int64
+	clearedFields     map[string]struct{}
+	user              *int64
+	cleareduser       bool
+	group             *int64
+	clearedgroup      bool
+	done              bool
+	oldValue          func(context.Context) (*RedeemCode, error)
+	predicates        []predicate.RedeemCode
 }
 
 var _ ent.Mutation = (*RedeemCodeMutation)(nil)
@@ -9442,6 +9836,76 @@ func (m *RedeemCodeMutation) ResetValidityDays() {
 	m.addvalidity_days = nil
 }
 
+// SetIssuerUserID sets the "issuer_user_id" field.
+func (m *RedeemCodeMutation) SetIssuerUserID(i int64) {
+	m.issuer_user_id = &i
+	m.addissuer_user_id = nil
+}
+
+// IssuerUserID returns the value of the "issuer_user_id" field in the mutation.
+func (m *RedeemCodeMutation) IssuerUserID() (r int64, exists bool) {
+	v := m.issuer_user_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldIssuerUserID returns the old "issuer_user_id" field's value of the RedeemCode entity.
+// If the RedeemCode object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *RedeemCodeMutation) OldIssuerUserID(ctx context.Context) (v *int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldIssuerUserID is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldIssuerUserID requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldIssuerUserID: %w", err)
+	}
+	return oldValue.IssuerUserID, nil
+}
+
+// AddIssuerUserID adds i to the "issuer_user_id" field.
+func (m *RedeemCodeMutation) AddIssuerUserID(i int64) {
+	if m.addissuer_user_id != nil {
+		*m.addissuer_user_id += i
+	} else {
+		m.addissuer_user_id = &i
+	}
+}
+
+// AddedIssuerUserID returns the value that was added to the "issuer_user_id" field in this mutation.
+func (m *RedeemCodeMutation) AddedIssuerUserID() (r int64, exists bool) {
+	v := m.addissuer_user_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearIssuerUserID clears the value of the "issuer_user_id" field.
+func (m *RedeemCodeMutation) ClearIssuerUserID() {
+	m.issuer_user_id = nil
+	m.addissuer_user_id = nil
+	m.clearedFields[redeemcode.FieldIssuerUserID] = struct{}{}
+}
+
+// IssuerUserIDCleared returns if the "issuer_user_id" field was cleared in this mutation.
+func (m *RedeemCodeMutation) IssuerUserIDCleared() bool {
+	_, ok := m.clearedFields[redeemcode.FieldIssuerUserID]
+	return ok
+}
+
+// ResetIssuerUserID resets all changes to the "issuer_user_id" field.
+func (m *RedeemCodeMutation) ResetIssuerUserID() {
+	m.issuer_user_id = nil
+	m.addissuer_user_id = nil
+	delete(m.clearedFields, redeemcode.FieldIssuerUserID)
+}
+
 // SetUserID sets the "user" edge to the User entity by id.
 func (m *RedeemCodeMutation) SetUserID(id int64) {
 	m.user = &id
@@ -9543,7 +10007,7 @@ func (m *RedeemCodeMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *RedeemCodeMutation) Fields() []string {
-	fields := make([]string, 0, 10)
+	fields := make([]string, 0, 11)
 	if m.code != nil {
 		fields = append(fields, redeemcode.FieldCode)
 	}
@@ -9574,6 +10038,9 @@ func (m *RedeemCodeMutation) Fields() []string {
 	if m.validity_days != nil {
 		fields = append(fields, redeemcode.FieldValidityDays)
 	}
+	if m.issuer_user_id != nil {
+		fields = append(fields, redeemcode.FieldIssuerUserID)
+	}
 	return fields
 }
 
@@ -9602,6 +10069,8 @@ func (m *RedeemCodeMutation) Field(name string) (ent.Value, bool) {
 		return m.GroupID()
 	case redeemcode.FieldValidityDays:
 		return m.ValidityDays()
+	case redeemcode.FieldIssuerUserID:
+		return m.IssuerUserID()
 	}
 	return nil, false
 }
@@ -9631,6 +10100,8 @@ func (m *RedeemCodeMutation) OldField(ctx context.Context, name string) (ent.Val
 		return m.OldGroupID(ctx)
 	case redeemcode.FieldValidityDays:
 		return m.OldValidityDays(ctx)
+	case redeemcode.FieldIssuerUserID:
+		return m.OldIssuerUserID(ctx)
 	}
 	return nil, fmt.Errorf("unknown RedeemCode field %s", name)
 }
@@ -9710,6 +10181,13 @@ func (m *RedeemCodeMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetValidityDays(v)
 		return nil
+	case redeemcode.FieldIssuerUserID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetIssuerUserID(v)
+		return nil
 	}
 	return fmt.Errorf("unknown RedeemCode field %s", name)
 }
@@ -9724,6 +10202,9 @@ func (m *RedeemCodeMutation) AddedFields() []string {
 	if m.addvalidity_days != nil {
 		fields = append(fields, redeemcode.FieldValidityDays)
 	}
+	if m.addissuer_user_id != nil {
+		fields = append(fields, redeemcode.FieldIssuerUserID)
+	}
 	return fields
 }
 
@@ -9736,6 +10217,8 @@ func (m *RedeemCodeMutation) AddedField(name string) (ent.Value, bool) {
 		return m.AddedValue()
 	case redeemcode.FieldValidityDays:
 		return m.AddedValidityDays()
+	case redeemcode.FieldIssuerUserID:
+		return m.AddedIssuerUserID()
 	}
 	return nil, false
 }
@@ -9759,6 +10242,13 @@ func (m *RedeemCodeMutation) AddField(name string, value ent.Value) error {
 		}
 		m.AddValidityDays(v)
 		return nil
+	case redeemcode.FieldIssuerUserID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddIssuerUserID(v)
+		return nil
 	}
 	return fmt.Errorf("unknown RedeemCode numeric field %s", name)
 }
@@ -9779,6 +10269,9 @@ func (m *RedeemCodeMutation) ClearedFields() []string {
 	if m.FieldCleared(redeemcode.FieldGroupID) {
 		fields = append(fields, redeemcode.FieldGroupID)
 	}
+	if m.FieldCleared(redeemcode.FieldIssuerUserID) {
+		fields = append(fields, redeemcode.FieldIssuerUserID)
+	}
 	return fields
 }
 
@@ -9805,6 +10298,9 @@ func (m *RedeemCodeMutation) ClearField(name string) error {
 	case redeemcode.FieldGroupID:
 		m.ClearGroupID()
 		return nil
+	case redeemcode.FieldIssuerUserID:
+		m.ClearIssuerUserID()
+		return nil
 	}
 	return fmt.Errorf("unknown RedeemCode nullable field %s", name)
 }
@@ -9843,6 +10339,9 @@ func (m *RedeemCodeMutation) ResetField(name string) error {
 	case redeemcode.FieldValidityDays:
 		m.ResetValidityDays()
 		return nil
+	case redeemcode.FieldIssuerUserID:
+		m.ResetIssuerUserID()
+		return nil
 	}
 	return fmt.Errorf("unknown RedeemCode field %s", name)
 }
@@ -11464,6 +11963,8 @@ type UsageLogMutation struct {
 	id                          *int64
 	request_id                  *string
 	model                       *string
+	organization_id             *int64
+	addorganization_id          *int64
 	input_tokens                *int
 	addinput_tokens             *int
 	output_tokens               *int
@@ -11484,6 +11985,8 @@ type UsageLogMutation struct {
 	addcache_creation_cost      *float64
 	cache_read_cost             *float64
 	addcache_read_cost          *float64
+	cache_creation_1h_cost      *float64
+	addcache_creation_1h_cost   *float64
 	total_cost                  *float64
 	addtotal_cost               *float64
 	actual_cost                 *float64
@@ -11492,6 +11995,7 @@ type UsageLogMutation struct {
 	addrate_multiplier          *float64
 	account_rate_multiplier     *float64
 	addaccount_rate_multiplier  *float64
+	rate_rule                   *string
 	billing_type                *int8
 	addbilling_type             *int8
 	stream                      *bool
@@ -11499,11 +12003,13 @@ type UsageLogMutation struct {
 	addduration_ms              *int
 	first_token_ms              *int
 	addfirst_token_ms           *int
+	hedged                      *bool
 	user_agent                  *string
 	ip_address                  *string
 	image_count                 *int
 	addimage_count              *int
 	image_size                  *string
+	tags                        *map[string]string
 	created_at                  *time.Time
 	clearedFields               map[string]struct{}
 	user                        *int64
@@ -11897,6 +12403,76 @@ func (m *UsageLogMutation) ResetSubscriptionID() {
 	delete(m.clearedFields, usagelog.FieldSubscriptionID)
 }
 
+// SetOrganizationID sets the "organization_id" field.
+func (m *UsageLogMutation) SetOrganizationID(i int64) {
+	m.organization_id = &i
+	m.addorganization_id = nil
+}
+
+// OrganizationID returns the value of the "organization_id" field in the mutation.
+func (m *UsageLogMutation) OrganizationID() (r int64, exists bool) {
+	v := m.organization_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldOrganizationID returns the old "organization_id" field's value of the UsageLog entity.
+// If the UsageLog object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UsageLogMutation) OldOrganizationID(ctx context.Context) (v *int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldOrganizationID is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldOrganizationID requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldOrganizationID: %w", err)
+	}
+	return oldValue.OrganizationID, nil
+}
+
+// AddOrganizationID adds i to the "organization_id" field.
+func (m *UsageLogMutation) AddOrganizationID(i int64) {
+	if m.addorganization_id != nil {
+		*m.addorganization_id += i
+	} else {
+		m.addorganization_id = &i
+	}
+}
+
+// AddedOrganizationID returns the value that was added to the "organization_id" field in this mutation.
+func (m *UsageLogMutation) AddedOrganizationID() (r int64, exists bool) {
+	v := m.addorganization_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearOrganizationID clears the value of the "organization_id" field.
+func (m *UsageLogMutation) ClearOrganizationID() {
+	m.organization_id = nil
+	m.addorganization_id = nil
+	m.clearedFields[usagelog.FieldOrganizationID] = struct{}{}
+}
+
+// OrganizationIDCleared returns if the "organization_id" field was cleared in this mutation.
+func (m *UsageLogMutation) OrganizationIDCleared() bool {
+	_, ok := m.clearedFields[usagelog.FieldOrganizationID]
+	return ok
+}
+
+// ResetOrganizationID resets all changes to the "organization_id" field.
+func (m *UsageLogMutation) ResetOrganizationID() {
+	m.organization_id = nil
+	m.addorganization_id = nil
+	delete(m.clearedFields, usagelog.FieldOrganizationID)
+}
+
 // SetInputTokens sets the "input_tokens" field.
 func (m *UsageLogMutation) SetInputTokens(i int) {
 	m.input_tokens = &i
@@ -12457,6 +13033,62 @@ func (m *UsageLogMutation) ResetCacheReadCost() {
 	m.addcache_read_cost = nil
 }
 
+// SetCacheCreation1hCost sets the "cache_creation_1h_cost" field.
+func (m *UsageLogMutation) SetCacheCreation1hCost(f float64) {
+	m.cache_creation_1h_cost = &f
+	m.addcache_creation_1h_cost = nil
+}
+
+// CacheCreation1hCost returns the value of the "cache_creation_1h_cost" field in the mutation.
+func (m *UsageLogMutation) CacheCreation1hCost() (r float64, exists bool) {
+	v := m.cache_creation_1h_cost
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldCacheCreation1hCost returns the old "cache_creation_1h_cost" field's value of the UsageLog entity.
+// If the UsageLog object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UsageLogMutation) OldCacheCreation1hCost(ctx context.Context) (v float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldCacheCreation1hCost is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldCacheCreation1hCost requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldCacheCreation1hCost: %w", err)
+	}
+	return oldValue.CacheCreation1hCost, nil
+}
+
+// AddCacheCreation1hCost adds f to the "cache_creation_1h_cost" field.
+func (m *UsageLogMutation) AddCacheCreation1hCost(f float64) {
+	if m.addcache_creation_1h_cost != nil {
+		*m.addcache_creation_1h_cost += f
+	} else {
+		m.addcache_creation_1h_cost = &f
+	}
+}
+
+// AddedCacheCreation1hCost returns the value that was added to the "cache_creation_1h_cost" field in this mutation.
+func (m *UsageLogMutation) AddedCacheCreation1hCost() (r float64, exists bool) {
+	v := m.addcache_creation_1h_cost
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ResetCacheCreation1hCost resets all changes to the "cache_creation_1h_cost" field.
+func (m *UsageLogMutation) ResetCacheCreation1hCost() {
+	m.cache_creation_1h_cost = nil
+	m.addcache_creation_1h_cost = nil
+}
+
 // SetTotalCost sets the "total_cost" field.
 func (m *UsageLogMutation) SetTotalCost(f float64) {
 	m.total_cost = &f
@@ -12695,6 +13327,55 @@ func (m *UsageLogMutation) ResetAccountRateMultiplier() {
 	delete(m.clearedFields, usagelog.FieldAccountRateMultiplier)
 }
 
+// SetRateRule sets the "rate_rule" field.
+func (m *UsageLogMutation) SetRateRule(s string) {
+	m.rate_rule = &s
+}
+
+// RateRule returns the value of the "rate_rule" field in the mutation.
+func (m *UsageLogMutation) RateRule() (r string, exists bool) {
+	v := m.rate_rule
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldRateRule returns the old "rate_rule" field's value of the UsageLog entity.
+// If the UsageLog object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UsageLogMutation) OldRateRule(ctx context.Context) (v *string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldRateRule is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldRateRule requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldRateRule: %w", err)
+	}
+	return oldValue.RateRule, nil
+}
+
+// ClearRateRule clears the value of the "rate_rule" field.
+func (m *UsageLogMutation) ClearRateRule() {
+	m.rate_rule = nil
+	m.clearedFields[usagelog.FieldRateRule] = struct{}{}
+}
+
+// RateRuleCleared returns if the "rate_rule" field was cleared in this mutation.
+func (m *UsageLogMutation) RateRuleCleared() bool {
+	_, ok := m.clearedFields[usagelog.FieldRateRule]
+	return ok
+}
+
+// ResetRateRule resets all changes to the "rate_rule" field.
+func (m *UsageLogMutation) ResetRateRule() {
+	m.rate_rule = nil
+	delete(m.clearedFields, usagelog.FieldRateRule)
+}
+
 // SetBillingType sets the "billing_type" field.
 func (m *UsageLogMutation) SetBillingType(i int8) {
 	m.billing_type = &i
@@ -12927,6 +13608,42 @@ func (m *UsageLogMutation) ResetFirstTokenMs() {
 	delete(m.clearedFields, usagelog.FieldFirstTokenMs)
 }
 
+// SetHedged sets the "hedged" field.
+func (m *UsageLogMutation) SetHedged(b bool) {
+	m.hedged = &b
+}
+
+// Hedged returns the value of the "hedged" field in the mutation.
+func (m *UsageLogMutation) Hedged() (r bool, exists bool) {
+	v := m.hedged
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldHedged returns the old "hedged" field's value of the UsageLog entity.
+// If the UsageLog object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UsageLogMutation) OldHedged(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldHedged is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldHedged requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldHedged: %w", err)
+	}
+	return oldValue.Hedged, nil
+}
+
+// ResetHedged resets all changes to the "hedged" field.
+func (m *UsageLogMutation) ResetHedged() {
+	m.hedged = nil
+}
+
 // SetUserAgent sets the "user_agent" field.
 func (m *UsageLogMutation) SetUserAgent(s string) {
 	m.user_agent = &s
@@ -13130,6 +13847,55 @@ func (m *UsageLogMutation) ResetImageSize() {
 	delete(m.clearedFields, usagelog.FieldImageSize)
 }
 
+// SetTags sets the "tags" field.
+func (m *UsageLogMutation) SetTags(value map[string]string) {
+	m.tags = &value
+}
+
+// Tags returns the value of the "tags" field in the mutation.
+func (m *UsageLogMutation) Tags() (r map[string]string, exists bool) {
+	v := m.tags
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldTags returns the old "tags" field's value of the UsageLog entity.
+// If the UsageLog object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UsageLogMutation) OldTags(ctx context.Context) (v map[string]string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldTags is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldTags requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldTags: %w", err)
+	}
+	return oldValue.Tags, nil
+}
+
+// ClearTags clears the value of the "tags" field.
+func (m *UsageLogMutation) ClearTags() {
+	m.tags = nil
+	m.clearedFields[usagelog.FieldTags] = struct{}{}
+}
+
+// TagsCleared returns if the "tags" field was cleared in this mutation.
+func (m *UsageLogMutation) TagsCleared() bool {
+	_, ok := m.clearedFields[usagelog.FieldTags]
+	return ok
+}
+
+// ResetTags resets all changes to the "tags" field.
+func (m *UsageLogMutation) ResetTags() {
+	m.tags = nil
+	delete(m.clearedFields, usagelog.FieldTags)
+}
+
 // SetCreatedAt sets the "created_at" field.
 func (m *UsageLogMutation) SetCreatedAt(t time.Time) {
 	m.created_at = &t
@@ -13335,7 +14101,7 @@ func (m *UsageLogMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *UsageLogMutation) Fields() []string {
-	fields := make([]string, 0, 30)
+	fields := make([]string, 0, 35)
 	if m.user != nil {
 		fields = append(fields, usagelog.FieldUserID)
 	}
@@ -13357,6 +14123,9 @@ func (m *UsageLogMutation) Fields() []string {
 	if m.subscription != nil {
 		fields = append(fields, usagelog.FieldSubscriptionID)
 	}
+	if m.organization_id != nil {
+		fields = append(fields, usagelog.FieldOrganizationID)
+	}
 	if m.input_tokens != nil {
 		fields = append(fields, usagelog.FieldInputTokens)
 	}
@@ -13387,6 +14156,9 @@ func (m *UsageLogMutation) Fields() []string {
 	if m.cache_read_cost != nil {
 		fields = append(fields, usagelog.FieldCacheReadCost)
 	}
+	if m.cache_creation_1h_cost != nil {
+		fields = append(fields, usagelog.FieldCacheCreation1hCost)
+	}
 	if m.total_cost != nil {
 		fields = append(fields, usagelog.FieldTotalCost)
 	}
@@ -13399,6 +14171,9 @@ func (m *UsageLogMutation) Fields() []string {
 	if m.account_rate_multiplier != nil {
 		fields = append(fields, usagelog.FieldAccountRateMultiplier)
 	}
+	if m.rate_rule != nil {
+		fields = append(fields, usagelog.FieldRateRule)
+	}
 	if m.billing_type != nil {
 		fields = append(fields, usagelog.FieldBillingType)
 	}
@@ -13411,6 +14186,9 @@ func (m *UsageLogMutation) Fields() []string {
 	if m.first_token_ms != nil {
 		fields = append(fields, usagelog.FieldFirstTokenMs)
 	}
+	if m.hedged != nil {
+		fields = append(fields, usagelog.FieldHedged)
+	}
 	if m.user_agent != nil {
 		fields = append(fields, usagelog.FieldUserAgent)
 	}
@@ -13423,6 +14201,9 @@ func (m *UsageLogMutation) Fields() []string {
 	if m.image_size != nil {
 		fields = append(fields, usagelog.FieldImageSize)
 	}
+	if m.tags != nil {
+		fields = append(fields, usagelog.FieldTags)
+	}
 	if m.created_at != nil {
 		fields = append(fields, usagelog.FieldCreatedAt)
 	}
@@ -13448,6 +14229,8 @@ func (m *UsageLogMutation) Field(name string) (ent.Value, bool) {
 		return m.GroupID()
 	case usagelog.FieldSubscriptionID:
 		return m.SubscriptionID()
+	case usagelog.FieldOrganizationID:
+		return m.OrganizationID()
 	case usagelog.FieldInputTokens:
 		return m.InputTokens()
 	case usagelog.FieldOutputTokens:
@@ -13468,6 +14251,8 @@ func (m *UsageLogMutation) Field(name string) (ent.Value, bool) {
 		return m.CacheCreationCost()
 	case usagelog.FieldCacheReadCost:
 		return m.CacheReadCost()
+	case usagelog.FieldCacheCreation1hCost:
+		return m.CacheCreation1hCost()
 	case usagelog.FieldTotalCost:
 		return m.TotalCost()
 	case usagelog.FieldActualCost:
@@ -13476,6 +14261,8 @@ func (m *UsageLogMutation) Field(name string) (ent.Value, bool) {
 		return m.RateMultiplier()
 	case usagelog.FieldAccountRateMultiplier:
 		return m.AccountRateMultiplier()
+	case usagelog.FieldRateRule:
+		return m.RateRule()
 	case usagelog.FieldBillingType:
 		return m.BillingType()
 	case usagelog.FieldStream:
@@ -13484,6 +14271,8 @@ func (m *UsageLogMutation) Field(name string) (ent.Value, bool) {
 		return m.DurationMs()
 	case usagelog.FieldFirstTokenMs:
 		return m.FirstTokenMs()
+	case usagelog.FieldHedged:
+		return m.Hedged()
 	case usagelog.FieldUserAgent:
 		return m.UserAgent()
 	case usagelog.FieldIPAddress:
@@ -13492,6 +14281,8 @@ func (m *UsageLogMutation) Field(name string) (ent.Value, bool) {
 		return m.ImageCount()
 	case usagelog.FieldImageSize:
 		return m.ImageSize()
+	case usagelog.FieldTags:
+		return m.Tags()
 	case usagelog.FieldCreatedAt:
 		return m.CreatedAt()
 	}
@@ -13517,6 +14308,8 @@ func (m *UsageLogMutation) OldField(ctx context.Context, name string) (ent.Value
 		return m.OldGroupID(ctx)
 	case usagelog.FieldSubscriptionID:
 		return m.OldSubscriptionID(ctx)
+	case usagelog.FieldOrganizationID:
+		return m.OldOrganizationID(ctx)
 	case usagelog.FieldInputTokens:
 		return m.OldInputTokens(ctx)
 	case usagelog.FieldOutputTokens:
@@ -13537,6 +14330,8 @@ func (m *UsageLogMutation) OldField(ctx context.Context, name string) (ent.Value
 		return m.OldCacheCreationCost(ctx)
 	case usagelog.FieldCacheReadCost:
 		return m.OldCacheReadCost(ctx)
+	case usagelog.FieldCacheCreation1hCost:
+		return m.OldCacheCreation1hCost(ctx)
 	case usagelog.FieldTotalCost:
 		return m.OldTotalCost(ctx)
 	case usagelog.FieldActualCost:
@@ -13545,6 +14340,8 @@ func (m *UsageLogMutation) OldField(ctx context.Context, name string) (ent.Value
 		return m.OldRateMultiplier(ctx)
 	case usagelog.FieldAccountRateMultiplier:
 		return m.OldAccountRateMultiplier(ctx)
+	case usagelog.FieldRateRule:
+		return m.OldRateRule(ctx)
 	case usagelog.FieldBillingType:
 		return m.OldBillingType(ctx)
 	case usagelog.FieldStream:
@@ -13553,6 +14350,8 @@ func (m *UsageLogMutation) OldField(ctx context.Context, name string) (ent.Value
 		return m.OldDurationMs(ctx)
 	case usagelog.FieldFirstTokenMs:
 		return m.OldFirstTokenMs(ctx)
+	case usagelog.FieldHedged:
+		return m.OldHedged(ctx)
 	case usagelog.FieldUserAgent:
 		return m.OldUserAgent(ctx)
 	case usagelog.FieldIPAddress:
@@ -13561,6 +14360,8 @@ func (m *UsageLogMutation) OldField(ctx context.Context, name string) (ent.Value
 		return m.OldImageCount(ctx)
 	case usagelog.FieldImageSize:
 		return m.OldImageSize(ctx)
+	case usagelog.FieldTags:
+		return m.OldTags(ctx)
 	case usagelog.FieldCreatedAt:
 		return m.OldCreatedAt(ctx)
 	}
@@ -13621,6 +14422,13 @@ func (m *UsageLogMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetSubscriptionID(v)
 		return nil
+	case usagelog.FieldOrganizationID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetOrganizationID(v)
+		return nil
 	case usagelog.FieldInputTokens:
 		v, ok := value.(int)
 		if !ok {
@@ -13691,6 +14499,13 @@ func (m *UsageLogMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetCacheReadCost(v)
 		return nil
+	case usagelog.FieldCacheCreation1hCost:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetCacheCreation1hCost(v)
+		return nil
 	case usagelog.FieldTotalCost:
 		v, ok := value.(float64)
 		if !ok {
@@ -13719,6 +14534,13 @@ func (m *UsageLogMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetAccountRateMultiplier(v)
 		return nil
+	case usagelog.FieldRateRule:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetRateRule(v)
+		return nil
 	case usagelog.FieldBillingType:
 		v, ok := value.(int8)
 		if !ok {
@@ -13747,6 +14569,13 @@ func (m *UsageLogMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetFirstTokenMs(v)
 		return nil
+	case usagelog.FieldHedged:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetHedged(v)
+		return nil
 	case usagelog.FieldUserAgent:
 		v, ok := value.(string)
 		if !ok {
@@ -13775,6 +14604,13 @@ func (m *UsageLogMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetImageSize(v)
 		return nil
+	case usagelog.FieldTags:
+		v, ok := value.(map[string]string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetTags(v)
+		return nil
 	case usagelog.FieldCreatedAt:
 		v, ok := value.(time.Time)
 		if !ok {
@@ -13790,6 +14626,9 @@ func (m *UsageLogMutation) SetField(name string, value ent.Value) error {
 // this mutation.
 func (m *UsageLogMutation) AddedFields() []string {
 	var fields []string
+	if m.addorganization_id != nil {
+		fields = append(fields, usagelog.FieldOrganizationID)
+	}
 	if m.addinput_tokens != nil {
 		fields = append(fields, usagelog.FieldInputTokens)
 	}
@@ -13820,6 +14659,9 @@ func (m *UsageLogMutation) AddedFields() []string {
 	if m.addcache_read_cost != nil {
 		fields = append(fields, usagelog.FieldCacheReadCost)
 	}
+	if m.addcache_creation_1h_cost != nil {
+		fields = append(fields, usagelog.FieldCacheCreation1hCost)
+	}
 	if m.addtotal_cost != nil {
 		fields = append(fields, usagelog.FieldTotalCost)
 	}
@@ -13852,6 +14694,8 @@ func (m *UsageLogMutation) AddedFields() []string {
 // was not set, or was not defined in the schema.
 func (m *UsageLogMutation) AddedField(name string) (ent.Value, bool) {
 	switch name {
+	case usagelog.FieldOrganizationID:
+		return m.AddedOrganizationID()
 	case usagelog.FieldInputTokens:
 		return m.AddedInputTokens()
 	case usagelog.FieldOutputTokens:
@@ -13872,6 +14716,8 @@ func (m *UsageLogMutation) AddedField(name string) (ent.Value, bool) {
 		return m.AddedCacheCreationCost()
 	case usagelog.FieldCacheReadCost:
 		return m.AddedCacheReadCost()
+	case usagelog.FieldCacheCreation1hCost:
+		return m.AddedCacheCreation1hCost()
 	case usagelog.FieldTotalCost:
 		return m.AddedTotalCost()
 	case usagelog.FieldActualCost:
@@ -13897,6 +14743,13 @@ func (m *UsageLogMutation) AddedField(name string) (ent.Value, bool) {
 // type.
 func (m *UsageLogMutation) AddField(name string, value ent.Value) error {
 	switch name {
+	case usagelog.FieldOrganizationID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddOrganizationID(v)
+		return nil
 	case usagelog.FieldInputTokens:
 		v, ok := value.(int)
 		if !ok {
@@ -13967,6 +14820,13 @@ func (m *UsageLogMutation) AddField(name string, value ent.Value) error {
 		}
 		m.AddCacheReadCost(v)
 		return nil
+	case usagelog.FieldCacheCreation1hCost:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddCacheCreation1hCost(v)
+		return nil
 	case usagelog.FieldTotalCost:
 		v, ok := value.(float64)
 		if !ok {
@@ -14037,9 +14897,15 @@ func (m *UsageLogMutation) ClearedFields() []string {
 	if m.FieldCleared(usagelog.FieldSubscriptionID) {
 		fields = append(fields, usagelog.FieldSubscriptionID)
 	}
+	if m.FieldCleared(usagelog.FieldOrganizationID) {
+		fields = append(fields, usagelog.FieldOrganizationID)
+	}
 	if m.FieldCleared(usagelog.FieldAccountRateMultiplier) {
 		fields = append(fields, usagelog.FieldAccountRateMultiplier)
 	}
+	if m.FieldCleared(usagelog.FieldRateRule) {
+		fields = append(fields, usagelog.FieldRateRule)
+	}
 	if m.FieldCleared(usagelog.FieldDurationMs) {
 		fields = append(fields, usagelog.FieldDurationMs)
 	}
@@ -14055,6 +14921,9 @@ func (m *UsageLogMutation) ClearedFields() []string {
 	if m.FieldCleared(usagelog.FieldImageSize) {
 		fields = append(fields, usagelog.FieldImageSize)
 	}
+	if m.FieldCleared(usagelog.FieldTags) {
+		fields = append(fields, usagelog.FieldTags)
+	}
 	return fields
 }
 
@@ -14075,9 +14944,15 @@ func (m *UsageLogMutation) ClearField(name string) error {
 	case usagelog.FieldSubscriptionID:
 		m.ClearSubscriptionID()
 		return nil
+	case usagelog.FieldOrganizationID:
+		m.ClearOrganizationID()
+		return nil
 	case usagelog.FieldAccountRateMultiplier:
 		m.ClearAccountRateMultiplier()
 		return nil
+	case usagelog.FieldRateRule:
+		m.ClearRateRule()
+		return nil
 	case usagelog.FieldDurationMs:
 		m.ClearDurationMs()
 		return nil
@@ -14093,6 +14968,9 @@ func (m *UsageLogMutation) ClearField(name string) error {
 	case usagelog.FieldImageSize:
 		m.ClearImageSize()
 		return nil
+	case usagelog.FieldTags:
+		m.ClearTags()
+		return nil
 	}
 	return fmt.Errorf("unknown UsageLog nullable field %s", name)
 }
@@ -14122,6 +15000,9 @@ func (m *UsageLogMutation) ResetField(name string) error {
 	case usagelog.FieldSubscriptionID:
 		m.ResetSubscriptionID()
 		return nil
+	case usagelog.FieldOrganizationID:
+		m.ResetOrganizationID()
+		return nil
 	case usagelog.FieldInputTokens:
 		m.ResetInputTokens()
 		return nil
@@ -14152,6 +15033,9 @@ func (m *UsageLogMutation) ResetField(name string) error {
 	case usagelog.FieldCacheReadCost:
 		m.ResetCacheReadCost()
 		return nil
+	case usagelog.FieldCacheCreation1hCost:
+		m.ResetCacheCreation1hCost()
+		return nil
 	case usagelog.FieldTotalCost:
 		m.ResetTotalCost()
 		return nil
@@ -14164,6 +15048,9 @@ func (m *UsageLogMutation) ResetField(name string) error {
 	case usagelog.FieldAccountRateMultiplier:
 		m.ResetAccountRateMultiplier()
 		return nil
+	case usagelog.FieldRateRule:
+		m.ResetRateRule()
+		return nil
 	case usagelog.FieldBillingType:
 		m.ResetBillingType()
 		return nil
@@ -14176,6 +15063,9 @@ func (m *UsageLogMutation) ResetField(name string) error {
 	case usagelog.FieldFirstTokenMs:
 		m.ResetFirstTokenMs()
 		return nil
+	case usagelog.FieldHedged:
+		m.ResetHedged()
+		return nil
 	case usagelog.FieldUserAgent:
 		m.ResetUserAgent()
 		return nil
@@ -14188,6 +15078,9 @@ func (m *UsageLogMutation) ResetField(name string) error {
 	case usagelog.FieldImageSize:
 		m.ResetImageSize()
 		return nil
+	case usagelog.FieldTags:
+		m.ResetTags()
+		return nil
 	case usagelog.FieldCreatedAt:
 		m.ResetCreatedAt()
 		return nil
@@ -14360,6 +15253,8 @@ type UserMutation struct {
 	status                        *string
 	username                      *string
 	notes                         *string
+	parent_user_id                *int64
+	addparent_user_id             *int64
 	clearedFields                 map[string]struct{}
 	api_keys                      map[int64]struct{}
 	removedapi_keys               map[int64]struct{}
@@ -14937,6 +15832,76 @@ func (m *UserMutation) ResetNotes() {
 	m.notes = nil
 }
 
+// SetParentUserID sets the "parent_user_id" field.
+func (m *UserMutation) SetParentUserID(i int64) {
+	m.parent_user_id = &i
+	m.addparent_user_id = nil
+}
+
+// ParentUserID returns the value of the "parent_user_id" field in the mutation.
+func (m *UserMutation) ParentUserID() (r int64, exists bool) {
+	v := m.parent_user_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldParentUserID returns the old "parent_user_id" field's value of the User entity.
+// If the User object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserMutation) OldParentUserID(ctx context.Context) (v *int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldParentUserID is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldParentUserID requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldParentUserID: %w", err)
+	}
+	return oldValue.ParentUserID, nil
+}
+
+// AddParentUserID adds i to the "parent_user_id" field.
+func (m *UserMutation) AddParentUserID(i int64) {
+	if m.addparent_user_id != nil {
+		*m.addparent_user_id += i
+	} else {
+		m.addparent_user_id = &i
+	}
+}
+
+// AddedParentUserID returns the value that was added to the "parent_user_id" field in this mutation.
+func (m *UserMutation) AddedParentUserID() (r int64, exists bool) {
+	v := m.addparent_user_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearParentUserID clears the value of the "parent_user_id" field.
+func (m *UserMutation) ClearParentUserID() {
+	m.parent_user_id = nil
+	m.addparent_user_id = nil
+	m.clearedFields[user.FieldParentUserID] = struct{}{}
+}
+
+// ParentUserIDCleared returns if the "parent_user_id" field was cleared in this mutation.
+func (m *UserMutation) ParentUserIDCleared() bool {
+	_, ok := m.clearedFields[user.FieldParentUserID]
+	return ok
+}
+
+// ResetParentUserID resets all changes to the "parent_user_id" field.
+func (m *UserMutation) ResetParentUserID() {
+	m.parent_user_id = nil
+	m.addparent_user_id = nil
+	delete(m.clearedFields, user.FieldParentUserID)
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by ids.
 func (m *UserMutation) AddAPIKeyIDs(ids ...int64) {
 	if m.api_keys == nil {
@@ -15403,7 +16368,7 @@ func (m *UserMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *UserMutation) Fields() []string {
-	fields := make([]string, 0, 11)
+	fields := make([]string, 0, 12)
 	if m.created_at != nil {
 		fields = append(fields, user.FieldCreatedAt)
 	}
@@ -15437,6 +16402,9 @@ func (m *UserMutation) Fields() []string {
 	if m.notes != nil {
 		fields = append(fields, user.FieldNotes)
 	}
+	if m.parent_user_id != nil {
+		fields = append(fields, user.FieldParentUserID)
+	}
 	return fields
 }
 
@@ -15467,6 +16435,8 @@ func (m *UserMutation) Field(name string) (ent.Value, bool) {
 		return m.Username()
 	case user.FieldNotes:
 		return m.Notes()
+	case user.FieldParentUserID:
+		return m.ParentUserID()
 	}
 	return nil, false
 }
@@ -15498,6 +16468,8 @@ func (m *UserMutation) OldField(ctx context.Context, name string) (ent.Value, er
 		return m.OldUsername(ctx)
 	case user.FieldNotes:
 		return m.OldNotes(ctx)
+	case user.FieldParentUserID:
+		return m.OldParentUserID(ctx)
 	}
 	return nil, fmt.Errorf("unknown User field %s", name)
 }
@@ -15584,6 +16556,13 @@ func (m *UserMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetNotes(v)
 		return nil
+	case user.FieldParentUserID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetParentUserID(v)
+		return nil
 	}
 	return fmt.Errorf("unknown User field %s", name)
 }
@@ -15598,6 +16577,9 @@ func (m *UserMutation) AddedFields() []string {
 	if m.addconcurrency != nil {
 		fields = append(fields, user.FieldConcurrency)
 	}
+	if m.addparent_user_id != nil {
+		fields = append(fields, user.FieldParentUserID)
+	}
 	return fields
 }
 
@@ -15610,6 +16592,8 @@ func (m *UserMutation) AddedField(name string) (ent.Value, bool) {
 		return m.AddedBalance()
 	case user.FieldConcurrency:
 		return m.AddedConcurrency()
+	case user.FieldParentUserID:
+		return m.AddedParentUserID()
 	}
 	return nil, false
 }
@@ -15633,6 +16617,13 @@ func (m *UserMutation) AddField(name string, value ent.Value) error {
 		}
 		m.AddConcurrency(v)
 		return nil
+	case user.FieldParentUserID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddParentUserID(v)
+		return nil
 	}
 	return fmt.Errorf("unknown User numeric field %s", name)
 }
@@ -15644,6 +16635,9 @@ func (m *UserMutation) ClearedFields() []string {
 	if m.FieldCleared(user.FieldDeletedAt) {
 		fields = append(fields, user.FieldDeletedAt)
 	}
+	if m.FieldCleared(user.FieldParentUserID) {
+		fields = append(fields, user.FieldParentUserID)
+	}
 	return fields
 }
 
@@ -15661,6 +16655,9 @@ func (m *UserMutation) ClearField(name string) error {
 	case user.FieldDeletedAt:
 		m.ClearDeletedAt()
 		return nil
+	case user.FieldParentUserID:
+		m.ClearParentUserID()
+		return nil
 	}
 	return fmt.Errorf("unknown User nullable field %s", name)
 }
@@ -15702,6 +16699,9 @@ func (m *UserMutation) ResetField(name string) error {
 	case user.FieldNotes:
 		m.ResetNotes()
 		return nil
+	case user.FieldParentUserID:
+		m.ResetParentUserID()
+		return nil
 	}
 	return fmt.Errorf("unknown User field %s", name)
 }
@@ -18211,6 +19211,18 @@ type UserSubscriptionMutation struct {
 	addmonthly_usage_usd    *float64
 	assigned_at             *time.Time
 	notes                   *string
+	plan_id                 *int64
+	addplan_id              *int64
+	next_plan_id            *int64
+	addnext_plan_id         *int64
+	auto_renew              *bool
+	renewal_failed_at       *time.Time
+	daily_limit_usd         *float64
+	adddaily_limit_usd      *float64
+	weekly_limit_usd        *float64
+	addweekly_limit_usd     *float64
+	monthly_limit_usd       *float64
+	addmonthly_limit_usd    *float64
 	clearedFields           map[string]struct{}
 	user                    *int64
 	cleareduser             bool
@@ -19074,10 +20086,445 @@ func (m *UserSubscriptionMutation) ResetNotes() {
 	delete(m.clearedFields, usersubscription.FieldNotes)
 }
 
-// ClearUser clears the "user" edge to the User entity.
-func (m *UserSubscriptionMutation) ClearUser() {
-	m.cleareduser = true
-	m.clearedFields[usersubscription.FieldUserID] = struct{}{}
+// SetPlanID sets the "plan_id" field.
+func (m *UserSubscriptionMutation) SetPlanID(i int64) {
+	m.plan_id = &i
+	m.addplan_id = nil
+}
+
+// PlanID returns the value of the "plan_id" field in the mutation.
+func (m *UserSubscriptionMutation) PlanID() (r int64, exists bool) {
+	v := m.plan_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldPlanID returns the old "plan_id" field's value of the UserSubscription entity.
+// If the UserSubscription object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserSubscriptionMutation) OldPlanID(ctx context.Context) (v *int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldPlanID is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldPlanID requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldPlanID: %w", err)
+	}
+	return oldValue.PlanID, nil
+}
+
+// AddPlanID adds i to the "plan_id" field.
+func (m *UserSubscriptionMutation) AddPlanID(i int64) {
+	if m.addplan_id != nil {
+		*m.addplan_id += i
+	} else {
+		m.addplan_id = &i
+	}
+}
+
+// AddedPlanID returns the value that was added to the "plan_id" field in this mutation.
+func (m *UserSubscriptionMutation) AddedPlanID() (r int64, exists bool) {
+	v := m.addplan_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearPlanID clears the value of the "plan_id" field.
+func (m *UserSubscriptionMutation) ClearPlanID() {
+	m.plan_id = nil
+	m.addplan_id = nil
+	m.clearedFields[usersubscription.FieldPlanID] = struct{}{}
+}
+
+// PlanIDCleared returns if the "plan_id" field was cleared in this mutation.
+func (m *UserSubscriptionMutation) PlanIDCleared() bool {
+	_, ok := m.clearedFields[usersubscription.FieldPlanID]
+	return ok
+}
+
+// ResetPlanID resets all changes to the "plan_id" field.
+func (m *UserSubscriptionMutation) ResetPlanID() {
+	m.plan_id = nil
+	m.addplan_id = nil
+	delete(m.clearedFields, usersubscription.FieldPlanID)
+}
+
+// SetNextPlanID sets the "next_plan_id" field.
+func (m *UserSubscriptionMutation) SetNextPlanID(i int64) {
+	m.next_plan_id = &i
+	m.addnext_plan_id = nil
+}
+
+// NextPlanID returns the value of the "next_plan_id" field in the mutation.
+func (m *UserSubscriptionMutation) NextPlanID() (r int64, exists bool) {
+	v := m.next_plan_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldNextPlanID returns the old "next_plan_id" field's value of the UserSubscription entity.
+// If the UserSubscription object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserSubscriptionMutation) OldNextPlanID(ctx context.Context) (v *int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldNextPlanID is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldNextPlanID requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldNextPlanID: %w", err)
+	}
+	return oldValue.NextPlanID, nil
+}
+
+// AddNextPlanID adds i to the "next_plan_id" field.
+func (m *UserSubscriptionMutation) AddNextPlanID(i int64) {
+	if m.addnext_plan_id != nil {
+		*m.addnext_plan_id += i
+	} else {
+		m.addnext_plan_id = &i
+	}
+}
+
+// AddedNextPlanID returns the value that was added to the "next_plan_id" field in this mutation.
+func (m *UserSubscriptionMutation) AddedNextPlanID() (r int64, exists bool) {
+	v := m.addnext_plan_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearNextPlanID clears the value of the "next_plan_id" field.
+func (m *UserSubscriptionMutation) ClearNextPlanID() {
+	m.next_plan_id = nil
+	m.addnext_plan_id = nil
+	m.clearedFields[usersubscription.FieldNextPlanID] = struct{}{}
+}
+
+// NextPlanIDCleared returns if the "next_plan_id" field was cleared in this mutation.
+func (m *UserSubscriptionMutation) NextPlanIDCleared() bool {
+	_, ok := m.clearedFields[usersubscription.FieldNextPlanID]
+	return ok
+}
+
+// ResetNextPlanID resets all changes to the "next_plan_id" field.
+func (m *UserSubscriptionMutation) ResetNextPlanID() {
+	m.next_plan_id = nil
+	m.addnext_plan_id = nil
+	delete(m.clearedFields, usersubscription.FieldNextPlanID)
+}
+
+// SetAutoRenew sets the "auto_renew" field.
+func (m *UserSubscriptionMutation) SetAutoRenew(b bool) {
+	m.auto_renew = &b
+}
+
+// AutoRenew returns the value of the "auto_renew" field in the mutation.
+func (m *UserSubscriptionMutation) AutoRenew() (r bool, exists bool) {
+	v := m.auto_renew
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAutoRenew returns the old "auto_renew" field's value of the UserSubscription entity.
+// If the UserSubscription object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserSubscriptionMutation) OldAutoRenew(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldAutoRenew is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldAutoRenew requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAutoRenew: %w", err)
+	}
+	return oldValue.AutoRenew, nil
+}
+
+// ResetAutoRenew resets all changes to the "auto_renew" field.
+func (m *UserSubscriptionMutation) ResetAutoRenew() {
+	m.auto_renew = nil
+}
+
+// SetRenewalFailedAt sets the "renewal_failed_at" field.
+func (m *UserSubscriptionMutation) SetRenewalFailedAt(t time.Time) {
+	m.renewal_failed_at = &t
+}
+
+// RenewalFailedAt returns the value of the "renewal_failed_at" field in the mutation.
+func (m *UserSubscriptionMutation) RenewalFailedAt() (r time.Time, exists bool) {
+	v := m.renewal_failed_at
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldRenewalFailedAt returns the old "renewal_failed_at" field's value of the UserSubscription entity.
+// If the UserSubscription object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserSubscriptionMutation) OldRenewalFailedAt(ctx context.Context) (v *time.Time, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldRenewalFailedAt is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldRenewalFailedAt requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldRenewalFailedAt: %w", err)
+	}
+	return oldValue.RenewalFailedAt, nil
+}
+
+// ClearRenewalFailedAt clears the value of the "renewal_failed_at" field.
+func (m *UserSubscriptionMutation) ClearRenewalFailedAt() {
+	m.renewal_failed_at = nil
+	m.clearedFields[usersubscription.FieldRenewalFailedAt] = struct{}{}
+}
+
+// RenewalFailedAtCleared returns if the "renewal_failed_at" field was cleared in this mutation.
+func (m *UserSubscriptionMutation) RenewalFailedAtCleared() bool {
+	_, ok := m.clearedFields[usersubscription.FieldRenewalFailedAt]
+	return ok
+}
+
+// ResetRenewalFailedAt resets all changes to the "renewal_failed_at" field.
+func (m *UserSubscriptionMutation) ResetRenewalFailedAt() {
+	m.renewal_failed_at = nil
+	delete(m.clearedFields, usersubscription.FieldRenewalFailedAt)
+}
+
+// SetDailyLimitUsd sets the "daily_limit_usd" field.
+func (m *UserSubscriptionMutation) SetDailyLimitUsd(f float64) {
+	m.daily_limit_usd = &f
+	m.adddaily_limit_usd = nil
+}
+
+// DailyLimitUsd returns the value of the "daily_limit_usd" field in the mutation.
+func (m *UserSubscriptionMutation) DailyLimitUsd() (r float64, exists bool) {
+	v := m.daily_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldDailyLimitUsd returns the old "daily_limit_usd" field's value of the UserSubscription entity.
+// If the UserSubscription object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserSubscriptionMutation) OldDailyLimitUsd(ctx context.Context) (v *float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldDailyLimitUsd is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldDailyLimitUsd requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldDailyLimitUsd: %w", err)
+	}
+	return oldValue.DailyLimitUsd, nil
+}
+
+// AddDailyLimitUsd adds f to the "daily_limit_usd" field.
+func (m *UserSubscriptionMutation) AddDailyLimitUsd(f float64) {
+	if m.adddaily_limit_usd != nil {
+		*m.adddaily_limit_usd += f
+	} else {
+		m.adddaily_limit_usd = &f
+	}
+}
+
+// AddedDailyLimitUsd returns the value that was added to the "daily_limit_usd" field in this mutation.
+func (m *UserSubscriptionMutation) AddedDailyLimitUsd() (r float64, exists bool) {
+	v := m.adddaily_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearDailyLimitUsd clears the value of the "daily_limit_usd" field.
+func (m *UserSubscriptionMutation) ClearDailyLimitUsd() {
+	m.daily_limit_usd = nil
+	m.adddaily_limit_usd = nil
+	m.clearedFields[usersubscription.FieldDailyLimitUsd] = struct{}{}
+}
+
+// DailyLimitUsdCleared returns if the "daily_limit_usd" field was cleared in this mutation.
+func (m *UserSubscriptionMutation) DailyLimitUsdCleared() bool {
+	_, ok := m.clearedFields[usersubscription.FieldDailyLimitUsd]
+	return ok
+}
+
+// ResetDailyLimitUsd resets all changes to the "daily_limit_usd" field.
+func (m *UserSubscriptionMutation) ResetDailyLimitUsd() {
+	m.daily_limit_usd = nil
+	m.adddaily_limit_usd = nil
+	delete(m.clearedFields, usersubscription.FieldDailyLimitUsd)
+}
+
+// SetWeeklyLimitUsd sets the "weekly_limit_usd" field.
+func (m *UserSubscriptionMutation) SetWeeklyLimitUsd(f float64) {
+	m.weekly_limit_usd = &f
+	m.addweekly_limit_usd = nil
+}
+
+// WeeklyLimitUsd returns the value of the "weekly_limit_usd" field in the mutation.
+func (m *UserSubscriptionMutation) WeeklyLimitUsd() (r float64, exists bool) {
+	v := m.weekly_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldWeeklyLimitUsd returns the old "weekly_limit_usd" field's value of the UserSubscription entity.
+// If the UserSubscription object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserSubscriptionMutation) OldWeeklyLimitUsd(ctx context.Context) (v *float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldWeeklyLimitUsd is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldWeeklyLimitUsd requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldWeeklyLimitUsd: %w", err)
+	}
+	return oldValue.WeeklyLimitUsd, nil
+}
+
+// AddWeeklyLimitUsd adds f to the "weekly_limit_usd" field.
+func (m *UserSubscriptionMutation) AddWeeklyLimitUsd(f float64) {
+	if m.addweekly_limit_usd != nil {
+		*m.addweekly_limit_usd += f
+	} else {
+		m.addweekly_limit_usd = &f
+	}
+}
+
+// AddedWeeklyLimitUsd returns the value that was added to the "weekly_limit_usd" field in this mutation.
+func (m *UserSubscriptionMutation) AddedWeeklyLimitUsd() (r float64, exists bool) {
+	v := m.addweekly_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearWeeklyLimitUsd clears the value of the "weekly_limit_usd" field.
+func (m *UserSubscriptionMutation) ClearWeeklyLimitUsd() {
+	m.weekly_limit_usd = nil
+	m.addweekly_limit_usd = nil
+	m.clearedFields[usersubscription.FieldWeeklyLimitUsd] = struct{}{}
+}
+
+// WeeklyLimitUsdCleared returns if the "weekly_limit_usd" field was cleared in this mutation.
+func (m *UserSubscriptionMutation) WeeklyLimitUsdCleared() bool {
+	_, ok := m.clearedFields[usersubscription.FieldWeeklyLimitUsd]
+	return ok
+}
+
+// ResetWeeklyLimitUsd resets all changes to the "weekly_limit_usd" field.
+func (m *UserSubscriptionMutation) ResetWeeklyLimitUsd() {
+	m.weekly_limit_usd = nil
+	m.addweekly_limit_usd = nil
+	delete(m.clearedFields, usersubscription.FieldWeeklyLimitUsd)
+}
+
+// SetMonthlyLimitUsd sets the "monthly_limit_usd" field.
+func (m *UserSubscriptionMutation) SetMonthlyLimitUsd(f float64) {
+	m.monthly_limit_usd = &f
+	m.addmonthly_limit_usd = nil
+}
+
+// MonthlyLimitUsd returns the value of the "monthly_limit_usd" field in the mutation.
+func (m *UserSubscriptionMutation) MonthlyLimitUsd() (r float64, exists bool) {
+	v := m.monthly_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldMonthlyLimitUsd returns the old "monthly_limit_usd" field's value of the UserSubscription entity.
+// If the UserSubscription object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserSubscriptionMutation) OldMonthlyLimitUsd(ctx context.Context) (v *float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldMonthlyLimitUsd is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldMonthlyLimitUsd requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldMonthlyLimitUsd: %w", err)
+	}
+	return oldValue.MonthlyLimitUsd, nil
+}
+
+// AddMonthlyLimitUsd adds f to the "monthly_limit_usd" field.
+func (m *UserSubscriptionMutation) AddMonthlyLimitUsd(f float64) {
+	if m.addmonthly_limit_usd != nil {
+		*m.addmonthly_limit_usd += f
+	} else {
+		m.addmonthly_limit_usd = &f
+	}
+}
+
+// AddedMonthlyLimitUsd returns the value that was added to the "monthly_limit_usd" field in this mutation.
+func (m *UserSubscriptionMutation) AddedMonthlyLimitUsd() (r float64, exists bool) {
+	v := m.addmonthly_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearMonthlyLimitUsd clears the value of the "monthly_limit_usd" field.
+func (m *UserSubscriptionMutation) ClearMonthlyLimitUsd() {
+	m.monthly_limit_usd = nil
+	m.addmonthly_limit_usd = nil
+	m.clearedFields[usersubscription.FieldMonthlyLimitUsd] = struct{}{}
+}
+
+// MonthlyLimitUsdCleared returns if the "monthly_limit_usd" field was cleared in this mutation.
+func (m *UserSubscriptionMutation) MonthlyLimitUsdCleared() bool {
+	_, ok := m.clearedFields[usersubscription.FieldMonthlyLimitUsd]
+	return ok
+}
+
+// ResetMonthlyLimitUsd resets all changes to the "monthly_limit_usd" field.
+func (m *UserSubscriptionMutation) ResetMonthlyLimitUsd() {
+	m.monthly_limit_usd = nil
+	m.addmonthly_limit_usd = nil
+	delete(m.clearedFields, usersubscription.FieldMonthlyLimitUsd)
+}
+
+// ClearUser clears the "user" edge to the User entity.
+func (m *UserSubscriptionMutation) ClearUser() {
+	m.cleareduser = true
+	m.clearedFields[usersubscription.FieldUserID] = struct{}{}
 }
 
 // UserCleared reports if the "user" edge to the User entity was cleared.
@@ -19256,7 +20703,7 @@ func (m *UserSubscriptionMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *UserSubscriptionMutation) Fields() []string {
-	fields := make([]string, 0, 17)
+	fields := make([]string, 0, 24)
 	if m.created_at != nil {
 		fields = append(fields, usersubscription.FieldCreatedAt)
 	}
@@ -19308,6 +20755,27 @@ func (m *UserSubscriptionMutation) Fields() []string {
 	if m.notes != nil {
 		fields = append(fields, usersubscription.FieldNotes)
 	}
+	if m.plan_id != nil {
+		fields = append(fields, usersubscription.FieldPlanID)
+	}
+	if m.next_plan_id != nil {
+		fields = append(fields, usersubscription.FieldNextPlanID)
+	}
+	if m.auto_renew != nil {
+		fields = append(fields, usersubscription.FieldAutoRenew)
+	}
+	if m.renewal_failed_at != nil {
+		fields = append(fields, usersubscription.FieldRenewalFailedAt)
+	}
+	if m.daily_limit_usd != nil {
+		fields = append(fields, usersubscription.FieldDailyLimitUsd)
+	}
+	if m.weekly_limit_usd != nil {
+		fields = append(fields, usersubscription.FieldWeeklyLimitUsd)
+	}
+	if m.monthly_limit_usd != nil {
+		fields = append(fields, usersubscription.FieldMonthlyLimitUsd)
+	}
 	return fields
 }
 
@@ -19350,6 +20818,20 @@ func (m *UserSubscriptionMutation) Field(name string) (ent.Value, bool) {
 		return m.AssignedAt()
 	case usersubscription.FieldNotes:
 		return m.Notes()
+	case usersubscription.FieldPlanID:
+		return m.PlanID()
+	case usersubscription.FieldNextPlanID:
+		return m.NextPlanID()
+	case usersubscription.FieldAutoRenew:
+		return m.AutoRenew()
+	case usersubscription.FieldRenewalFailedAt:
+		return m.RenewalFailedAt()
+	case usersubscription.FieldDailyLimitUsd:
+		return m.DailyLimitUsd()
+	case usersubscription.FieldWeeklyLimitUsd:
+		return m.WeeklyLimitUsd()
+	case usersubscription.FieldMonthlyLimitUsd:
+		return m.MonthlyLimitUsd()
 	}
 	return nil, false
 }
@@ -19393,6 +20875,20 @@ func (m *UserSubscriptionMutation) OldField(ctx context.Context, name string) (e
 		return m.OldAssignedAt(ctx)
 	case usersubscription.FieldNotes:
 		return m.OldNotes(ctx)
+	case usersubscription.FieldPlanID:
+		return m.OldPlanID(ctx)
+	case usersubscription.FieldNextPlanID:
+		return m.OldNextPlanID(ctx)
+	case usersubscription.FieldAutoRenew:
+		return m.OldAutoRenew(ctx)
+	case usersubscription.FieldRenewalFailedAt:
+		return m.OldRenewalFailedAt(ctx)
+	case usersubscription.FieldDailyLimitUsd:
+		return m.OldDailyLimitUsd(ctx)
+	case usersubscription.FieldWeeklyLimitUsd:
+		return m.OldWeeklyLimitUsd(ctx)
+	case usersubscription.FieldMonthlyLimitUsd:
+		return m.OldMonthlyLimitUsd(ctx)
 	}
 	return nil, fmt.Errorf("unknown UserSubscription field %s", name)
 }
@@ -19521,6 +21017,55 @@ func (m *UserSubscriptionMutation) SetField(name string, value ent.Value) error
 		}
 		m.SetNotes(v)
 		return nil
+	case usersubscription.FieldPlanID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetPlanID(v)
+		return nil
+	case usersubscription.FieldNextPlanID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetNextPlanID(v)
+		return nil
+	case usersubscription.FieldAutoRenew:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAutoRenew(v)
+		return nil
+	case usersubscription.FieldRenewalFailedAt:
+		v, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetRenewalFailedAt(v)
+		return nil
+	case usersubscription.FieldDailyLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetDailyLimitUsd(v)
+		return nil
+	case usersubscription.FieldWeeklyLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetWeeklyLimitUsd(v)
+		return nil
+	case usersubscription.FieldMonthlyLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetMonthlyLimitUsd(v)
+		return nil
 	}
 	return fmt.Errorf("unknown UserSubscription field %s", name)
 }
@@ -19538,6 +21083,21 @@ func (m *UserSubscriptionMutation) AddedFields() []string {
 	if m.addmonthly_usage_usd != nil {
 		fields = append(fields, usersubscription.FieldMonthlyUsageUsd)
 	}
+	if m.addplan_id != nil {
+		fields = append(fields, usersubscription.FieldPlanID)
+	}
+	if m.addnext_plan_id != nil {
+		fields = append(fields, usersubscription.FieldNextPlanID)
+	}
+	if m.adddaily_limit_usd != nil {
+		fields = append(fields, usersubscription.FieldDailyLimitUsd)
+	}
+	if m.addweekly_limit_usd != nil {
+		fields = append(fields, usersubscription.FieldWeeklyLimitUsd)
+	}
+	if m.addmonthly_limit_usd != nil {
+		fields = append(fields, usersubscription.FieldMonthlyLimitUsd)
+	}
 	return fields
 }
 
@@ -19552,6 +21112,16 @@ func (m *UserSubscriptionMutation) AddedField(name string) (ent.Value, bool) {
 		return m.AddedWeeklyUsageUsd()
 	case usersubscription.FieldMonthlyUsageUsd:
 		return m.AddedMonthlyUsageUsd()
+	case usersubscription.FieldPlanID:
+		return m.AddedPlanID()
+	case usersubscription.FieldNextPlanID:
+		return m.AddedNextPlanID()
+	case usersubscription.FieldDailyLimitUsd:
+		return m.AddedDailyLimitUsd()
+	case usersubscription.FieldWeeklyLimitUsd:
+		return m.AddedWeeklyLimitUsd()
+	case usersubscription.FieldMonthlyLimitUsd:
+		return m.AddedMonthlyLimitUsd()
 	}
 	return nil, false
 }
@@ -19582,6 +21152,41 @@ func (m *UserSubscriptionMutation) AddField(name string, value ent.Value) error
 		}
 		m.AddMonthlyUsageUsd(v)
 		return nil
+	case usersubscription.FieldPlanID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddPlanID(v)
+		return nil
+	case usersubscription.FieldNextPlanID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddNextPlanID(v)
+		return nil
+	case usersubscription.FieldDailyLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddDailyLimitUsd(v)
+		return nil
+	case usersubscription.FieldWeeklyLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddWeeklyLimitUsd(v)
+		return nil
+	case usersubscription.FieldMonthlyLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddMonthlyLimitUsd(v)
+		return nil
 	}
 	return fmt.Errorf("unknown UserSubscription numeric field %s", name)
 }
@@ -19608,6 +21213,24 @@ func (m *UserSubscriptionMutation) ClearedFields() []string {
 	if m.FieldCleared(usersubscription.FieldNotes) {
 		fields = append(fields, usersubscription.FieldNotes)
 	}
+	if m.FieldCleared(usersubscription.FieldPlanID) {
+		fields = append(fields, usersubscription.FieldPlanID)
+	}
+	if m.FieldCleared(usersubscription.FieldNextPlanID) {
+		fields = append(fields, usersubscription.FieldNextPlanID)
+	}
+	if m.FieldCleared(usersubscription.FieldRenewalFailedAt) {
+		fields = append(fields, usersubscription.FieldRenewalFailedAt)
+	}
+	if m.FieldCleared(usersubscription.FieldDailyLimitUsd) {
+		fields = append(fields, usersubscription.FieldDailyLimitUsd)
+	}
+	if m.FieldCleared(usersubscription.FieldWeeklyLimitUsd) {
+		fields = append(fields, usersubscription.FieldWeeklyLimitUsd)
+	}
+	if m.FieldCleared(usersubscription.FieldMonthlyLimitUsd) {
+		fields = append(fields, usersubscription.FieldMonthlyLimitUsd)
+	}
 	return fields
 }
 
@@ -19640,6 +21263,24 @@ func (m *UserSubscriptionMutation) ClearField(name string) error {
 	case usersubscription.FieldNotes:
 		m.ClearNotes()
 		return nil
+	case usersubscription.FieldPlanID:
+		m.ClearPlanID()
+		return nil
+	case usersubscription.FieldNextPlanID:
+		m.ClearNextPlanID()
+		return nil
+	case usersubscription.FieldRenewalFailedAt:
+		m.ClearRenewalFailedAt()
+		return nil
+	case usersubscription.FieldDailyLimitUsd:
+		m.ClearDailyLimitUsd()
+		return nil
+	case usersubscription.FieldWeeklyLimitUsd:
+		m.ClearWeeklyLimitUsd()
+		return nil
+	case usersubscription.FieldMonthlyLimitUsd:
+		m.ClearMonthlyLimitUsd()
+		return nil
 	}
 	return fmt.Errorf("unknown UserSubscription nullable field %s", name)
 }
@@ -19699,6 +21340,27 @@ func (m *UserSubscriptionMutation) ResetField(name string) error {
 	case usersubscription.FieldNotes:
 		m.ResetNotes()
 		return nil
+	case usersubscription.FieldPlanID:
+		m.ResetPlanID()
+		return nil
+	case usersubscription.FieldNextPlanID:
+		m.ResetNextPlanID()
+		return nil
+	case usersubscription.FieldAutoRenew:
+		m.ResetAutoRenew()
+		return nil
+	case usersubscription.FieldRenewalFailedAt:
+		m.ResetRenewalFailedAt()
+		return nil
+	case usersubscription.FieldDailyLimitUsd:
+		m.ResetDailyLimitUsd()
+		return nil
+	case usersubscription.FieldWeeklyLimitUsd:
+		m.ResetWeeklyLimitUsd()
+		return nil
+	case usersubscription.FieldMonthlyLimitUsd:
+		m.ResetMonthlyLimitUsd()
+		return nil
 	}
 	return fmt.Errorf("unknown UserSubscription field %s", name)
 }
@@ -34,6 +34,8 @@ const (
 	FieldGroupID = "group_id"
 	// FieldValidityDays holds the string denoting the validity_days field in the database.
 	FieldValidityDays = "validity_days"
+	// FieldIssuerUserID holds the string denoting the issuer_user_id field in the database.
+	FieldIssuerUserID = "issuer_user_id"
 	// EdgeUser holds the string denoting the user edge name in mutations.
 	EdgeUser = "user"
 	// EdgeGroup holds the string denoting the group edge name in mutations.
@@ -69,6 +71,7 @@ var Columns = []string{
 	FieldCreatedAt,
 	FieldGroupID,
 	FieldValidityDays,
+	FieldIssuerUserID,
 }
 
 // ValidColumn reports if the column name is valid (part of the table columns).
@@ -158,6 +161,11 @@ func ByValidityDays(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldValidityDays, opts...).ToFunc()
 }
 
+// ByIssuerUserID orders the results by the issuer_user_id field.
+func ByIssuerUserID(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldIssuerUserID, opts...).ToFunc()
+}
+
 // ByUserField orders the results by user field.
 func ByUserField(field string, opts ...sql.OrderTermOption) OrderOption {
 	return func(s *sql.Selector) {
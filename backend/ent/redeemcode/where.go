@@ -105,6 +105,11 @@ func ValidityDays(v int) predicate.RedeemCode {
 	return predicate.RedeemCode(sql.FieldEQ(FieldValidityDays, v))
 }
 
+// IssuerUserID applies equality check predicate on the "issuer_user_id" field. It's identical to IssuerUserIDEQ.
+func IssuerUserID(v int64) predicate.RedeemCode {
+	return predicate.RedeemCode(sql.FieldEQ(FieldIssuerUserID, v))
+}
+
 // CodeEQ applies the EQ predicate on the "code" field.
 func CodeEQ(v string) predicate.RedeemCode {
 	return predicate.RedeemCode(sql.FieldEQ(FieldCode, v))
@@ -605,6 +610,56 @@ func ValidityDaysLTE(v int) predicate.RedeemCode {
 	return predicate.RedeemCode(sql.FieldLTE(FieldValidityDays, v))
 }
 
+// IssuerUserIDEQ applies the EQ predicate on the "issuer_user_id" field.
+func IssuerUserIDEQ(v int64) predicate.RedeemCode {
+	return predicate.RedeemCode(sql.FieldEQ(FieldIssuerUserID, v))
+}
+
+// IssuerUserIDNEQ applies the NEQ predicate on the "issuer_user_id" field.
+func IssuerUserIDNEQ(v int64) predicate.RedeemCode {
+	return predicate.RedeemCode(sql.FieldNEQ(FieldIssuerUserID, v))
+}
+
+// IssuerUserIDIn applies the In predicate on the "issuer_user_id" field.
+func IssuerUserIDIn(vs ...int64) predicate.RedeemCode {
+	return predicate.RedeemCode(sql.FieldIn(FieldIssuerUserID, vs...))
+}
+
+// IssuerUserIDNotIn applies the NotIn predicate on the "issuer_user_id" field.
+func IssuerUserIDNotIn(vs ...int64) predicate.RedeemCode {
+	return predicate.RedeemCode(sql.FieldNotIn(FieldIssuerUserID, vs...))
+}
+
+// IssuerUserIDGT applies the GT predicate on the "issuer_user_id" field.
+func IssuerUserIDGT(v int64) predicate.RedeemCode {
+	return predicate.RedeemCode(sql.FieldGT(FieldIssuerUserID, v))
+}
+
+// IssuerUserIDGTE applies the GTE predicate on the "issuer_user_id" field.
+func IssuerUserIDGTE(v int64) predicate.RedeemCode {
+	return predicate.RedeemCode(sql.FieldGTE(FieldIssuerUserID, v))
+}
+
+// IssuerUserIDLT applies the LT predicate on the "issuer_user_id" field.
+func IssuerUserIDLT(v int64) predicate.RedeemCode {
+	return predicate.RedeemCode(sql.FieldLT(FieldIssuerUserID, v))
+}
+
+// IssuerUserIDLTE applies the LTE predicate on the "issuer_user_id" field.
+func IssuerUserIDLTE(v int64) predicate.RedeemCode {
+	return predicate.RedeemCode(sql.FieldLTE(FieldIssuerUserID, v))
+}
+
+// IssuerUserIDIsNil applies the IsNil predicate on the "issuer_user_id" field.
+func IssuerUserIDIsNil() predicate.RedeemCode {
+	return predicate.RedeemCode(sql.FieldIsNull(FieldIssuerUserID))
+}
+
+// IssuerUserIDNotNil applies the NotNil predicate on the "issuer_user_id" field.
+func IssuerUserIDNotNil() predicate.RedeemCode {
+	return predicate.RedeemCode(sql.FieldNotNull(FieldIssuerUserID))
+}
+
 // HasUser applies the HasEdge predicate on the "user" edge.
 func HasUser() predicate.RedeemCode {
 	return predicate.RedeemCode(func(s *sql.Selector) {
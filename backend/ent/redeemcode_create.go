@@ -156,6 +156,20 @@ func (_c *RedeemCodeCreate) SetNillableValidityDays(v *int) *RedeemCodeCreate {
 	return _c
 }
 
+// SetIssuerUserID sets the "issuer_user_id" field.
+func (_c *RedeemCodeCreate) SetIssuerUserID(v int64) *RedeemCodeCreate {
+	_c.mutation.SetIssuerUserID(v)
+	return _c
+}
+
+// SetNillableIssuerUserID sets the "issuer_user_id" field if the given value is not nil.
+func (_c *RedeemCodeCreate) SetNillableIssuerUserID(v *int64) *RedeemCodeCreate {
+	if v != nil {
+		_c.SetIssuerUserID(*v)
+	}
+	return _c
+}
+
 // SetUserID sets the "user" edge to the User entity by ID.
 func (_c *RedeemCodeCreate) SetUserID(id int64) *RedeemCodeCreate {
 	_c.mutation.SetUserID(id)
@@ -331,6 +345,10 @@ func (_c *RedeemCodeCreate) createSpec() (*RedeemCode, *sqlgraph.CreateSpec) {
 		_spec.SetField(redeemcode.FieldValidityDays, field.TypeInt, value)
 		_node.ValidityDays = value
 	}
+	if value, ok := _c.mutation.IssuerUserID(); ok {
+		_spec.SetField(redeemcode.FieldIssuerUserID, field.TypeInt64, value)
+		_node.IssuerUserID = &value
+	}
 	if nodes := _c.mutation.UserIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -561,6 +579,30 @@ func (u *RedeemCodeUpsert) AddValidityDays(v int) *RedeemCodeUpsert {
 	return u
 }
 
+// SetIssuerUserID sets the "issuer_user_id" field.
+func (u *RedeemCodeUpsert) SetIssuerUserID(v int64) *RedeemCodeUpsert {
+	u.Set(redeemcode.FieldIssuerUserID, v)
+	return u
+}
+
+// UpdateIssuerUserID sets the "issuer_user_id" field to the value that was provided on create.
+func (u *RedeemCodeUpsert) UpdateIssuerUserID() *RedeemCodeUpsert {
+	u.SetExcluded(redeemcode.FieldIssuerUserID)
+	return u
+}
+
+// AddIssuerUserID adds v to the "issuer_user_id" field.
+func (u *RedeemCodeUpsert) AddIssuerUserID(v int64) *RedeemCodeUpsert {
+	u.Add(redeemcode.FieldIssuerUserID, v)
+	return u
+}
+
+// ClearIssuerUserID clears the value of the "issuer_user_id" field.
+func (u *RedeemCodeUpsert) ClearIssuerUserID() *RedeemCodeUpsert {
+	u.SetNull(redeemcode.FieldIssuerUserID)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -774,6 +816,34 @@ func (u *RedeemCodeUpsertOne) UpdateValidityDays() *RedeemCodeUpsertOne {
 	})
 }
 
+// SetIssuerUserID sets the "issuer_user_id" field.
+func (u *RedeemCodeUpsertOne) SetIssuerUserID(v int64) *RedeemCodeUpsertOne {
+	return u.Update(func(s *RedeemCodeUpsert) {
+		s.SetIssuerUserID(v)
+	})
+}
+
+// AddIssuerUserID adds v to the "issuer_user_id" field.
+func (u *RedeemCodeUpsertOne) AddIssuerUserID(v int64) *RedeemCodeUpsertOne {
+	return u.Update(func(s *RedeemCodeUpsert) {
+		s.AddIssuerUserID(v)
+	})
+}
+
+// UpdateIssuerUserID sets the "issuer_user_id" field to the value that was provided on create.
+func (u *RedeemCodeUpsertOne) UpdateIssuerUserID() *RedeemCodeUpsertOne {
+	return u.Update(func(s *RedeemCodeUpsert) {
+		s.UpdateIssuerUserID()
+	})
+}
+
+// ClearIssuerUserID clears the value of the "issuer_user_id" field.
+func (u *RedeemCodeUpsertOne) ClearIssuerUserID() *RedeemCodeUpsertOne {
+	return u.Update(func(s *RedeemCodeUpsert) {
+		s.ClearIssuerUserID()
+	})
+}
+
 // Exec executes the query.
 func (u *RedeemCodeUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -1153,6 +1223,34 @@ func (u *RedeemCodeUpsertBulk) UpdateValidityDays() *RedeemCodeUpsertBulk {
 	})
 }
 
+// SetIssuerUserID sets the "issuer_user_id" field.
+func (u *RedeemCodeUpsertBulk) SetIssuerUserID(v int64) *RedeemCodeUpsertBulk {
+	return u.Update(func(s *RedeemCodeUpsert) {
+		s.SetIssuerUserID(v)
+	})
+}
+
+// AddIssuerUserID adds v to the "issuer_user_id" field.
+func (u *RedeemCodeUpsertBulk) AddIssuerUserID(v int64) *RedeemCodeUpsertBulk {
+	return u.Update(func(s *RedeemCodeUpsert) {
+		s.AddIssuerUserID(v)
+	})
+}
+
+// UpdateIssuerUserID sets the "issuer_user_id" field to the value that was provided on create.
+func (u *RedeemCodeUpsertBulk) UpdateIssuerUserID() *RedeemCodeUpsertBulk {
+	return u.Update(func(s *RedeemCodeUpsert) {
+		s.UpdateIssuerUserID()
+	})
+}
+
+// ClearIssuerUserID clears the value of the "issuer_user_id" field.
+func (u *RedeemCodeUpsertBulk) ClearIssuerUserID() *RedeemCodeUpsertBulk {
+	return u.Update(func(s *RedeemCodeUpsert) {
+		s.ClearIssuerUserID()
+	})
+}
+
 // Exec executes the query.
 func (u *RedeemCodeUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
@@ -194,6 +194,33 @@ func (_u *RedeemCodeUpdate) AddValidityDays(v int) *RedeemCodeUpdate {
 	return _u
 }
 
+// SetIssuerUserID sets the "issuer_user_id" field.
+func (_u *RedeemCodeUpdate) SetIssuerUserID(v int64) *RedeemCodeUpdate {
+	_u.mutation.ResetIssuerUserID()
+	_u.mutation.SetIssuerUserID(v)
+	return _u
+}
+
+// SetNillableIssuerUserID sets the "issuer_user_id" field if the given value is not nil.
+func (_u *RedeemCodeUpdate) SetNillableIssuerUserID(v *int64) *RedeemCodeUpdate {
+	if v != nil {
+		_u.SetIssuerUserID(*v)
+	}
+	return _u
+}
+
+// AddIssuerUserID adds value to the "issuer_user_id" field.
+func (_u *RedeemCodeUpdate) AddIssuerUserID(v int64) *RedeemCodeUpdate {
+	_u.mutation.AddIssuerUserID(v)
+	return _u
+}
+
+// ClearIssuerUserID clears the value of the "issuer_user_id" field.
+func (_u *RedeemCodeUpdate) ClearIssuerUserID() *RedeemCodeUpdate {
+	_u.mutation.ClearIssuerUserID()
+	return _u
+}
+
 // SetUserID sets the "user" edge to the User entity by ID.
 func (_u *RedeemCodeUpdate) SetUserID(id int64) *RedeemCodeUpdate {
 	_u.mutation.SetUserID(id)
@@ -327,6 +354,15 @@ func (_u *RedeemCodeUpdate) sqlSave(ctx context.Context) (_node int, err error)
 	if value, ok := _u.mutation.AddedValidityDays(); ok {
 		_spec.AddField(redeemcode.FieldValidityDays, field.TypeInt, value)
 	}
+	if value, ok := _u.mutation.IssuerUserID(); ok {
+		_spec.SetField(redeemcode.FieldIssuerUserID, field.TypeInt64, value)
+	}
+	if value, ok := _u.mutation.AddedIssuerUserID(); ok {
+		_spec.AddField(redeemcode.FieldIssuerUserID, field.TypeInt64, value)
+	}
+	if _u.mutation.IssuerUserIDCleared() {
+		_spec.ClearField(redeemcode.FieldIssuerUserID, field.TypeInt64)
+	}
 	if _u.mutation.UserCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -569,6 +605,33 @@ func (_u *RedeemCodeUpdateOne) AddValidityDays(v int) *RedeemCodeUpdateOne {
 	return _u
 }
 
+// SetIssuerUserID sets the "issuer_user_id" field.
+func (_u *RedeemCodeUpdateOne) SetIssuerUserID(v int64) *RedeemCodeUpdateOne {
+	_u.mutation.ResetIssuerUserID()
+	_u.mutation.SetIssuerUserID(v)
+	return _u
+}
+
+// SetNillableIssuerUserID sets the "issuer_user_id" field if the given value is not nil.
+func (_u *RedeemCodeUpdateOne) SetNillableIssuerUserID(v *int64) *RedeemCodeUpdateOne {
+	if v != nil {
+		_u.SetIssuerUserID(*v)
+	}
+	return _u
+}
+
+// AddIssuerUserID adds value to the "issuer_user_id" field.
+func (_u *RedeemCodeUpdateOne) AddIssuerUserID(v int64) *RedeemCodeUpdateOne {
+	_u.mutation.AddIssuerUserID(v)
+	return _u
+}
+
+// ClearIssuerUserID clears the value of the "issuer_user_id" field.
+func (_u *RedeemCodeUpdateOne) ClearIssuerUserID() *RedeemCodeUpdateOne {
+	_u.mutation.ClearIssuerUserID()
+	return _u
+}
+
 // SetUserID sets the "user" edge to the User entity by ID.
 func (_u *RedeemCodeUpdateOne) SetUserID(id int64) *RedeemCodeUpdateOne {
 	_u.mutation.SetUserID(id)
@@ -732,6 +795,15 @@ func (_u *RedeemCodeUpdateOne) sqlSave(ctx context.Context) (_node *RedeemCode,
 	if value, ok := _u.mutation.AddedValidityDays(); ok {
 		_spec.AddField(redeemcode.FieldValidityDays, field.TypeInt, value)
 	}
+	if value, ok := _u.mutation.IssuerUserID(); ok {
+		_spec.SetField(redeemcode.FieldIssuerUserID, field.TypeInt64, value)
+	}
+	if value, ok := _u.mutation.AddedIssuerUserID(); ok {
+		_spec.AddField(redeemcode.FieldIssuerUserID, field.TypeInt64, value)
+	}
+	if _u.mutation.IssuerUserIDCleared() {
+		_spec.ClearField(redeemcode.FieldIssuerUserID, field.TypeInt64)
+	}
 	if _u.mutation.UserCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
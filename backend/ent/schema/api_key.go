@@ -52,6 +52,10 @@ func (APIKey) Fields() []ent.Field {
 		field.JSON("ip_blacklist", []string{}).
 			Optional().
 			Comment("Blocked IPs/CIDRs"),
+		// 组织 Key：用量计入组织的付费账户，成员仅作为发起人
+		field.Int64("organization_id").
+			Optional().
+			Nillable(),
 	}
 }
 
@@ -75,6 +79,7 @@ func (APIKey) Indexes() []ent.Index {
 		// key 字段已在 Fields() 中声明 Unique()，无需重复索引
 		index.Fields("user_id"),
 		index.Fields("group_id"),
+		index.Fields("organization_id"),
 		index.Fields("status"),
 		index.Fields("deleted_at"),
 	}
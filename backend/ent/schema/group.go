@@ -1,6 +1,8 @@
 package schema
 
 import (
+	"encoding/json"
+
 	"github.com/Wei-Shaw/sub2api/ent/schema/mixins"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
@@ -106,6 +108,29 @@ func (Group) Fields() []ent.Field {
 		field.Bool("model_routing_enabled").
 			Default(false).
 			Comment("是否启用模型路由配置"),
+
+		// 费用预扣透支容忍 (added by migration 052)
+		field.Float("overdraft_usd").
+			SchemaType(map[string]string{dialect.Postgres: "decimal(20,8)"}).
+			Default(0).
+			Comment("预扣费用时允许透支的额度 (USD)"),
+
+		// count_tokens 计数方式 (added by migration 053)
+		field.String("count_tokens_mode").
+			MaxLen(20).
+			Default(service.CountTokensModeUpstream).
+			Comment("count_tokens 计数方式: upstream/local/local_fallback"),
+
+		// 请求对冲开关 (added by migration 063)
+		field.Bool("hedge_enabled").
+			Default(false).
+			Comment("首字节过慢时是否向第二个账号发送对冲请求"),
+
+		// 模型降级链 (added by migration 064)
+		field.JSON("model_fallback", json.RawMessage{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("模型降级链配置：触发条件 + 模型模式 -> 有序降级模型列表"),
 	}
 }
 
@@ -46,7 +46,7 @@ func (Proxy) Fields() []ent.Field {
 			Optional().
 			Nillable(),
 		field.String("password").
-			MaxLen(100).
+			MaxLen(512).
 			Optional().
 			Nillable(),
 		field.String("status").
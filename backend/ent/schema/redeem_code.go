@@ -68,6 +68,10 @@ func (RedeemCode) Fields() []ent.Field {
 			Nillable(),
 		field.Int("validity_days").
 			Default(30),
+		// 分销商发行的兑换码：面值已从发行人余额扣除，仅其下级用户可兑换
+		field.Int64("issuer_user_id").
+			Optional().
+			Nillable(),
 	}
 }
 
@@ -90,5 +94,6 @@ func (RedeemCode) Indexes() []ent.Index {
 		index.Fields("status"),
 		index.Fields("used_by"),
 		index.Fields("group_id"),
+		index.Fields("issuer_user_id"),
 	}
 }
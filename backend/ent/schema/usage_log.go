@@ -47,6 +47,10 @@ func (UsageLog) Fields() []ent.Field {
 		field.Int64("subscription_id").
 			Optional().
 			Nillable(),
+		// 组织 Key 产生的用量记录组织ID（user_id 仍为发起请求的成员）
+		field.Int64("organization_id").
+			Optional().
+			Nillable(),
 
 		// Token 计数字段
 		field.Int("input_tokens").
@@ -75,6 +79,10 @@ func (UsageLog) Fields() []ent.Field {
 		field.Float("cache_read_cost").
 			Default(0).
 			SchemaType(map[string]string{dialect.Postgres: "decimal(20,10)"}),
+		// cache_creation_1h_cost: 1 小时缓存写入费用（包含在 cache_creation_cost 中）
+		field.Float("cache_creation_1h_cost").
+			Default(0).
+			SchemaType(map[string]string{dialect.Postgres: "decimal(20,10)"}),
 		field.Float("total_cost").
 			Default(0).
 			SchemaType(map[string]string{dialect.Postgres: "decimal(20,10)"}),
@@ -90,6 +98,11 @@ func (UsageLog) Fields() []ent.Field {
 			Optional().
 			Nillable().
 			SchemaType(map[string]string{dialect.Postgres: "decimal(10,4)"}),
+		// rate_rule: 产生 rate_multiplier 的倍率规则（NULL 表示历史数据）
+		field.String("rate_rule").
+			MaxLen(100).
+			Optional().
+			Nillable(),
 
 		// 其他字段
 		field.Int8("billing_type").
@@ -102,6 +115,9 @@ func (UsageLog) Fields() []ent.Field {
 		field.Int("first_token_ms").
 			Optional().
 			Nillable(),
+		// hedged: 请求触发了对冲（同一请求发往第二个账号，仅计费胜出的一方）
+		field.Bool("hedged").
+			Default(false),
 		field.String("user_agent").
 			MaxLen(512).
 			Optional().
@@ -119,6 +135,11 @@ func (UsageLog) Fields() []ent.Field {
 			Optional().
 			Nillable(),
 
+		// tags: 成本归属标签（标签键 -> 标签值），仅包含管理员定义的标签键
+		field.JSON("tags", map[string]string{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}),
+
 		// 时间戳（只有 created_at，日志不可修改）
 		field.Time("created_at").
 			Default(time.Now).
@@ -164,6 +185,7 @@ func (UsageLog) Indexes() []ent.Index {
 		index.Fields("account_id"),
 		index.Fields("group_id"),
 		index.Fields("subscription_id"),
+		index.Fields("organization_id"),
 		index.Fields("created_at"),
 		index.Fields("model"),
 		index.Fields("request_id"),
@@ -61,6 +61,11 @@ func (User) Fields() []ent.Field {
 		field.String("notes").
 			SchemaType(map[string]string{dialect.Postgres: "text"}).
 			Default(""),
+
+		// 分销：下级用户所属的分销商（见迁移 048_add_resellers.sql）
+		field.Int64("parent_user_id").
+			Optional().
+			Nillable(),
 	}
 }
 
@@ -83,5 +88,6 @@ func (User) Indexes() []ent.Index {
 		// email 字段已在 Fields() 中声明 Unique()，无需重复索引
 		index.Fields("status"),
 		index.Fields("deleted_at"),
+		index.Fields("parent_user_id"),
 	}
 }
@@ -79,6 +79,33 @@ func (UserSubscription) Fields() []ent.Field {
 			Optional().
 			Nillable().
 			SchemaType(map[string]string{dialect.Postgres: "text"}),
+
+		// 订阅套餐：通过套餐购买的订阅记录套餐ID及限额覆盖（为空时沿用分组限额）
+		field.Int64("plan_id").
+			Optional().
+			Nillable(),
+		field.Int64("next_plan_id").
+			Optional().
+			Nillable().
+			Comment("降级套餐：下次续费时生效"),
+		field.Bool("auto_renew").
+			Default(false),
+		field.Time("renewal_failed_at").
+			Optional().
+			Nillable().
+			SchemaType(map[string]string{dialect.Postgres: "timestamptz"}),
+		field.Float("daily_limit_usd").
+			Optional().
+			Nillable().
+			SchemaType(map[string]string{dialect.Postgres: "decimal(20,8)"}),
+		field.Float("weekly_limit_usd").
+			Optional().
+			Nillable().
+			SchemaType(map[string]string{dialect.Postgres: "decimal(20,8)"}),
+		field.Float("monthly_limit_usd").
+			Optional().
+			Nillable().
+			SchemaType(map[string]string{dialect.Postgres: "decimal(20,8)"}),
 	}
 }
 
@@ -109,6 +136,8 @@ func (UserSubscription) Indexes() []ent.Index {
 		index.Fields("status"),
 		index.Fields("expires_at"),
 		index.Fields("assigned_by"),
+		index.Fields("plan_id"),
+		index.Fields("auto_renew", "expires_at"),
 		// 唯一约束通过部分索引实现（WHERE deleted_at IS NULL），支持软删除后重新订阅
 		// 见迁移文件 016_soft_delete_partial_unique_indexes.sql
 		index.Fields("user_id", "group_id"),
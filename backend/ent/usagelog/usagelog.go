@@ -28,6 +28,8 @@ const (
 	FieldGroupID = "group_id"
 	// FieldSubscriptionID holds the string denoting the subscription_id field in the database.
 	FieldSubscriptionID = "subscription_id"
+	// FieldOrganizationID holds the string denoting the organization_id field in the database.
+	FieldOrganizationID = "organization_id"
 	// FieldInputTokens holds the string denoting the input_tokens field in the database.
 	FieldInputTokens = "input_tokens"
 	// FieldOutputTokens holds the string denoting the output_tokens field in the database.
@@ -48,6 +50,8 @@ const (
 	FieldCacheCreationCost = "cache_creation_cost"
 	// FieldCacheReadCost holds the string denoting the cache_read_cost field in the database.
 	FieldCacheReadCost = "cache_read_cost"
+	// FieldCacheCreation1hCost holds the string denoting the cache_creation_1h_cost field in the database.
+	FieldCacheCreation1hCost = "cache_creation_1h_cost"
 	// FieldTotalCost holds the string denoting the total_cost field in the database.
 	FieldTotalCost = "total_cost"
 	// FieldActualCost holds the string denoting the actual_cost field in the database.
@@ -56,6 +60,8 @@ const (
 	FieldRateMultiplier = "rate_multiplier"
 	// FieldAccountRateMultiplier holds the string denoting the account_rate_multiplier field in the database.
 	FieldAccountRateMultiplier = "account_rate_multiplier"
+	// FieldRateRule holds the string denoting the rate_rule field in the database.
+	FieldRateRule = "rate_rule"
 	// FieldBillingType holds the string denoting the billing_type field in the database.
 	FieldBillingType = "billing_type"
 	// FieldStream holds the string denoting the stream field in the database.
@@ -64,6 +70,8 @@ const (
 	FieldDurationMs = "duration_ms"
 	// FieldFirstTokenMs holds the string denoting the first_token_ms field in the database.
 	FieldFirstTokenMs = "first_token_ms"
+	// FieldHedged holds the string denoting the hedged field in the database.
+	FieldHedged = "hedged"
 	// FieldUserAgent holds the string denoting the user_agent field in the database.
 	FieldUserAgent = "user_agent"
 	// FieldIPAddress holds the string denoting the ip_address field in the database.
@@ -72,6 +80,8 @@ const (
 	FieldImageCount = "image_count"
 	// FieldImageSize holds the string denoting the image_size field in the database.
 	FieldImageSize = "image_size"
+	// FieldTags holds the string denoting the tags field in the database.
+	FieldTags = "tags"
 	// FieldCreatedAt holds the string denoting the created_at field in the database.
 	FieldCreatedAt = "created_at"
 	// EdgeUser holds the string denoting the user edge name in mutations.
@@ -133,6 +143,7 @@ var Columns = []string{
 	FieldModel,
 	FieldGroupID,
 	FieldSubscriptionID,
+	FieldOrganizationID,
 	FieldInputTokens,
 	FieldOutputTokens,
 	FieldCacheCreationTokens,
@@ -143,18 +154,22 @@ var Columns = []string{
 	FieldOutputCost,
 	FieldCacheCreationCost,
 	FieldCacheReadCost,
+	FieldCacheCreation1hCost,
 	FieldTotalCost,
 	FieldActualCost,
 	FieldRateMultiplier,
 	FieldAccountRateMultiplier,
+	FieldRateRule,
 	FieldBillingType,
 	FieldStream,
 	FieldDurationMs,
 	FieldFirstTokenMs,
+	FieldHedged,
 	FieldUserAgent,
 	FieldIPAddress,
 	FieldImageCount,
 	FieldImageSize,
+	FieldTags,
 	FieldCreatedAt,
 }
 
@@ -193,16 +208,22 @@ var (
 	DefaultCacheCreationCost float64
 	// DefaultCacheReadCost holds the default value on creation for the "cache_read_cost" field.
 	DefaultCacheReadCost float64
+	// DefaultCacheCreation1hCost holds the default value on creation for the "cache_creation_1h_cost" field.
+	DefaultCacheCreation1hCost float64
 	// DefaultTotalCost holds the default value on creation for the "total_cost" field.
 	DefaultTotalCost float64
 	// DefaultActualCost holds the default value on creation for the "actual_cost" field.
 	DefaultActualCost float64
 	// DefaultRateMultiplier holds the default value on creation for the "rate_multiplier" field.
 	DefaultRateMultiplier float64
+	// RateRuleValidator is a validator for the "rate_rule" field. It is called by the builders before save.
+	RateRuleValidator func(string) error
 	// DefaultBillingType holds the default value on creation for the "billing_type" field.
 	DefaultBillingType int8
 	// DefaultStream holds the default value on creation for the "stream" field.
 	DefaultStream bool
+	// DefaultHedged holds the default value on creation for the "hedged" field.
+	DefaultHedged bool
 	// UserAgentValidator is a validator for the "user_agent" field. It is called by the builders before save.
 	UserAgentValidator func(string) error
 	// IPAddressValidator is a validator for the "ip_address" field. It is called by the builders before save.
@@ -258,6 +279,11 @@ func BySubscriptionID(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldSubscriptionID, opts...).ToFunc()
 }
 
+// ByOrganizationID orders the results by the organization_id field.
+func ByOrganizationID(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldOrganizationID, opts...).ToFunc()
+}
+
 // ByInputTokens orders the results by the input_tokens field.
 func ByInputTokens(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldInputTokens, opts...).ToFunc()
@@ -308,6 +334,11 @@ func ByCacheReadCost(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldCacheReadCost, opts...).ToFunc()
 }
 
+// ByCacheCreation1hCost orders the results by the cache_creation_1h_cost field.
+func ByCacheCreation1hCost(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldCacheCreation1hCost, opts...).ToFunc()
+}
+
 // ByTotalCost orders the results by the total_cost field.
 func ByTotalCost(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldTotalCost, opts...).ToFunc()
@@ -328,6 +359,11 @@ func ByAccountRateMultiplier(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldAccountRateMultiplier, opts...).ToFunc()
 }
 
+// ByRateRule orders the results by the rate_rule field.
+func ByRateRule(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldRateRule, opts...).ToFunc()
+}
+
 // ByBillingType orders the results by the billing_type field.
 func ByBillingType(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldBillingType, opts...).ToFunc()
@@ -348,6 +384,11 @@ func ByFirstTokenMs(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldFirstTokenMs, opts...).ToFunc()
 }
 
+// ByHedged orders the results by the hedged field.
+func ByHedged(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldHedged, opts...).ToFunc()
+}
+
 // ByUserAgent orders the results by the user_agent field.
 func ByUserAgent(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldUserAgent, opts...).ToFunc()
@@ -90,6 +90,11 @@ func SubscriptionID(v int64) predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldEQ(FieldSubscriptionID, v))
 }
 
+// OrganizationID applies equality check predicate on the "organization_id" field. It's identical to OrganizationIDEQ.
+func OrganizationID(v int64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldEQ(FieldOrganizationID, v))
+}
+
 // InputTokens applies equality check predicate on the "input_tokens" field. It's identical to InputTokensEQ.
 func InputTokens(v int) predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldEQ(FieldInputTokens, v))
@@ -140,6 +145,11 @@ func CacheReadCost(v float64) predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldEQ(FieldCacheReadCost, v))
 }
 
+// CacheCreation1hCost applies equality check predicate on the "cache_creation_1h_cost" field. It's identical to CacheCreation1hCostEQ.
+func CacheCreation1hCost(v float64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldEQ(FieldCacheCreation1hCost, v))
+}
+
 // TotalCost applies equality check predicate on the "total_cost" field. It's identical to TotalCostEQ.
 func TotalCost(v float64) predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldEQ(FieldTotalCost, v))
@@ -160,6 +170,11 @@ func AccountRateMultiplier(v float64) predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldEQ(FieldAccountRateMultiplier, v))
 }
 
+// RateRule applies equality check predicate on the "rate_rule" field. It's identical to RateRuleEQ.
+func RateRule(v string) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldEQ(FieldRateRule, v))
+}
+
 // BillingType applies equality check predicate on the "billing_type" field. It's identical to BillingTypeEQ.
 func BillingType(v int8) predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldEQ(FieldBillingType, v))
@@ -180,6 +195,11 @@ func FirstTokenMs(v int) predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldEQ(FieldFirstTokenMs, v))
 }
 
+// Hedged applies equality check predicate on the "hedged" field. It's identical to HedgedEQ.
+func Hedged(v bool) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldEQ(FieldHedged, v))
+}
+
 // UserAgent applies equality check predicate on the "user_agent" field. It's identical to UserAgentEQ.
 func UserAgent(v string) predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldEQ(FieldUserAgent, v))
@@ -455,6 +475,56 @@ func SubscriptionIDNotNil() predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldNotNull(FieldSubscriptionID))
 }
 
+// OrganizationIDEQ applies the EQ predicate on the "organization_id" field.
+func OrganizationIDEQ(v int64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldEQ(FieldOrganizationID, v))
+}
+
+// OrganizationIDNEQ applies the NEQ predicate on the "organization_id" field.
+func OrganizationIDNEQ(v int64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldNEQ(FieldOrganizationID, v))
+}
+
+// OrganizationIDIn applies the In predicate on the "organization_id" field.
+func OrganizationIDIn(vs ...int64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldIn(FieldOrganizationID, vs...))
+}
+
+// OrganizationIDNotIn applies the NotIn predicate on the "organization_id" field.
+func OrganizationIDNotIn(vs ...int64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldNotIn(FieldOrganizationID, vs...))
+}
+
+// OrganizationIDGT applies the GT predicate on the "organization_id" field.
+func OrganizationIDGT(v int64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldGT(FieldOrganizationID, v))
+}
+
+// OrganizationIDGTE applies the GTE predicate on the "organization_id" field.
+func OrganizationIDGTE(v int64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldGTE(FieldOrganizationID, v))
+}
+
+// OrganizationIDLT applies the LT predicate on the "organization_id" field.
+func OrganizationIDLT(v int64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldLT(FieldOrganizationID, v))
+}
+
+// OrganizationIDLTE applies the LTE predicate on the "organization_id" field.
+func OrganizationIDLTE(v int64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldLTE(FieldOrganizationID, v))
+}
+
+// OrganizationIDIsNil applies the IsNil predicate on the "organization_id" field.
+func OrganizationIDIsNil() predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldIsNull(FieldOrganizationID))
+}
+
+// OrganizationIDNotNil applies the NotNil predicate on the "organization_id" field.
+func OrganizationIDNotNil() predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldNotNull(FieldOrganizationID))
+}
+
 // InputTokensEQ applies the EQ predicate on the "input_tokens" field.
 func InputTokensEQ(v int) predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldEQ(FieldInputTokens, v))
@@ -855,6 +925,46 @@ func CacheReadCostLTE(v float64) predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldLTE(FieldCacheReadCost, v))
 }
 
+// CacheCreation1hCostEQ applies the EQ predicate on the "cache_creation_1h_cost" field.
+func CacheCreation1hCostEQ(v float64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldEQ(FieldCacheCreation1hCost, v))
+}
+
+// CacheCreation1hCostNEQ applies the NEQ predicate on the "cache_creation_1h_cost" field.
+func CacheCreation1hCostNEQ(v float64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldNEQ(FieldCacheCreation1hCost, v))
+}
+
+// CacheCreation1hCostIn applies the In predicate on the "cache_creation_1h_cost" field.
+func CacheCreation1hCostIn(vs ...float64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldIn(FieldCacheCreation1hCost, vs...))
+}
+
+// CacheCreation1hCostNotIn applies the NotIn predicate on the "cache_creation_1h_cost" field.
+func CacheCreation1hCostNotIn(vs ...float64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldNotIn(FieldCacheCreation1hCost, vs...))
+}
+
+// CacheCreation1hCostGT applies the GT predicate on the "cache_creation_1h_cost" field.
+func CacheCreation1hCostGT(v float64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldGT(FieldCacheCreation1hCost, v))
+}
+
+// CacheCreation1hCostGTE applies the GTE predicate on the "cache_creation_1h_cost" field.
+func CacheCreation1hCostGTE(v float64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldGTE(FieldCacheCreation1hCost, v))
+}
+
+// CacheCreation1hCostLT applies the LT predicate on the "cache_creation_1h_cost" field.
+func CacheCreation1hCostLT(v float64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldLT(FieldCacheCreation1hCost, v))
+}
+
+// CacheCreation1hCostLTE applies the LTE predicate on the "cache_creation_1h_cost" field.
+func CacheCreation1hCostLTE(v float64) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldLTE(FieldCacheCreation1hCost, v))
+}
+
 // TotalCostEQ applies the EQ predicate on the "total_cost" field.
 func TotalCostEQ(v float64) predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldEQ(FieldTotalCost, v))
@@ -1025,6 +1135,81 @@ func AccountRateMultiplierNotNil() predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldNotNull(FieldAccountRateMultiplier))
 }
 
+// RateRuleEQ applies the EQ predicate on the "rate_rule" field.
+func RateRuleEQ(v string) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldEQ(FieldRateRule, v))
+}
+
+// RateRuleNEQ applies the NEQ predicate on the "rate_rule" field.
+func RateRuleNEQ(v string) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldNEQ(FieldRateRule, v))
+}
+
+// RateRuleIn applies the In predicate on the "rate_rule" field.
+func RateRuleIn(vs ...string) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldIn(FieldRateRule, vs...))
+}
+
+// RateRuleNotIn applies the NotIn predicate on the "rate_rule" field.
+func RateRuleNotIn(vs ...string) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldNotIn(FieldRateRule, vs...))
+}
+
+// RateRuleGT applies the GT predicate on the "rate_rule" field.
+func RateRuleGT(v string) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldGT(FieldRateRule, v))
+}
+
+// RateRuleGTE applies the GTE predicate on the "rate_rule" field.
+func RateRuleGTE(v string) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldGTE(FieldRateRule, v))
+}
+
+// RateRuleLT applies the LT predicate on the "rate_rule" field.
+func RateRuleLT(v string) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldLT(FieldRateRule, v))
+}
+
+// RateRuleLTE applies the LTE predicate on the "rate_rule" field.
+func RateRuleLTE(v string) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldLTE(FieldRateRule, v))
+}
+
+// RateRuleContains applies the Contains predicate on the "rate_rule" field.
+func RateRuleContains(v string) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldContains(FieldRateRule, v))
+}
+
+// RateRuleHasPrefix applies the HasPrefix predicate on the "rate_rule" field.
+func RateRuleHasPrefix(v string) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldHasPrefix(FieldRateRule, v))
+}
+
+// RateRuleHasSuffix applies the HasSuffix predicate on the "rate_rule" field.
+func RateRuleHasSuffix(v string) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldHasSuffix(FieldRateRule, v))
+}
+
+// RateRuleIsNil applies the IsNil predicate on the "rate_rule" field.
+func RateRuleIsNil() predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldIsNull(FieldRateRule))
+}
+
+// RateRuleNotNil applies the NotNil predicate on the "rate_rule" field.
+func RateRuleNotNil() predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldNotNull(FieldRateRule))
+}
+
+// RateRuleEqualFold applies the EqualFold predicate on the "rate_rule" field.
+func RateRuleEqualFold(v string) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldEqualFold(FieldRateRule, v))
+}
+
+// RateRuleContainsFold applies the ContainsFold predicate on the "rate_rule" field.
+func RateRuleContainsFold(v string) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldContainsFold(FieldRateRule, v))
+}
+
 // BillingTypeEQ applies the EQ predicate on the "billing_type" field.
 func BillingTypeEQ(v int8) predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldEQ(FieldBillingType, v))
@@ -1175,6 +1360,16 @@ func FirstTokenMsNotNil() predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldNotNull(FieldFirstTokenMs))
 }
 
+// HedgedEQ applies the EQ predicate on the "hedged" field.
+func HedgedEQ(v bool) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldEQ(FieldHedged, v))
+}
+
+// HedgedNEQ applies the NEQ predicate on the "hedged" field.
+func HedgedNEQ(v bool) predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldNEQ(FieldHedged, v))
+}
+
 // UserAgentEQ applies the EQ predicate on the "user_agent" field.
 func UserAgentEQ(v string) predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldEQ(FieldUserAgent, v))
@@ -1440,6 +1635,16 @@ func ImageSizeContainsFold(v string) predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldContainsFold(FieldImageSize, v))
 }
 
+// TagsIsNil applies the IsNil predicate on the "tags" field.
+func TagsIsNil() predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldIsNull(FieldTags))
+}
+
+// TagsNotNil applies the NotNil predicate on the "tags" field.
+func TagsNotNil() predicate.UsageLog {
+	return predicate.UsageLog(sql.FieldNotNull(FieldTags))
+}
+
 // CreatedAtEQ applies the EQ predicate on the "created_at" field.
 func CreatedAtEQ(v time.Time) predicate.UsageLog {
 	return predicate.UsageLog(sql.FieldEQ(FieldCreatedAt, v))
@@ -85,6 +85,20 @@ func (_c *UsageLogCreate) SetNillableSubscriptionID(v *int64) *UsageLogCreate {
 	return _c
 }
 
+// SetOrganizationID sets the "organization_id" field.
+func (_c *UsageLogCreate) SetOrganizationID(v int64) *UsageLogCreate {
+	_c.mutation.SetOrganizationID(v)
+	return _c
+}
+
+// SetNillableOrganizationID sets the "organization_id" field if the given value is not nil.
+func (_c *UsageLogCreate) SetNillableOrganizationID(v *int64) *UsageLogCreate {
+	if v != nil {
+		_c.SetOrganizationID(*v)
+	}
+	return _c
+}
+
 // SetInputTokens sets the "input_tokens" field.
 func (_c *UsageLogCreate) SetInputTokens(v int) *UsageLogCreate {
 	_c.mutation.SetInputTokens(v)
@@ -225,6 +239,20 @@ func (_c *UsageLogCreate) SetNillableCacheReadCost(v *float64) *UsageLogCreate {
 	return _c
 }
 
+// SetCacheCreation1hCost sets the "cache_creation_1h_cost" field.
+func (_c *UsageLogCreate) SetCacheCreation1hCost(v float64) *UsageLogCreate {
+	_c.mutation.SetCacheCreation1hCost(v)
+	return _c
+}
+
+// SetNillableCacheCreation1hCost sets the "cache_creation_1h_cost" field if the given value is not nil.
+func (_c *UsageLogCreate) SetNillableCacheCreation1hCost(v *float64) *UsageLogCreate {
+	if v != nil {
+		_c.SetCacheCreation1hCost(*v)
+	}
+	return _c
+}
+
 // SetTotalCost sets the "total_cost" field.
 func (_c *UsageLogCreate) SetTotalCost(v float64) *UsageLogCreate {
 	_c.mutation.SetTotalCost(v)
@@ -281,6 +309,20 @@ func (_c *UsageLogCreate) SetNillableAccountRateMultiplier(v *float64) *UsageLog
 	return _c
 }
 
+// SetRateRule sets the "rate_rule" field.
+func (_c *UsageLogCreate) SetRateRule(v string) *UsageLogCreate {
+	_c.mutation.SetRateRule(v)
+	return _c
+}
+
+// SetNillableRateRule sets the "rate_rule" field if the given value is not nil.
+func (_c *UsageLogCreate) SetNillableRateRule(v *string) *UsageLogCreate {
+	if v != nil {
+		_c.SetRateRule(*v)
+	}
+	return _c
+}
+
 // SetBillingType sets the "billing_type" field.
 func (_c *UsageLogCreate) SetBillingType(v int8) *UsageLogCreate {
 	_c.mutation.SetBillingType(v)
@@ -337,6 +379,20 @@ func (_c *UsageLogCreate) SetNillableFirstTokenMs(v *int) *UsageLogCreate {
 	return _c
 }
 
+// SetHedged sets the "hedged" field.
+func (_c *UsageLogCreate) SetHedged(v bool) *UsageLogCreate {
+	_c.mutation.SetHedged(v)
+	return _c
+}
+
+// SetNillableHedged sets the "hedged" field if the given value is not nil.
+func (_c *UsageLogCreate) SetNillableHedged(v *bool) *UsageLogCreate {
+	if v != nil {
+		_c.SetHedged(*v)
+	}
+	return _c
+}
+
 // SetUserAgent sets the "user_agent" field.
 func (_c *UsageLogCreate) SetUserAgent(v string) *UsageLogCreate {
 	_c.mutation.SetUserAgent(v)
@@ -393,6 +449,12 @@ func (_c *UsageLogCreate) SetNillableImageSize(v *string) *UsageLogCreate {
 	return _c
 }
 
+// SetTags sets the "tags" field.
+func (_c *UsageLogCreate) SetTags(v map[string]string) *UsageLogCreate {
+	_c.mutation.SetTags(v)
+	return _c
+}
+
 // SetCreatedAt sets the "created_at" field.
 func (_c *UsageLogCreate) SetCreatedAt(v time.Time) *UsageLogCreate {
 	_c.mutation.SetCreatedAt(v)
@@ -507,6 +569,10 @@ func (_c *UsageLogCreate) defaults() {
 		v := usagelog.DefaultCacheReadCost
 		_c.mutation.SetCacheReadCost(v)
 	}
+	if _, ok := _c.mutation.CacheCreation1hCost(); !ok {
+		v := usagelog.DefaultCacheCreation1hCost
+		_c.mutation.SetCacheCreation1hCost(v)
+	}
 	if _, ok := _c.mutation.TotalCost(); !ok {
 		v := usagelog.DefaultTotalCost
 		_c.mutation.SetTotalCost(v)
@@ -527,6 +593,10 @@ func (_c *UsageLogCreate) defaults() {
 		v := usagelog.DefaultStream
 		_c.mutation.SetStream(v)
 	}
+	if _, ok := _c.mutation.Hedged(); !ok {
+		v := usagelog.DefaultHedged
+		_c.mutation.SetHedged(v)
+	}
 	if _, ok := _c.mutation.ImageCount(); !ok {
 		v := usagelog.DefaultImageCount
 		_c.mutation.SetImageCount(v)
@@ -594,6 +664,9 @@ func (_c *UsageLogCreate) check() error {
 	if _, ok := _c.mutation.CacheReadCost(); !ok {
 		return &ValidationError{Name: "cache_read_cost", err: errors.New(`ent: missing required field "UsageLog.cache_read_cost"`)}
 	}
+	if _, ok := _c.mutation.CacheCreation1hCost(); !ok {
+		return &ValidationError{Name: "cache_creation_1h_cost", err: errors.New(`ent: missing required field "UsageLog.cache_creation_1h_cost"`)}
+	}
 	if _, ok := _c.mutation.TotalCost(); !ok {
 		return &ValidationError{Name: "total_cost", err: errors.New(`ent: missing required field "UsageLog.total_cost"`)}
 	}
@@ -603,12 +676,20 @@ func (_c *UsageLogCreate) check() error {
 	if _, ok := _c.mutation.RateMultiplier(); !ok {
 		return &ValidationError{Name: "rate_multiplier", err: errors.New(`ent: missing required field "UsageLog.rate_multiplier"`)}
 	}
+	if v, ok := _c.mutation.RateRule(); ok {
+		if err := usagelog.RateRuleValidator(v); err != nil {
+			return &ValidationError{Name: "rate_rule", err: fmt.Errorf(`ent: validator failed for field "UsageLog.rate_rule": %w`, err)}
+		}
+	}
 	if _, ok := _c.mutation.BillingType(); !ok {
 		return &ValidationError{Name: "billing_type", err: errors.New(`ent: missing required field "UsageLog.billing_type"`)}
 	}
 	if _, ok := _c.mutation.Stream(); !ok {
 		return &ValidationError{Name: "stream", err: errors.New(`ent: missing required field "UsageLog.stream"`)}
 	}
+	if _, ok := _c.mutation.Hedged(); !ok {
+		return &ValidationError{Name: "hedged", err: errors.New(`ent: missing required field "UsageLog.hedged"`)}
+	}
 	if v, ok := _c.mutation.UserAgent(); ok {
 		if err := usagelog.UserAgentValidator(v); err != nil {
 			return &ValidationError{Name: "user_agent", err: fmt.Errorf(`ent: validator failed for field "UsageLog.user_agent": %w`, err)}
@@ -674,6 +755,10 @@ func (_c *UsageLogCreate) createSpec() (*UsageLog, *sqlgraph.CreateSpec) {
 		_spec.SetField(usagelog.FieldModel, field.TypeString, value)
 		_node.Model = value
 	}
+	if value, ok := _c.mutation.OrganizationID(); ok {
+		_spec.SetField(usagelog.FieldOrganizationID, field.TypeInt64, value)
+		_node.OrganizationID = &value
+	}
 	if value, ok := _c.mutation.InputTokens(); ok {
 		_spec.SetField(usagelog.FieldInputTokens, field.TypeInt, value)
 		_node.InputTokens = value
@@ -714,6 +799,10 @@ func (_c *UsageLogCreate) createSpec() (*UsageLog, *sqlgraph.CreateSpec) {
 		_spec.SetField(usagelog.FieldCacheReadCost, field.TypeFloat64, value)
 		_node.CacheReadCost = value
 	}
+	if value, ok := _c.mutation.CacheCreation1hCost(); ok {
+		_spec.SetField(usagelog.FieldCacheCreation1hCost, field.TypeFloat64, value)
+		_node.CacheCreation1hCost = value
+	}
 	if value, ok := _c.mutation.TotalCost(); ok {
 		_spec.SetField(usagelog.FieldTotalCost, field.TypeFloat64, value)
 		_node.TotalCost = value
@@ -730,6 +819,10 @@ func (_c *UsageLogCreate) createSpec() (*UsageLog, *sqlgraph.CreateSpec) {
 		_spec.SetField(usagelog.FieldAccountRateMultiplier, field.TypeFloat64, value)
 		_node.AccountRateMultiplier = &value
 	}
+	if value, ok := _c.mutation.RateRule(); ok {
+		_spec.SetField(usagelog.FieldRateRule, field.TypeString, value)
+		_node.RateRule = &value
+	}
 	if value, ok := _c.mutation.BillingType(); ok {
 		_spec.SetField(usagelog.FieldBillingType, field.TypeInt8, value)
 		_node.BillingType = value
@@ -746,6 +839,10 @@ func (_c *UsageLogCreate) createSpec() (*UsageLog, *sqlgraph.CreateSpec) {
 		_spec.SetField(usagelog.FieldFirstTokenMs, field.TypeInt, value)
 		_node.FirstTokenMs = &value
 	}
+	if value, ok := _c.mutation.Hedged(); ok {
+		_spec.SetField(usagelog.FieldHedged, field.TypeBool, value)
+		_node.Hedged = value
+	}
 	if value, ok := _c.mutation.UserAgent(); ok {
 		_spec.SetField(usagelog.FieldUserAgent, field.TypeString, value)
 		_node.UserAgent = &value
@@ -762,6 +859,10 @@ func (_c *UsageLogCreate) createSpec() (*UsageLog, *sqlgraph.CreateSpec) {
 		_spec.SetField(usagelog.FieldImageSize, field.TypeString, value)
 		_node.ImageSize = &value
 	}
+	if value, ok := _c.mutation.Tags(); ok {
+		_spec.SetField(usagelog.FieldTags, field.TypeJSON, value)
+		_node.Tags = value
+	}
 	if value, ok := _c.mutation.CreatedAt(); ok {
 		_spec.SetField(usagelog.FieldCreatedAt, field.TypeTime, value)
 		_node.CreatedAt = value
@@ -999,6 +1100,30 @@ func (u *UsageLogUpsert) ClearSubscriptionID() *UsageLogUpsert {
 	return u
 }
 
+// SetOrganizationID sets the "organization_id" field.
+func (u *UsageLogUpsert) SetOrganizationID(v int64) *UsageLogUpsert {
+	u.Set(usagelog.FieldOrganizationID, v)
+	return u
+}
+
+// UpdateOrganizationID sets the "organization_id" field to the value that was provided on create.
+func (u *UsageLogUpsert) UpdateOrganizationID() *UsageLogUpsert {
+	u.SetExcluded(usagelog.FieldOrganizationID)
+	return u
+}
+
+// AddOrganizationID adds v to the "organization_id" field.
+func (u *UsageLogUpsert) AddOrganizationID(v int64) *UsageLogUpsert {
+	u.Add(usagelog.FieldOrganizationID, v)
+	return u
+}
+
+// ClearOrganizationID clears the value of the "organization_id" field.
+func (u *UsageLogUpsert) ClearOrganizationID() *UsageLogUpsert {
+	u.SetNull(usagelog.FieldOrganizationID)
+	return u
+}
+
 // SetInputTokens sets the "input_tokens" field.
 func (u *UsageLogUpsert) SetInputTokens(v int) *UsageLogUpsert {
 	u.Set(usagelog.FieldInputTokens, v)
@@ -1179,6 +1304,24 @@ func (u *UsageLogUpsert) AddCacheReadCost(v float64) *UsageLogUpsert {
 	return u
 }
 
+// SetCacheCreation1hCost sets the "cache_creation_1h_cost" field.
+func (u *UsageLogUpsert) SetCacheCreation1hCost(v float64) *UsageLogUpsert {
+	u.Set(usagelog.FieldCacheCreation1hCost, v)
+	return u
+}
+
+// UpdateCacheCreation1hCost sets the "cache_creation_1h_cost" field to the value that was provided on create.
+func (u *UsageLogUpsert) UpdateCacheCreation1hCost() *UsageLogUpsert {
+	u.SetExcluded(usagelog.FieldCacheCreation1hCost)
+	return u
+}
+
+// AddCacheCreation1hCost adds v to the "cache_creation_1h_cost" field.
+func (u *UsageLogUpsert) AddCacheCreation1hCost(v float64) *UsageLogUpsert {
+	u.Add(usagelog.FieldCacheCreation1hCost, v)
+	return u
+}
+
 // SetTotalCost sets the "total_cost" field.
 func (u *UsageLogUpsert) SetTotalCost(v float64) *UsageLogUpsert {
 	u.Set(usagelog.FieldTotalCost, v)
@@ -1257,6 +1400,24 @@ func (u *UsageLogUpsert) ClearAccountRateMultiplier() *UsageLogUpsert {
 	return u
 }
 
+// SetRateRule sets the "rate_rule" field.
+func (u *UsageLogUpsert) SetRateRule(v string) *UsageLogUpsert {
+	u.Set(usagelog.FieldRateRule, v)
+	return u
+}
+
+// UpdateRateRule sets the "rate_rule" field to the value that was provided on create.
+func (u *UsageLogUpsert) UpdateRateRule() *UsageLogUpsert {
+	u.SetExcluded(usagelog.FieldRateRule)
+	return u
+}
+
+// ClearRateRule clears the value of the "rate_rule" field.
+func (u *UsageLogUpsert) ClearRateRule() *UsageLogUpsert {
+	u.SetNull(usagelog.FieldRateRule)
+	return u
+}
+
 // SetBillingType sets the "billing_type" field.
 func (u *UsageLogUpsert) SetBillingType(v int8) *UsageLogUpsert {
 	u.Set(usagelog.FieldBillingType, v)
@@ -1335,6 +1496,18 @@ func (u *UsageLogUpsert) ClearFirstTokenMs() *UsageLogUpsert {
 	return u
 }
 
+// SetHedged sets the "hedged" field.
+func (u *UsageLogUpsert) SetHedged(v bool) *UsageLogUpsert {
+	u.Set(usagelog.FieldHedged, v)
+	return u
+}
+
+// UpdateHedged sets the "hedged" field to the value that was provided on create.
+func (u *UsageLogUpsert) UpdateHedged() *UsageLogUpsert {
+	u.SetExcluded(usagelog.FieldHedged)
+	return u
+}
+
 // SetUserAgent sets the "user_agent" field.
 func (u *UsageLogUpsert) SetUserAgent(v string) *UsageLogUpsert {
 	u.Set(usagelog.FieldUserAgent, v)
@@ -1407,6 +1580,24 @@ func (u *UsageLogUpsert) ClearImageSize() *UsageLogUpsert {
 	return u
 }
 
+// SetTags sets the "tags" field.
+func (u *UsageLogUpsert) SetTags(v map[string]string) *UsageLogUpsert {
+	u.Set(usagelog.FieldTags, v)
+	return u
+}
+
+// UpdateTags sets the "tags" field to the value that was provided on create.
+func (u *UsageLogUpsert) UpdateTags() *UsageLogUpsert {
+	u.SetExcluded(usagelog.FieldTags)
+	return u
+}
+
+// ClearTags clears the value of the "tags" field.
+func (u *UsageLogUpsert) ClearTags() *UsageLogUpsert {
+	u.SetNull(usagelog.FieldTags)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -1564,6 +1755,34 @@ func (u *UsageLogUpsertOne) ClearSubscriptionID() *UsageLogUpsertOne {
 	})
 }
 
+// SetOrganizationID sets the "organization_id" field.
+func (u *UsageLogUpsertOne) SetOrganizationID(v int64) *UsageLogUpsertOne {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.SetOrganizationID(v)
+	})
+}
+
+// AddOrganizationID adds v to the "organization_id" field.
+func (u *UsageLogUpsertOne) AddOrganizationID(v int64) *UsageLogUpsertOne {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.AddOrganizationID(v)
+	})
+}
+
+// UpdateOrganizationID sets the "organization_id" field to the value that was provided on create.
+func (u *UsageLogUpsertOne) UpdateOrganizationID() *UsageLogUpsertOne {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.UpdateOrganizationID()
+	})
+}
+
+// ClearOrganizationID clears the value of the "organization_id" field.
+func (u *UsageLogUpsertOne) ClearOrganizationID() *UsageLogUpsertOne {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.ClearOrganizationID()
+	})
+}
+
 // SetInputTokens sets the "input_tokens" field.
 func (u *UsageLogUpsertOne) SetInputTokens(v int) *UsageLogUpsertOne {
 	return u.Update(func(s *UsageLogUpsert) {
@@ -1774,6 +1993,27 @@ func (u *UsageLogUpsertOne) UpdateCacheReadCost() *UsageLogUpsertOne {
 	})
 }
 
+// SetCacheCreation1hCost sets the "cache_creation_1h_cost" field.
+func (u *UsageLogUpsertOne) SetCacheCreation1hCost(v float64) *UsageLogUpsertOne {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.SetCacheCreation1hCost(v)
+	})
+}
+
+// AddCacheCreation1hCost adds v to the "cache_creation_1h_cost" field.
+func (u *UsageLogUpsertOne) AddCacheCreation1hCost(v float64) *UsageLogUpsertOne {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.AddCacheCreation1hCost(v)
+	})
+}
+
+// UpdateCacheCreation1hCost sets the "cache_creation_1h_cost" field to the value that was provided on create.
+func (u *UsageLogUpsertOne) UpdateCacheCreation1hCost() *UsageLogUpsertOne {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.UpdateCacheCreation1hCost()
+	})
+}
+
 // SetTotalCost sets the "total_cost" field.
 func (u *UsageLogUpsertOne) SetTotalCost(v float64) *UsageLogUpsertOne {
 	return u.Update(func(s *UsageLogUpsert) {
@@ -1865,6 +2105,27 @@ func (u *UsageLogUpsertOne) ClearAccountRateMultiplier() *UsageLogUpsertOne {
 	})
 }
 
+// SetRateRule sets the "rate_rule" field.
+func (u *UsageLogUpsertOne) SetRateRule(v string) *UsageLogUpsertOne {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.SetRateRule(v)
+	})
+}
+
+// UpdateRateRule sets the "rate_rule" field to the value that was provided on create.
+func (u *UsageLogUpsertOne) UpdateRateRule() *UsageLogUpsertOne {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.UpdateRateRule()
+	})
+}
+
+// ClearRateRule clears the value of the "rate_rule" field.
+func (u *UsageLogUpsertOne) ClearRateRule() *UsageLogUpsertOne {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.ClearRateRule()
+	})
+}
+
 // SetBillingType sets the "billing_type" field.
 func (u *UsageLogUpsertOne) SetBillingType(v int8) *UsageLogUpsertOne {
 	return u.Update(func(s *UsageLogUpsert) {
@@ -1956,6 +2217,20 @@ func (u *UsageLogUpsertOne) ClearFirstTokenMs() *UsageLogUpsertOne {
 	})
 }
 
+// SetHedged sets the "hedged" field.
+func (u *UsageLogUpsertOne) SetHedged(v bool) *UsageLogUpsertOne {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.SetHedged(v)
+	})
+}
+
+// UpdateHedged sets the "hedged" field to the value that was provided on create.
+func (u *UsageLogUpsertOne) UpdateHedged() *UsageLogUpsertOne {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.UpdateHedged()
+	})
+}
+
 // SetUserAgent sets the "user_agent" field.
 func (u *UsageLogUpsertOne) SetUserAgent(v string) *UsageLogUpsertOne {
 	return u.Update(func(s *UsageLogUpsert) {
@@ -2040,6 +2315,27 @@ func (u *UsageLogUpsertOne) ClearImageSize() *UsageLogUpsertOne {
 	})
 }
 
+// SetTags sets the "tags" field.
+func (u *UsageLogUpsertOne) SetTags(v map[string]string) *UsageLogUpsertOne {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.SetTags(v)
+	})
+}
+
+// UpdateTags sets the "tags" field to the value that was provided on create.
+func (u *UsageLogUpsertOne) UpdateTags() *UsageLogUpsertOne {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.UpdateTags()
+	})
+}
+
+// ClearTags clears the value of the "tags" field.
+func (u *UsageLogUpsertOne) ClearTags() *UsageLogUpsertOne {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.ClearTags()
+	})
+}
+
 // Exec executes the query.
 func (u *UsageLogUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -2363,6 +2659,34 @@ func (u *UsageLogUpsertBulk) ClearSubscriptionID() *UsageLogUpsertBulk {
 	})
 }
 
+// SetOrganizationID sets the "organization_id" field.
+func (u *UsageLogUpsertBulk) SetOrganizationID(v int64) *UsageLogUpsertBulk {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.SetOrganizationID(v)
+	})
+}
+
+// AddOrganizationID adds v to the "organization_id" field.
+func (u *UsageLogUpsertBulk) AddOrganizationID(v int64) *UsageLogUpsertBulk {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.AddOrganizationID(v)
+	})
+}
+
+// UpdateOrganizationID sets the "organization_id" field to the value that was provided on create.
+func (u *UsageLogUpsertBulk) UpdateOrganizationID() *UsageLogUpsertBulk {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.UpdateOrganizationID()
+	})
+}
+
+// ClearOrganizationID clears the value of the "organization_id" field.
+func (u *UsageLogUpsertBulk) ClearOrganizationID() *UsageLogUpsertBulk {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.ClearOrganizationID()
+	})
+}
+
 // SetInputTokens sets the "input_tokens" field.
 func (u *UsageLogUpsertBulk) SetInputTokens(v int) *UsageLogUpsertBulk {
 	return u.Update(func(s *UsageLogUpsert) {
@@ -2573,6 +2897,27 @@ func (u *UsageLogUpsertBulk) UpdateCacheReadCost() *UsageLogUpsertBulk {
 	})
 }
 
+// SetCacheCreation1hCost sets the "cache_creation_1h_cost" field.
+func (u *UsageLogUpsertBulk) SetCacheCreation1hCost(v float64) *UsageLogUpsertBulk {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.SetCacheCreation1hCost(v)
+	})
+}
+
+// AddCacheCreation1hCost adds v to the "cache_creation_1h_cost" field.
+func (u *UsageLogUpsertBulk) AddCacheCreation1hCost(v float64) *UsageLogUpsertBulk {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.AddCacheCreation1hCost(v)
+	})
+}
+
+// UpdateCacheCreation1hCost sets the "cache_creation_1h_cost" field to the value that was provided on create.
+func (u *UsageLogUpsertBulk) UpdateCacheCreation1hCost() *UsageLogUpsertBulk {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.UpdateCacheCreation1hCost()
+	})
+}
+
 // SetTotalCost sets the "total_cost" field.
 func (u *UsageLogUpsertBulk) SetTotalCost(v float64) *UsageLogUpsertBulk {
 	return u.Update(func(s *UsageLogUpsert) {
@@ -2664,6 +3009,27 @@ func (u *UsageLogUpsertBulk) ClearAccountRateMultiplier() *UsageLogUpsertBulk {
 	})
 }
 
+// SetRateRule sets the "rate_rule" field.
+func (u *UsageLogUpsertBulk) SetRateRule(v string) *UsageLogUpsertBulk {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.SetRateRule(v)
+	})
+}
+
+// UpdateRateRule sets the "rate_rule" field to the value that was provided on create.
+func (u *UsageLogUpsertBulk) UpdateRateRule() *UsageLogUpsertBulk {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.UpdateRateRule()
+	})
+}
+
+// ClearRateRule clears the value of the "rate_rule" field.
+func (u *UsageLogUpsertBulk) ClearRateRule() *UsageLogUpsertBulk {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.ClearRateRule()
+	})
+}
+
 // SetBillingType sets the "billing_type" field.
 func (u *UsageLogUpsertBulk) SetBillingType(v int8) *UsageLogUpsertBulk {
 	return u.Update(func(s *UsageLogUpsert) {
@@ -2755,6 +3121,20 @@ func (u *UsageLogUpsertBulk) ClearFirstTokenMs() *UsageLogUpsertBulk {
 	})
 }
 
+// SetHedged sets the "hedged" field.
+func (u *UsageLogUpsertBulk) SetHedged(v bool) *UsageLogUpsertBulk {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.SetHedged(v)
+	})
+}
+
+// UpdateHedged sets the "hedged" field to the value that was provided on create.
+func (u *UsageLogUpsertBulk) UpdateHedged() *UsageLogUpsertBulk {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.UpdateHedged()
+	})
+}
+
 // SetUserAgent sets the "user_agent" field.
 func (u *UsageLogUpsertBulk) SetUserAgent(v string) *UsageLogUpsertBulk {
 	return u.Update(func(s *UsageLogUpsert) {
@@ -2839,6 +3219,27 @@ func (u *UsageLogUpsertBulk) ClearImageSize() *UsageLogUpsertBulk {
 	})
 }
 
+// SetTags sets the "tags" field.
+func (u *UsageLogUpsertBulk) SetTags(v map[string]string) *UsageLogUpsertBulk {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.SetTags(v)
+	})
+}
+
+// UpdateTags sets the "tags" field to the value that was provided on create.
+func (u *UsageLogUpsertBulk) UpdateTags() *UsageLogUpsertBulk {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.UpdateTags()
+	})
+}
+
+// ClearTags clears the value of the "tags" field.
+func (u *UsageLogUpsertBulk) ClearTags() *UsageLogUpsertBulk {
+	return u.Update(func(s *UsageLogUpsert) {
+		s.ClearTags()
+	})
+}
+
 // Exec executes the query.
 func (u *UsageLogUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
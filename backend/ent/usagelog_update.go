@@ -142,6 +142,33 @@ func (_u *UsageLogUpdate) ClearSubscriptionID() *UsageLogUpdate {
 	return _u
 }
 
+// SetOrganizationID sets the "organization_id" field.
+func (_u *UsageLogUpdate) SetOrganizationID(v int64) *UsageLogUpdate {
+	_u.mutation.ResetOrganizationID()
+	_u.mutation.SetOrganizationID(v)
+	return _u
+}
+
+// SetNillableOrganizationID sets the "organization_id" field if the given value is not nil.
+func (_u *UsageLogUpdate) SetNillableOrganizationID(v *int64) *UsageLogUpdate {
+	if v != nil {
+		_u.SetOrganizationID(*v)
+	}
+	return _u
+}
+
+// AddOrganizationID adds value to the "organization_id" field.
+func (_u *UsageLogUpdate) AddOrganizationID(v int64) *UsageLogUpdate {
+	_u.mutation.AddOrganizationID(v)
+	return _u
+}
+
+// ClearOrganizationID clears the value of the "organization_id" field.
+func (_u *UsageLogUpdate) ClearOrganizationID() *UsageLogUpdate {
+	_u.mutation.ClearOrganizationID()
+	return _u
+}
+
 // SetInputTokens sets the "input_tokens" field.
 func (_u *UsageLogUpdate) SetInputTokens(v int) *UsageLogUpdate {
 	_u.mutation.ResetInputTokens()
@@ -352,6 +379,27 @@ func (_u *UsageLogUpdate) AddCacheReadCost(v float64) *UsageLogUpdate {
 	return _u
 }
 
+// SetCacheCreation1hCost sets the "cache_creation_1h_cost" field.
+func (_u *UsageLogUpdate) SetCacheCreation1hCost(v float64) *UsageLogUpdate {
+	_u.mutation.ResetCacheCreation1hCost()
+	_u.mutation.SetCacheCreation1hCost(v)
+	return _u
+}
+
+// SetNillableCacheCreation1hCost sets the "cache_creation_1h_cost" field if the given value is not nil.
+func (_u *UsageLogUpdate) SetNillableCacheCreation1hCost(v *float64) *UsageLogUpdate {
+	if v != nil {
+		_u.SetCacheCreation1hCost(*v)
+	}
+	return _u
+}
+
+// AddCacheCreation1hCost adds value to the "cache_creation_1h_cost" field.
+func (_u *UsageLogUpdate) AddCacheCreation1hCost(v float64) *UsageLogUpdate {
+	_u.mutation.AddCacheCreation1hCost(v)
+	return _u
+}
+
 // SetTotalCost sets the "total_cost" field.
 func (_u *UsageLogUpdate) SetTotalCost(v float64) *UsageLogUpdate {
 	_u.mutation.ResetTotalCost()
@@ -442,6 +490,26 @@ func (_u *UsageLogUpdate) ClearAccountRateMultiplier() *UsageLogUpdate {
 	return _u
 }
 
+// SetRateRule sets the "rate_rule" field.
+func (_u *UsageLogUpdate) SetRateRule(v string) *UsageLogUpdate {
+	_u.mutation.SetRateRule(v)
+	return _u
+}
+
+// SetNillableRateRule sets the "rate_rule" field if the given value is not nil.
+func (_u *UsageLogUpdate) SetNillableRateRule(v *string) *UsageLogUpdate {
+	if v != nil {
+		_u.SetRateRule(*v)
+	}
+	return _u
+}
+
+// ClearRateRule clears the value of the "rate_rule" field.
+func (_u *UsageLogUpdate) ClearRateRule() *UsageLogUpdate {
+	_u.mutation.ClearRateRule()
+	return _u
+}
+
 // SetBillingType sets the "billing_type" field.
 func (_u *UsageLogUpdate) SetBillingType(v int8) *UsageLogUpdate {
 	_u.mutation.ResetBillingType()
@@ -531,6 +599,20 @@ func (_u *UsageLogUpdate) ClearFirstTokenMs() *UsageLogUpdate {
 	return _u
 }
 
+// SetHedged sets the "hedged" field.
+func (_u *UsageLogUpdate) SetHedged(v bool) *UsageLogUpdate {
+	_u.mutation.SetHedged(v)
+	return _u
+}
+
+// SetNillableHedged sets the "hedged" field if the given value is not nil.
+func (_u *UsageLogUpdate) SetNillableHedged(v *bool) *UsageLogUpdate {
+	if v != nil {
+		_u.SetHedged(*v)
+	}
+	return _u
+}
+
 // SetUserAgent sets the "user_agent" field.
 func (_u *UsageLogUpdate) SetUserAgent(v string) *UsageLogUpdate {
 	_u.mutation.SetUserAgent(v)
@@ -612,6 +694,18 @@ func (_u *UsageLogUpdate) ClearImageSize() *UsageLogUpdate {
 	return _u
 }
 
+// SetTags sets the "tags" field.
+func (_u *UsageLogUpdate) SetTags(v map[string]string) *UsageLogUpdate {
+	_u.mutation.SetTags(v)
+	return _u
+}
+
+// ClearTags clears the value of the "tags" field.
+func (_u *UsageLogUpdate) ClearTags() *UsageLogUpdate {
+	_u.mutation.ClearTags()
+	return _u
+}
+
 // SetUser sets the "user" edge to the User entity.
 func (_u *UsageLogUpdate) SetUser(v *User) *UsageLogUpdate {
 	return _u.SetUserID(v.ID)
@@ -711,6 +805,11 @@ func (_u *UsageLogUpdate) check() error {
 			return &ValidationError{Name: "model", err: fmt.Errorf(`ent: validator failed for field "UsageLog.model": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.RateRule(); ok {
+		if err := usagelog.RateRuleValidator(v); err != nil {
+			return &ValidationError{Name: "rate_rule", err: fmt.Errorf(`ent: validator failed for field "UsageLog.rate_rule": %w`, err)}
+		}
+	}
 	if v, ok := _u.mutation.UserAgent(); ok {
 		if err := usagelog.UserAgentValidator(v); err != nil {
 			return &ValidationError{Name: "user_agent", err: fmt.Errorf(`ent: validator failed for field "UsageLog.user_agent": %w`, err)}
@@ -756,6 +855,15 @@ func (_u *UsageLogUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if value, ok := _u.mutation.Model(); ok {
 		_spec.SetField(usagelog.FieldModel, field.TypeString, value)
 	}
+	if value, ok := _u.mutation.OrganizationID(); ok {
+		_spec.SetField(usagelog.FieldOrganizationID, field.TypeInt64, value)
+	}
+	if value, ok := _u.mutation.AddedOrganizationID(); ok {
+		_spec.AddField(usagelog.FieldOrganizationID, field.TypeInt64, value)
+	}
+	if _u.mutation.OrganizationIDCleared() {
+		_spec.ClearField(usagelog.FieldOrganizationID, field.TypeInt64)
+	}
 	if value, ok := _u.mutation.InputTokens(); ok {
 		_spec.SetField(usagelog.FieldInputTokens, field.TypeInt, value)
 	}
@@ -816,6 +924,12 @@ func (_u *UsageLogUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if value, ok := _u.mutation.AddedCacheReadCost(); ok {
 		_spec.AddField(usagelog.FieldCacheReadCost, field.TypeFloat64, value)
 	}
+	if value, ok := _u.mutation.CacheCreation1hCost(); ok {
+		_spec.SetField(usagelog.FieldCacheCreation1hCost, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedCacheCreation1hCost(); ok {
+		_spec.AddField(usagelog.FieldCacheCreation1hCost, field.TypeFloat64, value)
+	}
 	if value, ok := _u.mutation.TotalCost(); ok {
 		_spec.SetField(usagelog.FieldTotalCost, field.TypeFloat64, value)
 	}
@@ -843,6 +957,12 @@ func (_u *UsageLogUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if _u.mutation.AccountRateMultiplierCleared() {
 		_spec.ClearField(usagelog.FieldAccountRateMultiplier, field.TypeFloat64)
 	}
+	if value, ok := _u.mutation.RateRule(); ok {
+		_spec.SetField(usagelog.FieldRateRule, field.TypeString, value)
+	}
+	if _u.mutation.RateRuleCleared() {
+		_spec.ClearField(usagelog.FieldRateRule, field.TypeString)
+	}
 	if value, ok := _u.mutation.BillingType(); ok {
 		_spec.SetField(usagelog.FieldBillingType, field.TypeInt8, value)
 	}
@@ -870,6 +990,9 @@ func (_u *UsageLogUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if _u.mutation.FirstTokenMsCleared() {
 		_spec.ClearField(usagelog.FieldFirstTokenMs, field.TypeInt)
 	}
+	if value, ok := _u.mutation.Hedged(); ok {
+		_spec.SetField(usagelog.FieldHedged, field.TypeBool, value)
+	}
 	if value, ok := _u.mutation.UserAgent(); ok {
 		_spec.SetField(usagelog.FieldUserAgent, field.TypeString, value)
 	}
@@ -894,6 +1017,12 @@ func (_u *UsageLogUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if _u.mutation.ImageSizeCleared() {
 		_spec.ClearField(usagelog.FieldImageSize, field.TypeString)
 	}
+	if value, ok := _u.mutation.Tags(); ok {
+		_spec.SetField(usagelog.FieldTags, field.TypeJSON, value)
+	}
+	if _u.mutation.TagsCleared() {
+		_spec.ClearField(usagelog.FieldTags, field.TypeJSON)
+	}
 	if _u.mutation.UserCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -1169,6 +1298,33 @@ func (_u *UsageLogUpdateOne) ClearSubscriptionID() *UsageLogUpdateOne {
 	return _u
 }
 
+// SetOrganizationID sets the "organization_id" field.
+func (_u *UsageLogUpdateOne) SetOrganizationID(v int64) *UsageLogUpdateOne {
+	_u.mutation.ResetOrganizationID()
+	_u.mutation.SetOrganizationID(v)
+	return _u
+}
+
+// SetNillableOrganizationID sets the "organization_id" field if the given value is not nil.
+func (_u *UsageLogUpdateOne) SetNillableOrganizationID(v *int64) *UsageLogUpdateOne {
+	if v != nil {
+		_u.SetOrganizationID(*v)
+	}
+	return _u
+}
+
+// AddOrganizationID adds value to the "organization_id" field.
+func (_u *UsageLogUpdateOne) AddOrganizationID(v int64) *UsageLogUpdateOne {
+	_u.mutation.AddOrganizationID(v)
+	return _u
+}
+
+// ClearOrganizationID clears the value of the "organization_id" field.
+func (_u *UsageLogUpdateOne) ClearOrganizationID() *UsageLogUpdateOne {
+	_u.mutation.ClearOrganizationID()
+	return _u
+}
+
 // SetInputTokens sets the "input_tokens" field.
 func (_u *UsageLogUpdateOne) SetInputTokens(v int) *UsageLogUpdateOne {
 	_u.mutation.ResetInputTokens()
@@ -1379,6 +1535,27 @@ func (_u *UsageLogUpdateOne) AddCacheReadCost(v float64) *UsageLogUpdateOne {
 	return _u
 }
 
+// SetCacheCreation1hCost sets the "cache_creation_1h_cost" field.
+func (_u *UsageLogUpdateOne) SetCacheCreation1hCost(v float64) *UsageLogUpdateOne {
+	_u.mutation.ResetCacheCreation1hCost()
+	_u.mutation.SetCacheCreation1hCost(v)
+	return _u
+}
+
+// SetNillableCacheCreation1hCost sets the "cache_creation_1h_cost" field if the given value is not nil.
+func (_u *UsageLogUpdateOne) SetNillableCacheCreation1hCost(v *float64) *UsageLogUpdateOne {
+	if v != nil {
+		_u.SetCacheCreation1hCost(*v)
+	}
+	return _u
+}
+
+// AddCacheCreation1hCost adds value to the "cache_creation_1h_cost" field.
+func (_u *UsageLogUpdateOne) AddCacheCreation1hCost(v float64) *UsageLogUpdateOne {
+	_u.mutation.AddCacheCreation1hCost(v)
+	return _u
+}
+
 // SetTotalCost sets the "total_cost" field.
 func (_u *UsageLogUpdateOne) SetTotalCost(v float64) *UsageLogUpdateOne {
 	_u.mutation.ResetTotalCost()
@@ -1469,6 +1646,26 @@ func (_u *UsageLogUpdateOne) ClearAccountRateMultiplier() *UsageLogUpdateOne {
 	return _u
 }
 
+// SetRateRule sets the "rate_rule" field.
+func (_u *UsageLogUpdateOne) SetRateRule(v string) *UsageLogUpdateOne {
+	_u.mutation.SetRateRule(v)
+	return _u
+}
+
+// SetNillableRateRule sets the "rate_rule" field if the given value is not nil.
+func (_u *UsageLogUpdateOne) SetNillableRateRule(v *string) *UsageLogUpdateOne {
+	if v != nil {
+		_u.SetRateRule(*v)
+	}
+	return _u
+}
+
+// ClearRateRule clears the value of the "rate_rule" field.
+func (_u *UsageLogUpdateOne) ClearRateRule() *UsageLogUpdateOne {
+	_u.mutation.ClearRateRule()
+	return _u
+}
+
 // SetBillingType sets the "billing_type" field.
 func (_u *UsageLogUpdateOne) SetBillingType(v int8) *UsageLogUpdateOne {
 	_u.mutation.ResetBillingType()
@@ -1558,6 +1755,20 @@ func (_u *UsageLogUpdateOne) ClearFirstTokenMs() *UsageLogUpdateOne {
 	return _u
 }
 
+// SetHedged sets the "hedged" field.
+func (_u *UsageLogUpdateOne) SetHedged(v bool) *UsageLogUpdateOne {
+	_u.mutation.SetHedged(v)
+	return _u
+}
+
+// SetNillableHedged sets the "hedged" field if the given value is not nil.
+func (_u *UsageLogUpdateOne) SetNillableHedged(v *bool) *UsageLogUpdateOne {
+	if v != nil {
+		_u.SetHedged(*v)
+	}
+	return _u
+}
+
 // SetUserAgent sets the "user_agent" field.
 func (_u *UsageLogUpdateOne) SetUserAgent(v string) *UsageLogUpdateOne {
 	_u.mutation.SetUserAgent(v)
@@ -1639,6 +1850,18 @@ func (_u *UsageLogUpdateOne) ClearImageSize() *UsageLogUpdateOne {
 	return _u
 }
 
+// SetTags sets the "tags" field.
+func (_u *UsageLogUpdateOne) SetTags(v map[string]string) *UsageLogUpdateOne {
+	_u.mutation.SetTags(v)
+	return _u
+}
+
+// ClearTags clears the value of the "tags" field.
+func (_u *UsageLogUpdateOne) ClearTags() *UsageLogUpdateOne {
+	_u.mutation.ClearTags()
+	return _u
+}
+
 // SetUser sets the "user" edge to the User entity.
 func (_u *UsageLogUpdateOne) SetUser(v *User) *UsageLogUpdateOne {
 	return _u.SetUserID(v.ID)
@@ -1751,6 +1974,11 @@ func (_u *UsageLogUpdateOne) check() error {
 			return &ValidationError{Name: "model", err: fmt.Errorf(`ent: validator failed for field "UsageLog.model": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.RateRule(); ok {
+		if err := usagelog.RateRuleValidator(v); err != nil {
+			return &ValidationError{Name: "rate_rule", err: fmt.Errorf(`ent: validator failed for field "UsageLog.rate_rule": %w`, err)}
+		}
+	}
 	if v, ok := _u.mutation.UserAgent(); ok {
 		if err := usagelog.UserAgentValidator(v); err != nil {
 			return &ValidationError{Name: "user_agent", err: fmt.Errorf(`ent: validator failed for field "UsageLog.user_agent": %w`, err)}
@@ -1813,6 +2041,15 @@ func (_u *UsageLogUpdateOne) sqlSave(ctx context.Context) (_node *UsageLog, err
 	if value, ok := _u.mutation.Model(); ok {
 		_spec.SetField(usagelog.FieldModel, field.TypeString, value)
 	}
+	if value, ok := _u.mutation.OrganizationID(); ok {
+		_spec.SetField(usagelog.FieldOrganizationID, field.TypeInt64, value)
+	}
+	if value, ok := _u.mutation.AddedOrganizationID(); ok {
+		_spec.AddField(usagelog.FieldOrganizationID, field.TypeInt64, value)
+	}
+	if _u.mutation.OrganizationIDCleared() {
+		_spec.ClearField(usagelog.FieldOrganizationID, field.TypeInt64)
+	}
 	if value, ok := _u.mutation.InputTokens(); ok {
 		_spec.SetField(usagelog.FieldInputTokens, field.TypeInt, value)
 	}
@@ -1873,6 +2110,12 @@ func (_u *UsageLogUpdateOne) sqlSave(ctx context.Context) (_node *UsageLog, err
 	if value, ok := _u.mutation.AddedCacheReadCost(); ok {
 		_spec.AddField(usagelog.FieldCacheReadCost, field.TypeFloat64, value)
 	}
+	if value, ok := _u.mutation.CacheCreation1hCost(); ok {
+		_spec.SetField(usagelog.FieldCacheCreation1hCost, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedCacheCreation1hCost(); ok {
+		_spec.AddField(usagelog.FieldCacheCreation1hCost, field.TypeFloat64, value)
+	}
 	if value, ok := _u.mutation.TotalCost(); ok {
 		_spec.SetField(usagelog.FieldTotalCost, field.TypeFloat64, value)
 	}
@@ -1900,6 +2143,12 @@ func (_u *UsageLogUpdateOne) sqlSave(ctx context.Context) (_node *UsageLog, err
 	if _u.mutation.AccountRateMultiplierCleared() {
 		_spec.ClearField(usagelog.FieldAccountRateMultiplier, field.TypeFloat64)
 	}
+	if value, ok := _u.mutation.RateRule(); ok {
+		_spec.SetField(usagelog.FieldRateRule, field.TypeString, value)
+	}
+	if _u.mutation.RateRuleCleared() {
+		_spec.ClearField(usagelog.FieldRateRule, field.TypeString)
+	}
 	if value, ok := _u.mutation.BillingType(); ok {
 		_spec.SetField(usagelog.FieldBillingType, field.TypeInt8, value)
 	}
@@ -1927,6 +2176,9 @@ func (_u *UsageLogUpdateOne) sqlSave(ctx context.Context) (_node *UsageLog, err
 	if _u.mutation.FirstTokenMsCleared() {
 		_spec.ClearField(usagelog.FieldFirstTokenMs, field.TypeInt)
 	}
+	if value, ok := _u.mutation.Hedged(); ok {
+		_spec.SetField(usagelog.FieldHedged, field.TypeBool, value)
+	}
 	if value, ok := _u.mutation.UserAgent(); ok {
 		_spec.SetField(usagelog.FieldUserAgent, field.TypeString, value)
 	}
@@ -1951,6 +2203,12 @@ func (_u *UsageLogUpdateOne) sqlSave(ctx context.Context) (_node *UsageLog, err
 	if _u.mutation.ImageSizeCleared() {
 		_spec.ClearField(usagelog.FieldImageSize, field.TypeString)
 	}
+	if value, ok := _u.mutation.Tags(); ok {
+		_spec.SetField(usagelog.FieldTags, field.TypeJSON, value)
+	}
+	if _u.mutation.TagsCleared() {
+		_spec.ClearField(usagelog.FieldTags, field.TypeJSON)
+	}
 	if _u.mutation.UserCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -110,6 +110,11 @@ func Notes(v string) predicate.User {
 	return predicate.User(sql.FieldEQ(FieldNotes, v))
 }
 
+// ParentUserID applies equality check predicate on the "parent_user_id" field. It's identical to ParentUserIDEQ.
+func ParentUserID(v int64) predicate.User {
+	return predicate.User(sql.FieldEQ(FieldParentUserID, v))
+}
+
 // CreatedAtEQ applies the EQ predicate on the "created_at" field.
 func CreatedAtEQ(v time.Time) predicate.User {
 	return predicate.User(sql.FieldEQ(FieldCreatedAt, v))
@@ -710,6 +715,56 @@ func NotesContainsFold(v string) predicate.User {
 	return predicate.User(sql.FieldContainsFold(FieldNotes, v))
 }
 
+// ParentUserIDEQ applies the EQ predicate on the "parent_user_id" field.
+func ParentUserIDEQ(v int64) predicate.User {
+	return predicate.User(sql.FieldEQ(FieldParentUserID, v))
+}
+
+// ParentUserIDNEQ applies the NEQ predicate on the "parent_user_id" field.
+func ParentUserIDNEQ(v int64) predicate.User {
+	return predicate.User(sql.FieldNEQ(FieldParentUserID, v))
+}
+
+// ParentUserIDIn applies the In predicate on the "parent_user_id" field.
+func ParentUserIDIn(vs ...int64) predicate.User {
+	return predicate.User(sql.FieldIn(FieldParentUserID, vs...))
+}
+
+// ParentUserIDNotIn applies the NotIn predicate on the "parent_user_id" field.
+func ParentUserIDNotIn(vs ...int64) predicate.User {
+	return predicate.User(sql.FieldNotIn(FieldParentUserID, vs...))
+}
+
+// ParentUserIDGT applies the GT predicate on the "parent_user_id" field.
+func ParentUserIDGT(v int64) predicate.User {
+	return predicate.User(sql.FieldGT(FieldParentUserID, v))
+}
+
+// ParentUserIDGTE applies the GTE predicate on the "parent_user_id" field.
+func ParentUserIDGTE(v int64) predicate.User {
+	return predicate.User(sql.FieldGTE(FieldParentUserID, v))
+}
+
+// ParentUserIDLT applies the LT predicate on the "parent_user_id" field.
+func ParentUserIDLT(v int64) predicate.User {
+	return predicate.User(sql.FieldLT(FieldParentUserID, v))
+}
+
+// ParentUserIDLTE applies the LTE predicate on the "parent_user_id" field.
+func ParentUserIDLTE(v int64) predicate.User {
+	return predicate.User(sql.FieldLTE(FieldParentUserID, v))
+}
+
+// ParentUserIDIsNil applies the IsNil predicate on the "parent_user_id" field.
+func ParentUserIDIsNil() predicate.User {
+	return predicate.User(sql.FieldIsNull(FieldParentUserID))
+}
+
+// ParentUserIDNotNil applies the NotNil predicate on the "parent_user_id" field.
+func ParentUserIDNotNil() predicate.User {
+	return predicate.User(sql.FieldNotNull(FieldParentUserID))
+}
+
 // HasAPIKeys applies the HasEdge predicate on the "api_keys" edge.
 func HasAPIKeys() predicate.User {
 	return predicate.User(func(s *sql.Selector) {
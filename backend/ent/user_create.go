@@ -167,6 +167,20 @@ func (_c *UserCreate) SetNillableNotes(v *string) *UserCreate {
 	return _c
 }
 
+// SetParentUserID sets the "parent_user_id" field.
+func (_c *UserCreate) SetParentUserID(v int64) *UserCreate {
+	_c.mutation.SetParentUserID(v)
+	return _c
+}
+
+// SetNillableParentUserID sets the "parent_user_id" field if the given value is not nil.
+func (_c *UserCreate) SetNillableParentUserID(v *int64) *UserCreate {
+	if v != nil {
+		_c.SetParentUserID(*v)
+	}
+	return _c
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_c *UserCreate) AddAPIKeyIDs(ids ...int64) *UserCreate {
 	_c.mutation.AddAPIKeyIDs(ids...)
@@ -493,6 +507,10 @@ func (_c *UserCreate) createSpec() (*User, *sqlgraph.CreateSpec) {
 		_spec.SetField(user.FieldNotes, field.TypeString, value)
 		_node.Notes = value
 	}
+	if value, ok := _c.mutation.ParentUserID(); ok {
+		_spec.SetField(user.FieldParentUserID, field.TypeInt64, value)
+		_node.ParentUserID = &value
+	}
 	if nodes := _c.mutation.APIKeysIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -815,6 +833,30 @@ func (u *UserUpsert) UpdateNotes() *UserUpsert {
 	return u
 }
 
+// SetParentUserID sets the "parent_user_id" field.
+func (u *UserUpsert) SetParentUserID(v int64) *UserUpsert {
+	u.Set(user.FieldParentUserID, v)
+	return u
+}
+
+// UpdateParentUserID sets the "parent_user_id" field to the value that was provided on create.
+func (u *UserUpsert) UpdateParentUserID() *UserUpsert {
+	u.SetExcluded(user.FieldParentUserID)
+	return u
+}
+
+// AddParentUserID adds v to the "parent_user_id" field.
+func (u *UserUpsert) AddParentUserID(v int64) *UserUpsert {
+	u.Add(user.FieldParentUserID, v)
+	return u
+}
+
+// ClearParentUserID clears the value of the "parent_user_id" field.
+func (u *UserUpsert) ClearParentUserID() *UserUpsert {
+	u.SetNull(user.FieldParentUserID)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -1021,6 +1063,34 @@ func (u *UserUpsertOne) UpdateNotes() *UserUpsertOne {
 	})
 }
 
+// SetParentUserID sets the "parent_user_id" field.
+func (u *UserUpsertOne) SetParentUserID(v int64) *UserUpsertOne {
+	return u.Update(func(s *UserUpsert) {
+		s.SetParentUserID(v)
+	})
+}
+
+// AddParentUserID adds v to the "parent_user_id" field.
+func (u *UserUpsertOne) AddParentUserID(v int64) *UserUpsertOne {
+	return u.Update(func(s *UserUpsert) {
+		s.AddParentUserID(v)
+	})
+}
+
+// UpdateParentUserID sets the "parent_user_id" field to the value that was provided on create.
+func (u *UserUpsertOne) UpdateParentUserID() *UserUpsertOne {
+	return u.Update(func(s *UserUpsert) {
+		s.UpdateParentUserID()
+	})
+}
+
+// ClearParentUserID clears the value of the "parent_user_id" field.
+func (u *UserUpsertOne) ClearParentUserID() *UserUpsertOne {
+	return u.Update(func(s *UserUpsert) {
+		s.ClearParentUserID()
+	})
+}
+
 // Exec executes the query.
 func (u *UserUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -1393,6 +1463,34 @@ func (u *UserUpsertBulk) UpdateNotes() *UserUpsertBulk {
 	})
 }
 
+// SetParentUserID sets the "parent_user_id" field.
+func (u *UserUpsertBulk) SetParentUserID(v int64) *UserUpsertBulk {
+	return u.Update(func(s *UserUpsert) {
+		s.SetParentUserID(v)
+	})
+}
+
+// AddParentUserID adds v to the "parent_user_id" field.
+func (u *UserUpsertBulk) AddParentUserID(v int64) *UserUpsertBulk {
+	return u.Update(func(s *UserUpsert) {
+		s.AddParentUserID(v)
+	})
+}
+
+// UpdateParentUserID sets the "parent_user_id" field to the value that was provided on create.
+func (u *UserUpsertBulk) UpdateParentUserID() *UserUpsertBulk {
+	return u.Update(func(s *UserUpsert) {
+		s.UpdateParentUserID()
+	})
+}
+
+// ClearParentUserID clears the value of the "parent_user_id" field.
+func (u *UserUpsertBulk) ClearParentUserID() *UserUpsertBulk {
+	return u.Update(func(s *UserUpsert) {
+		s.ClearParentUserID()
+	})
+}
+
 // Exec executes the query.
 func (u *UserUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
@@ -187,6 +187,33 @@ func (_u *UserUpdate) SetNillableNotes(v *string) *UserUpdate {
 	return _u
 }
 
+// SetParentUserID sets the "parent_user_id" field.
+func (_u *UserUpdate) SetParentUserID(v int64) *UserUpdate {
+	_u.mutation.ResetParentUserID()
+	_u.mutation.SetParentUserID(v)
+	return _u
+}
+
+// SetNillableParentUserID sets the "parent_user_id" field if the given value is not nil.
+func (_u *UserUpdate) SetNillableParentUserID(v *int64) *UserUpdate {
+	if v != nil {
+		_u.SetParentUserID(*v)
+	}
+	return _u
+}
+
+// AddParentUserID adds value to the "parent_user_id" field.
+func (_u *UserUpdate) AddParentUserID(v int64) *UserUpdate {
+	_u.mutation.AddParentUserID(v)
+	return _u
+}
+
+// ClearParentUserID clears the value of the "parent_user_id" field.
+func (_u *UserUpdate) ClearParentUserID() *UserUpdate {
+	_u.mutation.ClearParentUserID()
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *UserUpdate) AddAPIKeyIDs(ids ...int64) *UserUpdate {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -603,6 +630,15 @@ func (_u *UserUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if value, ok := _u.mutation.Notes(); ok {
 		_spec.SetField(user.FieldNotes, field.TypeString, value)
 	}
+	if value, ok := _u.mutation.ParentUserID(); ok {
+		_spec.SetField(user.FieldParentUserID, field.TypeInt64, value)
+	}
+	if value, ok := _u.mutation.AddedParentUserID(); ok {
+		_spec.AddField(user.FieldParentUserID, field.TypeInt64, value)
+	}
+	if _u.mutation.ParentUserIDCleared() {
+		_spec.ClearField(user.FieldParentUserID, field.TypeInt64)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1147,6 +1183,33 @@ func (_u *UserUpdateOne) SetNillableNotes(v *string) *UserUpdateOne {
 	return _u
 }
 
+// SetParentUserID sets the "parent_user_id" field.
+func (_u *UserUpdateOne) SetParentUserID(v int64) *UserUpdateOne {
+	_u.mutation.ResetParentUserID()
+	_u.mutation.SetParentUserID(v)
+	return _u
+}
+
+// SetNillableParentUserID sets the "parent_user_id" field if the given value is not nil.
+func (_u *UserUpdateOne) SetNillableParentUserID(v *int64) *UserUpdateOne {
+	if v != nil {
+		_u.SetParentUserID(*v)
+	}
+	return _u
+}
+
+// AddParentUserID adds value to the "parent_user_id" field.
+func (_u *UserUpdateOne) AddParentUserID(v int64) *UserUpdateOne {
+	_u.mutation.AddParentUserID(v)
+	return _u
+}
+
+// ClearParentUserID clears the value of the "parent_user_id" field.
+func (_u *UserUpdateOne) ClearParentUserID() *UserUpdateOne {
+	_u.mutation.ClearParentUserID()
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *UserUpdateOne) AddAPIKeyIDs(ids ...int64) *UserUpdateOne {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -1593,6 +1656,15 @@ func (_u *UserUpdateOne) sqlSave(ctx context.Context) (_node *User, err error) {
 	if value, ok := _u.mutation.Notes(); ok {
 		_spec.SetField(user.FieldNotes, field.TypeString, value)
 	}
+	if value, ok := _u.mutation.ParentUserID(); ok {
+		_spec.SetField(user.FieldParentUserID, field.TypeInt64, value)
+	}
+	if value, ok := _u.mutation.AddedParentUserID(); ok {
+		_spec.AddField(user.FieldParentUserID, field.TypeInt64, value)
+	}
+	if _u.mutation.ParentUserIDCleared() {
+		_spec.ClearField(user.FieldParentUserID, field.TypeInt64)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
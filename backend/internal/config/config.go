@@ -56,12 +56,21 @@ type Config struct {
 	Dashboard    DashboardCacheConfig       `mapstructure:"dashboard_cache"`
 	DashboardAgg DashboardAggregationConfig `mapstructure:"dashboard_aggregation"`
 	UsageCleanup UsageCleanupConfig         `mapstructure:"usage_cleanup"`
+	UsageExport  UsageExportConfig          `mapstructure:"usage_export"`
+	Statement    UserStatementConfig        `mapstructure:"statement"`
+	Anomaly      AnomalyDetectionConfig     `mapstructure:"anomaly_detection"`
+	Payment      PaymentConfig              `mapstructure:"payment"`
+	SubPlans     SubscriptionPlanConfig     `mapstructure:"subscription_plans"`
+	Notification NotificationConfig         `mapstructure:"notifications"`
 	Concurrency  ConcurrencyConfig          `mapstructure:"concurrency"`
 	TokenRefresh TokenRefreshConfig         `mapstructure:"token_refresh"`
 	RunMode      string                     `mapstructure:"run_mode" yaml:"run_mode"`
 	Timezone     string                     `mapstructure:"timezone"` // e.g. "Asia/Shanghai", "UTC"
 	Gemini       GeminiConfig               `mapstructure:"gemini"`
 	Update       UpdateConfig               `mapstructure:"update"`
+
+	// live 热更新发布的配置快照，见 Current
+	live *liveConfig
 }
 
 type GeminiConfig struct {
@@ -161,6 +170,8 @@ type SecurityConfig struct {
 	ResponseHeaders ResponseHeaderConfig `mapstructure:"response_headers"`
 	CSP             CSPConfig            `mapstructure:"csp"`
 	ProxyProbe      ProxyProbeConfig     `mapstructure:"proxy_probe"`
+	// SecretEncryption 账号凭证、代理密码与敏感系统设置的落库加密
+	SecretEncryption SecretEncryptionConfig `mapstructure:"secret_encryption"`
 }
 
 type URLAllowlistConfig struct {
@@ -188,8 +199,36 @@ type ProxyProbeConfig struct {
 	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"` // 已禁用：禁止跳过 TLS 证书验证
 }
 
+// SecretEncryptionConfig 敏感字段信封加密配置
+type SecretEncryptionConfig struct {
+	// Enabled: 新写入的账号凭证、代理密码与敏感设置使用主密钥信封加密；
+	// 关闭但仍配置密钥时进入只解密模式，重加密任务会把存量密文回写为明文
+	Enabled bool `mapstructure:"enabled"`
+	// Keys: 主密钥列表，格式 "<key_id>:<base64 32字节>"，多个以逗号分隔（可用环境变量 SECURITY_SECRET_ENCRYPTION_KEYS 注入）
+	Keys string `mapstructure:"keys"`
+	// KeyFile: 主密钥文件路径，每行一个 "<key_id>:<base64>"，与 Keys 合并
+	KeyFile string `mapstructure:"key_file"`
+	// ActiveKeyID: 用于加密新数据的主密钥 ID；只有一个密钥时可留空
+	ActiveKeyID string `mapstructure:"active_key_id"`
+	// ReencryptOnStartup: 启动后在后台分批把明文或旧主密钥加密的存量数据重写为当前主密钥
+	ReencryptOnStartup bool `mapstructure:"reencrypt_on_startup"`
+	// BatchSize: 重加密每批处理行数
+	BatchSize int `mapstructure:"batch_size"`
+}
+
 type BillingConfig struct {
 	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+	CostHold       CostHoldConfig       `mapstructure:"cost_hold"`
+}
+
+// CostHoldConfig 请求前费用预扣配置
+type CostHoldConfig struct {
+	// Enabled: 转发前按最大可能费用预扣余额/订阅额度，防止并发请求透支
+	Enabled bool `mapstructure:"enabled"`
+	// TTLSeconds: 预扣自动过期时间（秒），防止进程异常退出后预扣长期占用额度
+	TTLSeconds int `mapstructure:"ttl_seconds"`
+	// DefaultMaxOutputTokens: 请求未指定 max_tokens 时用于估算的输出 token 数
+	DefaultMaxOutputTokens int `mapstructure:"default_max_output_tokens"`
 }
 
 type CircuitBreakerConfig struct {
@@ -271,6 +310,93 @@ type GatewayConfig struct {
 
 	// TLSFingerprint: TLS指纹伪装配置
 	TLSFingerprint TLSFingerprintConfig `mapstructure:"tls_fingerprint"`
+
+	// Realtime: OpenAI Realtime API（/v1/realtime WebSocket）代理配置
+	Realtime GatewayRealtimeConfig `mapstructure:"realtime"`
+	// GeminiResources: Gemini Files / cachedContents 透传与存储计费配置
+	GeminiResources GatewayGeminiResourcesConfig `mapstructure:"gemini_resources"`
+	// AnthropicFiles: Anthropic Files API（/v1/files）透传配置
+	AnthropicFiles GatewayAnthropicFilesConfig `mapstructure:"anthropic_files"`
+	// StickyStandby: 粘性会话备用账号与缓存热度统计配置
+	StickyStandby GatewayStickyStandbyConfig `mapstructure:"sticky_standby"`
+	// Hedging: 首字节过慢时向第二个账号发送对冲请求（按分组开启）
+	Hedging GatewayHedgingConfig `mapstructure:"hedging"`
+	// Guardrails: 请求/响应内容策略（敏感信息、PII、关键词拦截与外部审核钩子）
+	Guardrails GatewayGuardrailsConfig `mapstructure:"guardrails"`
+}
+
+// GatewayGuardrailsConfig 内容策略配置（策略本身在管理后台按全局/分组/API Key 配置）
+type GatewayGuardrailsConfig struct {
+	// Enabled: 是否执行内容策略
+	Enabled bool `mapstructure:"enabled"`
+	// HookTimeoutSeconds: 外部审核钩子的请求超时（秒）
+	HookTimeoutSeconds int `mapstructure:"hook_timeout_seconds"`
+	// HookFailOpen: 外部审核钩子不可用时放行请求（false 时拒绝请求）
+	HookFailOpen bool `mapstructure:"hook_fail_open"`
+	// EventRetentionDays: 命中记录保留天数
+	EventRetentionDays int `mapstructure:"event_retention_days"`
+}
+
+// GatewayAnthropicFilesConfig Anthropic Files API 透传配置
+type GatewayAnthropicFilesConfig struct {
+	// Enabled: 是否开放 /v1/files
+	Enabled bool `mapstructure:"enabled"`
+	// MaxUploadSize: 单个上传请求体上限（字节），独立于 gateway.max_body_size
+	MaxUploadSize int64 `mapstructure:"max_upload_size"`
+}
+
+// GatewayStickyStandbyConfig 粘性会话备用账号配置
+type GatewayStickyStandbyConfig struct {
+	// Enabled: 为每个粘性会话预选备用账号，主账号不可用时优先切换到备用账号
+	Enabled bool `mapstructure:"enabled"`
+	// WarmthTTLSeconds: 会话在某账号上命中缓存后视为“热”的时长（对应上游 prompt cache TTL）
+	WarmthTTLSeconds int `mapstructure:"warmth_ttl_seconds"`
+	// StatsRetentionDays: 会话缓存统计与切换记录保留天数
+	StatsRetentionDays int `mapstructure:"stats_retention_days"`
+}
+
+// GatewayHedgingConfig 请求对冲配置（仅对开启 hedge_enabled 的分组生效）
+type GatewayHedgingConfig struct {
+	// Percentile: 对冲延迟取分组近期首个内容事件（content_block_delta）耗时的分位数，取值 (0, 1)；
+	// 超过对冲延迟仍未收到首个内容事件的流式请求会发往第二个账号（不以响应头到达计时）
+	Percentile float64 `mapstructure:"percentile"`
+	// MinDelayMs / MaxDelayMs: 对冲延迟上下限（毫秒）
+	MinDelayMs int `mapstructure:"min_delay_ms"`
+	MaxDelayMs int `mapstructure:"max_delay_ms"`
+	// InitialDelayMs: 样本不足 MinSamples 时使用的对冲延迟（毫秒）
+	InitialDelayMs int `mapstructure:"initial_delay_ms"`
+	// MinSamples: 按分位数计算延迟所需的最少样本数
+	MinSamples int `mapstructure:"min_samples"`
+	// WindowSize: 每个分组保留的最近首个内容事件耗时样本数
+	WindowSize int `mapstructure:"window_size"`
+	// BudgetRatio: 对冲请求占分组请求量的上限比例（令牌桶：每个请求补充 BudgetRatio 个令牌，每次对冲消耗 1 个）
+	BudgetRatio float64 `mapstructure:"budget_ratio"`
+	// BudgetBurst: 令牌桶容量，允许短时间内连续对冲的次数
+	BudgetBurst float64 `mapstructure:"budget_burst"`
+}
+
+// GatewayGeminiResourcesConfig Gemini Files API 与显式上下文缓存（cachedContents）配置
+type GatewayGeminiResourcesConfig struct {
+	// Enabled: 是否开放 /v1beta/files、/upload/v1beta/files 与 /v1beta/cachedContents
+	Enabled bool `mapstructure:"enabled"`
+	// CacheStoragePricePerMTokHour: 显式缓存存储单价（USD / 百万 token / 小时），按创建或延长时的 TTL 预收
+	CacheStoragePricePerMTokHour float64 `mapstructure:"cache_storage_price_per_mtok_hour"`
+	// CacheStorageModelPrices: 按模型覆盖缓存存储单价（键为模型名前缀，最长匹配优先）
+	CacheStorageModelPrices map[string]float64 `mapstructure:"cache_storage_model_prices"`
+	// FileStoragePricePerGBDay: 文件存储单价（USD / GB / 天），按文件保留时长在上传完成时收取；0 表示不收费
+	FileStoragePricePerGBDay float64 `mapstructure:"file_storage_price_per_gb_day"`
+}
+
+// GatewayRealtimeConfig OpenAI Realtime WebSocket 代理配置
+type GatewayRealtimeConfig struct {
+	// Enabled: 是否开放 /v1/realtime
+	Enabled bool `mapstructure:"enabled"`
+	// MaxSessionMinutes: 单个会话最长持续时间（分钟），到期后网关主动关闭，释放并发槽位
+	MaxSessionMinutes int `mapstructure:"max_session_minutes"`
+	// PingIntervalSeconds: 向客户端发送 WebSocket ping 的间隔（秒），0 表示不发送
+	PingIntervalSeconds int `mapstructure:"ping_interval_seconds"`
+	// MaxMessageBytes: 客户端单帧最大字节数（input_audio_buffer.append 携带 base64 音频）
+	MaxMessageBytes int64 `mapstructure:"max_message_bytes"`
 }
 
 // TLSFingerprintConfig TLS指纹伪装配置
@@ -546,6 +672,161 @@ type UsageCleanupConfig struct {
 	TaskTimeoutSeconds int `mapstructure:"task_timeout_seconds"`
 }
 
+// UsageExportConfig 使用记录导出配置
+type UsageExportConfig struct {
+	// Enabled: 是否启用使用记录导出
+	Enabled bool `mapstructure:"enabled"`
+	// StorageDir: 异步导出文件存放目录
+	StorageDir string `mapstructure:"storage_dir"`
+	// SyncMaxRows: 不超过该行数时直接同步流式返回，否则创建异步导出任务
+	SyncMaxRows int `mapstructure:"sync_max_rows"`
+	// MaxRangeDays: 单次导出允许的最大时间跨度（天），0 表示不限制
+	MaxRangeDays int `mapstructure:"max_range_days"`
+	// BatchSize: 单批读取行数
+	BatchSize int `mapstructure:"batch_size"`
+	// LinkTTLHours: 下载链接有效期（小时），过期后文件被清理
+	LinkTTLHours int `mapstructure:"link_ttl_hours"`
+	// WorkerIntervalSeconds: 后台任务轮询间隔（秒）
+	WorkerIntervalSeconds int `mapstructure:"worker_interval_seconds"`
+	// TaskTimeoutSeconds: 单次任务最大执行时长（秒）
+	TaskTimeoutSeconds int `mapstructure:"task_timeout_seconds"`
+}
+
+// UserStatementConfig 用户月度账单配置
+type UserStatementConfig struct {
+	// Enabled: 是否在月末自动生成用户月度账单（依赖仪表盘预聚合）
+	Enabled bool `mapstructure:"enabled"`
+	// EmailEnabled: 生成后是否通过邮件发送账单（PDF 附件）
+	EmailEnabled bool `mapstructure:"email_enabled"`
+	// GenerateDelayHours: 账期结束后延迟多少小时再生成，等待预聚合追平
+	GenerateDelayHours int `mapstructure:"generate_delay_hours"`
+	// WorkerIntervalMinutes: 后台任务轮询间隔（分钟）
+	WorkerIntervalMinutes int `mapstructure:"worker_interval_minutes"`
+	// BatchSize: 单轮生成的账单数量
+	BatchSize int `mapstructure:"batch_size"`
+	// EmailMaxAttempts: 单份账单邮件最大投递尝试次数
+	EmailMaxAttempts int `mapstructure:"email_max_attempts"`
+	// PDFFontPath: PDF 账单使用的 TrueType 字体（.ttf/.ttc，需包含中日韩字形）；留空时查找常见系统字体，
+	// 均不可用时只能输出拉丁字符
+	PDFFontPath string `mapstructure:"pdf_font_path"`
+}
+
+// AnomalyDetectionConfig API Key 用量异常检测配置
+type AnomalyDetectionConfig struct {
+	// Enabled: 是否启用异常检测（依赖仪表盘预聚合）
+	Enabled bool `mapstructure:"enabled"`
+	// WorkerIntervalMinutes: 后台任务轮询间隔（分钟）
+	WorkerIntervalMinutes int `mapstructure:"worker_interval_minutes"`
+	// BaselineDays: 基线回看天数
+	BaselineDays int `mapstructure:"baseline_days"`
+	// MinBaselineHours: Key 首次有用量后至少经过多少小时才参与检测（避免新 Key 误报）
+	MinBaselineHours int `mapstructure:"min_baseline_hours"`
+	// ZScoreThreshold: 小时消费/请求数超过基线均值多少个标准差记为异常
+	ZScoreThreshold float64 `mapstructure:"z_score_threshold"`
+	// CriticalZScore: 达到该标准差倍数（或异常同时出现新来源）时记为严重
+	CriticalZScore float64 `mapstructure:"critical_z_score"`
+	// MinSpendUSD: 小时消费低于该值时不判定消费异常
+	MinSpendUSD float64 `mapstructure:"min_spend_usd"`
+	// MinRequests: 小时请求数低于该值时不判定请求量异常
+	MinRequests int64 `mapstructure:"min_requests"`
+	// ModelShiftMinShare: 基线中未出现过的模型占小时消费的比例达到该值时记为模型异常（0-1）
+	ModelShiftMinShare float64 `mapstructure:"model_shift_min_share"`
+	// SourceCheckEnabled: 是否检测新的来源 IP 段 / User-Agent（查询 usage_logs）
+	SourceCheckEnabled bool `mapstructure:"source_check_enabled"`
+	// AutoSuspend: 严重异常时是否自动停用 API Key
+	AutoSuspend bool `mapstructure:"auto_suspend"`
+	// NotifyOwner: 是否通知 Key 所属用户（遵循用户的 API Key 通知偏好）
+	NotifyOwner bool `mapstructure:"notify_owner"`
+	// NotifyAdmins: 是否邮件通知运维告警收件人（遵循运维告警邮件的最低级别）
+	NotifyAdmins bool `mapstructure:"notify_admins"`
+	// MaxCatchUpHours: 单轮最多补检的小时数
+	MaxCatchUpHours int `mapstructure:"max_catch_up_hours"`
+}
+
+// SubscriptionPlanConfig 订阅套餐自动续费配置
+type SubscriptionPlanConfig struct {
+	// AutoRenewEnabled: 是否启用自动续费任务
+	AutoRenewEnabled bool `mapstructure:"auto_renew_enabled"`
+	// AutoRenewIntervalSeconds: 自动续费任务轮询间隔（秒）
+	AutoRenewIntervalSeconds int `mapstructure:"auto_renew_interval_seconds"`
+	// RenewBeforeHours: 到期前多少小时开始尝试续费
+	RenewBeforeHours int `mapstructure:"renew_before_hours"`
+	// BatchSize: 单次轮询处理的订阅数量上限
+	BatchSize int `mapstructure:"batch_size"`
+}
+
+// NotificationConfig 用户通知（余额不足、额度阈值、订阅到期、API Key 停用）配置
+type NotificationConfig struct {
+	// Enabled: 是否启用通知扫描任务
+	Enabled bool `mapstructure:"enabled"`
+	// ScanIntervalSeconds: 扫描间隔（秒）
+	ScanIntervalSeconds int `mapstructure:"scan_interval_seconds"`
+	// LowBalanceThreshold: 默认余额提醒阈值（USD），用户未设置时使用；<=0 表示默认不提醒
+	LowBalanceThreshold float64 `mapstructure:"low_balance_threshold"`
+	// QuotaWarningPercent: 订阅日/周/月额度预警百分比（达到 100% 时另行通知）
+	QuotaWarningPercent int `mapstructure:"quota_warning_percent"`
+	// ExpiryReminderDays: 默认订阅到期提前提醒天数，用户未设置时使用
+	ExpiryReminderDays int `mapstructure:"expiry_reminder_days"`
+	// WebhookTimeoutSeconds: 用户 Webhook 投递超时（秒）
+	WebhookTimeoutSeconds int `mapstructure:"webhook_timeout_seconds"`
+	// BatchSize: 单次扫描每类通知的处理数量上限
+	BatchSize int `mapstructure:"batch_size"`
+}
+
+// PaymentConfig 在线支付充值配置
+type PaymentConfig struct {
+	// Enabled: 是否启用在线支付
+	Enabled bool `mapstructure:"enabled"`
+	// Currency: 订单计价货币（ISO 4217，如 CNY / USD）
+	Currency string `mapstructure:"currency"`
+	// BalancePerUnit: 每 1 单位货币兑换的余额（USD）
+	BalancePerUnit float64 `mapstructure:"balance_per_unit"`
+	// MinAmount / MaxAmount: 单笔充值金额范围（订单货币）
+	MinAmount float64 `mapstructure:"min_amount"`
+	MaxAmount float64 `mapstructure:"max_amount"`
+	// OrderExpireMinutes: 待支付订单过期时间（分钟）
+	OrderExpireMinutes int `mapstructure:"order_expire_minutes"`
+	// MaxPendingOrders: 单个用户同时存在的待支付订单上限
+	MaxPendingOrders int `mapstructure:"max_pending_orders"`
+	// NotifyBaseURL: 支付回调的公网基础地址（如 https://example.com），回调路径为 /api/v1/payments/webhook/{provider}
+	NotifyBaseURL string `mapstructure:"notify_base_url"`
+	// ReturnURL: 支付完成后跳转的前端地址
+	ReturnURL string `mapstructure:"return_url"`
+
+	Stripe StripePaymentConfig `mapstructure:"stripe"`
+	Signed SignedPaymentConfig `mapstructure:"signed"`
+	Fake   FakePaymentConfig   `mapstructure:"fake"`
+}
+
+// StripePaymentConfig Stripe Checkout 支付配置
+type StripePaymentConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	SecretKey     string `mapstructure:"secret_key"`
+	WebhookSecret string `mapstructure:"webhook_secret"`
+	// APIBase: Stripe API 地址（测试时可指向 stripe-mock）
+	APIBase string `mapstructure:"api_base"`
+	// WebhookToleranceSeconds: 回调签名时间戳允许的最大偏差（秒）
+	WebhookToleranceSeconds int `mapstructure:"webhook_tolerance_seconds"`
+}
+
+// SignedPaymentConfig 通用签名回调支付配置（兼容易支付等支付宝/微信聚合支付协议）
+type SignedPaymentConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// GatewayURL: 聚合支付网关地址（如 https://pay.example.com）
+	GatewayURL string `mapstructure:"gateway_url"`
+	MerchantID string `mapstructure:"merchant_id"`
+	SecretKey  string `mapstructure:"secret_key"`
+	// SignType: 签名算法（md5 / hmac-sha256）
+	SignType string `mapstructure:"sign_type"`
+	// Methods: 允许的支付方式（如 alipay / wxpay）
+	Methods []string `mapstructure:"methods"`
+}
+
+// FakePaymentConfig 本地调试用的模拟支付（切勿在生产环境启用）
+type FakePaymentConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
 func NormalizeRunMode(value string) string {
 	normalized := strings.ToLower(strings.TrimSpace(value))
 	switch normalized {
@@ -578,6 +859,38 @@ func Load() (*Config, error) {
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
+	cfg, err := load(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.Security.URLAllowlist.Enabled {
+		log.Println("Warning: security.url_allowlist.enabled=false; allowlist/SSRF checks disabled (minimal format validation only).")
+	}
+	if !cfg.Security.ResponseHeaders.Enabled {
+		log.Println("Warning: security.response_headers.enabled=false; configurable header filtering disabled (default allowlist only).")
+	}
+
+	if cfg.JWT.Secret != "" && isWeakJWTSecret(cfg.JWT.Secret) {
+		log.Println("Warning: JWT secret appears weak; use a 32+ character random secret in production.")
+	}
+	if len(cfg.Security.ResponseHeaders.AdditionalAllowed) > 0 || len(cfg.Security.ResponseHeaders.ForceRemove) > 0 {
+		log.Printf("AUDIT: response header policy configured additional_allowed=%v force_remove=%v",
+			cfg.Security.ResponseHeaders.AdditionalAllowed,
+			cfg.Security.ResponseHeaders.ForceRemove,
+		)
+	}
+
+	return cfg, nil
+}
+
+// Reload 重新读取配置文件与环境变量（沿用 Load 设置的查找路径）并校验。
+// 配置中未设置 jwt.secret 时沿用 current 启动时自动生成的密钥，避免被误判为变更。
+func Reload(current *Config) (*Config, error) {
+	return load(current)
+}
+
+func load(current *Config) (*Config, error) {
 	// 默认值
 	setDefaults()
 
@@ -616,7 +929,12 @@ func Load() (*Config, error) {
 	cfg.Security.ResponseHeaders.AdditionalAllowed = normalizeStringSlice(cfg.Security.ResponseHeaders.AdditionalAllowed)
 	cfg.Security.ResponseHeaders.ForceRemove = normalizeStringSlice(cfg.Security.ResponseHeaders.ForceRemove)
 	cfg.Security.CSP.Policy = strings.TrimSpace(cfg.Security.CSP.Policy)
+	cfg.Security.SecretEncryption.KeyFile = strings.TrimSpace(cfg.Security.SecretEncryption.KeyFile)
+	cfg.Security.SecretEncryption.ActiveKeyID = strings.TrimSpace(cfg.Security.SecretEncryption.ActiveKeyID)
 
+	if cfg.JWT.Secret == "" && current != nil {
+		cfg.JWT.Secret = current.JWT.Secret
+	}
 	if cfg.JWT.Secret == "" {
 		secret, err := generateJWTSecret(64)
 		if err != nil {
@@ -629,24 +947,7 @@ func Load() (*Config, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("validate config error: %w", err)
 	}
-
-	if !cfg.Security.URLAllowlist.Enabled {
-		log.Println("Warning: security.url_allowlist.enabled=false; allowlist/SSRF checks disabled (minimal format validation only).")
-	}
-	if !cfg.Security.ResponseHeaders.Enabled {
-		log.Println("Warning: security.response_headers.enabled=false; configurable header filtering disabled (default allowlist only).")
-	}
-
-	if cfg.JWT.Secret != "" && isWeakJWTSecret(cfg.JWT.Secret) {
-		log.Println("Warning: JWT secret appears weak; use a 32+ character random secret in production.")
-	}
-	if len(cfg.Security.ResponseHeaders.AdditionalAllowed) > 0 || len(cfg.Security.ResponseHeaders.ForceRemove) > 0 {
-		log.Printf("AUDIT: response header policy configured additional_allowed=%v force_remove=%v",
-			cfg.Security.ResponseHeaders.AdditionalAllowed,
-			cfg.Security.ResponseHeaders.ForceRemove,
-		)
-	}
-
+	cfg.EnableReload()
 	return &cfg, nil
 }
 
@@ -689,12 +990,21 @@ func setDefaults() {
 	viper.SetDefault("security.csp.enabled", true)
 	viper.SetDefault("security.csp.policy", DefaultCSPPolicy)
 	viper.SetDefault("security.proxy_probe.insecure_skip_verify", false)
+	viper.SetDefault("security.secret_encryption.enabled", false)
+	viper.SetDefault("security.secret_encryption.keys", "")
+	viper.SetDefault("security.secret_encryption.key_file", "")
+	viper.SetDefault("security.secret_encryption.active_key_id", "")
+	viper.SetDefault("security.secret_encryption.reencrypt_on_startup", true)
+	viper.SetDefault("security.secret_encryption.batch_size", 200)
 
 	// Billing
 	viper.SetDefault("billing.circuit_breaker.enabled", true)
 	viper.SetDefault("billing.circuit_breaker.failure_threshold", 5)
 	viper.SetDefault("billing.circuit_breaker.reset_timeout_seconds", 30)
 	viper.SetDefault("billing.circuit_breaker.half_open_requests", 3)
+	viper.SetDefault("billing.cost_hold.enabled", true)
+	viper.SetDefault("billing.cost_hold.ttl_seconds", 600)
+	viper.SetDefault("billing.cost_hold.default_max_output_tokens", 4096)
 
 	// Turnstile
 	viper.SetDefault("turnstile.required", false)
@@ -813,6 +1123,74 @@ func setDefaults() {
 	viper.SetDefault("usage_cleanup.worker_interval_seconds", 10)
 	viper.SetDefault("usage_cleanup.task_timeout_seconds", 1800)
 
+	// Usage export
+	viper.SetDefault("usage_export.enabled", true)
+	viper.SetDefault("usage_export.storage_dir", "./data/exports")
+	viper.SetDefault("usage_export.sync_max_rows", 10000)
+	viper.SetDefault("usage_export.max_range_days", 366)
+	viper.SetDefault("usage_export.batch_size", 2000)
+	viper.SetDefault("usage_export.link_ttl_hours", 24)
+	viper.SetDefault("usage_export.worker_interval_seconds", 10)
+	viper.SetDefault("usage_export.task_timeout_seconds", 3600)
+
+	// Monthly statements
+	viper.SetDefault("statement.enabled", true)
+	viper.SetDefault("statement.email_enabled", true)
+	viper.SetDefault("statement.generate_delay_hours", 2)
+	viper.SetDefault("statement.worker_interval_minutes", 30)
+	viper.SetDefault("statement.batch_size", 200)
+	viper.SetDefault("statement.email_max_attempts", 3)
+	viper.SetDefault("statement.pdf_font_path", "")
+
+	// Anomaly detection
+	viper.SetDefault("anomaly_detection.enabled", true)
+	viper.SetDefault("anomaly_detection.worker_interval_minutes", 10)
+	viper.SetDefault("anomaly_detection.baseline_days", 7)
+	viper.SetDefault("anomaly_detection.min_baseline_hours", 72)
+	viper.SetDefault("anomaly_detection.z_score_threshold", 4.0)
+	viper.SetDefault("anomaly_detection.critical_z_score", 8.0)
+	viper.SetDefault("anomaly_detection.min_spend_usd", 1.0)
+	viper.SetDefault("anomaly_detection.min_requests", 100)
+	viper.SetDefault("anomaly_detection.model_shift_min_share", 0.5)
+	viper.SetDefault("anomaly_detection.source_check_enabled", true)
+	viper.SetDefault("anomaly_detection.auto_suspend", false)
+	viper.SetDefault("anomaly_detection.notify_owner", true)
+	viper.SetDefault("anomaly_detection.notify_admins", true)
+	viper.SetDefault("anomaly_detection.max_catch_up_hours", 24)
+
+	// Payment
+	viper.SetDefault("payment.enabled", false)
+	viper.SetDefault("payment.currency", "CNY")
+	viper.SetDefault("payment.balance_per_unit", 1.0)
+	viper.SetDefault("payment.min_amount", 1.0)
+	viper.SetDefault("payment.max_amount", 10000.0)
+	viper.SetDefault("payment.order_expire_minutes", 30)
+	viper.SetDefault("payment.max_pending_orders", 5)
+	viper.SetDefault("payment.notify_base_url", "")
+	viper.SetDefault("payment.return_url", "")
+	viper.SetDefault("payment.stripe.enabled", false)
+	viper.SetDefault("payment.stripe.api_base", "https://api.stripe.com")
+	viper.SetDefault("payment.stripe.webhook_tolerance_seconds", 300)
+	viper.SetDefault("payment.signed.enabled", false)
+	viper.SetDefault("payment.signed.sign_type", "md5")
+	viper.SetDefault("payment.signed.methods", []string{"alipay", "wxpay"})
+	viper.SetDefault("payment.fake.enabled", false)
+
+	// Subscription plans
+	viper.SetDefault("subscription_plans.auto_renew_enabled", true)
+	viper.SetDefault("subscription_plans.auto_renew_interval_seconds", 600)
+	viper.SetDefault("subscription_plans.renew_before_hours", 24)
+	viper.SetDefault("subscription_plans.batch_size", 100)
+
+	// Notifications
+	viper.SetDefault("notifications.enabled", true)
+	viper.SetDefault("notifications.scan_interval_seconds", 300)
+	viper.SetDefault("notifications.low_balance_threshold", 1.0)
+	viper.SetDefault("notifications.quota_warning_percent", 80)
+	viper.SetDefault("notifications.expiry_reminder_days", 3)
+	viper.SetDefault("notifications.webhook_timeout_seconds", 10)
+	viper.SetDefault("notifications.batch_size", 500)
+
 	// Gateway
 	viper.SetDefault("gateway.response_header_timeout", 600) // 600秒(10分钟)等待上游响应头，LLM高负载时可能排队较久
 	viper.SetDefault("gateway.log_upstream_error_body", true)
@@ -853,6 +1231,31 @@ func setDefaults() {
 	viper.SetDefault("gateway.scheduling.full_rebuild_interval_seconds", 300)
 	// TLS指纹伪装配置（默认关闭，需要账号级别单独启用）
 	viper.SetDefault("gateway.tls_fingerprint.enabled", true)
+	viper.SetDefault("gateway.realtime.enabled", true)
+	viper.SetDefault("gateway.realtime.max_session_minutes", 60)
+	viper.SetDefault("gateway.realtime.ping_interval_seconds", 30)
+	viper.SetDefault("gateway.realtime.max_message_bytes", int64(16*1024*1024))
+	viper.SetDefault("gateway.gemini_resources.enabled", true)
+	viper.SetDefault("gateway.gemini_resources.cache_storage_price_per_mtok_hour", 1.0)
+	viper.SetDefault("gateway.gemini_resources.cache_storage_model_prices", map[string]float64{})
+	viper.SetDefault("gateway.gemini_resources.file_storage_price_per_gb_day", 0.0)
+	viper.SetDefault("gateway.anthropic_files.enabled", true)
+	viper.SetDefault("gateway.anthropic_files.max_upload_size", int64(500*1024*1024))
+	viper.SetDefault("gateway.sticky_standby.enabled", true)
+	viper.SetDefault("gateway.sticky_standby.warmth_ttl_seconds", 300)
+	viper.SetDefault("gateway.sticky_standby.stats_retention_days", 7)
+	viper.SetDefault("gateway.hedging.percentile", 0.95)
+	viper.SetDefault("gateway.hedging.min_delay_ms", 1000)
+	viper.SetDefault("gateway.hedging.max_delay_ms", 30000)
+	viper.SetDefault("gateway.hedging.initial_delay_ms", 5000)
+	viper.SetDefault("gateway.hedging.min_samples", 20)
+	viper.SetDefault("gateway.hedging.window_size", 200)
+	viper.SetDefault("gateway.hedging.budget_ratio", 0.05)
+	viper.SetDefault("gateway.hedging.budget_burst", 5.0)
+	viper.SetDefault("gateway.guardrails.enabled", true)
+	viper.SetDefault("gateway.guardrails.hook_timeout_seconds", 5)
+	viper.SetDefault("gateway.guardrails.hook_fail_open", true)
+	viper.SetDefault("gateway.guardrails.event_retention_days", 30)
 	viper.SetDefault("concurrency.ping_interval", 10)
 
 	// TokenRefresh
@@ -884,6 +1287,13 @@ func (c *Config) Validate() error {
 	if c.Security.CSP.Enabled && strings.TrimSpace(c.Security.CSP.Policy) == "" {
 		return fmt.Errorf("security.csp.policy is required when CSP is enabled")
 	}
+	if c.Security.SecretEncryption.Enabled &&
+		strings.TrimSpace(c.Security.SecretEncryption.Keys) == "" && c.Security.SecretEncryption.KeyFile == "" {
+		return fmt.Errorf("security.secret_encryption.keys or key_file is required when secret encryption is enabled")
+	}
+	if c.Security.SecretEncryption.BatchSize <= 0 || c.Security.SecretEncryption.BatchSize > 5000 {
+		return fmt.Errorf("security.secret_encryption.batch_size must be between 1 and 5000")
+	}
 	if c.LinuxDo.Enabled {
 		if strings.TrimSpace(c.LinuxDo.ClientID) == "" {
 			return fmt.Errorf("linuxdo_connect.client_id is required when linuxdo_connect.enabled=true")
@@ -950,6 +1360,14 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("billing.circuit_breaker.half_open_requests must be positive")
 		}
 	}
+	if c.Billing.CostHold.Enabled {
+		if c.Billing.CostHold.TTLSeconds <= 0 {
+			return fmt.Errorf("billing.cost_hold.ttl_seconds must be positive")
+		}
+		if c.Billing.CostHold.DefaultMaxOutputTokens <= 0 {
+			return fmt.Errorf("billing.cost_hold.default_max_output_tokens must be positive")
+		}
+	}
 	if c.Database.MaxOpenConns <= 0 {
 		return fmt.Errorf("database.max_open_conns must be positive")
 	}
@@ -1082,6 +1500,102 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("usage_cleanup.task_timeout_seconds must be non-negative")
 		}
 	}
+	if c.UsageExport.Enabled {
+		if strings.TrimSpace(c.UsageExport.StorageDir) == "" {
+			return fmt.Errorf("usage_export.storage_dir is required when usage export is enabled")
+		}
+		if c.UsageExport.BatchSize <= 0 {
+			return fmt.Errorf("usage_export.batch_size must be positive")
+		}
+		if c.UsageExport.LinkTTLHours <= 0 {
+			return fmt.Errorf("usage_export.link_ttl_hours must be positive")
+		}
+		if c.UsageExport.WorkerIntervalSeconds <= 0 {
+			return fmt.Errorf("usage_export.worker_interval_seconds must be positive")
+		}
+		if c.UsageExport.TaskTimeoutSeconds <= 0 {
+			return fmt.Errorf("usage_export.task_timeout_seconds must be positive")
+		}
+	}
+	if c.Statement.Enabled {
+		if c.Statement.WorkerIntervalMinutes <= 0 {
+			return fmt.Errorf("statement.worker_interval_minutes must be positive")
+		}
+		if c.Statement.BatchSize <= 0 {
+			return fmt.Errorf("statement.batch_size must be positive")
+		}
+		if c.Statement.EmailMaxAttempts <= 0 {
+			return fmt.Errorf("statement.email_max_attempts must be positive")
+		}
+	}
+	if c.Statement.GenerateDelayHours < 0 {
+		return fmt.Errorf("statement.generate_delay_hours must be non-negative")
+	}
+	if c.Anomaly.Enabled {
+		if c.Anomaly.WorkerIntervalMinutes <= 0 {
+			return fmt.Errorf("anomaly_detection.worker_interval_minutes must be positive")
+		}
+		if c.Anomaly.BaselineDays <= 0 {
+			return fmt.Errorf("anomaly_detection.baseline_days must be positive")
+		}
+		if c.Anomaly.MinBaselineHours < 0 || c.Anomaly.MinBaselineHours > c.Anomaly.BaselineDays*24 {
+			return fmt.Errorf("anomaly_detection.min_baseline_hours must be between 0 and baseline_days*24")
+		}
+		if c.Anomaly.ZScoreThreshold <= 0 {
+			return fmt.Errorf("anomaly_detection.z_score_threshold must be positive")
+		}
+		if c.Anomaly.CriticalZScore < c.Anomaly.ZScoreThreshold {
+			return fmt.Errorf("anomaly_detection.critical_z_score must be >= z_score_threshold")
+		}
+		if c.Anomaly.MinSpendUSD < 0 || c.Anomaly.MinRequests < 0 {
+			return fmt.Errorf("anomaly_detection.min_spend_usd and min_requests must be non-negative")
+		}
+		if c.Anomaly.ModelShiftMinShare <= 0 || c.Anomaly.ModelShiftMinShare > 1 {
+			return fmt.Errorf("anomaly_detection.model_shift_min_share must be in (0, 1]")
+		}
+		if c.Anomaly.MaxCatchUpHours <= 0 {
+			return fmt.Errorf("anomaly_detection.max_catch_up_hours must be positive")
+		}
+	}
+	if c.UsageExport.SyncMaxRows < 0 {
+		return fmt.Errorf("usage_export.sync_max_rows must be non-negative")
+	}
+	if c.UsageExport.MaxRangeDays < 0 {
+		return fmt.Errorf("usage_export.max_range_days must be non-negative")
+	}
+	if c.Payment.Enabled {
+		if err := c.Payment.validate(); err != nil {
+			return err
+		}
+	}
+	if c.SubPlans.AutoRenewEnabled {
+		if c.SubPlans.AutoRenewIntervalSeconds <= 0 {
+			return fmt.Errorf("subscription_plans.auto_renew_interval_seconds must be positive")
+		}
+		if c.SubPlans.RenewBeforeHours <= 0 {
+			return fmt.Errorf("subscription_plans.renew_before_hours must be positive")
+		}
+		if c.SubPlans.BatchSize <= 0 {
+			return fmt.Errorf("subscription_plans.batch_size must be positive")
+		}
+	}
+	if c.Notification.Enabled {
+		if c.Notification.ScanIntervalSeconds <= 0 {
+			return fmt.Errorf("notifications.scan_interval_seconds must be positive")
+		}
+		if c.Notification.QuotaWarningPercent <= 0 || c.Notification.QuotaWarningPercent >= 100 {
+			return fmt.Errorf("notifications.quota_warning_percent must be between 1 and 99")
+		}
+		if c.Notification.ExpiryReminderDays < 0 {
+			return fmt.Errorf("notifications.expiry_reminder_days must be non-negative")
+		}
+		if c.Notification.WebhookTimeoutSeconds <= 0 {
+			return fmt.Errorf("notifications.webhook_timeout_seconds must be positive")
+		}
+		if c.Notification.BatchSize <= 0 {
+			return fmt.Errorf("notifications.batch_size must be positive")
+		}
+	}
 	if c.Gateway.MaxBodySize <= 0 {
 		return fmt.Errorf("gateway.max_body_size must be positive")
 	}
@@ -1158,6 +1672,67 @@ func (c *Config) Validate() error {
 	if c.Gateway.Scheduling.DbFallbackMaxQPS < 0 {
 		return fmt.Errorf("gateway.scheduling.db_fallback_max_qps must be non-negative")
 	}
+	if c.Gateway.Realtime.Enabled {
+		if c.Gateway.Realtime.MaxSessionMinutes <= 0 {
+			return fmt.Errorf("gateway.realtime.max_session_minutes must be positive")
+		}
+		if c.Gateway.Realtime.PingIntervalSeconds < 0 {
+			return fmt.Errorf("gateway.realtime.ping_interval_seconds must be non-negative")
+		}
+		if c.Gateway.Realtime.MaxMessageBytes <= 0 {
+			return fmt.Errorf("gateway.realtime.max_message_bytes must be positive")
+		}
+	}
+	if c.Gateway.GeminiResources.CacheStoragePricePerMTokHour < 0 {
+		return fmt.Errorf("gateway.gemini_resources.cache_storage_price_per_mtok_hour must be non-negative")
+	}
+	for model, price := range c.Gateway.GeminiResources.CacheStorageModelPrices {
+		if price < 0 {
+			return fmt.Errorf("gateway.gemini_resources.cache_storage_model_prices[%s] must be non-negative", model)
+		}
+	}
+	if c.Gateway.GeminiResources.FileStoragePricePerGBDay < 0 {
+		return fmt.Errorf("gateway.gemini_resources.file_storage_price_per_gb_day must be non-negative")
+	}
+	if c.Gateway.AnthropicFiles.Enabled && c.Gateway.AnthropicFiles.MaxUploadSize <= 0 {
+		return fmt.Errorf("gateway.anthropic_files.max_upload_size must be positive")
+	}
+	if c.Gateway.StickyStandby.WarmthTTLSeconds <= 0 {
+		return fmt.Errorf("gateway.sticky_standby.warmth_ttl_seconds must be positive")
+	}
+	if c.Gateway.StickyStandby.StatsRetentionDays <= 0 {
+		return fmt.Errorf("gateway.sticky_standby.stats_retention_days must be positive")
+	}
+	if c.Gateway.Hedging.Percentile <= 0 || c.Gateway.Hedging.Percentile >= 1 {
+		return fmt.Errorf("gateway.hedging.percentile must be between 0 and 1")
+	}
+	if c.Gateway.Hedging.MinDelayMs <= 0 {
+		return fmt.Errorf("gateway.hedging.min_delay_ms must be positive")
+	}
+	if c.Gateway.Hedging.MaxDelayMs < c.Gateway.Hedging.MinDelayMs {
+		return fmt.Errorf("gateway.hedging.max_delay_ms must be >= min_delay_ms")
+	}
+	if c.Gateway.Hedging.InitialDelayMs < c.Gateway.Hedging.MinDelayMs || c.Gateway.Hedging.InitialDelayMs > c.Gateway.Hedging.MaxDelayMs {
+		return fmt.Errorf("gateway.hedging.initial_delay_ms must be between min_delay_ms and max_delay_ms")
+	}
+	if c.Gateway.Hedging.MinSamples <= 0 {
+		return fmt.Errorf("gateway.hedging.min_samples must be positive")
+	}
+	if c.Gateway.Hedging.WindowSize < c.Gateway.Hedging.MinSamples {
+		return fmt.Errorf("gateway.hedging.window_size must be >= min_samples")
+	}
+	if c.Gateway.Hedging.BudgetRatio < 0 || c.Gateway.Hedging.BudgetRatio > 1 {
+		return fmt.Errorf("gateway.hedging.budget_ratio must be between 0 and 1")
+	}
+	if c.Gateway.Hedging.BudgetBurst < 1 {
+		return fmt.Errorf("gateway.hedging.budget_burst must be >= 1")
+	}
+	if c.Gateway.Guardrails.HookTimeoutSeconds <= 0 {
+		return fmt.Errorf("gateway.guardrails.hook_timeout_seconds must be positive")
+	}
+	if c.Gateway.Guardrails.EventRetentionDays <= 0 {
+		return fmt.Errorf("gateway.guardrails.event_retention_days must be positive")
+	}
 	if c.Gateway.Scheduling.OutboxPollIntervalSeconds <= 0 {
 		return fmt.Errorf("gateway.scheduling.outbox_poll_interval_seconds must be positive")
 	}
@@ -1202,6 +1777,72 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+func (p *PaymentConfig) validate() error {
+	if strings.TrimSpace(p.Currency) == "" {
+		return fmt.Errorf("payment.currency is required when payment.enabled=true")
+	}
+	if p.BalancePerUnit <= 0 {
+		return fmt.Errorf("payment.balance_per_unit must be positive")
+	}
+	if p.MinAmount <= 0 {
+		return fmt.Errorf("payment.min_amount must be positive")
+	}
+	if p.MaxAmount < p.MinAmount {
+		return fmt.Errorf("payment.max_amount must be >= payment.min_amount")
+	}
+	if p.OrderExpireMinutes <= 0 {
+		return fmt.Errorf("payment.order_expire_minutes must be positive")
+	}
+	if p.MaxPendingOrders < 0 {
+		return fmt.Errorf("payment.max_pending_orders must be non-negative")
+	}
+	if !p.Stripe.Enabled && !p.Signed.Enabled && !p.Fake.Enabled {
+		return fmt.Errorf("payment.enabled=true requires at least one provider (stripe/signed/fake)")
+	}
+	if (p.Stripe.Enabled || p.Signed.Enabled) && strings.TrimSpace(p.NotifyBaseURL) == "" {
+		return fmt.Errorf("payment.notify_base_url is required when a real payment provider is enabled")
+	}
+	if strings.TrimSpace(p.NotifyBaseURL) != "" {
+		if err := ValidateAbsoluteHTTPURL(p.NotifyBaseURL); err != nil {
+			return fmt.Errorf("payment.notify_base_url invalid: %w", err)
+		}
+	}
+	if p.Stripe.Enabled {
+		if strings.TrimSpace(p.Stripe.SecretKey) == "" {
+			return fmt.Errorf("payment.stripe.secret_key is required when payment.stripe.enabled=true")
+		}
+		if strings.TrimSpace(p.Stripe.WebhookSecret) == "" {
+			return fmt.Errorf("payment.stripe.webhook_secret is required when payment.stripe.enabled=true")
+		}
+		if p.Stripe.WebhookToleranceSeconds <= 0 {
+			return fmt.Errorf("payment.stripe.webhook_tolerance_seconds must be positive")
+		}
+	}
+	if p.Signed.Enabled {
+		if err := ValidateAbsoluteHTTPURL(p.Signed.GatewayURL); err != nil {
+			return fmt.Errorf("payment.signed.gateway_url invalid: %w", err)
+		}
+		if strings.TrimSpace(p.Signed.MerchantID) == "" {
+			return fmt.Errorf("payment.signed.merchant_id is required when payment.signed.enabled=true")
+		}
+		if strings.TrimSpace(p.Signed.SecretKey) == "" {
+			return fmt.Errorf("payment.signed.secret_key is required when payment.signed.enabled=true")
+		}
+		switch strings.ToLower(strings.TrimSpace(p.Signed.SignType)) {
+		case "md5", "hmac-sha256":
+		default:
+			return fmt.Errorf("payment.signed.sign_type must be one of: md5/hmac-sha256")
+		}
+		if len(normalizeStringSlice(p.Signed.Methods)) == 0 {
+			return fmt.Errorf("payment.signed.methods must not be empty")
+		}
+	}
+	if p.Fake.Enabled {
+		log.Println("Warning: payment.fake.enabled=true; orders can be marked paid without real payment. Never enable it in production.")
+	}
+	return nil
+}
+
 func normalizeStringSlice(values []string) []string {
 	if len(values) == 0 {
 		return values
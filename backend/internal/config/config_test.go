@@ -850,3 +850,113 @@ func TestValidateConfigErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadPaymentDefaults(t *testing.T) {
+	viper.Reset()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Payment.Enabled {
+		t.Fatalf("Payment.Enabled = true, want false")
+	}
+	if cfg.Payment.Currency != "CNY" {
+		t.Fatalf("Payment.Currency = %q, want CNY", cfg.Payment.Currency)
+	}
+	if cfg.Payment.OrderExpireMinutes != 30 {
+		t.Fatalf("Payment.OrderExpireMinutes = %d, want 30", cfg.Payment.OrderExpireMinutes)
+	}
+	if cfg.Payment.Stripe.WebhookToleranceSeconds != 300 {
+		t.Fatalf("Payment.Stripe.WebhookToleranceSeconds = %d, want 300", cfg.Payment.Stripe.WebhookToleranceSeconds)
+	}
+	if len(cfg.Payment.Signed.Methods) != 2 {
+		t.Fatalf("Payment.Signed.Methods = %v, want [alipay wxpay]", cfg.Payment.Signed.Methods)
+	}
+}
+
+func TestValidatePaymentConfig(t *testing.T) {
+	viper.Reset()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	cfg.Payment.Enabled = true
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "at least one provider") {
+		t.Fatalf("Validate() expected provider error, got: %v", err)
+	}
+
+	cfg.Payment.Stripe.Enabled = true
+	cfg.Payment.Stripe.SecretKey = "sk_test"
+	cfg.Payment.Stripe.WebhookSecret = "whsec_test"
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "payment.notify_base_url") {
+		t.Fatalf("Validate() expected notify_base_url error, got: %v", err)
+	}
+
+	cfg.Payment.NotifyBaseURL = "https://api.example.com"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+
+	cfg.Payment.Signed.Enabled = true
+	cfg.Payment.Signed.GatewayURL = "https://pay.example.com"
+	cfg.Payment.Signed.MerchantID = "1001"
+	cfg.Payment.Signed.SecretKey = "secret"
+	cfg.Payment.Signed.SignType = "sha1"
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "payment.signed.sign_type") {
+		t.Fatalf("Validate() expected sign_type error, got: %v", err)
+	}
+}
+
+func TestLoadSubscriptionPlanDefaults(t *testing.T) {
+	viper.Reset()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if !cfg.SubPlans.AutoRenewEnabled {
+		t.Fatalf("SubPlans.AutoRenewEnabled = false, want true")
+	}
+	if cfg.SubPlans.AutoRenewIntervalSeconds != 600 {
+		t.Fatalf("SubPlans.AutoRenewIntervalSeconds = %d, want 600", cfg.SubPlans.AutoRenewIntervalSeconds)
+	}
+	if cfg.SubPlans.RenewBeforeHours != 24 {
+		t.Fatalf("SubPlans.RenewBeforeHours = %d, want 24", cfg.SubPlans.RenewBeforeHours)
+	}
+
+	cfg.SubPlans.BatchSize = 0
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "subscription_plans.batch_size") {
+		t.Fatalf("Validate() expected batch_size error, got: %v", err)
+	}
+}
+
+func TestLoadNotificationDefaults(t *testing.T) {
+	viper.Reset()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if !cfg.Notification.Enabled {
+		t.Fatalf("Notification.Enabled = false, want true")
+	}
+	if cfg.Notification.QuotaWarningPercent != 80 {
+		t.Fatalf("Notification.QuotaWarningPercent = %d, want 80", cfg.Notification.QuotaWarningPercent)
+	}
+	if cfg.Notification.LowBalanceThreshold != 1.0 {
+		t.Fatalf("Notification.LowBalanceThreshold = %v, want 1.0", cfg.Notification.LowBalanceThreshold)
+	}
+
+	cfg.Notification.QuotaWarningPercent = 100
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "notifications.quota_warning_percent") {
+		t.Fatalf("Validate() expected quota_warning_percent error, got: %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// RedactedValue 脱敏后的密钥类配置值
+const RedactedValue = "******"
+
+// reloadableSections 支持热更新的顶层配置分区；
+// 热更新时整体发布新的配置快照，服务通过 Config.Current 读取这些分区，后续请求即可看到新值
+var reloadableSections = map[string]bool{
+	"gateway":    true,
+	"rate_limit": true,
+	"pricing":    true,
+}
+
+// startupOnlyKeys 可热更新分区中只在启动时读取的配置项（路由中间件、缓存 TTL、后台任务周期等），
+// 热更新时保留旧值，修改后需重启才能生效
+var startupOnlyKeys = map[string]bool{
+	"gateway.max_body_size":                    true,
+	"gateway.anthropic_files.max_upload_size":  true,
+	"gateway.concurrency_slot_ttl_minutes":     true,
+	"gateway.session_idle_timeout_minutes":     true,
+	"gateway.scheduling.slot_cleanup_interval": true,
+	"gateway.scheduling.db_fallback_max_qps":   true,
+	"pricing.data_dir":                         true,
+	"pricing.hash_check_interval_minutes":      true,
+}
+
+// ReloadableSections 返回支持热更新的顶层配置分区（已排序）
+func ReloadableSections() []string {
+	out := make([]string, 0, len(reloadableSections))
+	for section := range reloadableSections {
+		out = append(out, section)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ReloadResult 一次热更新的结果
+type ReloadResult struct {
+	// Applied 已生效的配置项
+	Applied []string `json:"applied"`
+	// RestartRequired 已修改但需重启才能生效的配置项（如数据库 / Redis 连接、服务端口）
+	RestartRequired []string `json:"restart_required"`
+}
+
+// liveConfig 热更新发布的配置快照；快照发布后不再修改，读取方无需加锁
+type liveConfig struct {
+	snapshot atomic.Pointer[Config]
+}
+
+// EnableReload 使 c 成为可热更新的根配置，之后 ApplyReloadable 发布的快照通过 Current 读取。
+// Load 返回的配置已启用；手工构造的配置须在并发读取 Current 之前调用
+func (c *Config) EnableReload() {
+	if c.live == nil {
+		c.live = &liveConfig{}
+	}
+}
+
+// Current 返回当前生效的配置快照（尚未热更新时为根配置本身）。
+// 请求处理中读取 gateway / rate_limit / pricing 分区时应经由 Current，返回值只读，不得修改
+func (c *Config) Current() *Config {
+	if c == nil || c.live == nil {
+		return c
+	}
+	if snapshot := c.live.snapshot.Load(); snapshot != nil {
+		return snapshot
+	}
+	return c
+}
+
+// ApplyReloadable 以当前快照为基础，用 next 中可热更新的分区整体替换并保留其中只在启动时读取的配置项，
+// 生成新的快照原子发布到 current（current 与已发布的快照均不做修改）。
+// 其他分区沿用当前值，变更的配置项记入 RestartRequired。
+func ApplyReloadable(current, next *Config) *ReloadResult {
+	result := &ReloadResult{Applied: []string{}, RestartRequired: []string{}}
+	current.EnableReload()
+	base := current.Current()
+	changed := DiffKeys(base, next)
+	if len(changed) == 0 {
+		return result
+	}
+
+	snapshot := *base
+	cur := reflect.ValueOf(&snapshot).Elem()
+	nxt := reflect.ValueOf(next).Elem()
+	for i := 0; i < cur.NumField(); i++ {
+		if !cur.Type().Field(i).IsExported() {
+			continue
+		}
+		section := configKey(cur.Type().Field(i))
+		if !reloadableSections[section] {
+			continue
+		}
+		// 先保存只在启动时读取的旧值，整体替换分区后再写回
+		kept := map[string]reflect.Value{}
+		for _, key := range changed {
+			if startupOnlyKeys[key] && strings.HasPrefix(key, section+".") {
+				if v, ok := lookupConfigValue(cur, key); ok {
+					saved := reflect.New(v.Type()).Elem()
+					saved.Set(v)
+					kept[key] = saved
+				}
+			}
+		}
+		cur.Field(i).Set(nxt.Field(i))
+		for key, saved := range kept {
+			if v, ok := lookupConfigValue(cur, key); ok {
+				v.Set(saved)
+			}
+		}
+	}
+	current.live.snapshot.Store(&snapshot)
+
+	for _, key := range changed {
+		section, _, _ := strings.Cut(key, ".")
+		if reloadableSections[section] && !startupOnlyKeys[key] {
+			result.Applied = append(result.Applied, key)
+		} else {
+			result.RestartRequired = append(result.RestartRequired, key)
+		}
+	}
+	return result
+}
+
+// DiffKeys 返回两份配置中取值不同的配置项（按配置文件中的键路径，已排序）
+func DiffKeys(a, b *Config) []string {
+	var keys []string
+	diffConfigValue("", reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem(), &keys)
+	sort.Strings(keys)
+	return keys
+}
+
+func diffConfigValue(prefix string, a, b reflect.Value, keys *[]string) {
+	if a.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*keys = append(*keys, prefix)
+		}
+		return
+	}
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		diffConfigValue(joinConfigKey(prefix, configKey(field)), a.Field(i), b.Field(i), keys)
+	}
+}
+
+func lookupConfigValue(v reflect.Value, key string) (reflect.Value, bool) {
+	for _, part := range strings.Split(key, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+		found := false
+		for i := 0; i < v.NumField(); i++ {
+			if configKey(v.Type().Field(i)) == part {
+				v = v.Field(i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}, false
+		}
+	}
+	return v, true
+}
+
+// Redact 按配置文件的键结构导出配置，密钥、密码类字段替换为 RedactedValue，URL 中的密码同样隐藏
+func Redact(cfg *Config) map[string]any {
+	if cfg == nil {
+		return map[string]any{}
+	}
+	out, _ := redactConfigValue(reflect.ValueOf(cfg).Elem()).(map[string]any)
+	return out
+}
+
+func redactConfigValue(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Struct:
+		out := make(map[string]any, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			key := configKey(field)
+			if isSecretConfigKey(key) && field.Type.Kind() != reflect.Struct {
+				if !v.Field(i).IsZero() {
+					out[key] = RedactedValue
+				} else {
+					out[key] = ""
+				}
+				continue
+			}
+			out[key] = redactConfigValue(v.Field(i))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return map[string]any{}
+		}
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = redactConfigValue(iter.Value())
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return []any{}
+		}
+		out := make([]any, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out = append(out, redactConfigValue(v.Index(i)))
+		}
+		return out
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return redactConfigValue(v.Elem())
+	case reflect.String:
+		return redactURLPassword(v.String())
+	default:
+		if d, ok := v.Interface().(time.Duration); ok {
+			return d.String()
+		}
+		return v.Interface()
+	}
+}
+
+// isSecretConfigKey 判断配置项是否为密钥类字段（secret / password / 主密钥列表）；分区本身（如 secret_encryption）不脱敏
+func isSecretConfigKey(key string) bool {
+	return strings.Contains(key, "secret") || strings.Contains(key, "password") || key == "keys"
+}
+
+// redactURLPassword 隐藏 URL（如代理地址）中的密码
+func redactURLPassword(raw string) string {
+	if !strings.Contains(raw, "@") || !strings.Contains(raw, "://") {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	if _, has := u.User.Password(); has {
+		u.User = url.UserPassword(u.User.Username(), RedactedValue)
+	}
+	return u.String()
+}
+
+func configKey(field reflect.StructField) string {
+	if tag := field.Tag.Get("mapstructure"); tag != "" && tag != "-" {
+		name, _, _ := strings.Cut(tag, ",")
+		return name
+	}
+	return strings.ToLower(field.Name)
+}
+
+func joinConfigKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
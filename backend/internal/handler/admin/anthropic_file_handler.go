@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnthropicFileHandler handles files uploaded by users through the Files API
+type AnthropicFileHandler struct {
+	fileService *service.AnthropicFileService
+}
+
+// NewAnthropicFileHandler creates a new admin Files API handler
+func NewAnthropicFileHandler(fileService *service.AnthropicFileService) *AnthropicFileHandler {
+	return &AnthropicFileHandler{fileService: fileService}
+}
+
+// List handles listing uploaded files
+// GET /api/v1/admin/anthropic-files?user_id=&account_id=
+func (h *AnthropicFileHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	var filters service.AnthropicFileFilters
+	for name, target := range map[string]*int64{
+		"user_id":    &filters.UserID,
+		"account_id": &filters.AccountID,
+	} {
+		v := strings.TrimSpace(c.Query(name))
+		if v == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.BadRequest(c, "Invalid "+name)
+			return
+		}
+		*target = id
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	files, result, err := h.fileService.AdminList(c.Request.Context(), params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.AnthropicFile, 0, len(files))
+	for i := range files {
+		out = append(out, *dto.AnthropicFileFromService(&files[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// Delete handles deleting a file upstream and its ownership record
+// DELETE /api/v1/admin/anthropic-files/:file_id
+func (h *AnthropicFileHandler) Delete(c *gin.Context) {
+	fileID := strings.TrimSpace(c.Param("file_id"))
+	if fileID == "" {
+		response.BadRequest(c, "Invalid file ID")
+		return
+	}
+	if err := h.fileService.Purge(c.Request.Context(), fileID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"deleted": true})
+}
+
+// PurgeUser handles deleting all files uploaded by a user
+// DELETE /api/v1/admin/users/:id/anthropic-files
+func (h *AnthropicFileHandler) PurgeUser(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+	deleted, err := h.fileService.PurgeUser(c.Request.Context(), userID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"deleted": deleted})
+}
@@ -0,0 +1,92 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BillingOutboxHandler handles the admin report of unapplied and failed charges
+type BillingOutboxHandler struct {
+	outboxService *service.BillingOutboxService
+}
+
+// NewBillingOutboxHandler creates a new admin billing outbox handler
+func NewBillingOutboxHandler(outboxService *service.BillingOutboxService) *BillingOutboxHandler {
+	return &BillingOutboxHandler{outboxService: outboxService}
+}
+
+// List handles listing billing events
+// GET /api/v1/admin/billing/outbox
+func (h *BillingOutboxHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	filters := service.BillingOutboxListFilters{
+		Status: strings.TrimSpace(c.Query("status")),
+	}
+	switch filters.Status {
+	case "", service.BillingOutboxStatusPending, service.BillingOutboxStatusApplied, service.BillingOutboxStatusFailed:
+	default:
+		response.BadRequest(c, "Invalid status, use pending, applied or failed")
+		return
+	}
+	if v := strings.TrimSpace(c.Query("user_id")); v != "" {
+		userID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || userID <= 0 {
+			response.BadRequest(c, "Invalid user_id")
+			return
+		}
+		filters.UserID = userID
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	events, result, err := h.outboxService.List(c.Request.Context(), params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.BillingOutboxEvent, 0, len(events))
+	for i := range events {
+		out = append(out, *dto.BillingOutboxEventFromService(&events[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// Summary handles summarizing pending and dead-lettered charges
+// GET /api/v1/admin/billing/outbox/summary
+func (h *BillingOutboxHandler) Summary(c *gin.Context) {
+	summary, err := h.outboxService.Summary(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.BillingOutboxStatusSummary, 0, len(summary))
+	for i := range summary {
+		out = append(out, *dto.BillingOutboxStatusSummaryFromService(&summary[i]))
+	}
+	response.Success(c, out)
+}
+
+// Retry handles re-queuing a dead-lettered charge
+// POST /api/v1/admin/billing/outbox/:id/retry
+func (h *BillingOutboxHandler) Retry(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid billing event ID")
+		return
+	}
+
+	event, err := h.outboxService.Retry(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.BillingOutboxEventFromService(event))
+}
@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigHandler handles viewing and hot-reloading the server config file
+type ConfigHandler struct {
+	configReloadService *service.ConfigReloadService
+}
+
+// NewConfigHandler creates a new admin config handler
+func NewConfigHandler(configReloadService *service.ConfigReloadService) *ConfigHandler {
+	return &ConfigHandler{configReloadService: configReloadService}
+}
+
+// GetEffective returns the effective config with secrets redacted
+// GET /api/v1/admin/system/config
+func (h *ConfigHandler) GetEffective(c *gin.Context) {
+	response.Success(c, gin.H{
+		"config":              h.configReloadService.EffectiveConfig(),
+		"reloadable_sections": config.ReloadableSections(),
+		"last_reload":         dto.ConfigReloadStatusFromService(h.configReloadService.LastStatus()),
+	})
+}
+
+// Reload re-reads the config file and applies the hot-reloadable sections
+// POST /api/v1/admin/system/config/reload
+func (h *ConfigHandler) Reload(c *gin.Context) {
+	if _, err := h.configReloadService.Reload(service.ConfigReloadTriggerManual); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.ConfigReloadStatusFromService(h.configReloadService.LastStatus()))
+}
@@ -43,6 +43,14 @@ type CreateGroupRequest struct {
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64 `json:"model_routing"`
 	ModelRoutingEnabled bool               `json:"model_routing_enabled"`
+	// 预扣费用透支容忍额度 (USD)
+	OverdraftUSD float64 `json:"overdraft_usd" binding:"min=0"`
+	// count_tokens 计数方式：upstream（转发上游）/ local（本地估算）/ local_fallback（本地估算，无法可靠估算时转发上游）
+	CountTokensMode string `json:"count_tokens_mode" binding:"omitempty,oneof=upstream local local_fallback"`
+	// 首字节过慢时是否向第二个账号发送对冲请求
+	HedgeEnabled bool `json:"hedge_enabled"`
+	// 模型降级链：触发条件 + 模型模式 -> 有序降级模型列表
+	ModelFallback *service.ModelFallbackConfig `json:"model_fallback"`
 }
 
 // UpdateGroupRequest represents update group request
@@ -66,6 +74,14 @@ type UpdateGroupRequest struct {
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64 `json:"model_routing"`
 	ModelRoutingEnabled *bool              `json:"model_routing_enabled"`
+	// 预扣费用透支容忍额度 (USD)
+	OverdraftUSD *float64 `json:"overdraft_usd" binding:"omitempty,min=0"`
+	// count_tokens 计数方式：upstream（转发上游）/ local（本地估算）/ local_fallback（本地估算，无法可靠估算时转发上游）
+	CountTokensMode *string `json:"count_tokens_mode" binding:"omitempty,oneof=upstream local local_fallback"`
+	// 首字节过慢时是否向第二个账号发送对冲请求
+	HedgeEnabled *bool `json:"hedge_enabled"`
+	// 模型降级链：不传表示不修改，chains 为空表示清除
+	ModelFallback *service.ModelFallbackConfig `json:"model_fallback"`
 }
 
 // List handles listing all groups with pagination
@@ -171,6 +187,10 @@ func (h *GroupHandler) Create(c *gin.Context) {
 		FallbackGroupID:     req.FallbackGroupID,
 		ModelRouting:        req.ModelRouting,
 		ModelRoutingEnabled: req.ModelRoutingEnabled,
+		OverdraftUSD:        req.OverdraftUSD,
+		CountTokensMode:     req.CountTokensMode,
+		HedgeEnabled:        req.HedgeEnabled,
+		ModelFallback:       req.ModelFallback,
 	})
 	if err != nil {
 		response.ErrorFrom(c, err)
@@ -213,6 +233,10 @@ func (h *GroupHandler) Update(c *gin.Context) {
 		FallbackGroupID:     req.FallbackGroupID,
 		ModelRouting:        req.ModelRouting,
 		ModelRoutingEnabled: req.ModelRoutingEnabled,
+		OverdraftUSD:        req.OverdraftUSD,
+		CountTokensMode:     req.CountTokensMode,
+		HedgeEnabled:        req.HedgeEnabled,
+		ModelFallback:       req.ModelFallback,
 	})
 	if err != nil {
 		response.ErrorFrom(c, err)
@@ -0,0 +1,164 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GuardrailHandler handles admin content guardrail policies and hit records
+type GuardrailHandler struct {
+	guardrailService *service.GuardrailService
+}
+
+// NewGuardrailHandler creates a new admin guardrail handler
+func NewGuardrailHandler(guardrailService *service.GuardrailService) *GuardrailHandler {
+	return &GuardrailHandler{guardrailService: guardrailService}
+}
+
+// SetGuardrailPolicyRequest represents a guardrail policy; leave group_id and api_key_id empty for a global policy
+type SetGuardrailPolicyRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	GroupID       *int64   `json:"group_id"`
+	APIKeyID      *int64   `json:"api_key_id"`
+	Enabled       *bool    `json:"enabled"`
+	Action        string   `json:"action" binding:"required,oneof=block redact log"`
+	Detectors     []string `json:"detectors"`
+	Keywords      []string `json:"keywords"`
+	Patterns      []string `json:"patterns"`
+	HookURL       string   `json:"hook_url"`
+	ScanResponses bool     `json:"scan_responses"`
+}
+
+func (r *SetGuardrailPolicyRequest) toInput() *service.SetGuardrailPolicyInput {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	return &service.SetGuardrailPolicyInput{
+		Name:          r.Name,
+		GroupID:       r.GroupID,
+		APIKeyID:      r.APIKeyID,
+		Enabled:       enabled,
+		Action:        r.Action,
+		Detectors:     r.Detectors,
+		Keywords:      r.Keywords,
+		Patterns:      r.Patterns,
+		HookURL:       r.HookURL,
+		ScanResponses: r.ScanResponses,
+	}
+}
+
+// ListPolicies handles listing guardrail policies
+// GET /api/v1/admin/guardrails/policies
+func (h *GuardrailHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.guardrailService.ListPolicies(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]dto.GuardrailPolicy, 0, len(policies))
+	for i := range policies {
+		out = append(out, *dto.GuardrailPolicyFromService(&policies[i]))
+	}
+	response.Success(c, out)
+}
+
+// CreatePolicy handles creating a guardrail policy
+// POST /api/v1/admin/guardrails/policies
+func (h *GuardrailHandler) CreatePolicy(c *gin.Context) {
+	var req SetGuardrailPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	policy, err := h.guardrailService.CreatePolicy(c.Request.Context(), req.toInput())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.GuardrailPolicyFromService(policy))
+}
+
+// UpdatePolicy handles updating a guardrail policy
+// PUT /api/v1/admin/guardrails/policies/:id
+func (h *GuardrailHandler) UpdatePolicy(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid policy ID")
+		return
+	}
+	var req SetGuardrailPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	policy, err := h.guardrailService.UpdatePolicy(c.Request.Context(), id, req.toInput())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.GuardrailPolicyFromService(policy))
+}
+
+// DeletePolicy handles deleting a guardrail policy
+// DELETE /api/v1/admin/guardrails/policies/:id
+func (h *GuardrailHandler) DeletePolicy(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid policy ID")
+		return
+	}
+	if err := h.guardrailService.DeletePolicy(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Guardrail policy deleted successfully"})
+}
+
+// ListEvents handles listing guardrail hit records
+// GET /api/v1/admin/guardrails/events?policy_id=&api_key_id=&group_id=&direction=&start_date=&end_date=
+func (h *GuardrailHandler) ListEvents(c *gin.Context) {
+	filters := service.GuardrailEventFilters{Direction: strings.TrimSpace(c.Query("direction"))}
+	for name, target := range map[string]**int64{
+		"policy_id":  &filters.PolicyID,
+		"api_key_id": &filters.APIKeyID,
+		"group_id":   &filters.GroupID,
+	} {
+		v := strings.TrimSpace(c.Query(name))
+		if v == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.BadRequest(c, "Invalid "+name)
+			return
+		}
+		*target = &id
+	}
+	if c.Query("start_date") != "" || c.Query("end_date") != "" {
+		startTime, endTime := parseTimeRange(c)
+		filters.StartTime = &startTime
+		filters.EndTime = &endTime
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	events, result, err := h.guardrailService.ListEvents(c.Request.Context(), params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.GuardrailEvent, 0, len(events))
+	for i := range events {
+		out = append(out, *dto.GuardrailEventFromService(&events[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
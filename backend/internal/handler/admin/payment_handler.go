@@ -0,0 +1,156 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentHandler handles admin payment order management
+type PaymentHandler struct {
+	paymentService *service.PaymentService
+}
+
+// NewPaymentHandler creates a new admin payment handler
+func NewPaymentHandler(paymentService *service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+	}
+}
+
+// CreatePaymentOrderRequest represents admin create payment order request
+type CreatePaymentOrderRequest struct {
+	UserID       int64    `json:"user_id" binding:"required,gt=0"`
+	Provider     string   `json:"provider" binding:"required"`
+	Method       string   `json:"method"`
+	OrderType    string   `json:"order_type" binding:"omitempty,oneof=balance subscription"`
+	Amount       float64  `json:"amount" binding:"required,gt=0"`
+	CreditAmount *float64 `json:"credit_amount" binding:"omitempty,gt=0"` // 余额订单到账金额，默认按汇率换算
+	GroupID      *int64   `json:"group_id"`                               // 订阅订单必填
+	ValidityDays int      `json:"validity_days"`                          // 订阅订单必填
+	Notes        string   `json:"notes"`
+}
+
+// RefundPaymentOrderRequest represents refund request
+type RefundPaymentOrderRequest struct {
+	Amount float64 `json:"amount" binding:"min=0"` // 0 表示退还全部剩余金额
+	Reason string  `json:"reason"`
+}
+
+// List handles listing payment orders with pagination
+// GET /api/v1/admin/payments/orders
+func (h *PaymentHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	filters := service.PaymentOrderFilters{
+		Status:    strings.TrimSpace(c.Query("status")),
+		Provider:  strings.TrimSpace(c.Query("provider")),
+		OrderType: strings.TrimSpace(c.Query("order_type")),
+	}
+	if v := strings.TrimSpace(c.Query("user_id")); v != "" {
+		userID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid user_id")
+			return
+		}
+		filters.UserID = &userID
+	}
+
+	orders, paginationResult, err := h.paymentService.List(c.Request.Context(), params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.AdminPaymentOrder, 0, len(orders))
+	for i := range orders {
+		out = append(out, *dto.PaymentOrderFromServiceAdmin(&orders[i]))
+	}
+	response.Paginated(c, out, paginationResult.Total, page, pageSize)
+}
+
+// GetByID handles getting a payment order by ID
+// GET /api/v1/admin/payments/orders/:id
+func (h *PaymentHandler) GetByID(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid order ID")
+		return
+	}
+
+	order, err := h.paymentService.GetByID(c.Request.Context(), orderID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.PaymentOrderFromServiceAdmin(order))
+}
+
+// Create handles creating a payment order on behalf of a user
+// POST /api/v1/admin/payments/orders
+func (h *PaymentHandler) Create(c *gin.Context) {
+	var req CreatePaymentOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	var operatorID int64
+	if subject, ok := middleware.GetAuthSubjectFromContext(c); ok {
+		operatorID = subject.UserID
+	}
+
+	order, err := h.paymentService.AdminCreateOrder(c.Request.Context(), operatorID, &service.AdminCreatePaymentOrderInput{
+		UserID:       req.UserID,
+		Provider:     req.Provider,
+		Method:       req.Method,
+		OrderType:    req.OrderType,
+		Amount:       req.Amount,
+		CreditAmount: req.CreditAmount,
+		GroupID:      req.GroupID,
+		ValidityDays: req.ValidityDays,
+		Notes:        req.Notes,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.PaymentOrderFromServiceAdmin(order))
+}
+
+// Refund handles refunding a paid order (full or partial)
+// POST /api/v1/admin/payments/orders/:id/refund
+func (h *PaymentHandler) Refund(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid order ID")
+		return
+	}
+
+	var req RefundPaymentOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	order, err := h.paymentService.RefundOrder(c.Request.Context(), orderID, req.Amount, strings.TrimSpace(req.Reason))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.PaymentOrderFromServiceAdmin(order))
+}
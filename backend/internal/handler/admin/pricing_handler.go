@@ -0,0 +1,239 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PricingHandler handles admin model price overrides and cost preview
+type PricingHandler struct {
+	overrideService *service.ModelPriceOverrideService
+	billingService  *service.BillingService
+	adminService    service.AdminService
+}
+
+// NewPricingHandler creates a new admin pricing handler
+func NewPricingHandler(overrideService *service.ModelPriceOverrideService, billingService *service.BillingService, adminService service.AdminService) *PricingHandler {
+	return &PricingHandler{
+		overrideService: overrideService,
+		billingService:  billingService,
+		adminService:    adminService,
+	}
+}
+
+// CreatePriceOverrideRequest represents a new price version (USD per million tokens)
+type CreatePriceOverrideRequest struct {
+	ModelPattern       string  `json:"model_pattern" binding:"required"`
+	GroupID            *int64  `json:"group_id"`
+	InputPrice         float64 `json:"input_price"`
+	OutputPrice        float64 `json:"output_price"`
+	CacheCreationPrice float64 `json:"cache_creation_price"`
+	CacheReadPrice     float64 `json:"cache_read_price"`
+	// CacheCreation1hPrice 0 means 2x input price
+	CacheCreation1hPrice float64 `json:"cache_creation_1h_price"`
+	// EffectiveFrom RFC3339; empty means effective immediately
+	EffectiveFrom string `json:"effective_from"`
+	Notes         string `json:"notes"`
+}
+
+// UpdateUnknownPricePolicyRequest represents the unknown price policy (USD per million tokens)
+type UpdateUnknownPricePolicyRequest struct {
+	Mode                 string  `json:"mode" binding:"required,oneof=block default free"`
+	InputPrice           float64 `json:"input_price"`
+	OutputPrice          float64 `json:"output_price"`
+	CacheCreationPrice   float64 `json:"cache_creation_price"`
+	CacheReadPrice       float64 `json:"cache_read_price"`
+	CacheCreation1hPrice float64 `json:"cache_creation_1h_price"`
+}
+
+// PricingPreviewRequest represents a sample usage to price
+type PricingPreviewRequest struct {
+	Model               string `json:"model" binding:"required"`
+	GroupID             *int64 `json:"group_id"`
+	InputTokens         int    `json:"input_tokens" binding:"min=0"`
+	OutputTokens        int    `json:"output_tokens" binding:"min=0"`
+	CacheCreationTokens int    `json:"cache_creation_tokens" binding:"min=0"`
+	CacheReadTokens     int    `json:"cache_read_tokens" binding:"min=0"`
+	// CacheCreation1hTokens 1小时缓存写入部分（包含在 cache_creation_tokens 中）
+	CacheCreation1hTokens int `json:"cache_creation_1h_tokens" binding:"min=0"`
+	// RateMultiplier 为空时使用分组倍率（未指定分组时使用默认倍率）
+	RateMultiplier *float64 `json:"rate_multiplier"`
+}
+
+// ListOverrides handles listing price overrides including history
+// GET /api/v1/admin/pricing/overrides
+func (h *PricingHandler) ListOverrides(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	filters := service.ModelPriceOverrideListFilters{
+		Search:         strings.TrimSpace(c.Query("search")),
+		IncludeDeleted: c.Query("include_deleted") == "true",
+	}
+	if len(filters.Search) > 100 {
+		filters.Search = filters.Search[:100]
+	}
+	if v := strings.TrimSpace(c.Query("group_id")); v != "" {
+		groupID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid group_id")
+			return
+		}
+		filters.GroupID = &groupID
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	overrides, result, err := h.overrideService.List(c.Request.Context(), params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.ModelPriceOverride, 0, len(overrides))
+	for i := range overrides {
+		out = append(out, *dto.ModelPriceOverrideFromService(&overrides[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// CreateOverride handles adding a price version
+// POST /api/v1/admin/pricing/overrides
+func (h *PricingHandler) CreateOverride(c *gin.Context) {
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok || subject.UserID <= 0 {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req CreatePriceOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	input := &service.CreateModelPriceOverrideInput{
+		ModelPattern:         req.ModelPattern,
+		GroupID:              req.GroupID,
+		InputPrice:           req.InputPrice,
+		OutputPrice:          req.OutputPrice,
+		CacheCreationPrice:   req.CacheCreationPrice,
+		CacheReadPrice:       req.CacheReadPrice,
+		CacheCreation1hPrice: req.CacheCreation1hPrice,
+		Notes:                req.Notes,
+	}
+	if v := strings.TrimSpace(req.EffectiveFrom); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			response.BadRequest(c, "Invalid effective_from, use RFC3339")
+			return
+		}
+		input.EffectiveFrom = &t
+	}
+	if req.GroupID != nil {
+		if _, err := h.adminService.GetGroup(c.Request.Context(), *req.GroupID); err != nil {
+			response.ErrorFrom(c, err)
+			return
+		}
+	}
+
+	override, err := h.overrideService.Create(c.Request.Context(), input, subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.ModelPriceOverrideFromService(override))
+}
+
+// DeleteOverride handles soft-deleting a price version
+// DELETE /api/v1/admin/pricing/overrides/:id
+func (h *PricingHandler) DeleteOverride(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid override ID")
+		return
+	}
+	if err := h.overrideService.Delete(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Price override deleted successfully"})
+}
+
+// GetUnknownPolicy handles getting the unknown price policy
+// GET /api/v1/admin/pricing/unknown-policy
+func (h *PricingHandler) GetUnknownPolicy(c *gin.Context) {
+	policy, err := h.overrideService.GetPolicy(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, policy)
+}
+
+// UpdateUnknownPolicy handles updating the unknown price policy
+// PUT /api/v1/admin/pricing/unknown-policy
+func (h *PricingHandler) UpdateUnknownPolicy(c *gin.Context) {
+	var req UpdateUnknownPricePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	policy, err := h.overrideService.UpdatePolicy(c.Request.Context(), &service.UnknownPricePolicy{
+		Mode:                 req.Mode,
+		InputPrice:           req.InputPrice,
+		OutputPrice:          req.OutputPrice,
+		CacheCreationPrice:   req.CacheCreationPrice,
+		CacheReadPrice:       req.CacheReadPrice,
+		CacheCreation1hPrice: req.CacheCreation1hPrice,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, policy)
+}
+
+// Preview handles pricing a sample usage without recording it
+// POST /api/v1/admin/pricing/preview
+func (h *PricingHandler) Preview(c *gin.Context) {
+	var req PricingPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	var multiplier float64
+	if req.RateMultiplier != nil {
+		multiplier = *req.RateMultiplier
+	} else if req.GroupID != nil {
+		group, err := h.adminService.GetGroup(c.Request.Context(), *req.GroupID)
+		if err != nil {
+			response.ErrorFrom(c, err)
+			return
+		}
+		multiplier = group.RateMultiplier
+	}
+
+	model := strings.TrimSpace(req.Model)
+	preview, err := h.billingService.PreviewCost(model, req.GroupID, service.UsageTokens{
+		InputTokens:           req.InputTokens,
+		OutputTokens:          req.OutputTokens,
+		CacheCreationTokens:   req.CacheCreationTokens,
+		CacheReadTokens:       req.CacheReadTokens,
+		CacheCreation1hTokens: req.CacheCreation1hTokens,
+	}, multiplier)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.PricingPreviewFromService(model, preview))
+}
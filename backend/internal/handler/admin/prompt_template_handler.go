@@ -0,0 +1,114 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PromptTemplateHandler handles admin system prompt templates
+type PromptTemplateHandler struct {
+	promptTemplateService *service.PromptTemplateService
+}
+
+// NewPromptTemplateHandler creates a new admin prompt template handler
+func NewPromptTemplateHandler(promptTemplateService *service.PromptTemplateService) *PromptTemplateHandler {
+	return &PromptTemplateHandler{promptTemplateService: promptTemplateService}
+}
+
+// SetPromptTemplateRequest represents a prompt template; leave group_id and api_key_id empty for a global template.
+// Content supports {{user_email}}, {{key_name}} and {{date}}.
+type SetPromptTemplateRequest struct {
+	Name     string `json:"name" binding:"required"`
+	GroupID  *int64 `json:"group_id"`
+	APIKeyID *int64 `json:"api_key_id"`
+	Enabled  *bool  `json:"enabled"`
+	Mode     string `json:"mode" binding:"required,oneof=prepend append replace"`
+	Content  string `json:"content"`
+}
+
+func (r *SetPromptTemplateRequest) toInput() *service.SetPromptTemplateInput {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	return &service.SetPromptTemplateInput{
+		Name:     r.Name,
+		GroupID:  r.GroupID,
+		APIKeyID: r.APIKeyID,
+		Enabled:  enabled,
+		Mode:     r.Mode,
+		Content:  r.Content,
+	}
+}
+
+// List handles listing prompt templates
+// GET /api/v1/admin/prompt-templates
+func (h *PromptTemplateHandler) List(c *gin.Context) {
+	templates, err := h.promptTemplateService.List(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]dto.PromptTemplate, 0, len(templates))
+	for i := range templates {
+		out = append(out, *dto.PromptTemplateFromService(&templates[i]))
+	}
+	response.Success(c, out)
+}
+
+// Create handles creating a prompt template
+// POST /api/v1/admin/prompt-templates
+func (h *PromptTemplateHandler) Create(c *gin.Context) {
+	var req SetPromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	template, err := h.promptTemplateService.Create(c.Request.Context(), req.toInput())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.PromptTemplateFromService(template))
+}
+
+// Update handles updating a prompt template
+// PUT /api/v1/admin/prompt-templates/:id
+func (h *PromptTemplateHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid template ID")
+		return
+	}
+	var req SetPromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	template, err := h.promptTemplateService.Update(c.Request.Context(), id, req.toInput())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.PromptTemplateFromService(template))
+}
+
+// Delete handles deleting a prompt template
+// DELETE /api/v1/admin/prompt-templates/:id
+func (h *PromptTemplateHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid template ID")
+		return
+	}
+	if err := h.promptTemplateService.Delete(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Prompt template deleted successfully"})
+}
@@ -0,0 +1,234 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateMultiplierHandler handles admin per-user/API-key rate multiplier overrides and volume discount tiers
+type RateMultiplierHandler struct {
+	rateMultiplierService *service.RateMultiplierService
+}
+
+// NewRateMultiplierHandler creates a new admin rate multiplier handler
+func NewRateMultiplierHandler(rateMultiplierService *service.RateMultiplierService) *RateMultiplierHandler {
+	return &RateMultiplierHandler{rateMultiplierService: rateMultiplierService}
+}
+
+// SetRateMultiplierOverrideRequest represents an override; exactly one of user_id / api_key_id is required
+type SetRateMultiplierOverrideRequest struct {
+	GroupID        int64   `json:"group_id" binding:"required"`
+	UserID         *int64  `json:"user_id"`
+	APIKeyID       *int64  `json:"api_key_id"`
+	RateMultiplier float64 `json:"rate_multiplier" binding:"gt=0"`
+	Notes          string  `json:"notes"`
+}
+
+// SetVolumeDiscountTierRequest represents a volume discount tier; empty group_id means all groups
+type SetVolumeDiscountTierRequest struct {
+	GroupID         *int64  `json:"group_id"`
+	MinMonthlySpend float64 `json:"min_monthly_spend" binding:"gt=0"`
+	DiscountPercent float64 `json:"discount_percent" binding:"gt=0,lt=100"`
+	Notes           string  `json:"notes"`
+}
+
+// RateMultiplierPreviewRequest represents proposed rules to compare against the current ones.
+// Omitted overrides / tiers keep the current rules; an empty list removes them.
+type RateMultiplierPreviewRequest struct {
+	GroupID   *int64                              `json:"group_id"`
+	UserID    *int64                              `json:"user_id"`
+	Overrides *[]SetRateMultiplierOverrideRequest `json:"overrides"`
+	Tiers     *[]SetVolumeDiscountTierRequest     `json:"tiers"`
+}
+
+// ListOverrides handles listing rate multiplier overrides
+// GET /api/v1/admin/rate-multipliers/overrides
+func (h *RateMultiplierHandler) ListOverrides(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	var filters service.RateMultiplierOverrideFilters
+	for name, target := range map[string]**int64{
+		"group_id":   &filters.GroupID,
+		"user_id":    &filters.UserID,
+		"api_key_id": &filters.APIKeyID,
+	} {
+		v := strings.TrimSpace(c.Query(name))
+		if v == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid "+name)
+			return
+		}
+		*target = &id
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	overrides, result, err := h.rateMultiplierService.ListOverrides(c.Request.Context(), params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.RateMultiplierOverride, 0, len(overrides))
+	for i := range overrides {
+		out = append(out, *dto.RateMultiplierOverrideFromService(&overrides[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// SetOverride handles creating or replacing an override
+// POST /api/v1/admin/rate-multipliers/overrides
+func (h *RateMultiplierHandler) SetOverride(c *gin.Context) {
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok || subject.UserID <= 0 {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req SetRateMultiplierOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	override, err := h.rateMultiplierService.SetOverride(c.Request.Context(), &service.SetRateMultiplierOverrideInput{
+		GroupID:        req.GroupID,
+		UserID:         req.UserID,
+		APIKeyID:       req.APIKeyID,
+		RateMultiplier: req.RateMultiplier,
+		Notes:          req.Notes,
+	}, subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.RateMultiplierOverrideFromService(override))
+}
+
+// DeleteOverride handles deleting an override
+// DELETE /api/v1/admin/rate-multipliers/overrides/:id
+func (h *RateMultiplierHandler) DeleteOverride(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid override ID")
+		return
+	}
+	if err := h.rateMultiplierService.DeleteOverride(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Rate multiplier override deleted successfully"})
+}
+
+// ListTiers handles listing volume discount tiers
+// GET /api/v1/admin/rate-multipliers/tiers
+func (h *RateMultiplierHandler) ListTiers(c *gin.Context) {
+	tiers, err := h.rateMultiplierService.ListTiers(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]dto.VolumeDiscountTier, 0, len(tiers))
+	for i := range tiers {
+		out = append(out, *dto.VolumeDiscountTierFromService(&tiers[i]))
+	}
+	response.Success(c, out)
+}
+
+// SetTier handles creating or replacing a volume discount tier
+// POST /api/v1/admin/rate-multipliers/tiers
+func (h *RateMultiplierHandler) SetTier(c *gin.Context) {
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok || subject.UserID <= 0 {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req SetVolumeDiscountTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	tier, err := h.rateMultiplierService.SetTier(c.Request.Context(), &service.SetVolumeDiscountTierInput{
+		GroupID:         req.GroupID,
+		MinMonthlySpend: req.MinMonthlySpend,
+		DiscountPercent: req.DiscountPercent,
+		Notes:           req.Notes,
+	}, subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.VolumeDiscountTierFromService(tier))
+}
+
+// DeleteTier handles deleting a volume discount tier
+// DELETE /api/v1/admin/rate-multipliers/tiers/:id
+func (h *RateMultiplierHandler) DeleteTier(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid tier ID")
+		return
+	}
+	if err := h.rateMultiplierService.DeleteTier(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Volume discount tier deleted successfully"})
+}
+
+// Preview handles re-pricing last month's usage under current and proposed rules
+// POST /api/v1/admin/rate-multipliers/preview
+func (h *RateMultiplierHandler) Preview(c *gin.Context) {
+	var req RateMultiplierPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	input := &service.RateMultiplierPreviewInput{GroupID: req.GroupID, UserID: req.UserID}
+	if req.Overrides != nil {
+		// 拟议规则没有 ID，按序号生成临时 ID 以便区分规则来源
+		overrides := make([]service.RateMultiplierOverride, 0, len(*req.Overrides))
+		for i, o := range *req.Overrides {
+			overrides = append(overrides, service.RateMultiplierOverride{
+				ID:             int64(i + 1),
+				GroupID:        o.GroupID,
+				UserID:         o.UserID,
+				APIKeyID:       o.APIKeyID,
+				RateMultiplier: o.RateMultiplier,
+			})
+		}
+		input.Overrides = &overrides
+	}
+	if req.Tiers != nil {
+		tiers := make([]service.VolumeDiscountTier, 0, len(*req.Tiers))
+		for i, t := range *req.Tiers {
+			tiers = append(tiers, service.VolumeDiscountTier{
+				ID:              int64(i + 1),
+				GroupID:         t.GroupID,
+				MinMonthlySpend: t.MinMonthlySpend,
+				DiscountPercent: t.DiscountPercent,
+			})
+		}
+		input.Tiers = &tiers
+	}
+
+	preview, err := h.rateMultiplierService.Preview(c.Request.Context(), input)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.RateMultiplierPreviewFromService(preview))
+}
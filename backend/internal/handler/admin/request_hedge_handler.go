@@ -0,0 +1,30 @@
+package admin
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestHedgeHandler handles request hedging stats in the ops dashboard
+type RequestHedgeHandler struct {
+	requestHedgeService *service.RequestHedgeService
+}
+
+// NewRequestHedgeHandler creates a new admin request hedge handler
+func NewRequestHedgeHandler(requestHedgeService *service.RequestHedgeService) *RequestHedgeHandler {
+	return &RequestHedgeHandler{requestHedgeService: requestHedgeService}
+}
+
+// Stats handles listing per-group hedge counts, current hedge delay and remaining budget
+// GET /api/v1/admin/ops/hedging
+func (h *RequestHedgeHandler) Stats(c *gin.Context) {
+	stats := h.requestHedgeService.Stats()
+	out := make([]dto.RequestHedgeStats, 0, len(stats))
+	for i := range stats {
+		out = append(out, *dto.RequestHedgeStatsFromService(&stats[i]))
+	}
+	response.Success(c, out)
+}
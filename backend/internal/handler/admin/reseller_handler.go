@@ -0,0 +1,87 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResellerHandler handles admin reseller management
+type ResellerHandler struct {
+	resellerService *service.ResellerService
+}
+
+// NewResellerHandler creates a new admin reseller handler
+func NewResellerHandler(resellerService *service.ResellerService) *ResellerHandler {
+	return &ResellerHandler{
+		resellerService: resellerService,
+	}
+}
+
+// UpsertResellerRequest represents grant/update reseller request (omitted fields stay unchanged)
+type UpsertResellerRequest struct {
+	Markup      *float64 `json:"markup"`
+	MaxSubUsers *int     `json:"max_sub_users"`
+	Status      *string  `json:"status" binding:"omitempty,oneof=active disabled"`
+	Notes       *string  `json:"notes"`
+}
+
+// List handles listing resellers
+// GET /api/v1/admin/resellers
+func (h *ResellerHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	filters := service.ResellerListFilters{
+		Status: strings.TrimSpace(c.Query("status")),
+		Search: strings.TrimSpace(c.Query("search")),
+	}
+	if len(filters.Search) > 100 {
+		filters.Search = filters.Search[:100]
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	resellers, result, err := h.resellerService.AdminList(c.Request.Context(), params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.AdminReseller, 0, len(resellers))
+	for i := range resellers {
+		out = append(out, *dto.ResellerFromServiceAdmin(&resellers[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// Upsert handles granting or updating a user's reseller tier
+// PUT /api/v1/admin/resellers/:user_id
+func (h *ResellerHandler) Upsert(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var req UpsertResellerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	reseller, err := h.resellerService.AdminUpsert(c.Request.Context(), userID, &service.UpsertResellerInput{
+		Markup:      req.Markup,
+		MaxSubUsers: req.MaxSubUsers,
+		Status:      req.Status,
+		Notes:       req.Notes,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.ResellerFromServiceAdmin(reseller))
+}
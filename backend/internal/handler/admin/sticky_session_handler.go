@@ -0,0 +1,124 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StickySessionHandler handles sticky session cache warmth and failover stats in the ops dashboard
+type StickySessionHandler struct {
+	stickyStandbyService *service.StickyStandbyService
+}
+
+// NewStickySessionHandler creates a new admin sticky session handler
+func NewStickySessionHandler(stickyStandbyService *service.StickyStandbyService) *StickySessionHandler {
+	return &StickySessionHandler{stickyStandbyService: stickyStandbyService}
+}
+
+// ListSessions handles listing per-session cache hit ratios and failover cost
+// GET /api/v1/admin/ops/sticky-sessions?group_id=&session_hash=&account_id=&start_date=&end_date=
+func (h *StickySessionHandler) ListSessions(c *gin.Context) {
+	filters, ok := parseStickySessionFilters(c)
+	if !ok {
+		return
+	}
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	sessions, result, err := h.stickyStandbyService.ListSessions(c.Request.Context(), params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.StickySessionSummary, 0, len(sessions))
+	for i := range sessions {
+		out = append(out, *dto.StickySessionSummaryFromService(&sessions[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// ListSessionAccounts handles listing one session's cache usage per account
+// GET /api/v1/admin/ops/sticky-sessions/accounts?group_id=&session_hash=
+func (h *StickySessionHandler) ListSessionAccounts(c *gin.Context) {
+	sessionHash := strings.TrimSpace(c.Query("session_hash"))
+	if sessionHash == "" {
+		response.BadRequest(c, "session_hash is required")
+		return
+	}
+	var groupID int64
+	if v := strings.TrimSpace(c.Query("group_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 0 {
+			response.BadRequest(c, "Invalid group_id")
+			return
+		}
+		groupID = id
+	}
+	stats, err := h.stickyStandbyService.ListSessionAccounts(c.Request.Context(), groupID, sessionHash)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.StickySessionStat, 0, len(stats))
+	for i := range stats {
+		out = append(out, *dto.StickySessionStatFromService(&stats[i]))
+	}
+	response.Success(c, out)
+}
+
+// ListFailovers handles listing sticky session failovers with estimated cost
+// GET /api/v1/admin/ops/sticky-failovers?group_id=&session_hash=&account_id=&start_date=&end_date=
+func (h *StickySessionHandler) ListFailovers(c *gin.Context) {
+	filters, ok := parseStickySessionFilters(c)
+	if !ok {
+		return
+	}
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	failovers, result, err := h.stickyStandbyService.ListFailovers(c.Request.Context(), params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.StickySessionFailover, 0, len(failovers))
+	for i := range failovers {
+		out = append(out, *dto.StickySessionFailoverFromService(&failovers[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// parseStickySessionFilters 解析查询条件，失败时已写入错误响应
+func parseStickySessionFilters(c *gin.Context) (service.StickySessionFilters, bool) {
+	filters := service.StickySessionFilters{SessionHash: strings.TrimSpace(c.Query("session_hash"))}
+	if v := strings.TrimSpace(c.Query("group_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 0 {
+			response.BadRequest(c, "Invalid group_id")
+			return filters, false
+		}
+		filters.GroupID = &id
+	}
+	if v := strings.TrimSpace(c.Query("account_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.BadRequest(c, "Invalid account_id")
+			return filters, false
+		}
+		filters.AccountID = id
+	}
+	if c.Query("start_date") != "" || c.Query("end_date") != "" {
+		startTime, endTime := parseTimeRange(c)
+		filters.StartTime = &startTime
+		filters.EndTime = &endTime
+	}
+	return filters, true
+}
@@ -0,0 +1,197 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubscriptionPlanHandler handles admin subscription plan management
+type SubscriptionPlanHandler struct {
+	planService *service.SubscriptionPlanService
+}
+
+// NewSubscriptionPlanHandler creates a new admin subscription plan handler
+func NewSubscriptionPlanHandler(planService *service.SubscriptionPlanService) *SubscriptionPlanHandler {
+	return &SubscriptionPlanHandler{
+		planService: planService,
+	}
+}
+
+// CreateSubscriptionPlanRequest represents create plan request
+type CreateSubscriptionPlanRequest struct {
+	Name            string   `json:"name" binding:"required"`
+	Description     string   `json:"description"`
+	GroupID         int64    `json:"group_id" binding:"required,gt=0"`
+	Price           float64  `json:"price" binding:"required,gt=0"`
+	ValidityDays    int      `json:"validity_days" binding:"required,gt=0"`
+	DailyLimitUSD   *float64 `json:"daily_limit_usd" binding:"omitempty,min=0"` // 为空时沿用分组限额
+	WeeklyLimitUSD  *float64 `json:"weekly_limit_usd" binding:"omitempty,min=0"`
+	MonthlyLimitUSD *float64 `json:"monthly_limit_usd" binding:"omitempty,min=0"`
+	Tier            int      `json:"tier"`
+	AllowUpgrade    *bool    `json:"allow_upgrade"`
+	AllowDowngrade  *bool    `json:"allow_downgrade"`
+	AllowAutoRenew  *bool    `json:"allow_auto_renew"`
+	SortOrder       int      `json:"sort_order"`
+	Status          string   `json:"status" binding:"omitempty,oneof=active disabled"`
+}
+
+// UpdateSubscriptionPlanRequest represents update plan request (nil fields are left unchanged)
+type UpdateSubscriptionPlanRequest struct {
+	Name            *string  `json:"name"`
+	Description     *string  `json:"description"`
+	GroupID         *int64   `json:"group_id" binding:"omitempty,gt=0"`
+	Price           *float64 `json:"price" binding:"omitempty,gt=0"`
+	ValidityDays    *int     `json:"validity_days" binding:"omitempty,gt=0"`
+	DailyLimitUSD   *float64 `json:"daily_limit_usd" binding:"omitempty,min=0"`
+	WeeklyLimitUSD  *float64 `json:"weekly_limit_usd" binding:"omitempty,min=0"`
+	MonthlyLimitUSD *float64 `json:"monthly_limit_usd" binding:"omitempty,min=0"`
+	ClearLimits     bool     `json:"clear_limits"` // 清空限额覆盖，沿用分组限额
+	Tier            *int     `json:"tier"`
+	AllowUpgrade    *bool    `json:"allow_upgrade"`
+	AllowDowngrade  *bool    `json:"allow_downgrade"`
+	AllowAutoRenew  *bool    `json:"allow_auto_renew"`
+	SortOrder       *int     `json:"sort_order"`
+	Status          *string  `json:"status" binding:"omitempty,oneof=active disabled"`
+}
+
+// List handles listing subscription plans
+// GET /api/v1/admin/subscription-plans
+func (h *SubscriptionPlanHandler) List(c *gin.Context) {
+	filters := service.SubscriptionPlanFilters{
+		Status: strings.TrimSpace(c.Query("status")),
+	}
+	if v := strings.TrimSpace(c.Query("group_id")); v != "" {
+		groupID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid group_id")
+			return
+		}
+		filters.GroupID = &groupID
+	}
+
+	plans, err := h.planService.ListPlans(c.Request.Context(), filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.SubscriptionPlan, 0, len(plans))
+	for i := range plans {
+		out = append(out, *dto.SubscriptionPlanFromService(&plans[i]))
+	}
+	response.Success(c, out)
+}
+
+// GetByID handles getting a subscription plan by ID
+// GET /api/v1/admin/subscription-plans/:id
+func (h *SubscriptionPlanHandler) GetByID(c *gin.Context) {
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid plan ID")
+		return
+	}
+
+	plan, err := h.planService.GetPlan(c.Request.Context(), planID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.SubscriptionPlanFromService(plan))
+}
+
+// Create handles creating a subscription plan
+// POST /api/v1/admin/subscription-plans
+func (h *SubscriptionPlanHandler) Create(c *gin.Context) {
+	var req CreateSubscriptionPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	input := &service.SubscriptionPlanInput{
+		Name:            &req.Name,
+		Description:     &req.Description,
+		GroupID:         &req.GroupID,
+		Price:           &req.Price,
+		ValidityDays:    &req.ValidityDays,
+		DailyLimitUSD:   req.DailyLimitUSD,
+		WeeklyLimitUSD:  req.WeeklyLimitUSD,
+		MonthlyLimitUSD: req.MonthlyLimitUSD,
+		Tier:            &req.Tier,
+		AllowUpgrade:    req.AllowUpgrade,
+		AllowDowngrade:  req.AllowDowngrade,
+		AllowAutoRenew:  req.AllowAutoRenew,
+		SortOrder:       &req.SortOrder,
+	}
+	if req.Status != "" {
+		input.Status = &req.Status
+	}
+
+	plan, err := h.planService.CreatePlan(c.Request.Context(), input)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.SubscriptionPlanFromService(plan))
+}
+
+// Update handles updating a subscription plan
+// PUT /api/v1/admin/subscription-plans/:id
+func (h *SubscriptionPlanHandler) Update(c *gin.Context) {
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid plan ID")
+		return
+	}
+
+	var req UpdateSubscriptionPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	plan, err := h.planService.UpdatePlan(c.Request.Context(), planID, &service.SubscriptionPlanInput{
+		Name:            req.Name,
+		Description:     req.Description,
+		GroupID:         req.GroupID,
+		Price:           req.Price,
+		ValidityDays:    req.ValidityDays,
+		DailyLimitUSD:   req.DailyLimitUSD,
+		WeeklyLimitUSD:  req.WeeklyLimitUSD,
+		MonthlyLimitUSD: req.MonthlyLimitUSD,
+		ClearLimits:     req.ClearLimits,
+		Tier:            req.Tier,
+		AllowUpgrade:    req.AllowUpgrade,
+		AllowDowngrade:  req.AllowDowngrade,
+		AllowAutoRenew:  req.AllowAutoRenew,
+		SortOrder:       req.SortOrder,
+		Status:          req.Status,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.SubscriptionPlanFromService(plan))
+}
+
+// Delete handles deleting a subscription plan
+// DELETE /api/v1/admin/subscription-plans/:id
+func (h *SubscriptionPlanHandler) Delete(c *gin.Context) {
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid plan ID")
+		return
+	}
+
+	if err := h.planService.DeletePlan(c.Request.Context(), planID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Subscription plan deleted successfully"})
+}
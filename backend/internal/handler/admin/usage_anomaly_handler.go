@@ -0,0 +1,105 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageAnomalyHandler handles API key usage anomaly events in the ops dashboard
+type UsageAnomalyHandler struct {
+	anomalyService *service.UsageAnomalyService
+}
+
+// NewUsageAnomalyHandler creates a new admin usage anomaly handler
+func NewUsageAnomalyHandler(anomalyService *service.UsageAnomalyService) *UsageAnomalyHandler {
+	return &UsageAnomalyHandler{anomalyService: anomalyService}
+}
+
+// UpdateUsageAnomalyStatusRequest represents acknowledging or reopening an anomaly event
+type UpdateUsageAnomalyStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=open acknowledged"`
+}
+
+// List handles listing anomaly events
+// GET /api/v1/admin/ops/anomalies?status=&severity=&kind=&user_id=&api_key_id=&start_date=&end_date=
+func (h *UsageAnomalyHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	filters := service.UsageAnomalyEventFilters{
+		Status:   strings.TrimSpace(c.Query("status")),
+		Severity: strings.ToUpper(strings.TrimSpace(c.Query("severity"))),
+		Kind:     strings.TrimSpace(c.Query("kind")),
+	}
+	switch filters.Status {
+	case "", service.UsageAnomalyStatusOpen, service.UsageAnomalyStatusAcknowledged:
+	default:
+		response.BadRequest(c, "Invalid status, use open or acknowledged")
+		return
+	}
+	for name, target := range map[string]*int64{
+		"user_id":    &filters.UserID,
+		"api_key_id": &filters.APIKeyID,
+	} {
+		v := strings.TrimSpace(c.Query(name))
+		if v == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.BadRequest(c, "Invalid "+name)
+			return
+		}
+		*target = id
+	}
+	if c.Query("start_date") != "" || c.Query("end_date") != "" {
+		startTime, endTime := parseTimeRange(c)
+		filters.StartTime = &startTime
+		filters.EndTime = &endTime
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	events, result, err := h.anomalyService.ListEvents(c.Request.Context(), params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.UsageAnomalyEvent, 0, len(events))
+	for i := range events {
+		out = append(out, *dto.UsageAnomalyEventFromService(&events[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// UpdateStatus handles acknowledging or reopening an anomaly event
+// PUT /api/v1/admin/ops/anomalies/:id/status
+func (h *UsageAnomalyHandler) UpdateStatus(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid event ID")
+		return
+	}
+	var req UpdateUsageAnomalyStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.anomalyService.UpdateStatus(c.Request.Context(), id, req.Status, subject.UserID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"updated": true})
+}
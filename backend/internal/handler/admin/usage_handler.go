@@ -24,6 +24,7 @@ type UsageHandler struct {
 	apiKeyService  *service.APIKeyService
 	adminService   service.AdminService
 	cleanupService *service.UsageCleanupService
+	exportService  *service.UsageExportService
 }
 
 // NewUsageHandler creates a new admin usage handler
@@ -32,12 +33,14 @@ func NewUsageHandler(
 	apiKeyService *service.APIKeyService,
 	adminService service.AdminService,
 	cleanupService *service.UsageCleanupService,
+	exportService *service.UsageExportService,
 ) *UsageHandler {
 	return &UsageHandler{
 		usageService:   usageService,
 		apiKeyService:  apiKeyService,
 		adminService:   adminService,
 		cleanupService: cleanupService,
+		exportService:  exportService,
 	}
 }
 
@@ -60,13 +63,34 @@ type CreateUsageCleanupTaskRequest struct {
 func (h *UsageHandler) List(c *gin.Context) {
 	page, pageSize := response.ParsePagination(c)
 
-	// Parse filters
-	var userID, apiKeyID, accountID, groupID int64
+	filters, ok := parseUsageLogFilters(c)
+	if !ok {
+		return
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+
+	records, result, err := h.usageService.ListWithFilters(c.Request.Context(), params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.AdminUsageLog, 0, len(records))
+	for i := range records {
+		out = append(out, *dto.UsageLogFromServiceAdmin(&records[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// parseUsageLogFilters 解析使用记录列表与导出共用的过滤参数；解析失败时已写入错误响应
+func parseUsageLogFilters(c *gin.Context) (usagestats.UsageLogFilters, bool) {
+	var userID, apiKeyID, accountID, groupID, organizationID int64
 	if userIDStr := c.Query("user_id"); userIDStr != "" {
 		id, err := strconv.ParseInt(userIDStr, 10, 64)
 		if err != nil {
 			response.BadRequest(c, "Invalid user_id")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		userID = id
 	}
@@ -75,7 +99,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		id, err := strconv.ParseInt(apiKeyIDStr, 10, 64)
 		if err != nil {
 			response.BadRequest(c, "Invalid api_key_id")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		apiKeyID = id
 	}
@@ -84,7 +108,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		id, err := strconv.ParseInt(accountIDStr, 10, 64)
 		if err != nil {
 			response.BadRequest(c, "Invalid account_id")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		accountID = id
 	}
@@ -93,11 +117,20 @@ func (h *UsageHandler) List(c *gin.Context) {
 		id, err := strconv.ParseInt(groupIDStr, 10, 64)
 		if err != nil {
 			response.BadRequest(c, "Invalid group_id")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		groupID = id
 	}
 
+	if organizationIDStr := c.Query("organization_id"); organizationIDStr != "" {
+		id, err := strconv.ParseInt(organizationIDStr, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid organization_id")
+			return usagestats.UsageLogFilters{}, false
+		}
+		organizationID = id
+	}
+
 	model := c.Query("model")
 
 	var stream *bool
@@ -105,7 +138,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		val, err := strconv.ParseBool(streamStr)
 		if err != nil {
 			response.BadRequest(c, "Invalid stream value, use true or false")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		stream = &val
 	}
@@ -115,7 +148,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		val, err := strconv.ParseInt(billingTypeStr, 10, 8)
 		if err != nil {
 			response.BadRequest(c, "Invalid billing_type")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		bt := int8(val)
 		billingType = &bt
@@ -128,7 +161,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		t, err := timezone.ParseInUserLocation("2006-01-02", startDateStr, userTZ)
 		if err != nil {
 			response.BadRequest(c, "Invalid start_date format, use YYYY-MM-DD")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		startTime = &t
 	}
@@ -137,44 +170,32 @@ func (h *UsageHandler) List(c *gin.Context) {
 		t, err := timezone.ParseInUserLocation("2006-01-02", endDateStr, userTZ)
 		if err != nil {
 			response.BadRequest(c, "Invalid end_date format, use YYYY-MM-DD")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		// Set end time to end of day
 		t = t.Add(24*time.Hour - time.Nanosecond)
 		endTime = &t
 	}
 
-	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
-	filters := usagestats.UsageLogFilters{
-		UserID:      userID,
-		APIKeyID:    apiKeyID,
-		AccountID:   accountID,
-		GroupID:     groupID,
-		Model:       model,
-		Stream:      stream,
-		BillingType: billingType,
-		StartTime:   startTime,
-		EndTime:     endTime,
-	}
-
-	records, result, err := h.usageService.ListWithFilters(c.Request.Context(), params, filters)
-	if err != nil {
-		response.ErrorFrom(c, err)
-		return
-	}
-
-	out := make([]dto.AdminUsageLog, 0, len(records))
-	for i := range records {
-		out = append(out, *dto.UsageLogFromServiceAdmin(&records[i]))
-	}
-	response.Paginated(c, out, result.Total, page, pageSize)
+	return usagestats.UsageLogFilters{
+		UserID:         userID,
+		APIKeyID:       apiKeyID,
+		AccountID:      accountID,
+		GroupID:        groupID,
+		OrganizationID: organizationID,
+		Model:          model,
+		Stream:         stream,
+		BillingType:    billingType,
+		StartTime:      startTime,
+		EndTime:        endTime,
+	}, true
 }
 
 // Stats handles getting usage statistics with filters
 // GET /api/v1/admin/usage/stats
 func (h *UsageHandler) Stats(c *gin.Context) {
 	// Parse filters - same as List endpoint
-	var userID, apiKeyID, accountID, groupID int64
+	var userID, apiKeyID, accountID, groupID, organizationID int64
 	if userIDStr := c.Query("user_id"); userIDStr != "" {
 		id, err := strconv.ParseInt(userIDStr, 10, 64)
 		if err != nil {
@@ -211,6 +232,15 @@ func (h *UsageHandler) Stats(c *gin.Context) {
 		groupID = id
 	}
 
+	if organizationIDStr := c.Query("organization_id"); organizationIDStr != "" {
+		id, err := strconv.ParseInt(organizationIDStr, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid organization_id")
+			return
+		}
+		organizationID = id
+	}
+
 	model := c.Query("model")
 
 	var stream *bool
@@ -272,15 +302,16 @@ func (h *UsageHandler) Stats(c *gin.Context) {
 
 	// Build filters and call GetStatsWithFilters
 	filters := usagestats.UsageLogFilters{
-		UserID:      userID,
-		APIKeyID:    apiKeyID,
-		AccountID:   accountID,
-		GroupID:     groupID,
-		Model:       model,
-		Stream:      stream,
-		BillingType: billingType,
-		StartTime:   &startTime,
-		EndTime:     &endTime,
+		UserID:         userID,
+		APIKeyID:       apiKeyID,
+		AccountID:      accountID,
+		GroupID:        groupID,
+		OrganizationID: organizationID,
+		Model:          model,
+		Stream:         stream,
+		BillingType:    billingType,
+		StartTime:      &startTime,
+		EndTime:        &endTime,
 	}
 
 	stats, err := h.usageService.GetStatsWithFilters(c.Request.Context(), filters)
@@ -524,3 +555,85 @@ func (h *UsageHandler) CancelCleanupTask(c *gin.Context) {
 	log.Printf("[UsageCleanup] 清理任务已取消: task=%d operator=%d", taskID, subject.UserID)
 	response.Success(c, gin.H{"id": taskID, "status": service.UsageCleanupStatusCanceled})
 }
+
+// Export handles exporting usage records with admin-only fields
+// GET /api/v1/admin/usage/export?format=csv|jsonl|parquet
+func (h *UsageHandler) Export(c *gin.Context) {
+	if h.exportService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Usage export service unavailable")
+		return
+	}
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok || subject.UserID <= 0 {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	filters, ok := parseUsageLogFilters(c)
+	if !ok {
+		return
+	}
+	req := &service.UsageExportRequest{
+		Scope:       service.UsageExportScopeAdmin,
+		Format:      c.Query("format"),
+		Filters:     filters,
+		RequestedBy: subject.UserID,
+	}
+	task, err := h.exportService.Submit(c.Request.Context(), req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	if task != nil {
+		response.Accepted(c, dto.UsageExportTaskFromServiceAdmin(task))
+		return
+	}
+
+	c.Header("Content-Type", service.UsageExportContentType(req.Format))
+	c.Header("Content-Disposition", "attachment; filename="+service.UsageExportFileName(req.Format, time.Now()))
+	c.Status(http.StatusOK)
+	if _, err := h.exportService.Stream(c.Request.Context(), c.Writer, req); err != nil {
+		log.Printf("[UsageExport] sync export failed: operator=%d scope=%s format=%s err=%v", req.RequestedBy, req.Scope, req.Format, err)
+	}
+}
+
+// ListExports handles listing usage export tasks of all users
+// GET /api/v1/admin/usage/exports
+func (h *UsageHandler) ListExports(c *gin.Context) {
+	if h.exportService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Usage export service unavailable")
+		return
+	}
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	tasks, result, err := h.exportService.ListTasks(c.Request.Context(), 0, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]dto.AdminUsageExportTask, 0, len(tasks))
+	for i := range tasks {
+		out = append(out, *dto.UsageExportTaskFromServiceAdmin(&tasks[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// GetExport handles getting a usage export task
+// GET /api/v1/admin/usage/exports/:id
+func (h *UsageHandler) GetExport(c *gin.Context) {
+	if h.exportService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Usage export service unavailable")
+		return
+	}
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || taskID <= 0 {
+		response.BadRequest(c, "Invalid export task ID")
+		return
+	}
+	task, err := h.exportService.GetTask(c.Request.Context(), taskID, 0)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UsageExportTaskFromServiceAdmin(task))
+}
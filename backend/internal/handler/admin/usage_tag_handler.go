@@ -0,0 +1,209 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageTagHandler handles admin cost attribution tag keys, per-tag stats and tag budgets
+type UsageTagHandler struct {
+	usageTagService *service.UsageTagService
+}
+
+// NewUsageTagHandler creates a new admin usage tag handler
+func NewUsageTagHandler(usageTagService *service.UsageTagService) *UsageTagHandler {
+	return &UsageTagHandler{usageTagService: usageTagService}
+}
+
+// SetUsageTagKeyRequest represents a tag key definition; empty allowed_values accepts any valid value
+type SetUsageTagKeyRequest struct {
+	Key           string   `json:"key" binding:"required"`
+	Description   string   `json:"description"`
+	AllowedValues []string `json:"allowed_values"`
+}
+
+// SetUsageTagBudgetRequest represents a monthly budget for one user's tag value
+type SetUsageTagBudgetRequest struct {
+	UserID          int64   `json:"user_id" binding:"required"`
+	TagKey          string  `json:"tag_key" binding:"required"`
+	TagValue        string  `json:"tag_value" binding:"required"`
+	MonthlyLimitUSD float64 `json:"monthly_limit_usd" binding:"gt=0"`
+}
+
+// ListKeys handles listing tag keys
+// GET /api/v1/admin/usage-tags/keys
+func (h *UsageTagHandler) ListKeys(c *gin.Context) {
+	keys, err := h.usageTagService.ListKeys(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]dto.UsageTagKey, 0, len(keys))
+	for i := range keys {
+		out = append(out, *dto.UsageTagKeyFromService(&keys[i]))
+	}
+	response.Success(c, out)
+}
+
+// CreateKey handles creating a tag key
+// POST /api/v1/admin/usage-tags/keys
+func (h *UsageTagHandler) CreateKey(c *gin.Context) {
+	var req SetUsageTagKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	key, err := h.usageTagService.CreateKey(c.Request.Context(), &service.SetUsageTagKeyInput{
+		Key:           req.Key,
+		Description:   req.Description,
+		AllowedValues: req.AllowedValues,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UsageTagKeyFromService(key))
+}
+
+// UpdateKey handles updating a tag key
+// PUT /api/v1/admin/usage-tags/keys/:id
+func (h *UsageTagHandler) UpdateKey(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid tag key ID")
+		return
+	}
+	var req SetUsageTagKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	key, err := h.usageTagService.UpdateKey(c.Request.Context(), id, &service.SetUsageTagKeyInput{
+		Key:           req.Key,
+		Description:   req.Description,
+		AllowedValues: req.AllowedValues,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UsageTagKeyFromService(key))
+}
+
+// DeleteKey handles deleting a tag key and its budgets
+// DELETE /api/v1/admin/usage-tags/keys/:id
+func (h *UsageTagHandler) DeleteKey(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid tag key ID")
+		return
+	}
+	if err := h.usageTagService.DeleteKey(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Tag key deleted successfully"})
+}
+
+// Stats handles usage aggregated by tag value
+// GET /api/v1/admin/usage-tags/stats?tag_key=project&user_id=&api_key_id=&start_date=&end_date=
+func (h *UsageTagHandler) Stats(c *gin.Context) {
+	startTime, endTime := parseTimeRange(c)
+	filters := service.UsageTagStatsFilters{
+		TagKey:    c.Query("tag_key"),
+		StartTime: startTime,
+		EndTime:   endTime,
+	}
+	for name, target := range map[string]**int64{
+		"user_id":    &filters.UserID,
+		"api_key_id": &filters.APIKeyID,
+	} {
+		v := strings.TrimSpace(c.Query(name))
+		if v == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid "+name)
+			return
+		}
+		*target = &id
+	}
+
+	stats, err := h.usageTagService.GetStats(c.Request.Context(), filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{
+		"tag_key":    filters.TagKey,
+		"start_time": startTime,
+		"end_time":   endTime,
+		"items":      dto.UsageTagStatsFromService(stats),
+	})
+}
+
+// ListBudgets handles listing tag budgets with month-to-date spend
+// GET /api/v1/admin/usage-tags/budgets?user_id=
+func (h *UsageTagHandler) ListBudgets(c *gin.Context) {
+	var userID *int64
+	if v := strings.TrimSpace(c.Query("user_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid user_id")
+			return
+		}
+		userID = &id
+	}
+	budgets, err := h.usageTagService.ListBudgets(c.Request.Context(), userID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]dto.UsageTagBudget, 0, len(budgets))
+	for i := range budgets {
+		out = append(out, *dto.UsageTagBudgetFromService(&budgets[i]))
+	}
+	response.Success(c, out)
+}
+
+// SetBudget handles creating or replacing a user's tag budget
+// PUT /api/v1/admin/usage-tags/budgets
+func (h *UsageTagHandler) SetBudget(c *gin.Context) {
+	var req SetUsageTagBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	budget, err := h.usageTagService.SetBudget(c.Request.Context(), req.UserID, &service.SetUsageTagBudgetInput{
+		TagKey:          req.TagKey,
+		TagValue:        req.TagValue,
+		MonthlyLimitUSD: req.MonthlyLimitUSD,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UsageTagBudgetFromService(budget))
+}
+
+// DeleteBudget handles deleting a tag budget
+// DELETE /api/v1/admin/usage-tags/budgets/:id
+func (h *UsageTagHandler) DeleteBudget(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid budget ID")
+		return
+	}
+	if err := h.usageTagService.DeleteBudget(c.Request.Context(), id, nil); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Tag budget deleted successfully"})
+}
@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// anthropicFileUploadResponseLimit 上传响应（文件对象 JSON）读取上限
+const anthropicFileUploadResponseLimit = 1 << 20
+
+// anthropicFileHeaders 透传到上游 Files API 的请求头
+var anthropicFileHeaders = []string{"anthropic-version", "anthropic-beta", "content-type"}
+
+// UploadFile handles Files API uploads
+// POST /v1/files (multipart/form-data)
+func (h *GatewayHandler) UploadFile(c *gin.Context) {
+	apiKey, ok := h.anthropicFileAPIKey(c)
+	if !ok {
+		return
+	}
+	subscription, _ := middleware2.GetSubscriptionFromContext(c)
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+		status, code, message := billingErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+
+	account, err := h.anthropicFileService.SelectAccount(c.Request.Context(), apiKey.GroupID)
+	if err != nil {
+		h.errorResponse(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error())
+		return
+	}
+	setOpsSelectedAccount(c, account.ID)
+
+	resp, err := h.anthropicFileService.Forward(c.Request.Context(), &service.AnthropicFileRequest{
+		Account:       account,
+		Method:        http.MethodPost,
+		Path:          "/v1/files",
+		Header:        anthropicFileRequestHeaders(c),
+		Body:          c.Request.Body,
+		ContentLength: c.Request.ContentLength,
+	})
+	if err != nil {
+		if _, ok := extractMaxBytesError(err); ok {
+			h.errorResponse(c, http.StatusRequestEntityTooLarge, "invalid_request_error", buildBodyTooLargeMessage(h.anthropicFileService.MaxUploadSize()))
+			return
+		}
+		log.Printf("Files API upload failed: account=%d err=%v", account.ID, err)
+		h.errorResponse(c, http.StatusBadGateway, "upstream_error", "Upstream request failed")
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, anthropicFileUploadResponseLimit))
+	if err != nil {
+		h.errorResponse(c, http.StatusBadGateway, "upstream_error", "Failed to read upstream response")
+		return
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if _, err := h.anthropicFileService.RecordUpload(c.Request.Context(), apiKey, account, body); err != nil {
+			log.Printf("Record uploaded file failed: account=%d err=%v", account.ID, err)
+			h.errorResponse(c, http.StatusInternalServerError, "api_error", "Failed to record uploaded file")
+			return
+		}
+	}
+	writeAnthropicFileResponse(c, resp, bytes.NewReader(body))
+}
+
+// ListFiles lists files uploaded by the caller
+// GET /v1/files?limit=&before_id=&after_id=
+func (h *GatewayHandler) ListFiles(c *gin.Context) {
+	apiKey, ok := h.anthropicFileAPIKey(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, err := h.anthropicFileService.List(c.Request.Context(), apiKey.UserID, limit, strings.TrimSpace(c.Query("before_id")), strings.TrimSpace(c.Query("after_id")))
+	if err != nil {
+		h.anthropicFileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetFile returns file metadata from the owning account
+// GET /v1/files/:file_id
+func (h *GatewayHandler) GetFile(c *gin.Context) {
+	h.forwardAnthropicFile(c, http.MethodGet, "")
+}
+
+// DownloadFile streams file content from the owning account
+// GET /v1/files/:file_id/content
+func (h *GatewayHandler) DownloadFile(c *gin.Context) {
+	h.forwardAnthropicFile(c, http.MethodGet, "/content")
+}
+
+// DeleteFile deletes the file on the owning account
+// DELETE /v1/files/:file_id
+func (h *GatewayHandler) DeleteFile(c *gin.Context) {
+	h.forwardAnthropicFile(c, http.MethodDelete, "")
+}
+
+// forwardAnthropicFile 把单个文件的读取/下载/删除转发到归属账号
+func (h *GatewayHandler) forwardAnthropicFile(c *gin.Context, method, suffix string) {
+	apiKey, ok := h.anthropicFileAPIKey(c)
+	if !ok {
+		return
+	}
+	fileID := strings.TrimSpace(c.Param("file_id"))
+	if fileID == "" {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "file_id is required")
+		return
+	}
+	_, account, err := h.anthropicFileService.Lookup(c.Request.Context(), apiKey.UserID, fileID)
+	if err != nil {
+		h.anthropicFileError(c, err)
+		return
+	}
+	setOpsSelectedAccount(c, account.ID)
+
+	resp, err := h.anthropicFileService.Forward(c.Request.Context(), &service.AnthropicFileRequest{
+		Account:       account,
+		Method:        method,
+		Path:          "/v1/files/" + fileID + suffix,
+		Header:        anthropicFileRequestHeaders(c),
+		ContentLength: -1,
+	})
+	if err != nil {
+		log.Printf("Files API request failed: file=%s account=%d err=%v", fileID, account.ID, err)
+		h.errorResponse(c, http.StatusBadGateway, "upstream_error", "Upstream request failed")
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// 上游已不存在的文件同步删除归属记录
+	if (method == http.MethodDelete && resp.StatusCode >= 200 && resp.StatusCode < 300) || resp.StatusCode == http.StatusNotFound {
+		if err := h.anthropicFileService.Remove(c.Request.Context(), fileID); err != nil {
+			log.Printf("Remove file %s failed: %v", fileID, err)
+		}
+	}
+	writeAnthropicFileResponse(c, resp, resp.Body)
+}
+
+// anthropicFileAPIKey 校验 Files API 调用方，失败时已写入错误响应
+func (h *GatewayHandler) anthropicFileAPIKey(c *gin.Context) (*service.APIKey, bool) {
+	if !h.anthropicFileService.Enabled() {
+		h.errorResponse(c, http.StatusNotFound, "not_found_error", "Files API is disabled")
+		return nil, false
+	}
+	apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+	if !ok || apiKey == nil {
+		h.errorResponse(c, http.StatusUnauthorized, "authentication_error", "Invalid API key")
+		return nil, false
+	}
+	if apiKey.Group != nil && apiKey.Group.Platform != service.PlatformAnthropic {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Files API requires an Anthropic group")
+		return nil, false
+	}
+	return apiKey, true
+}
+
+func (h *GatewayHandler) anthropicFileError(c *gin.Context, err error) {
+	status := infraerrors.Code(err)
+	switch {
+	case status == http.StatusNotFound:
+		h.errorResponse(c, status, "not_found_error", infraerrors.Message(err))
+	case status >= 400 && status < 500:
+		h.errorResponse(c, status, "invalid_request_error", infraerrors.Message(err))
+	case status == http.StatusServiceUnavailable:
+		h.errorResponse(c, status, "api_error", infraerrors.Message(err))
+	default:
+		log.Printf("Files API request failed: %v", err)
+		h.errorResponse(c, http.StatusInternalServerError, "api_error", "Internal error")
+	}
+}
+
+// pinFileAccount 引用了 Files API 文件的 /v1/messages 请求固定调度到文件所在账号，失败时已写入错误响应
+func (h *GatewayHandler) pinFileAccount(c *gin.Context, apiKey *service.APIKey, userID int64, body []byte) bool {
+	if middleware2.HasForcePlatform(c) || (apiKey.Group != nil && apiKey.Group.Platform != service.PlatformAnthropic) {
+		return true
+	}
+	accountID, err := h.anthropicFileService.ResolveAffinity(c.Request.Context(), userID, body)
+	if err != nil {
+		h.anthropicFileError(c, err)
+		return false
+	}
+	if accountID > 0 {
+		c.Request = c.Request.WithContext(service.WithPinnedAccount(c.Request.Context(), accountID))
+	}
+	return true
+}
+
+func anthropicFileRequestHeaders(c *gin.Context) http.Header {
+	out := make(http.Header)
+	for _, name := range anthropicFileHeaders {
+		if v := c.GetHeader(name); v != "" {
+			out.Set(name, v)
+		}
+	}
+	return out
+}
+
+// writeAnthropicFileResponse 透传上游状态码与内容相关响应头（下载时流式转发内容）
+func writeAnthropicFileResponse(c *gin.Context, resp *http.Response, body io.Reader) {
+	for _, name := range []string{"Content-Type", "Content-Disposition", "Request-Id"} {
+		if v := resp.Header.Get(name); v != "" {
+			c.Header(name, v)
+		}
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	contentLength := int64(-1)
+	if r, ok := body.(*bytes.Reader); ok {
+		contentLength = int64(r.Len())
+	} else if resp.ContentLength >= 0 {
+		contentLength = resp.ContentLength
+	}
+	c.DataFromReader(resp.StatusCode, contentLength, contentType, body, nil)
+}
@@ -20,6 +20,7 @@ func UserFromServiceShallow(u *service.User) *User {
 		Concurrency:   u.Concurrency,
 		Status:        u.Status,
 		AllowedGroups: u.AllowedGroups,
+		ParentUserID:  u.ParentUserID,
 		CreatedAt:     u.CreatedAt,
 		UpdatedAt:     u.UpdatedAt,
 	}
@@ -68,18 +69,19 @@ func APIKeyFromService(k *service.APIKey) *APIKey {
 		return nil
 	}
 	return &APIKey{
-		ID:          k.ID,
-		UserID:      k.UserID,
-		Key:         k.Key,
-		Name:        k.Name,
-		GroupID:     k.GroupID,
-		Status:      k.Status,
-		IPWhitelist: k.IPWhitelist,
-		IPBlacklist: k.IPBlacklist,
-		CreatedAt:   k.CreatedAt,
-		UpdatedAt:   k.UpdatedAt,
-		User:        UserFromServiceShallow(k.User),
-		Group:       GroupFromServiceShallow(k.Group),
+		ID:             k.ID,
+		UserID:         k.UserID,
+		Key:            k.Key,
+		Name:           k.Name,
+		GroupID:        k.GroupID,
+		OrganizationID: k.OrganizationID,
+		Status:         k.Status,
+		IPWhitelist:    k.IPWhitelist,
+		IPBlacklist:    k.IPBlacklist,
+		CreatedAt:      k.CreatedAt,
+		UpdatedAt:      k.UpdatedAt,
+		User:           UserFromServiceShallow(k.User),
+		Group:          GroupFromServiceShallow(k.Group),
 	}
 }
 
@@ -98,6 +100,24 @@ func GroupFromService(g *service.Group) *Group {
 	return GroupFromServiceShallow(g)
 }
 
+func modelFallbackFromService(cfg *service.ModelFallbackConfig) *ModelFallbackConfig {
+	if cfg == nil {
+		return nil
+	}
+	out := &ModelFallbackConfig{
+		Triggers: cfg.Triggers,
+		Chains:   make(map[string][]ModelFallbackStep, len(cfg.Chains)),
+	}
+	for pattern, steps := range cfg.Chains {
+		converted := make([]ModelFallbackStep, 0, len(steps))
+		for _, step := range steps {
+			converted = append(converted, ModelFallbackStep{Model: step.Model, GroupID: step.GroupID})
+		}
+		out.Chains[pattern] = converted
+	}
+	return out
+}
+
 // GroupFromServiceAdmin converts a service Group to DTO for admin users.
 // It includes internal fields like model_routing and account_count.
 func GroupFromServiceAdmin(g *service.Group) *AdminGroup {
@@ -108,6 +128,10 @@ func GroupFromServiceAdmin(g *service.Group) *AdminGroup {
 		Group:               groupFromServiceBase(g),
 		ModelRouting:        g.ModelRouting,
 		ModelRoutingEnabled: g.ModelRoutingEnabled,
+		OverdraftUSD:        g.OverdraftUSD,
+		CountTokensMode:     g.GetCountTokensMode(),
+		HedgeEnabled:        g.HedgeEnabled,
+		ModelFallback:       modelFallbackFromService(g.ModelFallback),
 		AccountCount:        g.AccountCount,
 	}
 	if len(g.AccountGroups) > 0 {
@@ -332,6 +356,7 @@ func redeemCodeFromServiceBase(rc *service.RedeemCode) RedeemCode {
 		CreatedAt:    rc.CreatedAt,
 		GroupID:      rc.GroupID,
 		ValidityDays: rc.ValidityDays,
+		IssuerUserID: rc.IssuerUserID,
 		User:         UserFromServiceShallow(rc.User),
 		Group:        GroupFromServiceShallow(rc.Group),
 	}
@@ -360,6 +385,7 @@ func usageLogFromServiceUser(l *service.UsageLog) UsageLog {
 		Model:                 l.Model,
 		GroupID:               l.GroupID,
 		SubscriptionID:        l.SubscriptionID,
+		OrganizationID:        l.OrganizationID,
 		InputTokens:           l.InputTokens,
 		OutputTokens:          l.OutputTokens,
 		CacheCreationTokens:   l.CacheCreationTokens,
@@ -369,6 +395,8 @@ func usageLogFromServiceUser(l *service.UsageLog) UsageLog {
 		InputCost:             l.InputCost,
 		OutputCost:            l.OutputCost,
 		CacheCreationCost:     l.CacheCreationCost,
+		CacheCreation5mCost:   l.CacheCreationCost - l.CacheCreation1hCost,
+		CacheCreation1hCost:   l.CacheCreation1hCost,
 		CacheReadCost:         l.CacheReadCost,
 		TotalCost:             l.TotalCost,
 		ActualCost:            l.ActualCost,
@@ -380,6 +408,7 @@ func usageLogFromServiceUser(l *service.UsageLog) UsageLog {
 		ImageCount:            l.ImageCount,
 		ImageSize:             l.ImageSize,
 		UserAgent:             l.UserAgent,
+		Tags:                  l.Tags,
 		CreatedAt:             l.CreatedAt,
 		User:                  UserFromServiceShallow(l.User),
 		APIKey:                APIKeyFromService(l.APIKey),
@@ -407,7 +436,9 @@ func UsageLogFromServiceAdmin(l *service.UsageLog) *AdminUsageLog {
 	return &AdminUsageLog{
 		UsageLog:              usageLogFromServiceUser(l),
 		AccountRateMultiplier: l.AccountRateMultiplier,
+		RateRule:              l.RateRule,
 		IPAddress:             l.IPAddress,
+		Hedged:                l.Hedged,
 		Account:               AccountSummaryFromService(l.Account),
 	}
 }
@@ -442,6 +473,139 @@ func UsageCleanupTaskFromService(task *service.UsageCleanupTask) *UsageCleanupTa
 	}
 }
 
+// UsageExportDownloadPath 导出文件公开下载路径前缀（后接下载令牌）
+const UsageExportDownloadPath = "/api/v1/exports/usage/"
+
+func UsageExportTaskFromService(task *service.UsageExportTask) *UsageExportTask {
+	if task == nil {
+		return nil
+	}
+	out := &UsageExportTask{
+		ID:     task.ID,
+		Format: task.Format,
+		Status: task.Status,
+		Filters: UsageExportFilters{
+			UserID:         task.Filters.UserID,
+			APIKeyID:       task.Filters.APIKeyID,
+			AccountID:      task.Filters.AccountID,
+			GroupID:        task.Filters.GroupID,
+			OrganizationID: task.Filters.OrganizationID,
+			Model:          task.Filters.Model,
+			Stream:         task.Filters.Stream,
+			BillingType:    task.Filters.BillingType,
+			StartTime:      task.Filters.StartTime,
+			EndTime:        task.Filters.EndTime,
+		},
+		RowCount:     task.RowCount,
+		FileSize:     task.FileSize,
+		ErrorMessage: task.ErrorMsg,
+		ExpiresAt:    task.ExpiresAt,
+		StartedAt:    task.StartedAt,
+		FinishedAt:   task.FinishedAt,
+		CreatedAt:    task.CreatedAt,
+	}
+	if task.Status == service.UsageExportStatusSucceeded && task.DownloadToken != "" &&
+		task.ExpiresAt != nil && time.Now().Before(*task.ExpiresAt) {
+		out.DownloadURL = UsageExportDownloadPath + task.DownloadToken
+	}
+	return out
+}
+
+func UsageExportTaskFromServiceAdmin(task *service.UsageExportTask) *AdminUsageExportTask {
+	if task == nil {
+		return nil
+	}
+	return &AdminUsageExportTask{
+		UsageExportTask: *UsageExportTaskFromService(task),
+		Scope:           task.Scope,
+		CreatedBy:       task.CreatedBy,
+	}
+}
+
+func UserStatementFromService(st *service.UserStatement) *UserStatement {
+	if st == nil {
+		return nil
+	}
+	return &UserStatement{
+		ID:                       st.ID,
+		Period:                   st.Period,
+		PeriodStart:              st.PeriodStart,
+		PeriodEnd:                st.PeriodEnd,
+		Currency:                 st.Currency,
+		TotalRequests:            st.TotalRequests,
+		TotalTokens:              st.TotalTokens,
+		UsageCost:                st.UsageCost,
+		CreditsTotal:             st.CreditsTotal,
+		SubscriptionChargesTotal: st.SubscriptionChargesTotal,
+		EmailedAt:                st.EmailedAt,
+		CreatedAt:                st.CreatedAt,
+	}
+}
+
+func UserStatementDetailFromService(st *service.UserStatement) *UserStatementDetail {
+	if st == nil {
+		return nil
+	}
+	s := &st.Summary
+	out := &UserStatementDetail{
+		UserStatement:       *UserStatementFromService(st),
+		StandardCost:        s.StandardCost,
+		Usage:               make([]UserStatementUsageLine, 0, len(s.Usage)),
+		ByGroup:             userStatementSubtotalsFromService(s.ByGroup),
+		ByModel:             userStatementSubtotalsFromService(s.ByModel),
+		ByAPIKey:            userStatementSubtotalsFromService(s.ByAPIKey),
+		Credits:             make([]UserStatementCredit, 0, len(s.Credits)),
+		SubscriptionCharges: make([]UserStatementCharge, 0, len(s.SubscriptionCharges)),
+	}
+	for _, l := range s.Usage {
+		out.Usage = append(out.Usage, UserStatementUsageLine{
+			GroupID:             l.GroupID,
+			GroupName:           l.GroupName,
+			Model:               l.Model,
+			APIKeyID:            l.APIKeyID,
+			APIKeyName:          l.APIKeyName,
+			Requests:            l.Requests,
+			InputTokens:         l.InputTokens,
+			OutputTokens:        l.OutputTokens,
+			CacheCreationTokens: l.CacheCreationTokens,
+			CacheReadTokens:     l.CacheReadTokens,
+			TotalCost:           l.TotalCost,
+			ActualCost:          l.ActualCost,
+		})
+	}
+	for _, c := range s.Credits {
+		out.Credits = append(out.Credits, UserStatementCredit{
+			Source:     c.Source,
+			Reference:  c.Reference,
+			Amount:     c.Amount,
+			OccurredAt: c.OccurredAt,
+		})
+	}
+	for _, c := range s.SubscriptionCharges {
+		out.SubscriptionCharges = append(out.SubscriptionCharges, UserStatementCharge{
+			Source:      c.Source,
+			Description: c.Description,
+			Amount:      c.Amount,
+			Currency:    c.Currency,
+			OccurredAt:  c.OccurredAt,
+		})
+	}
+	return out
+}
+
+func userStatementSubtotalsFromService(items []service.UserStatementSubtotal) []UserStatementSubtotal {
+	out := make([]UserStatementSubtotal, 0, len(items))
+	for _, it := range items {
+		out = append(out, UserStatementSubtotal{
+			Name:       it.Name,
+			Requests:   it.Requests,
+			Tokens:     it.Tokens,
+			ActualCost: it.ActualCost,
+		})
+	}
+	return out
+}
+
 func SettingFromService(s *service.Setting) *Setting {
 	if s == nil {
 		return nil
@@ -491,6 +655,12 @@ func userSubscriptionFromServiceBase(sub *service.UserSubscription) UserSubscrip
 		DailyUsageUSD:      sub.DailyUsageUSD,
 		WeeklyUsageUSD:     sub.WeeklyUsageUSD,
 		MonthlyUsageUSD:    sub.MonthlyUsageUSD,
+		PlanID:             sub.PlanID,
+		NextPlanID:         sub.NextPlanID,
+		AutoRenew:          sub.AutoRenew,
+		DailyLimitUSD:      sub.DailyLimitUSD,
+		WeeklyLimitUSD:     sub.WeeklyLimitUSD,
+		MonthlyLimitUSD:    sub.MonthlyLimitUSD,
 		CreatedAt:          sub.CreatedAt,
 		UpdatedAt:          sub.UpdatedAt,
 		User:               UserFromServiceShallow(sub.User),
@@ -545,3 +715,686 @@ func PromoCodeUsageFromService(u *service.PromoCodeUsage) *PromoCodeUsage {
 		User:        UserFromServiceShallow(u.User),
 	}
 }
+
+func PaymentOrderFromService(o *service.PaymentOrder) *PaymentOrder {
+	if o == nil {
+		return nil
+	}
+	out := paymentOrderFromServiceBase(o)
+	return &out
+}
+
+// PaymentOrderFromServiceAdmin converts a service PaymentOrder to DTO for admin users.
+func PaymentOrderFromServiceAdmin(o *service.PaymentOrder) *AdminPaymentOrder {
+	if o == nil {
+		return nil
+	}
+	return &AdminPaymentOrder{
+		PaymentOrder:      paymentOrderFromServiceBase(o),
+		ProviderOrderID:   o.ProviderOrderID,
+		ProviderPaymentID: o.ProviderPaymentID,
+		FailureReason:     o.FailureReason,
+		Notes:             o.Notes,
+		CreatedBy:         o.CreatedBy,
+	}
+}
+
+func paymentOrderFromServiceBase(o *service.PaymentOrder) PaymentOrder {
+	out := PaymentOrder{
+		ID:             o.ID,
+		OrderNo:        o.OrderNo,
+		UserID:         o.UserID,
+		Provider:       o.Provider,
+		Method:         o.Method,
+		OrderType:      o.OrderType,
+		Amount:         o.Amount,
+		Currency:       o.Currency,
+		CreditAmount:   o.CreditAmount,
+		GroupID:        o.GroupID,
+		ValidityDays:   o.ValidityDays,
+		SubscriptionID: o.SubscriptionID,
+		Status:         o.Status,
+		RefundedAmount: o.RefundedAmount,
+		ExpiresAt:      o.ExpiresAt,
+		PaidAt:         o.PaidAt,
+		RefundedAt:     o.RefundedAt,
+		CreatedAt:      o.CreatedAt,
+		UpdatedAt:      o.UpdatedAt,
+	}
+	// 仅待支付订单需要支付链接
+	if o.Status == service.PaymentOrderStatusPending {
+		out.PayURL = o.PayURL
+	}
+	return out
+}
+
+func PaymentConfigFromService(c *service.PaymentPublicConfig) *PaymentConfig {
+	if c == nil {
+		return nil
+	}
+	out := &PaymentConfig{
+		Enabled:        c.Enabled,
+		Currency:       c.Currency,
+		BalancePerUnit: c.BalancePerUnit,
+		MinAmount:      c.MinAmount,
+		MaxAmount:      c.MaxAmount,
+		Providers:      make([]PaymentProviderInfo, 0, len(c.Providers)),
+	}
+	for _, p := range c.Providers {
+		methods := p.Methods
+		if methods == nil {
+			methods = []string{}
+		}
+		out.Providers = append(out.Providers, PaymentProviderInfo{Name: p.Name, Methods: methods})
+	}
+	return out
+}
+
+func SubscriptionPlanFromService(p *service.SubscriptionPlan) *SubscriptionPlan {
+	if p == nil {
+		return nil
+	}
+	return &SubscriptionPlan{
+		ID:              p.ID,
+		Name:            p.Name,
+		Description:     p.Description,
+		GroupID:         p.GroupID,
+		Price:           p.Price,
+		ValidityDays:    p.ValidityDays,
+		DailyLimitUSD:   p.DailyLimitUSD,
+		WeeklyLimitUSD:  p.WeeklyLimitUSD,
+		MonthlyLimitUSD: p.MonthlyLimitUSD,
+		Tier:            p.Tier,
+		AllowUpgrade:    p.AllowUpgrade,
+		AllowDowngrade:  p.AllowDowngrade,
+		AllowAutoRenew:  p.AllowAutoRenew,
+		SortOrder:       p.SortOrder,
+		Status:          p.Status,
+		CreatedAt:       p.CreatedAt,
+		UpdatedAt:       p.UpdatedAt,
+		Group:           GroupFromServiceShallow(p.Group),
+	}
+}
+
+func SubscriptionPlanOrderFromService(o *service.SubscriptionPlanOrder) *SubscriptionPlanOrder {
+	if o == nil {
+		return nil
+	}
+	return &SubscriptionPlanOrder{
+		ID:             o.ID,
+		UserID:         o.UserID,
+		PlanID:         o.PlanID,
+		SubscriptionID: o.SubscriptionID,
+		Action:         o.Action,
+		Price:          o.Price,
+		Credit:         o.Credit,
+		Amount:         o.Amount,
+		ExpiresAt:      o.ExpiresAt,
+		CreatedAt:      o.CreatedAt,
+	}
+}
+
+func SubscriptionPlanPurchaseResultFromService(r *service.SubscriptionPlanPurchaseResult) *SubscriptionPlanPurchaseResult {
+	if r == nil {
+		return nil
+	}
+	return &SubscriptionPlanPurchaseResult{
+		Subscription: UserSubscriptionFromService(r.Subscription),
+		Order:        SubscriptionPlanOrderFromService(r.Order),
+		Scheduled:    r.Scheduled,
+	}
+}
+
+func NotificationSettingsFromService(s *service.NotificationSettings) *NotificationSettings {
+	if s == nil {
+		return nil
+	}
+	out := &NotificationSettings{
+		EmailEnabled:        s.EmailEnabled,
+		WebhookURL:          s.WebhookURL,
+		WebhookSecret:       s.WebhookSecret,
+		LowBalanceEnabled:   s.LowBalanceEnabled,
+		QuotaAlertsEnabled:  s.QuotaAlertsEnabled,
+		ExpiryAlertsEnabled: s.ExpiryAlertsEnabled,
+		APIKeyAlertsEnabled: s.APIKeyAlertsEnabled,
+	}
+	if s.LowBalanceThreshold != nil {
+		out.LowBalanceThreshold = *s.LowBalanceThreshold
+	}
+	if s.ExpiryReminderDays != nil {
+		out.ExpiryReminderDays = *s.ExpiryReminderDays
+	}
+	return out
+}
+
+func NotificationFromService(n *service.Notification) *Notification {
+	if n == nil {
+		return nil
+	}
+	return &Notification{
+		ID:            n.ID,
+		Type:          n.Type,
+		Title:         n.Title,
+		Message:       n.Message,
+		EmailSent:     n.EmailSent,
+		WebhookSent:   n.WebhookSent,
+		DeliveryError: n.DeliveryError,
+		CreatedAt:     n.CreatedAt,
+	}
+}
+
+func OrganizationFromService(o *service.Organization, role string) *Organization {
+	if o == nil {
+		return nil
+	}
+	return &Organization{
+		ID:            o.ID,
+		Name:          o.Name,
+		OwnerUserID:   o.OwnerUserID,
+		BillingUserID: o.BillingUserID,
+		Status:        o.Status,
+		Balance:       o.Balance,
+		MemberCount:   o.MemberCount,
+		Role:          role,
+		CreatedAt:     o.CreatedAt,
+		UpdatedAt:     o.UpdatedAt,
+	}
+}
+
+func OrganizationMemberFromService(m *service.OrganizationMember) *OrganizationMember {
+	if m == nil {
+		return nil
+	}
+	return &OrganizationMember{
+		UserID:           m.UserID,
+		Email:            m.Email,
+		Username:         m.Username,
+		Role:             m.Role,
+		SpendingLimitUSD: m.SpendingLimitUSD,
+		SpentUSD:         m.CurrentSpend(service.OrganizationSpendPeriod(time.Now())),
+		CreatedAt:        m.CreatedAt,
+	}
+}
+
+func OrganizationMembersFromService(members []service.OrganizationMember) []OrganizationMember {
+	out := make([]OrganizationMember, 0, len(members))
+	for i := range members {
+		out = append(out, *OrganizationMemberFromService(&members[i]))
+	}
+	return out
+}
+
+func ResellerFromService(r *service.Reseller) *Reseller {
+	if r == nil {
+		return nil
+	}
+	return &Reseller{
+		UserID:       r.UserID,
+		Email:        r.Email,
+		Username:     r.Username,
+		Markup:       r.Markup,
+		MaxSubUsers:  r.MaxSubUsers,
+		SubUserCount: r.SubUserCount,
+		Balance:      r.Balance,
+		Status:       r.Status,
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+	}
+}
+
+func ResellerFromServiceAdmin(r *service.Reseller) *AdminReseller {
+	if r == nil {
+		return nil
+	}
+	return &AdminReseller{
+		Reseller: *ResellerFromService(r),
+		Notes:    r.Notes,
+	}
+}
+
+func ResellerReportFromService(r *service.ResellerReport, start, end time.Time) *ResellerReport {
+	if r == nil {
+		return nil
+	}
+	out := &ResellerReport{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Add(-24 * time.Hour).Format("2006-01-02"),
+		Summary:   resellerReportRowFromService(r.Summary),
+		ByDay:     make([]ResellerReportRow, 0, len(r.ByDay)),
+		BySubUser: make([]ResellerReportRow, 0, len(r.BySubUser)),
+	}
+	for _, row := range r.ByDay {
+		out.ByDay = append(out.ByDay, resellerReportRowFromService(row))
+	}
+	for _, row := range r.BySubUser {
+		out.BySubUser = append(out.BySubUser, resellerReportRowFromService(row))
+	}
+	return out
+}
+
+func resellerReportRowFromService(row service.ResellerReportRow) ResellerReportRow {
+	return ResellerReportRow{
+		Date:        row.Date,
+		ChildUserID: row.ChildUserID,
+		Email:       row.Email,
+		Requests:    row.Requests,
+		TotalTokens: row.TotalTokens,
+		Revenue:     row.Revenue,
+		Cost:        row.Cost,
+		Profit:      row.Profit,
+	}
+}
+
+func ModelPriceOverrideFromService(o *service.ModelPriceOverride) *ModelPriceOverride {
+	if o == nil {
+		return nil
+	}
+	return &ModelPriceOverride{
+		ID:                   o.ID,
+		ModelPattern:         o.ModelPattern,
+		GroupID:              o.GroupID,
+		InputPrice:           o.InputPrice,
+		OutputPrice:          o.OutputPrice,
+		CacheCreationPrice:   o.CacheCreationPrice,
+		CacheReadPrice:       o.CacheReadPrice,
+		CacheCreation1hPrice: o.CacheCreation1hPrice,
+		EffectiveFrom:        o.EffectiveFrom,
+		Notes:                o.Notes,
+		CreatedBy:            o.CreatedBy,
+		CreatedAt:            o.CreatedAt,
+		DeletedAt:            o.DeletedAt,
+		State:                o.State,
+	}
+}
+
+func BillingOutboxEventFromService(e *service.BillingOutboxEvent) *BillingOutboxEvent {
+	if e == nil {
+		return nil
+	}
+	return &BillingOutboxEvent{
+		ID:             e.ID,
+		RequestID:      e.RequestID,
+		APIKeyID:       e.APIKeyID,
+		UsageLogID:     e.UsageLogID,
+		PayerUserID:    e.PayerUserID,
+		MemberUserID:   e.MemberUserID,
+		OrganizationID: e.OrganizationID,
+		BillingType:    e.BillingType,
+		SubscriptionID: e.SubscriptionID,
+		GroupID:        e.GroupID,
+		Amount:         e.Amount,
+		Reseller:       e.Reseller != nil,
+		Status:         e.Status,
+		Attempts:       e.Attempts,
+		LastError:      e.LastError,
+		NextAttemptAt:  e.NextAttemptAt,
+		AppliedAt:      e.AppliedAt,
+		CreatedAt:      e.CreatedAt,
+		UpdatedAt:      e.UpdatedAt,
+	}
+}
+
+func BillingOutboxStatusSummaryFromService(s *service.BillingOutboxStatusSummary) *BillingOutboxStatusSummary {
+	if s == nil {
+		return nil
+	}
+	return &BillingOutboxStatusSummary{
+		Status:      s.Status,
+		Count:       s.Count,
+		Amount:      s.Amount,
+		OldestAt:    s.OldestAt,
+		MaxAttempts: s.MaxAttempts,
+	}
+}
+
+func PricingPreviewFromService(model string, p *service.CostPreview) *PricingPreview {
+	if p == nil || p.Resolution == nil || p.Cost == nil {
+		return nil
+	}
+	pricing := p.Resolution.Pricing
+	out := &PricingPreview{
+		Model:                model,
+		Source:               p.Resolution.Source,
+		InputPrice:           pricing.InputPricePerToken * 1_000_000,
+		OutputPrice:          pricing.OutputPricePerToken * 1_000_000,
+		CacheCreationPrice:   pricing.CacheCreationPricePerToken * 1_000_000,
+		CacheReadPrice:       pricing.CacheReadPricePerToken * 1_000_000,
+		CacheCreation1hPrice: pricing.CacheCreation1hPriceOrDefault() * 1_000_000,
+		RateMultiplier:       p.RateMultiplier,
+		InputCost:            p.Cost.InputCost,
+		OutputCost:           p.Cost.OutputCost,
+		CacheCreationCost:    p.Cost.CacheCreationCost,
+		CacheCreation5mCost:  p.Cost.CacheCreation5mCost,
+		CacheCreation1hCost:  p.Cost.CacheCreation1hCost,
+		CacheReadCost:        p.Cost.CacheReadCost,
+		TotalCost:            p.Cost.TotalCost,
+		ActualCost:           p.Cost.ActualCost,
+	}
+	if p.Resolution.Override != nil {
+		id := p.Resolution.Override.ID
+		out.OverrideID = &id
+	}
+	return out
+}
+
+func RateMultiplierOverrideFromService(o *service.RateMultiplierOverride) *RateMultiplierOverride {
+	if o == nil {
+		return nil
+	}
+	return &RateMultiplierOverride{
+		ID:             o.ID,
+		GroupID:        o.GroupID,
+		UserID:         o.UserID,
+		APIKeyID:       o.APIKeyID,
+		RateMultiplier: o.RateMultiplier,
+		Notes:          o.Notes,
+		CreatedBy:      o.CreatedBy,
+		CreatedAt:      o.CreatedAt,
+		UpdatedAt:      o.UpdatedAt,
+	}
+}
+
+func VolumeDiscountTierFromService(t *service.VolumeDiscountTier) *VolumeDiscountTier {
+	if t == nil {
+		return nil
+	}
+	return &VolumeDiscountTier{
+		ID:              t.ID,
+		GroupID:         t.GroupID,
+		MinMonthlySpend: t.MinMonthlySpend,
+		DiscountPercent: t.DiscountPercent,
+		Notes:           t.Notes,
+		CreatedBy:       t.CreatedBy,
+		CreatedAt:       t.CreatedAt,
+		UpdatedAt:       t.UpdatedAt,
+	}
+}
+
+func RateMultiplierPreviewFromService(p *service.RateMultiplierPreview) *RateMultiplierPreview {
+	if p == nil {
+		return nil
+	}
+	rows := make([]RateMultiplierPreviewRow, 0, len(p.Rows))
+	for _, r := range p.Rows {
+		rows = append(rows, RateMultiplierPreviewRow{
+			UserID:       r.UserID,
+			UserEmail:    r.UserEmail,
+			GroupID:      r.GroupID,
+			GroupName:    r.GroupName,
+			Requests:     r.Requests,
+			ActualCost:   r.ActualCost,
+			CurrentCost:  r.CurrentCost,
+			ProposedCost: r.ProposedCost,
+		})
+	}
+	return &RateMultiplierPreview{
+		PeriodStart:  p.PeriodStart,
+		PeriodEnd:    p.PeriodEnd,
+		Requests:     p.Requests,
+		ActualCost:   p.ActualCost,
+		CurrentCost:  p.CurrentCost,
+		ProposedCost: p.ProposedCost,
+		Rows:         rows,
+		TotalRows:    p.TotalRows,
+	}
+}
+
+func UsageTagKeyFromService(k *service.UsageTagKey) *UsageTagKey {
+	if k == nil {
+		return nil
+	}
+	allowed := k.AllowedValues
+	if allowed == nil {
+		allowed = []string{}
+	}
+	return &UsageTagKey{
+		ID:            k.ID,
+		Key:           k.Key,
+		Description:   k.Description,
+		AllowedValues: allowed,
+		CreatedAt:     k.CreatedAt,
+		UpdatedAt:     k.UpdatedAt,
+	}
+}
+
+func UsageTagBudgetFromService(b *service.UsageTagBudget) *UsageTagBudget {
+	if b == nil {
+		return nil
+	}
+	return &UsageTagBudget{
+		ID:              b.ID,
+		UserID:          b.UserID,
+		TagKey:          b.TagKey,
+		TagValue:        b.TagValue,
+		MonthlyLimitUSD: b.MonthlyLimitUSD,
+		MonthSpend:      b.MonthSpend,
+		CreatedAt:       b.CreatedAt,
+		UpdatedAt:       b.UpdatedAt,
+	}
+}
+
+func UsageTagStatsFromService(stats []service.UsageTagStat) []UsageTagStat {
+	out := make([]UsageTagStat, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, UsageTagStat{
+			TagValue:     s.TagValue,
+			Requests:     s.Requests,
+			InputTokens:  s.InputTokens,
+			OutputTokens: s.OutputTokens,
+			TotalCost:    s.TotalCost,
+			ActualCost:   s.ActualCost,
+		})
+	}
+	return out
+}
+
+func UsageAnomalyEventFromService(e *service.UsageAnomalyEvent) *UsageAnomalyEvent {
+	if e == nil {
+		return nil
+	}
+	details := e.Details
+	if details == nil {
+		details = map[string]any{}
+	}
+	return &UsageAnomalyEvent{
+		ID:             e.ID,
+		UserID:         e.UserID,
+		UserEmail:      e.UserEmail,
+		APIKeyID:       e.APIKeyID,
+		APIKeyName:     e.APIKeyName,
+		BucketStart:    e.BucketStart,
+		Kind:           e.Kind,
+		Severity:       e.Severity,
+		Observed:       e.Observed,
+		BaselineMean:   e.BaselineMean,
+		BaselineStddev: e.BaselineStddev,
+		Score:          e.Score,
+		Details:        details,
+		KeySuspended:   e.KeySuspended,
+		Status:         e.Status,
+		AcknowledgedBy: e.AcknowledgedBy,
+		AcknowledgedAt: e.AcknowledgedAt,
+		CreatedAt:      e.CreatedAt,
+	}
+}
+
+func AnthropicFileFromService(f *service.AnthropicFile) *AnthropicFile {
+	if f == nil {
+		return nil
+	}
+	return &AnthropicFile{
+		ID:           f.ID,
+		FileID:       f.FileID,
+		AccountID:    f.AccountID,
+		AccountName:  f.AccountName,
+		UserID:       f.UserID,
+		UserEmail:    f.UserEmail,
+		APIKeyID:     f.APIKeyID,
+		GroupID:      f.GroupID,
+		Filename:     f.Filename,
+		MimeType:     f.MimeType,
+		SizeBytes:    f.SizeBytes,
+		Downloadable: f.Downloadable,
+		CreatedAt:    f.CreatedAt,
+	}
+}
+
+func StickySessionSummaryFromService(s *service.StickySessionSummary) *StickySessionSummary {
+	if s == nil {
+		return nil
+	}
+	return &StickySessionSummary{
+		GroupID:               s.GroupID,
+		SessionHash:           s.SessionHash,
+		AccountCount:          s.AccountCount,
+		RequestCount:          s.RequestCount,
+		InputTokens:           s.InputTokens,
+		CacheCreationTokens:   s.CacheCreationTokens,
+		CacheReadTokens:       s.CacheReadTokens,
+		CacheHitRatio:         s.CacheHitRatio(),
+		FailoverCount:         s.FailoverCount,
+		EstimatedFailoverCost: s.EstimatedFailoverCost,
+		LastUsedAt:            s.LastUsedAt,
+	}
+}
+
+func StickySessionStatFromService(s *service.StickySessionStat) *StickySessionStat {
+	if s == nil {
+		return nil
+	}
+	return &StickySessionStat{
+		AccountID:           s.AccountID,
+		AccountName:         s.AccountName,
+		RequestCount:        s.RequestCount,
+		InputTokens:         s.InputTokens,
+		CacheCreationTokens: s.CacheCreationTokens,
+		CacheReadTokens:     s.CacheReadTokens,
+		CacheHitRatio:       s.CacheHitRatio(),
+		LastCacheReadAt:     s.LastCacheReadAt,
+		CreatedAt:           s.CreatedAt,
+		UpdatedAt:           s.UpdatedAt,
+	}
+}
+
+func StickySessionFailoverFromService(f *service.StickySessionFailover) *StickySessionFailover {
+	if f == nil {
+		return nil
+	}
+	return &StickySessionFailover{
+		ID:                  f.ID,
+		GroupID:             f.GroupID,
+		SessionHash:         f.SessionHash,
+		FromAccountID:       f.FromAccountID,
+		FromAccountName:     f.FromAccountName,
+		ToAccountID:         f.ToAccountID,
+		ToAccountName:       f.ToAccountName,
+		Standby:             f.Standby,
+		Warm:                f.Warm,
+		Model:               f.Model,
+		CacheCreationTokens: f.CacheCreationTokens,
+		CacheReadTokens:     f.CacheReadTokens,
+		EstimatedCost:       f.EstimatedCost,
+		CreatedAt:           f.CreatedAt,
+	}
+}
+
+func RequestHedgeStatsFromService(s *service.RequestHedgeStats) *RequestHedgeStats {
+	if s == nil {
+		return nil
+	}
+	out := &RequestHedgeStats{
+		GroupID:      s.GroupID,
+		Requests:     s.Requests,
+		Hedged:       s.Hedged,
+		HedgeWins:    s.HedgeWins,
+		BudgetDenied: s.BudgetDenied,
+		NoAccount:    s.NoAccount,
+		DelayMs:      s.DelayMs,
+		Samples:      s.Samples,
+		BudgetTokens: s.BudgetTokens,
+	}
+	if s.Requests > 0 {
+		out.HedgeRate = float64(s.Hedged) / float64(s.Requests)
+	}
+	return out
+}
+
+func GuardrailPolicyFromService(p *service.GuardrailPolicy) *GuardrailPolicy {
+	if p == nil {
+		return nil
+	}
+	return &GuardrailPolicy{
+		ID:            p.ID,
+		Name:          p.Name,
+		GroupID:       p.GroupID,
+		APIKeyID:      p.APIKeyID,
+		Enabled:       p.Enabled,
+		Action:        p.Action,
+		Detectors:     nonNilStrings(p.Detectors),
+		Keywords:      nonNilStrings(p.Keywords),
+		Patterns:      nonNilStrings(p.Patterns),
+		HookURL:       p.HookURL,
+		ScanResponses: p.ScanResponses,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+	}
+}
+
+func GuardrailEventFromService(e *service.GuardrailEvent) *GuardrailEvent {
+	if e == nil {
+		return nil
+	}
+	return &GuardrailEvent{
+		ID:         e.ID,
+		PolicyID:   e.PolicyID,
+		PolicyName: e.PolicyName,
+		UserID:     e.UserID,
+		APIKeyID:   e.APIKeyID,
+		GroupID:    e.GroupID,
+		Direction:  e.Direction,
+		Action:     e.Action,
+		Findings:   e.Findings,
+		Model:      e.Model,
+		CreatedAt:  e.CreatedAt,
+	}
+}
+
+func PromptTemplateFromService(t *service.PromptTemplate) *PromptTemplate {
+	if t == nil {
+		return nil
+	}
+	return &PromptTemplate{
+		ID:        t.ID,
+		Name:      t.Name,
+		GroupID:   t.GroupID,
+		APIKeyID:  t.APIKeyID,
+		Enabled:   t.Enabled,
+		Mode:      t.Mode,
+		Content:   t.Content,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
+
+func ConfigReloadStatusFromService(s *service.ConfigReloadStatus) *ConfigReloadStatus {
+	if s == nil {
+		return nil
+	}
+	return &ConfigReloadStatus{
+		Trigger:         s.Trigger,
+		At:              s.At,
+		Success:         s.Success,
+		Error:           s.Error,
+		Applied:         nonNilStrings(s.Applied),
+		RestartRequired: nonNilStrings(s.Restart),
+		FilePath:        s.FilePath,
+	}
+}
+
+// nonNilStrings 空列表序列化为 [] 而不是 null
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
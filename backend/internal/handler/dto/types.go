@@ -11,6 +11,7 @@ type User struct {
 	Concurrency   int       `json:"concurrency"`
 	Status        string    `json:"status"`
 	AllowedGroups []int64   `json:"allowed_groups"`
+	ParentUserID  *int64    `json:"parent_user_id,omitempty"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 
@@ -27,16 +28,17 @@ type AdminUser struct {
 }
 
 type APIKey struct {
-	ID          int64     `json:"id"`
-	UserID      int64     `json:"user_id"`
-	Key         string    `json:"key"`
-	Name        string    `json:"name"`
-	GroupID     *int64    `json:"group_id"`
-	Status      string    `json:"status"`
-	IPWhitelist []string  `json:"ip_whitelist"`
-	IPBlacklist []string  `json:"ip_blacklist"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID             int64     `json:"id"`
+	UserID         int64     `json:"user_id"`
+	Key            string    `json:"key"`
+	Name           string    `json:"name"`
+	GroupID        *int64    `json:"group_id"`
+	OrganizationID *int64    `json:"organization_id,omitempty"`
+	Status         string    `json:"status"`
+	IPWhitelist    []string  `json:"ip_whitelist"`
+	IPBlacklist    []string  `json:"ip_blacklist"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 
 	User  *User  `json:"user,omitempty"`
 	Group *Group `json:"group,omitempty"`
@@ -78,10 +80,31 @@ type AdminGroup struct {
 	ModelRouting        map[string][]int64 `json:"model_routing"`
 	ModelRoutingEnabled bool               `json:"model_routing_enabled"`
 
+	// 预扣费用透支容忍额度 (USD)
+	OverdraftUSD float64 `json:"overdraft_usd"`
+	// count_tokens 计数方式
+	CountTokensMode string `json:"count_tokens_mode"`
+	// 是否启用请求对冲
+	HedgeEnabled bool `json:"hedge_enabled"`
+	// 模型降级链
+	ModelFallback *ModelFallbackConfig `json:"model_fallback"`
+
 	AccountGroups []AccountGroup `json:"account_groups,omitempty"`
 	AccountCount  int64          `json:"account_count,omitempty"`
 }
 
+// ModelFallbackStep 降级链中的一步，group_id 为空时在本分组内降级
+type ModelFallbackStep struct {
+	Model   string `json:"model"`
+	GroupID *int64 `json:"group_id,omitempty"`
+}
+
+// ModelFallbackConfig 分组级模型降级链配置
+type ModelFallbackConfig struct {
+	Triggers []string                       `json:"triggers"`
+	Chains   map[string][]ModelFallbackStep `json:"chains"`
+}
+
 type Account struct {
 	ID                 int64          `json:"id"`
 	Name               string         `json:"name"`
@@ -197,6 +220,7 @@ type RedeemCode struct {
 
 	GroupID      *int64 `json:"group_id"`
 	ValidityDays int    `json:"validity_days"`
+	IssuerUserID *int64 `json:"issuer_user_id,omitempty"`
 
 	User  *User  `json:"user,omitempty"`
 	Group *Group `json:"group,omitempty"`
@@ -221,6 +245,7 @@ type UsageLog struct {
 
 	GroupID        *int64 `json:"group_id"`
 	SubscriptionID *int64 `json:"subscription_id"`
+	OrganizationID *int64 `json:"organization_id,omitempty"`
 
 	InputTokens         int `json:"input_tokens"`
 	OutputTokens        int `json:"output_tokens"`
@@ -238,6 +263,10 @@ type UsageLog struct {
 	ActualCost        float64 `json:"actual_cost"`
 	RateMultiplier    float64 `json:"rate_multiplier"`
 
+	// 缓存写入费用按 TTL 拆分（合计等于 cache_creation_cost）
+	CacheCreation5mCost float64 `json:"cache_creation_5m_cost"`
+	CacheCreation1hCost float64 `json:"cache_creation_1h_cost"`
+
 	BillingType  int8 `json:"billing_type"`
 	Stream       bool `json:"stream"`
 	DurationMs   *int `json:"duration_ms"`
@@ -250,6 +279,9 @@ type UsageLog struct {
 	// User-Agent
 	UserAgent *string `json:"user_agent"`
 
+	// Tags 成本归属标签
+	Tags map[string]string `json:"tags,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 
 	User         *User             `json:"user,omitempty"`
@@ -265,9 +297,15 @@ type AdminUsageLog struct {
 	// AccountRateMultiplier 账号计费倍率快照（nil 表示按 1.0 处理）
 	AccountRateMultiplier *float64 `json:"account_rate_multiplier"`
 
+	// RateRule 产生 rate_multiplier 的倍率规则（nil 表示历史数据）
+	RateRule *string `json:"rate_rule"`
+
 	// IPAddress 用户请求 IP（仅管理员可见）
 	IPAddress *string `json:"ip_address,omitempty"`
 
+	// Hedged 请求触发了对冲（account 为胜出账号）
+	Hedged bool `json:"hedged"`
+
 	// Account 最小账号信息（避免泄露敏感字段）
 	Account *AccountSummary `json:"account,omitempty"`
 }
@@ -299,6 +337,42 @@ type UsageCleanupTask struct {
 	UpdatedAt    time.Time           `json:"updated_at"`
 }
 
+type UsageExportFilters struct {
+	UserID         int64      `json:"user_id,omitempty"`
+	APIKeyID       int64      `json:"api_key_id,omitempty"`
+	AccountID      int64      `json:"account_id,omitempty"`
+	GroupID        int64      `json:"group_id,omitempty"`
+	OrganizationID int64      `json:"organization_id,omitempty"`
+	Model          string     `json:"model,omitempty"`
+	Stream         *bool      `json:"stream,omitempty"`
+	BillingType    *int8      `json:"billing_type,omitempty"`
+	StartTime      *time.Time `json:"start_time,omitempty"`
+	EndTime        *time.Time `json:"end_time,omitempty"`
+}
+
+type UsageExportTask struct {
+	ID       int64              `json:"id"`
+	Format   string             `json:"format"`
+	Status   string             `json:"status"`
+	Filters  UsageExportFilters `json:"filters"`
+	RowCount int64              `json:"row_count"`
+	FileSize int64              `json:"file_size"`
+	// DownloadURL 下载链接（仅成功且未过期的任务返回）
+	DownloadURL  string     `json:"download_url,omitempty"`
+	ErrorMessage *string    `json:"error_message,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// AdminUsageExportTask 管理员视图额外包含导出范围与创建者
+type AdminUsageExportTask struct {
+	UsageExportTask
+	Scope     string `json:"scope"`
+	CreatedBy int64  `json:"created_by"`
+}
+
 // AccountSummary is a minimal account info for usage log display.
 // It intentionally excludes sensitive fields like Credentials, Proxy, etc.
 type AccountSummary struct {
@@ -330,6 +404,14 @@ type UserSubscription struct {
 	WeeklyUsageUSD  float64 `json:"weekly_usage_usd"`
 	MonthlyUsageUSD float64 `json:"monthly_usage_usd"`
 
+	// 套餐绑定（仅通过套餐购买的订阅返回）
+	PlanID          *int64   `json:"plan_id,omitempty"`
+	NextPlanID      *int64   `json:"next_plan_id,omitempty"`
+	AutoRenew       bool     `json:"auto_renew,omitempty"`
+	DailyLimitUSD   *float64 `json:"daily_limit_usd,omitempty"`
+	WeeklyLimitUSD  *float64 `json:"weekly_limit_usd,omitempty"`
+	MonthlyLimitUSD *float64 `json:"monthly_limit_usd,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
@@ -380,3 +462,569 @@ type PromoCodeUsage struct {
 
 	User *User `json:"user,omitempty"`
 }
+
+// PaymentOrder 在线支付订单（用户接口）
+type PaymentOrder struct {
+	ID             int64      `json:"id"`
+	OrderNo        string     `json:"order_no"`
+	UserID         int64      `json:"user_id"`
+	Provider       string     `json:"provider"`
+	Method         string     `json:"method"`
+	OrderType      string     `json:"order_type"`
+	Amount         float64    `json:"amount"`
+	Currency       string     `json:"currency"`
+	CreditAmount   float64    `json:"credit_amount"`
+	GroupID        *int64     `json:"group_id"`
+	ValidityDays   int        `json:"validity_days"`
+	SubscriptionID *int64     `json:"subscription_id"`
+	Status         string     `json:"status"`
+	PayURL         string     `json:"pay_url,omitempty"`
+	RefundedAmount float64    `json:"refunded_amount"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	PaidAt         *time.Time `json:"paid_at"`
+	RefundedAt     *time.Time `json:"refunded_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// AdminPaymentOrder 管理员接口使用的支付订单 DTO（包含渠道单号、备注等内部信息）
+type AdminPaymentOrder struct {
+	PaymentOrder
+
+	ProviderOrderID   string `json:"provider_order_id"`
+	ProviderPaymentID string `json:"provider_payment_id"`
+	FailureReason     string `json:"failure_reason"`
+	Notes             string `json:"notes"`
+	CreatedBy         *int64 `json:"created_by"`
+}
+
+// SubscriptionPlan 订阅套餐
+type SubscriptionPlan struct {
+	ID              int64     `json:"id"`
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	GroupID         int64     `json:"group_id"`
+	Price           float64   `json:"price"`
+	ValidityDays    int       `json:"validity_days"`
+	DailyLimitUSD   *float64  `json:"daily_limit_usd"`
+	WeeklyLimitUSD  *float64  `json:"weekly_limit_usd"`
+	MonthlyLimitUSD *float64  `json:"monthly_limit_usd"`
+	Tier            int       `json:"tier"`
+	AllowUpgrade    bool      `json:"allow_upgrade"`
+	AllowDowngrade  bool      `json:"allow_downgrade"`
+	AllowAutoRenew  bool      `json:"allow_auto_renew"`
+	SortOrder       int       `json:"sort_order"`
+	Status          string    `json:"status"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	Group *Group `json:"group,omitempty"`
+}
+
+// SubscriptionPlanOrder 套餐余额购买记录
+type SubscriptionPlanOrder struct {
+	ID             int64      `json:"id"`
+	UserID         int64      `json:"user_id"`
+	PlanID         int64      `json:"plan_id"`
+	SubscriptionID *int64     `json:"subscription_id"`
+	Action         string     `json:"action"`
+	Price          float64    `json:"price"`
+	Credit         float64    `json:"credit"`
+	Amount         float64    `json:"amount"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// SubscriptionPlanPurchaseResult 套餐购买/变更结果
+type SubscriptionPlanPurchaseResult struct {
+	Subscription *UserSubscription      `json:"subscription"`
+	Order        *SubscriptionPlanOrder `json:"order,omitempty"`
+	Scheduled    bool                   `json:"scheduled"`
+}
+
+// NotificationSettings 用户通知偏好（阈值/天数为生效值，未设置时为系统默认）
+type NotificationSettings struct {
+	EmailEnabled        bool    `json:"email_enabled"`
+	WebhookURL          string  `json:"webhook_url"`
+	WebhookSecret       string  `json:"webhook_secret,omitempty"`
+	LowBalanceEnabled   bool    `json:"low_balance_enabled"`
+	LowBalanceThreshold float64 `json:"low_balance_threshold"`
+	QuotaAlertsEnabled  bool    `json:"quota_alerts_enabled"`
+	ExpiryAlertsEnabled bool    `json:"expiry_alerts_enabled"`
+	ExpiryReminderDays  int     `json:"expiry_reminder_days"`
+	APIKeyAlertsEnabled bool    `json:"api_key_alerts_enabled"`
+}
+
+// Notification 用户通知记录
+type Notification struct {
+	ID            int64     `json:"id"`
+	Type          string    `json:"type"`
+	Title         string    `json:"title"`
+	Message       string    `json:"message"`
+	EmailSent     bool      `json:"email_sent"`
+	WebhookSent   bool      `json:"webhook_sent"`
+	DeliveryError string    `json:"delivery_error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// PaymentProviderInfo 可用支付渠道
+type PaymentProviderInfo struct {
+	Name    string   `json:"name"`
+	Methods []string `json:"methods"`
+}
+
+// PaymentConfig 用户侧支付配置
+type PaymentConfig struct {
+	Enabled        bool                  `json:"enabled"`
+	Currency       string                `json:"currency"`
+	BalancePerUnit float64               `json:"balance_per_unit"`
+	MinAmount      float64               `json:"min_amount"`
+	MaxAmount      float64               `json:"max_amount"`
+	Providers      []PaymentProviderInfo `json:"providers"`
+}
+
+// Organization 组织（Role 为当前用户在组织中的角色，管理端为空）
+type Organization struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	OwnerUserID   int64     `json:"owner_user_id"`
+	BillingUserID int64     `json:"billing_user_id"`
+	Status        string    `json:"status"`
+	Balance       float64   `json:"balance"`
+	MemberCount   int       `json:"member_count"`
+	Role          string    `json:"role,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// OrganizationMember 组织成员（SpentUSD 为本月累计消费）
+type OrganizationMember struct {
+	UserID           int64     `json:"user_id"`
+	Email            string    `json:"email"`
+	Username         string    `json:"username"`
+	Role             string    `json:"role"`
+	SpendingLimitUSD *float64  `json:"spending_limit_usd"`
+	SpentUSD         float64   `json:"spent_usd"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// OrganizationDetail 组织详情及成员列表
+type OrganizationDetail struct {
+	Organization *Organization        `json:"organization"`
+	Members      []OrganizationMember `json:"members"`
+}
+
+// Reseller 分销商资格与加价设置
+type Reseller struct {
+	UserID       int64     `json:"user_id"`
+	Email        string    `json:"email"`
+	Username     string    `json:"username"`
+	Markup       float64   `json:"markup"`
+	MaxSubUsers  int       `json:"max_sub_users"`
+	SubUserCount int       `json:"sub_user_count"`
+	Balance      float64   `json:"balance"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// AdminReseller 管理端分销商（包含备注）
+type AdminReseller struct {
+	Reseller
+
+	Notes string `json:"notes"`
+}
+
+// ResellerReportRow 分销利润报表的一行（按日期或按下级用户分组）
+type ResellerReportRow struct {
+	Date        string  `json:"date,omitempty"`
+	ChildUserID int64   `json:"child_user_id,omitempty"`
+	Email       string  `json:"email,omitempty"`
+	Requests    int64   `json:"requests"`
+	TotalTokens int64   `json:"total_tokens"`
+	Revenue     float64 `json:"revenue"`
+	Cost        float64 `json:"cost"`
+	Profit      float64 `json:"profit"`
+}
+
+// ResellerReport 分销商用量与利润报表
+type ResellerReport struct {
+	StartDate string              `json:"start_date"`
+	EndDate   string              `json:"end_date"`
+	Summary   ResellerReportRow   `json:"summary"`
+	ByDay     []ResellerReportRow `json:"by_day"`
+	BySubUser []ResellerReportRow `json:"by_sub_user"`
+}
+
+// ModelPriceOverride 管理员自定义模型价格版本（价格单位：USD / 百万 token）
+type ModelPriceOverride struct {
+	ID                   int64      `json:"id"`
+	ModelPattern         string     `json:"model_pattern"`
+	GroupID              *int64     `json:"group_id"`
+	InputPrice           float64    `json:"input_price"`
+	OutputPrice          float64    `json:"output_price"`
+	CacheCreationPrice   float64    `json:"cache_creation_price"`
+	CacheReadPrice       float64    `json:"cache_read_price"`
+	CacheCreation1hPrice float64    `json:"cache_creation_1h_price"`
+	EffectiveFrom        time.Time  `json:"effective_from"`
+	Notes                string     `json:"notes"`
+	CreatedBy            *int64     `json:"created_by"`
+	CreatedAt            time.Time  `json:"created_at"`
+	DeletedAt            *time.Time `json:"deleted_at,omitempty"`
+	State                string     `json:"state,omitempty"`
+}
+
+// BillingOutboxEvent 计费发件箱事件（管理端未扣费/死信报表）
+type BillingOutboxEvent struct {
+	ID             int64      `json:"id"`
+	RequestID      string     `json:"request_id"`
+	APIKeyID       int64      `json:"api_key_id"`
+	UsageLogID     *int64     `json:"usage_log_id"`
+	PayerUserID    int64      `json:"payer_user_id"`
+	MemberUserID   int64      `json:"member_user_id"`
+	OrganizationID *int64     `json:"organization_id"`
+	BillingType    int8       `json:"billing_type"`
+	SubscriptionID *int64     `json:"subscription_id"`
+	GroupID        *int64     `json:"group_id"`
+	Amount         float64    `json:"amount"`
+	Reseller       bool       `json:"reseller"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"last_error"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	AppliedAt      *time.Time `json:"applied_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// BillingOutboxStatusSummary 某一状态的计费事件汇总
+type BillingOutboxStatusSummary struct {
+	Status      string     `json:"status"`
+	Count       int64      `json:"count"`
+	Amount      float64    `json:"amount"`
+	OldestAt    *time.Time `json:"oldest_at"`
+	MaxAttempts int        `json:"max_attempts"`
+}
+
+// PricingPreview 管理端费用试算结果（价格单位：USD / 百万 token）
+type PricingPreview struct {
+	Model                string  `json:"model"`
+	Source               string  `json:"source"`
+	OverrideID           *int64  `json:"override_id,omitempty"`
+	InputPrice           float64 `json:"input_price"`
+	OutputPrice          float64 `json:"output_price"`
+	CacheCreationPrice   float64 `json:"cache_creation_price"`
+	CacheReadPrice       float64 `json:"cache_read_price"`
+	CacheCreation1hPrice float64 `json:"cache_creation_1h_price"`
+	RateMultiplier       float64 `json:"rate_multiplier"`
+	InputCost            float64 `json:"input_cost"`
+	OutputCost           float64 `json:"output_cost"`
+	CacheCreationCost    float64 `json:"cache_creation_cost"`
+	CacheCreation5mCost  float64 `json:"cache_creation_5m_cost"`
+	CacheCreation1hCost  float64 `json:"cache_creation_1h_cost"`
+	CacheReadCost        float64 `json:"cache_read_cost"`
+	TotalCost            float64 `json:"total_cost"`
+	ActualCost           float64 `json:"actual_cost"`
+}
+
+// RateMultiplierOverride 分组内用户或 API Key 的倍率覆盖
+type RateMultiplierOverride struct {
+	ID             int64     `json:"id"`
+	GroupID        int64     `json:"group_id"`
+	UserID         *int64    `json:"user_id"`
+	APIKeyID       *int64    `json:"api_key_id"`
+	RateMultiplier float64   `json:"rate_multiplier"`
+	Notes          string    `json:"notes"`
+	CreatedBy      *int64    `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// VolumeDiscountTier 月消费阶梯折扣（group_id 为空表示全局阶梯）
+type VolumeDiscountTier struct {
+	ID              int64     `json:"id"`
+	GroupID         *int64    `json:"group_id"`
+	MinMonthlySpend float64   `json:"min_monthly_spend"`
+	DiscountPercent float64   `json:"discount_percent"`
+	Notes           string    `json:"notes"`
+	CreatedBy       *int64    `json:"created_by"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// RateMultiplierPreviewRow 单个用户在单个分组内的规则预览
+type RateMultiplierPreviewRow struct {
+	UserID       int64   `json:"user_id"`
+	UserEmail    string  `json:"user_email"`
+	GroupID      int64   `json:"group_id"`
+	GroupName    string  `json:"group_name"`
+	Requests     int64   `json:"requests"`
+	ActualCost   float64 `json:"actual_cost"`
+	CurrentCost  float64 `json:"current_cost"`
+	ProposedCost float64 `json:"proposed_cost"`
+}
+
+// RateMultiplierPreview 上月余额计费用量按当前规则与拟议规则重算的结果
+type RateMultiplierPreview struct {
+	PeriodStart  time.Time                  `json:"period_start"`
+	PeriodEnd    time.Time                  `json:"period_end"`
+	Requests     int64                      `json:"requests"`
+	ActualCost   float64                    `json:"actual_cost"`
+	CurrentCost  float64                    `json:"current_cost"`
+	ProposedCost float64                    `json:"proposed_cost"`
+	Rows         []RateMultiplierPreviewRow `json:"rows"`
+	TotalRows    int                        `json:"total_rows"`
+}
+
+// UserStatement 月度账单（列表视图）
+type UserStatement struct {
+	ID                       int64      `json:"id"`
+	Period                   string     `json:"period"`
+	PeriodStart              time.Time  `json:"period_start"`
+	PeriodEnd                time.Time  `json:"period_end"`
+	Currency                 string     `json:"currency"`
+	TotalRequests            int64      `json:"total_requests"`
+	TotalTokens              int64      `json:"total_tokens"`
+	UsageCost                float64    `json:"usage_cost"`
+	CreditsTotal             float64    `json:"credits_total"`
+	SubscriptionChargesTotal float64    `json:"subscription_charges_total"`
+	EmailedAt                *time.Time `json:"emailed_at,omitempty"`
+	CreatedAt                time.Time  `json:"created_at"`
+}
+
+// UserStatementDetail 月度账单详情，包含分组/模型/API Key 明细与余额、订阅变动
+type UserStatementDetail struct {
+	UserStatement
+	StandardCost        float64                  `json:"standard_cost"`
+	Usage               []UserStatementUsageLine `json:"usage"`
+	ByGroup             []UserStatementSubtotal  `json:"by_group"`
+	ByModel             []UserStatementSubtotal  `json:"by_model"`
+	ByAPIKey            []UserStatementSubtotal  `json:"by_api_key"`
+	Credits             []UserStatementCredit    `json:"credits"`
+	SubscriptionCharges []UserStatementCharge    `json:"subscription_charges"`
+}
+
+type UserStatementUsageLine struct {
+	GroupID             int64   `json:"group_id"`
+	GroupName           string  `json:"group_name"`
+	Model               string  `json:"model"`
+	APIKeyID            int64   `json:"api_key_id"`
+	APIKeyName          string  `json:"api_key_name"`
+	Requests            int64   `json:"requests"`
+	InputTokens         int64   `json:"input_tokens"`
+	OutputTokens        int64   `json:"output_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	CacheReadTokens     int64   `json:"cache_read_tokens"`
+	TotalCost           float64 `json:"total_cost"`
+	ActualCost          float64 `json:"actual_cost"`
+}
+
+type UserStatementSubtotal struct {
+	Name       string  `json:"name"`
+	Requests   int64   `json:"requests"`
+	Tokens     int64   `json:"tokens"`
+	ActualCost float64 `json:"actual_cost"`
+}
+
+type UserStatementCredit struct {
+	Source     string    `json:"source"`
+	Reference  string    `json:"reference"`
+	Amount     float64   `json:"amount"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type UserStatementCharge struct {
+	Source      string    `json:"source"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"`
+	Currency    string    `json:"currency"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+// UsageTagKey 管理员定义的成本归属标签键（allowed_values 为空表示不限制标签值）
+type UsageTagKey struct {
+	ID            int64     `json:"id"`
+	Key           string    `json:"key"`
+	Description   string    `json:"description"`
+	AllowedValues []string  `json:"allowed_values"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// UsageTagBudget 用户按标签值设置的月度预算
+type UsageTagBudget struct {
+	ID              int64     `json:"id"`
+	UserID          int64     `json:"user_id"`
+	TagKey          string    `json:"tag_key"`
+	TagValue        string    `json:"tag_value"`
+	MonthlyLimitUSD float64   `json:"monthly_limit_usd"`
+	MonthSpend      float64   `json:"month_spend"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// AnthropicFile 用户通过 Files API 上传的文件及其归属账号
+type AnthropicFile struct {
+	ID           int64     `json:"id"`
+	FileID       string    `json:"file_id"`
+	AccountID    int64     `json:"account_id"`
+	AccountName  string    `json:"account_name"`
+	UserID       int64     `json:"user_id"`
+	UserEmail    string    `json:"user_email"`
+	APIKeyID     int64     `json:"api_key_id"`
+	GroupID      *int64    `json:"group_id,omitempty"`
+	Filename     string    `json:"filename"`
+	MimeType     string    `json:"mime_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Downloadable bool      `json:"downloadable"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// UsageAnomalyEvent 检测到的 API Key / 用户用量异常（用户级事件 api_key_id 为 0）
+type UsageAnomalyEvent struct {
+	ID             int64          `json:"id"`
+	UserID         int64          `json:"user_id"`
+	UserEmail      string         `json:"user_email"`
+	APIKeyID       int64          `json:"api_key_id"`
+	APIKeyName     string         `json:"api_key_name"`
+	BucketStart    time.Time      `json:"bucket_start"`
+	Kind           string         `json:"kind"`
+	Severity       string         `json:"severity"`
+	Observed       float64        `json:"observed"`
+	BaselineMean   float64        `json:"baseline_mean"`
+	BaselineStddev float64        `json:"baseline_stddev"`
+	Score          float64        `json:"score"`
+	Details        map[string]any `json:"details"`
+	KeySuspended   bool           `json:"key_suspended"`
+	Status         string         `json:"status"`
+	AcknowledgedBy *int64         `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time     `json:"acknowledged_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+// UsageTagStat 单个标签值的用量汇总（tag_value 为空表示未携带该标签键的请求）
+type UsageTagStat struct {
+	TagValue     string  `json:"tag_value"`
+	Requests     int64   `json:"requests"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	TotalCost    float64 `json:"total_cost"`
+	ActualCost   float64 `json:"actual_cost"`
+}
+
+// StickySessionSummary 粘性会话跨账号的缓存命中与切换成本汇总
+type StickySessionSummary struct {
+	GroupID               int64     `json:"group_id"`
+	SessionHash           string    `json:"session_hash"`
+	AccountCount          int64     `json:"account_count"`
+	RequestCount          int64     `json:"request_count"`
+	InputTokens           int64     `json:"input_tokens"`
+	CacheCreationTokens   int64     `json:"cache_creation_tokens"`
+	CacheReadTokens       int64     `json:"cache_read_tokens"`
+	CacheHitRatio         float64   `json:"cache_hit_ratio"`
+	FailoverCount         int64     `json:"failover_count"`
+	EstimatedFailoverCost float64   `json:"estimated_failover_cost"`
+	LastUsedAt            time.Time `json:"last_used_at"`
+}
+
+// StickySessionStat 粘性会话在单个账号上的缓存命中情况
+type StickySessionStat struct {
+	AccountID           int64      `json:"account_id"`
+	AccountName         string     `json:"account_name"`
+	RequestCount        int64      `json:"request_count"`
+	InputTokens         int64      `json:"input_tokens"`
+	CacheCreationTokens int64      `json:"cache_creation_tokens"`
+	CacheReadTokens     int64      `json:"cache_read_tokens"`
+	CacheHitRatio       float64    `json:"cache_hit_ratio"`
+	LastCacheReadAt     *time.Time `json:"last_cache_read_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// StickySessionFailover 粘性会话主账号切换记录及估算成本
+type StickySessionFailover struct {
+	ID                  int64     `json:"id"`
+	GroupID             int64     `json:"group_id"`
+	SessionHash         string    `json:"session_hash"`
+	FromAccountID       int64     `json:"from_account_id"`
+	FromAccountName     string    `json:"from_account_name"`
+	ToAccountID         int64     `json:"to_account_id"`
+	ToAccountName       string    `json:"to_account_name"`
+	Standby             bool      `json:"standby"`
+	Warm                bool      `json:"warm"`
+	Model               string    `json:"model"`
+	CacheCreationTokens int64     `json:"cache_creation_tokens"`
+	CacheReadTokens     int64     `json:"cache_read_tokens"`
+	EstimatedCost       float64   `json:"estimated_cost"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+// RequestHedgeStats 分组请求对冲统计（进程内，重启清零）
+type RequestHedgeStats struct {
+	GroupID      int64   `json:"group_id"`
+	Requests     int64   `json:"requests"`
+	Hedged       int64   `json:"hedged"`
+	HedgeWins    int64   `json:"hedge_wins"`
+	BudgetDenied int64   `json:"budget_denied"`
+	NoAccount    int64   `json:"no_account"`
+	HedgeRate    float64 `json:"hedge_rate"`
+	DelayMs      int64   `json:"delay_ms"`
+	Samples      int     `json:"samples"`
+	BudgetTokens float64 `json:"budget_tokens"`
+}
+
+// GuardrailPolicy 内容策略（group_id 与 api_key_id 均为空时为全局策略）
+type GuardrailPolicy struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	GroupID       *int64    `json:"group_id,omitempty"`
+	APIKeyID      *int64    `json:"api_key_id,omitempty"`
+	Enabled       bool      `json:"enabled"`
+	Action        string    `json:"action"`
+	Detectors     []string  `json:"detectors"`
+	Keywords      []string  `json:"keywords"`
+	Patterns      []string  `json:"patterns"`
+	HookURL       string    `json:"hook_url"`
+	ScanResponses bool      `json:"scan_responses"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// PromptTemplate 系统提示词模板（group_id 与 api_key_id 均为空时为全局模板）
+type PromptTemplate struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	GroupID   *int64    `json:"group_id,omitempty"`
+	APIKeyID  *int64    `json:"api_key_id,omitempty"`
+	Enabled   bool      `json:"enabled"`
+	Mode      string    `json:"mode"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ConfigReloadStatus 最近一次配置热更新的结果
+type ConfigReloadStatus struct {
+	Trigger         string    `json:"trigger"`
+	At              time.Time `json:"at"`
+	Success         bool      `json:"success"`
+	Error           string    `json:"error,omitempty"`
+	Applied         []string  `json:"applied"`
+	RestartRequired []string  `json:"restart_required"`
+	FilePath        string    `json:"file_path"`
+}
+
+// GuardrailEvent 内容策略命中记录（findings: 检测器 -> 命中次数）
+type GuardrailEvent struct {
+	ID         int64          `json:"id"`
+	PolicyID   *int64         `json:"policy_id,omitempty"`
+	PolicyName string         `json:"policy_name"`
+	UserID     *int64         `json:"user_id,omitempty"`
+	APIKeyID   *int64         `json:"api_key_id,omitempty"`
+	GroupID    *int64         `json:"group_id,omitempty"`
+	Direction  string         `json:"direction"`
+	Action     string         `json:"action"`
+	Findings   map[string]int `json:"findings"`
+	Model      string         `json:"model"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
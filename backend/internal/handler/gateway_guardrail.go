@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	pkgerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// 内容策略拦截响应时的错误格式（与各入口的错误响应保持一致）
+const (
+	guardrailFormatAnthropic = "anthropic"
+	guardrailFormatOpenAI    = "openai"
+	guardrailFormatGemini    = "gemini"
+)
+
+// errGuardrailResponseBlocked 响应被内容策略中断；网关按客户端断开处理，继续读取上游以完成计费
+var errGuardrailResponseBlocked = errors.New("response blocked by content policy")
+
+// guardrailErrorDetails 请求被内容策略拒绝或审核钩子不可用时的错误响应
+func guardrailErrorDetails(err error) (status int, code, message string) {
+	switch {
+	case errors.Is(err, service.ErrGuardrailBlocked):
+		return http.StatusForbidden, "permission_error", pkgerrors.Message(err)
+	case errors.Is(err, service.ErrGuardrailHookUnavailable):
+		return http.StatusServiceUnavailable, "api_error", pkgerrors.Message(err)
+	default:
+		return http.StatusInternalServerError, "api_error", "Failed to apply content policy"
+	}
+}
+
+// guardrailResponseWriter 按内容策略检查响应：
+// SSE 响应逐事件交给扫描器（文本增量在各内容块的滚动窗口中检查），命中 block 时写入错误事件并中断输出；
+// JSON 响应缓存到请求结束后统一检查，命中 block 时替换为 403 错误；其他类型原样输出。
+type guardrailResponseWriter struct {
+	gin.ResponseWriter
+	scanner *service.GuardrailResponseScanner
+	format  string
+
+	mode    int
+	pending []byte // 未写完的行
+	event   []byte // 当前 SSE 事件已读到的行，遇到空行时处理
+	buf     bytes.Buffer
+	blocked bool
+}
+
+const (
+	guardrailWriterModeUnknown = iota
+	guardrailWriterModeSSE
+	guardrailWriterModeJSON
+	guardrailWriterModePassthrough
+)
+
+// installGuardrailResponseWriter 存在适用于响应的策略时替换 c.Writer，返回的 finish 需在请求结束时调用
+func installGuardrailResponseWriter(c *gin.Context, svc *service.GuardrailService, apiKey *service.APIKey, model, format string) func() {
+	scanner := svc.NewResponseScanner(c.Request.Context(), apiKey, model)
+	if scanner == nil {
+		return func() {}
+	}
+	w := &guardrailResponseWriter{ResponseWriter: c.Writer, scanner: scanner, format: format}
+	c.Writer = w
+	return w.finish
+}
+
+func (w *guardrailResponseWriter) detectMode() {
+	if w.mode != guardrailWriterModeUnknown {
+		return
+	}
+	contentType := w.Header().Get("Content-Type")
+	switch {
+	case strings.Contains(contentType, "text/event-stream"):
+		w.mode = guardrailWriterModeSSE
+	case strings.Contains(contentType, "application/json"):
+		w.mode = guardrailWriterModeJSON
+		// 脱敏会改变响应长度
+		w.Header().Del("Content-Length")
+	default:
+		w.mode = guardrailWriterModePassthrough
+	}
+}
+
+func (w *guardrailResponseWriter) WriteHeaderNow() {
+	w.detectMode()
+	if w.mode != guardrailWriterModeJSON {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *guardrailResponseWriter) Written() bool {
+	return w.ResponseWriter.Written() || w.buf.Len() > 0
+}
+
+func (w *guardrailResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *guardrailResponseWriter) Write(data []byte) (int, error) {
+	if w.blocked {
+		return 0, errGuardrailResponseBlocked
+	}
+	w.detectMode()
+	switch w.mode {
+	case guardrailWriterModeJSON:
+		return w.buf.Write(data)
+	case guardrailWriterModeSSE:
+		return w.writeSSE(data)
+	default:
+		return w.ResponseWriter.Write(data)
+	}
+}
+
+// writeSSE 按事件检查：完整的事件处理后输出，不完整的事件留到下次写入
+func (w *guardrailResponseWriter) writeSSE(data []byte) (int, error) {
+	w.pending = append(w.pending, data...)
+	idx := bytes.LastIndexByte(w.pending, '\n')
+	if idx < 0 {
+		return len(data), nil
+	}
+	complete := w.pending[:idx+1]
+	var out bytes.Buffer
+	for len(complete) > 0 {
+		lineEnd := bytes.IndexByte(complete, '\n') + 1
+		line := complete[:lineEnd]
+		complete = complete[lineEnd:]
+		w.event = append(w.event, line...)
+		if len(bytes.TrimRight(line, "\r\n")) > 0 {
+			continue
+		}
+		blocked := !w.filterSSEEvent(&out, w.event)
+		w.event = w.event[:0]
+		if blocked {
+			return 0, w.abortSSE(&out)
+		}
+	}
+	w.pending = append(w.pending[:0], w.pending[idx+1:]...)
+	if _, err := w.ResponseWriter.Write(out.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// abortSSE 输出已通过检查的内容与错误事件，之后的写入均被拒绝
+func (w *guardrailResponseWriter) abortSSE(out *bytes.Buffer) error {
+	w.blocked = true
+	out.Write(w.sseErrorEvent())
+	_, _ = w.ResponseWriter.Write(out.Bytes())
+	w.ResponseWriter.Flush()
+	w.pending, w.event = nil, nil
+	return errGuardrailResponseBlocked
+}
+
+// filterSSEEvent 检查一个完整的 SSE 事件（含结尾空行）并写入 out；JSON data 交给扫描器，
+// 其他 data（如 [DONE]）先补发各内容块剩余的文本，不含 data 的事件（注释、keepalive）原样输出。
+// 命中 block 时返回 false。
+func (w *guardrailResponseWriter) filterSSEEvent(out *bytes.Buffer, event []byte) bool {
+	var prefix, payload, suffix []byte
+	dataLines := 0
+	for rest := event; len(rest) > 0; {
+		lineEnd := bytes.IndexByte(rest, '\n') + 1
+		if lineEnd == 0 {
+			lineEnd = len(rest)
+		}
+		line := rest[:lineEnd]
+		rest = rest[lineEnd:]
+		switch {
+		case bytes.HasPrefix(line, []byte("data:")):
+			if dataLines > 0 {
+				payload = append(payload, '\n')
+			}
+			payload = append(payload, bytes.TrimSpace(line[len("data:"):])...)
+			dataLines++
+			suffix = nil
+		case dataLines == 0:
+			prefix = append(prefix, line...)
+		default:
+			suffix = append(suffix, line...)
+		}
+	}
+	if dataLines == 0 {
+		out.Write(event)
+		return true
+	}
+	if len(payload) == 0 || payload[0] != '{' {
+		flushed, blocked := w.scanner.FlushStream()
+		if blocked {
+			return false
+		}
+		writeGuardrailSSEEvents(out, flushed)
+		out.Write(event)
+		return true
+	}
+	flushed, filtered, blocked := w.scanner.FilterStreamEvent(service.GuardrailStreamEvent{Prefix: prefix, Data: payload})
+	if blocked {
+		return false
+	}
+	writeGuardrailSSEEvents(out, flushed)
+	if bytes.Equal(filtered, payload) {
+		out.Write(event)
+		return true
+	}
+	out.Write(prefix)
+	out.WriteString("data: ")
+	out.Write(filtered)
+	out.WriteByte('\n')
+	out.Write(suffix)
+	return true
+}
+
+func writeGuardrailSSEEvents(out *bytes.Buffer, events []service.GuardrailStreamEvent) {
+	for _, ev := range events {
+		out.Write(ev.Prefix)
+		out.WriteString("data: ")
+		out.Write(ev.Data)
+		out.WriteString("\n\n")
+	}
+}
+
+// finish 输出缓存的 JSON 响应、未完成的 SSE 事件与各内容块剩余的文本，并写入命中记录
+func (w *guardrailResponseWriter) finish() {
+	defer w.scanner.Finish()
+	if w.blocked {
+		return
+	}
+	switch w.mode {
+	case guardrailWriterModeSSE:
+		if len(w.pending) > 0 {
+			_, _ = w.writeSSE([]byte("\n"))
+		}
+		if len(w.event) > 0 && !w.blocked {
+			_, _ = w.writeSSE([]byte("\n"))
+		}
+		if w.blocked {
+			return
+		}
+		var out bytes.Buffer
+		flushed, blocked := w.scanner.FlushStream()
+		if blocked {
+			_ = w.abortSSE(&out)
+			return
+		}
+		writeGuardrailSSEEvents(&out, flushed)
+		if out.Len() > 0 {
+			_, _ = w.ResponseWriter.Write(out.Bytes())
+			w.ResponseWriter.Flush()
+		}
+	case guardrailWriterModeJSON:
+		body := w.buf.Bytes()
+		w.buf = bytes.Buffer{}
+		filtered, blocked := w.scanner.FilterJSON(body)
+		if blocked {
+			w.blocked = true
+			w.ResponseWriter.WriteHeader(http.StatusForbidden)
+			filtered = w.jsonErrorBody()
+		}
+		if len(filtered) > 0 {
+			_, _ = w.ResponseWriter.Write(filtered)
+		} else {
+			w.ResponseWriter.WriteHeaderNow()
+		}
+	}
+}
+
+func (w *guardrailResponseWriter) sseErrorEvent() []byte {
+	message := w.scanner.BlockedMessage()
+	switch w.format {
+	case guardrailFormatOpenAI:
+		payload, _ := json.Marshal(gin.H{"type": "error", "code": "content_policy_violation", "message": message})
+		return []byte("event: error\ndata: " + string(payload) + "\n\n")
+	case guardrailFormatGemini:
+		payload, _ := json.Marshal(gin.H{"error": gin.H{"code": http.StatusForbidden, "message": message, "status": "PERMISSION_DENIED"}})
+		return []byte("data: " + string(payload) + "\n\n")
+	default:
+		payload, _ := json.Marshal(gin.H{"type": "error", "error": gin.H{"type": "permission_error", "message": message}})
+		return []byte("event: error\ndata: " + string(payload) + "\n\n")
+	}
+}
+
+func (w *guardrailResponseWriter) jsonErrorBody() []byte {
+	message := w.scanner.BlockedMessage()
+	var payload []byte
+	switch w.format {
+	case guardrailFormatOpenAI:
+		payload, _ = json.Marshal(gin.H{"error": gin.H{"type": "permission_error", "code": "content_policy_violation", "message": message}})
+	case guardrailFormatGemini:
+		payload, _ = json.Marshal(gin.H{"error": gin.H{"code": http.StatusForbidden, "message": message, "status": "PERMISSION_DENIED"}})
+	default:
+		payload, _ = json.Marshal(gin.H{"type": "error", "error": gin.H{"type": "permission_error", "message": message}})
+	}
+	return payload
+}
@@ -17,6 +17,7 @@ import (
 	pkgerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/openai"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tokenizer"
 	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
@@ -30,9 +31,14 @@ type GatewayHandler struct {
 	antigravityGatewayService *service.AntigravityGatewayService
 	userService               *service.UserService
 	billingCacheService       *service.BillingCacheService
+	costHoldService           *service.CostHoldService
+	usageTagService           *service.UsageTagService
+	geminiResourceService     *service.GeminiResourceService
+	anthropicFileService      *service.AnthropicFileService
+	guardrailService          *service.GuardrailService
+	promptTemplateService     *service.PromptTemplateService
 	concurrencyHelper         *ConcurrencyHelper
-	maxAccountSwitches        int
-	maxAccountSwitchesGemini  int
+	cfg                       *config.Config
 }
 
 // NewGatewayHandler creates a new GatewayHandler
@@ -43,19 +49,17 @@ func NewGatewayHandler(
 	userService *service.UserService,
 	concurrencyService *service.ConcurrencyService,
 	billingCacheService *service.BillingCacheService,
+	costHoldService *service.CostHoldService,
+	usageTagService *service.UsageTagService,
+	geminiResourceService *service.GeminiResourceService,
+	anthropicFileService *service.AnthropicFileService,
+	guardrailService *service.GuardrailService,
+	promptTemplateService *service.PromptTemplateService,
 	cfg *config.Config,
 ) *GatewayHandler {
 	pingInterval := time.Duration(0)
-	maxAccountSwitches := 10
-	maxAccountSwitchesGemini := 3
 	if cfg != nil {
 		pingInterval = time.Duration(cfg.Concurrency.PingInterval) * time.Second
-		if cfg.Gateway.MaxAccountSwitches > 0 {
-			maxAccountSwitches = cfg.Gateway.MaxAccountSwitches
-		}
-		if cfg.Gateway.MaxAccountSwitchesGemini > 0 {
-			maxAccountSwitchesGemini = cfg.Gateway.MaxAccountSwitchesGemini
-		}
 	}
 	return &GatewayHandler{
 		gatewayService:            gatewayService,
@@ -63,10 +67,33 @@ func NewGatewayHandler(
 		antigravityGatewayService: antigravityGatewayService,
 		userService:               userService,
 		billingCacheService:       billingCacheService,
+		costHoldService:           costHoldService,
+		usageTagService:           usageTagService,
+		geminiResourceService:     geminiResourceService,
+		anthropicFileService:      anthropicFileService,
+		guardrailService:          guardrailService,
+		promptTemplateService:     promptTemplateService,
 		concurrencyHelper:         NewConcurrencyHelper(concurrencyService, SSEPingFormatClaude, pingInterval),
-		maxAccountSwitches:        maxAccountSwitches,
-		maxAccountSwitchesGemini:  maxAccountSwitchesGemini,
+		cfg:                       cfg,
+	}
+}
+
+// accountSwitchLimit 每次请求读取最大账号切换次数，配置热更新后立即生效
+func (h *GatewayHandler) accountSwitchLimit() int {
+	cfg := h.cfg.Current()
+	if cfg != nil && cfg.Gateway.MaxAccountSwitches > 0 {
+		return cfg.Gateway.MaxAccountSwitches
 	}
+	return 10
+}
+
+// geminiAccountSwitchLimit Gemini 请求的最大账号切换次数
+func (h *GatewayHandler) geminiAccountSwitchLimit() int {
+	cfg := h.cfg.Current()
+	if cfg != nil && cfg.Gateway.MaxAccountSwitchesGemini > 0 {
+		return cfg.Gateway.MaxAccountSwitchesGemini
+	}
+	return 3
 }
 
 // Messages handles Claude API compatible messages endpoint
@@ -101,9 +128,28 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		return
 	}
 
+	// metadata.tags 为本服务的扩展字段，转发前从请求体中移除
+	metadataTags, body, err := service.ExtractMetadataTags(body)
+	if err != nil {
+		status, code, message := usageTagErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+
+	// 内容策略：转发前检查请求，redact 策略会改写请求体
+	body, err = h.guardrailService.CheckRequest(c.Request.Context(), apiKey, "", body)
+	if err != nil {
+		status, code, message := guardrailErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+
 	// 检查是否为 Claude Code 客户端，设置到 context 中
 	SetClaudeCodeClientContext(c, body)
 
+	// 系统提示词模板：在客户端识别与内容策略检查之后注入，避免管理员配置的提示词被误判或脱敏
+	body = h.promptTemplateService.Apply(apiKey, service.PromptTemplateFormatAnthropic, body)
+
 	setOpsRequestContext(c, "", false, body)
 
 	parsedReq, err := service.ParseGatewayRequest(body)
@@ -122,6 +168,26 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		return
 	}
 
+	// 未知价格策略为 block 时拒绝没有价格的模型
+	if err := h.gatewayService.CheckModelPricing(reqModel, apiKey.GroupID); err != nil {
+		status, code, message := billingErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+
+	// 成本归属标签：请求头优先于 metadata.tags
+	tags, err := h.usageTagService.Resolve(c.Request.Context(), apiKey, c.GetHeader(service.UsageTagHeader), metadataTags)
+	if err != nil {
+		status, code, message := usageTagErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+
+	// 引用了 Files API 文件时固定调度到文件所在账号
+	if !h.pinFileAccount(c, apiKey, subject.UserID, body) {
+		return
+	}
+
 	// Track if we've started streaming (for error handling)
 	streamStarted := false
 
@@ -175,6 +241,31 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		return
 	}
 
+	// 预扣本次请求的最大费用，防止并发请求透支；请求失败时释放，成功时在使用量入账后释放
+	costHold, err := h.costHoldService.Reserve(c.Request.Context(), &service.CostHoldInput{
+		User:         apiKey.User,
+		APIKey:       apiKey,
+		Group:        apiKey.Group,
+		Subscription: subscription,
+		Model:        reqModel,
+		Body:         body,
+	})
+	if err != nil {
+		log.Printf("Cost hold failed: %v", err)
+		status, code, message := billingErrorDetails(err)
+		h.handleStreamingAwareError(c, status, code, message, streamStarted)
+		return
+	}
+	defer func() {
+		if costHold != nil {
+			h.costHoldService.Release(context.Background(), costHold)
+		}
+	}()
+
+	// 内容策略：检查响应（含流式输出）
+	finishGuardrail := installGuardrailResponseWriter(c, h.guardrailService, apiKey, reqModel, guardrailFormatAnthropic)
+	defer finishGuardrail()
+
 	// 计算粘性会话hash
 	sessionHash := h.gatewayService.GenerateSessionHash(parsedReq)
 
@@ -191,7 +282,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	}
 
 	if platform == service.PlatformGemini {
-		maxAccountSwitches := h.maxAccountSwitchesGemini
+		maxAccountSwitches := h.geminiAccountSwitchLimit()
 		switchCount := 0
 		failedAccountIDs := make(map[int64]struct{})
 		lastFailoverStatus := 0
@@ -305,18 +396,24 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 			userAgent := c.GetHeader("User-Agent")
 			clientIP := ip.GetClientIP(c)
 
-			// 异步记录使用量（subscription已在函数开头获取）
+			// 异步记录使用量（subscription已在函数开头获取），入账后释放预扣
+			hold := costHold
+			costHold = nil
 			go func(result *service.ForwardResult, usedAccount *service.Account, ua, clientIP string) {
 				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 				defer cancel()
+				defer h.costHoldService.Release(ctx, hold)
 				if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
 					Result:       result,
 					APIKey:       apiKey,
 					User:         apiKey.User,
 					Account:      usedAccount,
 					Subscription: subscription,
+					CostHold:     hold,
 					UserAgent:    ua,
 					IPAddress:    clientIP,
+					Tags:         tags,
+					SessionHash:  sessionKey,
 				}); err != nil {
 					log.Printf("Record usage failed: %v", err)
 				}
@@ -325,19 +422,45 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		}
 	}
 
-	maxAccountSwitches := h.maxAccountSwitches
+	maxAccountSwitches := h.accountSwitchLimit()
 	switchCount := 0
 	failedAccountIDs := make(map[int64]struct{})
 	lastFailoverStatus := 0
 
+	// 分组模型降级链：容量不足时按顺序切换到后备模型（强制平台路由不降级）
+	var fallbackPlan *service.ModelFallbackPlan
+	if !middleware2.HasForcePlatform(c) {
+		fallbackPlan = service.NewModelFallbackPlan(apiKey.Group, reqModel)
+	}
+	target := &modelFallbackTarget{parsed: parsedReq, groupID: apiKey.GroupID, platform: platform, sessionKey: sessionKey, subscription: subscription}
+	// fallback 切换到下一个降级模型，并重置账号切换状态
+	fallback := func(trigger string) bool {
+		if !h.nextModelFallback(c, fallbackPlan, target, apiKey, subscription, platform, sessionHash, trigger) {
+			return false
+		}
+		failedAccountIDs = make(map[int64]struct{})
+		switchCount = 0
+		return true
+	}
+
 	for {
 		// 选择支持该模型的账号
-		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionKey, reqModel, failedAccountIDs, parsedReq.MetadataUserID)
+		metadataUserID := target.parsed.MetadataUserID
+		if target.platform == service.PlatformGemini {
+			metadataUserID = "" // Gemini 不使用会话限制
+		}
+		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), target.groupID, target.sessionKey, target.parsed.Model, failedAccountIDs, metadataUserID)
 		if err != nil {
 			if len(failedAccountIDs) == 0 {
+				if fallback(service.ModelFallbackTriggerNoAccounts) {
+					continue
+				}
 				h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error(), streamStarted)
 				return
 			}
+			if fallback(service.ModelFallbackTriggerSwitchesExhausted) {
+				continue
+			}
 			h.handleFailoverExhausted(c, lastFailoverStatus, streamStarted)
 			return
 		}
@@ -361,6 +484,9 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		accountReleaseFunc := selection.ReleaseFunc
 		if !selection.Acquired {
 			if selection.WaitPlan == nil {
+				if fallback(service.ModelFallbackTriggerNoAccounts) {
+					continue
+				}
 				h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts", streamStarted)
 				return
 			}
@@ -399,19 +525,23 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				h.concurrencyHelper.DecrementAccountWaitCount(c.Request.Context(), account.ID)
 				accountWaitCounted = false
 			}
-			if err := h.gatewayService.BindStickySession(c.Request.Context(), apiKey.GroupID, sessionKey, account.ID); err != nil {
+			if err := h.gatewayService.BindStickySession(c.Request.Context(), target.groupID, target.sessionKey, account.ID); err != nil {
 				log.Printf("Bind sticky session failed: %v", err)
 			}
 		}
 		// 账号槽位/等待计数需要在超时或断开时安全回收
 		accountReleaseFunc = wrapReleaseOnDone(c.Request.Context(), accountReleaseFunc)
 
-		// 转发请求 - 根据账号平台分流
+		// 转发请求 - 根据账号平台分流（跨平台降级到 Gemini 分组时走 Claude 兼容转换）
+		setServedModelHeaders(c, fallbackPlan, target.parsed.Model)
 		var result *service.ForwardResult
-		if account.Platform == service.PlatformAntigravity {
-			result, err = h.antigravityGatewayService.Forward(c.Request.Context(), c, account, body)
-		} else {
-			result, err = h.gatewayService.Forward(c.Request.Context(), c, account, parsedReq)
+		switch account.Platform {
+		case service.PlatformAntigravity:
+			result, err = h.antigravityGatewayService.Forward(c.Request.Context(), c, account, target.parsed.Body)
+		case service.PlatformGemini:
+			result, err = h.geminiCompatService.Forward(c.Request.Context(), c, account, target.parsed.Body)
+		default:
+			result, err = h.gatewayService.Forward(c.Request.Context(), c, account, target.parsed)
 		}
 		if accountReleaseFunc != nil {
 			accountReleaseFunc()
@@ -421,7 +551,14 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 			if errors.As(err, &failoverErr) {
 				failedAccountIDs[account.ID] = struct{}{}
 				lastFailoverStatus = failoverErr.StatusCode
+				// 529 过载是模型级容量问题，直接降级而不是继续切换账号
+				if failoverErr.StatusCode == 529 && fallback(service.ModelFallbackTriggerOverloaded) {
+					continue
+				}
 				if switchCount >= maxAccountSwitches {
+					if fallback(service.ModelFallbackTriggerSwitchesExhausted) {
+						continue
+					}
 					h.handleFailoverExhausted(c, lastFailoverStatus, streamStarted)
 					return
 				}
@@ -434,22 +571,41 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 			return
 		}
 
+		// 对冲账号胜出：以实际服务的账号计费，粘性会话改绑到该账号
+		if result.HedgeAccount != nil {
+			account = result.HedgeAccount
+			setOpsSelectedAccount(c, account.ID)
+			if err := h.gatewayService.BindStickySession(c.Request.Context(), target.groupID, target.sessionKey, account.ID); err != nil {
+				log.Printf("Bind sticky session failed: %v", err)
+			}
+		}
+
 		// 捕获请求信息（用于异步记录，避免在 goroutine 中访问 gin.Context）
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
 
-		// 异步记录使用量（subscription已在函数开头获取）
+		// 异步记录使用量（subscription已在函数开头获取），入账后释放预扣
+		// 发生模型降级时 result.Model 为实际运行的降级模型，按其及实际调度的分组计费
+		hold := costHold
+		costHold = nil
+		sessionKey := target.sessionKey
+		usedGroup, usedSubscription := target.group, target.subscription
 		go func(result *service.ForwardResult, usedAccount *service.Account, ua, clientIP string) {
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
+			defer h.costHoldService.Release(ctx, hold)
 			if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
 				Result:       result,
 				APIKey:       apiKey,
 				User:         apiKey.User,
 				Account:      usedAccount,
-				Subscription: subscription,
+				Subscription: usedSubscription,
+				CostHold:     hold,
 				UserAgent:    ua,
 				IPAddress:    clientIP,
+				Tags:         tags,
+				SessionHash:  sessionKey,
+				Group:        usedGroup,
 			}); err != nil {
 				log.Printf("Record usage failed: %v", err)
 			}
@@ -541,7 +697,7 @@ func (h *GatewayHandler) Usage(c *gin.Context) {
 			return
 		}
 
-		remaining := h.calculateSubscriptionRemaining(apiKey.Group, subscription)
+		remaining := h.calculateSubscriptionRemaining(subscription.EffectiveGroup(apiKey.Group), subscription)
 		c.JSON(http.StatusOK, gin.H{
 			"isValid":   true,
 			"planName":  apiKey.Group.Name,
@@ -551,8 +707,12 @@ func (h *GatewayHandler) Usage(c *gin.Context) {
 		return
 	}
 
-	// 余额模式：返回钱包余额
-	latestUser, err := h.userService.GetByID(c.Request.Context(), subject.UserID)
+	// 余额模式：返回钱包余额（组织 Key 返回组织余额）
+	payerID := subject.UserID
+	if payer := apiKey.Payer(); payer != nil {
+		payerID = payer.ID
+	}
+	latestUser, err := h.userService.GetByID(c.Request.Context(), payerID)
 	if err != nil {
 		h.errorResponse(c, http.StatusInternalServerError, "api_error", "Failed to get user info")
 		return
@@ -697,7 +857,7 @@ func (h *GatewayHandler) CountTokens(c *gin.Context) {
 		return
 	}
 
-	_, ok = middleware2.GetAuthSubjectFromContext(c)
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
 	if !ok {
 		h.errorResponse(c, http.StatusInternalServerError, "api_error", "User context not found")
 		return
@@ -719,6 +879,9 @@ func (h *GatewayHandler) CountTokens(c *gin.Context) {
 		return
 	}
 
+	// 与 Messages 注入相同的系统提示词模板，保证计数与实际请求一致
+	body = h.promptTemplateService.Apply(apiKey, service.PromptTemplateFormatAnthropic, body)
+
 	setOpsRequestContext(c, "", false, body)
 
 	parsedReq, err := service.ParseGatewayRequest(body)
@@ -746,12 +909,41 @@ func (h *GatewayHandler) CountTokens(c *gin.Context) {
 		return
 	}
 
+	// 本地计数：不占用上游账号；local_fallback 模式下请求含无法可靠估算的内容时继续转发上游
+	mode := service.CountTokensModeUpstream
+	if apiKey.Group != nil {
+		mode = apiKey.Group.GetCountTokensMode()
+	}
+	var localCount *tokenizer.Count
+	if mode != service.CountTokensModeUpstream {
+		count, err := h.gatewayService.CountTokensLocal(parsedReq)
+		if err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to count tokens: invalid messages")
+			return
+		}
+		if mode == service.CountTokensModeLocal || !count.Rough {
+			c.JSON(http.StatusOK, gin.H{"input_tokens": count.InputTokens})
+			return
+		}
+		localCount = &count
+	}
+
+	// 引用了 Files API 文件时固定到文件所在账号
+	if !h.pinFileAccount(c, apiKey, subject.UserID, body) {
+		return
+	}
+
 	// 计算粘性会话 hash
 	sessionHash := h.gatewayService.GenerateSessionHash(parsedReq)
 
 	// 选择支持该模型的账号
 	account, err := h.gatewayService.SelectAccountForModel(c.Request.Context(), apiKey.GroupID, sessionHash, parsedReq.Model)
 	if err != nil {
+		if localCount != nil {
+			// 无可用上游账号时退回本地估算
+			c.JSON(http.StatusOK, gin.H{"input_tokens": localCount.InputTokens})
+			return
+		}
 		h.errorResponse(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error())
 		return
 	}
@@ -870,6 +1062,14 @@ func sendMockWarmupResponse(c *gin.Context, model string) {
 	})
 }
 
+// usageTagErrorDetails 标签格式/校验错误返回 400，预算超限等按计费错误处理
+func usageTagErrorDetails(err error) (status int, code, message string) {
+	if pkgerrors.Code(err) == http.StatusBadRequest {
+		return http.StatusBadRequest, "invalid_request_error", pkgerrors.Message(err)
+	}
+	return billingErrorDetails(err)
+}
+
 func billingErrorDetails(err error) (status int, code, message string) {
 	if errors.Is(err, service.ErrBillingServiceUnavailable) {
 		msg := pkgerrors.Message(err)
@@ -295,6 +295,31 @@ func (h *ConcurrencyHelper) AcquireAccountSlotWithWaitTimeout(c *gin.Context, ac
 	return h.waitForSlotWithPingTimeout(c, "account", accountID, maxConcurrency, timeout, isStream, streamStarted)
 }
 
+// AcquireAccountSlotResultWithTimeout 在超时内轮询占用账号槽位（不发送 ping），返回完整结果以便长连接刷新槽位。
+// 用于 WebSocket 升级前等待：此时尚不能向客户端写入任何数据。
+func (h *ConcurrencyHelper) AcquireAccountSlotResultWithTimeout(ctx context.Context, accountID int64, maxConcurrency int, timeout time.Duration) (*service.AcquireResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := initialBackoff
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for {
+		result, err := h.concurrencyService.AcquireAccountSlot(ctx, accountID, maxConcurrency)
+		if err != nil {
+			return nil, err
+		}
+		if result.Acquired {
+			return result, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, &ConcurrencyError{SlotType: "account", IsTimeout: true}
+		case <-time.After(backoff):
+		}
+		backoff = nextBackoff(backoff, rng)
+	}
+}
+
 // nextBackoff 计算下一次退避时间
 // 性能优化：使用指数退避 + 随机抖动，避免惊群效应
 // current: 当前退避时间
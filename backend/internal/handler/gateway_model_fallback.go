@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"log"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// modelFallbackTarget 当前调度的模型与分组，发生模型降级后随之切换
+type modelFallbackTarget struct {
+	parsed     *service.ParsedRequest
+	groupID    *int64
+	platform   string
+	sessionKey string
+	// group/subscription 跨分组降级后的目标分组及其计费订阅，未跨分组时 group 为空
+	group        *service.Group
+	subscription *service.UserSubscription
+}
+
+// modelFallbackSessionKey 与调度循环保持一致：Gemini 分组的粘性会话使用独立前缀
+func modelFallbackSessionKey(platform, sessionHash string) string {
+	if platform == service.PlatformGemini && sessionHash != "" {
+		return "gemini:" + sessionHash
+	}
+	return sessionHash
+}
+
+// nextModelFallback 按触发条件切换到降级链中的下一个可用模型；
+// 跨分组目标不可用、付费方无权使用或额度不足、模型价格不可用时跳过该步，降级链走完时返回 false。
+func (h *GatewayHandler) nextModelFallback(c *gin.Context, plan *service.ModelFallbackPlan, target *modelFallbackTarget, apiKey *service.APIKey, subscription *service.UserSubscription, platform, sessionHash, trigger string) bool {
+	for {
+		step, ok := plan.Next(trigger)
+		if !ok {
+			return false
+		}
+		groupID, stepPlatform := apiKey.GroupID, platform
+		var stepGroup *service.Group
+		stepSubscription := subscription
+		if step.GroupID != nil {
+			group, groupSubscription, err := h.gatewayService.ResolveModelFallbackGroup(c.Request.Context(), apiKey, *step.GroupID)
+			if err != nil {
+				log.Printf("[ModelFallback] skip %s: %v", step.Model, err)
+				continue
+			}
+			if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, group, groupSubscription); err != nil {
+				log.Printf("[ModelFallback] skip %s: group %d billing eligibility: %v", step.Model, group.ID, err)
+				continue
+			}
+			groupID, stepPlatform = &group.ID, group.Platform
+			stepGroup, stepSubscription = group, groupSubscription
+		}
+		// 计费以实际运行的模型与分组为准，未知价格策略为 block 时不能降级到没有价格的模型
+		if err := h.gatewayService.CheckModelPricing(step.Model, groupID); err != nil {
+			log.Printf("[ModelFallback] skip %s: %v", step.Model, err)
+			continue
+		}
+		parsed, err := service.ReplaceRequestModel(target.parsed, step.Model)
+		if err != nil {
+			log.Printf("[ModelFallback] skip %s: replace model failed: %v", step.Model, err)
+			continue
+		}
+
+		log.Printf("[ModelFallback] %s -> %s (trigger=%s, group=%d)", target.parsed.Model, step.Model, trigger, derefGroupID(groupID))
+		target.parsed = parsed
+		target.groupID = groupID
+		target.platform = stepPlatform
+		target.sessionKey = modelFallbackSessionKey(stepPlatform, sessionHash)
+		target.group = stepGroup
+		target.subscription = stepSubscription
+		setOpsRequestContext(c, parsed.Model, parsed.Stream, parsed.Body)
+		return true
+	}
+}
+
+// setServedModelHeaders 告知客户端实际服务本次请求的模型（仅配置了降级链的请求）
+func setServedModelHeaders(c *gin.Context, plan *service.ModelFallbackPlan, servedModel string) {
+	if plan == nil || c.Writer.Written() {
+		return
+	}
+	c.Header(service.ModelServedHeader, servedModel)
+	if servedModel != plan.RequestedModel {
+		c.Header(service.ModelFallbackFromHeader, plan.RequestedModel)
+	} else {
+		c.Writer.Header().Del(service.ModelFallbackFromHeader)
+	}
+}
+
+func derefGroupID(groupID *int64) int64 {
+	if groupID == nil {
+		return 0
+	}
+	return *groupID
+}
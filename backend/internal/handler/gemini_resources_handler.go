@@ -0,0 +1,394 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// GeminiUploadFile proxies Files API uploads (resumable and multipart):
+// POST /upload/v1beta/files
+// POST /upload/v1beta/files?upload_id=...  (resumable upload chunks / finalize)
+func (h *GatewayHandler) GeminiUploadFile(c *gin.Context) {
+	caller, ok := h.geminiResourceCaller(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	uploadID := strings.TrimSpace(c.Query("upload_id"))
+
+	var account *service.Account
+	if uploadID == "" {
+		if !h.checkGeminiResourceBilling(c, caller) {
+			return
+		}
+		selected, err := h.geminiResourceService.SelectAccount(ctx, caller.APIKey.GroupID)
+		if err != nil {
+			writeGeminiResourceError(c, err)
+			return
+		}
+		account = selected
+	} else {
+		_, owner, err := h.geminiResourceService.Lookup(ctx, caller.APIKey.UserID, "uploads/"+uploadID, service.GeminiResourceTypeUpload)
+		if err != nil {
+			writeGeminiResourceError(c, err)
+			return
+		}
+		account = owner
+	}
+	setOpsSelectedAccount(c, account.ID)
+
+	res, err := h.geminiResourceService.Forward(ctx, &service.AIStudioRequest{
+		Account:       account,
+		Method:        http.MethodPost,
+		Path:          "/upload/v1beta/files",
+		RawQuery:      geminiUpstreamQuery(c),
+		Header:        geminiUploadHeaders(c.Request.Header),
+		Body:          c.Request.Body,
+		ContentLength: c.Request.ContentLength,
+	})
+	if err != nil {
+		log.Printf("Gemini upload forward failed: account=%d err=%v", account.ID, err)
+		googleError(c, http.StatusBadGateway, "Upstream request failed")
+		return
+	}
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		// 可续传上传开始：记录会话所在账号，并把上传地址改写为本网关地址
+		if uploadURL := res.Headers.Get("X-Goog-Upload-URL"); uploadURL != "" {
+			rewritten, newUploadID := rewriteGeminiUploadURL(c, uploadURL)
+			if newUploadID != "" {
+				if err := h.geminiResourceService.RecordUploadSession(ctx, caller, account, newUploadID); err != nil {
+					log.Printf("Record gemini upload session failed: %v", err)
+					googleError(c, http.StatusInternalServerError, "Failed to record upload session")
+					return
+				}
+				res.Headers.Set("X-Goog-Upload-URL", rewritten)
+			}
+		} else if err := h.geminiResourceService.CompleteUpload(ctx, caller, account, uploadID, res.Body); err != nil {
+			log.Printf("Record gemini file failed: %v", err)
+		}
+	}
+	writeUpstreamResponse(c, res)
+}
+
+// GeminiListFiles lists files uploaded by the caller:
+// GET /v1beta/files
+func (h *GatewayHandler) GeminiListFiles(c *gin.Context) {
+	h.listGeminiResources(c, service.GeminiResourceTypeFile, "files")
+}
+
+// GeminiGetFile proxies:
+// GET /v1beta/files/{name}
+func (h *GatewayHandler) GeminiGetFile(c *gin.Context) {
+	h.forwardGeminiResource(c, "files/", service.GeminiResourceTypeFile, http.MethodGet)
+}
+
+// GeminiDeleteFile proxies:
+// DELETE /v1beta/files/{name}
+func (h *GatewayHandler) GeminiDeleteFile(c *gin.Context) {
+	h.forwardGeminiResource(c, "files/", service.GeminiResourceTypeFile, http.MethodDelete)
+}
+
+// GeminiCreateCachedContent proxies:
+// POST /v1beta/cachedContents
+func (h *GatewayHandler) GeminiCreateCachedContent(c *gin.Context) {
+	caller, ok := h.geminiResourceCaller(c)
+	if !ok {
+		return
+	}
+	body, ok := readGeminiResourceBody(c)
+	if !ok {
+		return
+	}
+	model := strings.TrimPrefix(gjson.GetBytes(body, "model").String(), "models/")
+	if model == "" {
+		googleError(c, http.StatusBadRequest, "model is required")
+		return
+	}
+	setOpsRequestContext(c, model, false, body)
+	if err := h.gatewayService.CheckModelPricing(model, caller.APIKey.GroupID); err != nil {
+		status, _, message := billingErrorDetails(err)
+		googleError(c, status, message)
+		return
+	}
+	if !h.checkGeminiResourceBilling(c, caller) {
+		return
+	}
+
+	// 缓存内容引用了已上传文件时必须建在文件所在账号上
+	ctx := c.Request.Context()
+	account, err := h.geminiResourceService.ResolveAffinity(ctx, caller.APIKey.UserID, body)
+	if err != nil {
+		writeGeminiResourceError(c, err)
+		return
+	}
+	if account == nil {
+		if account, err = h.geminiResourceService.SelectAccount(ctx, caller.APIKey.GroupID); err != nil {
+			writeGeminiResourceError(c, err)
+			return
+		}
+	}
+	setOpsSelectedAccount(c, account.ID)
+
+	res, err := h.geminiResourceService.Forward(ctx, &service.AIStudioRequest{
+		Account:       account,
+		Method:        http.MethodPost,
+		Path:          "/v1beta/cachedContents",
+		RawQuery:      geminiUpstreamQuery(c),
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          bytes.NewReader(body),
+		ContentLength: int64(len(body)),
+	})
+	if err != nil {
+		log.Printf("Gemini cachedContents forward failed: account=%d err=%v", account.ID, err)
+		googleError(c, http.StatusBadGateway, "Upstream request failed")
+		return
+	}
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		if err := h.geminiResourceService.RecordCachedContent(ctx, caller, account, res.Body, nil); err != nil {
+			log.Printf("Record gemini cached content failed: %v", err)
+		}
+	}
+	writeUpstreamResponse(c, res)
+}
+
+// GeminiListCachedContents lists cached contents created by the caller:
+// GET /v1beta/cachedContents
+func (h *GatewayHandler) GeminiListCachedContents(c *gin.Context) {
+	h.listGeminiResources(c, service.GeminiResourceTypeCachedContent, "cachedContents")
+}
+
+// GeminiGetCachedContent proxies:
+// GET /v1beta/cachedContents/{name}
+func (h *GatewayHandler) GeminiGetCachedContent(c *gin.Context) {
+	h.forwardGeminiResource(c, "cachedContents/", service.GeminiResourceTypeCachedContent, http.MethodGet)
+}
+
+// GeminiUpdateCachedContent proxies (TTL 延长按新增时长收取存储费):
+// PATCH /v1beta/cachedContents/{name}
+func (h *GatewayHandler) GeminiUpdateCachedContent(c *gin.Context) {
+	h.forwardGeminiResource(c, "cachedContents/", service.GeminiResourceTypeCachedContent, http.MethodPatch)
+}
+
+// GeminiDeleteCachedContent proxies:
+// DELETE /v1beta/cachedContents/{name}
+func (h *GatewayHandler) GeminiDeleteCachedContent(c *gin.Context) {
+	h.forwardGeminiResource(c, "cachedContents/", service.GeminiResourceTypeCachedContent, http.MethodDelete)
+}
+
+// forwardGeminiResource 把单个资源的读取/更新/删除转发到归属账号
+func (h *GatewayHandler) forwardGeminiResource(c *gin.Context, prefix, resourceType, method string) {
+	caller, ok := h.geminiResourceCaller(c)
+	if !ok {
+		return
+	}
+	id := strings.TrimSpace(c.Param("name"))
+	if id == "" || strings.Contains(id, "/") {
+		googleError(c, http.StatusBadRequest, "Invalid resource name")
+		return
+	}
+	name := prefix + id
+
+	var body []byte
+	if method == http.MethodPatch {
+		if body, ok = readGeminiResourceBody(c); !ok {
+			return
+		}
+		if !h.checkGeminiResourceBilling(c, caller) {
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	resource, account, err := h.geminiResourceService.Lookup(ctx, caller.APIKey.UserID, name, resourceType)
+	if err != nil {
+		writeGeminiResourceError(c, err)
+		return
+	}
+	setOpsSelectedAccount(c, account.ID)
+
+	req := &service.AIStudioRequest{
+		Account:       account,
+		Method:        method,
+		Path:          "/v1beta/" + name,
+		RawQuery:      geminiUpstreamQuery(c),
+		ContentLength: -1,
+	}
+	if body != nil {
+		req.Header = http.Header{"Content-Type": []string{"application/json"}}
+		req.Body = bytes.NewReader(body)
+		req.ContentLength = int64(len(body))
+	}
+	res, err := h.geminiResourceService.Forward(ctx, req)
+	if err != nil {
+		log.Printf("Gemini resource forward failed: name=%s account=%d err=%v", name, account.ID, err)
+		googleError(c, http.StatusBadGateway, "Upstream request failed")
+		return
+	}
+
+	success := res.StatusCode >= 200 && res.StatusCode < 300
+	switch {
+	case method == http.MethodPatch && success:
+		if err := h.geminiResourceService.RecordCachedContent(ctx, caller, account, res.Body, resource); err != nil {
+			log.Printf("Record gemini cached content failed: %v", err)
+		}
+	case (method == http.MethodDelete && success) || res.StatusCode == http.StatusNotFound:
+		// 上游已不存在的资源同步删除归属记录
+		if err := h.geminiResourceService.Remove(ctx, name); err != nil {
+			log.Printf("Remove gemini resource %s failed: %v", name, err)
+		}
+	}
+	writeUpstreamResponse(c, res)
+}
+
+// listGeminiResources 从归属表分页列出调用方的资源（上游列表会混入同账号其他用户的资源）
+func (h *GatewayHandler) listGeminiResources(c *gin.Context, resourceType, field string) {
+	caller, ok := h.geminiResourceCaller(c)
+	if !ok {
+		return
+	}
+	pageSize, _ := strconv.Atoi(c.Query("pageSize"))
+	items, next, err := h.geminiResourceService.List(c.Request.Context(), caller.APIKey.UserID, resourceType, pageSize, c.Query("pageToken"))
+	if err != nil {
+		writeGeminiResourceError(c, err)
+		return
+	}
+	resp := gin.H{}
+	if len(items) > 0 {
+		resp[field] = items
+	}
+	if next != "" {
+		resp["nextPageToken"] = next
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// geminiResourceCaller 校验 Files/cachedContents 请求的调用方，失败时已写入错误响应
+func (h *GatewayHandler) geminiResourceCaller(c *gin.Context) (*service.GeminiResourceCaller, bool) {
+	if !h.geminiResourceService.Enabled() {
+		googleError(c, http.StatusNotFound, "Files and cachedContents APIs are disabled")
+		return nil, false
+	}
+	apiKey, ok := middleware.GetAPIKeyFromContext(c)
+	if !ok || apiKey == nil {
+		googleError(c, http.StatusUnauthorized, "Invalid API key")
+		return nil, false
+	}
+	if apiKey.Group == nil || apiKey.Group.Platform != service.PlatformGemini {
+		googleError(c, http.StatusBadRequest, "API key group platform is not gemini")
+		return nil, false
+	}
+	tags, err := h.usageTagService.Resolve(c.Request.Context(), apiKey, c.GetHeader(service.UsageTagHeader), nil)
+	if err != nil {
+		status, _, message := usageTagErrorDetails(err)
+		googleError(c, status, message)
+		return nil, false
+	}
+	subscription, _ := middleware.GetSubscriptionFromContext(c)
+	return &service.GeminiResourceCaller{
+		APIKey:       apiKey,
+		Subscription: subscription,
+		UserAgent:    c.GetHeader("User-Agent"),
+		IPAddress:    ip.GetClientIP(c),
+		Tags:         tags,
+	}, true
+}
+
+// checkGeminiResourceBilling 新建文件/缓存或延长缓存前检查计费资格
+func (h *GatewayHandler) checkGeminiResourceBilling(c *gin.Context, caller *service.GeminiResourceCaller) bool {
+	apiKey := caller.APIKey
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, caller.Subscription); err != nil {
+		status, _, message := billingErrorDetails(err)
+		googleError(c, status, message)
+		return false
+	}
+	return true
+}
+
+func readGeminiResourceBody(c *gin.Context) ([]byte, bool) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		if maxErr, ok := extractMaxBytesError(err); ok {
+			googleError(c, http.StatusRequestEntityTooLarge, buildBodyTooLargeMessage(maxErr.Limit))
+			return nil, false
+		}
+		googleError(c, http.StatusBadRequest, "Failed to read request body")
+		return nil, false
+	}
+	if len(body) == 0 {
+		googleError(c, http.StatusBadRequest, "Request body is empty")
+		return nil, false
+	}
+	return body, true
+}
+
+func writeGeminiResourceError(c *gin.Context, err error) {
+	status := infraerrors.Code(err)
+	if status < http.StatusBadRequest || status >= 600 {
+		log.Printf("Gemini resource request failed: %v", err)
+		googleError(c, http.StatusInternalServerError, "Internal error")
+		return
+	}
+	googleError(c, status, infraerrors.Message(err))
+}
+
+// geminiUpstreamQuery 透传查询参数（去除客户端的 key 凭证）
+func geminiUpstreamQuery(c *gin.Context) string {
+	query := c.Request.URL.Query()
+	query.Del("key")
+	return query.Encode()
+}
+
+// geminiUploadHeaders 上传请求只透传 X-Goog-Upload-* 与内容类型相关请求头
+func geminiUploadHeaders(in http.Header) http.Header {
+	out := make(http.Header)
+	for k, vv := range in {
+		canonical := textproto.CanonicalMIMEHeaderKey(k)
+		if strings.HasPrefix(canonical, "X-Goog-Upload-") || canonical == "Content-Type" {
+			out[canonical] = append([]string(nil), vv...)
+		}
+	}
+	return out
+}
+
+// rewriteGeminiUploadURL 把上游返回的可续传上传地址改写为本网关地址，返回新地址与 upload_id
+func rewriteGeminiUploadURL(c *gin.Context, upstreamURL string) (string, string) {
+	parsed, err := url.Parse(upstreamURL)
+	if err != nil {
+		return "", ""
+	}
+	query := parsed.Query()
+	uploadID := query.Get("upload_id")
+	if uploadID == "" {
+		return "", ""
+	}
+	query.Del("key")
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if xfProto := strings.TrimSpace(c.GetHeader("X-Forwarded-Proto")); xfProto != "" {
+		scheme = strings.TrimSpace(strings.Split(xfProto, ",")[0])
+	}
+	host := strings.TrimSpace(c.Request.Host)
+	if xfHost := strings.TrimSpace(c.GetHeader("X-Forwarded-Host")); xfHost != "" {
+		host = strings.TrimSpace(strings.Split(xfHost, ",")[0])
+	}
+	return fmt.Sprintf("%s://%s/upload/v1beta/files?%s", scheme, host, query.Encode()), uploadID
+}
@@ -162,11 +162,44 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 		return
 	}
 
+	// 内容策略：转发前检查请求，redact 策略会改写请求体
+	body, err = h.guardrailService.CheckRequest(c.Request.Context(), apiKey, modelName, body)
+	if err != nil {
+		status, _, message := guardrailErrorDetails(err)
+		googleError(c, status, message)
+		return
+	}
+
+	// 系统提示词模板：仅改写生成请求（countTokens 的请求结构不同）
+	if action == "generateContent" || action == "streamGenerateContent" {
+		body = h.promptTemplateService.Apply(apiKey, service.PromptTemplateFormatGemini, body)
+	}
+
 	setOpsRequestContext(c, modelName, stream, body)
 
+	// 未知价格策略为 block 时拒绝没有价格的模型
+	if err := h.gatewayService.CheckModelPricing(modelName, apiKey.GroupID); err != nil {
+		status, _, message := billingErrorDetails(err)
+		googleError(c, status, message)
+		return
+	}
+
 	// Get subscription (may be nil)
 	subscription, _ := middleware.GetSubscriptionFromContext(c)
 
+	// countTokens 本地计数：不占用并发槽位与上游账号
+	if action == "countTokens" && h.countGeminiTokensLocally(c, apiKey, subscription, body) {
+		return
+	}
+
+	// 成本归属标签（仅支持请求头）
+	tags, err := h.usageTagService.Resolve(c.Request.Context(), apiKey, c.GetHeader(service.UsageTagHeader), nil)
+	if err != nil {
+		status, _, message := usageTagErrorDetails(err)
+		googleError(c, status, message)
+		return
+	}
+
 	// For Gemini native API, do not send Claude-style ping frames.
 	geminiConcurrency := NewConcurrencyHelper(h.concurrencyHelper.concurrencyService, SSEPingFormatNone, 0)
 
@@ -213,20 +246,59 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 		return
 	}
 
+	// 预扣本次请求的最大费用；请求失败时释放，成功时在使用量入账后释放
+	costHold, err := h.costHoldService.Reserve(c.Request.Context(), &service.CostHoldInput{
+		User:         apiKey.User,
+		APIKey:       apiKey,
+		Group:        apiKey.Group,
+		Subscription: subscription,
+		Model:        modelName,
+		Body:         body,
+	})
+	if err != nil {
+		log.Printf("Cost hold failed: %v", err)
+		status, _, message := billingErrorDetails(err)
+		googleError(c, status, message)
+		return
+	}
+	defer func() {
+		if costHold != nil {
+			h.costHoldService.Release(context.Background(), costHold)
+		}
+	}()
+
+	// 内容策略：检查响应（含流式输出）
+	finishGuardrail := installGuardrailResponseWriter(c, h.guardrailService, apiKey, modelName, guardrailFormatGemini)
+	defer finishGuardrail()
+
 	// 3) select account (sticky session based on request body)
+	// 引用了已上传文件或显式缓存的请求固定路由到资源所在账号
+	var pinnedAccount *service.Account
+	if !middleware.HasForcePlatform(c) {
+		pinnedAccount, err = h.geminiResourceService.ResolveAffinity(c.Request.Context(), authSubject.UserID, body)
+		if err != nil {
+			writeGeminiResourceError(c, err)
+			return
+		}
+	}
 	parsedReq, _ := service.ParseGatewayRequest(body)
 	sessionHash := h.gatewayService.GenerateSessionHash(parsedReq)
 	sessionKey := sessionHash
 	if sessionHash != "" {
 		sessionKey = "gemini:" + sessionHash
 	}
-	maxAccountSwitches := h.maxAccountSwitchesGemini
+	maxAccountSwitches := h.geminiAccountSwitchLimit()
 	switchCount := 0
 	failedAccountIDs := make(map[int64]struct{})
 	lastFailoverStatus := 0
 
 	for {
-		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionKey, modelName, failedAccountIDs, "") // Gemini 不使用会话限制
+		var selection *service.AccountSelectionResult
+		if pinnedAccount != nil {
+			selection, err = h.geminiResourceService.PinnedSelection(c.Request.Context(), pinnedAccount)
+		} else {
+			selection, err = h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionKey, modelName, failedAccountIDs, "") // Gemini 不使用会话限制
+		}
 		if err != nil {
 			if len(failedAccountIDs) == 0 {
 				googleError(c, http.StatusServiceUnavailable, "No available Gemini accounts: "+err.Error())
@@ -300,7 +372,8 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 			var failoverErr *service.UpstreamFailoverError
 			if errors.As(err, &failoverErr) {
 				failedAccountIDs[account.ID] = struct{}{}
-				if switchCount >= maxAccountSwitches {
+				// 固定账号的资源无法在其他账号上使用，不切换
+				if pinnedAccount != nil || switchCount >= maxAccountSwitches {
 					lastFailoverStatus = failoverErr.StatusCode
 					handleGeminiFailoverExhausted(c, lastFailoverStatus)
 					return
@@ -319,18 +392,24 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
 
-		// 6) record usage async
+		// 6) record usage async, then release the cost hold
+		hold := costHold
+		costHold = nil
 		go func(result *service.ForwardResult, usedAccount *service.Account, ua, ip string) {
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
+			defer h.costHoldService.Release(ctx, hold)
 			if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
 				Result:       result,
 				APIKey:       apiKey,
 				User:         apiKey.User,
 				Account:      usedAccount,
 				Subscription: subscription,
+				CostHold:     hold,
 				UserAgent:    ua,
 				IPAddress:    ip,
+				Tags:         tags,
+				SessionHash:  sessionKey,
 			}); err != nil {
 				log.Printf("Record usage failed: %v", err)
 			}
@@ -339,6 +418,32 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 	}
 }
 
+// countGeminiTokensLocally 按分组 count_tokens 计数方式在本地响应 countTokens，返回 true 表示已响应
+func (h *GatewayHandler) countGeminiTokensLocally(c *gin.Context, apiKey *service.APIKey, subscription *service.UserSubscription, body []byte) bool {
+	if apiKey.Group == nil {
+		return false
+	}
+	mode := apiKey.Group.GetCountTokensMode()
+	if mode == service.CountTokensModeUpstream {
+		return false
+	}
+	count, err := h.geminiCompatService.CountTokensLocal(body)
+	if err != nil {
+		googleError(c, http.StatusBadRequest, "Failed to count tokens: invalid request body")
+		return true
+	}
+	if mode == service.CountTokensModeLocalFallback && count.Rough {
+		return false
+	}
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+		status, _, message := billingErrorDetails(err)
+		googleError(c, status, message)
+		return true
+	}
+	c.JSON(http.StatusOK, gin.H{"totalTokens": count.InputTokens})
+	return true
+}
+
 func parseGeminiModelAction(rest string) (model string, action string, err error) {
 	rest = strings.TrimSpace(rest)
 	if rest == "" {
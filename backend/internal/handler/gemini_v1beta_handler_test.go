@@ -3,9 +3,13 @@
 package handler
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
 )
 
@@ -141,3 +145,41 @@ func TestGeminiV1BetaHandler_GetModelAntigravityFallback(t *testing.T) {
 		})
 	}
 }
+
+// TestGeminiV1BetaHandler_CountTokensLocalMode 验证分组 count_tokens 计数方式对 countTokens 的本地响应
+func TestGeminiV1BetaHandler_CountTokensLocalMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	billingCache := service.NewBillingCacheService(nil, nil, nil, nil, &config.Config{RunMode: config.RunModeSimple})
+	defer billingCache.Stop()
+	h := &GatewayHandler{geminiCompatService: &service.GeminiMessagesCompatService{}, billingCacheService: billingCache}
+
+	textBody := []byte(`{"contents":[{"parts":[{"text":"hello world"}]}]}`)
+	fileBody := []byte(`{"contents":[{"parts":[{"fileData":{"fileUri":"gs://bucket/a.mp4"}}]}]}`)
+	tests := []struct {
+		name     string
+		mode     string
+		body     []byte
+		handled  bool
+		expected string
+	}{
+		{name: "upstream", mode: service.CountTokensModeUpstream, body: textBody, handled: false},
+		{name: "local", mode: service.CountTokensModeLocal, body: textBody, handled: true, expected: `{"totalTokens":3}`},
+		{name: "local_fallback-可估算", mode: service.CountTokensModeLocalFallback, body: textBody, handled: true, expected: `{"totalTokens":3}`},
+		{name: "local_fallback-转发上游", mode: service.CountTokensModeLocalFallback, body: fileBody, handled: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1beta/models/gemini-2.5-pro:countTokens", nil)
+			apiKey := &service.APIKey{User: &service.User{ID: 1}, Group: &service.Group{ID: 1, CountTokensMode: tt.mode}}
+
+			handled := h.countGeminiTokensLocally(c, apiKey, nil, tt.body)
+			require.Equal(t, tt.handled, handled)
+			if tt.handled {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.JSONEq(t, tt.expected, rec.Body.String())
+			}
+		})
+	}
+}
@@ -23,20 +23,42 @@ type AdminHandlers struct {
 	Subscription     *admin.SubscriptionHandler
 	Usage            *admin.UsageHandler
 	UserAttribute    *admin.UserAttributeHandler
+	Payment          *admin.PaymentHandler
+	SubscriptionPlan *admin.SubscriptionPlanHandler
+	Organization     *admin.OrganizationHandler
+	Reseller         *admin.ResellerHandler
+	Pricing          *admin.PricingHandler
+	RateMultiplier   *admin.RateMultiplierHandler
+	UsageTag         *admin.UsageTagHandler
+	UsageAnomaly     *admin.UsageAnomalyHandler
+	BillingOutbox    *admin.BillingOutboxHandler
+	AnthropicFile    *admin.AnthropicFileHandler
+	StickySession    *admin.StickySessionHandler
+	RequestHedge     *admin.RequestHedgeHandler
+	Guardrail        *admin.GuardrailHandler
+	PromptTemplate   *admin.PromptTemplateHandler
+	Config           *admin.ConfigHandler
 }
 
 // Handlers contains all HTTP handlers
 type Handlers struct {
-	Auth          *AuthHandler
-	User          *UserHandler
-	APIKey        *APIKeyHandler
-	Usage         *UsageHandler
-	Redeem        *RedeemHandler
-	Subscription  *SubscriptionHandler
-	Admin         *AdminHandlers
-	Gateway       *GatewayHandler
-	OpenAIGateway *OpenAIGatewayHandler
-	Setting       *SettingHandler
+	Auth             *AuthHandler
+	User             *UserHandler
+	APIKey           *APIKeyHandler
+	Usage            *UsageHandler
+	Redeem           *RedeemHandler
+	Subscription     *SubscriptionHandler
+	Payment          *PaymentHandler
+	SubscriptionPlan *SubscriptionPlanHandler
+	Notification     *NotificationHandler
+	Statement        *StatementHandler
+	UsageTag         *UsageTagHandler
+	Organization     *OrganizationHandler
+	Reseller         *ResellerHandler
+	Admin            *AdminHandlers
+	Gateway          *GatewayHandler
+	OpenAIGateway    *OpenAIGatewayHandler
+	Setting          *SettingHandler
 }
 
 // BuildInfo contains build-time information
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler handles user notification preferences and history
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+// NewNotificationHandler creates a new NotificationHandler
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// UpdateNotificationSettingsRequest represents the update preferences payload (nil fields are left unchanged)
+type UpdateNotificationSettingsRequest struct {
+	EmailEnabled            *bool    `json:"email_enabled"`
+	WebhookURL              *string  `json:"webhook_url"` // 空字符串关闭 Webhook
+	RegenerateWebhookSecret bool     `json:"regenerate_webhook_secret"`
+	LowBalanceEnabled       *bool    `json:"low_balance_enabled"`
+	LowBalanceThreshold     *float64 `json:"low_balance_threshold" binding:"omitempty,min=0"`
+	QuotaAlertsEnabled      *bool    `json:"quota_alerts_enabled"`
+	ExpiryAlertsEnabled     *bool    `json:"expiry_alerts_enabled"`
+	ExpiryReminderDays      *int     `json:"expiry_reminder_days" binding:"omitempty,min=1"`
+	APIKeyAlertsEnabled     *bool    `json:"api_key_alerts_enabled"`
+}
+
+// List returns the user's notification history
+// GET /api/v1/notifications
+func (h *NotificationHandler) List(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	items, result, err := h.notificationService.ListNotifications(c.Request.Context(), subject.UserID, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.Notification, 0, len(items))
+	for i := range items {
+		out = append(out, *dto.NotificationFromService(&items[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// GetSettings returns the user's notification preferences
+// GET /api/v1/notifications/settings
+func (h *NotificationHandler) GetSettings(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	settings, err := h.notificationService.GetSettings(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.NotificationSettingsFromService(settings))
+}
+
+// UpdateSettings updates the user's notification preferences
+// PUT /api/v1/notifications/settings
+func (h *NotificationHandler) UpdateSettings(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req UpdateNotificationSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	settings, err := h.notificationService.UpdateSettings(c.Request.Context(), subject.UserID, &service.NotificationSettingsInput{
+		EmailEnabled:            req.EmailEnabled,
+		WebhookURL:              req.WebhookURL,
+		RegenerateWebhookSecret: req.RegenerateWebhookSecret,
+		LowBalanceEnabled:       req.LowBalanceEnabled,
+		LowBalanceThreshold:     req.LowBalanceThreshold,
+		QuotaAlertsEnabled:      req.QuotaAlertsEnabled,
+		ExpiryAlertsEnabled:     req.ExpiryAlertsEnabled,
+		ExpiryReminderDays:      req.ExpiryReminderDays,
+		APIKeyAlertsEnabled:     req.APIKeyAlertsEnabled,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.NotificationSettingsFromService(settings))
+}
@@ -22,10 +22,15 @@ import (
 
 // OpenAIGatewayHandler handles OpenAI API gateway requests
 type OpenAIGatewayHandler struct {
-	gatewayService      *service.OpenAIGatewayService
-	billingCacheService *service.BillingCacheService
-	concurrencyHelper   *ConcurrencyHelper
-	maxAccountSwitches  int
+	gatewayService        *service.OpenAIGatewayService
+	billingCacheService   *service.BillingCacheService
+	costHoldService       *service.CostHoldService
+	usageTagService       *service.UsageTagService
+	realtimeService       *service.OpenAIRealtimeService
+	guardrailService      *service.GuardrailService
+	promptTemplateService *service.PromptTemplateService
+	concurrencyHelper     *ConcurrencyHelper
+	cfg                   *config.Config
 }
 
 // NewOpenAIGatewayHandler creates a new OpenAIGatewayHandler
@@ -33,22 +38,37 @@ func NewOpenAIGatewayHandler(
 	gatewayService *service.OpenAIGatewayService,
 	concurrencyService *service.ConcurrencyService,
 	billingCacheService *service.BillingCacheService,
+	costHoldService *service.CostHoldService,
+	usageTagService *service.UsageTagService,
+	realtimeService *service.OpenAIRealtimeService,
+	guardrailService *service.GuardrailService,
+	promptTemplateService *service.PromptTemplateService,
 	cfg *config.Config,
 ) *OpenAIGatewayHandler {
 	pingInterval := time.Duration(0)
-	maxAccountSwitches := 3
 	if cfg != nil {
 		pingInterval = time.Duration(cfg.Concurrency.PingInterval) * time.Second
-		if cfg.Gateway.MaxAccountSwitches > 0 {
-			maxAccountSwitches = cfg.Gateway.MaxAccountSwitches
-		}
 	}
 	return &OpenAIGatewayHandler{
-		gatewayService:      gatewayService,
-		billingCacheService: billingCacheService,
-		concurrencyHelper:   NewConcurrencyHelper(concurrencyService, SSEPingFormatComment, pingInterval),
-		maxAccountSwitches:  maxAccountSwitches,
+		gatewayService:        gatewayService,
+		billingCacheService:   billingCacheService,
+		costHoldService:       costHoldService,
+		usageTagService:       usageTagService,
+		realtimeService:       realtimeService,
+		guardrailService:      guardrailService,
+		promptTemplateService: promptTemplateService,
+		concurrencyHelper:     NewConcurrencyHelper(concurrencyService, SSEPingFormatComment, pingInterval),
+		cfg:                   cfg,
+	}
+}
+
+// accountSwitchLimit 每次请求读取最大账号切换次数，配置热更新后立即生效
+func (h *OpenAIGatewayHandler) accountSwitchLimit() int {
+	cfg := h.cfg.Current()
+	if cfg != nil && cfg.Gateway.MaxAccountSwitches > 0 {
+		return cfg.Gateway.MaxAccountSwitches
 	}
+	return 3
 }
 
 // Responses handles OpenAI Responses API endpoint
@@ -83,6 +103,14 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		return
 	}
 
+	// 内容策略：转发前检查请求，redact 策略会改写请求体
+	body, err = h.guardrailService.CheckRequest(c.Request.Context(), apiKey, "", body)
+	if err != nil {
+		status, code, message := guardrailErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+
 	setOpsRequestContext(c, "", false, body)
 
 	// Parse request body to map for potential modification
@@ -102,6 +130,21 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		return
 	}
 
+	// 未知价格策略为 block 时拒绝没有价格的模型
+	if err := h.gatewayService.CheckModelPricing(reqModel, apiKey.GroupID); err != nil {
+		status, code, message := billingErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+
+	// 成本归属标签（仅支持请求头）
+	tags, err := h.usageTagService.Resolve(c.Request.Context(), apiKey, c.GetHeader(service.UsageTagHeader), nil)
+	if err != nil {
+		status, code, message := usageTagErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+
 	userAgent := c.GetHeader("User-Agent")
 	if !openai.IsCodexCLIRequest(userAgent) {
 		existingInstructions, _ := reqBody["instructions"].(string)
@@ -118,6 +161,9 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		}
 	}
 
+	// 系统提示词模板：以 developer 消息注入 input
+	body = h.promptTemplateService.Apply(apiKey, service.PromptTemplateFormatOpenAIResponses, body)
+
 	setOpsRequestContext(c, reqModel, reqStream, body)
 
 	// 提前校验 function_call_output 是否具备可关联上下文，避免上游 400。
@@ -192,10 +238,35 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		return
 	}
 
+	// 预扣本次请求的最大费用；请求失败时释放，成功时在使用量入账后释放
+	costHold, err := h.costHoldService.Reserve(c.Request.Context(), &service.CostHoldInput{
+		User:         apiKey.User,
+		APIKey:       apiKey,
+		Group:        apiKey.Group,
+		Subscription: subscription,
+		Model:        reqModel,
+		Body:         body,
+	})
+	if err != nil {
+		log.Printf("Cost hold failed: %v", err)
+		status, code, message := billingErrorDetails(err)
+		h.handleStreamingAwareError(c, status, code, message, streamStarted)
+		return
+	}
+	defer func() {
+		if costHold != nil {
+			h.costHoldService.Release(context.Background(), costHold)
+		}
+	}()
+
+	// 内容策略：检查响应（含流式输出）
+	finishGuardrail := installGuardrailResponseWriter(c, h.guardrailService, apiKey, reqModel, guardrailFormatOpenAI)
+	defer finishGuardrail()
+
 	// Generate session hash (header first; fallback to prompt_cache_key)
 	sessionHash := h.gatewayService.GenerateSessionHash(c, reqBody)
 
-	maxAccountSwitches := h.maxAccountSwitches
+	maxAccountSwitches := h.accountSwitchLimit()
 	switchCount := 0
 	failedAccountIDs := make(map[int64]struct{})
 	lastFailoverStatus := 0
@@ -294,18 +365,23 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
 
-		// Async record usage
+		// Async record usage, then release the cost hold
+		hold := costHold
+		costHold = nil
 		go func(result *service.OpenAIForwardResult, usedAccount *service.Account, ua, ip string) {
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
+			defer h.costHoldService.Release(ctx, hold)
 			if err := h.gatewayService.RecordUsage(ctx, &service.OpenAIRecordUsageInput{
 				Result:       result,
 				APIKey:       apiKey,
 				User:         apiKey.User,
 				Account:      usedAccount,
 				Subscription: subscription,
+				CostHold:     hold,
 				UserAgent:    ua,
 				IPAddress:    ip,
+				Tags:         tags,
 			}); err != nil {
 				log.Printf("Record usage failed: %v", err)
 			}
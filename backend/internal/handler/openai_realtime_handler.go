@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// realtimeSlotRefreshInterval 会话期间刷新并发槽位的间隔，需小于槽位 TTL
+	realtimeSlotRefreshInterval = time.Minute
+	realtimeControlWriteTimeout = 5 * time.Second
+)
+
+// realtimeUpgrader 只回应 "realtime" 子协议，避免把 openai-insecure-api-key.<key> 回显到握手响应。
+// 鉴权由 API Key 中间件完成，因此不限制 Origin（浏览器直连场景）。
+var realtimeUpgrader = websocket.Upgrader{
+	CheckOrigin:  func(r *http.Request) bool { return true },
+	Subprotocols: []string{"realtime"},
+}
+
+// Realtime handles OpenAI Realtime WebSocket sessions
+// GET /v1/realtime?model=...
+func (h *OpenAIGatewayHandler) Realtime(c *gin.Context) {
+	if !h.realtimeService.Enabled() {
+		h.errorResponse(c, http.StatusNotFound, "not_found_error", "Realtime API is disabled")
+		return
+	}
+	if !websocket.IsWebSocketUpgrade(c.Request) {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "WebSocket upgrade required")
+		return
+	}
+
+	apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+	if !ok {
+		h.errorResponse(c, http.StatusUnauthorized, "authentication_error", "Invalid API key")
+		return
+	}
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		h.errorResponse(c, http.StatusInternalServerError, "api_error", "User context not found")
+		return
+	}
+
+	reqModel := strings.TrimSpace(c.Query("model"))
+	if reqModel == "" {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "model is required")
+		return
+	}
+	setOpsRequestContext(c, reqModel, true, nil)
+
+	if err := h.gatewayService.CheckModelPricing(reqModel, apiKey.GroupID); err != nil {
+		status, code, message := billingErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+	tags, err := h.usageTagService.Resolve(c.Request.Context(), apiKey, c.GetHeader(service.UsageTagHeader), nil)
+	if err != nil {
+		status, code, message := usageTagErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+
+	subscription, _ := middleware2.GetSubscriptionFromContext(c)
+
+	// 1. 用户槽位：会话期间一直占用，不排队等待
+	userSlot, err := h.concurrencyHelper.concurrencyService.AcquireUserSlot(c.Request.Context(), subject.UserID, subject.Concurrency)
+	if err != nil || !userSlot.Acquired {
+		if err != nil {
+			log.Printf("User concurrency acquire failed: %v", err)
+		}
+		h.handleConcurrencyError(c, err, "user", false)
+		return
+	}
+	defer userSlot.ReleaseFunc()
+
+	// 2. 计费资格（会话无法预估费用，不做预扣；每个 response.done 入账后重新检查）
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+		status, code, message := billingErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+
+	// 3. 选择账号并连接上游（握手失败时切换账号）
+	account, accountSlot, upstream, ok := h.connectRealtimeUpstream(c, apiKey.GroupID, reqModel)
+	if !ok {
+		return
+	}
+	defer accountSlot.ReleaseFunc()
+
+	// 4. 升级客户端连接；失败时 Upgrader 已写入错误响应
+	client, err := realtimeUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("[OpenAI Realtime] upgrade failed: %v", err)
+		_ = upstream.Close()
+		return
+	}
+	cfg := h.realtimeService.Config()
+	if cfg.MaxMessageBytes > 0 {
+		client.SetReadLimit(cfg.MaxMessageBytes)
+	}
+
+	userAgent := c.GetHeader("User-Agent")
+	clientIP := ip.GetClientIP(c)
+	startTime := time.Now()
+
+	// 请求 context 在连接被接管后不再可靠，会话生命周期由 sessionCtx 控制
+	sessionCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var closeOnce sync.Once
+	terminate := func(code int, reason string) {
+		closeOnce.Do(func() {
+			_ = client.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(realtimeControlWriteTimeout))
+			cancel()
+		})
+	}
+	if cfg.MaxSessionMinutes > 0 {
+		timer := time.AfterFunc(time.Duration(cfg.MaxSessionMinutes)*time.Minute, func() {
+			terminate(websocket.CloseNormalClosure, "max session duration reached")
+		})
+		defer timer.Stop()
+	}
+	go h.keepRealtimeSessionAlive(sessionCtx, client, time.Duration(cfg.PingIntervalSeconds)*time.Second, userSlot, accountSlot)
+
+	onResult := func(result *service.OpenAIForwardResult) {
+		go func() {
+			ctx, cancelRecord := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancelRecord()
+			if err := h.gatewayService.RecordUsage(ctx, &service.OpenAIRecordUsageInput{
+				Result:       result,
+				APIKey:       apiKey,
+				User:         apiKey.User,
+				Account:      account,
+				Subscription: subscription,
+				UserAgent:    userAgent,
+				IPAddress:    clientIP,
+				Tags:         tags,
+			}); err != nil {
+				log.Printf("Record usage failed: %v", err)
+			}
+			if err := h.billingCacheService.CheckBillingEligibility(ctx, apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+				log.Printf("[OpenAI Realtime] closing session for user %d: %v", subject.UserID, err)
+				_, _, message := billingErrorDetails(err)
+				terminate(websocket.ClosePolicyViolation, message)
+			}
+		}()
+	}
+
+	err = h.realtimeService.Relay(sessionCtx, client, upstream, reqModel, onResult)
+	if err != nil && sessionCtx.Err() == nil {
+		log.Printf("[OpenAI Realtime] session ended: account=%d model=%s duration=%s err=%v", account.ID, reqModel, time.Since(startTime).Round(time.Second), err)
+		return
+	}
+	log.Printf("[OpenAI Realtime] session ended: account=%d model=%s duration=%s", account.ID, reqModel, time.Since(startTime).Round(time.Second))
+}
+
+// connectRealtimeUpstream 选择账号、占用账号槽位并完成上游握手。
+// 失败时已写入错误响应并返回 ok=false。
+func (h *OpenAIGatewayHandler) connectRealtimeUpstream(c *gin.Context, groupID *int64, model string) (*service.Account, *service.AcquireResult, service.OpenAIRealtimeConn, bool) {
+	switchCount := 0
+	failedAccountIDs := make(map[int64]struct{})
+	lastFailoverStatus := 0
+
+	for {
+		selection, err := h.realtimeService.SelectAccount(c.Request.Context(), groupID, model, failedAccountIDs)
+		if err != nil {
+			log.Printf("[OpenAI Realtime] SelectAccount failed: %v", err)
+			if len(failedAccountIDs) == 0 {
+				h.errorResponse(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error())
+				return nil, nil, nil, false
+			}
+			h.handleFailoverExhausted(c, lastFailoverStatus, false)
+			return nil, nil, nil, false
+		}
+		account := selection.Account
+		setOpsSelectedAccount(c, account.ID)
+
+		slot := &service.AcquireResult{Acquired: true, ReleaseFunc: selection.ReleaseFunc, RefreshFunc: selection.RefreshFunc}
+		if !selection.Acquired {
+			if selection.WaitPlan == nil {
+				h.errorResponse(c, http.StatusServiceUnavailable, "api_error", "No available accounts")
+				return nil, nil, nil, false
+			}
+			slot, err = h.concurrencyHelper.AcquireAccountSlotResultWithTimeout(c.Request.Context(), account.ID, selection.WaitPlan.MaxConcurrency, selection.WaitPlan.Timeout)
+			if err != nil {
+				log.Printf("Account concurrency acquire failed: %v", err)
+				h.handleConcurrencyError(c, err, "account", false)
+				return nil, nil, nil, false
+			}
+		}
+		if slot.ReleaseFunc == nil {
+			slot.ReleaseFunc = func() {}
+		}
+
+		upstream, err := h.realtimeService.Connect(c.Request.Context(), account, model)
+		if err == nil {
+			return account, slot, upstream, true
+		}
+		slot.ReleaseFunc()
+
+		var failoverErr *service.UpstreamFailoverError
+		if !errors.As(err, &failoverErr) {
+			log.Printf("[OpenAI Realtime] account %d connect failed: %v", account.ID, err)
+			h.errorResponse(c, http.StatusBadGateway, "upstream_error", "Upstream realtime connection failed")
+			return nil, nil, nil, false
+		}
+		failedAccountIDs[account.ID] = struct{}{}
+		lastFailoverStatus = failoverErr.StatusCode
+		maxAccountSwitches := h.accountSwitchLimit()
+		if switchCount >= maxAccountSwitches {
+			h.handleFailoverExhausted(c, lastFailoverStatus, false)
+			return nil, nil, nil, false
+		}
+		switchCount++
+		log.Printf("Account %d: upstream error %d, switching account %d/%d", account.ID, failoverErr.StatusCode, switchCount, maxAccountSwitches)
+	}
+}
+
+// keepRealtimeSessionAlive 定期 ping 客户端并刷新用户/账号槽位，直到会话结束。
+func (h *OpenAIGatewayHandler) keepRealtimeSessionAlive(ctx context.Context, client *websocket.Conn, pingInterval time.Duration, slots ...*service.AcquireResult) {
+	var pingCh <-chan time.Time
+	if pingInterval > 0 {
+		pingTicker := time.NewTicker(pingInterval)
+		defer pingTicker.Stop()
+		pingCh = pingTicker.C
+	}
+	refreshTicker := time.NewTicker(realtimeSlotRefreshInterval)
+	defer refreshTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-pingCh:
+			// WriteControl 可与数据帧写入并发调用
+			_ = client.WriteControl(websocket.PingMessage, nil, time.Now().Add(realtimeControlWriteTimeout))
+		case <-refreshTicker.C:
+			for _, slot := range slots {
+				if slot == nil || slot.RefreshFunc == nil {
+					continue
+				}
+				if err := slot.RefreshFunc(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("[OpenAI Realtime] refresh concurrency slot failed: %v", err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package handler
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationHandler handles organization (team) requests
+type OrganizationHandler struct {
+	orgService *service.OrganizationService
+}
+
+// NewOrganizationHandler creates a new OrganizationHandler
+func NewOrganizationHandler(orgService *service.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		orgService: orgService,
+	}
+}
+
+// OrganizationNameRequest represents the create/rename organization payload
+type OrganizationNameRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// AddOrganizationMemberRequest represents the add member payload
+type AddOrganizationMemberRequest struct {
+	Email            string   `json:"email" binding:"required,email"`
+	Role             string   `json:"role"`
+	SpendingLimitUSD *float64 `json:"spending_limit_usd"`
+}
+
+// UpdateOrganizationMemberRequest represents the update member payload
+type UpdateOrganizationMemberRequest struct {
+	Role               *string  `json:"role"`
+	SpendingLimitUSD   *float64 `json:"spending_limit_usd"`
+	ClearSpendingLimit bool     `json:"clear_spending_limit"`
+}
+
+// OrganizationDepositRequest represents the deposit payload
+type OrganizationDepositRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+}
+
+// List returns organizations the current user belongs to
+// GET /api/v1/organizations
+func (h *OrganizationHandler) List(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	memberships, err := h.orgService.ListMine(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.Organization, 0, len(memberships))
+	for i := range memberships {
+		out = append(out, *dto.OrganizationFromService(&memberships[i].Organization, memberships[i].Role))
+	}
+	response.Success(c, out)
+}
+
+// Create creates an organization owned by the current user
+// POST /api/v1/organizations
+func (h *OrganizationHandler) Create(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req OrganizationNameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	org, err := h.orgService.Create(c.Request.Context(), subject.UserID, req.Name)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.OrganizationFromService(org, service.OrganizationRoleOwner))
+}
+
+// Get returns an organization the current user belongs to
+// GET /api/v1/organizations/:id
+func (h *OrganizationHandler) Get(c *gin.Context) {
+	subject, orgID, ok := h.parseSubjectAndID(c)
+	if !ok {
+		return
+	}
+
+	org, member, err := h.orgService.Get(c.Request.Context(), subject.UserID, orgID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.OrganizationFromService(org, member.Role))
+}
+
+// Rename renames an organization
+// PUT /api/v1/organizations/:id
+func (h *OrganizationHandler) Rename(c *gin.Context) {
+	subject, orgID, ok := h.parseSubjectAndID(c)
+	if !ok {
+		return
+	}
+
+	var req OrganizationNameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	org, err := h.orgService.Rename(c.Request.Context(), subject.UserID, orgID, req.Name)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.OrganizationFromService(org, ""))
+}
+
+// ListMembers returns members of an organization
+// GET /api/v1/organizations/:id/members
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	subject, orgID, ok := h.parseSubjectAndID(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.orgService.ListMembers(c.Request.Context(), subject.UserID, orgID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.OrganizationMembersFromService(members))
+}
+
+// AddMember adds an existing user to the organization by email
+// POST /api/v1/organizations/:id/members
+func (h *OrganizationHandler) AddMember(c *gin.Context) {
+	subject, orgID, ok := h.parseSubjectAndID(c)
+	if !ok {
+		return
+	}
+
+	var req AddOrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	member, err := h.orgService.AddMember(c.Request.Context(), subject.UserID, orgID, &service.AddOrganizationMemberInput{
+		Email:            req.Email,
+		Role:             req.Role,
+		SpendingLimitUSD: req.SpendingLimitUSD,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.OrganizationMemberFromService(member))
+}
+
+// UpdateMember changes a member's role or spending limit
+// PUT /api/v1/organizations/:id/members/:user_id
+func (h *OrganizationHandler) UpdateMember(c *gin.Context) {
+	subject, orgID, ok := h.parseSubjectAndID(c)
+	if !ok {
+		return
+	}
+	userID, ok := parseOrganizationMemberID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateOrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	member, err := h.orgService.UpdateMember(c.Request.Context(), subject.UserID, orgID, userID, &service.UpdateOrganizationMemberInput{
+		Role:               req.Role,
+		SpendingLimitUSD:   req.SpendingLimitUSD,
+		ClearSpendingLimit: req.ClearSpendingLimit,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.OrganizationMemberFromService(member))
+}
+
+// RemoveMember removes a member (members may remove themselves to leave)
+// DELETE /api/v1/organizations/:id/members/:user_id
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	subject, orgID, ok := h.parseSubjectAndID(c)
+	if !ok {
+		return
+	}
+	userID, ok := parseOrganizationMemberID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.orgService.RemoveMember(c.Request.Context(), subject.UserID, orgID, userID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Member removed successfully"})
+}
+
+// Deposit transfers personal balance into the organization balance
+// POST /api/v1/organizations/:id/deposit
+func (h *OrganizationHandler) Deposit(c *gin.Context) {
+	subject, orgID, ok := h.parseSubjectAndID(c)
+	if !ok {
+		return
+	}
+
+	var req OrganizationDepositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	org, err := h.orgService.Deposit(c.Request.Context(), subject.UserID, orgID, req.Amount)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.OrganizationFromService(org, ""))
+}
+
+// CreateAPIKey creates an API key billed to the organization
+// POST /api/v1/organizations/:id/keys
+func (h *OrganizationHandler) CreateAPIKey(c *gin.Context) {
+	subject, orgID, ok := h.parseSubjectAndID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	key, err := h.orgService.CreateAPIKey(c.Request.Context(), subject.UserID, orgID, service.CreateAPIKeyRequest{
+		Name:        req.Name,
+		GroupID:     req.GroupID,
+		CustomKey:   req.CustomKey,
+		IPWhitelist: req.IPWhitelist,
+		IPBlacklist: req.IPBlacklist,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.APIKeyFromService(key))
+}
+
+// ListUsage returns organization usage records
+// GET /api/v1/organizations/:id/usage
+func (h *OrganizationHandler) ListUsage(c *gin.Context) {
+	subject, orgID, ok := h.parseSubjectAndID(c)
+	if !ok {
+		return
+	}
+	query, ok := parseOrganizationUsageQuery(c)
+	if !ok {
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	records, result, err := h.orgService.ListUsage(c.Request.Context(), subject.UserID, orgID, params, query)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.UsageLog, 0, len(records))
+	for i := range records {
+		out = append(out, *dto.UsageLogFromService(&records[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// UsageStats returns aggregated organization usage
+// GET /api/v1/organizations/:id/usage/stats
+func (h *OrganizationHandler) UsageStats(c *gin.Context) {
+	subject, orgID, ok := h.parseSubjectAndID(c)
+	if !ok {
+		return
+	}
+	query, ok := parseOrganizationUsageQuery(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.orgService.GetUsageStats(c.Request.Context(), subject.UserID, orgID, query)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, stats)
+}
+
+func (h *OrganizationHandler) parseSubjectAndID(c *gin.Context) (middleware2.AuthSubject, int64, bool) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return subject, 0, false
+	}
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid organization ID")
+		return subject, 0, false
+	}
+	return subject, orgID, true
+}
+
+func parseOrganizationMemberID(c *gin.Context) (int64, bool) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return 0, false
+	}
+	return userID, true
+}
+
+func parseOrganizationUsageQuery(c *gin.Context) (service.OrganizationUsageQuery, bool) {
+	query := service.OrganizationUsageQuery{Model: c.Query("model")}
+
+	if v := c.Query("user_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid user_id")
+			return query, false
+		}
+		query.MemberUserID = id
+	}
+	if v := c.Query("api_key_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid api_key_id")
+			return query, false
+		}
+		query.APIKeyID = id
+	}
+
+	userTZ := c.Query("timezone")
+	if v := c.Query("start_date"); v != "" {
+		t, err := timezone.ParseInUserLocation("2006-01-02", v, userTZ)
+		if err != nil {
+			response.BadRequest(c, "Invalid start_date format, use YYYY-MM-DD")
+			return query, false
+		}
+		query.StartTime = &t
+	}
+	if v := c.Query("end_date"); v != "" {
+		t, err := timezone.ParseInUserLocation("2006-01-02", v, userTZ)
+		if err != nil {
+			response.BadRequest(c, "Invalid end_date format, use YYYY-MM-DD")
+			return query, false
+		}
+		t = t.Add(24*time.Hour - time.Nanosecond)
+		query.EndTime = &t
+	}
+	return query, true
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// paymentWebhookMaxBodySize 支付回调请求体上限
+const paymentWebhookMaxBodySize = 256 << 10
+
+// PaymentHandler handles online payment requests
+type PaymentHandler struct {
+	paymentService *service.PaymentService
+}
+
+// NewPaymentHandler creates a new PaymentHandler
+func NewPaymentHandler(paymentService *service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+	}
+}
+
+// CreatePaymentOrderRequest represents the create top-up order payload
+type CreatePaymentOrderRequest struct {
+	Provider string  `json:"provider" binding:"required"`
+	Method   string  `json:"method"`
+	Amount   float64 `json:"amount" binding:"required,gt=0"`
+}
+
+// GetConfig returns the payment options visible to users
+// GET /api/v1/payments/config
+func (h *PaymentHandler) GetConfig(c *gin.Context) {
+	response.Success(c, dto.PaymentConfigFromService(h.paymentService.GetPublicConfig()))
+}
+
+// CreateOrder creates a balance top-up order
+// POST /api/v1/payments/orders
+func (h *PaymentHandler) CreateOrder(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req CreatePaymentOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	order, err := h.paymentService.CreateTopUpOrder(c.Request.Context(), subject.UserID, &service.CreatePaymentOrderInput{
+		Provider: req.Provider,
+		Method:   req.Method,
+		Amount:   req.Amount,
+		ClientIP: ip.GetClientIP(c),
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.PaymentOrderFromService(order))
+}
+
+// ListOrders lists the current user's payment orders
+// GET /api/v1/payments/orders
+func (h *PaymentHandler) ListOrders(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	orders, result, err := h.paymentService.ListUserOrders(c.Request.Context(), subject.UserID, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.PaymentOrder, 0, len(orders))
+	for i := range orders {
+		out = append(out, *dto.PaymentOrderFromService(&orders[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// GetOrder returns one of the current user's payment orders
+// GET /api/v1/payments/orders/:order_no
+func (h *PaymentHandler) GetOrder(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	order, err := h.paymentService.GetUserOrder(c.Request.Context(), subject.UserID, c.Param("order_no"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.PaymentOrderFromService(order))
+}
+
+// CancelOrder cancels a pending payment order
+// POST /api/v1/payments/orders/:order_no/cancel
+func (h *PaymentHandler) CancelOrder(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	order, err := h.paymentService.CancelUserOrder(c.Request.Context(), subject.UserID, c.Param("order_no"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.PaymentOrderFromService(order))
+}
+
+// Webhook receives asynchronous payment notifications from providers.
+// The response format is dictated by each provider (e.g. EPay expects plain "success").
+// GET/POST /api/v1/payments/webhook/:provider
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	provider := c.Param("provider")
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, paymentWebhookMaxBodySize))
+	if err != nil {
+		status, contentType, ack := h.paymentService.WebhookAck(provider, err)
+		c.Data(status, contentType, ack)
+		return
+	}
+
+	req := &service.PaymentWebhookRequest{
+		Method:  c.Request.Method,
+		Headers: c.Request.Header,
+		Query:   c.Request.URL.Query(),
+		Body:    body,
+	}
+	if c.Request.Method == http.MethodPost && strings.HasPrefix(c.ContentType(), "application/x-www-form-urlencoded") {
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			req.Form = form
+		}
+	}
+
+	err = h.paymentService.HandleWebhook(c.Request.Context(), provider, req)
+	status, contentType, ack := h.paymentService.WebhookAck(provider, err)
+	c.Data(status, contentType, ack)
+}
@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResellerHandler handles reseller self-service requests
+type ResellerHandler struct {
+	resellerService *service.ResellerService
+}
+
+// NewResellerHandler creates a new ResellerHandler
+func NewResellerHandler(resellerService *service.ResellerService) *ResellerHandler {
+	return &ResellerHandler{
+		resellerService: resellerService,
+	}
+}
+
+// UpdateResellerMarkupRequest represents the update markup payload
+type UpdateResellerMarkupRequest struct {
+	Markup float64 `json:"markup" binding:"required"`
+}
+
+// CreateSubUserRequest represents the create sub-user payload
+type CreateSubUserRequest struct {
+	Email       string `json:"email" binding:"required,email"`
+	Password    string `json:"password" binding:"required,min=6"`
+	Username    string `json:"username"`
+	Concurrency int    `json:"concurrency"`
+}
+
+// UpdateSubUserStatusRequest represents the sub-user status payload
+type UpdateSubUserStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active disabled"`
+}
+
+// GenerateResellerCodesRequest represents the issue redeem codes payload
+type GenerateResellerCodesRequest struct {
+	Count int     `json:"count" binding:"required,min=1,max=100"`
+	Value float64 `json:"value" binding:"required,gt=0"`
+}
+
+// Profile returns the current user's reseller settings
+// GET /api/v1/reseller/profile
+func (h *ResellerHandler) Profile(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	reseller, err := h.resellerService.GetProfile(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.ResellerFromService(reseller))
+}
+
+// UpdateMarkup changes the markup applied to sub-users
+// PUT /api/v1/reseller/markup
+func (h *ResellerHandler) UpdateMarkup(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req UpdateResellerMarkupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	reseller, err := h.resellerService.UpdateMarkup(c.Request.Context(), subject.UserID, req.Markup)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.ResellerFromService(reseller))
+}
+
+// ListSubUsers returns the reseller's sub-users
+// GET /api/v1/reseller/sub-users
+func (h *ResellerHandler) ListSubUsers(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	users, result, err := h.resellerService.ListSubUsers(c.Request.Context(), subject.UserID, params, c.Query("search"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.User, 0, len(users))
+	for i := range users {
+		out = append(out, *dto.UserFromServiceShallow(&users[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// CreateSubUser creates a sub-user under the current reseller
+// POST /api/v1/reseller/sub-users
+func (h *ResellerHandler) CreateSubUser(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req CreateSubUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	user, err := h.resellerService.CreateSubUser(c.Request.Context(), subject.UserID, &service.CreateSubUserInput{
+		Email:       req.Email,
+		Password:    req.Password,
+		Username:    req.Username,
+		Concurrency: req.Concurrency,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UserFromServiceShallow(user))
+}
+
+// UpdateSubUserStatus enables or disables a sub-user
+// PUT /api/v1/reseller/sub-users/:id/status
+func (h *ResellerHandler) UpdateSubUserStatus(c *gin.Context) {
+	subject, id, ok := parseResellerSubjectAndID(c, "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	var req UpdateSubUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	if err := h.resellerService.UpdateSubUserStatus(c.Request.Context(), subject.UserID, id, req.Status); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Status updated successfully"})
+}
+
+// ListCodes returns redeem codes issued by the reseller
+// GET /api/v1/reseller/codes
+func (h *ResellerHandler) ListCodes(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	codes, result, err := h.resellerService.ListCodes(c.Request.Context(), subject.UserID, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.RedeemCode, 0, len(codes))
+	for i := range codes {
+		out = append(out, *dto.RedeemCodeFromService(&codes[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// GenerateCodes issues balance redeem codes paid from the reseller's balance
+// POST /api/v1/reseller/codes
+func (h *ResellerHandler) GenerateCodes(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req GenerateResellerCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	codes, err := h.resellerService.GenerateCodes(c.Request.Context(), subject.UserID, req.Count, req.Value)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.RedeemCode, 0, len(codes))
+	for i := range codes {
+		out = append(out, *dto.RedeemCodeFromService(&codes[i]))
+	}
+	response.Success(c, out)
+}
+
+// RevokeCode deletes an unused code and refunds its value to the reseller
+// DELETE /api/v1/reseller/codes/:id
+func (h *ResellerHandler) RevokeCode(c *gin.Context) {
+	subject, id, ok := parseResellerSubjectAndID(c, "Invalid code ID")
+	if !ok {
+		return
+	}
+
+	if err := h.resellerService.RevokeCode(c.Request.Context(), subject.UserID, id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Redeem code revoked successfully"})
+}
+
+// Report returns aggregated usage and profit for the reseller
+// GET /api/v1/reseller/report
+func (h *ResellerHandler) Report(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	startTime, endTime := parseUserTimeRange(c)
+	report, err := h.resellerService.Report(c.Request.Context(), subject.UserID, startTime, endTime)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.ResellerReportFromService(report, startTime, endTime))
+}
+
+func parseResellerSubjectAndID(c *gin.Context, invalidMsg string) (middleware2.AuthSubject, int64, bool) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return subject, 0, false
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, invalidMsg)
+		return subject, 0, false
+	}
+	return subject, id, true
+}
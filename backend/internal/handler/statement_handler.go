@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatementHandler handles user monthly statements
+type StatementHandler struct {
+	statementService *service.UserStatementService
+}
+
+// NewStatementHandler creates a new StatementHandler
+func NewStatementHandler(statementService *service.UserStatementService) *StatementHandler {
+	return &StatementHandler{
+		statementService: statementService,
+	}
+}
+
+// List returns the user's monthly statements
+// GET /api/v1/statements
+func (h *StatementHandler) List(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	items, result, err := h.statementService.ListStatements(c.Request.Context(), subject.UserID, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.UserStatement, 0, len(items))
+	for i := range items {
+		out = append(out, *dto.UserStatementFromService(&items[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// Get returns a statement with its breakdown
+// GET /api/v1/statements/:id
+func (h *StatementHandler) Get(c *gin.Context) {
+	statement, ok := h.loadStatement(c)
+	if !ok {
+		return
+	}
+	response.Success(c, dto.UserStatementDetailFromService(statement))
+}
+
+// Download returns the stored statement document
+// GET /api/v1/statements/:id/download?format=pdf|html
+func (h *StatementHandler) Download(c *gin.Context) {
+	format, err := service.NormalizeUserStatementFormat(c.Query("format"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	statement, ok := h.loadStatement(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+service.UserStatementFileName(statement.Period, format))
+	if format == service.UserStatementFormatHTML {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(statement.HTML))
+		return
+	}
+	c.Data(http.StatusOK, "application/pdf", statement.PDF)
+}
+
+func (h *StatementHandler) loadStatement(c *gin.Context) (*service.UserStatement, bool) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return nil, false
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid statement ID")
+		return nil, false
+	}
+	statement, err := h.statementService.GetStatement(c.Request.Context(), id, subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return nil, false
+	}
+	return statement, true
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubscriptionPlanHandler handles user-facing subscription plan requests
+type SubscriptionPlanHandler struct {
+	planService *service.SubscriptionPlanService
+}
+
+// NewSubscriptionPlanHandler creates a new SubscriptionPlanHandler
+func NewSubscriptionPlanHandler(planService *service.SubscriptionPlanService) *SubscriptionPlanHandler {
+	return &SubscriptionPlanHandler{
+		planService: planService,
+	}
+}
+
+// PurchasePlanRequest represents the purchase plan payload
+type PurchasePlanRequest struct {
+	AutoRenew *bool `json:"auto_renew"` // 为空时续费沿用原设置，新购默认关闭
+}
+
+// ChangePlanRequest represents the change plan payload
+type ChangePlanRequest struct {
+	PlanID int64 `json:"plan_id" binding:"required,gt=0"`
+}
+
+// SetAutoRenewRequest represents the toggle auto-renew payload
+type SetAutoRenewRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// List returns plans available for purchase
+// GET /api/v1/subscription-plans
+func (h *SubscriptionPlanHandler) List(c *gin.Context) {
+	plans, err := h.planService.ListAvailablePlans(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.SubscriptionPlan, 0, len(plans))
+	for i := range plans {
+		out = append(out, *dto.SubscriptionPlanFromService(&plans[i]))
+	}
+	response.Success(c, out)
+}
+
+// Purchase buys (or renews) a plan using the user's balance
+// POST /api/v1/subscription-plans/:id/purchase
+func (h *SubscriptionPlanHandler) Purchase(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid plan ID")
+		return
+	}
+
+	var req PurchasePlanRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+	}
+
+	result, err := h.planService.Purchase(c.Request.Context(), subject.UserID, planID, req.AutoRenew)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.SubscriptionPlanPurchaseResultFromService(result))
+}
+
+// ListOrders returns the user's plan purchase history
+// GET /api/v1/subscription-plans/orders
+func (h *SubscriptionPlanHandler) ListOrders(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	orders, result, err := h.planService.ListOrders(c.Request.Context(), subject.UserID, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.SubscriptionPlanOrder, 0, len(orders))
+	for i := range orders {
+		out = append(out, *dto.SubscriptionPlanOrderFromService(&orders[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// ChangePlan upgrades (immediately, prorated) or downgrades (next renewal) a subscription
+// POST /api/v1/subscriptions/:id/change-plan
+func (h *SubscriptionPlanHandler) ChangePlan(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+
+	var req ChangePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	result, err := h.planService.ChangePlan(c.Request.Context(), subject.UserID, subscriptionID, req.PlanID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.SubscriptionPlanPurchaseResultFromService(result))
+}
+
+// SetAutoRenew toggles auto-renewal of a plan subscription
+// PUT /api/v1/subscriptions/:id/auto-renew
+func (h *SubscriptionPlanHandler) SetAutoRenew(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+
+	var req SetAutoRenewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	sub, err := h.planService.SetAutoRenew(c.Request.Context(), subject.UserID, subscriptionID, req.Enabled)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UserSubscriptionFromService(sub))
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"log"
+	"net/http"
 	"strconv"
 	"time"
 
@@ -19,13 +21,15 @@ import (
 type UsageHandler struct {
 	usageService  *service.UsageService
 	apiKeyService *service.APIKeyService
+	exportService *service.UsageExportService
 }
 
 // NewUsageHandler creates a new UsageHandler
-func NewUsageHandler(usageService *service.UsageService, apiKeyService *service.APIKeyService) *UsageHandler {
+func NewUsageHandler(usageService *service.UsageService, apiKeyService *service.APIKeyService, exportService *service.UsageExportService) *UsageHandler {
 	return &UsageHandler{
 		usageService:  usageService,
 		apiKeyService: apiKeyService,
+		exportService: exportService,
 	}
 }
 
@@ -40,23 +44,45 @@ func (h *UsageHandler) List(c *gin.Context) {
 
 	page, pageSize := response.ParsePagination(c)
 
+	filters, ok := h.parseUsageLogFilters(c, subject.UserID)
+	if !ok {
+		return
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+
+	records, result, err := h.usageService.ListWithFilters(c.Request.Context(), params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.UsageLog, 0, len(records))
+	for i := range records {
+		out = append(out, *dto.UsageLogFromService(&records[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// parseUsageLogFilters 解析当前用户使用记录列表与导出共用的过滤参数；解析失败时已写入错误响应
+func (h *UsageHandler) parseUsageLogFilters(c *gin.Context, userID int64) (usagestats.UsageLogFilters, bool) {
 	var apiKeyID int64
 	if apiKeyIDStr := c.Query("api_key_id"); apiKeyIDStr != "" {
 		id, err := strconv.ParseInt(apiKeyIDStr, 10, 64)
 		if err != nil {
 			response.BadRequest(c, "Invalid api_key_id")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 
 		// [Security Fix] Verify API Key ownership to prevent horizontal privilege escalation
 		apiKey, err := h.apiKeyService.GetByID(c.Request.Context(), id)
 		if err != nil {
 			response.ErrorFrom(c, err)
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
-		if apiKey.UserID != subject.UserID {
+		if apiKey.UserID != userID {
 			response.Forbidden(c, "Not authorized to access this API key's usage records")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 
 		apiKeyID = id
@@ -70,7 +96,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		val, err := strconv.ParseBool(streamStr)
 		if err != nil {
 			response.BadRequest(c, "Invalid stream value, use true or false")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		stream = &val
 	}
@@ -80,7 +106,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		val, err := strconv.ParseInt(billingTypeStr, 10, 8)
 		if err != nil {
 			response.BadRequest(c, "Invalid billing_type")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		bt := int8(val)
 		billingType = &bt
@@ -93,7 +119,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		t, err := timezone.ParseInUserLocation("2006-01-02", startDateStr, userTZ)
 		if err != nil {
 			response.BadRequest(c, "Invalid start_date format, use YYYY-MM-DD")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		startTime = &t
 	}
@@ -102,35 +128,22 @@ func (h *UsageHandler) List(c *gin.Context) {
 		t, err := timezone.ParseInUserLocation("2006-01-02", endDateStr, userTZ)
 		if err != nil {
 			response.BadRequest(c, "Invalid end_date format, use YYYY-MM-DD")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		// Set end time to end of day
 		t = t.Add(24*time.Hour - time.Nanosecond)
 		endTime = &t
 	}
 
-	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
-	filters := usagestats.UsageLogFilters{
-		UserID:      subject.UserID, // Always filter by current user for security
+	return usagestats.UsageLogFilters{
+		UserID:      userID, // Always filter by current user for security
 		APIKeyID:    apiKeyID,
 		Model:       model,
 		Stream:      stream,
 		BillingType: billingType,
 		StartTime:   startTime,
 		EndTime:     endTime,
-	}
-
-	records, result, err := h.usageService.ListWithFilters(c.Request.Context(), params, filters)
-	if err != nil {
-		response.ErrorFrom(c, err)
-		return
-	}
-
-	out := make([]dto.UsageLog, 0, len(records))
-	for i := range records {
-		out = append(out, *dto.UsageLogFromService(&records[i]))
-	}
-	response.Paginated(c, out, result.Total, page, pageSize)
+	}, true
 }
 
 // GetByID handles getting a single usage record
@@ -400,3 +413,118 @@ func (h *UsageHandler) DashboardAPIKeysUsage(c *gin.Context) {
 
 	response.Success(c, gin.H{"stats": stats})
 }
+
+// Export handles exporting the current user's usage records
+// GET /api/v1/usage/export?format=csv|jsonl|parquet
+// 行数不超过 usage_export.sync_max_rows 时直接返回文件，否则创建异步导出任务（202）
+func (h *UsageHandler) Export(c *gin.Context) {
+	if h.exportService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Usage export service unavailable")
+		return
+	}
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	filters, ok := h.parseUsageLogFilters(c, subject.UserID)
+	if !ok {
+		return
+	}
+	req := &service.UsageExportRequest{
+		Scope:       service.UsageExportScopeUser,
+		Format:      c.Query("format"),
+		Filters:     filters,
+		RequestedBy: subject.UserID,
+	}
+	task, err := h.exportService.Submit(c.Request.Context(), req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	if task != nil {
+		response.Accepted(c, dto.UsageExportTaskFromService(task))
+		return
+	}
+	streamUsageExport(c, h.exportService, req)
+}
+
+// streamUsageExport 同步输出导出文件；响应头写出后发生的错误只能记录日志
+func streamUsageExport(c *gin.Context, exportService *service.UsageExportService, req *service.UsageExportRequest) {
+	c.Header("Content-Type", service.UsageExportContentType(req.Format))
+	c.Header("Content-Disposition", "attachment; filename="+service.UsageExportFileName(req.Format, time.Now()))
+	c.Status(http.StatusOK)
+	if _, err := exportService.Stream(c.Request.Context(), c.Writer, req); err != nil {
+		log.Printf("[UsageExport] sync export failed: operator=%d scope=%s format=%s err=%v", req.RequestedBy, req.Scope, req.Format, err)
+	}
+}
+
+// ListExports handles listing the current user's export tasks
+// GET /api/v1/usage/exports
+func (h *UsageHandler) ListExports(c *gin.Context) {
+	if h.exportService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Usage export service unavailable")
+		return
+	}
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	tasks, result, err := h.exportService.ListTasks(c.Request.Context(), subject.UserID, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]dto.UsageExportTask, 0, len(tasks))
+	for i := range tasks {
+		out = append(out, *dto.UsageExportTaskFromService(&tasks[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// GetExport handles getting one of the current user's export tasks
+// GET /api/v1/usage/exports/:id
+func (h *UsageHandler) GetExport(c *gin.Context) {
+	if h.exportService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Usage export service unavailable")
+		return
+	}
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || taskID <= 0 {
+		response.BadRequest(c, "Invalid export task ID")
+		return
+	}
+	task, err := h.exportService.GetTask(c.Request.Context(), taskID, subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UsageExportTaskFromService(task))
+}
+
+// DownloadExport handles downloading an export file by its expiring token (public)
+// GET /api/v1/exports/usage/:token
+func (h *UsageHandler) DownloadExport(c *gin.Context) {
+	if h.exportService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Usage export service unavailable")
+		return
+	}
+	task, path, err := h.exportService.ResolveDownload(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	c.Header("Content-Type", service.UsageExportContentType(task.Format))
+	c.FileAttachment(path, service.UsageExportFileName(task.Format, task.CreatedAt))
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageTagHandler handles user cost attribution tags: tag keys, per-tag stats and tag budgets
+type UsageTagHandler struct {
+	usageTagService *service.UsageTagService
+}
+
+// NewUsageTagHandler creates a new UsageTagHandler
+func NewUsageTagHandler(usageTagService *service.UsageTagService) *UsageTagHandler {
+	return &UsageTagHandler{usageTagService: usageTagService}
+}
+
+// SetUsageTagBudgetRequest represents a monthly budget for one tag value
+type SetUsageTagBudgetRequest struct {
+	TagKey          string  `json:"tag_key" binding:"required"`
+	TagValue        string  `json:"tag_value" binding:"required"`
+	MonthlyLimitUSD float64 `json:"monthly_limit_usd" binding:"gt=0"`
+}
+
+// ListKeys returns the tag keys clients may send
+// GET /api/v1/usage/tag-keys
+func (h *UsageTagHandler) ListKeys(c *gin.Context) {
+	keys, err := h.usageTagService.ListKeys(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]dto.UsageTagKey, 0, len(keys))
+	for i := range keys {
+		out = append(out, *dto.UsageTagKeyFromService(&keys[i]))
+	}
+	response.Success(c, out)
+}
+
+// Stats returns the current user's usage aggregated by tag value
+// GET /api/v1/usage/tags/stats?tag_key=project&start_date=&end_date=&api_key_id=
+func (h *UsageTagHandler) Stats(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	startTime, endTime := parseUserTimeRange(c)
+	filters := service.UsageTagStatsFilters{
+		TagKey:    c.Query("tag_key"),
+		UserID:    &subject.UserID,
+		StartTime: startTime,
+		EndTime:   endTime,
+	}
+	if v := c.Query("api_key_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid api_key_id")
+			return
+		}
+		filters.APIKeyID = &id
+	}
+
+	stats, err := h.usageTagService.GetStats(c.Request.Context(), filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{
+		"tag_key":    filters.TagKey,
+		"start_time": startTime,
+		"end_time":   endTime,
+		"items":      dto.UsageTagStatsFromService(stats),
+	})
+}
+
+// ListBudgets returns the current user's tag budgets with month-to-date spend
+// GET /api/v1/usage/tags/budgets
+func (h *UsageTagHandler) ListBudgets(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	budgets, err := h.usageTagService.ListBudgets(c.Request.Context(), &subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]dto.UsageTagBudget, 0, len(budgets))
+	for i := range budgets {
+		out = append(out, *dto.UsageTagBudgetFromService(&budgets[i]))
+	}
+	response.Success(c, out)
+}
+
+// SetBudget creates or replaces a budget for one of the current user's tag values
+// PUT /api/v1/usage/tags/budgets
+func (h *UsageTagHandler) SetBudget(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req SetUsageTagBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	budget, err := h.usageTagService.SetBudget(c.Request.Context(), subject.UserID, &service.SetUsageTagBudgetInput{
+		TagKey:          req.TagKey,
+		TagValue:        req.TagValue,
+		MonthlyLimitUSD: req.MonthlyLimitUSD,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UsageTagBudgetFromService(budget))
+}
+
+// DeleteBudget deletes one of the current user's tag budgets
+// DELETE /api/v1/usage/tags/budgets/:id
+func (h *UsageTagHandler) DeleteBudget(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid budget ID")
+		return
+	}
+	if err := h.usageTagService.DeleteBudget(c.Request.Context(), id, &subject.UserID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Tag budget deleted successfully"})
+}
@@ -26,6 +26,21 @@ func ProvideAdminHandlers(
 	subscriptionHandler *admin.SubscriptionHandler,
 	usageHandler *admin.UsageHandler,
 	userAttributeHandler *admin.UserAttributeHandler,
+	paymentHandler *admin.PaymentHandler,
+	subscriptionPlanHandler *admin.SubscriptionPlanHandler,
+	organizationHandler *admin.OrganizationHandler,
+	resellerHandler *admin.ResellerHandler,
+	pricingHandler *admin.PricingHandler,
+	rateMultiplierHandler *admin.RateMultiplierHandler,
+	usageTagHandler *admin.UsageTagHandler,
+	usageAnomalyHandler *admin.UsageAnomalyHandler,
+	billingOutboxHandler *admin.BillingOutboxHandler,
+	anthropicFileHandler *admin.AnthropicFileHandler,
+	stickySessionHandler *admin.StickySessionHandler,
+	requestHedgeHandler *admin.RequestHedgeHandler,
+	guardrailHandler *admin.GuardrailHandler,
+	promptTemplateHandler *admin.PromptTemplateHandler,
+	configHandler *admin.ConfigHandler,
 ) *AdminHandlers {
 	return &AdminHandlers{
 		Dashboard:        dashboardHandler,
@@ -45,6 +60,21 @@ func ProvideAdminHandlers(
 		Subscription:     subscriptionHandler,
 		Usage:            usageHandler,
 		UserAttribute:    userAttributeHandler,
+		Payment:          paymentHandler,
+		SubscriptionPlan: subscriptionPlanHandler,
+		Organization:     organizationHandler,
+		Reseller:         resellerHandler,
+		Pricing:          pricingHandler,
+		RateMultiplier:   rateMultiplierHandler,
+		UsageTag:         usageTagHandler,
+		UsageAnomaly:     usageAnomalyHandler,
+		BillingOutbox:    billingOutboxHandler,
+		AnthropicFile:    anthropicFileHandler,
+		StickySession:    stickySessionHandler,
+		RequestHedge:     requestHedgeHandler,
+		Guardrail:        guardrailHandler,
+		PromptTemplate:   promptTemplateHandler,
+		Config:           configHandler,
 	}
 }
 
@@ -66,22 +96,36 @@ func ProvideHandlers(
 	usageHandler *UsageHandler,
 	redeemHandler *RedeemHandler,
 	subscriptionHandler *SubscriptionHandler,
+	paymentHandler *PaymentHandler,
+	subscriptionPlanHandler *SubscriptionPlanHandler,
+	notificationHandler *NotificationHandler,
+	statementHandler *StatementHandler,
+	usageTagHandler *UsageTagHandler,
+	organizationHandler *OrganizationHandler,
+	resellerHandler *ResellerHandler,
 	adminHandlers *AdminHandlers,
 	gatewayHandler *GatewayHandler,
 	openaiGatewayHandler *OpenAIGatewayHandler,
 	settingHandler *SettingHandler,
 ) *Handlers {
 	return &Handlers{
-		Auth:          authHandler,
-		User:          userHandler,
-		APIKey:        apiKeyHandler,
-		Usage:         usageHandler,
-		Redeem:        redeemHandler,
-		Subscription:  subscriptionHandler,
-		Admin:         adminHandlers,
-		Gateway:       gatewayHandler,
-		OpenAIGateway: openaiGatewayHandler,
-		Setting:       settingHandler,
+		Auth:             authHandler,
+		User:             userHandler,
+		APIKey:           apiKeyHandler,
+		Usage:            usageHandler,
+		Redeem:           redeemHandler,
+		Subscription:     subscriptionHandler,
+		Payment:          paymentHandler,
+		SubscriptionPlan: subscriptionPlanHandler,
+		Notification:     notificationHandler,
+		Statement:        statementHandler,
+		UsageTag:         usageTagHandler,
+		Organization:     organizationHandler,
+		Reseller:         resellerHandler,
+		Admin:            adminHandlers,
+		Gateway:          gatewayHandler,
+		OpenAIGateway:    openaiGatewayHandler,
+		Setting:          settingHandler,
 	}
 }
 
@@ -94,6 +138,13 @@ var ProviderSet = wire.NewSet(
 	NewUsageHandler,
 	NewRedeemHandler,
 	NewSubscriptionHandler,
+	NewPaymentHandler,
+	NewSubscriptionPlanHandler,
+	NewNotificationHandler,
+	NewStatementHandler,
+	NewUsageTagHandler,
+	NewOrganizationHandler,
+	NewResellerHandler,
 	NewGatewayHandler,
 	NewOpenAIGatewayHandler,
 	ProvideSettingHandler,
@@ -116,6 +167,21 @@ var ProviderSet = wire.NewSet(
 	admin.NewSubscriptionHandler,
 	admin.NewUsageHandler,
 	admin.NewUserAttributeHandler,
+	admin.NewPaymentHandler,
+	admin.NewSubscriptionPlanHandler,
+	admin.NewOrganizationHandler,
+	admin.NewResellerHandler,
+	admin.NewPricingHandler,
+	admin.NewRateMultiplierHandler,
+	admin.NewUsageTagHandler,
+	admin.NewUsageAnomalyHandler,
+	admin.NewBillingOutboxHandler,
+	admin.NewAnthropicFileHandler,
+	admin.NewStickySessionHandler,
+	admin.NewRequestHedgeHandler,
+	admin.NewGuardrailHandler,
+	admin.NewPromptTemplateHandler,
+	admin.NewConfigHandler,
 
 	// AdminHandlers and Handlers constructors
 	ProvideAdminHandlers,
@@ -125,6 +125,14 @@ type ClaudeUsage struct {
 	OutputTokens             int `json:"output_tokens"`
 	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
 	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+	// CacheCreation 缓存写入按 TTL 拆分（Gemini 不区分 TTL，未设置时全部视为 5 分钟写入）
+	CacheCreation *ClaudeCacheCreation `json:"cache_creation,omitempty"`
+}
+
+// ClaudeCacheCreation 缓存写入 TTL 拆分
+type ClaudeCacheCreation struct {
+	Ephemeral5mInputTokens int `json:"ephemeral_5m_input_tokens"`
+	Ephemeral1hInputTokens int `json:"ephemeral_1h_input_tokens"`
 }
 
 // ClaudeError Claude 错误响应
@@ -9,6 +9,7 @@ const (
 	BetaClaudeCode               = "claude-code-20250219"
 	BetaInterleavedThinking      = "interleaved-thinking-2025-05-14"
 	BetaFineGrainedToolStreaming = "fine-grained-tool-streaming-2025-05-14"
+	BetaFilesAPI                 = "files-api-2025-04-14"
 )
 
 // DefaultBetaHeader Claude Code 客户端默认的 anthropic-beta header
@@ -18,4 +18,6 @@ const (
 	IsClaudeCodeClient Key = "ctx_is_claude_code_client"
 	// Group 认证后的分组信息，由 API Key 认证中间件设置
 	Group Key = "ctx_group"
+	// PinnedAccountID 请求必须使用的账号（如引用 Files API 文件时的文件归属账号）
+	PinnedAccountID Key = "ctx_pinned_account_id"
 )
@@ -0,0 +1,126 @@
+package parquet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWriterRoundTripWithIndependentReader 使用独立的 parquet-go 实现读回写入的文件，校验 schema 与每一行的值
+func TestWriterRoundTripWithIndependentReader(t *testing.T) {
+	columns := []Column{
+		{Name: "id", Type: Int64},
+		{Name: "model", Type: String},
+		{Name: "cost", Type: Double},
+		{Name: "stream", Type: Boolean},
+		{Name: "created_at", Type: Timestamp},
+		{Name: "group_id", Type: Int64, Optional: true},
+		{Name: "note", Type: String, Optional: true},
+	}
+	type row struct {
+		id        int64
+		model     string
+		cost      float64
+		stream    bool
+		createdAt time.Time
+		groupID   *int64
+		note      *string
+	}
+
+	base := time.Date(2026, 1, 2, 3, 4, 5, 678_000_000, time.UTC)
+	var want []row
+	for i := 0; i < 23; i++ {
+		r := row{
+			id:        int64(i + 1),
+			model:     fmt.Sprintf("model-%d", i%4),
+			cost:      float64(i) * 0.125,
+			stream:    i%3 == 0,
+			createdAt: base.Add(time.Duration(i) * time.Minute),
+		}
+		if i%2 == 0 {
+			g := int64(100 + i)
+			r.groupID = &g
+		}
+		if i%5 == 0 {
+			n := fmt.Sprintf("备注 %d", i)
+			r.note = &n
+		}
+		want = append(want, r)
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, columns, 10)
+	require.NoError(t, err)
+	for _, r := range want {
+		var groupID, note any
+		if r.groupID != nil {
+			groupID = *r.groupID
+		}
+		if r.note != nil {
+			note = *r.note
+		}
+		require.NoError(t, w.Write(r.id, r.model, r.cost, r.stream, r.createdAt, groupID, note))
+	}
+	require.NoError(t, w.Close())
+
+	file, err := pq.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.EqualValues(t, len(want), file.NumRows())
+	require.Len(t, file.RowGroups(), 3)
+
+	fields := file.Schema().Fields()
+	require.Len(t, fields, len(columns))
+	for i, col := range columns {
+		require.Equal(t, col.Name, fields[i].Name())
+		require.Equal(t, col.Optional, fields[i].Optional(), col.Name)
+	}
+	require.Equal(t, pq.ByteArray, fields[1].Type().Kind())
+	require.NotNil(t, fields[1].Type().LogicalType().UTF8)
+	require.Equal(t, pq.Double, fields[2].Type().Kind())
+	require.Equal(t, pq.Boolean, fields[3].Type().Kind())
+	require.Equal(t, pq.Int64, fields[4].Type().Kind())
+
+	var got []pq.Row
+	for _, rg := range file.RowGroups() {
+		rows := rg.Rows()
+		batch := make([]pq.Row, 4)
+		for {
+			n, err := rows.ReadRows(batch)
+			for _, r := range batch[:n] {
+				got = append(got, r.Clone())
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+		}
+		require.NoError(t, rows.Close())
+	}
+	require.Len(t, got, len(want))
+
+	for i, r := range want {
+		values := got[i]
+		require.Len(t, values, len(columns))
+		require.Equal(t, r.id, values[0].Int64(), "row %d id", i)
+		require.Equal(t, r.model, string(values[1].ByteArray()), "row %d model", i)
+		require.Equal(t, r.cost, values[2].Double(), "row %d cost", i)
+		require.Equal(t, r.stream, values[3].Boolean(), "row %d stream", i)
+		require.Equal(t, r.createdAt.UnixMilli(), values[4].Int64(), "row %d created_at", i)
+		if r.groupID == nil {
+			require.True(t, values[5].IsNull(), "row %d group_id", i)
+		} else {
+			require.Equal(t, *r.groupID, values[5].Int64(), "row %d group_id", i)
+		}
+		if r.note == nil {
+			require.True(t, values[6].IsNull(), "row %d note", i)
+		} else {
+			require.Equal(t, *r.note, string(values[6].ByteArray()), "row %d note", i)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+// Package parquet provides a minimal, dependency-free Apache Parquet file writer.
+//
+// 仅支持扁平 schema、PLAIN 编码、不压缩的数据页；可选列的定义级别使用 RLE/bit-packed 混合编码。
+// 足以被 pandas / DuckDB / Spark 等常见工具读取，适用于导出场景。
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+const magic = "PAR1"
+
+// Parquet 物理类型、重复类型、编码与页类型常量
+const (
+	physicalBoolean   int32 = 0
+	physicalInt64     int32 = 2
+	physicalDouble    int32 = 5
+	physicalByteArray int32 = 6
+
+	repetitionRequired int32 = 0
+	repetitionOptional int32 = 1
+
+	convertedUTF8            int32 = 0
+	convertedTimestampMillis int32 = 9
+
+	encodingPlain int32 = 0
+	encodingRLE   int32 = 3
+
+	codecUncompressed int32 = 0
+	pageTypeData      int32 = 0
+)
+
+// DefaultRowGroupSize 默认每个行组的行数
+const DefaultRowGroupSize = 10000
+
+// ColumnType 列的逻辑类型
+type ColumnType int
+
+const (
+	Int64 ColumnType = iota
+	Double
+	String
+	Boolean
+	// Timestamp 以 INT64 毫秒时间戳（TIMESTAMP_MILLIS, UTC）存储
+	Timestamp
+)
+
+// Column 列定义；Optional 列允许写入 nil
+type Column struct {
+	Name     string
+	Type     ColumnType
+	Optional bool
+}
+
+var errClosed = errors.New("parquet: writer closed")
+
+type columnBuffer struct {
+	values    bytes.Buffer
+	bools     []bool
+	defLevels []bool
+	count     int
+}
+
+type columnChunkMeta struct {
+	offset     int64
+	size       int64
+	numValues  int64
+	physical   int32
+	name       string
+	dataOffset int64
+}
+
+type rowGroupMeta struct {
+	columns []columnChunkMeta
+	size    int64
+	numRows int64
+}
+
+// Writer 按行写入 Parquet 文件，每 rowGroupSize 行落盘一个行组
+type Writer struct {
+	out          io.Writer
+	offset       int64
+	columns      []Column
+	buffers      []*columnBuffer
+	rowGroupSize int
+	pending      int
+	rowGroups    []rowGroupMeta
+	numRows      int64
+	closed       bool
+}
+
+// NewWriter 创建写入器并写入文件头
+func NewWriter(out io.Writer, columns []Column, rowGroupSize int) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("parquet: no columns")
+	}
+	if rowGroupSize <= 0 {
+		rowGroupSize = DefaultRowGroupSize
+	}
+	w := &Writer{out: out, columns: columns, rowGroupSize: rowGroupSize}
+	w.resetBuffers()
+	if err := w.write([]byte(magic)); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write 写入一行，values 与列定义一一对应：
+// Int64 接受 int/int64，Double 接受 float64，String 接受 string，Boolean 接受 bool，Timestamp 接受 time.Time；
+// 可选列可传 nil。
+func (w *Writer) Write(values ...any) error {
+	if w.closed {
+		return errClosed
+	}
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("parquet: expected %d values, got %d", len(w.columns), len(values))
+	}
+	for i, col := range w.columns {
+		if err := w.buffers[i].append(col, values[i]); err != nil {
+			return fmt.Errorf("parquet: column %s: %w", col.Name, err)
+		}
+	}
+	w.pending++
+	if w.pending >= w.rowGroupSize {
+		return w.flushRowGroup()
+	}
+	return nil
+}
+
+// Close 写入剩余行组与文件尾（不关闭底层 io.Writer）
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if w.pending > 0 {
+		if err := w.flushRowGroup(); err != nil {
+			return err
+		}
+	}
+	w.closed = true
+
+	footer := w.fileMetadata()
+	if err := w.write(footer); err != nil {
+		return err
+	}
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(footer)))
+	if err := w.write(size[:]); err != nil {
+		return err
+	}
+	return w.write([]byte(magic))
+}
+
+func (w *Writer) write(p []byte) error {
+	n, err := w.out.Write(p)
+	w.offset += int64(n)
+	return err
+}
+
+func (w *Writer) resetBuffers() {
+	w.buffers = make([]*columnBuffer, len(w.columns))
+	for i := range w.buffers {
+		w.buffers[i] = &columnBuffer{}
+	}
+	w.pending = 0
+}
+
+func (b *columnBuffer) append(col Column, v any) error {
+	b.count++
+	if v == nil {
+		if !col.Optional {
+			return errors.New("nil value for required column")
+		}
+		b.defLevels = append(b.defLevels, false)
+		return nil
+	}
+	if col.Optional {
+		b.defLevels = append(b.defLevels, true)
+	}
+
+	var tmp [8]byte
+	switch col.Type {
+	case Int64:
+		var n int64
+		switch x := v.(type) {
+		case int64:
+			n = x
+		case int:
+			n = int64(x)
+		default:
+			return fmt.Errorf("unexpected %T for int64", v)
+		}
+		binary.LittleEndian.PutUint64(tmp[:], uint64(n))
+		b.values.Write(tmp[:])
+	case Timestamp:
+		t, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("unexpected %T for timestamp", v)
+		}
+		binary.LittleEndian.PutUint64(tmp[:], uint64(t.UnixMilli()))
+		b.values.Write(tmp[:])
+	case Double:
+		f, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected %T for double", v)
+		}
+		binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(f))
+		b.values.Write(tmp[:])
+	case String:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("unexpected %T for string", v)
+		}
+		binary.LittleEndian.PutUint32(tmp[:4], uint32(len(s)))
+		b.values.Write(tmp[:4])
+		b.values.WriteString(s)
+	case Boolean:
+		x, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected %T for bool", v)
+		}
+		b.bools = append(b.bools, x)
+	default:
+		return fmt.Errorf("unsupported column type %d", col.Type)
+	}
+	return nil
+}
+
+func (w *Writer) flushRowGroup() error {
+	rg := rowGroupMeta{numRows: int64(w.pending)}
+	for i, col := range w.columns {
+		buf := w.buffers[i]
+		var page bytes.Buffer
+		if col.Optional {
+			levels := packBits(buf.defLevels)
+			var hdr bytes.Buffer
+			var tmp [binary.MaxVarintLen64]byte
+			// bit-packed run: header = (groups << 1) | 1，位宽 1
+			n := binary.PutUvarint(tmp[:], uint64(len(levels))<<1|1)
+			hdr.Write(tmp[:n])
+			hdr.Write(levels)
+			var size [4]byte
+			binary.LittleEndian.PutUint32(size[:], uint32(hdr.Len()))
+			page.Write(size[:])
+			page.Write(hdr.Bytes())
+		}
+		if col.Type == Boolean {
+			page.Write(packBits(buf.bools))
+		} else {
+			page.Write(buf.values.Bytes())
+		}
+
+		header := newThriftWriter()
+		header.I32(1, pageTypeData)
+		header.I32(2, int32(page.Len()))
+		header.I32(3, int32(page.Len()))
+		header.StructBegin(5)
+		header.I32(1, int32(buf.count))
+		header.I32(2, encodingPlain)
+		header.I32(3, encodingRLE)
+		header.I32(4, encodingRLE)
+		header.StructEnd()
+		header.StructEnd()
+
+		offset := w.offset
+		if err := w.write(header.Bytes()); err != nil {
+			return err
+		}
+		if err := w.write(page.Bytes()); err != nil {
+			return err
+		}
+		chunk := columnChunkMeta{
+			offset:     offset,
+			size:       w.offset - offset,
+			numValues:  int64(buf.count),
+			physical:   physicalType(col.Type),
+			name:       col.Name,
+			dataOffset: offset,
+		}
+		rg.columns = append(rg.columns, chunk)
+		rg.size += chunk.size
+	}
+	w.rowGroups = append(w.rowGroups, rg)
+	w.numRows += rg.numRows
+	w.resetBuffers()
+	return nil
+}
+
+func (w *Writer) fileMetadata() []byte {
+	t := newThriftWriter()
+	t.I32(1, 1)
+
+	t.ListBegin(2, thriftStruct, len(w.columns)+1)
+	t.StructBegin(0)
+	t.String(4, "schema")
+	t.I32(5, int32(len(w.columns)))
+	t.StructEnd()
+	for _, col := range w.columns {
+		t.StructBegin(0)
+		t.I32(1, physicalType(col.Type))
+		if col.Optional {
+			t.I32(3, repetitionOptional)
+		} else {
+			t.I32(3, repetitionRequired)
+		}
+		t.String(4, col.Name)
+		switch col.Type {
+		case String:
+			t.I32(6, convertedUTF8)
+		case Timestamp:
+			t.I32(6, convertedTimestampMillis)
+		}
+		t.StructEnd()
+	}
+
+	t.I64(3, w.numRows)
+
+	t.ListBegin(4, thriftStruct, len(w.rowGroups))
+	for _, rg := range w.rowGroups {
+		t.StructBegin(0)
+		t.ListBegin(1, thriftStruct, len(rg.columns))
+		for _, chunk := range rg.columns {
+			t.StructBegin(0)
+			t.I64(2, chunk.offset)
+			t.StructBegin(3)
+			t.I32(1, chunk.physical)
+			t.ListBegin(2, thriftI32, 2)
+			t.ListI32(encodingPlain)
+			t.ListI32(encodingRLE)
+			t.ListBegin(3, thriftBinary, 1)
+			t.ListString(chunk.name)
+			t.I32(4, codecUncompressed)
+			t.I64(5, chunk.numValues)
+			t.I64(6, chunk.size)
+			t.I64(7, chunk.size)
+			t.I64(9, chunk.dataOffset)
+			t.StructEnd()
+			t.StructEnd()
+		}
+		t.I64(2, rg.size)
+		t.I64(3, rg.numRows)
+		t.StructEnd()
+	}
+
+	t.String(6, "sub2api parquet writer")
+	t.StructEnd()
+	return t.Bytes()
+}
+
+func physicalType(t ColumnType) int32 {
+	switch t {
+	case Double:
+		return physicalDouble
+	case String:
+		return physicalByteArray
+	case Boolean:
+		return physicalBoolean
+	default:
+		return physicalInt64
+	}
+}
+
+// packBits 按 LSB 优先将布尔值打包为字节（按 8 个一组补齐）
+func packBits(bits []bool) []byte {
+	out := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		if b {
+			out[i/8] |= 1 << (i % 8)
+		}
+	}
+	return out
+}
@@ -0,0 +1,135 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// thriftReader 测试用的 compact protocol 解码器，将结构体解码为 field id -> 值
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.uvarint())
+		s := string(r.data[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftList:
+		head := r.data[r.pos]
+		r.pos++
+		size := int(head >> 4)
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		items := make([]any, size)
+		for i := range items {
+			items[i] = r.value(head & 0x0f)
+		}
+		return items
+	case thriftStruct:
+		return r.structValue()
+	}
+	panic("unsupported thrift type")
+}
+
+func (r *thriftReader) structValue() map[int16]any {
+	out := map[int16]any{}
+	var last int16
+	for {
+		head := r.data[r.pos]
+		r.pos++
+		if head == 0 {
+			return out
+		}
+		id := last + int16(head>>4)
+		if head>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		out[id] = r.value(head & 0x0f)
+		last = id
+	}
+}
+
+func TestWriterProducesReadableFile(t *testing.T) {
+	columns := []Column{
+		{Name: "id", Type: Int64},
+		{Name: "model", Type: String},
+		{Name: "cost", Type: Double},
+		{Name: "stream", Type: Boolean},
+		{Name: "created_at", Type: Timestamp},
+		{Name: "group_id", Type: Int64, Optional: true},
+	}
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, columns, 2)
+	require.NoError(t, err)
+
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, w.Write(int64(1), "claude-sonnet-4", 0.5, true, ts, int64(7)))
+	require.NoError(t, w.Write(2, "gpt-4o", 1.25, false, ts, nil))
+	require.NoError(t, w.Write(int64(3), "gemini", 0.0, true, ts, int64(9)))
+	require.Error(t, w.Write(int64(4)))
+	require.Error(t, w.Write(nil, "x", 0.0, false, ts, nil))
+	require.NoError(t, w.Close())
+	require.ErrorIs(t, w.Write(int64(5), "x", 0.0, false, ts, nil), errClosed)
+
+	data := buf.Bytes()
+	require.Equal(t, magic, string(data[:4]))
+	require.Equal(t, magic, string(data[len(data)-4:]))
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := data[len(data)-8-footerLen : len(data)-8]
+
+	meta := (&thriftReader{data: footer}).structValue()
+	require.EqualValues(t, 3, meta[3])
+
+	schema := meta[2].([]any)
+	require.Len(t, schema, len(columns)+1)
+	require.Equal(t, "model", schema[2].(map[int16]any)[4])
+	require.EqualValues(t, repetitionOptional, schema[6].(map[int16]any)[3])
+
+	rowGroups := meta[4].([]any)
+	require.Len(t, rowGroups, 2)
+	require.EqualValues(t, 2, rowGroups[0].(map[int16]any)[3])
+	require.EqualValues(t, 1, rowGroups[1].(map[int16]any)[3])
+
+	// 读取第一个行组 cost 列的数据页，校验 PLAIN 编码的值
+	chunks := rowGroups[0].(map[int16]any)[1].([]any)
+	costMeta := chunks[2].(map[int16]any)[3].(map[int16]any)
+	offset := int(costMeta[9].(int64))
+	page := &thriftReader{data: data, pos: offset}
+	header := page.structValue()
+	require.EqualValues(t, 16, header[2])
+	require.EqualValues(t, 2, header[5].(map[int16]any)[1])
+	values := data[page.pos : page.pos+16]
+	require.Equal(t, 0.5, math.Float64frombits(binary.LittleEndian.Uint64(values[:8])))
+	require.Equal(t, 1.25, math.Float64frombits(binary.LittleEndian.Uint64(values[8:])))
+
+	// 可选列：定义级别 [1, 0] 后仅包含一个非空值
+	groupMeta := chunks[5].(map[int16]any)[3].(map[int16]any)
+	page = &thriftReader{data: data, pos: int(groupMeta[9].(int64))}
+	page.structValue()
+	levelsLen := int(binary.LittleEndian.Uint32(data[page.pos:]))
+	require.Equal(t, []byte{0x03, 0x01}, data[page.pos+4:page.pos+4+levelsLen])
+	require.EqualValues(t, 7, binary.LittleEndian.Uint64(data[page.pos+4+levelsLen:]))
+}
@@ -0,0 +1,336 @@
+// Package pdf provides a minimal, dependency-free PDF 1.4 document writer.
+//
+// 支持内置 Helvetica 字体（WinAnsi 编码，无法表示的字符输出为 '?'）与嵌入的 TrueType 字体
+// （Type0 / Identity-H，按已用字形裁剪后嵌入，用于中日韩等非拉丁文本）、直线、矩形填充与 RGB 位图，
+// 足以生成账单、报表等以表格为主的文档。坐标以页面左上角为原点、单位为 pt，y 轴向下。
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"unicode/utf16"
+)
+
+// A4 页面尺寸（pt）
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Document PDF 文档
+type Document struct {
+	Title string
+
+	width  float64
+	height float64
+	pages  []*Page
+	images []*Image
+	fonts  []*embeddedFont
+}
+
+// Page 单个页面，绘制指令累积为内容流
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+	images  map[*Image]struct{}
+}
+
+// Image 已编码为 RGB + Flate 的位图资源
+type Image struct {
+	name   string
+	width  int
+	height int
+	data   []byte
+}
+
+// New 创建 A4 纵向文档
+func New() *Document {
+	return &Document{width: A4Width, height: A4Height}
+}
+
+// PageSize 返回页面宽高（pt）
+func (d *Document) PageSize() (float64, float64) {
+	return d.width, d.height
+}
+
+// AddPage 追加新页面
+func (d *Document) AddPage() *Page {
+	p := &Page{doc: d, images: map[*Image]struct{}{}}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// PageCount 返回页数
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// Page 返回第 i 页（从 0 开始），用于在排版完成后补充页脚等内容
+func (d *Document) Page(i int) *Page {
+	return d.pages[i]
+}
+
+// AddImage 注册位图；透明像素按白色背景合成
+func (d *Document) AddImage(img image.Image) (*Image, error) {
+	if img == nil {
+		return nil, errors.New("pdf: nil image")
+	}
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= 0 || h <= 0 {
+		return nil, errors.New("pdf: empty image")
+	}
+	raw := make([]byte, 0, w*h*3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			// RGBA() 返回预乘 alpha 的 16 位分量，叠加白色背景：c + (1 - a)
+			bg := 0xffff - a
+			raw = append(raw, byte((r+bg)>>8), byte((g+bg)>>8), byte((b+bg)>>8))
+		}
+	}
+	data, err := deflate(raw)
+	if err != nil {
+		return nil, err
+	}
+	im := &Image{name: "Im" + strconv.Itoa(len(d.images)+1), width: w, height: h, data: data}
+	d.images = append(d.images, im)
+	return im, nil
+}
+
+// Size 返回位图像素尺寸
+func (im *Image) Size() (int, int) {
+	return im.width, im.height
+}
+
+// Text 在 (x, y) 处绘制单行文本，y 为基线位置
+func (p *Page) Text(x, y float64, font Font, size float64, s string) {
+	if s == "" {
+		return
+	}
+	if font.ttf != nil {
+		ef := p.doc.embed(font.ttf)
+		p.content.WriteString("q BT ")
+		if font.bold {
+			// 填充 + 描边模拟粗体
+			fmt.Fprintf(&p.content, "2 Tr %s w ", num(size*0.03))
+		}
+		fmt.Fprintf(&p.content, "/%s %s Tf %s %s Td <", ef.name, num(size), num(x), num(p.doc.height-y))
+		for _, r := range s {
+			gid := font.ttf.glyph(r)
+			if _, ok := ef.used[gid]; !ok || gid == 0 {
+				ef.used[gid] = r
+			}
+			fmt.Fprintf(&p.content, "%04X", gid)
+		}
+		p.content.WriteString("> Tj ET Q\n")
+		return
+	}
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td (", font.resourceName(), num(size), num(x), num(p.doc.height-y))
+	writeEscaped(&p.content, encodeWinAnsi(s))
+	p.content.WriteString(") Tj ET\n")
+}
+
+// TextRight 绘制右对齐文本，x 为右边界
+func (p *Page) TextRight(x, y float64, font Font, size float64, s string) {
+	p.Text(x-font.TextWidth(s, size), y, font, size, s)
+}
+
+// Line 绘制直线
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n", num(width), num(x1), num(p.doc.height-y1), num(x2), num(p.doc.height-y2))
+}
+
+// FillRect 以灰度填充矩形（0 为黑，1 为白），(x, y) 为左上角
+func (p *Page) FillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(&p.content, "q %s g %s %s %s %s re f Q\n", num(gray), num(x), num(p.doc.height-y-h), num(w), num(h))
+}
+
+// DrawImage 将位图绘制到 (x, y) 为左上角、宽高为 w×h 的区域
+func (p *Page) DrawImage(im *Image, x, y, w, h float64) {
+	if im == nil {
+		return
+	}
+	p.images[im] = struct{}{}
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /%s Do Q\n", num(w), num(h), num(x), num(p.doc.height-y-h), im.name)
+}
+
+// WriteTo 输出完整的 PDF 文件
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	// 对象编号：1 Catalog, 2 Pages, 3/4 字体, 5 Info, 之后为位图、嵌入字体（每个 5 个对象），最后是每页的 Page + Contents
+	const (
+		catalogObj = 1
+		pagesObj   = 2
+		fontObj    = 3
+		boldObj    = 4
+		infoObj    = 5
+	)
+	imageBase := 6
+	fontBase := imageBase + len(d.images)
+	pageBase := fontBase + embeddedFontObjects*len(d.fonts)
+	imageObj := make(map[*Image]int, len(d.images))
+	for i, im := range d.images {
+		imageObj[im] = imageBase + i
+	}
+
+	var buf bytes.Buffer
+	offsets := make([]int, pageBase+2*len(d.pages))
+	begin := func(id int) {
+		offsets[id] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n", id)
+	}
+	end := func() {
+		buf.WriteString("endobj\n")
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	begin(catalogObj)
+	fmt.Fprintf(&buf, "<< /Type /Catalog /Pages %d 0 R >>\n", pagesObj)
+	end()
+
+	begin(pagesObj)
+	buf.WriteString("<< /Type /Pages /Kids [")
+	for i := range d.pages {
+		fmt.Fprintf(&buf, " %d 0 R", pageBase+2*i)
+	}
+	fmt.Fprintf(&buf, " ] /Count %d /MediaBox [0 0 %s %s] >>\n", len(d.pages), num(d.width), num(d.height))
+	end()
+
+	for _, f := range []struct {
+		id   int
+		font Font
+	}{{fontObj, Helvetica}, {boldObj, HelveticaBold}} {
+		begin(f.id)
+		fmt.Fprintf(&buf, "<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>\n", f.font.baseName())
+		end()
+	}
+
+	begin(infoObj)
+	buf.WriteString("<< /Producer (sub2api)")
+	if d.Title != "" {
+		buf.WriteString(" /Title ")
+		writeTextString(&buf, d.Title)
+	}
+	buf.WriteString(" >>\n")
+	end()
+
+	for _, im := range d.images {
+		begin(imageObj[im])
+		fmt.Fprintf(&buf, "<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n",
+			im.width, im.height, len(im.data))
+		buf.Write(im.data)
+		buf.WriteString("\nendstream\n")
+		end()
+	}
+
+	for i, ef := range d.fonts {
+		if err := ef.write(&buf, fontBase+embeddedFontObjects*i, begin, end); err != nil {
+			return 0, err
+		}
+	}
+
+	for i, p := range d.pages {
+		pageID := pageBase + 2*i
+		contentID := pageID + 1
+
+		begin(pageID)
+		fmt.Fprintf(&buf, "<< /Type /Page /Parent %d 0 R /Resources << /Font << /F1 %d 0 R /F2 %d 0 R", pagesObj, fontObj, boldObj)
+		for j, ef := range d.fonts {
+			fmt.Fprintf(&buf, " /%s %d 0 R", ef.name, fontBase+embeddedFontObjects*j)
+		}
+		buf.WriteString(" >>")
+		if len(p.images) > 0 {
+			buf.WriteString(" /XObject <<")
+			for _, im := range d.images {
+				if _, ok := p.images[im]; ok {
+					fmt.Fprintf(&buf, " /%s %d 0 R", im.name, imageObj[im])
+				}
+			}
+			buf.WriteString(" >>")
+		}
+		fmt.Fprintf(&buf, " >> /Contents %d 0 R >>\n", contentID)
+		end()
+
+		data, err := deflate(p.content.Bytes())
+		if err != nil {
+			return 0, err
+		}
+		begin(contentID)
+		fmt.Fprintf(&buf, "<< /Length %d /Filter /FlateDecode >>\nstream\n", len(data))
+		buf.Write(data)
+		buf.WriteString("\nendstream\n")
+		end()
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets))
+	for _, off := range offsets[1:] {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets), catalogObj, infoObj, xref)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// Bytes 以字节切片形式返回完整的 PDF 文件
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := d.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeEscaped(buf *bytes.Buffer, s []byte) {
+	for _, b := range s {
+		switch b {
+		case '(', ')', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(b)
+		default:
+			buf.WriteByte(b)
+		}
+	}
+}
+
+// writeTextString 写出 PDF 文本字符串：WinAnsi 可完整表示时使用字面量，否则使用带 BOM 的 UTF-16BE
+func writeTextString(buf *bytes.Buffer, s string) {
+	if !winAnsiLossless(s) {
+		buf.WriteString("<FEFF")
+		for _, u := range utf16.Encode([]rune(s)) {
+			fmt.Fprintf(buf, "%04X", u)
+		}
+		buf.WriteString(">")
+		return
+	}
+	buf.WriteString("(")
+	writeEscaped(buf, encodeWinAnsi(s))
+	buf.WriteString(")")
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
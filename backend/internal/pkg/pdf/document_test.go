@@ -0,0 +1,87 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image"
+	"image/color"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentXrefOffsetsPointAtObjects(t *testing.T) {
+	doc := New()
+	doc.Title = "Statement (2026-01)"
+	page := doc.AddPage()
+	page.Text(40, 60, HelveticaBold, 18, "Monthly Statement")
+	page.Line(40, 70, 555, 70, 0.5)
+	doc.AddPage().Text(40, 60, Helvetica, 10, "page two")
+
+	out, err := doc.Bytes()
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")))
+	require.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+	require.Contains(t, string(out), "/Count 2")
+	require.Contains(t, string(out), `/Title (Statement \(2026-01\))`)
+
+	requireXrefValid(t, out, 9)
+}
+
+func requireXrefValid(t *testing.T, out []byte, objects int) {
+	t.Helper()
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	require.NotNil(t, m)
+	xref, err := strconv.Atoi(string(m[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(out[xref:], []byte("xref\n")))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xref:], -1)
+	require.Len(t, entries, objects)
+	for i, e := range entries {
+		off, err := strconv.Atoi(string(e[1]))
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(out[off:], []byte(strconv.Itoa(i+1)+" 0 obj\n")), "object %d", i+1)
+	}
+}
+
+func TestPageContentUsesTopLeftOriginAndEscapesText(t *testing.T) {
+	doc := New()
+	page := doc.AddPage()
+	page.Text(10, 100, Helvetica, 12, `a(b)\c 中`)
+
+	content := page.content.String()
+	require.Contains(t, content, "/F1 12.00 Tf 10.00 741.89 Td (a\\(b\\)\\\\c ?) Tj")
+}
+
+func TestDrawImageRegistersResourceOnPage(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	img.Set(1, 0, color.NRGBA{A: 0})
+
+	doc := New()
+	im, err := doc.AddImage(img)
+	require.NoError(t, err)
+	doc.AddPage().DrawImage(im, 40, 40, 20, 10)
+
+	zr, err := zlib.NewReader(bytes.NewReader(im.data))
+	require.NoError(t, err)
+	raw, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	// 透明像素合成为白色
+	require.Equal(t, []byte{255, 0, 0, 255, 255, 255}, raw)
+
+	out, err := doc.Bytes()
+	require.NoError(t, err)
+	require.Contains(t, string(out), "/XObject << /Im1 6 0 R >>")
+	require.Contains(t, string(out), "/Width 2 /Height 1")
+}
+
+func TestTextWidthAndWinAnsi(t *testing.T) {
+	require.InDelta(t, 5.56*3, Helvetica.TextWidth("100", 10), 0.001)
+	require.Greater(t, HelveticaBold.TextWidth("Total", 10), Helvetica.TextWidth("Total", 10))
+	require.Equal(t, []byte{'a', 0x80, 0xe9, '?'}, encodeWinAnsi("a€é日"))
+}
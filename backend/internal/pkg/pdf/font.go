@@ -0,0 +1,114 @@
+package pdf
+
+// Font 页面字体：内置 Type1 标准字体 Helvetica（WinAnsi 编码，无需嵌入字体文件），
+// 或由 TrueType 创建的嵌入字体（Type0 / Identity-H 编码，支持中日韩等任意 Unicode 字符）。
+type Font struct {
+	bold bool
+	ttf  *TrueTypeFont
+}
+
+var (
+	Helvetica     = Font{}
+	HelveticaBold = Font{bold: true}
+)
+
+// TrueType 使用嵌入的 TrueType 字体绘制文本；bold 为 true 时以描边模拟粗体
+func TrueType(f *TrueTypeFont, bold bool) Font {
+	return Font{bold: bold, ttf: f}
+}
+
+func (f Font) baseName() string {
+	if f.bold {
+		return "Helvetica-Bold"
+	}
+	return "Helvetica"
+}
+
+func (f Font) resourceName() string {
+	if f.bold {
+		return "F2"
+	}
+	return "F1"
+}
+
+// ASCII 32..126 的字宽（千分之一 em），取自 Adobe 标准 AFM
+var helveticaWidths = [95]uint16{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]uint16{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// TextWidth 返回文本在指定字号下的宽度（pt）；内置字体中非 ASCII 字符按数字宽度估算
+func (f Font) TextWidth(s string, size float64) float64 {
+	var total int
+	if f.ttf != nil {
+		for _, r := range s {
+			total += f.ttf.width(f.ttf.glyph(r))
+		}
+		return float64(total) * size / 1000
+	}
+	widths := &helveticaWidths
+	if f.bold {
+		widths = &helveticaBoldWidths
+	}
+	for _, b := range encodeWinAnsi(s) {
+		if b >= 32 && b <= 126 {
+			total += int(widths[b-32])
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// winAnsiSpecials WinAnsiEncoding 中 0x80..0x9F 区间的常用字符
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'‰': 0x89, '‹': 0x8B, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99, '›': 0x9B,
+}
+
+// encodeWinAnsi 将 UTF-8 文本转换为 WinAnsiEncoding 字节；无法表示的字符替换为 '?'
+func encodeWinAnsi(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 32 && r <= 126, r >= 160 && r <= 255:
+			out = append(out, byte(r))
+		case r == '\t':
+			out = append(out, ' ')
+		default:
+			if b, ok := winAnsiSpecials[r]; ok {
+				out = append(out, b)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+// winAnsiLossless 文本是否可以无损转换为 WinAnsiEncoding
+func winAnsiLossless(s string) bool {
+	for _, r := range s {
+		if (r >= 32 && r <= 126) || (r >= 160 && r <= 255) || r == '\t' {
+			continue
+		}
+		if _, ok := winAnsiSpecials[r]; !ok {
+			return false
+		}
+	}
+	return true
+}
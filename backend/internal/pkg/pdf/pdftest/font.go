@@ -0,0 +1,169 @@
+// Package pdftest provides helpers for tests that render PDFs with embedded fonts.
+package pdftest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+)
+
+// UnitsPerEm 生成字体的 em 单位
+const UnitsPerEm = 1000
+
+// Advance 生成字体中每个字形的宽度（字体单位）
+const Advance = 800
+
+// TrueTypeFont 生成仅包含 chars 中字符的最小 TrueType 字体（glyf 轮廓、cmap format 4）。
+// 字形 1 为方块轮廓，每个字符对应一个引用字形 1 的复合字形，字形编号按字符排序后从 2 开始。
+func TrueTypeFont(chars string) []byte {
+	set := map[rune]bool{}
+	for _, r := range chars {
+		if r > 0 && r < 0xFFFF {
+			set[r] = true
+		}
+	}
+	runes := make([]rune, 0, len(set))
+	for r := range set {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	numGlyphs := len(runes) + 2
+
+	be := binary.BigEndian
+	var glyf bytes.Buffer
+	loca := make([]byte, 4*(numGlyphs+1))
+	// 字形 0：.notdef，空轮廓
+	be.PutUint32(loca[4:], 0)
+	// 字形 1：单个矩形轮廓
+	square := []byte{
+		0, 1, 0, 50, 0, 0, 2, 0xBC, 2, 0x58, // 1 contour, bbox 50,0,700,600
+		0, 3, // endPtsOfContours
+		0, 0, // instructionLength
+		0x01, 0x01, 0x01, 0x01, // flags: on-curve, 16-bit deltas
+		0, 50, 0, 0, 2, 0x8A, 0, 0, // x: 50, +0, +650, +0
+		0, 0, 2, 0x58, 0, 0, 0xFD, 0xA8, // y: 0, +600, +0, -600
+	}
+	glyf.Write(square)
+	for glyf.Len()%4 != 0 {
+		glyf.WriteByte(0)
+	}
+	be.PutUint32(loca[8:], uint32(glyf.Len()))
+	for i := range runes {
+		composite := []byte{
+			0xFF, 0xFF, 0, 50, 0, 0, 2, 0xBC, 2, 0x58, // composite, same bbox
+			0, 0x02, 0, 1, 0, 0, // ARGS_ARE_XY_VALUES, glyph 1, byte offsets 0,0
+		}
+		glyf.Write(composite)
+		be.PutUint32(loca[4*(i+3):], uint32(glyf.Len()))
+	}
+
+	head := make([]byte, 54)
+	be.PutUint32(head, 0x00010000)
+	be.PutUint32(head[12:], 0x5F0F3CF5)
+	be.PutUint16(head[18:], UnitsPerEm)
+	be.PutUint16(head[36:], 50)
+	be.PutUint16(head[40:], 700)
+	be.PutUint16(head[42:], 600)
+	be.PutUint16(head[50:], 1)
+
+	hhea := make([]byte, 36)
+	be.PutUint32(hhea, 0x00010000)
+	be.PutUint16(hhea[4:], 880)
+	be.PutUint16(hhea[6:], uint16(0xFFFF-120+1)) // -120
+	be.PutUint16(hhea[10:], Advance)
+	be.PutUint16(hhea[34:], uint16(numGlyphs))
+
+	maxp := make([]byte, 32)
+	be.PutUint32(maxp, 0x00010000)
+	be.PutUint16(maxp[4:], uint16(numGlyphs))
+
+	hmtx := make([]byte, 4*numGlyphs)
+	for gid := 0; gid < numGlyphs; gid++ {
+		be.PutUint16(hmtx[4*gid:], Advance)
+	}
+
+	tables := map[string][]byte{
+		"head": head,
+		"hhea": hhea,
+		"maxp": maxp,
+		"hmtx": hmtx,
+		"loca": loca,
+		"glyf": glyf.Bytes(),
+		"cmap": cmapFormat4(runes),
+		"name": postScriptName("Sub2apiTestCJK"),
+	}
+	return sfnt(tables)
+}
+
+// cmapFormat4 每个字符一个段，字形编号为排序序号 + 2
+func cmapFormat4(runes []rune) []byte {
+	be := binary.BigEndian
+	segs := len(runes) + 1
+	sub := make([]byte, 16+8*segs)
+	be.PutUint16(sub, 4)
+	be.PutUint16(sub[2:], uint16(len(sub)))
+	be.PutUint16(sub[6:], uint16(2*segs))
+	ends := sub[14:]
+	starts := sub[16+2*segs:]
+	deltas := sub[16+4*segs:]
+	for i, r := range runes {
+		be.PutUint16(ends[2*i:], uint16(r))
+		be.PutUint16(starts[2*i:], uint16(r))
+		be.PutUint16(deltas[2*i:], uint16(i+2)-uint16(r))
+	}
+	be.PutUint16(ends[2*len(runes):], 0xFFFF)
+	be.PutUint16(starts[2*len(runes):], 0xFFFF)
+	be.PutUint16(deltas[2*len(runes):], 1)
+
+	cmap := make([]byte, 12, 12+len(sub))
+	be.PutUint16(cmap[2:], 1)
+	be.PutUint16(cmap[4:], 3)
+	be.PutUint16(cmap[6:], 1)
+	be.PutUint32(cmap[8:], 12)
+	return append(cmap, sub...)
+}
+
+// postScriptName 只包含 nameID 6 的 name 表（Windows 平台，UTF-16BE）
+func postScriptName(name string) []byte {
+	be := binary.BigEndian
+	str := make([]byte, 0, 2*len(name))
+	for _, c := range []byte(name) {
+		str = append(str, 0, c)
+	}
+	out := make([]byte, 18, 18+len(str))
+	be.PutUint16(out[2:], 1)
+	be.PutUint16(out[4:], 18)
+	be.PutUint16(out[6:], 3)
+	be.PutUint16(out[8:], 1)
+	be.PutUint16(out[10:], 0x0409)
+	be.PutUint16(out[12:], 6)
+	be.PutUint16(out[14:], uint16(len(str)))
+	return append(out, str...)
+}
+
+func sfnt(tables map[string][]byte) []byte {
+	be := binary.BigEndian
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	header := make([]byte, 12+16*len(tags))
+	be.PutUint32(header, 0x00010000)
+	be.PutUint16(header[4:], uint16(len(tags)))
+	offset := len(header)
+	var body bytes.Buffer
+	for i, tag := range tags {
+		data := tables[tag]
+		rec := header[12+16*i:]
+		copy(rec, tag)
+		be.PutUint32(rec[8:], uint32(offset+body.Len()))
+		be.PutUint32(rec[12:], uint32(len(data)))
+		body.Write(data)
+		for body.Len()%4 != 0 {
+			body.WriteByte(0)
+		}
+	}
+	return append(header, body.Bytes()...)
+}
@@ -0,0 +1,492 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// TrueTypeFont 解析后的 TrueType 字体（.ttf，或 .ttc 集合中的第一个字体），只读，可在多个文档间共享。
+// 仅支持 glyf 轮廓；CFF 轮廓的 OpenType 字体（如 Noto Sans CJK 的 .otf）不受支持。
+type TrueTypeFont struct {
+	name       string
+	unitsPerEm uint16
+	bbox       [4]int16
+	ascent     int16
+	descent    int16
+
+	glyphs   map[rune]uint16
+	advances []uint16
+
+	numGlyphs        int
+	longLoca         bool
+	loca             []byte
+	glyf             []byte
+	tables           map[string][]byte
+	numberOfHMetrics uint16
+}
+
+var errNotTrueType = errors.New("pdf: not a TrueType font")
+
+// ParseTrueType 解析 TrueType 字体文件
+func ParseTrueType(data []byte) (*TrueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, errNotTrueType
+	}
+	offset := 0
+	switch string(data[:4]) {
+	case "ttcf":
+		if len(data) < 16 || binary.BigEndian.Uint32(data[8:]) == 0 {
+			return nil, errNotTrueType
+		}
+		offset = int(binary.BigEndian.Uint32(data[12:]))
+	case "OTTO":
+		return nil, errors.New("pdf: CFF-based OpenType fonts are not supported, use a TrueType (glyf) font")
+	}
+	if offset+12 > len(data) {
+		return nil, errNotTrueType
+	}
+	if v := binary.BigEndian.Uint32(data[offset:]); v != 0x00010000 && v != 0x74727565 {
+		return nil, errNotTrueType
+	}
+
+	numTables := int(binary.BigEndian.Uint16(data[offset+4:]))
+	tables := make(map[string][]byte, numTables)
+	for i := 0; i < numTables; i++ {
+		rec := offset + 12 + 16*i
+		if rec+16 > len(data) {
+			return nil, errNotTrueType
+		}
+		tag := string(data[rec : rec+4])
+		start := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if start < 0 || length < 0 || start+length > len(data) {
+			return nil, fmt.Errorf("pdf: truncated %q table", tag)
+		}
+		tables[tag] = data[start : start+length]
+	}
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "loca", "glyf", "cmap"} {
+		if _, ok := tables[tag]; !ok {
+			return nil, fmt.Errorf("pdf: missing %q table", tag)
+		}
+	}
+
+	head, hhea, maxp := tables["head"], tables["hhea"], tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, errNotTrueType
+	}
+	f := &TrueTypeFont{
+		unitsPerEm: binary.BigEndian.Uint16(head[18:]),
+		longLoca:   binary.BigEndian.Uint16(head[50:]) == 1,
+		ascent:     int16(binary.BigEndian.Uint16(hhea[4:])),
+		descent:    int16(binary.BigEndian.Uint16(hhea[6:])),
+		numGlyphs:  int(binary.BigEndian.Uint16(maxp[4:])),
+		loca:       tables["loca"],
+		glyf:       tables["glyf"],
+		tables:     tables,
+	}
+	if f.unitsPerEm == 0 {
+		return nil, errNotTrueType
+	}
+	for i := range f.bbox {
+		f.bbox[i] = int16(binary.BigEndian.Uint16(head[36+2*i:]))
+	}
+
+	f.numberOfHMetrics = binary.BigEndian.Uint16(hhea[34:])
+	hmtx := tables["hmtx"]
+	if f.numberOfHMetrics == 0 || len(hmtx) < 4*int(f.numberOfHMetrics) {
+		return nil, errors.New("pdf: invalid hmtx table")
+	}
+	f.advances = make([]uint16, f.numGlyphs)
+	for gid := range f.advances {
+		m := gid
+		if m >= int(f.numberOfHMetrics) {
+			m = int(f.numberOfHMetrics) - 1
+		}
+		f.advances[gid] = binary.BigEndian.Uint16(hmtx[4*m:])
+	}
+
+	glyphs, err := parseCmap(tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	f.glyphs = glyphs
+	f.name = postScriptName(tables["name"])
+	return f, nil
+}
+
+// Name 返回字体的 PostScript 名称
+func (f *TrueTypeFont) Name() string {
+	return f.name
+}
+
+// HasGlyph 字体是否包含该字符
+func (f *TrueTypeFont) HasGlyph(r rune) bool {
+	_, ok := f.glyphs[r]
+	return ok
+}
+
+// glyph 返回字符对应的字形编号，缺失时为 0（.notdef）
+func (f *TrueTypeFont) glyph(r rune) uint16 {
+	if r == '\t' {
+		r = ' '
+	}
+	return f.glyphs[r]
+}
+
+// width 返回字形宽度（千分之一 em）
+func (f *TrueTypeFont) width(gid uint16) int {
+	if int(gid) >= len(f.advances) {
+		return 0
+	}
+	return int(f.advances[gid]) * 1000 / int(f.unitsPerEm)
+}
+
+// scale 将字体单位换算为千分之一 em
+func (f *TrueTypeFont) scale(v int16) int {
+	return int(v) * 1000 / int(f.unitsPerEm)
+}
+
+// parseCmap 读取 Unicode 字符映射，优先使用完整 Unicode 的 format 12 子表
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, errors.New("pdf: invalid cmap table")
+	}
+	var bmp, full []byte
+	n := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < n; i++ {
+		rec := 4 + 8*i
+		if rec+8 > len(cmap) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(cmap[rec:])
+		encoding := binary.BigEndian.Uint16(cmap[rec+2:])
+		off := int(binary.BigEndian.Uint32(cmap[rec+4:]))
+		if off+4 > len(cmap) {
+			continue
+		}
+		sub := cmap[off:]
+		unicode := platform == 0 || (platform == 3 && (encoding == 1 || encoding == 10))
+		if !unicode {
+			continue
+		}
+		switch binary.BigEndian.Uint16(sub) {
+		case 4:
+			if bmp == nil {
+				bmp = sub
+			}
+		case 12:
+			if full == nil {
+				full = sub
+			}
+		}
+	}
+	switch {
+	case full != nil:
+		return parseCmap12(full)
+	case bmp != nil:
+		return parseCmap4(bmp)
+	}
+	return nil, errors.New("pdf: no Unicode cmap subtable")
+}
+
+func parseCmap4(sub []byte) (map[rune]uint16, error) {
+	if len(sub) < 14 {
+		return nil, errors.New("pdf: invalid cmap format 4")
+	}
+	segX2 := int(binary.BigEndian.Uint16(sub[6:]))
+	endBase := 14
+	startBase := endBase + segX2 + 2
+	deltaBase := startBase + segX2
+	rangeBase := deltaBase + segX2
+	if rangeBase+segX2 > len(sub) {
+		return nil, errors.New("pdf: invalid cmap format 4")
+	}
+	out := make(map[rune]uint16)
+	for s := 0; s < segX2; s += 2 {
+		end := int(binary.BigEndian.Uint16(sub[endBase+s:]))
+		start := int(binary.BigEndian.Uint16(sub[startBase+s:]))
+		delta := binary.BigEndian.Uint16(sub[deltaBase+s:])
+		rangeOffset := int(binary.BigEndian.Uint16(sub[rangeBase+s:]))
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			var gid uint16
+			if rangeOffset == 0 {
+				gid = uint16(c) + delta
+			} else {
+				pos := rangeBase + s + rangeOffset + 2*(c-start)
+				if pos+2 > len(sub) {
+					continue
+				}
+				gid = binary.BigEndian.Uint16(sub[pos:])
+				if gid != 0 {
+					gid += delta
+				}
+			}
+			if gid != 0 {
+				out[rune(c)] = gid
+			}
+		}
+	}
+	return out, nil
+}
+
+func parseCmap12(sub []byte) (map[rune]uint16, error) {
+	if len(sub) < 16 {
+		return nil, errors.New("pdf: invalid cmap format 12")
+	}
+	groups := int(binary.BigEndian.Uint32(sub[12:]))
+	if 16+12*groups > len(sub) {
+		return nil, errors.New("pdf: invalid cmap format 12")
+	}
+	out := make(map[rune]uint16)
+	for i := 0; i < groups; i++ {
+		g := sub[16+12*i:]
+		start := binary.BigEndian.Uint32(g)
+		end := binary.BigEndian.Uint32(g[4:])
+		gid := binary.BigEndian.Uint32(g[8:])
+		if end < start || end > 0x10FFFF {
+			continue
+		}
+		for c := start; c <= end; c++ {
+			if id := gid + (c - start); id != 0 && id <= 0xFFFF {
+				out[rune(c)] = uint16(id)
+			}
+		}
+	}
+	return out, nil
+}
+
+// postScriptName 读取 name 表中的 PostScript 名称（nameID 6），缺失时使用默认名称
+func postScriptName(name []byte) string {
+	const fallback = "EmbeddedFont"
+	if len(name) < 6 {
+		return fallback
+	}
+	count := int(binary.BigEndian.Uint16(name[2:]))
+	strings := int(binary.BigEndian.Uint16(name[4:]))
+	for i := 0; i < count; i++ {
+		rec := 6 + 12*i
+		if rec+12 > len(name) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(name[rec:])
+		if binary.BigEndian.Uint16(name[rec+6:]) != 6 {
+			continue
+		}
+		length := int(binary.BigEndian.Uint16(name[rec+8:]))
+		off := strings + int(binary.BigEndian.Uint16(name[rec+10:]))
+		if off+length > len(name) {
+			continue
+		}
+		raw := name[off : off+length]
+		var out []byte
+		if platform == 1 {
+			out = raw
+		} else {
+			for j := 1; j < len(raw); j += 2 {
+				out = append(out, raw[j])
+			}
+		}
+		if n := sanitizePDFName(out); n != "" {
+			return n
+		}
+	}
+	return fallback
+}
+
+// sanitizePDFName 仅保留 PDF 名称中安全的 ASCII 字符
+func sanitizePDFName(b []byte) string {
+	out := make([]byte, 0, len(b))
+	for _, c := range b {
+		if c > 32 && c < 127 && !bytes.ContainsRune([]byte("()<>[]{}/%#"), rune(c)) {
+			out = append(out, c)
+		}
+	}
+	return string(out)
+}
+
+// glyphData 返回字形在 glyf 表中的原始数据
+func (f *TrueTypeFont) glyphData(gid uint16) []byte {
+	if int(gid) >= f.numGlyphs {
+		return nil
+	}
+	var start, end int
+	if f.longLoca {
+		if 4*int(gid)+8 > len(f.loca) {
+			return nil
+		}
+		start = int(binary.BigEndian.Uint32(f.loca[4*int(gid):]))
+		end = int(binary.BigEndian.Uint32(f.loca[4*int(gid)+4:]))
+	} else {
+		if 2*int(gid)+4 > len(f.loca) {
+			return nil
+		}
+		start = 2 * int(binary.BigEndian.Uint16(f.loca[2*int(gid):]))
+		end = 2 * int(binary.BigEndian.Uint16(f.loca[2*int(gid)+2:]))
+	}
+	if start >= end || end > len(f.glyf) {
+		return nil
+	}
+	return f.glyf[start:end]
+}
+
+// compositeComponents 返回复合字形引用的子字形
+func compositeComponents(data []byte) []uint16 {
+	if len(data) < 10 || int16(binary.BigEndian.Uint16(data)) >= 0 {
+		return nil
+	}
+	const (
+		argsAreWords   = 0x0001
+		haveScale      = 0x0008
+		moreComponents = 0x0020
+		haveXYScale    = 0x0040
+		haveTwoByTwo   = 0x0080
+	)
+	var out []uint16
+	pos := 10
+	for pos+4 <= len(data) {
+		flags := binary.BigEndian.Uint16(data[pos:])
+		out = append(out, binary.BigEndian.Uint16(data[pos+2:]))
+		pos += 4
+		if flags&argsAreWords != 0 {
+			pos += 4
+		} else {
+			pos += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			pos += 2
+		case flags&haveXYScale != 0:
+			pos += 4
+		case flags&haveTwoByTwo != 0:
+			pos += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return out
+}
+
+// subset 生成只保留已用字形轮廓的字体文件：字形编号保持不变（CIDToGIDMap 为 Identity），
+// 未使用的字形轮廓置空，复合字形引用的子字形一并保留。
+func (f *TrueTypeFont) subset(used map[uint16]rune) []byte {
+	keep := make(map[uint16]bool, len(used)+1)
+	queue := make([]uint16, 0, len(used)+1)
+	queue = append(queue, 0)
+	for gid := range used {
+		queue = append(queue, gid)
+	}
+	for len(queue) > 0 {
+		gid := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if keep[gid] {
+			continue
+		}
+		keep[gid] = true
+		for _, c := range compositeComponents(f.glyphData(gid)) {
+			if !keep[c] {
+				queue = append(queue, c)
+			}
+		}
+	}
+
+	var glyf bytes.Buffer
+	loca := make([]byte, 4*(f.numGlyphs+1))
+	for gid := 0; gid < f.numGlyphs; gid++ {
+		binary.BigEndian.PutUint32(loca[4*gid:], uint32(glyf.Len()))
+		if keep[uint16(gid)] {
+			glyf.Write(f.glyphData(uint16(gid)))
+			for glyf.Len()%4 != 0 {
+				glyf.WriteByte(0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(loca[4*f.numGlyphs:], uint32(glyf.Len()))
+
+	head := append([]byte(nil), f.tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0)
+	binary.BigEndian.PutUint16(head[50:], 1)
+
+	tables := map[string][]byte{
+		"head": head,
+		"hhea": f.tables["hhea"],
+		"maxp": f.tables["maxp"],
+		"hmtx": f.tables["hmtx"],
+		"loca": loca,
+		"glyf": glyf.Bytes(),
+	}
+	for _, tag := range []string{"cmap", "OS/2", "post", "cvt ", "fpgm", "prep"} {
+		if t, ok := f.tables[tag]; ok {
+			tables[tag] = t
+		}
+	}
+	out := writeSfnt(tables)
+	binary.BigEndian.PutUint32(out[sfntTableOffset(out, "head")+8:], 0xB1B0AFBA-sfntChecksum(out))
+	return out
+}
+
+// writeSfnt 按标签排序写出表目录与各表（4 字节对齐）
+func writeSfnt(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	n := len(tags)
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= n {
+		entrySelector++
+	}
+	searchRange := (1 << entrySelector) * 16
+
+	var buf bytes.Buffer
+	header := make([]byte, 12+16*n)
+	binary.BigEndian.PutUint32(header, 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(n))
+	binary.BigEndian.PutUint16(header[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(header[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(header[10:], uint16(n*16-searchRange))
+	offset := len(header)
+	for i, tag := range tags {
+		data := tables[tag]
+		rec := header[12+16*i:]
+		copy(rec, tag)
+		binary.BigEndian.PutUint32(rec[4:], sfntChecksum(data))
+		binary.BigEndian.PutUint32(rec[8:], uint32(offset))
+		binary.BigEndian.PutUint32(rec[12:], uint32(len(data)))
+		offset += (len(data) + 3) &^ 3
+	}
+	buf.Write(header)
+	for _, tag := range tags {
+		buf.Write(tables[tag])
+		for buf.Len()%4 != 0 {
+			buf.WriteByte(0)
+		}
+	}
+	return buf.Bytes()
+}
+
+func sfntTableOffset(font []byte, tag string) int {
+	n := int(binary.BigEndian.Uint16(font[4:]))
+	for i := 0; i < n; i++ {
+		rec := font[12+16*i:]
+		if string(rec[:4]) == tag {
+			return int(binary.BigEndian.Uint32(rec[8:]))
+		}
+	}
+	return 0
+}
+
+func sfntChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
@@ -0,0 +1,96 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pdf/pdftest"
+	pdfreader "github.com/ledongthuc/pdf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrueType(t *testing.T) {
+	f, err := ParseTrueType(pdftest.TrueTypeFont("AB月账"))
+	require.NoError(t, err)
+	require.Equal(t, "Sub2apiTestCJK", f.Name())
+	require.True(t, f.HasGlyph('账'))
+	require.False(t, f.HasGlyph('中'))
+	// 字形编号按字符排序后从 2 开始
+	require.EqualValues(t, 2, f.glyph('A'))
+	require.EqualValues(t, 5, f.glyph('账'))
+	require.EqualValues(t, 0, f.glyph('中'))
+	require.Equal(t, []uint16{1}, compositeComponents(f.glyphData(5)))
+
+	_, err = ParseTrueType([]byte("OTTO\x00\x00\x00\x00\x00\x00\x00\x00"))
+	require.ErrorContains(t, err, "CFF")
+	_, err = ParseTrueType([]byte("not a font"))
+	require.Error(t, err)
+}
+
+func TestTrueTypeTextEmbedsIdentityHSubset(t *testing.T) {
+	ttf, err := ParseTrueType(pdftest.TrueTypeFont("测试站点月度账单AB"))
+	require.NoError(t, err)
+	font := TrueType(ttf, false)
+	require.InDelta(t, 4*12*float64(pdftest.Advance)/pdftest.UnitsPerEm, font.TextWidth("测试站点", 12), 0.001)
+
+	doc := New()
+	doc.Title = "测试站点 statement"
+	page := doc.AddPage()
+	page.Text(40, 60, font, 12, "测试站点")
+	page.Text(40, 80, TrueType(ttf, true), 12, "月度账单")
+	page.Text(40, 100, Helvetica, 10, "Latin")
+
+	gids := ""
+	for _, r := range "测试站点" {
+		gids += fmt.Sprintf("%04X", ttf.glyph(r))
+	}
+	content := page.content.String()
+	require.Contains(t, content, "/F3 12.00 Tf 40.00 781.89 Td <"+gids+"> Tj")
+	require.Contains(t, content, "2 Tr 0.36 w /F3 12.00 Tf")
+	require.NotContains(t, content, "?")
+
+	out, err := doc.Bytes()
+	require.NoError(t, err)
+	requireXrefValid(t, out, 5+embeddedFontObjects+2)
+	s := string(out)
+	require.Contains(t, s, "/Subtype /Type0")
+	require.Contains(t, s, "/Encoding /Identity-H")
+	require.Contains(t, s, "/CIDToGIDMap /Identity")
+	require.Regexp(t, `/BaseFont /[A-Z]{6}\+Sub2apiTestCJK`, s)
+	require.Contains(t, s, "/F1 3 0 R /F2 4 0 R /F3 6 0 R")
+	require.Contains(t, s, "/Title <FEFF6D4B8BD57AD970B9002000730074006100740065006D0065006E0074>")
+	require.Contains(t, s, fmt.Sprintf("<%04X> <6D4B>", ttf.glyph('测')))
+	require.Contains(t, s, fmt.Sprintf("%d [800]", ttf.glyph('测')))
+
+	// 独立的 PDF 解析器通过 ToUnicode 还原文本
+	reader, err := pdfreader.NewReader(bytes.NewReader(out), int64(len(out)))
+	require.NoError(t, err)
+	text, err := reader.Page(1).GetPlainText(nil)
+	require.NoError(t, err)
+	require.Contains(t, text, "测试站点")
+	require.Contains(t, text, "月度账单")
+	require.Contains(t, text, "Latin")
+
+	// 解出 FontFile2，子集仍是合法字体：已用字形与其复合引用的基础轮廓保留，未用字形置空
+	m := regexp.MustCompile(`/Length (\d+) /Length1 (\d+) /Filter /FlateDecode >>\nstream\n`).FindSubmatchIndex(out)
+	require.NotNil(t, m)
+	n, err := strconv.Atoi(string(out[m[2]:m[3]]))
+	require.NoError(t, err)
+	zr, err := zlib.NewReader(bytes.NewReader(out[m[1] : m[1]+n]))
+	require.NoError(t, err)
+	raw, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	require.Equal(t, string(out[m[4]:m[5]]), strconv.Itoa(len(raw)))
+
+	sub, err := ParseTrueType(raw)
+	require.NoError(t, err)
+	require.NotEmpty(t, sub.glyphData(ttf.glyph('测')))
+	require.NotEmpty(t, sub.glyphData(ttf.glyph('单')))
+	require.NotEmpty(t, sub.glyphData(1))
+	require.Empty(t, sub.glyphData(ttf.glyph('A')))
+}
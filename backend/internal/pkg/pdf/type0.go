@@ -0,0 +1,133 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+)
+
+// embeddedFontObjects 每个嵌入字体占用的对象数：Type0、CIDFontType2、FontDescriptor、FontFile2、ToUnicode
+const embeddedFontObjects = 5
+
+// embeddedFont 文档中使用的 TrueType 字体及已用字形（字形编号 -> 字符，用于 ToUnicode）
+type embeddedFont struct {
+	ttf  *TrueTypeFont
+	name string
+	used map[uint16]rune
+}
+
+// embed 返回文档中该字体的资源，首次使用时注册
+func (d *Document) embed(f *TrueTypeFont) *embeddedFont {
+	for _, ef := range d.fonts {
+		if ef.ttf == f {
+			return ef
+		}
+	}
+	ef := &embeddedFont{ttf: f, name: "F" + strconv.Itoa(len(d.fonts)+3), used: map[uint16]rune{}}
+	d.fonts = append(d.fonts, ef)
+	return ef
+}
+
+func (ef *embeddedFont) sortedGlyphs() []uint16 {
+	gids := make([]uint16, 0, len(ef.used))
+	for gid := range ef.used {
+		gids = append(gids, gid)
+	}
+	sort.Slice(gids, func(i, j int) bool { return gids[i] < gids[j] })
+	return gids
+}
+
+// subsetName 子集字体名：6 位大写字母标签（由已用字形决定）+ "+" + PostScript 名称
+func (ef *embeddedFont) subsetName(gids []uint16) string {
+	h := fnv.New32a()
+	for _, gid := range gids {
+		h.Write([]byte{byte(gid >> 8), byte(gid)})
+	}
+	sum := h.Sum32()
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + byte(sum%26)
+		sum /= 26
+	}
+	return string(tag) + "+" + ef.ttf.name
+}
+
+// write 写出 Type0 字体及其依赖对象，对象编号从 id 开始连续分配
+func (ef *embeddedFont) write(buf *bytes.Buffer, id int, begin func(int), end func()) error {
+	f := ef.ttf
+	gids := ef.sortedGlyphs()
+	baseName := ef.subsetName(gids)
+	cidID, descID, fileID, cmapID := id+1, id+2, id+3, id+4
+
+	begin(id)
+	fmt.Fprintf(buf, "<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>\n",
+		baseName, cidID, cmapID)
+	end()
+
+	begin(cidID)
+	fmt.Fprintf(buf, "<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /DW 1000 /W [",
+		baseName, descID)
+	for _, gid := range gids {
+		fmt.Fprintf(buf, " %d [%d]", gid, f.width(gid))
+	}
+	buf.WriteString(" ] >>\n")
+	end()
+
+	begin(descID)
+	fmt.Fprintf(buf, "<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>\n",
+		baseName, f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]),
+		f.scale(f.ascent), f.scale(f.descent), f.scale(f.ascent), fileID)
+	end()
+
+	font := f.subset(ef.used)
+	data, err := deflate(font)
+	if err != nil {
+		return err
+	}
+	begin(fileID)
+	fmt.Fprintf(buf, "<< /Length %d /Length1 %d /Filter /FlateDecode >>\nstream\n", len(data), len(font))
+	buf.Write(data)
+	buf.WriteString("\nendstream\n")
+	end()
+
+	cmap := toUnicodeCMap(ef.used, gids)
+	begin(cmapID)
+	fmt.Fprintf(buf, "<< /Length %d >>\nstream\n", len(cmap))
+	buf.Write(cmap)
+	buf.WriteString("\nendstream\n")
+	end()
+	return nil
+}
+
+// toUnicodeCMap 字形编号到 Unicode 的映射，供查看器复制与搜索文本
+func toUnicodeCMap(used map[uint16]rune, gids []uint16) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	var mapped []uint16
+	for _, gid := range gids {
+		if gid != 0 {
+			mapped = append(mapped, gid)
+		}
+	}
+	// 每个 bfchar 段最多 100 条
+	for start := 0; start < len(mapped); start += 100 {
+		chunk := mapped[start:min(start+100, len(mapped))]
+		fmt.Fprintf(&buf, "%d beginbfchar\n", len(chunk))
+		for _, gid := range chunk {
+			fmt.Fprintf(&buf, "<%04X> <", gid)
+			for _, u := range utf16.Encode([]rune{used[gid]}) {
+				fmt.Fprintf(&buf, "%04X", u)
+			}
+			buf.WriteString(">\n")
+		}
+		buf.WriteString("endbfchar\n")
+	}
+	buf.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend")
+	return buf.Bytes()
+}
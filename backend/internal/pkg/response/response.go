@@ -45,6 +45,15 @@ func Created(c *gin.Context, data any) {
 	})
 }
 
+// Accepted 返回已受理响应（异步处理）
+func Accepted(c *gin.Context, data any) {
+	c.JSON(http.StatusAccepted, Response{
+		Code:    0,
+		Message: "success",
+		Data:    data,
+	})
+}
+
 // Error 返回错误响应
 func Error(c *gin.Context, statusCode int, message string) {
 	c.JSON(statusCode, Response{
@@ -0,0 +1,342 @@
+// Package secretbox 提供数据库敏感字段的信封加密（envelope encryption）。
+//
+// 每个进程为当前主密钥生成一个随机数据密钥（DEK），DEK 由主密钥通过 AES-256-GCM 包裹后
+// 与密文一起存储；字段值使用 DEK 通过 AES-256-GCM 加密。密文格式：
+//
+//	enc:v1:<key_id>:<base64(wrapped_dek)>:<base64(nonce||ciphertext)>
+//
+// key_id 标识包裹 DEK 的主密钥，主密钥轮换时旧密钥保留在密钥环中用于解密，
+// 后台重加密任务将旧密钥或明文数据逐步迁移到当前主密钥。
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// Prefix 是加密值的固定前缀
+	Prefix = "enc:v1:"
+	// KeySize 主密钥与数据密钥长度（AES-256）
+	KeySize = 32
+
+	maxDEKCacheSize = 256
+)
+
+var (
+	// ErrNoKeyring 遇到加密值但未配置密钥环
+	ErrNoKeyring = errors.New("secretbox: encrypted value found but no master key is configured")
+	// ErrUnknownKeyID 密文引用的主密钥不在密钥环中
+	ErrUnknownKeyID = errors.New("secretbox: unknown master key id")
+	// ErrMalformed 密文格式错误
+	ErrMalformed = errors.New("secretbox: malformed encrypted value")
+
+	keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+)
+
+// Keyring 持有全部主密钥（按 key_id）与当前用于加密的主密钥。
+// 零值不可用，使用 NewKeyring 构造；nil *Keyring 表示未启用加密，Encrypt/Decrypt 对明文直接透传。
+type Keyring struct {
+	keys     map[string][]byte
+	activeID string
+	// encrypt 为 false 时只解密不加密（用于关闭加密后把存量数据回写为明文）
+	encrypt bool
+
+	mu         sync.Mutex
+	dek        []byte
+	wrappedDEK string
+	dekCache   map[string][]byte
+}
+
+// NewKeyring 创建密钥环。activeID 为空且只有一个主密钥时使用该密钥；encrypt 控制新写入是否加密。
+func NewKeyring(keys map[string][]byte, activeID string, encrypt bool) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("secretbox: at least one master key is required")
+	}
+	copied := make(map[string][]byte, len(keys))
+	for id, key := range keys {
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("secretbox: invalid key id %q (1-32 chars of [A-Za-z0-9_-])", id)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("secretbox: master key %q must be %d bytes, got %d", id, KeySize, len(key))
+		}
+		copied[id] = append([]byte(nil), key...)
+	}
+	activeID = strings.TrimSpace(activeID)
+	if activeID == "" {
+		if len(copied) > 1 {
+			return nil, errors.New("secretbox: active key id is required when multiple master keys are configured")
+		}
+		for id := range copied {
+			activeID = id
+		}
+	}
+	if _, ok := copied[activeID]; !ok {
+		return nil, fmt.Errorf("secretbox: active key id %q not found in keyring", activeID)
+	}
+	return &Keyring{
+		keys:     copied,
+		activeID: activeID,
+		encrypt:  encrypt,
+		dekCache: make(map[string][]byte),
+	}, nil
+}
+
+// ParseKeys 解析 "kid:base64key" 列表，条目以逗号、空白或换行分隔，# 开头的行视为注释。
+func ParseKeys(spec string) (map[string][]byte, error) {
+	out := make(map[string][]byte)
+	var lines []string
+	for _, line := range strings.Split(spec, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	entries := strings.FieldsFunc(strings.Join(lines, ","), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\r'
+	})
+	for _, entry := range entries {
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("secretbox: key entry must be <key_id>:<base64>, got %q", maskEntry(entry))
+		}
+		id = strings.TrimSpace(id)
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("secretbox: key %q is not valid base64: %w", id, err)
+		}
+		if _, dup := out[id]; dup {
+			return nil, fmt.Errorf("secretbox: duplicate key id %q", id)
+		}
+		out[id] = key
+	}
+	return out, nil
+}
+
+// LoadKeyring 从配置字符串与密钥文件加载密钥环；两者都为空时返回 nil（未启用加密）。
+func LoadKeyring(keysSpec, keyFile, activeID string, encrypt bool) (*Keyring, error) {
+	spec := strings.TrimSpace(keysSpec)
+	if path := strings.TrimSpace(keyFile); path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("secretbox: read key file: %w", err)
+		}
+		spec = spec + "\n" + string(raw)
+	}
+	keys, err := ParseKeys(spec)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		if encrypt {
+			return nil, errors.New("secretbox: encryption is enabled but no master key is configured")
+		}
+		return nil, nil
+	}
+	return NewKeyring(keys, activeID, encrypt)
+}
+
+// IsEncrypted 判断值是否为 secretbox 密文。
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// KeyIDOf 返回密文的主密钥 ID；非密文返回空串。
+func KeyIDOf(value string) string {
+	if !IsEncrypted(value) {
+		return ""
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(value, Prefix), ":")
+	return id
+}
+
+// ActiveKeyID 返回当前用于加密的主密钥 ID。
+func (k *Keyring) ActiveKeyID() string {
+	if k == nil {
+		return ""
+	}
+	return k.activeID
+}
+
+// KeyIDs 返回密钥环中全部主密钥 ID（排序后）。
+func (k *Keyring) KeyIDs() []string {
+	if k == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Encrypting 返回新写入是否会被加密。
+func (k *Keyring) Encrypting() bool {
+	return k != nil && k.encrypt
+}
+
+// NeedsRotation 判断存储值是否需要重写：加密模式下为明文或非当前主密钥加密；只解密模式下为密文。
+func (k *Keyring) NeedsRotation(value string) bool {
+	if k == nil || value == "" {
+		return false
+	}
+	if !k.encrypt {
+		return IsEncrypted(value)
+	}
+	return !IsEncrypted(value) || KeyIDOf(value) != k.activeID
+}
+
+// Encrypt 加密明文；未启用加密、空串或已是密文时原样返回。
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if !k.Encrypting() || plaintext == "" || IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+	dek, wrapped, err := k.activeDEK()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(dek, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+	return Prefix + k.activeID + ":" + wrapped + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密密文；明文原样返回。
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if k == nil {
+		return "", ErrNoKeyring
+	}
+	parts := strings.Split(strings.TrimPrefix(value, Prefix), ":")
+	if len(parts) != 3 {
+		return "", ErrMalformed
+	}
+	dek, err := k.unwrapDEK(parts[0], parts[1])
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrMalformed
+	}
+	plaintext, err := open(dek, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("secretbox: decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// activeDEK 返回本进程当前主密钥下的数据密钥，首次调用时生成并包裹。
+func (k *Keyring) activeDEK() ([]byte, string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.dek != nil {
+		return k.dek, k.wrappedDEK, nil
+	}
+	dek := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return nil, "", err
+	}
+	wrapped, err := seal(k.keys[k.activeID], dek, []byte(k.activeID))
+	if err != nil {
+		return nil, "", err
+	}
+	k.dek = dek
+	k.wrappedDEK = base64.StdEncoding.EncodeToString(wrapped)
+	k.cacheDEKLocked(k.activeID+":"+k.wrappedDEK, dek)
+	return k.dek, k.wrappedDEK, nil
+}
+
+func (k *Keyring) unwrapDEK(keyID, wrapped string) ([]byte, error) {
+	cacheKey := keyID + ":" + wrapped
+	k.mu.Lock()
+	if dek, ok := k.dekCache[cacheKey]; ok {
+		k.mu.Unlock()
+		return dek, nil
+	}
+	k.mu.Unlock()
+
+	master, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKeyID, keyID)
+	}
+	raw, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	dek, err := open(master, raw, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("secretbox: unwrap data key %q: %w", keyID, err)
+	}
+	if len(dek) != KeySize {
+		return nil, ErrMalformed
+	}
+
+	k.mu.Lock()
+	k.cacheDEKLocked(cacheKey, dek)
+	k.mu.Unlock()
+	return dek, nil
+}
+
+// cacheDEKLocked 缓存已解包的 DEK；每个进程写入时只有一个 DEK，缓存超限时整体清空即可。
+func (k *Keyring) cacheDEKLocked(cacheKey string, dek []byte) {
+	if len(k.dekCache) >= maxDEKCacheSize {
+		k.dekCache = make(map[string][]byte)
+	}
+	k.dekCache[cacheKey] = dek
+}
+
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(key, sealed, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func maskEntry(entry string) string {
+	if len(entry) <= 4 {
+		return "****"
+	}
+	return entry[:4] + "****"
+}
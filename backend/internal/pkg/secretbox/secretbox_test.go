@@ -0,0 +1,148 @@
+package secretbox
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) []byte {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = b
+	}
+	return key
+}
+
+func TestKeyringEncryptDecryptRoundTrip(t *testing.T) {
+	k, err := NewKeyring(map[string][]byte{"k1": testKey(1)}, "", true)
+	require.NoError(t, err)
+	require.Equal(t, "k1", k.ActiveKeyID())
+
+	enc, err := k.Encrypt("sk-ant-secret")
+	require.NoError(t, err)
+	require.True(t, IsEncrypted(enc))
+	require.Equal(t, "k1", KeyIDOf(enc))
+	require.NotContains(t, enc, "sk-ant-secret")
+
+	again, err := k.Encrypt(enc)
+	require.NoError(t, err)
+	require.Equal(t, enc, again, "already encrypted values must not be double wrapped")
+
+	dec, err := k.Decrypt(enc)
+	require.NoError(t, err)
+	require.Equal(t, "sk-ant-secret", dec)
+
+	plain, err := k.Decrypt("plain-value")
+	require.NoError(t, err)
+	require.Equal(t, "plain-value", plain)
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldRing, err := NewKeyring(map[string][]byte{"k1": testKey(1)}, "k1", true)
+	require.NoError(t, err)
+	enc, err := oldRing.Encrypt("refresh-token")
+	require.NoError(t, err)
+
+	newRing, err := NewKeyring(map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, "k2", true)
+	require.NoError(t, err)
+	require.True(t, newRing.NeedsRotation(enc))
+	require.True(t, newRing.NeedsRotation("plain"))
+	require.False(t, newRing.NeedsRotation(""))
+
+	dec, err := newRing.Decrypt(enc)
+	require.NoError(t, err)
+	rotated, err := newRing.Encrypt(dec)
+	require.NoError(t, err)
+	require.Equal(t, "k2", KeyIDOf(rotated))
+	require.False(t, newRing.NeedsRotation(rotated))
+
+	_, err = oldRing.Decrypt(rotated)
+	require.ErrorIs(t, err, ErrUnknownKeyID)
+}
+
+func TestKeyringDecryptOnlyMode(t *testing.T) {
+	writer, err := NewKeyring(map[string][]byte{"k1": testKey(1)}, "k1", true)
+	require.NoError(t, err)
+	enc, err := writer.Encrypt("password")
+	require.NoError(t, err)
+
+	reader, err := NewKeyring(map[string][]byte{"k1": testKey(1)}, "k1", false)
+	require.NoError(t, err)
+	require.False(t, reader.Encrypting())
+	require.True(t, reader.NeedsRotation(enc))
+	require.False(t, reader.NeedsRotation("password"))
+
+	out, err := reader.Encrypt("password")
+	require.NoError(t, err)
+	require.Equal(t, "password", out)
+}
+
+func TestNilKeyring(t *testing.T) {
+	var k *Keyring
+	out, err := k.Encrypt("value")
+	require.NoError(t, err)
+	require.Equal(t, "value", out)
+
+	_, err = k.Decrypt(Prefix + "k1:AAAA:BBBB")
+	require.ErrorIs(t, err, ErrNoKeyring)
+}
+
+func TestKeyringRejectsTamperedCiphertext(t *testing.T) {
+	k, err := NewKeyring(map[string][]byte{"k1": testKey(1)}, "", true)
+	require.NoError(t, err)
+	enc, err := k.Encrypt("value")
+	require.NoError(t, err)
+
+	parts := strings.Split(strings.TrimPrefix(enc, Prefix), ":")
+	raw, err := base64.StdEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	raw[len(raw)-1] ^= 0xff
+	tampered := Prefix + parts[0] + ":" + parts[1] + ":" + base64.StdEncoding.EncodeToString(raw)
+
+	_, err = k.Decrypt(tampered)
+	require.Error(t, err)
+
+	_, err = k.Decrypt(Prefix + "k1:only-two")
+	require.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestNewKeyringValidation(t *testing.T) {
+	_, err := NewKeyring(map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, "", true)
+	require.Error(t, err)
+
+	_, err = NewKeyring(map[string][]byte{"k1": []byte("short")}, "", true)
+	require.Error(t, err)
+
+	_, err = NewKeyring(map[string][]byte{"bad:id": testKey(1)}, "", true)
+	require.Error(t, err)
+
+	_, err = NewKeyring(map[string][]byte{"k1": testKey(1)}, "k9", true)
+	require.Error(t, err)
+}
+
+func TestLoadKeyringFromFileAndSpec(t *testing.T) {
+	k1 := base64.StdEncoding.EncodeToString(testKey(1))
+	k2 := base64.StdEncoding.EncodeToString(testKey(2))
+	path := filepath.Join(t.TempDir(), "master.keys")
+	require.NoError(t, os.WriteFile(path, []byte("# rotated 2026-10\nk2:"+k2+"\n"), 0o600))
+
+	k, err := LoadKeyring("k1:"+k1, path, "k2", true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"k1", "k2"}, k.KeyIDs())
+	require.Equal(t, "k2", k.ActiveKeyID())
+
+	none, err := LoadKeyring("", "", "", false)
+	require.NoError(t, err)
+	require.Nil(t, none)
+
+	_, err = LoadKeyring("", "", "", true)
+	require.Error(t, err)
+
+	_, err = LoadKeyring("k1:"+k1+",k1:"+k2, "", "", true)
+	require.Error(t, err)
+}
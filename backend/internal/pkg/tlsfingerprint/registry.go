@@ -64,6 +64,18 @@ func NewRegistryFromConfig(cfg *config.TLSFingerprintConfig) *Registry {
 	return r
 }
 
+// ApplyConfig replaces all profiles with the built-in default plus the profiles from config.
+// It is used when the config file is reloaded; pooled upstream clients are keyed on the
+// profile they were built with, so the next request rebuilds them with the new profile.
+func (r *Registry) ApplyConfig(cfg *config.TLSFingerprintConfig) {
+	next := NewRegistryFromConfig(cfg)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.profiles = next.profiles
+	r.profileNames = next.profileNames
+}
+
 // registerBuiltinProfile adds the default Claude CLI profile to the registry.
 func (r *Registry) registerBuiltinProfile() {
 	defaultProfile := &Profile{
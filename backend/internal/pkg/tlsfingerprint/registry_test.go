@@ -176,6 +176,33 @@ func TestNewRegistryFromConfig(t *testing.T) {
 	}
 }
 
+func TestApplyConfig(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterProfile("stale", &Profile{Name: "Stale"})
+
+	r.ApplyConfig(&config.TLSFingerprintConfig{
+		Enabled: true,
+		Profiles: map[string]config.TLSProfileConfig{
+			"fresh": {Name: "Fresh"},
+		},
+	})
+	if r.GetProfile("stale") != nil {
+		t.Error("expected stale profile to be removed")
+	}
+	if p := r.GetProfile("fresh"); p == nil || p.Name != "Fresh" {
+		t.Error("expected fresh profile to exist with correct name")
+	}
+	if r.ProfileCount() != 2 {
+		t.Errorf("expected 2 profiles, got %d", r.ProfileCount())
+	}
+
+	// Disabling falls back to the built-in default only
+	r.ApplyConfig(&config.TLSFingerprintConfig{Enabled: false})
+	if names := r.ProfileNames(); len(names) != 1 || names[0] != DefaultProfileName {
+		t.Errorf("expected profile names to be [%s], got %v", DefaultProfileName, names)
+	}
+}
+
 func TestProfileNames(t *testing.T) {
 	r := NewRegistry()
 
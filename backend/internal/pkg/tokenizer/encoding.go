@@ -0,0 +1,264 @@
+// Package tokenizer provides in-process token counting for Claude, OpenAI and Gemini models.
+//
+// OpenAI 模型使用 tiktoken cl100k_base / o200k_base 词表（随二进制嵌入，不在运行时下载）精确计数，结果与 tiktoken 一致。
+// Claude 与 Gemini 没有公开词表，只能估算：Claude 按 tiktoken 的预分词规则切分文本后按统计参数估算每个片段，
+// Gemini 按字符数估算。估算结果用于 count_tokens 回退与费用估算，不保证与上游完全一致。
+package tokenizer
+
+import (
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+func init() {
+	// 使用随二进制嵌入的 BPE 词表，避免运行时从 OpenAI 下载
+	tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+}
+
+// Encoding 文本 token 计数器
+type Encoding interface {
+	Name() string
+	Count(text string) int
+}
+
+var (
+	// CL100K tiktoken cl100k_base（GPT-4、GPT-3.5），精确计数
+	CL100K Encoding = &tiktokenEncoding{name: "cl100k_base", fallback: cl100kEstimate}
+	// O200K tiktoken o200k_base（GPT-4o、o 系列、GPT-5），精确计数
+	O200K Encoding = &tiktokenEncoding{name: "o200k_base", fallback: o200kEstimate}
+	// Claude Claude 系列模型的估算（无公开词表；词表约 65k，英文略多于 cl100k，中日韩字符约 1.2 token/字）
+	Claude Encoding = &estimatedEncoding{
+		name:                  "claude",
+		maxWordRunes:          7,
+		longWordRunesPerToken: 4.5,
+		cjkTokensPerRune:      1.2,
+		digitsPerToken:        3,
+		punctRunesPerToken:    2,
+	}
+	// Gemini Gemini 系列模型的字符估算（无公开词表；英文约 4 字符/token，中日韩约 1 token/字）
+	Gemini Encoding = geminiEncoding{}
+)
+
+// cl100kEstimate / o200kEstimate 词表加载失败时的估算回退
+var (
+	cl100kEstimate = &estimatedEncoding{
+		name:                  "cl100k_base",
+		maxWordRunes:          8,
+		longWordRunesPerToken: 5,
+		cjkTokensPerRune:      1.0,
+		digitsPerToken:        3,
+		punctRunesPerToken:    2,
+	}
+	o200kEstimate = &estimatedEncoding{
+		name:                  "o200k_base",
+		maxWordRunes:          9,
+		longWordRunesPerToken: 5.5,
+		cjkTokensPerRune:      0.75,
+		digitsPerToken:        3,
+		punctRunesPerToken:    2,
+	}
+)
+
+// ForModel 按模型名选择编码，未知模型按 Claude 估算
+func ForModel(model string) Encoding {
+	m := strings.ToLower(strings.TrimSpace(model))
+	m = strings.TrimPrefix(m, "models/")
+	switch {
+	case strings.Contains(m, "claude"):
+		return Claude
+	case strings.HasPrefix(m, "gemini") || strings.HasPrefix(m, "gemma"):
+		return Gemini
+	case strings.HasPrefix(m, "gpt-4o"), strings.HasPrefix(m, "gpt-4.1"), strings.HasPrefix(m, "gpt-4.5"),
+		strings.HasPrefix(m, "gpt-5"), strings.HasPrefix(m, "chatgpt-"), strings.HasPrefix(m, "codex"),
+		strings.HasPrefix(m, "o1"), strings.HasPrefix(m, "o3"), strings.HasPrefix(m, "o4"):
+		return O200K
+	case strings.HasPrefix(m, "gpt-4"), strings.HasPrefix(m, "gpt-3.5"), strings.HasPrefix(m, "text-embedding-"):
+		return CL100K
+	default:
+		return Claude
+	}
+}
+
+// tiktokenEncoding 基于 tiktoken BPE 词表的精确计数，词表在首次使用时加载
+type tiktokenEncoding struct {
+	name     string
+	fallback Encoding
+
+	once sync.Once
+	tk   *tiktoken.Tiktoken
+}
+
+func (e *tiktokenEncoding) Name() string {
+	return e.name
+}
+
+// Count 按普通文本编码（文本中的 <|endoftext|> 等特殊 token 字面量按普通字符计数）
+func (e *tiktokenEncoding) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	tk := e.load()
+	if tk == nil {
+		return e.fallback.Count(text)
+	}
+	return len(tk.EncodeOrdinary(text))
+}
+
+func (e *tiktokenEncoding) load() *tiktoken.Tiktoken {
+	e.once.Do(func() {
+		tk, err := tiktoken.GetEncoding(e.name)
+		if err != nil {
+			log.Printf("[Tokenizer] load %s ranks failed, falling back to estimation: %v", e.name, err)
+			return
+		}
+		e.tk = tk
+	})
+	return e.tk
+}
+
+// estimatedEncoding 无词表的 BPE 估算：按 tiktoken 预分词规则切分，再按统计参数估算每个片段的 token 数
+type estimatedEncoding struct {
+	name string
+	// maxWordRunes 不超过该长度的单词计为 1 个 token（常见词在词表中通常是整词）
+	maxWordRunes int
+	// longWordRunesPerToken 长单词平均每个 token 的字符数
+	longWordRunesPerToken float64
+	// cjkTokensPerRune 中日韩字符平均每个字符的 token 数
+	cjkTokensPerRune float64
+	// digitsPerToken 数字按该长度分组
+	digitsPerToken int
+	// punctRunesPerToken 连续标点平均每个 token 的字符数
+	punctRunesPerToken int
+}
+
+func (e *estimatedEncoding) Name() string {
+	return e.name
+}
+
+// Count 按 tiktoken 预分词规则切分后累加各片段的估算值：
+// 单个空格并入后续单词或标点，数字不吸收前导空格，连续换行计为 1 个 token。
+func (e *estimatedEncoding) Count(text string) int {
+	runes := []rune(text)
+	total := 0
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		j := i + 1
+		switch {
+		case isWordRune(r):
+			for j < len(runes) && isWordRune(runes[j]) {
+				j++
+			}
+			total += e.countWord(runes[i:j])
+		case unicode.IsNumber(r):
+			for j < len(runes) && unicode.IsNumber(runes[j]) {
+				j++
+			}
+			total += ceilDiv(j-i, e.digitsPerToken)
+		case unicode.IsSpace(r):
+			newline := r == '\n' || r == '\r'
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				newline = newline || runes[j] == '\n' || runes[j] == '\r'
+				j++
+			}
+			n := j - i
+			if !newline && j < len(runes) && runes[j-1] == ' ' && !unicode.IsNumber(runes[j]) {
+				n--
+			}
+			if n > 0 {
+				total++
+			}
+		default:
+			for j < len(runes) && !isWordRune(runes[j]) && !unicode.IsNumber(runes[j]) && !unicode.IsSpace(runes[j]) {
+				j++
+			}
+			total += ceilDiv(j-i, e.punctRunesPerToken)
+		}
+		i = j
+	}
+	return total
+}
+
+// countWord 单词片段：中日韩字符按字计数，其余字母按长度估算（非 ASCII 字母按 2 个字符计）
+func (e *estimatedEncoding) countWord(word []rune) int {
+	total := 0
+	cjk, latin := 0, 0
+	flush := func() {
+		if cjk > 0 {
+			total += int(math.Ceil(float64(cjk) * e.cjkTokensPerRune))
+			cjk = 0
+		}
+		if latin > 0 {
+			if latin <= e.maxWordRunes {
+				total++
+			} else {
+				total += int(math.Ceil(float64(latin) / e.longWordRunesPerToken))
+			}
+			latin = 0
+		}
+	}
+	for _, r := range word {
+		if isCJK(r) {
+			if latin > 0 {
+				flush()
+			}
+			cjk++
+			continue
+		}
+		if cjk > 0 {
+			flush()
+		}
+		if r > unicode.MaxASCII {
+			latin += 2
+		} else {
+			latin++
+		}
+	}
+	flush()
+	return total
+}
+
+// geminiEncoding Gemini 字符启发式：ASCII 占比不低于 80% 时按 4 字符/token，否则按 1 字符/token
+type geminiEncoding struct{}
+
+func (geminiEncoding) Name() string {
+	return "gemini"
+}
+
+func (geminiEncoding) Count(text string) int {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0
+	}
+	runes := []rune(text)
+	ascii := 0
+	for _, r := range runes {
+		if r <= unicode.MaxASCII {
+			ascii++
+		}
+	}
+	if float64(ascii)/float64(len(runes)) >= 0.8 {
+		return (len(runes) + 3) / 4
+	}
+	return len(runes)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r)
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func ceilDiv(a, b int) int {
+	if b <= 0 {
+		return a
+	}
+	return (a + b - 1) / b
+}
@@ -0,0 +1,95 @@
+package tokenizer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	_ "image/gif"  // register GIF for DecodeConfig
+	_ "image/jpeg" // register JPEG for DecodeConfig
+	_ "image/png"  // register PNG for DecodeConfig
+	"io"
+	"math"
+	"strings"
+)
+
+const (
+	// claudeImageMaxEdge / claudeImageMaxPixels Claude 会先把超过该尺寸的图片等比缩小
+	claudeImageMaxEdge   = 1568
+	claudeImageMaxPixels = 1_150_000
+	// claudeImageMaxTokens 无法获取尺寸时按单张图片上限估算
+	claudeImageMaxTokens = 1600
+
+	// geminiImageTokens Gemini 每张小图（或每个 768x768 图块）计 258 token
+	geminiImageTokens    = 258
+	geminiImageSmallEdge = 384
+	geminiImageTileEdge  = 768
+)
+
+// ClaudeImageTokens 按 Claude 文档公式 tokens = width*height/750 估算（先按尺寸上限缩放）
+func ClaudeImageTokens(width, height int) int {
+	if width <= 0 || height <= 0 {
+		return claudeImageMaxTokens
+	}
+	w, h := float64(width), float64(height)
+	if edge := math.Max(w, h); edge > claudeImageMaxEdge {
+		scale := claudeImageMaxEdge / edge
+		w, h = w*scale, h*scale
+	}
+	if pixels := w * h; pixels > claudeImageMaxPixels {
+		scale := math.Sqrt(claudeImageMaxPixels / pixels)
+		w, h = w*scale, h*scale
+	}
+	return int(math.Ceil(w * h / 750))
+}
+
+// GeminiImageTokens 两边均不超过 384px 的图片计 258 token，否则按 768x768 图块每块 258 token
+func GeminiImageTokens(width, height int) int {
+	if width <= 0 || height <= 0 {
+		return geminiImageTokens
+	}
+	if width <= geminiImageSmallEdge && height <= geminiImageSmallEdge {
+		return geminiImageTokens
+	}
+	return ceilDiv(width, geminiImageTileEdge) * ceilDiv(height, geminiImageTileEdge) * geminiImageTokens
+}
+
+// Base64ImageSize 从 base64 图片数据中读取宽高（支持 PNG/JPEG/GIF/WebP，只解码文件头）
+func Base64ImageSize(data string) (width, height int, ok bool) {
+	if i := strings.Index(data, ";base64,"); i >= 0 && strings.HasPrefix(data, "data:") {
+		data = data[i+len(";base64,"):]
+	}
+	decoder := base64.NewDecoder(base64.StdEncoding, strings.NewReader(data))
+	header := make([]byte, 30)
+	n, _ := io.ReadFull(decoder, header)
+	header = header[:n]
+	if w, h, ok := webpSize(header); ok {
+		return w, h, true
+	}
+	cfg, _, err := image.DecodeConfig(io.MultiReader(bytes.NewReader(header), decoder))
+	if err != nil {
+		return 0, 0, false
+	}
+	return cfg.Width, cfg.Height, true
+}
+
+// webpSize 解析 WebP 文件头中的尺寸（VP8 / VP8L / VP8X）
+func webpSize(b []byte) (int, int, bool) {
+	if len(b) < 30 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WEBP" {
+		return 0, 0, false
+	}
+	switch string(b[12:16]) {
+	case "VP8 ":
+		w := int(binary.LittleEndian.Uint16(b[26:28]) & 0x3fff)
+		h := int(binary.LittleEndian.Uint16(b[28:30]) & 0x3fff)
+		return w, h, true
+	case "VP8L":
+		bits := binary.LittleEndian.Uint32(b[21:25])
+		return int(bits&0x3fff) + 1, int((bits>>14)&0x3fff) + 1, true
+	case "VP8X":
+		w := int(b[24]) | int(b[25])<<8 | int(b[26])<<16
+		h := int(b[27]) | int(b[28])<<8 | int(b[29])<<16
+		return w + 1, h + 1, true
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,233 @@
+package tokenizer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// claudeRequestOverhead / claudeMessageOverhead 请求与每条消息的格式开销
+	claudeRequestOverhead = 3
+	claudeMessageOverhead = 3
+	claudeBlockOverhead   = 3
+	// claudeToolsSystemTokens 提供 tools 时 Claude 注入的工具系统提示（tool_choice 为 auto/none 时 346，any/tool 时 313）
+	claudeToolsSystemTokens       = 346
+	claudeToolsSystemTokensForced = 313
+	// pdfPageTokens PDF 每页按文本加页面图片约 2000 token 估算
+	pdfPageTokens = 2000
+)
+
+// ErrInvalidRequest 请求体不是合法的消息请求
+var ErrInvalidRequest = errors.New("tokenizer: invalid request body")
+
+var pdfPagePattern = regexp.MustCompile(`/Type\s*/Page[^s]`)
+
+// Count 请求的输入 token 估算结果
+type Count struct {
+	InputTokens int
+	// Rough 为 true 表示请求包含无法可靠估算的内容（URL/文件引用、PDF、未知内容块等）
+	Rough bool
+}
+
+type counter struct {
+	enc    Encoding
+	tokens int
+	rough  bool
+}
+
+// CountMessages 估算 Anthropic Messages 请求（system、messages、tools）的输入 token 数
+//
+// 与 count_tokens 一致，历史 assistant 轮次中的 thinking 块不计入输入。
+func CountMessages(enc Encoding, body []byte) (Count, error) {
+	if !gjson.ValidBytes(body) {
+		return Count{}, ErrInvalidRequest
+	}
+	root := gjson.ParseBytes(body)
+	messages := root.Get("messages")
+	if !messages.IsArray() {
+		return Count{}, ErrInvalidRequest
+	}
+
+	c := &counter{enc: enc, tokens: claudeRequestOverhead}
+	c.claudeContent(root.Get("system"))
+	for _, msg := range messages.Array() {
+		c.tokens += claudeMessageOverhead
+		c.claudeContent(msg.Get("content"))
+	}
+
+	tools := root.Get("tools").Array()
+	if len(tools) > 0 {
+		switch root.Get("tool_choice.type").String() {
+		case "any", "tool":
+			c.tokens += claudeToolsSystemTokensForced
+		default:
+			c.tokens += claudeToolsSystemTokens
+		}
+		for _, tool := range tools {
+			c.claudeTool(tool)
+		}
+	}
+	return Count{InputTokens: c.tokens, Rough: c.rough}, nil
+}
+
+// CountGeminiRequest 估算 Gemini generateContent / countTokens 请求的输入 token 数
+func CountGeminiRequest(enc Encoding, body []byte) (Count, error) {
+	if !gjson.ValidBytes(body) {
+		return Count{}, ErrInvalidRequest
+	}
+	root := gjson.ParseBytes(body)
+	if req := root.Get("generateContentRequest"); req.IsObject() {
+		root = req
+	}
+
+	c := &counter{enc: enc}
+	c.geminiParts(root.Get("systemInstruction.parts"))
+	for _, content := range root.Get("contents").Array() {
+		c.geminiParts(content.Get("parts"))
+	}
+	for _, tool := range root.Get("tools").Array() {
+		for _, decl := range tool.Get("functionDeclarations").Array() {
+			c.jsonText(decl.Raw)
+		}
+	}
+	return Count{InputTokens: c.tokens, Rough: c.rough}, nil
+}
+
+func (c *counter) text(s string) {
+	c.tokens += c.enc.Count(s)
+}
+
+// jsonText 按紧凑格式计数 JSON 片段（工具定义与参数以紧凑 JSON 注入提示）
+func (c *counter) jsonText(raw string) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(raw)); err != nil {
+		c.text(raw)
+		return
+	}
+	c.text(buf.String())
+}
+
+// claudeContent content 可以是字符串或内容块数组
+func (c *counter) claudeContent(content gjson.Result) {
+	switch {
+	case content.Type == gjson.String:
+		c.text(content.Str)
+	case content.IsArray():
+		for _, block := range content.Array() {
+			c.claudeBlock(block)
+		}
+	}
+}
+
+func (c *counter) claudeBlock(block gjson.Result) {
+	switch block.Get("type").String() {
+	case "text":
+		c.text(block.Get("text").String())
+	case "image":
+		c.claudeImage(block.Get("source"))
+	case "document":
+		c.text(block.Get("title").String())
+		c.text(block.Get("context").String())
+		c.claudeDocument(block.Get("source"))
+	case "tool_use", "server_tool_use":
+		c.tokens += claudeBlockOverhead
+		c.text(block.Get("name").String())
+		c.jsonText(block.Get("input").Raw)
+	case "tool_result":
+		c.tokens += claudeBlockOverhead
+		c.claudeContent(block.Get("content"))
+	case "thinking", "redacted_thinking":
+		// 历史轮次的 thinking 块不计入输入
+	default:
+		c.jsonText(block.Raw)
+		c.rough = true
+	}
+}
+
+func (c *counter) claudeImage(source gjson.Result) {
+	if source.Get("type").String() == "base64" {
+		if w, h, ok := Base64ImageSize(source.Get("data").String()); ok {
+			c.tokens += ClaudeImageTokens(w, h)
+			return
+		}
+	}
+	c.tokens += claudeImageMaxTokens
+	c.rough = true
+}
+
+func (c *counter) claudeDocument(source gjson.Result) {
+	switch source.Get("type").String() {
+	case "text":
+		c.text(source.Get("data").String())
+	case "content":
+		c.claudeContent(source.Get("content"))
+	case "base64":
+		c.tokens += pdfPages(source.Get("data").String()) * pdfPageTokens
+		c.rough = true
+	default:
+		c.tokens += pdfPageTokens
+		c.rough = true
+	}
+}
+
+func (c *counter) claudeTool(tool gjson.Result) {
+	c.tokens += claudeBlockOverhead
+	schema := tool.Get("input_schema")
+	if !schema.Exists() {
+		// 服务端工具（web_search 等）的提示由上游注入，无法精确估算
+		c.jsonText(tool.Raw)
+		c.rough = true
+		return
+	}
+	c.text(tool.Get("name").String())
+	c.text(tool.Get("description").String())
+	c.jsonText(schema.Raw)
+}
+
+func (c *counter) geminiParts(parts gjson.Result) {
+	for _, part := range parts.Array() {
+		switch {
+		case part.Get("text").Exists():
+			c.text(part.Get("text").String())
+		case part.Get("inlineData").Exists():
+			inline := part.Get("inlineData")
+			if strings.HasPrefix(inline.Get("mimeType").String(), "image/") {
+				if w, h, ok := Base64ImageSize(inline.Get("data").String()); ok {
+					c.tokens += GeminiImageTokens(w, h)
+					continue
+				}
+			}
+			c.tokens += geminiImageTokens
+			c.rough = true
+		case part.Get("fileData").Exists():
+			c.tokens += geminiImageTokens
+			c.rough = true
+		case part.Get("functionCall").Exists():
+			c.jsonText(part.Get("functionCall").Raw)
+		case part.Get("functionResponse").Exists():
+			c.jsonText(part.Get("functionResponse").Raw)
+		case part.Get("executableCode").Exists():
+			c.text(part.Get("executableCode.code").String())
+		case part.Get("codeExecutionResult").Exists():
+			c.text(part.Get("codeExecutionResult.output").String())
+		}
+	}
+}
+
+// pdfPages 统计 base64 PDF 的页数（至少 1 页）
+func pdfPages(data string) int {
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return 1
+	}
+	if pages := len(pdfPagePattern.FindAllIndex(raw, -1)); pages > 0 {
+		return pages
+	}
+	return 1
+}
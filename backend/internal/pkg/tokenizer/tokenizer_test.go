@@ -0,0 +1,158 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// estimateTolerance Claude/Gemini 及词表加载失败回退等估算结果允许的相对误差（小样本另允许 ±2 token）；
+// tiktoken 编码必须与录制样本完全一致
+const estimateTolerance = 0.2
+
+type fixtures struct {
+	Text []struct {
+		Name     string `json:"name"`
+		Encoding string `json:"encoding"`
+		Text     string `json:"text"`
+		Tokens   int    `json:"tokens"`
+	} `json:"text"`
+	Messages []struct {
+		Name        string          `json:"name"`
+		Model       string          `json:"model"`
+		Request     json.RawMessage `json:"request"`
+		InputTokens int             `json:"input_tokens"`
+	} `json:"messages"`
+}
+
+func loadFixtures(t *testing.T) fixtures {
+	t.Helper()
+	raw, err := os.ReadFile("testdata/fixtures.json")
+	require.NoError(t, err)
+	var f fixtures
+	require.NoError(t, json.Unmarshal(raw, &f))
+	return f
+}
+
+func requireClose(t *testing.T, want, got int) {
+	t.Helper()
+	delta := math.Max(2, float64(want)*estimateTolerance)
+	require.InDelta(t, want, got, delta, "want %d got %d", want, got)
+}
+
+func TestTiktokenEncodingMatchesFixtures(t *testing.T) {
+	encodings := map[string]Encoding{
+		CL100K.Name(): CL100K,
+		O200K.Name():  O200K,
+	}
+	for _, fx := range loadFixtures(t).Text {
+		t.Run(fx.Encoding+"/"+fx.Name, func(t *testing.T) {
+			enc, ok := encodings[fx.Encoding]
+			require.True(t, ok, "unknown encoding %s", fx.Encoding)
+			require.Equal(t, fx.Tokens, enc.Count(fx.Text))
+		})
+	}
+}
+
+func TestFallbackEstimateCloseToFixtures(t *testing.T) {
+	encodings := map[string]Encoding{
+		cl100kEstimate.Name(): cl100kEstimate,
+		o200kEstimate.Name():  o200kEstimate,
+	}
+	for _, fx := range loadFixtures(t).Text {
+		t.Run(fx.Encoding+"/"+fx.Name, func(t *testing.T) {
+			requireClose(t, fx.Tokens, encodings[fx.Encoding].Count(fx.Text))
+		})
+	}
+}
+
+func TestTiktokenEncodingSpecialTokensAsText(t *testing.T) {
+	require.Equal(t, 0, CL100K.Count(""))
+	require.Equal(t, 7, CL100K.Count("<|endoftext|>"))
+	require.Equal(t, 7, O200K.Count("<|endoftext|>"))
+}
+
+// TestCountMessagesAccuracyAgainstFixtures Claude 无公开词表，只校验估算误差
+func TestCountMessagesAccuracyAgainstFixtures(t *testing.T) {
+	for _, fx := range loadFixtures(t).Messages {
+		t.Run(fx.Name, func(t *testing.T) {
+			count, err := CountMessages(ForModel(fx.Model), fx.Request)
+			require.NoError(t, err)
+			require.False(t, count.Rough)
+			requireClose(t, fx.InputTokens, count.InputTokens)
+		})
+	}
+}
+
+func TestForModel(t *testing.T) {
+	require.Equal(t, Claude, ForModel("claude-opus-4-1"))
+	require.Equal(t, Gemini, ForModel("models/gemini-2.5-pro"))
+	require.Equal(t, O200K, ForModel("gpt-4o-mini"))
+	require.Equal(t, O200K, ForModel("gpt-5.1-codex"))
+	require.Equal(t, CL100K, ForModel("gpt-4-turbo"))
+	require.Equal(t, Claude, ForModel("unknown-model"))
+}
+
+func TestCountMessagesIgnoresThinkingAndRoughForURLImages(t *testing.T) {
+	body := []byte(`{"messages":[
+		{"role":"user","content":"hi"},
+		{"role":"assistant","content":[{"type":"thinking","thinking":"` + strings.Repeat("long reasoning ", 200) + `"},{"type":"text","text":"hello"}]}
+	]}`)
+	count, err := CountMessages(Claude, body)
+	require.NoError(t, err)
+	require.False(t, count.Rough)
+	require.Less(t, count.InputTokens, 20)
+
+	body = []byte(`{"messages":[{"role":"user","content":[{"type":"image","source":{"type":"url","url":"https://example.com/a.png"}}]}]}`)
+	count, err = CountMessages(Claude, body)
+	require.NoError(t, err)
+	require.True(t, count.Rough)
+	require.GreaterOrEqual(t, count.InputTokens, claudeImageMaxTokens)
+
+	_, err = CountMessages(Claude, []byte(`{"messages":"nope"}`))
+	require.ErrorIs(t, err, ErrInvalidRequest)
+}
+
+func TestImageTokens(t *testing.T) {
+	// 1x1 PNG
+	png := "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk+M9QDwADhgGAWjR9awAAAABJRU5ErkJggg=="
+	w, h, ok := Base64ImageSize(png)
+	require.True(t, ok)
+	require.Equal(t, 1, w)
+	require.Equal(t, 1, h)
+	_, _, ok = Base64ImageSize("data:image/png;base64," + png)
+	require.True(t, ok)
+
+	// VP8X WebP header, 1000x500
+	webp := make([]byte, 30)
+	copy(webp, "RIFF\x00\x00\x00\x00WEBPVP8X")
+	webp[24], webp[25] = 0xe7, 0x03 // 999
+	webp[27], webp[28] = 0xf3, 0x01 // 499
+	w, h, ok = Base64ImageSize(base64.StdEncoding.EncodeToString(webp))
+	require.True(t, ok)
+	require.Equal(t, 1000, w)
+	require.Equal(t, 500, h)
+
+	require.Equal(t, 1334, ClaudeImageTokens(1000, 1000))
+	require.LessOrEqual(t, ClaudeImageTokens(4000, 3000), claudeImageMaxTokens)
+	require.Equal(t, 258, GeminiImageTokens(300, 300))
+	require.Equal(t, 4*258, GeminiImageTokens(1024, 1024))
+}
+
+func TestCountGeminiRequest(t *testing.T) {
+	body := []byte(`{"systemInstruction":{"parts":[{"text":"be brief"}]},"contents":[{"role":"user","parts":[{"text":"hello world"},{"fileData":{"fileUri":"gs://x"}}]}]}`)
+	count, err := CountGeminiRequest(Gemini, body)
+	require.NoError(t, err)
+	require.True(t, count.Rough)
+	require.Equal(t, 2+3+geminiImageTokens, count.InputTokens)
+
+	wrapped := []byte(`{"generateContentRequest":{"contents":[{"parts":[{"text":"你好世界"}]}]}}`)
+	count, err = CountGeminiRequest(Gemini, wrapped)
+	require.NoError(t, err)
+	require.Equal(t, 4, count.InputTokens)
+}
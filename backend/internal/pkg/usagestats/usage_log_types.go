@@ -134,15 +134,16 @@ type UserDashboardStats struct {
 
 // UsageLogFilters represents filters for usage log queries
 type UsageLogFilters struct {
-	UserID      int64
-	APIKeyID    int64
-	AccountID   int64
-	GroupID     int64
-	Model       string
-	Stream      *bool
-	BillingType *int8
-	StartTime   *time.Time
-	EndTime     *time.Time
+	UserID         int64
+	APIKeyID       int64
+	AccountID      int64
+	GroupID        int64
+	OrganizationID int64
+	Model          string
+	Stream         *bool
+	BillingType    *int8
+	StartTime      *time.Time
+	EndTime        *time.Time
 }
 
 // UsageStats represents usage statistics
@@ -156,6 +157,12 @@ type UsageStats struct {
 	TotalActualCost   float64  `json:"total_actual_cost"`
 	TotalAccountCost  *float64 `json:"total_account_cost,omitempty"`
 	AverageDurationMs float64  `json:"average_duration_ms"`
+
+	// 缓存写入按 TTL 拆分（5 分钟 / 1 小时）
+	TotalCacheCreation5mTokens int64   `json:"total_cache_creation_5m_tokens"`
+	TotalCacheCreation1hTokens int64   `json:"total_cache_creation_1h_tokens"`
+	TotalCacheCreation5mCost   float64 `json:"total_cache_creation_5m_cost"`
+	TotalCacheCreation1hCost   float64 `json:"total_cache_creation_1h_cost"`
 }
 
 // BatchUserUsageStats represents usage stats for a single user
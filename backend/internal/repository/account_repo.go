@@ -15,6 +15,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"strconv"
 	"time"
@@ -26,6 +27,7 @@ import (
 	dbpredicate "github.com/Wei-Shaw/sub2api/ent/predicate"
 	dbproxy "github.com/Wei-Shaw/sub2api/ent/proxy"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/secretbox"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/lib/pq"
 
@@ -40,6 +42,7 @@ import (
 //   - client: Ent 客户端，用于类型安全的 ORM 操作
 //   - sql: 原生 SQL 执行器，用于复杂查询和批量操作
 //   - schedulerCache: 调度器缓存，用于在账号状态变更时同步快照
+//   - keyring: 敏感字段密钥环，用于加解密账号凭证（nil 表示未启用加密）
 type accountRepository struct {
 	client *dbent.Client // Ent ORM 客户端
 	sql    sqlExecutor   // 原生 SQL 执行接口
@@ -48,6 +51,7 @@ type accountRepository struct {
 	// Used to proactively sync account snapshot to cache when status changes,
 	// ensuring sticky sessions can promptly detect unavailable accounts.
 	schedulerCache service.SchedulerCache
+	keyring        *secretbox.Keyring
 }
 
 type tempUnschedSnapshot struct {
@@ -57,14 +61,14 @@ type tempUnschedSnapshot struct {
 
 // NewAccountRepository 创建账户仓储实例。
 // 这是对外暴露的构造函数，返回接口类型以便于依赖注入。
-func NewAccountRepository(client *dbent.Client, sqlDB *sql.DB, schedulerCache service.SchedulerCache) service.AccountRepository {
-	return newAccountRepositoryWithSQL(client, sqlDB, schedulerCache)
+func NewAccountRepository(client *dbent.Client, sqlDB *sql.DB, schedulerCache service.SchedulerCache, keyring *secretbox.Keyring) service.AccountRepository {
+	return newAccountRepositoryWithSQL(client, sqlDB, schedulerCache, keyring)
 }
 
 // newAccountRepositoryWithSQL 是内部构造函数，支持依赖注入 SQL 执行器。
 // 这种设计便于单元测试时注入 mock 对象。
-func newAccountRepositoryWithSQL(client *dbent.Client, sqlq sqlExecutor, schedulerCache service.SchedulerCache) *accountRepository {
-	return &accountRepository{client: client, sql: sqlq, schedulerCache: schedulerCache}
+func newAccountRepositoryWithSQL(client *dbent.Client, sqlq sqlExecutor, schedulerCache service.SchedulerCache, keyring *secretbox.Keyring) *accountRepository {
+	return &accountRepository{client: client, sql: sqlq, schedulerCache: schedulerCache, keyring: keyring}
 }
 
 func (r *accountRepository) Create(ctx context.Context, account *service.Account) error {
@@ -72,12 +76,17 @@ func (r *accountRepository) Create(ctx context.Context, account *service.Account
 		return service.ErrAccountNilInput
 	}
 
+	credentials, err := encryptCredentials(r.keyring, account.Credentials)
+	if err != nil {
+		return err
+	}
+
 	builder := r.client.Account.Create().
 		SetName(account.Name).
 		SetNillableNotes(account.Notes).
 		SetPlatform(account.Platform).
 		SetType(account.Type).
-		SetCredentials(normalizeJSONMap(account.Credentials)).
+		SetCredentials(credentials).
 		SetExtra(normalizeJSONMap(account.Extra)).
 		SetConcurrency(account.Concurrency).
 		SetPriority(account.Priority).
@@ -201,14 +210,14 @@ func (r *accountRepository) GetByIDs(ctx context.Context, ids []int64) ([]*servi
 
 	outByID := make(map[int64]*service.Account, len(entAccounts))
 	for _, entAcc := range entAccounts {
-		out := accountEntityToService(entAcc)
+		out := accountEntityToService(entAcc, r.keyring)
 		if out == nil {
 			continue
 		}
 
 		// Prefer the preloaded proxy edge when available.
 		if entAcc.Edges.Proxy != nil {
-			out.Proxy = proxyEntityToService(entAcc.Edges.Proxy)
+			out.Proxy = proxyEntityToService(entAcc.Edges.Proxy, r.keyring)
 		}
 
 		if groups, ok := groupsByAccount[entAcc.ID]; ok {
@@ -286,13 +295,21 @@ func (r *accountRepository) Update(ctx context.Context, account *service.Account
 	if account == nil {
 		return nil
 	}
+	if account.CredentialsUnreadable {
+		return service.ErrAccountCredentialsUnreadable
+	}
+
+	credentials, err := encryptCredentials(r.keyring, account.Credentials)
+	if err != nil {
+		return err
+	}
 
 	builder := r.client.Account.UpdateOneID(account.ID).
 		SetName(account.Name).
 		SetNillableNotes(account.Notes).
 		SetPlatform(account.Platform).
 		SetType(account.Type).
-		SetCredentials(normalizeJSONMap(account.Credentials)).
+		SetCredentials(credentials).
 		SetExtra(normalizeJSONMap(account.Extra)).
 		SetConcurrency(account.Concurrency).
 		SetPriority(account.Priority).
@@ -1141,7 +1158,16 @@ func (r *accountRepository) BulkUpdate(ctx context.Context, ids []int64, updates
 		idx++
 	}
 	// JSONB 需要合并而非覆盖，使用 raw SQL 保持旧行为。
-	if len(updates.Credentials) > 0 {
+	// 配置了密钥环时凭证可能是密文，无法在 SQL 中合并，改为逐行解密合并后写回。
+	credentialsMerged := false
+	if len(updates.Credentials) > 0 && r.keyring != nil {
+		for _, id := range ids {
+			if err := r.mergeSealedCredentials(ctx, id, updates.Credentials); err != nil {
+				return 0, err
+			}
+		}
+		credentialsMerged = true
+	} else if len(updates.Credentials) > 0 {
 		payload, err := json.Marshal(updates.Credentials)
 		if err != nil {
 			return 0, err
@@ -1160,7 +1186,7 @@ func (r *accountRepository) BulkUpdate(ctx context.Context, ids []int64, updates
 		idx++
 	}
 
-	if len(setClauses) == 0 {
+	if len(setClauses) == 0 && !credentialsMerged {
 		return 0, nil
 	}
 
@@ -1295,7 +1321,7 @@ func (r *accountRepository) accountsToService(ctx context.Context, accounts []*d
 
 	outAccounts := make([]service.Account, 0, len(accounts))
 	for _, acc := range accounts {
-		out := accountEntityToService(acc)
+		out := accountEntityToService(acc, r.keyring)
 		if out == nil {
 			continue
 		}
@@ -1395,7 +1421,7 @@ func (r *accountRepository) loadProxies(ctx context.Context, proxyIDs []int64) (
 	}
 
 	for _, p := range proxies {
-		proxyMap[p.ID] = proxyEntityToService(p)
+		proxyMap[p.ID] = proxyEntityToService(p, r.keyring)
 	}
 	return proxyMap, nil
 }
@@ -1478,6 +1504,59 @@ func mergeGroupIDs(a []int64, b []int64) []int64 {
 	return out
 }
 
+// credentialsMergeMaxAttempts 加密凭证合并的乐观并发重试次数
+const credentialsMergeMaxAttempts = 3
+
+// mergeSealedCredentials 读取单个账号凭证，解密后合并 updates 再加密，
+// 以读取到的原值为条件写回（乐观并发），并发修改时重新读取重试。
+func (r *accountRepository) mergeSealedCredentials(ctx context.Context, id int64, updates map[string]any) error {
+	for attempt := 0; attempt < credentialsMergeMaxAttempts; attempt++ {
+		var raw []byte
+		err := scanSingleRow(ctx, r.sql,
+			"SELECT COALESCE(credentials, '{}'::jsonb) FROM accounts WHERE id = $1 AND deleted_at IS NULL",
+			[]any{id}, &raw)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		current := map[string]any{}
+		if err := json.Unmarshal(raw, &current); err != nil {
+			return fmt.Errorf("unmarshal credentials of account %d: %w", id, err)
+		}
+		merged, err := decryptCredentials(r.keyring, current)
+		if err != nil {
+			return fmt.Errorf("decrypt credentials of account %d: %w", id, err)
+		}
+		for k, v := range updates {
+			merged[k] = v
+		}
+		sealed, err := encryptCredentials(r.keyring, merged)
+		if err != nil {
+			return err
+		}
+		payload, err := json.Marshal(sealed)
+		if err != nil {
+			return err
+		}
+		result, err := r.sql.ExecContext(ctx,
+			"UPDATE accounts SET credentials = $1::jsonb WHERE id = $2 AND COALESCE(credentials, '{}'::jsonb) = $3::jsonb",
+			payload, id, raw)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected > 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("merge credentials of account %d: concurrent modification, retry later", id)
+}
+
 func buildSchedulerGroupPayload(groupIDs []int64) map[string]any {
 	if len(groupIDs) == 0 {
 		return nil
@@ -1485,39 +1564,41 @@ func buildSchedulerGroupPayload(groupIDs []int64) map[string]any {
 	return map[string]any{"group_ids": groupIDs}
 }
 
-func accountEntityToService(m *dbent.Account) *service.Account {
+func accountEntityToService(m *dbent.Account, keyring *secretbox.Keyring) *service.Account {
 	if m == nil {
 		return nil
 	}
 
 	rateMultiplier := m.RateMultiplier
+	credentials, readable := decryptAccountCredentials(keyring, m.ID, m.Credentials)
 
 	return &service.Account{
-		ID:                  m.ID,
-		Name:                m.Name,
-		Notes:               m.Notes,
-		Platform:            m.Platform,
-		Type:                m.Type,
-		Credentials:         copyJSONMap(m.Credentials),
-		Extra:               copyJSONMap(m.Extra),
-		ProxyID:             m.ProxyID,
-		Concurrency:         m.Concurrency,
-		Priority:            m.Priority,
-		RateMultiplier:      &rateMultiplier,
-		Status:              m.Status,
-		ErrorMessage:        derefString(m.ErrorMessage),
-		LastUsedAt:          m.LastUsedAt,
-		ExpiresAt:           m.ExpiresAt,
-		AutoPauseOnExpired:  m.AutoPauseOnExpired,
-		CreatedAt:           m.CreatedAt,
-		UpdatedAt:           m.UpdatedAt,
-		Schedulable:         m.Schedulable,
-		RateLimitedAt:       m.RateLimitedAt,
-		RateLimitResetAt:    m.RateLimitResetAt,
-		OverloadUntil:       m.OverloadUntil,
-		SessionWindowStart:  m.SessionWindowStart,
-		SessionWindowEnd:    m.SessionWindowEnd,
-		SessionWindowStatus: derefString(m.SessionWindowStatus),
+		ID:                    m.ID,
+		Name:                  m.Name,
+		Notes:                 m.Notes,
+		Platform:              m.Platform,
+		Type:                  m.Type,
+		Credentials:           credentials,
+		CredentialsUnreadable: !readable,
+		Extra:                 copyJSONMap(m.Extra),
+		ProxyID:               m.ProxyID,
+		Concurrency:           m.Concurrency,
+		Priority:              m.Priority,
+		RateMultiplier:        &rateMultiplier,
+		Status:                m.Status,
+		ErrorMessage:          derefString(m.ErrorMessage),
+		LastUsedAt:            m.LastUsedAt,
+		ExpiresAt:             m.ExpiresAt,
+		AutoPauseOnExpired:    m.AutoPauseOnExpired,
+		CreatedAt:             m.CreatedAt,
+		UpdatedAt:             m.UpdatedAt,
+		Schedulable:           m.Schedulable && readable,
+		RateLimitedAt:         m.RateLimitedAt,
+		RateLimitResetAt:      m.RateLimitResetAt,
+		OverloadUntil:         m.OverloadUntil,
+		SessionWindowStart:    m.SessionWindowStart,
+		SessionWindowEnd:      m.SessionWindowEnd,
+		SessionWindowStatus:   derefString(m.SessionWindowStatus),
 	}
 }
 
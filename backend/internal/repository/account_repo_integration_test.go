@@ -70,7 +70,7 @@ func (s *AccountRepoSuite) SetupTest() {
 	s.ctx = context.Background()
 	tx := testEntTx(s.T())
 	s.client = tx.Client()
-	s.repo = newAccountRepositoryWithSQL(s.client, tx, nil)
+	s.repo = newAccountRepositoryWithSQL(s.client, tx, nil, nil)
 }
 
 func TestAccountRepoSuite(t *testing.T) {
@@ -233,7 +233,7 @@ func (s *AccountRepoSuite) TestListWithFilters() {
 			// 每个 case 重新获取隔离资源
 			tx := testEntTx(s.T())
 			client := tx.Client()
-			repo := newAccountRepositoryWithSQL(client, tx, nil)
+			repo := newAccountRepositoryWithSQL(client, tx, nil, nil)
 			ctx := context.Background()
 
 			tt.setup(client)
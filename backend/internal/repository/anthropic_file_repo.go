@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const anthropicFileColumns = `
+	f.id, f.file_id, f.account_id, f.user_id, f.api_key_id, f.group_id, f.filename, f.mime_type,
+	f.size_bytes, f.downloadable, f.metadata, f.created_at,
+	COALESCE(u.email, ''), COALESCE(a.name, '')
+`
+
+const anthropicFileFrom = `
+	FROM anthropic_files f
+	LEFT JOIN users u ON u.id = f.user_id
+	LEFT JOIN accounts a ON a.id = f.account_id
+`
+
+type anthropicFileRepository struct {
+	sql sqlExecutor
+}
+
+// NewAnthropicFileRepository 创建 Anthropic Files API 文件归属仓储
+func NewAnthropicFileRepository(sqlDB *sql.DB) service.AnthropicFileRepository {
+	return newAnthropicFileRepositoryWithSQL(sqlDB)
+}
+
+func newAnthropicFileRepositoryWithSQL(sqlq sqlExecutor) *anthropicFileRepository {
+	return &anthropicFileRepository{sql: sqlq}
+}
+
+func (r *anthropicFileRepository) Create(ctx context.Context, file *service.AnthropicFile) error {
+	metadata := []byte(file.Metadata)
+	if len(metadata) == 0 || !json.Valid(metadata) {
+		metadata = []byte("{}")
+	}
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO anthropic_files (
+			file_id, account_id, user_id, api_key_id, group_id, filename, mime_type,
+			size_bytes, downloadable, metadata, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10::jsonb, NOW())
+		ON CONFLICT (file_id) DO UPDATE SET
+			account_id = EXCLUDED.account_id,
+			user_id = EXCLUDED.user_id,
+			api_key_id = EXCLUDED.api_key_id,
+			group_id = EXCLUDED.group_id,
+			filename = EXCLUDED.filename,
+			mime_type = EXCLUDED.mime_type,
+			size_bytes = EXCLUDED.size_bytes,
+			downloadable = EXCLUDED.downloadable,
+			metadata = EXCLUDED.metadata
+		RETURNING id, created_at
+	`, []any{
+		file.FileID, file.AccountID, file.UserID, file.APIKeyID, nullInt64(file.GroupID),
+		file.Filename, file.MimeType, file.SizeBytes, file.Downloadable, string(metadata),
+	}, &file.ID, &file.CreatedAt)
+}
+
+func (r *anthropicFileRepository) GetByFileID(ctx context.Context, fileID string) (*service.AnthropicFile, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+anthropicFileColumns+anthropicFileFrom+"WHERE f.file_id = $1", fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrAnthropicFileNotFound
+	}
+	file, err := scanAnthropicFile(rows)
+	if err != nil {
+		return nil, err
+	}
+	return file, rows.Err()
+}
+
+func (r *anthropicFileRepository) ListByUser(ctx context.Context, userID int64, beforeID, afterID int64, limit int) ([]service.AnthropicFile, error) {
+	// before_id 向更新的方向翻页：升序取紧邻的记录后再反转为倒序
+	var query string
+	args := []any{userID}
+	switch {
+	case beforeID > 0:
+		args = append(args, beforeID, limit)
+		query = "SELECT * FROM (SELECT " + anthropicFileColumns + anthropicFileFrom +
+			"WHERE f.user_id = $1 AND f.id > $2 ORDER BY f.id ASC LIMIT $3) page ORDER BY 1 DESC"
+	case afterID > 0:
+		args = append(args, afterID, limit)
+		query = "SELECT " + anthropicFileColumns + anthropicFileFrom + "WHERE f.user_id = $1 AND f.id < $2 ORDER BY f.id DESC LIMIT $3"
+	default:
+		args = append(args, limit)
+		query = "SELECT " + anthropicFileColumns + anthropicFileFrom + "WHERE f.user_id = $1 ORDER BY f.id DESC LIMIT $2"
+	}
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.AnthropicFile, 0)
+	for rows.Next() {
+		file, err := scanAnthropicFile(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *file)
+	}
+	return out, rows.Err()
+}
+
+func (r *anthropicFileRepository) List(ctx context.Context, params pagination.PaginationParams, filters service.AnthropicFileFilters) ([]service.AnthropicFile, *pagination.PaginationResult, error) {
+	conditions := []string{"1=1"}
+	args := []any{}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+	if filters.UserID > 0 {
+		add("f.user_id = $%d", filters.UserID)
+	}
+	if filters.AccountID > 0 {
+		add("f.account_id = $%d", filters.AccountID)
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM anthropic_files f"+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.AnthropicFile{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := "SELECT " + anthropicFileColumns + anthropicFileFrom + where +
+		fmt.Sprintf(" ORDER BY f.id DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.AnthropicFile, 0)
+	for rows.Next() {
+		file, err := scanAnthropicFile(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = append(out, *file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
+
+func (r *anthropicFileRepository) Delete(ctx context.Context, fileID string) error {
+	_, err := r.sql.ExecContext(ctx, "DELETE FROM anthropic_files WHERE file_id = $1", fileID)
+	return err
+}
+
+func scanAnthropicFile(rows *sql.Rows) (*service.AnthropicFile, error) {
+	var (
+		file     service.AnthropicFile
+		groupID  sql.NullInt64
+		metadata []byte
+	)
+	if err := rows.Scan(
+		&file.ID, &file.FileID, &file.AccountID, &file.UserID, &file.APIKeyID, &groupID,
+		&file.Filename, &file.MimeType, &file.SizeBytes, &file.Downloadable, &metadata, &file.CreatedAt,
+		&file.UserEmail, &file.AccountName,
+	); err != nil {
+		return nil, err
+	}
+	file.GroupID = nullInt64Ptr(groupID)
+	if len(metadata) > 0 && !json.Valid(metadata) {
+		metadata = nil
+	}
+	file.Metadata = json.RawMessage(metadata)
+	return &file, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+var anthropicFileTestColumns = []string{
+	"id", "file_id", "account_id", "user_id", "api_key_id", "group_id", "filename", "mime_type",
+	"size_bytes", "downloadable", "metadata", "created_at", "email", "name",
+}
+
+func TestAnthropicFileRepositoryCreate(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newAnthropicFileRepositoryWithSQL(db)
+
+	now := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("INSERT INTO anthropic_files(.|\n)*ON CONFLICT \\(file_id\\) DO UPDATE").
+		WithArgs("file_011", int64(9), int64(7), int64(70), sql.NullInt64{}, "report.pdf", "application/pdf",
+			int64(2048), false, `{"id":"file_011"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(int64(5), now))
+
+	file := &service.AnthropicFile{
+		FileID:    "file_011",
+		AccountID: 9,
+		UserID:    7,
+		APIKeyID:  70,
+		Filename:  "report.pdf",
+		MimeType:  "application/pdf",
+		SizeBytes: 2048,
+		Metadata:  []byte(`{"id":"file_011"}`),
+	}
+	require.NoError(t, repo.Create(context.Background(), file))
+	require.Equal(t, int64(5), file.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnthropicFileRepositoryGetByFileIDNotFound(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newAnthropicFileRepositoryWithSQL(db)
+
+	mock.ExpectQuery("FROM anthropic_files f(.|\n)*WHERE f.file_id = \\$1").
+		WithArgs("file_missing").
+		WillReturnRows(sqlmock.NewRows(anthropicFileTestColumns))
+
+	_, err := repo.GetByFileID(context.Background(), "file_missing")
+	require.ErrorIs(t, err, service.ErrAnthropicFileNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnthropicFileRepositoryListByUserBeforeCursor(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newAnthropicFileRepositoryWithSQL(db)
+
+	now := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("WHERE f.user_id = \\$1 AND f.id > \\$2 ORDER BY f.id ASC LIMIT \\$3\\) page ORDER BY 1 DESC").
+		WithArgs(int64(7), int64(3), 2).
+		WillReturnRows(sqlmock.NewRows(anthropicFileTestColumns).
+			AddRow(int64(5), "file_5", int64(9), int64(7), int64(70), int64(2), "b.txt", "text/plain", int64(1), true, []byte(`{}`), now, "u@example.com", "acc").
+			AddRow(int64(4), "file_4", int64(9), int64(7), int64(70), nil, "a.txt", "text/plain", int64(1), false, []byte(`{}`), now, "u@example.com", "acc"))
+
+	rows, err := repo.ListByUser(context.Background(), 7, 3, 0, 2)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, "file_5", rows[0].FileID)
+	require.NotNil(t, rows[0].GroupID)
+	require.Nil(t, rows[1].GroupID)
+	require.Equal(t, "acc", rows[1].AccountName)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnthropicFileRepositoryListFiltersByUser(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newAnthropicFileRepositoryWithSQL(db)
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM anthropic_files f WHERE 1=1 AND f.user_id = \\$1").
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(0)))
+
+	files, result, err := repo.List(context.Background(), pagination.PaginationParams{Page: 1, PageSize: 20}, service.AnthropicFileFilters{UserID: 7})
+	require.NoError(t, err)
+	require.Empty(t, files)
+	require.Equal(t, int64(0), result.Total)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
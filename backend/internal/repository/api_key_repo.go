@@ -2,6 +2,10 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
 	"time"
 
 	dbent "github.com/Wei-Shaw/sub2api/ent"
@@ -33,7 +37,8 @@ func (r *apiKeyRepository) Create(ctx context.Context, key *service.APIKey) erro
 		SetKey(key.Key).
 		SetName(key.Name).
 		SetStatus(key.Status).
-		SetNillableGroupID(key.GroupID)
+		SetNillableGroupID(key.GroupID).
+		SetNillableOrganizationID(key.OrganizationID)
 
 	if len(key.IPWhitelist) > 0 {
 		builder.SetIPWhitelist(key.IPWhitelist)
@@ -63,7 +68,7 @@ func (r *apiKeyRepository) GetByID(ctx context.Context, id int64) (*service.APIK
 		}
 		return nil, err
 	}
-	return apiKeyEntityToService(m), nil
+	return r.withOrganization(ctx, apiKeyEntityToService(m))
 }
 
 // GetKeyAndOwnerID 根据 API Key ID 获取其 key 与所有者（用户）ID。
@@ -97,7 +102,7 @@ func (r *apiKeyRepository) GetByKey(ctx context.Context, key string) (*service.A
 		}
 		return nil, err
 	}
-	return apiKeyEntityToService(m), nil
+	return r.withOrganization(ctx, apiKeyEntityToService(m))
 }
 
 func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*service.APIKey, error) {
@@ -106,7 +111,9 @@ func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*se
 		Select(
 			apikey.FieldID,
 			apikey.FieldUserID,
+			apikey.FieldName,
 			apikey.FieldGroupID,
+			apikey.FieldOrganizationID,
 			apikey.FieldStatus,
 			apikey.FieldIPWhitelist,
 			apikey.FieldIPBlacklist,
@@ -114,10 +121,12 @@ func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*se
 		WithUser(func(q *dbent.UserQuery) {
 			q.Select(
 				user.FieldID,
+				user.FieldEmail,
 				user.FieldStatus,
 				user.FieldRole,
 				user.FieldBalance,
 				user.FieldConcurrency,
+				user.FieldParentUserID,
 			)
 		}).
 		WithGroup(func(q *dbent.GroupQuery) {
@@ -138,6 +147,10 @@ func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*se
 				group.FieldFallbackGroupID,
 				group.FieldModelRoutingEnabled,
 				group.FieldModelRouting,
+				group.FieldOverdraftUsd,
+				group.FieldCountTokensMode,
+				group.FieldHedgeEnabled,
+				group.FieldModelFallback,
 			)
 		}).
 		Only(ctx)
@@ -147,7 +160,45 @@ func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*se
 		}
 		return nil, err
 	}
-	return apiKeyEntityToService(m), nil
+	return r.withOrganization(ctx, apiKeyEntityToService(m))
+}
+
+// withOrganization 为组织 Key 加载计费上下文（组织状态、付费账户、所有者的成员角色与消费上限）。
+// 组织已删除或付费账户不存在时 Organization 保持为空，由认证中间件拒绝。
+func (r *apiKeyRepository) withOrganization(ctx context.Context, key *service.APIKey) (*service.APIKey, error) {
+	if key == nil || key.OrganizationID == nil {
+		return key, nil
+	}
+	var (
+		org        service.APIKeyOrganization
+		payer      service.User
+		memberRole sql.NullString
+		limit      sql.NullFloat64
+	)
+	err := scanSingleRow(ctx, clientFromContext(ctx, r.client), `
+		SELECT o.id, o.name, o.status,
+			bu.id, bu.status, bu.role, bu.balance, bu.concurrency,
+			m.role, m.spending_limit_usd
+		FROM organizations o
+		JOIN users bu ON bu.id = o.billing_user_id AND bu.deleted_at IS NULL
+		LEFT JOIN organization_members m ON m.organization_id = o.id AND m.user_id = $2
+		WHERE o.id = $1 AND o.deleted_at IS NULL
+	`, []any{*key.OrganizationID, key.UserID},
+		&org.ID, &org.Name, &org.Status,
+		&payer.ID, &payer.Status, &payer.Role, &payer.Balance, &payer.Concurrency,
+		&memberRole, &limit,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	org.MemberRole = memberRole.String
+	org.SpendingLimitUSD = nullFloat64Ptr(limit)
+	org.Payer = &payer
+	key.Organization = &org
+	return key, nil
 }
 
 func (r *apiKeyRepository) Update(ctx context.Context, key *service.APIKey) error {
@@ -335,6 +386,8 @@ func (r *apiKeyRepository) CountByGroupID(ctx context.Context, groupID int64) (i
 	return int64(count), err
 }
 
+// ListKeysByUserID 返回认证快照依赖该用户的所有 Key：用户自己的 Key，
+// 以及该用户作为组织付费账户时组织下的全部 Key（余额变化需要一并失效）。
 func (r *apiKeyRepository) ListKeysByUserID(ctx context.Context, userID int64) ([]string, error) {
 	keys, err := r.activeQuery().
 		Where(apikey.UserIDEQ(userID)).
@@ -343,6 +396,27 @@ func (r *apiKeyRepository) ListKeysByUserID(ctx context.Context, userID int64) (
 	if err != nil {
 		return nil, err
 	}
+
+	rows, err := clientFromContext(ctx, r.client).QueryContext(ctx, `
+		SELECT k.key
+		FROM api_keys k
+		JOIN organizations o ON o.id = k.organization_id
+		WHERE o.billing_user_id = $1 AND k.deleted_at IS NULL
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 	return keys, nil
 }
 
@@ -362,16 +436,17 @@ func apiKeyEntityToService(m *dbent.APIKey) *service.APIKey {
 		return nil
 	}
 	out := &service.APIKey{
-		ID:          m.ID,
-		UserID:      m.UserID,
-		Key:         m.Key,
-		Name:        m.Name,
-		Status:      m.Status,
-		IPWhitelist: m.IPWhitelist,
-		IPBlacklist: m.IPBlacklist,
-		CreatedAt:   m.CreatedAt,
-		UpdatedAt:   m.UpdatedAt,
-		GroupID:     m.GroupID,
+		ID:             m.ID,
+		UserID:         m.UserID,
+		Key:            m.Key,
+		Name:           m.Name,
+		Status:         m.Status,
+		IPWhitelist:    m.IPWhitelist,
+		IPBlacklist:    m.IPBlacklist,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+		GroupID:        m.GroupID,
+		OrganizationID: m.OrganizationID,
 	}
 	if m.Edges.User != nil {
 		out.User = userEntityToService(m.Edges.User)
@@ -396,6 +471,7 @@ func userEntityToService(u *dbent.User) *service.User {
 		Balance:      u.Balance,
 		Concurrency:  u.Concurrency,
 		Status:       u.Status,
+		ParentUserID: u.ParentUserID,
 		CreatedAt:    u.CreatedAt,
 		UpdatedAt:    u.UpdatedAt,
 	}
@@ -426,11 +502,28 @@ func groupEntityToService(g *dbent.Group) *service.Group {
 		FallbackGroupID:     g.FallbackGroupID,
 		ModelRouting:        g.ModelRouting,
 		ModelRoutingEnabled: g.ModelRoutingEnabled,
+		OverdraftUSD:        g.OverdraftUsd,
+		CountTokensMode:     g.CountTokensMode,
+		HedgeEnabled:        g.HedgeEnabled,
+		ModelFallback:       modelFallbackFromJSON(g.ID, g.ModelFallback),
 		CreatedAt:           g.CreatedAt,
 		UpdatedAt:           g.UpdatedAt,
 	}
 }
 
+// modelFallbackFromJSON 解析分组的模型降级链配置，解析失败时视为未配置
+func modelFallbackFromJSON(groupID int64, raw json.RawMessage) *service.ModelFallbackConfig {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var cfg service.ModelFallbackConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		log.Printf("[ModelFallback] invalid model_fallback for group %d: %v", groupID, err)
+		return nil
+	}
+	return &cfg
+}
+
 func derefString(s *string) string {
 	if s == nil {
 		return ""
@@ -0,0 +1,307 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const billingOutboxColumns = `
+	id, request_id, api_key_id, usage_log_id, payer_user_id, member_user_id, organization_id,
+	billing_type, subscription_id, group_id, amount, reseller_settlement, status, attempts,
+	last_error, next_attempt_at, applied_at, created_at, updated_at
+`
+
+type billingOutboxRepository struct {
+	sql sqlExecutor
+}
+
+func NewBillingOutboxRepository(sqlDB *sql.DB) service.BillingOutboxRepository {
+	return newBillingOutboxRepositoryWithSQL(sqlDB)
+}
+
+func newBillingOutboxRepositoryWithSQL(sqlq sqlExecutor) *billingOutboxRepository {
+	return &billingOutboxRepository{sql: sqlq}
+}
+
+// exec 在事务上下文中使用 tx 绑定的执行器，保证事件状态与扣费同事务
+func (r *billingOutboxRepository) exec(ctx context.Context) sqlExecutor {
+	if tx := dbent.TxFromContext(ctx); tx != nil {
+		return tx.Client()
+	}
+	return r.sql
+}
+
+func (r *billingOutboxRepository) Enqueue(ctx context.Context, event *service.BillingOutboxEvent) (bool, error) {
+	if event == nil {
+		return false, nil
+	}
+	var settlement sql.NullString
+	if event.Reseller != nil {
+		raw, err := json.Marshal(event.Reseller)
+		if err != nil {
+			return false, fmt.Errorf("marshal reseller settlement: %w", err)
+		}
+		settlement = sql.NullString{String: string(raw), Valid: true}
+	}
+	status := event.Status
+	if status == "" {
+		status = service.BillingOutboxStatusPending
+	}
+
+	err := scanSingleRow(ctx, r.exec(ctx), `
+		INSERT INTO billing_outbox
+			(request_id, api_key_id, usage_log_id, payer_user_id, member_user_id, organization_id,
+			 billing_type, subscription_id, group_id, amount, reseller_settlement, status,
+			 next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NOW(), NOW())
+		ON CONFLICT (request_id, api_key_id) DO NOTHING
+		RETURNING id, created_at
+	`, []any{
+		event.RequestID,
+		event.APIKeyID,
+		nullInt64(event.UsageLogID),
+		event.PayerUserID,
+		event.MemberUserID,
+		nullInt64(event.OrganizationID),
+		event.BillingType,
+		nullInt64(event.SubscriptionID),
+		nullInt64(event.GroupID),
+		event.Amount,
+		settlement,
+		status,
+		event.NextAttemptAt,
+	}, &event.ID, &event.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// 幂等键冲突：同一请求已入队
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	event.Status = status
+	event.UpdatedAt = event.CreatedAt
+	return true, nil
+}
+
+func (r *billingOutboxRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]service.BillingOutboxEvent, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := r.exec(ctx).QueryContext(ctx, "SELECT "+billingOutboxColumns+`
+		FROM billing_outbox
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY next_attempt_at ASC, id ASC
+		LIMIT $3
+	`, service.BillingOutboxStatusPending, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	return scanBillingOutboxEvents(rows)
+}
+
+func (r *billingOutboxRepository) MarkApplied(ctx context.Context, id int64) (bool, error) {
+	res, err := r.exec(ctx).ExecContext(ctx, `
+		UPDATE billing_outbox
+		SET status = $2, applied_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = $3
+	`, id, service.BillingOutboxStatusApplied, service.BillingOutboxStatusPending)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *billingOutboxRepository) MarkRetry(ctx context.Context, id int64, attempts int, lastError string, nextAttemptAt time.Time, dead bool) error {
+	status := service.BillingOutboxStatusPending
+	if dead {
+		status = service.BillingOutboxStatusFailed
+	}
+	_, err := r.exec(ctx).ExecContext(ctx, `
+		UPDATE billing_outbox
+		SET status = $2, attempts = $3, last_error = $4, next_attempt_at = $5, updated_at = NOW()
+		WHERE id = $1 AND status = $6
+	`, id, status, attempts, lastError, nextAttemptAt, service.BillingOutboxStatusPending)
+	return err
+}
+
+func (r *billingOutboxRepository) Requeue(ctx context.Context, id int64) error {
+	res, err := r.exec(ctx).ExecContext(ctx, `
+		UPDATE billing_outbox
+		SET status = $2, attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = $3
+	`, id, service.BillingOutboxStatusPending, service.BillingOutboxStatusFailed)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrBillingOutboxNotRetryable
+	}
+	return nil
+}
+
+func (r *billingOutboxRepository) GetByID(ctx context.Context, id int64) (*service.BillingOutboxEvent, error) {
+	rows, err := r.exec(ctx).QueryContext(ctx, "SELECT "+billingOutboxColumns+" FROM billing_outbox WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	events, err := scanBillingOutboxEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, service.ErrBillingOutboxEventNotFound
+	}
+	return &events[0], nil
+}
+
+func (r *billingOutboxRepository) List(ctx context.Context, params pagination.PaginationParams, filters service.BillingOutboxListFilters) ([]service.BillingOutboxEvent, *pagination.PaginationResult, error) {
+	conditions := []string{"1=1"}
+	args := []any{}
+	if filters.Status != "" {
+		args = append(args, filters.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filters.UserID > 0 {
+		args = append(args, filters.UserID)
+		conditions = append(conditions, fmt.Sprintf("(payer_user_id = $%d OR member_user_id = $%d)", len(args), len(args)))
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM billing_outbox"+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.BillingOutboxEvent{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := "SELECT " + billingOutboxColumns + " FROM billing_outbox" + where +
+		fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	items, err := scanBillingOutboxEvents(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	return items, paginationResultFromTotal(total, params), nil
+}
+
+func (r *billingOutboxRepository) Summary(ctx context.Context) ([]service.BillingOutboxStatusSummary, error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT status, COUNT(*), COALESCE(SUM(amount), 0), MIN(created_at), COALESCE(MAX(attempts), 0)
+		FROM billing_outbox
+		WHERE status <> $1
+		GROUP BY status
+		ORDER BY status ASC
+	`, service.BillingOutboxStatusApplied)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.BillingOutboxStatusSummary, 0)
+	for rows.Next() {
+		var (
+			s        service.BillingOutboxStatusSummary
+			oldestAt sql.NullTime
+		)
+		if err := rows.Scan(&s.Status, &s.Count, &s.Amount, &oldestAt, &s.MaxAttempts); err != nil {
+			return nil, err
+		}
+		s.OldestAt = nullTimePtr(oldestAt)
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *billingOutboxRepository) DeleteAppliedBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.sql.ExecContext(ctx,
+		"DELETE FROM billing_outbox WHERE status = $1 AND applied_at < $2",
+		service.BillingOutboxStatusApplied, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func scanBillingOutboxEvents(rows *sql.Rows) ([]service.BillingOutboxEvent, error) {
+	out := make([]service.BillingOutboxEvent, 0)
+	for rows.Next() {
+		var (
+			e              service.BillingOutboxEvent
+			usageLogID     sql.NullInt64
+			organizationID sql.NullInt64
+			subscriptionID sql.NullInt64
+			groupID        sql.NullInt64
+			settlement     []byte
+			appliedAt      sql.NullTime
+		)
+		if err := rows.Scan(
+			&e.ID,
+			&e.RequestID,
+			&e.APIKeyID,
+			&usageLogID,
+			&e.PayerUserID,
+			&e.MemberUserID,
+			&organizationID,
+			&e.BillingType,
+			&subscriptionID,
+			&groupID,
+			&e.Amount,
+			&settlement,
+			&e.Status,
+			&e.Attempts,
+			&e.LastError,
+			&e.NextAttemptAt,
+			&appliedAt,
+			&e.CreatedAt,
+			&e.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		e.UsageLogID = nullInt64Ptr(usageLogID)
+		e.OrganizationID = nullInt64Ptr(organizationID)
+		e.SubscriptionID = nullInt64Ptr(subscriptionID)
+		e.GroupID = nullInt64Ptr(groupID)
+		e.AppliedAt = nullTimePtr(appliedAt)
+		if len(settlement) > 0 {
+			var rs service.ResellerSettlement
+			if err := json.Unmarshal(settlement, &rs); err != nil {
+				return nil, fmt.Errorf("unmarshal reseller settlement for billing event %d: %w", e.ID, err)
+			}
+			e.Reseller = &rs
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBillingOutboxRepositoryEnqueueConflictReturnsFalse(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newBillingOutboxRepositoryWithSQL(db)
+
+	mock.ExpectQuery("INSERT INTO billing_outbox").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+
+	ok, err := repo.Enqueue(context.Background(), &service.BillingOutboxEvent{
+		RequestID:     "req-1",
+		APIKeyID:      3,
+		PayerUserID:   7,
+		Amount:        1,
+		NextAttemptAt: time.Now(),
+	})
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBillingOutboxRepositoryMarkAppliedOnlyOnce(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newBillingOutboxRepositoryWithSQL(db)
+
+	mock.ExpectExec("UPDATE billing_outbox\\s+SET status = \\$2, applied_at = NOW\\(\\)").
+		WithArgs(int64(5), service.BillingOutboxStatusApplied, service.BillingOutboxStatusPending).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.MarkApplied(context.Background(), 5)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBillingOutboxRepositoryRequeueResetsAttempts(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newBillingOutboxRepositoryWithSQL(db)
+
+	mock.ExpectExec(`UPDATE billing_outbox\s+SET status = \$2, attempts = 0,`).
+		WithArgs(int64(5), service.BillingOutboxStatusPending, service.BillingOutboxStatusFailed).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.Requeue(context.Background(), 5))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBillingOutboxRepositoryRequeueRejectsNonFailed(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newBillingOutboxRepositoryWithSQL(db)
+
+	mock.ExpectExec("UPDATE billing_outbox").
+		WithArgs(int64(5), service.BillingOutboxStatusPending, service.BillingOutboxStatusFailed).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Requeue(context.Background(), 5)
+	require.ErrorIs(t, err, service.ErrBillingOutboxNotRetryable)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
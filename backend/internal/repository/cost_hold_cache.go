@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const costHoldKeyPrefix = "billing:hold:"
+
+// costHoldKeys 同一 scope 的过期索引与金额表，使用 hash tag 保证在同一 slot
+func costHoldKeys(scope string) (expiryKey, amountKey string) {
+	base := fmt.Sprintf("%s{%s}", costHoldKeyPrefix, scope)
+	return base + ":exp", base + ":amt"
+}
+
+var (
+	// reserveCostHoldScript 清理过期预扣后原子检查并登记
+	// KEYS[1] = 过期索引 ZSET（member=holdID, score=过期时间 ms）
+	// KEYS[2] = 金额 HASH（field=holdID, value=金额）
+	// ARGV: now_ms, hold_id, amount, limit, expire_at_ms, key_ttl_seconds
+	reserveCostHoldScript = redis.NewScript(`
+		local expired = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+		for _, id in ipairs(expired) do
+			redis.call('HDEL', KEYS[2], id)
+		end
+		redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+
+		local held = 0
+		for _, v in ipairs(redis.call('HVALS', KEYS[2])) do
+			held = held + tonumber(v)
+		end
+		local amount = tonumber(ARGV[3])
+		if held + amount > tonumber(ARGV[4]) then
+			return {0, tostring(held)}
+		end
+
+		redis.call('ZADD', KEYS[1], ARGV[5], ARGV[2])
+		redis.call('HSET', KEYS[2], ARGV[2], ARGV[3])
+		redis.call('EXPIRE', KEYS[1], ARGV[6])
+		redis.call('EXPIRE', KEYS[2], ARGV[6])
+		return {1, tostring(held + amount)}
+	`)
+
+	releaseCostHoldScript = redis.NewScript(`
+		redis.call('ZREM', KEYS[1], ARGV[1])
+		redis.call('HDEL', KEYS[2], ARGV[1])
+		return 1
+	`)
+)
+
+type costHoldCache struct {
+	rdb *redis.Client
+}
+
+func NewCostHoldCache(rdb *redis.Client) service.CostHoldCache {
+	return &costHoldCache{rdb: rdb}
+}
+
+func (c *costHoldCache) Reserve(ctx context.Context, scope, holdID string, amount, limit float64, ttl time.Duration) (bool, float64, error) {
+	expiryKey, amountKey := costHoldKeys(scope)
+	now := time.Now()
+	res, err := reserveCostHoldScript.Run(ctx, c.rdb, []string{expiryKey, amountKey},
+		now.UnixMilli(),
+		holdID,
+		strconv.FormatFloat(amount, 'f', -1, 64),
+		strconv.FormatFloat(limit, 'f', -1, 64),
+		now.Add(ttl).UnixMilli(),
+		int(ttl.Seconds())+1,
+	).Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if len(res) != 2 {
+		return false, 0, fmt.Errorf("unexpected cost hold script result: %v", res)
+	}
+	ok, _ := res[0].(int64)
+	heldStr, _ := res[1].(string)
+	held, err := strconv.ParseFloat(heldStr, 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("parse held amount: %w", err)
+	}
+	return ok == 1, held, nil
+}
+
+func (c *costHoldCache) Release(ctx context.Context, scope, holdID string) error {
+	expiryKey, amountKey := costHoldKeys(scope)
+	return releaseCostHoldScript.Run(ctx, c.rdb, []string{expiryKey, amountKey}, holdID).Err()
+}
@@ -0,0 +1,58 @@
+//go:build integration
+
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type CostHoldCacheSuite struct {
+	IntegrationRedisSuite
+}
+
+func (s *CostHoldCacheSuite) TestReserveRespectsLimit() {
+	cache := NewCostHoldCache(s.rdb)
+
+	ok, held, err := cache.Reserve(s.ctx, "user:1", "a", 0.6, 1.0, time.Minute)
+	s.RequireNoError(err)
+	require.True(s.T(), ok)
+	require.InDelta(s.T(), 0.6, held, 1e-9)
+
+	ok, held, err = cache.Reserve(s.ctx, "user:1", "b", 0.6, 1.0, time.Minute)
+	s.RequireNoError(err)
+	require.False(s.T(), ok, "second hold would exceed limit")
+	require.InDelta(s.T(), 0.6, held, 1e-9)
+
+	s.RequireNoError(cache.Release(s.ctx, "user:1", "a"))
+	ok, _, err = cache.Reserve(s.ctx, "user:1", "b", 0.6, 1.0, time.Minute)
+	s.RequireNoError(err)
+	require.True(s.T(), ok, "hold should succeed after release")
+
+	expiryKey, amountKey := costHoldKeys("user:1")
+	ttl, err := s.rdb.TTL(s.ctx, amountKey).Result()
+	s.RequireNoError(err)
+	s.AssertTTLWithin(ttl, 1*time.Second, time.Minute+time.Second)
+	require.Equal(s.T(), int64(1), s.rdb.ZCard(s.ctx, expiryKey).Val())
+}
+
+func (s *CostHoldCacheSuite) TestExpiredHoldsAreIgnored() {
+	cache := NewCostHoldCache(s.rdb)
+
+	ok, _, err := cache.Reserve(s.ctx, "sub:1:2", "stale", 1.0, 1.0, time.Millisecond)
+	s.RequireNoError(err)
+	require.True(s.T(), ok)
+
+	time.Sleep(5 * time.Millisecond)
+	ok, held, err := cache.Reserve(s.ctx, "sub:1:2", "fresh", 1.0, 1.0, time.Minute)
+	s.RequireNoError(err)
+	require.True(s.T(), ok)
+	require.InDelta(s.T(), 1.0, held, 1e-9)
+}
+
+func TestCostHoldCacheSuite(t *testing.T) {
+	suite.Run(t, new(CostHoldCacheSuite))
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const geminiResourceColumns = `
+	id, name, resource_type, account_id, user_id, api_key_id, group_id, model,
+	size_bytes, token_count, metadata, expires_at, created_at, updated_at
+`
+
+type geminiResourceRepository struct {
+	sql sqlExecutor
+}
+
+// NewGeminiResourceRepository 创建 Gemini 资源归属仓储
+func NewGeminiResourceRepository(sqlDB *sql.DB) service.GeminiResourceRepository {
+	return newGeminiResourceRepositoryWithSQL(sqlDB)
+}
+
+func newGeminiResourceRepositoryWithSQL(sqlq sqlExecutor) *geminiResourceRepository {
+	return &geminiResourceRepository{sql: sqlq}
+}
+
+func (r *geminiResourceRepository) Upsert(ctx context.Context, resource *service.GeminiResource) error {
+	metadata := []byte(resource.Metadata)
+	if len(metadata) == 0 || !json.Valid(metadata) {
+		metadata = []byte("{}")
+	}
+	var expiresAt sql.NullTime
+	if resource.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: resource.ExpiresAt.UTC(), Valid: true}
+	}
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO gemini_resources (
+			name, resource_type, account_id, user_id, api_key_id, group_id, model,
+			size_bytes, token_count, metadata, expires_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10::jsonb, $11, NOW(), NOW())
+		ON CONFLICT (name) DO UPDATE SET
+			resource_type = EXCLUDED.resource_type,
+			account_id = EXCLUDED.account_id,
+			user_id = EXCLUDED.user_id,
+			api_key_id = EXCLUDED.api_key_id,
+			group_id = EXCLUDED.group_id,
+			model = EXCLUDED.model,
+			size_bytes = EXCLUDED.size_bytes,
+			token_count = EXCLUDED.token_count,
+			metadata = EXCLUDED.metadata,
+			expires_at = EXCLUDED.expires_at,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`, []any{
+		resource.Name, resource.ResourceType, resource.AccountID, resource.UserID, resource.APIKeyID,
+		nullInt64(resource.GroupID), resource.Model, resource.SizeBytes, resource.TokenCount,
+		string(metadata), expiresAt,
+	}, &resource.ID, &resource.CreatedAt, &resource.UpdatedAt)
+}
+
+func (r *geminiResourceRepository) GetByName(ctx context.Context, name string) (*service.GeminiResource, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+geminiResourceColumns+" FROM gemini_resources WHERE name = $1", name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrGeminiResourceNotFound
+	}
+	resource, err := scanGeminiResource(rows)
+	if err != nil {
+		return nil, err
+	}
+	return resource, rows.Err()
+}
+
+func (r *geminiResourceRepository) ListByUser(ctx context.Context, userID int64, resourceType string, afterID int64, limit int) ([]service.GeminiResource, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+geminiResourceColumns+`
+		FROM gemini_resources
+		WHERE user_id = $1 AND resource_type = $2 AND id > $3
+			AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY id
+		LIMIT $4
+	`, userID, resourceType, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.GeminiResource, 0)
+	for rows.Next() {
+		resource, err := scanGeminiResource(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *resource)
+	}
+	return out, rows.Err()
+}
+
+func (r *geminiResourceRepository) Delete(ctx context.Context, name string) error {
+	_, err := r.sql.ExecContext(ctx, "DELETE FROM gemini_resources WHERE name = $1", name)
+	return err
+}
+
+func (r *geminiResourceRepository) DeleteExpired(ctx context.Context, before time.Time, limit int) (int64, error) {
+	result, err := r.sql.ExecContext(ctx, `
+		DELETE FROM gemini_resources
+		WHERE id IN (
+			SELECT id FROM gemini_resources
+			WHERE expires_at IS NOT NULL AND expires_at <= $1
+			ORDER BY expires_at
+			LIMIT $2
+		)
+	`, before.UTC(), limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanGeminiResource(rows *sql.Rows) (*service.GeminiResource, error) {
+	var (
+		resource  service.GeminiResource
+		groupID   sql.NullInt64
+		metadata  []byte
+		expiresAt sql.NullTime
+	)
+	if err := rows.Scan(
+		&resource.ID, &resource.Name, &resource.ResourceType, &resource.AccountID, &resource.UserID,
+		&resource.APIKeyID, &groupID, &resource.Model, &resource.SizeBytes, &resource.TokenCount,
+		&metadata, &expiresAt, &resource.CreatedAt, &resource.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	resource.GroupID = nullInt64Ptr(groupID)
+	if len(metadata) > 0 && !json.Valid(metadata) {
+		metadata = nil
+	}
+	resource.Metadata = json.RawMessage(metadata)
+	if expiresAt.Valid {
+		t := expiresAt.Time
+		resource.ExpiresAt = &t
+	}
+	return &resource, nil
+}
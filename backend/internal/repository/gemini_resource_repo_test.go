@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeminiResourceRepositoryUpsertDefaultsMetadata(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newGeminiResourceRepositoryWithSQL(db)
+
+	groupID := int64(3)
+	expires := time.Date(2026, 10, 19, 3, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("INSERT INTO gemini_resources(.|\n)*ON CONFLICT \\(name\\) DO UPDATE").
+		WithArgs("files/abc", service.GeminiResourceTypeFile, int64(9), int64(7), int64(70), sql.NullInt64{Int64: 3, Valid: true},
+			"", int64(1024), int64(0), "{}", sql.NullTime{Time: expires, Valid: true}).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(int64(11), now, now))
+
+	resource := &service.GeminiResource{
+		Name:         "files/abc",
+		ResourceType: service.GeminiResourceTypeFile,
+		AccountID:    9,
+		UserID:       7,
+		APIKeyID:     70,
+		GroupID:      &groupID,
+		SizeBytes:    1024,
+		ExpiresAt:    &expires,
+	}
+	require.NoError(t, repo.Upsert(context.Background(), resource))
+	require.Equal(t, int64(11), resource.ID)
+	require.Equal(t, now, resource.CreatedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGeminiResourceRepositoryGetByNameNotFound(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newGeminiResourceRepositoryWithSQL(db)
+
+	mock.ExpectQuery("FROM gemini_resources WHERE name = \\$1").
+		WithArgs("cachedContents/missing").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := repo.GetByName(context.Background(), "cachedContents/missing")
+	require.ErrorIs(t, err, service.ErrGeminiResourceNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGeminiResourceRepositoryListByUserScansRows(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newGeminiResourceRepositoryWithSQL(db)
+
+	now := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	expires := now.Add(time.Hour)
+	mock.ExpectQuery("FROM gemini_resources(.|\n)*WHERE user_id = \\$1 AND resource_type = \\$2 AND id > \\$3(.|\n)*ORDER BY id").
+		WithArgs(int64(7), service.GeminiResourceTypeCachedContent, int64(5), 2).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "name", "resource_type", "account_id", "user_id", "api_key_id", "group_id", "model",
+			"size_bytes", "token_count", "metadata", "expires_at", "created_at", "updated_at",
+		}).AddRow(int64(6), "cachedContents/c1", service.GeminiResourceTypeCachedContent, int64(9), int64(7), int64(70), nil,
+			"gemini-2.0-flash", int64(0), int64(4096), []byte(`{"name":"cachedContents/c1"}`), expires, now, now))
+
+	rows, err := repo.ListByUser(context.Background(), 7, service.GeminiResourceTypeCachedContent, 5, 2)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Nil(t, rows[0].GroupID)
+	require.Equal(t, int64(4096), rows[0].TokenCount)
+	require.JSONEq(t, `{"name":"cachedContents/c1"}`, string(rows[0].Metadata))
+	require.NotNil(t, rows[0].ExpiresAt)
+	require.True(t, rows[0].ExpiresAt.Equal(expires))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGeminiResourceRepositoryDeleteExpired(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newGeminiResourceRepositoryWithSQL(db)
+
+	before := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	mock.ExpectExec("DELETE FROM gemini_resources(.|\n)*expires_at <= \\$1(.|\n)*LIMIT \\$2").
+		WithArgs(before, 1000).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	deleted, err := repo.DeleteExpired(context.Background(), before, 1000)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), deleted)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"log"
 
@@ -50,12 +51,22 @@ func (r *groupRepository) Create(ctx context.Context, groupIn *service.Group) er
 		SetDefaultValidityDays(groupIn.DefaultValidityDays).
 		SetClaudeCodeOnly(groupIn.ClaudeCodeOnly).
 		SetNillableFallbackGroupID(groupIn.FallbackGroupID).
-		SetModelRoutingEnabled(groupIn.ModelRoutingEnabled)
+		SetModelRoutingEnabled(groupIn.ModelRoutingEnabled).
+		SetOverdraftUsd(groupIn.OverdraftUSD).
+		SetCountTokensMode(groupIn.GetCountTokensMode()).
+		SetHedgeEnabled(groupIn.HedgeEnabled)
 
 	// 设置模型路由配置
 	if groupIn.ModelRouting != nil {
 		builder = builder.SetModelRouting(groupIn.ModelRouting)
 	}
+	if groupIn.ModelFallback != nil {
+		raw, err := json.Marshal(groupIn.ModelFallback)
+		if err != nil {
+			return err
+		}
+		builder = builder.SetModelFallback(raw)
+	}
 
 	created, err := builder.Save(ctx)
 	if err == nil {
@@ -108,7 +119,10 @@ func (r *groupRepository) Update(ctx context.Context, groupIn *service.Group) er
 		SetNillableImagePrice4k(groupIn.ImagePrice4K).
 		SetDefaultValidityDays(groupIn.DefaultValidityDays).
 		SetClaudeCodeOnly(groupIn.ClaudeCodeOnly).
-		SetModelRoutingEnabled(groupIn.ModelRoutingEnabled)
+		SetModelRoutingEnabled(groupIn.ModelRoutingEnabled).
+		SetOverdraftUsd(groupIn.OverdraftUSD).
+		SetCountTokensMode(groupIn.GetCountTokensMode()).
+		SetHedgeEnabled(groupIn.HedgeEnabled)
 
 	// 处理 FallbackGroupID：nil 时清除，否则设置
 	if groupIn.FallbackGroupID != nil {
@@ -124,6 +138,17 @@ func (r *groupRepository) Update(ctx context.Context, groupIn *service.Group) er
 		builder = builder.ClearModelRouting()
 	}
 
+	// 处理 ModelFallback：nil 时清除，否则设置
+	if groupIn.ModelFallback != nil {
+		raw, err := json.Marshal(groupIn.ModelFallback)
+		if err != nil {
+			return err
+		}
+		builder = builder.SetModelFallback(raw)
+	} else {
+		builder = builder.ClearModelFallback()
+	}
+
 	updated, err := builder.Save(ctx)
 	if err != nil {
 		return translatePersistenceError(err, service.ErrGroupNotFound, service.ErrGroupExists)
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/httpclient"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// guardrailHookClient 内容策略外部审核钩子
+//
+// 协议：POST JSON（service.GuardrailHookRequest），钩子返回 2xx 与 JSON（service.GuardrailHookResponse）。
+// 钩子地址由管理员配置，审核服务通常部署在内网，因此不做私有地址校验。
+type guardrailHookClient struct {
+	httpClient *http.Client
+}
+
+func NewGuardrailHookClient(cfg *config.Config) service.GuardrailHookClient {
+	timeout := 5 * time.Second
+	if cfg != nil && cfg.Gateway.Guardrails.HookTimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.Gateway.Guardrails.HookTimeoutSeconds) * time.Second
+	}
+	client, err := httpclient.GetClient(httpclient.Options{Timeout: timeout})
+	if err != nil {
+		client = &http.Client{Timeout: timeout}
+	}
+	return &guardrailHookClient{httpClient: client}
+}
+
+func (c *guardrailHookClient) Moderate(ctx context.Context, url string, hookReq *service.GuardrailHookRequest) (*service.GuardrailHookResponse, error) {
+	payload, err := json.Marshal(hookReq)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sub2api-guardrail")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("guardrail hook returned status %d", resp.StatusCode)
+	}
+	var out service.GuardrailHookResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("decode guardrail hook response: %w", err)
+	}
+	return &out, nil
+}
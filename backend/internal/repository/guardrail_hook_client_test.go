@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuardrailHookClientModerate(t *testing.T) {
+	var got service.GuardrailHookRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"flagged":true,"reason":"internal codename","redact":["Project X"]}`))
+	}))
+	defer srv.Close()
+
+	groupID := int64(3)
+	client := &guardrailHookClient{httpClient: srv.Client()}
+	resp, err := client.Moderate(context.Background(), srv.URL, &service.GuardrailHookRequest{
+		PolicyID:  1,
+		Direction: service.GuardrailDirectionRequest,
+		Model:     "claude-sonnet-4-5",
+		UserID:    7,
+		APIKeyID:  9,
+		GroupID:   &groupID,
+		Texts:     []string{"tell me about Project X"},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Flagged)
+	require.Equal(t, []string{"Project X"}, resp.Redact)
+	require.Equal(t, int64(9), got.APIKeyID)
+	require.Equal(t, []string{"tell me about Project X"}, got.Texts)
+}
+
+func TestGuardrailHookClientErrors(t *testing.T) {
+	status := http.StatusBadGateway
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("not json"))
+	}))
+	defer srv.Close()
+
+	client := &guardrailHookClient{httpClient: srv.Client()}
+	_, err := client.Moderate(context.Background(), srv.URL, &service.GuardrailHookRequest{})
+	require.ErrorContains(t, err, "status 502")
+
+	status = http.StatusOK
+	_, err = client.Moderate(context.Background(), srv.URL, &service.GuardrailHookRequest{})
+	require.ErrorContains(t, err, "decode guardrail hook response")
+}
@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const guardrailPolicyColumns = `
+	id, name, group_id, api_key_id, enabled, action, detectors, keywords, patterns,
+	hook_url, scan_responses, created_at, updated_at
+`
+
+const guardrailEventColumns = `
+	id, policy_id, policy_name, user_id, api_key_id, group_id, direction, action, findings, model, created_at
+`
+
+type guardrailRepository struct {
+	sql sqlExecutor
+}
+
+// NewGuardrailRepository 创建内容策略与命中记录仓储
+func NewGuardrailRepository(sqlDB *sql.DB) service.GuardrailRepository {
+	return newGuardrailRepositoryWithSQL(sqlDB)
+}
+
+func newGuardrailRepositoryWithSQL(sqlq sqlExecutor) *guardrailRepository {
+	return &guardrailRepository{sql: sqlq}
+}
+
+func (r *guardrailRepository) ListPolicies(ctx context.Context) ([]service.GuardrailPolicy, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+guardrailPolicyColumns+" FROM guardrail_policies ORDER BY id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.GuardrailPolicy, 0)
+	for rows.Next() {
+		policy, err := scanGuardrailPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *policy)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *guardrailRepository) GetPolicy(ctx context.Context, id int64) (*service.GuardrailPolicy, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+guardrailPolicyColumns+" FROM guardrail_policies WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrGuardrailPolicyNotFound
+	}
+	return scanGuardrailPolicy(rows)
+}
+
+func (r *guardrailRepository) CreatePolicy(ctx context.Context, policy *service.GuardrailPolicy) error {
+	args, err := guardrailPolicyArgs(policy)
+	if err != nil {
+		return err
+	}
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO guardrail_policies (
+			name, group_id, api_key_id, enabled, action, detectors, keywords, patterns,
+			hook_url, scan_responses, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`, args, &policy.ID, &policy.CreatedAt, &policy.UpdatedAt)
+}
+
+func (r *guardrailRepository) UpdatePolicy(ctx context.Context, policy *service.GuardrailPolicy) error {
+	args, err := guardrailPolicyArgs(policy)
+	if err != nil {
+		return err
+	}
+	err = scanSingleRow(ctx, r.sql, `
+		UPDATE guardrail_policies
+		SET name = $2, group_id = $3, api_key_id = $4, enabled = $5, action = $6, detectors = $7,
+			keywords = $8, patterns = $9, hook_url = $10, scan_responses = $11, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`, append([]any{policy.ID}, args...), &policy.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return service.ErrGuardrailPolicyNotFound
+	}
+	return err
+}
+
+func (r *guardrailRepository) DeletePolicy(ctx context.Context, id int64) error {
+	res, err := r.sql.ExecContext(ctx, "DELETE FROM guardrail_policies WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrGuardrailPolicyNotFound
+	}
+	return nil
+}
+
+func (r *guardrailRepository) CreateEvent(ctx context.Context, event *service.GuardrailEvent) error {
+	findings, err := json.Marshal(event.Findings)
+	if err != nil {
+		return err
+	}
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO guardrail_events (
+			policy_id, policy_name, user_id, api_key_id, group_id, direction, action, findings, model, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+		RETURNING id, created_at
+	`, []any{
+		event.PolicyID, event.PolicyName, event.UserID, event.APIKeyID, event.GroupID,
+		event.Direction, event.Action, string(findings), event.Model,
+	}, &event.ID, &event.CreatedAt)
+}
+
+func (r *guardrailRepository) ListEvents(ctx context.Context, params pagination.PaginationParams, filters service.GuardrailEventFilters) ([]service.GuardrailEvent, *pagination.PaginationResult, error) {
+	conditions := []string{"1=1"}
+	args := []any{}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+	if filters.PolicyID != nil {
+		add("policy_id = $%d", *filters.PolicyID)
+	}
+	if filters.APIKeyID != nil {
+		add("api_key_id = $%d", *filters.APIKeyID)
+	}
+	if filters.GroupID != nil {
+		add("group_id = $%d", *filters.GroupID)
+	}
+	if filters.Direction != "" {
+		add("direction = $%d", filters.Direction)
+	}
+	if filters.StartTime != nil {
+		add("created_at >= $%d", filters.StartTime.UTC())
+	}
+	if filters.EndTime != nil {
+		add("created_at < $%d", filters.EndTime.UTC())
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM guardrail_events"+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.GuardrailEvent{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := "SELECT " + guardrailEventColumns + " FROM guardrail_events" + where +
+		fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.GuardrailEvent, 0)
+	for rows.Next() {
+		var (
+			item     service.GuardrailEvent
+			findings []byte
+		)
+		if err := rows.Scan(
+			&item.ID, &item.PolicyID, &item.PolicyName, &item.UserID, &item.APIKeyID, &item.GroupID,
+			&item.Direction, &item.Action, &findings, &item.Model, &item.CreatedAt,
+		); err != nil {
+			return nil, nil, err
+		}
+		if err := json.Unmarshal(findings, &item.Findings); err != nil {
+			return nil, nil, fmt.Errorf("decode guardrail event findings: %w", err)
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
+
+func (r *guardrailRepository) DeleteEventsBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	result, err := r.sql.ExecContext(ctx, `
+		DELETE FROM guardrail_events WHERE id IN (
+			SELECT id FROM guardrail_events WHERE created_at < $1 ORDER BY created_at LIMIT $2
+		)
+	`, before.UTC(), limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func guardrailPolicyArgs(policy *service.GuardrailPolicy) ([]any, error) {
+	lists := make([]string, 0, 3)
+	for _, values := range [][]string{policy.Detectors, policy.Keywords, policy.Patterns} {
+		if values == nil {
+			values = []string{}
+		}
+		raw, err := json.Marshal(values)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, string(raw))
+	}
+	return []any{
+		policy.Name, policy.GroupID, policy.APIKeyID, policy.Enabled, policy.Action,
+		lists[0], lists[1], lists[2], policy.HookURL, policy.ScanResponses,
+	}, nil
+}
+
+func scanGuardrailPolicy(rows *sql.Rows) (*service.GuardrailPolicy, error) {
+	var (
+		policy                        service.GuardrailPolicy
+		detectors, keywords, patterns []byte
+	)
+	if err := rows.Scan(
+		&policy.ID, &policy.Name, &policy.GroupID, &policy.APIKeyID, &policy.Enabled, &policy.Action,
+		&detectors, &keywords, &patterns, &policy.HookURL, &policy.ScanResponses,
+		&policy.CreatedAt, &policy.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	for _, f := range []struct {
+		raw []byte
+		dst *[]string
+	}{{detectors, &policy.Detectors}, {keywords, &policy.Keywords}, {patterns, &policy.Patterns}} {
+		if err := json.Unmarshal(f.raw, f.dst); err != nil {
+			return nil, fmt.Errorf("decode guardrail policy %d: %w", policy.ID, err)
+		}
+	}
+	return &policy, nil
+}
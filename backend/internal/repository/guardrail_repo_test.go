@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuardrailRepositoryPolicyRoundTrip(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newGuardrailRepositoryWithSQL(db)
+
+	now := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	groupID := int64(2)
+	mock.ExpectQuery("INSERT INTO guardrail_policies").
+		WithArgs("secrets", &groupID, nil, true, "redact", `["aws_key","jwt"]`, `[]`, `[]`, "", true).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(int64(5), now, now))
+	mock.ExpectQuery("SELECT (.|\n)*FROM guardrail_policies ORDER BY id ASC").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "name", "group_id", "api_key_id", "enabled", "action", "detectors", "keywords", "patterns",
+			"hook_url", "scan_responses", "created_at", "updated_at",
+		}).AddRow(int64(5), "secrets", groupID, nil, true, "redact", []byte(`["aws_key","jwt"]`), []byte(`[]`), []byte(`["foo\\d+"]`), "", true, now, now))
+
+	policy := &service.GuardrailPolicy{
+		Name: "secrets", GroupID: &groupID, Enabled: true, Action: service.GuardrailActionRedact,
+		Detectors: []string{service.GuardrailDetectorAWSKey, service.GuardrailDetectorJWT}, ScanResponses: true,
+	}
+	require.NoError(t, repo.CreatePolicy(context.Background(), policy))
+	require.Equal(t, int64(5), policy.ID)
+
+	policies, err := repo.ListPolicies(context.Background())
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	require.Equal(t, groupID, *policies[0].GroupID)
+	require.Nil(t, policies[0].APIKeyID)
+	require.Equal(t, []string{`foo\d+`}, policies[0].Patterns)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGuardrailRepositoryUpdateMissingPolicy(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newGuardrailRepositoryWithSQL(db)
+
+	mock.ExpectQuery("UPDATE guardrail_policies").
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}))
+
+	err := repo.UpdatePolicy(context.Background(), &service.GuardrailPolicy{ID: 9, Name: "x", Action: service.GuardrailActionLog})
+	require.ErrorIs(t, err, service.ErrGuardrailPolicyNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGuardrailRepositoryListEvents(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newGuardrailRepositoryWithSQL(db)
+
+	now := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	apiKeyID := int64(9)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM guardrail_events WHERE 1=1 AND api_key_id = \\$1 AND direction = \\$2").
+		WithArgs(apiKeyID, service.GuardrailDirectionResponse).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(1)))
+	mock.ExpectQuery("FROM guardrail_events WHERE 1=1 AND api_key_id = \\$1 AND direction = \\$2 ORDER BY id DESC LIMIT \\$3 OFFSET \\$4").
+		WithArgs(apiKeyID, service.GuardrailDirectionResponse, 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "policy_id", "policy_name", "user_id", "api_key_id", "group_id", "direction", "action", "findings", "model", "created_at",
+		}).AddRow(int64(1), int64(5), "secrets", int64(3), apiKeyID, nil, "response", "redact", []byte(`{"email":2}`), "gpt-5", now))
+
+	items, result, err := repo.ListEvents(context.Background(), pagination.PaginationParams{Page: 1, PageSize: 20}, service.GuardrailEventFilters{
+		APIKeyID:  &apiKeyID,
+		Direction: service.GuardrailDirectionResponse,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.Total)
+	require.Len(t, items, 1)
+	require.Equal(t, 2, items[0].Findings[service.GuardrailDetectorEmail])
+	require.Nil(t, items[0].GroupID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
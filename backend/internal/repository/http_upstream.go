@@ -238,7 +238,8 @@ func (s *httpUpstreamService) getClientEntryWithTLS(proxyURL string, accountID i
 	proxyKey, parsedProxy := normalizeProxyURL(proxyURL)
 	// TLS 指纹客户端使用独立的缓存键，加 "tls:" 前缀
 	cacheKey := "tls:" + buildCacheKey(isolation, proxyKey, accountID)
-	poolKey := s.buildPoolKey(isolation, accountConcurrency) + ":tls"
+	settings := s.resolvePoolSettings(isolation, accountConcurrency)
+	poolKey := s.buildPoolKey(isolation, accountConcurrency, settings) + ":tls:" + tlsProfileKey(profile)
 
 	now := time.Now()
 	nowUnix := now.UnixNano()
@@ -289,7 +290,6 @@ func (s *httpUpstreamService) getClientEntryWithTLS(proxyURL string, accountID i
 
 	// 创建带 TLS 指纹的 Transport
 	slog.Debug("tls_fingerprint_creating_new_client", "account_id", accountID, "cache_key", cacheKey, "proxy", proxyKey)
-	settings := s.resolvePoolSettings(isolation, accountConcurrency)
 	transport, err := buildUpstreamTransportWithTLSFingerprint(settings, parsedProxy, profile)
 	if err != nil {
 		s.mu.Unlock()
@@ -389,7 +389,8 @@ func (s *httpUpstreamService) getClientEntry(proxyURL string, accountID int64, a
 	// 构建缓存键（根据隔离策略不同）
 	cacheKey := buildCacheKey(isolation, proxyKey, accountID)
 	// 构建连接池配置键（用于检测配置变更）
-	poolKey := s.buildPoolKey(isolation, accountConcurrency)
+	settings := s.resolvePoolSettings(isolation, accountConcurrency)
+	poolKey := s.buildPoolKey(isolation, accountConcurrency, settings)
 
 	now := time.Now()
 	nowUnix := now.UnixNano()
@@ -432,7 +433,6 @@ func (s *httpUpstreamService) getClientEntry(proxyURL string, accountID int64, a
 	}
 
 	// 缓存未命中或需要重建，创建新客户端
-	settings := s.resolvePoolSettings(isolation, accountConcurrency)
 	transport, err := buildUpstreamTransport(settings, parsedProxy)
 	if err != nil {
 		s.mu.Unlock()
@@ -569,7 +569,7 @@ func (s *httpUpstreamService) getIsolationMode() string {
 	if s.cfg == nil {
 		return config.ConnectionPoolIsolationAccountProxy
 	}
-	mode := strings.ToLower(strings.TrimSpace(s.cfg.Gateway.ConnectionPoolIsolation))
+	mode := strings.ToLower(strings.TrimSpace(s.cfg.Current().Gateway.ConnectionPoolIsolation))
 	if mode == "" {
 		return config.ConnectionPoolIsolationAccountProxy
 	}
@@ -587,8 +587,9 @@ func (s *httpUpstreamService) maxUpstreamClients() int {
 	if s.cfg == nil {
 		return defaultMaxUpstreamClients
 	}
-	if s.cfg.Gateway.MaxUpstreamClients > 0 {
-		return s.cfg.Gateway.MaxUpstreamClients
+	cfg := s.cfg.Current()
+	if cfg.Gateway.MaxUpstreamClients > 0 {
+		return cfg.Gateway.MaxUpstreamClients
 	}
 	return defaultMaxUpstreamClients
 }
@@ -599,8 +600,9 @@ func (s *httpUpstreamService) clientIdleTTL() time.Duration {
 	if s.cfg == nil {
 		return time.Duration(defaultClientIdleTTLSeconds) * time.Second
 	}
-	if s.cfg.Gateway.ClientIdleTTLSeconds > 0 {
-		return time.Duration(s.cfg.Gateway.ClientIdleTTLSeconds) * time.Second
+	cfg := s.cfg.Current()
+	if cfg.Gateway.ClientIdleTTLSeconds > 0 {
+		return time.Duration(cfg.Gateway.ClientIdleTTLSeconds) * time.Second
 	}
 	return time.Duration(defaultClientIdleTTLSeconds) * time.Second
 }
@@ -619,7 +621,7 @@ func (s *httpUpstreamService) clientIdleTTL() time.Duration {
 //   - 账户隔离模式下，连接池大小与账户并发数对应
 //   - 这确保了单账户不会占用过多连接资源
 func (s *httpUpstreamService) resolvePoolSettings(isolation string, accountConcurrency int) poolSettings {
-	settings := defaultPoolSettings(s.cfg)
+	settings := defaultPoolSettings(s.cfg.Current())
 	// 账户隔离模式下，根据账户并发数调整连接池大小
 	if (isolation == config.ConnectionPoolIsolationAccount || isolation == config.ConnectionPoolIsolationAccountProxy) && accountConcurrency > 0 {
 		settings.maxIdleConns = accountConcurrency
@@ -635,16 +637,28 @@ func (s *httpUpstreamService) resolvePoolSettings(isolation string, accountConcu
 // 参数:
 //   - isolation: 隔离模式
 //   - accountConcurrency: 账户并发限制
+//   - settings: 当前生效的连接池配置（热更新后取值变化，缓存的客户端随之重建）
 //
 // 返回:
 //   - string: 配置键
-func (s *httpUpstreamService) buildPoolKey(isolation string, accountConcurrency int) string {
+func (s *httpUpstreamService) buildPoolKey(isolation string, accountConcurrency int, settings poolSettings) string {
+	base := "default"
 	if isolation == config.ConnectionPoolIsolationAccount || isolation == config.ConnectionPoolIsolationAccountProxy {
 		if accountConcurrency > 0 {
-			return fmt.Sprintf("account:%d", accountConcurrency)
+			base = fmt.Sprintf("account:%d", accountConcurrency)
 		}
 	}
-	return "default"
+	return fmt.Sprintf("%s|%d/%d/%d/%s/%s", base,
+		settings.maxIdleConns, settings.maxIdleConnsPerHost, settings.maxConnsPerHost,
+		settings.idleConnTimeout, settings.responseHeaderTimeout)
+}
+
+// tlsProfileKey TLS 指纹模板的内容标识，热更新替换模板后缓存的指纹客户端随之重建
+func tlsProfileKey(profile *tlsfingerprint.Profile) string {
+	if profile == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/%v/%v/%v/%t", profile.Name, profile.CipherSuites, profile.Curves, profile.PointFormats, profile.EnableGREASE)
 }
 
 // buildCacheKey 构建客户端缓存键
@@ -61,6 +61,26 @@ func (s *HTTPUpstreamSuite) TestCustomResponseHeaderTimeout() {
 	require.Equal(s.T(), 7*time.Second, transport.ResponseHeaderTimeout, "ResponseHeaderTimeout mismatch")
 }
 
+// TestReloadRebuildsClientWithNewResponseHeaderTimeout 测试热更新后缓存的客户端按新配置重建
+// 验证连接池配置变化后，下一次请求使用新的 ResponseHeaderTimeout
+func (s *HTTPUpstreamSuite) TestReloadRebuildsClientWithNewResponseHeaderTimeout() {
+	s.cfg.Gateway = config.GatewayConfig{ResponseHeaderTimeout: 7}
+	svc := s.newService()
+	entry1 := svc.getOrCreateClient("", 0, 0)
+	require.Same(s.T(), entry1, svc.getOrCreateClient("", 0, 0), "配置未变化时应复用客户端")
+
+	next := *s.cfg
+	next.Gateway.ResponseHeaderTimeout = 11
+	config.ApplyReloadable(s.cfg, &next)
+
+	entry2 := svc.getOrCreateClient("", 0, 0)
+	require.NotSame(s.T(), entry1, entry2, "热更新连接池配置后应重建客户端")
+	transport, ok := entry2.client.Transport.(*http.Transport)
+	require.True(s.T(), ok, "expected *http.Transport")
+	require.Equal(s.T(), 11*time.Second, transport.ResponseHeaderTimeout, "ResponseHeaderTimeout mismatch")
+	require.False(s.T(), hasEntry(svc, entry1), "旧客户端应被清理")
+}
+
 // TestGetOrCreateClient_InvalidURLFallsBackToDirect 测试无效代理 URL 回退
 // 验证解析失败时回退到直连模式
 func (s *HTTPUpstreamSuite) TestGetOrCreateClient_InvalidURLFallsBackToDirect() {
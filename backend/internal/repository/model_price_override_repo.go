@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const modelPriceOverrideColumns = `
+	id, model_pattern, group_id, input_price, output_price, cache_creation_price, cache_read_price,
+	cache_creation_1h_price, effective_from, notes, created_by, created_at, deleted_at
+`
+
+type modelPriceOverrideRepository struct {
+	sql sqlExecutor
+}
+
+func NewModelPriceOverrideRepository(sqlDB *sql.DB) service.ModelPriceOverrideRepository {
+	return newModelPriceOverrideRepositoryWithSQL(sqlDB)
+}
+
+func newModelPriceOverrideRepositoryWithSQL(sqlq sqlExecutor) *modelPriceOverrideRepository {
+	return &modelPriceOverrideRepository{sql: sqlq}
+}
+
+func (r *modelPriceOverrideRepository) ListEffective(ctx context.Context) ([]service.ModelPriceOverride, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+modelPriceOverrideColumns+`
+		FROM model_price_overrides
+		WHERE deleted_at IS NULL
+		ORDER BY id ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	return scanModelPriceOverrides(rows)
+}
+
+func (r *modelPriceOverrideRepository) List(ctx context.Context, params pagination.PaginationParams, filters service.ModelPriceOverrideListFilters) ([]service.ModelPriceOverride, *pagination.PaginationResult, error) {
+	conditions := []string{"1=1"}
+	args := []any{}
+	if !filters.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if filters.Search != "" {
+		args = append(args, "%"+strings.ToLower(filters.Search)+"%")
+		conditions = append(conditions, fmt.Sprintf("model_pattern LIKE $%d", len(args)))
+	}
+	if filters.GroupID != nil {
+		args = append(args, *filters.GroupID)
+		conditions = append(conditions, fmt.Sprintf("group_id = $%d", len(args)))
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM model_price_overrides"+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.ModelPriceOverride{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := "SELECT " + modelPriceOverrideColumns + " FROM model_price_overrides" + where +
+		fmt.Sprintf(" ORDER BY model_pattern ASC, group_id ASC NULLS FIRST, effective_from DESC, id DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	items, err := scanModelPriceOverrides(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	return items, paginationResultFromTotal(total, params), nil
+}
+
+func (r *modelPriceOverrideRepository) Create(ctx context.Context, override *service.ModelPriceOverride) error {
+	if override == nil {
+		return nil
+	}
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO model_price_overrides
+			(model_pattern, group_id, input_price, output_price, cache_creation_price, cache_read_price,
+			 cache_creation_1h_price, effective_from, notes, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+		RETURNING id, created_at
+	`, []any{
+		override.ModelPattern,
+		nullInt64(override.GroupID),
+		override.InputPrice,
+		override.OutputPrice,
+		override.CacheCreationPrice,
+		override.CacheReadPrice,
+		override.CacheCreation1hPrice,
+		override.EffectiveFrom,
+		override.Notes,
+		nullInt64(override.CreatedBy),
+	}, &override.ID, &override.CreatedAt)
+}
+
+func (r *modelPriceOverrideRepository) SoftDelete(ctx context.Context, id int64) error {
+	res, err := r.sql.ExecContext(ctx,
+		"UPDATE model_price_overrides SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrPriceOverrideNotFound
+	}
+	return nil
+}
+
+func scanModelPriceOverrides(rows *sql.Rows) ([]service.ModelPriceOverride, error) {
+	out := make([]service.ModelPriceOverride, 0)
+	for rows.Next() {
+		var (
+			o         service.ModelPriceOverride
+			groupID   sql.NullInt64
+			createdBy sql.NullInt64
+			deletedAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&o.ID,
+			&o.ModelPattern,
+			&groupID,
+			&o.InputPrice,
+			&o.OutputPrice,
+			&o.CacheCreationPrice,
+			&o.CacheReadPrice,
+			&o.CacheCreation1hPrice,
+			&o.EffectiveFrom,
+			&o.Notes,
+			&createdBy,
+			&o.CreatedAt,
+			&deletedAt,
+		); err != nil {
+			return nil, err
+		}
+		o.GroupID = nullInt64Ptr(groupID)
+		o.CreatedBy = nullInt64Ptr(createdBy)
+		o.DeletedAt = nullTimePtr(deletedAt)
+		out = append(out, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
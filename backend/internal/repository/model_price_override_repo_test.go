@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelPriceOverrideRepositoryCreateReturnsID(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newModelPriceOverrideRepositoryWithSQL(db)
+
+	groupID := int64(3)
+	adminID := int64(1)
+	effective := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("INSERT INTO model_price_overrides").
+		WithArgs("claude-*", groupID, 3.0, 15.0, 3.75, 0.3, 6.0, effective, "launch", adminID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(int64(9), created))
+
+	override := &service.ModelPriceOverride{
+		ModelPattern:         "claude-*",
+		GroupID:              &groupID,
+		InputPrice:           3,
+		OutputPrice:          15,
+		CacheCreationPrice:   3.75,
+		CacheReadPrice:       0.3,
+		CacheCreation1hPrice: 6,
+		EffectiveFrom:        effective,
+		Notes:                "launch",
+		CreatedBy:            &adminID,
+	}
+	require.NoError(t, repo.Create(context.Background(), override))
+	require.Equal(t, int64(9), override.ID)
+	require.Equal(t, created, override.CreatedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestModelPriceOverrideRepositorySoftDeleteNotFound(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newModelPriceOverrideRepositoryWithSQL(db)
+
+	mock.ExpectExec("UPDATE model_price_overrides SET deleted_at = NOW\\(\\) WHERE id = \\$1 AND deleted_at IS NULL").
+		WithArgs(int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.SoftDelete(context.Background(), 5)
+	require.ErrorIs(t, err, service.ErrPriceOverrideNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
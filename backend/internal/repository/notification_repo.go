@@ -0,0 +1,377 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// notificationRecipientColumns 通知接收方字段（users u LEFT JOIN user_notification_settings s）
+const notificationRecipientColumns = `
+	u.id, u.email, COALESCE(s.email_enabled, TRUE), COALESCE(s.webhook_url, ''), COALESCE(s.webhook_secret, '')
+`
+
+type notificationRepository struct {
+	sql sqlExecutor
+}
+
+func NewNotificationRepository(sqlDB *sql.DB) service.NotificationRepository {
+	return newNotificationRepositoryWithSQL(sqlDB)
+}
+
+func newNotificationRepositoryWithSQL(sqlq sqlExecutor) *notificationRepository {
+	return &notificationRepository{sql: sqlq}
+}
+
+func (r *notificationRepository) GetSettings(ctx context.Context, userID int64) (*service.NotificationSettings, error) {
+	query := `
+		SELECT user_id, email_enabled, webhook_url, webhook_secret,
+			low_balance_enabled, low_balance_threshold, quota_alerts_enabled,
+			expiry_alerts_enabled, expiry_reminder_days, api_key_alerts_enabled,
+			created_at, updated_at
+		FROM user_notification_settings
+		WHERE user_id = $1
+	`
+	var (
+		settings     service.NotificationSettings
+		threshold    sql.NullFloat64
+		reminderDays sql.NullInt64
+	)
+	err := scanSingleRow(ctx, r.sql, query, []any{userID},
+		&settings.UserID,
+		&settings.EmailEnabled,
+		&settings.WebhookURL,
+		&settings.WebhookSecret,
+		&settings.LowBalanceEnabled,
+		&threshold,
+		&settings.QuotaAlertsEnabled,
+		&settings.ExpiryAlertsEnabled,
+		&reminderDays,
+		&settings.APIKeyAlertsEnabled,
+		&settings.CreatedAt,
+		&settings.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, service.ErrNotificationSettingsNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	settings.LowBalanceThreshold = nullFloat64Ptr(threshold)
+	if reminderDays.Valid {
+		v := int(reminderDays.Int64)
+		settings.ExpiryReminderDays = &v
+	}
+	return &settings, nil
+}
+
+func (r *notificationRepository) UpsertSettings(ctx context.Context, settings *service.NotificationSettings) error {
+	if settings == nil {
+		return nil
+	}
+	query := `
+		INSERT INTO user_notification_settings (
+			user_id, email_enabled, webhook_url, webhook_secret,
+			low_balance_enabled, low_balance_threshold, quota_alerts_enabled,
+			expiry_alerts_enabled, expiry_reminder_days, api_key_alerts_enabled,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			email_enabled = EXCLUDED.email_enabled,
+			webhook_url = EXCLUDED.webhook_url,
+			webhook_secret = EXCLUDED.webhook_secret,
+			low_balance_enabled = EXCLUDED.low_balance_enabled,
+			low_balance_threshold = EXCLUDED.low_balance_threshold,
+			quota_alerts_enabled = EXCLUDED.quota_alerts_enabled,
+			expiry_alerts_enabled = EXCLUDED.expiry_alerts_enabled,
+			expiry_reminder_days = EXCLUDED.expiry_reminder_days,
+			api_key_alerts_enabled = EXCLUDED.api_key_alerts_enabled,
+			updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+	args := []any{
+		settings.UserID,
+		settings.EmailEnabled,
+		settings.WebhookURL,
+		settings.WebhookSecret,
+		settings.LowBalanceEnabled,
+		settings.LowBalanceThreshold,
+		settings.QuotaAlertsEnabled,
+		settings.ExpiryAlertsEnabled,
+		nullInt(settings.ExpiryReminderDays),
+		settings.APIKeyAlertsEnabled,
+	}
+	return scanSingleRow(ctx, r.sql, query, args, &settings.CreatedAt, &settings.UpdatedAt)
+}
+
+func (r *notificationRepository) Record(ctx context.Context, n *service.Notification) (bool, error) {
+	if n == nil {
+		return false, nil
+	}
+	query := `
+		INSERT INTO user_notifications (user_id, type, dedup_key, title, message, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (user_id, type, dedup_key) DO NOTHING
+		RETURNING id, created_at
+	`
+	err := scanSingleRow(ctx, r.sql, query, []any{n.UserID, n.Type, n.DedupKey, n.Title, n.Message}, &n.ID, &n.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *notificationRepository) UpdateDelivery(ctx context.Context, id int64, emailSent, webhookSent bool, deliveryError string) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE user_notifications SET email_sent = $2, webhook_sent = $3, delivery_error = $4
+		WHERE id = $1
+	`, id, emailSent, webhookSent, nullString(&deliveryError))
+	return err
+}
+
+func (r *notificationRepository) ListByUser(ctx context.Context, userID int64, params pagination.PaginationParams) ([]service.Notification, *pagination.PaginationResult, error) {
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM user_notifications WHERE user_id = $1", []any{userID}, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.Notification{}, paginationResultFromTotal(0, params), nil
+	}
+
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT id, user_id, type, dedup_key, title, message, email_sent, webhook_sent, delivery_error, created_at
+		FROM user_notifications
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, userID, params.Limit(), params.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.Notification, 0)
+	for rows.Next() {
+		var (
+			n             service.Notification
+			deliveryError sql.NullString
+		)
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.DedupKey, &n.Title, &n.Message, &n.EmailSent, &n.WebhookSent, &deliveryError, &n.CreatedAt); err != nil {
+			return nil, nil, err
+		}
+		n.DeliveryError = deliveryError.String
+		out = append(out, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
+
+func (r *notificationRepository) ListLowBalance(ctx context.Context, defaultThreshold float64, limit int) ([]service.LowBalanceCandidate, error) {
+	query := `
+		SELECT ` + notificationRecipientColumns + `, u.balance, COALESCE(s.low_balance_threshold, $1)
+		FROM users u
+		LEFT JOIN user_notification_settings s ON s.user_id = u.id
+		WHERE u.deleted_at IS NULL
+			AND u.status = 'active'
+			AND u.role <> 'organization'
+			AND COALESCE(s.low_balance_enabled, TRUE)
+			AND COALESCE(s.low_balance_threshold, $1) > 0
+			AND u.balance < COALESCE(s.low_balance_threshold, $1)
+			AND NOT EXISTS (
+				SELECT 1 FROM user_notifications n
+				WHERE n.user_id = u.id AND n.type = $2 AND n.dedup_key = $3
+			)
+		ORDER BY u.id
+		LIMIT $4
+	`
+	rows, err := r.sql.QueryContext(ctx, query, defaultThreshold, service.NotificationTypeLowBalance, service.LowBalanceNotificationDedupKey, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.LowBalanceCandidate, 0)
+	for rows.Next() {
+		var c service.LowBalanceCandidate
+		if err := rows.Scan(recipientScanTargets(&c.NotificationRecipient, &c.Balance, &c.Threshold)...); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *notificationRepository) ResetLowBalance(ctx context.Context, defaultThreshold float64) (int64, error) {
+	res, err := r.sql.ExecContext(ctx, `
+		UPDATE user_notifications n
+		SET dedup_key = n.dedup_key || ':' || n.id
+		FROM users u
+		LEFT JOIN user_notification_settings s ON s.user_id = u.id
+		WHERE n.user_id = u.id
+			AND n.type = $2
+			AND n.dedup_key = $3
+			AND u.balance >= COALESCE(s.low_balance_threshold, $1)
+	`, defaultThreshold, service.NotificationTypeLowBalance, service.LowBalanceNotificationDedupKey)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// subscriptionCandidateColumns 订阅提醒字段；限额优先取订阅覆盖值，否则取分组设置
+const subscriptionCandidateColumns = `
+	us.id, us.group_id, g.name, us.expires_at,
+	us.daily_window_start, us.weekly_window_start, us.monthly_window_start,
+	us.daily_usage_usd, us.weekly_usage_usd, us.monthly_usage_usd,
+	COALESCE(us.daily_limit_usd, g.daily_limit_usd),
+	COALESCE(us.weekly_limit_usd, g.weekly_limit_usd),
+	COALESCE(us.monthly_limit_usd, g.monthly_limit_usd)
+`
+
+// subscriptionCandidateFrom 组织付费账户没有真实邮箱，不参与提醒扫描
+const subscriptionCandidateFrom = `
+	FROM user_subscriptions us
+	JOIN users u ON u.id = us.user_id AND u.deleted_at IS NULL AND u.status = 'active' AND u.role <> 'organization'
+	JOIN groups g ON g.id = us.group_id
+	LEFT JOIN user_notification_settings s ON s.user_id = u.id
+	WHERE us.deleted_at IS NULL
+		AND us.status = 'active'
+		AND us.expires_at > NOW()
+`
+
+func (r *notificationRepository) ListQuotaUsage(ctx context.Context, warnRatio float64, limit int) ([]service.SubscriptionNotificationCandidate, error) {
+	query := `SELECT ` + notificationRecipientColumns + `, ` + subscriptionCandidateColumns +
+		subscriptionCandidateFrom + `
+		AND COALESCE(s.quota_alerts_enabled, TRUE)
+		AND (
+			(us.daily_window_start > NOW() - INTERVAL '1 day'
+				AND COALESCE(us.daily_limit_usd, g.daily_limit_usd) > 0
+				AND us.daily_usage_usd >= COALESCE(us.daily_limit_usd, g.daily_limit_usd) * $1)
+			OR (us.weekly_window_start > NOW() - INTERVAL '7 days'
+				AND COALESCE(us.weekly_limit_usd, g.weekly_limit_usd) > 0
+				AND us.weekly_usage_usd >= COALESCE(us.weekly_limit_usd, g.weekly_limit_usd) * $1)
+			OR (us.monthly_window_start > NOW() - INTERVAL '30 days'
+				AND COALESCE(us.monthly_limit_usd, g.monthly_limit_usd) > 0
+				AND us.monthly_usage_usd >= COALESCE(us.monthly_limit_usd, g.monthly_limit_usd) * $1)
+		)
+		ORDER BY us.id
+		LIMIT $2
+	`
+	return r.querySubscriptionCandidates(ctx, query, warnRatio, limit)
+}
+
+func (r *notificationRepository) ListExpiringSubscriptions(ctx context.Context, defaultDays int, limit int) ([]service.SubscriptionNotificationCandidate, error) {
+	query := `SELECT ` + notificationRecipientColumns + `, ` + subscriptionCandidateColumns +
+		subscriptionCandidateFrom + `
+		AND COALESCE(s.expiry_alerts_enabled, TRUE)
+		AND NOT us.auto_renew
+		AND us.expires_at <= NOW() + make_interval(days => COALESCE(s.expiry_reminder_days, $1))
+		ORDER BY us.expires_at, us.id
+		LIMIT $2
+	`
+	return r.querySubscriptionCandidates(ctx, query, defaultDays, limit)
+}
+
+func (r *notificationRepository) querySubscriptionCandidates(ctx context.Context, query string, args ...any) ([]service.SubscriptionNotificationCandidate, error) {
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.SubscriptionNotificationCandidate, 0)
+	for rows.Next() {
+		var (
+			c                                   service.SubscriptionNotificationCandidate
+			dailyStart, weeklyStart, monthStart sql.NullTime
+			dailyLimit, weeklyLimit, monthLimit sql.NullFloat64
+		)
+		targets := recipientScanTargets(&c.NotificationRecipient,
+			&c.SubscriptionID, &c.GroupID, &c.GroupName, &c.ExpiresAt,
+			&dailyStart, &weeklyStart, &monthStart,
+			&c.DailyUsageUSD, &c.WeeklyUsageUSD, &c.MonthlyUsageUSD,
+			&dailyLimit, &weeklyLimit, &monthLimit,
+		)
+		if err := rows.Scan(targets...); err != nil {
+			return nil, err
+		}
+		c.DailyWindowStart = nullTimePtr(dailyStart)
+		c.WeeklyWindowStart = nullTimePtr(weeklyStart)
+		c.MonthlyWindowStart = nullTimePtr(monthStart)
+		c.DailyLimitUSD = nullFloat64Ptr(dailyLimit)
+		c.WeeklyLimitUSD = nullFloat64Ptr(weeklyLimit)
+		c.MonthlyLimitUSD = nullFloat64Ptr(monthLimit)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *notificationRepository) ListDisabledAPIKeys(ctx context.Context, since time.Time, limit int) ([]service.DisabledAPIKeyCandidate, error) {
+	query := `
+		SELECT ` + notificationRecipientColumns + `, k.id, k.name, k.updated_at
+		FROM api_keys k
+		JOIN users u ON u.id = k.user_id AND u.deleted_at IS NULL AND u.status = 'active'
+		LEFT JOIN user_notification_settings s ON s.user_id = u.id
+		WHERE k.deleted_at IS NULL
+			AND k.status = $1
+			AND k.updated_at > $2
+			AND COALESCE(s.api_key_alerts_enabled, TRUE)
+		ORDER BY k.updated_at, k.id
+		LIMIT $3
+	`
+	rows, err := r.sql.QueryContext(ctx, query, service.StatusDisabled, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.DisabledAPIKeyCandidate, 0)
+	for rows.Next() {
+		var c service.DisabledAPIKeyCandidate
+		if err := rows.Scan(recipientScanTargets(&c.NotificationRecipient, &c.APIKeyID, &c.APIKeyName, &c.UpdatedAt)...); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *notificationRepository) GetRecipient(ctx context.Context, userID int64) (*service.NotificationRecipient, error) {
+	query := `
+		SELECT ` + notificationRecipientColumns + `
+		FROM users u
+		LEFT JOIN user_notification_settings s ON s.user_id = u.id
+		WHERE u.id = $1 AND u.deleted_at IS NULL AND u.status = 'active'
+	`
+	var rcpt service.NotificationRecipient
+	err := scanSingleRow(ctx, r.sql, query, []any{userID}, recipientScanTargets(&rcpt)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, service.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rcpt, nil
+}
+
+// recipientScanTargets 按 notificationRecipientColumns 顺序拼接扫描目标
+func recipientScanTargets(rcpt *service.NotificationRecipient, rest ...any) []any {
+	return append([]any{&rcpt.UserID, &rcpt.Email, &rcpt.EmailEnabled, &rcpt.WebhookURL, &rcpt.WebhookSecret}, rest...)
+}
+
+func nullTimePtr(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	out := v.Time
+	return &out
+}
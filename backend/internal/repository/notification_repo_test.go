@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationRepositoryGetSettingsNotFound(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newNotificationRepositoryWithSQL(db)
+
+	mock.ExpectQuery("FROM user_notification_settings").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+
+	_, err := repo.GetSettings(context.Background(), 1)
+	require.ErrorIs(t, err, service.ErrNotificationSettingsNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNotificationRepositoryRecordDedup(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newNotificationRepositoryWithSQL(db)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("INSERT INTO user_notifications .* ON CONFLICT \\(user_id, type, dedup_key\\) DO NOTHING").
+		WithArgs(int64(1), service.NotificationTypeQuotaWarning, "5:daily:100", "t", "m").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(int64(9), now))
+	mock.ExpectQuery("INSERT INTO user_notifications").
+		WithArgs(int64(1), service.NotificationTypeQuotaWarning, "5:daily:100", "t", "m").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+
+	n := &service.Notification{UserID: 1, Type: service.NotificationTypeQuotaWarning, DedupKey: "5:daily:100", Title: "t", Message: "m"}
+	inserted, err := repo.Record(context.Background(), n)
+	require.NoError(t, err)
+	require.True(t, inserted)
+	require.Equal(t, int64(9), n.ID)
+
+	inserted, err = repo.Record(context.Background(), &service.Notification{UserID: 1, Type: service.NotificationTypeQuotaWarning, DedupKey: "5:daily:100", Title: "t", Message: "m"})
+	require.NoError(t, err)
+	require.False(t, inserted)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNotificationRepositoryListLowBalance(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newNotificationRepositoryWithSQL(db)
+
+	mock.ExpectQuery("FROM users u\\s+LEFT JOIN user_notification_settings s").
+		WithArgs(1.0, service.NotificationTypeLowBalance, service.LowBalanceNotificationDedupKey, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "email_enabled", "webhook_url", "webhook_secret", "balance", "threshold"}).
+			AddRow(int64(3), "u@example.com", true, "", "", 0.25, 1.0))
+
+	out, err := repo.ListLowBalance(context.Background(), 1.0, 50)
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+	require.Equal(t, int64(3), out[0].UserID)
+	require.True(t, out[0].EmailEnabled)
+	require.InDelta(t, 0.25, out[0].Balance, 1e-9)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/httpclient"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const (
+	notificationWebhookSignatureHeader = "X-Sub2api-Signature"
+	notificationWebhookTimestampHeader = "X-Sub2api-Timestamp"
+)
+
+// notificationWebhookSender 用户通知 Webhook 投递
+//
+// 签名：X-Sub2api-Signature = "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body))，
+// 接收方应校验签名并拒绝时间戳偏差过大的请求。
+//
+// Webhook 地址由用户填写：每次请求都校验解析后的 IP（防止 DNS rebinding 绕过保存时的校验），
+// 且不跟随重定向，3xx 响应按投递失败处理。
+type notificationWebhookSender struct {
+	httpClient *http.Client
+}
+
+func NewNotificationWebhookSender(cfg *config.Config) service.NotificationWebhookSender {
+	timeout := 10 * time.Second
+	allowPrivate := false
+	if cfg != nil {
+		if cfg.Notification.WebhookTimeoutSeconds > 0 {
+			timeout = time.Duration(cfg.Notification.WebhookTimeoutSeconds) * time.Second
+		}
+		allowPrivate = cfg.Security.URLAllowlist.AllowPrivateHosts
+	}
+	return newNotificationWebhookSender(timeout, allowPrivate)
+}
+
+func newNotificationWebhookSender(timeout time.Duration, allowPrivate bool) *notificationWebhookSender {
+	shared, err := httpclient.GetClient(httpclient.Options{
+		Timeout:            timeout,
+		ValidateResolvedIP: true,
+		AllowPrivateHosts:  allowPrivate,
+	})
+	if err != nil {
+		return &notificationWebhookSender{httpClient: &http.Client{Timeout: timeout, CheckRedirect: refuseWebhookRedirect}}
+	}
+	// 共享客户端不可修改，复制后关闭重定向
+	client := *shared
+	client.CheckRedirect = refuseWebhookRedirect
+	return &notificationWebhookSender{httpClient: &client}
+}
+
+func refuseWebhookRedirect(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+func (s *notificationWebhookSender) Send(ctx context.Context, rawURL, secret string, payload []byte) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return errors.New("invalid webhook url")
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sub2api-notifier")
+	req.Header.Set(notificationWebhookTimestampHeader, timestamp)
+	req.Header.Set(notificationWebhookSignatureHeader, "sha256="+signNotificationWebhook(secret, timestamp, payload))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func signNotificationWebhook(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return strings.ToLower(hex.EncodeToString(mac.Sum(nil)))
+}
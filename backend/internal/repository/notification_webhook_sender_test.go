@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationWebhookSenderSignsPayload(t *testing.T) {
+	var gotSignature, gotTimestamp string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get(notificationWebhookSignatureHeader)
+		gotTimestamp = r.Header.Get(notificationWebhookTimestampHeader)
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sender := newNotificationWebhookSender(5*time.Second, true)
+	payload := []byte(`{"type":"low_balance"}`)
+	require.NoError(t, sender.Send(context.Background(), srv.URL, "secret", payload))
+
+	require.Equal(t, payload, gotBody)
+	require.Equal(t, "sha256="+signNotificationWebhook("secret", gotTimestamp, payload), gotSignature)
+}
+
+func TestNotificationWebhookSenderRejectsPrivateAndErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	sender := newNotificationWebhookSender(5*time.Second, false)
+	require.ErrorContains(t, sender.Send(context.Background(), srv.URL, "s", []byte("{}")), "not allowed")
+
+	sender = newNotificationWebhookSender(5*time.Second, true)
+	require.ErrorContains(t, sender.Send(context.Background(), srv.URL, "s", []byte("{}")), "status 500")
+}
+
+func TestNotificationWebhookSenderDoesNotFollowRedirectToLoopback(t *testing.T) {
+	var hit atomic.Bool
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit.Store(true)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer internal.Close()
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL+"/latest/meta-data/", http.StatusFound)
+	}))
+	defer redirector.Close()
+
+	sender := newNotificationWebhookSender(5*time.Second, true)
+	err := sender.Send(context.Background(), redirector.URL, "s", []byte("{}"))
+	require.ErrorContains(t, err, "status 302")
+	require.False(t, hit.Load(), "redirect target must not be requested")
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/proxyutil"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gorilla/websocket"
+)
+
+const openAIRealtimeHandshakeTimeout = 30 * time.Second
+
+type openAIRealtimeDialer struct {
+	readLimit int64
+}
+
+// NewOpenAIRealtimeDialer 创建上游 Realtime WebSocket 拨号器（支持 HTTP/HTTPS/SOCKS5 代理）
+func NewOpenAIRealtimeDialer(cfg *config.Config) service.OpenAIRealtimeDialer {
+	var readLimit int64
+	if cfg != nil {
+		readLimit = cfg.Gateway.Realtime.MaxMessageBytes
+	}
+	return &openAIRealtimeDialer{readLimit: readLimit}
+}
+
+func (d *openAIRealtimeDialer) Dial(ctx context.Context, targetURL string, header http.Header, proxyURL string) (service.OpenAIRealtimeConn, *http.Response, error) {
+	dialer := &websocket.Dialer{HandshakeTimeout: openAIRealtimeHandshakeTimeout}
+	if raw := strings.TrimSpace(proxyURL); raw != "" {
+		parsed, err := url.Parse(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		// 复用 Transport 的代理配置逻辑，再迁移到 websocket.Dialer
+		transport := &http.Transport{}
+		if err := proxyutil.ConfigureTransportProxy(transport, parsed); err != nil {
+			return nil, nil, err
+		}
+		dialer.Proxy = transport.Proxy
+		dialer.NetDialContext = transport.DialContext
+	}
+
+	conn, resp, err := dialer.DialContext(ctx, targetURL, header)
+	if err != nil {
+		return nil, resp, err
+	}
+	if d.readLimit > 0 {
+		conn.SetReadLimit(d.readLimit)
+	}
+	return conn, resp, nil
+}
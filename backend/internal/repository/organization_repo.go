@@ -0,0 +1,379 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// organizationColumns 组织字段（organizations o JOIN users bu ON 付费账户），附带余额与成员数
+const organizationColumns = `
+	o.id, o.name, o.owner_user_id, o.billing_user_id, o.status, o.created_at, o.updated_at,
+	COALESCE(bu.balance, 0),
+	(SELECT COUNT(*) FROM organization_members om WHERE om.organization_id = o.id)
+`
+
+const organizationMemberColumns = `
+	m.id, m.organization_id, m.user_id, m.role, m.spending_limit_usd, m.spent_usd, m.spend_period,
+	m.created_at, m.updated_at, COALESCE(u.email, ''), COALESCE(u.username, '')
+`
+
+type organizationRepository struct {
+	sql sqlExecutor
+}
+
+func NewOrganizationRepository(sqlDB *sql.DB) service.OrganizationRepository {
+	return newOrganizationRepositoryWithSQL(sqlDB)
+}
+
+func newOrganizationRepositoryWithSQL(sqlq sqlExecutor) *organizationRepository {
+	return &organizationRepository{sql: sqlq}
+}
+
+// exec 在事务上下文中使用 tx 绑定的执行器，保证与付费账户创建/余额划转同事务
+func (r *organizationRepository) exec(ctx context.Context) sqlExecutor {
+	if tx := dbent.TxFromContext(ctx); tx != nil {
+		return tx.Client()
+	}
+	return r.sql
+}
+
+func (r *organizationRepository) Create(ctx context.Context, org *service.Organization, billing *service.User, owner *service.OrganizationMember) error {
+	if org == nil || billing == nil {
+		return nil
+	}
+	exec := r.exec(ctx)
+	err := scanSingleRow(ctx, exec, `
+		INSERT INTO users (email, password_hash, role, balance, concurrency, status, username, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, 0, $4, $5, $6, $7, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`, []any{billing.Email, billing.PasswordHash, billing.Role, billing.Concurrency, billing.Status, billing.Username, billing.Notes},
+		&billing.ID, &billing.CreatedAt, &billing.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	org.BillingUserID = billing.ID
+
+	err = scanSingleRow(ctx, exec, `
+		INSERT INTO organizations (name, owner_user_id, billing_user_id, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`, []any{org.Name, org.OwnerUserID, org.BillingUserID, org.Status},
+		&org.ID, &org.CreatedAt, &org.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if owner == nil {
+		return nil
+	}
+	owner.OrganizationID = org.ID
+	return r.AddMember(ctx, owner)
+}
+
+func (r *organizationRepository) GetByID(ctx context.Context, id int64) (*service.Organization, error) {
+	query := "SELECT " + organizationColumns + `
+		FROM organizations o
+		LEFT JOIN users bu ON bu.id = o.billing_user_id
+		WHERE o.id = $1 AND o.deleted_at IS NULL
+	`
+	rows, err := r.exec(ctx).QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrOrganizationNotFound
+	}
+	org, err := scanOrganization(rows)
+	if err != nil {
+		return nil, err
+	}
+	return org, rows.Err()
+}
+
+func (r *organizationRepository) Update(ctx context.Context, org *service.Organization) error {
+	if org == nil {
+		return nil
+	}
+	err := scanSingleRow(ctx, r.exec(ctx), `
+		UPDATE organizations SET name = $2, status = $3, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING updated_at
+	`, []any{org.ID, org.Name, org.Status}, &org.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return service.ErrOrganizationNotFound
+	}
+	return err
+}
+
+func (r *organizationRepository) List(ctx context.Context, params pagination.PaginationParams, filters service.OrganizationListFilters) ([]service.Organization, *pagination.PaginationResult, error) {
+	conditions := []string{"o.deleted_at IS NULL"}
+	args := make([]any, 0, 4)
+	if v := strings.TrimSpace(filters.Status); v != "" {
+		args = append(args, v)
+		conditions = append(conditions, fmt.Sprintf("o.status = $%d", len(args)))
+	}
+	if v := strings.TrimSpace(filters.Search); v != "" {
+		args = append(args, "%"+v+"%")
+		conditions = append(conditions, fmt.Sprintf("o.name ILIKE $%d", len(args)))
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM organizations o"+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.Organization{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := "SELECT " + organizationColumns + " FROM organizations o LEFT JOIN users bu ON bu.id = o.billing_user_id" + where +
+		fmt.Sprintf(" ORDER BY o.id DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	orgs := make([]service.Organization, 0)
+	for rows.Next() {
+		org, err := scanOrganization(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		orgs = append(orgs, *org)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return orgs, paginationResultFromTotal(total, params), nil
+}
+
+func (r *organizationRepository) ListByMember(ctx context.Context, userID int64) ([]service.OrganizationMembership, error) {
+	query := "SELECT " + organizationColumns + `, mm.role
+		FROM organization_members mm
+		JOIN organizations o ON o.id = mm.organization_id AND o.deleted_at IS NULL
+		LEFT JOIN users bu ON bu.id = o.billing_user_id
+		WHERE mm.user_id = $1
+		ORDER BY o.id ASC
+	`
+	rows, err := r.sql.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.OrganizationMembership, 0)
+	for rows.Next() {
+		var m service.OrganizationMembership
+		if err := rows.Scan(
+			&m.Organization.ID,
+			&m.Organization.Name,
+			&m.Organization.OwnerUserID,
+			&m.Organization.BillingUserID,
+			&m.Organization.Status,
+			&m.Organization.CreatedAt,
+			&m.Organization.UpdatedAt,
+			&m.Organization.Balance,
+			&m.Organization.MemberCount,
+			&m.Role,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *organizationRepository) GetMember(ctx context.Context, orgID, userID int64) (*service.OrganizationMember, error) {
+	query := "SELECT " + organizationMemberColumns + `
+		FROM organization_members m
+		LEFT JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = $1 AND m.user_id = $2
+	`
+	rows, err := r.exec(ctx).QueryContext(ctx, query, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrOrganizationMemberNotFound
+	}
+	member, err := scanOrganizationMember(rows)
+	if err != nil {
+		return nil, err
+	}
+	return member, rows.Err()
+}
+
+func (r *organizationRepository) ListMembers(ctx context.Context, orgID int64) ([]service.OrganizationMember, error) {
+	query := "SELECT " + organizationMemberColumns + `
+		FROM organization_members m
+		LEFT JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = $1
+		ORDER BY m.id ASC
+	`
+	rows, err := r.sql.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	members := make([]service.OrganizationMember, 0)
+	for rows.Next() {
+		member, err := scanOrganizationMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, *member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (r *organizationRepository) AddMember(ctx context.Context, member *service.OrganizationMember) error {
+	if member == nil {
+		return nil
+	}
+	err := scanSingleRow(ctx, r.exec(ctx), `
+		INSERT INTO organization_members (organization_id, user_id, role, spending_limit_usd, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		ON CONFLICT (organization_id, user_id) DO NOTHING
+		RETURNING id, created_at, updated_at
+	`, []any{member.OrganizationID, member.UserID, member.Role, member.SpendingLimitUSD},
+		&member.ID, &member.CreatedAt, &member.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return service.ErrOrganizationMemberExists
+	}
+	return err
+}
+
+func (r *organizationRepository) UpdateMember(ctx context.Context, member *service.OrganizationMember) error {
+	if member == nil {
+		return nil
+	}
+	err := scanSingleRow(ctx, r.exec(ctx), `
+		UPDATE organization_members SET role = $3, spending_limit_usd = $4, updated_at = NOW()
+		WHERE organization_id = $1 AND user_id = $2
+		RETURNING updated_at
+	`, []any{member.OrganizationID, member.UserID, member.Role, member.SpendingLimitUSD}, &member.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return service.ErrOrganizationMemberNotFound
+	}
+	return err
+}
+
+func (r *organizationRepository) RemoveMember(ctx context.Context, orgID, userID int64) error {
+	res, err := r.exec(ctx).ExecContext(ctx, "DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2", orgID, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrOrganizationMemberNotFound
+	}
+	return nil
+}
+
+func (r *organizationRepository) AddMemberSpend(ctx context.Context, orgID, userID int64, amount float64, period string) error {
+	_, err := r.exec(ctx).ExecContext(ctx, `
+		UPDATE organization_members
+		SET spent_usd = CASE WHEN spend_period = $4 THEN spent_usd + $3 ELSE $3 END,
+			spend_period = $4
+		WHERE organization_id = $1 AND user_id = $2
+	`, orgID, userID, amount, period)
+	return err
+}
+
+func (r *organizationRepository) GetMemberSpend(ctx context.Context, orgID, userID int64, period string) (float64, error) {
+	var spent float64
+	err := scanSingleRow(ctx, r.sql, `
+		SELECT CASE WHEN spend_period = $3 THEN spent_usd ELSE 0 END
+		FROM organization_members
+		WHERE organization_id = $1 AND user_id = $2
+	`, []any{orgID, userID, period}, &spent)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, service.ErrOrganizationMemberNotFound
+	}
+	return spent, err
+}
+
+func (r *organizationRepository) DeductBalanceIfSufficient(ctx context.Context, userID int64, amount float64) (bool, error) {
+	res, err := r.exec(ctx).ExecContext(ctx, `
+		UPDATE users SET balance = balance - $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL AND balance >= $2
+	`, userID, amount)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func scanOrganization(rows *sql.Rows) (*service.Organization, error) {
+	var org service.Organization
+	if err := rows.Scan(
+		&org.ID,
+		&org.Name,
+		&org.OwnerUserID,
+		&org.BillingUserID,
+		&org.Status,
+		&org.CreatedAt,
+		&org.UpdatedAt,
+		&org.Balance,
+		&org.MemberCount,
+	); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func scanOrganizationMember(rows *sql.Rows) (*service.OrganizationMember, error) {
+	var (
+		member service.OrganizationMember
+		limit  sql.NullFloat64
+	)
+	if err := rows.Scan(
+		&member.ID,
+		&member.OrganizationID,
+		&member.UserID,
+		&member.Role,
+		&limit,
+		&member.SpentUSD,
+		&member.SpendPeriod,
+		&member.CreatedAt,
+		&member.UpdatedAt,
+		&member.Email,
+		&member.Username,
+	); err != nil {
+		return nil, err
+	}
+	member.SpendingLimitUSD = nullFloat64Ptr(limit)
+	return &member, nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganizationRepositoryAddMemberExists(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newOrganizationRepositoryWithSQL(db)
+
+	mock.ExpectQuery("INSERT INTO organization_members .* ON CONFLICT \\(organization_id, user_id\\) DO NOTHING").
+		WithArgs(int64(1), int64(2), service.OrganizationRoleMember, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}))
+
+	err := repo.AddMember(context.Background(), &service.OrganizationMember{OrganizationID: 1, UserID: 2, Role: service.OrganizationRoleMember})
+	require.ErrorIs(t, err, service.ErrOrganizationMemberExists)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOrganizationRepositoryMemberSpendResetsPerPeriod(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newOrganizationRepositoryWithSQL(db)
+
+	mock.ExpectExec("SET spent_usd = CASE WHEN spend_period = \\$4 THEN spent_usd \\+ \\$3 ELSE \\$3 END").
+		WithArgs(int64(1), int64(2), 1.5, "2026-01").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT CASE WHEN spend_period = \\$3 THEN spent_usd ELSE 0 END").
+		WithArgs(int64(1), int64(2), "2026-01").
+		WillReturnRows(sqlmock.NewRows([]string{"spent"}).AddRow(1.5))
+	mock.ExpectQuery("FROM organization_members").
+		WithArgs(int64(1), int64(3), "2026-01").
+		WillReturnRows(sqlmock.NewRows([]string{"spent"}))
+
+	require.NoError(t, repo.AddMemberSpend(context.Background(), 1, 2, 1.5, "2026-01"))
+	spent, err := repo.GetMemberSpend(context.Background(), 1, 2, "2026-01")
+	require.NoError(t, err)
+	require.InDelta(t, 1.5, spent, 1e-9)
+
+	_, err = repo.GetMemberSpend(context.Background(), 1, 3, "2026-01")
+	require.ErrorIs(t, err, service.ErrOrganizationMemberNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOrganizationRepositoryDeductBalanceIfSufficient(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newOrganizationRepositoryWithSQL(db)
+
+	mock.ExpectExec("UPDATE users SET balance = balance - \\$2").
+		WithArgs(int64(1), 5.0).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.DeductBalanceIfSufficient(context.Background(), 1, 5)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,359 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const paymentOrderColumns = `
+	id, order_no, user_id, provider, method, order_type, amount, currency, credit_amount,
+	group_id, validity_days, subscription_id, status, provider_order_id, provider_payment_id,
+	pay_url, refunded_amount, failure_reason, notes, created_by,
+	expires_at, paid_at, refunded_at, created_at, updated_at
+`
+
+type paymentOrderRepository struct {
+	sql sqlExecutor
+}
+
+func NewPaymentOrderRepository(sqlDB *sql.DB) service.PaymentOrderRepository {
+	return newPaymentOrderRepositoryWithSQL(sqlDB)
+}
+
+func newPaymentOrderRepositoryWithSQL(sqlq sqlExecutor) *paymentOrderRepository {
+	return &paymentOrderRepository{sql: sqlq}
+}
+
+// exec 在事务上下文中使用 tx 绑定的执行器，保证与余额/订阅更新同事务
+func (r *paymentOrderRepository) exec(ctx context.Context) sqlExecutor {
+	if tx := dbent.TxFromContext(ctx); tx != nil {
+		return tx.Client()
+	}
+	return r.sql
+}
+
+func (r *paymentOrderRepository) Create(ctx context.Context, order *service.PaymentOrder) error {
+	if order == nil {
+		return nil
+	}
+	query := `
+		INSERT INTO payment_orders (
+			order_no, user_id, provider, method, order_type, amount, currency, credit_amount,
+			group_id, validity_days, status, notes, created_by, expires_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`
+	args := []any{
+		order.OrderNo,
+		order.UserID,
+		order.Provider,
+		order.Method,
+		order.OrderType,
+		order.Amount,
+		order.Currency,
+		order.CreditAmount,
+		nullInt64(order.GroupID),
+		order.ValidityDays,
+		order.Status,
+		nullString(&order.Notes),
+		nullInt64(order.CreatedBy),
+		order.ExpiresAt,
+	}
+	return scanSingleRow(ctx, r.exec(ctx), query, args, &order.ID, &order.CreatedAt, &order.UpdatedAt)
+}
+
+func (r *paymentOrderRepository) GetByID(ctx context.Context, id int64) (*service.PaymentOrder, error) {
+	return r.getOne(ctx, "SELECT "+paymentOrderColumns+" FROM payment_orders WHERE id = $1", id)
+}
+
+func (r *paymentOrderRepository) GetByOrderNo(ctx context.Context, orderNo string) (*service.PaymentOrder, error) {
+	return r.getOne(ctx, "SELECT "+paymentOrderColumns+" FROM payment_orders WHERE order_no = $1", orderNo)
+}
+
+func (r *paymentOrderRepository) GetByOrderNoForUpdate(ctx context.Context, orderNo string) (*service.PaymentOrder, error) {
+	return r.getOne(ctx, "SELECT "+paymentOrderColumns+" FROM payment_orders WHERE order_no = $1 FOR UPDATE", orderNo)
+}
+
+func (r *paymentOrderRepository) GetByProviderPaymentID(ctx context.Context, provider, providerPaymentID string) (*service.PaymentOrder, error) {
+	return r.getOne(ctx, "SELECT "+paymentOrderColumns+" FROM payment_orders WHERE provider = $1 AND provider_payment_id = $2 ORDER BY id DESC LIMIT 1", provider, providerPaymentID)
+}
+
+func (r *paymentOrderRepository) getOne(ctx context.Context, query string, args ...any) (*service.PaymentOrder, error) {
+	rows, err := r.exec(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrPaymentOrderNotFound
+	}
+	order, err := scanPaymentOrder(rows)
+	if err != nil {
+		return nil, err
+	}
+	return order, rows.Err()
+}
+
+func (r *paymentOrderRepository) List(ctx context.Context, params pagination.PaginationParams, filters service.PaymentOrderFilters) ([]service.PaymentOrder, *pagination.PaginationResult, error) {
+	conditions := make([]string, 0, 4)
+	args := make([]any, 0, 6)
+	if filters.UserID != nil {
+		args = append(args, *filters.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if v := strings.TrimSpace(filters.Status); v != "" {
+		args = append(args, v)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if v := strings.TrimSpace(filters.Provider); v != "" {
+		args = append(args, v)
+		conditions = append(conditions, fmt.Sprintf("provider = $%d", len(args)))
+	}
+	if v := strings.TrimSpace(filters.OrderType); v != "" {
+		args = append(args, v)
+		conditions = append(conditions, fmt.Sprintf("order_type = $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM payment_orders "+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.PaymentOrder{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM payment_orders %s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d",
+		paymentOrderColumns, where, len(args)+1, len(args)+2)
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	orders := make([]service.PaymentOrder, 0)
+	for rows.Next() {
+		order, err := scanPaymentOrder(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		orders = append(orders, *order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return orders, paginationResultFromTotal(total, params), nil
+}
+
+func (r *paymentOrderRepository) CountPendingByUser(ctx context.Context, userID int64) (int, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM payment_orders WHERE user_id = $1 AND status = $2 AND (expires_at IS NULL OR expires_at > NOW())"
+	if err := scanSingleRow(ctx, r.exec(ctx), query, []any{userID, service.PaymentOrderStatusPending}, &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *paymentOrderRepository) UpdateProviderInfo(ctx context.Context, id int64, providerOrderID, payURL string) error {
+	_, err := r.exec(ctx).ExecContext(ctx, `
+		UPDATE payment_orders
+		SET provider_order_id = $2, pay_url = $3, updated_at = NOW()
+		WHERE id = $1
+	`, id, nullString(&providerOrderID), nullString(&payURL))
+	return err
+}
+
+func (r *paymentOrderRepository) MarkPaid(ctx context.Context, order *service.PaymentOrder) (bool, error) {
+	if order == nil {
+		return false, nil
+	}
+	result, err := r.exec(ctx).ExecContext(ctx, `
+		UPDATE payment_orders
+		SET status = $2,
+			provider_order_id = COALESCE($3, provider_order_id),
+			provider_payment_id = COALESCE($4, provider_payment_id),
+			subscription_id = COALESCE($5, subscription_id),
+			paid_at = $6,
+			failure_reason = NULL,
+			updated_at = NOW()
+		WHERE id = $1 AND status IN ($7, $8, $9, $10)
+	`,
+		order.ID,
+		service.PaymentOrderStatusPaid,
+		nullString(&order.ProviderOrderID),
+		nullString(&order.ProviderPaymentID),
+		nullInt64(order.SubscriptionID),
+		order.PaidAt,
+		service.PaymentOrderStatusPending,
+		service.PaymentOrderStatusExpired,
+		service.PaymentOrderStatusCanceled,
+		service.PaymentOrderStatusFailed,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *paymentOrderRepository) TransitionStatus(ctx context.Context, id int64, from, to, reason string) (bool, error) {
+	result, err := r.exec(ctx).ExecContext(ctx, `
+		UPDATE payment_orders
+		SET status = $3, failure_reason = COALESCE($4, failure_reason), updated_at = NOW()
+		WHERE id = $1 AND status = $2
+	`, id, from, to, nullString(&reason))
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *paymentOrderRepository) ApplyRefund(ctx context.Context, id int64, refundedAmount float64, status string, refundedAt time.Time) error {
+	result, err := r.exec(ctx).ExecContext(ctx, `
+		UPDATE payment_orders
+		SET refunded_amount = $2, status = $3, refunded_at = $4, updated_at = NOW()
+		WHERE id = $1
+	`, id, refundedAmount, status, refundedAt)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrPaymentOrderNotFound
+	}
+	return nil
+}
+
+func (r *paymentOrderRepository) ExpirePending(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.exec(ctx).ExecContext(ctx, `
+		UPDATE payment_orders
+		SET status = $1, updated_at = NOW()
+		WHERE status = $2 AND expires_at IS NOT NULL AND expires_at <= $3
+	`, service.PaymentOrderStatusExpired, service.PaymentOrderStatusPending, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *paymentOrderRepository) RecordWebhookEvent(ctx context.Context, record *service.PaymentWebhookRecord) (bool, error) {
+	if record == nil {
+		return false, nil
+	}
+	var payload any
+	if len(record.Payload) > 0 {
+		payload = string(record.Payload)
+	}
+	var id int64
+	err := scanSingleRow(ctx, r.exec(ctx), `
+		INSERT INTO payment_webhook_events (provider, event_id, event_type, order_no, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5::jsonb, NOW())
+		ON CONFLICT (provider, event_id) DO NOTHING
+		RETURNING id
+	`, []any{record.Provider, record.EventID, record.EventType, nullString(&record.OrderNo), payload}, &id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func scanPaymentOrder(rows *sql.Rows) (*service.PaymentOrder, error) {
+	var (
+		order             service.PaymentOrder
+		groupID           sql.NullInt64
+		subscriptionID    sql.NullInt64
+		providerOrderID   sql.NullString
+		providerPaymentID sql.NullString
+		payURL            sql.NullString
+		failureReason     sql.NullString
+		notes             sql.NullString
+		createdBy         sql.NullInt64
+		expiresAt         sql.NullTime
+		paidAt            sql.NullTime
+		refundedAt        sql.NullTime
+	)
+	if err := rows.Scan(
+		&order.ID,
+		&order.OrderNo,
+		&order.UserID,
+		&order.Provider,
+		&order.Method,
+		&order.OrderType,
+		&order.Amount,
+		&order.Currency,
+		&order.CreditAmount,
+		&groupID,
+		&order.ValidityDays,
+		&subscriptionID,
+		&order.Status,
+		&providerOrderID,
+		&providerPaymentID,
+		&payURL,
+		&order.RefundedAmount,
+		&failureReason,
+		&notes,
+		&createdBy,
+		&expiresAt,
+		&paidAt,
+		&refundedAt,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if groupID.Valid {
+		v := groupID.Int64
+		order.GroupID = &v
+	}
+	if subscriptionID.Valid {
+		v := subscriptionID.Int64
+		order.SubscriptionID = &v
+	}
+	if createdBy.Valid {
+		v := createdBy.Int64
+		order.CreatedBy = &v
+	}
+	order.ProviderOrderID = providerOrderID.String
+	order.ProviderPaymentID = providerPaymentID.String
+	order.PayURL = payURL.String
+	order.FailureReason = failureReason.String
+	order.Notes = notes.String
+	if expiresAt.Valid {
+		order.ExpiresAt = &expiresAt.Time
+	}
+	if paidAt.Valid {
+		order.PaidAt = &paidAt.Time
+	}
+	if refundedAt.Valid {
+		order.RefundedAt = &refundedAt.Time
+	}
+	return &order, nil
+}
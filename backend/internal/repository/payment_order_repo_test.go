@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func paymentOrderRow(now time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "order_no", "user_id", "provider", "method", "order_type", "amount", "currency", "credit_amount",
+		"group_id", "validity_days", "subscription_id", "status", "provider_order_id", "provider_payment_id",
+		"pay_url", "refunded_amount", "failure_reason", "notes", "created_by",
+		"expires_at", "paid_at", "refunded_at", "created_at", "updated_at",
+	}).AddRow(
+		int64(1), "P1", int64(7), "fake", "", "balance", 10.0, "CNY", 10.0,
+		nil, 0, nil, "pending", "fake_P1", nil,
+		"http://pay", 0.0, nil, nil, nil,
+		now, nil, nil, now, now,
+	)
+}
+
+func TestPaymentOrderRepositoryGetByOrderNo(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newPaymentOrderRepositoryWithSQL(db)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM payment_orders WHERE order_no = \\$1").
+		WithArgs("P1").
+		WillReturnRows(paymentOrderRow(now))
+
+	order, err := repo.GetByOrderNo(context.Background(), "P1")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), order.ID)
+	require.Equal(t, "fake_P1", order.ProviderOrderID)
+	require.Equal(t, "", order.ProviderPaymentID)
+	require.Nil(t, order.GroupID)
+	require.NotNil(t, order.ExpiresAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentOrderRepositoryGetByOrderNoNotFound(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newPaymentOrderRepositoryWithSQL(db)
+
+	mock.ExpectQuery("FROM payment_orders WHERE order_no = \\$1").
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := repo.GetByOrderNo(context.Background(), "missing")
+	require.ErrorIs(t, err, service.ErrPaymentOrderNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentOrderRepositoryListWithFilters(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newPaymentOrderRepositoryWithSQL(db)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userID := int64(7)
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM payment_orders WHERE user_id = \\$1 AND status = \\$2").
+		WithArgs(userID, "pending").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(1)))
+	mock.ExpectQuery("FROM payment_orders WHERE user_id = \\$1 AND status = \\$2 ORDER BY created_at DESC, id DESC LIMIT \\$3 OFFSET \\$4").
+		WithArgs(userID, "pending", 20, 0).
+		WillReturnRows(paymentOrderRow(now))
+
+	orders, result, err := repo.List(context.Background(), pagination.PaginationParams{Page: 1, PageSize: 20}, service.PaymentOrderFilters{
+		UserID: &userID,
+		Status: "pending",
+	})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	require.Equal(t, int64(1), result.Total)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentOrderRepositoryRecordWebhookEventDuplicate(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newPaymentOrderRepositoryWithSQL(db)
+	record := &service.PaymentWebhookRecord{Provider: "stripe", EventID: "evt_1", EventType: "paid", OrderNo: "P1", Payload: []byte(`{}`)}
+
+	mock.ExpectQuery("INSERT INTO payment_webhook_events").
+		WithArgs("stripe", "evt_1", "paid", "P1", "{}").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(1)))
+	mock.ExpectQuery("INSERT INTO payment_webhook_events").
+		WithArgs("stripe", "evt_1", "paid", "P1", "{}").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	inserted, err := repo.RecordWebhookEvent(context.Background(), record)
+	require.NoError(t, err)
+	require.True(t, inserted)
+
+	inserted, err = repo.RecordWebhookEvent(context.Background(), record)
+	require.NoError(t, err)
+	require.False(t, inserted)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentOrderRepositoryMarkPaidGuardsStatus(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newPaymentOrderRepositoryWithSQL(db)
+	paidAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	order := &service.PaymentOrder{ID: 1, ProviderPaymentID: "pi_1", PaidAt: &paidAt}
+
+	mock.ExpectExec("UPDATE payment_orders").
+		WithArgs(int64(1), "paid", nil, "pi_1", nil, &paidAt, "pending", "expired", "canceled", "failed").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.MarkPaid(context.Background(), order)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/httpclient"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const (
+	signedPaymentSignMD5        = "md5"
+	signedPaymentSignHMACSHA256 = "hmac-sha256"
+	signedPaymentTradeSuccess   = "TRADE_SUCCESS"
+)
+
+// signedPaymentProvider 通用签名回调支付渠道
+//
+// 兼容易支付（EPay）协议的支付宝/微信聚合支付：
+//   - 下单：跳转 {gateway}/submit.php，参数按 key 排序拼接后签名
+//   - 回调：GET/POST 到 notify_url，验签通过且 trade_status=TRADE_SUCCESS 视为支付成功，需应答 "success"
+//   - 退款：POST {gateway}/api.php?act=refund
+type signedPaymentProvider struct {
+	httpClient *http.Client
+	gatewayURL string
+	merchantID string
+	secretKey  string
+	signType   string
+	methods    []string
+}
+
+func newSignedPaymentProvider(cfg config.SignedPaymentConfig) *signedPaymentProvider {
+	client, err := httpclient.GetClient(httpclient.Options{Timeout: 30 * time.Second})
+	if err != nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	methods := make([]string, 0, len(cfg.Methods))
+	for _, m := range cfg.Methods {
+		if m = strings.ToLower(strings.TrimSpace(m)); m != "" {
+			methods = append(methods, m)
+		}
+	}
+	return &signedPaymentProvider{
+		httpClient: client,
+		gatewayURL: strings.TrimRight(cfg.GatewayURL, "/"),
+		merchantID: cfg.MerchantID,
+		secretKey:  cfg.SecretKey,
+		signType:   strings.ToLower(strings.TrimSpace(cfg.SignType)),
+		methods:    methods,
+	}
+}
+
+func (p *signedPaymentProvider) Name() string { return service.PaymentProviderSigned }
+
+func (p *signedPaymentProvider) Methods() []string { return p.methods }
+
+func (p *signedPaymentProvider) CreatePayment(_ context.Context, req *service.PaymentCreateRequest) (*service.PaymentCreateResult, error) {
+	order := req.Order
+	params := url.Values{}
+	params.Set("pid", p.merchantID)
+	params.Set("type", order.Method)
+	params.Set("out_trade_no", order.OrderNo)
+	params.Set("notify_url", req.NotifyURL)
+	params.Set("return_url", req.ReturnURL)
+	params.Set("name", req.Subject)
+	params.Set("money", strconv.FormatFloat(order.Amount, 'f', 2, 64))
+	if req.ClientIP != "" {
+		params.Set("clientip", req.ClientIP)
+	}
+	params.Set("sign", p.sign(params))
+	params.Set("sign_type", p.signTypeParam())
+
+	return &service.PaymentCreateResult{
+		PayURL: p.gatewayURL + "/submit.php?" + params.Encode(),
+	}, nil
+}
+
+func (p *signedPaymentProvider) ParseWebhook(_ context.Context, req *service.PaymentWebhookRequest) (*service.PaymentWebhookEvent, error) {
+	params := req.Query
+	if len(req.Form) > 0 {
+		params = req.Form
+	}
+	if params == nil || params.Get("sign") == "" {
+		return nil, service.ErrPaymentInvalidSignature
+	}
+	if !hmac.Equal([]byte(strings.ToLower(params.Get("sign"))), []byte(p.sign(params))) {
+		return nil, service.ErrPaymentInvalidSignature
+	}
+	if params.Get("pid") != p.merchantID {
+		return nil, service.ErrPaymentInvalidSignature
+	}
+
+	raw, _ := json.Marshal(flattenValues(params))
+	out := &service.PaymentWebhookEvent{
+		OrderNo:           params.Get("out_trade_no"),
+		ProviderOrderID:   params.Get("trade_no"),
+		ProviderPaymentID: params.Get("trade_no"),
+		OccurredAt:        time.Now(),
+		Raw:               raw,
+	}
+	out.Amount, _ = strconv.ParseFloat(params.Get("money"), 64)
+	if params.Get("trade_status") != signedPaymentTradeSuccess {
+		out.Type = service.PaymentEventIgnored
+		return out, nil
+	}
+	out.Type = service.PaymentEventPaid
+	out.EventID = "paid:" + out.OrderNo + ":" + out.ProviderPaymentID
+	return out, nil
+}
+
+func (p *signedPaymentProvider) WebhookAck(err error) (int, string, []byte) {
+	if err != nil {
+		return http.StatusBadRequest, "text/plain; charset=utf-8", []byte("fail")
+	}
+	return http.StatusOK, "text/plain; charset=utf-8", []byte("success")
+}
+
+func (p *signedPaymentProvider) Refund(ctx context.Context, order *service.PaymentOrder, amount float64, _ string) (*service.PaymentRefundResult, error) {
+	form := url.Values{}
+	form.Set("pid", p.merchantID)
+	form.Set("key", p.secretKey)
+	form.Set("out_trade_no", order.OrderNo)
+	if order.ProviderPaymentID != "" {
+		form.Set("trade_no", order.ProviderPaymentID)
+	}
+	form.Set("money", strconv.FormatFloat(amount, 'f', 2, 64))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.gatewayURL+"/api.php?act=refund", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	var result struct {
+		Code json.Number `json:"code"`
+		Msg  string      `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("decode response: status=%d: %w", resp.StatusCode, err)
+	}
+	if result.Code.String() != "1" {
+		return nil, fmt.Errorf("refund rejected: code=%s msg=%s", result.Code.String(), result.Msg)
+	}
+	return &service.PaymentRefundResult{RefundID: order.OrderNo}, nil
+}
+
+// sign 计算签名：排除 sign/sign_type 与空值，按 key 升序拼接为 k=v&k=v
+// md5: md5(str + key)；hmac-sha256: hmac_sha256(key, str)。结果均为小写十六进制。
+func (p *signedPaymentProvider) sign(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k == "sign" || k == "sign_type" || params.Get(k) == "" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(params.Get(k))
+	}
+
+	if p.signType == signedPaymentSignHMACSHA256 {
+		mac := hmac.New(sha256.New, []byte(p.secretKey))
+		mac.Write([]byte(sb.String()))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	sum := md5.Sum([]byte(sb.String() + p.secretKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func (p *signedPaymentProvider) signTypeParam() string {
+	if p.signType == signedPaymentSignHMACSHA256 {
+		return "HMAC-SHA256"
+	}
+	return "MD5"
+}
+
+func flattenValues(values url.Values) map[string]string {
+	out := make(map[string]string, len(values))
+	for k := range values {
+		out[k] = values.Get(k)
+	}
+	return out
+}
@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/httpclient"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// stripeZeroDecimalCurrencies 无小数位的货币，金额不乘 100
+// https://docs.stripe.com/currencies#zero-decimal
+var stripeZeroDecimalCurrencies = map[string]struct{}{
+	"bif": {}, "clp": {}, "djf": {}, "gnf": {}, "jpy": {}, "kmf": {}, "krw": {}, "mga": {},
+	"pyg": {}, "rwf": {}, "ugx": {}, "vnd": {}, "vuv": {}, "xaf": {}, "xof": {}, "xpf": {},
+}
+
+type stripePaymentProvider struct {
+	httpClient    *http.Client
+	apiBase       string
+	secretKey     string
+	webhookSecret string
+	tolerance     time.Duration
+	now           func() time.Time
+}
+
+func newStripePaymentProvider(cfg config.StripePaymentConfig) *stripePaymentProvider {
+	client, err := httpclient.GetClient(httpclient.Options{Timeout: 30 * time.Second})
+	if err != nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	tolerance := time.Duration(cfg.WebhookToleranceSeconds) * time.Second
+	if tolerance <= 0 {
+		tolerance = 5 * time.Minute
+	}
+	return &stripePaymentProvider{
+		httpClient:    client,
+		apiBase:       strings.TrimRight(cfg.APIBase, "/"),
+		secretKey:     cfg.SecretKey,
+		webhookSecret: cfg.WebhookSecret,
+		tolerance:     tolerance,
+		now:           time.Now,
+	}
+}
+
+func (p *stripePaymentProvider) Name() string { return service.PaymentProviderStripe }
+
+func (p *stripePaymentProvider) Methods() []string { return nil }
+
+func (p *stripePaymentProvider) CreatePayment(ctx context.Context, req *service.PaymentCreateRequest) (*service.PaymentCreateResult, error) {
+	order := req.Order
+	currency := strings.ToLower(order.Currency)
+	returnURL := req.ReturnURL
+
+	form := url.Values{}
+	form.Set("mode", "payment")
+	form.Set("client_reference_id", order.OrderNo)
+	form.Set("success_url", appendQuery(returnURL, "order_no", order.OrderNo))
+	form.Set("cancel_url", appendQuery(returnURL, "order_no", order.OrderNo))
+	form.Set("metadata[order_no]", order.OrderNo)
+	form.Set("payment_intent_data[metadata][order_no]", order.OrderNo)
+	form.Set("line_items[0][quantity]", "1")
+	form.Set("line_items[0][price_data][currency]", currency)
+	form.Set("line_items[0][price_data][unit_amount]", strconv.FormatInt(stripeMinorUnits(order.Amount, currency), 10))
+	form.Set("line_items[0][price_data][product_data][name]", req.Subject)
+	// Stripe 要求 expires_at 至少在 30 分钟之后
+	if order.ExpiresAt != nil && order.ExpiresAt.Sub(p.now()) >= 30*time.Minute {
+		form.Set("expires_at", strconv.FormatInt(order.ExpiresAt.Unix(), 10))
+	}
+
+	var session struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	}
+	if err := p.post(ctx, "/v1/checkout/sessions", form, order.OrderNo, &session); err != nil {
+		return nil, err
+	}
+	if session.URL == "" {
+		return nil, fmt.Errorf("stripe checkout session missing url")
+	}
+	return &service.PaymentCreateResult{ProviderOrderID: session.ID, PayURL: session.URL}, nil
+}
+
+type stripeEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+	Created int64 `json:"created"`
+}
+
+type stripeCheckoutSession struct {
+	ID                string            `json:"id"`
+	ClientReferenceID string            `json:"client_reference_id"`
+	AmountTotal       int64             `json:"amount_total"`
+	Currency          string            `json:"currency"`
+	PaymentStatus     string            `json:"payment_status"`
+	PaymentIntent     string            `json:"payment_intent"`
+	Metadata          map[string]string `json:"metadata"`
+}
+
+type stripeCharge struct {
+	ID             string            `json:"id"`
+	Amount         int64             `json:"amount"`
+	AmountRefunded int64             `json:"amount_refunded"`
+	Currency       string            `json:"currency"`
+	PaymentIntent  string            `json:"payment_intent"`
+	Metadata       map[string]string `json:"metadata"`
+}
+
+func (p *stripePaymentProvider) ParseWebhook(_ context.Context, req *service.PaymentWebhookRequest) (*service.PaymentWebhookEvent, error) {
+	if err := p.verifySignature(req.Headers.Get("Stripe-Signature"), req.Body); err != nil {
+		return nil, err
+	}
+	var evt stripeEvent
+	if err := json.Unmarshal(req.Body, &evt); err != nil {
+		return nil, fmt.Errorf("decode stripe event: %w", err)
+	}
+
+	out := &service.PaymentWebhookEvent{
+		EventID:    evt.ID,
+		OccurredAt: time.Unix(evt.Created, 0),
+		Raw:        req.Body,
+	}
+	switch evt.Type {
+	case "checkout.session.completed", "checkout.session.async_payment_succeeded",
+		"checkout.session.expired", "checkout.session.async_payment_failed":
+		var session stripeCheckoutSession
+		if err := json.Unmarshal(evt.Data.Object, &session); err != nil {
+			return nil, fmt.Errorf("decode stripe checkout session: %w", err)
+		}
+		out.OrderNo = session.Metadata["order_no"]
+		if out.OrderNo == "" {
+			out.OrderNo = session.ClientReferenceID
+		}
+		out.ProviderOrderID = session.ID
+		out.ProviderPaymentID = session.PaymentIntent
+		out.Currency = strings.ToUpper(session.Currency)
+		out.Amount = stripeMajorUnits(session.AmountTotal, session.Currency)
+		switch {
+		case evt.Type == "checkout.session.expired" || evt.Type == "checkout.session.async_payment_failed":
+			out.Type = service.PaymentEventFailed
+			out.Reason = evt.Type
+		case session.PaymentStatus == "paid" || session.PaymentStatus == "no_payment_required":
+			out.Type = service.PaymentEventPaid
+		default:
+			// 异步支付方式（如银行转账）completed 时尚未到账，等待 async_payment_succeeded
+			out.Type = service.PaymentEventIgnored
+		}
+	case "charge.refunded":
+		var charge stripeCharge
+		if err := json.Unmarshal(evt.Data.Object, &charge); err != nil {
+			return nil, fmt.Errorf("decode stripe charge: %w", err)
+		}
+		out.Type = service.PaymentEventRefunded
+		out.OrderNo = charge.Metadata["order_no"]
+		out.ProviderPaymentID = charge.PaymentIntent
+		out.Currency = strings.ToUpper(charge.Currency)
+		out.Amount = stripeMajorUnits(charge.Amount, charge.Currency)
+		out.RefundedAmount = stripeMajorUnits(charge.AmountRefunded, charge.Currency)
+	default:
+		out.Type = service.PaymentEventIgnored
+	}
+	return out, nil
+}
+
+// verifySignature 校验 Stripe-Signature 头：t=<ts>,v1=<hmac_sha256(t.body)>
+func (p *stripePaymentProvider) verifySignature(header string, body []byte) error {
+	if header == "" || p.webhookSecret == "" {
+		return service.ErrPaymentInvalidSignature
+	}
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return service.ErrPaymentInvalidSignature
+	}
+	if age := p.now().Sub(time.Unix(ts, 0)); age > p.tolerance || age < -p.tolerance {
+		return service.ErrPaymentInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.webhookSecret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+	for _, sig := range signatures {
+		decoded, err := hex.DecodeString(sig)
+		if err != nil {
+			continue
+		}
+		if hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return service.ErrPaymentInvalidSignature
+}
+
+func (p *stripePaymentProvider) WebhookAck(err error) (int, string, []byte) {
+	if err != nil {
+		// 返回非 2xx 让 Stripe 重试
+		return http.StatusBadRequest, "application/json", []byte(`{"received":false}`)
+	}
+	return http.StatusOK, "application/json", []byte(`{"received":true}`)
+}
+
+func (p *stripePaymentProvider) Refund(ctx context.Context, order *service.PaymentOrder, amount float64, reason string) (*service.PaymentRefundResult, error) {
+	if order.ProviderPaymentID == "" {
+		return nil, fmt.Errorf("order %s has no stripe payment_intent", order.OrderNo)
+	}
+	form := url.Values{}
+	form.Set("payment_intent", order.ProviderPaymentID)
+	form.Set("amount", strconv.FormatInt(stripeMinorUnits(amount, order.Currency), 10))
+	form.Set("metadata[order_no]", order.OrderNo)
+	if reason != "" {
+		form.Set("metadata[reason]", reason)
+	}
+	idempotencyKey := fmt.Sprintf("refund-%s-%.2f-%.2f", order.OrderNo, order.RefundedAmount, amount)
+
+	var refund struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := p.post(ctx, "/v1/refunds", form, idempotencyKey, &refund); err != nil {
+		return nil, err
+	}
+	if refund.Status == "failed" || refund.Status == "canceled" {
+		return nil, fmt.Errorf("stripe refund %s status=%s", refund.ID, refund.Status)
+	}
+	return &service.PaymentRefundResult{RefundID: refund.ID}, nil
+}
+
+func (p *stripePaymentProvider) post(ctx context.Context, path string, form url.Values, idempotencyKey string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiBase+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.secretKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(body, &apiErr)
+		return fmt.Errorf("stripe %s: status=%d message=%s", path, resp.StatusCode, apiErr.Error.Message)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+func stripeMinorUnits(amount float64, currency string) int64 {
+	if _, ok := stripeZeroDecimalCurrencies[strings.ToLower(currency)]; ok {
+		return int64(math.Round(amount))
+	}
+	return int64(math.Round(amount * 100))
+}
+
+func stripeMajorUnits(amount int64, currency string) float64 {
+	if _, ok := stripeZeroDecimalCurrencies[strings.ToLower(currency)]; ok {
+		return float64(amount)
+	}
+	return float64(amount) / 100
+}
+
+func appendQuery(rawURL, key, value string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func stripeSignatureHeader(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10) + "." + string(body)))
+	return "t=" + strconv.FormatInt(ts, 10) + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newTestStripeProvider(now time.Time) *stripePaymentProvider {
+	p := newStripePaymentProvider(config.StripePaymentConfig{
+		SecretKey:               "sk_test",
+		WebhookSecret:           "whsec_test",
+		APIBase:                 "http://in-process",
+		WebhookToleranceSeconds: 300,
+	})
+	p.now = func() time.Time { return now }
+	return p
+}
+
+func TestStripeParseWebhook_CheckoutCompleted(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	p := newTestStripeProvider(now)
+	body := []byte(`{"id":"evt_1","type":"checkout.session.completed","created":1700000000,"data":{"object":{"id":"cs_1","client_reference_id":"P1","amount_total":1250,"currency":"usd","payment_status":"paid","payment_intent":"pi_1","metadata":{"order_no":"P1"}}}}`)
+
+	headers := http.Header{}
+	headers.Set("Stripe-Signature", stripeSignatureHeader("whsec_test", now.Unix(), body))
+	event, err := p.ParseWebhook(context.Background(), &service.PaymentWebhookRequest{Headers: headers, Body: body})
+	require.NoError(t, err)
+	require.Equal(t, service.PaymentEventPaid, event.Type)
+	require.Equal(t, "evt_1", event.EventID)
+	require.Equal(t, "P1", event.OrderNo)
+	require.Equal(t, "pi_1", event.ProviderPaymentID)
+	require.InDelta(t, 12.5, event.Amount, 1e-9)
+}
+
+func TestStripeParseWebhook_ChargeRefunded(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	p := newTestStripeProvider(now)
+	body := []byte(`{"id":"evt_2","type":"charge.refunded","created":1700000000,"data":{"object":{"id":"ch_1","amount":1000,"amount_refunded":400,"currency":"jpy","payment_intent":"pi_1","metadata":{}}}}`)
+
+	headers := http.Header{}
+	headers.Set("Stripe-Signature", stripeSignatureHeader("whsec_test", now.Unix(), body))
+	event, err := p.ParseWebhook(context.Background(), &service.PaymentWebhookRequest{Headers: headers, Body: body})
+	require.NoError(t, err)
+	require.Equal(t, service.PaymentEventRefunded, event.Type)
+	require.Empty(t, event.OrderNo)
+	require.Equal(t, "pi_1", event.ProviderPaymentID)
+	// JPY 为零小数货币
+	require.InDelta(t, 400, event.RefundedAmount, 1e-9)
+}
+
+func TestStripeParseWebhook_RejectsBadSignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	p := newTestStripeProvider(now)
+	body := []byte(`{"id":"evt_1","type":"checkout.session.completed"}`)
+
+	cases := map[string]string{
+		"missing":   "",
+		"wrong key": stripeSignatureHeader("whsec_other", now.Unix(), body),
+		"too old":   stripeSignatureHeader("whsec_test", now.Add(-10*time.Minute).Unix(), body),
+		"malformed": "v1=abc",
+	}
+	for name, header := range cases {
+		t.Run(name, func(t *testing.T) {
+			headers := http.Header{}
+			headers.Set("Stripe-Signature", header)
+			_, err := p.ParseWebhook(context.Background(), &service.PaymentWebhookRequest{Headers: headers, Body: body})
+			require.True(t, errors.Is(err, service.ErrPaymentInvalidSignature), "got %v", err)
+		})
+	}
+}
+
+func TestStripeCreatePayment_SendsCheckoutSession(t *testing.T) {
+	p := newTestStripeProvider(time.Now())
+	received := make(chan *http.Request, 1)
+	bodies := make(chan url.Values, 1)
+	p.httpClient = &http.Client{Transport: newInProcessTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		values, _ := url.ParseQuery(string(body))
+		received <- r
+		bodies <- values
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"id": "cs_123", "url": "https://checkout.stripe.com/c/cs_123"})
+	}), nil)}
+
+	result, err := p.CreatePayment(context.Background(), &service.PaymentCreateRequest{
+		Order:     &service.PaymentOrder{OrderNo: "P1", Amount: 9.99, Currency: "USD"},
+		Subject:   "Balance top-up",
+		ReturnURL: "https://example.com/payment/result",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "cs_123", result.ProviderOrderID)
+	require.Equal(t, "https://checkout.stripe.com/c/cs_123", result.PayURL)
+
+	r := <-received
+	form := <-bodies
+	require.Equal(t, "/v1/checkout/sessions", r.URL.Path)
+	require.Equal(t, "Bearer sk_test", r.Header.Get("Authorization"))
+	require.Equal(t, "P1", r.Header.Get("Idempotency-Key"))
+	require.Equal(t, "999", form.Get("line_items[0][price_data][unit_amount]"))
+	require.Equal(t, "usd", form.Get("line_items[0][price_data][currency]"))
+	require.Equal(t, "P1", form.Get("metadata[order_no]"))
+	require.Equal(t, "https://example.com/payment/result?order_no=P1", form.Get("success_url"))
+}
+
+func newTestSignedProvider(signType string) *signedPaymentProvider {
+	return newSignedPaymentProvider(config.SignedPaymentConfig{
+		GatewayURL: "https://pay.example.com/",
+		MerchantID: "1001",
+		SecretKey:  "secret",
+		SignType:   signType,
+		Methods:    []string{"alipay", " WXPAY "},
+	})
+}
+
+func TestSignedProvider_CreatePaymentAndParseWebhook(t *testing.T) {
+	for _, signType := range []string{"md5", "hmac-sha256"} {
+		t.Run(signType, func(t *testing.T) {
+			p := newTestSignedProvider(signType)
+			require.Equal(t, []string{"alipay", "wxpay"}, p.Methods())
+
+			result, err := p.CreatePayment(context.Background(), &service.PaymentCreateRequest{
+				Order:     &service.PaymentOrder{OrderNo: "P1", Amount: 10, Method: "alipay"},
+				Subject:   "Balance top-up",
+				NotifyURL: "https://api.example.com/api/v1/payments/webhook/signed",
+				ReturnURL: "https://example.com/",
+			})
+			require.NoError(t, err)
+			require.True(t, strings.HasPrefix(result.PayURL, "https://pay.example.com/submit.php?"))
+
+			u, err := url.Parse(result.PayURL)
+			require.NoError(t, err)
+			params := u.Query()
+			require.Equal(t, "10.00", params.Get("money"))
+			require.Equal(t, p.sign(params), params.Get("sign"))
+
+			notify := url.Values{}
+			notify.Set("pid", "1001")
+			notify.Set("trade_no", "T100")
+			notify.Set("out_trade_no", "P1")
+			notify.Set("type", "alipay")
+			notify.Set("money", "10.00")
+			notify.Set("trade_status", "TRADE_SUCCESS")
+			notify.Set("sign", p.sign(notify))
+			notify.Set("sign_type", p.signTypeParam())
+
+			event, err := p.ParseWebhook(context.Background(), &service.PaymentWebhookRequest{Query: notify})
+			require.NoError(t, err)
+			require.Equal(t, service.PaymentEventPaid, event.Type)
+			require.Equal(t, "P1", event.OrderNo)
+			require.Equal(t, "T100", event.ProviderPaymentID)
+			require.InDelta(t, 10.0, event.Amount, 1e-9)
+
+			notify.Set("money", "0.01")
+			_, err = p.ParseWebhook(context.Background(), &service.PaymentWebhookRequest{Query: notify})
+			require.ErrorIs(t, err, service.ErrPaymentInvalidSignature)
+		})
+	}
+}
+
+func TestSignedProvider_WebhookAck(t *testing.T) {
+	p := newTestSignedProvider("md5")
+	status, _, body := p.WebhookAck(nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "success", string(body))
+
+	status, _, body = p.WebhookAck(errors.New("boom"))
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "fail", string(body))
+}
+
+func TestProvidePaymentProviders(t *testing.T) {
+	cfg := &config.Config{}
+	require.Empty(t, ProvidePaymentProviders(cfg))
+
+	cfg.Payment.Enabled = true
+	cfg.Payment.Fake.Enabled = true
+	cfg.Payment.Signed.Enabled = true
+	providers := ProvidePaymentProviders(cfg)
+	require.Len(t, providers, 2)
+	require.Equal(t, service.PaymentProviderSigned, providers[0].Name())
+	require.Equal(t, service.PaymentProviderFake, providers[1].Name())
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const promptTemplateColumns = `
+	id, name, group_id, api_key_id, enabled, mode, content, created_at, updated_at
+`
+
+type promptTemplateRepository struct {
+	sql sqlExecutor
+}
+
+// NewPromptTemplateRepository 创建系统提示词模板仓储
+func NewPromptTemplateRepository(sqlDB *sql.DB) service.PromptTemplateRepository {
+	return newPromptTemplateRepositoryWithSQL(sqlDB)
+}
+
+func newPromptTemplateRepositoryWithSQL(sqlq sqlExecutor) *promptTemplateRepository {
+	return &promptTemplateRepository{sql: sqlq}
+}
+
+func (r *promptTemplateRepository) List(ctx context.Context) ([]service.PromptTemplate, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+promptTemplateColumns+" FROM prompt_templates ORDER BY id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.PromptTemplate, 0)
+	for rows.Next() {
+		template, err := scanPromptTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *promptTemplateRepository) GetByID(ctx context.Context, id int64) (*service.PromptTemplate, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+promptTemplateColumns+" FROM prompt_templates WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrPromptTemplateNotFound
+	}
+	return scanPromptTemplate(rows)
+}
+
+func (r *promptTemplateRepository) Create(ctx context.Context, template *service.PromptTemplate) error {
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO prompt_templates (name, group_id, api_key_id, enabled, mode, content, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`, []any{
+		template.Name, template.GroupID, template.APIKeyID, template.Enabled, template.Mode, template.Content,
+	}, &template.ID, &template.CreatedAt, &template.UpdatedAt)
+}
+
+func (r *promptTemplateRepository) Update(ctx context.Context, template *service.PromptTemplate) error {
+	err := scanSingleRow(ctx, r.sql, `
+		UPDATE prompt_templates
+		SET name = $2, group_id = $3, api_key_id = $4, enabled = $5, mode = $6, content = $7, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`, []any{
+		template.ID, template.Name, template.GroupID, template.APIKeyID, template.Enabled, template.Mode, template.Content,
+	}, &template.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return service.ErrPromptTemplateNotFound
+	}
+	return err
+}
+
+func (r *promptTemplateRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.sql.ExecContext(ctx, "DELETE FROM prompt_templates WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrPromptTemplateNotFound
+	}
+	return nil
+}
+
+func scanPromptTemplate(rows *sql.Rows) (*service.PromptTemplate, error) {
+	var template service.PromptTemplate
+	if err := rows.Scan(
+		&template.ID, &template.Name, &template.GroupID, &template.APIKeyID, &template.Enabled,
+		&template.Mode, &template.Content, &template.CreatedAt, &template.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptTemplateRepositoryRoundTrip(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newPromptTemplateRepositoryWithSQL(db)
+
+	now := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	groupID := int64(2)
+	mock.ExpectQuery("INSERT INTO prompt_templates").
+		WithArgs("policy", &groupID, nil, true, "prepend", "Hi {{user_email}}").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(int64(3), now, now))
+	mock.ExpectQuery("SELECT (.|\n)*FROM prompt_templates ORDER BY id ASC").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "name", "group_id", "api_key_id", "enabled", "mode", "content", "created_at", "updated_at",
+		}).AddRow(int64(3), "policy", groupID, nil, true, "prepend", "Hi {{user_email}}", now, now))
+
+	template := &service.PromptTemplate{
+		Name: "policy", GroupID: &groupID, Enabled: true, Mode: service.PromptTemplateModePrepend, Content: "Hi {{user_email}}",
+	}
+	require.NoError(t, repo.Create(context.Background(), template))
+	require.Equal(t, int64(3), template.ID)
+
+	templates, err := repo.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	require.Equal(t, groupID, *templates[0].GroupID)
+	require.Nil(t, templates[0].APIKeyID)
+	require.Equal(t, "Hi {{user_email}}", templates[0].Content)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPromptTemplateRepositoryMissing(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newPromptTemplateRepositoryWithSQL(db)
+
+	mock.ExpectQuery("UPDATE prompt_templates").
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}))
+	mock.ExpectExec("DELETE FROM prompt_templates WHERE id = \\$1").
+		WithArgs(int64(9)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Update(context.Background(), &service.PromptTemplate{ID: 9, Name: "x", Mode: service.PromptTemplateModeAppend})
+	require.ErrorIs(t, err, service.ErrPromptTemplateNotFound)
+	require.ErrorIs(t, repo.Delete(context.Background(), 9), service.ErrPromptTemplateNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/secretbox"
 )
 
 type sqlQuerier interface {
@@ -16,16 +17,17 @@ type sqlQuerier interface {
 }
 
 type proxyRepository struct {
-	client *dbent.Client
-	sql    sqlQuerier
+	client  *dbent.Client
+	sql     sqlQuerier
+	keyring *secretbox.Keyring
 }
 
-func NewProxyRepository(client *dbent.Client, sqlDB *sql.DB) service.ProxyRepository {
-	return newProxyRepositoryWithSQL(client, sqlDB)
+func NewProxyRepository(client *dbent.Client, sqlDB *sql.DB, keyring *secretbox.Keyring) service.ProxyRepository {
+	return newProxyRepositoryWithSQL(client, sqlDB, keyring)
 }
 
-func newProxyRepositoryWithSQL(client *dbent.Client, sqlq sqlQuerier) *proxyRepository {
-	return &proxyRepository{client: client, sql: sqlq}
+func newProxyRepositoryWithSQL(client *dbent.Client, sqlq sqlQuerier, keyring *secretbox.Keyring) *proxyRepository {
+	return &proxyRepository{client: client, sql: sqlq, keyring: keyring}
 }
 
 func (r *proxyRepository) Create(ctx context.Context, proxyIn *service.Proxy) error {
@@ -39,7 +41,11 @@ func (r *proxyRepository) Create(ctx context.Context, proxyIn *service.Proxy) er
 		builder.SetUsername(proxyIn.Username)
 	}
 	if proxyIn.Password != "" {
-		builder.SetPassword(proxyIn.Password)
+		password, err := encryptSecretString(r.keyring, proxyIn.Password)
+		if err != nil {
+			return err
+		}
+		builder.SetPassword(password)
 	}
 
 	created, err := builder.Save(ctx)
@@ -57,7 +63,7 @@ func (r *proxyRepository) GetByID(ctx context.Context, id int64) (*service.Proxy
 		}
 		return nil, err
 	}
-	return proxyEntityToService(m), nil
+	return proxyEntityToService(m, r.keyring), nil
 }
 
 func (r *proxyRepository) Update(ctx context.Context, proxyIn *service.Proxy) error {
@@ -73,7 +79,11 @@ func (r *proxyRepository) Update(ctx context.Context, proxyIn *service.Proxy) er
 		builder.ClearUsername()
 	}
 	if proxyIn.Password != "" {
-		builder.SetPassword(proxyIn.Password)
+		password, err := encryptSecretString(r.keyring, proxyIn.Password)
+		if err != nil {
+			return err
+		}
+		builder.SetPassword(password)
 	} else {
 		builder.ClearPassword()
 	}
@@ -127,7 +137,7 @@ func (r *proxyRepository) ListWithFilters(ctx context.Context, params pagination
 
 	outProxies := make([]service.Proxy, 0, len(proxies))
 	for i := range proxies {
-		outProxies = append(outProxies, *proxyEntityToService(proxies[i]))
+		outProxies = append(outProxies, *proxyEntityToService(proxies[i], r.keyring))
 	}
 
 	return outProxies, paginationResultFromTotal(int64(total), params), nil
@@ -169,7 +179,7 @@ func (r *proxyRepository) ListWithFiltersAndAccountCount(ctx context.Context, pa
 	// Build result with account counts
 	result := make([]service.ProxyWithAccountCount, 0, len(proxies))
 	for i := range proxies {
-		proxyOut := proxyEntityToService(proxies[i])
+		proxyOut := proxyEntityToService(proxies[i], r.keyring)
 		if proxyOut == nil {
 			continue
 		}
@@ -191,7 +201,7 @@ func (r *proxyRepository) ListActive(ctx context.Context) ([]service.Proxy, erro
 	}
 	outProxies := make([]service.Proxy, 0, len(proxies))
 	for i := range proxies {
-		outProxies = append(outProxies, *proxyEntityToService(proxies[i]))
+		outProxies = append(outProxies, *proxyEntityToService(proxies[i], r.keyring))
 	}
 	return outProxies, nil
 }
@@ -208,12 +218,21 @@ func (r *proxyRepository) ExistsByHostPortAuth(ctx context.Context, host string,
 	}
 	if password == "" {
 		q = q.Where(proxy.Or(proxy.PasswordIsNil(), proxy.PasswordEQ("")))
-	} else {
-		q = q.Where(proxy.PasswordEQ(password))
+		count, err := q.Count(ctx)
+		return count > 0, err
 	}
 
-	count, err := q.Count(ctx)
-	return count > 0, err
+	// 密码可能加密存储（每次加密结果不同），取出同 host/port/username 的候选后解密比较。
+	candidates, err := q.Where(proxy.PasswordNotNil()).All(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range candidates {
+		if decryptSecretString(r.keyring, *c.Password, "proxy password") == password {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 // CountAccountsByProxyID returns the number of accounts using a specific proxy
@@ -313,7 +332,7 @@ func (r *proxyRepository) ListActiveWithAccountCount(ctx context.Context) ([]ser
 	// Build result with account counts
 	result := make([]service.ProxyWithAccountCount, 0, len(proxies))
 	for i := range proxies {
-		proxyOut := proxyEntityToService(proxies[i])
+		proxyOut := proxyEntityToService(proxies[i], r.keyring)
 		if proxyOut == nil {
 			continue
 		}
@@ -326,7 +345,7 @@ func (r *proxyRepository) ListActiveWithAccountCount(ctx context.Context) ([]ser
 	return result, nil
 }
 
-func proxyEntityToService(m *dbent.Proxy) *service.Proxy {
+func proxyEntityToService(m *dbent.Proxy, keyring *secretbox.Keyring) *service.Proxy {
 	if m == nil {
 		return nil
 	}
@@ -344,7 +363,7 @@ func proxyEntityToService(m *dbent.Proxy) *service.Proxy {
 		out.Username = *m.Username
 	}
 	if m.Password != nil {
-		out.Password = *m.Password
+		out.Password = decryptSecretString(keyring, *m.Password, "proxy password")
 	}
 	return out
 }
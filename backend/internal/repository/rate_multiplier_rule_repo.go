@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const rateMultiplierOverrideColumns = `
+	id, group_id, user_id, api_key_id, rate_multiplier, notes, created_by, created_at, updated_at
+`
+
+const volumeDiscountTierColumns = `
+	id, group_id, min_monthly_spend, discount_percent, notes, created_by, created_at, updated_at
+`
+
+type rateMultiplierRuleRepository struct {
+	sql sqlExecutor
+}
+
+func NewRateMultiplierRuleRepository(sqlDB *sql.DB) service.RateMultiplierRuleRepository {
+	return newRateMultiplierRuleRepositoryWithSQL(sqlDB)
+}
+
+func newRateMultiplierRuleRepositoryWithSQL(sqlq sqlExecutor) *rateMultiplierRuleRepository {
+	return &rateMultiplierRuleRepository{sql: sqlq}
+}
+
+func (r *rateMultiplierRuleRepository) ListAllOverrides(ctx context.Context) ([]service.RateMultiplierOverride, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+rateMultiplierOverrideColumns+" FROM rate_multiplier_overrides ORDER BY id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	return scanRateMultiplierOverrides(rows)
+}
+
+func (r *rateMultiplierRuleRepository) ListOverrides(ctx context.Context, params pagination.PaginationParams, filters service.RateMultiplierOverrideFilters) ([]service.RateMultiplierOverride, *pagination.PaginationResult, error) {
+	conditions := []string{"1=1"}
+	args := []any{}
+	if filters.GroupID != nil {
+		args = append(args, *filters.GroupID)
+		conditions = append(conditions, fmt.Sprintf("group_id = $%d", len(args)))
+	}
+	if filters.UserID != nil {
+		args = append(args, *filters.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filters.APIKeyID != nil {
+		args = append(args, *filters.APIKeyID)
+		conditions = append(conditions, fmt.Sprintf("api_key_id = $%d", len(args)))
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM rate_multiplier_overrides"+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.RateMultiplierOverride{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := "SELECT " + rateMultiplierOverrideColumns + " FROM rate_multiplier_overrides" + where +
+		fmt.Sprintf(" ORDER BY group_id ASC, id ASC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	items, err := scanRateMultiplierOverrides(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	return items, paginationResultFromTotal(total, params), nil
+}
+
+func (r *rateMultiplierRuleRepository) UpsertOverride(ctx context.Context, override *service.RateMultiplierOverride) error {
+	if override == nil {
+		return nil
+	}
+	// 用户覆盖与 API Key 覆盖分别使用各自的部分唯一索引
+	conflict := "(group_id, user_id) WHERE user_id IS NOT NULL"
+	if override.APIKeyID != nil {
+		conflict = "(group_id, api_key_id) WHERE api_key_id IS NOT NULL"
+	}
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO rate_multiplier_overrides
+			(group_id, user_id, api_key_id, rate_multiplier, notes, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		ON CONFLICT `+conflict+` DO UPDATE SET
+			rate_multiplier = EXCLUDED.rate_multiplier,
+			notes = EXCLUDED.notes,
+			created_by = EXCLUDED.created_by,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`, []any{
+		override.GroupID,
+		nullInt64(override.UserID),
+		nullInt64(override.APIKeyID),
+		override.RateMultiplier,
+		override.Notes,
+		nullInt64(override.CreatedBy),
+	}, &override.ID, &override.CreatedAt, &override.UpdatedAt)
+}
+
+func (r *rateMultiplierRuleRepository) DeleteOverride(ctx context.Context, id int64) error {
+	return r.deleteByID(ctx, "rate_multiplier_overrides", id, service.ErrRateMultiplierOverrideNotFound)
+}
+
+func (r *rateMultiplierRuleRepository) ListTiers(ctx context.Context) ([]service.VolumeDiscountTier, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+volumeDiscountTierColumns+`
+		FROM volume_discount_tiers
+		ORDER BY group_id ASC NULLS FIRST, min_monthly_spend ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.VolumeDiscountTier, 0)
+	for rows.Next() {
+		var (
+			t         service.VolumeDiscountTier
+			groupID   sql.NullInt64
+			createdBy sql.NullInt64
+		)
+		if err := rows.Scan(
+			&t.ID,
+			&groupID,
+			&t.MinMonthlySpend,
+			&t.DiscountPercent,
+			&t.Notes,
+			&createdBy,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		t.GroupID = nullInt64Ptr(groupID)
+		t.CreatedBy = nullInt64Ptr(createdBy)
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *rateMultiplierRuleRepository) UpsertTier(ctx context.Context, tier *service.VolumeDiscountTier) error {
+	if tier == nil {
+		return nil
+	}
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO volume_discount_tiers
+			(group_id, min_monthly_spend, discount_percent, notes, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		ON CONFLICT ((COALESCE(group_id, 0)), min_monthly_spend) DO UPDATE SET
+			discount_percent = EXCLUDED.discount_percent,
+			notes = EXCLUDED.notes,
+			created_by = EXCLUDED.created_by,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`, []any{
+		nullInt64(tier.GroupID),
+		tier.MinMonthlySpend,
+		tier.DiscountPercent,
+		tier.Notes,
+		nullInt64(tier.CreatedBy),
+	}, &tier.ID, &tier.CreatedAt, &tier.UpdatedAt)
+}
+
+func (r *rateMultiplierRuleRepository) DeleteTier(ctx context.Context, id int64) error {
+	return r.deleteByID(ctx, "volume_discount_tiers", id, service.ErrVolumeDiscountTierNotFound)
+}
+
+func (r *rateMultiplierRuleRepository) deleteByID(ctx context.Context, table string, id int64, notFound error) error {
+	res, err := r.sql.ExecContext(ctx, "DELETE FROM "+table+" WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func (r *rateMultiplierRuleRepository) SumMonthlySpend(ctx context.Context, userID int64, since time.Time) (float64, error) {
+	var spend float64
+	err := scanSingleRow(ctx, r.sql, `
+		SELECT COALESCE(SUM(actual_cost), 0)
+		FROM usage_logs
+		WHERE user_id = $1 AND created_at >= $2 AND billing_type = $3
+	`, []any{userID, since, service.BillingTypeBalance}, &spend)
+	return spend, err
+}
+
+func (r *rateMultiplierRuleRepository) ListDailyUsage(ctx context.Context, start, end time.Time, userID *int64) ([]service.RateMultiplierUsageRow, error) {
+	args := []any{start, end, service.BillingTypeBalance}
+	userFilter := ""
+	if userID != nil {
+		args = append(args, *userID)
+		userFilter = fmt.Sprintf(" AND ul.user_id = $%d", len(args))
+	}
+	// 分组倍率取当前值，用于按当前规则重算
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT
+			DATE_TRUNC('day', ul.created_at) AS day,
+			ul.user_id,
+			COALESCE(u.email, ''),
+			ul.api_key_id,
+			COALESCE(ul.group_id, 0),
+			COALESCE(g.name, ''),
+			COALESCE(g.rate_multiplier, 1),
+			COUNT(*),
+			COALESCE(SUM(ul.total_cost), 0),
+			COALESCE(SUM(ul.actual_cost), 0)
+		FROM usage_logs ul
+		LEFT JOIN users u ON u.id = ul.user_id
+		LEFT JOIN groups g ON g.id = ul.group_id
+		WHERE ul.created_at >= $1 AND ul.created_at < $2 AND ul.billing_type = $3`+userFilter+`
+		GROUP BY 1, ul.user_id, u.email, ul.api_key_id, ul.group_id, g.name, g.rate_multiplier
+		ORDER BY 1 ASC, ul.user_id ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.RateMultiplierUsageRow, 0)
+	for rows.Next() {
+		var row service.RateMultiplierUsageRow
+		if err := rows.Scan(
+			&row.Day,
+			&row.UserID,
+			&row.UserEmail,
+			&row.APIKeyID,
+			&row.GroupID,
+			&row.GroupName,
+			&row.GroupRateMultiplier,
+			&row.Requests,
+			&row.TotalCost,
+			&row.ActualCost,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func scanRateMultiplierOverrides(rows *sql.Rows) ([]service.RateMultiplierOverride, error) {
+	out := make([]service.RateMultiplierOverride, 0)
+	for rows.Next() {
+		var (
+			o         service.RateMultiplierOverride
+			userID    sql.NullInt64
+			apiKeyID  sql.NullInt64
+			createdBy sql.NullInt64
+		)
+		if err := rows.Scan(
+			&o.ID,
+			&o.GroupID,
+			&userID,
+			&apiKeyID,
+			&o.RateMultiplier,
+			&o.Notes,
+			&createdBy,
+			&o.CreatedAt,
+			&o.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		o.UserID = nullInt64Ptr(userID)
+		o.APIKeyID = nullInt64Ptr(apiKeyID)
+		o.CreatedBy = nullInt64Ptr(createdBy)
+		out = append(out, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateMultiplierRuleRepositoryUpsertAPIKeyOverrideUsesKeyIndex(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newRateMultiplierRuleRepositoryWithSQL(db)
+
+	apiKeyID := int64(34)
+	adminID := int64(1)
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("INSERT INTO rate_multiplier_overrides(.|\n)*ON CONFLICT \\(group_id, api_key_id\\) WHERE api_key_id IS NOT NULL").
+		WithArgs(int64(2), nil, apiKeyID, 0.8, "vip", adminID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(int64(7), now, now))
+
+	override := &service.RateMultiplierOverride{GroupID: 2, APIKeyID: &apiKeyID, RateMultiplier: 0.8, Notes: "vip", CreatedBy: &adminID}
+	require.NoError(t, repo.UpsertOverride(context.Background(), override))
+	require.Equal(t, int64(7), override.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRateMultiplierRuleRepositoryDeleteTierNotFound(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newRateMultiplierRuleRepositoryWithSQL(db)
+
+	mock.ExpectExec("DELETE FROM volume_discount_tiers WHERE id = \\$1").
+		WithArgs(int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.DeleteTier(context.Background(), 5)
+	require.ErrorIs(t, err, service.ErrVolumeDiscountTierNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRateMultiplierRuleRepositorySumMonthlySpendBalanceOnly(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newRateMultiplierRuleRepositoryWithSQL(db)
+
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT COALESCE\\(SUM\\(actual_cost\\), 0\\)(.|\n)*billing_type = \\$3").
+		WithArgs(int64(9), since, service.BillingTypeBalance).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(123.5))
+
+	spend, err := repo.SumMonthlySpend(context.Background(), 9, since)
+	require.NoError(t, err)
+	require.InDelta(t, 123.5, spend, 1e-9)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
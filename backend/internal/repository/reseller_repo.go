@@ -0,0 +1,425 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// resellerColumns 分销商字段（resellers r JOIN users u），附带余额与下级用户数
+const resellerColumns = `
+	r.user_id, r.markup, r.max_sub_users, r.status, r.notes, r.created_at, r.updated_at,
+	COALESCE(u.email, ''), COALESCE(u.username, ''), COALESCE(u.balance, 0),
+	(SELECT COUNT(*) FROM users su WHERE su.parent_user_id = r.user_id AND su.deleted_at IS NULL)
+`
+
+const resellerSubUserColumns = `
+	id, email, username, notes, role, balance, concurrency, status, parent_user_id, created_at, updated_at
+`
+
+const resellerCodeColumns = `
+	id, code, type, value, status, used_by, used_at, notes, created_at, group_id, validity_days, issuer_user_id
+`
+
+type resellerRepository struct {
+	sql sqlExecutor
+}
+
+func NewResellerRepository(sqlDB *sql.DB) service.ResellerRepository {
+	return newResellerRepositoryWithSQL(sqlDB)
+}
+
+func newResellerRepositoryWithSQL(sqlq sqlExecutor) *resellerRepository {
+	return &resellerRepository{sql: sqlq}
+}
+
+// exec 在事务上下文中使用 tx 绑定的执行器，保证层级结算与余额变动同事务
+func (r *resellerRepository) exec(ctx context.Context) sqlExecutor {
+	if tx := dbent.TxFromContext(ctx); tx != nil {
+		return tx.Client()
+	}
+	return r.sql
+}
+
+func (r *resellerRepository) Get(ctx context.Context, userID int64) (*service.Reseller, error) {
+	rows, err := r.exec(ctx).QueryContext(ctx, "SELECT "+resellerColumns+`
+		FROM resellers r JOIN users u ON u.id = r.user_id AND u.deleted_at IS NULL
+		WHERE r.user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrResellerNotFound
+	}
+	return scanReseller(rows)
+}
+
+func (r *resellerRepository) Upsert(ctx context.Context, reseller *service.Reseller) error {
+	if reseller == nil {
+		return nil
+	}
+	return scanSingleRow(ctx, r.exec(ctx), `
+		INSERT INTO resellers (user_id, markup, max_sub_users, status, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			markup = EXCLUDED.markup,
+			max_sub_users = EXCLUDED.max_sub_users,
+			status = EXCLUDED.status,
+			notes = EXCLUDED.notes,
+			updated_at = NOW()
+		RETURNING created_at, updated_at
+	`, []any{reseller.UserID, reseller.Markup, reseller.MaxSubUsers, reseller.Status, reseller.Notes},
+		&reseller.CreatedAt, &reseller.UpdatedAt,
+	)
+}
+
+func (r *resellerRepository) List(ctx context.Context, params pagination.PaginationParams, filters service.ResellerListFilters) ([]service.Reseller, *pagination.PaginationResult, error) {
+	conditions := []string{"u.deleted_at IS NULL"}
+	args := []any{}
+	if filters.Status != "" {
+		args = append(args, filters.Status)
+		conditions = append(conditions, fmt.Sprintf("r.status = $%d", len(args)))
+	}
+	if filters.Search != "" {
+		args = append(args, "%"+filters.Search+"%")
+		conditions = append(conditions, fmt.Sprintf("(u.email ILIKE $%d OR u.username ILIKE $%d)", len(args), len(args)))
+	}
+	from := " FROM resellers r JOIN users u ON u.id = r.user_id WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*)"+from, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.Reseller{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := "SELECT " + resellerColumns + from +
+		fmt.Sprintf(" ORDER BY r.user_id DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	resellers := make([]service.Reseller, 0)
+	for rows.Next() {
+		reseller, err := scanReseller(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		resellers = append(resellers, *reseller)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return resellers, paginationResultFromTotal(total, params), nil
+}
+
+// GetChain 沿 users.parent_user_id 向上追溯，返回有效分销商（由近及远）。
+// 停用的分销商不参与加价，但不会切断更上层的链路。
+func (r *resellerRepository) GetChain(ctx context.Context, userID int64, maxDepth int) ([]service.ResellerChainLevel, error) {
+	rows, err := r.exec(ctx).QueryContext(ctx, `
+		WITH RECURSIVE chain AS (
+			SELECT u.parent_user_id AS user_id, 1 AS depth
+			FROM users u
+			WHERE u.id = $1 AND u.parent_user_id IS NOT NULL
+			UNION ALL
+			SELECT u.parent_user_id, c.depth + 1
+			FROM chain c
+			JOIN users u ON u.id = c.user_id
+			WHERE u.parent_user_id IS NOT NULL AND c.depth < $2
+		)
+		SELECT r.user_id, r.markup
+		FROM chain c
+		JOIN resellers r ON r.user_id = c.user_id AND r.status = 'active'
+		JOIN users u ON u.id = c.user_id AND u.deleted_at IS NULL
+		ORDER BY c.depth ASC
+	`, userID, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	levels := make([]service.ResellerChainLevel, 0)
+	for rows.Next() {
+		var level service.ResellerChainLevel
+		if err := rows.Scan(&level.ResellerID, &level.Markup); err != nil {
+			return nil, err
+		}
+		levels = append(levels, level)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
+
+func (r *resellerRepository) CountSubUsers(ctx context.Context, resellerID int64) (int, error) {
+	var count int
+	err := scanSingleRow(ctx, r.exec(ctx),
+		"SELECT COUNT(*) FROM users WHERE parent_user_id = $1 AND deleted_at IS NULL",
+		[]any{resellerID}, &count)
+	return count, err
+}
+
+func (r *resellerRepository) ListSubUsers(ctx context.Context, resellerID int64, params pagination.PaginationParams, search string) ([]service.User, *pagination.PaginationResult, error) {
+	conditions := []string{"parent_user_id = $1", "deleted_at IS NULL"}
+	args := []any{resellerID}
+	if search != "" {
+		args = append(args, "%"+search+"%")
+		conditions = append(conditions, fmt.Sprintf("(email ILIKE $%d OR username ILIKE $%d)", len(args), len(args)))
+	}
+	where := " FROM users WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*)"+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.User{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := "SELECT " + resellerSubUserColumns + where +
+		fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	users := make([]service.User, 0)
+	for rows.Next() {
+		var (
+			u      service.User
+			parent sql.NullInt64
+		)
+		if err := rows.Scan(
+			&u.ID, &u.Email, &u.Username, &u.Notes, &u.Role, &u.Balance, &u.Concurrency, &u.Status,
+			&parent, &u.CreatedAt, &u.UpdatedAt,
+		); err != nil {
+			return nil, nil, err
+		}
+		u.ParentUserID = nullInt64Ptr(parent)
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return users, paginationResultFromTotal(total, params), nil
+}
+
+func (r *resellerRepository) UpdateSubUserStatus(ctx context.Context, resellerID, userID int64, status string) error {
+	res, err := r.exec(ctx).ExecContext(ctx, `
+		UPDATE users SET status = $3, updated_at = NOW()
+		WHERE id = $2 AND parent_user_id = $1 AND deleted_at IS NULL
+	`, resellerID, userID, status)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrResellerSubUserNotFound
+	}
+	return nil
+}
+
+func (r *resellerRepository) ListCodes(ctx context.Context, resellerID int64, params pagination.PaginationParams) ([]service.RedeemCode, *pagination.PaginationResult, error) {
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM redeem_codes WHERE issuer_user_id = $1", []any{resellerID}, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.RedeemCode{}, paginationResultFromTotal(0, params), nil
+	}
+
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+resellerCodeColumns+`
+		FROM redeem_codes WHERE issuer_user_id = $1
+		ORDER BY id DESC LIMIT $2 OFFSET $3
+	`, resellerID, params.Limit(), params.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	codes := make([]service.RedeemCode, 0)
+	for rows.Next() {
+		var (
+			code    service.RedeemCode
+			usedBy  sql.NullInt64
+			usedAt  sql.NullTime
+			groupID sql.NullInt64
+			issuer  sql.NullInt64
+		)
+		if err := rows.Scan(
+			&code.ID, &code.Code, &code.Type, &code.Value, &code.Status, &usedBy, &usedAt, &code.Notes,
+			&code.CreatedAt, &groupID, &code.ValidityDays, &issuer,
+		); err != nil {
+			return nil, nil, err
+		}
+		code.UsedBy = nullInt64Ptr(usedBy)
+		code.GroupID = nullInt64Ptr(groupID)
+		code.IssuerUserID = nullInt64Ptr(issuer)
+		code.UsedAt = nullTimePtr(usedAt)
+		codes = append(codes, code)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return codes, paginationResultFromTotal(total, params), nil
+}
+
+func (r *resellerRepository) DeleteUnusedCode(ctx context.Context, resellerID, codeID int64) (float64, error) {
+	rows, err := r.exec(ctx).QueryContext(ctx, `
+		DELETE FROM redeem_codes
+		WHERE id = $1 AND issuer_user_id = $2 AND status = 'unused'
+		RETURNING value
+	`, codeID, resellerID)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = rows.Close() }()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, service.ErrResellerCodeNotFound
+	}
+	var value float64
+	if err := rows.Scan(&value); err != nil {
+		return 0, err
+	}
+	return value, rows.Err()
+}
+
+func (r *resellerRepository) InsertLedger(ctx context.Context, entries []service.ResellerLedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(entries))
+	args := make([]any, 0, len(entries)*9)
+	for _, e := range entries {
+		base := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, NOW())",
+			base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8, base+9))
+		args = append(args, e.ResellerID, e.ChildUserID, e.SourceUserID, e.RequestID, e.Model, e.TotalTokens, e.Revenue, e.Cost, e.Profit)
+	}
+	_, err := r.exec(ctx).ExecContext(ctx, `
+		INSERT INTO reseller_ledger
+			(reseller_id, child_user_id, source_user_id, request_id, model, total_tokens, revenue, cost, profit, created_at)
+		VALUES `+strings.Join(values, ", "), args...)
+	return err
+}
+
+func (r *resellerRepository) Report(ctx context.Context, resellerID int64, start, end time.Time) (*service.ResellerReport, error) {
+	report := &service.ResellerReport{
+		ByDay:     []service.ResellerReportRow{},
+		BySubUser: []service.ResellerReportRow{},
+	}
+	if err := scanSingleRow(ctx, r.sql, `
+		SELECT COUNT(*), COALESCE(SUM(total_tokens), 0), COALESCE(SUM(revenue), 0), COALESCE(SUM(cost), 0), COALESCE(SUM(profit), 0)
+		FROM reseller_ledger
+		WHERE reseller_id = $1 AND created_at >= $2 AND created_at < $3
+	`, []any{resellerID, start, end},
+		&report.Summary.Requests, &report.Summary.TotalTokens, &report.Summary.Revenue, &report.Summary.Cost, &report.Summary.Profit,
+	); err != nil {
+		return nil, err
+	}
+
+	dayRows, err := r.sql.QueryContext(ctx, `
+		SELECT TO_CHAR(created_at AT TIME ZONE $4, 'YYYY-MM-DD') AS day,
+			COUNT(*), COALESCE(SUM(total_tokens), 0), COALESCE(SUM(revenue), 0), COALESCE(SUM(cost), 0), COALESCE(SUM(profit), 0)
+		FROM reseller_ledger
+		WHERE reseller_id = $1 AND created_at >= $2 AND created_at < $3
+		GROUP BY day
+		ORDER BY day ASC
+	`, resellerID, start, end, resolveUsageStatsTimezone())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = dayRows.Close() }()
+	for dayRows.Next() {
+		var row service.ResellerReportRow
+		if err := dayRows.Scan(&row.Date, &row.Requests, &row.TotalTokens, &row.Revenue, &row.Cost, &row.Profit); err != nil {
+			return nil, err
+		}
+		report.ByDay = append(report.ByDay, row)
+	}
+	if err := dayRows.Err(); err != nil {
+		return nil, err
+	}
+
+	userRows, err := r.sql.QueryContext(ctx, `
+		SELECT l.child_user_id, COALESCE(u.email, ''),
+			COUNT(*), COALESCE(SUM(l.total_tokens), 0), COALESCE(SUM(l.revenue), 0), COALESCE(SUM(l.cost), 0), COALESCE(SUM(l.profit), 0)
+		FROM reseller_ledger l
+		LEFT JOIN users u ON u.id = l.child_user_id
+		WHERE l.reseller_id = $1 AND l.created_at >= $2 AND l.created_at < $3
+		GROUP BY l.child_user_id, u.email
+		ORDER BY SUM(l.profit) DESC
+	`, resellerID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = userRows.Close() }()
+	for userRows.Next() {
+		var row service.ResellerReportRow
+		if err := userRows.Scan(&row.ChildUserID, &row.Email, &row.Requests, &row.TotalTokens, &row.Revenue, &row.Cost, &row.Profit); err != nil {
+			return nil, err
+		}
+		report.BySubUser = append(report.BySubUser, row)
+	}
+	if err := userRows.Err(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (r *resellerRepository) DeductBalanceIfSufficient(ctx context.Context, userID int64, amount float64) (bool, error) {
+	res, err := r.exec(ctx).ExecContext(ctx, `
+		UPDATE users SET balance = balance - $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL AND balance >= $2
+	`, userID, amount)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func scanReseller(rows *sql.Rows) (*service.Reseller, error) {
+	var reseller service.Reseller
+	if err := rows.Scan(
+		&reseller.UserID,
+		&reseller.Markup,
+		&reseller.MaxSubUsers,
+		&reseller.Status,
+		&reseller.Notes,
+		&reseller.CreatedAt,
+		&reseller.UpdatedAt,
+		&reseller.Email,
+		&reseller.Username,
+		&reseller.Balance,
+		&reseller.SubUserCount,
+	); err != nil {
+		return nil, err
+	}
+	return &reseller, nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResellerRepositoryGetChainOrdersNearestFirst(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newResellerRepositoryWithSQL(db)
+
+	mock.ExpectQuery("WITH RECURSIVE chain AS").
+		WithArgs(int64(100), 8).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "markup"}).
+			AddRow(int64(10), 1.5).
+			AddRow(int64(1), 1.2))
+
+	levels, err := repo.GetChain(context.Background(), 100, 8)
+	require.NoError(t, err)
+	require.Equal(t, []service.ResellerChainLevel{
+		{ResellerID: 10, Markup: 1.5},
+		{ResellerID: 1, Markup: 1.2},
+	}, levels)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResellerRepositoryDeleteUnusedCodeNotFound(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newResellerRepositoryWithSQL(db)
+
+	mock.ExpectQuery("DELETE FROM redeem_codes").
+		WithArgs(int64(5), int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}))
+
+	_, err := repo.DeleteUnusedCode(context.Background(), 10, 5)
+	require.ErrorIs(t, err, service.ErrResellerCodeNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResellerRepositoryUpdateSubUserStatusScopedToParent(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newResellerRepositoryWithSQL(db)
+
+	mock.ExpectExec("UPDATE users SET status = \\$3.*parent_user_id = \\$1").
+		WithArgs(int64(10), int64(200), service.StatusDisabled).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.UpdateSubUserStatus(context.Background(), 10, 200, service.StatusDisabled)
+	require.ErrorIs(t, err, service.ErrResellerSubUserNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResellerRepositoryInsertLedgerBatches(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newResellerRepositoryWithSQL(db)
+
+	mock.ExpectExec("INSERT INTO reseller_ledger").
+		WithArgs(
+			int64(10), int64(100), int64(100), "req-1", "claude", int64(42), 3.6, 2.4, 1.2,
+			int64(1), int64(10), int64(100), "req-1", "claude", int64(42), 2.4, 2.0, 0.4,
+		).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err := repo.InsertLedger(context.Background(), []service.ResellerLedgerEntry{
+		{ResellerID: 10, ChildUserID: 100, SourceUserID: 100, RequestID: "req-1", Model: "claude", TotalTokens: 42, Revenue: 3.6, Cost: 2.4, Profit: 1.2},
+		{ResellerID: 1, ChildUserID: 10, SourceUserID: 100, RequestID: "req-1", Model: "claude", TotalTokens: 42, Revenue: 2.4, Cost: 2.0, Profit: 0.4},
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
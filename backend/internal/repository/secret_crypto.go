@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/secretbox"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// encryptedCredentialsField 加密后的账号凭证以 {"__encrypted": "enc:v1:..."} 形式存入 JSONB 列，
+// 保持列类型不变，明文与密文行可以在重加密过程中共存。
+const encryptedCredentialsField = "__encrypted"
+
+// secretSettingKeys 需要加密存储的系统设置项
+var secretSettingKeys = map[string]struct{}{
+	service.SettingKeySMTPPassword:               {},
+	service.SettingKeyTurnstileSecretKey:         {},
+	service.SettingKeyLinuxDoConnectClientSecret: {},
+	service.SettingKeyAdminAPIKey:                {},
+}
+
+func isSecretSettingKey(key string) bool {
+	_, ok := secretSettingKeys[key]
+	return ok
+}
+
+// sealedCredentials 返回凭证 JSON 中的密文；明文凭证返回 false。
+func sealedCredentials(m map[string]any) (string, bool) {
+	if len(m) != 1 {
+		return "", false
+	}
+	v, ok := m[encryptedCredentialsField].(string)
+	if !ok || !secretbox.IsEncrypted(v) {
+		return "", false
+	}
+	return v, true
+}
+
+// encryptCredentials 在写库前加密账号凭证；未启用加密时原样返回。
+func encryptCredentials(keyring *secretbox.Keyring, in map[string]any) (map[string]any, error) {
+	in = normalizeJSONMap(in)
+	if _, ok := in[encryptedCredentialsField]; ok && len(in) > 1 {
+		// 密文占位与其他字段混在一起：说明调用方在未能解密的凭证上做了修改，拒绝写入
+		return nil, fmt.Errorf("encrypt credentials: refusing to write credentials mixed with %s", encryptedCredentialsField)
+	}
+	if !keyring.Encrypting() || len(in) == 0 {
+		return in, nil
+	}
+	if _, ok := sealedCredentials(in); ok {
+		return in, nil
+	}
+	raw, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("marshal credentials: %w", err)
+	}
+	sealed, err := keyring.Encrypt(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("encrypt credentials: %w", err)
+	}
+	return map[string]any{encryptedCredentialsField: sealed}, nil
+}
+
+// decryptCredentials 解密账号凭证；明文凭证返回副本。
+func decryptCredentials(keyring *secretbox.Keyring, in map[string]any) (map[string]any, error) {
+	sealed, ok := sealedCredentials(in)
+	if !ok {
+		return copyJSONMap(in), nil
+	}
+	plain, err := keyring.Decrypt(sealed)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]any{}
+	if err := json.Unmarshal([]byte(plain), &out); err != nil {
+		return nil, fmt.Errorf("unmarshal credentials: %w", err)
+	}
+	return out, nil
+}
+
+// decryptAccountCredentials 供实体转换使用：解密失败时记录日志并原样返回密文（ok 为 false），
+// 避免整个列表查询失败；调用方需将账号标记为凭证不可读，不得用该值写回。
+func decryptAccountCredentials(keyring *secretbox.Keyring, accountID int64, in map[string]any) (map[string]any, bool) {
+	out, err := decryptCredentials(keyring, in)
+	if err != nil {
+		log.Printf("[SecretBox] decrypt account credentials failed: account=%d err=%v", accountID, err)
+		return copyJSONMap(in), false
+	}
+	return out, true
+}
+
+// rewriteCredentials 按当前密钥环重写凭证：返回新值与是否需要写回。
+func rewriteCredentials(keyring *secretbox.Keyring, in map[string]any) (map[string]any, bool, error) {
+	sealed, ok := sealedCredentials(in)
+	if !ok {
+		if !keyring.Encrypting() || len(in) == 0 {
+			return in, false, nil
+		}
+		out, err := encryptCredentials(keyring, in)
+		return out, err == nil, err
+	}
+	if !keyring.NeedsRotation(sealed) {
+		return in, false, nil
+	}
+	plain, err := decryptCredentials(keyring, in)
+	if err != nil {
+		return nil, false, err
+	}
+	out, err := encryptCredentials(keyring, plain)
+	return out, err == nil, err
+}
+
+// encryptSecretString 加密单个敏感字符串（代理密码、敏感设置）。
+func encryptSecretString(keyring *secretbox.Keyring, value string) (string, error) {
+	return keyring.Encrypt(value)
+}
+
+// decryptSecretString 解密单个敏感字符串；解密失败记录日志并返回空串。
+func decryptSecretString(keyring *secretbox.Keyring, value, what string) string {
+	plain, err := keyring.Decrypt(value)
+	if err != nil {
+		log.Printf("[SecretBox] decrypt %s failed: err=%v", what, err)
+		return ""
+	}
+	return plain
+}
+
+// rewriteSecretString 按当前密钥环重写单个敏感字符串：返回新值与是否需要写回。
+func rewriteSecretString(keyring *secretbox.Keyring, value string) (string, bool, error) {
+	if !keyring.NeedsRotation(value) {
+		return value, false, nil
+	}
+	plain, err := keyring.Decrypt(value)
+	if err != nil {
+		return "", false, err
+	}
+	out, err := keyring.Encrypt(plain)
+	if err != nil {
+		return "", false, err
+	}
+	return out, out != value, nil
+}
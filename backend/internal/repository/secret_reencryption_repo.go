@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/secretbox"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/lib/pq"
+)
+
+type secretReencryptionRepository struct {
+	sql     sqlExecutor
+	keyring *secretbox.Keyring
+}
+
+// NewSecretReencryptionRepository 创建敏感数据重加密仓储
+func NewSecretReencryptionRepository(sqlDB *sql.DB, keyring *secretbox.Keyring) service.SecretReencryptionRepository {
+	return newSecretReencryptionRepositoryWithSQL(sqlDB, keyring)
+}
+
+func newSecretReencryptionRepositoryWithSQL(sqlq sqlExecutor, keyring *secretbox.Keyring) *secretReencryptionRepository {
+	return &secretReencryptionRepository{sql: sqlq, keyring: keyring}
+}
+
+type secretRow struct {
+	id    int64
+	value string
+}
+
+// ReencryptBatch 读取 afterID 之后的一批行，按当前密钥环重写需要轮换的值，以原值为条件写回。
+func (r *secretReencryptionRepository) ReencryptBatch(ctx context.Context, target string, afterID int64, limit int) (*service.SecretReencryptionBatch, error) {
+	keyring := r.keyring
+	if keyring == nil {
+		return &service.SecretReencryptionBatch{Done: true}, nil
+	}
+
+	var (
+		selectQuery string
+		selectArgs  []any
+		updateQuery string
+		rewrite     func(string) (string, bool, error)
+	)
+	switch target {
+	case service.SecretTargetAccountCredentials:
+		selectQuery = "SELECT id, COALESCE(credentials, '{}'::jsonb)::text FROM accounts WHERE id > $1 ORDER BY id LIMIT $2"
+		selectArgs = []any{afterID, limit}
+		updateQuery = "UPDATE accounts SET credentials = $1::jsonb WHERE id = $2 AND COALESCE(credentials, '{}'::jsonb) = $3::jsonb"
+		rewrite = func(value string) (string, bool, error) {
+			return rewriteCredentialsJSON(keyring, value)
+		}
+	case service.SecretTargetProxyPassword:
+		selectQuery = "SELECT id, password FROM proxies WHERE id > $1 AND password IS NOT NULL AND password <> '' ORDER BY id LIMIT $2"
+		selectArgs = []any{afterID, limit}
+		updateQuery = "UPDATE proxies SET password = $1 WHERE id = $2 AND password = $3"
+		rewrite = func(value string) (string, bool, error) {
+			return rewriteSecretString(keyring, value)
+		}
+	case service.SecretTargetSettings:
+		keys := make([]string, 0, len(secretSettingKeys))
+		for key := range secretSettingKeys {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		selectQuery = "SELECT id, value FROM settings WHERE id > $1 AND key = ANY($3) ORDER BY id LIMIT $2"
+		selectArgs = []any{afterID, limit, pq.Array(keys)}
+		updateQuery = "UPDATE settings SET value = $1 WHERE id = $2 AND value = $3"
+		rewrite = func(value string) (string, bool, error) {
+			return rewriteSecretString(keyring, value)
+		}
+	default:
+		return nil, fmt.Errorf("unknown re-encryption target %q", target)
+	}
+
+	rows, err := r.querySecretRows(ctx, selectQuery, selectArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &service.SecretReencryptionBatch{Scanned: len(rows), Done: len(rows) < limit}
+	for _, row := range rows {
+		batch.NextCursor = row.id
+		next, changed, err := rewrite(row.value)
+		if err != nil {
+			log.Printf("[SecretReencrypt] skip %s id=%d: %v", target, row.id, err)
+			batch.Failed++
+			continue
+		}
+		if !changed {
+			continue
+		}
+		result, err := r.sql.ExecContext(ctx, updateQuery, next, row.id, row.value)
+		if err != nil {
+			return nil, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if affected == 0 {
+			// 读取后被业务写入修改：新值已按当前配置写入或将由下次运行处理
+			batch.Failed++
+			continue
+		}
+		batch.Rewritten++
+	}
+	return batch, nil
+}
+
+func (r *secretReencryptionRepository) querySecretRows(ctx context.Context, query string, args ...any) (_ []secretRow, err error) {
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	var out []secretRow
+	for rows.Next() {
+		var row secretRow
+		if err := rows.Scan(&row.id, &row.value); err != nil {
+			return nil, err
+		}
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// rewriteCredentialsJSON 对 JSONB 文本形式的凭证执行 rewriteCredentials。
+func rewriteCredentialsJSON(keyring *secretbox.Keyring, raw string) (string, bool, error) {
+	current := map[string]any{}
+	if err := json.Unmarshal([]byte(raw), &current); err != nil {
+		return "", false, fmt.Errorf("unmarshal credentials: %w", err)
+	}
+	next, changed, err := rewriteCredentials(keyring, current)
+	if err != nil || !changed {
+		return raw, false, err
+	}
+	payload, err := json.Marshal(next)
+	if err != nil {
+		return "", false, err
+	}
+	return string(payload), true, nil
+}
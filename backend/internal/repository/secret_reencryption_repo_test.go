@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/secretbox"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeyring(t *testing.T, activeID string, encrypt bool) *secretbox.Keyring {
+	t.Helper()
+	keys := map[string][]byte{"k1": make([]byte, secretbox.KeySize), "k2": make([]byte, secretbox.KeySize)}
+	keys["k2"][0] = 1
+	keyring, err := secretbox.NewKeyring(keys, activeID, encrypt)
+	require.NoError(t, err)
+	return keyring
+}
+
+func TestSecretCredentialsRoundTrip(t *testing.T) {
+	keyring := newTestKeyring(t, "k1", true)
+
+	sealed, err := encryptCredentials(keyring, map[string]any{"refresh_token": "rt-123", "expires_at": float64(1700000000)})
+	require.NoError(t, err)
+	require.Len(t, sealed, 1)
+	enc, ok := sealedCredentials(sealed)
+	require.True(t, ok)
+	require.Equal(t, "k1", secretbox.KeyIDOf(enc))
+
+	plain, ok := decryptAccountCredentials(keyring, 1, sealed)
+	require.True(t, ok)
+	require.Equal(t, map[string]any{"refresh_token": "rt-123", "expires_at": float64(1700000000)}, plain)
+
+	empty, err := encryptCredentials(keyring, nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{}, empty)
+}
+
+func TestUnreadableCredentialsAreNeverOverwritten(t *testing.T) {
+	keyring := newTestKeyring(t, "k1", true)
+	sealed, err := encryptCredentials(keyring, map[string]any{"api_key": "sk-original"})
+	require.NoError(t, err)
+
+	// 换成 k1 内容不同的密钥环：原密文无法解密
+	wrongKey := make([]byte, secretbox.KeySize)
+	wrongKey[0] = 9
+	wrongRing, err := secretbox.NewKeyring(map[string][]byte{"k1": wrongKey}, "k1", true)
+	require.NoError(t, err)
+
+	account := accountEntityToService(&dbent.Account{ID: 7, Status: service.StatusActive, Schedulable: true, Credentials: sealed}, wrongRing)
+	require.True(t, account.CredentialsUnreadable)
+	require.False(t, account.Schedulable)
+	require.False(t, account.IsSchedulable())
+	require.Equal(t, sealed, account.Credentials, "ciphertext is kept instead of empty credentials")
+
+	db, mock := newSQLMock(t)
+	client := dbent.NewClient(dbent.Driver(entsql.OpenDB(dialect.Postgres, db)))
+	repo := newAccountRepositoryWithSQL(client, db, nil, wrongRing)
+
+	account.Name = "renamed"
+	require.ErrorIs(t, repo.Update(context.Background(), account), service.ErrAccountCredentialsUnreadable)
+
+	// 即使调用方在密文占位上合并了新字段，加密写入也会被拒绝
+	account.Credentials["api_key"] = "sk-new"
+	_, err = encryptCredentials(wrongRing, account.Credentials)
+	require.Error(t, err)
+	require.NoError(t, mock.ExpectationsWereMet(), "no statement may reach the database")
+}
+
+func TestSecretReencryptionAccountCredentialsSkipsCurrentKey(t *testing.T) {
+	keyring := newTestKeyring(t, "k1", true)
+	current, err := encryptCredentials(keyring, map[string]any{"api_key": "sk-b"})
+	require.NoError(t, err)
+	currentJSON := `{"__encrypted":"` + current[encryptedCredentialsField].(string) + `"}`
+
+	db, mock := newSQLMock(t)
+	repo := newSecretReencryptionRepositoryWithSQL(db, keyring)
+
+	mock.ExpectQuery("SELECT id, COALESCE\\(credentials, '\\{\\}'::jsonb\\)::text FROM accounts WHERE id > \\$1").
+		WithArgs(int64(0), 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "credentials"}).
+			AddRow(int64(1), `{"api_key":"sk-a"}`).
+			AddRow(int64(2), currentJSON).
+			AddRow(int64(3), `{}`))
+	mock.ExpectExec("UPDATE accounts SET credentials = \\$1::jsonb WHERE id = \\$2 AND COALESCE\\(credentials, '\\{\\}'::jsonb\\) = \\$3::jsonb").
+		WithArgs(sqlmock.AnyArg(), int64(1), `{"api_key":"sk-a"}`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	batch, err := repo.ReencryptBatch(context.Background(), service.SecretTargetAccountCredentials, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, &service.SecretReencryptionBatch{Scanned: 3, Rewritten: 1, NextCursor: 3, Done: true}, batch)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSecretReencryptionProxyPasswordRotationConflict(t *testing.T) {
+	oldRing, err := secretbox.NewKeyring(map[string][]byte{"k1": make([]byte, secretbox.KeySize)}, "k1", true)
+	require.NoError(t, err)
+	oldValue, err := oldRing.Encrypt("proxy-pass")
+	require.NoError(t, err)
+
+	db, mock := newSQLMock(t)
+	repo := newSecretReencryptionRepositoryWithSQL(db, newTestKeyring(t, "k2", true))
+
+	mock.ExpectQuery("SELECT id, password FROM proxies").
+		WithArgs(int64(5), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).
+			AddRow(int64(6), oldValue).
+			AddRow(int64(9), "plain-pass"))
+	mock.ExpectExec("UPDATE proxies SET password = \\$1 WHERE id = \\$2 AND password = \\$3").
+		WithArgs(sqlmock.AnyArg(), int64(6), oldValue).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE proxies SET password = \\$1 WHERE id = \\$2 AND password = \\$3").
+		WithArgs(sqlmock.AnyArg(), int64(9), "plain-pass").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	batch, err := repo.ReencryptBatch(context.Background(), service.SecretTargetProxyPassword, 5, 2)
+	require.NoError(t, err)
+	require.Equal(t, &service.SecretReencryptionBatch{Scanned: 2, Rewritten: 1, Failed: 1, NextCursor: 9}, batch)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSecretReencryptionDecryptOnlyWritesPlaintext(t *testing.T) {
+	sealed, err := newTestKeyring(t, "k1", true).Encrypt("smtp-pass")
+	require.NoError(t, err)
+	decryptOnly := newTestKeyring(t, "k1", false)
+
+	next, changed, err := rewriteSecretString(decryptOnly, sealed)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, "smtp-pass", next)
+
+	_, changed, err = rewriteSecretString(decryptOnly, "already-plain")
+	require.NoError(t, err)
+	require.False(t, changed)
+}
@@ -2,19 +2,23 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/ent"
 	"github.com/Wei-Shaw/sub2api/ent/setting"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/secretbox"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 )
 
 type settingRepository struct {
-	client *ent.Client
+	client  *ent.Client
+	keyring *secretbox.Keyring
 }
 
-func NewSettingRepository(client *ent.Client) service.SettingRepository {
-	return &settingRepository{client: client}
+func NewSettingRepository(client *ent.Client, keyring *secretbox.Keyring) service.SettingRepository {
+	return &settingRepository{client: client, keyring: keyring}
 }
 
 func (r *settingRepository) Get(ctx context.Context, key string) (*service.Setting, error) {
@@ -25,10 +29,14 @@ func (r *settingRepository) Get(ctx context.Context, key string) (*service.Setti
 		}
 		return nil, err
 	}
+	value, err := decryptSettingValue(r.keyring, m.Key, m.Value)
+	if err != nil {
+		return nil, err
+	}
 	return &service.Setting{
 		ID:        m.ID,
 		Key:       m.Key,
-		Value:     m.Value,
+		Value:     value,
 		UpdatedAt: m.UpdatedAt,
 	}, nil
 }
@@ -42,6 +50,10 @@ func (r *settingRepository) GetValue(ctx context.Context, key string) (string, e
 }
 
 func (r *settingRepository) Set(ctx context.Context, key, value string) error {
+	value, err := encryptSettingValue(r.keyring, key, value)
+	if err != nil {
+		return err
+	}
 	now := time.Now()
 	return r.client.Setting.
 		Create().
@@ -62,11 +74,7 @@ func (r *settingRepository) GetMultiple(ctx context.Context, keys []string) (map
 		return nil, err
 	}
 
-	result := make(map[string]string)
-	for _, s := range settings {
-		result[s.Key] = s.Value
-	}
-	return result, nil
+	return settingsToMap(r.keyring, settings), nil
 }
 
 func (r *settingRepository) SetMultiple(ctx context.Context, settings map[string]string) error {
@@ -77,6 +85,10 @@ func (r *settingRepository) SetMultiple(ctx context.Context, settings map[string
 	now := time.Now()
 	builders := make([]*ent.SettingCreate, 0, len(settings))
 	for key, value := range settings {
+		value, err := encryptSettingValue(r.keyring, key, value)
+		if err != nil {
+			return err
+		}
 		builders = append(builders, r.client.Setting.Create().SetKey(key).SetValue(value).SetUpdatedAt(now))
 	}
 	return r.client.Setting.
@@ -92,14 +104,43 @@ func (r *settingRepository) GetAll(ctx context.Context) (map[string]string, erro
 		return nil, err
 	}
 
-	result := make(map[string]string)
-	for _, s := range settings {
-		result[s.Key] = s.Value
-	}
-	return result, nil
+	return settingsToMap(r.keyring, settings), nil
 }
 
 func (r *settingRepository) Delete(ctx context.Context, key string) error {
 	_, err := r.client.Setting.Delete().Where(setting.KeyEQ(key)).Exec(ctx)
 	return err
 }
+
+// settingsToMap 转换为 key -> value，敏感设置解密失败时记录日志并置空，避免整页设置不可读。
+func settingsToMap(keyring *secretbox.Keyring, settings []*ent.Setting) map[string]string {
+	result := make(map[string]string, len(settings))
+	for _, s := range settings {
+		value, err := decryptSettingValue(keyring, s.Key, s.Value)
+		if err != nil {
+			log.Printf("[SecretBox] decrypt setting failed: key=%s err=%v", s.Key, err)
+		}
+		result[s.Key] = value
+	}
+	return result
+}
+
+// encryptSettingValue 加密敏感设置项，其余设置原样返回。
+func encryptSettingValue(keyring *secretbox.Keyring, key, value string) (string, error) {
+	if !isSecretSettingKey(key) {
+		return value, nil
+	}
+	return encryptSecretString(keyring, value)
+}
+
+// decryptSettingValue 解密设置值；明文原样返回。
+func decryptSettingValue(keyring *secretbox.Keyring, key, value string) (string, error) {
+	if !secretbox.IsEncrypted(value) {
+		return value, nil
+	}
+	plain, err := keyring.Decrypt(value)
+	if err != nil {
+		return "", fmt.Errorf("decrypt setting %s: %w", key, err)
+	}
+	return plain, nil
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const stickySessionFailoverColumns = `
+	f.id, f.group_id, f.session_hash, f.from_account_id, f.to_account_id,
+	COALESCE(fa.name, ''), COALESCE(ta.name, ''), f.standby, f.warm, f.model,
+	f.cache_creation_tokens, f.cache_read_tokens, f.estimated_cost, f.created_at
+`
+
+type stickySessionRepository struct {
+	sql sqlExecutor
+}
+
+// NewStickySessionRepository 创建粘性会话缓存统计与切换记录仓储
+func NewStickySessionRepository(sqlDB *sql.DB) service.StickySessionRepository {
+	return newStickySessionRepositoryWithSQL(sqlDB)
+}
+
+func newStickySessionRepositoryWithSQL(sqlq sqlExecutor) *stickySessionRepository {
+	return &stickySessionRepository{sql: sqlq}
+}
+
+func (r *stickySessionRepository) UpsertStat(ctx context.Context, delta *service.StickyUsageDelta) error {
+	var lastCacheReadAt any
+	if delta.CacheReadTokens > 0 {
+		lastCacheReadAt = delta.At.UTC()
+	}
+	_, err := r.sql.ExecContext(ctx, `
+		INSERT INTO sticky_session_stats (
+			group_id, session_hash, account_id, request_count, input_tokens,
+			cache_creation_tokens, cache_read_tokens, last_cache_read_at, created_at, updated_at
+		) VALUES ($1, $2, $3, 1, $4, $5, $6, $7, $8, $8)
+		ON CONFLICT (group_id, session_hash, account_id) DO UPDATE SET
+			request_count = sticky_session_stats.request_count + 1,
+			input_tokens = sticky_session_stats.input_tokens + EXCLUDED.input_tokens,
+			cache_creation_tokens = sticky_session_stats.cache_creation_tokens + EXCLUDED.cache_creation_tokens,
+			cache_read_tokens = sticky_session_stats.cache_read_tokens + EXCLUDED.cache_read_tokens,
+			last_cache_read_at = COALESCE(EXCLUDED.last_cache_read_at, sticky_session_stats.last_cache_read_at),
+			updated_at = EXCLUDED.updated_at
+	`, delta.GroupID, delta.SessionHash, delta.AccountID, delta.InputTokens,
+		delta.CacheCreationTokens, delta.CacheReadTokens, lastCacheReadAt, delta.At.UTC())
+	return err
+}
+
+func (r *stickySessionRepository) CreateFailover(ctx context.Context, failover *service.StickySessionFailover) error {
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO sticky_session_failovers (
+			group_id, session_hash, from_account_id, to_account_id, standby, warm, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		RETURNING id, created_at
+	`, []any{
+		failover.GroupID, failover.SessionHash, failover.FromAccountID, failover.ToAccountID,
+		failover.Standby, failover.Warm,
+	}, &failover.ID, &failover.CreatedAt)
+}
+
+func (r *stickySessionRepository) UpdateFailoverCost(ctx context.Context, id int64, model string, cacheCreationTokens, cacheReadTokens int64, estimatedCost float64) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE sticky_session_failovers
+		SET model = $2, cache_creation_tokens = $3, cache_read_tokens = $4, estimated_cost = $5
+		WHERE id = $1
+	`, id, model, cacheCreationTokens, cacheReadTokens, estimatedCost)
+	return err
+}
+
+func (r *stickySessionRepository) ListSessions(ctx context.Context, params pagination.PaginationParams, filters service.StickySessionFilters) ([]service.StickySessionSummary, *pagination.PaginationResult, error) {
+	conditions := []string{"1=1"}
+	having := []string{"1=1"}
+	args := []any{}
+	add := func(target *[]string, cond string, v any) {
+		args = append(args, v)
+		*target = append(*target, fmt.Sprintf(cond, len(args)))
+	}
+	if filters.GroupID != nil {
+		add(&conditions, "s.group_id = $%d", *filters.GroupID)
+	}
+	if filters.SessionHash != "" {
+		add(&conditions, "s.session_hash = $%d", filters.SessionHash)
+	}
+	if filters.AccountID > 0 {
+		add(&having, "BOOL_OR(s.account_id = $%d)", filters.AccountID)
+	}
+	if filters.StartTime != nil {
+		add(&having, "MAX(s.updated_at) >= $%d", filters.StartTime.UTC())
+	}
+	if filters.EndTime != nil {
+		add(&having, "MAX(s.updated_at) < $%d", filters.EndTime.UTC())
+	}
+	grouped := " FROM sticky_session_stats s WHERE " + strings.Join(conditions, " AND ") +
+		" GROUP BY s.group_id, s.session_hash HAVING " + strings.Join(having, " AND ")
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM (SELECT 1"+grouped+") t", args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.StickySessionSummary{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := `
+		SELECT page.group_id, page.session_hash, page.account_count, page.request_count, page.input_tokens,
+			page.cache_creation_tokens, page.cache_read_tokens, page.last_used_at,
+			COALESCE(f.failover_count, 0), COALESCE(f.estimated_cost, 0)
+		FROM (
+			SELECT s.group_id, s.session_hash, COUNT(*) AS account_count,
+				SUM(s.request_count) AS request_count, SUM(s.input_tokens) AS input_tokens,
+				SUM(s.cache_creation_tokens) AS cache_creation_tokens, SUM(s.cache_read_tokens) AS cache_read_tokens,
+				MAX(s.updated_at) AS last_used_at` + grouped +
+		fmt.Sprintf(" ORDER BY MAX(s.updated_at) DESC, s.session_hash LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2) + `
+		) page
+		LEFT JOIN (
+			SELECT group_id, session_hash, COUNT(*) AS failover_count, SUM(estimated_cost) AS estimated_cost
+			FROM sticky_session_failovers
+			GROUP BY group_id, session_hash
+		) f ON f.group_id = page.group_id AND f.session_hash = page.session_hash
+		ORDER BY page.last_used_at DESC, page.session_hash
+	`
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.StickySessionSummary, 0)
+	for rows.Next() {
+		var item service.StickySessionSummary
+		if err := rows.Scan(
+			&item.GroupID, &item.SessionHash, &item.AccountCount, &item.RequestCount, &item.InputTokens,
+			&item.CacheCreationTokens, &item.CacheReadTokens, &item.LastUsedAt,
+			&item.FailoverCount, &item.EstimatedFailoverCost,
+		); err != nil {
+			return nil, nil, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
+
+func (r *stickySessionRepository) ListSessionAccounts(ctx context.Context, groupID int64, sessionHash string) ([]service.StickySessionStat, error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT s.group_id, s.session_hash, s.account_id, COALESCE(a.name, ''), s.request_count, s.input_tokens,
+			s.cache_creation_tokens, s.cache_read_tokens, s.last_cache_read_at, s.created_at, s.updated_at
+		FROM sticky_session_stats s
+		LEFT JOIN accounts a ON a.id = s.account_id
+		WHERE s.group_id = $1 AND s.session_hash = $2
+		ORDER BY s.updated_at DESC
+	`, groupID, sessionHash)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.StickySessionStat, 0)
+	for rows.Next() {
+		var (
+			stat            service.StickySessionStat
+			lastCacheReadAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&stat.GroupID, &stat.SessionHash, &stat.AccountID, &stat.AccountName, &stat.RequestCount, &stat.InputTokens,
+			&stat.CacheCreationTokens, &stat.CacheReadTokens, &lastCacheReadAt, &stat.CreatedAt, &stat.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if lastCacheReadAt.Valid {
+			t := lastCacheReadAt.Time
+			stat.LastCacheReadAt = &t
+		}
+		out = append(out, stat)
+	}
+	return out, rows.Err()
+}
+
+func (r *stickySessionRepository) ListFailovers(ctx context.Context, params pagination.PaginationParams, filters service.StickySessionFilters) ([]service.StickySessionFailover, *pagination.PaginationResult, error) {
+	conditions := []string{"1=1"}
+	args := []any{}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+	if filters.GroupID != nil {
+		add("f.group_id = $%d", *filters.GroupID)
+	}
+	if filters.SessionHash != "" {
+		add("f.session_hash = $%d", filters.SessionHash)
+	}
+	if filters.AccountID > 0 {
+		add("(f.from_account_id = $%[1]d OR f.to_account_id = $%[1]d)", filters.AccountID)
+	}
+	if filters.StartTime != nil {
+		add("f.created_at >= $%d", filters.StartTime.UTC())
+	}
+	if filters.EndTime != nil {
+		add("f.created_at < $%d", filters.EndTime.UTC())
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM sticky_session_failovers f"+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.StickySessionFailover{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := "SELECT " + stickySessionFailoverColumns + `
+		FROM sticky_session_failovers f
+		LEFT JOIN accounts fa ON fa.id = f.from_account_id
+		LEFT JOIN accounts ta ON ta.id = f.to_account_id` + where +
+		fmt.Sprintf(" ORDER BY f.id DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.StickySessionFailover, 0)
+	for rows.Next() {
+		var item service.StickySessionFailover
+		if err := rows.Scan(
+			&item.ID, &item.GroupID, &item.SessionHash, &item.FromAccountID, &item.ToAccountID,
+			&item.FromAccountName, &item.ToAccountName, &item.Standby, &item.Warm, &item.Model,
+			&item.CacheCreationTokens, &item.CacheReadTokens, &item.EstimatedCost, &item.CreatedAt,
+		); err != nil {
+			return nil, nil, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
+
+func (r *stickySessionRepository) DeleteBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	var total int64
+	for _, stmt := range []string{
+		`DELETE FROM sticky_session_stats WHERE id IN (
+			SELECT id FROM sticky_session_stats WHERE updated_at < $1 ORDER BY updated_at LIMIT $2
+		)`,
+		`DELETE FROM sticky_session_failovers WHERE id IN (
+			SELECT id FROM sticky_session_failovers WHERE created_at < $1 ORDER BY created_at LIMIT $2
+		)`,
+	} {
+		result, err := r.sql.ExecContext(ctx, stmt, before.UTC(), limit)
+		if err != nil {
+			return total, err
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+	}
+	return total, nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStickySessionRepositoryUpsertStat(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newStickySessionRepositoryWithSQL(db)
+
+	now := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	mock.ExpectExec("INSERT INTO sticky_session_stats(.|\n)*ON CONFLICT \\(group_id, session_hash, account_id\\) DO UPDATE").
+		WithArgs(int64(2), "abc", int64(9), int64(100), int64(0), int64(5000), now, now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	// 未命中缓存时不更新 last_cache_read_at
+	mock.ExpectExec("INSERT INTO sticky_session_stats").
+		WithArgs(int64(2), "abc", int64(9), int64(100), int64(5000), int64(0), nil, now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	require.NoError(t, repo.UpsertStat(context.Background(), &service.StickyUsageDelta{
+		GroupID: 2, SessionHash: "abc", AccountID: 9, InputTokens: 100, CacheReadTokens: 5000, At: now,
+	}))
+	require.NoError(t, repo.UpsertStat(context.Background(), &service.StickyUsageDelta{
+		GroupID: 2, SessionHash: "abc", AccountID: 9, InputTokens: 100, CacheCreationTokens: 5000, At: now,
+	}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStickySessionRepositoryListSessions(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newStickySessionRepositoryWithSQL(db)
+
+	now := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	groupID := int64(2)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM \\(SELECT 1 FROM sticky_session_stats s WHERE 1=1 AND s.group_id = \\$1 GROUP BY s.group_id, s.session_hash HAVING 1=1 AND BOOL_OR\\(s.account_id = \\$2\\)\\) t").
+		WithArgs(groupID, int64(9)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(1)))
+	mock.ExpectQuery("LIMIT \\$3 OFFSET \\$4(.|\n)*LEFT JOIN \\(\\s*SELECT group_id, session_hash, COUNT\\(\\*\\) AS failover_count").
+		WithArgs(groupID, int64(9), 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"group_id", "session_hash", "account_count", "request_count", "input_tokens",
+			"cache_creation_tokens", "cache_read_tokens", "last_used_at", "failover_count", "estimated_cost",
+		}).AddRow(groupID, "abc", int64(2), int64(10), int64(1000), int64(2000), int64(7000), now, int64(1), 0.75))
+
+	items, result, err := repo.ListSessions(context.Background(), pagination.PaginationParams{Page: 1, PageSize: 20}, service.StickySessionFilters{
+		GroupID:   &groupID,
+		AccountID: 9,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.Total)
+	require.Len(t, items, 1)
+	require.Equal(t, int64(1), items[0].FailoverCount)
+	require.InDelta(t, 0.7, items[0].CacheHitRatio(), 1e-9)
+	require.InDelta(t, 0.75, items[0].EstimatedFailoverCost, 1e-9)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStickySessionRepositoryListFailoversAccountFilter(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newStickySessionRepositoryWithSQL(db)
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM sticky_session_failovers f WHERE 1=1 AND \\(f.from_account_id = \\$1 OR f.to_account_id = \\$1\\)").
+		WithArgs(int64(9)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(0)))
+
+	items, result, err := repo.ListFailovers(context.Background(), pagination.PaginationParams{Page: 1, PageSize: 20}, service.StickySessionFilters{AccountID: 9})
+	require.NoError(t, err)
+	require.Empty(t, items)
+	require.Equal(t, int64(0), result.Total)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStickySessionRepositoryDeleteBefore(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newStickySessionRepositoryWithSQL(db)
+
+	cutoff := time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec("DELETE FROM sticky_session_stats WHERE id IN").
+		WithArgs(cutoff, 100).
+		WillReturnResult(sqlmock.NewResult(0, 30))
+	mock.ExpectExec("DELETE FROM sticky_session_failovers WHERE id IN").
+		WithArgs(cutoff, 100).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	deleted, err := repo.DeleteBefore(context.Background(), cutoff, 100)
+	require.NoError(t, err)
+	require.Equal(t, int64(34), deleted)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	stickyStandbyPrefix  = "sticky_standby:"
+	stickyWarmthPrefix   = "sticky_warmth:"
+	stickyFailoverPrefix = "sticky_failover:"
+)
+
+type stickyStandbyCache struct {
+	rdb *redis.Client
+}
+
+func NewStickyStandbyCache(rdb *redis.Client) service.StickyStandbyCache {
+	return &stickyStandbyCache{rdb: rdb}
+}
+
+// buildStickyKey 与粘性会话 key 相同按分组隔离
+// 格式: {prefix}{groupID}:{sessionHash}
+func buildStickyKey(prefix string, groupID int64, sessionHash string) string {
+	return fmt.Sprintf("%s%d:%s", prefix, groupID, sessionHash)
+}
+
+func (c *stickyStandbyCache) GetStandbyAccountID(ctx context.Context, groupID int64, sessionHash string) (int64, error) {
+	return c.rdb.Get(ctx, buildStickyKey(stickyStandbyPrefix, groupID, sessionHash)).Int64()
+}
+
+func (c *stickyStandbyCache) SetStandbyAccountID(ctx context.Context, groupID int64, sessionHash string, accountID int64, ttl time.Duration) error {
+	return c.rdb.Set(ctx, buildStickyKey(stickyStandbyPrefix, groupID, sessionHash), accountID, ttl).Err()
+}
+
+func (c *stickyStandbyCache) RefreshStandbyTTL(ctx context.Context, groupID int64, sessionHash string, ttl time.Duration) (bool, error) {
+	return c.rdb.Expire(ctx, buildStickyKey(stickyStandbyPrefix, groupID, sessionHash), ttl).Result()
+}
+
+func (c *stickyStandbyCache) DeleteStandbyAccountID(ctx context.Context, groupID int64, sessionHash string) error {
+	return c.rdb.Del(ctx, buildStickyKey(stickyStandbyPrefix, groupID, sessionHash)).Err()
+}
+
+// TouchWarmth 热度以 账号 → 毫秒时间戳 存于 hash，整体随最近一次写入续期；过期字段在读取时按时间过滤
+func (c *stickyStandbyCache) TouchWarmth(ctx context.Context, groupID int64, sessionHash string, accountID int64, at time.Time, ttl time.Duration) error {
+	key := buildStickyKey(stickyWarmthPrefix, groupID, sessionHash)
+	pipe := c.rdb.TxPipeline()
+	pipe.HSet(ctx, key, strconv.FormatInt(accountID, 10), at.UnixMilli())
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *stickyStandbyCache) GetWarmth(ctx context.Context, groupID int64, sessionHash string) (map[int64]time.Time, error) {
+	values, err := c.rdb.HGetAll(ctx, buildStickyKey(stickyWarmthPrefix, groupID, sessionHash)).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int64]time.Time, len(values))
+	for field, value := range values {
+		accountID, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			continue
+		}
+		ms, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		out[accountID] = time.UnixMilli(ms)
+	}
+	return out, nil
+}
+
+func (c *stickyStandbyCache) SetFailoverHint(ctx context.Context, groupID int64, sessionHash string, hint *service.StickyFailoverHint, ttl time.Duration) error {
+	raw, err := json.Marshal(hint)
+	if err != nil {
+		return fmt.Errorf("marshal failover hint: %w", err)
+	}
+	return c.rdb.Set(ctx, buildStickyKey(stickyFailoverPrefix, groupID, sessionHash), raw, ttl).Err()
+}
+
+func (c *stickyStandbyCache) TakeFailoverHint(ctx context.Context, groupID int64, sessionHash string) (*service.StickyFailoverHint, error) {
+	raw, err := c.rdb.GetDel(ctx, buildStickyKey(stickyFailoverPrefix, groupID, sessionHash)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var hint service.StickyFailoverHint
+	if err := json.Unmarshal(raw, &hint); err != nil {
+		return nil, fmt.Errorf("unmarshal failover hint: %w", err)
+	}
+	return &hint, nil
+}
@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const subscriptionPlanColumns = `
+	id, name, description, group_id, price, validity_days,
+	daily_limit_usd, weekly_limit_usd, monthly_limit_usd,
+	tier, allow_upgrade, allow_downgrade, allow_auto_renew, sort_order, status,
+	created_at, updated_at
+`
+
+const subscriptionPlanOrderColumns = `
+	id, user_id, plan_id, subscription_id, action, price, credit, amount, expires_at, created_at
+`
+
+type subscriptionPlanRepository struct {
+	sql sqlExecutor
+}
+
+func NewSubscriptionPlanRepository(sqlDB *sql.DB) service.SubscriptionPlanRepository {
+	return newSubscriptionPlanRepositoryWithSQL(sqlDB)
+}
+
+func newSubscriptionPlanRepositoryWithSQL(sqlq sqlExecutor) *subscriptionPlanRepository {
+	return &subscriptionPlanRepository{sql: sqlq}
+}
+
+// exec 在事务上下文中使用 tx 绑定的执行器，保证与余额/订阅更新同事务
+func (r *subscriptionPlanRepository) exec(ctx context.Context) sqlExecutor {
+	if tx := dbent.TxFromContext(ctx); tx != nil {
+		return tx.Client()
+	}
+	return r.sql
+}
+
+func (r *subscriptionPlanRepository) Create(ctx context.Context, plan *service.SubscriptionPlan) error {
+	if plan == nil {
+		return nil
+	}
+	query := `
+		INSERT INTO subscription_plans (
+			name, description, group_id, price, validity_days,
+			daily_limit_usd, weekly_limit_usd, monthly_limit_usd,
+			tier, allow_upgrade, allow_downgrade, allow_auto_renew, sort_order, status,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`
+	args := []any{
+		plan.Name,
+		plan.Description,
+		plan.GroupID,
+		plan.Price,
+		plan.ValidityDays,
+		plan.DailyLimitUSD,
+		plan.WeeklyLimitUSD,
+		plan.MonthlyLimitUSD,
+		plan.Tier,
+		plan.AllowUpgrade,
+		plan.AllowDowngrade,
+		plan.AllowAutoRenew,
+		plan.SortOrder,
+		plan.Status,
+	}
+	return scanSingleRow(ctx, r.exec(ctx), query, args, &plan.ID, &plan.CreatedAt, &plan.UpdatedAt)
+}
+
+func (r *subscriptionPlanRepository) GetByID(ctx context.Context, id int64) (*service.SubscriptionPlan, error) {
+	rows, err := r.exec(ctx).QueryContext(ctx, "SELECT "+subscriptionPlanColumns+" FROM subscription_plans WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrSubscriptionPlanNotFound
+	}
+	plan, err := scanSubscriptionPlan(rows)
+	if err != nil {
+		return nil, err
+	}
+	return plan, rows.Err()
+}
+
+func (r *subscriptionPlanRepository) Update(ctx context.Context, plan *service.SubscriptionPlan) error {
+	if plan == nil {
+		return nil
+	}
+	query := `
+		UPDATE subscription_plans SET
+			name = $2, description = $3, group_id = $4, price = $5, validity_days = $6,
+			daily_limit_usd = $7, weekly_limit_usd = $8, monthly_limit_usd = $9,
+			tier = $10, allow_upgrade = $11, allow_downgrade = $12, allow_auto_renew = $13,
+			sort_order = $14, status = $15, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING updated_at
+	`
+	args := []any{
+		plan.ID,
+		plan.Name,
+		plan.Description,
+		plan.GroupID,
+		plan.Price,
+		plan.ValidityDays,
+		plan.DailyLimitUSD,
+		plan.WeeklyLimitUSD,
+		plan.MonthlyLimitUSD,
+		plan.Tier,
+		plan.AllowUpgrade,
+		plan.AllowDowngrade,
+		plan.AllowAutoRenew,
+		plan.SortOrder,
+		plan.Status,
+	}
+	err := scanSingleRow(ctx, r.exec(ctx), query, args, &plan.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return service.ErrSubscriptionPlanNotFound
+	}
+	return err
+}
+
+func (r *subscriptionPlanRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.exec(ctx).ExecContext(ctx, "UPDATE subscription_plans SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrSubscriptionPlanNotFound
+	}
+	return nil
+}
+
+func (r *subscriptionPlanRepository) List(ctx context.Context, filters service.SubscriptionPlanFilters) ([]service.SubscriptionPlan, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	args := make([]any, 0, 2)
+	if filters.GroupID != nil {
+		args = append(args, *filters.GroupID)
+		conditions = append(conditions, fmt.Sprintf("group_id = $%d", len(args)))
+	}
+	if v := strings.TrimSpace(filters.Status); v != "" {
+		args = append(args, v)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	query := "SELECT " + subscriptionPlanColumns + " FROM subscription_plans WHERE " +
+		strings.Join(conditions, " AND ") + " ORDER BY sort_order ASC, tier ASC, id ASC"
+	rows, err := r.exec(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	plans := make([]service.SubscriptionPlan, 0)
+	for rows.Next() {
+		plan, err := scanSubscriptionPlan(rows)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, *plan)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+func (r *subscriptionPlanRepository) CreateOrder(ctx context.Context, order *service.SubscriptionPlanOrder, renewalKey string) error {
+	if order == nil {
+		return nil
+	}
+	query := `
+		INSERT INTO subscription_plan_orders (
+			user_id, plan_id, subscription_id, action, price, credit, amount, expires_at, renewal_key, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+		ON CONFLICT (renewal_key) WHERE renewal_key IS NOT NULL DO NOTHING
+		RETURNING id, created_at
+	`
+	args := []any{
+		order.UserID,
+		order.PlanID,
+		nullInt64(order.SubscriptionID),
+		order.Action,
+		order.Price,
+		order.Credit,
+		order.Amount,
+		order.ExpiresAt,
+		nullString(&renewalKey),
+	}
+	err := scanSingleRow(ctx, r.exec(ctx), query, args, &order.ID, &order.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return service.ErrSubscriptionPlanRenewalDuplicate
+	}
+	return err
+}
+
+func (r *subscriptionPlanRepository) ListOrdersByUser(ctx context.Context, userID int64, params pagination.PaginationParams) ([]service.SubscriptionPlanOrder, *pagination.PaginationResult, error) {
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM subscription_plan_orders WHERE user_id = $1", []any{userID}, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.SubscriptionPlanOrder{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := "SELECT " + subscriptionPlanOrderColumns + " FROM subscription_plan_orders WHERE user_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3"
+	rows, err := r.sql.QueryContext(ctx, query, userID, params.Limit(), params.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	orders := make([]service.SubscriptionPlanOrder, 0)
+	for rows.Next() {
+		var (
+			order          service.SubscriptionPlanOrder
+			subscriptionID sql.NullInt64
+			expiresAt      sql.NullTime
+		)
+		if err := rows.Scan(
+			&order.ID,
+			&order.UserID,
+			&order.PlanID,
+			&subscriptionID,
+			&order.Action,
+			&order.Price,
+			&order.Credit,
+			&order.Amount,
+			&expiresAt,
+			&order.CreatedAt,
+		); err != nil {
+			return nil, nil, err
+		}
+		if subscriptionID.Valid {
+			v := subscriptionID.Int64
+			order.SubscriptionID = &v
+		}
+		if expiresAt.Valid {
+			order.ExpiresAt = &expiresAt.Time
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return orders, paginationResultFromTotal(total, params), nil
+}
+
+func (r *subscriptionPlanRepository) DeductBalanceIfSufficient(ctx context.Context, userID int64, amount float64) (bool, error) {
+	res, err := r.exec(ctx).ExecContext(ctx, `
+		UPDATE users SET balance = balance - $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL AND balance >= $2
+	`, userID, amount)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func scanSubscriptionPlan(rows *sql.Rows) (*service.SubscriptionPlan, error) {
+	var (
+		plan         service.SubscriptionPlan
+		dailyLimit   sql.NullFloat64
+		weeklyLimit  sql.NullFloat64
+		monthlyLimit sql.NullFloat64
+	)
+	if err := rows.Scan(
+		&plan.ID,
+		&plan.Name,
+		&plan.Description,
+		&plan.GroupID,
+		&plan.Price,
+		&plan.ValidityDays,
+		&dailyLimit,
+		&weeklyLimit,
+		&monthlyLimit,
+		&plan.Tier,
+		&plan.AllowUpgrade,
+		&plan.AllowDowngrade,
+		&plan.AllowAutoRenew,
+		&plan.SortOrder,
+		&plan.Status,
+		&plan.CreatedAt,
+		&plan.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	plan.DailyLimitUSD = nullFloat64Ptr(dailyLimit)
+	plan.WeeklyLimitUSD = nullFloat64Ptr(weeklyLimit)
+	plan.MonthlyLimitUSD = nullFloat64Ptr(monthlyLimit)
+	return &plan, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionPlanRepositoryGetByID(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newSubscriptionPlanRepositoryWithSQL(db)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM subscription_plans WHERE id = \\$1 AND deleted_at IS NULL").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "name", "description", "group_id", "price", "validity_days",
+			"daily_limit_usd", "weekly_limit_usd", "monthly_limit_usd",
+			"tier", "allow_upgrade", "allow_downgrade", "allow_auto_renew", "sort_order", "status",
+			"created_at", "updated_at",
+		}).AddRow(
+			int64(1), "Pro", "", int64(3), 20.0, 30,
+			50.0, nil, nil,
+			2, true, true, false, 0, "active",
+			now, now,
+		))
+
+	plan, err := repo.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, "Pro", plan.Name)
+	require.NotNil(t, plan.DailyLimitUSD)
+	require.InDelta(t, 50.0, *plan.DailyLimitUSD, 1e-9)
+	require.Nil(t, plan.WeeklyLimitUSD)
+	require.False(t, plan.AllowAutoRenew)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionPlanRepositoryGetByIDNotFound(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newSubscriptionPlanRepositoryWithSQL(db)
+
+	mock.ExpectQuery("FROM subscription_plans WHERE id = \\$1").
+		WithArgs(int64(9)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := repo.GetByID(context.Background(), 9)
+	require.ErrorIs(t, err, service.ErrSubscriptionPlanNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionPlanRepositoryCreateOrderDuplicateRenewal(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newSubscriptionPlanRepositoryWithSQL(db)
+	subID := int64(5)
+
+	mock.ExpectQuery("INSERT INTO subscription_plan_orders .* ON CONFLICT \\(renewal_key\\)").
+		WithArgs(int64(7), int64(1), subID, service.SubscriptionPlanActionAutoRenew, 10.0, 0.0, 10.0, nil, "5:100").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+
+	err := repo.CreateOrder(context.Background(), &service.SubscriptionPlanOrder{
+		UserID:         7,
+		PlanID:         1,
+		SubscriptionID: &subID,
+		Action:         service.SubscriptionPlanActionAutoRenew,
+		Price:          10,
+		Amount:         10,
+	}, "5:100")
+	require.ErrorIs(t, err, service.ErrSubscriptionPlanRenewalDuplicate)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionPlanRepositoryDeductBalanceIfSufficient(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newSubscriptionPlanRepositoryWithSQL(db)
+
+	mock.ExpectExec("UPDATE users SET balance = balance - \\$2").
+		WithArgs(int64(7), 10.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET balance = balance - \\$2").
+		WithArgs(int64(7), 10.0).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.DeductBalanceIfSufficient(context.Background(), 7, 10)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = repo.DeductBalanceIfSufficient(context.Background(), 7, 10)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
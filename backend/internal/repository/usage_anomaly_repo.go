@@ -0,0 +1,366 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/lib/pq"
+)
+
+const usageAnomalyEventColumns = `
+	e.id, e.user_id, e.api_key_id, e.bucket_start, e.kind, e.severity,
+	e.observed, e.baseline_mean, e.baseline_stddev, e.score, e.details, e.key_suspended,
+	e.status, e.acknowledged_by, e.acknowledged_at, e.created_at,
+	COALESCE(u.email, ''), COALESCE(k.name, '')
+`
+
+// usageAnomalyMaxNewSources 单个小时桶返回的新来源数量上限
+const usageAnomalyMaxNewSources = 1000
+
+type usageAnomalyRepository struct {
+	sql sqlExecutor
+}
+
+func NewUsageAnomalyRepository(sqlDB *sql.DB) service.UsageAnomalyRepository {
+	return newUsageAnomalyRepositoryWithSQL(sqlDB)
+}
+
+func newUsageAnomalyRepositoryWithSQL(sqlq sqlExecutor) *usageAnomalyRepository {
+	return &usageAnomalyRepository{sql: sqlq}
+}
+
+func (r *usageAnomalyRepository) GetWatermark(ctx context.Context) (time.Time, error) {
+	var ts time.Time
+	err := scanSingleRow(ctx, r.sql, "SELECT last_bucket_end FROM usage_anomaly_watermark WHERE id = 1", nil, &ts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Unix(0, 0).UTC(), nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ts.UTC(), nil
+}
+
+func (r *usageAnomalyRepository) UpdateWatermark(ctx context.Context, bucketEnd time.Time) error {
+	_, err := r.sql.ExecContext(ctx, `
+		INSERT INTO usage_anomaly_watermark (id, last_bucket_end, updated_at)
+		VALUES (1, $1, NOW())
+		ON CONFLICT (id) DO UPDATE SET
+			last_bucket_end = GREATEST(usage_anomaly_watermark.last_bucket_end, EXCLUDED.last_bucket_end),
+			updated_at = NOW()
+	`, bucketEnd.UTC())
+	return err
+}
+
+func (r *usageAnomalyRepository) ListHourUsage(ctx context.Context, bucketStart time.Time) ([]service.UsageAnomalyHourUsage, error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT b.api_key_id, COALESCE(k.name, ''), b.user_id, b.model,
+			SUM(b.total_requests), SUM(b.actual_cost)
+		FROM usage_dashboard_hourly_user_breakdown b
+		LEFT JOIN api_keys k ON k.id = b.api_key_id
+		WHERE b.bucket_start = $1 AND b.api_key_id > 0
+		GROUP BY b.api_key_id, k.name, b.user_id, b.model
+		ORDER BY b.api_key_id, b.model
+	`, bucketStart.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.UsageAnomalyHourUsage, 0)
+	for rows.Next() {
+		var u service.UsageAnomalyHourUsage
+		if err := rows.Scan(&u.APIKeyID, &u.APIKeyName, &u.UserID, &u.Model, &u.Requests, &u.ActualCost); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+func (r *usageAnomalyRepository) ListKeyBaselines(ctx context.Context, apiKeyIDs []int64, from, to time.Time) (map[int64]*service.UsageAnomalyBaseline, error) {
+	out, err := r.listBaselines(ctx, "api_key_id", apiKeyIDs, from, to)
+	if err != nil || len(out) == 0 {
+		return out, err
+	}
+
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT DISTINCT api_key_id, model
+		FROM usage_dashboard_hourly_user_breakdown
+		WHERE api_key_id = ANY($1) AND bucket_start >= $2 AND bucket_start < $3
+	`, pq.Array(apiKeyIDs), from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var (
+			id    int64
+			model string
+		)
+		if err := rows.Scan(&id, &model); err != nil {
+			return nil, err
+		}
+		if base := out[id]; base != nil {
+			base.Models = append(base.Models, model)
+		}
+	}
+	return out, rows.Err()
+}
+
+func (r *usageAnomalyRepository) ListUserBaselines(ctx context.Context, userIDs []int64, from, to time.Time) (map[int64]*service.UsageAnomalyBaseline, error) {
+	return r.listBaselines(ctx, "user_id", userIDs, from, to)
+}
+
+// listBaselines 先按 (维度, 小时) 汇总，再计算小时序列的和与平方和；零值小时由服务层按首个小时桶补齐
+func (r *usageAnomalyRepository) listBaselines(ctx context.Context, column string, ids []int64, from, to time.Time) (map[int64]*service.UsageAnomalyBaseline, error) {
+	out := make(map[int64]*service.UsageAnomalyBaseline, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT id, MIN(bucket_start), SUM(cost), SUM(cost * cost), SUM(requests), SUM(requests * requests)
+		FROM (
+			SELECT `+column+` AS id, bucket_start,
+				SUM(actual_cost)::double precision AS cost,
+				SUM(total_requests)::double precision AS requests
+			FROM usage_dashboard_hourly_user_breakdown
+			WHERE `+column+` = ANY($1) AND bucket_start >= $2 AND bucket_start < $3
+			GROUP BY 1, 2
+		) h
+		GROUP BY id
+	`, pq.Array(ids), from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var b service.UsageAnomalyBaseline
+		if err := rows.Scan(&b.ID, &b.FirstBucket, &b.SumCost, &b.SumCostSq, &b.SumRequests, &b.SumRequestsSq); err != nil {
+			return nil, err
+		}
+		b.FirstBucket = b.FirstBucket.UTC()
+		out[b.ID] = &b
+	}
+	return out, rows.Err()
+}
+
+// usageAnomalyIPRangeExpr 将 IP 归并为网段（IPv4 /24，IPv6 取前三组近似 /48），避免同一网段内的地址变化误报
+func usageAnomalyIPRangeExpr(col string) string {
+	return `CASE WHEN position(':' in ` + col + `) > 0
+		THEN array_to_string((string_to_array(` + col + `, ':'))[1:3], ':') || '::/48'
+		ELSE regexp_replace(` + col + `, '\.[0-9]+$', '.0/24') END`
+}
+
+func (r *usageAnomalyRepository) ListNewSources(ctx context.Context, apiKeyIDs []int64, hourStart, hourEnd, baselineFrom time.Time) ([]service.UsageAnomalySource, error) {
+	if len(apiKeyIDs) == 0 {
+		return nil, nil
+	}
+	query := `
+		WITH cur AS (
+			SELECT DISTINCT api_key_id, 'ip' AS kind, ` + usageAnomalyIPRangeExpr("ip_address") + ` AS value
+			FROM usage_logs
+			WHERE api_key_id = ANY($1) AND created_at >= $2 AND created_at < $3 AND COALESCE(ip_address, '') <> ''
+			UNION
+			SELECT DISTINCT api_key_id, 'user_agent' AS kind, user_agent AS value
+			FROM usage_logs
+			WHERE api_key_id = ANY($1) AND created_at >= $2 AND created_at < $3 AND COALESCE(user_agent, '') <> ''
+		)
+		SELECT c.api_key_id, c.kind, c.value
+		FROM cur c
+		WHERE NOT EXISTS (
+			SELECT 1 FROM usage_logs l
+			WHERE l.api_key_id = c.api_key_id
+				AND l.created_at >= $4 AND l.created_at < $2
+				AND (
+					(c.kind = 'ip' AND COALESCE(l.ip_address, '') <> '' AND ` + usageAnomalyIPRangeExpr("l.ip_address") + ` = c.value)
+					OR (c.kind = 'user_agent' AND l.user_agent = c.value)
+				)
+		)
+		ORDER BY c.api_key_id, c.kind, c.value
+		LIMIT $5
+	`
+	rows, err := r.sql.QueryContext(ctx, query, pq.Array(apiKeyIDs), hourStart.UTC(), hourEnd.UTC(), baselineFrom.UTC(), usageAnomalyMaxNewSources)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.UsageAnomalySource, 0)
+	for rows.Next() {
+		var src service.UsageAnomalySource
+		if err := rows.Scan(&src.APIKeyID, &src.Kind, &src.Value); err != nil {
+			return nil, err
+		}
+		out = append(out, src)
+	}
+	return out, rows.Err()
+}
+
+func (r *usageAnomalyRepository) CreateEvent(ctx context.Context, event *service.UsageAnomalyEvent) (bool, error) {
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return false, err
+	}
+	if event.Status == "" {
+		event.Status = service.UsageAnomalyStatusOpen
+	}
+	err = scanSingleRow(ctx, r.sql, `
+		INSERT INTO usage_anomaly_events (
+			user_id, api_key_id, bucket_start, kind, severity,
+			observed, baseline_mean, baseline_stddev, score, details, status, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+		ON CONFLICT (user_id, api_key_id, bucket_start, kind) DO NOTHING
+		RETURNING id, created_at
+	`, []any{
+		event.UserID, event.APIKeyID, event.BucketStart.UTC(), event.Kind, event.Severity,
+		event.Observed, event.BaselineMean, event.BaselineStddev, event.Score, string(details), event.Status,
+	}, &event.ID, &event.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *usageAnomalyRepository) MarkKeySuspended(ctx context.Context, id int64) error {
+	_, err := r.sql.ExecContext(ctx, "UPDATE usage_anomaly_events SET key_suspended = TRUE WHERE id = $1", id)
+	return err
+}
+
+func (r *usageAnomalyRepository) ListEvents(ctx context.Context, params pagination.PaginationParams, filters service.UsageAnomalyEventFilters) ([]service.UsageAnomalyEvent, *pagination.PaginationResult, error) {
+	conditions := []string{"1=1"}
+	args := []any{}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+	if filters.Status != "" {
+		add("e.status = $%d", filters.Status)
+	}
+	if filters.Severity != "" {
+		add("e.severity = $%d", filters.Severity)
+	}
+	if filters.Kind != "" {
+		add("e.kind = $%d", filters.Kind)
+	}
+	if filters.UserID > 0 {
+		add("e.user_id = $%d", filters.UserID)
+	}
+	if filters.APIKeyID > 0 {
+		add("e.api_key_id = $%d", filters.APIKeyID)
+	}
+	if filters.StartTime != nil {
+		add("e.bucket_start >= $%d", filters.StartTime.UTC())
+	}
+	if filters.EndTime != nil {
+		add("e.bucket_start < $%d", filters.EndTime.UTC())
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM usage_anomaly_events e"+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.UsageAnomalyEvent{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := "SELECT " + usageAnomalyEventColumns + `
+		FROM usage_anomaly_events e
+		LEFT JOIN users u ON u.id = e.user_id
+		LEFT JOIN api_keys k ON k.id = e.api_key_id` + where +
+		fmt.Sprintf(" ORDER BY e.id DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.UsageAnomalyEvent, 0)
+	for rows.Next() {
+		event, err := scanUsageAnomalyEvent(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = append(out, *event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
+
+func (r *usageAnomalyRepository) UpdateStatus(ctx context.Context, id int64, status string, adminID *int64) error {
+	res, err := r.sql.ExecContext(ctx, `
+		UPDATE usage_anomaly_events
+		SET status = $2,
+			acknowledged_by = $3,
+			acknowledged_at = CASE WHEN $3::bigint IS NULL THEN NULL ELSE NOW() END
+		WHERE id = $1
+	`, id, status, adminID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrUsageAnomalyNotFound
+	}
+	return nil
+}
+
+func scanUsageAnomalyEvent(rows *sql.Rows) (*service.UsageAnomalyEvent, error) {
+	var (
+		event          service.UsageAnomalyEvent
+		details        []byte
+		acknowledgedBy sql.NullInt64
+		acknowledgedAt sql.NullTime
+	)
+	if err := rows.Scan(
+		&event.ID,
+		&event.UserID,
+		&event.APIKeyID,
+		&event.BucketStart,
+		&event.Kind,
+		&event.Severity,
+		&event.Observed,
+		&event.BaselineMean,
+		&event.BaselineStddev,
+		&event.Score,
+		&details,
+		&event.KeySuspended,
+		&event.Status,
+		&acknowledgedBy,
+		&acknowledgedAt,
+		&event.CreatedAt,
+		&event.UserEmail,
+		&event.APIKeyName,
+	); err != nil {
+		return nil, err
+	}
+	if len(details) > 0 {
+		if err := json.Unmarshal(details, &event.Details); err != nil {
+			return nil, fmt.Errorf("decode anomaly details: %w", err)
+		}
+	}
+	if acknowledgedBy.Valid {
+		v := acknowledgedBy.Int64
+		event.AcknowledgedBy = &v
+	}
+	event.AcknowledgedAt = nullTimePtr(acknowledgedAt)
+	return &event, nil
+}
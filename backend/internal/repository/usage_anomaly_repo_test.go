@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageAnomalyRepositoryCreateEventConflictReturnsFalse(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUsageAnomalyRepositoryWithSQL(db)
+
+	bucket := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("INSERT INTO usage_anomaly_events(.|\n)*ON CONFLICT \\(user_id, api_key_id, bucket_start, kind\\) DO NOTHING").
+		WithArgs(int64(7), int64(70), bucket, service.UsageAnomalyKindSpendSpike, service.UsageAnomalySeverityWarning,
+			25.0, 1.5, 0.5, 47.0, `{"api_key_name":"prod"}`, service.UsageAnomalyStatusOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+
+	inserted, err := repo.CreateEvent(context.Background(), &service.UsageAnomalyEvent{
+		UserID:         7,
+		APIKeyID:       70,
+		BucketStart:    bucket,
+		Kind:           service.UsageAnomalyKindSpendSpike,
+		Severity:       service.UsageAnomalySeverityWarning,
+		Observed:       25,
+		BaselineMean:   1.5,
+		BaselineStddev: 0.5,
+		Score:          47,
+		Details:        map[string]any{"api_key_name": "prod"},
+	})
+	require.NoError(t, err)
+	require.False(t, inserted)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsageAnomalyRepositoryListKeyBaselinesAttachesModels(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUsageAnomalyRepositoryWithSQL(db)
+
+	from := time.Date(2026, 10, 11, 3, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+	first := from.Add(2 * time.Hour)
+	ids := []int64{70, 80}
+
+	mock.ExpectQuery("SELECT id, MIN\\(bucket_start\\)(.|\n)*WHERE api_key_id = ANY\\(\\$1\\)(.|\n)*GROUP BY id").
+		WithArgs(pq.Array(ids), from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first", "cost", "cost_sq", "req", "req_sq"}).
+			AddRow(int64(70), first, 10.0, 20.0, 100.0, 2000.0))
+	mock.ExpectQuery("SELECT DISTINCT api_key_id, model").
+		WithArgs(pq.Array(ids), from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"api_key_id", "model"}).
+			AddRow(int64(70), "claude-sonnet-4").
+			AddRow(int64(70), "claude-haiku-4"))
+
+	baselines, err := repo.ListKeyBaselines(context.Background(), ids, from, to)
+	require.NoError(t, err)
+	require.Len(t, baselines, 1)
+	require.Equal(t, first, baselines[70].FirstBucket)
+	require.InDelta(t, 20.0, baselines[70].SumCostSq, 1e-9)
+	require.Equal(t, []string{"claude-sonnet-4", "claude-haiku-4"}, baselines[70].Models)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsageAnomalyRepositoryListNewSourcesExcludesBaselineSources(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUsageAnomalyRepositoryWithSQL(db)
+
+	hourStart := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	hourEnd := hourStart.Add(time.Hour)
+	baselineFrom := hourStart.AddDate(0, 0, -7)
+	mock.ExpectQuery("WITH cur AS(.|\n)*NOT EXISTS(.|\n)*l.created_at >= \\$4 AND l.created_at < \\$2(.|\n)*LIMIT \\$5").
+		WithArgs(pq.Array([]int64{70}), hourStart, hourEnd, baselineFrom, usageAnomalyMaxNewSources).
+		WillReturnRows(sqlmock.NewRows([]string{"api_key_id", "kind", "value"}).
+			AddRow(int64(70), "ip", "203.0.113.0/24").
+			AddRow(int64(70), "user_agent", "curl/8.5.0"))
+
+	sources, err := repo.ListNewSources(context.Background(), []int64{70}, hourStart, hourEnd, baselineFrom)
+	require.NoError(t, err)
+	require.Equal(t, []service.UsageAnomalySource{
+		{APIKeyID: 70, Kind: service.UsageAnomalySourceIP, Value: "203.0.113.0/24"},
+		{APIKeyID: 70, Kind: service.UsageAnomalySourceUserAgent, Value: "curl/8.5.0"},
+	}, sources)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsageAnomalyRepositoryUpdateStatusNotFound(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUsageAnomalyRepositoryWithSQL(db)
+
+	adminID := int64(1)
+	mock.ExpectExec("UPDATE usage_anomaly_events").
+		WithArgs(int64(5), service.UsageAnomalyStatusAcknowledged, &adminID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.UpdateStatus(context.Background(), 5, service.UsageAnomalyStatusAcknowledged, &adminID)
+	require.ErrorIs(t, err, service.ErrUsageAnomalyNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
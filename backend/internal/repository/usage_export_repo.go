@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/secretbox"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const usageExportTaskColumns = `
+	id, scope, format, status, filters, created_by, row_count, file_size, file_path,
+	download_token, error_message, expires_at, started_at, finished_at, created_at, updated_at
+`
+
+type usageExportRepository struct {
+	sql  sqlExecutor
+	logs *usageLogRepository
+}
+
+func NewUsageExportRepository(client *dbent.Client, sqlDB *sql.DB, keyring *secretbox.Keyring) service.UsageExportRepository {
+	return newUsageExportRepositoryWithSQL(client, sqlDB, keyring)
+}
+
+func newUsageExportRepositoryWithSQL(client *dbent.Client, sqlq sqlExecutor, keyring *secretbox.Keyring) *usageExportRepository {
+	return &usageExportRepository{sql: sqlq, logs: newUsageLogRepositoryWithSQL(client, sqlq, keyring)}
+}
+
+func (r *usageExportRepository) CreateTask(ctx context.Context, task *service.UsageExportTask) error {
+	if task == nil {
+		return nil
+	}
+	filtersJSON, err := json.Marshal(task.Filters)
+	if err != nil {
+		return fmt.Errorf("marshal export filters: %w", err)
+	}
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO usage_export_tasks (scope, format, status, filters, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`, []any{task.Scope, task.Format, task.Status, filtersJSON, task.CreatedBy},
+		&task.ID, &task.CreatedAt, &task.UpdatedAt)
+}
+
+func (r *usageExportRepository) ListTasks(ctx context.Context, createdBy int64, params pagination.PaginationParams) ([]service.UsageExportTask, *pagination.PaginationResult, error) {
+	where := ""
+	args := []any{}
+	if createdBy > 0 {
+		where = "WHERE created_by = $1"
+		args = append(args, createdBy)
+	}
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM usage_export_tasks "+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.UsageExportTask{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM usage_export_tasks %s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d",
+		usageExportTaskColumns, where, len(args)+1, len(args)+2)
+	tasks, err := r.queryTasks(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tasks, paginationResultFromTotal(total, params), nil
+}
+
+func (r *usageExportRepository) GetTask(ctx context.Context, taskID int64) (*service.UsageExportTask, error) {
+	return r.getTask(ctx, "SELECT "+usageExportTaskColumns+" FROM usage_export_tasks WHERE id = $1", taskID)
+}
+
+func (r *usageExportRepository) GetTaskByToken(ctx context.Context, token string) (*service.UsageExportTask, error) {
+	return r.getTask(ctx, "SELECT "+usageExportTaskColumns+" FROM usage_export_tasks WHERE download_token = $1", token)
+}
+
+func (r *usageExportRepository) getTask(ctx context.Context, query string, arg any) (*service.UsageExportTask, error) {
+	tasks, err := r.queryTasks(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, service.ErrUsageExportTaskNotFound
+	}
+	return &tasks[0], nil
+}
+
+func (r *usageExportRepository) ClaimNextPendingTask(ctx context.Context, staleRunningAfterSeconds int64) (*service.UsageExportTask, error) {
+	if staleRunningAfterSeconds <= 0 {
+		staleRunningAfterSeconds = 3600
+	}
+	query := `
+		WITH next AS (
+			SELECT id
+			FROM usage_export_tasks
+			WHERE status = $1
+				OR (
+					status = $2
+					AND started_at IS NOT NULL
+					AND started_at < NOW() - ($3 * interval '1 second')
+				)
+			ORDER BY created_at ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE usage_export_tasks AS tasks
+		SET status = $2,
+			started_at = NOW(),
+			finished_at = NULL,
+			error_message = NULL,
+			updated_at = NOW()
+		FROM next
+		WHERE tasks.id = next.id
+		RETURNING tasks.id, tasks.scope, tasks.format, tasks.status, tasks.filters, tasks.created_by,
+			tasks.row_count, tasks.file_size, tasks.file_path, tasks.download_token, tasks.error_message,
+			tasks.expires_at, tasks.started_at, tasks.finished_at, tasks.created_at, tasks.updated_at
+	`
+	tasks, err := r.queryTasks(ctx, query, service.UsageExportStatusPending, service.UsageExportStatusRunning, staleRunningAfterSeconds)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+	return &tasks[0], nil
+}
+
+func (r *usageExportRepository) MarkTaskSucceeded(ctx context.Context, task *service.UsageExportTask) error {
+	if task == nil {
+		return nil
+	}
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE usage_export_tasks
+		SET status = $2,
+			row_count = $3,
+			file_size = $4,
+			file_path = $5,
+			download_token = $6,
+			expires_at = $7,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1
+	`, task.ID, service.UsageExportStatusSucceeded, task.RowCount, task.FileSize, task.FilePath, task.DownloadToken, task.ExpiresAt)
+	return err
+}
+
+func (r *usageExportRepository) MarkTaskFailed(ctx context.Context, taskID int64, errorMsg string) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE usage_export_tasks
+		SET status = $2,
+			error_message = $3,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1
+	`, taskID, service.UsageExportStatusFailed, errorMsg)
+	return err
+}
+
+func (r *usageExportRepository) ListExpiredTasks(ctx context.Context, now time.Time, limit int) ([]service.UsageExportTask, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	query := "SELECT " + usageExportTaskColumns + `
+		FROM usage_export_tasks
+		WHERE status = $1 AND expires_at IS NOT NULL AND expires_at <= $2
+		ORDER BY expires_at ASC
+		LIMIT $3`
+	return r.queryTasks(ctx, query, service.UsageExportStatusSucceeded, now, limit)
+}
+
+func (r *usageExportRepository) MarkTaskExpired(ctx context.Context, taskID int64) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE usage_export_tasks
+		SET status = $2,
+			download_token = NULL,
+			updated_at = NOW()
+		WHERE id = $1
+	`, taskID, service.UsageExportStatusExpired)
+	return err
+}
+
+func (r *usageExportRepository) CountUsageLogs(ctx context.Context, filters usagestats.UsageLogFilters) (int64, error) {
+	conditions, args := usageLogFilterConditions(filters)
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM usage_logs "+buildWhere(conditions), args, &total); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func (r *usageExportRepository) ListUsageLogsAfter(ctx context.Context, filters usagestats.UsageLogFilters, afterID int64, limit int) ([]service.UsageLog, error) {
+	conditions, args := usageLogFilterConditions(filters)
+	conditions = append(conditions, fmt.Sprintf("id > $%d", len(args)+1))
+	args = append(args, afterID, limit)
+	query := fmt.Sprintf("SELECT %s FROM usage_logs %s ORDER BY id ASC LIMIT $%d", usageLogSelectColumns, buildWhere(conditions), len(args))
+	logs, err := r.logs.queryUsageLogs(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if r.logs.client != nil {
+		if err := r.logs.hydrateUsageLogAssociations(ctx, logs); err != nil {
+			return nil, err
+		}
+	}
+	return logs, nil
+}
+
+func (r *usageExportRepository) queryTasks(ctx context.Context, query string, args ...any) (tasks []service.UsageExportTask, err error) {
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+			tasks = nil
+		}
+	}()
+
+	tasks = make([]service.UsageExportTask, 0)
+	for rows.Next() {
+		var task service.UsageExportTask
+		var filtersJSON []byte
+		var token, errMsg sql.NullString
+		var expiresAt, startedAt, finishedAt sql.NullTime
+		if err = rows.Scan(
+			&task.ID,
+			&task.Scope,
+			&task.Format,
+			&task.Status,
+			&filtersJSON,
+			&task.CreatedBy,
+			&task.RowCount,
+			&task.FileSize,
+			&task.FilePath,
+			&token,
+			&errMsg,
+			&expiresAt,
+			&startedAt,
+			&finishedAt,
+			&task.CreatedAt,
+			&task.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(filtersJSON, &task.Filters); err != nil {
+			return nil, fmt.Errorf("parse export filters: %w", err)
+		}
+		task.DownloadToken = token.String
+		if errMsg.Valid {
+			task.ErrorMsg = &errMsg.String
+		}
+		task.ExpiresAt = nullTimePtr(expiresAt)
+		task.StartedAt = nullTimePtr(startedAt)
+		task.FinishedAt = nullTimePtr(finishedAt)
+		tasks = append(tasks, task)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageExportRepositoryListUsageLogsAfterUsesKeyset(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUsageExportRepositoryWithSQL(nil, db, nil)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT .* FROM usage_logs WHERE user_id = \$1 AND created_at >= \$2 AND id > \$3 ORDER BY id ASC LIMIT \$4`).
+		WithArgs(int64(7), start, int64(100), 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	logs, err := repo.ListUsageLogsAfter(context.Background(), usagestats.UsageLogFilters{UserID: 7, StartTime: &start}, 100, 50)
+	require.NoError(t, err)
+	require.Empty(t, logs)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsageExportRepositoryGetTaskByTokenNotFound(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUsageExportRepositoryWithSQL(nil, db, nil)
+
+	mock.ExpectQuery("FROM usage_export_tasks WHERE download_token = \\$1").
+		WithArgs("tok").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := repo.GetTaskByToken(context.Background(), "tok")
+	require.ErrorIs(t, err, service.ErrUsageExportTaskNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsageExportRepositoryCreateTaskPersistsFilters(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUsageExportRepositoryWithSQL(nil, db, nil)
+
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO usage_export_tasks").
+		WithArgs(service.UsageExportScopeUser, service.UsageExportFormatCSV, service.UsageExportStatusPending, []byte(`{"user_id":7,"model":"gpt-4o"}`), int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(int64(3), now, now))
+
+	task := &service.UsageExportTask{
+		Scope:     service.UsageExportScopeUser,
+		Format:    service.UsageExportFormatCSV,
+		Status:    service.UsageExportStatusPending,
+		Filters:   service.UsageExportFilters{UserID: 7, Model: "gpt-4o"},
+		CreatedBy: 7,
+	}
+	require.NoError(t, repo.CreateTask(context.Background(), task))
+	require.EqualValues(t, 3, task.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
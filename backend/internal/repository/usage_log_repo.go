@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -16,26 +17,28 @@ import (
 	dbuser "github.com/Wei-Shaw/sub2api/ent/user"
 	dbusersub "github.com/Wei-Shaw/sub2api/ent/usersubscription"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/secretbox"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/lib/pq"
 )
 
-const usageLogSelectColumns = "id, user_id, api_key_id, account_id, request_id, model, group_id, subscription_id, input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens, cache_creation_5m_tokens, cache_creation_1h_tokens, input_cost, output_cost, cache_creation_cost, cache_read_cost, total_cost, actual_cost, rate_multiplier, account_rate_multiplier, billing_type, stream, duration_ms, first_token_ms, user_agent, ip_address, image_count, image_size, created_at"
+const usageLogSelectColumns = "id, user_id, api_key_id, account_id, request_id, model, group_id, subscription_id, organization_id, input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens, cache_creation_5m_tokens, cache_creation_1h_tokens, input_cost, output_cost, cache_creation_cost, cache_read_cost, total_cost, actual_cost, rate_multiplier, account_rate_multiplier, billing_type, stream, duration_ms, first_token_ms, user_agent, ip_address, image_count, image_size, created_at, cache_creation_1h_cost, rate_rule, tags, hedged"
 
 type usageLogRepository struct {
-	client *dbent.Client
-	sql    sqlExecutor
+	client  *dbent.Client
+	sql     sqlExecutor
+	keyring *secretbox.Keyring
 }
 
-func NewUsageLogRepository(client *dbent.Client, sqlDB *sql.DB) service.UsageLogRepository {
-	return newUsageLogRepositoryWithSQL(client, sqlDB)
+func NewUsageLogRepository(client *dbent.Client, sqlDB *sql.DB, keyring *secretbox.Keyring) service.UsageLogRepository {
+	return newUsageLogRepositoryWithSQL(client, sqlDB, keyring)
 }
 
-func newUsageLogRepositoryWithSQL(client *dbent.Client, sqlq sqlExecutor) *usageLogRepository {
+func newUsageLogRepositoryWithSQL(client *dbent.Client, sqlq sqlExecutor, keyring *secretbox.Keyring) *usageLogRepository {
 	// 使用 scanSingleRow 替代 QueryRowContext，保证 ent.Tx 作为 sqlExecutor 可用。
-	return &usageLogRepository{client: client, sql: sqlq}
+	return &usageLogRepository{client: client, sql: sqlq, keyring: keyring}
 }
 
 // getPerformanceStats 获取 RPM 和 TPM（近5分钟平均值，可选按用户过滤）
@@ -92,6 +95,7 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 			model,
 			group_id,
 			subscription_id,
+			organization_id,
 			input_tokens,
 			output_tokens,
 			cache_creation_tokens,
@@ -114,14 +118,19 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 			ip_address,
 			image_count,
 			image_size,
-			created_at
+			created_at,
+			cache_creation_1h_cost,
+			rate_rule,
+			tags,
+			hedged
 		) VALUES (
 			$1, $2, $3, $4, $5,
-			$6, $7,
-			$8, $9, $10, $11,
-			$12, $13,
-			$14, $15, $16, $17, $18, $19,
-			$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30
+			$6, $7, $8,
+			$9, $10, $11, $12,
+			$13, $14,
+			$15, $16, $17, $18, $19, $20,
+			$21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31,
+			$32, $33, $34, $35
 		)
 		ON CONFLICT (request_id, api_key_id) DO NOTHING
 		RETURNING id, created_at
@@ -129,11 +138,17 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 
 	groupID := nullInt64(log.GroupID)
 	subscriptionID := nullInt64(log.SubscriptionID)
+	organizationID := nullInt64(log.OrganizationID)
 	duration := nullInt(log.DurationMs)
 	firstToken := nullInt(log.FirstTokenMs)
 	userAgent := nullString(log.UserAgent)
 	ipAddress := nullString(log.IPAddress)
 	imageSize := nullString(log.ImageSize)
+	rateRule := nullString(log.RateRule)
+	tags, err := usageTagsArg(log.Tags)
+	if err != nil {
+		return false, err
+	}
 
 	var requestIDArg any
 	if requestID != "" {
@@ -148,6 +163,7 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 		log.Model,
 		groupID,
 		subscriptionID,
+		organizationID,
 		log.InputTokens,
 		log.OutputTokens,
 		log.CacheCreationTokens,
@@ -171,6 +187,10 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 		log.ImageCount,
 		imageSize,
 		createdAt,
+		log.CacheCreation1hCost,
+		rateRule,
+		tags,
+		log.Hedged,
 	}
 	if err := scanSingleRow(ctx, sqlq, query, args, &log.ID, &log.CreatedAt); err != nil {
 		if errors.Is(err, sql.ErrNoRows) && requestID != "" {
@@ -574,6 +594,10 @@ func (r *usageLogRepository) GetUserStatsAggregated(ctx context.Context, userID
 			COALESCE(SUM(input_tokens), 0) as total_input_tokens,
 			COALESCE(SUM(output_tokens), 0) as total_output_tokens,
 			COALESCE(SUM(cache_creation_tokens + cache_read_tokens), 0) as total_cache_tokens,
+			COALESCE(SUM(cache_creation_tokens - cache_creation_1h_tokens), 0) as total_cache_creation_5m_tokens,
+			COALESCE(SUM(cache_creation_1h_tokens), 0) as total_cache_creation_1h_tokens,
+			COALESCE(SUM(cache_creation_cost - cache_creation_1h_cost), 0) as total_cache_creation_5m_cost,
+			COALESCE(SUM(cache_creation_1h_cost), 0) as total_cache_creation_1h_cost,
 			COALESCE(SUM(total_cost), 0) as total_cost,
 			COALESCE(SUM(actual_cost), 0) as total_actual_cost,
 			COALESCE(AVG(COALESCE(duration_ms, 0)), 0) as avg_duration_ms
@@ -591,6 +615,10 @@ func (r *usageLogRepository) GetUserStatsAggregated(ctx context.Context, userID
 		&stats.TotalInputTokens,
 		&stats.TotalOutputTokens,
 		&stats.TotalCacheTokens,
+		&stats.TotalCacheCreation5mTokens,
+		&stats.TotalCacheCreation1hTokens,
+		&stats.TotalCacheCreation5mCost,
+		&stats.TotalCacheCreation1hCost,
 		&stats.TotalCost,
 		&stats.TotalActualCost,
 		&stats.AverageDurationMs,
@@ -609,6 +637,10 @@ func (r *usageLogRepository) GetAPIKeyStatsAggregated(ctx context.Context, apiKe
 			COALESCE(SUM(input_tokens), 0) as total_input_tokens,
 			COALESCE(SUM(output_tokens), 0) as total_output_tokens,
 			COALESCE(SUM(cache_creation_tokens + cache_read_tokens), 0) as total_cache_tokens,
+			COALESCE(SUM(cache_creation_tokens - cache_creation_1h_tokens), 0) as total_cache_creation_5m_tokens,
+			COALESCE(SUM(cache_creation_1h_tokens), 0) as total_cache_creation_1h_tokens,
+			COALESCE(SUM(cache_creation_cost - cache_creation_1h_cost), 0) as total_cache_creation_5m_cost,
+			COALESCE(SUM(cache_creation_1h_cost), 0) as total_cache_creation_1h_cost,
 			COALESCE(SUM(total_cost), 0) as total_cost,
 			COALESCE(SUM(actual_cost), 0) as total_actual_cost,
 			COALESCE(AVG(COALESCE(duration_ms, 0)), 0) as avg_duration_ms
@@ -626,6 +658,10 @@ func (r *usageLogRepository) GetAPIKeyStatsAggregated(ctx context.Context, apiKe
 		&stats.TotalInputTokens,
 		&stats.TotalOutputTokens,
 		&stats.TotalCacheTokens,
+		&stats.TotalCacheCreation5mTokens,
+		&stats.TotalCacheCreation1hTokens,
+		&stats.TotalCacheCreation5mCost,
+		&stats.TotalCacheCreation1hCost,
 		&stats.TotalCost,
 		&stats.TotalActualCost,
 		&stats.AverageDurationMs,
@@ -654,6 +690,10 @@ func (r *usageLogRepository) GetAccountStatsAggregated(ctx context.Context, acco
 			COALESCE(SUM(input_tokens), 0) as total_input_tokens,
 			COALESCE(SUM(output_tokens), 0) as total_output_tokens,
 			COALESCE(SUM(cache_creation_tokens + cache_read_tokens), 0) as total_cache_tokens,
+			COALESCE(SUM(cache_creation_tokens - cache_creation_1h_tokens), 0) as total_cache_creation_5m_tokens,
+			COALESCE(SUM(cache_creation_1h_tokens), 0) as total_cache_creation_1h_tokens,
+			COALESCE(SUM(cache_creation_cost - cache_creation_1h_cost), 0) as total_cache_creation_5m_cost,
+			COALESCE(SUM(cache_creation_1h_cost), 0) as total_cache_creation_1h_cost,
 			COALESCE(SUM(total_cost), 0) as total_cost,
 			COALESCE(SUM(actual_cost), 0) as total_actual_cost,
 			COALESCE(AVG(COALESCE(duration_ms, 0)), 0) as avg_duration_ms
@@ -671,6 +711,10 @@ func (r *usageLogRepository) GetAccountStatsAggregated(ctx context.Context, acco
 		&stats.TotalInputTokens,
 		&stats.TotalOutputTokens,
 		&stats.TotalCacheTokens,
+		&stats.TotalCacheCreation5mTokens,
+		&stats.TotalCacheCreation1hTokens,
+		&stats.TotalCacheCreation5mCost,
+		&stats.TotalCacheCreation1hCost,
 		&stats.TotalCost,
 		&stats.TotalActualCost,
 		&stats.AverageDurationMs,
@@ -690,6 +734,10 @@ func (r *usageLogRepository) GetModelStatsAggregated(ctx context.Context, modelN
 			COALESCE(SUM(input_tokens), 0) as total_input_tokens,
 			COALESCE(SUM(output_tokens), 0) as total_output_tokens,
 			COALESCE(SUM(cache_creation_tokens + cache_read_tokens), 0) as total_cache_tokens,
+			COALESCE(SUM(cache_creation_tokens - cache_creation_1h_tokens), 0) as total_cache_creation_5m_tokens,
+			COALESCE(SUM(cache_creation_1h_tokens), 0) as total_cache_creation_1h_tokens,
+			COALESCE(SUM(cache_creation_cost - cache_creation_1h_cost), 0) as total_cache_creation_5m_cost,
+			COALESCE(SUM(cache_creation_1h_cost), 0) as total_cache_creation_1h_cost,
 			COALESCE(SUM(total_cost), 0) as total_cost,
 			COALESCE(SUM(actual_cost), 0) as total_actual_cost,
 			COALESCE(AVG(COALESCE(duration_ms, 0)), 0) as avg_duration_ms
@@ -707,6 +755,10 @@ func (r *usageLogRepository) GetModelStatsAggregated(ctx context.Context, modelN
 		&stats.TotalInputTokens,
 		&stats.TotalOutputTokens,
 		&stats.TotalCacheTokens,
+		&stats.TotalCacheCreation5mTokens,
+		&stats.TotalCacheCreation1hTokens,
+		&stats.TotalCacheCreation5mCost,
+		&stats.TotalCacheCreation1hCost,
 		&stats.TotalCost,
 		&stats.TotalActualCost,
 		&stats.AverageDurationMs,
@@ -1207,6 +1259,21 @@ type UsageLogFilters = usagestats.UsageLogFilters
 
 // ListWithFilters lists usage logs with optional filters (for admin)
 func (r *usageLogRepository) ListWithFilters(ctx context.Context, params pagination.PaginationParams, filters UsageLogFilters) ([]service.UsageLog, *pagination.PaginationResult, error) {
+	conditions, args := usageLogFilterConditions(filters)
+	whereClause := buildWhere(conditions)
+	logs, page, err := r.listUsageLogsWithPagination(ctx, whereClause, args, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := r.hydrateUsageLogAssociations(ctx, logs); err != nil {
+		return nil, nil, err
+	}
+	return logs, page, nil
+}
+
+// usageLogFilterConditions 将列表/导出共用的过滤条件转换为 WHERE 子句片段与参数
+func usageLogFilterConditions(filters UsageLogFilters) ([]string, []any) {
 	conditions := make([]string, 0, 8)
 	args := make([]any, 0, 8)
 
@@ -1226,6 +1293,10 @@ func (r *usageLogRepository) ListWithFilters(ctx context.Context, params paginat
 		conditions = append(conditions, fmt.Sprintf("group_id = $%d", len(args)+1))
 		args = append(args, filters.GroupID)
 	}
+	if filters.OrganizationID > 0 {
+		conditions = append(conditions, fmt.Sprintf("organization_id = $%d", len(args)+1))
+		args = append(args, filters.OrganizationID)
+	}
 	if filters.Model != "" {
 		conditions = append(conditions, fmt.Sprintf("model = $%d", len(args)+1))
 		args = append(args, filters.Model)
@@ -1246,17 +1317,7 @@ func (r *usageLogRepository) ListWithFilters(ctx context.Context, params paginat
 		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)+1))
 		args = append(args, *filters.EndTime)
 	}
-
-	whereClause := buildWhere(conditions)
-	logs, page, err := r.listUsageLogsWithPagination(ctx, whereClause, args, params)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	if err := r.hydrateUsageLogAssociations(ctx, logs); err != nil {
-		return nil, nil, err
-	}
-	return logs, page, nil
+	return conditions, args
 }
 
 // UsageStats represents usage statistics
@@ -1606,6 +1667,10 @@ func (r *usageLogRepository) GetStatsWithFilters(ctx context.Context, filters Us
 		conditions = append(conditions, fmt.Sprintf("group_id = $%d", len(args)+1))
 		args = append(args, filters.GroupID)
 	}
+	if filters.OrganizationID > 0 {
+		conditions = append(conditions, fmt.Sprintf("organization_id = $%d", len(args)+1))
+		args = append(args, filters.OrganizationID)
+	}
 	if filters.Model != "" {
 		conditions = append(conditions, fmt.Sprintf("model = $%d", len(args)+1))
 		args = append(args, filters.Model)
@@ -1633,6 +1698,10 @@ func (r *usageLogRepository) GetStatsWithFilters(ctx context.Context, filters Us
 			COALESCE(SUM(input_tokens), 0) as total_input_tokens,
 			COALESCE(SUM(output_tokens), 0) as total_output_tokens,
 			COALESCE(SUM(cache_creation_tokens + cache_read_tokens), 0) as total_cache_tokens,
+			COALESCE(SUM(cache_creation_tokens - cache_creation_1h_tokens), 0) as total_cache_creation_5m_tokens,
+			COALESCE(SUM(cache_creation_1h_tokens), 0) as total_cache_creation_1h_tokens,
+			COALESCE(SUM(cache_creation_cost - cache_creation_1h_cost), 0) as total_cache_creation_5m_cost,
+			COALESCE(SUM(cache_creation_1h_cost), 0) as total_cache_creation_1h_cost,
 			COALESCE(SUM(total_cost), 0) as total_cost,
 			COALESCE(SUM(actual_cost), 0) as total_actual_cost,
 			COALESCE(SUM(total_cost * COALESCE(account_rate_multiplier, 1)), 0) as total_account_cost,
@@ -1652,6 +1721,10 @@ func (r *usageLogRepository) GetStatsWithFilters(ctx context.Context, filters Us
 		&stats.TotalInputTokens,
 		&stats.TotalOutputTokens,
 		&stats.TotalCacheTokens,
+		&stats.TotalCacheCreation5mTokens,
+		&stats.TotalCacheCreation1hTokens,
+		&stats.TotalCacheCreation5mCost,
+		&stats.TotalCacheCreation1hCost,
 		&stats.TotalCost,
 		&stats.TotalActualCost,
 		&totalAccountCost,
@@ -2023,7 +2096,7 @@ func (r *usageLogRepository) loadAccounts(ctx context.Context, ids []int64) (map
 		return nil, err
 	}
 	for _, m := range models {
-		out[m.ID] = accountEntityToService(m)
+		out[m.ID] = accountEntityToService(m, r.keyring)
 	}
 	return out, nil
 }
@@ -2068,6 +2141,7 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		model                 string
 		groupID               sql.NullInt64
 		subscriptionID        sql.NullInt64
+		organizationID        sql.NullInt64
 		inputTokens           int
 		outputTokens          int
 		cacheCreationTokens   int
@@ -2091,6 +2165,10 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		imageCount            int
 		imageSize             sql.NullString
 		createdAt             time.Time
+		cacheCreation1hCost   float64
+		rateRule              sql.NullString
+		tags                  []byte
+		hedged                bool
 	)
 
 	if err := scanner.Scan(
@@ -2102,6 +2180,7 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		&model,
 		&groupID,
 		&subscriptionID,
+		&organizationID,
 		&inputTokens,
 		&outputTokens,
 		&cacheCreationTokens,
@@ -2125,6 +2204,10 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		&imageCount,
 		&imageSize,
 		&createdAt,
+		&cacheCreation1hCost,
+		&rateRule,
+		&tags,
+		&hedged,
 	); err != nil {
 		return nil, err
 	}
@@ -2144,6 +2227,7 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		InputCost:             inputCost,
 		OutputCost:            outputCost,
 		CacheCreationCost:     cacheCreationCost,
+		CacheCreation1hCost:   cacheCreation1hCost,
 		CacheReadCost:         cacheReadCost,
 		TotalCost:             totalCost,
 		ActualCost:            actualCost,
@@ -2151,6 +2235,7 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		AccountRateMultiplier: nullFloat64Ptr(accountRateMultiplier),
 		BillingType:           int8(billingType),
 		Stream:                stream,
+		Hedged:                hedged,
 		ImageCount:            imageCount,
 		CreatedAt:             createdAt,
 	}
@@ -2166,6 +2251,10 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		value := subscriptionID.Int64
 		log.SubscriptionID = &value
 	}
+	if organizationID.Valid {
+		value := organizationID.Int64
+		log.OrganizationID = &value
+	}
 	if durationMs.Valid {
 		value := int(durationMs.Int64)
 		log.DurationMs = &value
@@ -2183,10 +2272,30 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 	if imageSize.Valid {
 		log.ImageSize = &imageSize.String
 	}
+	if rateRule.Valid {
+		log.RateRule = &rateRule.String
+	}
+	if len(tags) > 0 {
+		if err := json.Unmarshal(tags, &log.Tags); err != nil {
+			return nil, fmt.Errorf("decode usage log tags: %w", err)
+		}
+	}
 
 	return log, nil
 }
 
+// usageTagsArg 将标签编码为 JSONB 参数；无标签时写入 NULL
+func usageTagsArg(tags map[string]string) (any, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	raw, err := json.Marshal(tags)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
 func scanTrendRows(rows *sql.Rows) ([]TrendDataPoint, error) {
 	results := make([]TrendDataPoint, 0)
 	for rows.Next() {
@@ -2255,6 +2364,14 @@ func nullInt(v *int) sql.NullInt64 {
 	return sql.NullInt64{Int64: int64(*v), Valid: true}
 }
 
+func nullInt64Ptr(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	out := v.Int64
+	return &out
+}
+
 func nullFloat64Ptr(v sql.NullFloat64) *float64 {
 	if !v.Valid {
 		return nil
@@ -30,7 +30,7 @@ func (s *UsageLogRepoSuite) SetupTest() {
 	tx := testEntTx(s.T())
 	s.tx = tx
 	s.client = tx.Client()
-	s.repo = newUsageLogRepositoryWithSQL(s.client, tx)
+	s.repo = newUsageLogRepositoryWithSQL(s.client, tx, nil)
 }
 
 func TestUsageLogRepoSuite(t *testing.T) {
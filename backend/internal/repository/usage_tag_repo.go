@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const usageTagKeyColumns = `
+	id, key, description, allowed_values, created_at, updated_at
+`
+
+const usageTagBudgetColumns = `
+	id, user_id, tag_key, tag_value, monthly_limit_usd, created_at, updated_at
+`
+
+type usageTagRepository struct {
+	sql sqlExecutor
+}
+
+func NewUsageTagRepository(sqlDB *sql.DB) service.UsageTagRepository {
+	return newUsageTagRepositoryWithSQL(sqlDB)
+}
+
+func newUsageTagRepositoryWithSQL(sqlq sqlExecutor) *usageTagRepository {
+	return &usageTagRepository{sql: sqlq}
+}
+
+func (r *usageTagRepository) ListKeys(ctx context.Context) ([]service.UsageTagKey, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+usageTagKeyColumns+" FROM usage_tag_keys ORDER BY key ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.UsageTagKey, 0)
+	for rows.Next() {
+		key, err := scanUsageTagKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *usageTagRepository) GetKey(ctx context.Context, id int64) (*service.UsageTagKey, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+usageTagKeyColumns+" FROM usage_tag_keys WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrUsageTagKeyNotFound
+	}
+	return scanUsageTagKey(rows)
+}
+
+func (r *usageTagRepository) CreateKey(ctx context.Context, key *service.UsageTagKey) error {
+	allowed, err := json.Marshal(key.AllowedValues)
+	if err != nil {
+		return err
+	}
+	err = scanSingleRow(ctx, r.sql, `
+		INSERT INTO usage_tag_keys (key, description, allowed_values, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`, []any{key.Key, key.Description, string(allowed)}, &key.ID, &key.CreatedAt, &key.UpdatedAt)
+	if isUniqueConstraintViolation(err) {
+		return service.ErrUsageTagKeyExists
+	}
+	return err
+}
+
+func (r *usageTagRepository) UpdateKey(ctx context.Context, key *service.UsageTagKey) error {
+	allowed, err := json.Marshal(key.AllowedValues)
+	if err != nil {
+		return err
+	}
+	err = scanSingleRow(ctx, r.sql, `
+		UPDATE usage_tag_keys
+		SET key = $2, description = $3, allowed_values = $4, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`, []any{key.ID, key.Key, key.Description, string(allowed)}, &key.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return service.ErrUsageTagKeyNotFound
+	}
+	if isUniqueConstraintViolation(err) {
+		return service.ErrUsageTagKeyExists
+	}
+	return err
+}
+
+func (r *usageTagRepository) DeleteKey(ctx context.Context, id int64) error {
+	res, err := r.sql.ExecContext(ctx, "DELETE FROM usage_tag_keys WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrUsageTagKeyNotFound
+	}
+	return nil
+}
+
+func (r *usageTagRepository) ListBudgets(ctx context.Context, userID *int64) ([]service.UsageTagBudget, error) {
+	query := "SELECT " + usageTagBudgetColumns + " FROM usage_tag_budgets"
+	args := []any{}
+	if userID != nil {
+		query += " WHERE user_id = $1"
+		args = append(args, *userID)
+	}
+	query += " ORDER BY user_id ASC, tag_key ASC, tag_value ASC"
+
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.UsageTagBudget, 0)
+	for rows.Next() {
+		var b service.UsageTagBudget
+		if err := rows.Scan(
+			&b.ID,
+			&b.UserID,
+			&b.TagKey,
+			&b.TagValue,
+			&b.MonthlyLimitUSD,
+			&b.CreatedAt,
+			&b.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *usageTagRepository) UpsertBudget(ctx context.Context, budget *service.UsageTagBudget) error {
+	if budget == nil {
+		return nil
+	}
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO usage_tag_budgets (user_id, tag_key, tag_value, monthly_limit_usd, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		ON CONFLICT (user_id, tag_key, tag_value) DO UPDATE SET
+			monthly_limit_usd = EXCLUDED.monthly_limit_usd,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`, []any{budget.UserID, budget.TagKey, budget.TagValue, budget.MonthlyLimitUSD}, &budget.ID, &budget.CreatedAt, &budget.UpdatedAt)
+}
+
+func (r *usageTagRepository) DeleteBudget(ctx context.Context, id int64, userID *int64) error {
+	query := "DELETE FROM usage_tag_budgets WHERE id = $1"
+	args := []any{id}
+	if userID != nil {
+		query += " AND user_id = $2"
+		args = append(args, *userID)
+	}
+	res, err := r.sql.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrUsageTagBudgetNotFound
+	}
+	return nil
+}
+
+func (r *usageTagRepository) SumMonthlySpendByTag(ctx context.Context, userID int64, tagKey, tagValue string, since time.Time) (float64, error) {
+	filter, err := json.Marshal(map[string]string{tagKey: tagValue})
+	if err != nil {
+		return 0, err
+	}
+	var spend float64
+	err = scanSingleRow(ctx, r.sql, `
+		SELECT COALESCE(SUM(actual_cost), 0)
+		FROM usage_logs
+		WHERE user_id = $1 AND created_at >= $2 AND tags @> $3::jsonb
+	`, []any{userID, since, string(filter)}, &spend)
+	return spend, err
+}
+
+func (r *usageTagRepository) GetStats(ctx context.Context, filters service.UsageTagStatsFilters) ([]service.UsageTagStat, error) {
+	args := []any{filters.TagKey, filters.StartTime, filters.EndTime}
+	where := "created_at >= $2 AND created_at < $3"
+	if filters.UserID != nil {
+		args = append(args, *filters.UserID)
+		where += fmt.Sprintf(" AND user_id = $%d", len(args))
+	}
+	if filters.APIKeyID != nil {
+		args = append(args, *filters.APIKeyID)
+		where += fmt.Sprintf(" AND api_key_id = $%d", len(args))
+	}
+
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT
+			COALESCE(tags ->> $1, '') AS tag_value,
+			COUNT(*),
+			COALESCE(SUM(input_tokens), 0),
+			COALESCE(SUM(output_tokens), 0),
+			COALESCE(SUM(total_cost), 0),
+			COALESCE(SUM(actual_cost), 0)
+		FROM usage_logs
+		WHERE `+where+`
+		GROUP BY 1
+		ORDER BY 6 DESC, 1 ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.UsageTagStat, 0)
+	for rows.Next() {
+		var s service.UsageTagStat
+		if err := rows.Scan(
+			&s.TagValue,
+			&s.Requests,
+			&s.InputTokens,
+			&s.OutputTokens,
+			&s.TotalCost,
+			&s.ActualCost,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func scanUsageTagKey(rows *sql.Rows) (*service.UsageTagKey, error) {
+	var (
+		key     service.UsageTagKey
+		allowed []byte
+	)
+	if err := rows.Scan(
+		&key.ID,
+		&key.Key,
+		&key.Description,
+		&allowed,
+		&key.CreatedAt,
+		&key.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if len(allowed) > 0 {
+		if err := json.Unmarshal(allowed, &key.AllowedValues); err != nil {
+			return nil, fmt.Errorf("decode usage tag allowed values: %w", err)
+		}
+	}
+	return &key, nil
+}
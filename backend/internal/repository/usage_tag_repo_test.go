@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageTagRepositoryCreateKeyDuplicate(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUsageTagRepositoryWithSQL(db)
+
+	mock.ExpectQuery("INSERT INTO usage_tag_keys").
+		WithArgs("project", "", `["search","ads"]`).
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	err := repo.CreateKey(context.Background(), &service.UsageTagKey{Key: "project", AllowedValues: []string{"search", "ads"}})
+	require.ErrorIs(t, err, service.ErrUsageTagKeyExists)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsageTagRepositorySumMonthlySpendByTagUsesContainment(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUsageTagRepositoryWithSQL(db)
+
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT COALESCE\\(SUM\\(actual_cost\\), 0\\)(.|\n)*tags @> \\$3::jsonb").
+		WithArgs(int64(9), since, `{"project":"search"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(12.5))
+
+	spend, err := repo.SumMonthlySpendByTag(context.Background(), 9, "project", "search", since)
+	require.NoError(t, err)
+	require.InDelta(t, 12.5, spend, 1e-9)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsageTagRepositoryGetStatsGroupsByTagValue(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUsageTagRepositoryWithSQL(db)
+
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	userID := int64(3)
+	mock.ExpectQuery("COALESCE\\(tags ->> \\$1, ''\\)(.|\n)*user_id = \\$4(.|\n)*GROUP BY 1").
+		WithArgs("project", start, end, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"tag_value", "count", "input", "output", "total", "actual"}).
+			AddRow("search", int64(10), int64(1000), int64(200), 1.5, 1.8).
+			AddRow("", int64(2), int64(50), int64(10), 0.1, 0.1))
+
+	stats, err := repo.GetStats(context.Background(), service.UsageTagStatsFilters{TagKey: "project", UserID: &userID, StartTime: start, EndTime: end})
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	require.Equal(t, "search", stats[0].TagValue)
+	require.EqualValues(t, 10, stats[0].Requests)
+	require.Empty(t, stats[1].TagValue)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsageTagRepositoryDeleteBudgetScopedToUser(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUsageTagRepositoryWithSQL(db)
+
+	userID := int64(3)
+	mock.ExpectExec("DELETE FROM usage_tag_budgets WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(int64(5), userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.DeleteBudget(context.Background(), 5, &userID)
+	require.ErrorIs(t, err, service.ErrUsageTagBudgetNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
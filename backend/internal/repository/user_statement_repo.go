@@ -0,0 +1,413 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type userStatementRepository struct {
+	sql sqlExecutor
+}
+
+func NewUserStatementRepository(sqlDB *sql.DB) service.UserStatementRepository {
+	return newUserStatementRepositoryWithSQL(sqlDB)
+}
+
+func newUserStatementRepositoryWithSQL(sqlq sqlExecutor) *userStatementRepository {
+	return &userStatementRepository{sql: sqlq}
+}
+
+func (r *userStatementRepository) ListCandidates(ctx context.Context, period string, start, end time.Time, afterUserID int64, limit int) (out []service.UserStatementRecipient, err error) {
+	query := `
+		WITH active AS (
+			SELECT user_id FROM usage_dashboard_hourly_user_breakdown
+			WHERE bucket_start >= $2 AND bucket_start < $3 AND user_id > $4
+			UNION
+			SELECT used_by FROM redeem_codes
+			WHERE used_by > $4 AND used_at >= $2 AND used_at < $3 AND type IN ($6, $7)
+			UNION
+			SELECT user_id FROM promo_code_usages
+			WHERE user_id > $4 AND used_at >= $2 AND used_at < $3
+			UNION
+			SELECT user_id FROM payment_orders
+			WHERE user_id > $4 AND paid_at >= $2 AND paid_at < $3
+			UNION
+			SELECT user_id FROM subscription_plan_orders
+			WHERE user_id > $4 AND created_at >= $2 AND created_at < $3
+		)
+		SELECT u.id, u.email, u.username
+		FROM active
+		JOIN users u ON u.id = active.user_id
+		WHERE u.deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM user_statements s WHERE s.user_id = u.id AND s.period = $1)
+		ORDER BY u.id ASC
+		LIMIT $5
+	`
+	rows, err := r.sql.QueryContext(ctx, query, period, start, end, afterUserID, limit, service.RedeemTypeBalance, service.AdjustmentTypeAdminBalance)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+			out = nil
+		}
+	}()
+
+	out = make([]service.UserStatementRecipient, 0)
+	for rows.Next() {
+		var rcpt service.UserStatementRecipient
+		if err = rows.Scan(&rcpt.UserID, &rcpt.Email, &rcpt.Username); err != nil {
+			return nil, err
+		}
+		out = append(out, rcpt)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *userStatementRepository) ListUsageLines(ctx context.Context, userID int64, start, end time.Time) (out []service.UserStatementUsageLine, err error) {
+	query := `
+		SELECT
+			b.group_id,
+			COALESCE(g.name, ''),
+			b.model,
+			b.api_key_id,
+			COALESCE(k.name, ''),
+			COALESCE(SUM(b.total_requests), 0),
+			COALESCE(SUM(b.input_tokens), 0),
+			COALESCE(SUM(b.output_tokens), 0),
+			COALESCE(SUM(b.cache_creation_tokens), 0),
+			COALESCE(SUM(b.cache_read_tokens), 0),
+			COALESCE(SUM(b.total_cost), 0),
+			COALESCE(SUM(b.actual_cost), 0)
+		FROM usage_dashboard_hourly_user_breakdown b
+		LEFT JOIN groups g ON g.id = b.group_id
+		LEFT JOIN api_keys k ON k.id = b.api_key_id
+		WHERE b.user_id = $1 AND b.bucket_start >= $2 AND b.bucket_start < $3
+		GROUP BY b.group_id, g.name, b.model, b.api_key_id, k.name
+		ORDER BY b.group_id, b.model, b.api_key_id
+	`
+	rows, err := r.sql.QueryContext(ctx, query, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+			out = nil
+		}
+	}()
+
+	out = make([]service.UserStatementUsageLine, 0)
+	for rows.Next() {
+		var l service.UserStatementUsageLine
+		if err = rows.Scan(
+			&l.GroupID,
+			&l.GroupName,
+			&l.Model,
+			&l.APIKeyID,
+			&l.APIKeyName,
+			&l.Requests,
+			&l.InputTokens,
+			&l.OutputTokens,
+			&l.CacheCreationTokens,
+			&l.CacheReadTokens,
+			&l.TotalCost,
+			&l.ActualCost,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *userStatementRepository) ListCredits(ctx context.Context, userID int64, start, end time.Time) (out []service.UserStatementCredit, err error) {
+	query := `
+		SELECT source, reference, amount, occurred_at FROM (
+			SELECT
+				CASE WHEN type = $4 THEN $6::text ELSE $5::text END AS source,
+				CASE WHEN type = $4 THEN '' ELSE code END AS reference,
+				value AS amount,
+				used_at AS occurred_at
+			FROM redeem_codes
+			WHERE used_by = $1 AND used_at >= $2 AND used_at < $3 AND type IN ($4, $9)
+			UNION ALL
+			SELECT $7::text, pc.code, pu.bonus_amount, pu.used_at
+			FROM promo_code_usages pu
+			JOIN promo_codes pc ON pc.id = pu.promo_code_id
+			WHERE pu.user_id = $1 AND pu.used_at >= $2 AND pu.used_at < $3
+			UNION ALL
+			SELECT $8::text, order_no, credit_amount, paid_at
+			FROM payment_orders
+			WHERE user_id = $1 AND paid_at >= $2 AND paid_at < $3 AND order_type = $10
+		) credits
+		ORDER BY occurred_at ASC
+	`
+	rows, err := r.sql.QueryContext(ctx, query, userID, start, end,
+		service.AdjustmentTypeAdminBalance,
+		service.UserStatementCreditRedeem,
+		service.UserStatementCreditAdjustment,
+		service.UserStatementCreditPromo,
+		service.UserStatementCreditPayment,
+		service.RedeemTypeBalance,
+		service.PaymentOrderTypeBalance,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+			out = nil
+		}
+	}()
+
+	out = make([]service.UserStatementCredit, 0)
+	for rows.Next() {
+		var c service.UserStatementCredit
+		if err = rows.Scan(&c.Source, &c.Reference, &c.Amount, &c.OccurredAt); err != nil {
+			return nil, err
+		}
+		c.OccurredAt = c.OccurredAt.In(start.Location())
+		out = append(out, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *userStatementRepository) ListSubscriptionCharges(ctx context.Context, userID int64, start, end time.Time) (out []service.UserStatementCharge, err error) {
+	query := `
+		SELECT source, description, amount, currency, occurred_at FROM (
+			SELECT
+				$4::text AS source,
+				p.name || ' (' || o.action || ')' AS description,
+				o.amount AS amount,
+				'USD' AS currency,
+				o.created_at AS occurred_at
+			FROM subscription_plan_orders o
+			JOIN subscription_plans p ON p.id = o.plan_id
+			WHERE o.user_id = $1 AND o.created_at >= $2 AND o.created_at < $3
+			UNION ALL
+			SELECT
+				$5::text,
+				COALESCE(g.name, '') || ' (' || po.validity_days || ' days)',
+				po.amount,
+				po.currency,
+				po.paid_at
+			FROM payment_orders po
+			LEFT JOIN groups g ON g.id = po.group_id
+			WHERE po.user_id = $1 AND po.paid_at >= $2 AND po.paid_at < $3 AND po.order_type = $6
+		) charges
+		ORDER BY occurred_at ASC
+	`
+	rows, err := r.sql.QueryContext(ctx, query, userID, start, end,
+		service.UserStatementChargePlan,
+		service.UserStatementChargePayment,
+		service.PaymentOrderTypeSubscription,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+			out = nil
+		}
+	}()
+
+	out = make([]service.UserStatementCharge, 0)
+	for rows.Next() {
+		var c service.UserStatementCharge
+		if err = rows.Scan(&c.Source, &c.Description, &c.Amount, &c.Currency, &c.OccurredAt); err != nil {
+			return nil, err
+		}
+		c.OccurredAt = c.OccurredAt.In(start.Location())
+		out = append(out, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *userStatementRepository) Create(ctx context.Context, st *service.UserStatement) (bool, error) {
+	if st == nil {
+		return false, nil
+	}
+	summaryJSON, err := json.Marshal(st.Summary)
+	if err != nil {
+		return false, fmt.Errorf("marshal statement summary: %w", err)
+	}
+	err = scanSingleRow(ctx, r.sql, `
+		INSERT INTO user_statements (
+			user_id, period, period_start, period_end, currency,
+			total_requests, total_tokens, usage_cost, credits_total, subscription_charges_total,
+			summary, html, pdf, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NOW())
+		ON CONFLICT (user_id, period) DO NOTHING
+		RETURNING id, created_at
+	`, []any{
+		st.UserID, st.Period, st.PeriodStart, st.PeriodEnd, st.Currency,
+		st.TotalRequests, st.TotalTokens, st.UsageCost, st.CreditsTotal, st.SubscriptionChargesTotal,
+		summaryJSON, st.HTML, st.PDF,
+	}, &st.ID, &st.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+const userStatementListColumns = `
+	id, user_id, period, period_start, period_end, currency,
+	total_requests, total_tokens, usage_cost, credits_total, subscription_charges_total,
+	emailed_at, created_at
+`
+
+func (r *userStatementRepository) ListByUser(ctx context.Context, userID int64, params pagination.PaginationParams) (out []service.UserStatement, _ *pagination.PaginationResult, err error) {
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM user_statements WHERE user_id = $1", []any{userID}, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.UserStatement{}, paginationResultFromTotal(0, params), nil
+	}
+
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+userStatementListColumns+`
+		FROM user_statements
+		WHERE user_id = $1
+		ORDER BY period DESC
+		LIMIT $2 OFFSET $3`, userID, params.Limit(), params.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+			out = nil
+		}
+	}()
+
+	out = make([]service.UserStatement, 0)
+	for rows.Next() {
+		var st service.UserStatement
+		var emailedAt sql.NullTime
+		if err = rows.Scan(
+			&st.ID, &st.UserID, &st.Period, &st.PeriodStart, &st.PeriodEnd, &st.Currency,
+			&st.TotalRequests, &st.TotalTokens, &st.UsageCost, &st.CreditsTotal, &st.SubscriptionChargesTotal,
+			&emailedAt, &st.CreatedAt,
+		); err != nil {
+			return nil, nil, err
+		}
+		st.EmailedAt = nullTimePtr(emailedAt)
+		out = append(out, st)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
+
+func (r *userStatementRepository) GetByID(ctx context.Context, id int64) (*service.UserStatement, error) {
+	var st service.UserStatement
+	var summaryJSON []byte
+	var emailedAt sql.NullTime
+	err := scanSingleRow(ctx, r.sql, "SELECT "+userStatementListColumns+`, summary, html, pdf
+		FROM user_statements
+		WHERE id = $1`, []any{id},
+		&st.ID, &st.UserID, &st.Period, &st.PeriodStart, &st.PeriodEnd, &st.Currency,
+		&st.TotalRequests, &st.TotalTokens, &st.UsageCost, &st.CreditsTotal, &st.SubscriptionChargesTotal,
+		&emailedAt, &st.CreatedAt, &summaryJSON, &st.HTML, &st.PDF,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, service.ErrUserStatementNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(summaryJSON, &st.Summary); err != nil {
+		return nil, fmt.Errorf("parse statement summary: %w", err)
+	}
+	st.EmailedAt = nullTimePtr(emailedAt)
+	return &st, nil
+}
+
+func (r *userStatementRepository) ClaimPendingEmails(ctx context.Context, limit, maxAttempts int, claimTTL time.Duration) (out []service.UserStatement, err error) {
+	query := `
+		WITH next AS (
+			SELECT s.id
+			FROM user_statements s
+			JOIN users u ON u.id = s.user_id
+			LEFT JOIN user_notification_settings ns ON ns.user_id = s.user_id
+			WHERE s.emailed_at IS NULL
+				AND s.email_attempts < $2
+				AND (s.email_claimed_at IS NULL OR s.email_claimed_at < NOW() - ($3 * interval '1 second'))
+				AND u.deleted_at IS NULL
+				AND u.email <> ''
+				AND COALESCE(ns.email_enabled, TRUE)
+			ORDER BY s.id ASC
+			LIMIT $1
+			FOR UPDATE OF s SKIP LOCKED
+		)
+		UPDATE user_statements AS st
+		SET email_attempts = st.email_attempts + 1,
+			email_claimed_at = NOW()
+		FROM next, users u
+		WHERE st.id = next.id AND u.id = st.user_id
+		RETURNING st.id, st.user_id, st.period, st.summary, st.pdf, u.email, u.username
+	`
+	rows, err := r.sql.QueryContext(ctx, query, limit, maxAttempts, int64(claimTTL.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+			out = nil
+		}
+	}()
+
+	out = make([]service.UserStatement, 0)
+	for rows.Next() {
+		var st service.UserStatement
+		var summaryJSON []byte
+		rcpt := &service.UserStatementRecipient{}
+		if err = rows.Scan(&st.ID, &st.UserID, &st.Period, &summaryJSON, &st.PDF, &rcpt.Email, &rcpt.Username); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(summaryJSON, &st.Summary); err != nil {
+			return nil, fmt.Errorf("parse statement summary: %w", err)
+		}
+		rcpt.UserID = st.UserID
+		st.Recipient = rcpt
+		out = append(out, st)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *userStatementRepository) MarkEmailed(ctx context.Context, id int64) error {
+	_, err := r.sql.ExecContext(ctx, "UPDATE user_statements SET emailed_at = NOW() WHERE id = $1 AND emailed_at IS NULL", id)
+	return err
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserStatementRepositoryCreateReturnsFalseOnConflict(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUserStatementRepositoryWithSQL(db)
+
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`INSERT INTO user_statements .* ON CONFLICT \(user_id, period\) DO NOTHING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+
+	created, err := repo.Create(context.Background(), &service.UserStatement{
+		UserID:      7,
+		Period:      "2026-09",
+		PeriodStart: start,
+		PeriodEnd:   start.AddDate(0, 1, 0),
+		Currency:    "USD",
+	})
+	require.NoError(t, err)
+	require.False(t, created)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserStatementRepositoryGetByIDNotFound(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUserStatementRepositoryWithSQL(db)
+
+	mock.ExpectQuery("FROM user_statements\\s+WHERE id = \\$1").
+		WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := repo.GetByID(context.Background(), 5)
+	require.ErrorIs(t, err, service.ErrUserStatementNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserStatementRepositoryListCandidatesSkipsExistingStatements(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUserStatementRepositoryWithSQL(db)
+
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	mock.ExpectQuery(`FROM usage_dashboard_hourly_user_breakdown.*NOT EXISTS \(SELECT 1 FROM user_statements`).
+		WithArgs("2026-09", start, end, int64(10), 50, service.RedeemTypeBalance, service.AdjustmentTypeAdminBalance).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "username"}).AddRow(int64(11), "a@example.com", "alice"))
+
+	out, err := repo.ListCandidates(context.Background(), "2026-09", start, end, 10, 50)
+	require.NoError(t, err)
+	require.Equal(t, []service.UserStatementRecipient{{UserID: 11, Email: "a@example.com", Username: "alice"}}, out)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserStatementRepositoryClaimPendingEmailsParsesRecipient(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUserStatementRepositoryWithSQL(db)
+
+	mock.ExpectQuery(`UPDATE user_statements AS st\s+SET email_attempts = st.email_attempts \+ 1`).
+		WithArgs(20, 3, int64(900)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "period", "summary", "pdf", "email", "username"}).
+			AddRow(int64(1), int64(7), "2026-09", []byte(`{"site_name":"Acme","usage_cost":1.5}`), []byte("%PDF"), "u@example.com", "u"))
+
+	out, err := repo.ClaimPendingEmails(context.Background(), 20, 3, 15*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+	require.Equal(t, "Acme", out[0].Summary.SiteName)
+	require.InDelta(t, 1.5, out[0].Summary.UsageCost, 1e-9)
+	require.Equal(t, "u@example.com", out[0].Recipient.Email)
+	require.EqualValues(t, 7, out[0].Recipient.UserID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -34,7 +34,14 @@ func (r *userSubscriptionRepository) Create(ctx context.Context, sub *service.Us
 		SetDailyUsageUsd(sub.DailyUsageUSD).
 		SetWeeklyUsageUsd(sub.WeeklyUsageUSD).
 		SetMonthlyUsageUsd(sub.MonthlyUsageUSD).
-		SetNillableAssignedBy(sub.AssignedBy)
+		SetNillableAssignedBy(sub.AssignedBy).
+		SetNillablePlanID(sub.PlanID).
+		SetNillableNextPlanID(sub.NextPlanID).
+		SetAutoRenew(sub.AutoRenew).
+		SetNillableRenewalFailedAt(sub.RenewalFailedAt).
+		SetNillableDailyLimitUsd(sub.DailyLimitUSD).
+		SetNillableWeeklyLimitUsd(sub.WeeklyLimitUSD).
+		SetNillableMonthlyLimitUsd(sub.MonthlyLimitUSD)
 
 	if sub.StartsAt.IsZero() {
 		builder.SetStartsAt(time.Now())
@@ -71,6 +78,19 @@ func (r *userSubscriptionRepository) GetByID(ctx context.Context, id int64) (*se
 	return userSubscriptionEntityToService(m), nil
 }
 
+func (r *userSubscriptionRepository) GetByIDForUpdate(ctx context.Context, id int64) (*service.UserSubscription, error) {
+	client := clientFromContext(ctx, r.client)
+	m, err := client.UserSubscription.Query().
+		Where(usersubscription.IDEQ(id)).
+		WithGroup().
+		ForUpdate().
+		Only(ctx)
+	if err != nil {
+		return nil, translatePersistenceError(err, service.ErrSubscriptionNotFound, nil)
+	}
+	return userSubscriptionEntityToService(m), nil
+}
+
 func (r *userSubscriptionRepository) GetByUserIDAndGroupID(ctx context.Context, userID, groupID int64) (*service.UserSubscription, error) {
 	client := clientFromContext(ctx, r.client)
 	m, err := client.UserSubscription.Query().
@@ -121,6 +141,12 @@ func (r *userSubscriptionRepository) Update(ctx context.Context, sub *service.Us
 		SetNillableAssignedBy(sub.AssignedBy).
 		SetAssignedAt(sub.AssignedAt).
 		SetNotes(sub.Notes)
+	if sub.RenewalFailedAt != nil {
+		builder.SetRenewalFailedAt(*sub.RenewalFailedAt)
+	} else {
+		builder.ClearRenewalFailedAt()
+	}
+	applyPlanBindingUpdate(builder, sub.PlanBinding())
 
 	updated, err := builder.Save(ctx)
 	if err == nil {
@@ -254,6 +280,51 @@ func (r *userSubscriptionRepository) UpdateNotes(ctx context.Context, subscripti
 	return translatePersistenceError(err, service.ErrSubscriptionNotFound, nil)
 }
 
+func (r *userSubscriptionRepository) UpdatePlanBinding(ctx context.Context, subscriptionID int64, binding *service.SubscriptionPlanBinding) error {
+	if binding == nil {
+		return service.ErrSubscriptionNilInput
+	}
+	client := clientFromContext(ctx, r.client)
+	builder := client.UserSubscription.UpdateOneID(subscriptionID)
+	applyPlanBindingUpdate(builder, *binding)
+	_, err := builder.Save(ctx)
+	return translatePersistenceError(err, service.ErrSubscriptionNotFound, nil)
+}
+
+func (r *userSubscriptionRepository) SetRenewalFailedAt(ctx context.Context, subscriptionID int64, failedAt *time.Time) error {
+	client := clientFromContext(ctx, r.client)
+	builder := client.UserSubscription.UpdateOneID(subscriptionID)
+	if failedAt != nil {
+		builder.SetRenewalFailedAt(*failedAt)
+	} else {
+		builder.ClearRenewalFailedAt()
+	}
+	_, err := builder.Save(ctx)
+	return translatePersistenceError(err, service.ErrSubscriptionNotFound, nil)
+}
+
+// ListAutoRenewDue 查询开启自动续费且将在 dueBefore 之前到期（尚未到期）的有效订阅
+func (r *userSubscriptionRepository) ListAutoRenewDue(ctx context.Context, dueBefore time.Time, limit int) ([]service.UserSubscription, error) {
+	client := clientFromContext(ctx, r.client)
+	q := client.UserSubscription.Query().
+		Where(
+			usersubscription.AutoRenewEQ(true),
+			usersubscription.PlanIDNotNil(),
+			usersubscription.StatusEQ(service.SubscriptionStatusActive),
+			usersubscription.ExpiresAtGT(time.Now()),
+			usersubscription.ExpiresAtLTE(dueBefore),
+		).
+		Order(dbent.Asc(usersubscription.FieldExpiresAt), dbent.Asc(usersubscription.FieldID))
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	subs, err := q.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return userSubscriptionEntitiesToService(subs), nil
+}
+
 func (r *userSubscriptionRepository) ActivateWindows(ctx context.Context, id int64, start time.Time) error {
 	client := clientFromContext(ctx, r.client)
 	_, err := client.UserSubscription.UpdateOneID(id).
@@ -400,6 +471,13 @@ func userSubscriptionEntityToService(m *dbent.UserSubscription) *service.UserSub
 		AssignedBy:         m.AssignedBy,
 		AssignedAt:         m.AssignedAt,
 		Notes:              derefString(m.Notes),
+		PlanID:             m.PlanID,
+		NextPlanID:         m.NextPlanID,
+		AutoRenew:          m.AutoRenew,
+		RenewalFailedAt:    m.RenewalFailedAt,
+		DailyLimitUSD:      m.DailyLimitUsd,
+		WeeklyLimitUSD:     m.WeeklyLimitUsd,
+		MonthlyLimitUSD:    m.MonthlyLimitUsd,
 		CreatedAt:          m.CreatedAt,
 		UpdatedAt:          m.UpdatedAt,
 	}
@@ -433,3 +511,33 @@ func applyUserSubscriptionEntityToService(dst *service.UserSubscription, src *db
 	dst.CreatedAt = src.CreatedAt
 	dst.UpdatedAt = src.UpdatedAt
 }
+
+// applyPlanBindingUpdate 写入套餐绑定字段（nil 值清空对应列）
+func applyPlanBindingUpdate(builder *dbent.UserSubscriptionUpdateOne, binding service.SubscriptionPlanBinding) {
+	builder.SetAutoRenew(binding.AutoRenew)
+	if binding.PlanID != nil {
+		builder.SetPlanID(*binding.PlanID)
+	} else {
+		builder.ClearPlanID()
+	}
+	if binding.NextPlanID != nil {
+		builder.SetNextPlanID(*binding.NextPlanID)
+	} else {
+		builder.ClearNextPlanID()
+	}
+	if binding.DailyLimitUSD != nil {
+		builder.SetDailyLimitUsd(*binding.DailyLimitUSD)
+	} else {
+		builder.ClearDailyLimitUsd()
+	}
+	if binding.WeeklyLimitUSD != nil {
+		builder.SetWeeklyLimitUsd(*binding.WeeklyLimitUSD)
+	} else {
+		builder.ClearWeeklyLimitUsd()
+	}
+	if binding.MonthlyLimitUSD != nil {
+		builder.SetMonthlyLimitUsd(*binding.MonthlyLimitUSD)
+	} else {
+		builder.ClearMonthlyLimitUsd()
+	}
+}
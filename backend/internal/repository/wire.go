@@ -7,6 +7,7 @@ import (
 	entsql "entgo.io/ent/dialect/sql"
 	"github.com/Wei-Shaw/sub2api/ent"
 	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/secretbox"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/google/wire"
 	"github.com/redis/go-redis/v9"
@@ -47,6 +48,24 @@ func ProvideSessionLimitCache(rdb *redis.Client, cfg *config.Config) service.Ses
 	return NewSessionLimitCache(rdb, defaultIdleTimeoutMinutes)
 }
 
+// ProvidePaymentProviders 按配置构建已启用的支付渠道
+func ProvidePaymentProviders(cfg *config.Config) service.PaymentProviders {
+	if cfg == nil || !cfg.Payment.Enabled {
+		return nil
+	}
+	var providers service.PaymentProviders
+	if cfg.Payment.Stripe.Enabled {
+		providers = append(providers, newStripePaymentProvider(cfg.Payment.Stripe))
+	}
+	if cfg.Payment.Signed.Enabled {
+		providers = append(providers, newSignedPaymentProvider(cfg.Payment.Signed))
+	}
+	if cfg.Payment.Fake.Enabled {
+		providers = append(providers, service.NewFakePaymentProvider())
+	}
+	return providers
+}
+
 // ProviderSet is the Wire provider set for all repositories
 var ProviderSet = wire.NewSet(
 	NewUserRepository,
@@ -58,16 +77,36 @@ var ProviderSet = wire.NewSet(
 	NewPromoCodeRepository,
 	NewUsageLogRepository,
 	NewUsageCleanupRepository,
+	NewUsageExportRepository,
 	NewDashboardAggregationRepository,
 	NewSettingRepository,
 	NewOpsRepository,
 	NewUserSubscriptionRepository,
 	NewUserAttributeDefinitionRepository,
 	NewUserAttributeValueRepository,
+	NewPaymentOrderRepository,
+	NewSubscriptionPlanRepository,
+	NewNotificationRepository,
+	NewUserStatementRepository,
+	NewOrganizationRepository,
+	NewResellerRepository,
+	NewModelPriceOverrideRepository,
+	NewRateMultiplierRuleRepository,
+	NewUsageTagRepository,
+	NewUsageAnomalyRepository,
+	NewSecretReencryptionRepository,
+	NewGeminiResourceRepository,
+	NewAnthropicFileRepository,
+	NewStickySessionRepository,
+	NewGuardrailRepository,
+	NewGuardrailHookClient,
+	NewPromptTemplateRepository,
+	NewBillingOutboxRepository,
 
 	// Cache implementations
 	NewGatewayCache,
 	NewBillingCache,
+	NewCostHoldCache,
 	NewAPIKeyCache,
 	NewTempUnschedCache,
 	NewTimeoutCounterCache,
@@ -82,6 +121,7 @@ var ProviderSet = wire.NewSet(
 	NewSchedulerCache,
 	NewSchedulerOutboxRepository,
 	NewProxyLatencyCache,
+	NewStickyStandbyCache,
 
 	// HTTP service ports (DI Strategy A: return interface directly)
 	NewTurnstileVerifier,
@@ -91,13 +131,17 @@ var ProviderSet = wire.NewSet(
 	NewClaudeUsageFetcher,
 	NewClaudeOAuthClient,
 	NewHTTPUpstream,
+	NewOpenAIRealtimeDialer,
 	NewOpenAIOAuthClient,
 	NewGeminiOAuthClient,
 	NewGeminiCliCodeAssistClient,
+	ProvidePaymentProviders,
+	NewNotificationWebhookSender,
 
 	ProvideEnt,
 	ProvideSQLDB,
 	ProvideRedis,
+	ProvideSecretKeyring,
 )
 
 // ProvideEnt 为依赖注入提供 Ent 客户端。
@@ -112,6 +156,18 @@ func ProvideEnt(cfg *config.Config) (*ent.Client, error) {
 	return client, err
 }
 
+// ProvideSecretKeyring 按 security.secret_encryption 加载敏感字段加密密钥环。
+//
+// 密钥环注入到读写账号凭证、代理密码、敏感设置的仓储以及重加密服务；
+// 未配置主密钥时返回 nil，此时数据以明文读写。
+//
+// 依赖：config.Config
+// 提供：*secretbox.Keyring
+func ProvideSecretKeyring(cfg *config.Config) (*secretbox.Keyring, error) {
+	secretCfg := cfg.Security.SecretEncryption
+	return secretbox.LoadKeyring(secretCfg.Keys, secretCfg.KeyFile, secretCfg.ActiveKeyID, secretCfg.Enabled)
+}
+
 // ProvideSQLDB 从 Ent 客户端提取底层的 *sql.DB 连接。
 //
 // 某些 Repository 需要直接执行原生 SQL（如复杂的批量更新、聚合查询），
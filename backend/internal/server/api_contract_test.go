@@ -326,7 +326,11 @@ func TestAPIContracts(t *testing.T) {
 					"total_tokens": 53,
 					"total_cost": 0.75,
 					"total_actual_cost": 0.75,
-					"average_duration_ms": 200
+					"average_duration_ms": 200,
+					"total_cache_creation_5m_tokens": 1,
+					"total_cache_creation_1h_tokens": 0,
+					"total_cache_creation_5m_cost": 0,
+					"total_cache_creation_1h_cost": 0
 				}
 			}`,
 		},
@@ -388,6 +392,8 @@ func TestAPIContracts(t *testing.T) {
 						"total_cost": 0.5,
 						"actual_cost": 0.5,
 						"rate_multiplier": 1,
+						"cache_creation_5m_cost": 0,
+						"cache_creation_1h_cost": 0,
 						"billing_type": 0,
 							"stream": true,
 							"duration_ms": 100,
@@ -597,7 +603,7 @@ func newContractDeps(t *testing.T) *contractDeps {
 	adminService := service.NewAdminService(userRepo, groupRepo, &accountRepo, proxyRepo, apiKeyRepo, redeemRepo, nil, nil, nil, nil)
 	authHandler := handler.NewAuthHandler(cfg, nil, userService, settingService, nil)
 	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
-	usageHandler := handler.NewUsageHandler(usageService, apiKeyService)
+	usageHandler := handler.NewUsageHandler(usageService, apiKeyService, nil)
 	adminSettingHandler := adminhandler.NewSettingHandler(settingService, nil, nil, nil)
 	adminAccountHandler := adminhandler.NewAccountHandler(adminService, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
@@ -1149,6 +1155,9 @@ func (stubUserSubscriptionRepo) Create(ctx context.Context, sub *service.UserSub
 func (stubUserSubscriptionRepo) GetByID(ctx context.Context, id int64) (*service.UserSubscription, error) {
 	return nil, errors.New("not implemented")
 }
+func (stubUserSubscriptionRepo) GetByIDForUpdate(ctx context.Context, id int64) (*service.UserSubscription, error) {
+	return nil, errors.New("not implemented")
+}
 func (stubUserSubscriptionRepo) GetByUserIDAndGroupID(ctx context.Context, userID, groupID int64) (*service.UserSubscription, error) {
 	return nil, errors.New("not implemented")
 }
@@ -1206,6 +1215,15 @@ func (stubUserSubscriptionRepo) ResetMonthlyUsage(ctx context.Context, id int64,
 func (stubUserSubscriptionRepo) IncrementUsage(ctx context.Context, id int64, costUSD float64) error {
 	return errors.New("not implemented")
 }
+func (stubUserSubscriptionRepo) UpdatePlanBinding(ctx context.Context, subscriptionID int64, binding *service.SubscriptionPlanBinding) error {
+	return errors.New("not implemented")
+}
+func (stubUserSubscriptionRepo) SetRenewalFailedAt(ctx context.Context, subscriptionID int64, failedAt *time.Time) error {
+	return errors.New("not implemented")
+}
+func (stubUserSubscriptionRepo) ListAutoRenewDue(ctx context.Context, dueBefore time.Time, limit int) ([]service.UserSubscription, error) {
+	return nil, errors.New("not implemented")
+}
 func (stubUserSubscriptionRepo) BatchUpdateExpiredStatus(ctx context.Context) (int64, error) {
 	return 0, errors.New("not implemented")
 }
@@ -1502,6 +1520,10 @@ func (r *stubUsageLogRepo) GetUserStatsAggregated(ctx context.Context, userID in
 	var totalInputTokens int64
 	var totalOutputTokens int64
 	var totalCacheTokens int64
+	var totalCache5mTokens int64
+	var totalCache1hTokens int64
+	var totalCache5mCost float64
+	var totalCache1hCost float64
 	var totalCost float64
 	var totalActualCost float64
 	var totalDuration int64
@@ -1512,6 +1534,10 @@ func (r *stubUsageLogRepo) GetUserStatsAggregated(ctx context.Context, userID in
 		totalInputTokens += int64(log.InputTokens)
 		totalOutputTokens += int64(log.OutputTokens)
 		totalCacheTokens += int64(log.CacheCreationTokens + log.CacheReadTokens)
+		totalCache5mTokens += int64(log.CacheCreationTokens - log.CacheCreation1hTokens)
+		totalCache1hTokens += int64(log.CacheCreation1hTokens)
+		totalCache5mCost += log.CacheCreationCost - log.CacheCreation1hCost
+		totalCache1hCost += log.CacheCreation1hCost
 		totalCost += log.TotalCost
 		totalActualCost += log.ActualCost
 		if log.DurationMs != nil {
@@ -1526,14 +1552,18 @@ func (r *stubUsageLogRepo) GetUserStatsAggregated(ctx context.Context, userID in
 	}
 
 	return &usagestats.UsageStats{
-		TotalRequests:     totalRequests,
-		TotalInputTokens:  totalInputTokens,
-		TotalOutputTokens: totalOutputTokens,
-		TotalCacheTokens:  totalCacheTokens,
-		TotalTokens:       totalInputTokens + totalOutputTokens + totalCacheTokens,
-		TotalCost:         totalCost,
-		TotalActualCost:   totalActualCost,
-		AverageDurationMs: avgDuration,
+		TotalRequests:              totalRequests,
+		TotalInputTokens:           totalInputTokens,
+		TotalOutputTokens:          totalOutputTokens,
+		TotalCacheTokens:           totalCacheTokens,
+		TotalTokens:                totalInputTokens + totalOutputTokens + totalCacheTokens,
+		TotalCost:                  totalCost,
+		TotalActualCost:            totalActualCost,
+		TotalCacheCreation5mTokens: totalCache5mTokens,
+		TotalCacheCreation1hTokens: totalCache1hTokens,
+		TotalCacheCreation5mCost:   totalCache5mCost,
+		TotalCacheCreation1hCost:   totalCache1hCost,
+		AverageDurationMs:          avgDuration,
 	}, nil
 }
 
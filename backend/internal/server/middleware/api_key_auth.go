@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log"
+	"net/http"
 	"strings"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
@@ -51,6 +52,11 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 			apiKeyString = c.GetHeader("x-goog-api-key")
 		}
 
+		// 浏览器 WebSocket 无法设置请求头：兼容 OpenAI Realtime 的子协议传参方式
+		if apiKeyString == "" && websocketUpgradeRequested(c.Request) {
+			apiKeyString = apiKeyFromWebSocketProtocol(c.Request)
+		}
+
 		// 如果所有header都没有API key
 		if apiKeyString == "" {
 			AbortWithError(c, 401, "API_KEY_REQUIRED", "API key is required in Authorization header (Bearer scheme), x-api-key header, or x-goog-api-key header")
@@ -97,6 +103,17 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 			return
 		}
 
+		// 组织 Key：校验组织状态与成员资格，余额/订阅由组织付费账户承担
+		if err := apiKey.CheckOrganizationAccess(); err != nil {
+			if errors.Is(err, service.ErrOrganizationMembershipRequired) {
+				AbortWithError(c, 403, "ORGANIZATION_MEMBERSHIP_REQUIRED", "API key owner is no longer a member of the organization")
+				return
+			}
+			AbortWithError(c, 403, "ORGANIZATION_DISABLED", "Organization is disabled")
+			return
+		}
+		payer := apiKey.Payer()
+
 		if cfg.RunMode == config.RunModeSimple {
 			// 简易模式：跳过余额和订阅检查，但仍需设置必要的上下文
 			c.Set(string(ContextKeyAPIKey), apiKey)
@@ -117,7 +134,7 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 			// 订阅模式：验证订阅
 			subscription, err := subscriptionService.GetActiveSubscription(
 				c.Request.Context(),
-				apiKey.User.ID,
+				payer.ID,
 				apiKey.Group.ID,
 			)
 			if err != nil {
@@ -150,8 +167,8 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 			// 将订阅信息存入上下文
 			c.Set(string(ContextKeySubscription), subscription)
 		} else {
-			// 余额模式：检查用户余额
-			if apiKey.User.Balance <= 0 {
+			// 余额模式：检查付费方余额
+			if payer.Balance <= 0 {
 				AbortWithError(c, 403, "INSUFFICIENT_BALANCE", "Insufficient account balance")
 				return
 			}
@@ -200,3 +217,23 @@ func setGroupContext(c *gin.Context, group *service.Group) {
 	ctx := context.WithValue(c.Request.Context(), ctxkey.Group, group)
 	c.Request = c.Request.WithContext(ctx)
 }
+
+// realtimeAPIKeyProtocolPrefix OpenAI Realtime 浏览器客户端在 Sec-WebSocket-Protocol 中携带密钥的前缀
+const realtimeAPIKeyProtocolPrefix = "openai-insecure-api-key."
+
+func websocketUpgradeRequested(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// apiKeyFromWebSocketProtocol 从 Sec-WebSocket-Protocol 中提取 openai-insecure-api-key.<key>
+func apiKeyFromWebSocketProtocol(r *http.Request) string {
+	for _, header := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(header, ",") {
+			protocol = strings.TrimSpace(protocol)
+			if strings.HasPrefix(protocol, realtimeAPIKeyProtocolPrefix) {
+				return strings.TrimPrefix(protocol, realtimeAPIKeyProtocolPrefix)
+			}
+		}
+	}
+	return ""
+}
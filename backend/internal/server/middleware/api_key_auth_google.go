@@ -54,6 +54,15 @@ func APIKeyAuthWithSubscriptionGoogle(apiKeyService *service.APIKeyService, subs
 			abortWithGoogleError(c, 401, "User account is not active")
 			return
 		}
+		if err := apiKey.CheckOrganizationAccess(); err != nil {
+			if errors.Is(err, service.ErrOrganizationMembershipRequired) {
+				abortWithGoogleError(c, 403, "API key owner is no longer a member of the organization")
+				return
+			}
+			abortWithGoogleError(c, 403, "Organization is disabled")
+			return
+		}
+		payer := apiKey.Payer()
 
 		// 简易模式：跳过余额和订阅检查
 		if cfg.RunMode == config.RunModeSimple {
@@ -72,7 +81,7 @@ func APIKeyAuthWithSubscriptionGoogle(apiKeyService *service.APIKeyService, subs
 		if isSubscriptionType && subscriptionService != nil {
 			subscription, err := subscriptionService.GetActiveSubscription(
 				c.Request.Context(),
-				apiKey.User.ID,
+				payer.ID,
 				apiKey.Group.ID,
 			)
 			if err != nil {
@@ -91,7 +100,7 @@ func APIKeyAuthWithSubscriptionGoogle(apiKeyService *service.APIKeyService, subs
 			}
 			c.Set(string(ContextKeySubscription), subscription)
 		} else {
-			if apiKey.User.Balance <= 0 {
+			if payer.Balance <= 0 {
 				abortWithGoogleError(c, 403, "Insufficient account balance")
 				return
 			}
@@ -336,6 +336,10 @@ func (r *stubUserSubscriptionRepo) GetByID(ctx context.Context, id int64) (*serv
 	return nil, errors.New("not implemented")
 }
 
+func (r *stubUserSubscriptionRepo) GetByIDForUpdate(ctx context.Context, id int64) (*service.UserSubscription, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (r *stubUserSubscriptionRepo) GetByUserIDAndGroupID(ctx context.Context, userID, groupID int64) (*service.UserSubscription, error) {
 	return nil, errors.New("not implemented")
 }
@@ -422,6 +426,31 @@ func (r *stubUserSubscriptionRepo) IncrementUsage(ctx context.Context, id int64,
 	return errors.New("not implemented")
 }
 
+func (r *stubUserSubscriptionRepo) UpdatePlanBinding(ctx context.Context, subscriptionID int64, binding *service.SubscriptionPlanBinding) error {
+	return errors.New("not implemented")
+}
+
+func (r *stubUserSubscriptionRepo) SetRenewalFailedAt(ctx context.Context, subscriptionID int64, failedAt *time.Time) error {
+	return errors.New("not implemented")
+}
+
+func (r *stubUserSubscriptionRepo) ListAutoRenewDue(ctx context.Context, dueBefore time.Time, limit int) ([]service.UserSubscription, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (r *stubUserSubscriptionRepo) BatchUpdateExpiredStatus(ctx context.Context) (int64, error) {
 	return 0, errors.New("not implemented")
 }
+
+func TestAPIKeyFromWebSocketProtocol(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/v1/realtime", nil)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Protocol", "realtime, openai-insecure-api-key.sk-test, openai-beta.realtime-v1")
+	require.True(t, websocketUpgradeRequested(req))
+	require.Equal(t, "sk-test", apiKeyFromWebSocketProtocol(req))
+
+	plain := httptest.NewRequest(http.MethodGet, "/v1/realtime", nil)
+	plain.Header.Set("Sec-WebSocket-Protocol", "realtime")
+	require.False(t, websocketUpgradeRequested(plain))
+	require.Empty(t, apiKeyFromWebSocketProtocol(plain))
+}
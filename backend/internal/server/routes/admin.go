@@ -47,6 +47,9 @@ func RegisterAdminRoutes(
 		// 优惠码管理
 		registerPromoCodeRoutes(admin, h)
 
+		// 支付订单管理
+		registerPaymentRoutes(admin, h)
+
 		// 系统设置
 		registerSettingsRoutes(admin, h)
 
@@ -59,6 +62,36 @@ func RegisterAdminRoutes(
 		// 订阅管理
 		registerSubscriptionRoutes(admin, h)
 
+		// 订阅套餐
+		registerSubscriptionPlanRoutes(admin, h)
+
+		// 组织管理
+		registerOrganizationRoutes(admin, h)
+
+		// 分销商管理
+		registerResellerRoutes(admin, h)
+
+		// 模型价格
+		registerPricingRoutes(admin, h)
+
+		// 客户级倍率（用户 / API Key 覆盖与阶梯折扣）
+		registerRateMultiplierRoutes(admin, h)
+
+		// 成本归属标签（标签键、按标签统计与标签预算）
+		registerUsageTagRoutes(admin, h)
+
+		// 计费发件箱（未扣费/死信报表）
+		registerBillingOutboxRoutes(admin, h)
+
+		// 内容策略（策略管理与命中记录）
+		registerGuardrailRoutes(admin, h)
+
+		// 系统提示词模板（全局 / 分组 / API Key）
+		registerPromptTemplateRoutes(admin, h)
+
+		// Files API 上传文件（按用户查看与清理）
+		registerAnthropicFileRoutes(admin, h)
+
 		// 使用记录管理
 		registerUsageRoutes(admin, h)
 
@@ -143,6 +176,18 @@ func registerOpsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		ops.GET("/dashboard/latency-histogram", h.Admin.Ops.GetDashboardLatencyHistogram)
 		ops.GET("/dashboard/error-trend", h.Admin.Ops.GetDashboardErrorTrend)
 		ops.GET("/dashboard/error-distribution", h.Admin.Ops.GetDashboardErrorDistribution)
+
+		// API key usage anomalies
+		ops.GET("/anomalies", h.Admin.UsageAnomaly.List)
+		ops.PUT("/anomalies/:id/status", h.Admin.UsageAnomaly.UpdateStatus)
+
+		// Sticky session cache warmth and failovers
+		ops.GET("/sticky-sessions", h.Admin.StickySession.ListSessions)
+		ops.GET("/sticky-sessions/accounts", h.Admin.StickySession.ListSessionAccounts)
+		ops.GET("/sticky-failovers", h.Admin.StickySession.ListFailovers)
+
+		// Request hedging
+		ops.GET("/hedging", h.Admin.RequestHedge.Stats)
 	}
 }
 
@@ -300,6 +345,16 @@ func registerPromoCodeRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 	}
 }
 
+func registerPaymentRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	payments := admin.Group("/payments")
+	{
+		payments.GET("/orders", h.Admin.Payment.List)
+		payments.GET("/orders/:id", h.Admin.Payment.GetByID)
+		payments.POST("/orders", h.Admin.Payment.Create)
+		payments.POST("/orders/:id/refund", h.Admin.Payment.Refund)
+	}
+}
+
 func registerSettingsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 	adminSettings := admin.Group("/settings")
 	{
@@ -325,6 +380,8 @@ func registerSystemRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		system.POST("/update", h.Admin.System.PerformUpdate)
 		system.POST("/rollback", h.Admin.System.Rollback)
 		system.POST("/restart", h.Admin.System.RestartService)
+		system.GET("/config", h.Admin.Config.GetEffective)
+		system.POST("/config/reload", h.Admin.Config.Reload)
 	}
 }
 
@@ -347,6 +404,112 @@ func registerSubscriptionRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 	admin.GET("/users/:id/subscriptions", h.Admin.Subscription.ListByUser)
 }
 
+func registerSubscriptionPlanRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	plans := admin.Group("/subscription-plans")
+	{
+		plans.GET("", h.Admin.SubscriptionPlan.List)
+		plans.GET("/:id", h.Admin.SubscriptionPlan.GetByID)
+		plans.POST("", h.Admin.SubscriptionPlan.Create)
+		plans.PUT("/:id", h.Admin.SubscriptionPlan.Update)
+		plans.DELETE("/:id", h.Admin.SubscriptionPlan.Delete)
+	}
+}
+
+func registerOrganizationRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	orgs := admin.Group("/organizations")
+	{
+		orgs.GET("", h.Admin.Organization.List)
+		orgs.GET("/:id", h.Admin.Organization.GetByID)
+		orgs.PUT("/:id/status", h.Admin.Organization.UpdateStatus)
+	}
+}
+
+func registerResellerRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	resellers := admin.Group("/resellers")
+	{
+		resellers.GET("", h.Admin.Reseller.List)
+		resellers.PUT("/:user_id", h.Admin.Reseller.Upsert)
+	}
+}
+
+func registerPricingRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	pricing := admin.Group("/pricing")
+	{
+		pricing.GET("/overrides", h.Admin.Pricing.ListOverrides)
+		pricing.POST("/overrides", h.Admin.Pricing.CreateOverride)
+		pricing.DELETE("/overrides/:id", h.Admin.Pricing.DeleteOverride)
+		pricing.GET("/unknown-policy", h.Admin.Pricing.GetUnknownPolicy)
+		pricing.PUT("/unknown-policy", h.Admin.Pricing.UpdateUnknownPolicy)
+		pricing.POST("/preview", h.Admin.Pricing.Preview)
+	}
+}
+
+func registerRateMultiplierRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	rates := admin.Group("/rate-multipliers")
+	{
+		rates.GET("/overrides", h.Admin.RateMultiplier.ListOverrides)
+		rates.POST("/overrides", h.Admin.RateMultiplier.SetOverride)
+		rates.DELETE("/overrides/:id", h.Admin.RateMultiplier.DeleteOverride)
+		rates.GET("/tiers", h.Admin.RateMultiplier.ListTiers)
+		rates.POST("/tiers", h.Admin.RateMultiplier.SetTier)
+		rates.DELETE("/tiers/:id", h.Admin.RateMultiplier.DeleteTier)
+		rates.POST("/preview", h.Admin.RateMultiplier.Preview)
+	}
+}
+
+func registerUsageTagRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	tags := admin.Group("/usage-tags")
+	{
+		tags.GET("/keys", h.Admin.UsageTag.ListKeys)
+		tags.POST("/keys", h.Admin.UsageTag.CreateKey)
+		tags.PUT("/keys/:id", h.Admin.UsageTag.UpdateKey)
+		tags.DELETE("/keys/:id", h.Admin.UsageTag.DeleteKey)
+		tags.GET("/stats", h.Admin.UsageTag.Stats)
+		tags.GET("/budgets", h.Admin.UsageTag.ListBudgets)
+		tags.PUT("/budgets", h.Admin.UsageTag.SetBudget)
+		tags.DELETE("/budgets/:id", h.Admin.UsageTag.DeleteBudget)
+	}
+}
+
+func registerGuardrailRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	guardrails := admin.Group("/guardrails")
+	{
+		guardrails.GET("/policies", h.Admin.Guardrail.ListPolicies)
+		guardrails.POST("/policies", h.Admin.Guardrail.CreatePolicy)
+		guardrails.PUT("/policies/:id", h.Admin.Guardrail.UpdatePolicy)
+		guardrails.DELETE("/policies/:id", h.Admin.Guardrail.DeletePolicy)
+		guardrails.GET("/events", h.Admin.Guardrail.ListEvents)
+	}
+}
+
+func registerPromptTemplateRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	templates := admin.Group("/prompt-templates")
+	{
+		templates.GET("", h.Admin.PromptTemplate.List)
+		templates.POST("", h.Admin.PromptTemplate.Create)
+		templates.PUT("/:id", h.Admin.PromptTemplate.Update)
+		templates.DELETE("/:id", h.Admin.PromptTemplate.Delete)
+	}
+}
+
+func registerBillingOutboxRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	outbox := admin.Group("/billing/outbox")
+	{
+		outbox.GET("", h.Admin.BillingOutbox.List)
+		outbox.GET("/summary", h.Admin.BillingOutbox.Summary)
+		outbox.POST("/:id/retry", h.Admin.BillingOutbox.Retry)
+	}
+}
+
+func registerAnthropicFileRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	files := admin.Group("/anthropic-files")
+	{
+		files.GET("", h.Admin.AnthropicFile.List)
+		files.DELETE("/:file_id", h.Admin.AnthropicFile.Delete)
+	}
+	admin.DELETE("/users/:id/anthropic-files", h.Admin.AnthropicFile.PurgeUser)
+}
+
 func registerUsageRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 	usage := admin.Group("/usage")
 	{
@@ -357,6 +520,9 @@ func registerUsageRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		usage.GET("/cleanup-tasks", h.Admin.Usage.ListCleanupTasks)
 		usage.POST("/cleanup-tasks", h.Admin.Usage.CreateCleanupTask)
 		usage.POST("/cleanup-tasks/:id/cancel", h.Admin.Usage.CancelCleanupTask)
+		usage.GET("/export", h.Admin.Usage.Export)
+		usage.GET("/exports", h.Admin.Usage.ListExports)
+		usage.GET("/exports/:id", h.Admin.Usage.GetExport)
 	}
 }
 
@@ -41,6 +41,19 @@ func RegisterAuthRoutes(
 		settings.GET("/public", h.Setting.GetPublicSettings)
 	}
 
+	// 支付渠道异步回调（公开，由各渠道签名校验）
+	payments := v1.Group("/payments")
+	{
+		payments.GET("/webhook/:provider", h.Payment.Webhook)
+		payments.POST("/webhook/:provider", h.Payment.Webhook)
+	}
+
+	// 使用记录导出文件下载（公开，凭带有效期的下载令牌访问）
+	exports := v1.Group("/exports")
+	{
+		exports.GET("/usage/:token", h.Usage.DownloadExport)
+	}
+
 	// 需要认证的当前用户信息
 	authenticated := v1.Group("")
 	authenticated.Use(gin.HandlerFunc(jwtAuth))
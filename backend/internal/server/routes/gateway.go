@@ -36,7 +36,16 @@ func RegisterGatewayRoutes(
 		gateway.GET("/usage", h.Gateway.Usage)
 		// OpenAI Responses API
 		gateway.POST("/responses", h.OpenAIGateway.Responses)
+		// OpenAI Realtime API（WebSocket）
+		gateway.GET("/realtime", h.OpenAIGateway.Realtime)
+		// Anthropic Files API（上传路由单独注册以使用独立的请求体上限）
+		gateway.GET("/files", h.Gateway.ListFiles)
+		gateway.GET("/files/:file_id", h.Gateway.GetFile)
+		gateway.GET("/files/:file_id/content", h.Gateway.DownloadFile)
+		gateway.DELETE("/files/:file_id", h.Gateway.DeleteFile)
 	}
+	r.POST("/v1/files", middleware.RequestBodyLimit(cfg.Gateway.AnthropicFiles.MaxUploadSize), clientRequestID, opsErrorLogger,
+		gin.HandlerFunc(apiKeyAuth), h.Gateway.UploadFile)
 
 	// Gemini 原生 API 兼容层（Gemini SDK/CLI 直连）
 	gemini := r.Group("/v1beta")
@@ -49,8 +58,21 @@ func RegisterGatewayRoutes(
 		gemini.GET("/models/:model", h.Gateway.GeminiV1BetaGetModel)
 		// Gin treats ":" as a param marker, but Gemini uses "{model}:{action}" in the same segment.
 		gemini.POST("/models/*modelAction", h.Gateway.GeminiV1BetaModels)
+		// Files API 与显式上下文缓存（资源固定在创建它们的账号上）
+		gemini.GET("/files", h.Gateway.GeminiListFiles)
+		gemini.GET("/files/:name", h.Gateway.GeminiGetFile)
+		gemini.DELETE("/files/:name", h.Gateway.GeminiDeleteFile)
+		gemini.POST("/cachedContents", h.Gateway.GeminiCreateCachedContent)
+		gemini.GET("/cachedContents", h.Gateway.GeminiListCachedContents)
+		gemini.GET("/cachedContents/:name", h.Gateway.GeminiGetCachedContent)
+		gemini.PATCH("/cachedContents/:name", h.Gateway.GeminiUpdateCachedContent)
+		gemini.DELETE("/cachedContents/:name", h.Gateway.GeminiDeleteCachedContent)
 	}
 
+	// Gemini Files API 上传（可续传上传的后续分片按 upload_id 路由到同一账号）
+	r.POST("/upload/v1beta/files", bodyLimit, clientRequestID, opsErrorLogger,
+		middleware.APIKeyAuthWithSubscriptionGoogle(apiKeyService, subscriptionService, cfg), h.Gateway.GeminiUploadFile)
+
 	// OpenAI Responses API（不带v1前缀的别名）
 	r.POST("/responses", bodyLimit, clientRequestID, opsErrorLogger, gin.HandlerFunc(apiKeyAuth), h.OpenAIGateway.Responses)
 
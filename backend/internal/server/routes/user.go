@@ -46,11 +46,20 @@ func RegisterUserRoutes(
 			usage.GET("", h.Usage.List)
 			usage.GET("/:id", h.Usage.GetByID)
 			usage.GET("/stats", h.Usage.Stats)
+			usage.GET("/export", h.Usage.Export)
+			usage.GET("/exports", h.Usage.ListExports)
+			usage.GET("/exports/:id", h.Usage.GetExport)
 			// User dashboard endpoints
 			usage.GET("/dashboard/stats", h.Usage.DashboardStats)
 			usage.GET("/dashboard/trend", h.Usage.DashboardTrend)
 			usage.GET("/dashboard/models", h.Usage.DashboardModels)
 			usage.POST("/dashboard/api-keys-usage", h.Usage.DashboardAPIKeysUsage)
+			// 成本归属标签
+			usage.GET("/tag-keys", h.UsageTag.ListKeys)
+			usage.GET("/tags/stats", h.UsageTag.Stats)
+			usage.GET("/tags/budgets", h.UsageTag.ListBudgets)
+			usage.PUT("/tags/budgets", h.UsageTag.SetBudget)
+			usage.DELETE("/tags/budgets/:id", h.UsageTag.DeleteBudget)
 		}
 
 		// 卡密兑换
@@ -67,6 +76,73 @@ func RegisterUserRoutes(
 			subscriptions.GET("/active", h.Subscription.GetActive)
 			subscriptions.GET("/progress", h.Subscription.GetProgress)
 			subscriptions.GET("/summary", h.Subscription.GetSummary)
+			subscriptions.POST("/:id/change-plan", h.SubscriptionPlan.ChangePlan)
+			subscriptions.PUT("/:id/auto-renew", h.SubscriptionPlan.SetAutoRenew)
+		}
+
+		// 订阅套餐（余额购买）
+		subscriptionPlans := authenticated.Group("/subscription-plans")
+		{
+			subscriptionPlans.GET("", h.SubscriptionPlan.List)
+			subscriptionPlans.GET("/orders", h.SubscriptionPlan.ListOrders)
+			subscriptionPlans.POST("/:id/purchase", h.SubscriptionPlan.Purchase)
+		}
+
+		// 在线支付
+		payments := authenticated.Group("/payments")
+		{
+			payments.GET("/config", h.Payment.GetConfig)
+			payments.GET("/orders", h.Payment.ListOrders)
+			payments.POST("/orders", h.Payment.CreateOrder)
+			payments.GET("/orders/:order_no", h.Payment.GetOrder)
+			payments.POST("/orders/:order_no/cancel", h.Payment.CancelOrder)
+		}
+
+		// 用户通知
+		notifications := authenticated.Group("/notifications")
+		{
+			notifications.GET("", h.Notification.List)
+			notifications.GET("/settings", h.Notification.GetSettings)
+			notifications.PUT("/settings", h.Notification.UpdateSettings)
+		}
+
+		// 月度账单
+		statements := authenticated.Group("/statements")
+		{
+			statements.GET("", h.Statement.List)
+			statements.GET("/:id", h.Statement.Get)
+			statements.GET("/:id/download", h.Statement.Download)
+		}
+
+		// 组织/团队
+		organizations := authenticated.Group("/organizations")
+		{
+			organizations.GET("", h.Organization.List)
+			organizations.POST("", h.Organization.Create)
+			organizations.GET("/:id", h.Organization.Get)
+			organizations.PUT("/:id", h.Organization.Rename)
+			organizations.GET("/:id/members", h.Organization.ListMembers)
+			organizations.POST("/:id/members", h.Organization.AddMember)
+			organizations.PUT("/:id/members/:user_id", h.Organization.UpdateMember)
+			organizations.DELETE("/:id/members/:user_id", h.Organization.RemoveMember)
+			organizations.POST("/:id/deposit", h.Organization.Deposit)
+			organizations.POST("/:id/keys", h.Organization.CreateAPIKey)
+			organizations.GET("/:id/usage", h.Organization.ListUsage)
+			organizations.GET("/:id/usage/stats", h.Organization.UsageStats)
+		}
+
+		// 分销商
+		reseller := authenticated.Group("/reseller")
+		{
+			reseller.GET("/profile", h.Reseller.Profile)
+			reseller.PUT("/markup", h.Reseller.UpdateMarkup)
+			reseller.GET("/sub-users", h.Reseller.ListSubUsers)
+			reseller.POST("/sub-users", h.Reseller.CreateSubUser)
+			reseller.PUT("/sub-users/:id/status", h.Reseller.UpdateSubUserStatus)
+			reseller.GET("/codes", h.Reseller.ListCodes)
+			reseller.POST("/codes", h.Reseller.GenerateCodes)
+			reseller.DELETE("/codes/:id", h.Reseller.RevokeCode)
+			reseller.GET("/report", h.Reseller.Report)
 		}
 	}
 }
@@ -15,10 +15,12 @@ type Account struct {
 	Platform    string
 	Type        string
 	Credentials map[string]any
-	Extra       map[string]any
-	ProxyID     *int64
-	Concurrency int
-	Priority    int
+	// CredentialsUnreadable 凭证密文无法用当前密钥解密：Credentials 保留原密文，账号不可调度，仓储拒绝写回
+	CredentialsUnreadable bool
+	Extra                 map[string]any
+	ProxyID               *int64
+	Concurrency           int
+	Priority              int
 	// RateMultiplier 账号计费倍率（>=0，允许 0 表示该账号计费为 0）。
 	// 使用指针用于兼容旧版本调度缓存（Redis）中缺字段的情况：nil 表示按 1.0 处理。
 	RateMultiplier     *float64
@@ -75,7 +77,7 @@ func (a *Account) BillingRateMultiplier() float64 {
 }
 
 func (a *Account) IsSchedulable() bool {
-	if !a.IsActive() || !a.Schedulable {
+	if !a.IsActive() || !a.Schedulable || a.CredentialsUnreadable {
 		return false
 	}
 	now := time.Now()
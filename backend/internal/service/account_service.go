@@ -12,6 +12,8 @@ import (
 var (
 	ErrAccountNotFound = infraerrors.NotFound("ACCOUNT_NOT_FOUND", "account not found")
 	ErrAccountNilInput = infraerrors.BadRequest("ACCOUNT_NIL_INPUT", "account input cannot be nil")
+	// ErrAccountCredentialsUnreadable 凭证无法解密时拒绝写回，避免用空凭证覆盖原密文
+	ErrAccountCredentialsUnreadable = infraerrors.Conflict("ACCOUNT_CREDENTIALS_UNREADABLE", "account credentials cannot be decrypted with the configured keys")
 )
 
 type AccountRepository interface {
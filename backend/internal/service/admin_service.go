@@ -109,7 +109,11 @@ type CreateGroupInput struct {
 	FallbackGroupID *int64 // 降级分组 ID
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64
-	ModelRoutingEnabled bool // 是否启用模型路由
+	ModelRoutingEnabled bool    // 是否启用模型路由
+	OverdraftUSD        float64 // 预扣费用透支容忍额度 (USD)
+	CountTokensMode     string  // count_tokens 计数方式
+	HedgeEnabled        bool    // 是否启用请求对冲
+	ModelFallback       *ModelFallbackConfig
 }
 
 type UpdateGroupInput struct {
@@ -131,7 +135,12 @@ type UpdateGroupInput struct {
 	FallbackGroupID *int64 // 降级分组 ID
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64
-	ModelRoutingEnabled *bool // 是否启用模型路由
+	ModelRoutingEnabled *bool    // 是否启用模型路由
+	OverdraftUSD        *float64 // 预扣费用透支容忍额度 (USD)
+	CountTokensMode     *string  // count_tokens 计数方式
+	HedgeEnabled        *bool    // 是否启用请求对冲
+	// 模型降级链：nil 表示不修改，Chains 为空表示清除
+	ModelFallback *ModelFallbackConfig
 }
 
 type CreateAccountInput struct {
@@ -554,6 +563,9 @@ func (s *adminServiceImpl) CreateGroup(ctx context.Context, input *CreateGroupIn
 	if subscriptionType == "" {
 		subscriptionType = SubscriptionTypeStandard
 	}
+	if input.OverdraftUSD < 0 {
+		return nil, ErrInvalidGroupOverdraft
+	}
 
 	// 限额字段：0 和 nil 都表示"无限制"
 	dailyLimit := normalizeLimit(input.DailyLimitUSD)
@@ -572,6 +584,11 @@ func (s *adminServiceImpl) CreateGroup(ctx context.Context, input *CreateGroupIn
 		}
 	}
 
+	// 校验模型降级链
+	if err := s.validateModelFallback(ctx, 0, input.ModelFallback); err != nil {
+		return nil, err
+	}
+
 	group := &Group{
 		Name:             input.Name,
 		Description:      input.Description,
@@ -589,6 +606,10 @@ func (s *adminServiceImpl) CreateGroup(ctx context.Context, input *CreateGroupIn
 		ClaudeCodeOnly:   input.ClaudeCodeOnly,
 		FallbackGroupID:  input.FallbackGroupID,
 		ModelRouting:     input.ModelRouting,
+		OverdraftUSD:     input.OverdraftUSD,
+		CountTokensMode:  input.CountTokensMode,
+		HedgeEnabled:     input.HedgeEnabled,
+		ModelFallback:    normalizeModelFallback(input.ModelFallback),
 	}
 	if err := s.groupRepo.Create(ctx, group); err != nil {
 		return nil, err
@@ -650,6 +671,40 @@ func (s *adminServiceImpl) validateFallbackGroup(ctx context.Context, currentGro
 	}
 }
 
+// validateModelFallback 校验模型降级链，跨分组降级的目标分组必须存在
+func (s *adminServiceImpl) validateModelFallback(ctx context.Context, currentGroupID int64, cfg *ModelFallbackConfig) error {
+	if err := cfg.Validate(currentGroupID); err != nil {
+		return err
+	}
+	if cfg == nil {
+		return nil
+	}
+	checked := map[int64]struct{}{}
+	for _, steps := range cfg.Chains {
+		for _, step := range steps {
+			if step.GroupID == nil {
+				continue
+			}
+			if _, ok := checked[*step.GroupID]; ok {
+				continue
+			}
+			checked[*step.GroupID] = struct{}{}
+			if _, err := s.groupRepo.GetByIDLite(ctx, *step.GroupID); err != nil {
+				return fmt.Errorf("model fallback group not found: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeModelFallback 未配置降级链时返回 nil（清除配置）
+func normalizeModelFallback(cfg *ModelFallbackConfig) *ModelFallbackConfig {
+	if cfg == nil || len(cfg.Chains) == 0 {
+		return nil
+	}
+	return cfg
+}
+
 func (s *adminServiceImpl) UpdateGroup(ctx context.Context, id int64, input *UpdateGroupInput) (*Group, error) {
 	group, err := s.groupRepo.GetByID(ctx, id)
 	if err != nil {
@@ -724,6 +779,24 @@ func (s *adminServiceImpl) UpdateGroup(ctx context.Context, id int64, input *Upd
 	if input.ModelRoutingEnabled != nil {
 		group.ModelRoutingEnabled = *input.ModelRoutingEnabled
 	}
+	if input.OverdraftUSD != nil {
+		if *input.OverdraftUSD < 0 {
+			return nil, ErrInvalidGroupOverdraft
+		}
+		group.OverdraftUSD = *input.OverdraftUSD
+	}
+	if input.CountTokensMode != nil {
+		group.CountTokensMode = *input.CountTokensMode
+	}
+	if input.HedgeEnabled != nil {
+		group.HedgeEnabled = *input.HedgeEnabled
+	}
+	if input.ModelFallback != nil {
+		if err := s.validateModelFallback(ctx, id, input.ModelFallback); err != nil {
+			return nil, err
+		}
+		group.ModelFallback = normalizeModelFallback(input.ModelFallback)
+	}
 
 	if err := s.groupRepo.Update(ctx, group); err != nil {
 		return nil, err
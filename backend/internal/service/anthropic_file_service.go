@@ -0,0 +1,452 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/claude"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	anthropicFilesBaseURL     = "https://api.anthropic.com"
+	anthropicFileDefaultLimit = 20
+	anthropicFileMaxLimit     = 1000
+	// anthropicFileErrorBodyLimit 错误响应读取上限（用于账号限流/停用判断）
+	anthropicFileErrorBodyLimit = 1 << 20
+)
+
+var (
+	ErrAnthropicFileNotFound           = infraerrors.NotFound("ANTHROPIC_FILE_NOT_FOUND", "file not found")
+	ErrAnthropicFileAccountConflict    = infraerrors.BadRequest("ANTHROPIC_FILE_ACCOUNT_CONFLICT", "referenced files were uploaded through different upstream accounts")
+	ErrAnthropicFileAccountUnavailable = infraerrors.ServiceUnavailable("ANTHROPIC_FILE_ACCOUNT_UNAVAILABLE", "the upstream account holding this file is no longer available")
+)
+
+// AnthropicFile Files API 文件与上游账号的归属记录
+type AnthropicFile struct {
+	ID           int64
+	FileID       string
+	AccountID    int64
+	UserID       int64
+	APIKeyID     int64
+	GroupID      *int64
+	Filename     string
+	MimeType     string
+	SizeBytes    int64
+	Downloadable bool
+	// Metadata 上游返回的文件对象原文
+	Metadata  json.RawMessage
+	CreatedAt time.Time
+
+	// 管理端列表附带
+	UserEmail   string
+	AccountName string
+}
+
+// AnthropicFileFilters 管理端文件列表筛选
+type AnthropicFileFilters struct {
+	UserID    int64
+	AccountID int64
+}
+
+// AnthropicFileRepository 文件归属表
+type AnthropicFileRepository interface {
+	// Create 按 file_id 写入或覆盖归属记录
+	Create(ctx context.Context, file *AnthropicFile) error
+	// GetByFileID 不存在时返回 ErrAnthropicFileNotFound
+	GetByFileID(ctx context.Context, fileID string) (*AnthropicFile, error)
+	// ListByUser 按 id 倒序（最新在前）列出；afterID > 0 时只返回更早的记录，beforeID > 0 时只返回更新的记录
+	ListByUser(ctx context.Context, userID int64, beforeID, afterID int64, limit int) ([]AnthropicFile, error)
+	// List 管理端分页列表
+	List(ctx context.Context, params pagination.PaginationParams, filters AnthropicFileFilters) ([]AnthropicFile, *pagination.PaginationResult, error)
+	Delete(ctx context.Context, fileID string) error
+}
+
+// AnthropicFileRequest Files API 透传请求
+type AnthropicFileRequest struct {
+	Account  *Account
+	Method   string
+	Path     string // 以 /v1/files 开头
+	RawQuery string
+	// Header 需要透传的请求头（anthropic-version / anthropic-beta / content-type，不含鉴权）
+	Header http.Header
+	Body   io.Reader
+	// ContentLength 请求体长度，-1 表示未知
+	ContentLength int64
+}
+
+// AnthropicFileListPage Files API 列表分页结果
+type AnthropicFileListPage struct {
+	Data    []json.RawMessage `json:"data"`
+	FirstID *string           `json:"first_id"`
+	LastID  *string           `json:"last_id"`
+	HasMore bool              `json:"has_more"`
+}
+
+// AnthropicFileService Anthropic Files API（/v1/files）透传。
+//
+// 文件只存在于上传时使用的账号上，因此：
+//   - 上传成功后记录 file_id → 账号 的归属，读取/下载/删除只转发到归属账号；
+//   - /v1/messages 引用 file_id 时通过 WithPinnedAccount 固定调度到归属账号；
+//   - 列表由归属表在本地分页生成，避免同账号下其他用户的文件泄露。
+type AnthropicFileService struct {
+	repo        AnthropicFileRepository
+	accountRepo AccountRepository
+	gateway     *GatewayService
+	cfg         *config.Config
+}
+
+// NewAnthropicFileService 创建 Anthropic Files API 透传服务
+func NewAnthropicFileService(repo AnthropicFileRepository, accountRepo AccountRepository, gateway *GatewayService, cfg *config.Config) *AnthropicFileService {
+	return &AnthropicFileService{repo: repo, accountRepo: accountRepo, gateway: gateway, cfg: cfg}
+}
+
+// Enabled 是否开放 /v1/files
+func (s *AnthropicFileService) Enabled() bool {
+	return s != nil && s.cfg != nil && s.cfg.Current().Gateway.AnthropicFiles.Enabled
+}
+
+// MaxUploadSize 上传请求体上限
+func (s *AnthropicFileService) MaxUploadSize() int64 {
+	if s == nil || s.cfg == nil {
+		return 0
+	}
+	return s.cfg.Current().Gateway.AnthropicFiles.MaxUploadSize
+}
+
+// SelectAccount 为上传选择 Anthropic 账号（不占用并发槽位；跳过混合调度中的 antigravity 账号）
+func (s *AnthropicFileService) SelectAccount(ctx context.Context, groupID *int64) (*Account, error) {
+	excluded := make(map[int64]struct{})
+	for {
+		account, err := s.gateway.SelectAccountForModelWithExclusions(ctx, groupID, "", "", excluded)
+		if err != nil {
+			return nil, err
+		}
+		if account.Platform == PlatformAnthropic {
+			return account, nil
+		}
+		excluded[account.ID] = struct{}{}
+	}
+}
+
+// Lookup 读取调用方拥有的文件及其归属账号；不属于该用户的文件视为不存在
+func (s *AnthropicFileService) Lookup(ctx context.Context, userID int64, fileID string) (*AnthropicFile, *Account, error) {
+	file, err := s.repo.GetByFileID(ctx, fileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if file.UserID != userID {
+		return nil, nil, ErrAnthropicFileNotFound
+	}
+	account, err := s.accountRepo.GetByID(ctx, file.AccountID)
+	if err != nil || account == nil || !account.IsActive() {
+		return nil, nil, ErrAnthropicFileAccountUnavailable
+	}
+	return file, account, nil
+}
+
+// Forward 使用账号凭证向上游发送 Files API 请求，调用方负责关闭响应体。
+// 上游 401/403/429/529 时应用账号限流/停用处理。
+func (s *AnthropicFileService) Forward(ctx context.Context, in *AnthropicFileRequest) (*http.Response, error) {
+	if in == nil || in.Account == nil {
+		return nil, errors.New("account is nil")
+	}
+	account := in.Account
+	token, tokenType, err := s.gateway.GetAccessToken(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := anthropicFilesBaseURL
+	if account.Type == AccountTypeAPIKey {
+		validated, err := s.gateway.validateUpstreamBaseURL(account.GetBaseURL())
+		if err != nil {
+			return nil, err
+		}
+		baseURL = validated
+	}
+	targetURL := strings.TrimRight(baseURL, "/") + in.Path
+	if in.RawQuery != "" {
+		targetURL += "?" + in.RawQuery
+	}
+
+	method := in.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, targetURL, in.Body)
+	if err != nil {
+		return nil, err
+	}
+	if in.Body != nil && in.ContentLength >= 0 {
+		req.ContentLength = in.ContentLength
+	}
+	for k, vv := range in.Header {
+		for _, v := range vv {
+			req.Header.Add(k, v)
+		}
+	}
+	if tokenType == "oauth" {
+		req.Header.Set("authorization", "Bearer "+token)
+	} else {
+		req.Header.Set("x-api-key", token)
+	}
+	if req.Header.Get("anthropic-version") == "" {
+		req.Header.Set("anthropic-version", "2023-06-01")
+	}
+	req.Header.Set("anthropic-beta", anthropicFilesBetaHeader(req.Header.Get("anthropic-beta"), tokenType == "oauth"))
+
+	proxyURL := ""
+	if account.ProxyID != nil && account.Proxy != nil {
+		proxyURL = account.Proxy.URL()
+	}
+	resp, err := s.gateway.httpUpstream.DoWithTLS(req, proxyURL, account.ID, account.Concurrency, account.IsTLSFingerprintEnabled())
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests, 529:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, anthropicFileErrorBodyLimit))
+		_ = resp.Body.Close()
+		if s.gateway.rateLimitService != nil {
+			s.gateway.rateLimitService.HandleUpstreamError(ctx, account, resp.StatusCode, resp.Header, body)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		resp.ContentLength = int64(len(body))
+	}
+	return resp, nil
+}
+
+// RecordUpload 上传成功后记录文件归属
+func (s *AnthropicFileService) RecordUpload(ctx context.Context, apiKey *APIKey, account *Account, body []byte) (*AnthropicFile, error) {
+	obj := gjson.ParseBytes(body)
+	fileID := obj.Get("id").String()
+	if fileID == "" {
+		return nil, errors.New("upstream response has no file id")
+	}
+	file := &AnthropicFile{
+		FileID:       fileID,
+		AccountID:    account.ID,
+		UserID:       apiKey.UserID,
+		APIKeyID:     apiKey.ID,
+		GroupID:      apiKey.GroupID,
+		Filename:     obj.Get("filename").String(),
+		MimeType:     obj.Get("mime_type").String(),
+		SizeBytes:    obj.Get("size_bytes").Int(),
+		Downloadable: obj.Get("downloadable").Bool(),
+		Metadata:     json.RawMessage(obj.Raw),
+	}
+	if err := s.repo.Create(ctx, file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Remove 删除归属记录（上游已删除或不存在）
+func (s *AnthropicFileService) Remove(ctx context.Context, fileID string) error {
+	return s.repo.Delete(ctx, fileID)
+}
+
+// List 按 Files API 列表格式分页返回调用方的文件（最新在前，before_id / after_id 为 file_id 游标）
+func (s *AnthropicFileService) List(ctx context.Context, userID int64, limit int, beforeFileID, afterFileID string) (*AnthropicFileListPage, error) {
+	if limit <= 0 {
+		limit = anthropicFileDefaultLimit
+	}
+	if limit > anthropicFileMaxLimit {
+		limit = anthropicFileMaxLimit
+	}
+	cursor := func(fileID string) (int64, error) {
+		if fileID == "" {
+			return 0, nil
+		}
+		file, err := s.repo.GetByFileID(ctx, fileID)
+		if err != nil || file.UserID != userID {
+			return 0, infraerrors.BadRequest("INVALID_FILE_CURSOR", "invalid pagination cursor")
+		}
+		return file.ID, nil
+	}
+	beforeID, err := cursor(beforeFileID)
+	if err != nil {
+		return nil, err
+	}
+	afterID, err := cursor(afterFileID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.ListByUser(ctx, userID, beforeID, afterID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &AnthropicFileListPage{Data: make([]json.RawMessage, 0, len(rows))}
+	if len(rows) > limit {
+		page.HasMore = true
+		// before_id 向更新的方向翻页，多取的一条位于列表头部
+		if beforeID > 0 {
+			rows = rows[1:]
+		} else {
+			rows = rows[:limit]
+		}
+	}
+	for _, row := range rows {
+		if len(row.Metadata) > 0 {
+			page.Data = append(page.Data, row.Metadata)
+		}
+	}
+	if len(rows) > 0 {
+		first, last := rows[0].FileID, rows[len(rows)-1].FileID
+		page.FirstID, page.LastID = &first, &last
+	}
+	return page, nil
+}
+
+// ResolveAffinity 解析 /v1/messages 请求体引用的 file_id，返回归属账号 ID（无引用时为 0）。
+// 只识别调用方自己上传的文件；引用分布在不同账号时返回 ErrAnthropicFileAccountConflict。
+func (s *AnthropicFileService) ResolveAffinity(ctx context.Context, userID int64, body []byte) (int64, error) {
+	if !s.Enabled() {
+		return 0, nil
+	}
+	var accountID int64
+	for _, fileID := range AnthropicFileRefs(body) {
+		file, err := s.repo.GetByFileID(ctx, fileID)
+		if err != nil {
+			if infraerrors.IsNotFound(err) {
+				continue
+			}
+			return 0, err
+		}
+		if file.UserID != userID {
+			continue
+		}
+		if accountID != 0 && file.AccountID != accountID {
+			return 0, ErrAnthropicFileAccountConflict
+		}
+		accountID = file.AccountID
+	}
+	return accountID, nil
+}
+
+// AdminList 管理端按用户/账号分页列出文件
+func (s *AnthropicFileService) AdminList(ctx context.Context, params pagination.PaginationParams, filters AnthropicFileFilters) ([]AnthropicFile, *pagination.PaginationResult, error) {
+	return s.repo.List(ctx, params, filters)
+}
+
+// Purge 管理端删除文件：先删除上游文件（账号已不存在时跳过），再删除归属记录
+func (s *AnthropicFileService) Purge(ctx context.Context, fileID string) error {
+	file, err := s.repo.GetByFileID(ctx, fileID)
+	if err != nil {
+		return err
+	}
+	account, err := s.accountRepo.GetByID(ctx, file.AccountID)
+	if err == nil && account != nil {
+		resp, err := s.Forward(ctx, &AnthropicFileRequest{Account: account, Method: http.MethodDelete, Path: "/v1/files/" + file.FileID})
+		if err != nil {
+			return infraerrors.ServiceUnavailable("ANTHROPIC_FILE_PURGE_FAILED", "failed to delete upstream file").WithCause(err)
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, anthropicFileErrorBodyLimit))
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+			return infraerrors.ServiceUnavailable("ANTHROPIC_FILE_PURGE_FAILED", fmt.Sprintf("upstream returned %d when deleting file", resp.StatusCode))
+		}
+	}
+	return s.repo.Delete(ctx, file.FileID)
+}
+
+// PurgeUser 管理端删除用户的全部文件，返回删除数量；任一文件删除失败时停止
+func (s *AnthropicFileService) PurgeUser(ctx context.Context, userID int64) (int, error) {
+	deleted := 0
+	for {
+		rows, err := s.repo.ListByUser(ctx, userID, 0, 0, anthropicFileMaxLimit)
+		if err != nil {
+			return deleted, err
+		}
+		if len(rows) == 0 {
+			return deleted, nil
+		}
+		for _, row := range rows {
+			if err := s.Purge(ctx, row.FileID); err != nil {
+				log.Printf("[AnthropicFiles] purge file %s of user %d failed: %v", row.FileID, userID, err)
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+}
+
+// AnthropicFileRefs 提取 messages 中引用的 file_id（document/image 的 file source 与 container_upload 块，去重）
+func AnthropicFileRefs(body []byte) []string {
+	seen := make(map[string]struct{})
+	var out []string
+	add := func(id string) {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return
+		}
+		if _, ok := seen[id]; ok {
+			return
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	gjson.GetBytes(body, "messages").ForEach(func(_, msg gjson.Result) bool {
+		content := msg.Get("content")
+		if !content.IsArray() {
+			return true
+		}
+		content.ForEach(func(_, block gjson.Result) bool {
+			if block.Get("source.type").String() == "file" {
+				add(block.Get("source.file_id").String())
+			}
+			if block.Get("type").String() == "container_upload" {
+				add(block.Get("file_id").String())
+			}
+			// tool_result 内嵌的内容块
+			block.Get("content").ForEach(func(_, inner gjson.Result) bool {
+				if inner.Get("source.type").String() == "file" {
+					add(inner.Get("source.file_id").String())
+				}
+				return true
+			})
+			return true
+		})
+		return true
+	})
+	return out
+}
+
+// anthropicFilesBetaHeader 确保 anthropic-beta 包含 files-api beta（OAuth 账号另需 oauth beta）
+func anthropicFilesBetaHeader(clientBeta string, oauth bool) string {
+	parts := make([]string, 0, 4)
+	seen := make(map[string]struct{})
+	add := func(v string) {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return
+		}
+		if _, ok := seen[v]; ok {
+			return
+		}
+		seen[v] = struct{}{}
+		parts = append(parts, v)
+	}
+	if oauth {
+		add(claude.BetaOAuth)
+	}
+	for _, p := range strings.Split(clientBeta, ",") {
+		add(p)
+	}
+	add(claude.BetaFilesAPI)
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,239 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/claude"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+// anthropicFileRepoStub 内存实现，按 id 升序保存
+type anthropicFileRepoStub struct {
+	rows []AnthropicFile
+}
+
+func (r *anthropicFileRepoStub) Create(_ context.Context, file *AnthropicFile) error {
+	file.ID = int64(len(r.rows) + 1)
+	r.rows = append(r.rows, *file)
+	return nil
+}
+
+func (r *anthropicFileRepoStub) GetByFileID(_ context.Context, fileID string) (*AnthropicFile, error) {
+	for i := range r.rows {
+		if r.rows[i].FileID == fileID {
+			row := r.rows[i]
+			return &row, nil
+		}
+	}
+	return nil, ErrAnthropicFileNotFound
+}
+
+func (r *anthropicFileRepoStub) ListByUser(_ context.Context, userID int64, beforeID, afterID int64, limit int) ([]AnthropicFile, error) {
+	var out []AnthropicFile
+	if beforeID > 0 {
+		// 与仓储一致：升序取紧邻的记录后反转为倒序
+		for _, row := range r.rows {
+			if row.UserID == userID && row.ID > beforeID && len(out) < limit {
+				out = append([]AnthropicFile{row}, out...)
+			}
+		}
+		return out, nil
+	}
+	for i := len(r.rows) - 1; i >= 0; i-- {
+		row := r.rows[i]
+		if row.UserID == userID && (afterID == 0 || row.ID < afterID) && len(out) < limit {
+			out = append(out, row)
+		}
+	}
+	return out, nil
+}
+
+func (r *anthropicFileRepoStub) List(context.Context, pagination.PaginationParams, AnthropicFileFilters) ([]AnthropicFile, *pagination.PaginationResult, error) {
+	return r.rows, nil, nil
+}
+
+func (r *anthropicFileRepoStub) Delete(_ context.Context, fileID string) error {
+	for i := range r.rows {
+		if r.rows[i].FileID == fileID {
+			r.rows = append(r.rows[:i], r.rows[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func newAnthropicFileTestService(repo AnthropicFileRepository) *AnthropicFileService {
+	cfg := &config.Config{}
+	cfg.Gateway.AnthropicFiles.Enabled = true
+	return NewAnthropicFileService(repo, nil, nil, cfg)
+}
+
+func TestAnthropicFileRefs(t *testing.T) {
+	body := []byte(`{
+		"messages": [
+			{"role": "user", "content": [
+				{"type": "document", "source": {"type": "file", "file_id": "file_a"}},
+				{"type": "image", "source": {"type": "base64", "data": "xx"}},
+				{"type": "container_upload", "file_id": "file_b"}
+			]},
+			{"role": "user", "content": [
+				{"type": "tool_result", "content": [{"type": "image", "source": {"type": "file", "file_id": "file_c"}}]},
+				{"type": "document", "source": {"type": "file", "file_id": "file_a"}}
+			]},
+			{"role": "assistant", "content": "plain text"}
+		]
+	}`)
+	require.Equal(t, []string{"file_a", "file_b", "file_c"}, AnthropicFileRefs(body))
+	require.Empty(t, AnthropicFileRefs([]byte(`{"messages":[{"role":"user","content":"hi"}]}`)))
+}
+
+func TestAnthropicFilesBetaHeader(t *testing.T) {
+	require.Equal(t, claude.BetaFilesAPI, anthropicFilesBetaHeader("", false))
+	require.Equal(t, "foo,"+claude.BetaFilesAPI, anthropicFilesBetaHeader(" foo , "+claude.BetaFilesAPI, false))
+
+	header := anthropicFilesBetaHeader("foo", true)
+	require.True(t, strings.HasPrefix(header, claude.BetaOAuth+","))
+	require.Contains(t, header, claude.BetaFilesAPI)
+}
+
+func TestAnthropicFileServiceListPaging(t *testing.T) {
+	repo := &anthropicFileRepoStub{}
+	for _, id := range []string{"file_1", "file_2", "file_3"} {
+		repo.rows = append(repo.rows, AnthropicFile{
+			ID: int64(len(repo.rows) + 1), FileID: id, UserID: 7,
+			Metadata: json.RawMessage(`{"id":"` + id + `"}`),
+		})
+	}
+	repo.rows = append(repo.rows, AnthropicFile{ID: 4, FileID: "file_other", UserID: 8, Metadata: json.RawMessage(`{}`)})
+	svc := newAnthropicFileTestService(repo)
+	ctx := context.Background()
+
+	// 默认由新到旧
+	page, err := svc.List(ctx, 7, 2, "", "")
+	require.NoError(t, err)
+	require.True(t, page.HasMore)
+	require.Len(t, page.Data, 2)
+	require.Equal(t, "file_3", *page.FirstID)
+	require.Equal(t, "file_2", *page.LastID)
+
+	page, err = svc.List(ctx, 7, 2, "", *page.LastID)
+	require.NoError(t, err)
+	require.False(t, page.HasMore)
+	require.Len(t, page.Data, 1)
+	require.JSONEq(t, `{"id":"file_1"}`, string(page.Data[0]))
+
+	// before_id 返回紧邻更新的一页
+	page, err = svc.List(ctx, 7, 1, "file_1", "")
+	require.NoError(t, err)
+	require.True(t, page.HasMore)
+	require.Equal(t, "file_2", *page.FirstID)
+
+	// 其他用户的文件不能作为游标
+	_, err = svc.List(ctx, 7, 2, "", "file_other")
+	require.Error(t, err)
+}
+
+func TestAnthropicFileServiceResolveAffinity(t *testing.T) {
+	repo := &anthropicFileRepoStub{rows: []AnthropicFile{
+		{ID: 1, FileID: "file_a", UserID: 7, AccountID: 1},
+		{ID: 2, FileID: "file_b", UserID: 7, AccountID: 2},
+		{ID: 3, FileID: "file_foreign", UserID: 8, AccountID: 3},
+		{ID: 4, FileID: "file_c", UserID: 7, AccountID: 1},
+	}}
+	svc := newAnthropicFileTestService(repo)
+	ctx := context.Background()
+	ref := func(ids ...string) []byte {
+		blocks := make([]string, 0, len(ids))
+		for _, id := range ids {
+			blocks = append(blocks, `{"type":"document","source":{"type":"file","file_id":"`+id+`"}}`)
+		}
+		return []byte(`{"messages":[{"role":"user","content":[` + strings.Join(blocks, ",") + `]}]}`)
+	}
+
+	accountID, err := svc.ResolveAffinity(ctx, 7, ref("file_a", "file_c"))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), accountID)
+
+	_, err = svc.ResolveAffinity(ctx, 7, ref("file_a", "file_b"))
+	require.ErrorIs(t, err, ErrAnthropicFileAccountConflict)
+
+	// 其他用户的文件与未登记的文件不参与路由
+	accountID, err = svc.ResolveAffinity(ctx, 7, ref("file_foreign", "file_unknown"))
+	require.NoError(t, err)
+	require.Zero(t, accountID)
+}
+
+func TestGatewayServicePinnedAccountSelection(t *testing.T) {
+	repo := &mockAccountRepoForPlatform{
+		accounts: []Account{
+			{ID: 1, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5},
+			{ID: 2, Platform: PlatformAnthropic, Priority: 2, Status: StatusActive, Schedulable: true, Concurrency: 5},
+			{ID: 3, Platform: PlatformAnthropic, Priority: 3, Status: StatusDisabled, Schedulable: true, Concurrency: 5},
+		},
+		accountsByID: map[int64]*Account{},
+	}
+	for i := range repo.accounts {
+		repo.accountsByID[repo.accounts[i].ID] = &repo.accounts[i]
+	}
+	svc := &GatewayService{accountRepo: repo, cache: &mockGatewayCacheForPlatform{}, cfg: testConfig()}
+	ctx := WithPinnedAccount(context.Background(), 2)
+
+	account, err := svc.SelectAccountForModelWithExclusions(ctx, nil, "", "claude-sonnet-4-5", nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), account.ID)
+
+	// 固定账号失败后不切换到其他账号
+	_, err = svc.SelectAccountForModelWithExclusions(ctx, nil, "", "claude-sonnet-4-5", map[int64]struct{}{2: {}})
+	require.ErrorIs(t, err, ErrPinnedAccountUnavailable)
+
+	_, err = svc.SelectAccountForModelWithExclusions(WithPinnedAccount(context.Background(), 3), nil, "", "claude-sonnet-4-5", nil)
+	require.ErrorIs(t, err, ErrPinnedAccountUnavailable)
+
+	result, err := svc.SelectAccountWithLoadAwareness(ctx, nil, "", "claude-sonnet-4-5", nil, "")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Account.ID)
+	require.True(t, result.Acquired)
+}
+
+// TestGatewayServicePinnedAccountRespectsGroupAndModel 固定账号同样要求属于当前分组且支持请求的模型
+func TestGatewayServicePinnedAccountRespectsGroupAndModel(t *testing.T) {
+	repo := &mockAccountRepoForPlatform{
+		accounts: []Account{
+			{ID: 1, Platform: PlatformAnthropic, Status: StatusActive, Schedulable: true, Concurrency: 5,
+				AccountGroups: []AccountGroup{{AccountID: 1, GroupID: 10}}},
+			{ID: 2, Platform: PlatformAnthropic, Status: StatusActive, Schedulable: true, Concurrency: 5,
+				AccountGroups: []AccountGroup{{AccountID: 2, GroupID: 20}}},
+			{ID: 3, Platform: PlatformAnthropic, Status: StatusActive, Schedulable: true, Concurrency: 5,
+				AccountGroups: []AccountGroup{{AccountID: 3, GroupID: 10}},
+				Credentials:   map[string]any{"model_mapping": map[string]any{"claude-haiku-4-5": "claude-haiku-4-5"}}},
+		},
+		accountsByID: map[int64]*Account{},
+	}
+	for i := range repo.accounts {
+		repo.accountsByID[repo.accounts[i].ID] = &repo.accounts[i]
+	}
+	groupRepo := &mockGroupRepoForGateway{groups: map[int64]*Group{
+		10: {ID: 10, Platform: PlatformAnthropic, Status: StatusActive, Hydrated: true},
+	}}
+	svc := &GatewayService{accountRepo: repo, groupRepo: groupRepo, cache: &mockGatewayCacheForPlatform{}, cfg: testConfig()}
+	groupID := int64(10)
+
+	account, err := svc.SelectAccountForModelWithExclusions(WithPinnedAccount(context.Background(), 1), &groupID, "", "claude-sonnet-4-5", nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), account.ID)
+
+	for _, pinned := range []int64{2, 3} {
+		ctx := WithPinnedAccount(context.Background(), pinned)
+		_, err = svc.SelectAccountForModelWithExclusions(ctx, &groupID, "", "claude-sonnet-4-5", nil)
+		require.ErrorIs(t, err, ErrPinnedAccountUnavailable, "account %d", pinned)
+		_, err = svc.SelectAccountWithLoadAwareness(ctx, &groupID, "", "claude-sonnet-4-5", nil, "")
+		require.ErrorIs(t, err, ErrPinnedAccountUnavailable, "account %d", pinned)
+	}
+}
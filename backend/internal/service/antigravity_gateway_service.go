@@ -54,6 +54,7 @@ type antigravityRetryLoopResult struct {
 
 // antigravityRetryLoop 执行带 URL fallback 的重试循环
 func antigravityRetryLoop(p antigravityRetryLoopParams) (*antigravityRetryLoopResult, error) {
+	cfg := p.settingService.currentConfig()
 	availableURLs := antigravity.DefaultURLAvailability.GetAvailableURLs()
 	if len(availableURLs) == 0 {
 		availableURLs = antigravity.BaseURLs
@@ -61,10 +62,10 @@ func antigravityRetryLoop(p antigravityRetryLoopParams) (*antigravityRetryLoopRe
 
 	var resp *http.Response
 	var usedBaseURL string
-	logBody := p.settingService != nil && p.settingService.cfg != nil && p.settingService.cfg.Gateway.LogUpstreamErrorBody
+	logBody := cfg != nil && cfg.Gateway.LogUpstreamErrorBody
 	maxBytes := 2048
-	if p.settingService != nil && p.settingService.cfg != nil && p.settingService.cfg.Gateway.LogUpstreamErrorBodyMaxBytes > 0 {
-		maxBytes = p.settingService.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+	if cfg != nil && cfg.Gateway.LogUpstreamErrorBodyMaxBytes > 0 {
+		maxBytes = cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 	}
 	getUpstreamDetail := func(body []byte) string {
 		if !logBody {
@@ -695,6 +696,7 @@ func isModelNotFoundError(statusCode int, body []byte) bool {
 
 // Forward 转发 Claude 协议请求（Claude → Gemini 转换）
 func (s *AntigravityGatewayService) Forward(ctx context.Context, c *gin.Context, account *Account, body []byte) (*ForwardResult, error) {
+	cfg := s.settingService.currentConfig()
 	startTime := time.Now()
 	sessionID := getSessionID(c)
 	prefix := logPrefix(sessionID, account.Name)
@@ -775,10 +777,10 @@ func (s *AntigravityGatewayService) Forward(ctx context.Context, c *gin.Context,
 		if resp.StatusCode == http.StatusBadRequest && isSignatureRelatedError(respBody) {
 			upstreamMsg := strings.TrimSpace(extractAntigravityErrorMessage(respBody))
 			upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
-			logBody := s.settingService != nil && s.settingService.cfg != nil && s.settingService.cfg.Gateway.LogUpstreamErrorBody
+			logBody := cfg != nil && cfg.Gateway.LogUpstreamErrorBody
 			maxBytes := 2048
-			if s.settingService != nil && s.settingService.cfg != nil && s.settingService.cfg.Gateway.LogUpstreamErrorBodyMaxBytes > 0 {
-				maxBytes = s.settingService.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+			if cfg != nil && cfg.Gateway.LogUpstreamErrorBodyMaxBytes > 0 {
+				maxBytes = cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 			}
 			upstreamDetail := ""
 			if logBody {
@@ -915,10 +917,10 @@ func (s *AntigravityGatewayService) Forward(ctx context.Context, c *gin.Context,
 			if s.shouldFailoverUpstreamError(resp.StatusCode) {
 				upstreamMsg := strings.TrimSpace(extractAntigravityErrorMessage(respBody))
 				upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
-				logBody := s.settingService != nil && s.settingService.cfg != nil && s.settingService.cfg.Gateway.LogUpstreamErrorBody
+				logBody := cfg != nil && cfg.Gateway.LogUpstreamErrorBody
 				maxBytes := 2048
-				if s.settingService != nil && s.settingService.cfg != nil && s.settingService.cfg.Gateway.LogUpstreamErrorBodyMaxBytes > 0 {
-					maxBytes = s.settingService.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+				if cfg != nil && cfg.Gateway.LogUpstreamErrorBodyMaxBytes > 0 {
+					maxBytes = cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 				}
 				upstreamDetail := ""
 				if logBody {
@@ -1243,6 +1245,7 @@ func stripSignatureSensitiveBlocksFromClaudeRequest(req *antigravity.ClaudeReque
 
 // ForwardGemini 转发 Gemini 协议请求
 func (s *AntigravityGatewayService) ForwardGemini(ctx context.Context, c *gin.Context, account *Account, originalModel string, action string, stream bool, body []byte) (*ForwardResult, error) {
+	cfg := s.settingService.currentConfig()
 	startTime := time.Now()
 	sessionID := getSessionID(c)
 	prefix := logPrefix(sessionID, account.Name)
@@ -1389,10 +1392,10 @@ func (s *AntigravityGatewayService) ForwardGemini(ctx context.Context, c *gin.Co
 		upstreamMsg := strings.TrimSpace(extractAntigravityErrorMessage(unwrappedForOps))
 		upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 
-		logBody := s.settingService != nil && s.settingService.cfg != nil && s.settingService.cfg.Gateway.LogUpstreamErrorBody
+		logBody := cfg != nil && cfg.Gateway.LogUpstreamErrorBody
 		maxBytes := 2048
-		if s.settingService != nil && s.settingService.cfg != nil && s.settingService.cfg.Gateway.LogUpstreamErrorBodyMaxBytes > 0 {
-			maxBytes = s.settingService.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+		if cfg != nil && cfg.Gateway.LogUpstreamErrorBodyMaxBytes > 0 {
+			maxBytes = cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 		}
 		upstreamDetail := ""
 		if logBody {
@@ -1526,6 +1529,7 @@ func antigravityUseScopeRateLimit() bool {
 }
 
 func (s *AntigravityGatewayService) handleUpstreamError(ctx context.Context, prefix string, account *Account, statusCode int, headers http.Header, body []byte, quotaScope AntigravityQuotaScope) {
+	cfg := s.settingService.currentConfig()
 	// 429 使用 Gemini 格式解析（从 body 解析重置时间）
 	if statusCode == 429 {
 		useScopeLimit := antigravityUseScopeRateLimit() && quotaScope != ""
@@ -1533,8 +1537,8 @@ func (s *AntigravityGatewayService) handleUpstreamError(ctx context.Context, pre
 		if resetAt == nil {
 			// 解析失败：使用配置的 fallback 时间，直接限流整个账户
 			fallbackMinutes := 5
-			if s.settingService != nil && s.settingService.cfg != nil && s.settingService.cfg.Gateway.AntigravityFallbackCooldownMinutes > 0 {
-				fallbackMinutes = s.settingService.cfg.Gateway.AntigravityFallbackCooldownMinutes
+			if cfg != nil && cfg.Gateway.AntigravityFallbackCooldownMinutes > 0 {
+				fallbackMinutes = cfg.Gateway.AntigravityFallbackCooldownMinutes
 			}
 			defaultDur := time.Duration(fallbackMinutes) * time.Minute
 			ra := time.Now().Add(defaultDur)
@@ -1581,6 +1585,7 @@ type antigravityStreamResult struct {
 }
 
 func (s *AntigravityGatewayService) handleGeminiStreamingResponse(c *gin.Context, resp *http.Response, startTime time.Time) (*antigravityStreamResult, error) {
+	cfg := s.settingService.currentConfig()
 	c.Status(resp.StatusCode)
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
@@ -1600,8 +1605,8 @@ func (s *AntigravityGatewayService) handleGeminiStreamingResponse(c *gin.Context
 	// 使用 Scanner 并限制单行大小，避免 ReadString 无上限导致 OOM
 	scanner := bufio.NewScanner(resp.Body)
 	maxLineSize := defaultMaxLineSize
-	if s.settingService.cfg != nil && s.settingService.cfg.Gateway.MaxLineSize > 0 {
-		maxLineSize = s.settingService.cfg.Gateway.MaxLineSize
+	if cfg != nil && cfg.Gateway.MaxLineSize > 0 {
+		maxLineSize = cfg.Gateway.MaxLineSize
 	}
 	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
 	usage := &ClaudeUsage{}
@@ -1640,8 +1645,8 @@ func (s *AntigravityGatewayService) handleGeminiStreamingResponse(c *gin.Context
 
 	// 上游数据间隔超时保护（防止上游挂起长期占用连接）
 	streamInterval := time.Duration(0)
-	if s.settingService.cfg != nil && s.settingService.cfg.Gateway.StreamDataIntervalTimeout > 0 {
-		streamInterval = time.Duration(s.settingService.cfg.Gateway.StreamDataIntervalTimeout) * time.Second
+	if cfg != nil && cfg.Gateway.StreamDataIntervalTimeout > 0 {
+		streamInterval = time.Duration(cfg.Gateway.StreamDataIntervalTimeout) * time.Second
 	}
 	var intervalTicker *time.Ticker
 	if streamInterval > 0 {
@@ -1742,10 +1747,11 @@ func (s *AntigravityGatewayService) handleGeminiStreamingResponse(c *gin.Context
 // handleGeminiStreamToNonStreaming 读取上游流式响应，合并为非流式响应返回给客户端
 // Gemini 流式响应是增量的，需要累积所有 chunk 的内容
 func (s *AntigravityGatewayService) handleGeminiStreamToNonStreaming(c *gin.Context, resp *http.Response, startTime time.Time) (*antigravityStreamResult, error) {
+	cfg := s.settingService.currentConfig()
 	scanner := bufio.NewScanner(resp.Body)
 	maxLineSize := defaultMaxLineSize
-	if s.settingService.cfg != nil && s.settingService.cfg.Gateway.MaxLineSize > 0 {
-		maxLineSize = s.settingService.cfg.Gateway.MaxLineSize
+	if cfg != nil && cfg.Gateway.MaxLineSize > 0 {
+		maxLineSize = cfg.Gateway.MaxLineSize
 	}
 	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
 
@@ -1791,8 +1797,8 @@ func (s *AntigravityGatewayService) handleGeminiStreamToNonStreaming(c *gin.Cont
 
 	// 上游数据间隔超时保护（防止上游挂起长期占用连接）
 	streamInterval := time.Duration(0)
-	if s.settingService.cfg != nil && s.settingService.cfg.Gateway.StreamDataIntervalTimeout > 0 {
-		streamInterval = time.Duration(s.settingService.cfg.Gateway.StreamDataIntervalTimeout) * time.Second
+	if cfg != nil && cfg.Gateway.StreamDataIntervalTimeout > 0 {
+		streamInterval = time.Duration(cfg.Gateway.StreamDataIntervalTimeout) * time.Second
 	}
 	var intervalTicker *time.Ticker
 	if streamInterval > 0 {
@@ -2025,13 +2031,14 @@ func (s *AntigravityGatewayService) writeClaudeError(c *gin.Context, status int,
 }
 
 func (s *AntigravityGatewayService) writeMappedClaudeError(c *gin.Context, account *Account, upstreamStatus int, upstreamRequestID string, body []byte) error {
+	cfg := s.settingService.currentConfig()
 	upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(body))
 	upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 
-	logBody := s.settingService != nil && s.settingService.cfg != nil && s.settingService.cfg.Gateway.LogUpstreamErrorBody
+	logBody := cfg != nil && cfg.Gateway.LogUpstreamErrorBody
 	maxBytes := 2048
-	if s.settingService != nil && s.settingService.cfg != nil && s.settingService.cfg.Gateway.LogUpstreamErrorBodyMaxBytes > 0 {
-		maxBytes = s.settingService.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+	if cfg != nil && cfg.Gateway.LogUpstreamErrorBodyMaxBytes > 0 {
+		maxBytes = cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 	}
 
 	upstreamDetail := ""
@@ -2123,10 +2130,11 @@ func (s *AntigravityGatewayService) writeGoogleError(c *gin.Context, status int,
 // handleClaudeStreamToNonStreaming 收集上游流式响应，转换为 Claude 非流式格式返回
 // 用于处理客户端非流式请求但上游只支持流式的情况
 func (s *AntigravityGatewayService) handleClaudeStreamToNonStreaming(c *gin.Context, resp *http.Response, startTime time.Time, originalModel string) (*antigravityStreamResult, error) {
+	cfg := s.settingService.currentConfig()
 	scanner := bufio.NewScanner(resp.Body)
 	maxLineSize := defaultMaxLineSize
-	if s.settingService.cfg != nil && s.settingService.cfg.Gateway.MaxLineSize > 0 {
-		maxLineSize = s.settingService.cfg.Gateway.MaxLineSize
+	if cfg != nil && cfg.Gateway.MaxLineSize > 0 {
+		maxLineSize = cfg.Gateway.MaxLineSize
 	}
 	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
 
@@ -2169,8 +2177,8 @@ func (s *AntigravityGatewayService) handleClaudeStreamToNonStreaming(c *gin.Cont
 
 	// 上游数据间隔超时保护（防止上游挂起长期占用连接）
 	streamInterval := time.Duration(0)
-	if s.settingService.cfg != nil && s.settingService.cfg.Gateway.StreamDataIntervalTimeout > 0 {
-		streamInterval = time.Duration(s.settingService.cfg.Gateway.StreamDataIntervalTimeout) * time.Second
+	if cfg != nil && cfg.Gateway.StreamDataIntervalTimeout > 0 {
+		streamInterval = time.Duration(cfg.Gateway.StreamDataIntervalTimeout) * time.Second
 	}
 	var intervalTicker *time.Ticker
 	if streamInterval > 0 {
@@ -2267,19 +2275,12 @@ returnResponse:
 
 	c.Data(http.StatusOK, "application/json", claudeResp)
 
-	// 转换为 service.ClaudeUsage
-	usage := &ClaudeUsage{
-		InputTokens:              agUsage.InputTokens,
-		OutputTokens:             agUsage.OutputTokens,
-		CacheCreationInputTokens: agUsage.CacheCreationInputTokens,
-		CacheReadInputTokens:     agUsage.CacheReadInputTokens,
-	}
-
-	return &antigravityStreamResult{usage: usage, firstTokenMs: firstTokenMs}, nil
+	return &antigravityStreamResult{usage: claudeUsageFromAntigravity(agUsage), firstTokenMs: firstTokenMs}, nil
 }
 
 // handleClaudeStreamingResponse 处理 Claude 流式响应（Gemini SSE → Claude SSE 转换）
 func (s *AntigravityGatewayService) handleClaudeStreamingResponse(c *gin.Context, resp *http.Response, startTime time.Time, originalModel string) (*antigravityStreamResult, error) {
+	cfg := s.settingService.currentConfig()
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
@@ -2296,24 +2297,11 @@ func (s *AntigravityGatewayService) handleClaudeStreamingResponse(c *gin.Context
 	// 使用 Scanner 并限制单行大小，避免 ReadString 无上限导致 OOM
 	scanner := bufio.NewScanner(resp.Body)
 	maxLineSize := defaultMaxLineSize
-	if s.settingService.cfg != nil && s.settingService.cfg.Gateway.MaxLineSize > 0 {
-		maxLineSize = s.settingService.cfg.Gateway.MaxLineSize
+	if cfg != nil && cfg.Gateway.MaxLineSize > 0 {
+		maxLineSize = cfg.Gateway.MaxLineSize
 	}
 	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
 
-	// 辅助函数：转换 antigravity.ClaudeUsage 到 service.ClaudeUsage
-	convertUsage := func(agUsage *antigravity.ClaudeUsage) *ClaudeUsage {
-		if agUsage == nil {
-			return &ClaudeUsage{}
-		}
-		return &ClaudeUsage{
-			InputTokens:              agUsage.InputTokens,
-			OutputTokens:             agUsage.OutputTokens,
-			CacheCreationInputTokens: agUsage.CacheCreationInputTokens,
-			CacheReadInputTokens:     agUsage.CacheReadInputTokens,
-		}
-	}
-
 	type scanEvent struct {
 		line string
 		err  error
@@ -2346,8 +2334,8 @@ func (s *AntigravityGatewayService) handleClaudeStreamingResponse(c *gin.Context
 	defer close(done)
 
 	streamInterval := time.Duration(0)
-	if s.settingService.cfg != nil && s.settingService.cfg.Gateway.StreamDataIntervalTimeout > 0 {
-		streamInterval = time.Duration(s.settingService.cfg.Gateway.StreamDataIntervalTimeout) * time.Second
+	if cfg != nil && cfg.Gateway.StreamDataIntervalTimeout > 0 {
+		streamInterval = time.Duration(cfg.Gateway.StreamDataIntervalTimeout) * time.Second
 	}
 	var intervalTicker *time.Ticker
 	if streamInterval > 0 {
@@ -2380,13 +2368,13 @@ func (s *AntigravityGatewayService) handleClaudeStreamingResponse(c *gin.Context
 					_, _ = c.Writer.Write(finalEvents)
 					flusher.Flush()
 				}
-				return &antigravityStreamResult{usage: convertUsage(agUsage), firstTokenMs: firstTokenMs}, nil
+				return &antigravityStreamResult{usage: claudeUsageFromAntigravity(agUsage), firstTokenMs: firstTokenMs}, nil
 			}
 			if ev.err != nil {
 				if errors.Is(ev.err, bufio.ErrTooLong) {
 					log.Printf("SSE line too long (antigravity): max_size=%d error=%v", maxLineSize, ev.err)
 					sendErrorEvent("response_too_large")
-					return &antigravityStreamResult{usage: convertUsage(nil), firstTokenMs: firstTokenMs}, ev.err
+					return &antigravityStreamResult{usage: claudeUsageFromAntigravity(nil), firstTokenMs: firstTokenMs}, ev.err
 				}
 				sendErrorEvent("stream_read_error")
 				return nil, fmt.Errorf("stream read error: %w", ev.err)
@@ -2408,7 +2396,7 @@ func (s *AntigravityGatewayService) handleClaudeStreamingResponse(c *gin.Context
 						_, _ = c.Writer.Write(finalEvents)
 					}
 					sendErrorEvent("write_failed")
-					return &antigravityStreamResult{usage: convertUsage(agUsage), firstTokenMs: firstTokenMs}, writeErr
+					return &antigravityStreamResult{usage: claudeUsageFromAntigravity(agUsage), firstTokenMs: firstTokenMs}, writeErr
 				}
 				flusher.Flush()
 			}
@@ -2421,7 +2409,7 @@ func (s *AntigravityGatewayService) handleClaudeStreamingResponse(c *gin.Context
 			log.Printf("Stream data interval timeout (antigravity)")
 			// 注意：此函数没有 account 上下文，无法调用 HandleStreamTimeout
 			sendErrorEvent("stream_timeout")
-			return &antigravityStreamResult{usage: convertUsage(nil), firstTokenMs: firstTokenMs}, fmt.Errorf("stream data interval timeout")
+			return &antigravityStreamResult{usage: claudeUsageFromAntigravity(nil), firstTokenMs: firstTokenMs}, fmt.Errorf("stream data interval timeout")
 		}
 	}
 
@@ -2459,3 +2447,23 @@ func isImageGenerationModel(model string) bool {
 		modelLower == "gemini-2.5-flash-image-preview" ||
 		strings.HasPrefix(modelLower, "gemini-2.5-flash-image-")
 }
+
+// claudeUsageFromAntigravity 转换 antigravity.ClaudeUsage 到 service.ClaudeUsage（携带缓存写入 TTL 拆分）
+func claudeUsageFromAntigravity(agUsage *antigravity.ClaudeUsage) *ClaudeUsage {
+	if agUsage == nil {
+		return &ClaudeUsage{}
+	}
+	usage := &ClaudeUsage{
+		InputTokens:              agUsage.InputTokens,
+		OutputTokens:             agUsage.OutputTokens,
+		CacheCreationInputTokens: agUsage.CacheCreationInputTokens,
+		CacheReadInputTokens:     agUsage.CacheReadInputTokens,
+	}
+	if agUsage.CacheCreation != nil {
+		usage.CacheCreation = ClaudeCacheCreation{
+			Ephemeral5mInputTokens: agUsage.CacheCreation.Ephemeral5mInputTokens,
+			Ephemeral1hInputTokens: agUsage.CacheCreation.Ephemeral1hInputTokens,
+		}
+	}
+	return usage
+}
@@ -3,20 +3,53 @@ package service
 import "time"
 
 type APIKey struct {
-	ID          int64
-	UserID      int64
-	Key         string
-	Name        string
-	GroupID     *int64
-	Status      string
-	IPWhitelist []string
-	IPBlacklist []string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	User        *User
-	Group       *Group
+	ID             int64
+	UserID         int64
+	Key            string
+	Name           string
+	GroupID        *int64
+	OrganizationID *int64
+	Status         string
+	IPWhitelist    []string
+	IPBlacklist    []string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	User           *User
+	Group          *Group
+	Organization   *APIKeyOrganization
 }
 
 func (k *APIKey) IsActive() bool {
 	return k.Status == StatusActive
 }
+
+// IsOrganizationKey 是否为组织 Key（用量由组织付费）
+func (k *APIKey) IsOrganizationKey() bool {
+	return k.OrganizationID != nil
+}
+
+// Payer 返回承担该 Key 费用的用户：组织 Key 为组织付费账户，个人 Key 为所有者
+func (k *APIKey) Payer() *User {
+	if k.IsOrganizationKey() {
+		if k.Organization == nil {
+			return nil
+		}
+		return k.Organization.Payer
+	}
+	return k.User
+}
+
+// CheckOrganizationAccess 校验组织 Key 是否可用：组织及付费账户有效，且所有者仍是组织成员
+func (k *APIKey) CheckOrganizationAccess() error {
+	if !k.IsOrganizationKey() {
+		return nil
+	}
+	org := k.Organization
+	if org == nil || org.Status != StatusActive || org.Payer == nil || !org.Payer.IsActive() {
+		return ErrOrganizationDisabled
+	}
+	if org.MemberRole == "" {
+		return ErrOrganizationMembershipRequired
+	}
+	return nil
+}
@@ -4,21 +4,38 @@ package service
 type APIKeyAuthSnapshot struct {
 	APIKeyID    int64                    `json:"api_key_id"`
 	UserID      int64                    `json:"user_id"`
+	Name        string                   `json:"name,omitempty"`
 	GroupID     *int64                   `json:"group_id,omitempty"`
 	Status      string                   `json:"status"`
 	IPWhitelist []string                 `json:"ip_whitelist,omitempty"`
 	IPBlacklist []string                 `json:"ip_blacklist,omitempty"`
 	User        APIKeyAuthUserSnapshot   `json:"user"`
 	Group       *APIKeyAuthGroupSnapshot `json:"group,omitempty"`
+
+	OrganizationID *int64                          `json:"organization_id,omitempty"`
+	Organization   *APIKeyAuthOrganizationSnapshot `json:"organization,omitempty"`
 }
 
 // APIKeyAuthUserSnapshot 用户快照
 type APIKeyAuthUserSnapshot struct {
 	ID          int64   `json:"id"`
+	Email       string  `json:"email,omitempty"`
 	Status      string  `json:"status"`
 	Role        string  `json:"role"`
 	Balance     float64 `json:"balance"`
 	Concurrency int     `json:"concurrency"`
+	// ParentUserID 所属分销商，计费时据此沿层级加价结算
+	ParentUserID *int64 `json:"parent_user_id,omitempty"`
+}
+
+// APIKeyAuthOrganizationSnapshot 组织计费上下文快照（Payer 为组织付费账户）
+type APIKeyAuthOrganizationSnapshot struct {
+	ID               int64                  `json:"id"`
+	Name             string                 `json:"name"`
+	Status           string                 `json:"status"`
+	MemberRole       string                 `json:"member_role,omitempty"`
+	SpendingLimitUSD *float64               `json:"spending_limit_usd,omitempty"`
+	Payer            APIKeyAuthUserSnapshot `json:"payer"`
 }
 
 // APIKeyAuthGroupSnapshot 分组快照
@@ -42,6 +59,18 @@ type APIKeyAuthGroupSnapshot struct {
 	// Only anthropic groups use these fields; others may leave them empty.
 	ModelRouting        map[string][]int64 `json:"model_routing,omitempty"`
 	ModelRoutingEnabled bool               `json:"model_routing_enabled"`
+
+	// OverdraftUSD is used by pre-request cost holds.
+	OverdraftUSD float64 `json:"overdraft_usd,omitempty"`
+
+	// CountTokensMode selects local or upstream count_tokens handling.
+	CountTokensMode string `json:"count_tokens_mode,omitempty"`
+
+	// HedgeEnabled enables request hedging for slow first bytes.
+	HedgeEnabled bool `json:"hedge_enabled,omitempty"`
+
+	// ModelFallback drives per-group model downgrade on capacity errors.
+	ModelFallback *ModelFallbackConfig `json:"model_fallback,omitempty"`
 }
 
 // APIKeyAuthCacheEntry 缓存条目，支持负缓存
@@ -209,16 +209,19 @@ func (s *APIKeyService) snapshotFromAPIKey(apiKey *APIKey) *APIKeyAuthSnapshot {
 	snapshot := &APIKeyAuthSnapshot{
 		APIKeyID:    apiKey.ID,
 		UserID:      apiKey.UserID,
+		Name:        apiKey.Name,
 		GroupID:     apiKey.GroupID,
 		Status:      apiKey.Status,
 		IPWhitelist: apiKey.IPWhitelist,
 		IPBlacklist: apiKey.IPBlacklist,
 		User: APIKeyAuthUserSnapshot{
-			ID:          apiKey.User.ID,
-			Status:      apiKey.User.Status,
-			Role:        apiKey.User.Role,
-			Balance:     apiKey.User.Balance,
-			Concurrency: apiKey.User.Concurrency,
+			ID:           apiKey.User.ID,
+			Email:        apiKey.User.Email,
+			Status:       apiKey.User.Status,
+			Role:         apiKey.User.Role,
+			Balance:      apiKey.User.Balance,
+			Concurrency:  apiKey.User.Concurrency,
+			ParentUserID: apiKey.User.ParentUserID,
 		},
 	}
 	if apiKey.Group != nil {
@@ -239,6 +242,29 @@ func (s *APIKeyService) snapshotFromAPIKey(apiKey *APIKey) *APIKeyAuthSnapshot {
 			FallbackGroupID:     apiKey.Group.FallbackGroupID,
 			ModelRouting:        apiKey.Group.ModelRouting,
 			ModelRoutingEnabled: apiKey.Group.ModelRoutingEnabled,
+			OverdraftUSD:        apiKey.Group.OverdraftUSD,
+			CountTokensMode:     apiKey.Group.CountTokensMode,
+			HedgeEnabled:        apiKey.Group.HedgeEnabled,
+			ModelFallback:       apiKey.Group.ModelFallback,
+		}
+	}
+	if apiKey.OrganizationID != nil {
+		snapshot.OrganizationID = apiKey.OrganizationID
+		if org := apiKey.Organization; org != nil && org.Payer != nil {
+			snapshot.Organization = &APIKeyAuthOrganizationSnapshot{
+				ID:               org.ID,
+				Name:             org.Name,
+				Status:           org.Status,
+				MemberRole:       org.MemberRole,
+				SpendingLimitUSD: org.SpendingLimitUSD,
+				Payer: APIKeyAuthUserSnapshot{
+					ID:          org.Payer.ID,
+					Status:      org.Payer.Status,
+					Role:        org.Payer.Role,
+					Balance:     org.Payer.Balance,
+					Concurrency: org.Payer.Concurrency,
+				},
+			}
 		}
 	}
 	return snapshot
@@ -251,17 +277,20 @@ func (s *APIKeyService) snapshotToAPIKey(key string, snapshot *APIKeyAuthSnapsho
 	apiKey := &APIKey{
 		ID:          snapshot.APIKeyID,
 		UserID:      snapshot.UserID,
+		Name:        snapshot.Name,
 		GroupID:     snapshot.GroupID,
 		Key:         key,
 		Status:      snapshot.Status,
 		IPWhitelist: snapshot.IPWhitelist,
 		IPBlacklist: snapshot.IPBlacklist,
 		User: &User{
-			ID:          snapshot.User.ID,
-			Status:      snapshot.User.Status,
-			Role:        snapshot.User.Role,
-			Balance:     snapshot.User.Balance,
-			Concurrency: snapshot.User.Concurrency,
+			ID:           snapshot.User.ID,
+			Email:        snapshot.User.Email,
+			Status:       snapshot.User.Status,
+			Role:         snapshot.User.Role,
+			Balance:      snapshot.User.Balance,
+			Concurrency:  snapshot.User.Concurrency,
+			ParentUserID: snapshot.User.ParentUserID,
 		},
 	}
 	if snapshot.Group != nil {
@@ -283,6 +312,27 @@ func (s *APIKeyService) snapshotToAPIKey(key string, snapshot *APIKeyAuthSnapsho
 			FallbackGroupID:     snapshot.Group.FallbackGroupID,
 			ModelRouting:        snapshot.Group.ModelRouting,
 			ModelRoutingEnabled: snapshot.Group.ModelRoutingEnabled,
+			OverdraftUSD:        snapshot.Group.OverdraftUSD,
+			CountTokensMode:     snapshot.Group.CountTokensMode,
+			HedgeEnabled:        snapshot.Group.HedgeEnabled,
+			ModelFallback:       snapshot.Group.ModelFallback,
+		}
+	}
+	apiKey.OrganizationID = snapshot.OrganizationID
+	if org := snapshot.Organization; org != nil {
+		apiKey.Organization = &APIKeyOrganization{
+			ID:               org.ID,
+			Name:             org.Name,
+			Status:           org.Status,
+			MemberRole:       org.MemberRole,
+			SpendingLimitUSD: org.SpendingLimitUSD,
+			Payer: &User{
+				ID:          org.Payer.ID,
+				Status:      org.Payer.Status,
+				Role:        org.Payer.Role,
+				Balance:     org.Payer.Balance,
+				Concurrency: org.Payer.Concurrency,
+			},
 		}
 	}
 	return apiKey
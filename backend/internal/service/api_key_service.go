@@ -212,11 +212,31 @@ func (s *APIKeyService) canUserBindGroup(ctx context.Context, user *User, group
 
 // Create 创建API Key
 func (s *APIKeyService) Create(ctx context.Context, userID int64, req CreateAPIKeyRequest) (*APIKey, error) {
+	return s.create(ctx, userID, nil, req)
+}
+
+// CreateForOrganization 创建组织 Key：分组权限按组织付费账户校验，用量由组织付费。
+// 成员资格由调用方（OrganizationService）校验。
+func (s *APIKeyService) CreateForOrganization(ctx context.Context, userID int64, org *Organization, req CreateAPIKeyRequest) (*APIKey, error) {
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return s.create(ctx, userID, org, req)
+}
+
+func (s *APIKeyService) create(ctx context.Context, userID int64, org *Organization, req CreateAPIKeyRequest) (*APIKey, error) {
 	// 验证用户存在
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("get user: %w", err)
 	}
+	bindUser := user
+	if org != nil {
+		bindUser, err = s.userRepo.GetByID(ctx, org.BillingUserID)
+		if err != nil {
+			return nil, fmt.Errorf("get organization billing user: %w", err)
+		}
+	}
 
 	// 验证 IP 白名单格式
 	if len(req.IPWhitelist) > 0 {
@@ -240,7 +260,7 @@ func (s *APIKeyService) Create(ctx context.Context, userID int64, req CreateAPIK
 		}
 
 		// 检查用户是否可以绑定该分组
-		if !s.canUserBindGroup(ctx, user, group) {
+		if !s.canUserBindGroup(ctx, bindUser, group) {
 			return nil, ErrGroupNotAllowed
 		}
 	}
@@ -290,6 +310,9 @@ func (s *APIKeyService) Create(ctx context.Context, userID int64, req CreateAPIK
 		IPWhitelist: req.IPWhitelist,
 		IPBlacklist: req.IPBlacklist,
 	}
+	if org != nil {
+		apiKey.OrganizationID = &org.ID
+	}
 
 	if err := s.apiKeyRepo.Create(ctx, apiKey); err != nil {
 		return nil, fmt.Errorf("create api key: %w", err)
@@ -410,8 +433,16 @@ func (s *APIKeyService) Update(ctx context.Context, id int64, userID int64, req
 	}
 
 	if req.GroupID != nil {
-		// 验证分组权限
-		user, err := s.userRepo.GetByID(ctx, userID)
+		// 验证分组权限（组织 Key 按组织付费账户校验）
+		bindUserID := userID
+		if apiKey.IsOrganizationKey() {
+			payer := apiKey.Payer()
+			if payer == nil {
+				return nil, ErrOrganizationDisabled
+			}
+			bindUserID = payer.ID
+		}
+		user, err := s.userRepo.GetByID(ctx, bindUserID)
 		if err != nil {
 			return nil, fmt.Errorf("get user: %w", err)
 		}
@@ -449,6 +480,24 @@ func (s *APIKeyService) Update(ctx context.Context, id int64, userID int64, req
 	return apiKey, nil
 }
 
+// Suspend 由系统停用 API Key（如异常检测）；Key 已非 active 状态时不做修改并返回 false
+func (s *APIKeyService) Suspend(ctx context.Context, id int64) (*APIKey, bool, error) {
+	apiKey, err := s.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, false, fmt.Errorf("get api key: %w", err)
+	}
+	if apiKey.Status != StatusActive {
+		return apiKey, false, nil
+	}
+
+	apiKey.Status = StatusDisabled
+	if err := s.apiKeyRepo.Update(ctx, apiKey); err != nil {
+		return nil, false, fmt.Errorf("update api key: %w", err)
+	}
+	s.InvalidateAuthCacheByKey(ctx, apiKey.Key)
+	return apiKey, true, nil
+}
+
 // Delete 删除API Key
 func (s *APIKeyService) Delete(ctx context.Context, id int64, userID int64) error {
 	key, ownerID, err := s.apiKeyRepo.GetKeyAndOwnerID(ctx, id)
@@ -334,8 +334,8 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (string
 		return "", nil, ErrServiceUnavailable
 	}
 
-	// 验证密码
-	if !s.CheckPassword(password, user.PasswordHash) {
+	// 验证密码（组织付费账户不可登录）
+	if user.IsOrganizationAccount() || !s.CheckPassword(password, user.PasswordHash) {
 		return "", nil, ErrInvalidCredentials
 	}
 
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -76,6 +77,7 @@ type BillingCacheService struct {
 	cache          BillingCache
 	userRepo       UserRepository
 	subRepo        UserSubscriptionRepository
+	orgRepo        OrganizationRepository
 	cfg            *config.Config
 	circuitBreaker *billingCircuitBreaker
 
@@ -90,11 +92,12 @@ type BillingCacheService struct {
 }
 
 // NewBillingCacheService 创建计费缓存服务
-func NewBillingCacheService(cache BillingCache, userRepo UserRepository, subRepo UserSubscriptionRepository, cfg *config.Config) *BillingCacheService {
+func NewBillingCacheService(cache BillingCache, userRepo UserRepository, subRepo UserSubscriptionRepository, orgRepo OrganizationRepository, cfg *config.Config) *BillingCacheService {
 	svc := &BillingCacheService{
 		cache:    cache,
 		userRepo: userRepo,
 		subRepo:  subRepo,
+		orgRepo:  orgRepo,
 		cfg:      cfg,
 	}
 	svc.circuitBreaker = newBillingCircuitBreaker(cfg.Billing.CircuitBreaker)
@@ -448,6 +451,7 @@ func (s *BillingCacheService) InvalidateSubscription(ctx context.Context, userID
 // CheckBillingEligibility 检查用户是否有资格发起请求
 // 余额模式：检查缓存余额 > 0
 // 订阅模式：检查缓存用量未超过限额（Group限额从参数传入）
+// 组织 Key：余额/订阅按组织付费账户检查，并校验成员的每月消费上限
 func (s *BillingCacheService) CheckBillingEligibility(ctx context.Context, user *User, apiKey *APIKey, group *Group, subscription *UserSubscription) error {
 	// 简易模式：跳过所有计费检查
 	if s.cfg.RunMode == config.RunModeSimple {
@@ -457,14 +461,72 @@ func (s *BillingCacheService) CheckBillingEligibility(ctx context.Context, user
 		return ErrBillingServiceUnavailable
 	}
 
+	payer, err := ResolveBillingPayer(apiKey, user)
+	if err != nil {
+		return err
+	}
+	if apiKey != nil && apiKey.IsOrganizationKey() {
+		if err := s.checkOrganizationMemberLimit(ctx, apiKey); err != nil {
+			return err
+		}
+	}
+
 	// 判断计费模式
 	isSubscriptionMode := group != nil && group.IsSubscriptionType() && subscription != nil
 
 	if isSubscriptionMode {
-		return s.checkSubscriptionEligibility(ctx, user.ID, group, subscription)
+		return s.checkSubscriptionEligibility(ctx, payer.ID, group, subscription)
+	}
+
+	return s.checkBalanceEligibility(ctx, payer.ID)
+}
+
+// ResolveBillingPayer 从 API Key 的所有者上下文解析付费方：
+// 组织 Key 由组织付费账户付费，个人 Key（或未提供 Key）由 user 付费。
+func ResolveBillingPayer(apiKey *APIKey, user *User) (*User, error) {
+	if apiKey == nil || !apiKey.IsOrganizationKey() {
+		if user == nil && apiKey != nil {
+			user = apiKey.User
+		}
+		if user == nil {
+			return nil, ErrUserNotFound
+		}
+		return user, nil
+	}
+	if err := apiKey.CheckOrganizationAccess(); err != nil {
+		return nil, err
 	}
+	return apiKey.Payer(), nil
+}
+
+// checkOrganizationMemberLimit 校验组织成员本月消费是否已达上限
+func (s *BillingCacheService) checkOrganizationMemberLimit(ctx context.Context, apiKey *APIKey) error {
+	org := apiKey.Organization
+	if org == nil || org.SpendingLimitUSD == nil || s.orgRepo == nil {
+		return nil
+	}
+	spent, err := s.orgRepo.GetMemberSpend(ctx, org.ID, apiKey.UserID, OrganizationSpendPeriod(time.Now()))
+	if err != nil {
+		if errors.Is(err, ErrOrganizationMemberNotFound) {
+			return ErrOrganizationMembershipRequired
+		}
+		log.Printf("ALERT: organization spend check failed for org %d user %d: %v", org.ID, apiKey.UserID, err)
+		return ErrBillingServiceUnavailable.WithCause(err)
+	}
+	if spent >= *org.SpendingLimitUSD {
+		return ErrOrganizationSpendingLimitExceeded
+	}
+	return nil
+}
 
-	return s.checkBalanceEligibility(ctx, user.ID)
+// RecordOrganizationSpend 累加组织成员本月消费（用于成员消费上限）
+func (s *BillingCacheService) RecordOrganizationSpend(ctx context.Context, apiKey *APIKey, amount float64) {
+	if s.orgRepo == nil || apiKey == nil || apiKey.OrganizationID == nil || amount <= 0 {
+		return
+	}
+	if err := s.orgRepo.AddMemberSpend(ctx, *apiKey.OrganizationID, apiKey.UserID, amount, OrganizationSpendPeriod(time.Now())); err != nil {
+		log.Printf("Warning: record organization spend failed for org %d user %d: %v", *apiKey.OrganizationID, apiKey.UserID, err)
+	}
 }
 
 // checkBalanceEligibility 检查余额模式资格
@@ -513,7 +575,8 @@ func (s *BillingCacheService) checkSubscriptionEligibility(ctx context.Context,
 		return ErrSubscriptionInvalid
 	}
 
-	// 检查限额（使用传入的Group限额配置）
+	// 检查限额（使用传入的Group限额配置，订阅级套餐覆盖优先）
+	group = subscription.EffectiveGroup(group)
 	if group.HasDailyLimit() && subData.DailyUsage >= *group.DailyLimitUSD {
 		return ErrDailyLimitExceeded
 	}
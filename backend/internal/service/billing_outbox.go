@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 计费事件状态
+const (
+	BillingOutboxStatusPending = "pending" // 待扣费（含重试中）
+	BillingOutboxStatusApplied = "applied" // 已扣费
+	BillingOutboxStatusFailed  = "failed"  // 超过最大重试次数，进入死信
+)
+
+var (
+	ErrBillingOutboxEventNotFound = infraerrors.NotFound("BILLING_OUTBOX_EVENT_NOT_FOUND", "billing event not found")
+	ErrBillingOutboxNotRetryable  = infraerrors.Conflict("BILLING_OUTBOX_NOT_RETRYABLE", "only failed billing events can be retried")
+)
+
+// BillingOutboxEvent 一次请求的待扣费事件
+//
+// 事件与使用日志在同一事务中写入，幂等键为 (RequestID, APIKeyID)；
+// worker 在扣费事务中先将状态置为 applied，再执行扣费，保证只扣一次。
+type BillingOutboxEvent struct {
+	ID         int64
+	RequestID  string
+	APIKeyID   int64
+	UsageLogID *int64
+
+	// PayerUserID 付费方（组织 Key 为组织付费账户）
+	PayerUserID int64
+	// MemberUserID/OrganizationID 用于记录组织成员消费
+	MemberUserID   int64
+	OrganizationID *int64
+
+	BillingType    int8
+	SubscriptionID *int64
+	GroupID        *int64
+	// Amount 订阅计费为原始费用（TotalCost），余额计费为倍率与分销加价后的费用
+	Amount float64
+	// Reseller 分销结算明细（仅余额计费的分销下级用户）
+	Reseller *ResellerSettlement
+
+	Status        string
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	AppliedAt     *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// BillingOutboxListFilters 管理端计费事件筛选
+type BillingOutboxListFilters struct {
+	Status string
+	UserID int64
+}
+
+// BillingOutboxStatusSummary 某一状态的计费事件汇总
+type BillingOutboxStatusSummary struct {
+	Status      string
+	Count       int64
+	Amount      float64
+	OldestAt    *time.Time
+	MaxAttempts int
+}
+
+// BillingOutboxRepository 计费发件箱存储
+type BillingOutboxRepository interface {
+	// Enqueue 写入计费事件；幂等键冲突时返回 false（同一请求已入队）
+	Enqueue(ctx context.Context, event *BillingOutboxEvent) (bool, error)
+	// ListDue 返回到期待扣费的事件
+	ListDue(ctx context.Context, now time.Time, limit int) ([]BillingOutboxEvent, error)
+	// MarkApplied 将待扣费事件置为已扣费；事件已被处理时返回 false
+	MarkApplied(ctx context.Context, id int64) (bool, error)
+	// MarkRetry 记录一次失败并安排下次重试；dead 为 true 时进入死信
+	MarkRetry(ctx context.Context, id int64, attempts int, lastError string, nextAttemptAt time.Time, dead bool) error
+	// Requeue 将死信事件重新放回待扣费队列
+	Requeue(ctx context.Context, id int64) error
+	GetByID(ctx context.Context, id int64) (*BillingOutboxEvent, error)
+	List(ctx context.Context, params pagination.PaginationParams, filters BillingOutboxListFilters) ([]BillingOutboxEvent, *pagination.PaginationResult, error)
+	Summary(ctx context.Context) ([]BillingOutboxStatusSummary, error)
+	// DeleteAppliedBefore 清理早于 before 的已扣费事件
+	DeleteAppliedBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/google/uuid"
+)
+
+const (
+	billingOutboxWorkerName = "billing_outbox_worker"
+	// billingOutboxWorkerInterval worker 扫描到期事件的周期
+	billingOutboxWorkerInterval = 10 * time.Second
+	// billingOutboxInlineGrace 入队后由请求路径立即扣费，worker 仅在宽限期后接手，避免重复尝试
+	billingOutboxInlineGrace = 30 * time.Second
+	billingOutboxBatchSize   = 200
+	// billingOutboxMaxAttempts 超过该次数进入死信
+	billingOutboxMaxAttempts = 8
+	billingOutboxMaxBackoff  = 30 * time.Minute
+	// billingOutboxRetention 已扣费事件保留时长
+	billingOutboxRetention       = 7 * 24 * time.Hour
+	billingOutboxCleanupInterval = time.Hour
+	billingOutboxLastErrorMaxLen = 1000
+)
+
+// BillingOutboxService 持久化计费流水线
+//
+// 请求路径在同一事务中写入使用日志与计费事件，随后立即尝试扣费；
+// 扣费失败（或进程在扣费前退出）时由后台 worker 按指数退避重试，超过最大次数进入死信并告警。
+type BillingOutboxService struct {
+	repo                BillingOutboxRepository
+	usageLogRepo        UsageLogRepository
+	userRepo            UserRepository
+	userSubRepo         UserSubscriptionRepository
+	resellerService     *ResellerService
+	billingCacheService *BillingCacheService
+	entClient           *dbent.Client
+	timingWheel         *TimingWheelService
+
+	cleanupMu   sync.Mutex
+	lastCleanup time.Time
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewBillingOutboxService 创建计费发件箱服务
+func NewBillingOutboxService(
+	repo BillingOutboxRepository,
+	usageLogRepo UsageLogRepository,
+	userRepo UserRepository,
+	userSubRepo UserSubscriptionRepository,
+	resellerService *ResellerService,
+	billingCacheService *BillingCacheService,
+	entClient *dbent.Client,
+	timingWheel *TimingWheelService,
+) *BillingOutboxService {
+	return &BillingOutboxService{
+		repo:                repo,
+		usageLogRepo:        usageLogRepo,
+		userRepo:            userRepo,
+		userSubRepo:         userSubRepo,
+		resellerService:     resellerService,
+		billingCacheService: billingCacheService,
+		entClient:           entClient,
+		timingWheel:         timingWheel,
+	}
+}
+
+// Start 启动后台重试 worker
+func (s *BillingOutboxService) Start() {
+	if s == nil || s.repo == nil || s.timingWheel == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.timingWheel.ScheduleRecurring(billingOutboxWorkerName, billingOutboxWorkerInterval, s.runWorker)
+	})
+}
+
+// Stop 停止后台 worker
+func (s *BillingOutboxService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(billingOutboxWorkerName)
+		}
+	})
+}
+
+// NewUsageBillingEvent 根据一次请求的计费结果构建计费事件
+func NewUsageBillingEvent(usageLog *UsageLog, apiKey *APIKey, payer *User, cost *CostBreakdown, baseCost float64, resellerQuote *ResellerQuote) *BillingOutboxEvent {
+	event := &BillingOutboxEvent{
+		RequestID:      usageLog.RequestID,
+		APIKeyID:       apiKey.ID,
+		PayerUserID:    payer.ID,
+		MemberUserID:   apiKey.UserID,
+		OrganizationID: apiKey.OrganizationID,
+		BillingType:    usageLog.BillingType,
+		SubscriptionID: usageLog.SubscriptionID,
+		GroupID:        usageLog.GroupID,
+	}
+	if usageLog.BillingType == BillingTypeSubscription {
+		// 订阅模式：使用 TotalCost 原始费用，不考虑倍率
+		event.Amount = cost.TotalCost
+		return event
+	}
+	// 余额模式：使用 ActualCost 考虑倍率后的费用
+	event.Amount = cost.ActualCost
+	if resellerQuote != nil {
+		event.Reseller = resellerQuote.BuildSettlement(payer.ID, baseCost, ResellerUsageMeta{
+			RequestID:   usageLog.RequestID,
+			Model:       usageLog.Model,
+			TotalTokens: int64(usageLog.TotalTokens()),
+		})
+	}
+	return event
+}
+
+// RecordUsage 在同一事务中写入使用日志与计费事件，并立即尝试扣费
+//
+// 同一请求（RequestID + APIKeyID）重复上报时不会重复扣费。扣费成功时返回前余额缓存已同步扣减，
+// 调用方随后释放费用预扣；扣费失败转由 worker 重试时保留预扣至过期，避免余额缓存扣减前的透支窗口。
+func (s *BillingOutboxService) RecordUsage(ctx context.Context, usageLog *UsageLog, event *BillingOutboxEvent, hold *CostHold) {
+	if event == nil || event.Amount <= 0 || (event.BillingType == BillingTypeSubscription && event.SubscriptionID == nil) {
+		if _, err := s.usageLogRepo.Create(ctx, usageLog); err != nil {
+			log.Printf("Create usage log failed: %v", err)
+		}
+		return
+	}
+	if strings.TrimSpace(event.RequestID) == "" {
+		// 无上游请求 ID 时生成本地幂等键
+		event.RequestID = "local-" + uuid.NewString()
+	}
+	event.Status = BillingOutboxStatusPending
+	event.NextAttemptAt = time.Now().Add(billingOutboxInlineGrace)
+
+	var enqueued bool
+	err := s.runInTx(ctx, func(txCtx context.Context) error {
+		inserted, err := s.usageLogRepo.Create(txCtx, usageLog)
+		if err != nil {
+			return fmt.Errorf("create usage log: %w", err)
+		}
+		if inserted {
+			event.UsageLogID = &usageLog.ID
+		}
+		enqueued, err = s.repo.Enqueue(txCtx, event)
+		return err
+	})
+	if err != nil {
+		log.Printf("Record usage with billing event failed (request %s): %v", event.RequestID, err)
+		// 使用日志写入失败时仍需保证计费事件落库
+		event.UsageLogID = nil
+		enqueued, err = s.repo.Enqueue(ctx, event)
+		if err != nil {
+			s.logLostEvent(event, err)
+			return
+		}
+	}
+	if !enqueued {
+		return
+	}
+
+	if err := s.apply(ctx, event); err != nil {
+		log.Printf("Apply billing event %d failed, will retry: %v", event.ID, err)
+		s.recordFailure(ctx, event, err)
+		hold.Retain()
+	}
+}
+
+// runWorker 处理到期事件并定期清理已扣费事件
+func (s *BillingOutboxService) runWorker() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	if _, err := s.ProcessDue(ctx); err != nil {
+		log.Printf("[BillingOutbox] process due events failed: %v", err)
+	}
+	s.cleanupApplied(ctx)
+}
+
+// ProcessDue 处理到期的待扣费事件，返回成功扣费的数量
+func (s *BillingOutboxService) ProcessDue(ctx context.Context) (int, error) {
+	events, err := s.repo.ListDue(ctx, time.Now(), billingOutboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	applied := 0
+	for i := range events {
+		event := &events[i]
+		if err := s.apply(ctx, event); err != nil {
+			s.recordFailure(ctx, event, err)
+			continue
+		}
+		applied++
+	}
+	if applied > 0 {
+		log.Printf("[BillingOutbox] applied %d pending billing events", applied)
+	}
+	return applied, nil
+}
+
+// apply 在单个事务中将事件置为已扣费并执行扣费，事件已被处理时直接返回
+func (s *BillingOutboxService) apply(ctx context.Context, event *BillingOutboxEvent) error {
+	var applied bool
+	err := s.runInTx(ctx, func(txCtx context.Context) error {
+		ok, err := s.repo.MarkApplied(txCtx, event.ID)
+		if err != nil {
+			return fmt.Errorf("mark applied: %w", err)
+		}
+		if !ok {
+			return nil
+		}
+		applied = true
+		return s.charge(txCtx, event)
+	})
+	if err != nil || !applied {
+		return err
+	}
+	s.afterApplied(ctx, event)
+	return nil
+}
+
+// charge 执行扣费（调用方持有事务）
+func (s *BillingOutboxService) charge(ctx context.Context, event *BillingOutboxEvent) error {
+	if event.BillingType == BillingTypeSubscription {
+		if err := s.userSubRepo.IncrementUsage(ctx, *event.SubscriptionID, event.Amount); err != nil {
+			return fmt.Errorf("increment subscription usage: %w", err)
+		}
+		return nil
+	}
+
+	if event.Reseller != nil {
+		// 分销结算：下级扣费与各级分销商利润在同一事务中完成；失败时整体重试或进入死信，
+		// 不退化为仅扣除付费方余额，否则分销商利润丢失、上下级账目不一致
+		if err := s.resellerService.Settle(ctx, event.Reseller); err != nil {
+			return fmt.Errorf("reseller settlement: %w", err)
+		}
+		return nil
+	}
+	if err := s.userRepo.DeductBalance(ctx, event.PayerUserID, event.Amount); err != nil {
+		return fmt.Errorf("deduct balance: %w", err)
+	}
+	return nil
+}
+
+// afterApplied 扣费提交后同步更新缓存与组织成员消费
+//
+// 计费发件箱不在请求热路径上（入账 goroutine 或后台 worker），同步写缓存保证返回时余额缓存已扣减，
+// 调用方随后才释放费用预扣。缓存扣减失败时使余额缓存失效，下次检查从数据库加载已扣费的余额。
+func (s *BillingOutboxService) afterApplied(ctx context.Context, event *BillingOutboxEvent) {
+	if s.billingCacheService == nil {
+		return
+	}
+	if event.BillingType == BillingTypeSubscription {
+		if event.GroupID != nil {
+			if err := s.billingCacheService.UpdateSubscriptionUsage(ctx, event.PayerUserID, *event.GroupID, event.Amount); err != nil {
+				log.Printf("Warning: update subscription cache failed for user %d group %d: %v", event.PayerUserID, *event.GroupID, err)
+				_ = s.billingCacheService.InvalidateSubscription(ctx, event.PayerUserID, *event.GroupID)
+			}
+		}
+	} else {
+		amount := event.Amount
+		if event.Reseller != nil {
+			amount = event.Reseller.Charge
+		}
+		if err := s.billingCacheService.DeductBalanceCache(ctx, event.PayerUserID, amount); err != nil {
+			log.Printf("Warning: deduct balance cache failed for user %d: %v", event.PayerUserID, err)
+			_ = s.billingCacheService.InvalidateUserBalance(ctx, event.PayerUserID)
+		}
+		if event.Reseller != nil {
+			// 分销商利润已入账，使其余额缓存失效
+			for _, e := range event.Reseller.Entries {
+				if e.Profit > 0 {
+					_ = s.billingCacheService.InvalidateUserBalance(ctx, e.ResellerID)
+				}
+			}
+		}
+	}
+	s.billingCacheService.RecordOrganizationSpend(ctx, &APIKey{
+		ID:             event.APIKeyID,
+		UserID:         event.MemberUserID,
+		OrganizationID: event.OrganizationID,
+	}, event.Amount)
+}
+
+// recordFailure 记录失败并按指数退避安排重试，超过最大次数进入死信
+func (s *BillingOutboxService) recordFailure(ctx context.Context, event *BillingOutboxEvent, cause error) {
+	attempts := event.Attempts + 1
+	dead := attempts >= billingOutboxMaxAttempts
+	next := time.Now().Add(billingOutboxBackoff(attempts))
+	msg := cause.Error()
+	if len(msg) > billingOutboxLastErrorMaxLen {
+		msg = msg[:billingOutboxLastErrorMaxLen]
+	}
+	if err := s.repo.MarkRetry(ctx, event.ID, attempts, msg, next, dead); err != nil {
+		log.Printf("[BillingOutbox] record failure for event %d failed: %v", event.ID, err)
+		return
+	}
+	event.Attempts = attempts
+	event.LastError = msg
+	if dead {
+		event.Status = BillingOutboxStatusFailed
+		log.Printf("ALERT: billing event %d (request %s, payer %d, amount %.6f) dead-lettered after %d attempts: %s",
+			event.ID, event.RequestID, event.PayerUserID, event.Amount, attempts, msg)
+	}
+}
+
+// billingOutboxBackoff 第 n 次失败后的重试间隔：10s、20s、40s ... 上限 30 分钟
+func billingOutboxBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := billingOutboxWorkerInterval
+	for i := 1; i < attempts && backoff < billingOutboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > billingOutboxMaxBackoff {
+		backoff = billingOutboxMaxBackoff
+	}
+	return backoff
+}
+
+func (s *BillingOutboxService) cleanupApplied(ctx context.Context) {
+	s.cleanupMu.Lock()
+	if time.Since(s.lastCleanup) < billingOutboxCleanupInterval {
+		s.cleanupMu.Unlock()
+		return
+	}
+	s.lastCleanup = time.Now()
+	s.cleanupMu.Unlock()
+
+	n, err := s.repo.DeleteAppliedBefore(ctx, time.Now().Add(-billingOutboxRetention))
+	if err != nil {
+		log.Printf("[BillingOutbox] cleanup applied events failed: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("[BillingOutbox] cleaned up %d applied billing events", n)
+	}
+}
+
+// logLostEvent 数据库不可用时事件无法落库，完整输出到日志以便人工补扣
+func (s *BillingOutboxService) logLostEvent(event *BillingOutboxEvent, cause error) {
+	payload, _ := json.Marshal(event)
+	log.Printf("ALERT: billing event could not be persisted (request %s): %v; event=%s", event.RequestID, cause, payload)
+}
+
+// List 管理端计费事件列表
+func (s *BillingOutboxService) List(ctx context.Context, params pagination.PaginationParams, filters BillingOutboxListFilters) ([]BillingOutboxEvent, *pagination.PaginationResult, error) {
+	return s.repo.List(ctx, params, filters)
+}
+
+// Summary 管理端按状态汇总未扣费与死信事件
+func (s *BillingOutboxService) Summary(ctx context.Context) ([]BillingOutboxStatusSummary, error) {
+	return s.repo.Summary(ctx)
+}
+
+// Retry 将死信事件重新入队并立即尝试扣费
+func (s *BillingOutboxService) Retry(ctx context.Context, id int64) (*BillingOutboxEvent, error) {
+	event, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if event.Status != BillingOutboxStatusFailed {
+		return nil, ErrBillingOutboxNotRetryable
+	}
+	if err := s.repo.Requeue(ctx, id); err != nil {
+		return nil, err
+	}
+	event.Status = BillingOutboxStatusPending
+	event.Attempts = 0
+	if err := s.apply(ctx, event); err != nil {
+		s.recordFailure(ctx, event, err)
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *BillingOutboxService) runInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.entClient == nil || dbent.TxFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	tx, err := s.entClient.Tx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(dbent.NewTxContext(ctx, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,360 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type outboxRepoStub struct {
+	BillingOutboxRepository
+	nextID int64
+	events map[int64]*BillingOutboxEvent
+}
+
+func newOutboxRepoStub() *outboxRepoStub {
+	return &outboxRepoStub{events: map[int64]*BillingOutboxEvent{}}
+}
+
+func (r *outboxRepoStub) Enqueue(ctx context.Context, event *BillingOutboxEvent) (bool, error) {
+	for _, e := range r.events {
+		if e.RequestID == event.RequestID && e.APIKeyID == event.APIKeyID {
+			return false, nil
+		}
+	}
+	r.nextID++
+	event.ID = r.nextID
+	clone := *event
+	r.events[event.ID] = &clone
+	return true, nil
+}
+
+func (r *outboxRepoStub) ListDue(ctx context.Context, now time.Time, limit int) ([]BillingOutboxEvent, error) {
+	out := make([]BillingOutboxEvent, 0)
+	for _, e := range r.events {
+		if e.Status == BillingOutboxStatusPending && !e.NextAttemptAt.After(now) {
+			out = append(out, *e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (r *outboxRepoStub) MarkApplied(ctx context.Context, id int64) (bool, error) {
+	e, ok := r.events[id]
+	if !ok || e.Status != BillingOutboxStatusPending {
+		return false, nil
+	}
+	e.Status = BillingOutboxStatusApplied
+	return true, nil
+}
+
+func (r *outboxRepoStub) MarkRetry(ctx context.Context, id int64, attempts int, lastError string, nextAttemptAt time.Time, dead bool) error {
+	e := r.events[id]
+	e.Attempts = attempts
+	e.LastError = lastError
+	e.NextAttemptAt = nextAttemptAt
+	// 单元测试不使用事务，扣费失败时模拟 MarkApplied 随事务回滚
+	e.Status = BillingOutboxStatusPending
+	if dead {
+		e.Status = BillingOutboxStatusFailed
+	}
+	return nil
+}
+
+func (r *outboxRepoStub) Requeue(ctx context.Context, id int64) error {
+	e := r.events[id]
+	if e.Status != BillingOutboxStatusFailed {
+		return ErrBillingOutboxNotRetryable
+	}
+	e.Status = BillingOutboxStatusPending
+	e.Attempts = 0
+	return nil
+}
+
+func (r *outboxRepoStub) GetByID(ctx context.Context, id int64) (*BillingOutboxEvent, error) {
+	e, ok := r.events[id]
+	if !ok {
+		return nil, ErrBillingOutboxEventNotFound
+	}
+	clone := *e
+	return &clone, nil
+}
+
+func (r *outboxRepoStub) List(ctx context.Context, params pagination.PaginationParams, filters BillingOutboxListFilters) ([]BillingOutboxEvent, *pagination.PaginationResult, error) {
+	return nil, nil, nil
+}
+
+// makeDue 将所有待扣费事件的下次重试时间提前到现在
+func (r *outboxRepoStub) makeDue() {
+	for _, e := range r.events {
+		e.NextAttemptAt = time.Time{}
+	}
+}
+
+type outboxUsageLogRepoStub struct {
+	UsageLogRepository
+	logs []*UsageLog
+}
+
+func (r *outboxUsageLogRepoStub) Create(ctx context.Context, log *UsageLog) (bool, error) {
+	for _, l := range r.logs {
+		if l.RequestID == log.RequestID && l.APIKeyID == log.APIKeyID {
+			return false, nil
+		}
+	}
+	log.ID = int64(len(r.logs) + 1)
+	r.logs = append(r.logs, log)
+	return true, nil
+}
+
+type outboxUserRepoStub struct {
+	UserRepository
+	balances map[int64]float64
+	failures int
+}
+
+func (r *outboxUserRepoStub) DeductBalance(ctx context.Context, id int64, amount float64) error {
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("database unavailable")
+	}
+	r.balances[id] -= amount
+	return nil
+}
+
+func (r *outboxUserRepoStub) UpdateBalance(ctx context.Context, id int64, amount float64) error {
+	r.balances[id] += amount
+	return nil
+}
+
+type outboxUserSubRepoStub struct {
+	UserSubscriptionRepository
+	usage map[int64]float64
+}
+
+func (r *outboxUserSubRepoStub) IncrementUsage(ctx context.Context, id int64, costUSD float64) error {
+	r.usage[id] += costUSD
+	return nil
+}
+
+func newOutboxTestService(users *outboxUserRepoStub) (*BillingOutboxService, *outboxRepoStub, *outboxUsageLogRepoStub) {
+	repo := newOutboxRepoStub()
+	logs := &outboxUsageLogRepoStub{}
+	subs := &outboxUserSubRepoStub{usage: map[int64]float64{}}
+	svc := NewBillingOutboxService(repo, logs, users, subs, nil, nil, nil, nil)
+	return svc, repo, logs
+}
+
+func TestBillingOutboxService_RecordUsageChargesOnce(t *testing.T) {
+	users := &outboxUserRepoStub{balances: map[int64]float64{7: 10}}
+	svc, repo, logs := newOutboxTestService(users)
+
+	for i := 0; i < 2; i++ {
+		usageLog := &UsageLog{RequestID: "req-1", APIKeyID: 3, UserID: 7}
+		svc.RecordUsage(context.Background(), usageLog, &BillingOutboxEvent{
+			RequestID:    "req-1",
+			APIKeyID:     3,
+			PayerUserID:  7,
+			MemberUserID: 7,
+			BillingType:  BillingTypeBalance,
+			Amount:       1.5,
+		}, nil)
+	}
+
+	require.Len(t, logs.logs, 1)
+	require.Len(t, repo.events, 1)
+	require.Equal(t, BillingOutboxStatusApplied, repo.events[1].Status)
+	require.Equal(t, int64(1), *repo.events[1].UsageLogID)
+	require.InDelta(t, 8.5, users.balances[7], 1e-9)
+
+	// worker 再次处理不会重复扣费
+	repo.makeDue()
+	applied, err := svc.ProcessDue(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, applied)
+	require.InDelta(t, 8.5, users.balances[7], 1e-9)
+}
+
+func TestBillingOutboxService_RetriesFailedCharge(t *testing.T) {
+	users := &outboxUserRepoStub{balances: map[int64]float64{7: 10}, failures: 2}
+	svc, repo, _ := newOutboxTestService(users)
+
+	svc.RecordUsage(context.Background(), &UsageLog{RequestID: "req-1", APIKeyID: 3}, &BillingOutboxEvent{
+		RequestID:   "req-1",
+		APIKeyID:    3,
+		PayerUserID: 7,
+		BillingType: BillingTypeBalance,
+		Amount:      2,
+	}, nil)
+	event := repo.events[1]
+	require.Equal(t, BillingOutboxStatusPending, event.Status)
+	require.Equal(t, 1, event.Attempts)
+	require.Contains(t, event.LastError, "database unavailable")
+	require.InDelta(t, 10, users.balances[7], 1e-9)
+
+	// 未到重试时间不处理
+	applied, err := svc.ProcessDue(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, applied)
+
+	repo.makeDue()
+	_, err = svc.ProcessDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, event.Attempts)
+
+	repo.makeDue()
+	applied, err = svc.ProcessDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, applied)
+	require.Equal(t, BillingOutboxStatusApplied, event.Status)
+	require.InDelta(t, 8, users.balances[7], 1e-9)
+}
+
+func TestBillingOutboxService_DeadLetterAndRetry(t *testing.T) {
+	users := &outboxUserRepoStub{balances: map[int64]float64{7: 10}, failures: billingOutboxMaxAttempts}
+	svc, repo, _ := newOutboxTestService(users)
+
+	svc.RecordUsage(context.Background(), &UsageLog{RequestID: "req-1", APIKeyID: 3}, &BillingOutboxEvent{
+		RequestID:   "req-1",
+		APIKeyID:    3,
+		PayerUserID: 7,
+		BillingType: BillingTypeBalance,
+		Amount:      1,
+	}, nil)
+	for i := 1; i < billingOutboxMaxAttempts; i++ {
+		repo.makeDue()
+		_, err := svc.ProcessDue(context.Background())
+		require.NoError(t, err)
+	}
+	event := repo.events[1]
+	require.Equal(t, BillingOutboxStatusFailed, event.Status)
+	require.Equal(t, billingOutboxMaxAttempts, event.Attempts)
+
+	// 死信不再被 worker 处理
+	repo.makeDue()
+	applied, err := svc.ProcessDue(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, applied)
+
+	retried, err := svc.Retry(context.Background(), event.ID)
+	require.NoError(t, err)
+	require.Equal(t, BillingOutboxStatusApplied, retried.Status)
+	require.InDelta(t, 9, users.balances[7], 1e-9)
+
+	_, err = svc.Retry(context.Background(), event.ID)
+	require.ErrorIs(t, err, ErrBillingOutboxNotRetryable)
+}
+
+func TestBillingOutboxService_ResellerSettlementNeverDegrades(t *testing.T) {
+	users := &outboxUserRepoStub{balances: map[int64]float64{100: 10, 10: 0}, failures: billingOutboxMaxAttempts}
+	svc, repo, _ := newOutboxTestService(users)
+	resellers := newResellerRepoStub()
+	svc.resellerService = NewResellerService(resellers, users, nil, nil, nil, nil, nil, nil)
+
+	quote := &ResellerQuote{Levels: []ResellerChainLevel{{ResellerID: 10, Markup: 1.5}}}
+	settlement := quote.BuildSettlement(100, 2.0, ResellerUsageMeta{RequestID: "req-1"})
+	svc.RecordUsage(context.Background(), &UsageLog{RequestID: "req-1", APIKeyID: 3}, &BillingOutboxEvent{
+		RequestID:   "req-1",
+		APIKeyID:    3,
+		PayerUserID: 100,
+		BillingType: BillingTypeBalance,
+		Amount:      settlement.Charge,
+		Reseller:    settlement,
+	}, nil)
+	for i := 1; i < billingOutboxMaxAttempts; i++ {
+		repo.makeDue()
+		_, err := svc.ProcessDue(context.Background())
+		require.NoError(t, err)
+	}
+
+	// 结算多次失败后进入死信，不会退化为仅扣除付费方余额
+	event := repo.events[1]
+	require.Equal(t, BillingOutboxStatusFailed, event.Status)
+	require.InDelta(t, 10, users.balances[100], 1e-9)
+	require.Empty(t, resellers.ledger)
+
+	retried, err := svc.Retry(context.Background(), event.ID)
+	require.NoError(t, err)
+	require.Equal(t, BillingOutboxStatusApplied, retried.Status)
+	require.Zero(t, retried.Attempts)
+	require.InDelta(t, 7, users.balances[100], 1e-9)
+	require.InDelta(t, 1, users.balances[10], 1e-9)
+	require.Len(t, resellers.ledger, 1)
+}
+
+type outboxBalanceCacheStub struct {
+	BillingCache
+	balances map[int64]float64
+}
+
+func (c *outboxBalanceCacheStub) DeductUserBalance(ctx context.Context, userID int64, amount float64) error {
+	c.balances[userID] -= amount
+	return nil
+}
+
+func TestBillingOutboxService_CostHoldReleasedAfterCacheDeduction(t *testing.T) {
+	users := &outboxUserRepoStub{balances: map[int64]float64{7: 10}}
+	svc, _, _ := newOutboxTestService(users)
+	balanceCache := &outboxBalanceCacheStub{balances: map[int64]float64{7: 10}}
+	svc.billingCacheService = NewBillingCacheService(balanceCache, nil, nil, nil, &config.Config{})
+	defer svc.billingCacheService.Stop()
+
+	holds := newCostHoldCacheStub()
+	holdService := &CostHoldService{cache: holds}
+	newHold := func(id string) *CostHold {
+		_, _, _ = holds.Reserve(context.Background(), "user:7", id, 2, 100, time.Minute)
+		return &CostHold{ID: id, Scope: "user:7", Amount: 2}
+	}
+	event := func(requestID string) *BillingOutboxEvent {
+		return &BillingOutboxEvent{RequestID: requestID, APIKeyID: 3, PayerUserID: 7, BillingType: BillingTypeBalance, Amount: 1}
+	}
+
+	// 扣费成功：返回时余额缓存已同步扣减，随后释放预扣
+	hold := newHold("h1")
+	svc.RecordUsage(context.Background(), &UsageLog{RequestID: "req-1", APIKeyID: 3}, event("req-1"), hold)
+	require.InDelta(t, 9, balanceCache.balances[7], 1e-9)
+	holdService.Release(context.Background(), hold)
+	require.Empty(t, holds.holds["user:7"])
+
+	// 扣费失败转由 worker 重试：余额缓存未扣减，预扣保留至过期
+	users.failures = 1
+	hold = newHold("h2")
+	svc.RecordUsage(context.Background(), &UsageLog{RequestID: "req-2", APIKeyID: 3}, event("req-2"), hold)
+	require.InDelta(t, 9, balanceCache.balances[7], 1e-9)
+	holdService.Release(context.Background(), hold)
+	require.Contains(t, holds.holds["user:7"], "h2")
+}
+
+func TestBillingOutboxService_SubscriptionChargesUsage(t *testing.T) {
+	users := &outboxUserRepoStub{balances: map[int64]float64{}}
+	svc, repo, _ := newOutboxTestService(users)
+	subs := svc.userSubRepo.(*outboxUserSubRepoStub)
+
+	subID := int64(11)
+	svc.RecordUsage(context.Background(), &UsageLog{APIKeyID: 3}, &BillingOutboxEvent{
+		APIKeyID:       3,
+		PayerUserID:    7,
+		BillingType:    BillingTypeSubscription,
+		SubscriptionID: &subID,
+		Amount:         0.25,
+	}, nil)
+
+	require.Len(t, repo.events, 1)
+	require.Contains(t, repo.events[1].RequestID, "local-")
+	require.InDelta(t, 0.25, subs.usage[subID], 1e-9)
+}
+
+func TestBillingOutboxBackoff(t *testing.T) {
+	require.Equal(t, 10*time.Second, billingOutboxBackoff(1))
+	require.Equal(t, 40*time.Second, billingOutboxBackoff(3))
+	require.Equal(t, billingOutboxMaxBackoff, billingOutboxBackoff(20))
+}
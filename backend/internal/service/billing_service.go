@@ -2,10 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"log"
 	"strings"
+	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
 )
@@ -29,46 +31,120 @@ type BillingCache interface {
 type ModelPricing struct {
 	InputPricePerToken         float64 // 每token输入价格 (USD)
 	OutputPricePerToken        float64 // 每token输出价格 (USD)
-	CacheCreationPricePerToken float64 // 缓存创建每token价格 (USD)
+	CacheCreationPricePerToken float64 // 缓存创建每token价格 (USD)，即 5 分钟缓存写入价格
 	CacheReadPricePerToken     float64 // 缓存读取每token价格 (USD)
-	CacheCreation5mPrice       float64 // 5分钟缓存创建价格（每百万token）- 仅用于硬编码回退
-	CacheCreation1hPrice       float64 // 1小时缓存创建价格（每百万token）- 仅用于硬编码回退
-	SupportsCacheBreakdown     bool    // 是否支持详细的缓存分类
+	// CacheCreation1hPricePerToken 1小时缓存写入每token价格 (USD)，为 0 时按输入价格的 2 倍计算
+	CacheCreation1hPricePerToken float64
+	// 音频 token 单价 (USD)，用于 Realtime 等语音模型；为 0 时按对应的文本价格计费
+	AudioInputPricePerToken     float64
+	AudioOutputPricePerToken    float64
+	AudioCacheReadPricePerToken float64
+}
+
+func (p *ModelPricing) audioInputPrice() float64 {
+	if p.AudioInputPricePerToken > 0 {
+		return p.AudioInputPricePerToken
+	}
+	return p.InputPricePerToken
+}
+
+func (p *ModelPricing) audioOutputPrice() float64 {
+	if p.AudioOutputPricePerToken > 0 {
+		return p.AudioOutputPricePerToken
+	}
+	return p.OutputPricePerToken
+}
+
+func (p *ModelPricing) audioCacheReadPrice() float64 {
+	if p.AudioCacheReadPricePerToken > 0 {
+		return p.AudioCacheReadPricePerToken
+	}
+	return p.CacheReadPricePerToken
+}
+
+// CacheCreation1hPriceOrDefault 1小时缓存写入单价（未单独配置时按 Anthropic 规则：输入价格的 2 倍）
+func (p *ModelPricing) CacheCreation1hPriceOrDefault() float64 {
+	if p.CacheCreation1hPricePerToken > 0 {
+		return p.CacheCreation1hPricePerToken
+	}
+	if p.InputPricePerToken > 0 {
+		return p.InputPricePerToken * 2
+	}
+	// 无输入价格时按 5 分钟写入价格（1.25x）换算到 2x
+	return p.CacheCreationPricePerToken * 1.6
 }
 
 // UsageTokens 使用的token数量
 type UsageTokens struct {
-	InputTokens           int
-	OutputTokens          int
-	CacheCreationTokens   int
-	CacheReadTokens       int
+	InputTokens         int
+	OutputTokens        int
+	CacheCreationTokens int
+	CacheReadTokens     int
+	// CacheCreation5mTokens/CacheCreation1hTokens 缓存写入按 TTL 拆分；
+	// CacheCreationTokens 中未被拆分覆盖的部分按 5 分钟写入计费
 	CacheCreation5mTokens int
 	CacheCreation1hTokens int
+	// AudioInputTokens/AudioOutputTokens/AudioCacheReadTokens 分别包含在
+	// InputTokens/OutputTokens/CacheReadTokens 中的音频部分，按音频单价计费
+	AudioInputTokens     int
+	AudioOutputTokens    int
+	AudioCacheReadTokens int
+}
+
+// cacheCreationSplit 返回按 5 分钟与 1 小时计费的缓存写入 token 数
+func (t UsageTokens) cacheCreationSplit() (int, int) {
+	tokens1h := t.CacheCreation1hTokens
+	tokens5m := t.CacheCreationTokens - tokens1h
+	if tokens5m < t.CacheCreation5mTokens {
+		tokens5m = t.CacheCreation5mTokens
+	}
+	return tokens5m, tokens1h
 }
 
 // CostBreakdown 费用明细
 type CostBreakdown struct {
 	InputCost         float64
 	OutputCost        float64
-	CacheCreationCost float64
+	CacheCreationCost float64 // 缓存写入总费用（5 分钟 + 1 小时）
 	CacheReadCost     float64
 	TotalCost         float64
 	ActualCost        float64 // 应用倍率后的实际费用
+
+	CacheCreation5mCost float64
+	CacheCreation1hCost float64
+}
+
+// PricingResolution 模型价格解析结果
+type PricingResolution struct {
+	Pricing *ModelPricing
+	// Source 价格来源：override / litellm / fallback / default / free
+	Source string
+	// Override 命中的自定义价格版本（仅 Source 为 override 时非空）
+	Override *ModelPriceOverride
+}
+
+// CostPreview 管理端试算结果
+type CostPreview struct {
+	Resolution     *PricingResolution
+	RateMultiplier float64
+	Cost           *CostBreakdown
 }
 
 // BillingService 计费服务
 type BillingService struct {
-	cfg            *config.Config
-	pricingService *PricingService
-	fallbackPrices map[string]*ModelPricing // 硬编码回退价格
+	cfg             *config.Config
+	pricingService  *PricingService
+	overrideService *ModelPriceOverrideService
+	fallbackPrices  map[string]*ModelPricing // 硬编码回退价格
 }
 
 // NewBillingService 创建计费服务实例
-func NewBillingService(cfg *config.Config, pricingService *PricingService) *BillingService {
+func NewBillingService(cfg *config.Config, pricingService *PricingService, overrideService *ModelPriceOverrideService) *BillingService {
 	s := &BillingService{
-		cfg:            cfg,
-		pricingService: pricingService,
-		fallbackPrices: make(map[string]*ModelPricing),
+		cfg:             cfg,
+		pricingService:  pricingService,
+		overrideService: overrideService,
+		fallbackPrices:  make(map[string]*ModelPricing),
 	}
 
 	// 初始化硬编码回退价格（当动态价格不可用时使用）
@@ -86,7 +162,6 @@ func (s *BillingService) initFallbackPricing() {
 		OutputPricePerToken:        25e-6,   // $25 per MTok
 		CacheCreationPricePerToken: 6.25e-6, // $6.25 per MTok
 		CacheReadPricePerToken:     0.5e-6,  // $0.50 per MTok
-		SupportsCacheBreakdown:     false,
 	}
 
 	// Claude 4 Sonnet
@@ -95,7 +170,6 @@ func (s *BillingService) initFallbackPricing() {
 		OutputPricePerToken:        15e-6,   // $15 per MTok
 		CacheCreationPricePerToken: 3.75e-6, // $3.75 per MTok
 		CacheReadPricePerToken:     0.3e-6,  // $0.30 per MTok
-		SupportsCacheBreakdown:     false,
 	}
 
 	// Claude 3.5 Sonnet
@@ -104,7 +178,6 @@ func (s *BillingService) initFallbackPricing() {
 		OutputPricePerToken:        15e-6,   // $15 per MTok
 		CacheCreationPricePerToken: 3.75e-6, // $3.75 per MTok
 		CacheReadPricePerToken:     0.3e-6,  // $0.30 per MTok
-		SupportsCacheBreakdown:     false,
 	}
 
 	// Claude 3.5 Haiku
@@ -113,7 +186,6 @@ func (s *BillingService) initFallbackPricing() {
 		OutputPricePerToken:        5e-6,    // $5 per MTok
 		CacheCreationPricePerToken: 1.25e-6, // $1.25 per MTok
 		CacheReadPricePerToken:     0.1e-6,  // $0.10 per MTok
-		SupportsCacheBreakdown:     false,
 	}
 
 	// Claude 3 Opus
@@ -122,7 +194,6 @@ func (s *BillingService) initFallbackPricing() {
 		OutputPricePerToken:        75e-6,    // $75 per MTok
 		CacheCreationPricePerToken: 18.75e-6, // $18.75 per MTok
 		CacheReadPricePerToken:     1.5e-6,   // $1.50 per MTok
-		SupportsCacheBreakdown:     false,
 	}
 
 	// Claude 3 Haiku
@@ -131,7 +202,6 @@ func (s *BillingService) initFallbackPricing() {
 		OutputPricePerToken:        1.25e-6, // $1.25 per MTok
 		CacheCreationPricePerToken: 0.3e-6,  // $0.30 per MTok
 		CacheReadPricePerToken:     0.03e-6, // $0.03 per MTok
-		SupportsCacheBreakdown:     false,
 	}
 }
 
@@ -159,65 +229,182 @@ func (s *BillingService) getFallbackPricing(model string) *ModelPricing {
 		return s.fallbackPrices["claude-3-haiku"]
 	}
 
-	// 默认使用Sonnet价格
-	return s.fallbackPrices["claude-sonnet-4"]
+	// 无法识别的模型由未知价格策略处理
+	return nil
 }
 
-// GetModelPricing 获取模型价格配置
+// GetModelPricing 获取模型价格配置（全局价格，不考虑分组自定义价格）
 func (s *BillingService) GetModelPricing(model string) (*ModelPricing, error) {
+	resolution, err := s.ResolvePricing(model, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resolution.Pricing, nil
+}
+
+// ResolvePricing 解析模型价格，优先级：
+// 管理员自定义价格（分组 > 全局）> LiteLLM 动态价格 > 硬编码回退价格 > 未知价格策略
+func (s *BillingService) ResolvePricing(model string, groupID *int64) (*PricingResolution, error) {
+	return s.resolvePricing(model, groupID, true)
+}
+
+// CheckModelPriced 未知价格策略为 block 时，拒绝没有任何价格的模型（转发前调用）
+func (s *BillingService) CheckModelPriced(model string, groupID *int64) error {
+	if s.overrideService.Policy().Mode != UnknownPriceModeBlock {
+		return nil
+	}
+	if s.resolveKnownPricing(strings.ToLower(model), groupID, false) == nil {
+		return ErrModelPriceUnknown
+	}
+	return nil
+}
+
+func (s *BillingService) resolvePricing(model string, groupID *int64, logUsage bool) (*PricingResolution, error) {
 	// 标准化模型名称（转小写）
 	model = strings.ToLower(model)
 
-	// 1. 优先从动态价格服务获取
+	if resolution := s.resolveKnownPricing(model, groupID, logUsage); resolution != nil {
+		return resolution, nil
+	}
+
+	policy := s.overrideService.Policy()
+	switch policy.Mode {
+	case UnknownPriceModeBlock:
+		return nil, fmt.Errorf("pricing not found for model %s: %w", model, ErrModelPriceUnknown)
+	case UnknownPriceModeFree:
+		if logUsage && s.overrideService.shouldAlertUnknown(model) {
+			log.Printf("ALERT: [Billing] no pricing for model %s, billing as free per unknown price policy", model)
+		}
+		return &PricingResolution{Pricing: &ModelPricing{}, Source: PricingSourceFree}, nil
+	default:
+		if logUsage && s.overrideService.shouldAlertUnknown(model) {
+			log.Printf("[Billing] no pricing for model %s, using default price from unknown price policy", model)
+		}
+		return unknownPolicyPricing(&policy), nil
+	}
+}
+
+// unknownPolicyPricing 按未知价格策略的默认价格计费
+func unknownPolicyPricing(policy *UnknownPricePolicy) *PricingResolution {
+	return &PricingResolution{
+		Pricing: &ModelPricing{
+			InputPricePerToken:           policy.InputPrice / 1_000_000,
+			OutputPricePerToken:          policy.OutputPrice / 1_000_000,
+			CacheCreationPricePerToken:   policy.CacheCreationPrice / 1_000_000,
+			CacheReadPricePerToken:       policy.CacheReadPrice / 1_000_000,
+			CacheCreation1hPricePerToken: policy.CacheCreation1hPrice / 1_000_000,
+		},
+		Source: PricingSourceDefault,
+	}
+}
+
+// resolveKnownPricing 按自定义价格、LiteLLM、硬编码回退的顺序查找价格，均未命中返回 nil
+func (s *BillingService) resolveKnownPricing(model string, groupID *int64, logUsage bool) *PricingResolution {
+	// 1. 管理员自定义价格
+	if override := s.overrideService.Resolve(model, groupID, time.Now()); override != nil {
+		return &PricingResolution{Pricing: override.ToModelPricing(), Source: PricingSourceOverride, Override: override}
+	}
+
+	// 2. 从动态价格服务获取
 	if s.pricingService != nil {
 		litellmPricing := s.pricingService.GetModelPricing(model)
 		if litellmPricing != nil {
-			return &ModelPricing{
-				InputPricePerToken:         litellmPricing.InputCostPerToken,
-				OutputPricePerToken:        litellmPricing.OutputCostPerToken,
-				CacheCreationPricePerToken: litellmPricing.CacheCreationInputTokenCost,
-				CacheReadPricePerToken:     litellmPricing.CacheReadInputTokenCost,
-				SupportsCacheBreakdown:     false,
-			}, nil
+			return &PricingResolution{
+				Pricing: &ModelPricing{
+					InputPricePerToken:           litellmPricing.InputCostPerToken,
+					OutputPricePerToken:          litellmPricing.OutputCostPerToken,
+					CacheCreationPricePerToken:   litellmPricing.CacheCreationInputTokenCost,
+					CacheReadPricePerToken:       litellmPricing.CacheReadInputTokenCost,
+					CacheCreation1hPricePerToken: litellmPricing.CacheCreationInputTokenCostAbove1hr,
+					AudioInputPricePerToken:      litellmPricing.InputCostPerAudioToken,
+					AudioOutputPricePerToken:     litellmPricing.OutputCostPerAudioToken,
+					AudioCacheReadPricePerToken:  litellmPricing.CacheReadInputAudioTokenCost,
+				},
+				Source: PricingSourceLiteLLM,
+			}
 		}
 	}
 
-	// 2. 使用硬编码回退价格
+	// 3. 使用硬编码回退价格
 	fallback := s.getFallbackPricing(model)
 	if fallback != nil {
-		log.Printf("[Billing] Using fallback pricing for model: %s", model)
-		return fallback, nil
+		if logUsage {
+			log.Printf("[Billing] Using fallback pricing for model: %s", model)
+		}
+		return &PricingResolution{Pricing: fallback, Source: PricingSourceFallback}
 	}
-
-	return nil, fmt.Errorf("pricing not found for model: %s", model)
+	return nil
 }
 
 // CalculateCost 计算使用费用
 func (s *BillingService) CalculateCost(model string, tokens UsageTokens, rateMultiplier float64) (*CostBreakdown, error) {
-	pricing, err := s.GetModelPricing(model)
+	return s.CalculateCostForGroup(model, nil, tokens, rateMultiplier)
+}
+
+// CalculateCostForGroup 计算使用费用（应用分组自定义价格）
+//
+// 用于请求完成后的计费：block 策略只在转发前拦截，若转发前检查通过、计费时价格已消失
+// （LiteLLM 刷新、自定义价格被删除），按策略中的默认价格计费（未配置时使用内置默认价格），不记为 0。
+func (s *BillingService) CalculateCostForGroup(model string, groupID *int64, tokens UsageTokens, rateMultiplier float64) (*CostBreakdown, error) {
+	resolution, err := s.ResolvePricing(model, groupID)
+	if errors.Is(err, ErrModelPriceUnknown) {
+		policy := s.overrideService.Policy()
+		if policy.InputPrice == 0 && policy.OutputPrice == 0 {
+			policy = *DefaultUnknownPricePolicy()
+		}
+		log.Printf("ALERT: [Billing] pricing for model %s disappeared after the request was accepted, billing default price from unknown price policy", model)
+		resolution, err = unknownPolicyPricing(&policy), nil
+	}
 	if err != nil {
 		return nil, err
 	}
+	return calculateCostWithPricing(resolution.Pricing, tokens, rateMultiplier), nil
+}
+
+// PreviewCost 管理端试算：返回价格来源与费用明细（不产生告警日志）
+// rateMultiplier <= 0 时使用配置中的默认倍率。
+func (s *BillingService) PreviewCost(model string, groupID *int64, tokens UsageTokens, rateMultiplier float64) (*CostPreview, error) {
+	resolution, err := s.resolvePricing(model, groupID, false)
+	if err != nil {
+		return nil, err
+	}
+	if rateMultiplier <= 0 && s.cfg != nil {
+		rateMultiplier = s.cfg.Default.RateMultiplier
+	}
+	if rateMultiplier <= 0 {
+		rateMultiplier = 1.0
+	}
+	return &CostPreview{
+		Resolution:     resolution,
+		RateMultiplier: rateMultiplier,
+		Cost:           calculateCostWithPricing(resolution.Pricing, tokens, rateMultiplier),
+	}, nil
+}
 
+func calculateCostWithPricing(pricing *ModelPricing, tokens UsageTokens, rateMultiplier float64) *CostBreakdown {
 	breakdown := &CostBreakdown{}
 
-	// 计算输入token费用（使用per-token价格）
-	breakdown.InputCost = float64(tokens.InputTokens) * pricing.InputPricePerToken
+	// 计算输入token费用（使用per-token价格，音频部分按音频单价）
+	audioInput := min(max(tokens.AudioInputTokens, 0), tokens.InputTokens)
+	breakdown.InputCost = float64(tokens.InputTokens-audioInput)*pricing.InputPricePerToken +
+		float64(audioInput)*pricing.audioInputPrice()
 
 	// 计算输出token费用
-	breakdown.OutputCost = float64(tokens.OutputTokens) * pricing.OutputPricePerToken
-
-	// 计算缓存费用
-	if pricing.SupportsCacheBreakdown && (pricing.CacheCreation5mPrice > 0 || pricing.CacheCreation1hPrice > 0) {
-		// 支持详细缓存分类的模型（5分钟/1小时缓存）
-		breakdown.CacheCreationCost = float64(tokens.CacheCreation5mTokens)/1_000_000*pricing.CacheCreation5mPrice +
-			float64(tokens.CacheCreation1hTokens)/1_000_000*pricing.CacheCreation1hPrice
-	} else {
-		// 标准缓存创建价格（per-token）
-		breakdown.CacheCreationCost = float64(tokens.CacheCreationTokens) * pricing.CacheCreationPricePerToken
+	audioOutput := min(max(tokens.AudioOutputTokens, 0), tokens.OutputTokens)
+	breakdown.OutputCost = float64(tokens.OutputTokens-audioOutput)*pricing.OutputPricePerToken +
+		float64(audioOutput)*pricing.audioOutputPrice()
+
+	// 计算缓存写入费用（5 分钟与 1 小时缓存分别计价）
+	tokens5m, tokens1h := tokens.cacheCreationSplit()
+	breakdown.CacheCreation5mCost = float64(tokens5m) * pricing.CacheCreationPricePerToken
+	if tokens1h > 0 {
+		breakdown.CacheCreation1hCost = float64(tokens1h) * pricing.CacheCreation1hPriceOrDefault()
 	}
+	breakdown.CacheCreationCost = breakdown.CacheCreation5mCost + breakdown.CacheCreation1hCost
 
-	breakdown.CacheReadCost = float64(tokens.CacheReadTokens) * pricing.CacheReadPricePerToken
+	audioCacheRead := min(max(tokens.AudioCacheReadTokens, 0), tokens.CacheReadTokens)
+	breakdown.CacheReadCost = float64(tokens.CacheReadTokens-audioCacheRead)*pricing.CacheReadPricePerToken +
+		float64(audioCacheRead)*pricing.audioCacheReadPrice()
 
 	// 计算总费用
 	breakdown.TotalCost = breakdown.InputCost + breakdown.OutputCost +
@@ -229,7 +416,7 @@ func (s *BillingService) CalculateCost(model string, tokens UsageTokens, rateMul
 	}
 	breakdown.ActualCost = breakdown.TotalCost * rateMultiplier
 
-	return breakdown, nil
+	return breakdown
 }
 
 // CalculateCostWithConfig 使用配置中的默认倍率计算费用
@@ -332,6 +519,43 @@ func (s *BillingService) CalculateImageCost(model string, imageSize string, imag
 	}
 }
 
+// CalculateStorageCost 计算 Gemini 资源存储费用：显式缓存按 token·小时，文件按 GB·天。
+// 缓存存储费用计入 CacheCreationCost。
+func (s *BillingService) CalculateStorageCost(model string, tokenHours, gbDays float64, rateMultiplier float64) *CostBreakdown {
+	var cacheCost, fileCost float64
+	if s.cfg != nil {
+		resources := s.cfg.Current().Gateway.GeminiResources
+		if tokenHours > 0 {
+			cacheCost = tokenHours / 1_000_000 * cacheStoragePrice(resources.CacheStoragePricePerMTokHour, resources.CacheStorageModelPrices, model)
+		}
+		if gbDays > 0 {
+			fileCost = gbDays * resources.FileStoragePricePerGBDay
+		}
+	}
+	if rateMultiplier <= 0 {
+		rateMultiplier = 1.0
+	}
+	totalCost := cacheCost + fileCost
+	return &CostBreakdown{
+		CacheCreationCost: cacheCost,
+		TotalCost:         totalCost,
+		ActualCost:        totalCost * rateMultiplier,
+	}
+}
+
+// cacheStoragePrice 按模型名前缀最长匹配覆盖单价
+func cacheStoragePrice(defaultPrice float64, overrides map[string]float64, model string) float64 {
+	model = strings.TrimPrefix(strings.ToLower(model), "models/")
+	price, matched := defaultPrice, ""
+	for prefix, p := range overrides {
+		prefix = strings.ToLower(prefix)
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(matched) {
+			price, matched = p, prefix
+		}
+	}
+	return price
+}
+
 // getImageUnitPrice 获取图片单价
 func (s *BillingService) getImageUnitPrice(model string, imageSize string, groupConfig *ImagePriceConfig) float64 {
 	// 优先使用分组配置的价格
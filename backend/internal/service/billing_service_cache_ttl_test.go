@@ -0,0 +1,65 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCalculateCost_CacheCreationTTLSplit 1 小时缓存写入按输入价格 2 倍计费，其余按 5 分钟价格
+func TestCalculateCost_CacheCreationTTLSplit(t *testing.T) {
+	svc := NewBillingService(&config.Config{}, nil, nil)
+
+	cost, err := svc.CalculateCost("claude-sonnet-4-5", UsageTokens{
+		CacheCreationTokens:   3_000_000,
+		CacheCreation5mTokens: 1_000_000,
+		CacheCreation1hTokens: 2_000_000,
+	}, 1.0)
+	require.NoError(t, err)
+	require.InDelta(t, 3.75, cost.CacheCreation5mCost, 1e-9)
+	require.InDelta(t, 12.0, cost.CacheCreation1hCost, 1e-9)
+	require.InDelta(t, 15.75, cost.CacheCreationCost, 1e-9)
+	require.InDelta(t, 15.75, cost.TotalCost, 1e-9)
+}
+
+// TestCalculateCost_CacheCreationWithoutSplit 上游未返回拆分时全部按 5 分钟价格计费
+func TestCalculateCost_CacheCreationWithoutSplit(t *testing.T) {
+	svc := NewBillingService(&config.Config{}, nil, nil)
+
+	cost, err := svc.CalculateCost("claude-sonnet-4-5", UsageTokens{CacheCreationTokens: 1_000_000}, 1.0)
+	require.NoError(t, err)
+	require.InDelta(t, 3.75, cost.CacheCreationCost, 1e-9)
+	require.Zero(t, cost.CacheCreation1hCost)
+}
+
+// TestCalculateCost_CacheCreation1hExplicitPrice 显式配置的 1 小时价格优先于 2 倍输入价格
+func TestCalculateCost_CacheCreation1hExplicitPrice(t *testing.T) {
+	cost := calculateCostWithPricing(&ModelPricing{
+		InputPricePerToken:           3e-6,
+		CacheCreationPricePerToken:   3.75e-6,
+		CacheCreation1hPricePerToken: 5e-6,
+	}, UsageTokens{CacheCreationTokens: 1_000_000, CacheCreation1hTokens: 1_000_000}, 2.0)
+	require.InDelta(t, 5.0, cost.CacheCreation1hCost, 1e-9)
+	require.Zero(t, cost.CacheCreation5mCost)
+	require.InDelta(t, 10.0, cost.ActualCost, 1e-9)
+}
+
+func TestParseSSEUsage_CacheCreationSplit(t *testing.T) {
+	svc := &GatewayService{}
+	usage := &ClaudeUsage{}
+
+	svc.parseSSEUsage(`{"type":"message_start","message":{"usage":{"input_tokens":10,"cache_creation_input_tokens":300,"cache_read_input_tokens":5,"cache_creation":{"ephemeral_5m_input_tokens":100,"ephemeral_1h_input_tokens":200}}}}`, usage)
+	svc.parseSSEUsage(`{"type":"message_delta","usage":{"output_tokens":42}}`, usage)
+
+	require.Equal(t, 300, usage.CacheCreationInputTokens)
+	require.Equal(t, ClaudeCacheCreation{Ephemeral5mInputTokens: 100, Ephemeral1hInputTokens: 200}, usage.CacheCreation)
+	require.Equal(t, 42, usage.OutputTokens)
+
+	tokens := usage.BillingTokens()
+	tokens5m, tokens1h := tokens.cacheCreationSplit()
+	require.Equal(t, 100, tokens5m)
+	require.Equal(t, 200, tokens1h)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -66,10 +67,15 @@ func NewConcurrencyService(cache ConcurrencyCache) *ConcurrencyService {
 	return &ConcurrencyService{cache: cache}
 }
 
+// ErrConcurrencySlotLost 刷新槽位时发现槽位已过期被清理且无法重新占用
+var ErrConcurrencySlotLost = errors.New("concurrency slot expired and could not be re-acquired")
+
 // AcquireResult represents the result of acquiring a concurrency slot
 type AcquireResult struct {
 	Acquired    bool
 	ReleaseFunc func() // Must be called when done (typically via defer)
+	// RefreshFunc 刷新槽位时间戳，长连接（如 Realtime 会话）需在槽位 TTL 内定期调用；无并发限制时为 nil
+	RefreshFunc func(ctx context.Context) error
 }
 
 type AccountWithConcurrency struct {
@@ -114,6 +120,13 @@ func (s *ConcurrencyService) AcquireAccountSlot(ctx context.Context, accountID i
 					log.Printf("Warning: failed to release account slot for %d (req=%s): %v", accountID, requestID, err)
 				}
 			},
+			RefreshFunc: func(ctx context.Context) error {
+				ok, err := s.cache.AcquireAccountSlot(ctx, accountID, maxConcurrency, requestID)
+				if err == nil && !ok {
+					err = ErrConcurrencySlotLost
+				}
+				return err
+			},
 		}, nil
 	}
 
@@ -153,6 +166,13 @@ func (s *ConcurrencyService) AcquireUserSlot(ctx context.Context, userID int64,
 					log.Printf("Warning: failed to release user slot for %d (req=%s): %v", userID, requestID, err)
 				}
 			},
+			RefreshFunc: func(ctx context.Context) error {
+				ok, err := s.cache.AcquireUserSlot(ctx, userID, maxConcurrency, requestID)
+				if err == nil && !ok {
+					err = ErrConcurrencySlotLost
+				}
+				return err
+			},
 		}, nil
 	}
 
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tlsfingerprint"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// 配置重新加载的触发方式
+const (
+	ConfigReloadTriggerFile   = "file"
+	ConfigReloadTriggerSignal = "signal"
+	ConfigReloadTriggerManual = "manual"
+)
+
+// configReloadDebounce 编辑器保存时会产生多次写入 / 重命名事件，合并为一次重新加载
+const configReloadDebounce = 500 * time.Millisecond
+
+var ErrConfigReloadInvalid = infraerrors.BadRequest("CONFIG_RELOAD_INVALID", "new config is invalid; current config kept")
+
+// ConfigReloadStatus 最近一次重新加载的结果
+type ConfigReloadStatus struct {
+	Trigger  string
+	At       time.Time
+	Success  bool
+	Error    string
+	Applied  []string
+	Restart  []string
+	FilePath string
+}
+
+// ConfigReloadService 配置热更新：监听配置文件变化与 SIGHUP，重新读取并校验配置后，
+// 将可热更新的分区（gateway / rate_limit / pricing）生成新的配置快照，原子发布到各服务共享的 *config.Config。
+//
+// 校验失败时保留当前配置；其他分区（数据库 / Redis 连接、服务端口等）的修改只记录为需要重启。
+// 服务在处理请求时经由 cfg.Current() 读取快照，新值对之后的请求生效，进行中的流式请求不受影响。
+type ConfigReloadService struct {
+	cfg    *config.Config
+	loader func(current *config.Config) (*config.Config, error)
+
+	mu        sync.Mutex
+	last      *ConfigReloadStatus
+	listeners []func(cfg *config.Config, result *config.ReloadResult)
+
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewConfigReloadService 创建配置热更新服务
+func NewConfigReloadService(cfg *config.Config) *ConfigReloadService {
+	if cfg != nil {
+		cfg.EnableReload()
+	}
+	return &ConfigReloadService{
+		cfg:    cfg,
+		loader: config.Reload,
+		stopCh: make(chan struct{}),
+	}
+}
+
+// OnReload 注册配置变更回调（在重新加载的调用方 goroutine 中同步执行，参数为新发布的快照），用于刷新启动时构建的派生状态
+func (s *ConfigReloadService) OnReload(fn func(cfg *config.Config, result *config.ReloadResult)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// Start 监听 SIGHUP 与配置文件变化
+func (s *ConfigReloadService) Start() {
+	if s == nil || s.cfg == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGHUP)
+
+		var events <-chan fsnotify.Event
+		var watchErrors <-chan error
+		var watcher *fsnotify.Watcher
+		file := viper.ConfigFileUsed()
+		if file != "" {
+			w, err := fsnotify.NewWatcher()
+			if err == nil {
+				// 监听所在目录：编辑器保存与 Kubernetes ConfigMap 更新都会替换文件本身
+				err = w.Add(filepath.Dir(file))
+			}
+			if err != nil {
+				log.Printf("[ConfigReload] watch %s failed, only SIGHUP reload is available: %v", file, err)
+				if w != nil {
+					_ = w.Close()
+				}
+			} else {
+				watcher = w
+				events = w.Events
+				watchErrors = w.Errors
+			}
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer signal.Stop(sigCh)
+			if watcher != nil {
+				defer func() { _ = watcher.Close() }()
+			}
+
+			var debounce *time.Timer
+			var debounceC <-chan time.Time
+			for {
+				select {
+				case <-sigCh:
+					_, _ = s.Reload(ConfigReloadTriggerSignal)
+				case event, ok := <-events:
+					if !ok {
+						events = nil
+						continue
+					}
+					if !isConfigFileEvent(event, file) {
+						continue
+					}
+					if debounce == nil {
+						debounce = time.NewTimer(configReloadDebounce)
+					} else {
+						debounce.Reset(configReloadDebounce)
+					}
+					debounceC = debounce.C
+				case <-debounceC:
+					debounceC = nil
+					_, _ = s.Reload(ConfigReloadTriggerFile)
+				case err, ok := <-watchErrors:
+					if !ok {
+						watchErrors = nil
+						continue
+					}
+					log.Printf("[ConfigReload] watcher error: %v", err)
+				case <-s.stopCh:
+					if debounce != nil {
+						debounce.Stop()
+					}
+					return
+				}
+			}
+		}()
+		if file != "" {
+			log.Printf("[ConfigReload] watching %s (send SIGHUP to reload manually)", file)
+		}
+	})
+}
+
+// Stop 停止监听
+func (s *ConfigReloadService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+		s.wg.Wait()
+	})
+}
+
+// isConfigFileEvent 判断目录事件是否涉及配置文件（含 ConfigMap 的 ..data 符号链接切换）
+func isConfigFileEvent(event fsnotify.Event, file string) bool {
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+		return false
+	}
+	name := filepath.Clean(event.Name)
+	return name == filepath.Clean(file) || filepath.Base(name) == "..data"
+}
+
+// Reload 重新读取并校验配置，发布可热更新分区的新快照；校验失败时返回 ErrConfigReloadInvalid 并保留当前配置
+func (s *ConfigReloadService) Reload(trigger string) (*config.ReloadResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := &ConfigReloadStatus{Trigger: trigger, At: time.Now(), FilePath: viper.ConfigFileUsed()}
+	s.last = status
+
+	next, err := s.loader(s.cfg)
+	if err != nil {
+		status.Error = err.Error()
+		log.Printf("[ConfigReload] %s reload rejected, keeping current config: %v", trigger, err)
+		return nil, infraerrors.BadRequest(infraerrors.Reason(ErrConfigReloadInvalid), err.Error())
+	}
+
+	result := config.ApplyReloadable(s.cfg, next)
+	status.Success = true
+	status.Applied = result.Applied
+	status.Restart = result.RestartRequired
+	if len(result.Applied) > 0 {
+		for _, fn := range s.listeners {
+			fn(s.cfg.Current(), result)
+		}
+		log.Printf("[ConfigReload] %s reload applied: %v", trigger, result.Applied)
+	}
+	if len(result.RestartRequired) > 0 {
+		log.Printf("[ConfigReload] changes require restart to take effect: %v", result.RestartRequired)
+	}
+	if len(result.Applied) == 0 && len(result.RestartRequired) == 0 {
+		log.Printf("[ConfigReload] %s reload: no changes", trigger)
+	}
+	return result, nil
+}
+
+// LastStatus 返回最近一次重新加载的结果（尚未重新加载时为 nil）
+func (s *ConfigReloadService) LastStatus() *ConfigReloadStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last == nil {
+		return nil
+	}
+	status := *s.last
+	return &status
+}
+
+// EffectiveConfig 返回当前生效的配置，密钥类字段已脱敏
+func (s *ConfigReloadService) EffectiveConfig() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return config.Redact(s.cfg.Current())
+}
+
+// applyTLSFingerprintProfiles 按配置刷新全局 TLS 指纹模板（新建的上游客户端生效）；result 为 nil 时无条件刷新
+func applyTLSFingerprintProfiles(cfg *config.Config, result *config.ReloadResult) {
+	if result != nil && !hasConfigKeyPrefix(result.Applied, "gateway.tls_fingerprint.") {
+		return
+	}
+	tlsfingerprint.GlobalRegistry().ApplyConfig(&cfg.Gateway.TLSFingerprint)
+}
+
+func hasConfigKeyPrefix(keys []string, prefix string) bool {
+	for _, key := range keys {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+//go:build unit
+
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigReloadService_AppliesReloadableSections(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Gateway.MaxAccountSwitches = 10
+	cfg.Server.Port = 8080
+
+	svc := NewConfigReloadService(cfg)
+	svc.loader = func(current *config.Config) (*config.Config, error) {
+		next := *current
+		next.Gateway.MaxAccountSwitches = 2
+		next.Server.Port = 9090
+		return &next, nil
+	}
+	var notified *config.ReloadResult
+	var published *config.Config
+	svc.OnReload(func(snapshot *config.Config, result *config.ReloadResult) {
+		published, notified = snapshot, result
+	})
+
+	result, err := svc.Reload(ConfigReloadTriggerManual)
+	require.NoError(t, err)
+	require.Equal(t, []string{"gateway.max_account_switches"}, result.Applied)
+	require.Equal(t, []string{"server.port"}, result.RestartRequired)
+	require.Same(t, result, notified)
+	require.Same(t, cfg.Current(), published)
+	require.Equal(t, 2, cfg.Current().Gateway.MaxAccountSwitches)
+	require.Equal(t, 8080, cfg.Current().Server.Port)
+	// 已发布的快照之外的配置不做修改
+	require.Equal(t, 10, cfg.Gateway.MaxAccountSwitches)
+
+	status := svc.LastStatus()
+	require.NotNil(t, status)
+	require.True(t, status.Success)
+	require.Equal(t, ConfigReloadTriggerManual, status.Trigger)
+}
+
+func TestConfigReloadService_ConcurrentReadsDuringReload(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Gateway.Realtime.MaxSessionMinutes = 1
+
+	svc := NewConfigReloadService(cfg)
+	n := 1
+	svc.loader = func(current *config.Config) (*config.Config, error) {
+		n++
+		next := *current.Current()
+		next.Gateway.Realtime.MaxSessionMinutes = n
+		return &next, nil
+	}
+	realtime := NewOpenAIRealtimeService(nil, nil, cfg)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if realtime.Config().MaxSessionMinutes < 1 {
+					t.Error("observed zero max_session_minutes")
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		_, err := svc.Reload(ConfigReloadTriggerManual)
+		require.NoError(t, err)
+	}
+	close(done)
+	wg.Wait()
+
+	require.Equal(t, 101, realtime.Config().MaxSessionMinutes)
+}
+
+func TestConfigReloadService_InvalidConfigKeepsCurrent(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Gateway.MaxAccountSwitches = 10
+
+	svc := NewConfigReloadService(cfg)
+	svc.loader = func(*config.Config) (*config.Config, error) {
+		return nil, errors.New("gateway.max_account_switches must be non-negative")
+	}
+	called := false
+	svc.OnReload(func(*config.Config, *config.ReloadResult) { called = true })
+
+	_, err := svc.Reload(ConfigReloadTriggerSignal)
+	require.Error(t, err)
+	require.Equal(t, infraerrors.Reason(ErrConfigReloadInvalid), infraerrors.Reason(err))
+	require.False(t, called)
+	require.Equal(t, 10, cfg.Current().Gateway.MaxAccountSwitches)
+
+	status := svc.LastStatus()
+	require.False(t, status.Success)
+	require.Contains(t, status.Error, "non-negative")
+}
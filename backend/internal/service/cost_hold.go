@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+var (
+	ErrCostHoldInsufficientBalance = infraerrors.Forbidden("INSUFFICIENT_BALANCE_FOR_REQUEST", "insufficient balance for the estimated cost of this request, lower max_tokens or top up")
+	ErrCostHoldQuotaExceeded       = infraerrors.TooManyRequests("SUBSCRIPTION_QUOTA_RESERVED", "subscription quota is reserved by in-flight requests, lower max_tokens or retry later")
+)
+
+// CostHoldCache 费用预扣存储
+//
+// 同一 scope（余额付费方或订阅窗口）下的预扣以原子方式登记：
+// 已有未过期预扣总额 + amount 超过 limit 时拒绝。
+type CostHoldCache interface {
+	// Reserve 登记预扣，返回是否成功以及登记后（失败时为当前）的预扣总额
+	Reserve(ctx context.Context, scope, holdID string, amount, limit float64, ttl time.Duration) (bool, float64, error)
+	// Release 释放预扣（不存在时忽略）
+	Release(ctx context.Context, scope, holdID string) error
+}
+
+// CostHold 一次请求的费用预扣
+type CostHold struct {
+	ID     string
+	Scope  string
+	Amount float64
+
+	releaseOnce sync.Once
+}
+
+// Retain 保留预扣直至 TTL 过期，之后的 Release 不再生效
+//
+// 扣费未能在入账时完成（余额缓存尚未扣减）时调用，避免释放后并发请求按未扣减的余额通过检查。
+func (h *CostHold) Retain() {
+	if h == nil {
+		return
+	}
+	h.releaseOnce.Do(func() {})
+}
+
+// CostHoldInput 预扣估算所需的请求信息
+type CostHoldInput struct {
+	User         *User
+	APIKey       *APIKey
+	Group        *Group
+	Subscription *UserSubscription
+	Model        string
+	Body         []byte
+}
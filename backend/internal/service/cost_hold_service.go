@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// costHoldBinaryMinLen 不含空白且超过该长度的字符串视为 base64 图片/文件，按固定 token 数估算
+	costHoldBinaryMinLen = 4096
+	costHoldBinaryTokens = 1600
+	// costHoldCharsPerToken 输入文本按每 3 个字符 1 个 token 粗略估算（偏保守）
+	costHoldCharsPerToken = 3
+)
+
+// costHoldMaxTokensPaths 各平台请求体中的最大输出 token 字段
+var costHoldMaxTokensPaths = []string{
+	"max_tokens",
+	"max_output_tokens",
+	"max_completion_tokens",
+	"generationConfig.maxOutputTokens",
+}
+
+// CostHoldService 请求前费用预扣
+//
+// 转发前按输入 token 估算与 max_tokens 计算本次请求的最大费用，在 Redis 中预扣付费方余额
+// （或订阅窗口剩余额度）；使用量入账后或请求失败时释放。预扣总额不得超过可用额度加分组透支容忍额度。
+type CostHoldService struct {
+	cache                 CostHoldCache
+	billingService        *BillingService
+	billingCacheService   *BillingCacheService
+	rateMultiplierService *RateMultiplierService
+	resellerService       *ResellerService
+	cfg                   *config.Config
+}
+
+// NewCostHoldService 创建费用预扣服务
+func NewCostHoldService(cache CostHoldCache, billingService *BillingService, billingCacheService *BillingCacheService, rateMultiplierService *RateMultiplierService, resellerService *ResellerService, cfg *config.Config) *CostHoldService {
+	return &CostHoldService{
+		cache:                 cache,
+		billingService:        billingService,
+		billingCacheService:   billingCacheService,
+		rateMultiplierService: rateMultiplierService,
+		resellerService:       resellerService,
+		cfg:                   cfg,
+	}
+}
+
+func (s *CostHoldService) enabled() bool {
+	return s != nil && s.cache != nil && s.cfg != nil &&
+		s.cfg.Billing.CostHold.Enabled && s.cfg.RunMode != config.RunModeSimple
+}
+
+// Reserve 估算并预扣本次请求的最大费用
+//
+// 返回 nil 表示无需预扣（未启用、无法估算价格或订阅无限额）。
+func (s *CostHoldService) Reserve(ctx context.Context, input *CostHoldInput) (*CostHold, error) {
+	if !s.enabled() || input == nil {
+		return nil, nil
+	}
+	payer, err := ResolveBillingPayer(input.APIKey, input.User)
+	if err != nil {
+		return nil, err
+	}
+
+	var groupID *int64
+	if input.Group != nil {
+		groupID = &input.Group.ID
+	}
+	multiplier := s.rateMultiplierService.Resolve(ctx, input.APIKey, input.Group).Multiplier
+	cost, err := s.billingService.CalculateCostForGroup(input.Model, groupID, UsageTokens{
+		InputTokens:  estimateInputTokens(input.Body),
+		OutputTokens: s.maxOutputTokens(input.Body),
+	}, multiplier)
+	if err != nil {
+		// 无价格的模型由未知价格策略处理，这里不阻断
+		return nil, nil
+	}
+
+	isSubscription := input.Group != nil && input.Group.IsSubscriptionType() && input.Subscription != nil
+	var (
+		scope  string
+		amount float64
+		limit  float64
+	)
+	if isSubscription {
+		// 订阅模式按原始费用占用窗口额度
+		amount = cost.TotalCost
+		headroom, limited, err := s.subscriptionHeadroom(ctx, payer.ID, input.Group, input.Subscription)
+		if err != nil {
+			return nil, err
+		}
+		if !limited {
+			return nil, nil
+		}
+		scope = fmt.Sprintf("sub:%d:%d", payer.ID, input.Group.ID)
+		limit = headroom
+	} else {
+		amount = cost.ActualCost
+		// 分销下级用户入账时叠加各级分销商加价，预扣按加价后的费用计算
+		if quote := s.resellerService.Quote(ctx, payer); quote != nil {
+			amount *= quote.Markup()
+		}
+		balance, err := s.billingCacheService.GetUserBalance(ctx, payer.ID)
+		if err != nil {
+			log.Printf("ALERT: cost hold balance lookup failed for user %d: %v", payer.ID, err)
+			return nil, ErrBillingServiceUnavailable.WithCause(err)
+		}
+		scope = fmt.Sprintf("user:%d", payer.ID)
+		limit = balance
+	}
+	if amount <= 0 {
+		return nil, nil
+	}
+	if input.Group != nil {
+		limit += input.Group.OverdraftUSD
+	}
+
+	hold := &CostHold{ID: uuid.NewString(), Scope: scope, Amount: amount}
+	ttl := time.Duration(s.cfg.Billing.CostHold.TTLSeconds) * time.Second
+	ok, held, err := s.cache.Reserve(ctx, scope, hold.ID, amount, limit, ttl)
+	if err != nil {
+		log.Printf("ALERT: cost hold reserve failed for %s: %v", scope, err)
+		return nil, ErrBillingServiceUnavailable.WithCause(err)
+	}
+	if !ok {
+		log.Printf("Cost hold rejected: scope=%s estimate=%.6f held=%.6f limit=%.6f model=%s", scope, amount, held, limit, input.Model)
+		if isSubscription {
+			return nil, ErrCostHoldQuotaExceeded
+		}
+		return nil, ErrCostHoldInsufficientBalance
+	}
+	return hold, nil
+}
+
+// Release 释放预扣：使用量入账（实际费用已写入余额缓存）后或请求失败时调用，可重复调用
+func (s *CostHoldService) Release(ctx context.Context, hold *CostHold) {
+	if s == nil || s.cache == nil || hold == nil {
+		return
+	}
+	hold.releaseOnce.Do(func() {
+		if err := s.cache.Release(ctx, hold.Scope, hold.ID); err != nil {
+			// 释放失败时预扣会在 TTL 后自动过期
+			log.Printf("Warning: release cost hold %s for %s failed: %v", hold.ID, hold.Scope, err)
+		}
+	})
+}
+
+// subscriptionHeadroom 返回订阅各窗口剩余额度的最小值；所有窗口均无限额时 limited 为 false
+func (s *CostHoldService) subscriptionHeadroom(ctx context.Context, userID int64, group *Group, subscription *UserSubscription) (float64, bool, error) {
+	subData, err := s.billingCacheService.GetSubscriptionStatus(ctx, userID, group.ID)
+	if err != nil {
+		log.Printf("ALERT: cost hold subscription lookup failed for user %d group %d: %v", userID, group.ID, err)
+		return 0, false, ErrBillingServiceUnavailable.WithCause(err)
+	}
+	group = subscription.EffectiveGroup(group)
+
+	headroom := math.Inf(1)
+	if group.HasDailyLimit() {
+		headroom = math.Min(headroom, *group.DailyLimitUSD-subData.DailyUsage)
+	}
+	if group.HasWeeklyLimit() {
+		headroom = math.Min(headroom, *group.WeeklyLimitUSD-subData.WeeklyUsage)
+	}
+	if group.HasMonthlyLimit() {
+		headroom = math.Min(headroom, *group.MonthlyLimitUSD-subData.MonthlyUsage)
+	}
+	if math.IsInf(headroom, 1) {
+		return 0, false, nil
+	}
+	return headroom, true, nil
+}
+
+// maxOutputTokens 请求体中的最大输出 token 数，未指定时使用配置默认值
+func (s *CostHoldService) maxOutputTokens(body []byte) int {
+	for _, path := range costHoldMaxTokensPaths {
+		if v := gjson.GetBytes(body, path).Int(); v > 0 {
+			return int(v)
+		}
+	}
+	return s.cfg.Billing.CostHold.DefaultMaxOutputTokens
+}
+
+// estimateInputTokens 粗略估算请求输入 token 数：累计所有字符串字段的字符数，
+// 疑似 base64 的大段二进制内容（图片/文件）按固定 token 数计算。
+func estimateInputTokens(body []byte) int {
+	chars, binaries := 0, 0
+	var walk func(r gjson.Result)
+	walk = func(r gjson.Result) {
+		switch {
+		case r.IsObject() || r.IsArray():
+			r.ForEach(func(_, v gjson.Result) bool {
+				walk(v)
+				return true
+			})
+		case r.Type == gjson.String:
+			if len(r.Str) >= costHoldBinaryMinLen && !strings.ContainsAny(r.Str[:costHoldBinaryMinLen], " \n") {
+				binaries++
+				return
+			}
+			chars += utf8.RuneCountInString(r.Str)
+		}
+	}
+	walk(gjson.ParseBytes(body))
+	return (chars+costHoldCharsPerToken-1)/costHoldCharsPerToken + binaries*costHoldBinaryTokens
+}
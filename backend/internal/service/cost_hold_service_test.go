@@ -0,0 +1,134 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type costHoldCacheStub struct {
+	holds map[string]map[string]float64
+}
+
+func newCostHoldCacheStub() *costHoldCacheStub {
+	return &costHoldCacheStub{holds: map[string]map[string]float64{}}
+}
+
+func (c *costHoldCacheStub) Reserve(ctx context.Context, scope, holdID string, amount, limit float64, ttl time.Duration) (bool, float64, error) {
+	held := 0.0
+	for _, v := range c.holds[scope] {
+		held += v
+	}
+	if held+amount > limit {
+		return false, held, nil
+	}
+	if c.holds[scope] == nil {
+		c.holds[scope] = map[string]float64{}
+	}
+	c.holds[scope][holdID] = amount
+	return true, held + amount, nil
+}
+
+func (c *costHoldCacheStub) Release(ctx context.Context, scope, holdID string) error {
+	delete(c.holds[scope], holdID)
+	return nil
+}
+
+func newCostHoldTestService(balance float64) (*CostHoldService, *costHoldCacheStub) {
+	return newCostHoldTestServiceWithReseller(balance, nil)
+}
+
+func newCostHoldTestServiceWithReseller(balance float64, resellerService *ResellerService) (*CostHoldService, *costHoldCacheStub) {
+	cfg := &config.Config{}
+	cfg.Default.RateMultiplier = 1
+	cfg.Billing.CostHold = config.CostHoldConfig{Enabled: true, TTLSeconds: 600, DefaultMaxOutputTokens: 4096}
+	cache := newCostHoldCacheStub()
+	billingCache := &BillingCacheService{userRepo: &paymentUserRepoStub{balances: map[int64]float64{7: balance}}, cfg: cfg}
+	return NewCostHoldService(cache, NewBillingService(cfg, nil, nil), billingCache, nil, resellerService, cfg), cache
+}
+
+func TestCostHoldService_ReserveRespectsBalanceAndOverdraft(t *testing.T) {
+	// max_tokens=10000 的 claude-sonnet-4-5 输出上限约 $0.15
+	body := []byte(`{"model":"claude-sonnet-4-5","max_tokens":10000,"messages":[{"role":"user","content":"hi"}]}`)
+	user := &User{ID: 7}
+	group := &Group{ID: 1, RateMultiplier: 1}
+
+	svc, _ := newCostHoldTestService(0.1)
+	_, err := svc.Reserve(context.Background(), &CostHoldInput{User: user, Group: group, Model: "claude-sonnet-4-5", Body: body})
+	require.ErrorIs(t, err, ErrCostHoldInsufficientBalance)
+
+	group.OverdraftUSD = 0.1
+	hold, err := svc.Reserve(context.Background(), &CostHoldInput{User: user, Group: group, Model: "claude-sonnet-4-5", Body: body})
+	require.NoError(t, err)
+	require.NotNil(t, hold)
+	require.Equal(t, "user:7", hold.Scope)
+
+	// 并发的第二个请求超出余额 + 透支额度
+	_, err = svc.Reserve(context.Background(), &CostHoldInput{User: user, Group: group, Model: "claude-sonnet-4-5", Body: body})
+	require.ErrorIs(t, err, ErrCostHoldInsufficientBalance)
+
+	svc.Release(context.Background(), hold)
+	svc.Release(context.Background(), hold)
+	hold, err = svc.Reserve(context.Background(), &CostHoldInput{User: user, Group: group, Model: "claude-sonnet-4-5", Body: body})
+	require.NoError(t, err)
+	require.NotNil(t, hold)
+}
+
+func TestCostHoldService_ReserveIncludesResellerMarkup(t *testing.T) {
+	body := []byte(`{"model":"claude-sonnet-4-5","max_tokens":10000,"messages":[{"role":"user","content":"hi"}]}`)
+	parentID := int64(3)
+	subUser := &User{ID: 7, ParentUserID: &parentID}
+	group := &Group{ID: 1, RateMultiplier: 1}
+
+	base, _ := newCostHoldTestService(100)
+	plain, err := base.Reserve(context.Background(), &CostHoldInput{User: &User{ID: 7}, Group: group, Model: "claude-sonnet-4-5", Body: body})
+	require.NoError(t, err)
+	require.NotNil(t, plain)
+
+	// 下级用户 7 -> 分销商 3（加价 2 倍）：预扣金额与入账扣费一致
+	repo := newResellerRepoStub()
+	repo.chains[7] = []ResellerChainLevel{{ResellerID: 3, Markup: 2}}
+	resellerService := NewResellerService(repo, nil, nil, nil, nil, nil, nil, &config.Config{})
+	svc, _ := newCostHoldTestServiceWithReseller(100, resellerService)
+	hold, err := svc.Reserve(context.Background(), &CostHoldInput{User: subUser, Group: group, Model: "claude-sonnet-4-5", Body: body})
+	require.NoError(t, err)
+	require.NotNil(t, hold)
+	require.InDelta(t, plain.Amount*2, hold.Amount, 1e-9)
+
+	// 余额只够未加价的一次预扣时，加价后的请求被拒绝
+	svc, _ = newCostHoldTestServiceWithReseller(plain.Amount*1.5, resellerService)
+	_, err = svc.Reserve(context.Background(), &CostHoldInput{User: subUser, Group: group, Model: "claude-sonnet-4-5", Body: body})
+	require.ErrorIs(t, err, ErrCostHoldInsufficientBalance)
+}
+
+func TestCostHoldService_Disabled(t *testing.T) {
+	svc, cache := newCostHoldTestService(0)
+	svc.cfg.Billing.CostHold.Enabled = false
+
+	hold, err := svc.Reserve(context.Background(), &CostHoldInput{User: &User{ID: 7}, Model: "claude-sonnet-4-5", Body: []byte(`{"max_tokens":10}`)})
+	require.NoError(t, err)
+	require.Nil(t, hold)
+	require.Empty(t, cache.holds)
+}
+
+func TestCostHoldService_MaxOutputTokens(t *testing.T) {
+	svc, _ := newCostHoldTestService(0)
+	require.Equal(t, 123, svc.maxOutputTokens([]byte(`{"max_tokens":123}`)))
+	require.Equal(t, 456, svc.maxOutputTokens([]byte(`{"max_output_tokens":456}`)))
+	require.Equal(t, 789, svc.maxOutputTokens([]byte(`{"generationConfig":{"maxOutputTokens":789}}`)))
+	require.Equal(t, 4096, svc.maxOutputTokens([]byte(`{"messages":[]}`)))
+}
+
+func TestEstimateInputTokens(t *testing.T) {
+	require.Equal(t, 2, estimateInputTokens([]byte(`{"messages":[{"content":"hello"}]}`)))
+
+	image := strings.Repeat("A", costHoldBinaryMinLen)
+	body := []byte(`{"messages":[{"content":[{"type":"image","source":{"data":"` + image + `"}}]}]}`)
+	require.Equal(t, 2+costHoldBinaryTokens, estimateInputTokens(body))
+}
@@ -14,6 +14,8 @@ const (
 const (
 	RoleAdmin = "admin"
 	RoleUser  = "user"
+	// RoleOrganization 组织付费账户：承载组织余额与订阅，不可登录
+	RoleOrganization = "organization"
 )
 
 // Platform constants
@@ -56,6 +58,13 @@ const (
 	SubscriptionTypeSubscription = "subscription" // 订阅模式（按限额控制）
 )
 
+// Group count_tokens mode constants
+const (
+	CountTokensModeUpstream      = "upstream"       // 转发上游账号计数
+	CountTokensModeLocal         = "local"          // 本地估算，不请求上游
+	CountTokensModeLocalFallback = "local_fallback" // 本地估算，含无法可靠估算的内容时转发上游
+)
+
 // Subscription status constants
 const (
 	SubscriptionStatusActive    = "active"
@@ -155,6 +164,13 @@ const (
 
 	// SettingKeyStreamTimeoutSettings stores JSON config for stream timeout handling.
 	SettingKeyStreamTimeoutSettings = "stream_timeout_settings"
+
+	// =========================
+	// Pricing
+	// =========================
+
+	// SettingKeyUnknownPricePolicy stores JSON config for billing models without any known price.
+	SettingKeyUnknownPricePolicy = "unknown_price_policy"
 )
 
 // AdminAPIKeyPrefix is the prefix for admin API keys (distinct from user "sk-" keys).
@@ -12,7 +12,9 @@ import (
 type EmailTask struct {
 	Email    string
 	SiteName string
-	TaskType string // "verify_code"
+	TaskType string // "verify_code" | "notification"
+	Subject  string // notification 任务使用
+	Body     string
 }
 
 // EmailQueueService 异步邮件队列服务
@@ -79,6 +81,10 @@ func (s *EmailQueueService) processTask(workerID int, task EmailTask) {
 		} else {
 			log.Printf("[EmailQueue] Worker %d sent verify code to %s", workerID, task.Email)
 		}
+	case "notification":
+		if err := s.emailService.SendEmail(ctx, task.Email, task.Subject, task.Body); err != nil {
+			log.Printf("[EmailQueue] Worker %d failed to send notification to %s: %v", workerID, task.Email, err)
+		}
 	default:
 		log.Printf("[EmailQueue] Worker %d unknown task type: %s", workerID, task.TaskType)
 	}
@@ -101,6 +107,23 @@ func (s *EmailQueueService) EnqueueVerifyCode(email, siteName string) error {
 	}
 }
 
+// EnqueueEmail 将通用邮件（如用户通知）加入队列
+func (s *EmailQueueService) EnqueueEmail(email, subject, body string) error {
+	task := EmailTask{
+		Email:    email,
+		TaskType: "notification",
+		Subject:  subject,
+		Body:     body,
+	}
+
+	select {
+	case s.taskChan <- task:
+		return nil
+	default:
+		return fmt.Errorf("email queue is full")
+	}
+}
+
 // Stop 停止队列服务
 func (s *EmailQueueService) Stop() {
 	close(s.stopChan)
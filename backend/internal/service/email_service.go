@@ -1,12 +1,16 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"math/big"
+	"mime"
 	"net/smtp"
 	"strconv"
 	"time"
@@ -127,14 +131,76 @@ func (s *EmailService) SendEmailWithConfig(config *SMTPConfig, to, subject, body
 	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n%s",
 		from, to, subject, body)
 
+	return s.deliver(config, to, []byte(msg))
+}
+
+// EmailAttachment 邮件附件
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// SendEmailWithAttachments 发送带附件的 HTML 邮件（使用数据库中保存的配置）
+func (s *EmailService) SendEmailWithAttachments(ctx context.Context, to, subject, body string, attachments []EmailAttachment) error {
+	config, err := s.GetSMTPConfig(ctx)
+	if err != nil {
+		return err
+	}
+	from := config.From
+	if config.FromName != "" {
+		from = fmt.Sprintf("%s <%s>", config.FromName, config.From)
+	}
+	msg, err := buildMultipartEmail(from, to, subject, body, attachments)
+	if err != nil {
+		return err
+	}
+	return s.deliver(config, to, msg)
+}
+
+// buildMultipartEmail 构造 multipart/mixed 邮件：HTML 正文 + base64 编码的附件
+func buildMultipartEmail(from, to, subject, body string, attachments []EmailAttachment) ([]byte, error) {
+	boundaryBytes := make([]byte, 12)
+	if _, err := rand.Read(boundaryBytes); err != nil {
+		return nil, fmt.Errorf("generate mime boundary: %w", err)
+	}
+	boundary := "sub2api-" + hex.EncodeToString(boundaryBytes)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\n", from, to, mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", boundary)
+	fmt.Fprintf(&buf, "--%s\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n%s\r\n", boundary, body)
+	for _, a := range attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		name := mime.QEncoding.Encode("utf-8", a.Filename)
+		fmt.Fprintf(&buf, "--%s\r\nContent-Type: %s; name=%q\r\nContent-Transfer-Encoding: base64\r\nContent-Disposition: attachment; filename=%q\r\n\r\n",
+			boundary, contentType, name, name)
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 76 {
+			buf.WriteString(encoded[:76])
+			buf.WriteString("\r\n")
+			encoded = encoded[76:]
+		}
+		buf.WriteString(encoded)
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	return buf.Bytes(), nil
+}
+
+// deliver 通过 SMTP 投递已构造好的邮件
+func (s *EmailService) deliver(config *SMTPConfig, to string, msg []byte) error {
 	addr := fmt.Sprintf("%s:%d", config.Host, config.Port)
 	auth := smtp.PlainAuth("", config.Username, config.Password, config.Host)
 
 	if config.UseTLS {
-		return s.sendMailTLS(addr, auth, config.From, to, []byte(msg), config.Host)
+		return s.sendMailTLS(addr, auth, config.From, to, msg, config.Host)
 	}
 
-	return smtp.SendMail(addr, auth, config.From, []string{to}, []byte(msg))
+	return smtp.SendMail(addr, auth, config.From, []string{to}, msg)
 }
 
 // sendMailTLS 使用TLS发送邮件
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// hedgeFirstContentMaxBytes 等待首个内容事件时最多预读的响应字节数，超过后不再等待
+const hedgeFirstContentMaxBytes = 1 << 20
+
+// forwardTarget 转发到某个账号所需的请求体与凭证（已按账号完成提示词注入与模型映射）
+type forwardTarget struct {
+	account   *Account
+	body      []byte
+	model     string // 映射后的上游模型
+	token     string
+	tokenType string
+	proxyURL  string
+}
+
+// prepareForwardTarget 按账号准备请求体与凭证
+func (s *GatewayService) prepareForwardTarget(ctx context.Context, c *gin.Context, account *Account, parsed *ParsedRequest) (*forwardTarget, error) {
+	body := parsed.Body
+	reqModel := parsed.Model
+
+	// 智能注入 Claude Code 系统提示词（仅 OAuth/SetupToken 账号需要）
+	// 条件：1) OAuth/SetupToken 账号  2) 不是 Claude Code 客户端  3) 不是 Haiku 模型  4) system 中还没有 Claude Code 提示词
+	if account.IsOAuth() &&
+		!isClaudeCodeClient(c.GetHeader("User-Agent"), parsed.MetadataUserID) &&
+		!strings.Contains(strings.ToLower(reqModel), "haiku") &&
+		!systemIncludesClaudeCodePrompt(parsed.System) {
+		body = injectClaudeCodePrompt(body, parsed.System)
+	}
+
+	// 强制执行 cache_control 块数量限制（最多 4 个）
+	body = enforceCacheControlLimit(body)
+
+	// 应用模型映射（仅对apikey类型账号）
+	if account.Type == AccountTypeAPIKey {
+		mappedModel := account.GetMappedModel(reqModel)
+		if mappedModel != reqModel {
+			// 替换请求体中的模型名
+			body = s.replaceModelInBody(body, mappedModel)
+			log.Printf("Model mapping applied: %s -> %s (account: %s)", reqModel, mappedModel, account.Name)
+			reqModel = mappedModel
+		}
+	}
+
+	// 获取凭证
+	token, tokenType, err := s.GetAccessToken(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取代理URL
+	proxyURL := ""
+	if account.ProxyID != nil && account.Proxy != nil {
+		proxyURL = account.Proxy.URL()
+	}
+
+	return &forwardTarget{
+		account:   account,
+		body:      body,
+		model:     reqModel,
+		token:     token,
+		tokenType: tokenType,
+		proxyURL:  proxyURL,
+	}, nil
+}
+
+// hedgePlan 单次请求的对冲参数
+type hedgePlan struct {
+	groupID  int64
+	delay    time.Duration
+	launched bool // 已向第二个账号发出对冲请求
+	// firstContent 从发出首个请求到胜出方收到首个内容事件的耗时；未收到内容事件时为 0
+	firstContent time.Duration
+}
+
+// hedgePlanFor 判断请求是否可对冲：分组开启 hedge_enabled、流式请求、未固定账号
+func (s *GatewayService) hedgePlanFor(ctx context.Context, parsed *ParsedRequest) *hedgePlan {
+	if s.requestHedge == nil || !parsed.Stream {
+		return nil
+	}
+	if _, pinned := pinnedAccountID(ctx); pinned {
+		return nil
+	}
+	group, ok := ctx.Value(ctxkey.Group).(*Group)
+	if !ok || !IsGroupContextValid(group) || !group.HedgeEnabled {
+		return nil
+	}
+	return &hedgePlan{groupID: group.ID, delay: s.requestHedge.Admit(group.ID)}
+}
+
+// hedgeOutcome 对冲中某一方的上游响应
+type hedgeOutcome struct {
+	target  *forwardTarget
+	resp    *http.Response
+	err     error
+	cancel  context.CancelFunc
+	release func()
+	// firstContent 收到首个内容事件时距首个请求发出的耗时；未收到时为 0
+	firstContent time.Duration
+}
+
+// close 取消请求并释放响应体与并发槽位
+func (o *hedgeOutcome) close() {
+	if o.resp != nil && o.resp.Body != nil {
+		_ = o.resp.Body.Close()
+	}
+	o.cancel()
+	if o.release != nil {
+		o.release()
+	}
+}
+
+// doHedged 发送首个上游请求；超过对冲延迟仍未收到首个内容事件（content_block_delta）时，将同一请求发往第二个账号。
+// Anthropic 流式响应会立即返回响应头，首字慢体现在第一个内容事件上，因此以首个内容事件而非响应头为准。
+// 先收到成功响应（< 400）且读到首个内容事件（或流已结束）的一方胜出，另一方立即取消；
+// 双方都失败时返回主账号的响应，按原有逻辑重试或切换账号。
+// 返回的 cleanup 需在响应体读取完毕后调用（取消胜出请求的 context、释放对冲账号槽位）。
+func (s *GatewayService) doHedged(ctx context.Context, c *gin.Context, primary *forwardTarget, req *http.Request, parsed *ParsedRequest, plan *hedgePlan) (resp *http.Response, winner *forwardTarget, cleanup func(), err error) {
+	start := time.Now()
+	results := make(chan *hedgeOutcome, 2)
+	send := func(out *hedgeOutcome, req *http.Request) {
+		account := out.target.account
+		out.resp, out.err = s.httpUpstream.DoWithTLS(req, out.target.proxyURL, account.ID, account.Concurrency, account.IsTLSFingerprintEnabled())
+		if out.err == nil && out.resp != nil && out.resp.StatusCode < 400 {
+			found, err := awaitFirstContent(out.resp)
+			if err != nil {
+				out.err = err
+			} else if found {
+				out.firstContent = time.Since(start)
+			}
+		}
+		results <- out
+	}
+
+	primaryCtx, primaryCancel := context.WithCancel(ctx)
+	go send(&hedgeOutcome{target: primary, cancel: primaryCancel}, req.WithContext(primaryCtx))
+
+	timer := time.NewTimer(plan.delay)
+	defer timer.Stop()
+	select {
+	case out := <-results:
+		plan.firstContent = out.firstContent
+		return out.resp, primary, out.cancel, out.err
+	case <-timer.C:
+	}
+
+	hedge, hedgeReq, hedgeOut := s.startHedge(ctx, c, primary, parsed, plan)
+	if hedge == nil {
+		out := <-results
+		plan.firstContent = out.firstContent
+		return out.resp, primary, out.cancel, out.err
+	}
+	go send(hedgeOut, hedgeReq)
+	plan.launched = true
+	log.Printf("[Hedge] group=%d account %d: no content after %v, hedging to account %d",
+		plan.groupID, primary.account.ID, plan.delay, hedge.account.ID)
+
+	var primaryOut *hedgeOutcome
+	for pending := 2; pending > 0; pending-- {
+		out := <-results
+		if out.err == nil && out.resp != nil && out.resp.StatusCode < 400 {
+			if pending > 1 {
+				// 取消落后的一方，其响应在后台回收
+				loserCancel := primaryCancel
+				if out.target == primary {
+					loserCancel = hedgeOut.cancel
+				}
+				loserCancel()
+				go func() { (<-results).close() }()
+			} else if primaryOut != nil {
+				s.discardFailedOutcome(ctx, c, primaryOut)
+			}
+			hedgeWon := out.target == hedge
+			plan.firstContent = out.firstContent
+			s.requestHedge.RecordHedge(plan.groupID, hedgeWon)
+			if hedgeWon {
+				log.Printf("[Hedge] group=%d hedge account %d won over account %d", plan.groupID, hedge.account.ID, primary.account.ID)
+				return out.resp, hedge, func() {
+					out.cancel()
+					hedgeOut.release()
+				}, nil
+			}
+			return out.resp, primary, out.cancel, nil
+		}
+		if out.target == primary {
+			primaryOut = out
+			continue
+		}
+		// 对冲账号失败：仍需处理账号状态副作用（限流/异常标记），但不影响主请求
+		s.discardFailedOutcome(ctx, c, out)
+	}
+
+	// 双方都失败：按主账号的响应继续原有处理
+	s.requestHedge.RecordHedge(plan.groupID, false)
+	return primaryOut.resp, primary, primaryOut.cancel, primaryOut.err
+}
+
+// awaitFirstContent 预读流式响应直到首个内容事件（content_block_delta），已读取的部分拼回响应体供后续流式处理。
+// 流在此之前结束（message_stop / error 事件、EOF）或预读超过 hedgeFirstContentMaxBytes 时同样返回，found 为 false。
+func awaitFirstContent(resp *http.Response) (found bool, err error) {
+	br := bufio.NewReaderSize(resp.Body, 64*1024)
+	var buf bytes.Buffer
+	lineStart := true
+	for buf.Len() < hedgeFirstContentMaxBytes {
+		line, readErr := br.ReadSlice('\n')
+		buf.Write(line)
+		if lineStart && sseDataRe.Match(line) {
+			switch gjson.GetBytes(sseDataRe.ReplaceAll(line, nil), "type").String() {
+			case "content_block_delta":
+				found = true
+			case "message_stop", "error":
+				readErr = io.EOF
+			}
+		}
+		lineStart = !errors.Is(readErr, bufio.ErrBufferFull)
+		if found || errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil && lineStart {
+			return false, readErr
+		}
+	}
+	resp.Body = &replayedBody{Reader: io.MultiReader(bytes.NewReader(buf.Bytes()), br), Closer: resp.Body}
+	return found, nil
+}
+
+// replayedBody 预读部分与剩余上游响应拼接后的响应体，关闭时关闭原响应体
+type replayedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// startHedge 选择第二个账号并构建对冲请求；预算不足、没有可立即占用槽位的账号时返回 nil
+func (s *GatewayService) startHedge(ctx context.Context, c *gin.Context, primary *forwardTarget, parsed *ParsedRequest, plan *hedgePlan) (*forwardTarget, *http.Request, *hedgeOutcome) {
+	if ctx.Err() != nil || !s.requestHedge.TryAcquireBudget(plan.groupID) {
+		return nil, nil, nil
+	}
+	groupID := plan.groupID
+	excluded := map[int64]struct{}{primary.account.ID: {}}
+	selection, err := s.SelectAccountWithLoadAwareness(ctx, &groupID, "", parsed.Model, excluded, "")
+	if err != nil || selection == nil || selection.Account == nil || !selection.Acquired {
+		s.requestHedge.RefundBudget(plan.groupID)
+		return nil, nil, nil
+	}
+	release := selection.ReleaseFunc
+	if release == nil {
+		release = func() {}
+	}
+	// 对冲只在 Anthropic 账号之间进行（混合调度可能选中 antigravity 账号）
+	if selection.Account.Platform != PlatformAnthropic {
+		release()
+		s.requestHedge.RefundBudget(plan.groupID)
+		return nil, nil, nil
+	}
+	hedge, err := s.prepareForwardTarget(ctx, c, selection.Account, parsed)
+	if err != nil {
+		log.Printf("[Hedge] prepare account %d failed: %v", selection.Account.ID, err)
+		release()
+		s.requestHedge.RefundBudget(plan.groupID)
+		return nil, nil, nil
+	}
+	hedgeCtx, hedgeCancel := context.WithCancel(ctx)
+	req, err := s.buildUpstreamRequest(hedgeCtx, c, hedge.account, hedge.body, hedge.token, hedge.tokenType, hedge.model)
+	if err != nil {
+		log.Printf("[Hedge] build request for account %d failed: %v", hedge.account.ID, err)
+		hedgeCancel()
+		release()
+		s.requestHedge.RefundBudget(plan.groupID)
+		return nil, nil, nil
+	}
+	return hedge, req, &hedgeOutcome{target: hedge, cancel: hedgeCancel, release: release}
+}
+
+// discardFailedOutcome 丢弃对冲中失败一方的响应，并对可切换的上游错误执行账号状态副作用
+func (s *GatewayService) discardFailedOutcome(ctx context.Context, c *gin.Context, out *hedgeOutcome) {
+	defer out.close()
+	account := out.target.account
+	if out.err != nil {
+		log.Printf("[Hedge] account %d request failed: %v", account.ID, out.err)
+		return
+	}
+	if out.resp == nil {
+		return
+	}
+	log.Printf("[Hedge] account %d returned %d, discarding", account.ID, out.resp.StatusCode)
+	if s.shouldFailoverUpstreamError(out.resp.StatusCode) {
+		respBody, _ := io.ReadAll(io.LimitReader(out.resp.Body, 2<<20))
+		appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+			Platform:           account.Platform,
+			AccountID:          account.ID,
+			AccountName:        account.Name,
+			UpstreamStatusCode: out.resp.StatusCode,
+			UpstreamRequestID:  out.resp.Header.Get("x-request-id"),
+			Kind:               "hedge_discarded",
+			Message:            extractUpstreamErrorMessage(respBody),
+		})
+		out.resp.Body = io.NopCloser(strings.NewReader(string(respBody)))
+		s.handleFailoverSideEffects(ctx, out.resp, account)
+	}
+}
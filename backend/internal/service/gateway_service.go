@@ -24,6 +24,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/claude"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tokenizer"
 	"github.com/Wei-Shaw/sub2api/internal/util/responseheaders"
 	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
 	"github.com/tidwall/gjson"
@@ -77,6 +78,9 @@ var (
 // ErrClaudeCodeOnly 表示分组仅允许 Claude Code 客户端访问
 var ErrClaudeCodeOnly = errors.New("this group only allows Claude Code clients")
 
+// ErrPinnedAccountUnavailable 表示请求固定的账号（如 Files API 文件所在账号）当前不可调度
+var ErrPinnedAccountUnavailable = errors.New("the upstream account holding the referenced files is unavailable")
+
 // allowedHeaders 白名单headers（参考CRS项目）
 var allowedHeaders = map[string]bool{
 	"accept":                                    true,
@@ -160,7 +164,8 @@ type AccountSelectionResult struct {
 	Account     *Account
 	Acquired    bool
 	ReleaseFunc func()
-	WaitPlan    *AccountWaitPlan // nil means no wait allowed
+	RefreshFunc func(ctx context.Context) error // 刷新已占用槽位（长连接使用），可能为 nil
+	WaitPlan    *AccountWaitPlan                // nil means no wait allowed
 }
 
 // ClaudeUsage 表示Claude API返回的usage信息
@@ -169,6 +174,26 @@ type ClaudeUsage struct {
 	OutputTokens             int `json:"output_tokens"`
 	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
 	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	// CacheCreation 缓存写入按 TTL 拆分（5 分钟 / 1 小时），合计等于 CacheCreationInputTokens
+	CacheCreation ClaudeCacheCreation `json:"cache_creation"`
+}
+
+// ClaudeCacheCreation Claude usage.cache_creation
+type ClaudeCacheCreation struct {
+	Ephemeral5mInputTokens int `json:"ephemeral_5m_input_tokens"`
+	Ephemeral1hInputTokens int `json:"ephemeral_1h_input_tokens"`
+}
+
+// BillingTokens 转换为计费用量
+func (u *ClaudeUsage) BillingTokens() UsageTokens {
+	return UsageTokens{
+		InputTokens:           u.InputTokens,
+		OutputTokens:          u.OutputTokens,
+		CacheCreationTokens:   u.CacheCreationInputTokens,
+		CacheReadTokens:       u.CacheReadInputTokens,
+		CacheCreation5mTokens: u.CacheCreation.Ephemeral5mInputTokens,
+		CacheCreation1hTokens: u.CacheCreation.Ephemeral1hInputTokens,
+	}
 }
 
 // ForwardResult 转发结果
@@ -184,6 +209,14 @@ type ForwardResult struct {
 	// 图片生成计费字段（仅 gemini-3-pro-image 使用）
 	ImageCount int    // 生成的图片数量
 	ImageSize  string // 图片尺寸 "1K", "2K", "4K"
+
+	// 存储计费字段（仅 Gemini Files / cachedContents 使用）
+	StorageTokenHours float64 // 显式缓存 token 数 × 小时
+	StorageGBDays     float64 // 文件大小（GB）× 保留天数
+
+	// 请求对冲（仅 /v1/messages 流式请求）
+	Hedged       bool     // 请求触发了对冲
+	HedgeAccount *Account // 对冲账号胜出时为实际服务的账号，调用方应以其计费
 }
 
 // UpstreamFailoverError indicates an upstream error that should trigger account failover.
@@ -197,23 +230,28 @@ func (e *UpstreamFailoverError) Error() string {
 
 // GatewayService handles API gateway operations
 type GatewayService struct {
-	accountRepo         AccountRepository
-	groupRepo           GroupRepository
-	usageLogRepo        UsageLogRepository
-	userRepo            UserRepository
-	userSubRepo         UserSubscriptionRepository
-	cache               GatewayCache
-	cfg                 *config.Config
-	schedulerSnapshot   *SchedulerSnapshotService
-	billingService      *BillingService
-	rateLimitService    *RateLimitService
-	billingCacheService *BillingCacheService
-	identityService     *IdentityService
-	httpUpstream        HTTPUpstream
-	deferredService     *DeferredService
-	concurrencyService  *ConcurrencyService
-	claudeTokenProvider *ClaudeTokenProvider
-	sessionLimitCache   SessionLimitCache // 会话数量限制缓存（仅 Anthropic OAuth/SetupToken）
+	accountRepo           AccountRepository
+	groupRepo             GroupRepository
+	usageLogRepo          UsageLogRepository
+	userRepo              UserRepository
+	userSubRepo           UserSubscriptionRepository
+	cache                 GatewayCache
+	cfg                   *config.Config
+	schedulerSnapshot     *SchedulerSnapshotService
+	billingService        *BillingService
+	rateLimitService      *RateLimitService
+	billingCacheService   *BillingCacheService
+	identityService       *IdentityService
+	httpUpstream          HTTPUpstream
+	deferredService       *DeferredService
+	concurrencyService    *ConcurrencyService
+	claudeTokenProvider   *ClaudeTokenProvider
+	sessionLimitCache     SessionLimitCache // 会话数量限制缓存（仅 Anthropic OAuth/SetupToken）
+	resellerService       *ResellerService
+	billingOutboxService  *BillingOutboxService
+	rateMultiplierService *RateMultiplierService
+	stickyStandby         *StickyStandbyService
+	requestHedge          *RequestHedgeService
 }
 
 // NewGatewayService creates a new GatewayService
@@ -235,25 +273,35 @@ func NewGatewayService(
 	deferredService *DeferredService,
 	claudeTokenProvider *ClaudeTokenProvider,
 	sessionLimitCache SessionLimitCache,
+	resellerService *ResellerService,
+	billingOutboxService *BillingOutboxService,
+	rateMultiplierService *RateMultiplierService,
+	stickyStandby *StickyStandbyService,
+	requestHedge *RequestHedgeService,
 ) *GatewayService {
 	return &GatewayService{
-		accountRepo:         accountRepo,
-		groupRepo:           groupRepo,
-		usageLogRepo:        usageLogRepo,
-		userRepo:            userRepo,
-		userSubRepo:         userSubRepo,
-		cache:               cache,
-		cfg:                 cfg,
-		schedulerSnapshot:   schedulerSnapshot,
-		concurrencyService:  concurrencyService,
-		billingService:      billingService,
-		rateLimitService:    rateLimitService,
-		billingCacheService: billingCacheService,
-		identityService:     identityService,
-		httpUpstream:        httpUpstream,
-		deferredService:     deferredService,
-		claudeTokenProvider: claudeTokenProvider,
-		sessionLimitCache:   sessionLimitCache,
+		accountRepo:           accountRepo,
+		groupRepo:             groupRepo,
+		usageLogRepo:          usageLogRepo,
+		userRepo:              userRepo,
+		userSubRepo:           userSubRepo,
+		cache:                 cache,
+		cfg:                   cfg,
+		schedulerSnapshot:     schedulerSnapshot,
+		concurrencyService:    concurrencyService,
+		billingService:        billingService,
+		rateLimitService:      rateLimitService,
+		billingCacheService:   billingCacheService,
+		identityService:       identityService,
+		httpUpstream:          httpUpstream,
+		deferredService:       deferredService,
+		claudeTokenProvider:   claudeTokenProvider,
+		sessionLimitCache:     sessionLimitCache,
+		resellerService:       resellerService,
+		billingOutboxService:  billingOutboxService,
+		rateMultiplierService: rateMultiplierService,
+		stickyStandby:         stickyStandby,
+		requestHedge:          requestHedge,
 	}
 }
 
@@ -298,11 +346,22 @@ func (s *GatewayService) GenerateSessionHash(parsed *ParsedRequest) string {
 }
 
 // BindStickySession sets session -> account binding with standard TTL.
+// 启用备用账号时，绑定到与原账号不同的账号会记录一次切换。
 func (s *GatewayService) BindStickySession(ctx context.Context, groupID *int64, sessionHash string, accountID int64) error {
 	if sessionHash == "" || accountID <= 0 || s.cache == nil {
 		return nil
 	}
-	return s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, accountID, stickySessionTTL)
+	var previousID int64
+	if s.stickyStandby.Enabled() {
+		previousID, _ = s.cache.GetSessionAccountID(ctx, derefGroupID(groupID), sessionHash)
+	}
+	if err := s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, accountID, stickySessionTTL); err != nil {
+		return err
+	}
+	if previousID > 0 && previousID != accountID {
+		s.stickyStandby.RecordFailover(ctx, derefGroupID(groupID), sessionHash, previousID, accountID)
+	}
+	return nil
 }
 
 func (s *GatewayService) extractCacheableContent(parsed *ParsedRequest) string {
@@ -435,6 +494,11 @@ func (s *GatewayService) SelectAccountForModelWithExclusions(ctx context.Context
 		platform = PlatformAnthropic
 	}
 
+	// 引用了 Files API 文件的请求只能使用文件所在账号
+	if pinnedID, ok := pinnedAccountID(ctx); ok {
+		return s.pinnedSchedulableAccount(ctx, groupID, pinnedID, requestedModel, excludedIDs)
+	}
+
 	// anthropic/gemini 分组支持混合调度（包含启用了 mixed_scheduling 的 antigravity 账户）
 	// 注意：强制平台模式不走混合调度
 	if (platform == PlatformAnthropic || platform == PlatformGemini) && !hasForcePlatform {
@@ -476,6 +540,11 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 	}
 	ctx = s.withGroupContext(ctx, group)
 
+	// 引用了 Files API 文件的请求只能使用文件所在账号
+	if pinnedID, ok := pinnedAccountID(ctx); ok {
+		return s.selectPinnedAccount(ctx, groupID, pinnedID, requestedModel, excludedIDs)
+	}
+
 	if s.debugModelRoutingEnabled() && requestedModel != "" {
 		groupPlatform := ""
 		if group != nil {
@@ -797,8 +866,12 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 	}
 
 	// ============ Layer 1.5: 粘性会话（仅在无模型路由配置时生效） ============
+	var stickyPrimaryID int64
 	if len(routingAccountIDs) == 0 && sessionHash != "" && s.cache != nil {
 		accountID, err := s.cache.GetSessionAccountID(ctx, derefGroupID(groupID), sessionHash)
+		if err == nil {
+			stickyPrimaryID = accountID
+		}
 		if err == nil && accountID > 0 && !isExcluded(accountID) {
 			account, ok := accountByID[accountID]
 			if ok {
@@ -821,6 +894,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 							result.ReleaseFunc() // 释放槽位，继续到 Layer 2
 						} else {
 							_ = s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, stickySessionTTL)
+							s.ensureStickyStandby(ctx, groupID, sessionHash, accountID, accounts, platform, useMixed, requestedModel, isExcluded)
 							return &AccountSelectionResult{
 								Account:     account,
 								Acquired:    true,
@@ -837,6 +911,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 							// 会话限制已满，继续到 Layer 2
 							// Session limit full, continue to Layer 2
 						} else {
+							s.ensureStickyStandby(ctx, groupID, sessionHash, accountID, accounts, platform, useMixed, requestedModel, isExcluded)
 							return &AccountSelectionResult{
 								Account: account,
 								WaitPlan: &AccountWaitPlan{
@@ -853,6 +928,13 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 		}
 	}
 
+	// ============ Layer 1.6: 粘性会话备用账号（主账号不可用时保留缓存热度） ============
+	if len(routingAccountIDs) == 0 && stickyPrimaryID > 0 {
+		if result := s.selectStickyStandby(ctx, groupID, sessionHash, stickyPrimaryID, accounts, accountByID, platform, useMixed, requestedModel, isExcluded); result != nil {
+			return result, nil
+		}
+	}
+
 	// ============ Layer 2: 负载感知选择 ============
 	candidates := make([]*Account, 0, len(accounts))
 	for i := range accounts {
@@ -951,7 +1033,8 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 						continue
 					}
 					if sessionHash != "" && s.cache != nil {
-						_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, item.account.ID, stickySessionTTL)
+						s.bindStickySession(ctx, groupID, sessionHash, item.account.ID, stickyPrimaryID)
+						s.ensureStickyStandby(ctx, groupID, sessionHash, item.account.ID, accounts, platform, useMixed, requestedModel, isExcluded)
 					}
 					return &AccountSelectionResult{
 						Account:     item.account,
@@ -1011,7 +1094,7 @@ func (s *GatewayService) tryAcquireByLegacyOrder(ctx context.Context, candidates
 
 func (s *GatewayService) schedulingConfig() config.GatewaySchedulingConfig {
 	if s.cfg != nil {
-		return s.cfg.Gateway.Scheduling
+		return s.cfg.Current().Gateway.Scheduling
 	}
 	return config.GatewaySchedulingConfig{
 		StickySessionMaxWaiting:  3,
@@ -1023,6 +1106,57 @@ func (s *GatewayService) schedulingConfig() config.GatewaySchedulingConfig {
 	}
 }
 
+// WithPinnedAccount 将请求固定到指定账号：SelectAccountWithLoadAwareness / SelectAccountForModel 只会返回该账号，
+// 账号不可调度或已被排除（failover）时返回 ErrPinnedAccountUnavailable
+func WithPinnedAccount(ctx context.Context, accountID int64) context.Context {
+	if accountID <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxkey.PinnedAccountID, accountID)
+}
+
+func pinnedAccountID(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(ctxkey.PinnedAccountID).(int64)
+	return id, ok && id > 0
+}
+
+// selectPinnedAccount 固定账号的调度：能立即占用槽位则直接返回，否则按粘性会话的等待策略排队
+func (s *GatewayService) selectPinnedAccount(ctx context.Context, groupID *int64, accountID int64, requestedModel string, excludedIDs map[int64]struct{}) (*AccountSelectionResult, error) {
+	account, err := s.pinnedSchedulableAccount(ctx, groupID, accountID, requestedModel, excludedIDs)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.tryAcquireAccountSlot(ctx, account.ID, account.Concurrency)
+	if err == nil && result.Acquired {
+		return &AccountSelectionResult{Account: account, Acquired: true, ReleaseFunc: result.ReleaseFunc}, nil
+	}
+	cfg := s.schedulingConfig()
+	return &AccountSelectionResult{
+		Account: account,
+		WaitPlan: &AccountWaitPlan{
+			AccountID:      account.ID,
+			MaxConcurrency: account.Concurrency,
+			Timeout:        cfg.StickySessionWaitTimeout,
+			MaxWaiting:     cfg.StickySessionMaxWaiting,
+		},
+	}, nil
+}
+
+// pinnedSchedulableAccount 校验固定账号：未被排除、可调度、属于当前分组且支持请求的模型
+func (s *GatewayService) pinnedSchedulableAccount(ctx context.Context, groupID *int64, accountID int64, requestedModel string, excludedIDs map[int64]struct{}) (*Account, error) {
+	if _, excluded := excludedIDs[accountID]; excluded {
+		return nil, ErrPinnedAccountUnavailable
+	}
+	account, err := s.getSchedulableAccount(ctx, accountID)
+	if err != nil || account == nil || !account.IsSchedulable() || !s.isAccountInGroup(account, groupID) {
+		return nil, ErrPinnedAccountUnavailable
+	}
+	if requestedModel != "" && !s.isModelSupportedByAccount(account, requestedModel) {
+		return nil, ErrPinnedAccountUnavailable
+	}
+	return account, nil
+}
+
 func (s *GatewayService) withGroupContext(ctx context.Context, group *Group) context.Context {
 	if !IsGroupContextValid(group) {
 		return ctx
@@ -2255,50 +2389,25 @@ func removeCacheControlFromThinkingBlocks(data map[string]any) {
 
 // Forward 转发请求到Claude API
 func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *Account, parsed *ParsedRequest) (*ForwardResult, error) {
+	cfg := s.cfg.Current()
 	startTime := time.Now()
 	if parsed == nil {
 		return nil, fmt.Errorf("parse request: empty request")
 	}
 
-	body := parsed.Body
-	reqModel := parsed.Model
 	reqStream := parsed.Stream
+	originalModel := parsed.Model
 
-	// 智能注入 Claude Code 系统提示词（仅 OAuth/SetupToken 账号需要）
-	// 条件：1) OAuth/SetupToken 账号  2) 不是 Claude Code 客户端  3) 不是 Haiku 模型  4) system 中还没有 Claude Code 提示词
-	if account.IsOAuth() &&
-		!isClaudeCodeClient(c.GetHeader("User-Agent"), parsed.MetadataUserID) &&
-		!strings.Contains(strings.ToLower(reqModel), "haiku") &&
-		!systemIncludesClaudeCodePrompt(parsed.System) {
-		body = injectClaudeCodePrompt(body, parsed.System)
-	}
-
-	// 强制执行 cache_control 块数量限制（最多 4 个）
-	body = enforceCacheControlLimit(body)
-
-	// 应用模型映射（仅对apikey类型账号）
-	originalModel := reqModel
-	if account.Type == AccountTypeAPIKey {
-		mappedModel := account.GetMappedModel(reqModel)
-		if mappedModel != reqModel {
-			// 替换请求体中的模型名
-			body = s.replaceModelInBody(body, mappedModel)
-			reqModel = mappedModel
-			log.Printf("Model mapping applied: %s -> %s (account: %s)", originalModel, mappedModel, account.Name)
-		}
-	}
-
-	// 获取凭证
-	token, tokenType, err := s.GetAccessToken(ctx, account)
+	target, err := s.prepareForwardTarget(ctx, c, account, parsed)
 	if err != nil {
 		return nil, err
 	}
+	body, reqModel := target.body, target.model
+	token, tokenType, proxyURL := target.token, target.tokenType, target.proxyURL
 
-	// 获取代理URL
-	proxyURL := ""
-	if account.ProxyID != nil && account.Proxy != nil {
-		proxyURL = account.Proxy.URL()
-	}
+	// 请求对冲：首个请求超过对冲延迟仍无响应头时发往第二个账号
+	hedge := s.hedgePlanFor(ctx, parsed)
+	var hedgeAccount *Account
 
 	// 调试日志：记录即将转发的账号信息
 	log.Printf("[Forward] Using account: ID=%d Name=%s Platform=%s Type=%s TLSFingerprint=%v Proxy=%s",
@@ -2318,7 +2427,21 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 		}
 
 		// 发送请求
-		resp, err = s.httpUpstream.DoWithTLS(upstreamReq, proxyURL, account.ID, account.Concurrency, account.IsTLSFingerprintEnabled())
+		if attempt == 1 && hedge != nil {
+			var winner *forwardTarget
+			var cleanup func()
+			resp, winner, cleanup, err = s.doHedged(ctx, c, target, upstreamReq, parsed, hedge)
+			defer cleanup()
+			if winner != target {
+				// 对冲账号胜出：后续处理与计费均使用对冲账号
+				account, hedgeAccount = winner.account, winner.account
+				body, reqModel = winner.body, winner.model
+				token, tokenType, proxyURL = winner.token, winner.tokenType, winner.proxyURL
+				c.Set(OpsUpstreamRequestBodyKey, string(body))
+			}
+		} else {
+			resp, err = s.httpUpstream.DoWithTLS(upstreamReq, proxyURL, account.ID, account.Concurrency, account.IsTLSFingerprintEnabled())
+		}
 		if err != nil {
 			if resp != nil && resp.Body != nil {
 				_ = resp.Body.Close()
@@ -2360,8 +2483,8 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 						Kind:               "signature_error",
 						Message:            extractUpstreamErrorMessage(respBody),
 						Detail: func() string {
-							if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-								return truncateString(string(respBody), s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes)
+							if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+								return truncateString(string(respBody), cfg.Gateway.LogUpstreamErrorBodyMaxBytes)
 							}
 							return ""
 						}(),
@@ -2412,8 +2535,8 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 									Kind:               "signature_retry_thinking",
 									Message:            extractUpstreamErrorMessage(retryRespBody),
 									Detail: func() string {
-										if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-											return truncateString(string(retryRespBody), s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes)
+										if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+											return truncateString(string(retryRespBody), cfg.Gateway.LogUpstreamErrorBodyMaxBytes)
 										}
 										return ""
 									}(),
@@ -2500,8 +2623,8 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 					Kind:               "retry",
 					Message:            extractUpstreamErrorMessage(respBody),
 					Detail: func() string {
-						if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-							return truncateString(string(respBody), s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes)
+						if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+							return truncateString(string(respBody), cfg.Gateway.LogUpstreamErrorBodyMaxBytes)
 						}
 						return ""
 					}(),
@@ -2553,8 +2676,8 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 				Kind:               "retry_exhausted_failover",
 				Message:            extractUpstreamErrorMessage(respBody),
 				Detail: func() string {
-					if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-						return truncateString(string(respBody), s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes)
+					if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+						return truncateString(string(respBody), cfg.Gateway.LogUpstreamErrorBodyMaxBytes)
 					}
 					return ""
 				}(),
@@ -2583,8 +2706,8 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 			Kind:               "failover",
 			Message:            extractUpstreamErrorMessage(respBody),
 			Detail: func() string {
-				if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-					return truncateString(string(respBody), s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes)
+				if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+					return truncateString(string(respBody), cfg.Gateway.LogUpstreamErrorBodyMaxBytes)
 				}
 				return ""
 			}(),
@@ -2595,7 +2718,7 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 	// 处理错误响应（不可重试的错误）
 	if resp.StatusCode >= 400 {
 		// 可选：对部分 400 触发 failover（默认关闭以保持语义）
-		if resp.StatusCode == 400 && s.cfg != nil && s.cfg.Gateway.FailoverOn400 {
+		if resp.StatusCode == 400 && cfg != nil && cfg.Gateway.FailoverOn400 {
 			respBody, readErr := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
 			if readErr != nil {
 				// ReadAll failed, fall back to normal error handling without consuming the stream
@@ -2608,8 +2731,8 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 				upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(respBody))
 				upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 				upstreamDetail := ""
-				if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-					maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+				if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+					maxBytes := cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 					if maxBytes <= 0 {
 						maxBytes = 2048
 					}
@@ -2626,11 +2749,11 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 					Detail:             upstreamDetail,
 				})
 
-				if s.cfg.Gateway.LogUpstreamErrorBody {
+				if cfg.Gateway.LogUpstreamErrorBody {
 					log.Printf(
 						"Account %d: 400 error, attempting failover: %s",
 						account.ID,
-						truncateForLog(respBody, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
+						truncateForLog(respBody, cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
 					)
 				} else {
 					log.Printf("Account %d: 400 error, attempting failover", account.ID)
@@ -2666,6 +2789,10 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 		}
 	}
 
+	if hedge != nil && hedge.firstContent > 0 {
+		s.requestHedge.ObserveFirstContent(hedge.groupID, int(hedge.firstContent.Milliseconds()))
+	}
+
 	return &ForwardResult{
 		RequestID:        resp.Header.Get("x-request-id"),
 		Usage:            *usage,
@@ -2674,6 +2801,8 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 		Duration:         time.Since(startTime),
 		FirstTokenMs:     firstTokenMs,
 		ClientDisconnect: clientDisconnect,
+		Hedged:           hedge != nil && hedge.launched,
+		HedgeAccount:     hedgeAccount,
 	}, nil
 }
 
@@ -2751,7 +2880,7 @@ func (s *GatewayService) buildUpstreamRequest(ctx context.Context, c *gin.Contex
 	// 处理anthropic-beta header（OAuth账号需要特殊处理）
 	if tokenType == "oauth" {
 		req.Header.Set("anthropic-beta", s.getBetaHeader(modelID, c.GetHeader("anthropic-beta")))
-	} else if s.cfg != nil && s.cfg.Gateway.InjectBetaForAPIKey && req.Header.Get("anthropic-beta") == "" {
+	} else if s.cfg != nil && s.cfg.Current().Gateway.InjectBetaForAPIKey && req.Header.Get("anthropic-beta") == "" {
 		// API-key：仅在请求显式使用 beta 特性且客户端未提供时，按需补齐（默认关闭）
 		if requestNeedsBetaFeatures(body) {
 			if beta := defaultAPIKeyBetaHeader(body); beta != "" {
@@ -2923,6 +3052,7 @@ func extractUpstreamErrorMessage(body []byte) string {
 }
 
 func (s *GatewayService) handleErrorResponse(ctx context.Context, resp *http.Response, c *gin.Context, account *Account) (*ForwardResult, error) {
+	cfg := s.cfg.Current()
 	body, _ := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
 
 	// 调试日志：打印上游错误响应
@@ -2934,8 +3064,8 @@ func (s *GatewayService) handleErrorResponse(ctx context.Context, resp *http.Res
 
 	// Enrich Ops error logs with upstream status + message, and optionally a truncated body snippet.
 	upstreamDetail := ""
-	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-		maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+	if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+		maxBytes := cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 		if maxBytes <= 0 {
 			maxBytes = 2048
 		}
@@ -2962,14 +3092,14 @@ func (s *GatewayService) handleErrorResponse(ctx context.Context, resp *http.Res
 	}
 
 	// 记录上游错误响应体摘要便于排障（可选：由配置控制；不回显到客户端）
-	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
+	if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
 		log.Printf(
 			"Upstream error %d (account=%d platform=%s type=%s): %s",
 			resp.StatusCode,
 			account.ID,
 			account.Platform,
 			account.Type,
-			truncateForLog(body, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
+			truncateForLog(body, cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
 		)
 	}
 
@@ -3052,6 +3182,7 @@ func (s *GatewayService) handleFailoverSideEffects(ctx context.Context, resp *ht
 // OAuth 403：标记账号异常
 // API Key 未配置错误码：仅返回错误，不标记账号
 func (s *GatewayService) handleRetryExhaustedError(ctx context.Context, resp *http.Response, c *gin.Context, account *Account) (*ForwardResult, error) {
+	cfg := s.cfg.Current()
 	// Capture upstream error body before side-effects consume the stream.
 	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
 	_ = resp.Body.Close()
@@ -3062,8 +3193,8 @@ func (s *GatewayService) handleRetryExhaustedError(ctx context.Context, resp *ht
 	upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(respBody))
 	upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 	upstreamDetail := ""
-	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-		maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+	if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+		maxBytes := cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 		if maxBytes <= 0 {
 			maxBytes = 2048
 		}
@@ -3080,14 +3211,14 @@ func (s *GatewayService) handleRetryExhaustedError(ctx context.Context, resp *ht
 		Detail:             upstreamDetail,
 	})
 
-	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
+	if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
 		log.Printf(
 			"Upstream error %d retries_exhausted (account=%d platform=%s type=%s): %s",
 			resp.StatusCode,
 			account.ID,
 			account.Platform,
 			account.Type,
-			truncateForLog(respBody, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
+			truncateForLog(respBody, cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
 		)
 	}
 
@@ -3114,6 +3245,7 @@ type streamingResult struct {
 }
 
 func (s *GatewayService) handleStreamingResponse(ctx context.Context, resp *http.Response, c *gin.Context, account *Account, startTime time.Time, originalModel, mappedModel string) (*streamingResult, error) {
+	cfg := s.cfg.Current()
 	// 更新5h窗口状态
 	s.rateLimitService.UpdateSessionWindow(ctx, account, resp.Header)
 
@@ -3143,8 +3275,8 @@ func (s *GatewayService) handleStreamingResponse(ctx context.Context, resp *http
 	scanner := bufio.NewScanner(resp.Body)
 	// 设置更大的buffer以处理长行
 	maxLineSize := defaultMaxLineSize
-	if s.cfg != nil && s.cfg.Gateway.MaxLineSize > 0 {
-		maxLineSize = s.cfg.Gateway.MaxLineSize
+	if cfg != nil && cfg.Gateway.MaxLineSize > 0 {
+		maxLineSize = cfg.Gateway.MaxLineSize
 	}
 	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
 
@@ -3180,8 +3312,8 @@ func (s *GatewayService) handleStreamingResponse(ctx context.Context, resp *http
 	defer close(done)
 
 	streamInterval := time.Duration(0)
-	if s.cfg != nil && s.cfg.Gateway.StreamDataIntervalTimeout > 0 {
-		streamInterval = time.Duration(s.cfg.Gateway.StreamDataIntervalTimeout) * time.Second
+	if cfg != nil && cfg.Gateway.StreamDataIntervalTimeout > 0 {
+		streamInterval = time.Duration(cfg.Gateway.StreamDataIntervalTimeout) * time.Second
 	}
 	// 仅监控上游数据间隔超时，避免下游写入阻塞导致误判
 	var intervalTicker *time.Ticker
@@ -3346,16 +3478,18 @@ func (s *GatewayService) parseSSEUsage(data string, usage *ClaudeUsage) {
 		usage.InputTokens = msgStart.Message.Usage.InputTokens
 		usage.CacheCreationInputTokens = msgStart.Message.Usage.CacheCreationInputTokens
 		usage.CacheReadInputTokens = msgStart.Message.Usage.CacheReadInputTokens
+		usage.CacheCreation = msgStart.Message.Usage.CacheCreation
 	}
 
 	// 解析message_delta获取tokens（兼容GLM等把所有usage放在delta中的API）
 	var msgDelta struct {
 		Type  string `json:"type"`
 		Usage struct {
-			InputTokens              int `json:"input_tokens"`
-			OutputTokens             int `json:"output_tokens"`
-			CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
-			CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+			InputTokens              int                 `json:"input_tokens"`
+			OutputTokens             int                 `json:"output_tokens"`
+			CacheCreationInputTokens int                 `json:"cache_creation_input_tokens"`
+			CacheReadInputTokens     int                 `json:"cache_read_input_tokens"`
+			CacheCreation            ClaudeCacheCreation `json:"cache_creation"`
 		} `json:"usage"`
 	}
 	if json.Unmarshal([]byte(data), &msgDelta) == nil && msgDelta.Type == "message_delta" {
@@ -3372,6 +3506,9 @@ func (s *GatewayService) parseSSEUsage(data string, usage *ClaudeUsage) {
 		if usage.CacheReadInputTokens == 0 {
 			usage.CacheReadInputTokens = msgDelta.Usage.CacheReadInputTokens
 		}
+		if usage.CacheCreation == (ClaudeCacheCreation{}) {
+			usage.CacheCreation = msgDelta.Usage.CacheCreation
+		}
 	}
 }
 
@@ -3442,6 +3579,10 @@ type RecordUsageInput struct {
 	Subscription *UserSubscription // 可选：订阅信息
 	UserAgent    string            // 请求的 User-Agent
 	IPAddress    string            // 请求的客户端 IP 地址
+	Tags         map[string]string // 已校验的成本归属标签
+	SessionHash  string            // 粘性会话 hash（用于缓存热度统计）
+	CostHold     *CostHold         // 可选：本次请求的费用预扣，扣费未能同步完成时保留至过期
+	Group        *Group            // 可选：实际调度的分组（跨分组模型降级），为空时使用 API Key 的分组
 }
 
 // RecordUsage 记录使用量并扣费（或更新订阅用量）
@@ -3452,36 +3593,40 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 	account := input.Account
 	subscription := input.Subscription
 
-	// 获取费率倍数
-	multiplier := s.cfg.Default.RateMultiplier
-	if apiKey.GroupID != nil && apiKey.Group != nil {
-		multiplier = apiKey.Group.RateMultiplier
+	// 实际调度的分组：跨分组模型降级时为目标分组，否则为 API Key 的分组
+	groupID := apiKey.GroupID
+	var group *Group
+	if input.Group != nil {
+		group, groupID = input.Group, &input.Group.ID
+	} else if apiKey.GroupID != nil {
+		group = apiKey.Group
 	}
 
+	// 获取费率倍数（用户 / API Key 覆盖与阶梯折扣）
+	rate := s.rateMultiplierService.Resolve(ctx, apiKey, group)
+	multiplier := rate.Multiplier
+
 	var cost *CostBreakdown
 
 	// 根据请求类型选择计费方式
 	if result.ImageCount > 0 {
 		// 图片生成计费
 		var groupConfig *ImagePriceConfig
-		if apiKey.Group != nil {
+		if group != nil {
 			groupConfig = &ImagePriceConfig{
-				Price1K: apiKey.Group.ImagePrice1K,
-				Price2K: apiKey.Group.ImagePrice2K,
-				Price4K: apiKey.Group.ImagePrice4K,
+				Price1K: group.ImagePrice1K,
+				Price2K: group.ImagePrice2K,
+				Price4K: group.ImagePrice4K,
 			}
 		}
 		cost = s.billingService.CalculateImageCost(result.Model, result.ImageSize, result.ImageCount, groupConfig, multiplier)
+	} else if result.StorageTokenHours > 0 || result.StorageGBDays > 0 {
+		// 资源存储计费
+		cost = s.billingService.CalculateStorageCost(result.Model, result.StorageTokenHours, result.StorageGBDays, multiplier)
 	} else {
 		// Token 计费
-		tokens := UsageTokens{
-			InputTokens:         result.Usage.InputTokens,
-			OutputTokens:        result.Usage.OutputTokens,
-			CacheCreationTokens: result.Usage.CacheCreationInputTokens,
-			CacheReadTokens:     result.Usage.CacheReadInputTokens,
-		}
 		var err error
-		cost, err = s.billingService.CalculateCost(result.Model, tokens, multiplier)
+		cost, err = s.billingService.CalculateCostForGroup(result.Model, groupID, result.Usage.BillingTokens(), multiplier)
 		if err != nil {
 			log.Printf("Calculate cost failed: %v", err)
 			cost = &CostBreakdown{ActualCost: 0}
@@ -3489,12 +3634,26 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 	}
 
 	// 判断计费方式：订阅模式 vs 余额模式
-	isSubscriptionBilling := subscription != nil && apiKey.Group != nil && apiKey.Group.IsSubscriptionType()
+	isSubscriptionBilling := subscription != nil && group != nil && group.IsSubscriptionType()
 	billingType := BillingTypeBalance
 	if isSubscriptionBilling {
 		billingType = BillingTypeSubscription
 	}
 
+	// 分销下级用户：余额计费在分组倍率之上叠加各级分销商加价（订阅计费不加价）
+	var resellerQuote *ResellerQuote
+	baseCost := cost.ActualCost
+	if !isSubscriptionBilling {
+		if quotePayer, err := ResolveBillingPayer(apiKey, user); err == nil {
+			resellerQuote = s.resellerService.Quote(ctx, quotePayer)
+		}
+	}
+	if resellerQuote != nil {
+		markup := resellerQuote.Markup()
+		cost.ActualCost *= markup
+		multiplier *= markup
+	}
+
 	// 创建使用日志
 	durationMs := int(result.Duration.Milliseconds())
 	var imageSize *string
@@ -3502,6 +3661,7 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 		imageSize = &result.ImageSize
 	}
 	accountRateMultiplier := account.BillingRateMultiplier()
+	cacheCreation5m, cacheCreation1h := result.Usage.BillingTokens().cacheCreationSplit()
 	usageLog := &UsageLog{
 		UserID:                user.ID,
 		APIKeyID:              apiKey.ID,
@@ -3512,9 +3672,12 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 		OutputTokens:          result.Usage.OutputTokens,
 		CacheCreationTokens:   result.Usage.CacheCreationInputTokens,
 		CacheReadTokens:       result.Usage.CacheReadInputTokens,
+		CacheCreation5mTokens: cacheCreation5m,
+		CacheCreation1hTokens: cacheCreation1h,
 		InputCost:             cost.InputCost,
 		OutputCost:            cost.OutputCost,
 		CacheCreationCost:     cost.CacheCreationCost,
+		CacheCreation1hCost:   cost.CacheCreation1hCost,
 		CacheReadCost:         cost.CacheReadCost,
 		TotalCost:             cost.TotalCost,
 		ActualCost:            cost.ActualCost,
@@ -3524,8 +3687,11 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 		Stream:                result.Stream,
 		DurationMs:            &durationMs,
 		FirstTokenMs:          result.FirstTokenMs,
+		Hedged:                result.Hedged,
 		ImageCount:            result.ImageCount,
 		ImageSize:             imageSize,
+		RateRule:              &rate.Rule,
+		Tags:                  input.Tags,
 		CreatedAt:             time.Now(),
 	}
 
@@ -3540,56 +3706,61 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 	}
 
 	// 添加分组和订阅关联
-	if apiKey.GroupID != nil {
-		usageLog.GroupID = apiKey.GroupID
-	}
+	usageLog.GroupID = groupID
 	if subscription != nil {
 		usageLog.SubscriptionID = &subscription.ID
 	}
+	usageLog.OrganizationID = apiKey.OrganizationID
 
-	inserted, err := s.usageLogRepo.Create(ctx, usageLog)
-	if err != nil {
-		log.Printf("Create usage log failed: %v", err)
-	}
+	// 粘性会话缓存热度与切换成本统计
+	s.stickyStandby.ObserveUsage(ctx, groupID, input.SessionHash, account.ID, result.Model, result.Usage.BillingTokens(), multiplier)
 
 	if s.cfg != nil && s.cfg.RunMode == config.RunModeSimple {
+		if _, err := s.usageLogRepo.Create(ctx, usageLog); err != nil {
+			log.Printf("Create usage log failed: %v", err)
+		}
 		log.Printf("[SIMPLE MODE] Usage recorded (not billed): user=%d, tokens=%d", usageLog.UserID, usageLog.TotalTokens())
 		s.deferredService.ScheduleLastUsedUpdate(account.ID)
 		return nil
 	}
 
-	shouldBill := inserted || err != nil
-
-	// 根据计费类型执行扣费
-	if isSubscriptionBilling {
-		// 订阅模式：更新订阅用量（使用 TotalCost 原始费用，不考虑倍率）
-		if shouldBill && cost.TotalCost > 0 {
-			if err := s.userSubRepo.IncrementUsage(ctx, subscription.ID, cost.TotalCost); err != nil {
-				log.Printf("Increment subscription usage failed: %v", err)
-			}
-			// 异步更新订阅缓存
-			s.billingCacheService.QueueUpdateSubscriptionUsage(user.ID, *apiKey.GroupID, cost.TotalCost)
-		}
-	} else {
-		// 余额模式：扣除用户余额（使用 ActualCost 考虑倍率后的费用）
-		if shouldBill && cost.ActualCost > 0 {
-			if err := s.userRepo.DeductBalance(ctx, user.ID, cost.ActualCost); err != nil {
-				log.Printf("Deduct balance failed: %v", err)
-			}
-			// 异步更新余额缓存
-			s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
+	// 组织 Key 由组织付费账户扣费
+	payer, payerErr := ResolveBillingPayer(apiKey, user)
+	if payerErr != nil {
+		if _, err := s.usageLogRepo.Create(ctx, usageLog); err != nil {
+			log.Printf("Create usage log failed: %v", err)
 		}
+		log.Printf("ALERT: resolve billing payer failed for api key %d (request %s): %v", apiKey.ID, result.RequestID, payerErr)
+		s.deferredService.ScheduleLastUsedUpdate(account.ID)
+		return nil
 	}
 
+	// 使用日志与计费事件同事务落库，由计费发件箱负责扣费与失败重试
+	s.billingOutboxService.RecordUsage(ctx, usageLog, NewUsageBillingEvent(usageLog, apiKey, payer, cost, baseCost, resellerQuote), input.CostHold)
+
 	// Schedule batch update for account last_used_at
 	s.deferredService.ScheduleLastUsedUpdate(account.ID)
 
 	return nil
 }
 
+// CheckModelPricing 未知价格策略为 block 时拒绝没有任何价格的模型
+func (s *GatewayService) CheckModelPricing(model string, groupID *int64) error {
+	return s.billingService.CheckModelPriced(model, groupID)
+}
+
+// CountTokensLocal 在进程内估算 count_tokens，不占用上游账号
+func (s *GatewayService) CountTokensLocal(parsed *ParsedRequest) (tokenizer.Count, error) {
+	if parsed == nil {
+		return tokenizer.Count{}, tokenizer.ErrInvalidRequest
+	}
+	return tokenizer.CountMessages(tokenizer.ForModel(parsed.Model), parsed.Body)
+}
+
 // ForwardCountTokens 转发 count_tokens 请求到上游 API
 // 特点：不记录使用量、仅支持非流式响应
 func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context, account *Account, parsed *ParsedRequest) error {
+	cfg := s.cfg.Current()
 	if parsed == nil {
 		s.countTokensError(c, http.StatusBadRequest, "invalid_request_error", "Request body is empty")
 		return fmt.Errorf("parse request: empty request")
@@ -3680,8 +3851,8 @@ func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context,
 		upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(respBody))
 		upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 		upstreamDetail := ""
-		if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-			maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+		if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+			maxBytes := cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 			if maxBytes <= 0 {
 				maxBytes = 2048
 			}
@@ -3690,14 +3861,14 @@ func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context,
 		setOpsUpstreamError(c, resp.StatusCode, upstreamMsg, upstreamDetail)
 
 		// 记录上游错误摘要便于排障（不回显请求内容）
-		if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
+		if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
 			log.Printf(
 				"count_tokens upstream error %d (account=%d platform=%s type=%s): %s",
 				resp.StatusCode,
 				account.ID,
 				account.Platform,
 				account.Type,
-				truncateForLog(respBody, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
+				truncateForLog(respBody, cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
 			)
 		}
 
@@ -3791,7 +3962,7 @@ func (s *GatewayService) buildCountTokensRequest(ctx context.Context, c *gin.Con
 	// OAuth 账号：处理 anthropic-beta header
 	if tokenType == "oauth" {
 		req.Header.Set("anthropic-beta", s.getBetaHeader(modelID, c.GetHeader("anthropic-beta")))
-	} else if s.cfg != nil && s.cfg.Gateway.InjectBetaForAPIKey && req.Header.Get("anthropic-beta") == "" {
+	} else if s.cfg != nil && s.cfg.Current().Gateway.InjectBetaForAPIKey && req.Header.Get("anthropic-beta") == "" {
 		// API-key：与 messages 同步的按需 beta 注入（默认关闭）
 		if requestNeedsBetaFeatures(body) {
 			if beta := defaultAPIKeyBetaHeader(body); beta != "" {
@@ -0,0 +1,101 @@
+package service
+
+import "context"
+
+// stickyStandbyEligible 备用账号需满足与 Layer 2 相同的平台/模型条件（窗口费用在切换时检查）
+func (s *GatewayService) stickyStandbyEligible(account *Account, platform string, useMixed bool, requestedModel string) bool {
+	return account.IsSchedulable() &&
+		s.isAccountAllowedForPlatform(account, platform, useMixed) &&
+		account.IsSchedulableForModel(requestedModel) &&
+		(requestedModel == "" || s.isModelSupportedByAccount(account, requestedModel))
+}
+
+// ensureStickyStandby 粘性命中或新绑定后为会话预选备用账号（已存在时仅续期），本次请求已失败的账号不参与预选
+func (s *GatewayService) ensureStickyStandby(ctx context.Context, groupID *int64, sessionHash string, primaryID int64, accounts []Account, platform string, useMixed bool, requestedModel string, isExcluded func(int64) bool) {
+	if !s.stickyStandby.Enabled() || sessionHash == "" {
+		return
+	}
+	s.stickyStandby.EnsureStandby(ctx, derefGroupID(groupID), sessionHash, primaryID, func() []*Account {
+		candidates := make([]*Account, 0, len(accounts))
+		for i := range accounts {
+			if !isExcluded(accounts[i].ID) && s.stickyStandbyEligible(&accounts[i], platform, useMixed, requestedModel) {
+				candidates = append(candidates, &accounts[i])
+			}
+		}
+		return candidates
+	})
+}
+
+// bindStickySession 绑定会话到账号；原绑定账号不同时记录一次切换
+func (s *GatewayService) bindStickySession(ctx context.Context, groupID *int64, sessionHash string, accountID, previousID int64) {
+	if sessionHash == "" || s.cache == nil {
+		return
+	}
+	_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, accountID, stickySessionTTL)
+	if previousID > 0 && previousID != accountID {
+		s.stickyStandby.RecordFailover(ctx, derefGroupID(groupID), sessionHash, previousID, accountID)
+	}
+}
+
+// selectStickyStandby 粘性主账号不可用时优先切换到预选的备用账号，备用账号同样不可用时返回 nil 继续负载感知选择
+func (s *GatewayService) selectStickyStandby(
+	ctx context.Context,
+	groupID *int64,
+	sessionHash string,
+	primaryID int64,
+	accounts []Account,
+	accountByID map[int64]*Account,
+	platform string,
+	useMixed bool,
+	requestedModel string,
+	isExcluded func(int64) bool,
+) *AccountSelectionResult {
+	if !s.stickyStandby.Enabled() || sessionHash == "" || primaryID <= 0 {
+		return nil
+	}
+	gid := derefGroupID(groupID)
+	standbyID := s.stickyStandby.StandbyAccountID(ctx, gid, sessionHash)
+	if standbyID <= 0 || standbyID == primaryID || isExcluded(standbyID) {
+		return nil
+	}
+	account, ok := accountByID[standbyID]
+	if !ok || !s.stickyStandbyEligible(account, platform, useMixed, requestedModel) || !s.isAccountSchedulableForWindowCost(ctx, account, true) {
+		s.stickyStandby.DropStandby(ctx, gid, sessionHash)
+		return nil
+	}
+
+	result, err := s.tryAcquireAccountSlot(ctx, account.ID, account.Concurrency)
+	if err == nil && result.Acquired {
+		if !s.checkAndRegisterSession(ctx, account, sessionHash) {
+			result.ReleaseFunc()
+			return nil
+		}
+		s.bindStickySession(ctx, groupID, sessionHash, account.ID, primaryID)
+		s.ensureStickyStandby(ctx, groupID, sessionHash, account.ID, accounts, platform, useMixed, requestedModel, isExcluded)
+		return &AccountSelectionResult{
+			Account:     account,
+			Acquired:    true,
+			ReleaseFunc: result.ReleaseFunc,
+		}
+	}
+
+	cfg := s.schedulingConfig()
+	if s.concurrencyService == nil {
+		return nil
+	}
+	waitingCount, _ := s.concurrencyService.GetAccountWaitingCount(ctx, account.ID)
+	if waitingCount >= cfg.StickySessionMaxWaiting || !s.checkAndRegisterSession(ctx, account, sessionHash) {
+		return nil
+	}
+	// 等待备用账号前即完成转正，主账号绑定可能已被清理，不能依赖等待后的 BindStickySession 记录切换
+	s.bindStickySession(ctx, groupID, sessionHash, account.ID, primaryID)
+	return &AccountSelectionResult{
+		Account: account,
+		WaitPlan: &AccountWaitPlan{
+			AccountID:      account.ID,
+			MaxConcurrency: account.Concurrency,
+			Timeout:        cfg.StickySessionWaitTimeout,
+			MaxWaiting:     cfg.StickySessionMaxWaiting,
+		},
+	}
+}
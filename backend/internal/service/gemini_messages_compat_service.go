@@ -22,6 +22,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/geminicli"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/googleapi"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tokenizer"
 	"github.com/Wei-Shaw/sub2api/internal/util/responseheaders"
 	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
 
@@ -505,6 +506,7 @@ func (s *GeminiMessagesCompatService) SelectAccountForAIStudioEndpoints(ctx cont
 }
 
 func (s *GeminiMessagesCompatService) Forward(ctx context.Context, c *gin.Context, account *Account, body []byte) (*ForwardResult, error) {
+	cfg := s.cfg.Current()
 	startTime := time.Now()
 
 	var req struct {
@@ -716,8 +718,8 @@ func (s *GeminiMessagesCompatService) Forward(ctx context.Context, c *gin.Contex
 				upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(respBody))
 				upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 				upstreamDetail := ""
-				if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-					maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+				if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+					maxBytes := cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 					if maxBytes <= 0 {
 						maxBytes = 2048
 					}
@@ -791,8 +793,8 @@ func (s *GeminiMessagesCompatService) Forward(ctx context.Context, c *gin.Contex
 				upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(respBody))
 				upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 				upstreamDetail := ""
-				if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-					maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+				if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+					maxBytes := cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 					if maxBytes <= 0 {
 						maxBytes = 2048
 					}
@@ -841,8 +843,8 @@ func (s *GeminiMessagesCompatService) Forward(ctx context.Context, c *gin.Contex
 			upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(respBody))
 			upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 			upstreamDetail := ""
-			if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-				maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+			if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+				maxBytes := cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 				if maxBytes <= 0 {
 					maxBytes = 2048
 				}
@@ -868,8 +870,8 @@ func (s *GeminiMessagesCompatService) Forward(ctx context.Context, c *gin.Contex
 			upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(respBody))
 			upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 			upstreamDetail := ""
-			if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-				maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+			if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+				maxBytes := cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 				if maxBytes <= 0 {
 					maxBytes = 2048
 				}
@@ -950,6 +952,7 @@ func isGeminiSignatureRelatedError(respBody []byte) bool {
 }
 
 func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.Context, account *Account, originalModel string, action string, stream bool, body []byte) (*ForwardResult, error) {
+	cfg := s.cfg.Current()
 	startTime := time.Now()
 
 	if strings.TrimSpace(originalModel) == "" {
@@ -1175,8 +1178,8 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 				upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(respBody))
 				upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 				upstreamDetail := ""
-				if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-					maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+				if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+					maxBytes := cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 					if maxBytes <= 0 {
 						maxBytes = 2048
 					}
@@ -1260,8 +1263,8 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 			upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(evBody))
 			upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 			upstreamDetail := ""
-			if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-				maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+			if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+				maxBytes := cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 				if maxBytes <= 0 {
 					maxBytes = 2048
 				}
@@ -1284,8 +1287,8 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 			upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(evBody))
 			upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 			upstreamDetail := ""
-			if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-				maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+			if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+				maxBytes := cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 				if maxBytes <= 0 {
 					maxBytes = 2048
 				}
@@ -1308,13 +1311,13 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 		upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(respBody))
 		upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 		upstreamDetail := ""
-		if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-			maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+		if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+			maxBytes := cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 			if maxBytes <= 0 {
 				maxBytes = 2048
 			}
 			upstreamDetail = truncateString(string(respBody), maxBytes)
-			log.Printf("[Gemini] native upstream error %d: %s", resp.StatusCode, truncateForLog(respBody, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes))
+			log.Printf("[Gemini] native upstream error %d: %s", resp.StatusCode, truncateForLog(respBody, cfg.Gateway.LogUpstreamErrorBodyMaxBytes))
 		}
 		setOpsUpstreamError(c, resp.StatusCode, upstreamMsg, upstreamDetail)
 		appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
@@ -1439,11 +1442,12 @@ func sanitizeUpstreamErrorMessage(msg string) string {
 }
 
 func (s *GeminiMessagesCompatService) writeGeminiMappedError(c *gin.Context, account *Account, upstreamStatus int, upstreamRequestID string, body []byte) error {
+	cfg := s.cfg.Current()
 	upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(body))
 	upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 	upstreamDetail := ""
-	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-		maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+	if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+		maxBytes := cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 		if maxBytes <= 0 {
 			maxBytes = 2048
 		}
@@ -1461,8 +1465,8 @@ func (s *GeminiMessagesCompatService) writeGeminiMappedError(c *gin.Context, acc
 		Detail:             upstreamDetail,
 	})
 
-	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-		log.Printf("[Gemini] upstream error %d: %s", upstreamStatus, truncateForLog(body, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes))
+	if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+		log.Printf("[Gemini] upstream error %d: %s", upstreamStatus, truncateForLog(body, cfg.Gateway.LogUpstreamErrorBodyMaxBytes))
 	}
 
 	var statusCode int
@@ -2042,82 +2046,18 @@ func isGeminiInsufficientScope(headers http.Header, body []byte) bool {
 	return strings.Contains(lower, "insufficient authentication scopes") || strings.Contains(lower, "access_token_scope_insufficient")
 }
 
-func estimateGeminiCountTokens(reqBody []byte) int {
-	var obj map[string]any
-	if err := json.Unmarshal(reqBody, &obj); err != nil {
-		return 0
-	}
-
-	var texts []string
-
-	// systemInstruction.parts[].text
-	if si, ok := obj["systemInstruction"].(map[string]any); ok {
-		if parts, ok := si["parts"].([]any); ok {
-			for _, p := range parts {
-				if pm, ok := p.(map[string]any); ok {
-					if t, ok := pm["text"].(string); ok && strings.TrimSpace(t) != "" {
-						texts = append(texts, t)
-					}
-				}
-			}
-		}
-	}
-
-	// contents[].parts[].text
-	if contents, ok := obj["contents"].([]any); ok {
-		for _, c := range contents {
-			cm, ok := c.(map[string]any)
-			if !ok {
-				continue
-			}
-			parts, ok := cm["parts"].([]any)
-			if !ok {
-				continue
-			}
-			for _, p := range parts {
-				pm, ok := p.(map[string]any)
-				if !ok {
-					continue
-				}
-				if t, ok := pm["text"].(string); ok && strings.TrimSpace(t) != "" {
-					texts = append(texts, t)
-				}
-			}
-		}
-	}
-
-	total := 0
-	for _, t := range texts {
-		total += estimateTokensForText(t)
-	}
-	if total < 0 {
-		return 0
-	}
-	return total
+// CountTokensLocal 在进程内估算 Gemini countTokens，不占用上游账号
+func (s *GeminiMessagesCompatService) CountTokensLocal(body []byte) (tokenizer.Count, error) {
+	return tokenizer.CountGeminiRequest(tokenizer.Gemini, body)
 }
 
-func estimateTokensForText(s string) int {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return 0
-	}
-	runes := []rune(s)
-	if len(runes) == 0 {
+// estimateGeminiCountTokens 上游 countTokens 不可用时的本地估算
+func estimateGeminiCountTokens(reqBody []byte) int {
+	count, err := tokenizer.CountGeminiRequest(tokenizer.Gemini, reqBody)
+	if err != nil {
 		return 0
 	}
-	ascii := 0
-	for _, r := range runes {
-		if r <= 0x7f {
-			ascii++
-		}
-	}
-	asciiRatio := float64(ascii) / float64(len(runes))
-	if asciiRatio >= 0.8 {
-		// Roughly 4 chars per token for English-like text.
-		return (len(runes) + 3) / 4
-	}
-	// For CJK-heavy text, approximate 1 rune per token.
-	return len(runes)
+	return count.InputTokens
 }
 
 type UpstreamHTTPResult struct {
@@ -2270,10 +2210,28 @@ func (s *GeminiMessagesCompatService) handleNativeStreamingResponse(c *gin.Conte
 //
 // This is used to support Gemini SDKs that call models listing endpoints before generation.
 func (s *GeminiMessagesCompatService) ForwardAIStudioGET(ctx context.Context, account *Account, path string) (*UpstreamHTTPResult, error) {
-	if account == nil {
+	return s.ForwardAIStudio(ctx, &AIStudioRequest{Account: account, Method: http.MethodGet, Path: path})
+}
+
+// AIStudioRequest AI Studio 透传请求（Files / cachedContents 等资源接口）
+type AIStudioRequest struct {
+	Account  *Account
+	Method   string
+	Path     string      // 以 / 开头，如 /v1beta/files/abc
+	RawQuery string      // 已去除客户端凭证的查询串
+	Header   http.Header // 需要透传的请求头（不含鉴权）
+	Body     io.Reader
+	// ContentLength 请求体长度，-1 表示未知
+	ContentLength int64
+}
+
+// ForwardAIStudio 使用账号凭证向 AI Studio 发送请求并读取完整响应（响应体上限 8MB）
+func (s *GeminiMessagesCompatService) ForwardAIStudio(ctx context.Context, in *AIStudioRequest) (*UpstreamHTTPResult, error) {
+	if in == nil || in.Account == nil {
 		return nil, errors.New("account is nil")
 	}
-	path = strings.TrimSpace(path)
+	account := in.Account
+	path := strings.TrimSpace(in.Path)
 	if path == "" || !strings.HasPrefix(path, "/") {
 		return nil, errors.New("invalid path")
 	}
@@ -2287,16 +2245,31 @@ func (s *GeminiMessagesCompatService) ForwardAIStudioGET(ctx context.Context, ac
 		return nil, err
 	}
 	fullURL := strings.TrimRight(normalizedBaseURL, "/") + path
+	if in.RawQuery != "" {
+		fullURL += "?" + in.RawQuery
+	}
 
 	var proxyURL string
 	if account.ProxyID != nil && account.Proxy != nil {
 		proxyURL = account.Proxy.URL()
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	method := in.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, in.Body)
 	if err != nil {
 		return nil, err
 	}
+	if in.Body != nil && in.ContentLength >= 0 {
+		req.ContentLength = in.ContentLength
+	}
+	for k, vv := range in.Header {
+		for _, v := range vv {
+			req.Header.Add(k, v)
+		}
+	}
 
 	switch account.Type {
 	case AccountTypeAPIKey:
@@ -2330,6 +2303,12 @@ func (s *GeminiMessagesCompatService) ForwardAIStudioGET(ctx context.Context, ac
 	if wwwAuthenticate != "" {
 		filteredHeaders.Set("Www-Authenticate", wwwAuthenticate)
 	}
+	// 可续传上传协议依赖 X-Goog-Upload-* 响应头（上传地址、状态、分片粒度）
+	for k, vv := range resp.Header {
+		if strings.HasPrefix(http.CanonicalHeaderKey(k), "X-Goog-Upload-") {
+			filteredHeaders[http.CanonicalHeaderKey(k)] = vv
+		}
+	}
 	return &UpstreamHTTPResult{
 		StatusCode: resp.StatusCode,
 		Headers:    filteredHeaders,
@@ -0,0 +1,544 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/tidwall/gjson"
+)
+
+// Gemini 资源类型
+const (
+	GeminiResourceTypeFile          = "file"
+	GeminiResourceTypeCachedContent = "cached_content"
+	// GeminiResourceTypeUpload 可续传上传会话（name 为 uploads/<upload_id>）
+	GeminiResourceTypeUpload = "upload"
+)
+
+const (
+	geminiUploadSessionTTL       = 24 * time.Hour
+	geminiResourceCleanupEvery   = time.Hour
+	geminiResourceCleanupBatch   = 1000
+	geminiResourceDefaultPage    = 100
+	geminiResourceMaxPage        = 1000
+	geminiFileStorageUsageModel  = "gemini-file-storage"
+	geminiStorageRequestIDPrefix = "gemini-storage:"
+)
+
+var (
+	ErrGeminiResourceNotFound           = infraerrors.NotFound("GEMINI_RESOURCE_NOT_FOUND", "resource not found")
+	ErrGeminiResourceAccountConflict    = infraerrors.BadRequest("GEMINI_RESOURCE_ACCOUNT_CONFLICT", "referenced files and cached contents were created on different upstream accounts")
+	ErrGeminiResourceAccountUnavailable = infraerrors.ServiceUnavailable("GEMINI_RESOURCE_ACCOUNT_UNAVAILABLE", "the upstream account holding this resource is no longer available")
+)
+
+// geminiFileRefPattern 匹配 fileUri 中的 files/<id>（完整 URI 或资源名）
+var geminiFileRefPattern = regexp.MustCompile(`(?:^|/)(files/[A-Za-z0-9_-]+)$`)
+
+// GeminiResource 上游文件/缓存/上传会话与账号的归属记录
+type GeminiResource struct {
+	ID           int64
+	Name         string
+	ResourceType string
+	AccountID    int64
+	UserID       int64
+	APIKeyID     int64
+	GroupID      *int64
+	Model        string
+	SizeBytes    int64
+	TokenCount   int64
+	// Metadata 上游返回的资源对象原文
+	Metadata  json.RawMessage
+	ExpiresAt *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// GeminiResourceRepository 资源归属表
+type GeminiResourceRepository interface {
+	// Upsert 按 name 写入或覆盖归属记录
+	Upsert(ctx context.Context, resource *GeminiResource) error
+	// GetByName 不存在时返回 ErrGeminiResourceNotFound
+	GetByName(ctx context.Context, name string) (*GeminiResource, error)
+	// ListByUser 按 id 升序列出未过期资源
+	ListByUser(ctx context.Context, userID int64, resourceType string, afterID int64, limit int) ([]GeminiResource, error)
+	Delete(ctx context.Context, name string) error
+	DeleteExpired(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+// GeminiResourceCaller 资源请求的调用方信息（用于归属与计费）
+type GeminiResourceCaller struct {
+	APIKey       *APIKey
+	Subscription *UserSubscription
+	UserAgent    string
+	IPAddress    string
+	Tags         map[string]string
+}
+
+// GeminiResourceService Gemini Files API 与显式上下文缓存（cachedContents）透传。
+//
+// 文件与缓存只存在于创建它们的 AI Studio API Key 账号上，因此：
+//   - 创建时记录 资源名 → 账号 的归属，后续读取/更新/删除只转发到归属账号；
+//   - generateContent 引用 files/... 或 cachedContents/... 时固定路由到归属账号；
+//   - 缓存按 TTL 预收 token·小时存储费，文件按保留时长收取 GB·天存储费（单价见 gateway.gemini_resources）。
+//
+// 提前删除缓存不退还已预收的存储费。
+type GeminiResourceService struct {
+	repo        GeminiResourceRepository
+	accountRepo AccountRepository
+	compat      *GeminiMessagesCompatService
+	gateway     *GatewayService
+	cfg         *config.Config
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+
+	workerCtx    context.Context
+	workerCancel context.CancelFunc
+}
+
+// NewGeminiResourceService 创建 Gemini 资源透传服务
+func NewGeminiResourceService(
+	repo GeminiResourceRepository,
+	accountRepo AccountRepository,
+	compat *GeminiMessagesCompatService,
+	gateway *GatewayService,
+	cfg *config.Config,
+) *GeminiResourceService {
+	workerCtx, workerCancel := context.WithCancel(context.Background())
+	return &GeminiResourceService{
+		repo:         repo,
+		accountRepo:  accountRepo,
+		compat:       compat,
+		gateway:      gateway,
+		cfg:          cfg,
+		workerCtx:    workerCtx,
+		workerCancel: workerCancel,
+	}
+}
+
+// Enabled 是否开放 Files / cachedContents 接口
+func (s *GeminiResourceService) Enabled() bool {
+	return s != nil && s.cfg != nil && s.cfg.Current().Gateway.GeminiResources.Enabled
+}
+
+// Start 启动过期归属记录清理任务
+func (s *GeminiResourceService) Start() {
+	if s == nil || s.repo == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			ticker := time.NewTicker(geminiResourceCleanupEvery)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-s.workerCtx.Done():
+					return
+				case <-ticker.C:
+					s.cleanupExpired()
+				}
+			}
+		}()
+	})
+}
+
+// Stop 停止清理任务
+func (s *GeminiResourceService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.workerCancel != nil {
+			s.workerCancel()
+		}
+		s.wg.Wait()
+		log.Printf("[GeminiResource] stopped")
+	})
+}
+
+func (s *GeminiResourceService) cleanupExpired() {
+	ctx, cancel := context.WithTimeout(s.workerCtx, time.Minute)
+	defer cancel()
+	var total int64
+	for {
+		deleted, err := s.repo.DeleteExpired(ctx, time.Now(), geminiResourceCleanupBatch)
+		if err != nil {
+			log.Printf("[GeminiResource] cleanup expired failed: %v", err)
+			return
+		}
+		total += deleted
+		if deleted < geminiResourceCleanupBatch {
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("[GeminiResource] cleaned up %d expired resources", total)
+	}
+}
+
+// SelectAccount 为新建文件/缓存选择 AI Studio API Key 账号（OAuth 账号不支持 Files/cachedContents）
+func (s *GeminiResourceService) SelectAccount(ctx context.Context, groupID *int64) (*Account, error) {
+	accounts, err := s.compat.listSchedulableAccountsOnce(ctx, groupID, PlatformGemini, true)
+	if err != nil {
+		return nil, err
+	}
+	var selected *Account
+	for i := range accounts {
+		acc := &accounts[i]
+		if acc.Platform != PlatformGemini || acc.Type != AccountTypeAPIKey || strings.TrimSpace(acc.GetCredential("api_key")) == "" {
+			continue
+		}
+		if selected == nil || acc.Priority < selected.Priority ||
+			(acc.Priority == selected.Priority && lastUsedBefore(acc, selected)) {
+			selected = acc
+		}
+	}
+	if selected == nil {
+		return nil, infraerrors.ServiceUnavailable("NO_GEMINI_API_KEY_ACCOUNT", "no available Gemini API key accounts")
+	}
+	return selected, nil
+}
+
+func lastUsedBefore(a, b *Account) bool {
+	switch {
+	case a.LastUsedAt == nil:
+		return b.LastUsedAt != nil
+	case b.LastUsedAt == nil:
+		return false
+	default:
+		return a.LastUsedAt.Before(*b.LastUsedAt)
+	}
+}
+
+// Lookup 读取调用方拥有的资源及其归属账号；不属于该用户或已过期的资源视为不存在。
+func (s *GeminiResourceService) Lookup(ctx context.Context, userID int64, name string, resourceType string) (*GeminiResource, *Account, error) {
+	resource, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resource.UserID != userID || resource.ResourceType != resourceType {
+		return nil, nil, ErrGeminiResourceNotFound
+	}
+	if resource.ExpiresAt != nil && !resource.ExpiresAt.After(time.Now()) {
+		return nil, nil, ErrGeminiResourceNotFound
+	}
+	account, err := s.accountRepo.GetByID(ctx, resource.AccountID)
+	if err != nil || account == nil || !account.IsActive() {
+		return nil, nil, ErrGeminiResourceAccountUnavailable
+	}
+	return resource, account, nil
+}
+
+// Forward 转发到指定账号；上游 401/403/429 时应用账号限流/停用处理
+func (s *GeminiResourceService) Forward(ctx context.Context, req *AIStudioRequest) (*UpstreamHTTPResult, error) {
+	res, err := s.compat.ForwardAIStudio(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusTooManyRequests {
+		s.compat.handleGeminiUpstreamError(ctx, req.Account, res.StatusCode, res.Headers, res.Body)
+	}
+	return res, nil
+}
+
+// RecordUploadSession 记录可续传上传会话所在账号，后续分片按 upload_id 路由
+func (s *GeminiResourceService) RecordUploadSession(ctx context.Context, caller *GeminiResourceCaller, account *Account, uploadID string) error {
+	expiresAt := time.Now().Add(geminiUploadSessionTTL)
+	return s.repo.Upsert(ctx, s.newResource(caller, account, "uploads/"+uploadID, GeminiResourceTypeUpload, &expiresAt))
+}
+
+// CompleteUpload 上传完成：记录文件归属、结束上传会话并收取文件存储费
+func (s *GeminiResourceService) CompleteUpload(ctx context.Context, caller *GeminiResourceCaller, account *Account, uploadID string, body []byte) error {
+	file := gjson.GetBytes(body, "file")
+	if !file.Exists() || file.Get("name").String() == "" {
+		return nil
+	}
+	resource := s.newResource(caller, account, file.Get("name").String(), GeminiResourceTypeFile, parseGoogleTime(file.Get("expirationTime").String()))
+	resource.SizeBytes = file.Get("sizeBytes").Int()
+	resource.Metadata = json.RawMessage(file.Raw)
+	if err := s.repo.Upsert(ctx, resource); err != nil {
+		return err
+	}
+	if uploadID != "" {
+		if err := s.repo.Delete(ctx, "uploads/"+uploadID); err != nil {
+			log.Printf("[GeminiResource] delete upload session %s failed: %v", uploadID, err)
+		}
+	}
+
+	created := parseGoogleTime(file.Get("createTime").String())
+	if resource.ExpiresAt != nil && created != nil && resource.SizeBytes > 0 {
+		days := resource.ExpiresAt.Sub(*created).Hours() / 24
+		gbDays := float64(resource.SizeBytes) / (1 << 30) * days
+		s.recordStorage(caller, account, &ForwardResult{
+			RequestID:     geminiStorageRequestIDPrefix + resource.Name,
+			Model:         geminiFileStorageUsageModel,
+			StorageGBDays: gbDays,
+		})
+	}
+	return nil
+}
+
+// RecordCachedContent 记录新建或更新后的缓存归属，并按新增 TTL 预收存储费。
+// previous 为更新前的记录（新建时为 nil）。
+func (s *GeminiResourceService) RecordCachedContent(ctx context.Context, caller *GeminiResourceCaller, account *Account, body []byte, previous *GeminiResource) error {
+	cache := gjson.ParseBytes(body)
+	name := cache.Get("name").String()
+	if name == "" {
+		return nil
+	}
+	model := strings.TrimPrefix(cache.Get("model").String(), "models/")
+	resource := s.newResource(caller, account, name, GeminiResourceTypeCachedContent, parseGoogleTime(cache.Get("expireTime").String()))
+	resource.Model = model
+	resource.TokenCount = cache.Get("usageMetadata.totalTokenCount").Int()
+	resource.Metadata = json.RawMessage(cache.Raw)
+	if previous != nil {
+		// 更新由原创建者以外的 Key 发起时保留原归属
+		resource.UserID, resource.APIKeyID, resource.GroupID = previous.UserID, previous.APIKeyID, previous.GroupID
+		if resource.Model == "" {
+			resource.Model = previous.Model
+		}
+		if resource.TokenCount == 0 {
+			resource.TokenCount = previous.TokenCount
+		}
+	}
+	if err := s.repo.Upsert(ctx, resource); err != nil {
+		return err
+	}
+
+	if resource.ExpiresAt == nil || resource.TokenCount <= 0 {
+		return nil
+	}
+	var from *time.Time
+	if previous != nil {
+		from = previous.ExpiresAt
+	} else {
+		from = parseGoogleTime(cache.Get("createTime").String())
+	}
+	if from == nil || !resource.ExpiresAt.After(*from) {
+		return nil
+	}
+	hours := resource.ExpiresAt.Sub(*from).Hours()
+	s.recordStorage(caller, account, &ForwardResult{
+		RequestID: geminiStorageRequestIDPrefix + name + ":" + resource.ExpiresAt.UTC().Format(time.RFC3339),
+		Model:     resource.Model,
+		Usage:     ClaudeUsage{CacheCreationInputTokens: int(resource.TokenCount)},
+		// 缓存存储按 token·小时计费
+		StorageTokenHours: float64(resource.TokenCount) * hours,
+	})
+	return nil
+}
+
+// Remove 删除归属记录（上游已删除或不存在）
+func (s *GeminiResourceService) Remove(ctx context.Context, name string) error {
+	return s.repo.Delete(ctx, name)
+}
+
+// List 按 Gemini 列表格式分页返回调用方的资源（pageToken 为上一页最后一条记录的 id）
+func (s *GeminiResourceService) List(ctx context.Context, userID int64, resourceType string, pageSize int, pageToken string) ([]json.RawMessage, string, error) {
+	if pageSize <= 0 {
+		pageSize = geminiResourceDefaultPage
+	}
+	if pageSize > geminiResourceMaxPage {
+		pageSize = geminiResourceMaxPage
+	}
+	var afterID int64
+	if pageToken != "" {
+		parsed, err := parsePageToken(pageToken)
+		if err != nil {
+			return nil, "", infraerrors.BadRequest("INVALID_PAGE_TOKEN", "invalid pageToken")
+		}
+		afterID = parsed
+	}
+	rows, err := s.repo.ListByUser(ctx, userID, resourceType, afterID, pageSize+1)
+	if err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(rows) > pageSize {
+		rows = rows[:pageSize]
+		next = formatPageToken(rows[len(rows)-1].ID)
+	}
+	out := make([]json.RawMessage, 0, len(rows))
+	for _, row := range rows {
+		if len(row.Metadata) > 0 {
+			out = append(out, row.Metadata)
+		}
+	}
+	return out, next, nil
+}
+
+// ResolveAffinity 解析 generateContent / cachedContents 请求体引用的文件与缓存，返回归属账号（无引用时为 nil）。
+// 只识别调用方自己创建的资源；引用分布在不同账号时返回 ErrGeminiResourceAccountConflict。
+func (s *GeminiResourceService) ResolveAffinity(ctx context.Context, userID int64, body []byte) (*Account, error) {
+	if !s.Enabled() {
+		return nil, nil
+	}
+	names := GeminiResourceRefs(body)
+	if len(names) == 0 {
+		return nil, nil
+	}
+	var accountID int64
+	for _, name := range names {
+		resource, err := s.repo.GetByName(ctx, name)
+		if err != nil {
+			if infraerrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if resource.UserID != userID {
+			continue
+		}
+		if accountID != 0 && resource.AccountID != accountID {
+			return nil, ErrGeminiResourceAccountConflict
+		}
+		accountID = resource.AccountID
+	}
+	if accountID == 0 {
+		return nil, nil
+	}
+	account, err := s.accountRepo.GetByID(ctx, accountID)
+	if err != nil || account == nil || !account.IsActive() {
+		return nil, ErrGeminiResourceAccountUnavailable
+	}
+	return account, nil
+}
+
+// PinnedSelection 为固定账号构造调度结果：能立即占用槽位则直接返回，否则按粘性会话的等待策略排队
+func (s *GeminiResourceService) PinnedSelection(ctx context.Context, account *Account) (*AccountSelectionResult, error) {
+	result, err := s.gateway.tryAcquireAccountSlot(ctx, account.ID, account.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+	if result.Acquired {
+		return &AccountSelectionResult{Account: account, Acquired: true, ReleaseFunc: result.ReleaseFunc}, nil
+	}
+	cfg := s.gateway.schedulingConfig()
+	return &AccountSelectionResult{
+		Account: account,
+		WaitPlan: &AccountWaitPlan{
+			AccountID:      account.ID,
+			MaxConcurrency: account.Concurrency,
+			Timeout:        cfg.StickySessionWaitTimeout,
+			MaxWaiting:     cfg.StickySessionMaxWaiting,
+		},
+	}, nil
+}
+
+// GeminiResourceRefs 提取请求体中引用的 cachedContents/... 与 files/... 资源名（去重）
+func GeminiResourceRefs(body []byte) []string {
+	seen := make(map[string]struct{})
+	var out []string
+	add := func(name string) {
+		if name == "" {
+			return
+		}
+		if _, ok := seen[name]; ok {
+			return
+		}
+		seen[name] = struct{}{}
+		out = append(out, name)
+	}
+
+	for _, key := range []string{"cachedContent", "cached_content"} {
+		if v := strings.TrimSpace(gjson.GetBytes(body, key).String()); strings.HasPrefix(v, "cachedContents/") {
+			add(v)
+		}
+	}
+	gjson.GetBytes(body, "contents").ForEach(func(_, content gjson.Result) bool {
+		content.Get("parts").ForEach(func(_, part gjson.Result) bool {
+			for _, path := range []string{"fileData.fileUri", "file_data.file_uri"} {
+				if m := geminiFileRefPattern.FindStringSubmatch(strings.TrimSpace(stripURLQuery(part.Get(path).String()))); m != nil {
+					add(m[1])
+				}
+			}
+			return true
+		})
+		return true
+	})
+	return out
+}
+
+func (s *GeminiResourceService) newResource(caller *GeminiResourceCaller, account *Account, name, resourceType string, expiresAt *time.Time) *GeminiResource {
+	resource := &GeminiResource{
+		Name:         name,
+		ResourceType: resourceType,
+		AccountID:    account.ID,
+		ExpiresAt:    expiresAt,
+	}
+	if caller != nil && caller.APIKey != nil {
+		resource.UserID = caller.APIKey.UserID
+		resource.APIKeyID = caller.APIKey.ID
+		resource.GroupID = caller.APIKey.GroupID
+	}
+	return resource
+}
+
+// recordStorage 异步记录存储费用（与 generateContent 计费共用 RecordUsage）
+func (s *GeminiResourceService) recordStorage(caller *GeminiResourceCaller, account *Account, result *ForwardResult) {
+	if caller == nil || caller.APIKey == nil || s.gateway == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.gateway.RecordUsage(ctx, &RecordUsageInput{
+			Result:       result,
+			APIKey:       caller.APIKey,
+			User:         caller.APIKey.User,
+			Account:      account,
+			Subscription: caller.Subscription,
+			UserAgent:    caller.UserAgent,
+			IPAddress:    caller.IPAddress,
+			Tags:         caller.Tags,
+		}); err != nil {
+			log.Printf("[GeminiResource] record storage usage failed: %v", err)
+		}
+	}()
+}
+
+// parseGoogleTime 解析 RFC3339 时间（Google API 的 Timestamp JSON 格式）
+func parseGoogleTime(raw string) *time.Time {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+func stripURLQuery(raw string) string {
+	if u, err := url.Parse(raw); err == nil && u.Path != "" {
+		return u.Path
+	}
+	return raw
+}
+
+func parsePageToken(token string) (int64, error) {
+	id, err := strconv.ParseInt(token, 10, 64)
+	if err != nil || id < 0 {
+		return 0, errors.New("invalid page token")
+	}
+	return id, nil
+}
+
+func formatPageToken(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,157 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// geminiResourceRepoStub 内存实现，按 id 升序保存
+type geminiResourceRepoStub struct {
+	rows []GeminiResource
+}
+
+func (r *geminiResourceRepoStub) Upsert(_ context.Context, resource *GeminiResource) error {
+	for i := range r.rows {
+		if r.rows[i].Name == resource.Name {
+			resource.ID = r.rows[i].ID
+			r.rows[i] = *resource
+			return nil
+		}
+	}
+	resource.ID = int64(len(r.rows) + 1)
+	r.rows = append(r.rows, *resource)
+	return nil
+}
+
+func (r *geminiResourceRepoStub) GetByName(_ context.Context, name string) (*GeminiResource, error) {
+	for i := range r.rows {
+		if r.rows[i].Name == name {
+			row := r.rows[i]
+			return &row, nil
+		}
+	}
+	return nil, ErrGeminiResourceNotFound
+}
+
+func (r *geminiResourceRepoStub) ListByUser(_ context.Context, userID int64, resourceType string, afterID int64, limit int) ([]GeminiResource, error) {
+	var out []GeminiResource
+	for _, row := range r.rows {
+		if row.UserID == userID && row.ResourceType == resourceType && row.ID > afterID && len(out) < limit {
+			out = append(out, row)
+		}
+	}
+	return out, nil
+}
+
+func (r *geminiResourceRepoStub) Delete(_ context.Context, name string) error {
+	for i := range r.rows {
+		if r.rows[i].Name == name {
+			r.rows = append(r.rows[:i], r.rows[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *geminiResourceRepoStub) DeleteExpired(context.Context, time.Time, int) (int64, error) {
+	return 0, nil
+}
+
+func newGeminiResourceTestService(repo GeminiResourceRepository) *GeminiResourceService {
+	cfg := &config.Config{}
+	cfg.Gateway.GeminiResources.Enabled = true
+	return NewGeminiResourceService(repo, nil, nil, nil, cfg)
+}
+
+func TestGeminiResourceRefs(t *testing.T) {
+	body := []byte(`{
+		"cachedContent": "cachedContents/c1",
+		"contents": [
+			{"parts": [
+				{"fileData": {"fileUri": "https://generativelanguage.googleapis.com/v1beta/files/abc-123?alt=media"}},
+				{"file_data": {"file_uri": "files/xyz"}},
+				{"fileData": {"fileUri": "gs://bucket/object.pdf"}},
+				{"text": "hello"}
+			]},
+			{"parts": [{"fileData": {"fileUri": "files/abc-123"}}]}
+		]
+	}`)
+	require.Equal(t, []string{"cachedContents/c1", "files/abc-123", "files/xyz"}, GeminiResourceRefs(body))
+	require.Empty(t, GeminiResourceRefs([]byte(`{"contents":[{"parts":[{"text":"hi"}]}]}`)))
+}
+
+func TestCalculateStorageCost(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Gateway.GeminiResources.CacheStoragePricePerMTokHour = 1.0
+	cfg.Gateway.GeminiResources.CacheStorageModelPrices = map[string]float64{
+		"gemini-2.5":     4.5,
+		"gemini-2.5-pro": 4.5,
+		"gemini-2.5-fla": 1.0,
+	}
+	cfg.Gateway.GeminiResources.FileStoragePricePerGBDay = 0.02
+	svc := &BillingService{cfg: cfg}
+
+	// 100 万 token 缓存 2 小时，最长前缀 gemini-2.5-fla 生效
+	cost := svc.CalculateStorageCost("models/gemini-2.5-flash", 2_000_000, 0, 1.0)
+	require.InDelta(t, 2.0, cost.CacheCreationCost, 1e-9)
+	require.InDelta(t, 2.0, cost.TotalCost, 1e-9)
+
+	cost = svc.CalculateStorageCost("gemini-2.0-flash", 1_000_000, 0, 2.0)
+	require.InDelta(t, 1.0, cost.TotalCost, 1e-9)
+	require.InDelta(t, 2.0, cost.ActualCost, 1e-9)
+
+	cost = svc.CalculateStorageCost(geminiFileStorageUsageModel, 0, 10, 1.0)
+	require.InDelta(t, 0, cost.CacheCreationCost, 1e-9)
+	require.InDelta(t, 0.2, cost.TotalCost, 1e-9)
+}
+
+func TestGeminiResourceServiceListPaging(t *testing.T) {
+	repo := &geminiResourceRepoStub{}
+	for _, name := range []string{"files/a", "files/b", "files/c"} {
+		repo.rows = append(repo.rows, GeminiResource{
+			ID: int64(len(repo.rows) + 1), Name: name, ResourceType: GeminiResourceTypeFile, UserID: 7,
+			Metadata: json.RawMessage(`{"name":"` + name + `"}`),
+		})
+	}
+	repo.rows = append(repo.rows, GeminiResource{ID: 4, Name: "files/other", ResourceType: GeminiResourceTypeFile, UserID: 8, Metadata: json.RawMessage(`{}`)})
+	svc := newGeminiResourceTestService(repo)
+
+	items, next, err := svc.List(context.Background(), 7, GeminiResourceTypeFile, 2, "")
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, "2", next)
+
+	items, next, err = svc.List(context.Background(), 7, GeminiResourceTypeFile, 2, next)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.JSONEq(t, `{"name":"files/c"}`, string(items[0]))
+	require.Empty(t, next)
+
+	_, _, err = svc.List(context.Background(), 7, GeminiResourceTypeFile, 2, "bad")
+	require.Error(t, err)
+}
+
+func TestGeminiResourceServiceResolveAffinityConflict(t *testing.T) {
+	repo := &geminiResourceRepoStub{rows: []GeminiResource{
+		{ID: 1, Name: "files/a", ResourceType: GeminiResourceTypeFile, UserID: 7, AccountID: 1},
+		{ID: 2, Name: "cachedContents/c", ResourceType: GeminiResourceTypeCachedContent, UserID: 7, AccountID: 2},
+		{ID: 3, Name: "files/foreign", ResourceType: GeminiResourceTypeFile, UserID: 8, AccountID: 3},
+	}}
+	svc := newGeminiResourceTestService(repo)
+
+	body := []byte(`{"cachedContent":"cachedContents/c","contents":[{"parts":[{"fileData":{"fileUri":"files/a"}}]}]}`)
+	_, err := svc.ResolveAffinity(context.Background(), 7, body)
+	require.ErrorIs(t, err, ErrGeminiResourceAccountConflict)
+
+	// 其他用户的资源与未登记的资源不参与路由
+	account, err := svc.ResolveAffinity(context.Background(), 7, []byte(`{"contents":[{"parts":[{"fileData":{"fileUri":"files/foreign"}},{"fileData":{"fileUri":"files/unknown"}}]}]}`))
+	require.NoError(t, err)
+	require.Nil(t, account)
+}
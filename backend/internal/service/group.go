@@ -36,6 +36,18 @@ type Group struct {
 	ModelRouting        map[string][]int64
 	ModelRoutingEnabled bool
 
+	// OverdraftUSD 请求前预扣费用时允许透支的额度（0 表示不允许透支）
+	OverdraftUSD float64
+
+	// CountTokensMode count_tokens 计数方式：upstream / local / local_fallback
+	CountTokensMode string
+
+	// HedgeEnabled 首字节过慢时是否向第二个账号发送对冲请求
+	HedgeEnabled bool
+
+	// ModelFallback 模型降级链：容量不足时按顺序降级到后备模型（nil 表示不降级）
+	ModelFallback *ModelFallbackConfig
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
@@ -55,6 +67,16 @@ func (g *Group) IsFreeSubscription() bool {
 	return g.IsSubscriptionType() && g.RateMultiplier == 0
 }
 
+// GetCountTokensMode 返回 count_tokens 计数方式，未配置时转发上游
+func (g *Group) GetCountTokensMode() string {
+	switch g.CountTokensMode {
+	case CountTokensModeLocal, CountTokensModeLocalFallback:
+		return g.CountTokensMode
+	default:
+		return CountTokensModeUpstream
+	}
+}
+
 func (g *Group) HasDailyLimit() bool {
 	return g.DailyLimitUSD != nil && *g.DailyLimitUSD > 0
 }
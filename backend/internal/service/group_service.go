@@ -11,6 +11,8 @@ import (
 var (
 	ErrGroupNotFound = infraerrors.NotFound("GROUP_NOT_FOUND", "group not found")
 	ErrGroupExists   = infraerrors.Conflict("GROUP_EXISTS", "group name already exists")
+
+	ErrInvalidGroupOverdraft = infraerrors.BadRequest("INVALID_GROUP_OVERDRAFT", "overdraft_usd must not be negative")
 )
 
 type GroupRepository interface {
@@ -0,0 +1,378 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// 内容策略动作
+const (
+	GuardrailActionBlock  = "block"  // 拒绝请求 / 中断响应
+	GuardrailActionRedact = "redact" // 替换为 [REDACTED:<检测器>]
+	GuardrailActionLog    = "log"    // 仅记录命中
+)
+
+// 内容策略检查方向
+const (
+	GuardrailDirectionRequest  = "request"
+	GuardrailDirectionResponse = "response"
+)
+
+// 检测器名称（写入 guardrail_events.findings）
+const (
+	GuardrailDetectorKeyword    = "keyword"
+	GuardrailDetectorRegex      = "regex"
+	GuardrailDetectorAWSKey     = "aws_key"
+	GuardrailDetectorPrivateKey = "private_key"
+	GuardrailDetectorJWT        = "jwt"
+	GuardrailDetectorEmail      = "email"
+	GuardrailDetectorPhone      = "phone"
+	GuardrailDetectorHook       = "hook"
+)
+
+var (
+	ErrGuardrailPolicyNotFound   = infraerrors.NotFound("GUARDRAIL_POLICY_NOT_FOUND", "guardrail policy not found")
+	ErrGuardrailPolicyInvalid    = infraerrors.BadRequest("GUARDRAIL_POLICY_INVALID", "invalid guardrail policy")
+	ErrGuardrailBlocked          = infraerrors.Forbidden("GUARDRAIL_BLOCKED", "request blocked by content policy")
+	ErrGuardrailHookUnavailable  = infraerrors.ServiceUnavailable("GUARDRAIL_HOOK_UNAVAILABLE", "content moderation hook unavailable")
+	guardrailBuiltinDetectorExps = map[string]*regexp.Regexp{
+		GuardrailDetectorAWSKey: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b|(?i:aws_secret_access_key)\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}\b`),
+		GuardrailDetectorPrivateKey: regexp.MustCompile(
+			`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----[\s\S]*?(?:-----END (?:[A-Z0-9]+ )*PRIVATE KEY-----|$)`),
+		GuardrailDetectorJWT:   regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`),
+		GuardrailDetectorEmail: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`),
+		// 北美格式（可带国家码）与中国大陆手机号
+		GuardrailDetectorPhone: regexp.MustCompile(`(?:\+\d{1,3}[ -]?)?\(?\b\d{3}\)?[ .-]\d{3}[ .-]\d{4}\b|\b1[3-9]\d{9}\b`),
+	}
+)
+
+// guardrailRequestTextFields 请求中需要检查的文本字段（覆盖 Anthropic / OpenAI / Gemini 格式）
+var guardrailRequestTextFields = map[string]struct{}{
+	"text": {}, "content": {}, "system": {}, "input": {}, "instructions": {}, "prompt": {}, "arguments": {},
+}
+
+// guardrailResponseTextFields 响应（含流式增量）中需要检查的文本字段；
+// 不检查 thinking（带签名，修改后后续轮次会被上游拒绝）与 partial_json（片段脱敏会破坏拼接后的 JSON）
+var guardrailResponseTextFields = map[string]struct{}{
+	"text": {}, "delta": {}, "content": {}, "output_text": {}, "refusal": {},
+}
+
+// GuardrailPolicy 内容策略；GroupID 与 APIKeyID 均为空时对所有请求生效
+type GuardrailPolicy struct {
+	ID       int64
+	Name     string
+	GroupID  *int64
+	APIKeyID *int64
+	Enabled  bool
+	Action   string
+	// Detectors 内置检测器：aws_key / private_key / jwt / email / phone
+	Detectors []string
+	// Keywords 关键词（不区分大小写）
+	Keywords []string
+	// Patterns 自定义正则（RE2 语法）
+	Patterns []string
+	// HookURL 外部审核钩子；ScanResponses 为 true 时同时审核响应
+	HookURL string
+	// ScanResponses 是否检查响应（含流式输出）
+	ScanResponses bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// GuardrailEvent 策略命中记录（不保存命中原文）
+type GuardrailEvent struct {
+	ID         int64
+	PolicyID   *int64
+	PolicyName string
+	UserID     *int64
+	APIKeyID   *int64
+	GroupID    *int64
+	Direction  string
+	Action     string
+	// Findings 检测器 -> 命中次数
+	Findings  map[string]int
+	Model     string
+	CreatedAt time.Time
+}
+
+// GuardrailEventFilters 命中记录筛选
+type GuardrailEventFilters struct {
+	PolicyID  *int64
+	APIKeyID  *int64
+	GroupID   *int64
+	Direction string
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+// GuardrailRepository 内容策略与命中记录存储
+type GuardrailRepository interface {
+	ListPolicies(ctx context.Context) ([]GuardrailPolicy, error)
+	GetPolicy(ctx context.Context, id int64) (*GuardrailPolicy, error)
+	CreatePolicy(ctx context.Context, policy *GuardrailPolicy) error
+	UpdatePolicy(ctx context.Context, policy *GuardrailPolicy) error
+	DeletePolicy(ctx context.Context, id int64) error
+
+	CreateEvent(ctx context.Context, event *GuardrailEvent) error
+	ListEvents(ctx context.Context, params pagination.PaginationParams, filters GuardrailEventFilters) ([]GuardrailEvent, *pagination.PaginationResult, error)
+	DeleteEventsBefore(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+// GuardrailHookRequest 发送给外部审核钩子的请求
+type GuardrailHookRequest struct {
+	PolicyID  int64    `json:"policy_id"`
+	Direction string   `json:"direction"`
+	Model     string   `json:"model"`
+	UserID    int64    `json:"user_id"`
+	APIKeyID  int64    `json:"api_key_id"`
+	GroupID   *int64   `json:"group_id,omitempty"`
+	Texts     []string `json:"texts"`
+}
+
+// GuardrailHookResponse 外部审核钩子的判定：Flagged 时按策略动作处理，Redact 为需要脱敏的原文片段
+type GuardrailHookResponse struct {
+	Flagged bool     `json:"flagged"`
+	Reason  string   `json:"reason,omitempty"`
+	Redact  []string `json:"redact,omitempty"`
+}
+
+// GuardrailHookClient 调用外部审核钩子
+type GuardrailHookClient interface {
+	Moderate(ctx context.Context, url string, req *GuardrailHookRequest) (*GuardrailHookResponse, error)
+}
+
+// guardrailStreamHoldbackMax 流式检查时每个内容块最多保留的文本长度（字节），用于没有长度上界的检测模式
+const guardrailStreamHoldbackMax = 256
+
+// guardrailMatcher 单个检测器
+type guardrailMatcher struct {
+	name string
+	re   *regexp.Regexp
+	// maxLen 可能命中的最长片段（字节），无上界时为 guardrailStreamHoldbackMax
+	maxLen int
+}
+
+func newGuardrailMatcher(name string, re *regexp.Regexp) guardrailMatcher {
+	maxLen := guardrailStreamHoldbackMax
+	if parsed, err := syntax.Parse(re.String(), syntax.Perl); err == nil {
+		if n := guardrailMaxMatchLen(parsed.Simplify()); n >= 0 && n < maxLen {
+			maxLen = n
+		}
+	}
+	return guardrailMatcher{name: name, re: re, maxLen: maxLen}
+}
+
+// guardrailMaxMatchLen 正则可能匹配的最大字节数，无上界时返回 -1
+func guardrailMaxMatchLen(re *syntax.Regexp) int {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpNoMatch, syntax.OpBeginLine, syntax.OpEndLine,
+		syntax.OpBeginText, syntax.OpEndText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return 0
+	case syntax.OpLiteral:
+		n := 0
+		for _, r := range re.Rune {
+			size := utf8.RuneLen(r)
+			if re.Flags&syntax.FoldCase != 0 {
+				for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+					size = max(size, utf8.RuneLen(f))
+				}
+			}
+			n += size
+		}
+		return n
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return 0
+		}
+		return max(utf8.RuneLen(re.Rune[len(re.Rune)-1]), 1)
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return utf8.UTFMax
+	case syntax.OpCapture, syntax.OpQuest:
+		return guardrailMaxMatchLen(re.Sub[0])
+	case syntax.OpRepeat:
+		n := guardrailMaxMatchLen(re.Sub[0])
+		if re.Max < 0 || n < 0 {
+			return -1
+		}
+		return n * re.Max
+	case syntax.OpConcat:
+		total := 0
+		for _, sub := range re.Sub {
+			n := guardrailMaxMatchLen(sub)
+			if n < 0 {
+				return -1
+			}
+			total += n
+		}
+		return total
+	case syntax.OpAlternate:
+		longest := 0
+		for _, sub := range re.Sub {
+			n := guardrailMaxMatchLen(sub)
+			if n < 0 {
+				return -1
+			}
+			longest = max(longest, n)
+		}
+		return longest
+	default:
+		return -1
+	}
+}
+
+// compiledGuardrailPolicy 预编译检测器的策略
+type compiledGuardrailPolicy struct {
+	GuardrailPolicy
+	matchers []guardrailMatcher
+}
+
+// compileGuardrailPolicy 校验并编译策略
+func compileGuardrailPolicy(p *GuardrailPolicy) (*compiledGuardrailPolicy, error) {
+	if strings.TrimSpace(p.Name) == "" {
+		return nil, infraerrors.BadRequest(infraerrors.Reason(ErrGuardrailPolicyInvalid), "name is required")
+	}
+	if p.GroupID != nil && p.APIKeyID != nil {
+		return nil, infraerrors.BadRequest(infraerrors.Reason(ErrGuardrailPolicyInvalid), "group_id and api_key_id are mutually exclusive")
+	}
+	switch p.Action {
+	case GuardrailActionBlock, GuardrailActionRedact, GuardrailActionLog:
+	default:
+		return nil, infraerrors.BadRequest(infraerrors.Reason(ErrGuardrailPolicyInvalid), "action must be block, redact or log")
+	}
+	out := &compiledGuardrailPolicy{GuardrailPolicy: *p}
+	for _, name := range p.Detectors {
+		re, ok := guardrailBuiltinDetectorExps[name]
+		if !ok {
+			return nil, infraerrors.BadRequest(infraerrors.Reason(ErrGuardrailPolicyInvalid), fmt.Sprintf("unknown detector %q", name))
+		}
+		out.matchers = append(out.matchers, newGuardrailMatcher(name, re))
+	}
+	if len(p.Keywords) > 0 {
+		quoted := make([]string, 0, len(p.Keywords))
+		for _, kw := range p.Keywords {
+			quoted = append(quoted, regexp.QuoteMeta(kw))
+		}
+		out.matchers = append(out.matchers, newGuardrailMatcher(GuardrailDetectorKeyword, regexp.MustCompile(`(?i)`+strings.Join(quoted, "|"))))
+	}
+	for _, pattern := range p.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, infraerrors.BadRequest(infraerrors.Reason(ErrGuardrailPolicyInvalid), fmt.Sprintf("invalid pattern %q: %v", pattern, err))
+		}
+		out.matchers = append(out.matchers, newGuardrailMatcher(GuardrailDetectorRegex, re))
+	}
+	if len(out.matchers) == 0 && p.HookURL == "" {
+		return nil, infraerrors.BadRequest(infraerrors.Reason(ErrGuardrailPolicyInvalid), "at least one detector, keyword, pattern or hook_url is required")
+	}
+	return out, nil
+}
+
+// scan 统计命中次数；redact 为 true 时返回脱敏后的文本
+func (p *compiledGuardrailPolicy) scan(text string, findings map[string]int, redact bool) string {
+	for _, m := range p.matchers {
+		hits := len(m.re.FindAllStringIndex(text, -1))
+		if hits == 0 {
+			continue
+		}
+		findings[m.name] += hits
+		if redact {
+			text = m.re.ReplaceAllLiteralString(text, guardrailRedaction(m.name))
+		}
+	}
+	return text
+}
+
+func guardrailRedaction(detector string) string {
+	return "[REDACTED:" + detector + "]"
+}
+
+// guardrailText JSON 中的一个文本字段
+type guardrailText struct {
+	path  string
+	value string
+}
+
+// collectGuardrailTexts 收集 JSON 中指定字段下的字符串（数组元素继承所在字段名）
+func collectGuardrailTexts(body []byte, fields map[string]struct{}) []guardrailText {
+	var out []guardrailText
+	var walk func(res gjson.Result, path, key string)
+	walk = func(res gjson.Result, path, key string) {
+		switch {
+		case res.IsObject():
+			res.ForEach(func(k, v gjson.Result) bool {
+				walk(v, joinGuardrailPath(path, escapeGuardrailPathKey(k.String())), k.String())
+				return true
+			})
+		case res.IsArray():
+			i := 0
+			res.ForEach(func(_, v gjson.Result) bool {
+				walk(v, joinGuardrailPath(path, strconv.Itoa(i)), key)
+				i++
+				return true
+			})
+		case res.Type == gjson.String:
+			if _, ok := fields[key]; ok && res.Str != "" {
+				out = append(out, guardrailText{path: path, value: res.Str})
+			}
+		}
+	}
+	walk(gjson.ParseBytes(body), "", "")
+	return out
+}
+
+// replaceGuardrailTexts 按路径写回脱敏后的文本，保留其余字段的原始字节
+func replaceGuardrailTexts(body []byte, texts []guardrailText) ([]byte, error) {
+	var err error
+	for _, t := range texts {
+		body, err = sjson.SetBytes(body, t.path, t.value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+func joinGuardrailPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// escapeGuardrailPathKey 转义 gjson/sjson 路径中的特殊字符
+func escapeGuardrailPathKey(key string) string {
+	if !strings.ContainsAny(key, `.*?|#@!:\`) {
+		return key
+	}
+	var b strings.Builder
+	for _, r := range key {
+		if strings.ContainsRune(`.*?|#@!:\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// formatGuardrailFindings 按检测器名称排序输出，用于错误信息与日志
+func formatGuardrailFindings(findings map[string]int) string {
+	names := make([]string, 0, len(findings))
+	for name := range findings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
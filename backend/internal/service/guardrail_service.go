@@ -0,0 +1,757 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	guardrailReloadWorkerName  = "guardrail_reload"
+	guardrailCleanupWorkerName = "guardrail_event_cleanup"
+	// guardrailReloadInterval 多实例部署时从数据库同步策略的周期
+	guardrailReloadInterval  = time.Minute
+	guardrailCleanupInterval = time.Hour
+	guardrailCleanupBatch    = 5000
+)
+
+// SetGuardrailPolicyInput 创建或更新内容策略
+type SetGuardrailPolicyInput struct {
+	Name          string
+	GroupID       *int64
+	APIKeyID      *int64
+	Enabled       bool
+	Action        string
+	Detectors     []string
+	Keywords      []string
+	Patterns      []string
+	HookURL       string
+	ScanResponses bool
+}
+
+// GuardrailService 内容策略：在转发前检查请求中的敏感信息、PII 与关键词，并可选检查响应（含流式输出）
+//
+// 策略按全局、分组、API Key 三个范围配置，同一请求依次执行所有适用策略：
+// block 拒绝请求（响应侧中断输出），redact 将命中片段替换为 [REDACTED:<检测器>]，log 仅记录命中。
+// 策略缓存在内存中并预编译检测器，管理端修改后立即重新加载，其他实例按 guardrailReloadInterval 周期同步。
+// 命中记录异步写入，只保存检测器名称与命中次数。
+type GuardrailService struct {
+	repo        GuardrailRepository
+	hookClient  GuardrailHookClient
+	timingWheel *TimingWheelService
+	cfg         *config.Config
+
+	mu       sync.RWMutex
+	policies []*compiledGuardrailPolicy
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewGuardrailService 创建内容策略服务
+func NewGuardrailService(repo GuardrailRepository, hookClient GuardrailHookClient, timingWheel *TimingWheelService, cfg *config.Config) *GuardrailService {
+	return &GuardrailService{
+		repo:        repo,
+		hookClient:  hookClient,
+		timingWheel: timingWheel,
+		cfg:         cfg,
+	}
+}
+
+// Start 加载策略并启动周期同步与命中记录清理
+func (s *GuardrailService) Start() {
+	if s == nil || s.repo == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.reloadLogged()
+		if s.timingWheel != nil {
+			s.timingWheel.ScheduleRecurring(guardrailReloadWorkerName, guardrailReloadInterval, s.reloadLogged)
+			s.timingWheel.ScheduleRecurring(guardrailCleanupWorkerName, guardrailCleanupInterval, s.cleanupEvents)
+		}
+	})
+}
+
+// Stop 停止周期任务
+func (s *GuardrailService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(guardrailReloadWorkerName)
+			s.timingWheel.Cancel(guardrailCleanupWorkerName)
+		}
+	})
+}
+
+func (s *GuardrailService) enabled() bool {
+	return s != nil && s.cfg != nil && s.cfg.Current().Gateway.Guardrails.Enabled
+}
+
+func (s *GuardrailService) reloadLogged() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.Reload(ctx); err != nil {
+		log.Printf("[Guardrail] reload failed: %v", err)
+	}
+}
+
+// Reload 从数据库重新加载并编译策略；无法编译的策略被跳过
+func (s *GuardrailService) Reload(ctx context.Context) error {
+	policies, err := s.repo.ListPolicies(ctx)
+	if err != nil {
+		return err
+	}
+	compiled := make([]*compiledGuardrailPolicy, 0, len(policies))
+	for i := range policies {
+		if !policies[i].Enabled {
+			continue
+		}
+		p, err := compileGuardrailPolicy(&policies[i])
+		if err != nil {
+			log.Printf("[Guardrail] skip policy %d: %v", policies[i].ID, err)
+			continue
+		}
+		compiled = append(compiled, p)
+	}
+	s.mu.Lock()
+	s.policies = compiled
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *GuardrailService) cleanupEvents() {
+	cfg := s.cfg.Current()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	days := 30
+	if cfg != nil && cfg.Gateway.Guardrails.EventRetentionDays > 0 {
+		days = cfg.Gateway.Guardrails.EventRetentionDays
+	}
+	cutoff := time.Now().AddDate(0, 0, -days)
+	var total int64
+	for {
+		deleted, err := s.repo.DeleteEventsBefore(ctx, cutoff, guardrailCleanupBatch)
+		if err != nil {
+			log.Printf("[Guardrail] event cleanup failed: %v", err)
+			return
+		}
+		total += deleted
+		if deleted < guardrailCleanupBatch {
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("[Guardrail] cleaned up %d expired events", total)
+	}
+}
+
+// policiesFor 返回适用于 API Key 的策略：全局、分组、API Key 依次排列
+func (s *GuardrailService) policiesFor(apiKey *APIKey, responses bool) []*compiledGuardrailPolicy {
+	if !s.enabled() || apiKey == nil {
+		return nil
+	}
+	s.mu.RLock()
+	all := s.policies
+	s.mu.RUnlock()
+
+	var global, group, key []*compiledGuardrailPolicy
+	for _, p := range all {
+		if responses && !p.ScanResponses {
+			continue
+		}
+		switch {
+		case p.APIKeyID != nil:
+			if *p.APIKeyID == apiKey.ID {
+				key = append(key, p)
+			}
+		case p.GroupID != nil:
+			if apiKey.GroupID != nil && *p.GroupID == *apiKey.GroupID {
+				group = append(group, p)
+			}
+		default:
+			global = append(global, p)
+		}
+	}
+	return append(append(global, group...), key...)
+}
+
+// CheckRequest 在转发前检查请求体，返回（可能已脱敏的）请求体；命中 block 策略时返回 ErrGuardrailBlocked。
+// model 为空时从请求体的 model 字段读取（仅用于命中记录）。
+func (s *GuardrailService) CheckRequest(ctx context.Context, apiKey *APIKey, model string, body []byte) ([]byte, error) {
+	policies := s.policiesFor(apiKey, false)
+	if len(policies) == 0 {
+		return body, nil
+	}
+	texts := collectGuardrailTexts(body, guardrailRequestTextFields)
+	if len(texts) == 0 {
+		return body, nil
+	}
+	if model == "" {
+		model = gjson.GetBytes(body, "model").String()
+	}
+
+	redacted := false
+	for _, p := range policies {
+		findings := make(map[string]int)
+		redact := p.Action == GuardrailActionRedact
+		for i := range texts {
+			if scanned := p.scan(texts[i].value, findings, redact); scanned != texts[i].value {
+				texts[i].value = scanned
+				redacted = true
+			}
+		}
+		if p.HookURL != "" {
+			if err := s.runHook(ctx, p, apiKey, GuardrailDirectionRequest, model, texts, findings); err != nil {
+				return nil, err
+			}
+			if redact {
+				redacted = true
+			}
+		}
+		if len(findings) == 0 {
+			continue
+		}
+		s.recordEvent(p, apiKey, GuardrailDirectionRequest, model, findings)
+		if p.Action == GuardrailActionBlock {
+			return nil, infraerrors.Forbidden(infraerrors.Reason(ErrGuardrailBlocked),
+				fmt.Sprintf("request blocked by content policy %q (%s)", p.Name, formatGuardrailFindings(findings)))
+		}
+	}
+	if !redacted {
+		return body, nil
+	}
+	out, err := replaceGuardrailTexts(body, texts)
+	if err != nil {
+		return nil, fmt.Errorf("apply guardrail redaction: %w", err)
+	}
+	return out, nil
+}
+
+// runHook 调用外部审核钩子；判定为 flagged 时记为 hook 命中，redact 动作下替换钩子返回的片段。
+// 钩子不可用时按 hook_fail_open 放行或拒绝。
+func (s *GuardrailService) runHook(ctx context.Context, p *compiledGuardrailPolicy, apiKey *APIKey, direction, model string, texts []guardrailText, findings map[string]int) error {
+	if s.hookClient == nil {
+		return nil
+	}
+	cfg := s.cfg.Current()
+	values := make([]string, 0, len(texts))
+	for _, t := range texts {
+		values = append(values, t.value)
+	}
+	timeout := 5 * time.Second
+	if cfg.Gateway.Guardrails.HookTimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.Gateway.Guardrails.HookTimeoutSeconds) * time.Second
+	}
+	hookCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resp, err := s.hookClient.Moderate(hookCtx, p.HookURL, &GuardrailHookRequest{
+		PolicyID:  p.ID,
+		Direction: direction,
+		Model:     model,
+		UserID:    apiKey.UserID,
+		APIKeyID:  apiKey.ID,
+		GroupID:   apiKey.GroupID,
+		Texts:     values,
+	})
+	if err != nil {
+		log.Printf("[Guardrail] hook for policy %d failed: %v", p.ID, err)
+		if cfg.Gateway.Guardrails.HookFailOpen {
+			return nil
+		}
+		return ErrGuardrailHookUnavailable
+	}
+	if !resp.Flagged {
+		return nil
+	}
+	findings[GuardrailDetectorHook]++
+	if p.Action != GuardrailActionRedact {
+		return nil
+	}
+	for i := range texts {
+		for _, fragment := range resp.Redact {
+			if fragment != "" {
+				texts[i].value = strings.ReplaceAll(texts[i].value, fragment, guardrailRedaction(GuardrailDetectorHook))
+			}
+		}
+	}
+	return nil
+}
+
+// recordEvent 异步写入命中记录
+func (s *GuardrailService) recordEvent(p *compiledGuardrailPolicy, apiKey *APIKey, direction, model string, findings map[string]int) {
+	log.Printf("[Guardrail] policy=%d action=%s direction=%s api_key=%d findings=%s",
+		p.ID, p.Action, direction, apiKey.ID, formatGuardrailFindings(findings))
+	policyID, userID, apiKeyID := p.ID, apiKey.UserID, apiKey.ID
+	event := &GuardrailEvent{
+		PolicyID:   &policyID,
+		PolicyName: p.Name,
+		UserID:     &userID,
+		APIKeyID:   &apiKeyID,
+		GroupID:    apiKey.GroupID,
+		Direction:  direction,
+		Action:     p.Action,
+		Findings:   findings,
+		Model:      model,
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.repo.CreateEvent(ctx, event); err != nil {
+			log.Printf("[Guardrail] record event failed: %v", err)
+		}
+	}()
+}
+
+// guardrailStreamHookChunk 配置了审核钩子时，流式文本累积到该长度（字节）或内容块结束时再送审
+const guardrailStreamHookChunk = 1024
+
+// GuardrailStreamEvent 流式响应中的一个事件：Prefix 为 data 之前的字段行（如 "event: ...\n"），Data 为 data 的 JSON
+type GuardrailStreamEvent struct {
+	Prefix []byte
+	Data   []byte
+}
+
+// guardrailStreamBlock 流式响应中一个内容块（Anthropic content block、OpenAI choice 等）的检查状态
+type guardrailStreamBlock struct {
+	// tail 滚动窗口末尾尚未检查的文本，与后续增量拼接后再检查
+	tail string
+	// checked 已通过检测器、等待审核钩子的文本
+	checked string
+	// last/path 该内容块最近的增量事件与文本路径，补发剩余文本时以其为模板
+	last GuardrailStreamEvent
+	path string
+}
+
+// GuardrailResponseScanner 单个请求的响应检查器；命中记录在 Finish 时按策略汇总写入
+type GuardrailResponseScanner struct {
+	svc      *GuardrailService
+	ctx      context.Context
+	apiKey   *APIKey
+	model    string
+	policies []*compiledGuardrailPolicy
+	findings []map[string]int
+	blocked  *compiledGuardrailPolicy
+	message  string
+
+	// holdback 流式输出时每个内容块保留的窗口长度：所有检测模式中最长的命中长度
+	holdback int
+	hooks    bool
+	blocks   map[string]*guardrailStreamBlock
+	order    []string
+}
+
+// NewResponseScanner 没有适用于响应的策略时返回 nil
+func (s *GuardrailService) NewResponseScanner(ctx context.Context, apiKey *APIKey, model string) *GuardrailResponseScanner {
+	policies := s.policiesFor(apiKey, true)
+	if len(policies) == 0 {
+		return nil
+	}
+	r := &GuardrailResponseScanner{
+		svc:      s,
+		ctx:      ctx,
+		apiKey:   apiKey,
+		model:    model,
+		policies: policies,
+		findings: make([]map[string]int, len(policies)),
+	}
+	for i, p := range policies {
+		r.findings[i] = make(map[string]int)
+		for _, m := range p.matchers {
+			r.holdback = max(r.holdback, m.maxLen)
+		}
+		if p.HookURL != "" && s.hookClient != nil {
+			r.hooks = true
+		}
+	}
+	return r
+}
+
+// FilterJSON 检查一个完整的 JSON 响应体；返回脱敏后的数据，命中 block 策略或审核钩子不可用（未配置 fail open）时 blocked 为 true
+func (r *GuardrailResponseScanner) FilterJSON(data []byte) (out []byte, blocked bool) {
+	if r == nil {
+		return data, false
+	}
+	if r.blocked != nil {
+		return nil, true
+	}
+	texts := collectGuardrailTexts(data, guardrailResponseTextFields)
+	if len(texts) == 0 {
+		return data, false
+	}
+	redacted, blocked := r.scanTexts(texts)
+	if blocked {
+		return nil, true
+	}
+	moderated, blocked := r.moderateTexts(texts)
+	if blocked {
+		return nil, true
+	}
+	if !redacted && !moderated {
+		return data, false
+	}
+	out, err := replaceGuardrailTexts(data, texts)
+	if err != nil {
+		log.Printf("[Guardrail] apply response redaction failed: %v", err)
+		return data, false
+	}
+	return out, false
+}
+
+// FilterStreamEvent 检查流式响应的一个事件。
+//
+// 文本增量按内容块进入滚动窗口：窗口末尾保留 holdback 长度的文本（跨越该边界的命中片段整体保留），
+// 与后续增量拼接后再检查，因此被拆分到多个事件中的敏感片段同样能识别；配置了审核钩子时文本累积到
+// guardrailStreamHookChunk 后送审。不含文本增量的事件（ping 除外）表示内容块结束，先补发各内容块剩余的文本。
+// 返回需在该事件之前输出的补发事件与处理后的事件数据；命中 block 策略时 blocked 为 true。
+func (r *GuardrailResponseScanner) FilterStreamEvent(ev GuardrailStreamEvent) (flushed []GuardrailStreamEvent, data []byte, blocked bool) {
+	if r == nil {
+		return nil, ev.Data, false
+	}
+	if r.blocked != nil {
+		return nil, nil, true
+	}
+	var deltas, whole []guardrailText
+	for _, t := range collectGuardrailTexts(ev.Data, guardrailResponseTextFields) {
+		if isGuardrailDeltaPath(t.path) {
+			deltas = append(deltas, t)
+		} else {
+			whole = append(whole, t)
+		}
+	}
+	if len(deltas) == 0 && gjson.GetBytes(ev.Data, "type").String() != "ping" {
+		if flushed, blocked = r.FlushStream(); blocked {
+			return nil, nil, true
+		}
+	}
+
+	changed := false
+	finished := guardrailStreamFinished(ev.Data)
+	for i := range deltas {
+		b := r.streamBlock(ev, deltas[i].path)
+		text, blocked := r.advance(b, deltas[i].value, finished)
+		if blocked {
+			return nil, nil, true
+		}
+		if text != deltas[i].value {
+			deltas[i].value = text
+			changed = true
+		}
+	}
+	if len(whole) > 0 {
+		redacted, blocked := r.scanTexts(whole)
+		if blocked {
+			return nil, nil, true
+		}
+		moderated, blocked := r.moderateTexts(whole)
+		if blocked {
+			return nil, nil, true
+		}
+		changed = changed || redacted || moderated
+	}
+	if !changed {
+		return flushed, ev.Data, false
+	}
+	out, err := replaceGuardrailTexts(ev.Data, append(deltas, whole...))
+	if err != nil {
+		log.Printf("[Guardrail] apply response redaction failed: %v", err)
+		return flushed, ev.Data, false
+	}
+	return flushed, out, false
+}
+
+// FlushStream 检查并输出所有内容块窗口中剩余的文本（内容块或响应结束时调用），每个内容块补发一个增量事件
+func (r *GuardrailResponseScanner) FlushStream() ([]GuardrailStreamEvent, bool) {
+	if r == nil {
+		return nil, false
+	}
+	if r.blocked != nil {
+		return nil, true
+	}
+	var out []GuardrailStreamEvent
+	for _, key := range r.order {
+		b := r.blocks[key]
+		text, blocked := r.advance(b, "", true)
+		if blocked {
+			return nil, true
+		}
+		if text == "" {
+			continue
+		}
+		data, err := sjson.SetBytes(b.last.Data, b.path, text)
+		if err != nil {
+			log.Printf("[Guardrail] flush response text failed: %v", err)
+			continue
+		}
+		out = append(out, GuardrailStreamEvent{Prefix: b.last.Prefix, Data: data})
+	}
+	r.blocks, r.order = nil, nil
+	return out, false
+}
+
+// streamBlock 返回文本所在内容块的状态，并记录该事件作为补发模板
+func (r *GuardrailResponseScanner) streamBlock(ev GuardrailStreamEvent, path string) *guardrailStreamBlock {
+	key := path
+	for _, field := range []string{"index", "output_index", "content_index"} {
+		if v := gjson.GetBytes(ev.Data, field); v.Exists() {
+			key += "|" + field + "=" + v.Raw
+		}
+	}
+	if r.blocks == nil {
+		r.blocks = make(map[string]*guardrailStreamBlock)
+	}
+	b, ok := r.blocks[key]
+	if !ok {
+		b = &guardrailStreamBlock{}
+		r.blocks[key] = b
+		r.order = append(r.order, key)
+	}
+	b.last = GuardrailStreamEvent{Prefix: bytes.Clone(ev.Prefix), Data: bytes.Clone(ev.Data)}
+	b.path = path
+	return b
+}
+
+// advance 将增量追加到内容块窗口，返回检查后可以输出的文本；final 为 true 时输出窗口中全部文本
+func (r *GuardrailResponseScanner) advance(b *guardrailStreamBlock, text string, final bool) (string, bool) {
+	window := b.tail + text
+	split := len(window)
+	if !final {
+		split = r.splitPoint(window)
+	}
+	emit := window[:split]
+	b.tail = window[split:]
+	if emit != "" {
+		texts := []guardrailText{{value: emit}}
+		if _, blocked := r.scanTexts(texts); blocked {
+			return "", true
+		}
+		emit = texts[0].value
+	}
+	if !r.hooks {
+		return emit, false
+	}
+	b.checked += emit
+	if b.checked == "" || (!final && len(b.checked) < guardrailStreamHookChunk) {
+		return "", false
+	}
+	texts := []guardrailText{{value: b.checked}}
+	b.checked = ""
+	if _, blocked := r.moderateTexts(texts); blocked {
+		return "", true
+	}
+	return texts[0].value, false
+}
+
+// splitPoint 窗口中可以输出的长度：末尾保留 holdback 字节，且不拆开跨越边界的命中片段
+func (r *GuardrailResponseScanner) splitPoint(window string) int {
+	if r.holdback == 0 {
+		return len(window)
+	}
+	split := len(window) - r.holdback
+	if split <= 0 {
+		return 0
+	}
+	for split > 0 && !utf8.RuneStart(window[split]) {
+		split--
+	}
+	var spans [][]int
+	for _, p := range r.policies {
+		for _, m := range p.matchers {
+			spans = append(spans, m.re.FindAllStringIndex(window, -1)...)
+		}
+	}
+	for moved := true; moved; {
+		moved = false
+		for _, span := range spans {
+			if span[0] < split && span[1] > split {
+				split = span[0]
+				moved = true
+			}
+		}
+	}
+	return split
+}
+
+// scanTexts 按策略顺序执行检测器，redact 策略就地脱敏
+func (r *GuardrailResponseScanner) scanTexts(texts []guardrailText) (redacted, blocked bool) {
+	for i, p := range r.policies {
+		before := sumGuardrailFindings(r.findings[i])
+		redact := p.Action == GuardrailActionRedact
+		for j := range texts {
+			if scanned := p.scan(texts[j].value, r.findings[i], redact); scanned != texts[j].value {
+				texts[j].value = scanned
+				redacted = true
+			}
+		}
+		if p.Action == GuardrailActionBlock && sumGuardrailFindings(r.findings[i]) > before {
+			r.block(p, fmt.Sprintf("response blocked by content policy %q", p.Name))
+			return redacted, true
+		}
+	}
+	return redacted, false
+}
+
+// moderateTexts 将文本送外部审核钩子；钩子不可用且未配置 fail open 时中断响应
+func (r *GuardrailResponseScanner) moderateTexts(texts []guardrailText) (moderated, blocked bool) {
+	if !r.hooks {
+		return false, false
+	}
+	for i, p := range r.policies {
+		if p.HookURL == "" {
+			continue
+		}
+		before := sumGuardrailFindings(r.findings[i])
+		if err := r.svc.runHook(r.ctx, p, r.apiKey, GuardrailDirectionResponse, r.model, texts, r.findings[i]); err != nil {
+			r.block(p, "response blocked: "+infraerrors.Message(err))
+			return moderated, true
+		}
+		if sumGuardrailFindings(r.findings[i]) == before {
+			continue
+		}
+		switch p.Action {
+		case GuardrailActionBlock:
+			r.block(p, fmt.Sprintf("response blocked by content policy %q", p.Name))
+			return moderated, true
+		case GuardrailActionRedact:
+			moderated = true
+		}
+	}
+	return moderated, false
+}
+
+func (r *GuardrailResponseScanner) block(p *compiledGuardrailPolicy, message string) {
+	r.blocked = p
+	r.message = message
+}
+
+// BlockedMessage 响应被中断时返回给客户端的说明
+func (r *GuardrailResponseScanner) BlockedMessage() string {
+	if r == nil || r.blocked == nil {
+		return ""
+	}
+	return r.message
+}
+
+// Finish 写入本次响应的命中记录
+func (r *GuardrailResponseScanner) Finish() {
+	if r == nil {
+		return
+	}
+	for i, p := range r.policies {
+		if len(r.findings[i]) > 0 {
+			r.svc.recordEvent(p, r.apiKey, GuardrailDirectionResponse, r.model, r.findings[i])
+		}
+	}
+}
+
+// isGuardrailDeltaPath 流式事件中的文本增量：Anthropic delta.text、OpenAI choices.N.delta.content、
+// Responses API 的 delta，以及 Gemini 流式分片中的 candidates.N.content.parts.M.text
+func isGuardrailDeltaPath(path string) bool {
+	if path == "delta" || strings.HasPrefix(path, "delta.") || strings.Contains(path, ".delta.") || strings.HasSuffix(path, ".delta") {
+		return true
+	}
+	return strings.HasPrefix(path, "candidates.") && strings.Contains(path, ".content.parts.") && strings.HasSuffix(path, ".text")
+}
+
+// guardrailStreamFinished 事件同时带有结束标记（OpenAI finish_reason、Gemini finishReason）时，其文本之后不再有增量
+func guardrailStreamFinished(data []byte) bool {
+	for _, path := range []string{"choices.#.finish_reason", "candidates.#.finishReason"} {
+		for _, v := range gjson.GetBytes(data, path).Array() {
+			if v.Type == gjson.String && v.Str != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sumGuardrailFindings(findings map[string]int) int {
+	total := 0
+	for _, n := range findings {
+		total += n
+	}
+	return total
+}
+
+// ListPolicies 列出所有策略
+func (s *GuardrailService) ListPolicies(ctx context.Context) ([]GuardrailPolicy, error) {
+	return s.repo.ListPolicies(ctx)
+}
+
+// CreatePolicy 创建策略
+func (s *GuardrailService) CreatePolicy(ctx context.Context, input *SetGuardrailPolicyInput) (*GuardrailPolicy, error) {
+	policy := &GuardrailPolicy{}
+	if err := applyGuardrailPolicyInput(policy, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreatePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	s.reloadLogged()
+	return policy, nil
+}
+
+// UpdatePolicy 更新策略
+func (s *GuardrailService) UpdatePolicy(ctx context.Context, id int64, input *SetGuardrailPolicyInput) (*GuardrailPolicy, error) {
+	policy, err := s.repo.GetPolicy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyGuardrailPolicyInput(policy, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdatePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	s.reloadLogged()
+	return policy, nil
+}
+
+// DeletePolicy 删除策略；历史命中记录保留
+func (s *GuardrailService) DeletePolicy(ctx context.Context, id int64) error {
+	if err := s.repo.DeletePolicy(ctx, id); err != nil {
+		return err
+	}
+	s.reloadLogged()
+	return nil
+}
+
+// ListEvents 分页查询命中记录
+func (s *GuardrailService) ListEvents(ctx context.Context, params pagination.PaginationParams, filters GuardrailEventFilters) ([]GuardrailEvent, *pagination.PaginationResult, error) {
+	return s.repo.ListEvents(ctx, params, filters)
+}
+
+// applyGuardrailPolicyInput 规范化输入并校验（编译检测器）
+func applyGuardrailPolicyInput(policy *GuardrailPolicy, input *SetGuardrailPolicyInput) error {
+	policy.Name = strings.TrimSpace(input.Name)
+	policy.GroupID = input.GroupID
+	policy.APIKeyID = input.APIKeyID
+	policy.Enabled = input.Enabled
+	policy.Action = strings.TrimSpace(input.Action)
+	policy.Detectors = normalizeUsageTagValues(input.Detectors)
+	policy.Keywords = normalizeUsageTagValues(input.Keywords)
+	policy.Patterns = normalizeUsageTagValues(input.Patterns)
+	policy.HookURL = strings.TrimSpace(input.HookURL)
+	policy.ScanResponses = input.ScanResponses
+	if policy.HookURL != "" {
+		u, err := url.Parse(policy.HookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return infraerrors.BadRequest(infraerrors.Reason(ErrGuardrailPolicyInvalid), "hook_url must be an http(s) URL")
+		}
+	}
+	_, err := compileGuardrailPolicy(policy)
+	return err
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/tidwall/sjson"
+)
+
+// 模型降级触发条件
+const (
+	// ModelFallbackTriggerNoAccounts 没有可调度的账号（全部限流、过载或不可用）
+	ModelFallbackTriggerNoAccounts = "no_accounts"
+	// ModelFallbackTriggerOverloaded 上游返回 529 过载
+	ModelFallbackTriggerOverloaded = "overloaded"
+	// ModelFallbackTriggerSwitchesExhausted 账号切换次数（MaxAccountSwitches）用尽或可切换账号耗尽
+	ModelFallbackTriggerSwitchesExhausted = "switches_exhausted"
+)
+
+// 模型降级相关响应头
+const (
+	// ModelServedHeader 实际服务本次请求的模型
+	ModelServedHeader = "X-Sub2api-Served-Model"
+	// ModelFallbackFromHeader 发生降级时客户端原本请求的模型
+	ModelFallbackFromHeader = "X-Sub2api-Fallback-From"
+)
+
+var modelFallbackTriggers = []string{
+	ModelFallbackTriggerNoAccounts,
+	ModelFallbackTriggerOverloaded,
+	ModelFallbackTriggerSwitchesExhausted,
+}
+
+// ModelFallbackStep 降级链中的一步
+type ModelFallbackStep struct {
+	Model string `json:"model"`
+	// GroupID 从指定分组调度账号（跨平台降级，如 Claude 分组降级到 Gemini 分组的 gemini-2.5-pro）；为空时在本分组内降级
+	GroupID *int64 `json:"group_id,omitempty"`
+}
+
+// ModelFallbackConfig 分组级模型降级链配置
+type ModelFallbackConfig struct {
+	// Triggers 触发降级的条件，为空时所有条件均生效
+	Triggers []string `json:"triggers,omitempty"`
+	// Chains key: 模型匹配模式（支持末尾 * 通配符）；value: 有序降级模型列表
+	Chains map[string][]ModelFallbackStep `json:"chains,omitempty"`
+}
+
+// Validate 校验降级链配置；currentGroupID 为当前分组 ID（新建时为 0）
+func (c *ModelFallbackConfig) Validate(currentGroupID int64) error {
+	if c == nil {
+		return nil
+	}
+	for _, trigger := range c.Triggers {
+		if !isModelFallbackTrigger(trigger) {
+			return infraerrors.BadRequest("INVALID_MODEL_FALLBACK", fmt.Sprintf("unknown trigger %q", trigger))
+		}
+	}
+	for pattern, steps := range c.Chains {
+		if strings.TrimSpace(pattern) == "" {
+			return infraerrors.BadRequest("INVALID_MODEL_FALLBACK", "chain pattern must not be empty")
+		}
+		if len(steps) == 0 {
+			return infraerrors.BadRequest("INVALID_MODEL_FALLBACK", fmt.Sprintf("chain %q has no fallback models", pattern))
+		}
+		for _, step := range steps {
+			if strings.TrimSpace(step.Model) == "" {
+				return infraerrors.BadRequest("INVALID_MODEL_FALLBACK", fmt.Sprintf("chain %q has an empty model", pattern))
+			}
+			if step.GroupID != nil && (*step.GroupID <= 0 || *step.GroupID == currentGroupID) {
+				return infraerrors.BadRequest("INVALID_MODEL_FALLBACK", fmt.Sprintf("chain %q has an invalid group_id", pattern))
+			}
+		}
+	}
+	return nil
+}
+
+func isModelFallbackTrigger(trigger string) bool {
+	for _, t := range modelFallbackTriggers {
+		if t == trigger {
+			return true
+		}
+	}
+	return false
+}
+
+// GetModelFallbackChain 根据请求模型获取降级链：精确匹配优先，其次最长前缀的通配符模式
+func (g *Group) GetModelFallbackChain(requestedModel string) []ModelFallbackStep {
+	if g == nil || g.ModelFallback == nil || len(g.ModelFallback.Chains) == 0 || requestedModel == "" {
+		return nil
+	}
+	if steps, ok := g.ModelFallback.Chains[requestedModel]; ok && len(steps) > 0 {
+		return steps
+	}
+	patterns := make([]string, 0, len(g.ModelFallback.Chains))
+	for pattern := range g.ModelFallback.Chains {
+		if strings.HasSuffix(pattern, "*") {
+			patterns = append(patterns, pattern)
+		}
+	}
+	sort.Slice(patterns, func(i, j int) bool { return len(patterns[i]) > len(patterns[j]) })
+	for _, pattern := range patterns {
+		if steps := g.ModelFallback.Chains[pattern]; matchModelPattern(pattern, requestedModel) && len(steps) > 0 {
+			return steps
+		}
+	}
+	return nil
+}
+
+// ModelFallbackPlan 单次请求的模型降级进度
+type ModelFallbackPlan struct {
+	RequestedModel string
+
+	triggers map[string]struct{}
+	steps    []ModelFallbackStep
+	next     int
+}
+
+// NewModelFallbackPlan 为请求模型创建降级计划；分组未配置匹配的降级链时返回 nil
+func NewModelFallbackPlan(group *Group, requestedModel string) *ModelFallbackPlan {
+	steps := group.GetModelFallbackChain(requestedModel)
+	if len(steps) == 0 {
+		return nil
+	}
+	triggers := group.ModelFallback.Triggers
+	if len(triggers) == 0 {
+		triggers = modelFallbackTriggers
+	}
+	plan := &ModelFallbackPlan{
+		RequestedModel: requestedModel,
+		triggers:       make(map[string]struct{}, len(triggers)),
+		steps:          steps,
+	}
+	for _, trigger := range triggers {
+		plan.triggers[trigger] = struct{}{}
+	}
+	return plan
+}
+
+// Next 触发条件已配置且降级链未走完时返回下一个降级模型
+func (p *ModelFallbackPlan) Next(trigger string) (ModelFallbackStep, bool) {
+	if p == nil || p.next >= len(p.steps) {
+		return ModelFallbackStep{}, false
+	}
+	if _, ok := p.triggers[trigger]; !ok {
+		return ModelFallbackStep{}, false
+	}
+	step := p.steps[p.next]
+	p.next++
+	return step, true
+}
+
+// ReplaceRequestModel 替换请求体中的模型名并重新解析，用于降级到后备模型
+func ReplaceRequestModel(parsed *ParsedRequest, model string) (*ParsedRequest, error) {
+	body, err := sjson.SetBytes(parsed.Body, "model", model)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGatewayRequest(body)
+}
+
+// ResolveModelFallbackGroup 解析跨分组降级的目标分组并校验付费方能否使用该分组：
+// 订阅分组需要有效订阅（返回该订阅用于计费），标准分组按可绑定分组校验。
+func (s *GatewayService) ResolveModelFallbackGroup(ctx context.Context, apiKey *APIKey, groupID int64) (*Group, *UserSubscription, error) {
+	group, err := s.resolveGroupByID(ctx, groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !group.IsActive() {
+		return nil, nil, fmt.Errorf("model fallback group %d is not active", groupID)
+	}
+	payer, err := ResolveBillingPayer(apiKey, apiKey.User)
+	if err != nil {
+		return nil, nil, err
+	}
+	if group.IsSubscriptionType() {
+		subscription, err := s.userSubRepo.GetActiveByUserIDAndGroupID(ctx, payer.ID, group.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("model fallback group %d: %w", groupID, ErrGroupNotAllowed)
+		}
+		return group, subscription, nil
+	}
+	// 认证缓存中的用户不含可绑定分组，需要从库中读取
+	user, err := s.userRepo.GetByID(ctx, payer.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get user: %w", err)
+	}
+	if !user.CanBindGroup(group.ID, group.IsExclusive) {
+		return nil, nil, fmt.Errorf("model fallback group %d: %w", groupID, ErrGroupNotAllowed)
+	}
+	return group, nil, nil
+}
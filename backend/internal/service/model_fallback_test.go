@@ -0,0 +1,173 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupGetModelFallbackChain(t *testing.T) {
+	geminiGroup := int64(12)
+	group := &Group{
+		ID: 1,
+		ModelFallback: &ModelFallbackConfig{
+			Chains: map[string][]ModelFallbackStep{
+				"claude-*":               {{Model: "gemini-2.5-pro", GroupID: &geminiGroup}},
+				"claude-opus-*":          {{Model: "claude-sonnet-4-5"}, {Model: "claude-haiku-4-5"}},
+				"claude-opus-4-1":        {{Model: "claude-opus-4-0"}},
+				"claude-sonnet-4-5-2025": {},
+			},
+		},
+	}
+
+	// 精确匹配优先，其次最长前缀
+	require.Equal(t, "claude-opus-4-0", group.GetModelFallbackChain("claude-opus-4-1")[0].Model)
+	require.Len(t, group.GetModelFallbackChain("claude-opus-4-5"), 2)
+	require.Equal(t, "gemini-2.5-pro", group.GetModelFallbackChain("claude-sonnet-4-5")[0].Model)
+	require.Nil(t, group.GetModelFallbackChain("gpt-4o"))
+
+	var noFallback *Group
+	require.Nil(t, noFallback.GetModelFallbackChain("claude-opus-4-5"))
+	require.Nil(t, NewModelFallbackPlan(&Group{ID: 2}, "claude-opus-4-5"))
+}
+
+func TestModelFallbackPlanNext(t *testing.T) {
+	group := &Group{
+		ID: 1,
+		ModelFallback: &ModelFallbackConfig{
+			Triggers: []string{ModelFallbackTriggerOverloaded},
+			Chains: map[string][]ModelFallbackStep{
+				"claude-opus-*": {{Model: "claude-sonnet-4-5"}, {Model: "claude-haiku-4-5"}},
+			},
+		},
+	}
+	plan := NewModelFallbackPlan(group, "claude-opus-4-5")
+	require.NotNil(t, plan)
+
+	// 未配置的触发条件不降级
+	_, ok := plan.Next(ModelFallbackTriggerNoAccounts)
+	require.False(t, ok)
+
+	step, ok := plan.Next(ModelFallbackTriggerOverloaded)
+	require.True(t, ok)
+	require.Equal(t, "claude-sonnet-4-5", step.Model)
+	step, ok = plan.Next(ModelFallbackTriggerOverloaded)
+	require.True(t, ok)
+	require.Equal(t, "claude-haiku-4-5", step.Model)
+	_, ok = plan.Next(ModelFallbackTriggerOverloaded)
+	require.False(t, ok, "chain exhausted")
+
+	// 未配置触发条件时全部生效
+	group.ModelFallback.Triggers = nil
+	plan = NewModelFallbackPlan(group, "claude-opus-4-5")
+	_, ok = plan.Next(ModelFallbackTriggerSwitchesExhausted)
+	require.True(t, ok)
+
+	var nilPlan *ModelFallbackPlan
+	_, ok = nilPlan.Next(ModelFallbackTriggerOverloaded)
+	require.False(t, ok)
+}
+
+func TestModelFallbackConfigValidate(t *testing.T) {
+	self := int64(3)
+	other := int64(4)
+	valid := &ModelFallbackConfig{
+		Triggers: []string{ModelFallbackTriggerNoAccounts},
+		Chains:   map[string][]ModelFallbackStep{"claude-*": {{Model: "gemini-2.5-pro", GroupID: &other}}},
+	}
+	require.NoError(t, valid.Validate(self))
+
+	cases := []*ModelFallbackConfig{
+		{Triggers: []string{"timeout"}},
+		{Chains: map[string][]ModelFallbackStep{"": {{Model: "claude-haiku-4-5"}}}},
+		{Chains: map[string][]ModelFallbackStep{"claude-*": {}}},
+		{Chains: map[string][]ModelFallbackStep{"claude-*": {{Model: " "}}}},
+		{Chains: map[string][]ModelFallbackStep{"claude-*": {{Model: "claude-haiku-4-5", GroupID: &self}}}},
+	}
+	for _, cfg := range cases {
+		require.Error(t, cfg.Validate(self))
+	}
+}
+
+func TestReplaceRequestModel(t *testing.T) {
+	parsed, err := ParseGatewayRequest([]byte(`{"model":"claude-opus-4-5","stream":true,"metadata":{"user_id":"u1"},"messages":[{"role":"user","content":"hi"}]}`))
+	require.NoError(t, err)
+
+	replaced, err := ReplaceRequestModel(parsed, "claude-sonnet-4-5")
+	require.NoError(t, err)
+	require.Equal(t, "claude-sonnet-4-5", replaced.Model)
+	require.True(t, replaced.Stream)
+	require.Equal(t, "u1", replaced.MetadataUserID)
+	require.Len(t, replaced.Messages, 1)
+	require.Equal(t, "claude-opus-4-5", parsed.Model, "original request is not modified")
+}
+
+type fallbackUserRepoStub struct {
+	UserRepository
+	users map[int64]*User
+}
+
+func (r *fallbackUserRepoStub) GetByID(ctx context.Context, id int64) (*User, error) {
+	if u, ok := r.users[id]; ok {
+		return u, nil
+	}
+	return nil, ErrUserNotFound
+}
+
+type fallbackUserSubRepoStub struct {
+	UserSubscriptionRepository
+	active map[int64]*UserSubscription // key: groupID
+}
+
+func (r *fallbackUserSubRepoStub) GetActiveByUserIDAndGroupID(ctx context.Context, userID, groupID int64) (*UserSubscription, error) {
+	if sub, ok := r.active[groupID]; ok && sub.UserID == userID {
+		return sub, nil
+	}
+	return nil, ErrSubscriptionNotFound
+}
+
+// TestResolveModelFallbackGroupChecksAccess 跨分组降级要求付费方能使用目标分组，订阅分组返回该分组的订阅
+func TestResolveModelFallbackGroupChecksAccess(t *testing.T) {
+	groups := &mockGroupRepoForGateway{groups: map[int64]*Group{
+		11: {ID: 11, Platform: PlatformGemini, Status: StatusActive, Hydrated: true},
+		12: {ID: 12, Platform: PlatformGemini, Status: StatusActive, Hydrated: true, IsExclusive: true},
+		13: {ID: 13, Platform: PlatformGemini, Status: StatusActive, Hydrated: true, SubscriptionType: SubscriptionTypeSubscription},
+		14: {ID: 14, Platform: PlatformGemini, Status: StatusActive, Hydrated: true, SubscriptionType: SubscriptionTypeSubscription},
+		15: {ID: 15, Platform: PlatformGemini, Status: StatusDisabled, Hydrated: true},
+	}}
+	sub := &UserSubscription{ID: 99, UserID: 7, GroupID: 13}
+	svc := &GatewayService{
+		groupRepo:   groups,
+		userRepo:    &fallbackUserRepoStub{users: map[int64]*User{7: {ID: 7}}},
+		userSubRepo: &fallbackUserSubRepoStub{active: map[int64]*UserSubscription{13: sub}},
+	}
+	apiKey := &APIKey{ID: 1, UserID: 7, User: &User{ID: 7}}
+	ctx := context.Background()
+
+	group, subscription, err := svc.ResolveModelFallbackGroup(ctx, apiKey, 11)
+	require.NoError(t, err)
+	require.Equal(t, int64(11), group.ID)
+	require.Nil(t, subscription)
+
+	group, subscription, err = svc.ResolveModelFallbackGroup(ctx, apiKey, 13)
+	require.NoError(t, err)
+	require.Equal(t, int64(13), group.ID)
+	require.Same(t, sub, subscription)
+
+	// 专属分组未授权、订阅分组无有效订阅
+	for _, id := range []int64{12, 14} {
+		_, _, err = svc.ResolveModelFallbackGroup(ctx, apiKey, id)
+		require.ErrorIs(t, err, ErrGroupNotAllowed, "group %d", id)
+	}
+	_, _, err = svc.ResolveModelFallbackGroup(ctx, apiKey, 15)
+	require.Error(t, err)
+
+	// 用户的可绑定分组来自数据库而非认证缓存
+	svc.userRepo = &fallbackUserRepoStub{users: map[int64]*User{7: {ID: 7, AllowedGroups: []int64{12}}}}
+	group, _, err = svc.ResolveModelFallbackGroup(ctx, apiKey, 12)
+	require.NoError(t, err)
+	require.Equal(t, int64(12), group.ID)
+}
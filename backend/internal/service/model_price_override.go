@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 未知价格策略：模型既无自定义价格、LiteLLM 价格，也无法匹配硬编码回退价格时的处理方式
+const (
+	UnknownPriceModeBlock   = "block"   // 拒绝请求
+	UnknownPriceModeDefault = "default" // 按默认价格计费
+	UnknownPriceModeFree    = "free"    // 免费放行并告警
+)
+
+// 价格来源
+const (
+	PricingSourceOverride = "override"
+	PricingSourceLiteLLM  = "litellm"
+	PricingSourceFallback = "fallback"
+	PricingSourceDefault  = "default"
+	PricingSourceFree     = "free"
+)
+
+// 自定义价格状态（管理端展示用）
+const (
+	PriceOverrideStateActive     = "active"
+	PriceOverrideStateScheduled  = "scheduled"
+	PriceOverrideStateSuperseded = "superseded"
+	PriceOverrideStateDeleted    = "deleted"
+)
+
+const maxModelPatternLength = 200
+
+var (
+	ErrModelPriceUnknown         = infraerrors.Forbidden("MODEL_PRICE_UNKNOWN", "pricing for this model is not configured")
+	ErrPriceOverrideNotFound     = infraerrors.NotFound("PRICE_OVERRIDE_NOT_FOUND", "price override not found")
+	ErrPriceOverrideInvalid      = infraerrors.BadRequest("PRICE_OVERRIDE_INVALID", "model_pattern is required (only a trailing * wildcard is allowed) and prices must not be negative")
+	ErrUnknownPricePolicyInvalid = infraerrors.BadRequest("UNKNOWN_PRICE_POLICY_INVALID", "mode must be block, default or free and prices must not be negative")
+)
+
+// ModelPriceOverride 管理员自定义模型价格的一个版本（价格单位：USD / 百万 token）
+type ModelPriceOverride struct {
+	ID                 int64
+	ModelPattern       string
+	GroupID            *int64
+	InputPrice         float64
+	OutputPrice        float64
+	CacheCreationPrice float64
+	CacheReadPrice     float64
+	// CacheCreation1hPrice 1小时缓存写入价格，0 表示按输入价格的 2 倍计算
+	CacheCreation1hPrice float64
+	EffectiveFrom        time.Time
+	Notes                string
+	CreatedBy            *int64
+	CreatedAt            time.Time
+	DeletedAt            *time.Time
+
+	// State 列表查询时由服务层填充
+	State string
+}
+
+// ToModelPricing 转换为计费使用的 per-token 价格
+func (o *ModelPriceOverride) ToModelPricing() *ModelPricing {
+	return &ModelPricing{
+		InputPricePerToken:           o.InputPrice / 1_000_000,
+		OutputPricePerToken:          o.OutputPrice / 1_000_000,
+		CacheCreationPricePerToken:   o.CacheCreationPrice / 1_000_000,
+		CacheReadPricePerToken:       o.CacheReadPrice / 1_000_000,
+		CacheCreation1hPricePerToken: o.CacheCreation1hPrice / 1_000_000,
+	}
+}
+
+// sameTarget 是否为同一模式与分组（同一价格的不同版本）
+func (o *ModelPriceOverride) sameTarget(other *ModelPriceOverride) bool {
+	if o.ModelPattern != other.ModelPattern {
+		return false
+	}
+	if o.GroupID == nil || other.GroupID == nil {
+		return o.GroupID == nil && other.GroupID == nil
+	}
+	return *o.GroupID == *other.GroupID
+}
+
+// UnknownPricePolicy 未知价格策略（价格单位：USD / 百万 token）
+type UnknownPricePolicy struct {
+	Mode               string  `json:"mode"`
+	InputPrice         float64 `json:"input_price"`
+	OutputPrice        float64 `json:"output_price"`
+	CacheCreationPrice float64 `json:"cache_creation_price"`
+	CacheReadPrice     float64 `json:"cache_read_price"`
+	// CacheCreation1hPrice 0 表示按输入价格的 2 倍计算
+	CacheCreation1hPrice float64 `json:"cache_creation_1h_price"`
+}
+
+// DefaultUnknownPricePolicy 默认策略：按 Claude Sonnet 价格计费（与历史行为一致）
+func DefaultUnknownPricePolicy() *UnknownPricePolicy {
+	return &UnknownPricePolicy{
+		Mode:               UnknownPriceModeDefault,
+		InputPrice:         3,
+		OutputPrice:        15,
+		CacheCreationPrice: 3.75,
+		CacheReadPrice:     0.3,
+	}
+}
+
+// Validate 校验策略
+func (p *UnknownPricePolicy) Validate() error {
+	switch p.Mode {
+	case UnknownPriceModeBlock, UnknownPriceModeDefault, UnknownPriceModeFree:
+	default:
+		return ErrUnknownPricePolicyInvalid
+	}
+	if p.InputPrice < 0 || p.OutputPrice < 0 || p.CacheCreationPrice < 0 || p.CacheReadPrice < 0 || p.CacheCreation1hPrice < 0 {
+		return ErrUnknownPricePolicyInvalid
+	}
+	return nil
+}
+
+// ModelPriceOverrideListFilters 自定义价格列表筛选
+type ModelPriceOverrideListFilters struct {
+	Search         string
+	GroupID        *int64
+	IncludeDeleted bool
+}
+
+// ModelPriceOverrideRepository 自定义模型价格存储
+type ModelPriceOverrideRepository interface {
+	// ListEffective 返回所有未删除的版本（含尚未生效的版本），供内存匹配使用
+	ListEffective(ctx context.Context) ([]ModelPriceOverride, error)
+	List(ctx context.Context, params pagination.PaginationParams, filters ModelPriceOverrideListFilters) ([]ModelPriceOverride, *pagination.PaginationResult, error)
+	Create(ctx context.Context, override *ModelPriceOverride) error
+	// SoftDelete 软删除一个版本，不存在或已删除返回 ErrPriceOverrideNotFound
+	SoftDelete(ctx context.Context, id int64) error
+}
+
+// normalizeModelPattern 统一为小写并去除空白
+func normalizeModelPattern(pattern string) string {
+	return strings.ToLower(strings.TrimSpace(pattern))
+}
+
+// validModelPattern 与分组模型路由一致，仅支持末尾 * 通配符
+func validModelPattern(pattern string) bool {
+	if pattern == "" || len(pattern) > maxModelPatternLength {
+		return false
+	}
+	return !strings.Contains(strings.TrimSuffix(pattern, "*"), "*")
+}
+
+// modelPatternSpecificity 模式的精确程度：精确匹配最高，通配符模式按前缀长度排序
+func modelPatternSpecificity(pattern string) int {
+	if !strings.HasSuffix(pattern, "*") {
+		return maxModelPatternLength + 1
+	}
+	return len(pattern) - 1
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+const (
+	modelPriceOverrideReloadWorkerName = "model_price_override_reload"
+	// modelPriceOverrideReloadInterval 多实例部署时从数据库同步自定义价格的周期
+	modelPriceOverrideReloadInterval = time.Minute
+	// unknownPriceAlertInterval 同一模型未知价格告警的最小间隔
+	unknownPriceAlertInterval = time.Hour
+)
+
+// CreateModelPriceOverrideInput 新增自定义价格版本（价格单位：USD / 百万 token）
+type CreateModelPriceOverrideInput struct {
+	ModelPattern       string
+	GroupID            *int64
+	InputPrice         float64
+	OutputPrice        float64
+	CacheCreationPrice float64
+	CacheReadPrice     float64
+	// CacheCreation1hPrice 1小时缓存写入价格，0 表示按输入价格的 2 倍计算
+	CacheCreation1hPrice float64
+	// EffectiveFrom 为空表示立即生效
+	EffectiveFrom *time.Time
+	Notes         string
+}
+
+// ModelPriceOverrideService 自定义模型价格与未知价格策略
+//
+// 所有未删除的价格版本缓存在内存中，计费路径只做内存匹配；
+// 管理端修改后立即重新加载，其他实例按 modelPriceOverrideReloadInterval 周期同步。
+type ModelPriceOverrideService struct {
+	repo        ModelPriceOverrideRepository
+	settingRepo SettingRepository
+	timingWheel *TimingWheelService
+
+	mu        sync.RWMutex
+	overrides []ModelPriceOverride
+	policy    *UnknownPricePolicy
+
+	alertMu     sync.Mutex
+	lastAlerted map[string]time.Time
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewModelPriceOverrideService 创建自定义价格服务
+func NewModelPriceOverrideService(repo ModelPriceOverrideRepository, settingRepo SettingRepository, timingWheel *TimingWheelService) *ModelPriceOverrideService {
+	return &ModelPriceOverrideService{
+		repo:        repo,
+		settingRepo: settingRepo,
+		timingWheel: timingWheel,
+		policy:      DefaultUnknownPricePolicy(),
+		lastAlerted: make(map[string]time.Time),
+	}
+}
+
+// Start 加载价格并启动周期同步
+func (s *ModelPriceOverrideService) Start() {
+	if s == nil || s.repo == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.reloadLogged()
+		if s.timingWheel != nil {
+			s.timingWheel.ScheduleRecurring(modelPriceOverrideReloadWorkerName, modelPriceOverrideReloadInterval, s.reloadLogged)
+		}
+	})
+}
+
+// Stop 停止周期同步
+func (s *ModelPriceOverrideService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(modelPriceOverrideReloadWorkerName)
+		}
+	})
+}
+
+func (s *ModelPriceOverrideService) reloadLogged() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.Reload(ctx); err != nil {
+		log.Printf("[PriceOverride] reload failed: %v", err)
+	}
+}
+
+// Reload 从数据库重新加载价格版本与未知价格策略
+func (s *ModelPriceOverrideService) Reload(ctx context.Context) error {
+	overrides, err := s.repo.ListEffective(ctx)
+	if err != nil {
+		return err
+	}
+	policy, err := s.loadPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.overrides = overrides
+	s.policy = policy
+	s.mu.Unlock()
+	return nil
+}
+
+// Resolve 返回 at 时刻对该模型生效的自定义价格：
+// 分组价格优先于全局价格，其次精确模式优先于通配符模式，同一模式取已生效的最新版本。
+func (s *ModelPriceOverrideService) Resolve(model string, groupID *int64, at time.Time) *ModelPriceOverride {
+	if s == nil {
+		return nil
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var best *ModelPriceOverride
+	bestGroup, bestSpec := false, -1
+	for i := range s.overrides {
+		o := &s.overrides[i]
+		if o.EffectiveFrom.After(at) {
+			continue
+		}
+		groupMatch := false
+		if o.GroupID != nil {
+			if groupID == nil || *o.GroupID != *groupID {
+				continue
+			}
+			groupMatch = true
+		}
+		if !matchModelPattern(o.ModelPattern, model) {
+			continue
+		}
+		spec := modelPatternSpecificity(o.ModelPattern)
+		switch {
+		case best == nil,
+			groupMatch && !bestGroup,
+			groupMatch == bestGroup && spec > bestSpec,
+			groupMatch == bestGroup && spec == bestSpec && o.EffectiveFrom.After(best.EffectiveFrom),
+			groupMatch == bestGroup && spec == bestSpec && o.EffectiveFrom.Equal(best.EffectiveFrom) && o.ID > best.ID:
+			best, bestGroup, bestSpec = o, groupMatch, spec
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	out := *best
+	return &out
+}
+
+// Policy 当前未知价格策略
+func (s *ModelPriceOverrideService) Policy() UnknownPricePolicy {
+	if s == nil {
+		return *DefaultUnknownPricePolicy()
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return *s.policy
+}
+
+// shouldAlertUnknown 限制同一模型的未知价格告警频率
+func (s *ModelPriceOverrideService) shouldAlertUnknown(model string) bool {
+	if s == nil {
+		return true
+	}
+	s.alertMu.Lock()
+	defer s.alertMu.Unlock()
+	now := time.Now()
+	if last, ok := s.lastAlerted[model]; ok && now.Sub(last) < unknownPriceAlertInterval {
+		return false
+	}
+	s.lastAlerted[model] = now
+	return true
+}
+
+// List 管理端价格列表（含历史版本），并标注每个版本的状态
+func (s *ModelPriceOverrideService) List(ctx context.Context, params pagination.PaginationParams, filters ModelPriceOverrideListFilters) ([]ModelPriceOverride, *pagination.PaginationResult, error) {
+	items, result, err := s.repo.List(ctx, params, filters)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i := range items {
+		items[i].State = s.stateOf(&items[i], now)
+	}
+	return items, result, nil
+}
+
+// stateOf 计算版本状态（调用方持有读锁）
+func (s *ModelPriceOverrideService) stateOf(o *ModelPriceOverride, now time.Time) string {
+	if o.DeletedAt != nil {
+		return PriceOverrideStateDeleted
+	}
+	if o.EffectiveFrom.After(now) {
+		return PriceOverrideStateScheduled
+	}
+	for i := range s.overrides {
+		other := &s.overrides[i]
+		if other.ID == o.ID || !other.sameTarget(o) || other.EffectiveFrom.After(now) {
+			continue
+		}
+		if other.EffectiveFrom.After(o.EffectiveFrom) || (other.EffectiveFrom.Equal(o.EffectiveFrom) && other.ID > o.ID) {
+			return PriceOverrideStateSuperseded
+		}
+	}
+	return PriceOverrideStateActive
+}
+
+// Create 新增价格版本；修改价格即新增一个生效时间更晚的版本
+func (s *ModelPriceOverrideService) Create(ctx context.Context, input *CreateModelPriceOverrideInput, adminID int64) (*ModelPriceOverride, error) {
+	pattern := normalizeModelPattern(input.ModelPattern)
+	if !validModelPattern(pattern) ||
+		input.InputPrice < 0 || input.OutputPrice < 0 || input.CacheCreationPrice < 0 || input.CacheReadPrice < 0 || input.CacheCreation1hPrice < 0 {
+		return nil, ErrPriceOverrideInvalid
+	}
+
+	override := &ModelPriceOverride{
+		ModelPattern:         pattern,
+		GroupID:              input.GroupID,
+		InputPrice:           input.InputPrice,
+		OutputPrice:          input.OutputPrice,
+		CacheCreationPrice:   input.CacheCreationPrice,
+		CacheReadPrice:       input.CacheReadPrice,
+		CacheCreation1hPrice: input.CacheCreation1hPrice,
+		EffectiveFrom:        time.Now(),
+		Notes:                strings.TrimSpace(input.Notes),
+	}
+	if input.EffectiveFrom != nil && !input.EffectiveFrom.IsZero() {
+		override.EffectiveFrom = *input.EffectiveFrom
+	}
+	if adminID > 0 {
+		override.CreatedBy = &adminID
+	}
+	if err := s.repo.Create(ctx, override); err != nil {
+		return nil, err
+	}
+	log.Printf("[PriceOverride] admin %d set price for %q (group=%v) effective %s", adminID, pattern, input.GroupID, override.EffectiveFrom.Format(time.RFC3339))
+	s.reloadLogged()
+	return override, nil
+}
+
+// Delete 软删除一个价格版本
+func (s *ModelPriceOverrideService) Delete(ctx context.Context, id int64) error {
+	if err := s.repo.SoftDelete(ctx, id); err != nil {
+		return err
+	}
+	s.reloadLogged()
+	return nil
+}
+
+// GetPolicy 读取未知价格策略
+func (s *ModelPriceOverrideService) GetPolicy(ctx context.Context) (*UnknownPricePolicy, error) {
+	return s.loadPolicy(ctx)
+}
+
+// UpdatePolicy 更新未知价格策略
+func (s *ModelPriceOverrideService) UpdatePolicy(ctx context.Context, policy *UnknownPricePolicy) (*UnknownPricePolicy, error) {
+	if policy == nil {
+		return nil, ErrUnknownPricePolicyInvalid
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return nil, fmt.Errorf("marshal unknown price policy: %w", err)
+	}
+	if err := s.settingRepo.Set(ctx, SettingKeyUnknownPricePolicy, string(data)); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	clone := *policy
+	s.policy = &clone
+	s.mu.Unlock()
+	return policy, nil
+}
+
+func (s *ModelPriceOverrideService) loadPolicy(ctx context.Context) (*UnknownPricePolicy, error) {
+	if s.settingRepo == nil {
+		return DefaultUnknownPricePolicy(), nil
+	}
+	value, err := s.settingRepo.GetValue(ctx, SettingKeyUnknownPricePolicy)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return DefaultUnknownPricePolicy(), nil
+		}
+		return nil, fmt.Errorf("get unknown price policy: %w", err)
+	}
+	if value == "" {
+		return DefaultUnknownPricePolicy(), nil
+	}
+	var policy UnknownPricePolicy
+	if err := json.Unmarshal([]byte(value), &policy); err != nil || policy.Validate() != nil {
+		return DefaultUnknownPricePolicy(), nil
+	}
+	return &policy, nil
+}
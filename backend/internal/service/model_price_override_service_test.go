@@ -0,0 +1,169 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type priceOverrideRepoStub struct {
+	ModelPriceOverrideRepository
+	overrides []ModelPriceOverride
+}
+
+func (s *priceOverrideRepoStub) ListEffective(ctx context.Context) ([]ModelPriceOverride, error) {
+	return append([]ModelPriceOverride(nil), s.overrides...), nil
+}
+
+func newPriceOverrideServiceForTest(t *testing.T, policy string, overrides ...ModelPriceOverride) *ModelPriceOverrideService {
+	t.Helper()
+	values := map[string]string{}
+	if policy != "" {
+		values[SettingKeyUnknownPricePolicy] = policy
+	}
+	svc := NewModelPriceOverrideService(&priceOverrideRepoStub{overrides: overrides}, &settingRepoStub{values: values}, nil)
+	require.NoError(t, svc.Reload(context.Background()))
+	return svc
+}
+
+func TestModelPriceOverrideResolvePrecedence(t *testing.T) {
+	now := time.Now()
+	groupID := int64(7)
+	otherGroup := int64(8)
+	svc := newPriceOverrideServiceForTest(t, "",
+		ModelPriceOverride{ID: 1, ModelPattern: "claude-*", InputPrice: 1, EffectiveFrom: now.Add(-48 * time.Hour)},
+		ModelPriceOverride{ID: 2, ModelPattern: "claude-sonnet-4-5", InputPrice: 2, EffectiveFrom: now.Add(-48 * time.Hour)},
+		ModelPriceOverride{ID: 3, ModelPattern: "claude-sonnet-4-5", InputPrice: 3, EffectiveFrom: now.Add(-time.Hour)},
+		ModelPriceOverride{ID: 4, ModelPattern: "claude-sonnet-4-5", InputPrice: 4, EffectiveFrom: now.Add(time.Hour)},
+		ModelPriceOverride{ID: 5, ModelPattern: "claude-*", GroupID: &groupID, InputPrice: 5, EffectiveFrom: now.Add(-time.Hour)},
+		ModelPriceOverride{ID: 6, ModelPattern: "*", GroupID: &otherGroup, InputPrice: 6, EffectiveFrom: now.Add(-time.Hour)},
+	)
+
+	// 精确模式优先于通配符，同一模式取已生效的最新版本，未来版本不生效
+	got := svc.Resolve("Claude-Sonnet-4-5", nil, now)
+	require.NotNil(t, got)
+	require.Equal(t, int64(3), got.ID)
+
+	// 分组价格优先于全局精确价格
+	got = svc.Resolve("claude-sonnet-4-5", &groupID, now)
+	require.NotNil(t, got)
+	require.Equal(t, int64(5), got.ID)
+
+	// 通配符兜底
+	got = svc.Resolve("claude-haiku-4-5", nil, now)
+	require.NotNil(t, got)
+	require.Equal(t, int64(1), got.ID)
+
+	// 生效时间到达后使用新版本
+	got = svc.Resolve("claude-sonnet-4-5", nil, now.Add(2*time.Hour))
+	require.NotNil(t, got)
+	require.Equal(t, int64(4), got.ID)
+
+	require.Nil(t, svc.Resolve("gpt-5", nil, now))
+	require.Equal(t, int64(6), svc.Resolve("gpt-5", &otherGroup, now).ID)
+}
+
+func TestModelPriceOverrideStateOf(t *testing.T) {
+	now := time.Now()
+	deletedAt := now
+	svc := newPriceOverrideServiceForTest(t, "",
+		ModelPriceOverride{ID: 1, ModelPattern: "gpt-5", EffectiveFrom: now.Add(-48 * time.Hour)},
+		ModelPriceOverride{ID: 2, ModelPattern: "gpt-5", EffectiveFrom: now.Add(-time.Hour)},
+		ModelPriceOverride{ID: 3, ModelPattern: "gpt-5", EffectiveFrom: now.Add(time.Hour)},
+	)
+
+	require.Equal(t, PriceOverrideStateSuperseded, svc.stateOf(&ModelPriceOverride{ID: 1, ModelPattern: "gpt-5", EffectiveFrom: now.Add(-48 * time.Hour)}, now))
+	require.Equal(t, PriceOverrideStateActive, svc.stateOf(&ModelPriceOverride{ID: 2, ModelPattern: "gpt-5", EffectiveFrom: now.Add(-time.Hour)}, now))
+	require.Equal(t, PriceOverrideStateScheduled, svc.stateOf(&ModelPriceOverride{ID: 3, ModelPattern: "gpt-5", EffectiveFrom: now.Add(time.Hour)}, now))
+	require.Equal(t, PriceOverrideStateDeleted, svc.stateOf(&ModelPriceOverride{ID: 4, ModelPattern: "gpt-5", EffectiveFrom: now.Add(-time.Hour), DeletedAt: &deletedAt}, now))
+}
+
+func TestModelPriceOverrideCreateRejectsInvalidPattern(t *testing.T) {
+	svc := newPriceOverrideServiceForTest(t, "")
+
+	_, err := svc.Create(context.Background(), &CreateModelPriceOverrideInput{ModelPattern: "claude-*-sonnet", InputPrice: 1}, 1)
+	require.ErrorIs(t, err, ErrPriceOverrideInvalid)
+
+	_, err = svc.Create(context.Background(), &CreateModelPriceOverrideInput{ModelPattern: "gpt-5", InputPrice: -1}, 1)
+	require.ErrorIs(t, err, ErrPriceOverrideInvalid)
+}
+
+func TestBillingServiceOverrideBeatsFallback(t *testing.T) {
+	svc := newPriceOverrideServiceForTest(t, "",
+		ModelPriceOverride{ID: 1, ModelPattern: "claude-sonnet-4*", InputPrice: 10, OutputPrice: 20, EffectiveFrom: time.Now().Add(-time.Hour)},
+	)
+	billing := NewBillingService(&config.Config{}, nil, svc)
+
+	cost, err := billing.CalculateCostForGroup("claude-sonnet-4-5", nil, UsageTokens{InputTokens: 1_000_000, OutputTokens: 500_000}, 2)
+	require.NoError(t, err)
+	require.InDelta(t, 20.0, cost.TotalCost, 1e-9)
+	require.InDelta(t, 40.0, cost.ActualCost, 1e-9)
+}
+
+func TestBillingServiceUnknownPricePolicies(t *testing.T) {
+	tokens := UsageTokens{InputTokens: 1_000_000, OutputTokens: 1_000_000}
+
+	t.Run("default bills policy prices", func(t *testing.T) {
+		billing := NewBillingService(&config.Config{}, nil, newPriceOverrideServiceForTest(t, `{"mode":"default","input_price":1,"output_price":2}`))
+		cost, err := billing.CalculateCostForGroup("mystery-model", nil, tokens, 1)
+		require.NoError(t, err)
+		require.InDelta(t, 3.0, cost.ActualCost, 1e-9)
+		require.NoError(t, billing.CheckModelPriced("mystery-model", nil))
+	})
+
+	t.Run("free bills zero", func(t *testing.T) {
+		billing := NewBillingService(&config.Config{}, nil, newPriceOverrideServiceForTest(t, `{"mode":"free"}`))
+		cost, err := billing.CalculateCostForGroup("mystery-model", nil, tokens, 1)
+		require.NoError(t, err)
+		require.Zero(t, cost.ActualCost)
+
+		preview, err := billing.PreviewCost("mystery-model", nil, tokens, 0)
+		require.NoError(t, err)
+		require.Equal(t, PricingSourceFree, preview.Resolution.Source)
+	})
+
+	t.Run("block rejects", func(t *testing.T) {
+		billing := NewBillingService(&config.Config{}, nil, newPriceOverrideServiceForTest(t, `{"mode":"block"}`))
+		_, err := billing.ResolvePricing("mystery-model", nil)
+		require.True(t, errors.Is(err, ErrModelPriceUnknown))
+		require.ErrorIs(t, billing.CheckModelPriced("mystery-model", nil), ErrModelPriceUnknown)
+
+		// 已知模型不受影响
+		require.NoError(t, billing.CheckModelPriced("claude-sonnet-4-5", nil))
+	})
+}
+
+func TestBillingServiceBlockPolicyBillsPriceRemovedAfterCheck(t *testing.T) {
+	tokens := UsageTokens{InputTokens: 1_000_000, OutputTokens: 1_000_000}
+	repo := &priceOverrideRepoStub{overrides: []ModelPriceOverride{
+		{ID: 1, ModelPattern: "mystery-model", InputPrice: 10, OutputPrice: 10, EffectiveFrom: time.Now().Add(-time.Hour)},
+	}}
+
+	t.Run("policy prices", func(t *testing.T) {
+		settings := &settingRepoStub{values: map[string]string{SettingKeyUnknownPricePolicy: `{"mode":"block","input_price":1,"output_price":2}`}}
+		overrides := NewModelPriceOverrideService(repo, settings, nil)
+		require.NoError(t, overrides.Reload(context.Background()))
+		billing := NewBillingService(&config.Config{}, nil, overrides)
+		require.NoError(t, billing.CheckModelPriced("mystery-model", nil))
+
+		// 转发完成前自定义价格被删除：按策略默认价格计费，而不是记为 0
+		repo.overrides = nil
+		require.NoError(t, overrides.Reload(context.Background()))
+		cost, err := billing.CalculateCostForGroup("mystery-model", nil, tokens, 1)
+		require.NoError(t, err)
+		require.InDelta(t, 3.0, cost.ActualCost, 1e-9)
+	})
+
+	t.Run("builtin default when policy has no prices", func(t *testing.T) {
+		billing := NewBillingService(&config.Config{}, nil, newPriceOverrideServiceForTest(t, `{"mode":"block"}`))
+		cost, err := billing.CalculateCostForGroup("mystery-model", nil, tokens, 1)
+		require.NoError(t, err)
+		require.InDelta(t, 18.0, cost.ActualCost, 1e-9)
+	})
+}
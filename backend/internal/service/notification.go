@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 通知类型
+const (
+	NotificationTypeLowBalance           = "low_balance"
+	NotificationTypeQuotaWarning         = "quota_warning"
+	NotificationTypeQuotaExhausted       = "quota_exhausted"
+	NotificationTypeSubscriptionExpiring = "subscription_expiring"
+	NotificationTypeAPIKeyDisabled       = "api_key_disabled"
+	NotificationTypeAPIKeyAnomaly        = "api_key_anomaly"
+)
+
+// LowBalanceNotificationDedupKey 余额提醒每次跌破阈值只发送一次；余额恢复后由 ResetLowBalance 改写旧记录的键重新布防
+const LowBalanceNotificationDedupKey = "below_threshold"
+
+// 订阅额度窗口
+const (
+	QuotaWindowDaily   = "daily"
+	QuotaWindowWeekly  = "weekly"
+	QuotaWindowMonthly = "monthly"
+)
+
+var (
+	ErrNotificationSettingsNotFound = infraerrors.NotFound("NOTIFICATION_SETTINGS_NOT_FOUND", "notification settings not found")
+	ErrNotificationWebhookInvalid   = infraerrors.BadRequest("NOTIFICATION_WEBHOOK_INVALID", "invalid webhook url")
+)
+
+// NotificationSettings 用户通知偏好
+//
+// LowBalanceThreshold / ExpiryReminderDays 为空时使用配置默认值。
+type NotificationSettings struct {
+	UserID              int64
+	EmailEnabled        bool
+	WebhookURL          string
+	WebhookSecret       string
+	LowBalanceEnabled   bool
+	LowBalanceThreshold *float64
+	QuotaAlertsEnabled  bool
+	ExpiryAlertsEnabled bool
+	ExpiryReminderDays  *int
+	APIKeyAlertsEnabled bool
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// DefaultNotificationSettings 用户未保存偏好时的默认设置（全部开启，仅邮件渠道）
+func DefaultNotificationSettings(userID int64) *NotificationSettings {
+	return &NotificationSettings{
+		UserID:              userID,
+		EmailEnabled:        true,
+		LowBalanceEnabled:   true,
+		QuotaAlertsEnabled:  true,
+		ExpiryAlertsEnabled: true,
+		APIKeyAlertsEnabled: true,
+	}
+}
+
+// Notification 已发送的通知记录
+type Notification struct {
+	ID            int64
+	UserID        int64
+	Type          string
+	DedupKey      string // 同一 (用户, 类型, 窗口) 只通知一次
+	Title         string
+	Message       string
+	EmailSent     bool
+	WebhookSent   bool
+	DeliveryError string
+	CreatedAt     time.Time
+}
+
+// NotificationRecipient 通知接收方及其投递渠道
+type NotificationRecipient struct {
+	UserID        int64
+	Email         string
+	EmailEnabled  bool
+	WebhookURL    string
+	WebhookSecret string
+}
+
+// LowBalanceCandidate 余额低于阈值的用户
+type LowBalanceCandidate struct {
+	NotificationRecipient
+	Balance   float64
+	Threshold float64
+}
+
+// SubscriptionNotificationCandidate 需要额度/到期提醒的订阅（限额已合并订阅覆盖与分组设置）
+type SubscriptionNotificationCandidate struct {
+	NotificationRecipient
+	SubscriptionID     int64
+	GroupID            int64
+	GroupName          string
+	ExpiresAt          time.Time
+	DailyWindowStart   *time.Time
+	WeeklyWindowStart  *time.Time
+	MonthlyWindowStart *time.Time
+	DailyUsageUSD      float64
+	WeeklyUsageUSD     float64
+	MonthlyUsageUSD    float64
+	DailyLimitUSD      *float64
+	WeeklyLimitUSD     *float64
+	MonthlyLimitUSD    *float64
+}
+
+// DisabledAPIKeyCandidate 最近被停用的 API Key
+type DisabledAPIKeyCandidate struct {
+	NotificationRecipient
+	APIKeyID   int64
+	APIKeyName string
+	UpdatedAt  time.Time
+}
+
+// NotificationRepository 通知偏好与通知记录存储
+type NotificationRepository interface {
+	GetSettings(ctx context.Context, userID int64) (*NotificationSettings, error)
+	UpsertSettings(ctx context.Context, settings *NotificationSettings) error
+
+	// Record 写入通知记录；(user_id, type, dedup_key) 已存在时返回 false，调用方不应再投递
+	Record(ctx context.Context, n *Notification) (bool, error)
+	UpdateDelivery(ctx context.Context, id int64, emailSent, webhookSent bool, deliveryError string) error
+	ListByUser(ctx context.Context, userID int64, params pagination.PaginationParams) ([]Notification, *pagination.PaginationResult, error)
+
+	// ListLowBalance 返回余额低于阈值且本轮尚未提醒的用户
+	ListLowBalance(ctx context.Context, defaultThreshold float64, limit int) ([]LowBalanceCandidate, error)
+	// ResetLowBalance 余额已恢复的用户重新布防（保留历史记录），下次跌破阈值时再次提醒
+	ResetLowBalance(ctx context.Context, defaultThreshold float64) (int64, error)
+	// ListQuotaUsage 返回任一窗口用量达到 warnRatio 的有效订阅
+	ListQuotaUsage(ctx context.Context, warnRatio float64, limit int) ([]SubscriptionNotificationCandidate, error)
+	// ListExpiringSubscriptions 返回将在提醒天数内到期的有效订阅
+	ListExpiringSubscriptions(ctx context.Context, defaultDays int, limit int) ([]SubscriptionNotificationCandidate, error)
+	// ListDisabledAPIKeys 返回 since 之后被停用的 API Key
+	ListDisabledAPIKeys(ctx context.Context, since time.Time, limit int) ([]DisabledAPIKeyCandidate, error)
+	// GetRecipient 返回有效用户的通知渠道；用户不存在或非 active 时返回 ErrUserNotFound
+	GetRecipient(ctx context.Context, userID int64) (*NotificationRecipient, error)
+}
+
+// NotificationWebhookSender 投递用户 Webhook（请求体使用用户密钥签名）
+type NotificationWebhookSender interface {
+	Send(ctx context.Context, url, secret string, payload []byte) error
+}
@@ -0,0 +1,518 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
+)
+
+const (
+	notificationScanWorkerName = "user_notification_scan"
+
+	maxExpiryReminderDays = 90
+)
+
+// NotificationEmailSender 通知邮件投递（由 EmailQueueService 异步发送）
+type NotificationEmailSender interface {
+	EnqueueEmail(email, subject, body string) error
+}
+
+// NotificationSettingsInput 用户更新通知偏好（nil 字段保持不变）
+type NotificationSettingsInput struct {
+	EmailEnabled            *bool
+	WebhookURL              *string // 空字符串表示关闭 Webhook
+	RegenerateWebhookSecret bool
+	LowBalanceEnabled       *bool
+	LowBalanceThreshold     *float64
+	QuotaAlertsEnabled      *bool
+	ExpiryAlertsEnabled     *bool
+	ExpiryReminderDays      *int
+	APIKeyAlertsEnabled     *bool
+}
+
+// NotificationService 用户通知服务
+//
+// 后台定时扫描余额、订阅额度窗口、订阅到期与 API Key 状态，通过邮件队列与用户 Webhook 投递。
+// 每条通知先以 (user_id, type, dedup_key) 唯一写入记录再投递，多实例并发扫描时同一窗口只通知一次。
+type NotificationService struct {
+	repo          NotificationRepository
+	emailSender   NotificationEmailSender
+	webhookSender NotificationWebhookSender
+	timingWheel   *TimingWheelService
+	cfg           *config.Config
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewNotificationService 创建用户通知服务
+func NewNotificationService(
+	repo NotificationRepository,
+	emailSender NotificationEmailSender,
+	webhookSender NotificationWebhookSender,
+	timingWheel *TimingWheelService,
+	cfg *config.Config,
+) *NotificationService {
+	return &NotificationService{
+		repo:          repo,
+		emailSender:   emailSender,
+		webhookSender: webhookSender,
+		timingWheel:   timingWheel,
+		cfg:           cfg,
+	}
+}
+
+// Start 启动通知扫描任务
+func (s *NotificationService) Start() {
+	if s == nil || s.cfg == nil {
+		return
+	}
+	if !s.cfg.Notification.Enabled {
+		log.Printf("[Notification] scanner not started (disabled)")
+		return
+	}
+	if s.repo == nil || s.timingWheel == nil {
+		log.Printf("[Notification] scanner not started (missing deps)")
+		return
+	}
+	interval := s.scanInterval()
+	s.startOnce.Do(func() {
+		s.timingWheel.ScheduleRecurring(notificationScanWorkerName, interval, s.runScan)
+		log.Printf("[Notification] scanner started (interval=%s)", interval)
+	})
+}
+
+// Stop 停止后台任务
+func (s *NotificationService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(notificationScanWorkerName)
+		}
+		log.Printf("[Notification] scanner stopped")
+	})
+}
+
+func (s *NotificationService) scanInterval() time.Duration {
+	return time.Duration(s.cfg.Notification.ScanIntervalSeconds) * time.Second
+}
+
+// ==================== 用户偏好 ====================
+
+// GetSettings 获取用户通知偏好（阈值等未设置项填充为配置默认值）
+func (s *NotificationService) GetSettings(ctx context.Context, userID int64) (*NotificationSettings, error) {
+	settings, err := s.loadSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.effectiveSettings(settings), nil
+}
+
+// UpdateSettings 更新用户通知偏好
+func (s *NotificationService) UpdateSettings(ctx context.Context, userID int64, input *NotificationSettingsInput) (*NotificationSettings, error) {
+	settings, err := s.loadSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if input == nil {
+		return s.effectiveSettings(settings), nil
+	}
+
+	if input.EmailEnabled != nil {
+		settings.EmailEnabled = *input.EmailEnabled
+	}
+	if input.WebhookURL != nil {
+		raw := strings.TrimSpace(*input.WebhookURL)
+		if raw == "" {
+			settings.WebhookURL = ""
+			settings.WebhookSecret = ""
+		} else {
+			normalized, err := s.validateWebhookURL(raw)
+			if err != nil {
+				return nil, err
+			}
+			settings.WebhookURL = normalized
+		}
+	}
+	if settings.WebhookURL != "" && (settings.WebhookSecret == "" || input.RegenerateWebhookSecret) {
+		secret, err := generateNotificationWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		settings.WebhookSecret = secret
+	}
+	if input.LowBalanceEnabled != nil {
+		settings.LowBalanceEnabled = *input.LowBalanceEnabled
+	}
+	if input.LowBalanceThreshold != nil {
+		if *input.LowBalanceThreshold < 0 {
+			return nil, infraerrors.BadRequest("NOTIFICATION_THRESHOLD_INVALID", "low balance threshold must be non-negative")
+		}
+		v := *input.LowBalanceThreshold
+		settings.LowBalanceThreshold = &v
+	}
+	if input.QuotaAlertsEnabled != nil {
+		settings.QuotaAlertsEnabled = *input.QuotaAlertsEnabled
+	}
+	if input.ExpiryAlertsEnabled != nil {
+		settings.ExpiryAlertsEnabled = *input.ExpiryAlertsEnabled
+	}
+	if input.ExpiryReminderDays != nil {
+		if *input.ExpiryReminderDays < 1 || *input.ExpiryReminderDays > maxExpiryReminderDays {
+			return nil, infraerrors.BadRequest("NOTIFICATION_EXPIRY_DAYS_INVALID", fmt.Sprintf("expiry reminder days must be between 1 and %d", maxExpiryReminderDays))
+		}
+		v := *input.ExpiryReminderDays
+		settings.ExpiryReminderDays = &v
+	}
+	if input.APIKeyAlertsEnabled != nil {
+		settings.APIKeyAlertsEnabled = *input.APIKeyAlertsEnabled
+	}
+
+	if err := s.repo.UpsertSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return s.effectiveSettings(settings), nil
+}
+
+// ListNotifications 获取用户通知历史
+func (s *NotificationService) ListNotifications(ctx context.Context, userID int64, params pagination.PaginationParams) ([]Notification, *pagination.PaginationResult, error) {
+	return s.repo.ListByUser(ctx, userID, params)
+}
+
+func (s *NotificationService) loadSettings(ctx context.Context, userID int64) (*NotificationSettings, error) {
+	settings, err := s.repo.GetSettings(ctx, userID)
+	if errors.Is(err, ErrNotificationSettingsNotFound) {
+		return DefaultNotificationSettings(userID), nil
+	}
+	return settings, err
+}
+
+func (s *NotificationService) effectiveSettings(settings *NotificationSettings) *NotificationSettings {
+	out := *settings
+	if out.LowBalanceThreshold == nil {
+		v := s.cfg.Notification.LowBalanceThreshold
+		out.LowBalanceThreshold = &v
+	}
+	if out.ExpiryReminderDays == nil {
+		v := s.cfg.Notification.ExpiryReminderDays
+		out.ExpiryReminderDays = &v
+	}
+	return &out
+}
+
+// validateWebhookURL 用户提供的回调地址必须为 https，且默认禁止指向内网（防止 SSRF）
+func (s *NotificationService) validateWebhookURL(raw string) (string, error) {
+	normalized, err := urlvalidator.ValidateHTTPSURL(raw, urlvalidator.ValidationOptions{
+		AllowPrivate: s.cfg.Security.URLAllowlist.AllowPrivateHosts,
+	})
+	if err != nil {
+		return "", ErrNotificationWebhookInvalid.WithCause(err)
+	}
+	return normalized, nil
+}
+
+func generateNotificationWebhookSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// ==================== 后台扫描 ====================
+
+func (s *NotificationService) runScan() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	sent := 0
+	sent += s.scanLowBalance(ctx)
+	sent += s.scanQuotaUsage(ctx)
+	sent += s.scanExpiringSubscriptions(ctx)
+	sent += s.scanDisabledAPIKeys(ctx)
+	if sent > 0 {
+		log.Printf("[Notification] sent %d notifications", sent)
+	}
+}
+
+func (s *NotificationService) scanLowBalance(ctx context.Context) int {
+	defaultThreshold := s.cfg.Notification.LowBalanceThreshold
+	if _, err := s.repo.ResetLowBalance(ctx, defaultThreshold); err != nil {
+		log.Printf("[Notification] reset low balance alerts failed: %v", err)
+	}
+
+	candidates, err := s.repo.ListLowBalance(ctx, defaultThreshold, s.cfg.Notification.BatchSize)
+	if err != nil {
+		log.Printf("[Notification] list low balance users failed: %v", err)
+		return 0
+	}
+	sent := 0
+	for i := range candidates {
+		c := &candidates[i]
+		n := &Notification{
+			UserID:   c.UserID,
+			Type:     NotificationTypeLowBalance,
+			DedupKey: LowBalanceNotificationDedupKey,
+			Title:    "Your balance is running low",
+			Message: fmt.Sprintf("Your account balance is $%.2f, below your alert threshold of $%.2f. Requests will be rejected once the balance is exhausted; please top up to avoid interruption.",
+				c.Balance, c.Threshold),
+		}
+		if s.dispatch(ctx, &c.NotificationRecipient, n, map[string]any{
+			"balance":   c.Balance,
+			"threshold": c.Threshold,
+		}) {
+			sent++
+		}
+	}
+	return sent
+}
+
+func (s *NotificationService) scanQuotaUsage(ctx context.Context) int {
+	warnRatio := float64(s.cfg.Notification.QuotaWarningPercent) / 100
+	candidates, err := s.repo.ListQuotaUsage(ctx, warnRatio, s.cfg.Notification.BatchSize)
+	if err != nil {
+		log.Printf("[Notification] list quota usage failed: %v", err)
+		return 0
+	}
+
+	now := time.Now()
+	sent := 0
+	for i := range candidates {
+		c := &candidates[i]
+		for _, w := range subscriptionQuotaWindows(c) {
+			n := buildQuotaNotification(c, w, warnRatio, now)
+			if n == nil {
+				continue
+			}
+			if s.dispatch(ctx, &c.NotificationRecipient, n, map[string]any{
+				"subscription_id": c.SubscriptionID,
+				"group_id":        c.GroupID,
+				"window":          w.name,
+				"usage_usd":       w.usage,
+				"limit_usd":       w.limit,
+				"resets_at":       w.start.Add(w.period),
+			}) {
+				sent++
+			}
+		}
+	}
+	return sent
+}
+
+func (s *NotificationService) scanExpiringSubscriptions(ctx context.Context) int {
+	candidates, err := s.repo.ListExpiringSubscriptions(ctx, s.cfg.Notification.ExpiryReminderDays, s.cfg.Notification.BatchSize)
+	if err != nil {
+		log.Printf("[Notification] list expiring subscriptions failed: %v", err)
+		return 0
+	}
+	sent := 0
+	for i := range candidates {
+		c := &candidates[i]
+		n := &Notification{
+			UserID:   c.UserID,
+			Type:     NotificationTypeSubscriptionExpiring,
+			DedupKey: fmt.Sprintf("%d:%d", c.SubscriptionID, c.ExpiresAt.Unix()),
+			Title:    fmt.Sprintf("Your %s subscription expires soon", c.GroupName),
+			Message: fmt.Sprintf("Your subscription to %s expires at %s. Renew it before then to keep access.",
+				c.GroupName, c.ExpiresAt.UTC().Format(time.RFC1123)),
+		}
+		if s.dispatch(ctx, &c.NotificationRecipient, n, map[string]any{
+			"subscription_id": c.SubscriptionID,
+			"group_id":        c.GroupID,
+			"expires_at":      c.ExpiresAt,
+		}) {
+			sent++
+		}
+	}
+	return sent
+}
+
+func (s *NotificationService) scanDisabledAPIKeys(ctx context.Context) int {
+	// 回看两个扫描周期，容忍任务延迟；重复由 dedup_key 去重
+	since := time.Now().Add(-2 * s.scanInterval())
+	candidates, err := s.repo.ListDisabledAPIKeys(ctx, since, s.cfg.Notification.BatchSize)
+	if err != nil {
+		log.Printf("[Notification] list disabled api keys failed: %v", err)
+		return 0
+	}
+	sent := 0
+	for i := range candidates {
+		c := &candidates[i]
+		n := &Notification{
+			UserID:   c.UserID,
+			Type:     NotificationTypeAPIKeyDisabled,
+			DedupKey: fmt.Sprintf("%d:%d", c.APIKeyID, c.UpdatedAt.Unix()),
+			Title:    fmt.Sprintf("API key %q was disabled", c.APIKeyName),
+			Message: fmt.Sprintf("Your API key %q was disabled at %s. Requests using this key will be rejected until it is re-enabled.",
+				c.APIKeyName, c.UpdatedAt.UTC().Format(time.RFC1123)),
+		}
+		if s.dispatch(ctx, &c.NotificationRecipient, n, map[string]any{
+			"api_key_id":   c.APIKeyID,
+			"api_key_name": c.APIKeyName,
+		}) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// NotifyAPIKeyAnomaly 通知 Key 所属用户检测到的用量异常；用户关闭 API Key 通知时跳过。
+// dedupKey 由调用方按异常事件生成，同一事件只通知一次。
+func (s *NotificationService) NotifyAPIKeyAnomaly(ctx context.Context, userID int64, dedupKey, title, message string, data map[string]any) bool {
+	if s == nil || s.repo == nil {
+		return false
+	}
+	settings, err := s.loadSettings(ctx, userID)
+	if err != nil {
+		log.Printf("[Notification] load settings failed: user_id=%d err=%v", userID, err)
+		return false
+	}
+	if !settings.APIKeyAlertsEnabled {
+		return false
+	}
+	recipient, err := s.repo.GetRecipient(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, ErrUserNotFound) {
+			log.Printf("[Notification] get recipient failed: user_id=%d err=%v", userID, err)
+		}
+		return false
+	}
+	return s.dispatch(ctx, recipient, &Notification{
+		UserID:   userID,
+		Type:     NotificationTypeAPIKeyAnomaly,
+		DedupKey: dedupKey,
+		Title:    title,
+		Message:  message,
+	}, data)
+}
+
+type subscriptionQuotaWindow struct {
+	name   string
+	start  time.Time
+	period time.Duration
+	usage  float64
+	limit  float64
+}
+
+// subscriptionQuotaWindows 返回已激活、未过期且设置了限额的窗口
+func subscriptionQuotaWindows(c *SubscriptionNotificationCandidate) []subscriptionQuotaWindow {
+	windows := make([]subscriptionQuotaWindow, 0, 3)
+	add := func(name string, start *time.Time, period time.Duration, usage float64, limit *float64) {
+		if start == nil || limit == nil || *limit <= 0 || time.Since(*start) >= period {
+			return
+		}
+		windows = append(windows, subscriptionQuotaWindow{name: name, start: *start, period: period, usage: usage, limit: *limit})
+	}
+	add(QuotaWindowDaily, c.DailyWindowStart, 24*time.Hour, c.DailyUsageUSD, c.DailyLimitUSD)
+	add(QuotaWindowWeekly, c.WeeklyWindowStart, 7*24*time.Hour, c.WeeklyUsageUSD, c.WeeklyLimitUSD)
+	add(QuotaWindowMonthly, c.MonthlyWindowStart, 30*24*time.Hour, c.MonthlyUsageUSD, c.MonthlyLimitUSD)
+	return windows
+}
+
+// buildQuotaNotification 用量达到预警比例或 100% 时生成通知；dedup_key 以窗口起点区分周期
+func buildQuotaNotification(c *SubscriptionNotificationCandidate, w subscriptionQuotaWindow, warnRatio float64, now time.Time) *Notification {
+	ratio := w.usage / w.limit
+	if ratio < warnRatio {
+		return nil
+	}
+
+	resetsIn := w.start.Add(w.period).Sub(now).Round(time.Minute)
+	n := &Notification{
+		UserID:   c.UserID,
+		DedupKey: fmt.Sprintf("%d:%s:%d", c.SubscriptionID, w.name, w.start.Unix()),
+	}
+	if ratio >= 1 {
+		n.Type = NotificationTypeQuotaExhausted
+		n.Title = fmt.Sprintf("%s %s limit reached", c.GroupName, w.name)
+		n.Message = fmt.Sprintf("Your %s subscription has used its %s limit ($%.2f / $%.2f). Requests will be rejected until the window resets in %s.",
+			c.GroupName, w.name, w.usage, w.limit, resetsIn)
+		return n
+	}
+	n.Type = NotificationTypeQuotaWarning
+	n.Title = fmt.Sprintf("%s %s usage at %.0f%%", c.GroupName, w.name, ratio*100)
+	n.Message = fmt.Sprintf("Your %s subscription has used %.0f%% of its %s limit ($%.2f / $%.2f). The window resets in %s.",
+		c.GroupName, ratio*100, w.name, w.usage, w.limit, resetsIn)
+	return n
+}
+
+// dispatch 登记并投递通知；同一窗口已通知过时返回 false
+func (s *NotificationService) dispatch(ctx context.Context, recipient *NotificationRecipient, n *Notification, data map[string]any) bool {
+	inserted, err := s.repo.Record(ctx, n)
+	if err != nil {
+		log.Printf("[Notification] record notification failed: user_id=%d type=%s err=%v", n.UserID, n.Type, err)
+		return false
+	}
+	if !inserted {
+		return false
+	}
+
+	var (
+		emailSent   bool
+		webhookSent bool
+		errs        []string
+	)
+	if recipient.EmailEnabled && recipient.Email != "" && s.emailSender != nil {
+		if err := s.emailSender.EnqueueEmail(recipient.Email, n.Title, buildNotificationEmailBody(n)); err != nil {
+			errs = append(errs, "email: "+err.Error())
+		} else {
+			emailSent = true
+		}
+	}
+	if recipient.WebhookURL != "" && s.webhookSender != nil {
+		if err := s.sendWebhook(ctx, recipient, n, data); err != nil {
+			errs = append(errs, "webhook: "+err.Error())
+		} else {
+			webhookSent = true
+		}
+	}
+
+	if err := s.repo.UpdateDelivery(ctx, n.ID, emailSent, webhookSent, strings.Join(errs, "; ")); err != nil {
+		log.Printf("[Notification] update delivery failed: id=%d err=%v", n.ID, err)
+	}
+	return true
+}
+
+func (s *NotificationService) sendWebhook(ctx context.Context, recipient *NotificationRecipient, n *Notification, data map[string]any) error {
+	payload, err := json.Marshal(map[string]any{
+		"id":         n.ID,
+		"type":       n.Type,
+		"user_id":    n.UserID,
+		"title":      n.Title,
+		"message":    n.Message,
+		"data":       data,
+		"created_at": n.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, time.Duration(s.cfg.Notification.WebhookTimeoutSeconds)*time.Second)
+	defer cancel()
+	return s.webhookSender.Send(sendCtx, recipient.WebhookURL, recipient.WebhookSecret, payload)
+}
+
+func buildNotificationEmailBody(n *Notification) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+  <h2>%s</h2>
+  <p>%s</p>
+  <p style="color: #999; font-size: 12px;">You can change which notifications you receive in your account settings.</p>
+</body>
+</html>`, html.EscapeString(n.Title), html.EscapeString(n.Message))
+}
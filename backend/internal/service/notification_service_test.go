@@ -0,0 +1,257 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type notificationRepoStub struct {
+	settings   map[int64]*NotificationSettings
+	records    map[string]*Notification
+	deliveries map[int64]string
+
+	lowBalance []LowBalanceCandidate
+	quota      []SubscriptionNotificationCandidate
+	expiring   []SubscriptionNotificationCandidate
+	apiKeys    []DisabledAPIKeyCandidate
+}
+
+func newNotificationRepoStub() *notificationRepoStub {
+	return &notificationRepoStub{
+		settings:   map[int64]*NotificationSettings{},
+		records:    map[string]*Notification{},
+		deliveries: map[int64]string{},
+	}
+}
+
+func (r *notificationRepoStub) GetSettings(ctx context.Context, userID int64) (*NotificationSettings, error) {
+	s, ok := r.settings[userID]
+	if !ok {
+		return nil, ErrNotificationSettingsNotFound
+	}
+	clone := *s
+	return &clone, nil
+}
+
+func (r *notificationRepoStub) UpsertSettings(ctx context.Context, settings *NotificationSettings) error {
+	clone := *settings
+	r.settings[settings.UserID] = &clone
+	return nil
+}
+
+func (r *notificationRepoStub) Record(ctx context.Context, n *Notification) (bool, error) {
+	key := n.Type + "|" + n.DedupKey
+	if _, ok := r.records[key]; ok {
+		return false, nil
+	}
+	n.ID = int64(len(r.records) + 1)
+	n.CreatedAt = time.Now()
+	clone := *n
+	r.records[key] = &clone
+	return true, nil
+}
+
+func (r *notificationRepoStub) UpdateDelivery(ctx context.Context, id int64, emailSent, webhookSent bool, deliveryError string) error {
+	r.deliveries[id] = deliveryError
+	return nil
+}
+
+func (r *notificationRepoStub) ListByUser(ctx context.Context, userID int64, params pagination.PaginationParams) ([]Notification, *pagination.PaginationResult, error) {
+	return nil, &pagination.PaginationResult{}, nil
+}
+
+func (r *notificationRepoStub) ListLowBalance(ctx context.Context, defaultThreshold float64, limit int) ([]LowBalanceCandidate, error) {
+	return r.lowBalance, nil
+}
+
+func (r *notificationRepoStub) ResetLowBalance(ctx context.Context, defaultThreshold float64) (int64, error) {
+	return 0, nil
+}
+
+func (r *notificationRepoStub) ListQuotaUsage(ctx context.Context, warnRatio float64, limit int) ([]SubscriptionNotificationCandidate, error) {
+	return r.quota, nil
+}
+
+func (r *notificationRepoStub) ListExpiringSubscriptions(ctx context.Context, defaultDays int, limit int) ([]SubscriptionNotificationCandidate, error) {
+	return r.expiring, nil
+}
+
+func (r *notificationRepoStub) ListDisabledAPIKeys(ctx context.Context, since time.Time, limit int) ([]DisabledAPIKeyCandidate, error) {
+	return r.apiKeys, nil
+}
+
+func (r *notificationRepoStub) GetRecipient(ctx context.Context, userID int64) (*NotificationRecipient, error) {
+	return &NotificationRecipient{UserID: userID, Email: "owner@example.com", EmailEnabled: true}, nil
+}
+
+func (r *notificationRepoStub) types() map[string]int {
+	out := map[string]int{}
+	for _, n := range r.records {
+		out[n.Type]++
+	}
+	return out
+}
+
+type notificationEmailStub struct {
+	sent []string
+}
+
+func (s *notificationEmailStub) EnqueueEmail(email, subject, body string) error {
+	s.sent = append(s.sent, email+"|"+subject)
+	return nil
+}
+
+type notificationWebhookStub struct {
+	payloads [][]byte
+	err      error
+}
+
+func (s *notificationWebhookStub) Send(ctx context.Context, url, secret string, payload []byte) error {
+	s.payloads = append(s.payloads, payload)
+	return s.err
+}
+
+func newNotificationTestService(repo NotificationRepository, email NotificationEmailSender, webhook NotificationWebhookSender) *NotificationService {
+	cfg := &config.Config{}
+	cfg.Notification = config.NotificationConfig{
+		Enabled:               true,
+		ScanIntervalSeconds:   60,
+		LowBalanceThreshold:   1,
+		QuotaWarningPercent:   80,
+		ExpiryReminderDays:    3,
+		WebhookTimeoutSeconds: 5,
+		BatchSize:             100,
+	}
+	return NewNotificationService(repo, email, webhook, nil, cfg)
+}
+
+func TestNotificationService_QuotaThresholdsDedupPerWindow(t *testing.T) {
+	repo := newNotificationRepoStub()
+	email := &notificationEmailStub{}
+	svc := newNotificationTestService(repo, email, nil)
+
+	windowStart := time.Now().Add(-time.Hour)
+	limit := 10.0
+	candidate := SubscriptionNotificationCandidate{
+		NotificationRecipient: NotificationRecipient{UserID: 1, Email: "a@example.com", EmailEnabled: true},
+		SubscriptionID:        5,
+		GroupName:             "Pro",
+		DailyWindowStart:      &windowStart,
+		DailyUsageUSD:         8.5,
+		DailyLimitUSD:         &limit,
+	}
+	repo.quota = []SubscriptionNotificationCandidate{candidate}
+
+	require.Equal(t, 1, svc.scanQuotaUsage(context.Background()))
+	require.Equal(t, 0, svc.scanQuotaUsage(context.Background()), "same window must not notify twice")
+
+	repo.quota[0].DailyUsageUSD = 10
+	require.Equal(t, 1, svc.scanQuotaUsage(context.Background()), "100% is a separate notification")
+	require.Equal(t, map[string]int{NotificationTypeQuotaWarning: 1, NotificationTypeQuotaExhausted: 1}, repo.types())
+
+	// 新窗口重新提醒
+	nextWindow := time.Now()
+	repo.quota[0].DailyWindowStart = &nextWindow
+	repo.quota[0].DailyUsageUSD = 9
+	require.Equal(t, 1, svc.scanQuotaUsage(context.Background()))
+	require.Len(t, email.sent, 3)
+}
+
+func TestNotificationService_QuotaIgnoresExpiredOrUnlimitedWindows(t *testing.T) {
+	expiredStart := time.Now().Add(-25 * time.Hour)
+	weeklyStart := time.Now().Add(-time.Hour)
+	limit := 10.0
+	c := &SubscriptionNotificationCandidate{
+		DailyWindowStart:  &expiredStart,
+		DailyUsageUSD:     50,
+		DailyLimitUSD:     &limit,
+		WeeklyWindowStart: &weeklyStart,
+		WeeklyUsageUSD:    50,
+	}
+	require.Empty(t, subscriptionQuotaWindows(c))
+}
+
+func TestNotificationService_DeliversToEmailAndWebhook(t *testing.T) {
+	repo := newNotificationRepoStub()
+	email := &notificationEmailStub{}
+	webhook := &notificationWebhookStub{err: errors.New("boom")}
+	svc := newNotificationTestService(repo, email, webhook)
+
+	repo.lowBalance = []LowBalanceCandidate{{
+		NotificationRecipient: NotificationRecipient{UserID: 1, Email: "a@example.com", EmailEnabled: true, WebhookURL: "https://hooks.example.com/x", WebhookSecret: "s"},
+		Balance:               0.5,
+		Threshold:             1,
+	}}
+	require.Equal(t, 1, svc.scanLowBalance(context.Background()))
+	require.Len(t, email.sent, 1)
+	require.Len(t, webhook.payloads, 1)
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(webhook.payloads[0], &payload))
+	require.Equal(t, NotificationTypeLowBalance, payload["type"])
+	require.Contains(t, repo.deliveries[1], "webhook: boom")
+}
+
+func TestNotificationService_RespectsEmailOptOut(t *testing.T) {
+	repo := newNotificationRepoStub()
+	email := &notificationEmailStub{}
+	svc := newNotificationTestService(repo, email, nil)
+
+	repo.apiKeys = []DisabledAPIKeyCandidate{{
+		NotificationRecipient: NotificationRecipient{UserID: 1, Email: "a@example.com", EmailEnabled: false},
+		APIKeyID:              3,
+		APIKeyName:            "prod",
+		UpdatedAt:             time.Now(),
+	}}
+	require.Equal(t, 1, svc.scanDisabledAPIKeys(context.Background()))
+	require.Empty(t, email.sent)
+	require.Equal(t, 1, repo.types()[NotificationTypeAPIKeyDisabled])
+}
+
+func TestNotificationService_UpdateSettings(t *testing.T) {
+	repo := newNotificationRepoStub()
+	svc := newNotificationTestService(repo, nil, nil)
+	ctx := context.Background()
+
+	settings, err := svc.GetSettings(ctx, 1)
+	require.NoError(t, err)
+	require.True(t, settings.EmailEnabled)
+	require.InDelta(t, 1, *settings.LowBalanceThreshold, 1e-9)
+	require.Equal(t, 3, *settings.ExpiryReminderDays)
+
+	threshold := 5.0
+	url := "https://8.8.8.8/hook"
+	settings, err = svc.UpdateSettings(ctx, 1, &NotificationSettingsInput{WebhookURL: &url, LowBalanceThreshold: &threshold})
+	require.NoError(t, err)
+	require.NotEmpty(t, settings.WebhookSecret)
+	require.InDelta(t, 5, *settings.LowBalanceThreshold, 1e-9)
+	require.Nil(t, repo.settings[1].ExpiryReminderDays, "untouched defaults stay unset")
+	secret := settings.WebhookSecret
+
+	settings, err = svc.UpdateSettings(ctx, 1, &NotificationSettingsInput{RegenerateWebhookSecret: true})
+	require.NoError(t, err)
+	require.NotEqual(t, secret, settings.WebhookSecret)
+
+	private := "https://127.0.0.1/hook"
+	_, err = svc.UpdateSettings(ctx, 1, &NotificationSettingsInput{WebhookURL: &private})
+	require.ErrorIs(t, err, ErrNotificationWebhookInvalid)
+
+	insecure := "http://8.8.8.8/hook"
+	_, err = svc.UpdateSettings(ctx, 1, &NotificationSettingsInput{WebhookURL: &insecure})
+	require.ErrorIs(t, err, ErrNotificationWebhookInvalid)
+
+	empty := ""
+	settings, err = svc.UpdateSettings(ctx, 1, &NotificationSettingsInput{WebhookURL: &empty})
+	require.NoError(t, err)
+	require.Empty(t, settings.WebhookURL)
+	require.Empty(t, settings.WebhookSecret)
+}
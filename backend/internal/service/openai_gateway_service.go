@@ -66,6 +66,24 @@ type OpenAIUsage struct {
 	OutputTokens             int `json:"output_tokens"`
 	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
 	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+	// 音频 token（Realtime），分别包含在 InputTokens/OutputTokens/CacheReadInputTokens 中
+	AudioInputTokens          int `json:"audio_input_tokens,omitempty"`
+	AudioOutputTokens         int `json:"audio_output_tokens,omitempty"`
+	AudioCacheReadInputTokens int `json:"audio_cache_read_input_tokens,omitempty"`
+}
+
+// billingTokens 转换为计费 token：input_tokens 包含缓存读取部分，需扣除后按输入价格计费；
+// 音频部分同样扣除缓存读取的音频 token
+func (u OpenAIUsage) billingTokens() UsageTokens {
+	return UsageTokens{
+		InputTokens:          max(u.InputTokens-u.CacheReadInputTokens, 0),
+		OutputTokens:         u.OutputTokens,
+		CacheCreationTokens:  u.CacheCreationInputTokens,
+		CacheReadTokens:      u.CacheReadInputTokens,
+		AudioInputTokens:     max(u.AudioInputTokens-u.AudioCacheReadInputTokens, 0),
+		AudioOutputTokens:    u.AudioOutputTokens,
+		AudioCacheReadTokens: u.AudioCacheReadInputTokens,
+	}
 }
 
 // OpenAIForwardResult represents the result of forwarding
@@ -80,21 +98,24 @@ type OpenAIForwardResult struct {
 
 // OpenAIGatewayService handles OpenAI API gateway operations
 type OpenAIGatewayService struct {
-	accountRepo         AccountRepository
-	usageLogRepo        UsageLogRepository
-	userRepo            UserRepository
-	userSubRepo         UserSubscriptionRepository
-	cache               GatewayCache
-	cfg                 *config.Config
-	schedulerSnapshot   *SchedulerSnapshotService
-	concurrencyService  *ConcurrencyService
-	billingService      *BillingService
-	rateLimitService    *RateLimitService
-	billingCacheService *BillingCacheService
-	httpUpstream        HTTPUpstream
-	deferredService     *DeferredService
-	openAITokenProvider *OpenAITokenProvider
-	toolCorrector       *CodexToolCorrector
+	accountRepo           AccountRepository
+	usageLogRepo          UsageLogRepository
+	userRepo              UserRepository
+	userSubRepo           UserSubscriptionRepository
+	cache                 GatewayCache
+	cfg                   *config.Config
+	schedulerSnapshot     *SchedulerSnapshotService
+	concurrencyService    *ConcurrencyService
+	billingService        *BillingService
+	rateLimitService      *RateLimitService
+	billingCacheService   *BillingCacheService
+	httpUpstream          HTTPUpstream
+	deferredService       *DeferredService
+	openAITokenProvider   *OpenAITokenProvider
+	toolCorrector         *CodexToolCorrector
+	resellerService       *ResellerService
+	billingOutboxService  *BillingOutboxService
+	rateMultiplierService *RateMultiplierService
 }
 
 // NewOpenAIGatewayService creates a new OpenAIGatewayService
@@ -113,23 +134,29 @@ func NewOpenAIGatewayService(
 	httpUpstream HTTPUpstream,
 	deferredService *DeferredService,
 	openAITokenProvider *OpenAITokenProvider,
+	resellerService *ResellerService,
+	billingOutboxService *BillingOutboxService,
+	rateMultiplierService *RateMultiplierService,
 ) *OpenAIGatewayService {
 	return &OpenAIGatewayService{
-		accountRepo:         accountRepo,
-		usageLogRepo:        usageLogRepo,
-		userRepo:            userRepo,
-		userSubRepo:         userSubRepo,
-		cache:               cache,
-		cfg:                 cfg,
-		schedulerSnapshot:   schedulerSnapshot,
-		concurrencyService:  concurrencyService,
-		billingService:      billingService,
-		rateLimitService:    rateLimitService,
-		billingCacheService: billingCacheService,
-		httpUpstream:        httpUpstream,
-		deferredService:     deferredService,
-		openAITokenProvider: openAITokenProvider,
-		toolCorrector:       NewCodexToolCorrector(),
+		accountRepo:           accountRepo,
+		usageLogRepo:          usageLogRepo,
+		userRepo:              userRepo,
+		userSubRepo:           userSubRepo,
+		cache:                 cache,
+		cfg:                   cfg,
+		schedulerSnapshot:     schedulerSnapshot,
+		concurrencyService:    concurrencyService,
+		billingService:        billingService,
+		rateLimitService:      rateLimitService,
+		billingCacheService:   billingCacheService,
+		httpUpstream:          httpUpstream,
+		deferredService:       deferredService,
+		openAITokenProvider:   openAITokenProvider,
+		toolCorrector:         NewCodexToolCorrector(),
+		resellerService:       resellerService,
+		billingOutboxService:  billingOutboxService,
+		rateMultiplierService: rateMultiplierService,
 	}
 }
 
@@ -360,6 +387,7 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 				Account:     account,
 				Acquired:    true,
 				ReleaseFunc: result.ReleaseFunc,
+				RefreshFunc: result.RefreshFunc,
 			}, nil
 		}
 		if stickyAccountID > 0 && stickyAccountID == account.ID && s.concurrencyService != nil {
@@ -422,6 +450,7 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 							Account:     account,
 							Acquired:    true,
 							ReleaseFunc: result.ReleaseFunc,
+							RefreshFunc: result.RefreshFunc,
 						}, nil
 					}
 
@@ -487,6 +516,7 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 					Account:     acc,
 					Acquired:    true,
 					ReleaseFunc: result.ReleaseFunc,
+					RefreshFunc: result.RefreshFunc,
 				}, nil
 			}
 		}
@@ -540,6 +570,7 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 						Account:     item.account,
 						Acquired:    true,
 						ReleaseFunc: result.ReleaseFunc,
+						RefreshFunc: result.RefreshFunc,
 					}, nil
 				}
 			}
@@ -599,7 +630,7 @@ func (s *OpenAIGatewayService) getSchedulableAccount(ctx context.Context, accoun
 
 func (s *OpenAIGatewayService) schedulingConfig() config.GatewaySchedulingConfig {
 	if s.cfg != nil {
-		return s.cfg.Gateway.Scheduling
+		return s.cfg.Current().Gateway.Scheduling
 	}
 	return config.GatewaySchedulingConfig{
 		StickySessionMaxWaiting:  3,
@@ -656,6 +687,7 @@ func (s *OpenAIGatewayService) handleFailoverSideEffects(ctx context.Context, re
 
 // Forward forwards request to OpenAI API
 func (s *OpenAIGatewayService) Forward(ctx context.Context, c *gin.Context, account *Account, body []byte) (*OpenAIForwardResult, error) {
+	cfg := s.cfg.Current()
 	startTime := time.Now()
 
 	// Parse request body once (avoid multiple parse/serialize cycles)
@@ -824,8 +856,8 @@ func (s *OpenAIGatewayService) Forward(ctx context.Context, c *gin.Context, acco
 			upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(respBody))
 			upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 			upstreamDetail := ""
-			if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-				maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+			if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+				maxBytes := cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 				if maxBytes <= 0 {
 					maxBytes = 2048
 				}
@@ -962,13 +994,14 @@ func (s *OpenAIGatewayService) buildUpstreamRequest(ctx context.Context, c *gin.
 }
 
 func (s *OpenAIGatewayService) handleErrorResponse(ctx context.Context, resp *http.Response, c *gin.Context, account *Account) (*OpenAIForwardResult, error) {
+	cfg := s.cfg.Current()
 	body, _ := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
 
 	upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(body))
 	upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 	upstreamDetail := ""
-	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-		maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+	if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
+		maxBytes := cfg.Gateway.LogUpstreamErrorBodyMaxBytes
 		if maxBytes <= 0 {
 			maxBytes = 2048
 		}
@@ -976,14 +1009,14 @@ func (s *OpenAIGatewayService) handleErrorResponse(ctx context.Context, resp *ht
 	}
 	setOpsUpstreamError(c, resp.StatusCode, upstreamMsg, upstreamDetail)
 
-	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
+	if cfg != nil && cfg.Gateway.LogUpstreamErrorBody {
 		log.Printf(
 			"OpenAI upstream error %d (account=%d platform=%s type=%s): %s",
 			resp.StatusCode,
 			account.ID,
 			account.Platform,
 			account.Type,
-			truncateForLog(body, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
+			truncateForLog(body, cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
 		)
 	}
 
@@ -1081,6 +1114,7 @@ type openaiStreamingResult struct {
 }
 
 func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp *http.Response, c *gin.Context, account *Account, startTime time.Time, originalModel, mappedModel string) (*openaiStreamingResult, error) {
+	cfg := s.cfg.Current()
 	if s.cfg != nil {
 		responseheaders.WriteFilteredHeaders(c.Writer.Header(), resp.Header, s.cfg.Security.ResponseHeaders)
 	}
@@ -1106,8 +1140,8 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 	var firstTokenMs *int
 	scanner := bufio.NewScanner(resp.Body)
 	maxLineSize := defaultMaxLineSize
-	if s.cfg != nil && s.cfg.Gateway.MaxLineSize > 0 {
-		maxLineSize = s.cfg.Gateway.MaxLineSize
+	if cfg != nil && cfg.Gateway.MaxLineSize > 0 {
+		maxLineSize = cfg.Gateway.MaxLineSize
 	}
 	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
 
@@ -1143,8 +1177,8 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 	defer close(done)
 
 	streamInterval := time.Duration(0)
-	if s.cfg != nil && s.cfg.Gateway.StreamDataIntervalTimeout > 0 {
-		streamInterval = time.Duration(s.cfg.Gateway.StreamDataIntervalTimeout) * time.Second
+	if cfg != nil && cfg.Gateway.StreamDataIntervalTimeout > 0 {
+		streamInterval = time.Duration(cfg.Gateway.StreamDataIntervalTimeout) * time.Second
 	}
 	// 仅监控上游数据间隔超时，不被下游写入阻塞影响
 	var intervalTicker *time.Ticker
@@ -1158,8 +1192,8 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 	}
 
 	keepaliveInterval := time.Duration(0)
-	if s.cfg != nil && s.cfg.Gateway.StreamKeepaliveInterval > 0 {
-		keepaliveInterval = time.Duration(s.cfg.Gateway.StreamKeepaliveInterval) * time.Second
+	if cfg != nil && cfg.Gateway.StreamKeepaliveInterval > 0 {
+		keepaliveInterval = time.Duration(cfg.Gateway.StreamKeepaliveInterval) * time.Second
 	}
 	// 下游 keepalive 仅用于防止代理空闲断开
 	var keepaliveTicker *time.Ticker
@@ -1547,8 +1581,15 @@ type OpenAIRecordUsageInput struct {
 	User         *User
 	Account      *Account
 	Subscription *UserSubscription
-	UserAgent    string // 请求的 User-Agent
-	IPAddress    string // 请求的客户端 IP 地址
+	UserAgent    string            // 请求的 User-Agent
+	IPAddress    string            // 请求的客户端 IP 地址
+	Tags         map[string]string // 已校验的成本归属标签
+	CostHold     *CostHold         // 可选：本次请求的费用预扣，扣费未能同步完成时保留至过期
+}
+
+// CheckModelPricing 未知价格策略为 block 时拒绝没有任何价格的模型
+func (s *OpenAIGatewayService) CheckModelPricing(model string, groupID *int64) error {
+	return s.billingService.CheckModelPriced(model, groupID)
 }
 
 // RecordUsage records usage and deducts balance
@@ -1567,20 +1608,17 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 	}
 
 	// Calculate cost
-	tokens := UsageTokens{
-		InputTokens:         actualInputTokens,
-		OutputTokens:        result.Usage.OutputTokens,
-		CacheCreationTokens: result.Usage.CacheCreationInputTokens,
-		CacheReadTokens:     result.Usage.CacheReadInputTokens,
-	}
+	tokens := result.Usage.billingTokens()
 
-	// Get rate multiplier
-	multiplier := s.cfg.Default.RateMultiplier
-	if apiKey.GroupID != nil && apiKey.Group != nil {
-		multiplier = apiKey.Group.RateMultiplier
+	// Get rate multiplier (user / API key overrides and volume discounts)
+	var group *Group
+	if apiKey.GroupID != nil {
+		group = apiKey.Group
 	}
+	rate := s.rateMultiplierService.Resolve(ctx, apiKey, group)
+	multiplier := rate.Multiplier
 
-	cost, err := s.billingService.CalculateCost(result.Model, tokens, multiplier)
+	cost, err := s.billingService.CalculateCostForGroup(result.Model, apiKey.GroupID, tokens, multiplier)
 	if err != nil {
 		cost = &CostBreakdown{ActualCost: 0}
 	}
@@ -1592,6 +1630,20 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		billingType = BillingTypeSubscription
 	}
 
+	// 分销下级用户：余额计费在分组倍率之上叠加各级分销商加价（订阅计费不加价）
+	var resellerQuote *ResellerQuote
+	baseCost := cost.ActualCost
+	if !isSubscriptionBilling {
+		if quotePayer, err := ResolveBillingPayer(apiKey, user); err == nil {
+			resellerQuote = s.resellerService.Quote(ctx, quotePayer)
+		}
+	}
+	if resellerQuote != nil {
+		markup := resellerQuote.Markup()
+		cost.ActualCost *= markup
+		multiplier *= markup
+	}
+
 	// Create usage log
 	durationMs := int(result.Duration.Milliseconds())
 	accountRateMultiplier := account.BillingRateMultiplier()
@@ -1605,6 +1657,7 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		OutputTokens:          result.Usage.OutputTokens,
 		CacheCreationTokens:   result.Usage.CacheCreationInputTokens,
 		CacheReadTokens:       result.Usage.CacheReadInputTokens,
+		CacheCreation5mTokens: result.Usage.CacheCreationInputTokens,
 		InputCost:             cost.InputCost,
 		OutputCost:            cost.OutputCost,
 		CacheCreationCost:     cost.CacheCreationCost,
@@ -1617,6 +1670,8 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		Stream:                result.Stream,
 		DurationMs:            &durationMs,
 		FirstTokenMs:          result.FirstTokenMs,
+		RateRule:              &rate.Rule,
+		Tags:                  input.Tags,
 		CreatedAt:             time.Now(),
 	}
 
@@ -1636,29 +1691,27 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 	if subscription != nil {
 		usageLog.SubscriptionID = &subscription.ID
 	}
+	usageLog.OrganizationID = apiKey.OrganizationID
 
-	inserted, err := s.usageLogRepo.Create(ctx, usageLog)
 	if s.cfg != nil && s.cfg.RunMode == config.RunModeSimple {
+		_, _ = s.usageLogRepo.Create(ctx, usageLog)
 		log.Printf("[SIMPLE MODE] Usage recorded (not billed): user=%d, tokens=%d", usageLog.UserID, usageLog.TotalTokens())
 		s.deferredService.ScheduleLastUsedUpdate(account.ID)
 		return nil
 	}
 
-	shouldBill := inserted || err != nil
-
-	// Deduct based on billing type
-	if isSubscriptionBilling {
-		if shouldBill && cost.TotalCost > 0 {
-			_ = s.userSubRepo.IncrementUsage(ctx, subscription.ID, cost.TotalCost)
-			s.billingCacheService.QueueUpdateSubscriptionUsage(user.ID, *apiKey.GroupID, cost.TotalCost)
-		}
-	} else {
-		if shouldBill && cost.ActualCost > 0 {
-			_ = s.userRepo.DeductBalance(ctx, user.ID, cost.ActualCost)
-			s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
-		}
+	// 组织 Key 由组织付费账户扣费
+	payer, payerErr := ResolveBillingPayer(apiKey, user)
+	if payerErr != nil {
+		_, _ = s.usageLogRepo.Create(ctx, usageLog)
+		log.Printf("ALERT: resolve billing payer failed for api key %d (request %s): %v", apiKey.ID, result.RequestID, payerErr)
+		s.deferredService.ScheduleLastUsedUpdate(account.ID)
+		return nil
 	}
 
+	// Persist usage log and billing event together; the outbox applies the charge with retries
+	s.billingOutboxService.RecordUsage(ctx, usageLog, NewUsageBillingEvent(usageLog, apiKey, payer, cost, baseCost, resellerQuote), input.CostHold)
+
 	// Schedule batch update for account last_used_at
 	s.deferredService.ScheduleLastUsedUpdate(account.ID)
 
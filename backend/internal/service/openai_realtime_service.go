@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/gorilla/websocket"
+	"github.com/tidwall/gjson"
+)
+
+// OpenAIRealtimeConn Realtime 会话两端的 WebSocket 连接（*websocket.Conn 满足该接口）
+type OpenAIRealtimeConn interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	Close() error
+}
+
+// OpenAIRealtimeDialer 建立到上游的 Realtime WebSocket 连接。
+// 握手被拒绝时返回上游的 *http.Response（Body 已预读，可能为 nil），用于失败切换与限流处理。
+type OpenAIRealtimeDialer interface {
+	Dial(ctx context.Context, targetURL string, header http.Header, proxyURL string) (OpenAIRealtimeConn, *http.Response, error)
+}
+
+// OpenAIRealtimeService OpenAI Realtime（/v1/realtime）WebSocket 代理。
+//
+// 仅调度 OpenAI API Key 账号（ChatGPT OAuth 账号不提供 Realtime 接口），
+// 会话期间双向透传帧，并从 response.done 事件提取用量交由 OpenAIGatewayService.RecordUsage 计费。
+type OpenAIRealtimeService struct {
+	gateway *OpenAIGatewayService
+	dialer  OpenAIRealtimeDialer
+	cfg     *config.Config
+}
+
+// NewOpenAIRealtimeService 创建 Realtime 代理服务
+func NewOpenAIRealtimeService(gateway *OpenAIGatewayService, dialer OpenAIRealtimeDialer, cfg *config.Config) *OpenAIRealtimeService {
+	return &OpenAIRealtimeService{gateway: gateway, dialer: dialer, cfg: cfg}
+}
+
+// Enabled 是否开放 /v1/realtime
+func (s *OpenAIRealtimeService) Enabled() bool {
+	return s != nil && s.dialer != nil && s.cfg != nil && s.cfg.Current().Gateway.Realtime.Enabled
+}
+
+// Config 返回 Realtime 会话配置
+func (s *OpenAIRealtimeService) Config() config.GatewayRealtimeConfig {
+	if s == nil || s.cfg == nil {
+		return config.GatewayRealtimeConfig{}
+	}
+	return s.cfg.Current().Gateway.Realtime
+}
+
+// SelectAccount 选择支持 Realtime 的账号：在调度器结果中排除非 API Key 账号。
+func (s *OpenAIRealtimeService) SelectAccount(ctx context.Context, groupID *int64, model string, excludedIDs map[int64]struct{}) (*AccountSelectionResult, error) {
+	accounts, err := s.gateway.listSchedulableAccounts(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	excluded := make(map[int64]struct{}, len(excludedIDs))
+	for id := range excludedIDs {
+		excluded[id] = struct{}{}
+	}
+	for i := range accounts {
+		if !accounts[i].IsOpenAIApiKey() {
+			excluded[accounts[i].ID] = struct{}{}
+		}
+	}
+	return s.gateway.SelectAccountWithLoadAwareness(ctx, groupID, "", model, excluded)
+}
+
+// Connect 使用账号凭证连接上游 Realtime 接口。
+// 握手返回可切换的状态码时，应用限流副作用并返回 *UpstreamFailoverError。
+func (s *OpenAIRealtimeService) Connect(ctx context.Context, account *Account, model string) (OpenAIRealtimeConn, error) {
+	if !account.IsOpenAIApiKey() {
+		return nil, fmt.Errorf("account %d does not support realtime", account.ID)
+	}
+	token, _, err := s.gateway.GetAccessToken(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	baseURL, err := s.gateway.validateUpstreamBaseURL(account.GetOpenAIBaseURL())
+	if err != nil {
+		return nil, err
+	}
+	targetURL, err := buildOpenAIRealtimeURL(baseURL, account.GetMappedModel(model))
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+	header.Set("OpenAI-Beta", "realtime=v1")
+	proxyURL := ""
+	if account.ProxyID != nil && account.Proxy != nil {
+		proxyURL = account.Proxy.URL()
+	}
+
+	conn, resp, err := s.dialer.Dial(ctx, targetURL, header, proxyURL)
+	if err == nil {
+		return conn, nil
+	}
+	if resp != nil {
+		defer func() { _ = resp.Body.Close() }()
+		if s.gateway.shouldFailoverUpstreamError(resp.StatusCode) {
+			s.gateway.handleFailoverSideEffects(ctx, resp, account)
+			return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode}
+		}
+		return nil, fmt.Errorf("upstream realtime handshake failed: status %d", resp.StatusCode)
+	}
+	return nil, fmt.Errorf("upstream realtime dial failed: %w", err)
+}
+
+// Relay 双向透传帧直到任一端断开或 ctx 结束。
+// 每个 response.done 事件解析出的用量通过 onResult 回调（在上游读取协程中同步调用）。
+func (s *OpenAIRealtimeService) Relay(ctx context.Context, client, upstream OpenAIRealtimeConn, model string, onResult func(*OpenAIForwardResult)) error {
+	tracker := newOpenAIRealtimeUsageTracker(model)
+	errCh := make(chan error, 2)
+
+	go func() {
+		errCh <- pipeRealtimeFrames(upstream, client, nil)
+	}()
+	go func() {
+		errCh <- pipeRealtimeFrames(client, upstream, func(data []byte) {
+			if result := tracker.Observe(data); result != nil && onResult != nil {
+				onResult(result)
+			}
+		})
+	}()
+
+	var relayErr error
+	pending := 2
+	select {
+	case relayErr = <-errCh:
+		pending--
+	case <-ctx.Done():
+		relayErr = ctx.Err()
+	}
+	// 关闭两端使剩余协程退出；其错误仅由关闭导致，忽略
+	_ = client.Close()
+	_ = upstream.Close()
+	for ; pending > 0; pending-- {
+		<-errCh
+	}
+
+	var closeErr *websocket.CloseError
+	if errors.As(relayErr, &closeErr) && (closeErr.Code == websocket.CloseNormalClosure || closeErr.Code == websocket.CloseGoingAway) {
+		return nil
+	}
+	return relayErr
+}
+
+// pipeRealtimeFrames 从 src 读取帧写入 dst；收到关闭帧时转发给对端后返回。
+func pipeRealtimeFrames(dst, src OpenAIRealtimeConn, observe func([]byte)) error {
+	for {
+		messageType, data, err := src.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				code := closeErr.Code
+				if code == websocket.CloseNoStatusReceived || code == websocket.CloseAbnormalClosure {
+					code = websocket.CloseNormalClosure
+				}
+				_ = dst.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, closeErr.Text))
+			}
+			return err
+		}
+		if messageType == websocket.TextMessage && observe != nil {
+			observe(data)
+		}
+		if err := dst.WriteMessage(messageType, data); err != nil {
+			return err
+		}
+	}
+}
+
+// buildOpenAIRealtimeURL 将 HTTP(S) base_url 转换为 Realtime WebSocket 地址。
+// base_url 无路径时使用 /v1/realtime，否则视为已包含版本前缀（与 /responses 拼接规则一致）。
+func buildOpenAIRealtimeURL(baseURL, model string) (string, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return "", fmt.Errorf("invalid base_url: %w", err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "https", "wss":
+		u.Scheme = "wss"
+	case "http", "ws":
+		u.Scheme = "ws"
+	default:
+		return "", fmt.Errorf("unsupported base_url scheme: %s", u.Scheme)
+	}
+	if u.Path == "" {
+		u.Path = "/v1/realtime"
+	} else {
+		u.Path += "/realtime"
+	}
+	u.RawQuery = url.Values{"model": []string{model}}.Encode()
+	return u.String(), nil
+}
+
+// openAIRealtimeUsageTracker 从上游事件流中提取每个 response 的用量与时延
+type openAIRealtimeUsageTracker struct {
+	model string
+	now   func() time.Time
+
+	mu         sync.Mutex
+	started    time.Time
+	firstToken *int
+}
+
+func newOpenAIRealtimeUsageTracker(model string) *openAIRealtimeUsageTracker {
+	return &openAIRealtimeUsageTracker{model: model, now: time.Now}
+}
+
+// Observe 处理一个上游文本帧；遇到带用量的 response.done 时返回计费结果。
+func (t *openAIRealtimeUsageTracker) Observe(data []byte) *OpenAIForwardResult {
+	eventType := gjson.GetBytes(data, "type").String()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch {
+	case eventType == "response.created":
+		t.started = t.now()
+		t.firstToken = nil
+		return nil
+	case strings.HasSuffix(eventType, ".delta"):
+		if t.firstToken == nil && !t.started.IsZero() {
+			ms := int(t.now().Sub(t.started).Milliseconds())
+			t.firstToken = &ms
+		}
+		return nil
+	case eventType != "response.done":
+		return nil
+	}
+
+	usage := gjson.GetBytes(data, "response.usage")
+	if !usage.Exists() {
+		return nil
+	}
+	result := &OpenAIForwardResult{
+		RequestID: gjson.GetBytes(data, "response.id").String(),
+		Usage: OpenAIUsage{
+			InputTokens:               int(usage.Get("input_tokens").Int()),
+			OutputTokens:              int(usage.Get("output_tokens").Int()),
+			CacheReadInputTokens:      int(usage.Get("input_token_details.cached_tokens").Int()),
+			AudioInputTokens:          int(usage.Get("input_token_details.audio_tokens").Int()),
+			AudioOutputTokens:         int(usage.Get("output_token_details.audio_tokens").Int()),
+			AudioCacheReadInputTokens: int(usage.Get("input_token_details.cached_tokens_details.audio_tokens").Int()),
+		},
+		Model:        t.model,
+		Stream:       true,
+		FirstTokenMs: t.firstToken,
+	}
+	if result.RequestID == "" {
+		result.RequestID = "realtime-" + generateRequestID()
+	}
+	if !t.started.IsZero() {
+		result.Duration = t.now().Sub(t.started)
+	}
+	t.started = time.Time{}
+	t.firstToken = nil
+	if result.Usage.InputTokens == 0 && result.Usage.OutputTokens == 0 {
+		return nil
+	}
+	return result
+}
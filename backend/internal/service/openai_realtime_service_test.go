@@ -0,0 +1,195 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+type realtimeFrame struct {
+	messageType int
+	data        []byte
+}
+
+// fakeRealtimeConn 按顺序返回预置帧，读完后阻塞直到被关闭或收到 closeErr
+type fakeRealtimeConn struct {
+	inbound chan realtimeFrame
+	closed  chan struct{}
+	once    sync.Once
+
+	mu      sync.Mutex
+	written []realtimeFrame
+	onWrite func()
+}
+
+func newFakeRealtimeConn(frames ...realtimeFrame) *fakeRealtimeConn {
+	c := &fakeRealtimeConn{inbound: make(chan realtimeFrame, len(frames)+2), closed: make(chan struct{})}
+	for _, f := range frames {
+		c.inbound <- f
+	}
+	return c
+}
+
+func (c *fakeRealtimeConn) ReadMessage() (int, []byte, error) {
+	select {
+	case f, ok := <-c.inbound:
+		if !ok {
+			return 0, nil, &websocket.CloseError{Code: websocket.CloseNormalClosure}
+		}
+		return f.messageType, f.data, nil
+	case <-c.closed:
+		return 0, nil, errors.New("use of closed connection")
+	}
+}
+
+func (c *fakeRealtimeConn) WriteMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.written = append(c.written, realtimeFrame{messageType: messageType, data: data})
+	if c.onWrite != nil {
+		c.onWrite()
+		c.onWrite = nil
+	}
+	return nil
+}
+
+func (c *fakeRealtimeConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *fakeRealtimeConn) frames() []realtimeFrame {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]realtimeFrame(nil), c.written...)
+}
+
+func TestBuildOpenAIRealtimeURL(t *testing.T) {
+	got, err := buildOpenAIRealtimeURL("https://api.openai.com", "gpt-4o-realtime-preview")
+	require.NoError(t, err)
+	require.Equal(t, "wss://api.openai.com/v1/realtime?model=gpt-4o-realtime-preview", got)
+
+	got, err = buildOpenAIRealtimeURL("http://relay.local:8080/openai/v1/", "m")
+	require.NoError(t, err)
+	require.Equal(t, "ws://relay.local:8080/openai/v1/realtime?model=m", got)
+
+	_, err = buildOpenAIRealtimeURL("ftp://example.com", "m")
+	require.Error(t, err)
+}
+
+func TestOpenAIRealtimeUsageTracker(t *testing.T) {
+	tracker := newOpenAIRealtimeUsageTracker("gpt-4o-realtime-preview")
+	base := time.Unix(1700000000, 0)
+	now := base
+	tracker.now = func() time.Time { return now }
+
+	require.Nil(t, tracker.Observe([]byte(`{"type":"response.created","response":{"id":"resp_1"}}`)))
+	now = base.Add(150 * time.Millisecond)
+	require.Nil(t, tracker.Observe([]byte(`{"type":"response.audio.delta","delta":"AAA"}`)))
+	now = base.Add(2 * time.Second)
+
+	result := tracker.Observe([]byte(`{"type":"response.done","response":{"id":"resp_1","usage":{"input_tokens":120,"output_tokens":45,"input_token_details":{"cached_tokens":64}}}}`))
+	require.NotNil(t, result)
+	require.Equal(t, "resp_1", result.RequestID)
+	require.Equal(t, OpenAIUsage{InputTokens: 120, OutputTokens: 45, CacheReadInputTokens: 64}, result.Usage)
+	require.Equal(t, "gpt-4o-realtime-preview", result.Model)
+	require.True(t, result.Stream)
+	require.Equal(t, 2*time.Second, result.Duration)
+	require.NotNil(t, result.FirstTokenMs)
+	require.Equal(t, 150, *result.FirstTokenMs)
+
+	// 取消的响应没有用量，不计费
+	require.Nil(t, tracker.Observe([]byte(`{"type":"response.done","response":{"id":"resp_2","status":"cancelled","usage":{"input_tokens":0,"output_tokens":0}}}`)))
+}
+
+// TestOpenAIRealtimeUsageTrackerAudioTokens 使用真实的 response.done 载荷，音频 token 按音频单价计费
+func TestOpenAIRealtimeUsageTrackerAudioTokens(t *testing.T) {
+	payload, err := os.ReadFile("testdata/openai_realtime_response_done.json")
+	require.NoError(t, err)
+
+	tracker := newOpenAIRealtimeUsageTracker("gpt-4o-realtime-preview")
+	result := tracker.Observe(payload)
+	require.NotNil(t, result)
+	require.Equal(t, "resp_001", result.RequestID)
+	require.Equal(t, OpenAIUsage{
+		InputTokens:               1270,
+		OutputTokens:              148,
+		CacheReadInputTokens:      384,
+		AudioInputTokens:          800,
+		AudioOutputTokens:         112,
+		AudioCacheReadInputTokens: 256,
+	}, result.Usage)
+
+	tokens := result.Usage.billingTokens()
+	require.Equal(t, UsageTokens{
+		InputTokens:          886,
+		OutputTokens:         148,
+		CacheReadTokens:      384,
+		AudioInputTokens:     544,
+		AudioOutputTokens:    112,
+		AudioCacheReadTokens: 256,
+	}, tokens)
+
+	cost := calculateCostWithPricing(&ModelPricing{
+		InputPricePerToken:          5e-6,
+		OutputPricePerToken:         2e-5,
+		CacheReadPricePerToken:      2.5e-6,
+		AudioInputPricePerToken:     4e-5,
+		AudioOutputPricePerToken:    8e-5,
+		AudioCacheReadPricePerToken: 2.5e-6,
+	}, tokens, 1.0)
+	require.InDelta(t, 342*5e-6+544*4e-5, cost.InputCost, 1e-12)
+	require.InDelta(t, 36*2e-5+112*8e-5, cost.OutputCost, 1e-12)
+	require.InDelta(t, 384*2.5e-6, cost.CacheReadCost, 1e-12)
+
+	// 未配置音频单价时按文本价格计费
+	textOnly := calculateCostWithPricing(&ModelPricing{
+		InputPricePerToken:  5e-6,
+		OutputPricePerToken: 2e-5,
+	}, tokens, 1.0)
+	require.InDelta(t, 886*5e-6, textOnly.InputCost, 1e-12)
+	require.InDelta(t, 148*2e-5, textOnly.OutputCost, 1e-12)
+}
+
+func TestOpenAIRealtimeRelayForwardsFramesAndReportsUsage(t *testing.T) {
+	client := newFakeRealtimeConn(realtimeFrame{websocket.TextMessage, []byte(`{"type":"response.create"}`)})
+	upstream := newFakeRealtimeConn()
+	// 上游收到 response.create 后才返回事件并正常关闭
+	upstream.onWrite = func() {
+		upstream.inbound <- realtimeFrame{websocket.TextMessage, []byte(`{"type":"response.created","response":{"id":"resp_9"}}`)}
+		upstream.inbound <- realtimeFrame{websocket.TextMessage, []byte(`{"type":"response.done","response":{"id":"resp_9","usage":{"input_tokens":10,"output_tokens":5}}}`)}
+		close(upstream.inbound)
+	}
+
+	var (
+		mu      sync.Mutex
+		results []*OpenAIForwardResult
+	)
+	svc := &OpenAIRealtimeService{}
+	err := svc.Relay(context.Background(), client, upstream, "gpt-4o-realtime-preview", func(r *OpenAIForwardResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, r)
+	})
+	require.NoError(t, err)
+
+	require.Len(t, results, 1)
+	require.Equal(t, "resp_9", results[0].RequestID)
+	require.Equal(t, 10, results[0].Usage.InputTokens)
+
+	toUpstream := upstream.frames()
+	require.Len(t, toUpstream, 1)
+	require.JSONEq(t, `{"type":"response.create"}`, string(toUpstream[0].data))
+
+	toClient := client.frames()
+	require.Len(t, toClient, 3)
+	require.Equal(t, websocket.CloseMessage, toClient[2].messageType)
+}
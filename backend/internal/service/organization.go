@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+)
+
+// 组织成员角色
+const (
+	OrganizationRoleOwner  = "owner"
+	OrganizationRoleAdmin  = "admin"
+	OrganizationRoleMember = "member"
+)
+
+var (
+	ErrOrganizationNotFound              = infraerrors.NotFound("ORGANIZATION_NOT_FOUND", "organization not found")
+	ErrOrganizationMemberNotFound        = infraerrors.NotFound("ORGANIZATION_MEMBER_NOT_FOUND", "organization member not found")
+	ErrOrganizationMemberExists          = infraerrors.Conflict("ORGANIZATION_MEMBER_EXISTS", "user is already a member of this organization")
+	ErrOrganizationForbidden             = infraerrors.Forbidden("ORGANIZATION_FORBIDDEN", "insufficient organization permissions")
+	ErrOrganizationDisabled              = infraerrors.Forbidden("ORGANIZATION_DISABLED", "organization is disabled")
+	ErrOrganizationMembershipRequired    = infraerrors.Forbidden("ORGANIZATION_MEMBERSHIP_REQUIRED", "api key owner is no longer a member of the organization")
+	ErrOrganizationOwnerImmutable        = infraerrors.BadRequest("ORGANIZATION_OWNER_IMMUTABLE", "the organization owner cannot be removed or demoted")
+	ErrOrganizationInvalidRole           = infraerrors.BadRequest("ORGANIZATION_INVALID_ROLE", "invalid organization role")
+	ErrOrganizationInvalidName           = infraerrors.BadRequest("ORGANIZATION_INVALID_NAME", "organization name must be 1-100 characters")
+	ErrOrganizationInvalidStatus         = infraerrors.BadRequest("ORGANIZATION_INVALID_STATUS", "invalid organization status")
+	ErrOrganizationInvalidAmount         = infraerrors.BadRequest("ORGANIZATION_INVALID_AMOUNT", "amount must be greater than 0")
+	ErrOrganizationSpendingLimitExceeded = infraerrors.TooManyRequests("ORGANIZATION_SPENDING_LIMIT_EXCEEDED", "monthly spending limit for this organization member exceeded")
+)
+
+// Organization 组织
+//
+// BillingUserID 指向组织付费账户（role=organization 的用户），组织余额与订阅均挂在该账户上。
+type Organization struct {
+	ID            int64
+	Name          string
+	OwnerUserID   int64
+	BillingUserID int64
+	Status        string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	// 查询时填充
+	Balance     float64
+	MemberCount int
+}
+
+func (o *Organization) IsActive() bool {
+	return o.Status == StatusActive
+}
+
+// OrganizationMember 组织成员
+type OrganizationMember struct {
+	ID             int64
+	OrganizationID int64
+	UserID         int64
+	Role           string
+	// SpendingLimitUSD 每月消费上限（nil 表示不限）
+	SpendingLimitUSD *float64
+	SpentUSD         float64
+	SpendPeriod      string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+
+	// 查询时填充
+	Email    string
+	Username string
+}
+
+// CurrentSpend 当前周期内的累计消费（跨月后归零）
+func (m *OrganizationMember) CurrentSpend(period string) float64 {
+	if m.SpendPeriod != period {
+		return 0
+	}
+	return m.SpentUSD
+}
+
+// CanManage 是否可管理组织成员与设置
+func (m *OrganizationMember) CanManage() bool {
+	return m.Role == OrganizationRoleOwner || m.Role == OrganizationRoleAdmin
+}
+
+// OrganizationMembership 当前用户所在的组织及其角色
+type OrganizationMembership struct {
+	Organization Organization
+	Role         string
+}
+
+// OrganizationListFilters 管理端组织列表筛选
+type OrganizationListFilters struct {
+	Status string
+	Search string
+}
+
+// APIKeyOrganization API Key 所属组织的计费上下文（认证时加载并进入认证缓存）
+type APIKeyOrganization struct {
+	ID     int64
+	Name   string
+	Status string
+	// MemberRole Key 所有者在组织中的角色，为空表示已不是成员
+	MemberRole       string
+	SpendingLimitUSD *float64
+	// Payer 组织付费账户
+	Payer *User
+}
+
+// IsOrganizationRoleValid 校验成员角色
+func IsOrganizationRoleValid(role string) bool {
+	switch role {
+	case OrganizationRoleOwner, OrganizationRoleAdmin, OrganizationRoleMember:
+		return true
+	}
+	return false
+}
+
+// OrganizationSpendPeriod 成员消费上限的统计周期（按系统时区的自然月）
+func OrganizationSpendPeriod(t time.Time) string {
+	return t.In(timezone.Location()).Format("2006-01")
+}
+
+// OrganizationRepository 组织与成员存储
+type OrganizationRepository interface {
+	// Create 创建组织付费账户、组织及所有者成员（需在事务中调用）
+	Create(ctx context.Context, org *Organization, billing *User, owner *OrganizationMember) error
+	GetByID(ctx context.Context, id int64) (*Organization, error)
+	Update(ctx context.Context, org *Organization) error
+	List(ctx context.Context, params pagination.PaginationParams, filters OrganizationListFilters) ([]Organization, *pagination.PaginationResult, error)
+	ListByMember(ctx context.Context, userID int64) ([]OrganizationMembership, error)
+
+	GetMember(ctx context.Context, orgID, userID int64) (*OrganizationMember, error)
+	ListMembers(ctx context.Context, orgID int64) ([]OrganizationMember, error)
+	// AddMember 成员已存在时返回 ErrOrganizationMemberExists
+	AddMember(ctx context.Context, member *OrganizationMember) error
+	UpdateMember(ctx context.Context, member *OrganizationMember) error
+	RemoveMember(ctx context.Context, orgID, userID int64) error
+
+	// AddMemberSpend 累加成员在 period 内的消费，跨周期时从本次费用重新计数
+	AddMemberSpend(ctx context.Context, orgID, userID int64, amount float64, period string) error
+	// GetMemberSpend 返回成员在 period 内的累计消费
+	GetMemberSpend(ctx context.Context, orgID, userID int64, period string) (float64, error)
+
+	// DeductBalanceIfSufficient 余额充足时原子扣减，余额不足返回 false
+	DeductBalanceIfSufficient(ctx context.Context, userID int64, amount float64) (bool, error)
+}
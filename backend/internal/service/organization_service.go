@@ -0,0 +1,501 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+)
+
+// organizationBillingEmailDomain 组织付费账户的占位邮箱域名（.invalid 保留域名，不会投递）
+const organizationBillingEmailDomain = "organization.invalid"
+
+// organizationBillingPasswordHash 不可匹配任何密码的占位哈希，付费账户不可登录
+const organizationBillingPasswordHash = "!"
+
+// AddOrganizationMemberInput 添加成员
+type AddOrganizationMemberInput struct {
+	Email            string
+	Role             string
+	SpendingLimitUSD *float64
+}
+
+// UpdateOrganizationMemberInput 更新成员（nil 字段不变）
+type UpdateOrganizationMemberInput struct {
+	Role               *string
+	SpendingLimitUSD   *float64
+	ClearSpendingLimit bool
+}
+
+// OrganizationUsageQuery 组织用量查询（MemberUserID 为 0 表示全部成员）
+type OrganizationUsageQuery struct {
+	MemberUserID int64
+	APIKeyID     int64
+	Model        string
+	StartTime    *time.Time
+	EndTime      *time.Time
+}
+
+// OrganizationService 组织管理：成员与角色、共享余额、成员消费上限与组织用量
+//
+// 组织余额与订阅挂在组织付费账户（role=organization 的用户）上，
+// 因此余额扣费、管理员充值、订阅分配与缓存均复用用户维度的现有逻辑；
+// 成员使用组织 Key 时由 BillingCacheService 从 Key 解析付费方。
+type OrganizationService struct {
+	orgRepo              OrganizationRepository
+	userRepo             UserRepository
+	usageRepo            UsageLogRepository
+	apiKeyService        *APIKeyService
+	billingCacheService  *BillingCacheService
+	authCacheInvalidator APIKeyAuthCacheInvalidator
+	entClient            *dbent.Client
+}
+
+// NewOrganizationService 创建组织服务
+func NewOrganizationService(
+	orgRepo OrganizationRepository,
+	userRepo UserRepository,
+	usageRepo UsageLogRepository,
+	apiKeyService *APIKeyService,
+	billingCacheService *BillingCacheService,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	entClient *dbent.Client,
+) *OrganizationService {
+	return &OrganizationService{
+		orgRepo:              orgRepo,
+		userRepo:             userRepo,
+		usageRepo:            usageRepo,
+		apiKeyService:        apiKeyService,
+		billingCacheService:  billingCacheService,
+		authCacheInvalidator: authCacheInvalidator,
+		entClient:            entClient,
+	}
+}
+
+// Create 创建组织，创建者成为所有者
+func (s *OrganizationService) Create(ctx context.Context, ownerID int64, name string) (*Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > 100 {
+		return nil, ErrOrganizationInvalidName
+	}
+	owner, err := s.userRepo.GetByID(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if owner.IsOrganizationAccount() {
+		return nil, ErrOrganizationForbidden
+	}
+
+	suffix, err := randomOrganizationSuffix()
+	if err != nil {
+		return nil, err
+	}
+	billing := &User{
+		Email:        fmt.Sprintf("org-%s@%s", suffix, organizationBillingEmailDomain),
+		Username:     name,
+		Notes:        fmt.Sprintf("organization billing account (owner user %d)", ownerID),
+		PasswordHash: organizationBillingPasswordHash,
+		Role:         RoleOrganization,
+		Status:       StatusActive,
+		Concurrency:  owner.Concurrency,
+	}
+	org := &Organization{
+		Name:        name,
+		OwnerUserID: ownerID,
+		Status:      StatusActive,
+	}
+	member := &OrganizationMember{
+		UserID: ownerID,
+		Role:   OrganizationRoleOwner,
+	}
+	if err := s.runInTx(ctx, func(txCtx context.Context) error {
+		return s.orgRepo.Create(txCtx, org, billing, member)
+	}); err != nil {
+		return nil, err
+	}
+	org.MemberCount = 1
+	return org, nil
+}
+
+// ListMine 返回用户所在的组织
+func (s *OrganizationService) ListMine(ctx context.Context, userID int64) ([]OrganizationMembership, error) {
+	return s.orgRepo.ListByMember(ctx, userID)
+}
+
+// Get 成员查看组织详情
+func (s *OrganizationService) Get(ctx context.Context, actorID, orgID int64) (*Organization, *OrganizationMember, error) {
+	actor, err := s.requireMember(ctx, orgID, actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return org, actor, nil
+}
+
+// Rename 所有者/管理员修改组织名称
+func (s *OrganizationService) Rename(ctx context.Context, actorID, orgID int64, name string) (*Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > 100 {
+		return nil, ErrOrganizationInvalidName
+	}
+	if _, err := s.requireManager(ctx, orgID, actorID); err != nil {
+		return nil, err
+	}
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	org.Name = name
+	if err := s.orgRepo.Update(ctx, org); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// ListMembers 成员列表（普通成员也可查看）
+func (s *OrganizationService) ListMembers(ctx context.Context, actorID, orgID int64) ([]OrganizationMember, error) {
+	if _, err := s.requireMember(ctx, orgID, actorID); err != nil {
+		return nil, err
+	}
+	return s.orgRepo.ListMembers(ctx, orgID)
+}
+
+// AddMember 所有者/管理员按邮箱添加已注册用户；仅所有者可添加管理员
+func (s *OrganizationService) AddMember(ctx context.Context, actorID, orgID int64, input *AddOrganizationMemberInput) (*OrganizationMember, error) {
+	actor, err := s.requireManager(ctx, orgID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	role := input.Role
+	if role == "" {
+		role = OrganizationRoleMember
+	}
+	if role == OrganizationRoleOwner || !IsOrganizationRoleValid(role) {
+		return nil, ErrOrganizationInvalidRole
+	}
+	if role == OrganizationRoleAdmin && actor.Role != OrganizationRoleOwner {
+		return nil, ErrOrganizationForbidden
+	}
+	if err := validateOrganizationSpendingLimit(input.SpendingLimitUSD); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, strings.TrimSpace(input.Email))
+	if err != nil {
+		return nil, err
+	}
+	if user.IsOrganizationAccount() || !user.IsActive() {
+		return nil, ErrUserNotFound
+	}
+
+	member := &OrganizationMember{
+		OrganizationID:   orgID,
+		UserID:           user.ID,
+		Role:             role,
+		SpendingLimitUSD: input.SpendingLimitUSD,
+		Email:            user.Email,
+		Username:         user.Username,
+	}
+	if err := s.orgRepo.AddMember(ctx, member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// UpdateMember 调整成员角色或消费上限
+//
+// 所有者不可被降级；管理员只能调整普通成员，且不能授予管理员角色。
+func (s *OrganizationService) UpdateMember(ctx context.Context, actorID, orgID, userID int64, input *UpdateOrganizationMemberInput) (*OrganizationMember, error) {
+	actor, err := s.requireManager(ctx, orgID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := s.orgRepo.GetMember(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOrganizationMemberManageable(actor, member); err != nil {
+		return nil, err
+	}
+
+	if input.Role != nil && *input.Role != member.Role {
+		role := *input.Role
+		if role == OrganizationRoleOwner || !IsOrganizationRoleValid(role) {
+			return nil, ErrOrganizationInvalidRole
+		}
+		if member.Role == OrganizationRoleOwner {
+			return nil, ErrOrganizationOwnerImmutable
+		}
+		if role == OrganizationRoleAdmin && actor.Role != OrganizationRoleOwner {
+			return nil, ErrOrganizationForbidden
+		}
+		member.Role = role
+	}
+	if input.ClearSpendingLimit {
+		member.SpendingLimitUSD = nil
+	} else if input.SpendingLimitUSD != nil {
+		if err := validateOrganizationSpendingLimit(input.SpendingLimitUSD); err != nil {
+			return nil, err
+		}
+		member.SpendingLimitUSD = input.SpendingLimitUSD
+	}
+
+	if err := s.orgRepo.UpdateMember(ctx, member); err != nil {
+		return nil, err
+	}
+	// 角色与上限进入成员 Key 的认证快照
+	s.invalidateMemberKeys(ctx, userID)
+	return member, nil
+}
+
+// RemoveMember 移除成员；成员可自行退出，所有者不可移除
+//
+// 被移除成员的组织 Key 保留但在认证时被拒绝。
+func (s *OrganizationService) RemoveMember(ctx context.Context, actorID, orgID, userID int64) error {
+	if actorID == userID {
+		member, err := s.requireMember(ctx, orgID, actorID)
+		if err != nil {
+			return err
+		}
+		if member.Role == OrganizationRoleOwner {
+			return ErrOrganizationOwnerImmutable
+		}
+	} else {
+		actor, err := s.requireManager(ctx, orgID, actorID)
+		if err != nil {
+			return err
+		}
+		member, err := s.orgRepo.GetMember(ctx, orgID, userID)
+		if err != nil {
+			return err
+		}
+		if err := checkOrganizationMemberManageable(actor, member); err != nil {
+			return err
+		}
+	}
+
+	if err := s.orgRepo.RemoveMember(ctx, orgID, userID); err != nil {
+		return err
+	}
+	s.invalidateMemberKeys(ctx, userID)
+	return nil
+}
+
+// Deposit 成员将个人余额划转到组织余额
+func (s *OrganizationService) Deposit(ctx context.Context, actorID, orgID int64, amount float64) (*Organization, error) {
+	if amount <= 0 {
+		return nil, ErrOrganizationInvalidAmount
+	}
+	if _, err := s.requireMember(ctx, orgID, actorID); err != nil {
+		return nil, err
+	}
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !org.IsActive() {
+		return nil, ErrOrganizationDisabled
+	}
+
+	err = s.runInTx(ctx, func(txCtx context.Context) error {
+		ok, err := s.orgRepo.DeductBalanceIfSufficient(txCtx, actorID, amount)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInsufficientBalance
+		}
+		return s.userRepo.UpdateBalance(txCtx, org.BillingUserID, amount)
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[Organization] user %d deposited %.4f to organization %d", actorID, amount, orgID)
+
+	if s.billingCacheService != nil {
+		_ = s.billingCacheService.InvalidateUserBalance(ctx, actorID)
+		_ = s.billingCacheService.InvalidateUserBalance(ctx, org.BillingUserID)
+	}
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, actorID)
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, org.BillingUserID)
+	}
+	return s.orgRepo.GetByID(ctx, orgID)
+}
+
+// CreateAPIKey 成员创建组织 Key（用量计入组织）
+func (s *OrganizationService) CreateAPIKey(ctx context.Context, actorID, orgID int64, req CreateAPIKeyRequest) (*APIKey, error) {
+	if _, err := s.requireMember(ctx, orgID, actorID); err != nil {
+		return nil, err
+	}
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !org.IsActive() {
+		return nil, ErrOrganizationDisabled
+	}
+	return s.apiKeyService.CreateForOrganization(ctx, actorID, org, req)
+}
+
+// ListUsage 组织用量明细；管理者可查看全部成员，普通成员只能查看自己
+func (s *OrganizationService) ListUsage(ctx context.Context, actorID, orgID int64, params pagination.PaginationParams, query OrganizationUsageQuery) ([]UsageLog, *pagination.PaginationResult, error) {
+	filters, err := s.usageFilters(ctx, actorID, orgID, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.usageRepo.ListWithFilters(ctx, params, filters)
+}
+
+// GetUsageStats 组织用量汇总（筛选规则同 ListUsage）
+func (s *OrganizationService) GetUsageStats(ctx context.Context, actorID, orgID int64, query OrganizationUsageQuery) (*usagestats.UsageStats, error) {
+	filters, err := s.usageFilters(ctx, actorID, orgID, query)
+	if err != nil {
+		return nil, err
+	}
+	return s.usageRepo.GetStatsWithFilters(ctx, filters)
+}
+
+// AdminList 管理端组织列表
+func (s *OrganizationService) AdminList(ctx context.Context, params pagination.PaginationParams, filters OrganizationListFilters) ([]Organization, *pagination.PaginationResult, error) {
+	return s.orgRepo.List(ctx, params, filters)
+}
+
+// AdminGet 管理端查看组织及成员
+func (s *OrganizationService) AdminGet(ctx context.Context, orgID int64) (*Organization, []OrganizationMember, error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	members, err := s.orgRepo.ListMembers(ctx, orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return org, members, nil
+}
+
+// AdminUpdateStatus 管理员启用/停用组织；停用后组织 Key 立即失效
+func (s *OrganizationService) AdminUpdateStatus(ctx context.Context, orgID int64, status string) (*Organization, error) {
+	if status != StatusActive && status != StatusDisabled {
+		return nil, ErrOrganizationInvalidStatus
+	}
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	org.Status = status
+	if err := s.orgRepo.Update(ctx, org); err != nil {
+		return nil, err
+	}
+	// 付费账户维度的失效会覆盖组织下的全部 Key
+	s.invalidateMemberKeys(ctx, org.BillingUserID)
+	return org, nil
+}
+
+func (s *OrganizationService) usageFilters(ctx context.Context, actorID, orgID int64, query OrganizationUsageQuery) (usagestats.UsageLogFilters, error) {
+	actor, err := s.requireMember(ctx, orgID, actorID)
+	if err != nil {
+		return usagestats.UsageLogFilters{}, err
+	}
+	memberID := query.MemberUserID
+	if !actor.CanManage() {
+		if memberID != 0 && memberID != actorID {
+			return usagestats.UsageLogFilters{}, ErrOrganizationForbidden
+		}
+		memberID = actorID
+	}
+	return usagestats.UsageLogFilters{
+		OrganizationID: orgID,
+		UserID:         memberID,
+		APIKeyID:       query.APIKeyID,
+		Model:          query.Model,
+		StartTime:      query.StartTime,
+		EndTime:        query.EndTime,
+	}, nil
+}
+
+func (s *OrganizationService) requireMember(ctx context.Context, orgID, userID int64) (*OrganizationMember, error) {
+	member, err := s.orgRepo.GetMember(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, ErrOrganizationMemberNotFound) {
+			// 不暴露组织是否存在
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+func (s *OrganizationService) requireManager(ctx context.Context, orgID, userID int64) (*OrganizationMember, error) {
+	member, err := s.requireMember(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !member.CanManage() {
+		return nil, ErrOrganizationForbidden
+	}
+	return member, nil
+}
+
+func (s *OrganizationService) invalidateMemberKeys(ctx context.Context, userID int64) {
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, userID)
+	}
+}
+
+// runInTx 在事务中执行；已处于事务中或未注入 ent client（单元测试）时直接执行
+func (s *OrganizationService) runInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.entClient == nil || dbent.TxFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	tx, err := s.entClient.Tx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(dbent.NewTxContext(ctx, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// checkOrganizationMemberManageable 所有者只能由自己调整；管理员只能管理普通成员（不含自己）
+func checkOrganizationMemberManageable(actor, member *OrganizationMember) error {
+	if member.Role == OrganizationRoleOwner && actor.UserID != member.UserID {
+		return ErrOrganizationOwnerImmutable
+	}
+	if actor.Role == OrganizationRoleAdmin && member.Role != OrganizationRoleMember {
+		return ErrOrganizationForbidden
+	}
+	return nil
+}
+
+func validateOrganizationSpendingLimit(limit *float64) error {
+	if limit != nil && *limit < 0 {
+		return ErrOrganizationInvalidAmount
+	}
+	return nil
+}
+
+func randomOrganizationSuffix() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate organization account suffix: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,304 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type orgRepoStub struct {
+	orgs    map[int64]*Organization
+	members map[int64]map[int64]*OrganizationMember
+	nextID  int64
+}
+
+func newOrgRepoStub() *orgRepoStub {
+	return &orgRepoStub{
+		orgs:    map[int64]*Organization{},
+		members: map[int64]map[int64]*OrganizationMember{},
+	}
+}
+
+func (r *orgRepoStub) Create(ctx context.Context, org *Organization, billing *User, owner *OrganizationMember) error {
+	r.nextID++
+	billing.ID = 1000 + r.nextID
+	org.ID = r.nextID
+	org.BillingUserID = billing.ID
+	owner.OrganizationID = org.ID
+	clone := *org
+	r.orgs[org.ID] = &clone
+	return r.AddMember(ctx, owner)
+}
+
+func (r *orgRepoStub) GetByID(ctx context.Context, id int64) (*Organization, error) {
+	org, ok := r.orgs[id]
+	if !ok {
+		return nil, ErrOrganizationNotFound
+	}
+	clone := *org
+	return &clone, nil
+}
+
+func (r *orgRepoStub) Update(ctx context.Context, org *Organization) error {
+	clone := *org
+	r.orgs[org.ID] = &clone
+	return nil
+}
+
+func (r *orgRepoStub) List(ctx context.Context, params pagination.PaginationParams, filters OrganizationListFilters) ([]Organization, *pagination.PaginationResult, error) {
+	return nil, &pagination.PaginationResult{}, nil
+}
+
+func (r *orgRepoStub) ListByMember(ctx context.Context, userID int64) ([]OrganizationMembership, error) {
+	return nil, nil
+}
+
+func (r *orgRepoStub) GetMember(ctx context.Context, orgID, userID int64) (*OrganizationMember, error) {
+	m, ok := r.members[orgID][userID]
+	if !ok {
+		return nil, ErrOrganizationMemberNotFound
+	}
+	clone := *m
+	return &clone, nil
+}
+
+func (r *orgRepoStub) ListMembers(ctx context.Context, orgID int64) ([]OrganizationMember, error) {
+	out := make([]OrganizationMember, 0, len(r.members[orgID]))
+	for _, m := range r.members[orgID] {
+		out = append(out, *m)
+	}
+	return out, nil
+}
+
+func (r *orgRepoStub) AddMember(ctx context.Context, member *OrganizationMember) error {
+	if r.members[member.OrganizationID] == nil {
+		r.members[member.OrganizationID] = map[int64]*OrganizationMember{}
+	}
+	if _, ok := r.members[member.OrganizationID][member.UserID]; ok {
+		return ErrOrganizationMemberExists
+	}
+	clone := *member
+	r.members[member.OrganizationID][member.UserID] = &clone
+	return nil
+}
+
+func (r *orgRepoStub) UpdateMember(ctx context.Context, member *OrganizationMember) error {
+	clone := *member
+	r.members[member.OrganizationID][member.UserID] = &clone
+	return nil
+}
+
+func (r *orgRepoStub) RemoveMember(ctx context.Context, orgID, userID int64) error {
+	if _, ok := r.members[orgID][userID]; !ok {
+		return ErrOrganizationMemberNotFound
+	}
+	delete(r.members[orgID], userID)
+	return nil
+}
+
+func (r *orgRepoStub) AddMemberSpend(ctx context.Context, orgID, userID int64, amount float64, period string) error {
+	m, ok := r.members[orgID][userID]
+	if !ok {
+		return ErrOrganizationMemberNotFound
+	}
+	if m.SpendPeriod != period {
+		m.SpendPeriod = period
+		m.SpentUSD = 0
+	}
+	m.SpentUSD += amount
+	return nil
+}
+
+func (r *orgRepoStub) GetMemberSpend(ctx context.Context, orgID, userID int64, period string) (float64, error) {
+	m, ok := r.members[orgID][userID]
+	if !ok {
+		return 0, ErrOrganizationMemberNotFound
+	}
+	return m.CurrentSpend(period), nil
+}
+
+func (r *orgRepoStub) DeductBalanceIfSufficient(ctx context.Context, userID int64, amount float64) (bool, error) {
+	return false, nil
+}
+
+type orgUserRepoStub struct {
+	paymentUserRepoStub
+	byEmail map[string]*User
+}
+
+func (r *orgUserRepoStub) GetByID(ctx context.Context, id int64) (*User, error) {
+	return &User{ID: id, Status: StatusActive, Concurrency: 5, Balance: r.balances[id]}, nil
+}
+
+func (r *orgUserRepoStub) GetByEmail(ctx context.Context, email string) (*User, error) {
+	u, ok := r.byEmail[email]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return u, nil
+}
+
+func newOrganizationTestService() (*OrganizationService, *orgRepoStub, *orgUserRepoStub) {
+	repo := newOrgRepoStub()
+	users := &orgUserRepoStub{
+		paymentUserRepoStub: paymentUserRepoStub{balances: map[int64]float64{}},
+		byEmail: map[string]*User{
+			"admin@example.com":  {ID: 2, Email: "admin@example.com", Status: StatusActive},
+			"member@example.com": {ID: 3, Email: "member@example.com", Status: StatusActive},
+			"other@example.com":  {ID: 4, Email: "other@example.com", Status: StatusActive},
+			"org@example.com":    {ID: 5, Email: "org@example.com", Status: StatusActive, Role: RoleOrganization},
+		},
+	}
+	return NewOrganizationService(repo, users, nil, nil, nil, nil, nil), repo, users
+}
+
+func TestOrganizationService_CreateAddsOwner(t *testing.T) {
+	svc, repo, _ := newOrganizationTestService()
+	ctx := context.Background()
+
+	org, err := svc.Create(ctx, 1, "  Acme  ")
+	require.NoError(t, err)
+	require.Equal(t, "Acme", org.Name)
+	require.NotZero(t, org.BillingUserID)
+
+	owner, err := repo.GetMember(ctx, org.ID, 1)
+	require.NoError(t, err)
+	require.Equal(t, OrganizationRoleOwner, owner.Role)
+
+	_, err = svc.Create(ctx, 1, " ")
+	require.ErrorIs(t, err, ErrOrganizationInvalidName)
+}
+
+func TestOrganizationService_MemberRolePermissions(t *testing.T) {
+	svc, _, _ := newOrganizationTestService()
+	ctx := context.Background()
+
+	org, err := svc.Create(ctx, 1, "Acme")
+	require.NoError(t, err)
+
+	_, err = svc.AddMember(ctx, 1, org.ID, &AddOrganizationMemberInput{Email: "admin@example.com", Role: OrganizationRoleAdmin})
+	require.NoError(t, err)
+	_, err = svc.AddMember(ctx, 2, org.ID, &AddOrganizationMemberInput{Email: "member@example.com"})
+	require.NoError(t, err)
+
+	// 管理员不能授予管理员角色，也不能管理所有者或其他管理员
+	_, err = svc.AddMember(ctx, 2, org.ID, &AddOrganizationMemberInput{Email: "other@example.com", Role: OrganizationRoleAdmin})
+	require.ErrorIs(t, err, ErrOrganizationForbidden)
+	err = svc.RemoveMember(ctx, 2, org.ID, 1)
+	require.ErrorIs(t, err, ErrOrganizationOwnerImmutable)
+
+	// 普通成员不能管理
+	_, err = svc.AddMember(ctx, 3, org.ID, &AddOrganizationMemberInput{Email: "other@example.com"})
+	require.ErrorIs(t, err, ErrOrganizationForbidden)
+
+	// 不可添加所有者角色、组织付费账户或重复成员
+	_, err = svc.AddMember(ctx, 1, org.ID, &AddOrganizationMemberInput{Email: "other@example.com", Role: OrganizationRoleOwner})
+	require.ErrorIs(t, err, ErrOrganizationInvalidRole)
+	_, err = svc.AddMember(ctx, 1, org.ID, &AddOrganizationMemberInput{Email: "org@example.com"})
+	require.ErrorIs(t, err, ErrUserNotFound)
+	_, err = svc.AddMember(ctx, 1, org.ID, &AddOrganizationMemberInput{Email: "member@example.com"})
+	require.ErrorIs(t, err, ErrOrganizationMemberExists)
+
+	// 所有者不可被移除，成员可自行退出
+	err = svc.RemoveMember(ctx, 1, org.ID, 1)
+	require.ErrorIs(t, err, ErrOrganizationOwnerImmutable)
+	require.NoError(t, svc.RemoveMember(ctx, 3, org.ID, 3))
+
+	// 非成员看不到组织
+	_, _, err = svc.Get(ctx, 4, org.ID)
+	require.ErrorIs(t, err, ErrOrganizationNotFound)
+}
+
+func TestOrganizationService_UpdateMemberSpendingLimit(t *testing.T) {
+	svc, repo, _ := newOrganizationTestService()
+	ctx := context.Background()
+
+	org, err := svc.Create(ctx, 1, "Acme")
+	require.NoError(t, err)
+	_, err = svc.AddMember(ctx, 1, org.ID, &AddOrganizationMemberInput{Email: "member@example.com"})
+	require.NoError(t, err)
+
+	limit := 25.0
+	member, err := svc.UpdateMember(ctx, 1, org.ID, 3, &UpdateOrganizationMemberInput{SpendingLimitUSD: &limit})
+	require.NoError(t, err)
+	require.InDelta(t, 25, *member.SpendingLimitUSD, 1e-9)
+
+	negative := -1.0
+	_, err = svc.UpdateMember(ctx, 1, org.ID, 3, &UpdateOrganizationMemberInput{SpendingLimitUSD: &negative})
+	require.Error(t, err)
+
+	member, err = svc.UpdateMember(ctx, 1, org.ID, 3, &UpdateOrganizationMemberInput{ClearSpendingLimit: true})
+	require.NoError(t, err)
+	require.Nil(t, member.SpendingLimitUSD)
+
+	stored, err := repo.GetMember(ctx, org.ID, 3)
+	require.NoError(t, err)
+	require.Nil(t, stored.SpendingLimitUSD)
+}
+
+func TestOrganizationService_UsageFiltersScopedToMember(t *testing.T) {
+	svc, _, _ := newOrganizationTestService()
+	ctx := context.Background()
+
+	org, err := svc.Create(ctx, 1, "Acme")
+	require.NoError(t, err)
+	_, err = svc.AddMember(ctx, 1, org.ID, &AddOrganizationMemberInput{Email: "member@example.com"})
+	require.NoError(t, err)
+
+	filters, err := svc.usageFilters(ctx, 1, org.ID, OrganizationUsageQuery{MemberUserID: 3})
+	require.NoError(t, err)
+	require.Equal(t, org.ID, filters.OrganizationID)
+	require.Equal(t, int64(3), filters.UserID)
+
+	filters, err = svc.usageFilters(ctx, 3, org.ID, OrganizationUsageQuery{})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), filters.UserID, "members only see their own usage")
+
+	_, err = svc.usageFilters(ctx, 3, org.ID, OrganizationUsageQuery{MemberUserID: 1})
+	require.ErrorIs(t, err, ErrOrganizationForbidden)
+}
+
+func TestBillingCacheService_OrganizationKeyBillsPayer(t *testing.T) {
+	repo := newOrgRepoStub()
+	users := &paymentUserRepoStub{balances: map[int64]float64{1: 0, 100: 10}}
+	svc := NewBillingCacheService(nil, users, nil, repo, &config.Config{})
+	t.Cleanup(svc.Stop)
+	ctx := context.Background()
+
+	limit := 5.0
+	orgID := int64(7)
+	require.NoError(t, repo.AddMember(ctx, &OrganizationMember{OrganizationID: orgID, UserID: 1, Role: OrganizationRoleMember}))
+	member := &User{ID: 1, Status: StatusActive}
+	apiKey := &APIKey{
+		ID:             9,
+		UserID:         1,
+		User:           member,
+		OrganizationID: &orgID,
+		Organization: &APIKeyOrganization{
+			ID:               orgID,
+			Status:           StatusActive,
+			MemberRole:       OrganizationRoleMember,
+			SpendingLimitUSD: &limit,
+			Payer:            &User{ID: 100, Status: StatusActive, Role: RoleOrganization},
+		},
+	}
+
+	// 成员个人余额为 0，但组织余额充足
+	require.NoError(t, svc.CheckBillingEligibility(ctx, member, apiKey, nil, nil))
+	require.ErrorIs(t, svc.CheckBillingEligibility(ctx, member, &APIKey{UserID: 1, User: member}, nil, nil), ErrInsufficientBalance)
+
+	svc.RecordOrganizationSpend(ctx, apiKey, 5)
+	require.ErrorIs(t, svc.CheckBillingEligibility(ctx, member, apiKey, nil, nil), ErrOrganizationSpendingLimitExceeded)
+
+	apiKey.Organization.MemberRole = ""
+	require.ErrorIs(t, svc.CheckBillingEligibility(ctx, member, apiKey, nil, nil), ErrOrganizationMembershipRequired)
+
+	apiKey.Organization.MemberRole = OrganizationRoleMember
+	apiKey.Organization.Status = StatusDisabled
+	require.ErrorIs(t, svc.CheckBillingEligibility(ctx, member, apiKey, nil, nil), ErrOrganizationDisabled)
+}
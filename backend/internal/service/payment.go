@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// Payment order status constants
+const (
+	PaymentOrderStatusPending           = "pending"
+	PaymentOrderStatusPaid              = "paid"
+	PaymentOrderStatusFailed            = "failed"
+	PaymentOrderStatusExpired           = "expired"
+	PaymentOrderStatusCanceled          = "canceled"
+	PaymentOrderStatusPartiallyRefunded = "partially_refunded"
+	PaymentOrderStatusRefunded          = "refunded"
+)
+
+// Payment order type constants
+const (
+	PaymentOrderTypeBalance      = "balance"      // 余额充值
+	PaymentOrderTypeSubscription = "subscription" // 购买分组订阅
+)
+
+// Payment provider name constants
+const (
+	PaymentProviderStripe = "stripe"
+	PaymentProviderSigned = "signed"
+	PaymentProviderFake   = "fake"
+)
+
+// Payment webhook event type constants
+const (
+	PaymentEventPaid     = "paid"
+	PaymentEventFailed   = "failed"
+	PaymentEventRefunded = "refunded"
+	PaymentEventIgnored  = "ignored"
+)
+
+var (
+	ErrPaymentDisabled          = infraerrors.New(http.StatusServiceUnavailable, "PAYMENT_DISABLED", "online payment is disabled")
+	ErrPaymentProviderNotFound  = infraerrors.BadRequest("PAYMENT_PROVIDER_NOT_FOUND", "payment provider not found or disabled")
+	ErrPaymentOrderNotFound     = infraerrors.NotFound("PAYMENT_ORDER_NOT_FOUND", "payment order not found")
+	ErrPaymentInvalidAmount     = infraerrors.BadRequest("PAYMENT_INVALID_AMOUNT", "invalid payment amount")
+	ErrPaymentInvalidOrderType  = infraerrors.BadRequest("PAYMENT_INVALID_ORDER_TYPE", "invalid payment order type")
+	ErrPaymentTooManyPending    = infraerrors.TooManyRequests("PAYMENT_TOO_MANY_PENDING", "too many pending payment orders")
+	ErrPaymentOrderNotPending   = infraerrors.Conflict("PAYMENT_ORDER_NOT_PENDING", "payment order is not pending")
+	ErrPaymentOrderNotRefunable = infraerrors.Conflict("PAYMENT_ORDER_NOT_REFUNDABLE", "payment order cannot be refunded")
+	ErrPaymentRefundExceeds     = infraerrors.BadRequest("PAYMENT_REFUND_EXCEEDS", "refund amount exceeds refundable amount")
+	ErrPaymentInvalidSignature  = infraerrors.Unauthorized("PAYMENT_INVALID_SIGNATURE", "invalid payment callback signature")
+	ErrPaymentAmountMismatch    = infraerrors.BadRequest("PAYMENT_AMOUNT_MISMATCH", "paid amount does not match order amount")
+)
+
+// PaymentOrder 在线支付订单
+//
+// Amount 以订单货币（Currency）计价；CreditAmount 为支付成功后到账的余额（USD），
+// 仅余额充值订单使用。订阅订单通过 GroupID + ValidityDays 发放订阅。
+type PaymentOrder struct {
+	ID                int64
+	OrderNo           string
+	UserID            int64
+	Provider          string
+	Method            string
+	OrderType         string
+	Amount            float64
+	Currency          string
+	CreditAmount      float64
+	GroupID           *int64
+	ValidityDays      int
+	SubscriptionID    *int64
+	Status            string
+	ProviderOrderID   string
+	ProviderPaymentID string
+	PayURL            string
+	RefundedAmount    float64
+	FailureReason     string
+	Notes             string
+	CreatedBy         *int64
+	ExpiresAt         *time.Time
+	PaidAt            *time.Time
+	RefundedAt        *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+
+	User  *User
+	Group *Group
+}
+
+// IsPaid 订单是否已支付（包含部分退款）
+func (o *PaymentOrder) IsPaid() bool {
+	return o.Status == PaymentOrderStatusPaid || o.Status == PaymentOrderStatusPartiallyRefunded
+}
+
+// CanAcceptPayment 订单是否仍可接收支付成功回调
+// 过期/取消的订单如果用户实际完成了支付，仍需入账，避免吞款。
+func (o *PaymentOrder) CanAcceptPayment() bool {
+	switch o.Status {
+	case PaymentOrderStatusPending, PaymentOrderStatusExpired, PaymentOrderStatusCanceled, PaymentOrderStatusFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// RefundableAmount 剩余可退款金额（订单货币）
+func (o *PaymentOrder) RefundableAmount() float64 {
+	if !o.IsPaid() {
+		return 0
+	}
+	remaining := o.Amount - o.RefundedAmount
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// PaymentOrderFilters 订单列表过滤条件
+type PaymentOrderFilters struct {
+	UserID    *int64
+	Status    string
+	Provider  string
+	OrderType string
+}
+
+// PaymentWebhookRecord 支付回调去重记录
+type PaymentWebhookRecord struct {
+	Provider  string
+	EventID   string
+	EventType string
+	OrderNo   string
+	Payload   []byte
+}
+
+// PaymentOrderRepository 支付订单持久层接口
+type PaymentOrderRepository interface {
+	Create(ctx context.Context, order *PaymentOrder) error
+	GetByID(ctx context.Context, id int64) (*PaymentOrder, error)
+	GetByOrderNo(ctx context.Context, orderNo string) (*PaymentOrder, error)
+	// GetByOrderNoForUpdate 在事务中加行锁读取订单
+	GetByOrderNoForUpdate(ctx context.Context, orderNo string) (*PaymentOrder, error)
+	GetByProviderPaymentID(ctx context.Context, provider, providerPaymentID string) (*PaymentOrder, error)
+	List(ctx context.Context, params pagination.PaginationParams, filters PaymentOrderFilters) ([]PaymentOrder, *pagination.PaginationResult, error)
+	CountPendingByUser(ctx context.Context, userID int64) (int, error)
+
+	UpdateProviderInfo(ctx context.Context, id int64, providerOrderID, payURL string) error
+	// MarkPaid 将订单标记为已支付，返回 false 表示订单状态已变化（并发回调）
+	MarkPaid(ctx context.Context, order *PaymentOrder) (bool, error)
+	// TransitionStatus 仅当当前状态为 from 时更新为 to
+	TransitionStatus(ctx context.Context, id int64, from, to, reason string) (bool, error)
+	ApplyRefund(ctx context.Context, id int64, refundedAmount float64, status string, refundedAt time.Time) error
+	ExpirePending(ctx context.Context, now time.Time) (int64, error)
+
+	// RecordWebhookEvent 写入回调去重记录，返回 false 表示事件已处理过
+	RecordWebhookEvent(ctx context.Context, record *PaymentWebhookRecord) (bool, error)
+}
+
+// PaymentCreateRequest 发起支付时传给支付渠道的参数
+type PaymentCreateRequest struct {
+	Order     *PaymentOrder
+	Subject   string
+	NotifyURL string
+	ReturnURL string
+	ClientIP  string
+}
+
+// PaymentCreateResult 支付渠道下单结果
+type PaymentCreateResult struct {
+	ProviderOrderID string
+	PayURL          string
+}
+
+// PaymentWebhookRequest 支付回调原始请求
+type PaymentWebhookRequest struct {
+	Method  string
+	Headers http.Header
+	Query   url.Values
+	Form    url.Values
+	Body    []byte
+}
+
+// PaymentWebhookEvent 验签通过后的标准化回调事件
+//
+// RefundedAmount 为累计退款金额（订单货币），便于与主动退款合并去重。
+type PaymentWebhookEvent struct {
+	EventID           string
+	Type              string
+	OrderNo           string
+	ProviderOrderID   string
+	ProviderPaymentID string
+	Amount            float64
+	Currency          string
+	RefundedAmount    float64
+	Reason            string
+	OccurredAt        time.Time
+	Raw               []byte
+}
+
+// PaymentRefundResult 支付渠道退款结果
+type PaymentRefundResult struct {
+	RefundID string
+}
+
+// PaymentProvider 支付渠道适配器
+type PaymentProvider interface {
+	Name() string
+	// Methods 返回渠道支持的支付方式（如 alipay / wxpay），空表示无需选择
+	Methods() []string
+	CreatePayment(ctx context.Context, req *PaymentCreateRequest) (*PaymentCreateResult, error)
+	// ParseWebhook 校验回调签名并解析为标准事件，签名错误返回 ErrPaymentInvalidSignature
+	ParseWebhook(ctx context.Context, req *PaymentWebhookRequest) (*PaymentWebhookEvent, error)
+	// WebhookAck 返回渠道要求的回调应答
+	WebhookAck(err error) (status int, contentType string, body []byte)
+	Refund(ctx context.Context, order *PaymentOrder, amount float64, reason string) (*PaymentRefundResult, error)
+}
+
+// PaymentProviders 已启用的支付渠道集合（由 repository 层按配置构建）
+type PaymentProviders []PaymentProvider
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FakePaymentProvider 本地调试用的模拟支付渠道
+//
+// pay_url 直接指向本服务的回调地址，浏览器打开即视为支付成功；
+// 也可以 POST JSON {"event_id","type","order_no","amount","refunded_amount"} 模拟任意事件。
+// 不做任何签名校验，切勿在生产环境启用。
+type FakePaymentProvider struct{}
+
+// NewFakePaymentProvider 创建模拟支付渠道
+func NewFakePaymentProvider() *FakePaymentProvider {
+	return &FakePaymentProvider{}
+}
+
+func (p *FakePaymentProvider) Name() string { return PaymentProviderFake }
+
+func (p *FakePaymentProvider) Methods() []string { return nil }
+
+func (p *FakePaymentProvider) CreatePayment(_ context.Context, req *PaymentCreateRequest) (*PaymentCreateResult, error) {
+	q := url.Values{}
+	q.Set("order_no", req.Order.OrderNo)
+	q.Set("amount", strconv.FormatFloat(req.Order.Amount, 'f', 2, 64))
+	q.Set("type", PaymentEventPaid)
+	return &PaymentCreateResult{
+		ProviderOrderID: "fake_" + req.Order.OrderNo,
+		PayURL:          req.NotifyURL + "?" + q.Encode(),
+	}, nil
+}
+
+type fakePaymentEvent struct {
+	EventID        string  `json:"event_id"`
+	Type           string  `json:"type"`
+	OrderNo        string  `json:"order_no"`
+	Amount         float64 `json:"amount"`
+	RefundedAmount float64 `json:"refunded_amount"`
+	Reason         string  `json:"reason"`
+}
+
+func (p *FakePaymentProvider) ParseWebhook(_ context.Context, req *PaymentWebhookRequest) (*PaymentWebhookEvent, error) {
+	var in fakePaymentEvent
+	if len(req.Body) > 0 && strings.HasPrefix(strings.TrimSpace(req.Headers.Get("Content-Type")), "application/json") {
+		if err := json.Unmarshal(req.Body, &in); err != nil {
+			return nil, fmt.Errorf("decode fake payment event: %w", err)
+		}
+	} else {
+		values := req.Query
+		if len(req.Form) > 0 {
+			values = req.Form
+		}
+		in.EventID = values.Get("event_id")
+		in.Type = values.Get("type")
+		in.OrderNo = values.Get("order_no")
+		in.Reason = values.Get("reason")
+		in.Amount, _ = strconv.ParseFloat(values.Get("amount"), 64)
+		in.RefundedAmount, _ = strconv.ParseFloat(values.Get("refunded_amount"), 64)
+	}
+
+	if in.Type == "" {
+		in.Type = PaymentEventPaid
+	}
+	switch in.Type {
+	case PaymentEventPaid, PaymentEventFailed, PaymentEventRefunded:
+	default:
+		return &PaymentWebhookEvent{Type: PaymentEventIgnored}, nil
+	}
+	if in.EventID == "" {
+		in.EventID = fmt.Sprintf("fake_%s_%s", in.Type, in.OrderNo)
+		if in.Type == PaymentEventRefunded {
+			in.EventID += "_" + strconv.FormatFloat(in.RefundedAmount, 'f', 2, 64)
+		}
+	}
+	raw, _ := json.Marshal(in)
+	return &PaymentWebhookEvent{
+		EventID:           in.EventID,
+		Type:              in.Type,
+		OrderNo:           in.OrderNo,
+		ProviderOrderID:   "fake_" + in.OrderNo,
+		ProviderPaymentID: "fake_pay_" + in.OrderNo,
+		Amount:            in.Amount,
+		RefundedAmount:    in.RefundedAmount,
+		Reason:            in.Reason,
+		OccurredAt:        time.Now(),
+		Raw:               raw,
+	}, nil
+}
+
+func (p *FakePaymentProvider) WebhookAck(err error) (int, string, []byte) {
+	if err != nil {
+		return http.StatusBadRequest, "text/plain; charset=utf-8", []byte(err.Error())
+	}
+	return http.StatusOK, "text/plain; charset=utf-8", []byte("success")
+}
+
+func (p *FakePaymentProvider) Refund(_ context.Context, order *PaymentOrder, _ float64, _ string) (*PaymentRefundResult, error) {
+	return &PaymentRefundResult{RefundID: fmt.Sprintf("fake_re_%s_%d", order.OrderNo, time.Now().UnixNano())}, nil
+}
@@ -0,0 +1,799 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+const (
+	paymentExpiryWorkerName = "payment_order_expiry"
+	paymentExpiryInterval   = time.Minute
+
+	// paymentAmountEpsilon 金额比较容差（订单货币最小单位以下）
+	paymentAmountEpsilon = 0.005
+)
+
+// errPaymentEventDuplicate 回调事件已处理过（内部哨兵，不向渠道返回错误）
+var errPaymentEventDuplicate = errors.New("payment webhook event already processed")
+
+// CreatePaymentOrderInput 用户创建充值订单输入
+type CreatePaymentOrderInput struct {
+	Provider string
+	Method   string
+	Amount   float64
+	ClientIP string
+}
+
+// AdminCreatePaymentOrderInput 管理员为用户创建支付订单输入（支持订阅订单）
+type AdminCreatePaymentOrderInput struct {
+	UserID       int64
+	Provider     string
+	Method       string
+	OrderType    string
+	Amount       float64
+	CreditAmount *float64
+	GroupID      *int64
+	ValidityDays int
+	Notes        string
+}
+
+// PaymentProviderInfo 对用户公开的支付渠道信息
+type PaymentProviderInfo struct {
+	Name    string
+	Methods []string
+}
+
+// PaymentPublicConfig 对用户公开的支付配置
+type PaymentPublicConfig struct {
+	Enabled        bool
+	Currency       string
+	BalancePerUnit float64
+	MinAmount      float64
+	MaxAmount      float64
+	Providers      []PaymentProviderInfo
+}
+
+// PaymentService 在线支付服务
+//
+// 订单创建后由支付渠道回调驱动状态流转；回调事件按 (provider, event_id) 去重，
+// 与余额/订阅发放在同一事务内完成，保证重复回调不会重复入账。
+type PaymentService struct {
+	repo                 PaymentOrderRepository
+	userRepo             UserRepository
+	groupRepo            GroupRepository
+	userSubRepo          UserSubscriptionRepository
+	subscriptionService  *SubscriptionService
+	billingCacheService  *BillingCacheService
+	authCacheInvalidator APIKeyAuthCacheInvalidator
+	entClient            *dbent.Client
+	providers            map[string]PaymentProvider
+	providerNames        []string
+	timingWheel          *TimingWheelService
+	cfg                  *config.Config
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewPaymentService 创建在线支付服务
+func NewPaymentService(
+	repo PaymentOrderRepository,
+	userRepo UserRepository,
+	groupRepo GroupRepository,
+	userSubRepo UserSubscriptionRepository,
+	subscriptionService *SubscriptionService,
+	billingCacheService *BillingCacheService,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	entClient *dbent.Client,
+	providers PaymentProviders,
+	timingWheel *TimingWheelService,
+	cfg *config.Config,
+) *PaymentService {
+	s := &PaymentService{
+		repo:                 repo,
+		userRepo:             userRepo,
+		groupRepo:            groupRepo,
+		userSubRepo:          userSubRepo,
+		subscriptionService:  subscriptionService,
+		billingCacheService:  billingCacheService,
+		authCacheInvalidator: authCacheInvalidator,
+		entClient:            entClient,
+		providers:            make(map[string]PaymentProvider, len(providers)),
+		timingWheel:          timingWheel,
+		cfg:                  cfg,
+	}
+	for _, p := range providers {
+		if p == nil {
+			continue
+		}
+		if _, exists := s.providers[p.Name()]; exists {
+			continue
+		}
+		s.providers[p.Name()] = p
+		s.providerNames = append(s.providerNames, p.Name())
+	}
+	return s
+}
+
+// Start 启动待支付订单过期任务
+func (s *PaymentService) Start() {
+	if s == nil {
+		return
+	}
+	if !s.Enabled() {
+		log.Printf("[Payment] not started (disabled)")
+		return
+	}
+	if s.repo == nil || s.timingWheel == nil {
+		log.Printf("[Payment] not started (missing deps)")
+		return
+	}
+	s.startOnce.Do(func() {
+		s.timingWheel.ScheduleRecurring(paymentExpiryWorkerName, paymentExpiryInterval, s.expirePendingOrders)
+		log.Printf("[Payment] started (providers=%s currency=%s)", strings.Join(s.providerNames, ","), s.cfg.Payment.Currency)
+	})
+}
+
+// Stop 停止后台任务
+func (s *PaymentService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(paymentExpiryWorkerName)
+		}
+		log.Printf("[Payment] stopped")
+	})
+}
+
+// Enabled 是否启用在线支付（需至少一个可用渠道）
+func (s *PaymentService) Enabled() bool {
+	return s != nil && s.cfg != nil && s.cfg.Payment.Enabled && len(s.providers) > 0
+}
+
+// GetPublicConfig 返回用户侧可见的支付配置
+func (s *PaymentService) GetPublicConfig() *PaymentPublicConfig {
+	out := &PaymentPublicConfig{Enabled: s.Enabled()}
+	if !out.Enabled {
+		return out
+	}
+	out.Currency = s.cfg.Payment.Currency
+	out.BalancePerUnit = s.cfg.Payment.BalancePerUnit
+	out.MinAmount = s.cfg.Payment.MinAmount
+	out.MaxAmount = s.cfg.Payment.MaxAmount
+	for _, name := range s.providerNames {
+		out.Providers = append(out.Providers, PaymentProviderInfo{
+			Name:    name,
+			Methods: s.providers[name].Methods(),
+		})
+	}
+	return out
+}
+
+func (s *PaymentService) provider(name string) (PaymentProvider, error) {
+	p, ok := s.providers[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, ErrPaymentProviderNotFound
+	}
+	return p, nil
+}
+
+// CreateTopUpOrder 用户创建余额充值订单并发起支付
+func (s *PaymentService) CreateTopUpOrder(ctx context.Context, userID int64, input *CreatePaymentOrderInput) (*PaymentOrder, error) {
+	if !s.Enabled() {
+		return nil, ErrPaymentDisabled
+	}
+	if input == nil {
+		return nil, ErrPaymentInvalidAmount
+	}
+	amount := roundPaymentAmount(input.Amount)
+	if amount <= 0 || amount < s.cfg.Payment.MinAmount || amount > s.cfg.Payment.MaxAmount {
+		return nil, infraerrors.BadRequest("PAYMENT_INVALID_AMOUNT",
+			fmt.Sprintf("amount must be between %.2f and %.2f", s.cfg.Payment.MinAmount, s.cfg.Payment.MaxAmount))
+	}
+
+	order := &PaymentOrder{
+		UserID:       userID,
+		Provider:     strings.ToLower(strings.TrimSpace(input.Provider)),
+		Method:       strings.ToLower(strings.TrimSpace(input.Method)),
+		OrderType:    PaymentOrderTypeBalance,
+		Amount:       amount,
+		CreditAmount: roundBalanceAmount(amount * s.cfg.Payment.BalancePerUnit),
+	}
+	return s.createOrder(ctx, order, input.ClientIP)
+}
+
+// AdminCreateOrder 管理员为用户创建支付订单（余额充值或分组订阅），用户通过 pay_url 完成支付
+func (s *PaymentService) AdminCreateOrder(ctx context.Context, operatorID int64, input *AdminCreatePaymentOrderInput) (*PaymentOrder, error) {
+	if !s.Enabled() {
+		return nil, ErrPaymentDisabled
+	}
+	if input == nil || input.UserID <= 0 {
+		return nil, infraerrors.BadRequest("PAYMENT_INVALID_USER", "invalid user_id")
+	}
+	amount := roundPaymentAmount(input.Amount)
+	if amount <= 0 {
+		return nil, ErrPaymentInvalidAmount
+	}
+	if _, err := s.userRepo.GetByID(ctx, input.UserID); err != nil {
+		return nil, err
+	}
+
+	order := &PaymentOrder{
+		UserID:   input.UserID,
+		Provider: strings.ToLower(strings.TrimSpace(input.Provider)),
+		Method:   strings.ToLower(strings.TrimSpace(input.Method)),
+		Amount:   amount,
+		Notes:    strings.TrimSpace(input.Notes),
+	}
+	if operatorID > 0 {
+		order.CreatedBy = &operatorID
+	}
+
+	switch input.OrderType {
+	case "", PaymentOrderTypeBalance:
+		order.OrderType = PaymentOrderTypeBalance
+		order.CreditAmount = roundBalanceAmount(amount * s.cfg.Payment.BalancePerUnit)
+		if input.CreditAmount != nil {
+			if *input.CreditAmount <= 0 {
+				return nil, ErrPaymentInvalidAmount
+			}
+			order.CreditAmount = roundBalanceAmount(*input.CreditAmount)
+		}
+	case PaymentOrderTypeSubscription:
+		if input.GroupID == nil || *input.GroupID <= 0 {
+			return nil, infraerrors.BadRequest("PAYMENT_GROUP_REQUIRED", "group_id is required for subscription orders")
+		}
+		if input.ValidityDays <= 0 || input.ValidityDays > MaxValidityDays {
+			return nil, infraerrors.BadRequest("PAYMENT_INVALID_VALIDITY_DAYS", "invalid validity_days")
+		}
+		group, err := s.groupRepo.GetByID(ctx, *input.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		if !group.IsSubscriptionType() {
+			return nil, ErrGroupNotSubscriptionType
+		}
+		order.OrderType = PaymentOrderTypeSubscription
+		order.GroupID = input.GroupID
+		order.ValidityDays = input.ValidityDays
+	default:
+		return nil, ErrPaymentInvalidOrderType
+	}
+
+	return s.createOrder(ctx, order, "")
+}
+
+func (s *PaymentService) createOrder(ctx context.Context, order *PaymentOrder, clientIP string) (*PaymentOrder, error) {
+	provider, err := s.provider(order.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if methods := provider.Methods(); len(methods) > 0 {
+		if order.Method == "" {
+			order.Method = methods[0]
+		}
+		if !slices.Contains(methods, order.Method) {
+			return nil, infraerrors.BadRequest("PAYMENT_INVALID_METHOD", "unsupported payment method")
+		}
+	}
+
+	if limit := s.cfg.Payment.MaxPendingOrders; limit > 0 {
+		pending, err := s.repo.CountPendingByUser(ctx, order.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("count pending orders: %w", err)
+		}
+		if pending >= limit {
+			return nil, ErrPaymentTooManyPending
+		}
+	}
+
+	orderNo, err := generatePaymentOrderNo()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(time.Duration(s.cfg.Payment.OrderExpireMinutes) * time.Minute)
+	order.OrderNo = orderNo
+	order.Currency = s.cfg.Payment.Currency
+	order.Status = PaymentOrderStatusPending
+	order.ExpiresAt = &expiresAt
+
+	if err := s.repo.Create(ctx, order); err != nil {
+		return nil, fmt.Errorf("create payment order: %w", err)
+	}
+
+	result, err := provider.CreatePayment(ctx, &PaymentCreateRequest{
+		Order:     order,
+		Subject:   s.orderSubject(order),
+		NotifyURL: s.notifyURL(provider.Name()),
+		ReturnURL: s.returnURL(),
+		ClientIP:  clientIP,
+	})
+	if err != nil {
+		log.Printf("[Payment] create_payment failed: order=%s provider=%s err=%v", order.OrderNo, provider.Name(), err)
+		if _, markErr := s.repo.TransitionStatus(ctx, order.ID, PaymentOrderStatusPending, PaymentOrderStatusFailed, err.Error()); markErr != nil {
+			log.Printf("[Payment] mark order failed error: order=%s err=%v", order.OrderNo, markErr)
+		}
+		return nil, infraerrors.New(http.StatusBadGateway, "PAYMENT_PROVIDER_ERROR", "failed to create payment with provider").WithCause(err)
+	}
+
+	if err := s.repo.UpdateProviderInfo(ctx, order.ID, result.ProviderOrderID, result.PayURL); err != nil {
+		return nil, fmt.Errorf("update payment order: %w", err)
+	}
+	order.ProviderOrderID = result.ProviderOrderID
+	order.PayURL = result.PayURL
+
+	log.Printf("[Payment] order created: order=%s user=%d type=%s provider=%s amount=%.2f %s", order.OrderNo, order.UserID, order.OrderType, order.Provider, order.Amount, order.Currency)
+	return order, nil
+}
+
+func (s *PaymentService) orderSubject(order *PaymentOrder) string {
+	if order.OrderType == PaymentOrderTypeSubscription {
+		return fmt.Sprintf("Subscription %d days", order.ValidityDays)
+	}
+	return fmt.Sprintf("Balance top-up %.2f", order.CreditAmount)
+}
+
+func (s *PaymentService) notifyURL(provider string) string {
+	base := strings.TrimRight(strings.TrimSpace(s.cfg.Payment.NotifyBaseURL), "/")
+	return base + "/api/v1/payments/webhook/" + provider
+}
+
+// returnURL 支付完成后的跳转地址，未配置时回到站点首页
+func (s *PaymentService) returnURL() string {
+	if v := strings.TrimSpace(s.cfg.Payment.ReturnURL); v != "" {
+		return v
+	}
+	return strings.TrimRight(strings.TrimSpace(s.cfg.Payment.NotifyBaseURL), "/") + "/"
+}
+
+// GetUserOrder 获取用户自己的订单
+func (s *PaymentService) GetUserOrder(ctx context.Context, userID int64, orderNo string) (*PaymentOrder, error) {
+	order, err := s.repo.GetByOrderNo(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, ErrPaymentOrderNotFound
+	}
+	return order, nil
+}
+
+// ListUserOrders 分页获取用户订单
+func (s *PaymentService) ListUserOrders(ctx context.Context, userID int64, params pagination.PaginationParams) ([]PaymentOrder, *pagination.PaginationResult, error) {
+	return s.repo.List(ctx, params, PaymentOrderFilters{UserID: &userID})
+}
+
+// CancelUserOrder 用户取消待支付订单
+func (s *PaymentService) CancelUserOrder(ctx context.Context, userID int64, orderNo string) (*PaymentOrder, error) {
+	order, err := s.GetUserOrder(ctx, userID, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.TransitionStatus(ctx, order.ID, PaymentOrderStatusPending, PaymentOrderStatusCanceled, "canceled by user")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrPaymentOrderNotPending
+	}
+	return s.repo.GetByID(ctx, order.ID)
+}
+
+// List 管理员分页查询订单
+func (s *PaymentService) List(ctx context.Context, params pagination.PaginationParams, filters PaymentOrderFilters) ([]PaymentOrder, *pagination.PaginationResult, error) {
+	return s.repo.List(ctx, params, filters)
+}
+
+// GetByID 管理员获取订单
+func (s *PaymentService) GetByID(ctx context.Context, id int64) (*PaymentOrder, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// HandleWebhook 处理支付渠道回调
+//
+// 返回 nil 表示事件已被接受（包括重复事件），渠道不应再重试。
+func (s *PaymentService) HandleWebhook(ctx context.Context, providerName string, req *PaymentWebhookRequest) error {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return err
+	}
+	event, err := provider.ParseWebhook(ctx, req)
+	if err != nil {
+		log.Printf("[Payment] webhook rejected: provider=%s err=%v", provider.Name(), err)
+		return err
+	}
+	if event == nil || event.Type == PaymentEventIgnored {
+		return nil
+	}
+	if event.OrderNo == "" && event.ProviderPaymentID != "" {
+		// 部分渠道的退款事件只携带支付单号，回查订单
+		if order, err := s.repo.GetByProviderPaymentID(ctx, provider.Name(), event.ProviderPaymentID); err == nil {
+			event.OrderNo = order.OrderNo
+		}
+	}
+	if event.EventID == "" || event.OrderNo == "" {
+		return infraerrors.BadRequest("PAYMENT_INVALID_EVENT", "payment event missing id or order")
+	}
+
+	switch event.Type {
+	case PaymentEventPaid:
+		err = s.handlePaidEvent(ctx, provider, event)
+	case PaymentEventRefunded:
+		err = s.handleRefundEvent(ctx, provider, event)
+	case PaymentEventFailed:
+		err = s.handleFailedEvent(ctx, provider, event)
+	default:
+		return nil
+	}
+	if errors.Is(err, errPaymentEventDuplicate) {
+		log.Printf("[Payment] webhook duplicate ignored: provider=%s event=%s order=%s", provider.Name(), event.EventID, event.OrderNo)
+		return nil
+	}
+	if err != nil {
+		log.Printf("[Payment] webhook failed: provider=%s event=%s type=%s order=%s err=%v", provider.Name(), event.EventID, event.Type, event.OrderNo, err)
+	}
+	return err
+}
+
+// WebhookAck 返回渠道要求的回调应答格式
+func (s *PaymentService) WebhookAck(providerName string, err error) (int, string, []byte) {
+	provider, pErr := s.provider(providerName)
+	if pErr != nil {
+		return infraerrors.Code(pErr), "text/plain; charset=utf-8", []byte("unknown provider")
+	}
+	return provider.WebhookAck(err)
+}
+
+func (s *PaymentService) recordEvent(ctx context.Context, provider PaymentProvider, event *PaymentWebhookEvent) error {
+	inserted, err := s.repo.RecordWebhookEvent(ctx, &PaymentWebhookRecord{
+		Provider:  provider.Name(),
+		EventID:   event.EventID,
+		EventType: event.Type,
+		OrderNo:   event.OrderNo,
+		Payload:   event.Raw,
+	})
+	if err != nil {
+		return fmt.Errorf("record webhook event: %w", err)
+	}
+	if !inserted {
+		return errPaymentEventDuplicate
+	}
+	return nil
+}
+
+func (s *PaymentService) handlePaidEvent(ctx context.Context, provider PaymentProvider, event *PaymentWebhookEvent) error {
+	var fulfilled *PaymentOrder
+	err := s.runInTx(ctx, func(txCtx context.Context) error {
+		if err := s.recordEvent(txCtx, provider, event); err != nil {
+			return err
+		}
+		order, err := s.repo.GetByOrderNoForUpdate(txCtx, event.OrderNo)
+		if err != nil {
+			return err
+		}
+		if order.Provider != provider.Name() {
+			return infraerrors.BadRequest("PAYMENT_PROVIDER_MISMATCH", "payment event provider does not match order")
+		}
+		if !order.CanAcceptPayment() {
+			// 已支付/已退款：同一笔支付的其它事件，直接确认
+			return nil
+		}
+		if event.Amount > 0 && math.Abs(event.Amount-order.Amount) > paymentAmountEpsilon {
+			return ErrPaymentAmountMismatch
+		}
+		if order.Status != PaymentOrderStatusPending {
+			log.Printf("[Payment] late payment accepted: order=%s status=%s", order.OrderNo, order.Status)
+		}
+
+		if err := s.fulfillOrder(txCtx, order); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		order.Status = PaymentOrderStatusPaid
+		order.PaidAt = &now
+		if event.ProviderPaymentID != "" {
+			order.ProviderPaymentID = event.ProviderPaymentID
+		}
+		if event.ProviderOrderID != "" {
+			order.ProviderOrderID = event.ProviderOrderID
+		}
+		ok, err := s.repo.MarkPaid(txCtx, order)
+		if err != nil {
+			return fmt.Errorf("mark order paid: %w", err)
+		}
+		if !ok {
+			return ErrPaymentOrderNotPending
+		}
+		fulfilled = order
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if fulfilled != nil {
+		s.invalidateCaches(ctx, fulfilled)
+		log.Printf("[Payment] order paid: order=%s user=%d type=%s amount=%.2f %s", fulfilled.OrderNo, fulfilled.UserID, fulfilled.OrderType, fulfilled.Amount, fulfilled.Currency)
+	}
+	return nil
+}
+
+// fulfillOrder 发放订单权益（需在事务上下文中调用）
+func (s *PaymentService) fulfillOrder(txCtx context.Context, order *PaymentOrder) error {
+	switch order.OrderType {
+	case PaymentOrderTypeBalance:
+		if err := s.userRepo.UpdateBalance(txCtx, order.UserID, order.CreditAmount); err != nil {
+			return fmt.Errorf("update user balance: %w", err)
+		}
+	case PaymentOrderTypeSubscription:
+		if order.GroupID == nil {
+			return infraerrors.BadRequest("PAYMENT_GROUP_REQUIRED", "subscription order missing group_id")
+		}
+		sub, _, err := s.subscriptionService.AssignOrExtendSubscription(txCtx, &AssignSubscriptionInput{
+			UserID:       order.UserID,
+			GroupID:      *order.GroupID,
+			ValidityDays: order.ValidityDays,
+			AssignedBy:   0, // 系统分配
+			Notes:        fmt.Sprintf("通过支付订单 %s 购买", order.OrderNo),
+		})
+		if err != nil {
+			return fmt.Errorf("assign or extend subscription: %w", err)
+		}
+		if sub != nil {
+			order.SubscriptionID = &sub.ID
+		}
+	default:
+		return ErrPaymentInvalidOrderType
+	}
+	return nil
+}
+
+func (s *PaymentService) handleFailedEvent(ctx context.Context, provider PaymentProvider, event *PaymentWebhookEvent) error {
+	return s.runInTx(ctx, func(txCtx context.Context) error {
+		if err := s.recordEvent(txCtx, provider, event); err != nil {
+			return err
+		}
+		order, err := s.repo.GetByOrderNoForUpdate(txCtx, event.OrderNo)
+		if err != nil {
+			return err
+		}
+		reason := event.Reason
+		if reason == "" {
+			reason = "payment failed"
+		}
+		_, err = s.repo.TransitionStatus(txCtx, order.ID, PaymentOrderStatusPending, PaymentOrderStatusFailed, reason)
+		return err
+	})
+}
+
+func (s *PaymentService) handleRefundEvent(ctx context.Context, provider PaymentProvider, event *PaymentWebhookEvent) error {
+	var refunded *PaymentOrder
+	err := s.runInTx(ctx, func(txCtx context.Context) error {
+		if err := s.recordEvent(txCtx, provider, event); err != nil {
+			return err
+		}
+		order, err := s.applyRefund(txCtx, event.OrderNo, event.RefundedAmount, event.Reason)
+		refunded = order
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if refunded != nil {
+		s.invalidateCaches(ctx, refunded)
+	}
+	return nil
+}
+
+// RefundOrder 管理员发起退款；amount<=0 表示退还全部剩余金额
+func (s *PaymentService) RefundOrder(ctx context.Context, orderID int64, amount float64, reason string) (*PaymentOrder, error) {
+	order, err := s.repo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if !order.IsPaid() {
+		return nil, ErrPaymentOrderNotRefunable
+	}
+	refundable := order.RefundableAmount()
+	amount = roundPaymentAmount(amount)
+	if amount <= 0 {
+		amount = refundable
+	}
+	if amount <= 0 || amount > refundable+paymentAmountEpsilon {
+		return nil, ErrPaymentRefundExceeds
+	}
+	provider, err := s.provider(order.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := provider.Refund(ctx, order, amount, reason)
+	if err != nil {
+		log.Printf("[Payment] refund failed: order=%s amount=%.2f err=%v", order.OrderNo, amount, err)
+		return nil, infraerrors.New(http.StatusBadGateway, "PAYMENT_REFUND_FAILED", "provider refund failed").WithCause(err)
+	}
+	refundID := ""
+	if result != nil {
+		refundID = result.RefundID
+	}
+
+	// 渠道的退款回调可能先于此处到达，applyRefund 基于累计金额计算差额，天然去重
+	target := roundPaymentAmount(order.RefundedAmount + amount)
+	var refunded *PaymentOrder
+	err = s.runInTx(ctx, func(txCtx context.Context) error {
+		refunded, err = s.applyRefund(txCtx, order.OrderNo, target, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateCaches(ctx, refunded)
+	log.Printf("[Payment] order refunded: order=%s amount=%.2f refund_id=%s", order.OrderNo, amount, refundID)
+	return s.repo.GetByID(ctx, order.ID)
+}
+
+// applyRefund 将订单累计退款金额推进到 cumulative，并按差额比例回收权益（需在事务上下文中调用）
+func (s *PaymentService) applyRefund(txCtx context.Context, orderNo string, cumulative float64, reason string) (*PaymentOrder, error) {
+	order, err := s.repo.GetByOrderNoForUpdate(txCtx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	if !order.IsPaid() {
+		return nil, nil
+	}
+	if cumulative > order.Amount {
+		cumulative = order.Amount
+	}
+	delta := cumulative - order.RefundedAmount
+	if delta <= paymentAmountEpsilon {
+		return nil, nil
+	}
+	ratio := delta / order.Amount
+
+	switch order.OrderType {
+	case PaymentOrderTypeBalance:
+		// 已消费的余额允许扣成负数，由用户后续充值补齐
+		deduct := roundBalanceAmount(order.CreditAmount * ratio)
+		if err := s.userRepo.DeductBalance(txCtx, order.UserID, deduct); err != nil {
+			return nil, fmt.Errorf("deduct user balance: %w", err)
+		}
+	case PaymentOrderTypeSubscription:
+		if err := s.shortenSubscription(txCtx, order, ratio); err != nil {
+			return nil, err
+		}
+	}
+
+	status := PaymentOrderStatusPartiallyRefunded
+	if order.Amount-cumulative <= paymentAmountEpsilon {
+		status = PaymentOrderStatusRefunded
+	}
+	if err := s.repo.ApplyRefund(txCtx, order.ID, cumulative, status, time.Now()); err != nil {
+		return nil, fmt.Errorf("apply refund: %w", err)
+	}
+	order.RefundedAmount = cumulative
+	order.Status = status
+	log.Printf("[Payment] refund applied: order=%s refunded=%.2f/%.2f status=%s reason=%q", order.OrderNo, cumulative, order.Amount, status, reason)
+	return order, nil
+}
+
+// shortenSubscription 按退款比例缩短订阅有效期，不足时直接置为过期
+func (s *PaymentService) shortenSubscription(txCtx context.Context, order *PaymentOrder, ratio float64) error {
+	if order.SubscriptionID == nil {
+		return nil
+	}
+	sub, err := s.userSubRepo.GetByID(txCtx, *order.SubscriptionID)
+	if err != nil {
+		if errors.Is(err, ErrSubscriptionNotFound) {
+			return nil
+		}
+		return fmt.Errorf("get subscription: %w", err)
+	}
+	days := int(math.Ceil(float64(order.ValidityDays) * ratio))
+	newExpiresAt := sub.ExpiresAt.AddDate(0, 0, -days)
+	now := time.Now()
+	if newExpiresAt.After(now) {
+		return s.userSubRepo.ExtendExpiry(txCtx, sub.ID, newExpiresAt)
+	}
+	if err := s.userSubRepo.ExtendExpiry(txCtx, sub.ID, now); err != nil {
+		return err
+	}
+	return s.userSubRepo.UpdateStatus(txCtx, sub.ID, SubscriptionStatusExpired)
+}
+
+func (s *PaymentService) invalidateCaches(ctx context.Context, order *PaymentOrder) {
+	if order == nil {
+		return
+	}
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, order.UserID)
+	}
+	if s.billingCacheService == nil {
+		return
+	}
+	userID := order.UserID
+	switch order.OrderType {
+	case PaymentOrderTypeBalance:
+		go func() {
+			cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = s.billingCacheService.InvalidateUserBalance(cacheCtx, userID)
+		}()
+	case PaymentOrderTypeSubscription:
+		if order.GroupID == nil {
+			return
+		}
+		groupID := *order.GroupID
+		go func() {
+			cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = s.billingCacheService.InvalidateSubscription(cacheCtx, userID, groupID)
+		}()
+	}
+}
+
+func (s *PaymentService) expirePendingOrders() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	n, err := s.repo.ExpirePending(ctx, time.Now())
+	if err != nil {
+		log.Printf("[Payment] expire pending orders failed: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("[Payment] expired %d pending orders", n)
+	}
+}
+
+// runInTx 在数据库事务中执行 fn；已处于事务中或未注入 entClient 时直接执行
+func (s *PaymentService) runInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.entClient == nil || dbent.TxFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	tx, err := s.entClient.Tx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(dbent.NewTxContext(ctx, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// generatePaymentOrderNo 生成订单号：P + 时间戳 + 随机串
+func generatePaymentOrderNo() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate order no: %w", err)
+	}
+	return "P" + time.Now().UTC().Format("20060102150405") + strings.ToUpper(hex.EncodeToString(b)), nil
+}
+
+func roundPaymentAmount(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func roundBalanceAmount(v float64) float64 {
+	return math.Round(v*1e8) / 1e8
+}
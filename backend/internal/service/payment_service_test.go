@@ -0,0 +1,429 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type paymentOrderRepoStub struct {
+	mu     sync.Mutex
+	nextID int64
+	orders map[string]*PaymentOrder
+	events map[string]struct{}
+}
+
+func newPaymentOrderRepoStub() *paymentOrderRepoStub {
+	return &paymentOrderRepoStub{orders: map[string]*PaymentOrder{}, events: map[string]struct{}{}}
+}
+
+func (r *paymentOrderRepoStub) Create(ctx context.Context, order *PaymentOrder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	order.ID = r.nextID
+	order.CreatedAt = time.Now()
+	clone := *order
+	r.orders[order.OrderNo] = &clone
+	return nil
+}
+
+func (r *paymentOrderRepoStub) find(pred func(*PaymentOrder) bool) (*PaymentOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.orders {
+		if pred(o) {
+			clone := *o
+			return &clone, nil
+		}
+	}
+	return nil, ErrPaymentOrderNotFound
+}
+
+func (r *paymentOrderRepoStub) GetByID(ctx context.Context, id int64) (*PaymentOrder, error) {
+	return r.find(func(o *PaymentOrder) bool { return o.ID == id })
+}
+
+func (r *paymentOrderRepoStub) GetByOrderNo(ctx context.Context, orderNo string) (*PaymentOrder, error) {
+	return r.find(func(o *PaymentOrder) bool { return o.OrderNo == orderNo })
+}
+
+func (r *paymentOrderRepoStub) GetByOrderNoForUpdate(ctx context.Context, orderNo string) (*PaymentOrder, error) {
+	return r.GetByOrderNo(ctx, orderNo)
+}
+
+func (r *paymentOrderRepoStub) GetByProviderPaymentID(ctx context.Context, provider, providerPaymentID string) (*PaymentOrder, error) {
+	return r.find(func(o *PaymentOrder) bool { return o.Provider == provider && o.ProviderPaymentID == providerPaymentID })
+}
+
+func (r *paymentOrderRepoStub) List(ctx context.Context, params pagination.PaginationParams, filters PaymentOrderFilters) ([]PaymentOrder, *pagination.PaginationResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]PaymentOrder, 0)
+	for _, o := range r.orders {
+		if filters.UserID != nil && o.UserID != *filters.UserID {
+			continue
+		}
+		out = append(out, *o)
+	}
+	return out, &pagination.PaginationResult{Total: int64(len(out))}, nil
+}
+
+func (r *paymentOrderRepoStub) CountPendingByUser(ctx context.Context, userID int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, o := range r.orders {
+		if o.UserID == userID && o.Status == PaymentOrderStatusPending {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *paymentOrderRepoStub) update(id int64, fn func(o *PaymentOrder) bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.orders {
+		if o.ID == id {
+			return fn(o)
+		}
+	}
+	return false
+}
+
+func (r *paymentOrderRepoStub) UpdateProviderInfo(ctx context.Context, id int64, providerOrderID, payURL string) error {
+	r.update(id, func(o *PaymentOrder) bool {
+		o.ProviderOrderID = providerOrderID
+		o.PayURL = payURL
+		return true
+	})
+	return nil
+}
+
+func (r *paymentOrderRepoStub) MarkPaid(ctx context.Context, order *PaymentOrder) (bool, error) {
+	return r.update(order.ID, func(o *PaymentOrder) bool {
+		if !o.CanAcceptPayment() {
+			return false
+		}
+		o.Status = PaymentOrderStatusPaid
+		o.PaidAt = order.PaidAt
+		o.ProviderPaymentID = order.ProviderPaymentID
+		o.SubscriptionID = order.SubscriptionID
+		return true
+	}), nil
+}
+
+func (r *paymentOrderRepoStub) TransitionStatus(ctx context.Context, id int64, from, to, reason string) (bool, error) {
+	return r.update(id, func(o *PaymentOrder) bool {
+		if o.Status != from {
+			return false
+		}
+		o.Status = to
+		o.FailureReason = reason
+		return true
+	}), nil
+}
+
+func (r *paymentOrderRepoStub) ApplyRefund(ctx context.Context, id int64, refundedAmount float64, status string, refundedAt time.Time) error {
+	r.update(id, func(o *PaymentOrder) bool {
+		o.RefundedAmount = refundedAmount
+		o.Status = status
+		o.RefundedAt = &refundedAt
+		return true
+	})
+	return nil
+}
+
+func (r *paymentOrderRepoStub) ExpirePending(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for _, o := range r.orders {
+		if o.Status == PaymentOrderStatusPending && o.ExpiresAt != nil && !o.ExpiresAt.After(now) {
+			o.Status = PaymentOrderStatusExpired
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *paymentOrderRepoStub) RecordWebhookEvent(ctx context.Context, record *PaymentWebhookRecord) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := record.Provider + "|" + record.EventID
+	if _, ok := r.events[key]; ok {
+		return false, nil
+	}
+	r.events[key] = struct{}{}
+	return true, nil
+}
+
+type paymentUserRepoStub struct {
+	UserRepository
+	balances map[int64]float64
+}
+
+func (r *paymentUserRepoStub) GetByID(ctx context.Context, id int64) (*User, error) {
+	return &User{ID: id, Balance: r.balances[id]}, nil
+}
+
+func (r *paymentUserRepoStub) UpdateBalance(ctx context.Context, id int64, amount float64) error {
+	r.balances[id] += amount
+	return nil
+}
+
+func (r *paymentUserRepoStub) DeductBalance(ctx context.Context, id int64, amount float64) error {
+	r.balances[id] -= amount
+	return nil
+}
+
+type paymentGroupRepoStub struct {
+	GroupRepository
+	group *Group
+}
+
+func (r *paymentGroupRepoStub) GetByID(ctx context.Context, id int64) (*Group, error) {
+	return r.group, nil
+}
+
+type paymentUserSubRepoStub struct {
+	UserSubscriptionRepository
+	sub *UserSubscription
+}
+
+func (r *paymentUserSubRepoStub) GetByUserIDAndGroupID(ctx context.Context, userID, groupID int64) (*UserSubscription, error) {
+	if r.sub == nil {
+		return nil, ErrSubscriptionNotFound
+	}
+	return r.sub, nil
+}
+
+func (r *paymentUserSubRepoStub) Create(ctx context.Context, sub *UserSubscription) error {
+	sub.ID = 99
+	clone := *sub
+	r.sub = &clone
+	return nil
+}
+
+func (r *paymentUserSubRepoStub) GetByID(ctx context.Context, id int64) (*UserSubscription, error) {
+	if r.sub == nil || r.sub.ID != id {
+		return nil, ErrSubscriptionNotFound
+	}
+	clone := *r.sub
+	return &clone, nil
+}
+
+func (r *paymentUserSubRepoStub) ExtendExpiry(ctx context.Context, id int64, newExpiresAt time.Time) error {
+	r.sub.ExpiresAt = newExpiresAt
+	return nil
+}
+
+func (r *paymentUserSubRepoStub) UpdateStatus(ctx context.Context, id int64, status string) error {
+	r.sub.Status = status
+	return nil
+}
+
+type paymentTestEnv struct {
+	svc      *PaymentService
+	repo     *paymentOrderRepoStub
+	users    *paymentUserRepoStub
+	subs     *paymentUserSubRepoStub
+	provider *FakePaymentProvider
+}
+
+func newPaymentTestEnv(t *testing.T) *paymentTestEnv {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Payment = config.PaymentConfig{
+		Enabled:            true,
+		Currency:           "CNY",
+		BalancePerUnit:     0.5,
+		MinAmount:          1,
+		MaxAmount:          1000,
+		OrderExpireMinutes: 30,
+		MaxPendingOrders:   2,
+		NotifyBaseURL:      "https://api.example.com",
+	}
+	repo := newPaymentOrderRepoStub()
+	users := &paymentUserRepoStub{balances: map[int64]float64{}}
+	groups := &paymentGroupRepoStub{group: &Group{ID: 3, SubscriptionType: SubscriptionTypeSubscription}}
+	subs := &paymentUserSubRepoStub{}
+	subscriptionService := NewSubscriptionService(groups, subs, nil)
+	provider := NewFakePaymentProvider()
+	svc := NewPaymentService(repo, users, groups, subs, subscriptionService, nil, nil, nil, PaymentProviders{provider}, nil, cfg)
+	return &paymentTestEnv{svc: svc, repo: repo, users: users, subs: subs, provider: provider}
+}
+
+// payViaURL 模拟用户打开模拟支付链接
+func (e *paymentTestEnv) payViaURL(t *testing.T, payURL string) error {
+	t.Helper()
+	u, err := url.Parse(payURL)
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(u.Path, "/api/v1/payments/webhook/fake"))
+	return e.svc.HandleWebhook(context.Background(), PaymentProviderFake, &PaymentWebhookRequest{Method: "GET", Query: u.Query()})
+}
+
+func TestPaymentService_TopUpPaidOnceForDuplicateWebhooks(t *testing.T) {
+	env := newPaymentTestEnv(t)
+	ctx := context.Background()
+
+	order, err := env.svc.CreateTopUpOrder(ctx, 7, &CreatePaymentOrderInput{Provider: "fake", Amount: 20})
+	require.NoError(t, err)
+	require.Equal(t, PaymentOrderStatusPending, order.Status)
+	require.Equal(t, "CNY", order.Currency)
+	require.InDelta(t, 10.0, order.CreditAmount, 1e-9)
+	require.NotEmpty(t, order.PayURL)
+
+	require.NoError(t, env.payViaURL(t, order.PayURL))
+	require.NoError(t, env.payViaURL(t, order.PayURL), "duplicate callback must be acknowledged")
+	require.InDelta(t, 10.0, env.users.balances[7], 1e-9)
+
+	stored, err := env.svc.GetUserOrder(ctx, 7, order.OrderNo)
+	require.NoError(t, err)
+	require.Equal(t, PaymentOrderStatusPaid, stored.Status)
+	require.NotNil(t, stored.PaidAt)
+
+	_, err = env.svc.GetUserOrder(ctx, 8, order.OrderNo)
+	require.ErrorIs(t, err, ErrPaymentOrderNotFound)
+}
+
+func TestPaymentService_CreateTopUpOrderValidation(t *testing.T) {
+	env := newPaymentTestEnv(t)
+	ctx := context.Background()
+
+	_, err := env.svc.CreateTopUpOrder(ctx, 7, &CreatePaymentOrderInput{Provider: "fake", Amount: 0.5})
+	require.Error(t, err)
+	_, err = env.svc.CreateTopUpOrder(ctx, 7, &CreatePaymentOrderInput{Provider: "stripe", Amount: 10})
+	require.ErrorIs(t, err, ErrPaymentProviderNotFound)
+
+	_, err = env.svc.CreateTopUpOrder(ctx, 7, &CreatePaymentOrderInput{Provider: "fake", Amount: 10})
+	require.NoError(t, err)
+	_, err = env.svc.CreateTopUpOrder(ctx, 7, &CreatePaymentOrderInput{Provider: "fake", Amount: 10})
+	require.NoError(t, err)
+	_, err = env.svc.CreateTopUpOrder(ctx, 7, &CreatePaymentOrderInput{Provider: "fake", Amount: 10})
+	require.ErrorIs(t, err, ErrPaymentTooManyPending)
+
+	env.svc.cfg.Payment.Enabled = false
+	_, err = env.svc.CreateTopUpOrder(ctx, 7, &CreatePaymentOrderInput{Provider: "fake", Amount: 10})
+	require.ErrorIs(t, err, ErrPaymentDisabled)
+}
+
+func TestPaymentService_RejectsAmountMismatch(t *testing.T) {
+	env := newPaymentTestEnv(t)
+	ctx := context.Background()
+
+	order, err := env.svc.CreateTopUpOrder(ctx, 7, &CreatePaymentOrderInput{Provider: "fake", Amount: 20})
+	require.NoError(t, err)
+
+	q := url.Values{}
+	q.Set("order_no", order.OrderNo)
+	q.Set("amount", "0.01")
+	q.Set("event_id", "tampered")
+	err = env.svc.HandleWebhook(ctx, PaymentProviderFake, &PaymentWebhookRequest{Query: q})
+	require.ErrorIs(t, err, ErrPaymentAmountMismatch)
+	require.Zero(t, env.users.balances[7])
+}
+
+func TestPaymentService_LatePaymentOnExpiredOrderIsCredited(t *testing.T) {
+	env := newPaymentTestEnv(t)
+	ctx := context.Background()
+
+	order, err := env.svc.CreateTopUpOrder(ctx, 7, &CreatePaymentOrderInput{Provider: "fake", Amount: 20})
+	require.NoError(t, err)
+
+	n, err := env.repo.ExpirePending(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	require.NoError(t, env.payViaURL(t, order.PayURL))
+	require.InDelta(t, 10.0, env.users.balances[7], 1e-9)
+}
+
+func TestPaymentService_PartialAndFullRefund(t *testing.T) {
+	env := newPaymentTestEnv(t)
+	ctx := context.Background()
+
+	order, err := env.svc.CreateTopUpOrder(ctx, 7, &CreatePaymentOrderInput{Provider: "fake", Amount: 20})
+	require.NoError(t, err)
+	require.NoError(t, env.payViaURL(t, order.PayURL))
+
+	refunded, err := env.svc.RefundOrder(ctx, order.ID, 5, "partial")
+	require.NoError(t, err)
+	require.Equal(t, PaymentOrderStatusPartiallyRefunded, refunded.Status)
+	require.InDelta(t, 5.0, refunded.RefundedAmount, 1e-9)
+	require.InDelta(t, 7.5, env.users.balances[7], 1e-9)
+
+	// 渠道随后推送的累计退款回调不应重复扣减
+	q := url.Values{}
+	q.Set("type", PaymentEventRefunded)
+	q.Set("order_no", order.OrderNo)
+	q.Set("refunded_amount", "5.00")
+	require.NoError(t, env.svc.HandleWebhook(ctx, PaymentProviderFake, &PaymentWebhookRequest{Query: q}))
+	require.InDelta(t, 7.5, env.users.balances[7], 1e-9)
+
+	_, err = env.svc.RefundOrder(ctx, order.ID, 100, "too much")
+	require.ErrorIs(t, err, ErrPaymentRefundExceeds)
+
+	refunded, err = env.svc.RefundOrder(ctx, order.ID, 0, "rest")
+	require.NoError(t, err)
+	require.Equal(t, PaymentOrderStatusRefunded, refunded.Status)
+	require.InDelta(t, 0.0, env.users.balances[7], 1e-9)
+
+	_, err = env.svc.RefundOrder(ctx, order.ID, 0, "again")
+	require.ErrorIs(t, err, ErrPaymentOrderNotRefunable)
+}
+
+func TestPaymentService_SubscriptionOrderGrantAndRefund(t *testing.T) {
+	env := newPaymentTestEnv(t)
+	ctx := context.Background()
+	groupID := int64(3)
+
+	order, err := env.svc.AdminCreateOrder(ctx, 1, &AdminCreatePaymentOrderInput{
+		UserID:       7,
+		Provider:     "fake",
+		OrderType:    PaymentOrderTypeSubscription,
+		Amount:       30,
+		GroupID:      &groupID,
+		ValidityDays: 30,
+	})
+	require.NoError(t, err)
+	require.Equal(t, PaymentOrderTypeSubscription, order.OrderType)
+
+	require.NoError(t, env.payViaURL(t, order.PayURL))
+	require.NotNil(t, env.subs.sub)
+	require.Equal(t, groupID, env.subs.sub.GroupID)
+	require.Zero(t, env.users.balances[7])
+
+	stored, err := env.svc.GetByID(ctx, order.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.SubscriptionID)
+
+	_, err = env.svc.RefundOrder(ctx, order.ID, 0, "cancel")
+	require.NoError(t, err)
+	require.Equal(t, SubscriptionStatusExpired, env.subs.sub.Status)
+}
+
+func TestPaymentService_CancelPendingOrder(t *testing.T) {
+	env := newPaymentTestEnv(t)
+	ctx := context.Background()
+
+	order, err := env.svc.CreateTopUpOrder(ctx, 7, &CreatePaymentOrderInput{Provider: "fake", Amount: 20})
+	require.NoError(t, err)
+
+	canceled, err := env.svc.CancelUserOrder(ctx, 7, order.OrderNo)
+	require.NoError(t, err)
+	require.Equal(t, PaymentOrderStatusCanceled, canceled.Status)
+
+	_, err = env.svc.CancelUserOrder(ctx, 7, order.OrderNo)
+	require.ErrorIs(t, err, ErrPaymentOrderNotPending)
+}
@@ -30,11 +30,17 @@ type LiteLLMModelPricing struct {
 	InputCostPerToken           float64 `json:"input_cost_per_token"`
 	OutputCostPerToken          float64 `json:"output_cost_per_token"`
 	CacheCreationInputTokenCost float64 `json:"cache_creation_input_token_cost"`
-	CacheReadInputTokenCost     float64 `json:"cache_read_input_token_cost"`
-	LiteLLMProvider             string  `json:"litellm_provider"`
-	Mode                        string  `json:"mode"`
-	SupportsPromptCaching       bool    `json:"supports_prompt_caching"`
-	OutputCostPerImage          float64 `json:"output_cost_per_image"` // 图片生成模型每张图片价格
+	// CacheCreationInputTokenCostAbove1hr 1小时缓存写入价格（LiteLLM 字段名）
+	CacheCreationInputTokenCostAbove1hr float64 `json:"cache_creation_input_token_cost_above_1hr"`
+	CacheReadInputTokenCost             float64 `json:"cache_read_input_token_cost"`
+	LiteLLMProvider                     string  `json:"litellm_provider"`
+	Mode                                string  `json:"mode"`
+	SupportsPromptCaching               bool    `json:"supports_prompt_caching"`
+	OutputCostPerImage                  float64 `json:"output_cost_per_image"` // 图片生成模型每张图片价格
+	// 音频 token 价格（Realtime 等语音模型）
+	InputCostPerAudioToken       float64 `json:"input_cost_per_audio_token"`
+	OutputCostPerAudioToken      float64 `json:"output_cost_per_audio_token"`
+	CacheReadInputAudioTokenCost float64 `json:"cache_read_input_audio_token_cost"`
 }
 
 // PricingRemoteClient 远程价格数据获取接口
@@ -45,14 +51,18 @@ type PricingRemoteClient interface {
 
 // LiteLLMRawEntry 用于解析原始JSON数据
 type LiteLLMRawEntry struct {
-	InputCostPerToken           *float64 `json:"input_cost_per_token"`
-	OutputCostPerToken          *float64 `json:"output_cost_per_token"`
-	CacheCreationInputTokenCost *float64 `json:"cache_creation_input_token_cost"`
-	CacheReadInputTokenCost     *float64 `json:"cache_read_input_token_cost"`
-	LiteLLMProvider             string   `json:"litellm_provider"`
-	Mode                        string   `json:"mode"`
-	SupportsPromptCaching       bool     `json:"supports_prompt_caching"`
-	OutputCostPerImage          *float64 `json:"output_cost_per_image"`
+	InputCostPerToken                   *float64 `json:"input_cost_per_token"`
+	OutputCostPerToken                  *float64 `json:"output_cost_per_token"`
+	CacheCreationInputTokenCost         *float64 `json:"cache_creation_input_token_cost"`
+	CacheCreationInputTokenCostAbove1hr *float64 `json:"cache_creation_input_token_cost_above_1hr"`
+	CacheReadInputTokenCost             *float64 `json:"cache_read_input_token_cost"`
+	LiteLLMProvider                     string   `json:"litellm_provider"`
+	Mode                                string   `json:"mode"`
+	SupportsPromptCaching               bool     `json:"supports_prompt_caching"`
+	OutputCostPerImage                  *float64 `json:"output_cost_per_image"`
+	InputCostPerAudioToken              *float64 `json:"input_cost_per_audio_token"`
+	OutputCostPerAudioToken             *float64 `json:"output_cost_per_audio_token"`
+	CacheReadInputAudioTokenCost        *float64 `json:"cache_read_input_audio_token_cost"`
 }
 
 // PricingService 动态价格服务
@@ -83,7 +93,7 @@ func NewPricingService(cfg *config.Config, remoteClient PricingRemoteClient) *Pr
 // Initialize 初始化价格服务
 func (s *PricingService) Initialize() error {
 	// 确保数据目录存在
-	if err := os.MkdirAll(s.cfg.Pricing.DataDir, 0755); err != nil {
+	if err := os.MkdirAll(s.cfg.Current().Pricing.DataDir, 0755); err != nil {
 		log.Printf("[Pricing] Failed to create data directory: %v", err)
 	}
 
@@ -112,7 +122,7 @@ func (s *PricingService) Stop() {
 // startUpdateScheduler 启动定时更新调度器
 func (s *PricingService) startUpdateScheduler() {
 	// 定期检查哈希更新
-	hashInterval := time.Duration(s.cfg.Pricing.HashCheckIntervalMinutes) * time.Minute
+	hashInterval := time.Duration(s.cfg.Current().Pricing.HashCheckIntervalMinutes) * time.Minute
 	if hashInterval < time.Minute {
 		hashInterval = 10 * time.Minute
 	}
@@ -155,7 +165,7 @@ func (s *PricingService) checkAndUpdatePricing() error {
 	}
 
 	fileAge := time.Since(info.ModTime())
-	maxAge := time.Duration(s.cfg.Pricing.UpdateIntervalHours) * time.Hour
+	maxAge := time.Duration(s.cfg.Current().Pricing.UpdateIntervalHours) * time.Hour
 
 	if fileAge > maxAge {
 		log.Printf("[Pricing] Local file is %v old, updating...", fileAge.Round(time.Hour))
@@ -170,6 +180,7 @@ func (s *PricingService) checkAndUpdatePricing() error {
 
 // syncWithRemote 与远程同步（基于哈希校验）
 func (s *PricingService) syncWithRemote() error {
+	cfg := s.cfg.Current()
 	pricingFile := s.getPricingFilePath()
 
 	// 计算本地文件哈希
@@ -180,7 +191,7 @@ func (s *PricingService) syncWithRemote() error {
 	}
 
 	// 如果配置了哈希URL，从远程获取哈希进行比对
-	if s.cfg.Pricing.HashURL != "" {
+	if cfg.Pricing.HashURL != "" {
 		remoteHash, err := s.fetchRemoteHash()
 		if err != nil {
 			log.Printf("[Pricing] Failed to fetch remote hash: %v", err)
@@ -202,7 +213,7 @@ func (s *PricingService) syncWithRemote() error {
 	}
 
 	fileAge := time.Since(info.ModTime())
-	maxAge := time.Duration(s.cfg.Pricing.UpdateIntervalHours) * time.Hour
+	maxAge := time.Duration(cfg.Pricing.UpdateIntervalHours) * time.Hour
 
 	if fileAge > maxAge {
 		log.Printf("[Pricing] File is %v old, downloading...", fileAge.Round(time.Hour))
@@ -214,7 +225,8 @@ func (s *PricingService) syncWithRemote() error {
 
 // downloadPricingData 从远程下载价格数据
 func (s *PricingService) downloadPricingData() error {
-	remoteURL, err := s.validatePricingURL(s.cfg.Pricing.RemoteURL)
+	cfg := s.cfg.Current()
+	remoteURL, err := s.validatePricingURL(cfg.Pricing.RemoteURL)
 	if err != nil {
 		return err
 	}
@@ -224,7 +236,7 @@ func (s *PricingService) downloadPricingData() error {
 	defer cancel()
 
 	var expectedHash string
-	if strings.TrimSpace(s.cfg.Pricing.HashURL) != "" {
+	if strings.TrimSpace(cfg.Pricing.HashURL) != "" {
 		expectedHash, err = s.fetchRemoteHash()
 		if err != nil {
 			return fmt.Errorf("fetch remote hash: %w", err)
@@ -318,12 +330,24 @@ func (s *PricingService) parsePricingData(body []byte) (map[string]*LiteLLMModel
 		if entry.CacheCreationInputTokenCost != nil {
 			pricing.CacheCreationInputTokenCost = *entry.CacheCreationInputTokenCost
 		}
+		if entry.CacheCreationInputTokenCostAbove1hr != nil {
+			pricing.CacheCreationInputTokenCostAbove1hr = *entry.CacheCreationInputTokenCostAbove1hr
+		}
 		if entry.CacheReadInputTokenCost != nil {
 			pricing.CacheReadInputTokenCost = *entry.CacheReadInputTokenCost
 		}
 		if entry.OutputCostPerImage != nil {
 			pricing.OutputCostPerImage = *entry.OutputCostPerImage
 		}
+		if entry.InputCostPerAudioToken != nil {
+			pricing.InputCostPerAudioToken = *entry.InputCostPerAudioToken
+		}
+		if entry.OutputCostPerAudioToken != nil {
+			pricing.OutputCostPerAudioToken = *entry.OutputCostPerAudioToken
+		}
+		if entry.CacheReadInputAudioTokenCost != nil {
+			pricing.CacheReadInputAudioTokenCost = *entry.CacheReadInputAudioTokenCost
+		}
 
 		result[modelName] = pricing
 	}
@@ -374,7 +398,7 @@ func (s *PricingService) loadPricingData(filePath string) error {
 
 // useFallbackPricing 使用回退价格文件
 func (s *PricingService) useFallbackPricing() error {
-	fallbackFile := s.cfg.Pricing.FallbackFile
+	fallbackFile := s.cfg.Current().Pricing.FallbackFile
 
 	if _, err := os.Stat(fallbackFile); os.IsNotExist(err) {
 		return fmt.Errorf("fallback file not found: %s", fallbackFile)
@@ -398,7 +422,7 @@ func (s *PricingService) useFallbackPricing() error {
 
 // fetchRemoteHash 从远程获取哈希值
 func (s *PricingService) fetchRemoteHash() (string, error) {
-	hashURL, err := s.validatePricingURL(s.cfg.Pricing.HashURL)
+	hashURL, err := s.validatePricingURL(s.cfg.Current().Pricing.HashURL)
 	if err != nil {
 		return "", err
 	}
@@ -726,12 +750,12 @@ func (s *PricingService) ForceUpdate() error {
 
 // getPricingFilePath 获取价格文件路径
 func (s *PricingService) getPricingFilePath() string {
-	return filepath.Join(s.cfg.Pricing.DataDir, "model_pricing.json")
+	return filepath.Join(s.cfg.Current().Pricing.DataDir, "model_pricing.json")
 }
 
 // getHashFilePath 获取哈希文件路径
 func (s *PricingService) getHashFilePath() string {
-	return filepath.Join(s.cfg.Pricing.DataDir, "model_pricing.sha256")
+	return filepath.Join(s.cfg.Current().Pricing.DataDir, "model_pricing.sha256")
 }
 
 // isNumeric 检查字符串是否为纯数字
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// 系统提示词模板注入方式
+const (
+	PromptTemplateModePrepend = "prepend" // 放在客户端系统提示词之前
+	PromptTemplateModeAppend  = "append"  // 放在客户端系统提示词之后
+	PromptTemplateModeReplace = "replace" // 替换客户端系统提示词
+)
+
+// 系统提示词模板适用的请求格式
+const (
+	PromptTemplateFormatAnthropic       = "anthropic"
+	PromptTemplateFormatOpenAIResponses = "openai_responses"
+	PromptTemplateFormatGemini          = "gemini"
+)
+
+// 模板变量
+const (
+	PromptTemplateVarUserEmail = "{{user_email}}"
+	PromptTemplateVarKeyName   = "{{key_name}}"
+	PromptTemplateVarDate      = "{{date}}"
+)
+
+var (
+	ErrPromptTemplateNotFound = infraerrors.NotFound("PROMPT_TEMPLATE_NOT_FOUND", "prompt template not found")
+	ErrPromptTemplateInvalid  = infraerrors.BadRequest("PROMPT_TEMPLATE_INVALID", "invalid prompt template")
+)
+
+// PromptTemplate 系统提示词模板；GroupID 与 APIKeyID 均为空时对所有请求生效
+type PromptTemplate struct {
+	ID       int64
+	Name     string
+	GroupID  *int64
+	APIKeyID *int64
+	Enabled  bool
+	Mode     string
+	// Content 支持变量 {{user_email}}、{{key_name}}、{{date}}
+	Content   string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PromptTemplateRepository 系统提示词模板存储
+type PromptTemplateRepository interface {
+	List(ctx context.Context) ([]PromptTemplate, error)
+	GetByID(ctx context.Context, id int64) (*PromptTemplate, error)
+	Create(ctx context.Context, template *PromptTemplate) error
+	Update(ctx context.Context, template *PromptTemplate) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// promptTemplateParts 合并后的注入内容（变量已替换）；
+// 多个 replace 模板时范围最具体（最后应用）的生效
+type promptTemplateParts struct {
+	Prepend    []string
+	Append     []string
+	Replace    string
+	HasReplace bool
+}
+
+func (p *promptTemplateParts) prependText() string { return strings.Join(p.Prepend, "\n\n") }
+func (p *promptTemplateParts) appendText() string  { return strings.Join(p.Append, "\n\n") }
+
+// promptTextBlock Anthropic system 文本块
+type promptTextBlock struct {
+	Type         string              `json:"type"`
+	Text         string              `json:"text"`
+	CacheControl *promptCacheControl `json:"cache_control,omitempty"`
+}
+
+type promptCacheControl struct {
+	Type string `json:"type"`
+}
+
+// applyAnthropicPromptTemplates 改写 Anthropic Messages 请求的 system：
+// 开头的 Claude Code 提示词始终保留在最前（OAuth 账号依赖它），其后依次为 prepend、客户端（或 replace）、append。
+// 注入内容之后没有缓存断点时为最后一个注入块添加 cache_control，并按 enforceCacheControlLimit 限制总数。
+func applyAnthropicPromptTemplates(body []byte, parts *promptTemplateParts) ([]byte, error) {
+	var lead, client []json.RawMessage
+	system := gjson.GetBytes(body, "system")
+	switch {
+	case system.Type == gjson.String:
+		if text := system.String(); text != "" {
+			block, _ := json.Marshal(promptTextBlock{Type: "text", Text: text})
+			client = append(client, block)
+		}
+	case system.IsArray():
+		for _, item := range system.Array() {
+			client = append(client, json.RawMessage(item.Raw))
+		}
+	}
+	for len(client) > 0 && hasClaudeCodePrefix(gjson.GetBytes(client[0], "text").String()) {
+		lead = append(lead, client[0])
+		client = client[1:]
+	}
+
+	type injected struct {
+		text string
+		raw  json.RawMessage
+	}
+	var blocks []injected
+	for _, raw := range lead {
+		blocks = append(blocks, injected{raw: raw})
+	}
+	if text := parts.prependText(); text != "" {
+		blocks = append(blocks, injected{text: text})
+	}
+	if parts.HasReplace {
+		if parts.Replace != "" {
+			blocks = append(blocks, injected{text: parts.Replace})
+		}
+	} else {
+		for _, raw := range client {
+			blocks = append(blocks, injected{raw: raw})
+		}
+	}
+	if text := parts.appendText(); text != "" {
+		blocks = append(blocks, injected{text: text})
+	}
+
+	// 最后一个注入块之后已有缓存断点时无需再添加
+	lastInjected := -1
+	for i, b := range blocks {
+		if b.raw == nil {
+			lastInjected = i
+		} else if lastInjected >= 0 && gjson.GetBytes(b.raw, "cache_control").Exists() {
+			lastInjected = -1
+		}
+	}
+
+	out := make([]json.RawMessage, 0, len(blocks))
+	for i, b := range blocks {
+		if b.raw != nil {
+			out = append(out, b.raw)
+			continue
+		}
+		block := promptTextBlock{Type: "text", Text: b.text}
+		if i == lastInjected {
+			block.CacheControl = &promptCacheControl{Type: "ephemeral"}
+		}
+		raw, err := json.Marshal(block)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, raw)
+	}
+
+	var result []byte
+	var err error
+	if len(out) == 0 {
+		result, err = sjson.DeleteBytes(body, "system")
+	} else {
+		raw, marshalErr := json.Marshal(out)
+		if marshalErr != nil {
+			return nil, marshalErr
+		}
+		result, err = sjson.SetRawBytes(body, "system", raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return enforceCacheControlLimit(result), nil
+}
+
+// applyOpenAIResponsesPromptTemplates 以 developer 消息注入 OpenAI Responses 请求的 input：
+// OAuth 账号转发时 instructions 会被替换为 Codex 指令，因此不能写入 instructions。
+// input 开头的 system/developer 消息视为客户端系统提示词；replace 会移除它们与 instructions。
+// 带 previous_response_id 的后续轮次上游已保存此前注入的消息，不再重复注入。
+func applyOpenAIResponsesPromptTemplates(body []byte, parts *promptTemplateParts) ([]byte, error) {
+	if strings.TrimSpace(gjson.GetBytes(body, "previous_response_id").String()) != "" {
+		return body, nil
+	}
+
+	var items []json.RawMessage
+	input := gjson.GetBytes(body, "input")
+	switch {
+	case input.Type == gjson.String:
+		raw, _ := json.Marshal(map[string]any{"type": "message", "role": "user", "content": input.String()})
+		items = append(items, raw)
+	case input.IsArray():
+		for _, item := range input.Array() {
+			items = append(items, json.RawMessage(item.Raw))
+		}
+	}
+	leading := 0
+	for leading < len(items) {
+		role := gjson.GetBytes(items[leading], "role").String()
+		if role != "system" && role != "developer" {
+			break
+		}
+		leading++
+	}
+
+	developerMessage := func(text string) (json.RawMessage, error) {
+		return json.Marshal(map[string]any{
+			"type":    "message",
+			"role":    "developer",
+			"content": []map[string]string{{"type": "input_text", "text": text}},
+		})
+	}
+
+	out := make([]json.RawMessage, 0, len(items)+3)
+	if text := parts.prependText(); text != "" {
+		msg, err := developerMessage(text)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, msg)
+	}
+	if parts.HasReplace {
+		if parts.Replace != "" {
+			msg, err := developerMessage(parts.Replace)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, msg)
+		}
+	} else {
+		out = append(out, items[:leading]...)
+	}
+	if text := parts.appendText(); text != "" {
+		msg, err := developerMessage(text)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, msg)
+	}
+	out = append(out, items[leading:]...)
+
+	raw, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	result, err := sjson.SetRawBytes(body, "input", raw)
+	if err != nil {
+		return nil, err
+	}
+	if parts.HasReplace && gjson.GetBytes(result, "instructions").Exists() {
+		return sjson.DeleteBytes(result, "instructions")
+	}
+	return result, nil
+}
+
+// applyGeminiPromptTemplates 改写 Gemini 原生请求的 systemInstruction.parts。
+// 使用 cachedContent 时上游不允许同时指定 systemInstruction，此时不注入。
+func applyGeminiPromptTemplates(body []byte, parts *promptTemplateParts) ([]byte, error) {
+	if gjson.GetBytes(body, "cachedContent").Exists() || gjson.GetBytes(body, "cached_content").Exists() {
+		return body, nil
+	}
+	key := "systemInstruction"
+	if !gjson.GetBytes(body, key).Exists() && gjson.GetBytes(body, "system_instruction").Exists() {
+		key = "system_instruction"
+	}
+
+	var client []json.RawMessage
+	if existing := gjson.GetBytes(body, key+".parts"); existing.IsArray() {
+		for _, part := range existing.Array() {
+			client = append(client, json.RawMessage(part.Raw))
+		}
+	}
+
+	textPart := func(text string) json.RawMessage {
+		raw, _ := json.Marshal(map[string]string{"text": text})
+		return raw
+	}
+	out := make([]json.RawMessage, 0, len(client)+2)
+	if text := parts.prependText(); text != "" {
+		out = append(out, textPart(text))
+	}
+	if parts.HasReplace {
+		if parts.Replace != "" {
+			out = append(out, textPart(parts.Replace))
+		}
+	} else {
+		out = append(out, client...)
+	}
+	if text := parts.appendText(); text != "" {
+		out = append(out, textPart(text))
+	}
+
+	if len(out) == 0 {
+		return sjson.DeleteBytes(body, key)
+	}
+	raw, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	if !gjson.GetBytes(body, key).IsObject() {
+		return sjson.SetRawBytes(body, key, append(append([]byte(`{"parts":`), raw...), '}'))
+	}
+	return sjson.SetRawBytes(body, key+".parts", raw)
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+)
+
+const (
+	promptTemplateReloadWorkerName = "prompt_template_reload"
+	// promptTemplateReloadInterval 多实例部署时从数据库同步模板的周期
+	promptTemplateReloadInterval = time.Minute
+	promptTemplateMaxNameLength  = 100
+)
+
+// SetPromptTemplateInput 创建或更新系统提示词模板
+type SetPromptTemplateInput struct {
+	Name     string
+	GroupID  *int64
+	APIKeyID *int64
+	Enabled  bool
+	Mode     string
+	Content  string
+}
+
+// PromptTemplateService 系统提示词模板：转发前按全局、分组、API Key 依次将管理员配置的提示词注入请求。
+//
+// 同一范围内按 ID 顺序应用；prepend/append 的内容依次拼接，replace 以范围最具体的模板为准。
+// 支持 Anthropic Messages、OpenAI Responses 与 Gemini 原生格式，变量在每次请求时替换。
+// 模板缓存在内存中，管理端修改后立即重新加载，其他实例按 promptTemplateReloadInterval 周期同步。
+type PromptTemplateService struct {
+	repo        PromptTemplateRepository
+	timingWheel *TimingWheelService
+
+	mu        sync.RWMutex
+	templates []PromptTemplate
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewPromptTemplateService 创建系统提示词模板服务
+func NewPromptTemplateService(repo PromptTemplateRepository, timingWheel *TimingWheelService) *PromptTemplateService {
+	return &PromptTemplateService{repo: repo, timingWheel: timingWheel}
+}
+
+// Start 加载模板并启动周期同步
+func (s *PromptTemplateService) Start() {
+	if s == nil || s.repo == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.reloadLogged()
+		if s.timingWheel != nil {
+			s.timingWheel.ScheduleRecurring(promptTemplateReloadWorkerName, promptTemplateReloadInterval, s.reloadLogged)
+		}
+	})
+}
+
+// Stop 停止周期同步
+func (s *PromptTemplateService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(promptTemplateReloadWorkerName)
+		}
+	})
+}
+
+func (s *PromptTemplateService) reloadLogged() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.Reload(ctx); err != nil {
+		log.Printf("[PromptTemplate] reload failed: %v", err)
+	}
+}
+
+// Reload 从数据库重新加载已启用的模板
+func (s *PromptTemplateService) Reload(ctx context.Context) error {
+	templates, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	enabled := make([]PromptTemplate, 0, len(templates))
+	for _, t := range templates {
+		if t.Enabled {
+			enabled = append(enabled, t)
+		}
+	}
+	s.mu.Lock()
+	s.templates = enabled
+	s.mu.Unlock()
+	return nil
+}
+
+// resolve 合并适用于 API Key 的模板并替换变量；没有适用模板时返回 nil
+func (s *PromptTemplateService) resolve(apiKey *APIKey) *promptTemplateParts {
+	if s == nil || apiKey == nil {
+		return nil
+	}
+	s.mu.RLock()
+	all := s.templates
+	s.mu.RUnlock()
+	if len(all) == 0 {
+		return nil
+	}
+
+	var global, group, key []*PromptTemplate
+	for i := range all {
+		t := &all[i]
+		switch {
+		case t.APIKeyID != nil:
+			if *t.APIKeyID == apiKey.ID {
+				key = append(key, t)
+			}
+		case t.GroupID != nil:
+			if apiKey.GroupID != nil && *t.GroupID == *apiKey.GroupID {
+				group = append(group, t)
+			}
+		default:
+			global = append(global, t)
+		}
+	}
+	applicable := append(append(global, group...), key...)
+	if len(applicable) == 0 {
+		return nil
+	}
+
+	email := ""
+	if apiKey.User != nil {
+		email = apiKey.User.Email
+	}
+	replacer := strings.NewReplacer(
+		PromptTemplateVarUserEmail, email,
+		PromptTemplateVarKeyName, apiKey.Name,
+		PromptTemplateVarDate, timezone.Now().Format("2006-01-02"),
+	)
+	parts := &promptTemplateParts{}
+	for _, t := range applicable {
+		content := replacer.Replace(t.Content)
+		switch t.Mode {
+		case PromptTemplateModePrepend:
+			parts.Prepend = append(parts.Prepend, content)
+		case PromptTemplateModeAppend:
+			parts.Append = append(parts.Append, content)
+		case PromptTemplateModeReplace:
+			parts.Replace = content
+			parts.HasReplace = true
+		}
+	}
+	return parts
+}
+
+// Apply 按请求格式将适用模板注入请求体；没有适用模板或请求体无法改写时原样返回
+func (s *PromptTemplateService) Apply(apiKey *APIKey, format string, body []byte) []byte {
+	parts := s.resolve(apiKey)
+	if parts == nil {
+		return body
+	}
+	var (
+		out []byte
+		err error
+	)
+	switch format {
+	case PromptTemplateFormatAnthropic:
+		out, err = applyAnthropicPromptTemplates(body, parts)
+	case PromptTemplateFormatOpenAIResponses:
+		out, err = applyOpenAIResponsesPromptTemplates(body, parts)
+	case PromptTemplateFormatGemini:
+		out, err = applyGeminiPromptTemplates(body, parts)
+	default:
+		return body
+	}
+	if err != nil {
+		log.Printf("[PromptTemplate] apply failed (api_key=%d format=%s): %v", apiKey.ID, format, err)
+		return body
+	}
+	return out
+}
+
+// List 列出所有模板
+func (s *PromptTemplateService) List(ctx context.Context) ([]PromptTemplate, error) {
+	return s.repo.List(ctx)
+}
+
+// Create 创建模板
+func (s *PromptTemplateService) Create(ctx context.Context, input *SetPromptTemplateInput) (*PromptTemplate, error) {
+	template := &PromptTemplate{}
+	if err := applyPromptTemplateInput(template, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, template); err != nil {
+		return nil, err
+	}
+	s.reloadLogged()
+	return template, nil
+}
+
+// Update 更新模板
+func (s *PromptTemplateService) Update(ctx context.Context, id int64, input *SetPromptTemplateInput) (*PromptTemplate, error) {
+	template, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyPromptTemplateInput(template, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, template); err != nil {
+		return nil, err
+	}
+	s.reloadLogged()
+	return template, nil
+}
+
+// Delete 删除模板
+func (s *PromptTemplateService) Delete(ctx context.Context, id int64) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.reloadLogged()
+	return nil
+}
+
+// applyPromptTemplateInput 规范化输入并校验
+func applyPromptTemplateInput(template *PromptTemplate, input *SetPromptTemplateInput) error {
+	template.Name = strings.TrimSpace(input.Name)
+	template.GroupID = input.GroupID
+	template.APIKeyID = input.APIKeyID
+	template.Enabled = input.Enabled
+	template.Mode = strings.TrimSpace(input.Mode)
+	template.Content = input.Content
+
+	switch {
+	case template.Name == "" || len(template.Name) > promptTemplateMaxNameLength:
+		return infraerrors.BadRequest(infraerrors.Reason(ErrPromptTemplateInvalid), "name must be 1-100 characters")
+	case template.GroupID != nil && template.APIKeyID != nil:
+		return infraerrors.BadRequest(infraerrors.Reason(ErrPromptTemplateInvalid), "group_id and api_key_id cannot both be set")
+	}
+	switch template.Mode {
+	case PromptTemplateModePrepend, PromptTemplateModeAppend:
+		if strings.TrimSpace(template.Content) == "" {
+			return infraerrors.BadRequest(infraerrors.Reason(ErrPromptTemplateInvalid), "content is required")
+		}
+	case PromptTemplateModeReplace:
+		// 空内容的 replace 模板用于移除客户端系统提示词
+	default:
+		return infraerrors.BadRequest(infraerrors.Reason(ErrPromptTemplateInvalid), "mode must be prepend, append or replace")
+	}
+	return nil
+}
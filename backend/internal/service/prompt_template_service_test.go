@@ -0,0 +1,197 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+type promptTemplateRepoStub struct {
+	templates []PromptTemplate
+}
+
+func (r *promptTemplateRepoStub) List(context.Context) ([]PromptTemplate, error) {
+	return r.templates, nil
+}
+func (r *promptTemplateRepoStub) GetByID(context.Context, int64) (*PromptTemplate, error) {
+	return nil, ErrPromptTemplateNotFound
+}
+func (r *promptTemplateRepoStub) Create(context.Context, *PromptTemplate) error { return nil }
+func (r *promptTemplateRepoStub) Update(context.Context, *PromptTemplate) error { return nil }
+func (r *promptTemplateRepoStub) Delete(context.Context, int64) error           { return nil }
+
+func newPromptTemplateTestService(t *testing.T, templates ...PromptTemplate) *PromptTemplateService {
+	t.Helper()
+	svc := NewPromptTemplateService(&promptTemplateRepoStub{templates: templates}, nil)
+	require.NoError(t, svc.Reload(context.Background()))
+	return svc
+}
+
+func promptTemplateTestAPIKey() *APIKey {
+	groupID := int64(2)
+	return &APIKey{ID: 7, Name: "ci-bot", GroupID: &groupID, User: &User{ID: 1, Email: "dev@example.com"}}
+}
+
+func TestPromptTemplateServiceResolveScopes(t *testing.T) {
+	groupID, otherGroupID, keyID := int64(2), int64(3), int64(7)
+	svc := newPromptTemplateTestService(t,
+		PromptTemplate{ID: 1, Enabled: true, Mode: PromptTemplateModePrepend, Content: "org for {{user_email}}"},
+		PromptTemplate{ID: 2, Enabled: true, GroupID: &otherGroupID, Mode: PromptTemplateModePrepend, Content: "other group"},
+		PromptTemplate{ID: 3, Enabled: false, Mode: PromptTemplateModeAppend, Content: "disabled"},
+		PromptTemplate{ID: 4, Enabled: true, APIKeyID: &keyID, Mode: PromptTemplateModeReplace, Content: "key {{key_name}}"},
+		PromptTemplate{ID: 5, Enabled: true, GroupID: &groupID, Mode: PromptTemplateModeReplace, Content: "group"},
+		PromptTemplate{ID: 6, Enabled: true, GroupID: &groupID, Mode: PromptTemplateModeAppend, Content: "on {{date}}"},
+	)
+
+	parts := svc.resolve(promptTemplateTestAPIKey())
+	require.NotNil(t, parts)
+	require.Equal(t, []string{"org for dev@example.com"}, parts.Prepend)
+	require.Equal(t, []string{"on " + timezone.Now().Format("2006-01-02")}, parts.Append)
+	require.True(t, parts.HasReplace)
+	require.Equal(t, "key ci-bot", parts.Replace, "API Key 范围的 replace 优先于分组")
+
+	require.Nil(t, newPromptTemplateTestService(t).resolve(promptTemplateTestAPIKey()))
+	var nilSvc *PromptTemplateService
+	body := []byte(`{"system":"x"}`)
+	require.Equal(t, body, nilSvc.Apply(promptTemplateTestAPIKey(), PromptTemplateFormatAnthropic, body))
+}
+
+func TestApplyAnthropicPromptTemplates(t *testing.T) {
+	parts := &promptTemplateParts{Prepend: []string{"A"}, Append: []string{"Z"}}
+
+	out, err := applyAnthropicPromptTemplates([]byte(`{"model":"claude","system":"client"}`), parts)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"type":"text","text":"A"},
+		{"type":"text","text":"client"},
+		{"type":"text","text":"Z","cache_control":{"type":"ephemeral"}}
+	]`, gjson.GetBytes(out, "system").Raw)
+
+	// Claude Code 提示词保持在最前；客户端块已有缓存断点时不再为 prepend 添加
+	body := []byte(`{"system":[
+		{"type":"text","text":"You are Claude Code, Anthropic's official CLI for Claude.","cache_control":{"type":"ephemeral"}},
+		{"type":"text","text":"client","cache_control":{"type":"ephemeral"}}
+	],"messages":[]}`)
+	out, err = applyAnthropicPromptTemplates(body, &promptTemplateParts{Prepend: []string{"A"}})
+	require.NoError(t, err)
+	system := gjson.GetBytes(out, "system").Array()
+	require.Len(t, system, 3)
+	require.True(t, hasClaudeCodePrefix(system[0].Get("text").String()))
+	require.Equal(t, "A", system[1].Get("text").String())
+	require.False(t, system[1].Get("cache_control").Exists())
+
+	// replace 移除客户端提示词但保留 Claude Code 提示词
+	out, err = applyAnthropicPromptTemplates(body, &promptTemplateParts{Replace: "R", HasReplace: true})
+	require.NoError(t, err)
+	system = gjson.GetBytes(out, "system").Array()
+	require.Len(t, system, 2)
+	require.Equal(t, "R", system[1].Get("text").String())
+	require.True(t, system[1].Get("cache_control").Exists())
+
+	// 空 replace 且没有其他块时删除 system
+	out, err = applyAnthropicPromptTemplates([]byte(`{"system":"client"}`), &promptTemplateParts{HasReplace: true})
+	require.NoError(t, err)
+	require.False(t, gjson.GetBytes(out, "system").Exists())
+}
+
+func TestApplyAnthropicPromptTemplatesRespectsCacheControlLimit(t *testing.T) {
+	body := []byte(`{"system":[{"type":"text","text":"s","cache_control":{"type":"ephemeral"}}],"messages":[
+		{"role":"user","content":[
+			{"type":"text","text":"1","cache_control":{"type":"ephemeral"}},
+			{"type":"text","text":"2","cache_control":{"type":"ephemeral"}},
+			{"type":"text","text":"3","cache_control":{"type":"ephemeral"}}
+		]}
+	]}`)
+	out, err := applyAnthropicPromptTemplates(body, &promptTemplateParts{Append: []string{"Z"}})
+	require.NoError(t, err)
+
+	var count int
+	for _, path := range []string{"system.#.cache_control", "messages.0.content.#.cache_control"} {
+		count += len(gjson.GetBytes(out, path).Array())
+	}
+	require.Equal(t, maxCacheControlBlocks, count)
+	require.True(t, gjson.GetBytes(out, "system.1.cache_control").Exists(), "system 中的缓存断点优先保留")
+	require.False(t, gjson.GetBytes(out, "messages.0.content.0.cache_control").Exists())
+}
+
+func TestApplyOpenAIResponsesPromptTemplates(t *testing.T) {
+	parts := &promptTemplateParts{Prepend: []string{"A"}, Append: []string{"Z"}}
+
+	out, err := applyOpenAIResponsesPromptTemplates([]byte(`{"model":"gpt-5","input":"hi"}`), parts)
+	require.NoError(t, err)
+	input := gjson.GetBytes(out, "input").Array()
+	require.Len(t, input, 3)
+	require.Equal(t, "developer", input[0].Get("role").String())
+	require.Equal(t, "A", input[0].Get("content.0.text").String())
+	require.Equal(t, "Z", input[1].Get("content.0.text").String())
+	require.Equal(t, "hi", input[2].Get("content").String())
+
+	body := []byte(`{"instructions":"be brief","input":[
+		{"type":"message","role":"system","content":"client"},
+		{"type":"message","role":"user","content":"hi"}
+	]}`)
+	out, err = applyOpenAIResponsesPromptTemplates(body, parts)
+	require.NoError(t, err)
+	input = gjson.GetBytes(out, "input").Array()
+	require.Len(t, input, 4)
+	require.Equal(t, "client", input[1].Get("content").String())
+	require.Equal(t, "Z", input[2].Get("content.0.text").String())
+	require.Equal(t, "be brief", gjson.GetBytes(out, "instructions").String())
+
+	out, err = applyOpenAIResponsesPromptTemplates(body, &promptTemplateParts{Replace: "R", HasReplace: true})
+	require.NoError(t, err)
+	input = gjson.GetBytes(out, "input").Array()
+	require.Len(t, input, 2)
+	require.Equal(t, "R", input[0].Get("content.0.text").String())
+	require.Equal(t, "user", input[1].Get("role").String())
+	require.False(t, gjson.GetBytes(out, "instructions").Exists())
+
+	followUp := []byte(`{"previous_response_id":"resp_1","input":"next"}`)
+	out, err = applyOpenAIResponsesPromptTemplates(followUp, parts)
+	require.NoError(t, err)
+	require.Equal(t, followUp, out)
+}
+
+func TestApplyGeminiPromptTemplates(t *testing.T) {
+	parts := &promptTemplateParts{Prepend: []string{"A"}, Append: []string{"Z"}}
+
+	out, err := applyGeminiPromptTemplates([]byte(`{"contents":[]}`), parts)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"parts":[{"text":"A"},{"text":"Z"}]}`, gjson.GetBytes(out, "systemInstruction").Raw)
+
+	out, err = applyGeminiPromptTemplates([]byte(`{"system_instruction":{"role":"user","parts":[{"text":"client"}]}}`), parts)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"role":"user","parts":[{"text":"A"},{"text":"client"},{"text":"Z"}]}`, gjson.GetBytes(out, "system_instruction").Raw)
+	require.False(t, gjson.GetBytes(out, "systemInstruction").Exists())
+
+	out, err = applyGeminiPromptTemplates([]byte(`{"systemInstruction":{"parts":[{"text":"client"}]}}`), &promptTemplateParts{Replace: "R", HasReplace: true})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"parts":[{"text":"R"}]}`, gjson.GetBytes(out, "systemInstruction").Raw)
+
+	cached := []byte(`{"cachedContent":"cachedContents/abc","contents":[]}`)
+	out, err = applyGeminiPromptTemplates(cached, parts)
+	require.NoError(t, err)
+	require.Equal(t, cached, out)
+}
+
+func TestApplyPromptTemplateInputValidation(t *testing.T) {
+	groupID, keyID := int64(1), int64(2)
+	cases := []SetPromptTemplateInput{
+		{Name: " ", Mode: PromptTemplateModePrepend, Content: "x"},
+		{Name: "a", Mode: "wrap", Content: "x"},
+		{Name: "a", Mode: PromptTemplateModeAppend, Content: "  "},
+		{Name: "a", GroupID: &groupID, APIKeyID: &keyID, Mode: PromptTemplateModePrepend, Content: "x"},
+	}
+	for _, input := range cases {
+		require.ErrorIs(t, applyPromptTemplateInput(&PromptTemplate{}, &input), ErrPromptTemplateInvalid)
+	}
+
+	template := &PromptTemplate{}
+	require.NoError(t, applyPromptTemplateInput(template, &SetPromptTemplateInput{Name: " strip ", Enabled: true, Mode: PromptTemplateModeReplace}))
+	require.Equal(t, "strip", template.Name)
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 倍率规则（写入 usage_logs.rate_rule）
+const (
+	RateRuleDefault        = "default"          // 未绑定分组，使用配置默认倍率
+	RateRuleGroup          = "group"            // 分组倍率
+	RateRuleUserOverride   = "user_override"    // 用户覆盖，格式 user_override:<id>
+	RateRuleAPIKeyOverride = "api_key_override" // API Key 覆盖，格式 api_key_override:<id>
+	RateRuleVolumeTier     = "volume_tier"      // 阶梯折扣，以 +volume_tier:<id> 追加在基础规则之后
+)
+
+var (
+	ErrRateMultiplierOverrideNotFound = infraerrors.NotFound("RATE_MULTIPLIER_OVERRIDE_NOT_FOUND", "rate multiplier override not found")
+	ErrRateMultiplierOverrideInvalid  = infraerrors.BadRequest("RATE_MULTIPLIER_OVERRIDE_INVALID", "exactly one of user_id or api_key_id is required and rate_multiplier must be positive")
+	ErrRateMultiplierOverrideKeyGroup = infraerrors.BadRequest("RATE_MULTIPLIER_OVERRIDE_KEY_GROUP", "api key does not belong to this group")
+	ErrVolumeDiscountTierNotFound     = infraerrors.NotFound("VOLUME_DISCOUNT_TIER_NOT_FOUND", "volume discount tier not found")
+	ErrVolumeDiscountTierInvalid      = infraerrors.BadRequest("VOLUME_DISCOUNT_TIER_INVALID", "min_monthly_spend must be positive and discount_percent must be between 0 and 100")
+)
+
+// RateMultiplierOverride 在分组内按用户或 API Key 覆盖分组倍率（UserID 与 APIKeyID 二选一）
+type RateMultiplierOverride struct {
+	ID             int64
+	GroupID        int64
+	UserID         *int64
+	APIKeyID       *int64
+	RateMultiplier float64
+	Notes          string
+	CreatedBy      *int64
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Valid 校验覆盖目标与倍率
+func (o *RateMultiplierOverride) Valid() bool {
+	if o.GroupID <= 0 || o.RateMultiplier <= 0 {
+		return false
+	}
+	return (o.UserID == nil) != (o.APIKeyID == nil)
+}
+
+func (o *RateMultiplierOverride) target() string {
+	if o.APIKeyID != nil {
+		return fmt.Sprintf("api_key=%d", *o.APIKeyID)
+	}
+	if o.UserID != nil {
+		return fmt.Sprintf("user=%d", *o.UserID)
+	}
+	return "none"
+}
+
+// VolumeDiscountTier 月消费阶梯折扣：当月余额计费消费达到 MinMonthlySpend 后倍率打 (100-DiscountPercent)% 折
+type VolumeDiscountTier struct {
+	ID int64
+	// GroupID 为空表示适用于所有分组
+	GroupID         *int64
+	MinMonthlySpend float64
+	DiscountPercent float64
+	Notes           string
+	CreatedBy       *int64
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// Valid 校验阈值与折扣
+func (t *VolumeDiscountTier) Valid() bool {
+	return t.MinMonthlySpend > 0 && t.DiscountPercent > 0 && t.DiscountPercent < 100
+}
+
+// groupKey 全局阶梯返回 0
+func (t *VolumeDiscountTier) groupKey() int64 {
+	if t.GroupID == nil {
+		return 0
+	}
+	return *t.GroupID
+}
+
+// Factor 折扣系数
+func (t *VolumeDiscountTier) Factor() float64 {
+	return 1 - t.DiscountPercent/100
+}
+
+// RateMultiplierResolution 一次请求的有效倍率及其来源
+type RateMultiplierResolution struct {
+	Multiplier float64
+	Rule       string
+}
+
+// RateMultiplierOverrideFilters 覆盖列表筛选
+type RateMultiplierOverrideFilters struct {
+	GroupID  *int64
+	UserID   *int64
+	APIKeyID *int64
+}
+
+// RateMultiplierUsageRow 按 日/用户/API Key/分组 汇总的余额计费用量（用于预览）
+type RateMultiplierUsageRow struct {
+	Day       time.Time
+	UserID    int64
+	UserEmail string
+	APIKeyID  int64
+	// GroupID 0 表示未绑定分组
+	GroupID             int64
+	GroupName           string
+	GroupRateMultiplier float64
+	Requests            int64
+	TotalCost           float64
+	ActualCost          float64
+}
+
+// RateMultiplierRuleRepository 倍率覆盖与阶梯折扣存储
+type RateMultiplierRuleRepository interface {
+	ListAllOverrides(ctx context.Context) ([]RateMultiplierOverride, error)
+	ListOverrides(ctx context.Context, params pagination.PaginationParams, filters RateMultiplierOverrideFilters) ([]RateMultiplierOverride, *pagination.PaginationResult, error)
+	// UpsertOverride 同一分组内同一用户（或 API Key）只保留一条覆盖
+	UpsertOverride(ctx context.Context, override *RateMultiplierOverride) error
+	DeleteOverride(ctx context.Context, id int64) error
+
+	ListTiers(ctx context.Context) ([]VolumeDiscountTier, error)
+	// UpsertTier 同一分组（或全局）内同一阈值只保留一条阶梯
+	UpsertTier(ctx context.Context, tier *VolumeDiscountTier) error
+	DeleteTier(ctx context.Context, id int64) error
+
+	// SumMonthlySpend 用户自 since 起余额计费的实际消费
+	SumMonthlySpend(ctx context.Context, userID int64, since time.Time) (float64, error)
+	// ListDailyUsage 按日汇总 [start, end) 内余额计费的用量，按日期与用户排序；userID 为空表示所有用户
+	ListDailyUsage(ctx context.Context, start, end time.Time, userID *int64) ([]RateMultiplierUsageRow, error)
+}
+
+// rateMultiplierRules 供计费路径做内存匹配的规则索引
+type rateMultiplierRules struct {
+	userOverrides   map[int64]map[int64]*RateMultiplierOverride // group_id -> user_id
+	apiKeyOverrides map[int64]map[int64]*RateMultiplierOverride // group_id -> api_key_id
+	// groupTiers 按阈值降序排列；key 0 为全局阶梯
+	groupTiers map[int64][]VolumeDiscountTier
+}
+
+func newRateMultiplierRules(overrides []RateMultiplierOverride, tiers []VolumeDiscountTier) *rateMultiplierRules {
+	rules := &rateMultiplierRules{
+		userOverrides:   make(map[int64]map[int64]*RateMultiplierOverride),
+		apiKeyOverrides: make(map[int64]map[int64]*RateMultiplierOverride),
+		groupTiers:      make(map[int64][]VolumeDiscountTier),
+	}
+	for i := range overrides {
+		o := &overrides[i]
+		switch {
+		case o.APIKeyID != nil:
+			if rules.apiKeyOverrides[o.GroupID] == nil {
+				rules.apiKeyOverrides[o.GroupID] = make(map[int64]*RateMultiplierOverride)
+			}
+			rules.apiKeyOverrides[o.GroupID][*o.APIKeyID] = o
+		case o.UserID != nil:
+			if rules.userOverrides[o.GroupID] == nil {
+				rules.userOverrides[o.GroupID] = make(map[int64]*RateMultiplierOverride)
+			}
+			rules.userOverrides[o.GroupID][*o.UserID] = o
+		}
+	}
+	for _, t := range tiers {
+		key := t.groupKey()
+		rules.groupTiers[key] = append(rules.groupTiers[key], t)
+	}
+	for key := range rules.groupTiers {
+		list := rules.groupTiers[key]
+		sort.Slice(list, func(i, j int) bool { return list[i].MinMonthlySpend > list[j].MinMonthlySpend })
+	}
+	return rules
+}
+
+// tiersFor 分组存在专属阶梯时只使用专属阶梯，否则使用全局阶梯
+func (r *rateMultiplierRules) tiersFor(groupID int64) []VolumeDiscountTier {
+	if r == nil {
+		return nil
+	}
+	if groupID > 0 {
+		if tiers := r.groupTiers[groupID]; len(tiers) > 0 {
+			return tiers
+		}
+	}
+	return r.groupTiers[0]
+}
+
+// base 返回覆盖后的基础倍率：API Key 覆盖 > 用户覆盖 > 分组倍率（未绑定分组时为默认倍率）
+func (r *rateMultiplierRules) base(groupID, userID, apiKeyID int64, groupMultiplier, defaultMultiplier float64) RateMultiplierResolution {
+	if groupID <= 0 {
+		return RateMultiplierResolution{Multiplier: defaultMultiplier, Rule: RateRuleDefault}
+	}
+	if r != nil {
+		if o := r.apiKeyOverrides[groupID][apiKeyID]; o != nil {
+			return RateMultiplierResolution{Multiplier: o.RateMultiplier, Rule: fmt.Sprintf("%s:%d", RateRuleAPIKeyOverride, o.ID)}
+		}
+		if o := r.userOverrides[groupID][userID]; o != nil {
+			return RateMultiplierResolution{Multiplier: o.RateMultiplier, Rule: fmt.Sprintf("%s:%d", RateRuleUserOverride, o.ID)}
+		}
+	}
+	return RateMultiplierResolution{Multiplier: groupMultiplier, Rule: RateRuleGroup}
+}
+
+// applyVolumeTier 按当月消费选择最高的已达阈值阶梯并打折
+func applyVolumeTier(res RateMultiplierResolution, tiers []VolumeDiscountTier, monthlySpend float64) RateMultiplierResolution {
+	for i := range tiers {
+		if monthlySpend >= tiers[i].MinMonthlySpend {
+			res.Multiplier *= tiers[i].Factor()
+			res.Rule = fmt.Sprintf("%s+%s:%d", res.Rule, RateRuleVolumeTier, tiers[i].ID)
+			return res
+		}
+	}
+	return res
+}
@@ -0,0 +1,478 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+)
+
+const (
+	rateMultiplierReloadWorkerName = "rate_multiplier_rule_reload"
+	// rateMultiplierReloadInterval 多实例部署时从数据库同步倍率规则的周期
+	rateMultiplierReloadInterval = time.Minute
+	// rateMultiplierSpendCacheTTL 用户当月消费的缓存时间（阶梯判断允许短暂滞后）
+	rateMultiplierSpendCacheTTL = time.Minute
+	// rateMultiplierSpendCacheMax 消费缓存条目上限，超过后清理过期条目
+	rateMultiplierSpendCacheMax = 10000
+	// rateMultiplierPreviewMaxRows 预览返回的明细行上限
+	rateMultiplierPreviewMaxRows = 200
+)
+
+// SetRateMultiplierOverrideInput 设置分组内用户或 API Key 的倍率覆盖（UserID 与 APIKeyID 二选一）
+type SetRateMultiplierOverrideInput struct {
+	GroupID        int64
+	UserID         *int64
+	APIKeyID       *int64
+	RateMultiplier float64
+	Notes          string
+}
+
+// SetVolumeDiscountTierInput 设置阶梯折扣；GroupID 为空表示全局阶梯
+type SetVolumeDiscountTierInput struct {
+	GroupID         *int64
+	MinMonthlySpend float64
+	DiscountPercent float64
+	Notes           string
+}
+
+// RateMultiplierPreviewInput 预览规则对上月用量的影响
+type RateMultiplierPreviewInput struct {
+	GroupID *int64
+	UserID  *int64
+	// Overrides/Tiers 为 nil 时使用当前规则，非 nil 时以其替换当前规则作为拟议规则
+	Overrides *[]RateMultiplierOverride
+	Tiers     *[]VolumeDiscountTier
+}
+
+// RateMultiplierPreviewRow 单个用户在单个分组内的预览结果
+type RateMultiplierPreviewRow struct {
+	UserID       int64
+	UserEmail    string
+	GroupID      int64
+	GroupName    string
+	Requests     int64
+	ActualCost   float64
+	CurrentCost  float64
+	ProposedCost float64
+}
+
+// RateMultiplierPreview 上月余额计费用量按当前规则与拟议规则重算的结果
+//
+// ActualCost 为历史实扣（含分销加价），CurrentCost/ProposedCost 为不含分销加价的重算值；
+// 阶梯按自然日粒度判断（以当日开始时的累计消费选择阶梯）。
+type RateMultiplierPreview struct {
+	PeriodStart  time.Time
+	PeriodEnd    time.Time
+	Requests     int64
+	ActualCost   float64
+	CurrentCost  float64
+	ProposedCost float64
+	// Rows 按差额绝对值降序，最多 rateMultiplierPreviewMaxRows 行；TotalRows 为截断前的行数
+	Rows      []RateMultiplierPreviewRow
+	TotalRows int
+}
+
+type rateMultiplierSpendEntry struct {
+	since     time.Time
+	amount    float64
+	expiresAt time.Time
+}
+
+// RateMultiplierService 客户级计费倍率：用户 / API Key 覆盖与月消费阶梯折扣
+//
+// 规则缓存在内存中，计费路径只做内存匹配；用户当月消费按 rateMultiplierSpendCacheTTL 缓存，
+// 仅在存在适用阶梯时查询。管理端修改后立即重新加载，其他实例按 rateMultiplierReloadInterval 周期同步。
+type RateMultiplierService struct {
+	repo        RateMultiplierRuleRepository
+	groupRepo   GroupRepository
+	userRepo    UserRepository
+	apiKeyRepo  APIKeyRepository
+	timingWheel *TimingWheelService
+	cfg         *config.Config
+
+	mu        sync.RWMutex
+	overrides []RateMultiplierOverride
+	tiers     []VolumeDiscountTier
+	rules     *rateMultiplierRules
+
+	spendMu sync.Mutex
+	spend   map[int64]rateMultiplierSpendEntry
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewRateMultiplierService 创建客户级倍率服务
+func NewRateMultiplierService(
+	repo RateMultiplierRuleRepository,
+	groupRepo GroupRepository,
+	userRepo UserRepository,
+	apiKeyRepo APIKeyRepository,
+	timingWheel *TimingWheelService,
+	cfg *config.Config,
+) *RateMultiplierService {
+	return &RateMultiplierService{
+		repo:        repo,
+		groupRepo:   groupRepo,
+		userRepo:    userRepo,
+		apiKeyRepo:  apiKeyRepo,
+		timingWheel: timingWheel,
+		cfg:         cfg,
+		spend:       make(map[int64]rateMultiplierSpendEntry),
+	}
+}
+
+// Start 加载规则并启动周期同步
+func (s *RateMultiplierService) Start() {
+	if s == nil || s.repo == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.reloadLogged()
+		if s.timingWheel != nil {
+			s.timingWheel.ScheduleRecurring(rateMultiplierReloadWorkerName, rateMultiplierReloadInterval, s.reloadLogged)
+		}
+	})
+}
+
+// Stop 停止周期同步
+func (s *RateMultiplierService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(rateMultiplierReloadWorkerName)
+		}
+	})
+}
+
+func (s *RateMultiplierService) reloadLogged() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.Reload(ctx); err != nil {
+		log.Printf("[RateMultiplier] reload failed: %v", err)
+	}
+}
+
+// Reload 从数据库重新加载覆盖与阶梯
+func (s *RateMultiplierService) Reload(ctx context.Context) error {
+	overrides, err := s.repo.ListAllOverrides(ctx)
+	if err != nil {
+		return err
+	}
+	tiers, err := s.repo.ListTiers(ctx)
+	if err != nil {
+		return err
+	}
+	rules := newRateMultiplierRules(overrides, tiers)
+	s.mu.Lock()
+	s.overrides = overrides
+	s.tiers = tiers
+	s.rules = rules
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *RateMultiplierService) defaultMultiplier() float64 {
+	if s != nil && s.cfg != nil {
+		return s.cfg.Default.RateMultiplier
+	}
+	return 1.0
+}
+
+// Resolve 返回本次请求的有效倍率：
+// API Key 覆盖 > 用户覆盖 > 分组倍率（未绑定分组时为默认倍率），再按用户当月消费叠加阶梯折扣。
+// s 为 nil 时退化为分组倍率（未绑定分组时为 1.0）。
+func (s *RateMultiplierService) Resolve(ctx context.Context, apiKey *APIKey, group *Group) RateMultiplierResolution {
+	var groupID int64
+	var groupMultiplier float64
+	if group != nil {
+		groupID = group.ID
+		groupMultiplier = group.RateMultiplier
+	}
+	var userID, apiKeyID int64
+	if apiKey != nil {
+		userID = apiKey.UserID
+		apiKeyID = apiKey.ID
+	}
+	if s == nil {
+		return (*rateMultiplierRules)(nil).base(groupID, userID, apiKeyID, groupMultiplier, s.defaultMultiplier())
+	}
+
+	s.mu.RLock()
+	rules := s.rules
+	s.mu.RUnlock()
+
+	res := rules.base(groupID, userID, apiKeyID, groupMultiplier, s.defaultMultiplier())
+	tiers := rules.tiersFor(groupID)
+	if len(tiers) == 0 || userID <= 0 {
+		return res
+	}
+	spend, err := s.monthlySpend(ctx, userID, time.Now())
+	if err != nil {
+		log.Printf("[RateMultiplier] monthly spend lookup failed: user=%d err=%v", userID, err)
+		return res
+	}
+	return applyVolumeTier(res, tiers, spend)
+}
+
+// monthlySpend 用户当月余额计费消费（带短期缓存）
+func (s *RateMultiplierService) monthlySpend(ctx context.Context, userID int64, now time.Time) (float64, error) {
+	since := timezone.StartOfMonth(now)
+
+	s.spendMu.Lock()
+	entry, ok := s.spend[userID]
+	s.spendMu.Unlock()
+	if ok && entry.since.Equal(since) && now.Before(entry.expiresAt) {
+		return entry.amount, nil
+	}
+
+	amount, err := s.repo.SumMonthlySpend(ctx, userID, since)
+	if err != nil {
+		return 0, err
+	}
+
+	s.spendMu.Lock()
+	if len(s.spend) >= rateMultiplierSpendCacheMax {
+		for id, e := range s.spend {
+			if !now.Before(e.expiresAt) {
+				delete(s.spend, id)
+			}
+		}
+	}
+	s.spend[userID] = rateMultiplierSpendEntry{since: since, amount: amount, expiresAt: now.Add(rateMultiplierSpendCacheTTL)}
+	s.spendMu.Unlock()
+	return amount, nil
+}
+
+// ListOverrides 管理端覆盖列表
+func (s *RateMultiplierService) ListOverrides(ctx context.Context, params pagination.PaginationParams, filters RateMultiplierOverrideFilters) ([]RateMultiplierOverride, *pagination.PaginationResult, error) {
+	return s.repo.ListOverrides(ctx, params, filters)
+}
+
+// SetOverride 设置（新增或替换）覆盖；API Key 覆盖要求该 Key 当前绑定在此分组
+func (s *RateMultiplierService) SetOverride(ctx context.Context, input *SetRateMultiplierOverrideInput, adminID int64) (*RateMultiplierOverride, error) {
+	override := &RateMultiplierOverride{
+		GroupID:        input.GroupID,
+		UserID:         input.UserID,
+		APIKeyID:       input.APIKeyID,
+		RateMultiplier: input.RateMultiplier,
+		Notes:          strings.TrimSpace(input.Notes),
+	}
+	if !override.Valid() {
+		return nil, ErrRateMultiplierOverrideInvalid
+	}
+	if _, err := s.groupRepo.GetByID(ctx, override.GroupID); err != nil {
+		return nil, err
+	}
+	if override.UserID != nil {
+		if _, err := s.userRepo.GetByID(ctx, *override.UserID); err != nil {
+			return nil, err
+		}
+	}
+	if override.APIKeyID != nil {
+		key, err := s.apiKeyRepo.GetByID(ctx, *override.APIKeyID)
+		if err != nil {
+			return nil, err
+		}
+		if key.GroupID == nil || *key.GroupID != override.GroupID {
+			return nil, ErrRateMultiplierOverrideKeyGroup
+		}
+	}
+	if adminID > 0 {
+		override.CreatedBy = &adminID
+	}
+	if err := s.repo.UpsertOverride(ctx, override); err != nil {
+		return nil, err
+	}
+	log.Printf("[RateMultiplier] admin %d set override #%d group=%d %s multiplier=%.4f",
+		adminID, override.ID, override.GroupID, override.target(), override.RateMultiplier)
+	s.reloadLogged()
+	return override, nil
+}
+
+// DeleteOverride 删除覆盖
+func (s *RateMultiplierService) DeleteOverride(ctx context.Context, id int64) error {
+	if err := s.repo.DeleteOverride(ctx, id); err != nil {
+		return err
+	}
+	s.reloadLogged()
+	return nil
+}
+
+// ListTiers 管理端阶梯列表
+func (s *RateMultiplierService) ListTiers(ctx context.Context) ([]VolumeDiscountTier, error) {
+	return s.repo.ListTiers(ctx)
+}
+
+// SetTier 设置（新增或替换）阶梯；同一分组内阈值相同的阶梯会被替换
+func (s *RateMultiplierService) SetTier(ctx context.Context, input *SetVolumeDiscountTierInput, adminID int64) (*VolumeDiscountTier, error) {
+	tier := &VolumeDiscountTier{
+		GroupID:         input.GroupID,
+		MinMonthlySpend: input.MinMonthlySpend,
+		DiscountPercent: input.DiscountPercent,
+		Notes:           strings.TrimSpace(input.Notes),
+	}
+	if !tier.Valid() {
+		return nil, ErrVolumeDiscountTierInvalid
+	}
+	if tier.GroupID != nil {
+		if _, err := s.groupRepo.GetByID(ctx, *tier.GroupID); err != nil {
+			return nil, err
+		}
+	}
+	if adminID > 0 {
+		tier.CreatedBy = &adminID
+	}
+	if err := s.repo.UpsertTier(ctx, tier); err != nil {
+		return nil, err
+	}
+	log.Printf("[RateMultiplier] admin %d set volume tier #%d group=%d min_spend=%.2f discount=%.2f%%",
+		adminID, tier.ID, tier.groupKey(), tier.MinMonthlySpend, tier.DiscountPercent)
+	s.reloadLogged()
+	return tier, nil
+}
+
+// DeleteTier 删除阶梯
+func (s *RateMultiplierService) DeleteTier(ctx context.Context, id int64) error {
+	if err := s.repo.DeleteTier(ctx, id); err != nil {
+		return err
+	}
+	s.reloadLogged()
+	return nil
+}
+
+// Preview 按当前规则与拟议规则重算上一个自然月的余额计费用量
+func (s *RateMultiplierService) Preview(ctx context.Context, input *RateMultiplierPreviewInput) (*RateMultiplierPreview, error) {
+	if input == nil {
+		input = &RateMultiplierPreviewInput{}
+	}
+	for _, t := range derefTiers(input.Tiers) {
+		if !t.Valid() {
+			return nil, ErrVolumeDiscountTierInvalid
+		}
+	}
+	for _, o := range derefOverrides(input.Overrides) {
+		if !o.Valid() {
+			return nil, ErrRateMultiplierOverrideInvalid
+		}
+	}
+
+	s.mu.RLock()
+	current := s.rules
+	overrides, tiers := s.overrides, s.tiers
+	s.mu.RUnlock()
+	if input.Overrides != nil {
+		overrides = *input.Overrides
+	}
+	if input.Tiers != nil {
+		tiers = *input.Tiers
+	}
+	proposed := newRateMultiplierRules(overrides, tiers)
+
+	end := timezone.StartOfMonth(time.Now())
+	start := end.AddDate(0, -1, 0)
+	rows, err := s.repo.ListDailyUsage(ctx, start, end, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultMultiplier := s.defaultMultiplier()
+	currentCosts := simulateRateMultiplier(rows, current, defaultMultiplier)
+	proposedCosts := simulateRateMultiplier(rows, proposed, defaultMultiplier)
+
+	preview := &RateMultiplierPreview{PeriodStart: start, PeriodEnd: end}
+	type rowKey struct{ userID, groupID int64 }
+	byKey := make(map[rowKey]*RateMultiplierPreviewRow)
+	for i := range rows {
+		r := &rows[i]
+		// 分组筛选只作用于结果；阶梯所需的累计消费始终按用户全部分组计算
+		if input.GroupID != nil && r.GroupID != *input.GroupID {
+			continue
+		}
+		k := rowKey{r.UserID, r.GroupID}
+		out := byKey[k]
+		if out == nil {
+			out = &RateMultiplierPreviewRow{UserID: r.UserID, UserEmail: r.UserEmail, GroupID: r.GroupID, GroupName: r.GroupName}
+			byKey[k] = out
+		}
+		out.Requests += r.Requests
+		out.ActualCost += r.ActualCost
+		out.CurrentCost += currentCosts[i]
+		out.ProposedCost += proposedCosts[i]
+
+		preview.Requests += r.Requests
+		preview.ActualCost += r.ActualCost
+		preview.CurrentCost += currentCosts[i]
+		preview.ProposedCost += proposedCosts[i]
+	}
+
+	preview.Rows = make([]RateMultiplierPreviewRow, 0, len(byKey))
+	for _, r := range byKey {
+		preview.Rows = append(preview.Rows, *r)
+	}
+	sort.Slice(preview.Rows, func(i, j int) bool {
+		di := math.Abs(preview.Rows[i].ProposedCost - preview.Rows[i].CurrentCost)
+		dj := math.Abs(preview.Rows[j].ProposedCost - preview.Rows[j].CurrentCost)
+		if di != dj {
+			return di > dj
+		}
+		if preview.Rows[i].UserID != preview.Rows[j].UserID {
+			return preview.Rows[i].UserID < preview.Rows[j].UserID
+		}
+		return preview.Rows[i].GroupID < preview.Rows[j].GroupID
+	})
+	preview.TotalRows = len(preview.Rows)
+	if len(preview.Rows) > rateMultiplierPreviewMaxRows {
+		preview.Rows = preview.Rows[:rateMultiplierPreviewMaxRows]
+	}
+	return preview, nil
+}
+
+// simulateRateMultiplier 按规则重算每行费用；rows 需按日期升序，阶梯以当日开始时的累计消费判断
+func simulateRateMultiplier(rows []RateMultiplierUsageRow, rules *rateMultiplierRules, defaultMultiplier float64) []float64 {
+	costs := make([]float64, len(rows))
+	spend := make(map[int64]float64)
+	for i := 0; i < len(rows); {
+		j := i
+		for j < len(rows) && rows[j].Day.Equal(rows[i].Day) {
+			j++
+		}
+		dayCost := make(map[int64]float64)
+		for k := i; k < j; k++ {
+			r := &rows[k]
+			res := rules.base(r.GroupID, r.UserID, r.APIKeyID, r.GroupRateMultiplier, defaultMultiplier)
+			res = applyVolumeTier(res, rules.tiersFor(r.GroupID), spend[r.UserID])
+			costs[k] = r.TotalCost * res.Multiplier
+			dayCost[r.UserID] += costs[k]
+		}
+		for userID, cost := range dayCost {
+			spend[userID] += cost
+		}
+		i = j
+	}
+	return costs
+}
+
+func derefOverrides(v *[]RateMultiplierOverride) []RateMultiplierOverride {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func derefTiers(v *[]VolumeDiscountTier) []VolumeDiscountTier {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
@@ -0,0 +1,176 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type rateMultiplierRepoStub struct {
+	overrides  []RateMultiplierOverride
+	tiers      []VolumeDiscountTier
+	spend      map[int64]float64
+	spendErr   error
+	spendCalls int
+	usage      []RateMultiplierUsageRow
+}
+
+func (r *rateMultiplierRepoStub) ListAllOverrides(ctx context.Context) ([]RateMultiplierOverride, error) {
+	return append([]RateMultiplierOverride(nil), r.overrides...), nil
+}
+
+func (r *rateMultiplierRepoStub) ListOverrides(ctx context.Context, params pagination.PaginationParams, filters RateMultiplierOverrideFilters) ([]RateMultiplierOverride, *pagination.PaginationResult, error) {
+	return r.overrides, &pagination.PaginationResult{Total: int64(len(r.overrides))}, nil
+}
+
+func (r *rateMultiplierRepoStub) UpsertOverride(ctx context.Context, override *RateMultiplierOverride) error {
+	override.ID = int64(len(r.overrides) + 1)
+	r.overrides = append(r.overrides, *override)
+	return nil
+}
+
+func (r *rateMultiplierRepoStub) DeleteOverride(ctx context.Context, id int64) error {
+	return ErrRateMultiplierOverrideNotFound
+}
+
+func (r *rateMultiplierRepoStub) ListTiers(ctx context.Context) ([]VolumeDiscountTier, error) {
+	return append([]VolumeDiscountTier(nil), r.tiers...), nil
+}
+
+func (r *rateMultiplierRepoStub) UpsertTier(ctx context.Context, tier *VolumeDiscountTier) error {
+	tier.ID = int64(len(r.tiers) + 1)
+	r.tiers = append(r.tiers, *tier)
+	return nil
+}
+
+func (r *rateMultiplierRepoStub) DeleteTier(ctx context.Context, id int64) error {
+	return ErrVolumeDiscountTierNotFound
+}
+
+func (r *rateMultiplierRepoStub) SumMonthlySpend(ctx context.Context, userID int64, since time.Time) (float64, error) {
+	r.spendCalls++
+	return r.spend[userID], r.spendErr
+}
+
+func (r *rateMultiplierRepoStub) ListDailyUsage(ctx context.Context, start, end time.Time, userID *int64) ([]RateMultiplierUsageRow, error) {
+	return r.usage, nil
+}
+
+func newRateMultiplierTestService(t *testing.T, repo *rateMultiplierRepoStub) *RateMultiplierService {
+	t.Helper()
+	cfg := &config.Config{Default: config.DefaultConfig{RateMultiplier: 1.5}}
+	svc := NewRateMultiplierService(repo, nil, nil, nil, nil, cfg)
+	require.NoError(t, svc.Reload(context.Background()))
+	return svc
+}
+
+func TestRateMultiplierResolvePrecedence(t *testing.T) {
+	userID, keyID := int64(7), int64(70)
+	repo := &rateMultiplierRepoStub{overrides: []RateMultiplierOverride{
+		{ID: 1, GroupID: 2, UserID: &userID, RateMultiplier: 0.9},
+		{ID: 2, GroupID: 2, APIKeyID: &keyID, RateMultiplier: 0.5},
+	}}
+	svc := newRateMultiplierTestService(t, repo)
+	group := &Group{ID: 2, RateMultiplier: 1.2}
+	ctx := context.Background()
+
+	res := svc.Resolve(ctx, &APIKey{ID: keyID, UserID: userID}, group)
+	require.Equal(t, RateMultiplierResolution{Multiplier: 0.5, Rule: "api_key_override:2"}, res)
+
+	res = svc.Resolve(ctx, &APIKey{ID: 71, UserID: userID}, group)
+	require.Equal(t, RateMultiplierResolution{Multiplier: 0.9, Rule: "user_override:1"}, res)
+
+	res = svc.Resolve(ctx, &APIKey{ID: 80, UserID: 8}, group)
+	require.Equal(t, RateMultiplierResolution{Multiplier: 1.2, Rule: RateRuleGroup}, res)
+
+	res = svc.Resolve(ctx, &APIKey{ID: keyID, UserID: userID}, &Group{ID: 3, RateMultiplier: 2})
+	require.Equal(t, RateMultiplierResolution{Multiplier: 2, Rule: RateRuleGroup}, res, "overrides are scoped to their group")
+
+	res = svc.Resolve(ctx, &APIKey{ID: keyID, UserID: userID}, nil)
+	require.Equal(t, RateMultiplierResolution{Multiplier: 1.5, Rule: RateRuleDefault}, res)
+
+	require.Zero(t, repo.spendCalls, "spend is only looked up when tiers apply")
+
+	var nilSvc *RateMultiplierService
+	require.Equal(t, RateMultiplierResolution{Multiplier: 1, Rule: RateRuleDefault}, nilSvc.Resolve(ctx, nil, nil))
+}
+
+func TestRateMultiplierResolveVolumeTiers(t *testing.T) {
+	groupID := int64(2)
+	repo := &rateMultiplierRepoStub{
+		tiers: []VolumeDiscountTier{
+			{ID: 1, MinMonthlySpend: 100, DiscountPercent: 10},
+			{ID: 2, MinMonthlySpend: 1000, DiscountPercent: 20},
+			{ID: 3, GroupID: &groupID, MinMonthlySpend: 50, DiscountPercent: 50},
+		},
+		spend: map[int64]float64{7: 500, 8: 20},
+	}
+	svc := newRateMultiplierTestService(t, repo)
+	ctx := context.Background()
+
+	res := svc.Resolve(ctx, &APIKey{ID: 70, UserID: 7}, &Group{ID: 1, RateMultiplier: 1})
+	require.InDelta(t, 0.9, res.Multiplier, 1e-9)
+	require.Equal(t, "group+volume_tier:1", res.Rule)
+
+	// 分组专属阶梯替代全局阶梯
+	res = svc.Resolve(ctx, &APIKey{ID: 70, UserID: 7}, &Group{ID: 2, RateMultiplier: 2})
+	require.InDelta(t, 1.0, res.Multiplier, 1e-9)
+	require.Equal(t, "group+volume_tier:3", res.Rule)
+
+	res = svc.Resolve(ctx, &APIKey{ID: 80, UserID: 8}, &Group{ID: 1, RateMultiplier: 1})
+	require.Equal(t, RateMultiplierResolution{Multiplier: 1, Rule: RateRuleGroup}, res)
+
+	require.Equal(t, 2, repo.spendCalls, "spend is cached per user across groups")
+	svc.Resolve(ctx, &APIKey{ID: 70, UserID: 7}, &Group{ID: 1, RateMultiplier: 1})
+	require.Equal(t, 2, repo.spendCalls, "monthly spend is cached")
+
+	repo.spendErr = errors.New("db down")
+	res = svc.Resolve(ctx, &APIKey{ID: 90, UserID: 9}, &Group{ID: 1, RateMultiplier: 1})
+	require.Equal(t, RateMultiplierResolution{Multiplier: 1, Rule: RateRuleGroup}, res, "spend errors fall back to the undiscounted multiplier")
+}
+
+func TestRateMultiplierPreviewComparesCurrentAndProposedRules(t *testing.T) {
+	day1 := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	repo := &rateMultiplierRepoStub{usage: []RateMultiplierUsageRow{
+		{Day: day1, UserID: 7, UserEmail: "a@example.com", APIKeyID: 70, GroupID: 1, GroupName: "claude", GroupRateMultiplier: 1, Requests: 10, TotalCost: 100, ActualCost: 100},
+		{Day: day1, UserID: 8, UserEmail: "b@example.com", APIKeyID: 80, GroupID: 1, GroupName: "claude", GroupRateMultiplier: 1, Requests: 1, TotalCost: 5, ActualCost: 5},
+		{Day: day2, UserID: 7, UserEmail: "a@example.com", APIKeyID: 70, GroupID: 1, GroupName: "claude", GroupRateMultiplier: 1, Requests: 10, TotalCost: 100, ActualCost: 100},
+		{Day: day2, UserID: 7, UserEmail: "a@example.com", APIKeyID: 71, GroupID: 0, GroupRateMultiplier: 1, Requests: 2, TotalCost: 10, ActualCost: 15},
+	}}
+	svc := newRateMultiplierTestService(t, repo)
+
+	userID := int64(8)
+	overrides := []RateMultiplierOverride{{ID: 1, GroupID: 1, UserID: &userID, RateMultiplier: 0.5}}
+	tiers := []VolumeDiscountTier{{ID: 1, MinMonthlySpend: 100, DiscountPercent: 25}}
+	preview, err := svc.Preview(context.Background(), &RateMultiplierPreviewInput{Overrides: &overrides, Tiers: &tiers})
+	require.NoError(t, err)
+
+	require.EqualValues(t, 23, preview.Requests)
+	require.InDelta(t, 220, preview.ActualCost, 1e-9)
+	require.InDelta(t, 220, preview.CurrentCost, 1e-9)
+	// 用户 7 第二天开始时累计消费 100，达到阶梯；未分组行按默认倍率 1.5 计算后同样打折
+	require.InDelta(t, 100+75+10*1.5*0.75+2.5, preview.ProposedCost, 1e-9)
+	require.Equal(t, 3, preview.TotalRows)
+	require.EqualValues(t, 7, preview.Rows[0].UserID)
+	require.EqualValues(t, 1, preview.Rows[0].GroupID)
+	require.InDelta(t, 175, preview.Rows[0].ProposedCost, 1e-9)
+
+	groupID := int64(0)
+	preview, err = svc.Preview(context.Background(), &RateMultiplierPreviewInput{GroupID: &groupID, Tiers: &tiers})
+	require.NoError(t, err)
+	require.Len(t, preview.Rows, 1)
+	require.InDelta(t, 15, preview.CurrentCost, 1e-9)
+	require.InDelta(t, 11.25, preview.ProposedCost, 1e-9, "tier spend still counts usage in other groups")
+
+	bad := []VolumeDiscountTier{{MinMonthlySpend: 100, DiscountPercent: 100}}
+	_, err = svc.Preview(context.Background(), &RateMultiplierPreviewInput{Tiers: &bad})
+	require.ErrorIs(t, err, ErrVolumeDiscountTierInvalid)
+}
@@ -422,7 +422,7 @@ func (s *RateLimitService) shouldScopeClaudeSonnetRateLimit(account *Account, re
 // handle529 处理529过载错误
 // 根据配置设置过载冷却时间
 func (s *RateLimitService) handle529(ctx context.Context, account *Account) {
-	cooldownMinutes := s.cfg.RateLimit.OverloadCooldownMinutes
+	cooldownMinutes := s.cfg.Current().RateLimit.OverloadCooldownMinutes
 	if cooldownMinutes <= 0 {
 		cooldownMinutes = 10 // 默认10分钟
 	}
@@ -20,6 +20,9 @@ type RedeemCode struct {
 	GroupID      *int64
 	ValidityDays int
 
+	// IssuerUserID 发行该兑换码的分销商（nil 表示管理员发行）
+	IssuerUserID *int64
+
 	User  *User
 	Group *Group
 }
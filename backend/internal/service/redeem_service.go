@@ -253,7 +253,11 @@ func (s *RedeemService) Redeem(ctx context.Context, userID int64, code string) (
 	if err != nil {
 		return nil, fmt.Errorf("get user: %w", err)
 	}
-	_ = user // 使用变量避免未使用错误
+
+	// 分销商发行的兑换码仅限其下级用户兑换
+	if redeemCode.IssuerUserID != nil && (user.ParentUserID == nil || *user.ParentUserID != *redeemCode.IssuerUserID) {
+		return nil, ErrRedeemCodeRestricted
+	}
 
 	// 使用数据库事务保证兑换码标记与权益发放的原子性
 	tx, err := s.entClient.Tx(ctx)
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+const (
+	defaultHedgePercentile     = 0.95
+	defaultHedgeMinDelayMs     = 1000
+	defaultHedgeMaxDelayMs     = 30000
+	defaultHedgeInitialDelayMs = 5000
+	defaultHedgeMinSamples     = 20
+	defaultHedgeWindowSize     = 200
+	defaultHedgeBudgetRatio    = 0.05
+	defaultHedgeBudgetBurst    = 5.0
+)
+
+// RequestHedgeStats 单个分组的对冲统计（进程内，重启清零）
+type RequestHedgeStats struct {
+	GroupID int64
+	// Requests 开启对冲的分组中可对冲的请求数（流式 /v1/messages）
+	Requests int64
+	// Hedged 实际发出的对冲请求数
+	Hedged int64
+	// HedgeWins 对冲账号先返回响应的次数
+	HedgeWins int64
+	// BudgetDenied 达到对冲延迟但预算不足而未对冲的次数
+	BudgetDenied int64
+	// NoAccount 达到对冲延迟但没有可立即占用槽位的第二个账号的次数
+	NoAccount int64
+	// DelayMs 当前对冲延迟
+	DelayMs int64
+	// Samples 当前首个内容事件耗时样本数
+	Samples int
+	// BudgetTokens 当前剩余对冲预算（令牌数）
+	BudgetTokens float64
+}
+
+type hedgeGroupState struct {
+	samples []int
+	next    int
+	tokens  float64
+	stats   RequestHedgeStats
+}
+
+// RequestHedgeService 请求对冲的延迟估计与预算控制。
+//
+// 上游账号迟迟不返回首字时，用户只能干等，而分组内其他空闲账号本可以应答。
+// 开启 hedge_enabled 的分组中，流式请求超过对冲延迟仍未收到首个内容事件（content_block_delta）时，
+// 同一请求会发往第二个账号，先收到首个内容事件的一方胜出，另一方被取消，仅计费胜出的一方。
+// Anthropic 流式响应会立即返回响应头与 message_start，因此不以响应头或首个 SSE 事件计时。
+//   - 对冲延迟取分组最近 window_size 个首个内容事件耗时的 percentile 分位数，并限制在 [min_delay_ms, max_delay_ms]；
+//   - 预算为令牌桶：每个可对冲请求补充 budget_ratio 个令牌（上限 budget_burst），每次对冲消耗 1 个，
+//     保证对冲带来的额外上游请求不超过 budget_ratio。
+type RequestHedgeService struct {
+	cfg *config.Config
+
+	mu     sync.Mutex
+	groups map[int64]*hedgeGroupState
+}
+
+// NewRequestHedgeService 创建请求对冲服务
+func NewRequestHedgeService(cfg *config.Config) *RequestHedgeService {
+	return &RequestHedgeService{
+		cfg:    cfg,
+		groups: make(map[int64]*hedgeGroupState),
+	}
+}
+
+func (s *RequestHedgeService) settings() config.GatewayHedgingConfig {
+	out := config.GatewayHedgingConfig{
+		Percentile:     defaultHedgePercentile,
+		MinDelayMs:     defaultHedgeMinDelayMs,
+		MaxDelayMs:     defaultHedgeMaxDelayMs,
+		InitialDelayMs: defaultHedgeInitialDelayMs,
+		MinSamples:     defaultHedgeMinSamples,
+		WindowSize:     defaultHedgeWindowSize,
+		BudgetRatio:    defaultHedgeBudgetRatio,
+		BudgetBurst:    defaultHedgeBudgetBurst,
+	}
+	if s.cfg == nil {
+		return out
+	}
+	cfg := s.cfg.Current().Gateway.Hedging
+	if cfg.Percentile > 0 && cfg.Percentile < 1 {
+		out.Percentile = cfg.Percentile
+	}
+	if cfg.MinDelayMs > 0 {
+		out.MinDelayMs = cfg.MinDelayMs
+	}
+	if cfg.MaxDelayMs >= out.MinDelayMs {
+		out.MaxDelayMs = cfg.MaxDelayMs
+	}
+	if cfg.InitialDelayMs > 0 {
+		out.InitialDelayMs = cfg.InitialDelayMs
+	}
+	if cfg.MinSamples > 0 {
+		out.MinSamples = cfg.MinSamples
+	}
+	if cfg.WindowSize > 0 {
+		out.WindowSize = cfg.WindowSize
+	}
+	if cfg.BudgetRatio >= 0 && cfg.BudgetRatio <= 1 {
+		out.BudgetRatio = cfg.BudgetRatio
+	}
+	if cfg.BudgetBurst >= 1 {
+		out.BudgetBurst = cfg.BudgetBurst
+	}
+	return out
+}
+
+// groupLocked 返回分组状态，调用方需持有 s.mu；新分组以满预算开始
+func (s *RequestHedgeService) groupLocked(groupID int64, cfg config.GatewayHedgingConfig) *hedgeGroupState {
+	state, ok := s.groups[groupID]
+	if !ok {
+		state = &hedgeGroupState{tokens: cfg.BudgetBurst}
+		state.stats.GroupID = groupID
+		s.groups[groupID] = state
+	}
+	return state
+}
+
+// Admit 登记一个可对冲请求（补充预算）并返回其对冲延迟
+func (s *RequestHedgeService) Admit(groupID int64) time.Duration {
+	if s == nil {
+		return 0
+	}
+	cfg := s.settings()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.groupLocked(groupID, cfg)
+	state.stats.Requests++
+	state.tokens = math.Min(cfg.BudgetBurst, state.tokens+cfg.BudgetRatio)
+	return time.Duration(hedgeDelayMs(state.samples, cfg)) * time.Millisecond
+}
+
+// TryAcquireBudget 对冲前扣减预算，预算不足时返回 false
+func (s *RequestHedgeService) TryAcquireBudget(groupID int64) bool {
+	if s == nil {
+		return false
+	}
+	cfg := s.settings()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.groupLocked(groupID, cfg)
+	if state.tokens < 1 {
+		state.stats.BudgetDenied++
+		return false
+	}
+	state.tokens--
+	return true
+}
+
+// RefundBudget 已扣减预算但最终未发出对冲（如没有可用账号）时退还
+func (s *RequestHedgeService) RefundBudget(groupID int64) {
+	if s == nil {
+		return
+	}
+	cfg := s.settings()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.groupLocked(groupID, cfg)
+	state.tokens = math.Min(cfg.BudgetBurst, state.tokens+1)
+	state.stats.NoAccount++
+}
+
+// RecordHedge 记录一次已发出的对冲及其结果
+func (s *RequestHedgeService) RecordHedge(groupID int64, hedgeWon bool) {
+	if s == nil {
+		return
+	}
+	cfg := s.settings()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.groupLocked(groupID, cfg)
+	state.stats.Hedged++
+	if hedgeWon {
+		state.stats.HedgeWins++
+	}
+}
+
+// ObserveFirstContent 记录一个首个内容事件耗时样本（毫秒）
+func (s *RequestHedgeService) ObserveFirstContent(groupID int64, firstContentMs int) {
+	if s == nil || firstContentMs < 0 {
+		return
+	}
+	cfg := s.settings()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.groupLocked(groupID, cfg)
+	if len(state.samples) > cfg.WindowSize {
+		state.samples = state.samples[len(state.samples)-cfg.WindowSize:]
+		state.next = 0
+	}
+	if len(state.samples) < cfg.WindowSize {
+		state.samples = append(state.samples, firstContentMs)
+		return
+	}
+	state.samples[state.next%len(state.samples)] = firstContentMs
+	state.next = (state.next + 1) % len(state.samples)
+}
+
+// Stats 返回各分组的对冲统计（按分组 ID 排序）
+func (s *RequestHedgeService) Stats() []RequestHedgeStats {
+	if s == nil {
+		return nil
+	}
+	cfg := s.settings()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]RequestHedgeStats, 0, len(s.groups))
+	for _, state := range s.groups {
+		stats := state.stats
+		stats.DelayMs = int64(hedgeDelayMs(state.samples, cfg))
+		stats.Samples = len(state.samples)
+		stats.BudgetTokens = state.tokens
+		out = append(out, stats)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].GroupID < out[j].GroupID })
+	return out
+}
+
+// hedgeDelayMs 样本足够时取分位数，否则使用初始延迟；结果限制在 [MinDelayMs, MaxDelayMs]
+func hedgeDelayMs(samples []int, cfg config.GatewayHedgingConfig) int {
+	delay := cfg.InitialDelayMs
+	if len(samples) >= cfg.MinSamples && len(samples) > 0 {
+		sorted := append([]int(nil), samples...)
+		sort.Ints(sorted)
+		idx := int(math.Ceil(cfg.Percentile*float64(len(sorted)))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= len(sorted) {
+			idx = len(sorted) - 1
+		}
+		delay = sorted[idx]
+	}
+	if delay < cfg.MinDelayMs {
+		delay = cfg.MinDelayMs
+	}
+	if delay > cfg.MaxDelayMs {
+		delay = cfg.MaxDelayMs
+	}
+	return delay
+}
@@ -0,0 +1,239 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func hedgeTestConfig() *config.Config {
+	cfg := testConfig()
+	cfg.Gateway.Hedging = config.GatewayHedgingConfig{
+		Percentile:     0.9,
+		MinDelayMs:     100,
+		MaxDelayMs:     5000,
+		InitialDelayMs: 2000,
+		MinSamples:     5,
+		WindowSize:     10,
+		BudgetRatio:    0.5,
+		BudgetBurst:    1,
+	}
+	return cfg
+}
+
+func TestRequestHedgeServiceDelayUsesPercentile(t *testing.T) {
+	svc := NewRequestHedgeService(hedgeTestConfig())
+
+	// 样本不足时使用初始延迟
+	require.Equal(t, 2000*time.Millisecond, svc.Admit(1))
+
+	for _, ms := range []int{300, 100, 200, 900, 400, 500, 600, 700, 800, 1000} {
+		svc.ObserveFirstContent(1, ms)
+	}
+	require.Equal(t, 900*time.Millisecond, svc.Admit(1))
+
+	// 窗口满后覆盖最旧的样本
+	for i := 0; i < 10; i++ {
+		svc.ObserveFirstContent(1, 10)
+	}
+	require.Equal(t, 100*time.Millisecond, svc.Admit(1), "delay is clamped to min_delay_ms")
+
+	stats := svc.Stats()
+	require.Len(t, stats, 1)
+	require.Equal(t, 10, stats[0].Samples)
+	require.EqualValues(t, 3, stats[0].Requests)
+}
+
+func TestRequestHedgeServiceBudget(t *testing.T) {
+	svc := NewRequestHedgeService(hedgeTestConfig())
+
+	svc.Admit(1)
+	require.True(t, svc.TryAcquireBudget(1), "new groups start with a full bucket")
+	require.False(t, svc.TryAcquireBudget(1))
+
+	// budget_ratio=0.5：两个请求补充一次对冲
+	svc.Admit(1)
+	require.False(t, svc.TryAcquireBudget(1))
+	svc.Admit(1)
+	require.True(t, svc.TryAcquireBudget(1))
+
+	svc.RefundBudget(1)
+	require.True(t, svc.TryAcquireBudget(1))
+
+	stats := svc.Stats()
+	require.EqualValues(t, 2, stats[0].BudgetDenied)
+	require.EqualValues(t, 1, stats[0].NoAccount)
+}
+
+// hedgeUpstreamStub 账号 1 一直不返回响应头（直到请求被取消），其他账号立即返回 200；
+// headersFirst 时账号 1 立即返回响应头与 message_start，但在请求被取消前不发送内容事件
+type hedgeUpstreamStub struct {
+	mu           sync.Mutex
+	canceled     map[int64]bool
+	headersFirst bool
+}
+
+// stalledStreamBody 发送 message_start 后阻塞，直到请求被取消
+type stalledStreamBody struct {
+	ctx  context.Context
+	head *strings.Reader
+	stub *hedgeUpstreamStub
+}
+
+func (b *stalledStreamBody) Read(p []byte) (int, error) {
+	if b.head.Len() > 0 {
+		return b.head.Read(p)
+	}
+	<-b.ctx.Done()
+	b.stub.mu.Lock()
+	b.stub.canceled[1] = true
+	b.stub.mu.Unlock()
+	return 0, b.ctx.Err()
+}
+
+func (b *stalledStreamBody) Close() error { return nil }
+
+func (s *hedgeUpstreamStub) Do(req *http.Request, proxyURL string, accountID int64, accountConcurrency int) (*http.Response, error) {
+	return s.DoWithTLS(req, proxyURL, accountID, accountConcurrency, false)
+}
+
+func (s *hedgeUpstreamStub) DoWithTLS(req *http.Request, _ string, accountID int64, _ int, _ bool) (*http.Response, error) {
+	if accountID == 1 && s.headersFirst {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body: &stalledStreamBody{
+				ctx:  req.Context(),
+				head: strings.NewReader("event: message_start\ndata: {\"type\":\"message_start\"}\n\n"),
+				stub: s,
+			},
+		}, nil
+	}
+	if accountID == 1 {
+		<-req.Context().Done()
+		s.mu.Lock()
+		s.canceled[accountID] = true
+		s.mu.Unlock()
+		return nil, req.Context().Err()
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"X-Request-Id": []string{"req_hedge"}},
+		Body:       io.NopCloser(strings.NewReader("ok")),
+	}, nil
+}
+
+func TestGatewayServiceDoHedgedHedgeWins(t *testing.T) {
+	runDoHedgedHedgeWins(t, false)
+}
+
+// 主账号立即返回响应头但迟迟不发送内容事件时同样对冲
+func TestGatewayServiceDoHedgedOnSlowFirstContent(t *testing.T) {
+	runDoHedgedHedgeWins(t, true)
+}
+
+func runDoHedgedHedgeWins(t *testing.T, headersFirst bool) {
+	gin.SetMode(gin.TestMode)
+	repo := &mockAccountRepoForPlatform{
+		accounts: []Account{
+			{ID: 1, Platform: PlatformAnthropic, Type: AccountTypeAPIKey, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5, Credentials: map[string]any{"api_key": "sk-1"}},
+			{ID: 2, Platform: PlatformAnthropic, Type: AccountTypeAPIKey, Priority: 2, Status: StatusActive, Schedulable: true, Concurrency: 5, Credentials: map[string]any{"api_key": "sk-2"}},
+		},
+		accountsByID: map[int64]*Account{},
+	}
+	for i := range repo.accounts {
+		repo.accountsByID[repo.accounts[i].ID] = &repo.accounts[i]
+	}
+	cfg := hedgeTestConfig()
+	cfg.Gateway.Scheduling.LoadBatchEnabled = false
+	upstream := &hedgeUpstreamStub{canceled: map[int64]bool{}, headersFirst: headersFirst}
+	hedgeSvc := NewRequestHedgeService(cfg)
+	svc := &GatewayService{
+		accountRepo:  repo,
+		cache:        &mockGatewayCacheForPlatform{},
+		cfg:          cfg,
+		httpUpstream: upstream,
+		requestHedge: hedgeSvc,
+	}
+
+	group := &Group{ID: 9, Platform: PlatformAnthropic, Status: StatusActive, Hydrated: true, HedgeEnabled: true}
+	ctx := context.WithValue(context.Background(), ctxkey.Group, group)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+
+	parsed, err := ParseGatewayRequest([]byte(`{"model":"claude-sonnet-4-5","stream":true,"messages":[{"role":"user","content":"hi"}]}`))
+	require.NoError(t, err)
+
+	plan := svc.hedgePlanFor(ctx, parsed)
+	require.NotNil(t, plan)
+	plan.delay = 10 * time.Millisecond
+
+	primary, err := svc.prepareForwardTarget(ctx, c, repo.accountsByID[1], parsed)
+	require.NoError(t, err)
+	req, err := svc.buildUpstreamRequest(ctx, c, primary.account, primary.body, primary.token, primary.tokenType, primary.model)
+	require.NoError(t, err)
+
+	resp, winner, cleanup, err := svc.doHedged(ctx, c, primary, req, parsed, plan)
+	require.NoError(t, err)
+	defer cleanup()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, int64(2), winner.account.ID)
+	require.Equal(t, "sk-2", winner.token)
+	require.True(t, plan.launched)
+
+	require.Eventually(t, func() bool {
+		upstream.mu.Lock()
+		defer upstream.mu.Unlock()
+		return upstream.canceled[1]
+	}, time.Second, 5*time.Millisecond, "losing primary request is canceled")
+
+	stats := hedgeSvc.Stats()
+	require.Len(t, stats, 1)
+	require.EqualValues(t, 1, stats[0].Hedged)
+	require.EqualValues(t, 1, stats[0].HedgeWins)
+}
+
+func TestAwaitFirstContentReplaysBody(t *testing.T) {
+	stream := "event: message_start\ndata: {\"type\":\"message_start\"}\n\n" +
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"text\":\"hi\"}}\n\n" +
+		"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"
+	resp := &http.Response{Body: io.NopCloser(strings.NewReader(stream))}
+	found, err := awaitFirstContent(resp)
+	require.NoError(t, err)
+	require.True(t, found)
+	replayed, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, stream, string(replayed))
+
+	// 没有内容事件的流读到结束为止
+	resp = &http.Response{Body: io.NopCloser(strings.NewReader("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))}
+	found, err = awaitFirstContent(resp)
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestGatewayServiceHedgePlanRequiresStreamingHedgeGroup(t *testing.T) {
+	svc := &GatewayService{requestHedge: NewRequestHedgeService(hedgeTestConfig())}
+	stream := &ParsedRequest{Model: "claude-sonnet-4-5", Stream: true}
+
+	enabled := &Group{ID: 9, Platform: PlatformAnthropic, Status: StatusActive, Hydrated: true, HedgeEnabled: true}
+	disabled := &Group{ID: 10, Platform: PlatformAnthropic, Status: StatusActive, Hydrated: true}
+	enabledCtx := context.WithValue(context.Background(), ctxkey.Group, enabled)
+
+	require.NotNil(t, svc.hedgePlanFor(enabledCtx, stream))
+	require.Nil(t, svc.hedgePlanFor(enabledCtx, &ParsedRequest{Model: "claude-sonnet-4-5"}), "non-streaming requests are not hedged")
+	require.Nil(t, svc.hedgePlanFor(context.WithValue(context.Background(), ctxkey.Group, disabled), stream))
+	require.Nil(t, svc.hedgePlanFor(WithPinnedAccount(enabledCtx, 1), stream), "pinned requests are not hedged")
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+const (
+	// ResellerMaxMarkup 分销商可设置的最大加价倍数
+	ResellerMaxMarkup = 10.0
+	// resellerMaxDepth 计费时向上追溯的最大层级，防止异常数据导致无限递归
+	resellerMaxDepth = 8
+	// resellerMaxCodesPerBatch 单次最多发行的兑换码数量
+	resellerMaxCodesPerBatch = 100
+)
+
+var (
+	ErrResellerNotFound            = infraerrors.NotFound("RESELLER_NOT_FOUND", "reseller not found")
+	ErrResellerDisabled            = infraerrors.Forbidden("RESELLER_DISABLED", "reseller account is not active")
+	ErrResellerInvalidMarkup       = infraerrors.BadRequest("RESELLER_INVALID_MARKUP", "markup must be between 1 and 10")
+	ErrResellerInvalidStatus       = infraerrors.BadRequest("RESELLER_INVALID_STATUS", "invalid reseller status")
+	ErrResellerSubUserLimit        = infraerrors.Conflict("RESELLER_SUB_USER_LIMIT", "sub-user limit reached")
+	ErrResellerInvalidSubUserLimit = infraerrors.BadRequest("RESELLER_INVALID_SUB_USER_LIMIT", "max_sub_users must not be negative")
+	ErrResellerSubUserNotFound     = infraerrors.NotFound("RESELLER_SUB_USER_NOT_FOUND", "sub-user not found")
+	ErrResellerInvalidCodes        = infraerrors.BadRequest("RESELLER_INVALID_CODES", "count must be 1-100 and value must be greater than 0")
+	ErrResellerCodeNotFound        = infraerrors.NotFound("RESELLER_CODE_NOT_FOUND", "unused redeem code not found")
+	ErrRedeemCodeRestricted        = infraerrors.Forbidden("REDEEM_CODE_RESTRICTED", "this redeem code can only be used by the issuer's sub-users")
+)
+
+// Reseller 分销商资格与加价设置
+type Reseller struct {
+	UserID int64
+	// Markup 在分组倍率之上对下级用户的加价倍数（>= 1）
+	Markup float64
+	// MaxSubUsers 可创建的下级用户数量上限，0 表示不限
+	MaxSubUsers int
+	Status      string
+	Notes       string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	// 查询时填充
+	Email        string
+	Username     string
+	Balance      float64
+	SubUserCount int
+}
+
+func (r *Reseller) IsActive() bool {
+	return r.Status == StatusActive
+}
+
+// ResellerListFilters 管理端分销商列表筛选
+type ResellerListFilters struct {
+	Status string
+	Search string
+}
+
+// ResellerChainLevel 计费层级中的一级分销商（由近及远）
+type ResellerChainLevel struct {
+	ResellerID int64
+	Markup     float64
+}
+
+// ResellerQuote 下级用户一次余额计费的层级加价
+type ResellerQuote struct {
+	Levels []ResellerChainLevel
+}
+
+// Markup 累计加价倍数
+func (q *ResellerQuote) Markup() float64 {
+	m := 1.0
+	for _, l := range q.Levels {
+		m *= l.Markup
+	}
+	return m
+}
+
+// ResellerUsageMeta 结算时写入分销流水的请求信息
+type ResellerUsageMeta struct {
+	RequestID   string
+	Model       string
+	TotalTokens int64
+}
+
+// ResellerLedgerEntry 分销流水：一次计费中某一级分销商的收入、成本与利润
+type ResellerLedgerEntry struct {
+	ID           int64
+	ResellerID   int64
+	ChildUserID  int64
+	SourceUserID int64
+	RequestID    string
+	Model        string
+	TotalTokens  int64
+	Revenue      float64
+	Cost         float64
+	Profit       float64
+	CreatedAt    time.Time
+}
+
+// ResellerSettlement 按层级拆分一次计费：付费方扣除 Charge，各级分销商获得利润
+type ResellerSettlement struct {
+	PayerID int64
+	Charge  float64
+	Entries []ResellerLedgerEntry
+}
+
+// BuildSettlement 以基础费用（分组倍率后的费用）计算各级价格：
+// 顶层分销商的成本为基础费用，每向下一级乘以该级分销商的加价。
+func (q *ResellerQuote) BuildSettlement(payerID int64, baseCost float64, meta ResellerUsageMeta) *ResellerSettlement {
+	entries := make([]ResellerLedgerEntry, len(q.Levels))
+	price := baseCost
+	for i := len(q.Levels) - 1; i >= 0; i-- {
+		level := q.Levels[i]
+		revenue := price * level.Markup
+		childID := payerID
+		if i > 0 {
+			childID = q.Levels[i-1].ResellerID
+		}
+		entries[i] = ResellerLedgerEntry{
+			ResellerID:   level.ResellerID,
+			ChildUserID:  childID,
+			SourceUserID: payerID,
+			RequestID:    meta.RequestID,
+			Model:        meta.Model,
+			TotalTokens:  meta.TotalTokens,
+			Revenue:      revenue,
+			Cost:         price,
+			Profit:       revenue - price,
+		}
+		price = revenue
+	}
+	return &ResellerSettlement{PayerID: payerID, Charge: price, Entries: entries}
+}
+
+// ResellerReportRow 利润报表的一个分组（按日期或按下级用户）
+type ResellerReportRow struct {
+	Date        string
+	ChildUserID int64
+	Email       string
+	Requests    int64
+	TotalTokens int64
+	Revenue     float64
+	Cost        float64
+	Profit      float64
+}
+
+// ResellerReport 分销商用量与利润报表
+type ResellerReport struct {
+	Summary   ResellerReportRow
+	ByDay     []ResellerReportRow
+	BySubUser []ResellerReportRow
+}
+
+// ResellerRepository 分销商、层级与分销流水存储
+type ResellerRepository interface {
+	Get(ctx context.Context, userID int64) (*Reseller, error)
+	// Upsert 创建或更新分销商资格
+	Upsert(ctx context.Context, reseller *Reseller) error
+	List(ctx context.Context, params pagination.PaginationParams, filters ResellerListFilters) ([]Reseller, *pagination.PaginationResult, error)
+
+	// GetChain 返回 userID 的上级分销商链（由近及远，仅包含有效的分销商）
+	GetChain(ctx context.Context, userID int64, maxDepth int) ([]ResellerChainLevel, error)
+	CountSubUsers(ctx context.Context, resellerID int64) (int, error)
+	ListSubUsers(ctx context.Context, resellerID int64, params pagination.PaginationParams, search string) ([]User, *pagination.PaginationResult, error)
+	UpdateSubUserStatus(ctx context.Context, resellerID, userID int64, status string) error
+
+	// ListCodes 分销商发行的兑换码
+	ListCodes(ctx context.Context, resellerID int64, params pagination.PaginationParams) ([]RedeemCode, *pagination.PaginationResult, error)
+	// DeleteUnusedCode 删除未使用的兑换码并返回面值，不存在或已使用返回 ErrResellerCodeNotFound
+	DeleteUnusedCode(ctx context.Context, resellerID, codeID int64) (float64, error)
+
+	InsertLedger(ctx context.Context, entries []ResellerLedgerEntry) error
+	Report(ctx context.Context, resellerID int64, start, end time.Time) (*ResellerReport, error)
+
+	// DeductBalanceIfSufficient 余额充足时原子扣减，余额不足返回 false
+	DeductBalanceIfSufficient(ctx context.Context, userID int64, amount float64) (bool, error)
+}
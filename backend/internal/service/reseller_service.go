@@ -0,0 +1,377 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// UpsertResellerInput 管理员授予/调整分销商资格（nil 字段不变）
+type UpsertResellerInput struct {
+	Markup      *float64
+	MaxSubUsers *int
+	Status      *string
+	Notes       *string
+}
+
+// CreateSubUserInput 分销商创建下级用户
+type CreateSubUserInput struct {
+	Email       string
+	Password    string
+	Username    string
+	Concurrency int
+}
+
+// ResellerService 分销商层级：下级用户管理、加价计费、兑换码发行与利润报表
+//
+// 下级用户的余额计费按上级链逐级加价：下级支付加价后的价格，
+// 每一级分销商获得"下级价格 - 自身价格"的利润，顶层分销商的价格即平台价格。
+// 订阅计费按订阅额度结算，不参与加价。
+type ResellerService struct {
+	resellerRepo         ResellerRepository
+	userRepo             UserRepository
+	redeemRepo           RedeemCodeRepository
+	redeemService        *RedeemService
+	billingCacheService  *BillingCacheService
+	authCacheInvalidator APIKeyAuthCacheInvalidator
+	entClient            *dbent.Client
+	cfg                  *config.Config
+}
+
+// NewResellerService 创建分销商服务
+func NewResellerService(
+	resellerRepo ResellerRepository,
+	userRepo UserRepository,
+	redeemRepo RedeemCodeRepository,
+	redeemService *RedeemService,
+	billingCacheService *BillingCacheService,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	entClient *dbent.Client,
+	cfg *config.Config,
+) *ResellerService {
+	return &ResellerService{
+		resellerRepo:         resellerRepo,
+		userRepo:             userRepo,
+		redeemRepo:           redeemRepo,
+		redeemService:        redeemService,
+		billingCacheService:  billingCacheService,
+		authCacheInvalidator: authCacheInvalidator,
+		entClient:            entClient,
+		cfg:                  cfg,
+	}
+}
+
+// Quote 返回付费方的层级加价；非下级用户或查询失败时返回 nil（按原价计费）
+func (s *ResellerService) Quote(ctx context.Context, payer *User) *ResellerQuote {
+	if s == nil || payer == nil || payer.ParentUserID == nil {
+		return nil
+	}
+	levels, err := s.resellerRepo.GetChain(ctx, payer.ID, resellerMaxDepth)
+	if err != nil {
+		log.Printf("[Reseller] load chain for user %d failed, billing at base price: %v", payer.ID, err)
+		return nil
+	}
+	if len(levels) == 0 {
+		return nil
+	}
+	return &ResellerQuote{Levels: levels}
+}
+
+// Settle 原子结算一次下级用户计费：扣除付费方余额、按层级增加分销商利润并写入分销流水
+//
+// 余额缓存由调用方（计费发件箱）在外层事务提交后更新。
+func (s *ResellerService) Settle(ctx context.Context, settlement *ResellerSettlement) error {
+	if settlement == nil || settlement.Charge <= 0 {
+		return nil
+	}
+	return s.runInTx(ctx, func(txCtx context.Context) error {
+		if err := s.userRepo.DeductBalance(txCtx, settlement.PayerID, settlement.Charge); err != nil {
+			return fmt.Errorf("deduct payer balance: %w", err)
+		}
+		for _, e := range settlement.Entries {
+			if e.Profit <= 0 {
+				continue
+			}
+			if err := s.userRepo.UpdateBalance(txCtx, e.ResellerID, e.Profit); err != nil {
+				return fmt.Errorf("credit reseller %d: %w", e.ResellerID, err)
+			}
+		}
+		return s.resellerRepo.InsertLedger(txCtx, settlement.Entries)
+	})
+}
+
+// GetProfile 分销商查看自身资格与加价
+func (s *ResellerService) GetProfile(ctx context.Context, userID int64) (*Reseller, error) {
+	return s.requireActive(ctx, userID)
+}
+
+// UpdateMarkup 分销商调整对下级用户的加价
+func (s *ResellerService) UpdateMarkup(ctx context.Context, userID int64, markup float64) (*Reseller, error) {
+	if err := validateResellerMarkup(markup); err != nil {
+		return nil, err
+	}
+	reseller, err := s.requireActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	reseller.Markup = markup
+	if err := s.resellerRepo.Upsert(ctx, reseller); err != nil {
+		return nil, err
+	}
+	return reseller, nil
+}
+
+// CreateSubUser 创建下级用户；初始余额为 0，由分销商发行的兑换码充值
+func (s *ResellerService) CreateSubUser(ctx context.Context, resellerID int64, input *CreateSubUserInput) (*User, error) {
+	reseller, err := s.requireActive(ctx, resellerID)
+	if err != nil {
+		return nil, err
+	}
+	if reseller.MaxSubUsers > 0 {
+		count, err := s.resellerRepo.CountSubUsers(ctx, resellerID)
+		if err != nil {
+			return nil, err
+		}
+		if count >= reseller.MaxSubUsers {
+			return nil, ErrResellerSubUserLimit
+		}
+	}
+
+	owner, err := s.userRepo.GetByID(ctx, resellerID)
+	if err != nil {
+		return nil, err
+	}
+	// 下级用户的并发不超过分销商自身
+	concurrency := input.Concurrency
+	if concurrency <= 0 && s.cfg != nil {
+		concurrency = s.cfg.Default.UserConcurrency
+	}
+	if concurrency <= 0 || concurrency > owner.Concurrency {
+		concurrency = owner.Concurrency
+	}
+
+	user := &User{
+		Email:        strings.TrimSpace(input.Email),
+		Username:     strings.TrimSpace(input.Username),
+		Role:         RoleUser,
+		Concurrency:  concurrency,
+		Status:       StatusActive,
+		ParentUserID: &resellerID,
+	}
+	if err := user.SetPassword(input.Password); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ListSubUsers 分销商的下级用户列表
+func (s *ResellerService) ListSubUsers(ctx context.Context, resellerID int64, params pagination.PaginationParams, search string) ([]User, *pagination.PaginationResult, error) {
+	if _, err := s.requireActive(ctx, resellerID); err != nil {
+		return nil, nil, err
+	}
+	return s.resellerRepo.ListSubUsers(ctx, resellerID, params, search)
+}
+
+// UpdateSubUserStatus 分销商启用/停用下级用户
+func (s *ResellerService) UpdateSubUserStatus(ctx context.Context, resellerID, userID int64, status string) error {
+	if status != StatusActive && status != StatusDisabled {
+		return ErrResellerInvalidStatus
+	}
+	if _, err := s.requireActive(ctx, resellerID); err != nil {
+		return err
+	}
+	if err := s.resellerRepo.UpdateSubUserStatus(ctx, resellerID, userID, status); err != nil {
+		return err
+	}
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, userID)
+	}
+	return nil
+}
+
+// GenerateCodes 用分销商余额发行余额兑换码（面值总额立即从余额扣除）
+func (s *ResellerService) GenerateCodes(ctx context.Context, resellerID int64, count int, value float64) ([]RedeemCode, error) {
+	if count <= 0 || count > resellerMaxCodesPerBatch || value <= 0 {
+		return nil, ErrResellerInvalidCodes
+	}
+	if _, err := s.requireActive(ctx, resellerID); err != nil {
+		return nil, err
+	}
+
+	codes := make([]RedeemCode, 0, count)
+	for i := 0; i < count; i++ {
+		code, err := s.redeemService.GenerateRandomCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, RedeemCode{
+			Code:         code,
+			Type:         RedeemTypeBalance,
+			Value:        value,
+			Status:       StatusUnused,
+			Notes:        fmt.Sprintf("issued by reseller %d", resellerID),
+			IssuerUserID: &resellerID,
+		})
+	}
+
+	total := value * float64(count)
+	err := s.runInTx(ctx, func(txCtx context.Context) error {
+		ok, err := s.resellerRepo.DeductBalanceIfSufficient(txCtx, resellerID, total)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInsufficientBalance
+		}
+		return s.redeemRepo.CreateBatch(txCtx, codes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[Reseller] reseller %d issued %d codes (%.4f each)", resellerID, count, value)
+	s.invalidateBalance(ctx, resellerID)
+	return codes, nil
+}
+
+// ListCodes 分销商发行的兑换码
+func (s *ResellerService) ListCodes(ctx context.Context, resellerID int64, params pagination.PaginationParams) ([]RedeemCode, *pagination.PaginationResult, error) {
+	if _, err := s.requireActive(ctx, resellerID); err != nil {
+		return nil, nil, err
+	}
+	return s.resellerRepo.ListCodes(ctx, resellerID, params)
+}
+
+// RevokeCode 作废未使用的兑换码并退回面值
+func (s *ResellerService) RevokeCode(ctx context.Context, resellerID, codeID int64) error {
+	if _, err := s.requireActive(ctx, resellerID); err != nil {
+		return err
+	}
+	err := s.runInTx(ctx, func(txCtx context.Context) error {
+		value, err := s.resellerRepo.DeleteUnusedCode(txCtx, resellerID, codeID)
+		if err != nil {
+			return err
+		}
+		return s.userRepo.UpdateBalance(txCtx, resellerID, value)
+	})
+	if err != nil {
+		return err
+	}
+	s.invalidateBalance(ctx, resellerID)
+	return nil
+}
+
+// Report 分销商在 [start, end) 内的用量与利润报表
+func (s *ResellerService) Report(ctx context.Context, resellerID int64, start, end time.Time) (*ResellerReport, error) {
+	if _, err := s.resellerRepo.Get(ctx, resellerID); err != nil {
+		return nil, err
+	}
+	return s.resellerRepo.Report(ctx, resellerID, start, end)
+}
+
+// AdminList 管理端分销商列表
+func (s *ResellerService) AdminList(ctx context.Context, params pagination.PaginationParams, filters ResellerListFilters) ([]Reseller, *pagination.PaginationResult, error) {
+	return s.resellerRepo.List(ctx, params, filters)
+}
+
+// AdminUpsert 管理员授予或调整分销商资格；新授予时加价默认为 1
+func (s *ResellerService) AdminUpsert(ctx context.Context, userID int64, input *UpsertResellerInput) (*Reseller, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsOrganizationAccount() {
+		return nil, ErrUserNotFound
+	}
+
+	reseller, err := s.resellerRepo.Get(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, ErrResellerNotFound) {
+			return nil, err
+		}
+		reseller = &Reseller{UserID: userID, Markup: 1, Status: StatusActive}
+	}
+
+	if input.Markup != nil {
+		if err := validateResellerMarkup(*input.Markup); err != nil {
+			return nil, err
+		}
+		reseller.Markup = *input.Markup
+	}
+	if input.MaxSubUsers != nil {
+		if *input.MaxSubUsers < 0 {
+			return nil, ErrResellerInvalidSubUserLimit
+		}
+		reseller.MaxSubUsers = *input.MaxSubUsers
+	}
+	if input.Status != nil {
+		if *input.Status != StatusActive && *input.Status != StatusDisabled {
+			return nil, ErrResellerInvalidStatus
+		}
+		reseller.Status = *input.Status
+	}
+	if input.Notes != nil {
+		reseller.Notes = *input.Notes
+	}
+
+	if err := s.resellerRepo.Upsert(ctx, reseller); err != nil {
+		return nil, err
+	}
+	return s.resellerRepo.Get(ctx, userID)
+}
+
+func (s *ResellerService) requireActive(ctx context.Context, userID int64) (*Reseller, error) {
+	reseller, err := s.resellerRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !reseller.IsActive() {
+		return nil, ErrResellerDisabled
+	}
+	return reseller, nil
+}
+
+func (s *ResellerService) invalidateBalance(ctx context.Context, userID int64) {
+	if s.billingCacheService != nil {
+		_ = s.billingCacheService.InvalidateUserBalance(ctx, userID)
+	}
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, userID)
+	}
+}
+
+// runInTx 在事务中执行；已处于事务中或未注入 ent client（单元测试）时直接执行
+func (s *ResellerService) runInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.entClient == nil || dbent.TxFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	tx, err := s.entClient.Tx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(dbent.NewTxContext(ctx, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+func validateResellerMarkup(markup float64) error {
+	if markup < 1 || markup > ResellerMaxMarkup {
+		return ErrResellerInvalidMarkup
+	}
+	return nil
+}
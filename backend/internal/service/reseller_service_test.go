@@ -0,0 +1,178 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type resellerRepoStub struct {
+	ResellerRepository
+	resellers map[int64]*Reseller
+	chains    map[int64][]ResellerChainLevel
+	balances  map[int64]float64
+	ledger    []ResellerLedgerEntry
+}
+
+func newResellerRepoStub() *resellerRepoStub {
+	return &resellerRepoStub{
+		resellers: map[int64]*Reseller{},
+		chains:    map[int64][]ResellerChainLevel{},
+		balances:  map[int64]float64{},
+	}
+}
+
+func (r *resellerRepoStub) Get(ctx context.Context, userID int64) (*Reseller, error) {
+	reseller, ok := r.resellers[userID]
+	if !ok {
+		return nil, ErrResellerNotFound
+	}
+	clone := *reseller
+	return &clone, nil
+}
+
+func (r *resellerRepoStub) Upsert(ctx context.Context, reseller *Reseller) error {
+	clone := *reseller
+	r.resellers[reseller.UserID] = &clone
+	return nil
+}
+
+func (r *resellerRepoStub) GetChain(ctx context.Context, userID int64, maxDepth int) ([]ResellerChainLevel, error) {
+	return r.chains[userID], nil
+}
+
+func (r *resellerRepoStub) InsertLedger(ctx context.Context, entries []ResellerLedgerEntry) error {
+	r.ledger = append(r.ledger, entries...)
+	return nil
+}
+
+func (r *resellerRepoStub) DeductBalanceIfSufficient(ctx context.Context, userID int64, amount float64) (bool, error) {
+	if r.balances[userID] < amount {
+		return false, nil
+	}
+	r.balances[userID] -= amount
+	return true, nil
+}
+
+type resellerRedeemRepoStub struct {
+	RedeemCodeRepository
+	created []RedeemCode
+}
+
+func (r *resellerRedeemRepoStub) CreateBatch(ctx context.Context, codes []RedeemCode) error {
+	r.created = append(r.created, codes...)
+	return nil
+}
+
+func TestResellerQuote_BuildSettlementMultiLevel(t *testing.T) {
+	// 下级用户 100 -> 分销商 10（加价 1.5）-> 顶层分销商 1（加价 1.2）
+	quote := &ResellerQuote{Levels: []ResellerChainLevel{
+		{ResellerID: 10, Markup: 1.5},
+		{ResellerID: 1, Markup: 1.2},
+	}}
+	require.InDelta(t, 1.8, quote.Markup(), 1e-9)
+
+	settlement := quote.BuildSettlement(100, 2.0, ResellerUsageMeta{RequestID: "req-1", Model: "claude", TotalTokens: 42})
+	require.Equal(t, int64(100), settlement.PayerID)
+	require.InDelta(t, 3.6, settlement.Charge, 1e-9)
+	require.Len(t, settlement.Entries, 2)
+
+	near := settlement.Entries[0]
+	require.Equal(t, int64(10), near.ResellerID)
+	require.Equal(t, int64(100), near.ChildUserID)
+	require.InDelta(t, 3.6, near.Revenue, 1e-9)
+	require.InDelta(t, 2.4, near.Cost, 1e-9)
+	require.InDelta(t, 1.2, near.Profit, 1e-9)
+
+	top := settlement.Entries[1]
+	require.Equal(t, int64(1), top.ResellerID)
+	require.Equal(t, int64(10), top.ChildUserID)
+	require.Equal(t, int64(100), top.SourceUserID)
+	require.InDelta(t, 2.4, top.Revenue, 1e-9)
+	require.InDelta(t, 2.0, top.Cost, 1e-9)
+	require.InDelta(t, 0.4, top.Profit, 1e-9)
+	require.Equal(t, "req-1", top.RequestID)
+}
+
+func TestResellerService_QuoteSkipsUsersWithoutParent(t *testing.T) {
+	repo := newResellerRepoStub()
+	parent := int64(10)
+	repo.chains[100] = []ResellerChainLevel{{ResellerID: 10, Markup: 1.5}}
+	svc := NewResellerService(repo, nil, nil, nil, nil, nil, nil, nil)
+
+	require.Nil(t, svc.Quote(context.Background(), &User{ID: 200}))
+	require.Nil(t, svc.Quote(context.Background(), &User{ID: 201, ParentUserID: &parent}))
+
+	quote := svc.Quote(context.Background(), &User{ID: 100, ParentUserID: &parent})
+	require.NotNil(t, quote)
+	require.InDelta(t, 1.5, quote.Markup(), 1e-9)
+
+	var nilSvc *ResellerService
+	require.Nil(t, nilSvc.Quote(context.Background(), &User{ID: 100, ParentUserID: &parent}))
+}
+
+func TestResellerService_SettleChargesPayerAndCreditsResellers(t *testing.T) {
+	repo := newResellerRepoStub()
+	users := &paymentUserRepoStub{balances: map[int64]float64{100: 10, 10: 0, 1: 0}}
+	svc := NewResellerService(repo, users, nil, nil, nil, nil, nil, nil)
+
+	quote := &ResellerQuote{Levels: []ResellerChainLevel{
+		{ResellerID: 10, Markup: 1.5},
+		{ResellerID: 1, Markup: 1.2},
+	}}
+	err := svc.Settle(context.Background(), quote.BuildSettlement(100, 2.0, ResellerUsageMeta{}))
+	require.NoError(t, err)
+
+	require.InDelta(t, 6.4, users.balances[100], 1e-9)
+	require.InDelta(t, 1.2, users.balances[10], 1e-9)
+	require.InDelta(t, 0.4, users.balances[1], 1e-9)
+	require.Len(t, repo.ledger, 2)
+}
+
+func TestResellerService_GenerateCodesRequiresBalance(t *testing.T) {
+	repo := newResellerRepoStub()
+	repo.resellers[10] = &Reseller{UserID: 10, Markup: 1.5, Status: StatusActive}
+	repo.balances[10] = 25
+	redeemRepo := &resellerRedeemRepoStub{}
+	svc := NewResellerService(repo, nil, redeemRepo, &RedeemService{}, nil, nil, nil, nil)
+
+	_, err := svc.GenerateCodes(context.Background(), 10, 3, 10)
+	require.ErrorIs(t, err, ErrInsufficientBalance)
+	require.Empty(t, redeemRepo.created)
+	require.InDelta(t, 25, repo.balances[10], 1e-9)
+
+	codes, err := svc.GenerateCodes(context.Background(), 10, 2, 10)
+	require.NoError(t, err)
+	require.Len(t, codes, 2)
+	require.InDelta(t, 5, repo.balances[10], 1e-9)
+	for _, code := range redeemRepo.created {
+		require.Equal(t, RedeemTypeBalance, code.Type)
+		require.NotNil(t, code.IssuerUserID)
+		require.Equal(t, int64(10), *code.IssuerUserID)
+	}
+
+	_, err = svc.GenerateCodes(context.Background(), 10, 0, 10)
+	require.ErrorIs(t, err, ErrResellerInvalidCodes)
+}
+
+func TestResellerService_UpdateMarkupValidation(t *testing.T) {
+	repo := newResellerRepoStub()
+	repo.resellers[10] = &Reseller{UserID: 10, Markup: 1, Status: StatusActive}
+	repo.resellers[11] = &Reseller{UserID: 11, Markup: 1, Status: StatusDisabled}
+	svc := NewResellerService(repo, nil, nil, nil, nil, nil, nil, nil)
+
+	_, err := svc.UpdateMarkup(context.Background(), 10, 0.9)
+	require.ErrorIs(t, err, ErrResellerInvalidMarkup)
+	_, err = svc.UpdateMarkup(context.Background(), 10, ResellerMaxMarkup+1)
+	require.ErrorIs(t, err, ErrResellerInvalidMarkup)
+	_, err = svc.UpdateMarkup(context.Background(), 11, 1.5)
+	require.ErrorIs(t, err, ErrResellerDisabled)
+
+	reseller, err := svc.UpdateMarkup(context.Background(), 10, 1.25)
+	require.NoError(t, err)
+	require.InDelta(t, 1.25, reseller.Markup, 1e-9)
+	require.InDelta(t, 1.25, repo.resellers[10].Markup, 1e-9)
+}
@@ -470,22 +470,23 @@ func (s *SchedulerSnapshotService) triggerFullRebuild(reason string) error {
 }
 
 func (s *SchedulerSnapshotService) checkOutboxLag(ctx context.Context, oldest SchedulerOutboxEvent, watermark int64) {
+	cfg := s.cfg.Current()
 	if oldest.CreatedAt.IsZero() || s.cfg == nil {
 		return
 	}
 
 	lag := time.Since(oldest.CreatedAt)
-	if lagSeconds := int(lag.Seconds()); lagSeconds >= s.cfg.Gateway.Scheduling.OutboxLagWarnSeconds && s.cfg.Gateway.Scheduling.OutboxLagWarnSeconds > 0 {
+	if lagSeconds := int(lag.Seconds()); lagSeconds >= cfg.Gateway.Scheduling.OutboxLagWarnSeconds && cfg.Gateway.Scheduling.OutboxLagWarnSeconds > 0 {
 		log.Printf("[Scheduler] outbox lag warning: %ds", lagSeconds)
 	}
 
-	if s.cfg.Gateway.Scheduling.OutboxLagRebuildSeconds > 0 && int(lag.Seconds()) >= s.cfg.Gateway.Scheduling.OutboxLagRebuildSeconds {
+	if cfg.Gateway.Scheduling.OutboxLagRebuildSeconds > 0 && int(lag.Seconds()) >= cfg.Gateway.Scheduling.OutboxLagRebuildSeconds {
 		s.lagMu.Lock()
 		s.lagFailures++
 		failures := s.lagFailures
 		s.lagMu.Unlock()
 
-		if failures >= s.cfg.Gateway.Scheduling.OutboxLagRebuildFailures {
+		if failures >= cfg.Gateway.Scheduling.OutboxLagRebuildFailures {
 			log.Printf("[Scheduler] outbox lag rebuild triggered: lag=%s failures=%d", lag, failures)
 			s.lagMu.Lock()
 			s.lagFailures = 0
@@ -500,7 +501,7 @@ func (s *SchedulerSnapshotService) checkOutboxLag(ctx context.Context, oldest Sc
 		s.lagMu.Unlock()
 	}
 
-	threshold := s.cfg.Gateway.Scheduling.OutboxBacklogRebuildRows
+	threshold := cfg.Gateway.Scheduling.OutboxBacklogRebuildRows
 	if threshold <= 0 || s.outboxRepo == nil {
 		return
 	}
@@ -607,7 +608,7 @@ func (s *SchedulerSnapshotService) resolveMode(platform string, hasForcePlatform
 }
 
 func (s *SchedulerSnapshotService) guardFallback(ctx context.Context) error {
-	if s.cfg == nil || s.cfg.Gateway.Scheduling.DbFallbackEnabled {
+	if s.cfg == nil || s.cfg.Current().Gateway.Scheduling.DbFallbackEnabled {
 		if s.fallbackLimit == nil || s.fallbackLimit.Allow() {
 			return nil
 		}
@@ -617,10 +618,11 @@ func (s *SchedulerSnapshotService) guardFallback(ctx context.Context) error {
 }
 
 func (s *SchedulerSnapshotService) withFallbackTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
-	if s.cfg == nil || s.cfg.Gateway.Scheduling.DbFallbackTimeoutSeconds <= 0 {
+	cfg := s.cfg.Current()
+	if s.cfg == nil || cfg.Gateway.Scheduling.DbFallbackTimeoutSeconds <= 0 {
 		return context.WithCancel(ctx)
 	}
-	timeout := time.Duration(s.cfg.Gateway.Scheduling.DbFallbackTimeoutSeconds) * time.Second
+	timeout := time.Duration(cfg.Gateway.Scheduling.DbFallbackTimeoutSeconds) * time.Second
 	if deadline, ok := ctx.Deadline(); ok {
 		remaining := time.Until(deadline)
 		if remaining <= 0 {
@@ -641,7 +643,7 @@ func (s *SchedulerSnapshotService) outboxPollInterval() time.Duration {
 	if s.cfg == nil {
 		return time.Second
 	}
-	sec := s.cfg.Gateway.Scheduling.OutboxPollIntervalSeconds
+	sec := s.cfg.Current().Gateway.Scheduling.OutboxPollIntervalSeconds
 	if sec <= 0 {
 		return time.Second
 	}
@@ -652,7 +654,7 @@ func (s *SchedulerSnapshotService) fullRebuildInterval() time.Duration {
 	if s.cfg == nil {
 		return 0
 	}
-	sec := s.cfg.Gateway.Scheduling.FullRebuildIntervalSeconds
+	sec := s.cfg.Current().Gateway.Scheduling.FullRebuildIntervalSeconds
 	if sec <= 0 {
 		return 0
 	}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/secretbox"
+)
+
+// 重加密目标
+const (
+	SecretTargetAccountCredentials = "account_credentials"
+	SecretTargetProxyPassword      = "proxy_password"
+	SecretTargetSettings           = "settings"
+)
+
+// SecretReencryptionTargets 按执行顺序列出全部重加密目标
+var SecretReencryptionTargets = []string{
+	SecretTargetAccountCredentials,
+	SecretTargetProxyPassword,
+	SecretTargetSettings,
+}
+
+// secretReencryptionBatchPause 批次间暂停，避免后台重加密挤占数据库
+const secretReencryptionBatchPause = 200 * time.Millisecond
+
+// SecretReencryptionBatch 单批重加密结果
+type SecretReencryptionBatch struct {
+	Scanned   int
+	Rewritten int
+	// Failed 解密失败或并发修改导致未写回的行数（下次运行会重试）
+	Failed int
+	// NextCursor 下一批的起始 id（不含）
+	NextCursor int64
+	Done       bool
+}
+
+// SecretReencryptionResult 单个目标的累计结果
+type SecretReencryptionResult struct {
+	Target    string
+	Scanned   int
+	Rewritten int
+	Failed    int
+}
+
+// SecretReencryptionRepository 按 id 游标分批重写敏感字段。
+// 实现需以读取到的原值为条件写回，保证与业务写入并发时不会覆盖新数据。
+type SecretReencryptionRepository interface {
+	ReencryptBatch(ctx context.Context, target string, afterID int64, limit int) (*SecretReencryptionBatch, error)
+}
+
+// SecretReencryptionService 将明文或旧主密钥加密的存量敏感数据重写为当前主密钥（或只解密模式下回写明文）。
+//
+// 启用 reencrypt_on_startup 时在启动后于后台执行一次；也可通过 `server -reencrypt-secrets` 同步执行。
+// 多实例同时执行是安全的：每行写回都以原值为条件，冲突行计入 Failed 由下次运行处理。
+type SecretReencryptionService struct {
+	repo    SecretReencryptionRepository
+	keyring *secretbox.Keyring
+	cfg     *config.Config
+
+	running   int32
+	startOnce sync.Once
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+
+	workerCtx    context.Context
+	workerCancel context.CancelFunc
+}
+
+// NewSecretReencryptionService 创建敏感数据重加密服务
+func NewSecretReencryptionService(repo SecretReencryptionRepository, keyring *secretbox.Keyring, cfg *config.Config) *SecretReencryptionService {
+	workerCtx, workerCancel := context.WithCancel(context.Background())
+	return &SecretReencryptionService{
+		repo:         repo,
+		keyring:      keyring,
+		cfg:          cfg,
+		workerCtx:    workerCtx,
+		workerCancel: workerCancel,
+	}
+}
+
+func (s *SecretReencryptionService) Start() {
+	if s == nil {
+		return
+	}
+	if s.cfg == nil || !s.cfg.Security.SecretEncryption.ReencryptOnStartup {
+		log.Printf("[SecretReencrypt] not started (disabled)")
+		return
+	}
+	if s.keyring == nil {
+		log.Printf("[SecretReencrypt] not started (no master key configured)")
+		return
+	}
+	if s.repo == nil {
+		log.Printf("[SecretReencrypt] not started (missing deps)")
+		return
+	}
+
+	s.startOnce.Do(func() {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if _, err := s.RunAll(s.workerCtx); err != nil && s.workerCtx.Err() == nil {
+				log.Printf("[SecretReencrypt] background run failed: %v", err)
+			}
+		}()
+	})
+}
+
+func (s *SecretReencryptionService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.workerCancel != nil {
+			s.workerCancel()
+		}
+		s.wg.Wait()
+		log.Printf("[SecretReencrypt] stopped")
+	})
+}
+
+// RunAll 依次处理全部目标，直到扫描完成或 ctx 取消。
+func (s *SecretReencryptionService) RunAll(ctx context.Context) ([]SecretReencryptionResult, error) {
+	if s.keyring == nil {
+		return nil, fmt.Errorf("secret encryption: no master key configured")
+	}
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		return nil, fmt.Errorf("secret re-encryption already running")
+	}
+	defer atomic.StoreInt32(&s.running, 0)
+
+	log.Printf("[SecretReencrypt] start: active_key=%s encrypting=%v", s.keyring.ActiveKeyID(), s.keyring.Encrypting())
+
+	results := make([]SecretReencryptionResult, 0, len(SecretReencryptionTargets))
+	for _, target := range SecretReencryptionTargets {
+		result, err := s.runTarget(ctx, target)
+		results = append(results, result)
+		if err != nil {
+			return results, fmt.Errorf("re-encrypt %s: %w", target, err)
+		}
+		log.Printf("[SecretReencrypt] %s done: scanned=%d rewritten=%d failed=%d",
+			target, result.Scanned, result.Rewritten, result.Failed)
+	}
+	return results, nil
+}
+
+func (s *SecretReencryptionService) runTarget(ctx context.Context, target string) (SecretReencryptionResult, error) {
+	result := SecretReencryptionResult{Target: target}
+	batchSize := 200
+	if s.cfg != nil && s.cfg.Security.SecretEncryption.BatchSize > 0 {
+		batchSize = s.cfg.Security.SecretEncryption.BatchSize
+	}
+
+	var cursor int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		batch, err := s.repo.ReencryptBatch(ctx, target, cursor, batchSize)
+		if err != nil {
+			return result, err
+		}
+		result.Scanned += batch.Scanned
+		result.Rewritten += batch.Rewritten
+		result.Failed += batch.Failed
+		if batch.Done {
+			return result, nil
+		}
+		cursor = batch.NextCursor
+
+		if batch.Rewritten > 0 {
+			select {
+			case <-ctx.Done():
+				return result, ctx.Err()
+			case <-time.After(secretReencryptionBatchPause):
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/secretbox"
+	"github.com/stretchr/testify/require"
+)
+
+type secretReencryptionRepoStub struct {
+	calls   []int64
+	batches map[string][]*SecretReencryptionBatch
+}
+
+func (s *secretReencryptionRepoStub) ReencryptBatch(_ context.Context, target string, afterID int64, limit int) (*SecretReencryptionBatch, error) {
+	s.calls = append(s.calls, afterID)
+	queue := s.batches[target]
+	if len(queue) == 0 {
+		return &SecretReencryptionBatch{Done: true}, nil
+	}
+	s.batches[target] = queue[1:]
+	return queue[0], nil
+}
+
+func TestSecretReencryptionRunAllFollowsCursor(t *testing.T) {
+	keyring, err := secretbox.NewKeyring(map[string][]byte{"k1": make([]byte, secretbox.KeySize)}, "", true)
+	require.NoError(t, err)
+
+	repo := &secretReencryptionRepoStub{batches: map[string][]*SecretReencryptionBatch{
+		SecretTargetAccountCredentials: {
+			{Scanned: 2, NextCursor: 7},
+			{Scanned: 1, Failed: 1, NextCursor: 9, Done: true},
+		},
+	}}
+	cfg := &config.Config{}
+	cfg.Security.SecretEncryption.BatchSize = 2
+	svc := NewSecretReencryptionService(repo, keyring, cfg)
+
+	results, err := svc.RunAll(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []SecretReencryptionResult{
+		{Target: SecretTargetAccountCredentials, Scanned: 3, Failed: 1},
+		{Target: SecretTargetProxyPassword},
+		{Target: SecretTargetSettings},
+	}, results)
+	require.Equal(t, []int64{0, 7, 0, 0}, repo.calls)
+}
+
+func TestSecretReencryptionRunAllRequiresKeyring(t *testing.T) {
+	svc := NewSecretReencryptionService(&secretReencryptionRepoStub{}, nil, &config.Config{})
+	_, err := svc.RunAll(context.Background())
+	require.Error(t, err)
+}
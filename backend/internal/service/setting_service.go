@@ -271,6 +271,15 @@ func (s *SettingService) GetSiteName(ctx context.Context) string {
 	return value
 }
 
+// GetSiteLogo 获取网站Logo（data URL 或图片地址，未设置时为空）
+func (s *SettingService) GetSiteLogo(ctx context.Context) string {
+	value, err := s.settingRepo.GetValue(ctx, SettingKeySiteLogo)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
 // GetDefaultConcurrency 获取默认并发量
 func (s *SettingService) GetDefaultConcurrency(ctx context.Context) int {
 	value, err := s.settingRepo.GetValue(ctx, SettingKeyDefaultConcurrency)
@@ -606,6 +615,14 @@ func (s *SettingService) GetFallbackModel(ctx context.Context, platform string)
 	return value
 }
 
+// currentConfig 返回当前生效的配置快照；未注入配置时为 nil
+func (s *SettingService) currentConfig() *config.Config {
+	if s == nil {
+		return nil
+	}
+	return s.cfg.Current()
+}
+
 // GetLinuxDoConnectOAuthConfig 返回用于登录的"最终生效" LinuxDo Connect 配置。
 //
 // 优先级：
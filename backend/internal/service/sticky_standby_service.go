@@ -0,0 +1,449 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+const (
+	// stickyFailoverHintTTL 切换后等待新账号首个请求计费的时长，超时不再估算切换成本
+	stickyFailoverHintTTL     = 10 * time.Minute
+	stickyStatsCleanupEvery   = time.Hour
+	stickyStatsCleanupBatch   = 5000
+	defaultStickyWarmthTTL    = 5 * time.Minute
+	defaultStickyRetentionDay = 7
+)
+
+// StickySessionStat 单个会话在单个账号上的累计用量
+type StickySessionStat struct {
+	GroupID             int64
+	SessionHash         string
+	AccountID           int64
+	AccountName         string
+	RequestCount        int64
+	InputTokens         int64
+	CacheCreationTokens int64
+	CacheReadTokens     int64
+	LastCacheReadAt     *time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// CacheHitRatio 缓存命中率：cache_read / (input + cache_creation + cache_read)
+func (s *StickySessionStat) CacheHitRatio() float64 {
+	return cacheHitRatio(s.InputTokens, s.CacheCreationTokens, s.CacheReadTokens)
+}
+
+// StickySessionSummary 单个会话跨账号的汇总（ops 面板列表）
+type StickySessionSummary struct {
+	GroupID             int64
+	SessionHash         string
+	AccountCount        int64
+	RequestCount        int64
+	InputTokens         int64
+	CacheCreationTokens int64
+	CacheReadTokens     int64
+	FailoverCount       int64
+	// EstimatedFailoverCost 该会话所有切换的估算成本之和
+	EstimatedFailoverCost float64
+	LastUsedAt            time.Time
+}
+
+// CacheHitRatio 缓存命中率：cache_read / (input + cache_creation + cache_read)
+func (s *StickySessionSummary) CacheHitRatio() float64 {
+	return cacheHitRatio(s.InputTokens, s.CacheCreationTokens, s.CacheReadTokens)
+}
+
+// StickySessionFailover 粘性会话主账号切换记录
+type StickySessionFailover struct {
+	ID            int64
+	GroupID       int64
+	SessionHash   string
+	FromAccountID int64
+	ToAccountID   int64
+	// FromAccountName / ToAccountName 仅查询时填充
+	FromAccountName string
+	ToAccountName   string
+	// Standby 切换目标为预选的备用账号
+	Standby bool
+	// Warm 切换时目标账号对该会话仍有缓存热度
+	Warm                bool
+	Model               string
+	CacheCreationTokens int64
+	CacheReadTokens     int64
+	// EstimatedCost 新账号首个请求因缓存未命中多付的费用
+	EstimatedCost float64
+	CreatedAt     time.Time
+}
+
+// StickySessionFilters ops 面板查询条件
+type StickySessionFilters struct {
+	GroupID     *int64
+	SessionHash string
+	AccountID   int64
+	StartTime   *time.Time
+	EndTime     *time.Time
+}
+
+// StickyUsageDelta 单次请求对 (会话, 账号) 统计的增量
+type StickyUsageDelta struct {
+	GroupID             int64
+	SessionHash         string
+	AccountID           int64
+	InputTokens         int64
+	CacheCreationTokens int64
+	CacheReadTokens     int64
+	At                  time.Time
+}
+
+// StickyFailoverHint 切换后暂存于缓存，新账号首个请求计费时据此估算切换成本
+type StickyFailoverHint struct {
+	FailoverID    int64 `json:"failover_id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+}
+
+// StickySessionRepository 会话缓存统计与切换记录
+type StickySessionRepository interface {
+	UpsertStat(ctx context.Context, delta *StickyUsageDelta) error
+	CreateFailover(ctx context.Context, failover *StickySessionFailover) error
+	// UpdateFailoverCost 回填新账号首个请求的缓存 token 与估算成本
+	UpdateFailoverCost(ctx context.Context, id int64, model string, cacheCreationTokens, cacheReadTokens int64, estimatedCost float64) error
+	ListSessions(ctx context.Context, params pagination.PaginationParams, filters StickySessionFilters) ([]StickySessionSummary, *pagination.PaginationResult, error)
+	ListSessionAccounts(ctx context.Context, groupID int64, sessionHash string) ([]StickySessionStat, error)
+	ListFailovers(ctx context.Context, params pagination.PaginationParams, filters StickySessionFilters) ([]StickySessionFailover, *pagination.PaginationResult, error)
+	// DeleteBefore 分批删除 updated_at / created_at 早于 before 的统计与切换记录
+	DeleteBefore(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+// StickyStandbyCache 备用账号绑定、缓存热度与待估算切换的缓存（与粘性会话同样按分组隔离）
+type StickyStandbyCache interface {
+	GetStandbyAccountID(ctx context.Context, groupID int64, sessionHash string) (int64, error)
+	SetStandbyAccountID(ctx context.Context, groupID int64, sessionHash string, accountID int64, ttl time.Duration) error
+	// RefreshStandbyTTL 刷新备用账号绑定的过期时间，绑定不存在时返回 false
+	RefreshStandbyTTL(ctx context.Context, groupID int64, sessionHash string, ttl time.Duration) (bool, error)
+	DeleteStandbyAccountID(ctx context.Context, groupID int64, sessionHash string) error
+	// TouchWarmth 记录会话在账号上最近一次命中/写入缓存的时间
+	TouchWarmth(ctx context.Context, groupID int64, sessionHash string, accountID int64, at time.Time, ttl time.Duration) error
+	// GetWarmth 返回 账号 → 最近命中/写入缓存时间
+	GetWarmth(ctx context.Context, groupID int64, sessionHash string) (map[int64]time.Time, error)
+	SetFailoverHint(ctx context.Context, groupID int64, sessionHash string, hint *StickyFailoverHint, ttl time.Duration) error
+	// TakeFailoverHint 读取并删除待估算的切换，不存在时返回 nil
+	TakeFailoverHint(ctx context.Context, groupID int64, sessionHash string) (*StickyFailoverHint, error)
+}
+
+// StickyStandbyService 粘性会话备用账号与缓存热度。
+//
+// 主账号被限流或不可调度时，原逻辑直接清除粘性绑定，新账号的 prompt 缓存是冷的，
+// 用户要为整段上下文重新支付缓存写入费用。本服务：
+//   - 为每个粘性会话预选一个备用账号（优先选择对该会话仍有缓存热度的账号），主账号不可用时优先切换到备用账号；
+//   - 按 (会话, 账号) 记录 cache_read_tokens 等用量，标记缓存热度；
+//   - 记录每次切换，并在新账号首个请求计费时估算切换成本（缓存写入价 - 缓存读取价）。
+type StickyStandbyService struct {
+	repo           StickySessionRepository
+	cache          StickyStandbyCache
+	billingService *BillingService
+	cfg            *config.Config
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+
+	workerCtx    context.Context
+	workerCancel context.CancelFunc
+}
+
+// NewStickyStandbyService 创建粘性会话备用账号服务
+func NewStickyStandbyService(repo StickySessionRepository, cache StickyStandbyCache, billingService *BillingService, cfg *config.Config) *StickyStandbyService {
+	workerCtx, workerCancel := context.WithCancel(context.Background())
+	return &StickyStandbyService{
+		repo:           repo,
+		cache:          cache,
+		billingService: billingService,
+		cfg:            cfg,
+		workerCtx:      workerCtx,
+		workerCancel:   workerCancel,
+	}
+}
+
+// Enabled 是否启用备用账号调度与热度统计
+func (s *StickyStandbyService) Enabled() bool {
+	return s != nil && s.cache != nil && s.cfg != nil && s.cfg.Current().Gateway.StickyStandby.Enabled
+}
+
+func (s *StickyStandbyService) warmthTTL() time.Duration {
+	cfg := s.cfg.Current()
+	if cfg != nil && cfg.Gateway.StickyStandby.WarmthTTLSeconds > 0 {
+		return time.Duration(cfg.Gateway.StickyStandby.WarmthTTLSeconds) * time.Second
+	}
+	return defaultStickyWarmthTTL
+}
+
+func (s *StickyStandbyService) retention() time.Duration {
+	cfg := s.cfg.Current()
+	days := defaultStickyRetentionDay
+	if cfg != nil && cfg.Gateway.StickyStandby.StatsRetentionDays > 0 {
+		days = cfg.Gateway.StickyStandby.StatsRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Start 启动过期统计清理任务
+func (s *StickyStandbyService) Start() {
+	if s == nil || s.repo == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			ticker := time.NewTicker(stickyStatsCleanupEvery)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-s.workerCtx.Done():
+					return
+				case <-ticker.C:
+					s.cleanup()
+				}
+			}
+		}()
+	})
+}
+
+// Stop 停止清理任务
+func (s *StickyStandbyService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.workerCancel != nil {
+			s.workerCancel()
+		}
+		s.wg.Wait()
+		log.Printf("[StickyStandby] stopped")
+	})
+}
+
+func (s *StickyStandbyService) cleanup() {
+	ctx, cancel := context.WithTimeout(s.workerCtx, time.Minute)
+	defer cancel()
+	cutoff := time.Now().Add(-s.retention())
+	var total int64
+	for {
+		deleted, err := s.repo.DeleteBefore(ctx, cutoff, stickyStatsCleanupBatch)
+		if err != nil {
+			log.Printf("[StickyStandby] cleanup failed: %v", err)
+			return
+		}
+		total += deleted
+		if deleted < stickyStatsCleanupBatch {
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("[StickyStandby] cleaned up %d expired rows", total)
+	}
+}
+
+// StandbyAccountID 返回会话预选的备用账号（未设置时为 0）
+func (s *StickyStandbyService) StandbyAccountID(ctx context.Context, groupID int64, sessionHash string) int64 {
+	if !s.Enabled() || sessionHash == "" {
+		return 0
+	}
+	accountID, err := s.cache.GetStandbyAccountID(ctx, groupID, sessionHash)
+	if err != nil {
+		return 0
+	}
+	return accountID
+}
+
+// DropStandby 删除失效的备用账号绑定
+func (s *StickyStandbyService) DropStandby(ctx context.Context, groupID int64, sessionHash string) {
+	if !s.Enabled() || sessionHash == "" {
+		return
+	}
+	_ = s.cache.DeleteStandbyAccountID(ctx, groupID, sessionHash)
+}
+
+// EnsureStandby 主账号命中或新绑定后确保会话存在备用账号；已存在时仅续期。
+// candidates 仅在需要重新预选时调用，应返回当前可调度且满足平台/模型要求的账号。
+func (s *StickyStandbyService) EnsureStandby(ctx context.Context, groupID int64, sessionHash string, primaryID int64, candidates func() []*Account) {
+	if !s.Enabled() || sessionHash == "" || primaryID <= 0 {
+		return
+	}
+	if exists, err := s.cache.RefreshStandbyTTL(ctx, groupID, sessionHash, stickySessionTTL); err != nil || exists {
+		return
+	}
+	warmth, _ := s.cache.GetWarmth(ctx, groupID, sessionHash)
+	standby := pickStandbyAccount(candidates(), primaryID, warmth, time.Now().Add(-s.warmthTTL()))
+	if standby == nil {
+		return
+	}
+	_ = s.cache.SetStandbyAccountID(ctx, groupID, sessionHash, standby.ID, stickySessionTTL)
+}
+
+// RecordFailover 粘性会话从 fromID 切换到 toID 时记录切换，并暂存提示供首个请求计费时估算成本
+func (s *StickyStandbyService) RecordFailover(ctx context.Context, groupID int64, sessionHash string, fromID, toID int64) {
+	if !s.Enabled() || sessionHash == "" || fromID <= 0 || toID <= 0 || fromID == toID {
+		return
+	}
+	standbyID, _ := s.cache.GetStandbyAccountID(ctx, groupID, sessionHash)
+	warmth, _ := s.cache.GetWarmth(ctx, groupID, sessionHash)
+	lastWarm, warm := warmth[toID]
+	failover := &StickySessionFailover{
+		GroupID:       groupID,
+		SessionHash:   sessionHash,
+		FromAccountID: fromID,
+		ToAccountID:   toID,
+		Standby:       standbyID == toID,
+		Warm:          warm && lastWarm.After(time.Now().Add(-s.warmthTTL())),
+	}
+	// 备用账号已转正，下次命中时重新预选
+	if failover.Standby {
+		_ = s.cache.DeleteStandbyAccountID(ctx, groupID, sessionHash)
+	}
+	if s.repo == nil {
+		return
+	}
+	if err := s.repo.CreateFailover(ctx, failover); err != nil {
+		log.Printf("[StickyStandby] record failover failed: session=%s err=%v", shortSessionHash(sessionHash), err)
+		return
+	}
+	hint := &StickyFailoverHint{FailoverID: failover.ID, FromAccountID: fromID, ToAccountID: toID}
+	_ = s.cache.SetFailoverHint(ctx, groupID, sessionHash, hint, stickyFailoverHintTTL)
+}
+
+// ObserveUsage 记录单次请求的会话缓存用量：更新 (会话, 账号) 统计与缓存热度，
+// 若该请求是切换后新账号的首个请求，回填切换的估算成本。
+func (s *StickyStandbyService) ObserveUsage(ctx context.Context, groupID *int64, sessionHash string, accountID int64, model string, tokens UsageTokens, multiplier float64) {
+	if !s.Enabled() || sessionHash == "" || accountID <= 0 {
+		return
+	}
+	gid := derefGroupID(groupID)
+	now := time.Now()
+	// 缓存写入同样会在该账号上留下前缀缓存，视为热
+	if tokens.CacheReadTokens > 0 || tokens.CacheCreationTokens > 0 {
+		_ = s.cache.TouchWarmth(ctx, gid, sessionHash, accountID, now, s.warmthTTL())
+	}
+	if s.repo == nil {
+		return
+	}
+	if err := s.repo.UpsertStat(ctx, &StickyUsageDelta{
+		GroupID:             gid,
+		SessionHash:         sessionHash,
+		AccountID:           accountID,
+		InputTokens:         int64(tokens.InputTokens),
+		CacheCreationTokens: int64(tokens.CacheCreationTokens),
+		CacheReadTokens:     int64(tokens.CacheReadTokens),
+		At:                  now,
+	}); err != nil {
+		log.Printf("[StickyStandby] upsert session stat failed: session=%s err=%v", shortSessionHash(sessionHash), err)
+	}
+
+	hint, err := s.cache.TakeFailoverHint(ctx, gid, sessionHash)
+	if err != nil || hint == nil || hint.ToAccountID != accountID || hint.FailoverID <= 0 {
+		return
+	}
+	cost := s.estimateFailoverCost(model, groupID, tokens, multiplier)
+	if err := s.repo.UpdateFailoverCost(ctx, hint.FailoverID, model, int64(tokens.CacheCreationTokens), int64(tokens.CacheReadTokens), cost); err != nil {
+		log.Printf("[StickyStandby] update failover cost failed: id=%d err=%v", hint.FailoverID, err)
+	}
+}
+
+// estimateFailoverCost 估算缓存未命中多付的费用：实际费用 - 缓存写入全部按缓存读取计费时的费用
+func (s *StickyStandbyService) estimateFailoverCost(model string, groupID *int64, tokens UsageTokens, multiplier float64) float64 {
+	if s.billingService == nil || tokens.CacheCreationTokens <= 0 {
+		return 0
+	}
+	actual, err := s.billingService.CalculateCostForGroup(model, groupID, tokens, multiplier)
+	if err != nil {
+		return 0
+	}
+	warm := tokens
+	warm.CacheReadTokens += warm.CacheCreationTokens
+	warm.CacheCreationTokens, warm.CacheCreation5mTokens, warm.CacheCreation1hTokens = 0, 0, 0
+	counterfactual, err := s.billingService.CalculateCostForGroup(model, groupID, warm, multiplier)
+	if err != nil {
+		return 0
+	}
+	if diff := actual.ActualCost - counterfactual.ActualCost; diff > 0 {
+		return diff
+	}
+	return 0
+}
+
+// ListSessions ops 面板：按会话汇总缓存命中率与切换成本
+func (s *StickyStandbyService) ListSessions(ctx context.Context, params pagination.PaginationParams, filters StickySessionFilters) ([]StickySessionSummary, *pagination.PaginationResult, error) {
+	filters.SessionHash = strings.TrimSpace(filters.SessionHash)
+	return s.repo.ListSessions(ctx, params, filters)
+}
+
+// ListSessionAccounts ops 面板：单个会话在各账号上的缓存命中情况
+func (s *StickyStandbyService) ListSessionAccounts(ctx context.Context, groupID int64, sessionHash string) ([]StickySessionStat, error) {
+	return s.repo.ListSessionAccounts(ctx, groupID, strings.TrimSpace(sessionHash))
+}
+
+// ListFailovers ops 面板：切换记录与估算成本
+func (s *StickyStandbyService) ListFailovers(ctx context.Context, params pagination.PaginationParams, filters StickySessionFilters) ([]StickySessionFailover, *pagination.PaginationResult, error) {
+	filters.SessionHash = strings.TrimSpace(filters.SessionHash)
+	return s.repo.ListFailovers(ctx, params, filters)
+}
+
+// pickStandbyAccount 在候选账号中选出备用账号：
+// 对该会话仍有缓存热度的账号优先（越近越优先），其次按优先级、最久未使用。
+func pickStandbyAccount(candidates []*Account, primaryID int64, warmth map[int64]time.Time, warmSince time.Time) *Account {
+	pool := make([]*Account, 0, len(candidates))
+	for _, acc := range candidates {
+		if acc != nil && acc.ID != primaryID && acc.IsSchedulable() {
+			pool = append(pool, acc)
+		}
+	}
+	if len(pool) == 0 {
+		return nil
+	}
+	warmAt := func(acc *Account) (time.Time, bool) {
+		at, ok := warmth[acc.ID]
+		return at, ok && at.After(warmSince)
+	}
+	sort.SliceStable(pool, func(i, j int) bool {
+		a, b := pool[i], pool[j]
+		aAt, aWarm := warmAt(a)
+		bAt, bWarm := warmAt(b)
+		if aWarm != bWarm {
+			return aWarm
+		}
+		if aWarm && !aAt.Equal(bAt) {
+			return aAt.After(bAt)
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		switch {
+		case a.LastUsedAt == nil && b.LastUsedAt != nil:
+			return true
+		case a.LastUsedAt != nil && b.LastUsedAt == nil:
+			return false
+		case a.LastUsedAt == nil && b.LastUsedAt == nil:
+			return false
+		default:
+			return a.LastUsedAt.Before(*b.LastUsedAt)
+		}
+	})
+	return pool[0]
+}
+
+func cacheHitRatio(input, creation, read int64) float64 {
+	total := input + creation + read
+	if total <= 0 {
+		return 0
+	}
+	return float64(read) / float64(total)
+}
@@ -0,0 +1,219 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+// stickyStandbyCacheStub 内存实现（忽略分组与 TTL）
+type stickyStandbyCacheStub struct {
+	standby map[string]int64
+	warmth  map[string]map[int64]time.Time
+	hints   map[string]*StickyFailoverHint
+}
+
+func newStickyStandbyCacheStub() *stickyStandbyCacheStub {
+	return &stickyStandbyCacheStub{
+		standby: map[string]int64{},
+		warmth:  map[string]map[int64]time.Time{},
+		hints:   map[string]*StickyFailoverHint{},
+	}
+}
+
+func (c *stickyStandbyCacheStub) GetStandbyAccountID(_ context.Context, _ int64, sessionHash string) (int64, error) {
+	if id, ok := c.standby[sessionHash]; ok {
+		return id, nil
+	}
+	return 0, errors.New("not found")
+}
+
+func (c *stickyStandbyCacheStub) SetStandbyAccountID(_ context.Context, _ int64, sessionHash string, accountID int64, _ time.Duration) error {
+	c.standby[sessionHash] = accountID
+	return nil
+}
+
+func (c *stickyStandbyCacheStub) RefreshStandbyTTL(_ context.Context, _ int64, sessionHash string, _ time.Duration) (bool, error) {
+	_, ok := c.standby[sessionHash]
+	return ok, nil
+}
+
+func (c *stickyStandbyCacheStub) DeleteStandbyAccountID(_ context.Context, _ int64, sessionHash string) error {
+	delete(c.standby, sessionHash)
+	return nil
+}
+
+func (c *stickyStandbyCacheStub) TouchWarmth(_ context.Context, _ int64, sessionHash string, accountID int64, at time.Time, _ time.Duration) error {
+	if c.warmth[sessionHash] == nil {
+		c.warmth[sessionHash] = map[int64]time.Time{}
+	}
+	c.warmth[sessionHash][accountID] = at
+	return nil
+}
+
+func (c *stickyStandbyCacheStub) GetWarmth(_ context.Context, _ int64, sessionHash string) (map[int64]time.Time, error) {
+	return c.warmth[sessionHash], nil
+}
+
+func (c *stickyStandbyCacheStub) SetFailoverHint(_ context.Context, _ int64, sessionHash string, hint *StickyFailoverHint, _ time.Duration) error {
+	c.hints[sessionHash] = hint
+	return nil
+}
+
+func (c *stickyStandbyCacheStub) TakeFailoverHint(_ context.Context, _ int64, sessionHash string) (*StickyFailoverHint, error) {
+	hint := c.hints[sessionHash]
+	delete(c.hints, sessionHash)
+	return hint, nil
+}
+
+type stickySessionRepoStub struct {
+	deltas    []StickyUsageDelta
+	failovers []StickySessionFailover
+}
+
+func (r *stickySessionRepoStub) UpsertStat(_ context.Context, delta *StickyUsageDelta) error {
+	r.deltas = append(r.deltas, *delta)
+	return nil
+}
+
+func (r *stickySessionRepoStub) CreateFailover(_ context.Context, failover *StickySessionFailover) error {
+	failover.ID = int64(len(r.failovers) + 1)
+	r.failovers = append(r.failovers, *failover)
+	return nil
+}
+
+func (r *stickySessionRepoStub) UpdateFailoverCost(_ context.Context, id int64, model string, cacheCreationTokens, cacheReadTokens int64, estimatedCost float64) error {
+	f := &r.failovers[id-1]
+	f.Model, f.CacheCreationTokens, f.CacheReadTokens, f.EstimatedCost = model, cacheCreationTokens, cacheReadTokens, estimatedCost
+	return nil
+}
+
+func (r *stickySessionRepoStub) ListSessions(context.Context, pagination.PaginationParams, StickySessionFilters) ([]StickySessionSummary, *pagination.PaginationResult, error) {
+	return nil, nil, nil
+}
+
+func (r *stickySessionRepoStub) ListSessionAccounts(context.Context, int64, string) ([]StickySessionStat, error) {
+	return nil, nil
+}
+
+func (r *stickySessionRepoStub) ListFailovers(context.Context, pagination.PaginationParams, StickySessionFilters) ([]StickySessionFailover, *pagination.PaginationResult, error) {
+	return nil, nil, nil
+}
+
+func (r *stickySessionRepoStub) DeleteBefore(context.Context, time.Time, int) (int64, error) {
+	return 0, nil
+}
+
+func newStickyStandbyTestService() (*StickyStandbyService, *stickyStandbyCacheStub, *stickySessionRepoStub) {
+	cfg := testConfig()
+	cfg.Gateway.StickyStandby = config.GatewayStickyStandbyConfig{Enabled: true, WarmthTTLSeconds: 300, StatsRetentionDays: 7}
+	cache := newStickyStandbyCacheStub()
+	repo := &stickySessionRepoStub{}
+	return NewStickyStandbyService(repo, cache, NewBillingService(cfg, nil, nil), cfg), cache, repo
+}
+
+func TestPickStandbyAccount(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-time.Hour)
+	accounts := []*Account{
+		{ID: 1, Priority: 1, Status: StatusActive, Schedulable: true},
+		{ID: 2, Priority: 1, Status: StatusActive, Schedulable: true, LastUsedAt: &now},
+		{ID: 3, Priority: 1, Status: StatusActive, Schedulable: true, LastUsedAt: &old},
+		{ID: 4, Priority: 5, Status: StatusActive, Schedulable: true},
+		{ID: 5, Priority: 0, Status: StatusDisabled, Schedulable: true},
+	}
+	warmSince := now.Add(-5 * time.Minute)
+
+	// 无热度：优先级 > 最久未使用，且不选主账号与不可调度账号
+	require.Equal(t, int64(3), pickStandbyAccount(accounts, 1, nil, warmSince).ID)
+
+	// 有热度的账号优先于优先级更高的账号；过期热度不计
+	warmth := map[int64]time.Time{4: now.Add(-time.Minute), 2: now.Add(-10 * time.Minute)}
+	require.Equal(t, int64(4), pickStandbyAccount(accounts, 1, warmth, warmSince).ID)
+
+	require.Nil(t, pickStandbyAccount(accounts[:1], 1, nil, warmSince))
+}
+
+func TestStickyStandbyServiceFailoverCost(t *testing.T) {
+	svc, cache, repo := newStickyStandbyTestService()
+	ctx := context.Background()
+
+	// 备用账号 2 对会话有热度，切换后首个请求回填估算成本
+	cache.standby["s1"] = 2
+	require.NoError(t, cache.TouchWarmth(ctx, 0, "s1", 2, time.Now(), time.Minute))
+	svc.RecordFailover(ctx, 0, "s1", 1, 2)
+	require.Len(t, repo.failovers, 1)
+	require.True(t, repo.failovers[0].Standby)
+	require.True(t, repo.failovers[0].Warm)
+	require.NotContains(t, cache.standby, "s1", "备用账号转正后应重新预选")
+
+	tokens := UsageTokens{InputTokens: 10, CacheCreationTokens: 1_000_000}
+	svc.ObserveUsage(ctx, nil, "s1", 2, "claude-sonnet-4", tokens, 2.0)
+	require.Len(t, repo.deltas, 1)
+	require.Equal(t, int64(1_000_000), repo.deltas[0].CacheCreationTokens)
+	// (缓存写入价 - 缓存读取价) × 100 万 token × 倍率
+	pricing := svc.billingService.fallbackPrices["claude-sonnet-4"]
+	expected := (pricing.CacheCreationPricePerToken - pricing.CacheReadPricePerToken) * 1_000_000 * 2.0
+	require.InDelta(t, expected, repo.failovers[0].EstimatedCost, 1e-9)
+	require.Equal(t, int64(1_000_000), repo.failovers[0].CacheCreationTokens)
+	require.Empty(t, cache.hints, "切换提示只使用一次")
+
+	// 同一账号或未启用时不记录切换
+	svc.RecordFailover(ctx, 0, "s1", 2, 2)
+	require.Len(t, repo.failovers, 1)
+	var disabled *StickyStandbyService
+	disabled.RecordFailover(ctx, 0, "s1", 1, 3)
+	disabled.ObserveUsage(ctx, nil, "s1", 1, "claude-sonnet-4", tokens, 1)
+}
+
+func TestGatewayServiceSelectsStickyStandby(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockAccountRepoForPlatform{
+		accounts: []Account{
+			{ID: 1, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5},
+			{ID: 2, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5},
+			{ID: 3, Platform: PlatformAnthropic, Priority: 9, Status: StatusActive, Schedulable: true, Concurrency: 5},
+		},
+		accountsByID: map[int64]*Account{},
+	}
+	for i := range repo.accounts {
+		repo.accountsByID[repo.accounts[i].ID] = &repo.accounts[i]
+	}
+	standby, standbyCache, sessions := newStickyStandbyTestService()
+	cfg := standby.cfg
+	cfg.Gateway.Scheduling.LoadBatchEnabled = true
+	gatewayCache := &mockGatewayCacheForPlatform{sessionBindings: map[string]int64{"s1": 1}}
+	svc := &GatewayService{
+		accountRepo:        repo,
+		cache:              gatewayCache,
+		cfg:                cfg,
+		concurrencyService: NewConcurrencyService(&mockConcurrencyCache{}),
+		stickyStandby:      standby,
+	}
+
+	// 粘性命中时预选备用账号：账号 3 优先级低但对会话有热度
+	require.NoError(t, standbyCache.TouchWarmth(ctx, 0, "s1", 3, time.Now(), time.Minute))
+	result, err := svc.SelectAccountWithLoadAwareness(ctx, nil, "s1", "claude-sonnet-4", nil, "")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.Account.ID)
+	require.Equal(t, int64(3), standbyCache.standby["s1"])
+
+	// 主账号失败被排除后切换到备用账号，而非优先级更高的账号 2
+	result, err = svc.SelectAccountWithLoadAwareness(ctx, nil, "s1", "claude-sonnet-4", map[int64]struct{}{1: {}}, "")
+	require.NoError(t, err)
+	require.True(t, result.Acquired)
+	require.Equal(t, int64(3), result.Account.ID)
+	require.Equal(t, int64(3), gatewayCache.sessionBindings["s1"])
+	require.Len(t, sessions.failovers, 1)
+	require.Equal(t, int64(1), sessions.failovers[0].FromAccountID)
+	require.True(t, sessions.failovers[0].Standby)
+	require.True(t, sessions.failovers[0].Warm)
+	require.Equal(t, int64(2), standbyCache.standby["s1"], "转正后应重新预选备用账号")
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// Subscription plan status constants
+const (
+	SubscriptionPlanStatusActive   = "active"
+	SubscriptionPlanStatusDisabled = "disabled"
+)
+
+// Subscription plan order action constants
+const (
+	SubscriptionPlanActionPurchase  = "purchase"   // 首次购买（或订阅过期后重新购买）
+	SubscriptionPlanActionRenew     = "renew"      // 手动续费
+	SubscriptionPlanActionUpgrade   = "upgrade"    // 升级（按剩余价值折算）
+	SubscriptionPlanActionAutoRenew = "auto_renew" // 到期前自动续费
+)
+
+var (
+	ErrSubscriptionPlanNotFound            = infraerrors.NotFound("SUBSCRIPTION_PLAN_NOT_FOUND", "subscription plan not found")
+	ErrSubscriptionPlanUnavailable         = infraerrors.BadRequest("SUBSCRIPTION_PLAN_UNAVAILABLE", "subscription plan is not available")
+	ErrSubscriptionPlanChangeRequired      = infraerrors.Conflict("SUBSCRIPTION_PLAN_CHANGE_REQUIRED", "an active subscription on another plan exists, change plan instead")
+	ErrSubscriptionPlanGroupMismatch       = infraerrors.BadRequest("SUBSCRIPTION_PLAN_GROUP_MISMATCH", "plans can only be changed within the same group")
+	ErrSubscriptionPlanSameTier            = infraerrors.BadRequest("SUBSCRIPTION_PLAN_SAME_TIER", "target plan has the same tier as the current plan")
+	ErrSubscriptionPlanUpgradeNotAllowed   = infraerrors.Forbidden("SUBSCRIPTION_PLAN_UPGRADE_NOT_ALLOWED", "upgrade is not allowed from the current plan")
+	ErrSubscriptionPlanDowngradeNotAllowed = infraerrors.Forbidden("SUBSCRIPTION_PLAN_DOWNGRADE_NOT_ALLOWED", "downgrade is not allowed from the current plan")
+	ErrSubscriptionPlanAutoRenewNotAllowed = infraerrors.BadRequest("SUBSCRIPTION_PLAN_AUTO_RENEW_NOT_ALLOWED", "auto-renew is not available for this plan")
+	ErrSubscriptionNotPlanBound            = infraerrors.BadRequest("SUBSCRIPTION_NOT_PLAN_BOUND", "subscription was not purchased from a plan")
+	ErrSubscriptionPlanRenewalDuplicate    = infraerrors.Conflict("SUBSCRIPTION_PLAN_RENEWAL_DUPLICATE", "subscription has already been renewed for this period")
+)
+
+// SubscriptionPlan 订阅套餐（可售商品）
+//
+// 套餐引用一个订阅类型分组，价格以余额（USD）计；限额字段为空时沿用分组限额。
+// 同一分组下的套餐按 Tier 排序，Tier 更高视为升级。
+type SubscriptionPlan struct {
+	ID              int64
+	Name            string
+	Description     string
+	GroupID         int64
+	Price           float64
+	ValidityDays    int
+	DailyLimitUSD   *float64
+	WeeklyLimitUSD  *float64
+	MonthlyLimitUSD *float64
+	Tier            int
+	AllowUpgrade    bool // 是否允许从本套餐升级
+	AllowDowngrade  bool // 是否允许从本套餐降级
+	AllowAutoRenew  bool
+	SortOrder       int
+	Status          string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+
+	Group *Group
+}
+
+// IsActive 套餐是否可售
+func (p *SubscriptionPlan) IsActive() bool {
+	return p.Status == SubscriptionPlanStatusActive
+}
+
+// Binding 返回该套餐写入订阅的绑定信息
+func (p *SubscriptionPlan) Binding(autoRenew bool) *SubscriptionPlanBinding {
+	planID := p.ID
+	return &SubscriptionPlanBinding{
+		PlanID:          &planID,
+		AutoRenew:       autoRenew && p.AllowAutoRenew,
+		DailyLimitUSD:   p.DailyLimitUSD,
+		WeeklyLimitUSD:  p.WeeklyLimitUSD,
+		MonthlyLimitUSD: p.MonthlyLimitUSD,
+	}
+}
+
+// SubscriptionPlanBinding 订阅上的套餐绑定字段
+type SubscriptionPlanBinding struct {
+	PlanID          *int64
+	NextPlanID      *int64
+	AutoRenew       bool
+	DailyLimitUSD   *float64
+	WeeklyLimitUSD  *float64
+	MonthlyLimitUSD *float64
+}
+
+// PlanBinding 返回订阅当前的套餐绑定信息
+func (s *UserSubscription) PlanBinding() SubscriptionPlanBinding {
+	return SubscriptionPlanBinding{
+		PlanID:          s.PlanID,
+		NextPlanID:      s.NextPlanID,
+		AutoRenew:       s.AutoRenew,
+		DailyLimitUSD:   s.DailyLimitUSD,
+		WeeklyLimitUSD:  s.WeeklyLimitUSD,
+		MonthlyLimitUSD: s.MonthlyLimitUSD,
+	}
+}
+
+// SubscriptionPlanOrder 套餐余额购买记录
+type SubscriptionPlanOrder struct {
+	ID             int64
+	UserID         int64
+	PlanID         int64
+	SubscriptionID *int64
+	Action         string
+	Price          float64 // 套餐标价
+	Credit         float64 // 升级时折算的原套餐剩余价值
+	Amount         float64 // 实际扣除余额
+	ExpiresAt      *time.Time
+	CreatedAt      time.Time
+}
+
+// SubscriptionPlanFilters 套餐列表筛选条件
+type SubscriptionPlanFilters struct {
+	GroupID *int64
+	Status  string
+}
+
+type SubscriptionPlanRepository interface {
+	Create(ctx context.Context, plan *SubscriptionPlan) error
+	GetByID(ctx context.Context, id int64) (*SubscriptionPlan, error)
+	Update(ctx context.Context, plan *SubscriptionPlan) error
+	Delete(ctx context.Context, id int64) error
+	List(ctx context.Context, filters SubscriptionPlanFilters) ([]SubscriptionPlan, error)
+
+	// CreateOrder 写入购买记录；renewalKey 非空时唯一，重复写入返回 ErrSubscriptionPlanRenewalDuplicate
+	CreateOrder(ctx context.Context, order *SubscriptionPlanOrder, renewalKey string) error
+	ListOrdersByUser(ctx context.Context, userID int64, params pagination.PaginationParams) ([]SubscriptionPlanOrder, *pagination.PaginationResult, error)
+
+	// DeductBalanceIfSufficient 余额充足时原子扣减，余额不足返回 false
+	DeductBalanceIfSufficient(ctx context.Context, userID int64, amount float64) (bool, error)
+}
@@ -0,0 +1,790 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+const subscriptionAutoRenewWorkerName = "subscription_plan_auto_renew"
+
+// SubscriptionPlanInput 管理员创建/更新套餐输入（更新时 nil 字段保持不变）
+type SubscriptionPlanInput struct {
+	Name            *string
+	Description     *string
+	GroupID         *int64
+	Price           *float64
+	ValidityDays    *int
+	DailyLimitUSD   *float64
+	WeeklyLimitUSD  *float64
+	MonthlyLimitUSD *float64
+	// ClearLimits 为 true 时清空全部限额覆盖（沿用分组限额），优先于上面的限额字段
+	ClearLimits    bool
+	Tier           *int
+	AllowUpgrade   *bool
+	AllowDowngrade *bool
+	AllowAutoRenew *bool
+	SortOrder      *int
+	Status         *string
+}
+
+// SubscriptionPlanPurchaseResult 套餐购买/变更结果
+type SubscriptionPlanPurchaseResult struct {
+	Subscription *UserSubscription
+	Order        *SubscriptionPlanOrder // 降级（下期生效）时为 nil
+	Scheduled    bool                   // 降级已登记，将在下次续费时生效
+}
+
+// SubscriptionPlanService 订阅套餐服务
+//
+// 在 SubscriptionService 之上提供套餐目录、余额购买、升降级与自动续费：
+// 扣费、订阅延期与套餐绑定在同一事务内完成；自动续费以 (订阅, 到期时间) 作为幂等键，
+// 多实例并发执行时只会扣费一次。
+type SubscriptionPlanService struct {
+	planRepo             SubscriptionPlanRepository
+	userRepo             UserRepository
+	groupRepo            GroupRepository
+	userSubRepo          UserSubscriptionRepository
+	subscriptionService  *SubscriptionService
+	billingCacheService  *BillingCacheService
+	authCacheInvalidator APIKeyAuthCacheInvalidator
+	emailService         *EmailService
+	entClient            *dbent.Client
+	timingWheel          *TimingWheelService
+	cfg                  *config.Config
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewSubscriptionPlanService 创建订阅套餐服务
+func NewSubscriptionPlanService(
+	planRepo SubscriptionPlanRepository,
+	userRepo UserRepository,
+	groupRepo GroupRepository,
+	userSubRepo UserSubscriptionRepository,
+	subscriptionService *SubscriptionService,
+	billingCacheService *BillingCacheService,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	emailService *EmailService,
+	entClient *dbent.Client,
+	timingWheel *TimingWheelService,
+	cfg *config.Config,
+) *SubscriptionPlanService {
+	return &SubscriptionPlanService{
+		planRepo:             planRepo,
+		userRepo:             userRepo,
+		groupRepo:            groupRepo,
+		userSubRepo:          userSubRepo,
+		subscriptionService:  subscriptionService,
+		billingCacheService:  billingCacheService,
+		authCacheInvalidator: authCacheInvalidator,
+		emailService:         emailService,
+		entClient:            entClient,
+		timingWheel:          timingWheel,
+		cfg:                  cfg,
+	}
+}
+
+// Start 启动自动续费任务
+func (s *SubscriptionPlanService) Start() {
+	if s == nil || s.cfg == nil {
+		return
+	}
+	if !s.cfg.SubPlans.AutoRenewEnabled {
+		log.Printf("[SubscriptionPlan] auto-renew not started (disabled)")
+		return
+	}
+	if s.planRepo == nil || s.userSubRepo == nil || s.timingWheel == nil {
+		log.Printf("[SubscriptionPlan] auto-renew not started (missing deps)")
+		return
+	}
+	interval := time.Duration(s.cfg.SubPlans.AutoRenewIntervalSeconds) * time.Second
+	s.startOnce.Do(func() {
+		s.timingWheel.ScheduleRecurring(subscriptionAutoRenewWorkerName, interval, s.runAutoRenew)
+		log.Printf("[SubscriptionPlan] auto-renew started (interval=%s renew_before=%dh)", interval, s.cfg.SubPlans.RenewBeforeHours)
+	})
+}
+
+// Stop 停止后台任务
+func (s *SubscriptionPlanService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(subscriptionAutoRenewWorkerName)
+		}
+		log.Printf("[SubscriptionPlan] auto-renew stopped")
+	})
+}
+
+// ==================== 套餐管理 ====================
+
+// ListPlans 管理员获取套餐列表
+func (s *SubscriptionPlanService) ListPlans(ctx context.Context, filters SubscriptionPlanFilters) ([]SubscriptionPlan, error) {
+	plans, err := s.planRepo.List(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	s.attachGroups(ctx, plans)
+	return plans, nil
+}
+
+// ListAvailablePlans 用户获取可购买的套餐列表
+func (s *SubscriptionPlanService) ListAvailablePlans(ctx context.Context) ([]SubscriptionPlan, error) {
+	plans, err := s.planRepo.List(ctx, SubscriptionPlanFilters{Status: SubscriptionPlanStatusActive})
+	if err != nil {
+		return nil, err
+	}
+	s.attachGroups(ctx, plans)
+
+	// 分组已停用或不再是订阅类型的套餐不对用户展示
+	out := plans[:0]
+	for i := range plans {
+		if g := plans[i].Group; g != nil && g.IsActive() && g.IsSubscriptionType() {
+			out = append(out, plans[i])
+		}
+	}
+	return out, nil
+}
+
+// GetPlan 获取套餐
+func (s *SubscriptionPlanService) GetPlan(ctx context.Context, id int64) (*SubscriptionPlan, error) {
+	plan, err := s.planRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if group, err := s.groupRepo.GetByID(ctx, plan.GroupID); err == nil {
+		plan.Group = group
+	}
+	return plan, nil
+}
+
+// CreatePlan 管理员创建套餐
+func (s *SubscriptionPlanService) CreatePlan(ctx context.Context, input *SubscriptionPlanInput) (*SubscriptionPlan, error) {
+	if input == nil || input.Name == nil || input.GroupID == nil || input.Price == nil || input.ValidityDays == nil {
+		return nil, infraerrors.BadRequest("SUBSCRIPTION_PLAN_INVALID", "name, group_id, price and validity_days are required")
+	}
+	plan := &SubscriptionPlan{
+		AllowUpgrade:   true,
+		AllowDowngrade: true,
+		AllowAutoRenew: true,
+		Status:         SubscriptionPlanStatusActive,
+	}
+	applySubscriptionPlanInput(plan, input)
+	if err := s.validatePlan(ctx, plan); err != nil {
+		return nil, err
+	}
+	if err := s.planRepo.Create(ctx, plan); err != nil {
+		return nil, err
+	}
+	return s.GetPlan(ctx, plan.ID)
+}
+
+// UpdatePlan 管理员更新套餐（已购订阅的限额在下次购买/续费时同步）
+func (s *SubscriptionPlanService) UpdatePlan(ctx context.Context, id int64, input *SubscriptionPlanInput) (*SubscriptionPlan, error) {
+	if input == nil {
+		return nil, infraerrors.BadRequest("SUBSCRIPTION_PLAN_INVALID", "update input is required")
+	}
+	plan, err := s.planRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	applySubscriptionPlanInput(plan, input)
+	if err := s.validatePlan(ctx, plan); err != nil {
+		return nil, err
+	}
+	if err := s.planRepo.Update(ctx, plan); err != nil {
+		return nil, err
+	}
+	return s.GetPlan(ctx, id)
+}
+
+// DeletePlan 管理员删除套餐（软删除；已绑定订阅的自动续费将失败并通知用户）
+func (s *SubscriptionPlanService) DeletePlan(ctx context.Context, id int64) error {
+	return s.planRepo.Delete(ctx, id)
+}
+
+func applySubscriptionPlanInput(plan *SubscriptionPlan, input *SubscriptionPlanInput) {
+	if input.Name != nil {
+		plan.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Description != nil {
+		plan.Description = strings.TrimSpace(*input.Description)
+	}
+	if input.GroupID != nil {
+		plan.GroupID = *input.GroupID
+	}
+	if input.Price != nil {
+		plan.Price = roundBalanceAmount(*input.Price)
+	}
+	if input.ValidityDays != nil {
+		plan.ValidityDays = *input.ValidityDays
+	}
+	if input.ClearLimits {
+		plan.DailyLimitUSD, plan.WeeklyLimitUSD, plan.MonthlyLimitUSD = nil, nil, nil
+	} else {
+		if input.DailyLimitUSD != nil {
+			plan.DailyLimitUSD = input.DailyLimitUSD
+		}
+		if input.WeeklyLimitUSD != nil {
+			plan.WeeklyLimitUSD = input.WeeklyLimitUSD
+		}
+		if input.MonthlyLimitUSD != nil {
+			plan.MonthlyLimitUSD = input.MonthlyLimitUSD
+		}
+	}
+	if input.Tier != nil {
+		plan.Tier = *input.Tier
+	}
+	if input.AllowUpgrade != nil {
+		plan.AllowUpgrade = *input.AllowUpgrade
+	}
+	if input.AllowDowngrade != nil {
+		plan.AllowDowngrade = *input.AllowDowngrade
+	}
+	if input.AllowAutoRenew != nil {
+		plan.AllowAutoRenew = *input.AllowAutoRenew
+	}
+	if input.SortOrder != nil {
+		plan.SortOrder = *input.SortOrder
+	}
+	if input.Status != nil {
+		plan.Status = *input.Status
+	}
+}
+
+func (s *SubscriptionPlanService) validatePlan(ctx context.Context, plan *SubscriptionPlan) error {
+	if plan.Name == "" {
+		return infraerrors.BadRequest("SUBSCRIPTION_PLAN_INVALID", "name is required")
+	}
+	if plan.Price <= 0 {
+		return infraerrors.BadRequest("SUBSCRIPTION_PLAN_INVALID", "price must be positive")
+	}
+	if plan.ValidityDays <= 0 || plan.ValidityDays > MaxValidityDays {
+		return infraerrors.BadRequest("SUBSCRIPTION_PLAN_INVALID", fmt.Sprintf("validity_days must be between 1 and %d", MaxValidityDays))
+	}
+	for _, limit := range []*float64{plan.DailyLimitUSD, plan.WeeklyLimitUSD, plan.MonthlyLimitUSD} {
+		if limit != nil && *limit < 0 {
+			return infraerrors.BadRequest("SUBSCRIPTION_PLAN_INVALID", "limits must be non-negative")
+		}
+	}
+	if plan.Status != SubscriptionPlanStatusActive && plan.Status != SubscriptionPlanStatusDisabled {
+		return infraerrors.BadRequest("SUBSCRIPTION_PLAN_INVALID", "status must be active or disabled")
+	}
+
+	group, err := s.groupRepo.GetByID(ctx, plan.GroupID)
+	if err != nil {
+		return err
+	}
+	if !group.IsSubscriptionType() {
+		return ErrGroupNotSubscriptionType
+	}
+	return nil
+}
+
+// attachGroups 为套餐列表填充分组信息（分组数量少，逐个查询即可）
+func (s *SubscriptionPlanService) attachGroups(ctx context.Context, plans []SubscriptionPlan) {
+	groups := make(map[int64]*Group)
+	for i := range plans {
+		groupID := plans[i].GroupID
+		group, ok := groups[groupID]
+		if !ok {
+			g, err := s.groupRepo.GetByID(ctx, groupID)
+			if err != nil {
+				log.Printf("[SubscriptionPlan] load group failed: plan_id=%d group_id=%d err=%v", plans[i].ID, groupID, err)
+			}
+			groups[groupID] = g
+			group = g
+		}
+		plans[i].Group = group
+	}
+}
+
+// ==================== 用户购买 ====================
+
+// getAvailablePlan 获取可购买的套餐（套餐与分组均需可用）
+func (s *SubscriptionPlanService) getAvailablePlan(ctx context.Context, planID int64) (*SubscriptionPlan, error) {
+	plan, err := s.planRepo.GetByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.IsActive() {
+		return nil, ErrSubscriptionPlanUnavailable
+	}
+	group, err := s.groupRepo.GetByID(ctx, plan.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if !group.IsActive() || !group.IsSubscriptionType() {
+		return nil, ErrSubscriptionPlanUnavailable
+	}
+	plan.Group = group
+	return plan, nil
+}
+
+// Purchase 用户使用余额购买套餐
+//
+// 分组下没有有效订阅时新开订阅；已有同一套餐（或管理员分配的无套餐订阅）时延长有效期；
+// 已有其他套餐的有效订阅时需走 ChangePlan。autoRenew 为 nil 时续费沿用原设置。
+func (s *SubscriptionPlanService) Purchase(ctx context.Context, userID, planID int64, autoRenew *bool) (*SubscriptionPlanPurchaseResult, error) {
+	plan, err := s.getAvailablePlan(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if autoRenew != nil && *autoRenew && !plan.AllowAutoRenew {
+		return nil, ErrSubscriptionPlanAutoRenewNotAllowed
+	}
+
+	var result *SubscriptionPlanPurchaseResult
+	err = s.runInTx(ctx, func(txCtx context.Context) error {
+		action := SubscriptionPlanActionPurchase
+		enableAutoRenew := autoRenew != nil && *autoRenew
+
+		existing, err := s.userSubRepo.GetActiveByUserIDAndGroupID(txCtx, userID, plan.GroupID)
+		if err != nil && !errors.Is(err, ErrSubscriptionNotFound) {
+			return err
+		}
+		if existing != nil && existing.PlanID != nil {
+			if *existing.PlanID != plan.ID {
+				return ErrSubscriptionPlanChangeRequired
+			}
+			action = SubscriptionPlanActionRenew
+			if autoRenew == nil {
+				enableAutoRenew = existing.AutoRenew
+			}
+		}
+
+		result, err = s.chargeAndExtend(txCtx, userID, plan, action, plan.Binding(enableAutoRenew), nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateCaches(ctx, userID, plan.GroupID)
+	return result, nil
+}
+
+// ChangePlan 在同一分组内切换套餐
+//
+// 升级立即生效：原套餐剩余价值按时间比例折算抵扣新套餐价格，有效期从当前时间重新计算
+// （折算金额超过新套餐价格时，多出部分折算为额外有效期）。
+// 降级登记为下期套餐，在下次续费时生效；对当前套餐再次"切换"可取消已登记的降级。
+func (s *SubscriptionPlanService) ChangePlan(ctx context.Context, userID, subscriptionID, targetPlanID int64) (*SubscriptionPlanPurchaseResult, error) {
+	var result *SubscriptionPlanPurchaseResult
+	err := s.runInTx(ctx, func(txCtx context.Context) error {
+		// 事务内加行锁读取订阅：并发的套餐变更 / 续费串行执行，折算始终基于最新的到期时间
+		sub, err := s.getOwnedSubscriptionForUpdate(txCtx, userID, subscriptionID)
+		if err != nil {
+			return err
+		}
+		result, err = s.changePlan(txCtx, userID, sub, targetPlanID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Order != nil {
+		s.invalidateCaches(ctx, userID, result.Subscription.GroupID)
+	}
+	return result, nil
+}
+
+// changePlan 执行套餐切换（需在事务内调用，sub 已加行锁）
+func (s *SubscriptionPlanService) changePlan(txCtx context.Context, userID int64, sub *UserSubscription, targetPlanID int64) (*SubscriptionPlanPurchaseResult, error) {
+	if !sub.IsActive() {
+		return nil, ErrSubscriptionExpired
+	}
+	if sub.PlanID == nil {
+		return nil, ErrSubscriptionNotPlanBound
+	}
+
+	// 取消已登记的降级
+	if targetPlanID == *sub.PlanID {
+		if sub.NextPlanID == nil {
+			return nil, ErrSubscriptionPlanSameTier
+		}
+		binding := sub.PlanBinding()
+		binding.NextPlanID = nil
+		if err := s.userSubRepo.UpdatePlanBinding(txCtx, sub.ID, &binding); err != nil {
+			return nil, err
+		}
+		sub.NextPlanID = nil
+		return &SubscriptionPlanPurchaseResult{Subscription: sub}, nil
+	}
+
+	current, err := s.planRepo.GetByID(txCtx, *sub.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.getAvailablePlan(txCtx, targetPlanID)
+	if err != nil {
+		return nil, err
+	}
+	if target.GroupID != sub.GroupID {
+		return nil, ErrSubscriptionPlanGroupMismatch
+	}
+
+	switch {
+	case target.Tier == current.Tier:
+		return nil, ErrSubscriptionPlanSameTier
+
+	case target.Tier < current.Tier:
+		if !current.AllowDowngrade {
+			return nil, ErrSubscriptionPlanDowngradeNotAllowed
+		}
+		binding := sub.PlanBinding()
+		binding.NextPlanID = &target.ID
+		if err := s.userSubRepo.UpdatePlanBinding(txCtx, sub.ID, &binding); err != nil {
+			return nil, err
+		}
+		sub.NextPlanID = &target.ID
+		return &SubscriptionPlanPurchaseResult{Subscription: sub, Scheduled: true}, nil
+	}
+
+	if !current.AllowUpgrade {
+		return nil, ErrSubscriptionPlanUpgradeNotAllowed
+	}
+
+	now := time.Now()
+	credit := proratedPlanCredit(current, sub.ExpiresAt, now)
+	amount := roundBalanceAmount(max(target.Price-credit, 0))
+
+	duration := time.Duration(target.ValidityDays) * 24 * time.Hour
+	if credit > target.Price {
+		// 剩余价值超过新套餐价格：多出部分按新套餐单价折算为额外时长
+		duration += time.Duration(float64(duration) * (credit - target.Price) / target.Price)
+	}
+	expiresAt := now.Add(duration)
+	if expiresAt.After(MaxExpiresAt) {
+		expiresAt = MaxExpiresAt
+	}
+
+	if err := s.deductBalance(txCtx, userID, amount); err != nil {
+		return nil, err
+	}
+	if err := s.userSubRepo.ExtendExpiry(txCtx, sub.ID, expiresAt); err != nil {
+		return nil, fmt.Errorf("extend subscription: %w", err)
+	}
+	binding := target.Binding(sub.AutoRenew)
+	if err := s.userSubRepo.UpdatePlanBinding(txCtx, sub.ID, binding); err != nil {
+		return nil, fmt.Errorf("bind subscription plan: %w", err)
+	}
+
+	order := &SubscriptionPlanOrder{
+		UserID:         userID,
+		PlanID:         target.ID,
+		SubscriptionID: &sub.ID,
+		Action:         SubscriptionPlanActionUpgrade,
+		Price:          target.Price,
+		Credit:         credit,
+		Amount:         amount,
+		ExpiresAt:      &expiresAt,
+	}
+	if err := s.planRepo.CreateOrder(txCtx, order, ""); err != nil {
+		return nil, err
+	}
+
+	sub.ExpiresAt = expiresAt
+	applyPlanBinding(sub, binding)
+	return &SubscriptionPlanPurchaseResult{Subscription: sub, Order: order}, nil
+}
+
+// SetAutoRenew 用户开启/关闭自动续费
+func (s *SubscriptionPlanService) SetAutoRenew(ctx context.Context, userID, subscriptionID int64, enabled bool) (*UserSubscription, error) {
+	sub, err := s.getOwnedSubscription(ctx, userID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.PlanID == nil {
+		return nil, ErrSubscriptionNotPlanBound
+	}
+	if enabled {
+		plan, err := s.planRepo.GetByID(ctx, *sub.PlanID)
+		if err != nil {
+			return nil, err
+		}
+		if !plan.AllowAutoRenew {
+			return nil, ErrSubscriptionPlanAutoRenewNotAllowed
+		}
+	}
+
+	binding := sub.PlanBinding()
+	binding.AutoRenew = enabled
+	if err := s.userSubRepo.UpdatePlanBinding(ctx, sub.ID, &binding); err != nil {
+		return nil, err
+	}
+	sub.AutoRenew = enabled
+	return sub, nil
+}
+
+// ListOrders 用户获取套餐购买记录
+func (s *SubscriptionPlanService) ListOrders(ctx context.Context, userID int64, params pagination.PaginationParams) ([]SubscriptionPlanOrder, *pagination.PaginationResult, error) {
+	return s.planRepo.ListOrdersByUser(ctx, userID, params)
+}
+
+func (s *SubscriptionPlanService) getOwnedSubscription(ctx context.Context, userID, subscriptionID int64) (*UserSubscription, error) {
+	sub, err := s.userSubRepo.GetByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.UserID != userID {
+		return nil, ErrSubscriptionNotFound
+	}
+	return sub, nil
+}
+
+func (s *SubscriptionPlanService) getOwnedSubscriptionForUpdate(txCtx context.Context, userID, subscriptionID int64) (*UserSubscription, error) {
+	sub, err := s.userSubRepo.GetByIDForUpdate(txCtx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.UserID != userID {
+		return nil, ErrSubscriptionNotFound
+	}
+	return sub, nil
+}
+
+// chargeAndExtend 扣除余额、按套餐时长延长（或新开）订阅并写入套餐绑定（需在事务内调用）
+//
+// renewalKey 非空时作为购买记录的幂等键，重复写入返回 ErrSubscriptionPlanRenewalDuplicate 使事务回滚。
+func (s *SubscriptionPlanService) chargeAndExtend(txCtx context.Context, userID int64, plan *SubscriptionPlan, action string, binding *SubscriptionPlanBinding, renewalKey *string) (*SubscriptionPlanPurchaseResult, error) {
+	if err := s.deductBalance(txCtx, userID, plan.Price); err != nil {
+		return nil, err
+	}
+
+	sub, _, err := s.subscriptionService.AssignOrExtendSubscription(txCtx, &AssignSubscriptionInput{
+		UserID:       userID,
+		GroupID:      plan.GroupID,
+		ValidityDays: plan.ValidityDays,
+		AssignedBy:   0, // 系统分配
+		Notes:        fmt.Sprintf("套餐 %s（%s）", plan.Name, action),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userSubRepo.UpdatePlanBinding(txCtx, sub.ID, binding); err != nil {
+		return nil, fmt.Errorf("bind subscription plan: %w", err)
+	}
+	if sub.RenewalFailedAt != nil {
+		if err := s.userSubRepo.SetRenewalFailedAt(txCtx, sub.ID, nil); err != nil {
+			return nil, err
+		}
+		sub.RenewalFailedAt = nil
+	}
+
+	expiresAt := sub.ExpiresAt
+	order := &SubscriptionPlanOrder{
+		UserID:         userID,
+		PlanID:         plan.ID,
+		SubscriptionID: &sub.ID,
+		Action:         action,
+		Price:          plan.Price,
+		Amount:         plan.Price,
+		ExpiresAt:      &expiresAt,
+	}
+	key := ""
+	if renewalKey != nil {
+		key = *renewalKey
+	}
+	if err := s.planRepo.CreateOrder(txCtx, order, key); err != nil {
+		return nil, err
+	}
+
+	applyPlanBinding(sub, binding)
+	return &SubscriptionPlanPurchaseResult{Subscription: sub, Order: order}, nil
+}
+
+func (s *SubscriptionPlanService) deductBalance(txCtx context.Context, userID int64, amount float64) error {
+	if amount <= 0 {
+		return nil
+	}
+	ok, err := s.planRepo.DeductBalanceIfSufficient(txCtx, userID, amount)
+	if err != nil {
+		return fmt.Errorf("deduct balance: %w", err)
+	}
+	if !ok {
+		return ErrInsufficientBalance
+	}
+	return nil
+}
+
+// proratedPlanCredit 计算套餐剩余价值：价格 × 剩余时长 / 套餐时长（叠加续费的剩余时长同样计入）
+func proratedPlanCredit(plan *SubscriptionPlan, expiresAt, now time.Time) float64 {
+	if plan == nil || plan.ValidityDays <= 0 || !expiresAt.After(now) {
+		return 0
+	}
+	period := time.Duration(plan.ValidityDays) * 24 * time.Hour
+	return roundBalanceAmount(plan.Price * float64(expiresAt.Sub(now)) / float64(period))
+}
+
+func applyPlanBinding(sub *UserSubscription, binding *SubscriptionPlanBinding) {
+	sub.PlanID = binding.PlanID
+	sub.NextPlanID = binding.NextPlanID
+	sub.AutoRenew = binding.AutoRenew
+	sub.DailyLimitUSD = binding.DailyLimitUSD
+	sub.WeeklyLimitUSD = binding.WeeklyLimitUSD
+	sub.MonthlyLimitUSD = binding.MonthlyLimitUSD
+}
+
+// ==================== 自动续费 ====================
+
+func (s *SubscriptionPlanService) runAutoRenew() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	dueBefore := time.Now().Add(time.Duration(s.cfg.SubPlans.RenewBeforeHours) * time.Hour)
+	subs, err := s.userSubRepo.ListAutoRenewDue(ctx, dueBefore, s.cfg.SubPlans.BatchSize)
+	if err != nil {
+		log.Printf("[SubscriptionPlan] list auto-renew subscriptions failed: %v", err)
+		return
+	}
+
+	renewed := 0
+	for i := range subs {
+		sub := &subs[i]
+		if err := s.autoRenew(ctx, sub); err != nil {
+			if errors.Is(err, ErrSubscriptionPlanRenewalDuplicate) {
+				continue
+			}
+			s.handleRenewalFailure(ctx, sub, err)
+			continue
+		}
+		renewed++
+	}
+	if renewed > 0 {
+		log.Printf("[SubscriptionPlan] auto-renewed %d subscriptions", renewed)
+	}
+}
+
+// autoRenew 续费单个订阅；已登记降级时按下期套餐续费
+func (s *SubscriptionPlanService) autoRenew(ctx context.Context, sub *UserSubscription) error {
+	if sub.PlanID == nil {
+		return ErrSubscriptionNotPlanBound
+	}
+	planID := *sub.PlanID
+	if sub.NextPlanID != nil {
+		planID = *sub.NextPlanID
+	}
+	plan, err := s.getAvailablePlan(ctx, planID)
+	if err != nil {
+		return err
+	}
+	if !plan.AllowAutoRenew {
+		return ErrSubscriptionPlanAutoRenewNotAllowed
+	}
+
+	// 同一到期时间只续费一次，防止多实例重复扣费
+	renewalKey := fmt.Sprintf("%d:%d", sub.ID, sub.ExpiresAt.Unix())
+	err = s.runInTx(ctx, func(txCtx context.Context) error {
+		// 加行锁后确认到期时间未变：期间已被升级或续费时跳过，避免按过期快照重复续费
+		locked, err := s.userSubRepo.GetByIDForUpdate(txCtx, sub.ID)
+		if err != nil {
+			return err
+		}
+		if !locked.ExpiresAt.Equal(sub.ExpiresAt) {
+			return ErrSubscriptionPlanRenewalDuplicate
+		}
+		_, err = s.chargeAndExtend(txCtx, sub.UserID, plan, SubscriptionPlanActionAutoRenew, plan.Binding(true), &renewalKey)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidateCaches(ctx, sub.UserID, sub.GroupID)
+	return nil
+}
+
+// handleRenewalFailure 记录续费失败并通知用户（每个到期周期只通知一次，续费成功后重置）
+func (s *SubscriptionPlanService) handleRenewalFailure(ctx context.Context, sub *UserSubscription, cause error) {
+	log.Printf("[SubscriptionPlan] auto-renew failed: sub_id=%d user_id=%d err=%v", sub.ID, sub.UserID, cause)
+	if sub.RenewalFailedAt != nil {
+		return
+	}
+
+	now := time.Now()
+	if err := s.userSubRepo.SetRenewalFailedAt(ctx, sub.ID, &now); err != nil {
+		log.Printf("[SubscriptionPlan] mark renewal failed error: sub_id=%d err=%v", sub.ID, err)
+		return
+	}
+	if s.emailService == nil || s.userRepo == nil {
+		return
+	}
+
+	user, err := s.userRepo.GetByID(ctx, sub.UserID)
+	if err != nil || user.Email == "" {
+		return
+	}
+	subject, body := buildRenewalFailureEmail(sub, cause)
+	if err := s.emailService.SendEmail(ctx, user.Email, subject, body); err != nil {
+		log.Printf("[SubscriptionPlan] send renewal failure email failed: sub_id=%d err=%v", sub.ID, err)
+	}
+}
+
+func buildRenewalFailureEmail(sub *UserSubscription, cause error) (string, string) {
+	reason := "the renewal could not be completed"
+	switch {
+	case errors.Is(cause, ErrInsufficientBalance):
+		reason = "your balance is insufficient"
+	case errors.Is(cause, ErrSubscriptionPlanUnavailable), errors.Is(cause, ErrSubscriptionPlanNotFound),
+		errors.Is(cause, ErrSubscriptionPlanAutoRenewNotAllowed):
+		reason = "the plan is no longer available for renewal"
+	}
+
+	subject := "Subscription auto-renewal failed"
+	body := fmt.Sprintf(`<html><body style="font-family: Arial, sans-serif;">
+<p>Automatic renewal of your subscription (#%d) failed because %s.</p>
+<p>The subscription expires at <strong>%s</strong>. Please top up your balance or renew manually before then to avoid interruption.</p>
+<p>We will keep retrying until the subscription expires.</p>
+</body></html>`, sub.ID, html.EscapeString(reason), sub.ExpiresAt.UTC().Format("2006-01-02 15:04 MST"))
+	return subject, body
+}
+
+func (s *SubscriptionPlanService) invalidateCaches(ctx context.Context, userID, groupID int64) {
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, userID)
+	}
+	if s.billingCacheService == nil {
+		return
+	}
+	go func() {
+		cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.billingCacheService.InvalidateUserBalance(cacheCtx, userID)
+		_ = s.billingCacheService.InvalidateSubscription(cacheCtx, userID, groupID)
+	}()
+}
+
+// runInTx 在事务中执行；已处于事务中或未注入 ent client（单元测试）时直接执行
+func (s *SubscriptionPlanService) runInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.entClient == nil || dbent.TxFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	tx, err := s.entClient.Tx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(dbent.NewTxContext(ctx, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,367 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type planRepoStub struct {
+	plans       map[int64]*SubscriptionPlan
+	orders      []SubscriptionPlanOrder
+	renewalKeys map[string]struct{}
+	balances    map[int64]float64
+}
+
+func newPlanRepoStub(plans ...*SubscriptionPlan) *planRepoStub {
+	r := &planRepoStub{
+		plans:       map[int64]*SubscriptionPlan{},
+		renewalKeys: map[string]struct{}{},
+		balances:    map[int64]float64{},
+	}
+	for _, p := range plans {
+		r.plans[p.ID] = p
+	}
+	return r
+}
+
+func (r *planRepoStub) Create(ctx context.Context, plan *SubscriptionPlan) error {
+	plan.ID = int64(len(r.plans) + 1)
+	clone := *plan
+	r.plans[plan.ID] = &clone
+	return nil
+}
+
+func (r *planRepoStub) GetByID(ctx context.Context, id int64) (*SubscriptionPlan, error) {
+	p, ok := r.plans[id]
+	if !ok {
+		return nil, ErrSubscriptionPlanNotFound
+	}
+	clone := *p
+	return &clone, nil
+}
+
+func (r *planRepoStub) Update(ctx context.Context, plan *SubscriptionPlan) error {
+	clone := *plan
+	r.plans[plan.ID] = &clone
+	return nil
+}
+
+func (r *planRepoStub) Delete(ctx context.Context, id int64) error {
+	delete(r.plans, id)
+	return nil
+}
+
+func (r *planRepoStub) List(ctx context.Context, filters SubscriptionPlanFilters) ([]SubscriptionPlan, error) {
+	out := make([]SubscriptionPlan, 0, len(r.plans))
+	for _, p := range r.plans {
+		if filters.Status != "" && p.Status != filters.Status {
+			continue
+		}
+		out = append(out, *p)
+	}
+	return out, nil
+}
+
+func (r *planRepoStub) CreateOrder(ctx context.Context, order *SubscriptionPlanOrder, renewalKey string) error {
+	if renewalKey != "" {
+		if _, ok := r.renewalKeys[renewalKey]; ok {
+			return ErrSubscriptionPlanRenewalDuplicate
+		}
+		r.renewalKeys[renewalKey] = struct{}{}
+	}
+	order.ID = int64(len(r.orders) + 1)
+	r.orders = append(r.orders, *order)
+	return nil
+}
+
+func (r *planRepoStub) ListOrdersByUser(ctx context.Context, userID int64, params pagination.PaginationParams) ([]SubscriptionPlanOrder, *pagination.PaginationResult, error) {
+	return r.orders, &pagination.PaginationResult{Total: int64(len(r.orders))}, nil
+}
+
+func (r *planRepoStub) DeductBalanceIfSufficient(ctx context.Context, userID int64, amount float64) (bool, error) {
+	if r.balances[userID] < amount {
+		return false, nil
+	}
+	r.balances[userID] -= amount
+	return true, nil
+}
+
+type planUserSubRepoStub struct {
+	*paymentUserSubRepoStub
+	renewalFailedCalls int
+	lockCalls          int
+}
+
+func (r *planUserSubRepoStub) GetByIDForUpdate(ctx context.Context, id int64) (*UserSubscription, error) {
+	r.lockCalls++
+	return r.GetByID(ctx, id)
+}
+
+func (r *planUserSubRepoStub) GetActiveByUserIDAndGroupID(ctx context.Context, userID, groupID int64) (*UserSubscription, error) {
+	if r.sub == nil || !r.sub.IsActive() {
+		return nil, ErrSubscriptionNotFound
+	}
+	clone := *r.sub
+	return &clone, nil
+}
+
+func (r *planUserSubRepoStub) UpdateNotes(ctx context.Context, id int64, notes string) error {
+	r.sub.Notes = notes
+	return nil
+}
+
+func (r *planUserSubRepoStub) UpdatePlanBinding(ctx context.Context, id int64, binding *SubscriptionPlanBinding) error {
+	applyPlanBinding(r.sub, binding)
+	return nil
+}
+
+func (r *planUserSubRepoStub) SetRenewalFailedAt(ctx context.Context, id int64, failedAt *time.Time) error {
+	r.renewalFailedCalls++
+	r.sub.RenewalFailedAt = failedAt
+	return nil
+}
+
+func (r *planUserSubRepoStub) ListAutoRenewDue(ctx context.Context, dueBefore time.Time, limit int) ([]UserSubscription, error) {
+	if r.sub == nil || !r.sub.AutoRenew || r.sub.ExpiresAt.After(dueBefore) {
+		return nil, nil
+	}
+	return []UserSubscription{*r.sub}, nil
+}
+
+type planTestEnv struct {
+	svc   *SubscriptionPlanService
+	plans *planRepoStub
+	subs  *planUserSubRepoStub
+}
+
+func newPlanTestEnv(t *testing.T, plans ...*SubscriptionPlan) *planTestEnv {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.SubPlans = config.SubscriptionPlanConfig{AutoRenewEnabled: true, AutoRenewIntervalSeconds: 60, RenewBeforeHours: 24, BatchSize: 10}
+
+	planRepo := newPlanRepoStub(plans...)
+	groups := &paymentGroupRepoStub{group: &Group{ID: 3, Status: StatusActive, SubscriptionType: SubscriptionTypeSubscription}}
+	subs := &planUserSubRepoStub{paymentUserSubRepoStub: &paymentUserSubRepoStub{}}
+	subscriptionService := NewSubscriptionService(groups, subs, nil)
+	svc := NewSubscriptionPlanService(planRepo, nil, groups, subs, subscriptionService, nil, nil, nil, nil, nil, cfg)
+	return &planTestEnv{svc: svc, plans: planRepo, subs: subs}
+}
+
+func testPlan(id int64, tier int, price float64) *SubscriptionPlan {
+	daily := float64(tier * 10)
+	return &SubscriptionPlan{
+		ID:             id,
+		Name:           "plan",
+		GroupID:        3,
+		Price:          price,
+		ValidityDays:   30,
+		DailyLimitUSD:  &daily,
+		Tier:           tier,
+		AllowUpgrade:   true,
+		AllowDowngrade: true,
+		AllowAutoRenew: true,
+		Status:         SubscriptionPlanStatusActive,
+	}
+}
+
+func TestSubscriptionPlanService_PurchaseAndRenew(t *testing.T) {
+	env := newPlanTestEnv(t, testPlan(1, 1, 10))
+	env.plans.balances[7] = 25
+	ctx := context.Background()
+	autoRenew := true
+
+	result, err := env.svc.Purchase(ctx, 7, 1, &autoRenew)
+	require.NoError(t, err)
+	require.Equal(t, SubscriptionPlanActionPurchase, result.Order.Action)
+	require.InDelta(t, 15, env.plans.balances[7], 1e-9)
+	require.NotNil(t, env.subs.sub.PlanID)
+	require.Equal(t, int64(1), *env.subs.sub.PlanID)
+	require.True(t, env.subs.sub.AutoRenew)
+	require.InDelta(t, 10, *env.subs.sub.DailyLimitUSD, 1e-9)
+	firstExpiry := env.subs.sub.ExpiresAt
+
+	// 续费：autoRenew 为空时沿用原设置，有效期在原到期时间上累加
+	result, err = env.svc.Purchase(ctx, 7, 1, nil)
+	require.NoError(t, err)
+	require.Equal(t, SubscriptionPlanActionRenew, result.Order.Action)
+	require.True(t, env.subs.sub.AutoRenew)
+	require.Equal(t, firstExpiry.AddDate(0, 0, 30), env.subs.sub.ExpiresAt)
+
+	_, err = env.svc.Purchase(ctx, 7, 1, nil)
+	require.ErrorIs(t, err, ErrInsufficientBalance)
+	require.InDelta(t, 5, env.plans.balances[7], 1e-9)
+}
+
+func TestSubscriptionPlanService_PurchaseOtherPlanRequiresChange(t *testing.T) {
+	env := newPlanTestEnv(t, testPlan(1, 1, 10), testPlan(2, 2, 20))
+	env.plans.balances[7] = 100
+	ctx := context.Background()
+
+	_, err := env.svc.Purchase(ctx, 7, 1, nil)
+	require.NoError(t, err)
+	_, err = env.svc.Purchase(ctx, 7, 2, nil)
+	require.ErrorIs(t, err, ErrSubscriptionPlanChangeRequired)
+}
+
+func TestSubscriptionPlanService_UpgradeIsProrated(t *testing.T) {
+	env := newPlanTestEnv(t, testPlan(1, 1, 30), testPlan(2, 2, 60))
+	env.plans.balances[7] = 100
+	ctx := context.Background()
+
+	_, err := env.svc.Purchase(ctx, 7, 1, nil)
+	require.NoError(t, err)
+	// 剩余一半时长：抵扣 15
+	env.subs.sub.ExpiresAt = time.Now().Add(15 * 24 * time.Hour)
+
+	result, err := env.svc.ChangePlan(ctx, 7, env.subs.sub.ID, 2)
+	require.NoError(t, err)
+	require.False(t, result.Scheduled)
+	require.Equal(t, SubscriptionPlanActionUpgrade, result.Order.Action)
+	require.InDelta(t, 15, result.Order.Credit, 0.01)
+	require.InDelta(t, 45, result.Order.Amount, 0.01)
+	require.InDelta(t, 25, env.plans.balances[7], 0.01)
+	require.Equal(t, int64(2), *env.subs.sub.PlanID)
+	require.InDelta(t, 20, *env.subs.sub.DailyLimitUSD, 1e-9)
+	require.WithinDuration(t, time.Now().AddDate(0, 0, 30), env.subs.sub.ExpiresAt, time.Minute)
+	require.Equal(t, 1, env.subs.lockCalls, "subscription is read with a row lock inside the tx")
+}
+
+func TestSubscriptionPlanService_DowngradeIsScheduledUntilRenewal(t *testing.T) {
+	env := newPlanTestEnv(t, testPlan(1, 1, 10), testPlan(2, 2, 20))
+	env.plans.balances[7] = 100
+	ctx := context.Background()
+	autoRenew := true
+
+	_, err := env.svc.Purchase(ctx, 7, 2, &autoRenew)
+	require.NoError(t, err)
+	subID := env.subs.sub.ID
+
+	result, err := env.svc.ChangePlan(ctx, 7, subID, 1)
+	require.NoError(t, err)
+	require.True(t, result.Scheduled)
+	require.Nil(t, result.Order)
+	require.Equal(t, int64(2), *env.subs.sub.PlanID, "current plan stays until renewal")
+	require.Equal(t, int64(1), *env.subs.sub.NextPlanID)
+	require.InDelta(t, 80, env.plans.balances[7], 1e-9)
+
+	// 到期前自动续费按下期套餐扣费
+	env.subs.sub.ExpiresAt = time.Now().Add(time.Hour)
+	env.svc.runAutoRenew()
+	require.InDelta(t, 70, env.plans.balances[7], 1e-9)
+	require.Equal(t, int64(1), *env.subs.sub.PlanID)
+	require.Nil(t, env.subs.sub.NextPlanID)
+	require.Equal(t, SubscriptionPlanActionAutoRenew, env.plans.orders[len(env.plans.orders)-1].Action)
+
+	_, err = env.svc.ChangePlan(ctx, 8, subID, 2)
+	require.ErrorIs(t, err, ErrSubscriptionNotFound)
+}
+
+func TestSubscriptionPlanService_ChangePlanRules(t *testing.T) {
+	locked := testPlan(1, 1, 10)
+	locked.AllowUpgrade = false
+	env := newPlanTestEnv(t, locked, testPlan(2, 2, 20), testPlan(3, 1, 15))
+	env.plans.balances[7] = 100
+	ctx := context.Background()
+
+	_, err := env.svc.Purchase(ctx, 7, 1, nil)
+	require.NoError(t, err)
+	subID := env.subs.sub.ID
+
+	_, err = env.svc.ChangePlan(ctx, 7, subID, 2)
+	require.ErrorIs(t, err, ErrSubscriptionPlanUpgradeNotAllowed)
+	_, err = env.svc.ChangePlan(ctx, 7, subID, 3)
+	require.ErrorIs(t, err, ErrSubscriptionPlanSameTier)
+	_, err = env.svc.ChangePlan(ctx, 7, subID, 1)
+	require.ErrorIs(t, err, ErrSubscriptionPlanSameTier)
+}
+
+func TestSubscriptionPlanService_AutoRenewFailureMarkedOnce(t *testing.T) {
+	env := newPlanTestEnv(t, testPlan(1, 1, 10))
+	env.plans.balances[7] = 10
+	ctx := context.Background()
+	autoRenew := true
+
+	_, err := env.svc.Purchase(ctx, 7, 1, &autoRenew)
+	require.NoError(t, err)
+	env.subs.sub.ExpiresAt = time.Now().Add(time.Hour)
+
+	env.svc.runAutoRenew()
+	env.svc.runAutoRenew()
+	require.NotNil(t, env.subs.sub.RenewalFailedAt)
+	require.Equal(t, 1, env.subs.renewalFailedCalls, "failure is recorded (and notified) once per period")
+
+	// 充值后续费成功并清除失败标记
+	env.plans.balances[7] = 10
+	env.svc.runAutoRenew()
+	require.Nil(t, env.subs.sub.RenewalFailedAt)
+	require.InDelta(t, 0, env.plans.balances[7], 1e-9)
+}
+
+func TestSubscriptionPlanService_AutoRenewIdempotentPerPeriod(t *testing.T) {
+	env := newPlanTestEnv(t, testPlan(1, 1, 10))
+	env.plans.balances[7] = 100
+	autoRenew := true
+
+	_, err := env.svc.Purchase(context.Background(), 7, 1, &autoRenew)
+	require.NoError(t, err)
+	sub := *env.subs.sub
+	sub.ExpiresAt = time.Now().Add(time.Hour)
+	env.subs.sub.ExpiresAt = sub.ExpiresAt
+
+	require.NoError(t, env.svc.autoRenew(context.Background(), &sub))
+	// 另一实例持有相同快照再次续费：幂等键冲突（真实环境中事务回滚扣费）
+	err = env.svc.autoRenew(context.Background(), &sub)
+	require.ErrorIs(t, err, ErrSubscriptionPlanRenewalDuplicate)
+
+	renewals := 0
+	for _, order := range env.plans.orders {
+		if order.Action == SubscriptionPlanActionAutoRenew {
+			renewals++
+		}
+	}
+	require.Equal(t, 1, renewals)
+}
+
+func TestSubscriptionPlanService_AutoRenewSkipsChangedSubscription(t *testing.T) {
+	env := newPlanTestEnv(t, testPlan(1, 1, 10), testPlan(2, 2, 20))
+	env.plans.balances[7] = 100
+	ctx := context.Background()
+	autoRenew := true
+
+	_, err := env.svc.Purchase(ctx, 7, 1, &autoRenew)
+	require.NoError(t, err)
+	env.subs.sub.ExpiresAt = time.Now().Add(time.Hour)
+	stale := *env.subs.sub
+
+	// 续费任务拿到快照后，订阅被并发升级：加锁读取到新的到期时间，续费跳过且不扣费
+	_, err = env.svc.ChangePlan(ctx, 7, stale.ID, 2)
+	require.NoError(t, err)
+	balance := env.plans.balances[7]
+
+	err = env.svc.autoRenew(ctx, &stale)
+	require.ErrorIs(t, err, ErrSubscriptionPlanRenewalDuplicate)
+	require.InDelta(t, balance, env.plans.balances[7], 1e-9)
+}
+
+func TestUserSubscription_EffectiveGroupOverridesLimits(t *testing.T) {
+	groupDaily, groupWeekly, override := 5.0, 50.0, 20.0
+	group := &Group{ID: 3, DailyLimitUSD: &groupDaily, WeeklyLimitUSD: &groupWeekly}
+	sub := &UserSubscription{DailyUsageUSD: 10}
+
+	require.Same(t, group, sub.EffectiveGroup(group))
+	require.False(t, sub.CheckDailyLimit(group, 0))
+
+	sub.DailyLimitUSD = &override
+	effective := sub.EffectiveGroup(group)
+	require.InDelta(t, 20, *effective.DailyLimitUSD, 1e-9)
+	require.InDelta(t, 50, *effective.WeeklyLimitUSD, 1e-9)
+	require.InDelta(t, 5, *group.DailyLimitUSD, 1e-9, "original group must not be mutated")
+	require.True(t, sub.CheckDailyLimit(group, 0))
+}
@@ -558,6 +558,8 @@ func (s *SubscriptionService) GetSubscriptionProgress(ctx context.Context, subsc
 		}
 	}
 
+	group = sub.EffectiveGroup(group)
+
 	progress := &SubscriptionProgress{
 		ID:            sub.ID,
 		GroupName:     group.Name,
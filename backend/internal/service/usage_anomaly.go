@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 异常类型
+const (
+	UsageAnomalyKindSpendSpike     = "spend_spike"
+	UsageAnomalyKindRequestSpike   = "request_spike"
+	UsageAnomalyKindModelShift     = "model_shift"
+	UsageAnomalyKindNewSource      = "new_source"
+	UsageAnomalyKindUserSpendSpike = "user_spend_spike"
+)
+
+// 异常级别（与运维告警一致，便于复用告警邮件的最低级别设置）
+const (
+	UsageAnomalySeverityCritical = "P0"
+	UsageAnomalySeverityWarning  = "P1"
+	UsageAnomalySeverityInfo     = "P2"
+)
+
+// 异常事件状态
+const (
+	UsageAnomalyStatusOpen         = "open"
+	UsageAnomalyStatusAcknowledged = "acknowledged"
+)
+
+// 新来源类型
+const (
+	UsageAnomalySourceIP        = "ip"
+	UsageAnomalySourceUserAgent = "user_agent"
+)
+
+const (
+	// usageAnomalyStddevFloorRatio 标准差下限（基线均值的比例），避免平稳基线上的小幅波动被放大为异常
+	usageAnomalyStddevFloorRatio = 0.25
+	// usageAnomalyMaxScore 基线为零时的得分上限
+	usageAnomalyMaxScore = 999
+	// usageAnomalyMaxSourcesPerEvent 单个事件详情中记录的新来源数量上限
+	usageAnomalyMaxSourcesPerEvent = 10
+)
+
+var (
+	ErrUsageAnomalyNotFound      = infraerrors.NotFound("USAGE_ANOMALY_NOT_FOUND", "anomaly event not found")
+	ErrUsageAnomalyInvalidStatus = infraerrors.BadRequest("USAGE_ANOMALY_INVALID_STATUS", "status must be open or acknowledged")
+)
+
+// UsageAnomalyEvent 检测到的用量异常（用户级事件的 APIKeyID 为 0）
+type UsageAnomalyEvent struct {
+	ID             int64
+	UserID         int64
+	APIKeyID       int64
+	BucketStart    time.Time
+	Kind           string
+	Severity       string
+	Observed       float64
+	BaselineMean   float64
+	BaselineStddev float64
+	Score          float64
+	Details        map[string]any
+	KeySuspended   bool
+	Status         string
+	AcknowledgedBy *int64
+	AcknowledgedAt *time.Time
+	CreatedAt      time.Time
+
+	// 列表查询时关联填充
+	UserEmail  string
+	APIKeyName string
+}
+
+// UsageAnomalyEventFilters 异常事件列表筛选条件
+type UsageAnomalyEventFilters struct {
+	Status    string
+	Severity  string
+	Kind      string
+	UserID    int64
+	APIKeyID  int64
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+// UsageAnomalyHourUsage 某小时桶内某 Key 在某模型上的用量
+type UsageAnomalyHourUsage struct {
+	APIKeyID   int64
+	APIKeyName string
+	UserID     int64
+	Model      string
+	Requests   int64
+	ActualCost float64
+}
+
+// UsageAnomalyBaseline 基线窗口内按小时汇总后的统计量；FirstBucket 为窗口内首个有用量的小时桶
+type UsageAnomalyBaseline struct {
+	ID            int64 // api_key_id 或 user_id
+	FirstBucket   time.Time
+	SumCost       float64
+	SumCostSq     float64
+	SumRequests   float64
+	SumRequestsSq float64
+	Models        []string // 仅 Key 级基线填充
+}
+
+// UsageAnomalySource 当前小时出现、基线窗口内未出现过的来源
+type UsageAnomalySource struct {
+	APIKeyID int64
+	Kind     string // ip / user_agent
+	Value    string // IP 段（IPv4 /24，IPv6 /48）或 User-Agent
+}
+
+// UsageAnomalyRepository 异常检测数据访问
+type UsageAnomalyRepository interface {
+	// GetWatermark 返回已检测到的小时桶末尾（不含）
+	GetWatermark(ctx context.Context) (time.Time, error)
+	// UpdateWatermark 单调推进检测水位
+	UpdateWatermark(ctx context.Context, bucketEnd time.Time) error
+
+	// ListHourUsage 返回指定小时桶内按 (Key, 模型) 汇总的用量
+	ListHourUsage(ctx context.Context, bucketStart time.Time) ([]UsageAnomalyHourUsage, error)
+	// ListKeyBaselines 返回 [from, to) 内各 Key 的小时统计量与使用过的模型
+	ListKeyBaselines(ctx context.Context, apiKeyIDs []int64, from, to time.Time) (map[int64]*UsageAnomalyBaseline, error)
+	// ListUserBaselines 返回 [from, to) 内各用户（跨 Key 合计）的小时统计量
+	ListUserBaselines(ctx context.Context, userIDs []int64, from, to time.Time) (map[int64]*UsageAnomalyBaseline, error)
+	// ListNewSources 返回 [hourStart, hourEnd) 内出现、但 [baselineFrom, hourStart) 内未出现的来源 IP 段与 User-Agent
+	ListNewSources(ctx context.Context, apiKeyIDs []int64, hourStart, hourEnd, baselineFrom time.Time) ([]UsageAnomalySource, error)
+
+	// CreateEvent 写入异常事件；(user_id, api_key_id, bucket_start, kind) 已存在时返回 false
+	CreateEvent(ctx context.Context, event *UsageAnomalyEvent) (bool, error)
+	MarkKeySuspended(ctx context.Context, id int64) error
+	ListEvents(ctx context.Context, params pagination.PaginationParams, filters UsageAnomalyEventFilters) ([]UsageAnomalyEvent, *pagination.PaginationResult, error)
+	UpdateStatus(ctx context.Context, id int64, status string, adminID *int64) error
+}
+
+// usageAnomalyStats 基线统计量；小时序列从首个有用量的小时起零填充
+type usageAnomalyStats struct {
+	Mean   float64
+	Stddev float64
+}
+
+func newUsageAnomalyStats(sum, sumSq float64, hours int) usageAnomalyStats {
+	if hours <= 0 {
+		return usageAnomalyStats{}
+	}
+	n := float64(hours)
+	mean := sum / n
+	variance := sumSq/n - mean*mean
+	if variance < 0 {
+		// 浮点误差
+		variance = 0
+	}
+	return usageAnomalyStats{Mean: mean, Stddev: math.Sqrt(variance)}
+}
+
+// zScore 观测值高于基线均值的标准差倍数
+func (st usageAnomalyStats) zScore(observed float64) float64 {
+	std := math.Max(st.Stddev, st.Mean*usageAnomalyStddevFloorRatio)
+	if std <= 0 {
+		if observed > st.Mean {
+			return usageAnomalyMaxScore
+		}
+		return 0
+	}
+	return math.Min((observed-st.Mean)/std, usageAnomalyMaxScore)
+}
+
+// usageAnomalyKeyHour 某 Key 在检测小时内的合计用量
+type usageAnomalyKeyHour struct {
+	APIKeyID   int64
+	APIKeyName string
+	UserID     int64
+	Requests   int64
+	Cost       float64
+	ModelCost  map[string]float64
+}
+
+// groupUsageAnomalyHourUsage 将 (Key, 模型) 粒度的用量合并为 Key 粒度，按 Key ID 排序
+func groupUsageAnomalyHourUsage(rows []UsageAnomalyHourUsage) []*usageAnomalyKeyHour {
+	byKey := make(map[int64]*usageAnomalyKeyHour)
+	for _, row := range rows {
+		h := byKey[row.APIKeyID]
+		if h == nil {
+			h = &usageAnomalyKeyHour{
+				APIKeyID:   row.APIKeyID,
+				APIKeyName: row.APIKeyName,
+				UserID:     row.UserID,
+				ModelCost:  make(map[string]float64),
+			}
+			byKey[row.APIKeyID] = h
+		}
+		h.Requests += row.Requests
+		h.Cost += row.ActualCost
+		h.ModelCost[row.Model] += row.ActualCost
+	}
+	out := make([]*usageAnomalyKeyHour, 0, len(byKey))
+	for _, h := range byKey {
+		out = append(out, h)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].APIKeyID < out[j].APIKeyID })
+	return out
+}
+
+// usageAnomalyBaselineHours 返回基线的零填充小时数；Key/用户的历史不足 min_baseline_hours 时返回 0（不参与检测）
+func usageAnomalyBaselineHours(cfg *config.AnomalyDetectionConfig, base *UsageAnomalyBaseline, bucketStart time.Time) int {
+	if base == nil {
+		return 0
+	}
+	hours := int(bucketStart.Sub(base.FirstBucket) / time.Hour)
+	if hours <= 0 || hours < cfg.MinBaselineHours {
+		return 0
+	}
+	return hours
+}
+
+// detectKeyAnomalies 将 Key 的小时用量与基线比较，返回异常事件（尚未写入）。
+//
+// 消费/请求量超过基线 z_score_threshold 个标准差记为 P1，达到 critical_z_score 记为 P0；
+// 基线中未出现的模型占小时消费达到 model_shift_min_share 记为 P1；
+// 新来源单独出现记为 P2，与上述任一异常同时出现时将其升级为 P0（典型的 Key 泄露特征）。
+func detectKeyAnomalies(cfg *config.AnomalyDetectionConfig, hour *usageAnomalyKeyHour, base *UsageAnomalyBaseline, bucketStart time.Time, sources []UsageAnomalySource) []UsageAnomalyEvent {
+	hours := usageAnomalyBaselineHours(cfg, base, bucketStart)
+	if hour == nil || hours == 0 {
+		return nil
+	}
+
+	newEvent := func(kind, severity string, observed float64, stats usageAnomalyStats, score float64, details map[string]any) UsageAnomalyEvent {
+		if details == nil {
+			details = map[string]any{}
+		}
+		details["api_key_name"] = hour.APIKeyName
+		details["baseline_hours"] = hours
+		return UsageAnomalyEvent{
+			UserID:         hour.UserID,
+			APIKeyID:       hour.APIKeyID,
+			BucketStart:    bucketStart,
+			Kind:           kind,
+			Severity:       severity,
+			Observed:       observed,
+			BaselineMean:   stats.Mean,
+			BaselineStddev: stats.Stddev,
+			Score:          score,
+			Details:        details,
+			Status:         UsageAnomalyStatusOpen,
+		}
+	}
+	spikeSeverity := func(score float64) string {
+		if score >= cfg.CriticalZScore {
+			return UsageAnomalySeverityCritical
+		}
+		return UsageAnomalySeverityWarning
+	}
+
+	var events []UsageAnomalyEvent
+
+	costStats := newUsageAnomalyStats(base.SumCost, base.SumCostSq, hours)
+	if hour.Cost >= cfg.MinSpendUSD {
+		if score := costStats.zScore(hour.Cost); score >= cfg.ZScoreThreshold {
+			events = append(events, newEvent(UsageAnomalyKindSpendSpike, spikeSeverity(score), hour.Cost, costStats, score, nil))
+		}
+	}
+
+	reqStats := newUsageAnomalyStats(base.SumRequests, base.SumRequestsSq, hours)
+	if hour.Requests >= cfg.MinRequests {
+		if score := reqStats.zScore(float64(hour.Requests)); score >= cfg.ZScoreThreshold {
+			events = append(events, newEvent(UsageAnomalyKindRequestSpike, spikeSeverity(score), float64(hour.Requests), reqStats, score, nil))
+		}
+	}
+
+	if hour.Cost >= cfg.MinSpendUSD && hour.Cost > 0 {
+		known := make(map[string]struct{}, len(base.Models))
+		for _, m := range base.Models {
+			known[m] = struct{}{}
+		}
+		var (
+			newCost   float64
+			newModels []string
+		)
+		for model, cost := range hour.ModelCost {
+			if _, ok := known[model]; ok {
+				continue
+			}
+			newCost += cost
+			newModels = append(newModels, model)
+		}
+		if share := newCost / hour.Cost; len(newModels) > 0 && share >= cfg.ModelShiftMinShare {
+			sort.Strings(newModels)
+			events = append(events, newEvent(UsageAnomalyKindModelShift, UsageAnomalySeverityWarning, share, usageAnomalyStats{}, share, map[string]any{
+				"new_models":     newModels,
+				"new_model_cost": newCost,
+			}))
+		}
+	}
+
+	if len(sources) > 0 {
+		ips, agents := splitUsageAnomalySources(sources)
+		for i := range events {
+			events[i].Severity = UsageAnomalySeverityCritical
+			events[i].Details["new_source"] = true
+		}
+		events = append(events, newEvent(UsageAnomalyKindNewSource, UsageAnomalySeverityInfo, float64(len(sources)), usageAnomalyStats{}, 0, map[string]any{
+			"ip_ranges":   ips,
+			"user_agents": agents,
+		}))
+	}
+	return events
+}
+
+// detectUserAnomaly 将用户跨 Key 合计的小时消费与用户基线比较
+func detectUserAnomaly(cfg *config.AnomalyDetectionConfig, userID int64, cost float64, keyCount int, base *UsageAnomalyBaseline, bucketStart time.Time) *UsageAnomalyEvent {
+	hours := usageAnomalyBaselineHours(cfg, base, bucketStart)
+	if hours == 0 || cost < cfg.MinSpendUSD {
+		return nil
+	}
+	stats := newUsageAnomalyStats(base.SumCost, base.SumCostSq, hours)
+	score := stats.zScore(cost)
+	if score < cfg.ZScoreThreshold {
+		return nil
+	}
+	severity := UsageAnomalySeverityWarning
+	if score >= cfg.CriticalZScore {
+		severity = UsageAnomalySeverityCritical
+	}
+	return &UsageAnomalyEvent{
+		UserID:         userID,
+		BucketStart:    bucketStart,
+		Kind:           UsageAnomalyKindUserSpendSpike,
+		Severity:       severity,
+		Observed:       cost,
+		BaselineMean:   stats.Mean,
+		BaselineStddev: stats.Stddev,
+		Score:          score,
+		Details:        map[string]any{"active_keys": keyCount, "baseline_hours": hours},
+		Status:         UsageAnomalyStatusOpen,
+	}
+}
+
+func splitUsageAnomalySources(sources []UsageAnomalySource) (ips, agents []string) {
+	ips, agents = []string{}, []string{}
+	for _, src := range sources {
+		switch src.Kind {
+		case UsageAnomalySourceIP:
+			if len(ips) < usageAnomalyMaxSourcesPerEvent {
+				ips = append(ips, src.Value)
+			}
+		case UsageAnomalySourceUserAgent:
+			if len(agents) < usageAnomalyMaxSourcesPerEvent {
+				agents = append(agents, src.Value)
+			}
+		}
+	}
+	return ips, agents
+}
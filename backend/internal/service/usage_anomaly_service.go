@@ -0,0 +1,408 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+const (
+	usageAnomalyWorkerName = "usage_anomaly_worker"
+	// usageAnomalyRunTimeout 单轮检测的最大执行时长
+	usageAnomalyRunTimeout = 5 * time.Minute
+)
+
+// UsageAnomalyService API Key 用量异常检测
+//
+// 后台任务按小时桶推进：仪表盘预聚合水位越过某小时末尾后，将该小时各 Key 的消费、请求量与模型分布
+// 同 baseline_days 内的零填充小时序列比较，并（可选）检查 usage_logs 中新出现的来源 IP 段与 User-Agent；
+// 同时按用户合计检测消费突增。异常事件以 (user_id, api_key_id, bucket_start, kind) 唯一写入，
+// 多实例并发检测时只有写入成功的实例执行停用 Key、通知用户与运维邮件。
+type UsageAnomalyService struct {
+	repo                UsageAnomalyRepository
+	aggRepo             DashboardAggregationRepository
+	apiKeyService       *APIKeyService
+	notificationService *NotificationService
+	opsService          *OpsService
+	emailSender         NotificationEmailSender
+	timingWheel         *TimingWheelService
+	cfg                 *config.Config
+
+	running   int32
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	workerCtx    context.Context
+	workerCancel context.CancelFunc
+}
+
+// NewUsageAnomalyService 创建用量异常检测服务
+func NewUsageAnomalyService(
+	repo UsageAnomalyRepository,
+	aggRepo DashboardAggregationRepository,
+	apiKeyService *APIKeyService,
+	notificationService *NotificationService,
+	opsService *OpsService,
+	emailSender NotificationEmailSender,
+	timingWheel *TimingWheelService,
+	cfg *config.Config,
+) *UsageAnomalyService {
+	workerCtx, workerCancel := context.WithCancel(context.Background())
+	return &UsageAnomalyService{
+		repo:                repo,
+		aggRepo:             aggRepo,
+		apiKeyService:       apiKeyService,
+		notificationService: notificationService,
+		opsService:          opsService,
+		emailSender:         emailSender,
+		timingWheel:         timingWheel,
+		cfg:                 cfg,
+		workerCtx:           workerCtx,
+		workerCancel:        workerCancel,
+	}
+}
+
+func (s *UsageAnomalyService) Start() {
+	if s == nil {
+		return
+	}
+	if s.cfg == nil || !s.cfg.Anomaly.Enabled {
+		log.Printf("[Anomaly] not started (disabled)")
+		return
+	}
+	if !s.cfg.DashboardAgg.Enabled || s.aggRepo == nil {
+		log.Printf("[Anomaly] not started (dashboard aggregation disabled)")
+		return
+	}
+	if s.repo == nil || s.timingWheel == nil {
+		log.Printf("[Anomaly] not started (missing deps)")
+		return
+	}
+
+	interval := time.Duration(s.cfg.Anomaly.WorkerIntervalMinutes) * time.Minute
+	s.startOnce.Do(func() {
+		s.timingWheel.ScheduleRecurring(usageAnomalyWorkerName, interval, s.runOnce)
+		log.Printf("[Anomaly] started (interval=%s baseline=%dd auto_suspend=%v)", interval, s.cfg.Anomaly.BaselineDays, s.cfg.Anomaly.AutoSuspend)
+	})
+}
+
+func (s *UsageAnomalyService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.workerCancel != nil {
+			s.workerCancel()
+		}
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(usageAnomalyWorkerName)
+		}
+		log.Printf("[Anomaly] stopped")
+	})
+}
+
+// ListEvents 分页列出异常事件（运维面板）
+func (s *UsageAnomalyService) ListEvents(ctx context.Context, params pagination.PaginationParams, filters UsageAnomalyEventFilters) ([]UsageAnomalyEvent, *pagination.PaginationResult, error) {
+	return s.repo.ListEvents(ctx, params, filters)
+}
+
+// UpdateStatus 管理员确认异常事件或重新打开
+func (s *UsageAnomalyService) UpdateStatus(ctx context.Context, id int64, status string, adminID int64) error {
+	switch status {
+	case UsageAnomalyStatusAcknowledged:
+		return s.repo.UpdateStatus(ctx, id, status, &adminID)
+	case UsageAnomalyStatusOpen:
+		return s.repo.UpdateStatus(ctx, id, status, nil)
+	default:
+		return ErrUsageAnomalyInvalidStatus
+	}
+}
+
+func (s *UsageAnomalyService) runOnce() {
+	if s == nil {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&s.running, 0)
+
+	parent := context.Background()
+	if s.workerCtx != nil {
+		parent = s.workerCtx
+	}
+	ctx, cancel := context.WithTimeout(parent, usageAnomalyRunTimeout)
+	defer cancel()
+
+	s.runAt(ctx)
+}
+
+// runAt 检测水位与预聚合水位之间已完成的小时桶，单轮最多 max_catch_up_hours 个；
+// 落后更多时跳过较早的小时（过期的异常已无处置价值）
+func (s *UsageAnomalyService) runAt(ctx context.Context) {
+	aggWatermark, err := s.aggRepo.GetAggregationWatermark(ctx)
+	if err != nil {
+		log.Printf("[Anomaly] read aggregation watermark failed: %v", err)
+		return
+	}
+	limit := aggWatermark.UTC().Truncate(time.Hour)
+
+	next, err := s.repo.GetWatermark(ctx)
+	if err != nil {
+		log.Printf("[Anomaly] read watermark failed: %v", err)
+		return
+	}
+	next = next.UTC().Truncate(time.Hour)
+	maxHours := s.cfg.Anomaly.MaxCatchUpHours
+	if earliest := limit.Add(-time.Duration(maxHours) * time.Hour); next.Before(earliest) {
+		if next.Unix() > 0 {
+			log.Printf("[Anomaly] watermark behind, skipping %s..%s", next.Format(time.RFC3339), earliest.Format(time.RFC3339))
+		}
+		next = earliest
+	}
+
+	var created []UsageAnomalyEvent
+	for ; next.Before(limit); next = next.Add(time.Hour) {
+		if ctx.Err() != nil {
+			break
+		}
+		events, err := s.detectHour(ctx, next)
+		if err != nil {
+			log.Printf("[Anomaly] detect failed: bucket=%s err=%v", next.Format(time.RFC3339), err)
+			break
+		}
+		created = append(created, events...)
+		if err := s.repo.UpdateWatermark(ctx, next.Add(time.Hour)); err != nil {
+			log.Printf("[Anomaly] update watermark failed: %v", err)
+			break
+		}
+	}
+
+	if len(created) > 0 {
+		log.Printf("[Anomaly] flagged %d events", len(created))
+		s.notifyAdmins(ctx, created)
+	}
+}
+
+// detectHour 检测单个小时桶，返回本实例新写入的事件
+func (s *UsageAnomalyService) detectHour(ctx context.Context, bucketStart time.Time) ([]UsageAnomalyEvent, error) {
+	rows, err := s.repo.ListHourUsage(ctx, bucketStart)
+	if err != nil {
+		return nil, fmt.Errorf("list hour usage: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	cfg := &s.cfg.Anomaly
+	baselineFrom := bucketStart.Add(-time.Duration(cfg.BaselineDays) * 24 * time.Hour)
+
+	keyHours := groupUsageAnomalyHourUsage(rows)
+	keyIDs := make([]int64, 0, len(keyHours))
+	userCost := make(map[int64]float64)
+	userKeys := make(map[int64]int)
+	for _, h := range keyHours {
+		keyIDs = append(keyIDs, h.APIKeyID)
+		userCost[h.UserID] += h.Cost
+		userKeys[h.UserID]++
+	}
+
+	keyBaselines, err := s.repo.ListKeyBaselines(ctx, keyIDs, baselineFrom, bucketStart)
+	if err != nil {
+		return nil, fmt.Errorf("list key baselines: %w", err)
+	}
+
+	// 来源检查只针对基线已建立的 Key，新 Key 的来源必然都是“新”的
+	sourcesByKey := make(map[int64][]UsageAnomalySource)
+	if cfg.SourceCheckEnabled {
+		eligible := make([]int64, 0, len(keyIDs))
+		for _, id := range keyIDs {
+			if usageAnomalyBaselineHours(cfg, keyBaselines[id], bucketStart) > 0 {
+				eligible = append(eligible, id)
+			}
+		}
+		if len(eligible) > 0 {
+			sources, err := s.repo.ListNewSources(ctx, eligible, bucketStart, bucketStart.Add(time.Hour), baselineFrom)
+			if err != nil {
+				return nil, fmt.Errorf("list new sources: %w", err)
+			}
+			for _, src := range sources {
+				sourcesByKey[src.APIKeyID] = append(sourcesByKey[src.APIKeyID], src)
+			}
+		}
+	}
+
+	var candidates []UsageAnomalyEvent
+	for _, h := range keyHours {
+		candidates = append(candidates, detectKeyAnomalies(cfg, h, keyBaselines[h.APIKeyID], bucketStart, sourcesByKey[h.APIKeyID])...)
+	}
+
+	userIDs := make([]int64, 0, len(userCost))
+	for id := range userCost {
+		userIDs = append(userIDs, id)
+	}
+	userBaselines, err := s.repo.ListUserBaselines(ctx, userIDs, baselineFrom, bucketStart)
+	if err != nil {
+		return nil, fmt.Errorf("list user baselines: %w", err)
+	}
+	for _, id := range userIDs {
+		if event := detectUserAnomaly(cfg, id, userCost[id], userKeys[id], userBaselines[id], bucketStart); event != nil {
+			candidates = append(candidates, *event)
+		}
+	}
+
+	var created []UsageAnomalyEvent
+	for i := range candidates {
+		event := &candidates[i]
+		inserted, err := s.repo.CreateEvent(ctx, event)
+		if err != nil {
+			log.Printf("[Anomaly] create event failed: user=%d key=%d kind=%s err=%v", event.UserID, event.APIKeyID, event.Kind, err)
+			continue
+		}
+		if !inserted {
+			continue
+		}
+		s.handleEvent(ctx, event)
+		created = append(created, *event)
+	}
+	return created, nil
+}
+
+// handleEvent 严重的 Key 级异常按配置停用 Key；P2 提示仅在运维面板展示，不通知用户
+func (s *UsageAnomalyService) handleEvent(ctx context.Context, event *UsageAnomalyEvent) {
+	cfg := &s.cfg.Anomaly
+	if cfg.AutoSuspend && event.Severity == UsageAnomalySeverityCritical && event.APIKeyID > 0 && s.apiKeyService != nil {
+		if _, suspended, err := s.apiKeyService.Suspend(ctx, event.APIKeyID); err != nil {
+			log.Printf("[Anomaly] suspend api key failed: key=%d err=%v", event.APIKeyID, err)
+		} else if suspended {
+			event.KeySuspended = true
+			if err := s.repo.MarkKeySuspended(ctx, event.ID); err != nil {
+				log.Printf("[Anomaly] mark key suspended failed: event=%d err=%v", event.ID, err)
+			}
+			log.Printf("[Anomaly] api key suspended: key=%d user=%d kind=%s", event.APIKeyID, event.UserID, event.Kind)
+		}
+	}
+
+	if cfg.NotifyOwner && event.Severity != UsageAnomalySeverityInfo && s.notificationService != nil {
+		title, message := buildUsageAnomalyOwnerMessage(event)
+		s.notificationService.NotifyAPIKeyAnomaly(ctx, event.UserID, fmt.Sprintf("%d", event.ID), title, message, map[string]any{
+			"event_id":      event.ID,
+			"api_key_id":    event.APIKeyID,
+			"kind":          event.Kind,
+			"severity":      event.Severity,
+			"bucket_start":  event.BucketStart,
+			"observed":      event.Observed,
+			"baseline_mean": event.BaselineMean,
+			"key_suspended": event.KeySuspended,
+		})
+	}
+}
+
+// notifyAdmins 将本轮事件汇总为一封邮件发送给运维告警收件人，按运维告警最低级别过滤
+func (s *UsageAnomalyService) notifyAdmins(ctx context.Context, events []UsageAnomalyEvent) {
+	if !s.cfg.Anomaly.NotifyAdmins || s.opsService == nil || s.emailSender == nil {
+		return
+	}
+	emailCfg, err := s.opsService.GetEmailNotificationConfig(ctx)
+	if err != nil || emailCfg == nil || !emailCfg.Alert.Enabled || len(emailCfg.Alert.Recipients) == 0 {
+		return
+	}
+
+	minSeverity := strings.TrimSpace(emailCfg.Alert.MinSeverity)
+	selected := make([]UsageAnomalyEvent, 0, len(events))
+	for _, event := range events {
+		if shouldSendOpsAlertEmailByMinSeverity(minSeverity, event.Severity) {
+			selected = append(selected, event)
+		}
+	}
+	if len(selected) == 0 {
+		return
+	}
+
+	subject := fmt.Sprintf("[Ops Alert][%s] %d API key usage anomalies", usageAnomalyHighestSeverity(selected), len(selected))
+	body := buildUsageAnomalyAdminEmailBody(selected)
+	for _, to := range emailCfg.Alert.Recipients {
+		addr := strings.TrimSpace(to)
+		if addr == "" {
+			continue
+		}
+		if err := s.emailSender.EnqueueEmail(addr, subject, body); err != nil {
+			log.Printf("[Anomaly] enqueue admin email failed: to=%s err=%v", addr, err)
+		}
+	}
+}
+
+func usageAnomalyHighestSeverity(events []UsageAnomalyEvent) string {
+	highest := UsageAnomalySeverityInfo
+	for _, event := range events {
+		if event.Severity < highest {
+			highest = event.Severity
+		}
+	}
+	return highest
+}
+
+func usageAnomalyDescription(event *UsageAnomalyEvent) string {
+	switch event.Kind {
+	case UsageAnomalyKindSpendSpike:
+		return fmt.Sprintf("hourly spend $%.2f vs. baseline $%.2f (%.1fσ)", event.Observed, event.BaselineMean, event.Score)
+	case UsageAnomalyKindRequestSpike:
+		return fmt.Sprintf("%.0f requests in one hour vs. baseline %.1f (%.1fσ)", event.Observed, event.BaselineMean, event.Score)
+	case UsageAnomalyKindModelShift:
+		return fmt.Sprintf("%.0f%% of hourly spend on models not used before: %v", event.Observed*100, event.Details["new_models"])
+	case UsageAnomalyKindNewSource:
+		return fmt.Sprintf("requests from new IP ranges %v / user agents %v", event.Details["ip_ranges"], event.Details["user_agents"])
+	case UsageAnomalyKindUserSpendSpike:
+		return fmt.Sprintf("account hourly spend $%.2f vs. baseline $%.2f (%.1fσ)", event.Observed, event.BaselineMean, event.Score)
+	default:
+		return event.Kind
+	}
+}
+
+func buildUsageAnomalyOwnerMessage(event *UsageAnomalyEvent) (title, message string) {
+	hour := event.BucketStart.UTC().Format("2006-01-02 15:04 MST")
+	if event.APIKeyID == 0 {
+		title = "Unusual spend on your account"
+		message = fmt.Sprintf("We detected unusual activity on your account in the hour starting %s: %s. If this was not you, review your API keys.",
+			hour, usageAnomalyDescription(event))
+		return title, message
+	}
+
+	name, _ := event.Details["api_key_name"].(string)
+	title = fmt.Sprintf("Unusual activity on API key %q", name)
+	message = fmt.Sprintf("We detected unusual activity on your API key %q in the hour starting %s: %s.",
+		name, hour, usageAnomalyDescription(event))
+	if event.KeySuspended {
+		message += " The key has been disabled as a precaution; re-enable it in your API key settings once you have confirmed it is safe, or rotate it."
+	} else {
+		message += " If this was not you, disable or rotate the key."
+	}
+	return title, message
+}
+
+func buildUsageAnomalyAdminEmailBody(events []UsageAnomalyEvent) string {
+	var b strings.Builder
+	b.WriteString("<h2>API Key Usage Anomalies</h2>\n<table border=\"1\" cellpadding=\"4\" cellspacing=\"0\">\n")
+	b.WriteString("<tr><th>Severity</th><th>Hour</th><th>User</th><th>API Key</th><th>Kind</th><th>Details</th><th>Suspended</th></tr>\n")
+	for i := range events {
+		event := &events[i]
+		fmt.Fprintf(&b, "<tr><td>%s</td><td>%s</td><td>%d</td><td>%d</td><td>%s</td><td>%s</td><td>%v</td></tr>\n",
+			html.EscapeString(event.Severity),
+			event.BucketStart.UTC().Format(time.RFC3339),
+			event.UserID,
+			event.APIKeyID,
+			html.EscapeString(event.Kind),
+			html.EscapeString(usageAnomalyDescription(event)),
+			event.KeySuspended,
+		)
+	}
+	b.WriteString("</table>\n<p>Review and acknowledge these events in the ops dashboard.</p>\n")
+	return b.String()
+}
@@ -0,0 +1,261 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type usageAnomalyRepoStub struct {
+	watermark     time.Time
+	hourUsage     map[time.Time][]UsageAnomalyHourUsage
+	keyBaselines  map[int64]*UsageAnomalyBaseline
+	userBaselines map[int64]*UsageAnomalyBaseline
+	sources       []UsageAnomalySource
+	sourceKeyIDs  []int64
+	events        map[string]*UsageAnomalyEvent
+}
+
+func newUsageAnomalyRepoStub() *usageAnomalyRepoStub {
+	return &usageAnomalyRepoStub{
+		hourUsage:     map[time.Time][]UsageAnomalyHourUsage{},
+		keyBaselines:  map[int64]*UsageAnomalyBaseline{},
+		userBaselines: map[int64]*UsageAnomalyBaseline{},
+		events:        map[string]*UsageAnomalyEvent{},
+	}
+}
+
+func (r *usageAnomalyRepoStub) GetWatermark(ctx context.Context) (time.Time, error) {
+	return r.watermark, nil
+}
+
+func (r *usageAnomalyRepoStub) UpdateWatermark(ctx context.Context, bucketEnd time.Time) error {
+	if bucketEnd.After(r.watermark) {
+		r.watermark = bucketEnd
+	}
+	return nil
+}
+
+func (r *usageAnomalyRepoStub) ListHourUsage(ctx context.Context, bucketStart time.Time) ([]UsageAnomalyHourUsage, error) {
+	return r.hourUsage[bucketStart], nil
+}
+
+func (r *usageAnomalyRepoStub) ListKeyBaselines(ctx context.Context, apiKeyIDs []int64, from, to time.Time) (map[int64]*UsageAnomalyBaseline, error) {
+	return r.keyBaselines, nil
+}
+
+func (r *usageAnomalyRepoStub) ListUserBaselines(ctx context.Context, userIDs []int64, from, to time.Time) (map[int64]*UsageAnomalyBaseline, error) {
+	return r.userBaselines, nil
+}
+
+func (r *usageAnomalyRepoStub) ListNewSources(ctx context.Context, apiKeyIDs []int64, hourStart, hourEnd, baselineFrom time.Time) ([]UsageAnomalySource, error) {
+	r.sourceKeyIDs = append(r.sourceKeyIDs, apiKeyIDs...)
+	return r.sources, nil
+}
+
+func (r *usageAnomalyRepoStub) CreateEvent(ctx context.Context, event *UsageAnomalyEvent) (bool, error) {
+	key := fmt.Sprintf("%d:%d:%d:%s", event.UserID, event.APIKeyID, event.BucketStart.Unix(), event.Kind)
+	if _, ok := r.events[key]; ok {
+		return false, nil
+	}
+	event.ID = int64(len(r.events) + 1)
+	stored := *event
+	r.events[key] = &stored
+	return true, nil
+}
+
+func (r *usageAnomalyRepoStub) MarkKeySuspended(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (r *usageAnomalyRepoStub) ListEvents(ctx context.Context, params pagination.PaginationParams, filters UsageAnomalyEventFilters) ([]UsageAnomalyEvent, *pagination.PaginationResult, error) {
+	return nil, nil, nil
+}
+
+func (r *usageAnomalyRepoStub) UpdateStatus(ctx context.Context, id int64, status string, adminID *int64) error {
+	return nil
+}
+
+func (r *usageAnomalyRepoStub) kinds() map[string]string {
+	out := map[string]string{}
+	for _, e := range r.events {
+		out[e.Kind] = e.Severity
+	}
+	return out
+}
+
+func testAnomalyConfig() *config.AnomalyDetectionConfig {
+	return &config.AnomalyDetectionConfig{
+		Enabled:               true,
+		WorkerIntervalMinutes: 10,
+		BaselineDays:          7,
+		MinBaselineHours:      72,
+		ZScoreThreshold:       4,
+		CriticalZScore:        8,
+		MinSpendUSD:           1,
+		MinRequests:           100,
+		ModelShiftMinShare:    0.5,
+		SourceCheckEnabled:    true,
+		NotifyOwner:           true,
+		MaxCatchUpHours:       24,
+	}
+}
+
+// steadyBaseline 在 hours 小时内每小时消费 cost、请求 requests
+func steadyBaseline(id int64, bucketStart time.Time, hours int, cost, requests float64, models ...string) *UsageAnomalyBaseline {
+	n := float64(hours)
+	return &UsageAnomalyBaseline{
+		ID:            id,
+		FirstBucket:   bucketStart.Add(-time.Duration(hours) * time.Hour),
+		SumCost:       cost * n,
+		SumCostSq:     cost * cost * n,
+		SumRequests:   requests * n,
+		SumRequestsSq: requests * requests * n,
+		Models:        models,
+	}
+}
+
+func TestUsageAnomalyStatsZeroFilled(t *testing.T) {
+	// 10 小时中只有 2 小时有消费（各 5 USD），其余小时视为 0
+	stats := newUsageAnomalyStats(10, 50, 10)
+	require.InDelta(t, 1, stats.Mean, 1e-9)
+	require.InDelta(t, 2, stats.Stddev, 1e-9)
+	require.InDelta(t, 4.5, stats.zScore(10), 1e-9)
+
+	// 平稳基线的标准差按均值的 25% 设下限
+	flat := newUsageAnomalyStats(100, 100, 100)
+	require.InDelta(t, 0, flat.Stddev, 1e-9)
+	require.InDelta(t, 4, flat.zScore(2), 1e-9)
+
+	require.Equal(t, float64(usageAnomalyMaxScore), usageAnomalyStats{}.zScore(3))
+	require.Zero(t, usageAnomalyStats{}.zScore(0))
+}
+
+func TestDetectKeyAnomaliesSpikeSeverity(t *testing.T) {
+	cfg := testAnomalyConfig()
+	bucket := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	base := steadyBaseline(70, bucket, 100, 1, 20, "claude-sonnet-4")
+
+	hour := &usageAnomalyKeyHour{APIKeyID: 70, APIKeyName: "prod", UserID: 7, Requests: 20, Cost: 2.5,
+		ModelCost: map[string]float64{"claude-sonnet-4": 2.5}}
+	events := detectKeyAnomalies(cfg, hour, base, bucket, nil)
+	require.Len(t, events, 1)
+	require.Equal(t, UsageAnomalyKindSpendSpike, events[0].Kind)
+	require.Equal(t, UsageAnomalySeverityWarning, events[0].Severity)
+	require.InDelta(t, 6, events[0].Score, 1e-9)
+	require.Equal(t, "prod", events[0].Details["api_key_name"])
+
+	hour.Cost, hour.ModelCost["claude-sonnet-4"] = 30, 30
+	hour.Requests = 400
+	events = detectKeyAnomalies(cfg, hour, base, bucket, nil)
+	require.Len(t, events, 2)
+	for _, e := range events {
+		require.Equal(t, UsageAnomalySeverityCritical, e.Severity, e.Kind)
+	}
+
+	// 低于最小消费时不判定
+	hour.Cost, hour.ModelCost["claude-sonnet-4"], hour.Requests = 0.9, 0.9, 20
+	require.Empty(t, detectKeyAnomalies(cfg, hour, steadyBaseline(70, bucket, 100, 0.1, 20), bucket, nil))
+
+	// 历史不足 min_baseline_hours 的 Key 不参与检测
+	hour.Cost = 30
+	require.Empty(t, detectKeyAnomalies(cfg, hour, steadyBaseline(70, bucket, 48, 1, 20), bucket, nil))
+	require.Empty(t, detectKeyAnomalies(cfg, hour, nil, bucket, nil))
+}
+
+func TestDetectKeyAnomaliesModelShiftEscalatedByNewSource(t *testing.T) {
+	cfg := testAnomalyConfig()
+	bucket := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	base := steadyBaseline(70, bucket, 100, 2, 20, "claude-haiku-4")
+
+	hour := &usageAnomalyKeyHour{APIKeyID: 70, UserID: 7, Requests: 20, Cost: 2,
+		ModelCost: map[string]float64{"claude-haiku-4": 0.5, "claude-opus-4": 1.5}}
+	events := detectKeyAnomalies(cfg, hour, base, bucket, nil)
+	require.Len(t, events, 1)
+	require.Equal(t, UsageAnomalyKindModelShift, events[0].Kind)
+	require.Equal(t, UsageAnomalySeverityWarning, events[0].Severity)
+	require.InDelta(t, 0.75, events[0].Observed, 1e-9)
+	require.Equal(t, []string{"claude-opus-4"}, events[0].Details["new_models"])
+
+	sources := []UsageAnomalySource{
+		{APIKeyID: 70, Kind: UsageAnomalySourceIP, Value: "203.0.113.0/24"},
+		{APIKeyID: 70, Kind: UsageAnomalySourceUserAgent, Value: "curl/8.5.0"},
+	}
+	events = detectKeyAnomalies(cfg, hour, base, bucket, sources)
+	require.Len(t, events, 2)
+	require.Equal(t, UsageAnomalySeverityCritical, events[0].Severity)
+	require.Equal(t, true, events[0].Details["new_source"])
+	require.Equal(t, UsageAnomalyKindNewSource, events[1].Kind)
+	require.Equal(t, UsageAnomalySeverityInfo, events[1].Severity)
+	require.Equal(t, []string{"203.0.113.0/24"}, events[1].Details["ip_ranges"])
+
+	// 仅有新来源时为提示级
+	hour.ModelCost = map[string]float64{"claude-haiku-4": 2}
+	events = detectKeyAnomalies(cfg, hour, base, bucket, sources)
+	require.Len(t, events, 1)
+	require.Equal(t, UsageAnomalySeverityInfo, events[0].Severity)
+}
+
+func TestUsageAnomalyRunAtDetectsCompletedHoursOnce(t *testing.T) {
+	bucket := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	repo := newUsageAnomalyRepoStub()
+	repo.watermark = bucket
+	repo.hourUsage[bucket] = []UsageAnomalyHourUsage{
+		{APIKeyID: 70, APIKeyName: "prod", UserID: 7, Model: "claude-sonnet-4", Requests: 30, ActualCost: 20},
+		{APIKeyID: 80, APIKeyName: "new", UserID: 8, Model: "claude-sonnet-4", Requests: 30, ActualCost: 20},
+	}
+	repo.keyBaselines[70] = steadyBaseline(70, bucket, 100, 1, 20, "claude-sonnet-4")
+	repo.keyBaselines[80] = steadyBaseline(80, bucket, 10, 1, 20, "claude-sonnet-4")
+	repo.userBaselines[7] = steadyBaseline(7, bucket, 100, 1, 20)
+
+	notifyRepo := newNotificationRepoStub()
+	email := &notificationEmailStub{}
+	cfg := &config.Config{Anomaly: *testAnomalyConfig()}
+	svc := NewUsageAnomalyService(repo, &dashboardAggregationRepoTestStub{watermark: bucket.Add(90 * time.Minute)},
+		nil, newNotificationTestService(notifyRepo, email, nil), nil, nil, nil, cfg)
+
+	svc.runAt(context.Background())
+	require.Equal(t, bucket.Add(time.Hour), repo.watermark, "only completed hours below the aggregation watermark")
+	require.Equal(t, []int64{70}, repo.sourceKeyIDs, "source check only for keys with an established baseline")
+	require.Equal(t, map[string]string{
+		UsageAnomalyKindSpendSpike:     UsageAnomalySeverityCritical,
+		UsageAnomalyKindUserSpendSpike: UsageAnomalySeverityCritical,
+	}, repo.kinds())
+	require.Equal(t, 2, notifyRepo.types()[NotificationTypeAPIKeyAnomaly])
+	require.Len(t, email.sent, 2)
+
+	// 重复检测同一小时不会重复通知
+	repo.watermark = bucket
+	svc.runAt(context.Background())
+	require.Len(t, repo.events, 2)
+	require.Len(t, email.sent, 2)
+}
+
+func TestUsageAnomalyOwnerNotificationRespectsPreference(t *testing.T) {
+	notifyRepo := newNotificationRepoStub()
+	settings := DefaultNotificationSettings(7)
+	settings.APIKeyAlertsEnabled = false
+	notifyRepo.settings[7] = settings
+	email := &notificationEmailStub{}
+	svc := newNotificationTestService(notifyRepo, email, nil)
+
+	require.False(t, svc.NotifyAPIKeyAnomaly(context.Background(), 7, "1", "title", "message", nil))
+	require.Empty(t, email.sent)
+
+	require.True(t, svc.NotifyAPIKeyAnomaly(context.Background(), 8, "2", "title", "message", nil))
+	require.False(t, svc.NotifyAPIKeyAnomaly(context.Background(), 8, "2", "title", "message", nil), "same event notifies once")
+	require.Len(t, email.sent, 1)
+}
+
+func TestUsageAnomalyUpdateStatusValidates(t *testing.T) {
+	svc := NewUsageAnomalyService(newUsageAnomalyRepoStub(), nil, nil, nil, nil, nil, nil, &config.Config{})
+	require.ErrorIs(t, svc.UpdateStatus(context.Background(), 1, "closed", 1), ErrUsageAnomalyInvalidStatus)
+	require.NoError(t, svc.UpdateStatus(context.Background(), 1, UsageAnomalyStatusAcknowledged, 1))
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+)
+
+const (
+	UsageExportFormatCSV     = "csv"
+	UsageExportFormatJSONL   = "jsonl"
+	UsageExportFormatParquet = "parquet"
+)
+
+const (
+	UsageExportStatusPending   = "pending"
+	UsageExportStatusRunning   = "running"
+	UsageExportStatusSucceeded = "succeeded"
+	UsageExportStatusFailed    = "failed"
+	UsageExportStatusExpired   = "expired"
+)
+
+// 导出范围：user 仅包含用户可见字段，admin 额外包含账号、IP 等字段
+const (
+	UsageExportScopeUser  = "user"
+	UsageExportScopeAdmin = "admin"
+)
+
+// UsageExportFilters 导出任务过滤条件（与使用记录列表一致），JSON 序列化用于存储任务参数
+type UsageExportFilters struct {
+	UserID         int64      `json:"user_id,omitempty"`
+	APIKeyID       int64      `json:"api_key_id,omitempty"`
+	AccountID      int64      `json:"account_id,omitempty"`
+	GroupID        int64      `json:"group_id,omitempty"`
+	OrganizationID int64      `json:"organization_id,omitempty"`
+	Model          string     `json:"model,omitempty"`
+	Stream         *bool      `json:"stream,omitempty"`
+	BillingType    *int8      `json:"billing_type,omitempty"`
+	StartTime      *time.Time `json:"start_time,omitempty"`
+	EndTime        *time.Time `json:"end_time,omitempty"`
+}
+
+// UsageExportFiltersFromLogFilters 从使用记录列表过滤条件构造导出过滤条件
+func UsageExportFiltersFromLogFilters(f usagestats.UsageLogFilters) UsageExportFilters {
+	return UsageExportFilters{
+		UserID:         f.UserID,
+		APIKeyID:       f.APIKeyID,
+		AccountID:      f.AccountID,
+		GroupID:        f.GroupID,
+		OrganizationID: f.OrganizationID,
+		Model:          f.Model,
+		Stream:         f.Stream,
+		BillingType:    f.BillingType,
+		StartTime:      f.StartTime,
+		EndTime:        f.EndTime,
+	}
+}
+
+// LogFilters 转换为使用记录查询过滤条件
+func (f UsageExportFilters) LogFilters() usagestats.UsageLogFilters {
+	return usagestats.UsageLogFilters{
+		UserID:         f.UserID,
+		APIKeyID:       f.APIKeyID,
+		AccountID:      f.AccountID,
+		GroupID:        f.GroupID,
+		OrganizationID: f.OrganizationID,
+		Model:          f.Model,
+		Stream:         f.Stream,
+		BillingType:    f.BillingType,
+		StartTime:      f.StartTime,
+		EndTime:        f.EndTime,
+	}
+}
+
+// UsageExportTask 异步使用记录导出任务
+// 状态包含 pending/running/succeeded/failed/expired
+type UsageExportTask struct {
+	ID        int64
+	Scope     string
+	Format    string
+	Status    string
+	Filters   UsageExportFilters
+	CreatedBy int64
+	RowCount  int64
+	FileSize  int64
+	// FilePath 导出文件在 storage_dir 下的相对路径
+	FilePath string
+	// DownloadToken 下载令牌，仅在任务成功后生成，随下载链接下发
+	DownloadToken string
+	ErrorMsg      *string
+	ExpiresAt     *time.Time
+	StartedAt     *time.Time
+	FinishedAt    *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// UsageExportRepository 导出任务与导出数据读取的持久层接口
+type UsageExportRepository interface {
+	CreateTask(ctx context.Context, task *UsageExportTask) error
+	// ListTasks 按创建者列出任务；createdBy 为 0 表示全部
+	ListTasks(ctx context.Context, createdBy int64, params pagination.PaginationParams) ([]UsageExportTask, *pagination.PaginationResult, error)
+	// GetTask 查询任务；不存在返回 ErrUsageExportTaskNotFound
+	GetTask(ctx context.Context, taskID int64) (*UsageExportTask, error)
+	// GetTaskByToken 按下载令牌查询任务；不存在返回 ErrUsageExportTaskNotFound
+	GetTaskByToken(ctx context.Context, token string) (*UsageExportTask, error)
+	// ClaimNextPendingTask 抢占下一条 pending 任务，或 running 超过 staleRunningAfterSeconds 的任务
+	ClaimNextPendingTask(ctx context.Context, staleRunningAfterSeconds int64) (*UsageExportTask, error)
+	MarkTaskSucceeded(ctx context.Context, task *UsageExportTask) error
+	MarkTaskFailed(ctx context.Context, taskID int64, errorMsg string) error
+	// ListExpiredTasks 列出已过期但尚未清理文件的成功任务
+	ListExpiredTasks(ctx context.Context, now time.Time, limit int) ([]UsageExportTask, error)
+	MarkTaskExpired(ctx context.Context, taskID int64) error
+
+	CountUsageLogs(ctx context.Context, filters usagestats.UsageLogFilters) (int64, error)
+	// ListUsageLogsAfter 按 id 升序读取 id > afterID 的使用记录（含关联的 Key/分组/账号/用户）
+	ListUsageLogsAfter(ctx context.Context, filters usagestats.UsageLogFilters, afterID int64, limit int) ([]UsageLog, error)
+}
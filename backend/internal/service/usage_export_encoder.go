@@ -0,0 +1,252 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/parquet"
+)
+
+// usageExportColumn 导出列定义；value 返回 int64/float64/string/bool/time.Time 或 nil
+type usageExportColumn struct {
+	name      string
+	typ       parquet.ColumnType
+	optional  bool
+	adminOnly bool
+	value     func(l *UsageLog) any
+}
+
+var usageExportColumns = []usageExportColumn{
+	{name: "id", typ: parquet.Int64, value: func(l *UsageLog) any { return l.ID }},
+	{name: "created_at", typ: parquet.Timestamp, value: func(l *UsageLog) any { return l.CreatedAt.UTC() }},
+	{name: "request_id", typ: parquet.String, value: func(l *UsageLog) any { return l.RequestID }},
+	{name: "user_id", typ: parquet.Int64, value: func(l *UsageLog) any { return l.UserID }},
+	{name: "user_email", typ: parquet.String, optional: true, adminOnly: true, value: func(l *UsageLog) any {
+		if l.User == nil {
+			return nil
+		}
+		return l.User.Email
+	}},
+	{name: "organization_id", typ: parquet.Int64, optional: true, value: func(l *UsageLog) any { return optionalInt64(l.OrganizationID) }},
+	{name: "api_key_id", typ: parquet.Int64, value: func(l *UsageLog) any { return l.APIKeyID }},
+	{name: "api_key_name", typ: parquet.String, optional: true, value: func(l *UsageLog) any {
+		if l.APIKey == nil {
+			return nil
+		}
+		return l.APIKey.Name
+	}},
+	{name: "group_id", typ: parquet.Int64, optional: true, value: func(l *UsageLog) any { return optionalInt64(l.GroupID) }},
+	{name: "group_name", typ: parquet.String, optional: true, value: func(l *UsageLog) any {
+		if l.Group == nil {
+			return nil
+		}
+		return l.Group.Name
+	}},
+	{name: "account_id", typ: parquet.Int64, adminOnly: true, value: func(l *UsageLog) any { return l.AccountID }},
+	{name: "account_name", typ: parquet.String, optional: true, adminOnly: true, value: func(l *UsageLog) any {
+		if l.Account == nil {
+			return nil
+		}
+		return l.Account.Name
+	}},
+	{name: "model", typ: parquet.String, value: func(l *UsageLog) any { return l.Model }},
+	{name: "billing_type", typ: parquet.String, value: func(l *UsageLog) any { return usageExportBillingType(l.BillingType) }},
+	{name: "stream", typ: parquet.Boolean, value: func(l *UsageLog) any { return l.Stream }},
+	{name: "input_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return l.InputTokens }},
+	{name: "output_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return l.OutputTokens }},
+	{name: "cache_creation_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return l.CacheCreationTokens }},
+	{name: "cache_creation_5m_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return l.CacheCreation5mTokens }},
+	{name: "cache_creation_1h_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return l.CacheCreation1hTokens }},
+	{name: "cache_read_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return l.CacheReadTokens }},
+	{name: "total_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return l.TotalTokens() }},
+	{name: "image_count", typ: parquet.Int64, value: func(l *UsageLog) any { return l.ImageCount }},
+	{name: "input_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.InputCost }},
+	{name: "output_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.OutputCost }},
+	{name: "cache_creation_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.CacheCreationCost }},
+	{name: "cache_read_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.CacheReadCost }},
+	{name: "total_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.TotalCost }},
+	{name: "rate_multiplier", typ: parquet.Double, value: func(l *UsageLog) any { return l.RateMultiplier }},
+	{name: "actual_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.ActualCost }},
+	{name: "account_rate_multiplier", typ: parquet.Double, optional: true, adminOnly: true, value: func(l *UsageLog) any {
+		if l.AccountRateMultiplier == nil {
+			return nil
+		}
+		return *l.AccountRateMultiplier
+	}},
+	{name: "duration_ms", typ: parquet.Int64, optional: true, value: func(l *UsageLog) any { return optionalInt(l.DurationMs) }},
+	{name: "first_token_ms", typ: parquet.Int64, optional: true, value: func(l *UsageLog) any { return optionalInt(l.FirstTokenMs) }},
+	{name: "ip_address", typ: parquet.String, optional: true, adminOnly: true, value: func(l *UsageLog) any { return optionalString(l.IPAddress) }},
+	{name: "user_agent", typ: parquet.String, optional: true, adminOnly: true, value: func(l *UsageLog) any { return optionalString(l.UserAgent) }},
+}
+
+// usageExportColumnsForScope 返回指定范围可见的导出列
+func usageExportColumnsForScope(scope string) []usageExportColumn {
+	cols := make([]usageExportColumn, 0, len(usageExportColumns))
+	for _, col := range usageExportColumns {
+		if col.adminOnly && scope != UsageExportScopeAdmin {
+			continue
+		}
+		cols = append(cols, col)
+	}
+	return cols
+}
+
+func usageExportBillingType(t int8) string {
+	if t == BillingTypeSubscription {
+		return "subscription"
+	}
+	return "balance"
+}
+
+func optionalInt64(v *int64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func optionalInt(v *int) any {
+	if v == nil {
+		return nil
+	}
+	return int64(*v)
+}
+
+func optionalString(v *string) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+// usageExportEncoder 将使用记录逐行编码为导出格式
+type usageExportEncoder interface {
+	Write(l *UsageLog) error
+	// Close 写出剩余缓冲数据（不关闭底层 io.Writer）
+	Close() error
+}
+
+func newUsageExportEncoder(w io.Writer, format, scope string) (usageExportEncoder, error) {
+	cols := usageExportColumnsForScope(scope)
+	switch format {
+	case UsageExportFormatCSV:
+		return newUsageExportCSVEncoder(w, cols)
+	case UsageExportFormatJSONL:
+		return &usageExportJSONLEncoder{w: bufio.NewWriter(w), cols: cols}, nil
+	case UsageExportFormatParquet:
+		pcols := make([]parquet.Column, len(cols))
+		for i, col := range cols {
+			pcols[i] = parquet.Column{Name: col.name, Type: col.typ, Optional: col.optional}
+		}
+		pw, err := parquet.NewWriter(w, pcols, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &usageExportParquetEncoder{w: pw, cols: cols}, nil
+	default:
+		return nil, ErrUsageExportInvalidFormat
+	}
+}
+
+type usageExportCSVEncoder struct {
+	w    *csv.Writer
+	cols []usageExportColumn
+	row  []string
+}
+
+func newUsageExportCSVEncoder(w io.Writer, cols []usageExportColumn) (*usageExportCSVEncoder, error) {
+	enc := &usageExportCSVEncoder{w: csv.NewWriter(w), cols: cols, row: make([]string, len(cols))}
+	for i, col := range cols {
+		enc.row[i] = col.name
+	}
+	if err := enc.w.Write(enc.row); err != nil {
+		return nil, err
+	}
+	return enc, nil
+}
+
+func (e *usageExportCSVEncoder) Write(l *UsageLog) error {
+	for i, col := range e.cols {
+		e.row[i] = formatUsageExportValue(col.value(l))
+	}
+	return e.w.Write(e.row)
+}
+
+func (e *usageExportCSVEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func formatUsageExportValue(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case int:
+		return strconv.Itoa(x)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	case time.Time:
+		return x.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+type usageExportJSONLEncoder struct {
+	w    *bufio.Writer
+	cols []usageExportColumn
+}
+
+func (e *usageExportJSONLEncoder) Write(l *UsageLog) error {
+	_ = e.w.WriteByte('{')
+	for i, col := range e.cols {
+		if i > 0 {
+			_ = e.w.WriteByte(',')
+		}
+		key, _ := json.Marshal(col.name)
+		val, err := json.Marshal(col.value(l))
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", col.name, err)
+		}
+		_, _ = e.w.Write(key)
+		_ = e.w.WriteByte(':')
+		_, _ = e.w.Write(val)
+	}
+	_, err := e.w.WriteString("}\n")
+	return err
+}
+
+func (e *usageExportJSONLEncoder) Close() error {
+	return e.w.Flush()
+}
+
+type usageExportParquetEncoder struct {
+	w    *parquet.Writer
+	cols []usageExportColumn
+	row  []any
+}
+
+func (e *usageExportParquetEncoder) Write(l *UsageLog) error {
+	if e.row == nil {
+		e.row = make([]any, len(e.cols))
+	}
+	for i, col := range e.cols {
+		e.row[i] = col.value(l)
+	}
+	return e.w.Write(e.row...)
+}
+
+func (e *usageExportParquetEncoder) Close() error {
+	return e.w.Close()
+}
@@ -0,0 +1,478 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+)
+
+const (
+	usageExportWorkerName = "usage_export_worker"
+	// usageExportExpireBatch 单轮清理的过期任务数
+	usageExportExpireBatch = 100
+)
+
+var (
+	ErrUsageExportDisabled      = infraerrors.New(http.StatusServiceUnavailable, "USAGE_EXPORT_DISABLED", "usage export is disabled")
+	ErrUsageExportInvalidFormat = infraerrors.BadRequest("USAGE_EXPORT_INVALID_FORMAT", "format must be one of csv, jsonl, parquet")
+	ErrUsageExportMissingRange  = infraerrors.BadRequest("USAGE_EXPORT_MISSING_RANGE", "start_date and end_date are required")
+	ErrUsageExportInvalidRange  = infraerrors.BadRequest("USAGE_EXPORT_INVALID_RANGE", "end_date must be after start_date")
+	ErrUsageExportTaskNotFound  = infraerrors.NotFound("USAGE_EXPORT_TASK_NOT_FOUND", "export task not found")
+	ErrUsageExportLinkExpired   = infraerrors.New(http.StatusGone, "USAGE_EXPORT_LINK_EXPIRED", "download link has expired")
+)
+
+// UsageExportRequest 导出请求
+type UsageExportRequest struct {
+	Scope       string
+	Format      string
+	Filters     usagestats.UsageLogFilters
+	RequestedBy int64
+}
+
+// UsageExportService 使用记录导出：小范围同步流式输出，大范围创建异步任务生成文件
+type UsageExportService struct {
+	repo        UsageExportRepository
+	timingWheel *TimingWheelService
+	cfg         *config.Config
+
+	running   int32
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	workerCtx    context.Context
+	workerCancel context.CancelFunc
+}
+
+func NewUsageExportService(repo UsageExportRepository, timingWheel *TimingWheelService, cfg *config.Config) *UsageExportService {
+	workerCtx, workerCancel := context.WithCancel(context.Background())
+	return &UsageExportService{
+		repo:         repo,
+		timingWheel:  timingWheel,
+		cfg:          cfg,
+		workerCtx:    workerCtx,
+		workerCancel: workerCancel,
+	}
+}
+
+func (s *UsageExportService) Start() {
+	if s == nil {
+		return
+	}
+	if !s.enabled() {
+		log.Printf("[UsageExport] not started (disabled)")
+		return
+	}
+	if s.repo == nil || s.timingWheel == nil {
+		log.Printf("[UsageExport] not started (missing deps)")
+		return
+	}
+
+	interval := s.workerInterval()
+	s.startOnce.Do(func() {
+		s.timingWheel.ScheduleRecurring(usageExportWorkerName, interval, s.runOnce)
+		log.Printf("[UsageExport] started (interval=%s sync_max_rows=%d batch_size=%d storage_dir=%s)", interval, s.syncMaxRows(), s.batchSize(), s.storageDir())
+	})
+}
+
+func (s *UsageExportService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.workerCancel != nil {
+			s.workerCancel()
+		}
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(usageExportWorkerName)
+		}
+		log.Printf("[UsageExport] stopped")
+	})
+}
+
+// NormalizeUsageExportFormat 规范化导出格式，空值默认为 csv
+func NormalizeUsageExportFormat(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", UsageExportFormatCSV:
+		return UsageExportFormatCSV, nil
+	case UsageExportFormatJSONL, "ndjson":
+		return UsageExportFormatJSONL, nil
+	case UsageExportFormatParquet:
+		return UsageExportFormatParquet, nil
+	default:
+		return "", ErrUsageExportInvalidFormat
+	}
+}
+
+// UsageExportContentType 返回导出格式对应的 Content-Type
+func UsageExportContentType(format string) string {
+	switch format {
+	case UsageExportFormatJSONL:
+		return "application/x-ndjson"
+	case UsageExportFormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// UsageExportFileName 生成下载文件名
+func UsageExportFileName(format string, at time.Time) string {
+	return fmt.Sprintf("usage_%s.%s", at.Format("20060102_150405"), format)
+}
+
+// Submit 校验导出请求：行数不超过 sync_max_rows 时返回 (nil, nil)，调用方随后通过 Stream 同步输出；
+// 否则创建异步导出任务并返回。
+func (s *UsageExportService) Submit(ctx context.Context, req *UsageExportRequest) (*UsageExportTask, error) {
+	if err := s.validate(req); err != nil {
+		return nil, err
+	}
+
+	total, err := s.repo.CountUsageLogs(ctx, req.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("count usage logs: %w", err)
+	}
+	if total <= int64(s.syncMaxRows()) {
+		return nil, nil
+	}
+
+	task := &UsageExportTask{
+		Scope:     req.Scope,
+		Format:    req.Format,
+		Status:    UsageExportStatusPending,
+		Filters:   UsageExportFiltersFromLogFilters(req.Filters),
+		CreatedBy: req.RequestedBy,
+	}
+	if err := s.repo.CreateTask(ctx, task); err != nil {
+		return nil, fmt.Errorf("create export task: %w", err)
+	}
+	log.Printf("[UsageExport] task created: task=%d scope=%s format=%s operator=%d rows=%d", task.ID, task.Scope, task.Format, task.CreatedBy, total)
+	go s.runOnce()
+	return task, nil
+}
+
+// Stream 按过滤条件将使用记录同步写入 w，返回写出的行数
+func (s *UsageExportService) Stream(ctx context.Context, w io.Writer, req *UsageExportRequest) (int64, error) {
+	if err := s.validate(req); err != nil {
+		return 0, err
+	}
+	return s.writeLogs(ctx, w, req.Scope, req.Format, req.Filters)
+}
+
+func (s *UsageExportService) ListTasks(ctx context.Context, createdBy int64, params pagination.PaginationParams) ([]UsageExportTask, *pagination.PaginationResult, error) {
+	if s == nil || s.repo == nil {
+		return nil, nil, fmt.Errorf("export service not ready")
+	}
+	return s.repo.ListTasks(ctx, createdBy, params)
+}
+
+// GetTask 查询导出任务；createdBy 大于 0 时仅允许查看自己创建的任务
+func (s *UsageExportService) GetTask(ctx context.Context, taskID int64, createdBy int64) (*UsageExportTask, error) {
+	if s == nil || s.repo == nil {
+		return nil, fmt.Errorf("export service not ready")
+	}
+	task, err := s.repo.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if createdBy > 0 && task.CreatedBy != createdBy {
+		return nil, ErrUsageExportTaskNotFound
+	}
+	return task, nil
+}
+
+// ResolveDownload 校验下载令牌并返回任务与文件的绝对路径
+func (s *UsageExportService) ResolveDownload(ctx context.Context, token string) (*UsageExportTask, string, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, "", ErrUsageExportTaskNotFound
+	}
+	task, err := s.repo.GetTaskByToken(ctx, token)
+	if err != nil {
+		return nil, "", err
+	}
+	if task.Status != UsageExportStatusSucceeded || task.ExpiresAt == nil || !time.Now().Before(*task.ExpiresAt) {
+		return nil, "", ErrUsageExportLinkExpired
+	}
+	path := filepath.Join(s.storageDir(), filepath.Base(task.FilePath))
+	if _, err := os.Stat(path); err != nil {
+		return nil, "", ErrUsageExportLinkExpired
+	}
+	return task, path, nil
+}
+
+func (s *UsageExportService) validate(req *UsageExportRequest) error {
+	if s == nil || s.repo == nil {
+		return fmt.Errorf("export service not ready")
+	}
+	if !s.enabled() {
+		return ErrUsageExportDisabled
+	}
+	if req == nil {
+		return ErrUsageExportMissingRange
+	}
+	format, err := NormalizeUsageExportFormat(req.Format)
+	if err != nil {
+		return err
+	}
+	req.Format = format
+	if req.Scope != UsageExportScopeAdmin {
+		req.Scope = UsageExportScopeUser
+		if req.Filters.UserID <= 0 {
+			return infraerrors.BadRequest("USAGE_EXPORT_INVALID_USER", "user export requires a user")
+		}
+	}
+	if req.RequestedBy <= 0 {
+		return infraerrors.BadRequest("USAGE_EXPORT_INVALID_CREATOR", "invalid creator")
+	}
+
+	f := req.Filters
+	if f.StartTime == nil || f.EndTime == nil {
+		return ErrUsageExportMissingRange
+	}
+	if f.EndTime.Before(*f.StartTime) {
+		return ErrUsageExportInvalidRange
+	}
+	if maxDays := s.maxRangeDays(); maxDays > 0 && f.EndTime.Sub(*f.StartTime) > time.Duration(maxDays)*24*time.Hour {
+		return infraerrors.BadRequest("USAGE_EXPORT_RANGE_TOO_LARGE", fmt.Sprintf("date range exceeds %d days", maxDays))
+	}
+	return nil
+}
+
+// writeLogs 以 id 游标分批读取使用记录并编码输出
+func (s *UsageExportService) writeLogs(ctx context.Context, w io.Writer, scope, format string, filters usagestats.UsageLogFilters) (int64, error) {
+	enc, err := newUsageExportEncoder(w, format, scope)
+	if err != nil {
+		return 0, err
+	}
+	batchSize := s.batchSize()
+	var afterID, rows int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return rows, err
+		}
+		logs, err := s.repo.ListUsageLogsAfter(ctx, filters, afterID, batchSize)
+		if err != nil {
+			return rows, fmt.Errorf("list usage logs: %w", err)
+		}
+		for i := range logs {
+			if err := enc.Write(&logs[i]); err != nil {
+				return rows, err
+			}
+			rows++
+		}
+		if len(logs) < batchSize {
+			break
+		}
+		afterID = logs[len(logs)-1].ID
+	}
+	return rows, enc.Close()
+}
+
+func (s *UsageExportService) runOnce() {
+	if s == nil {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&s.running, 0)
+
+	parent := context.Background()
+	if s.workerCtx != nil {
+		parent = s.workerCtx
+	}
+	ctx, cancel := context.WithTimeout(parent, s.taskTimeout())
+	defer cancel()
+
+	s.expireTasks(ctx)
+
+	task, err := s.repo.ClaimNextPendingTask(ctx, int64(s.taskTimeout().Seconds()))
+	if err != nil {
+		log.Printf("[UsageExport] claim pending task failed: %v", err)
+		return
+	}
+	if task == nil {
+		return
+	}
+	log.Printf("[UsageExport] task claimed: task=%d scope=%s format=%s created_by=%d", task.ID, task.Scope, task.Format, task.CreatedBy)
+	s.executeTask(ctx, task)
+}
+
+func (s *UsageExportService) executeTask(ctx context.Context, task *UsageExportTask) {
+	start := time.Now()
+	dir := s.storageDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		s.markTaskFailed(task.ID, fmt.Errorf("create storage dir: %w", err))
+		return
+	}
+
+	token, err := newUsageExportToken()
+	if err != nil {
+		s.markTaskFailed(task.ID, err)
+		return
+	}
+	fileName := fmt.Sprintf("usage_export_%d_%s.%s", task.ID, token[:8], task.Format)
+	tmpPath := filepath.Join(dir, fileName+".tmp")
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		s.markTaskFailed(task.ID, fmt.Errorf("create export file: %w", err))
+		return
+	}
+
+	rows, err := s.writeLogs(ctx, file, task.Scope, task.Format, task.Filters.LogFilters())
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			// 服务停止或超时：保持 running，后续通过 stale reclaim 重新生成
+			log.Printf("[UsageExport] task interrupted: task=%d err=%v", task.ID, err)
+			return
+		}
+		s.markTaskFailed(task.ID, err)
+		return
+	}
+
+	finalPath := filepath.Join(dir, fileName)
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		_ = os.Remove(tmpPath)
+		s.markTaskFailed(task.ID, fmt.Errorf("finalize export file: %w", err))
+		return
+	}
+	info, err := os.Stat(finalPath)
+	if err != nil {
+		s.markTaskFailed(task.ID, err)
+		return
+	}
+
+	expiresAt := time.Now().Add(s.linkTTL())
+	task.Status = UsageExportStatusSucceeded
+	task.RowCount = rows
+	task.FileSize = info.Size()
+	task.FilePath = fileName
+	task.DownloadToken = token
+	task.ExpiresAt = &expiresAt
+
+	updateCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.repo.MarkTaskSucceeded(updateCtx, task); err != nil {
+		_ = os.Remove(finalPath)
+		log.Printf("[UsageExport] update task succeeded failed: task=%d err=%v", task.ID, err)
+		return
+	}
+	log.Printf("[UsageExport] task succeeded: task=%d rows=%d size=%d duration=%s", task.ID, rows, task.FileSize, time.Since(start))
+}
+
+// expireTasks 清理下载链接已过期的导出文件
+func (s *UsageExportService) expireTasks(ctx context.Context) {
+	tasks, err := s.repo.ListExpiredTasks(ctx, time.Now(), usageExportExpireBatch)
+	if err != nil {
+		log.Printf("[UsageExport] list expired tasks failed: %v", err)
+		return
+	}
+	for i := range tasks {
+		task := &tasks[i]
+		if task.FilePath != "" {
+			path := filepath.Join(s.storageDir(), filepath.Base(task.FilePath))
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				log.Printf("[UsageExport] remove expired file failed: task=%d err=%v", task.ID, err)
+				continue
+			}
+		}
+		if err := s.repo.MarkTaskExpired(ctx, task.ID); err != nil {
+			log.Printf("[UsageExport] mark task expired failed: task=%d err=%v", task.ID, err)
+		}
+	}
+}
+
+func (s *UsageExportService) markTaskFailed(taskID int64, err error) {
+	msg := strings.TrimSpace(err.Error())
+	if len(msg) > 500 {
+		msg = msg[:500]
+	}
+	log.Printf("[UsageExport] task failed: task=%d err=%s", taskID, msg)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if updateErr := s.repo.MarkTaskFailed(ctx, taskID, msg); updateErr != nil {
+		log.Printf("[UsageExport] update task failed failed: task=%d err=%v", taskID, updateErr)
+	}
+}
+
+func newUsageExportToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate download token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (s *UsageExportService) enabled() bool {
+	return s.cfg == nil || s.cfg.UsageExport.Enabled
+}
+
+func (s *UsageExportService) storageDir() string {
+	if s == nil || s.cfg == nil || strings.TrimSpace(s.cfg.UsageExport.StorageDir) == "" {
+		return "./data/exports"
+	}
+	return s.cfg.UsageExport.StorageDir
+}
+
+func (s *UsageExportService) syncMaxRows() int {
+	if s == nil || s.cfg == nil {
+		return 10000
+	}
+	return s.cfg.UsageExport.SyncMaxRows
+}
+
+func (s *UsageExportService) maxRangeDays() int {
+	if s == nil || s.cfg == nil {
+		return 366
+	}
+	return s.cfg.UsageExport.MaxRangeDays
+}
+
+func (s *UsageExportService) batchSize() int {
+	if s == nil || s.cfg == nil || s.cfg.UsageExport.BatchSize <= 0 {
+		return 2000
+	}
+	return s.cfg.UsageExport.BatchSize
+}
+
+func (s *UsageExportService) linkTTL() time.Duration {
+	if s == nil || s.cfg == nil || s.cfg.UsageExport.LinkTTLHours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(s.cfg.UsageExport.LinkTTLHours) * time.Hour
+}
+
+func (s *UsageExportService) workerInterval() time.Duration {
+	if s == nil || s.cfg == nil || s.cfg.UsageExport.WorkerIntervalSeconds <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(s.cfg.UsageExport.WorkerIntervalSeconds) * time.Second
+}
+
+func (s *UsageExportService) taskTimeout() time.Duration {
+	if s == nil || s.cfg == nil || s.cfg.UsageExport.TaskTimeoutSeconds <= 0 {
+		return time.Hour
+	}
+	return time.Duration(s.cfg.UsageExport.TaskTimeoutSeconds) * time.Second
+}
@@ -0,0 +1,244 @@
+//go:build unit
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+	"github.com/stretchr/testify/require"
+)
+
+type usageExportRepoStub struct {
+	logs      []UsageLog
+	created   []*UsageExportTask
+	succeeded *UsageExportTask
+	failed    map[int64]string
+	expired   []int64
+	pending   *UsageExportTask
+	byToken   map[string]*UsageExportTask
+	expiredTs []UsageExportTask
+}
+
+func (r *usageExportRepoStub) CreateTask(ctx context.Context, task *UsageExportTask) error {
+	task.ID = int64(len(r.created) + 1)
+	task.CreatedAt = time.Now()
+	r.created = append(r.created, task)
+	return nil
+}
+
+func (r *usageExportRepoStub) ListTasks(ctx context.Context, createdBy int64, params pagination.PaginationParams) ([]UsageExportTask, *pagination.PaginationResult, error) {
+	return nil, &pagination.PaginationResult{}, nil
+}
+
+func (r *usageExportRepoStub) GetTask(ctx context.Context, taskID int64) (*UsageExportTask, error) {
+	for _, task := range r.created {
+		if task.ID == taskID {
+			return task, nil
+		}
+	}
+	return nil, ErrUsageExportTaskNotFound
+}
+
+func (r *usageExportRepoStub) GetTaskByToken(ctx context.Context, token string) (*UsageExportTask, error) {
+	if task, ok := r.byToken[token]; ok {
+		return task, nil
+	}
+	return nil, ErrUsageExportTaskNotFound
+}
+
+func (r *usageExportRepoStub) ClaimNextPendingTask(ctx context.Context, staleRunningAfterSeconds int64) (*UsageExportTask, error) {
+	task := r.pending
+	r.pending = nil
+	return task, nil
+}
+
+func (r *usageExportRepoStub) MarkTaskSucceeded(ctx context.Context, task *UsageExportTask) error {
+	copied := *task
+	r.succeeded = &copied
+	return nil
+}
+
+func (r *usageExportRepoStub) MarkTaskFailed(ctx context.Context, taskID int64, errorMsg string) error {
+	if r.failed == nil {
+		r.failed = map[int64]string{}
+	}
+	r.failed[taskID] = errorMsg
+	return nil
+}
+
+func (r *usageExportRepoStub) ListExpiredTasks(ctx context.Context, now time.Time, limit int) ([]UsageExportTask, error) {
+	return r.expiredTs, nil
+}
+
+func (r *usageExportRepoStub) MarkTaskExpired(ctx context.Context, taskID int64) error {
+	r.expired = append(r.expired, taskID)
+	return nil
+}
+
+func (r *usageExportRepoStub) CountUsageLogs(ctx context.Context, filters usagestats.UsageLogFilters) (int64, error) {
+	return int64(len(r.logs)), nil
+}
+
+func (r *usageExportRepoStub) ListUsageLogsAfter(ctx context.Context, filters usagestats.UsageLogFilters, afterID int64, limit int) ([]UsageLog, error) {
+	out := make([]UsageLog, 0, limit)
+	for _, l := range r.logs {
+		if l.ID > afterID && len(out) < limit {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func newUsageExportTestService(t *testing.T, repo UsageExportRepository, syncMaxRows int) *UsageExportService {
+	cfg := &config.Config{UsageExport: config.UsageExportConfig{
+		Enabled:      true,
+		StorageDir:   t.TempDir(),
+		SyncMaxRows:  syncMaxRows,
+		BatchSize:    2,
+		LinkTTLHours: 1,
+	}}
+	return NewUsageExportService(repo, nil, cfg)
+}
+
+func usageExportTestRequest(scope, format string) *UsageExportRequest {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	return &UsageExportRequest{
+		Scope:       scope,
+		Format:      format,
+		Filters:     usagestats.UsageLogFilters{UserID: 7, StartTime: &start, EndTime: &end},
+		RequestedBy: 7,
+	}
+}
+
+func usageExportTestLogs(n int) []UsageLog {
+	ip := "10.0.0.1"
+	logs := make([]UsageLog, n)
+	for i := range logs {
+		logs[i] = UsageLog{
+			ID:          int64(i + 1),
+			UserID:      7,
+			APIKeyID:    3,
+			AccountID:   11,
+			Model:       "claude-sonnet-4",
+			InputTokens: 10 * (i + 1),
+			ActualCost:  0.5,
+			IPAddress:   &ip,
+			Account:     &Account{Name: "upstream-1"},
+			CreatedAt:   time.Date(2026, 1, 1, 1, 0, 0, 0, time.UTC),
+		}
+	}
+	return logs
+}
+
+func TestUsageExportSubmitStreamsSmallRangesAndQueuesLargeOnes(t *testing.T) {
+	repo := &usageExportRepoStub{logs: usageExportTestLogs(3)}
+	svc := newUsageExportTestService(t, repo, 3)
+
+	task, err := svc.Submit(context.Background(), usageExportTestRequest(UsageExportScopeUser, ""))
+	require.NoError(t, err)
+	require.Nil(t, task)
+	require.Empty(t, repo.created)
+
+	repo.logs = usageExportTestLogs(4)
+	task, err = svc.Submit(context.Background(), usageExportTestRequest(UsageExportScopeUser, "parquet"))
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	require.Equal(t, UsageExportStatusPending, task.Status)
+	require.Equal(t, UsageExportFormatParquet, task.Format)
+	require.EqualValues(t, 7, task.Filters.UserID)
+}
+
+func TestUsageExportSubmitValidatesRequest(t *testing.T) {
+	svc := newUsageExportTestService(t, &usageExportRepoStub{}, 10)
+
+	req := usageExportTestRequest(UsageExportScopeUser, "xlsx")
+	_, err := svc.Submit(context.Background(), req)
+	require.ErrorIs(t, err, ErrUsageExportInvalidFormat)
+
+	req = usageExportTestRequest(UsageExportScopeUser, "csv")
+	req.Filters.StartTime = nil
+	_, err = svc.Submit(context.Background(), req)
+	require.ErrorIs(t, err, ErrUsageExportMissingRange)
+
+	req = usageExportTestRequest(UsageExportScopeUser, "csv")
+	req.Filters.UserID = 0
+	_, err = svc.Submit(context.Background(), req)
+	require.Error(t, err)
+}
+
+func TestUsageExportStreamUserScopeOmitsAdminColumns(t *testing.T) {
+	repo := &usageExportRepoStub{logs: usageExportTestLogs(3)}
+	svc := newUsageExportTestService(t, repo, 10)
+
+	var buf bytes.Buffer
+	rows, err := svc.Stream(context.Background(), &buf, usageExportTestRequest(UsageExportScopeUser, "csv"))
+	require.NoError(t, err)
+	require.EqualValues(t, 3, rows)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	require.NotContains(t, lines[0], "account")
+	require.NotContains(t, lines[0], "ip_address")
+	require.Contains(t, lines[0], "actual_cost")
+
+	buf.Reset()
+	req := usageExportTestRequest(UsageExportScopeAdmin, "jsonl")
+	rows, err = svc.Stream(context.Background(), &buf, req)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, rows)
+	var first map[string]any
+	require.NoError(t, json.Unmarshal([]byte(strings.SplitN(buf.String(), "\n", 2)[0]), &first))
+	require.Equal(t, "upstream-1", first["account_name"])
+	require.Equal(t, "10.0.0.1", first["ip_address"])
+	require.Nil(t, first["group_id"])
+}
+
+func TestUsageExportWorkerWritesFileAndResolvesDownload(t *testing.T) {
+	repo := &usageExportRepoStub{logs: usageExportTestLogs(5)}
+	svc := newUsageExportTestService(t, repo, 0)
+	repo.pending = &UsageExportTask{
+		ID:        9,
+		Scope:     UsageExportScopeUser,
+		Format:    UsageExportFormatCSV,
+		Status:    UsageExportStatusRunning,
+		Filters:   UsageExportFiltersFromLogFilters(usageExportTestRequest(UsageExportScopeUser, "").Filters),
+		CreatedBy: 7,
+	}
+
+	svc.runOnce()
+
+	done := repo.succeeded
+	require.NotNil(t, done)
+	require.EqualValues(t, 5, done.RowCount)
+	require.NotEmpty(t, done.DownloadToken)
+	require.NotNil(t, done.ExpiresAt)
+
+	repo.byToken = map[string]*UsageExportTask{done.DownloadToken: done}
+	task, path, err := svc.ResolveDownload(context.Background(), done.DownloadToken)
+	require.NoError(t, err)
+	require.Equal(t, done.ID, task.ID)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, strings.Split(strings.TrimSpace(string(content)), "\n"), 6)
+
+	past := time.Now().Add(-time.Minute)
+	done.ExpiresAt = &past
+	_, _, err = svc.ResolveDownload(context.Background(), done.DownloadToken)
+	require.ErrorIs(t, err, ErrUsageExportLinkExpired)
+
+	repo.expiredTs = []UsageExportTask{*done}
+	svc.runOnce()
+	require.Equal(t, []int64{done.ID}, repo.expired)
+	_, err = os.Stat(filepath.Join(svc.storageDir(), done.FilePath))
+	require.True(t, os.IsNotExist(err))
+}
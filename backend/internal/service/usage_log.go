@@ -17,6 +17,8 @@ type UsageLog struct {
 
 	GroupID        *int64
 	SubscriptionID *int64
+	// OrganizationID 组织 Key 产生的用量（费用由组织承担，UserID 为发起请求的成员）
+	OrganizationID *int64
 
 	InputTokens         int
 	OutputTokens        int
@@ -26,27 +28,36 @@ type UsageLog struct {
 	CacheCreation5mTokens int
 	CacheCreation1hTokens int
 
-	InputCost         float64
-	OutputCost        float64
-	CacheCreationCost float64
-	CacheReadCost     float64
-	TotalCost         float64
-	ActualCost        float64
-	RateMultiplier    float64
+	InputCost  float64
+	OutputCost float64
+	// CacheCreationCost 缓存写入总费用，其中 1 小时缓存写入部分为 CacheCreation1hCost
+	CacheCreationCost   float64
+	CacheCreation1hCost float64
+	CacheReadCost       float64
+	TotalCost           float64
+	ActualCost          float64
+	RateMultiplier      float64
 	// AccountRateMultiplier 账号计费倍率快照（nil 表示历史数据，按 1.0 处理）
 	AccountRateMultiplier *float64
+	// RateRule 产生 RateMultiplier 的倍率规则（nil 表示历史数据）
+	RateRule *string
 
 	BillingType  int8
 	Stream       bool
 	DurationMs   *int
 	FirstTokenMs *int
-	UserAgent    *string
-	IPAddress    *string
+	// Hedged 请求触发了对冲（同一请求发往第二个账号，AccountID 为胜出账号）
+	Hedged    bool
+	UserAgent *string
+	IPAddress *string
 
 	// 图片生成字段
 	ImageCount int
 	ImageSize  *string
 
+	// Tags 成本归属标签（标签键 -> 标签值），仅包含管理员定义的标签键
+	Tags map[string]string
+
 	CreatedAt time.Time
 
 	User         *User
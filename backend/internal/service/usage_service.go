@@ -50,6 +50,12 @@ type UsageStats struct {
 	TotalCost         float64 `json:"total_cost"`
 	TotalActualCost   float64 `json:"total_actual_cost"`
 	AverageDurationMs float64 `json:"average_duration_ms"`
+
+	// 缓存写入按 TTL 拆分（5 分钟 / 1 小时）
+	TotalCacheCreation5mTokens int64   `json:"total_cache_creation_5m_tokens"`
+	TotalCacheCreation1hTokens int64   `json:"total_cache_creation_1h_tokens"`
+	TotalCacheCreation5mCost   float64 `json:"total_cache_creation_5m_cost"`
+	TotalCacheCreation1hCost   float64 `json:"total_cache_creation_1h_cost"`
 }
 
 // UsageService 使用统计服务
@@ -198,6 +204,11 @@ func (s *UsageService) GetStatsByUser(ctx context.Context, userID int64, startTi
 		TotalCost:         stats.TotalCost,
 		TotalActualCost:   stats.TotalActualCost,
 		AverageDurationMs: stats.AverageDurationMs,
+
+		TotalCacheCreation5mTokens: stats.TotalCacheCreation5mTokens,
+		TotalCacheCreation1hTokens: stats.TotalCacheCreation1hTokens,
+		TotalCacheCreation5mCost:   stats.TotalCacheCreation5mCost,
+		TotalCacheCreation1hCost:   stats.TotalCacheCreation1hCost,
 	}, nil
 }
 
@@ -217,6 +228,11 @@ func (s *UsageService) GetStatsByAPIKey(ctx context.Context, apiKeyID int64, sta
 		TotalCost:         stats.TotalCost,
 		TotalActualCost:   stats.TotalActualCost,
 		AverageDurationMs: stats.AverageDurationMs,
+
+		TotalCacheCreation5mTokens: stats.TotalCacheCreation5mTokens,
+		TotalCacheCreation1hTokens: stats.TotalCacheCreation1hTokens,
+		TotalCacheCreation5mCost:   stats.TotalCacheCreation5mCost,
+		TotalCacheCreation1hCost:   stats.TotalCacheCreation1hCost,
 	}, nil
 }
 
@@ -236,6 +252,11 @@ func (s *UsageService) GetStatsByAccount(ctx context.Context, accountID int64, s
 		TotalCost:         stats.TotalCost,
 		TotalActualCost:   stats.TotalActualCost,
 		AverageDurationMs: stats.AverageDurationMs,
+
+		TotalCacheCreation5mTokens: stats.TotalCacheCreation5mTokens,
+		TotalCacheCreation1hTokens: stats.TotalCacheCreation1hTokens,
+		TotalCacheCreation5mCost:   stats.TotalCacheCreation5mCost,
+		TotalCacheCreation1hCost:   stats.TotalCacheCreation1hCost,
 	}, nil
 }
 
@@ -255,6 +276,11 @@ func (s *UsageService) GetStatsByModel(ctx context.Context, modelName string, st
 		TotalCost:         stats.TotalCost,
 		TotalActualCost:   stats.TotalActualCost,
 		AverageDurationMs: stats.AverageDurationMs,
+
+		TotalCacheCreation5mTokens: stats.TotalCacheCreation5mTokens,
+		TotalCacheCreation1hTokens: stats.TotalCacheCreation1hTokens,
+		TotalCacheCreation5mCost:   stats.TotalCacheCreation5mCost,
+		TotalCacheCreation1hCost:   stats.TotalCacheCreation1hCost,
 	}, nil
 }
 
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// UsageTagHeader 客户端传递成本归属标签的请求头，格式 key=value,key2=value2
+const UsageTagHeader = "X-Sub2api-Tags"
+
+// usageTagMaxPerRequest 单个请求允许携带的标签数量上限
+const usageTagMaxPerRequest = 10
+
+var (
+	usageTagKeyPattern   = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+	usageTagValuePattern = regexp.MustCompile(`^[A-Za-z0-9._:/-]{1,64}$`)
+)
+
+var (
+	ErrUsageTagKeyNotFound    = infraerrors.NotFound("USAGE_TAG_KEY_NOT_FOUND", "usage tag key not found")
+	ErrUsageTagKeyExists      = infraerrors.Conflict("USAGE_TAG_KEY_EXISTS", "usage tag key already exists")
+	ErrUsageTagKeyInvalid     = infraerrors.BadRequest("USAGE_TAG_KEY_INVALID", "tag key must match [a-z][a-z0-9_-]{0,31} and allowed values must be valid tag values")
+	ErrUsageTagInvalid        = infraerrors.BadRequest("USAGE_TAG_INVALID", "invalid usage tags")
+	ErrUsageTagBudgetNotFound = infraerrors.NotFound("USAGE_TAG_BUDGET_NOT_FOUND", "usage tag budget not found")
+	ErrUsageTagBudgetInvalid  = infraerrors.BadRequest("USAGE_TAG_BUDGET_INVALID", "tag key must be defined, tag value must be valid and monthly_limit_usd must be positive")
+	ErrUsageTagBudgetExceeded = infraerrors.Forbidden("USAGE_TAG_BUDGET_EXCEEDED", "usage tag monthly budget exceeded")
+)
+
+// UsageTagKey 管理员定义的标签键
+type UsageTagKey struct {
+	ID          int64
+	Key         string
+	Description string
+	// AllowedValues 为空表示允许任意符合格式的标签值
+	AllowedValues []string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Valid 校验标签键与允许值格式
+func (k *UsageTagKey) Valid() bool {
+	if !usageTagKeyPattern.MatchString(k.Key) {
+		return false
+	}
+	for _, v := range k.AllowedValues {
+		if !usageTagValuePattern.MatchString(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// allows 判断标签值是否被允许
+func (k *UsageTagKey) allows(value string) bool {
+	if len(k.AllowedValues) == 0 {
+		return true
+	}
+	for _, v := range k.AllowedValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// UsageTagBudget 用户按标签值设置的月度预算
+type UsageTagBudget struct {
+	ID              int64
+	UserID          int64
+	TagKey          string
+	TagValue        string
+	MonthlyLimitUSD float64
+	// MonthSpend 当月携带该标签值的实际消费（仅列表接口填充）
+	MonthSpend float64
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// UsageTagStatsFilters 标签聚合筛选；TagKey 必填
+type UsageTagStatsFilters struct {
+	TagKey    string
+	UserID    *int64
+	APIKeyID  *int64
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// UsageTagStat 单个标签值的用量汇总；TagValue 为空表示未携带该标签键的请求
+type UsageTagStat struct {
+	TagValue     string
+	Requests     int64
+	InputTokens  int64
+	OutputTokens int64
+	TotalCost    float64
+	ActualCost   float64
+}
+
+// UsageTagRepository 标签键、标签预算存储与按标签聚合查询
+type UsageTagRepository interface {
+	ListKeys(ctx context.Context) ([]UsageTagKey, error)
+	GetKey(ctx context.Context, id int64) (*UsageTagKey, error)
+	CreateKey(ctx context.Context, key *UsageTagKey) error
+	UpdateKey(ctx context.Context, key *UsageTagKey) error
+	DeleteKey(ctx context.Context, id int64) error
+
+	// ListBudgets userID 为空表示所有用户
+	ListBudgets(ctx context.Context, userID *int64) ([]UsageTagBudget, error)
+	// UpsertBudget 同一用户同一标签值只保留一条预算
+	UpsertBudget(ctx context.Context, budget *UsageTagBudget) error
+	// DeleteBudget userID 非空时只删除该用户的预算
+	DeleteBudget(ctx context.Context, id int64, userID *int64) error
+
+	// SumMonthlySpendByTag 用户自 since 起携带指定标签值的实际消费
+	SumMonthlySpendByTag(ctx context.Context, userID int64, tagKey, tagValue string, since time.Time) (float64, error)
+	// GetStats 按标签值聚合 [StartTime, EndTime) 内的用量，按实际消费降序
+	GetStats(ctx context.Context, filters UsageTagStatsFilters) ([]UsageTagStat, error)
+}
+
+// ParseUsageTagHeader 解析 key=value,key2=value2 格式的标签
+func ParseUsageTagHeader(raw string) (map[string]string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	tags := make(map[string]string)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" || value == "" {
+			return nil, infraerrors.BadRequest(infraerrors.Reason(ErrUsageTagInvalid), fmt.Sprintf("malformed tag %q, expected key=value", part))
+		}
+		if _, dup := tags[key]; dup {
+			return nil, infraerrors.BadRequest(infraerrors.Reason(ErrUsageTagInvalid), fmt.Sprintf("duplicate tag key %q", key))
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+// ExtractMetadataTags 读取 Anthropic 请求体中的 metadata.tags（对象或 key=value 字符串），
+// 并从请求体中移除该字段（上游不接受未知的 metadata 字段）。
+func ExtractMetadataTags(body []byte) (map[string]string, []byte, error) {
+	field := gjson.GetBytes(body, "metadata.tags")
+	if !field.Exists() {
+		return nil, body, nil
+	}
+
+	var tags map[string]string
+	switch {
+	case field.IsObject():
+		tags = make(map[string]string)
+		var bad string
+		field.ForEach(func(k, v gjson.Result) bool {
+			if v.Type != gjson.String {
+				bad = k.String()
+				return false
+			}
+			tags[strings.TrimSpace(k.String())] = strings.TrimSpace(v.String())
+			return true
+		})
+		if bad != "" {
+			return nil, body, infraerrors.BadRequest(infraerrors.Reason(ErrUsageTagInvalid), fmt.Sprintf("metadata.tags.%s must be a string", bad))
+		}
+	case field.Type == gjson.String:
+		parsed, err := ParseUsageTagHeader(field.String())
+		if err != nil {
+			return nil, body, err
+		}
+		tags = parsed
+	case field.Type == gjson.Null:
+	default:
+		return nil, body, infraerrors.BadRequest(infraerrors.Reason(ErrUsageTagInvalid), "metadata.tags must be an object or a key=value string")
+	}
+
+	stripped, err := sjson.DeleteBytes(body, "metadata.tags")
+	if err != nil {
+		return nil, body, err
+	}
+	return tags, stripped, nil
+}
+
+// mergeUsageTags 合并请求头与 metadata 标签，同名标签以请求头为准
+func mergeUsageTags(header, metadata map[string]string) map[string]string {
+	if len(header) == 0 && len(metadata) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(header)+len(metadata))
+	for k, v := range metadata {
+		merged[k] = v
+	}
+	for k, v := range header {
+		merged[k] = v
+	}
+	return merged
+}
+
+// validateUsageTags 按已定义标签键校验标签；错误信息按标签键排序以保证稳定
+func validateUsageTags(tags map[string]string, keys map[string]*UsageTagKey) error {
+	if len(tags) > usageTagMaxPerRequest {
+		return infraerrors.BadRequest(infraerrors.Reason(ErrUsageTagInvalid), fmt.Sprintf("at most %d tags are allowed per request", usageTagMaxPerRequest))
+	}
+	names := make([]string, 0, len(tags))
+	for k := range tags {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := tags[name]
+		def := keys[name]
+		if def == nil {
+			return infraerrors.BadRequest(infraerrors.Reason(ErrUsageTagInvalid), fmt.Sprintf("unknown tag key %q", name))
+		}
+		if !usageTagValuePattern.MatchString(value) {
+			return infraerrors.BadRequest(infraerrors.Reason(ErrUsageTagInvalid), fmt.Sprintf("invalid value for tag %q", name))
+		}
+		if !def.allows(value) {
+			return infraerrors.BadRequest(infraerrors.Reason(ErrUsageTagInvalid), fmt.Sprintf("value %q is not allowed for tag %q", value, name))
+		}
+	}
+	return nil
+}
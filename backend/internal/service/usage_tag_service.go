@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+)
+
+const (
+	usageTagReloadWorkerName = "usage_tag_reload"
+	// usageTagReloadInterval 多实例部署时从数据库同步标签键与预算的周期
+	usageTagReloadInterval = time.Minute
+	// usageTagSpendCacheTTL 标签当月消费的缓存时间（预算判断允许短暂滞后）
+	usageTagSpendCacheTTL = time.Minute
+	// usageTagSpendCacheMax 消费缓存条目上限，超过后清理过期条目
+	usageTagSpendCacheMax = 10000
+	// usageTagStatsMaxRange 聚合查询允许的最大时间跨度
+	usageTagStatsMaxRange = 366 * 24 * time.Hour
+)
+
+// SetUsageTagKeyInput 创建或更新标签键
+type SetUsageTagKeyInput struct {
+	Key           string
+	Description   string
+	AllowedValues []string
+}
+
+// SetUsageTagBudgetInput 设置标签值月度预算
+type SetUsageTagBudgetInput struct {
+	TagKey          string
+	TagValue        string
+	MonthlyLimitUSD float64
+}
+
+type usageTagSpendKey struct {
+	userID int64
+	key    string
+	value  string
+}
+
+type usageTagSpendEntry struct {
+	since     time.Time
+	amount    float64
+	expiresAt time.Time
+}
+
+// UsageTagService 成本归属标签：校验请求标签、按标签聚合用量、按标签值执行月度预算
+//
+// 标签键与预算缓存在内存中，网关路径只做内存匹配；仅当请求标签命中用户预算时才查询当月消费，
+// 消费按 usageTagSpendCacheTTL 缓存。管理端修改后立即重新加载，其他实例按 usageTagReloadInterval 周期同步。
+type UsageTagService struct {
+	repo        UsageTagRepository
+	userRepo    UserRepository
+	timingWheel *TimingWheelService
+
+	mu      sync.RWMutex
+	keys    map[string]*UsageTagKey
+	budgets map[int64][]UsageTagBudget
+
+	spendMu sync.Mutex
+	spend   map[usageTagSpendKey]usageTagSpendEntry
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewUsageTagService 创建标签服务
+func NewUsageTagService(repo UsageTagRepository, userRepo UserRepository, timingWheel *TimingWheelService) *UsageTagService {
+	return &UsageTagService{
+		repo:        repo,
+		userRepo:    userRepo,
+		timingWheel: timingWheel,
+		keys:        make(map[string]*UsageTagKey),
+		budgets:     make(map[int64][]UsageTagBudget),
+		spend:       make(map[usageTagSpendKey]usageTagSpendEntry),
+	}
+}
+
+// Start 加载标签键与预算并启动周期同步
+func (s *UsageTagService) Start() {
+	if s == nil || s.repo == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.reloadLogged()
+		if s.timingWheel != nil {
+			s.timingWheel.ScheduleRecurring(usageTagReloadWorkerName, usageTagReloadInterval, s.reloadLogged)
+		}
+	})
+}
+
+// Stop 停止周期同步
+func (s *UsageTagService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(usageTagReloadWorkerName)
+		}
+	})
+}
+
+func (s *UsageTagService) reloadLogged() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.Reload(ctx); err != nil {
+		log.Printf("[UsageTag] reload failed: %v", err)
+	}
+}
+
+// Reload 从数据库重新加载标签键与预算
+func (s *UsageTagService) Reload(ctx context.Context) error {
+	keys, err := s.repo.ListKeys(ctx)
+	if err != nil {
+		return err
+	}
+	budgets, err := s.repo.ListBudgets(ctx, nil)
+	if err != nil {
+		return err
+	}
+	keyMap := make(map[string]*UsageTagKey, len(keys))
+	for i := range keys {
+		keyMap[keys[i].Key] = &keys[i]
+	}
+	budgetMap := make(map[int64][]UsageTagBudget)
+	for _, b := range budgets {
+		budgetMap[b.UserID] = append(budgetMap[b.UserID], b)
+	}
+	s.mu.Lock()
+	s.keys = keyMap
+	s.budgets = budgetMap
+	s.mu.Unlock()
+	return nil
+}
+
+// Resolve 合并请求头与 metadata 标签（同名以请求头为准），校验标签键/值并检查用户的标签预算。
+// 无标签时返回 nil；s 为 nil 时忽略所有标签。
+func (s *UsageTagService) Resolve(ctx context.Context, apiKey *APIKey, header string, metadataTags map[string]string) (map[string]string, error) {
+	if s == nil {
+		return nil, nil
+	}
+	headerTags, err := ParseUsageTagHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	tags := mergeUsageTags(headerTags, metadataTags)
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	s.mu.RLock()
+	keys := s.keys
+	var budgets []UsageTagBudget
+	if apiKey != nil {
+		budgets = s.budgets[apiKey.UserID]
+	}
+	s.mu.RUnlock()
+
+	if err := validateUsageTags(tags, keys); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range budgets {
+		b := &budgets[i]
+		if tags[b.TagKey] != b.TagValue {
+			continue
+		}
+		spend, err := s.monthlySpend(ctx, b.UserID, b.TagKey, b.TagValue, now)
+		if err != nil {
+			// 预算查询失败时放行，与余额缓存不可用时的降级策略保持一致
+			log.Printf("[UsageTag] monthly spend lookup failed: user=%d tag=%s=%s err=%v", b.UserID, b.TagKey, b.TagValue, err)
+			continue
+		}
+		if spend >= b.MonthlyLimitUSD {
+			return nil, infraerrors.Forbidden(infraerrors.Reason(ErrUsageTagBudgetExceeded),
+				fmt.Sprintf("monthly budget for tag %s=%s exceeded ($%.2f / $%.2f)", b.TagKey, b.TagValue, spend, b.MonthlyLimitUSD))
+		}
+	}
+	return tags, nil
+}
+
+// monthlySpend 用户当月携带指定标签值的实际消费（带短期缓存）
+func (s *UsageTagService) monthlySpend(ctx context.Context, userID int64, key, value string, now time.Time) (float64, error) {
+	since := timezone.StartOfMonth(now)
+	cacheKey := usageTagSpendKey{userID: userID, key: key, value: value}
+
+	s.spendMu.Lock()
+	entry, ok := s.spend[cacheKey]
+	s.spendMu.Unlock()
+	if ok && entry.since.Equal(since) && now.Before(entry.expiresAt) {
+		return entry.amount, nil
+	}
+
+	amount, err := s.repo.SumMonthlySpendByTag(ctx, userID, key, value, since)
+	if err != nil {
+		return 0, err
+	}
+
+	s.spendMu.Lock()
+	if len(s.spend) >= usageTagSpendCacheMax {
+		for k, e := range s.spend {
+			if !now.Before(e.expiresAt) {
+				delete(s.spend, k)
+			}
+		}
+	}
+	s.spend[cacheKey] = usageTagSpendEntry{since: since, amount: amount, expiresAt: now.Add(usageTagSpendCacheTTL)}
+	s.spendMu.Unlock()
+	return amount, nil
+}
+
+// ListKeys 列出已定义的标签键
+func (s *UsageTagService) ListKeys(ctx context.Context) ([]UsageTagKey, error) {
+	return s.repo.ListKeys(ctx)
+}
+
+// CreateKey 创建标签键
+func (s *UsageTagService) CreateKey(ctx context.Context, input *SetUsageTagKeyInput) (*UsageTagKey, error) {
+	key := &UsageTagKey{
+		Key:           strings.TrimSpace(input.Key),
+		Description:   strings.TrimSpace(input.Description),
+		AllowedValues: normalizeUsageTagValues(input.AllowedValues),
+	}
+	if !key.Valid() {
+		return nil, ErrUsageTagKeyInvalid
+	}
+	if err := s.repo.CreateKey(ctx, key); err != nil {
+		return nil, err
+	}
+	s.reloadLogged()
+	return key, nil
+}
+
+// UpdateKey 更新标签键；修改键名时已有预算随之迁移，历史用量中的旧键名不变
+func (s *UsageTagService) UpdateKey(ctx context.Context, id int64, input *SetUsageTagKeyInput) (*UsageTagKey, error) {
+	key, err := s.repo.GetKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	key.Key = strings.TrimSpace(input.Key)
+	key.Description = strings.TrimSpace(input.Description)
+	key.AllowedValues = normalizeUsageTagValues(input.AllowedValues)
+	if !key.Valid() {
+		return nil, ErrUsageTagKeyInvalid
+	}
+	if err := s.repo.UpdateKey(ctx, key); err != nil {
+		return nil, err
+	}
+	s.reloadLogged()
+	return key, nil
+}
+
+// DeleteKey 删除标签键及其预算；历史用量中的标签保留
+func (s *UsageTagService) DeleteKey(ctx context.Context, id int64) error {
+	if err := s.repo.DeleteKey(ctx, id); err != nil {
+		return err
+	}
+	s.reloadLogged()
+	return nil
+}
+
+// ListBudgets 列出预算并填充当月消费；userID 为空表示所有用户
+func (s *UsageTagService) ListBudgets(ctx context.Context, userID *int64) ([]UsageTagBudget, error) {
+	budgets, err := s.repo.ListBudgets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range budgets {
+		b := &budgets[i]
+		spend, err := s.monthlySpend(ctx, b.UserID, b.TagKey, b.TagValue, now)
+		if err != nil {
+			return nil, err
+		}
+		b.MonthSpend = spend
+	}
+	return budgets, nil
+}
+
+// SetBudget 设置（新增或替换）用户的标签值预算
+func (s *UsageTagService) SetBudget(ctx context.Context, userID int64, input *SetUsageTagBudgetInput) (*UsageTagBudget, error) {
+	budget := &UsageTagBudget{
+		UserID:          userID,
+		TagKey:          strings.TrimSpace(input.TagKey),
+		TagValue:        strings.TrimSpace(input.TagValue),
+		MonthlyLimitUSD: input.MonthlyLimitUSD,
+	}
+	s.mu.RLock()
+	def := s.keys[budget.TagKey]
+	s.mu.RUnlock()
+	if def == nil || !usageTagValuePattern.MatchString(budget.TagValue) || !def.allows(budget.TagValue) || budget.MonthlyLimitUSD <= 0 {
+		return nil, ErrUsageTagBudgetInvalid
+	}
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpsertBudget(ctx, budget); err != nil {
+		return nil, err
+	}
+	s.reloadLogged()
+	return budget, nil
+}
+
+// DeleteBudget 删除预算；userID 非空时只允许删除该用户自己的预算
+func (s *UsageTagService) DeleteBudget(ctx context.Context, id int64, userID *int64) error {
+	if err := s.repo.DeleteBudget(ctx, id, userID); err != nil {
+		return err
+	}
+	s.reloadLogged()
+	return nil
+}
+
+// GetStats 按标签值聚合用量
+func (s *UsageTagService) GetStats(ctx context.Context, filters UsageTagStatsFilters) ([]UsageTagStat, error) {
+	filters.TagKey = strings.TrimSpace(filters.TagKey)
+	if !usageTagKeyPattern.MatchString(filters.TagKey) {
+		return nil, infraerrors.BadRequest(infraerrors.Reason(ErrUsageTagInvalid), "tag_key is required")
+	}
+	if !filters.EndTime.After(filters.StartTime) || filters.EndTime.Sub(filters.StartTime) > usageTagStatsMaxRange {
+		return nil, infraerrors.BadRequest(infraerrors.Reason(ErrUsageTagInvalid), "invalid time range (max 366 days)")
+	}
+	return s.repo.GetStats(ctx, filters)
+}
+
+// normalizeUsageTagValues 去除空白与重复的允许值
+func normalizeUsageTagValues(values []string) []string {
+	out := make([]string, 0, len(values))
+	seen := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	return out
+}
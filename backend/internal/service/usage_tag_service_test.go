@@ -0,0 +1,212 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+type usageTagRepoStub struct {
+	keys       []UsageTagKey
+	budgets    []UsageTagBudget
+	spend      map[string]float64
+	spendErr   error
+	spendCalls int
+	upserted   []UsageTagBudget
+}
+
+func (r *usageTagRepoStub) ListKeys(ctx context.Context) ([]UsageTagKey, error) {
+	return append([]UsageTagKey(nil), r.keys...), nil
+}
+
+func (r *usageTagRepoStub) GetKey(ctx context.Context, id int64) (*UsageTagKey, error) {
+	for i := range r.keys {
+		if r.keys[i].ID == id {
+			k := r.keys[i]
+			return &k, nil
+		}
+	}
+	return nil, ErrUsageTagKeyNotFound
+}
+
+func (r *usageTagRepoStub) CreateKey(ctx context.Context, key *UsageTagKey) error {
+	key.ID = int64(len(r.keys) + 1)
+	r.keys = append(r.keys, *key)
+	return nil
+}
+
+func (r *usageTagRepoStub) UpdateKey(ctx context.Context, key *UsageTagKey) error {
+	return nil
+}
+
+func (r *usageTagRepoStub) DeleteKey(ctx context.Context, id int64) error {
+	return ErrUsageTagKeyNotFound
+}
+
+func (r *usageTagRepoStub) ListBudgets(ctx context.Context, userID *int64) ([]UsageTagBudget, error) {
+	return append([]UsageTagBudget(nil), r.budgets...), nil
+}
+
+func (r *usageTagRepoStub) UpsertBudget(ctx context.Context, budget *UsageTagBudget) error {
+	budget.ID = int64(len(r.upserted) + 1)
+	r.upserted = append(r.upserted, *budget)
+	return nil
+}
+
+func (r *usageTagRepoStub) DeleteBudget(ctx context.Context, id int64, userID *int64) error {
+	return ErrUsageTagBudgetNotFound
+}
+
+func (r *usageTagRepoStub) SumMonthlySpendByTag(ctx context.Context, userID int64, tagKey, tagValue string, since time.Time) (float64, error) {
+	r.spendCalls++
+	return r.spend[tagKey+"="+tagValue], r.spendErr
+}
+
+func (r *usageTagRepoStub) GetStats(ctx context.Context, filters UsageTagStatsFilters) ([]UsageTagStat, error) {
+	return nil, nil
+}
+
+func newUsageTagTestService(t *testing.T, repo *usageTagRepoStub) *UsageTagService {
+	t.Helper()
+	svc := NewUsageTagService(repo, &userRepoStub{user: &User{ID: 7}}, nil)
+	require.NoError(t, svc.Reload(context.Background()))
+	return svc
+}
+
+func TestParseUsageTagHeader(t *testing.T) {
+	tags, err := ParseUsageTagHeader(" project=search, env = prod ,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"project": "search", "env": "prod"}, tags)
+
+	tags, err = ParseUsageTagHeader("")
+	require.NoError(t, err)
+	require.Nil(t, tags)
+
+	for _, raw := range []string{"project", "project=", "=search", "project=a,project=b"} {
+		_, err := ParseUsageTagHeader(raw)
+		require.ErrorIs(t, err, ErrUsageTagInvalid, raw)
+	}
+}
+
+func TestExtractMetadataTags(t *testing.T) {
+	body := []byte(`{"model":"claude","metadata":{"user_id":"u1","tags":{"project":"search"}}}`)
+	tags, stripped, err := ExtractMetadataTags(body)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"project": "search"}, tags)
+	require.False(t, gjson.GetBytes(stripped, "metadata.tags").Exists())
+	require.Equal(t, "u1", gjson.GetBytes(stripped, "metadata.user_id").String())
+
+	tags, stripped, err = ExtractMetadataTags([]byte(`{"metadata":{"tags":"project=ads,env=dev"}}`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"project": "ads", "env": "dev"}, tags)
+	require.JSONEq(t, `{"metadata":{}}`, string(stripped))
+
+	body = []byte(`{"model":"claude"}`)
+	tags, stripped, err = ExtractMetadataTags(body)
+	require.NoError(t, err)
+	require.Nil(t, tags)
+	require.Equal(t, body, stripped)
+
+	_, _, err = ExtractMetadataTags([]byte(`{"metadata":{"tags":{"project":1}}}`))
+	require.ErrorIs(t, err, ErrUsageTagInvalid)
+	_, _, err = ExtractMetadataTags([]byte(`{"metadata":{"tags":["project"]}}`))
+	require.ErrorIs(t, err, ErrUsageTagInvalid)
+}
+
+func TestUsageTagResolveValidatesAgainstDefinedKeys(t *testing.T) {
+	repo := &usageTagRepoStub{keys: []UsageTagKey{
+		{ID: 1, Key: "project"},
+		{ID: 2, Key: "env", AllowedValues: []string{"prod", "dev"}},
+	}}
+	svc := newUsageTagTestService(t, repo)
+	ctx := context.Background()
+	apiKey := &APIKey{ID: 70, UserID: 7}
+
+	tags, err := svc.Resolve(ctx, apiKey, "project=search", map[string]string{"project": "ads", "env": "prod"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"project": "search", "env": "prod"}, tags, "header wins over metadata")
+
+	tags, err = svc.Resolve(ctx, apiKey, "", nil)
+	require.NoError(t, err)
+	require.Nil(t, tags)
+
+	_, err = svc.Resolve(ctx, apiKey, "team=core", nil)
+	require.ErrorIs(t, err, ErrUsageTagInvalid)
+	require.Equal(t, http.StatusBadRequest, infraerrors.Code(err))
+	require.Contains(t, infraerrors.Message(err), `unknown tag key "team"`)
+
+	_, err = svc.Resolve(ctx, apiKey, "env=staging", nil)
+	require.ErrorIs(t, err, ErrUsageTagInvalid)
+
+	_, err = svc.Resolve(ctx, apiKey, "project=has space", nil)
+	require.ErrorIs(t, err, ErrUsageTagInvalid)
+
+	var nilSvc *UsageTagService
+	tags, err = nilSvc.Resolve(ctx, apiKey, "team=core", nil)
+	require.NoError(t, err)
+	require.Nil(t, tags)
+}
+
+func TestUsageTagResolveEnforcesBudgets(t *testing.T) {
+	repo := &usageTagRepoStub{
+		keys: []UsageTagKey{{ID: 1, Key: "project"}},
+		budgets: []UsageTagBudget{
+			{ID: 1, UserID: 7, TagKey: "project", TagValue: "search", MonthlyLimitUSD: 10},
+			{ID: 2, UserID: 7, TagKey: "project", TagValue: "ads", MonthlyLimitUSD: 10},
+		},
+		spend: map[string]float64{"project=search": 10, "project=ads": 2},
+	}
+	svc := newUsageTagTestService(t, repo)
+	ctx := context.Background()
+
+	_, err := svc.Resolve(ctx, &APIKey{ID: 70, UserID: 7}, "project=search", nil)
+	require.ErrorIs(t, err, ErrUsageTagBudgetExceeded)
+	require.Equal(t, http.StatusForbidden, infraerrors.Code(err))
+
+	_, err = svc.Resolve(ctx, &APIKey{ID: 70, UserID: 7}, "project=ads", nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, repo.spendCalls)
+
+	_, err = svc.Resolve(ctx, &APIKey{ID: 70, UserID: 7}, "project=search", nil)
+	require.ErrorIs(t, err, ErrUsageTagBudgetExceeded)
+	require.Equal(t, 2, repo.spendCalls, "tag spend is cached")
+
+	_, err = svc.Resolve(ctx, &APIKey{ID: 80, UserID: 8}, "project=search", nil)
+	require.NoError(t, err, "budgets are per user")
+	require.Equal(t, 2, repo.spendCalls)
+
+	repo.spendErr = errors.New("db down")
+	svc = newUsageTagTestService(t, repo)
+	_, err = svc.Resolve(ctx, &APIKey{ID: 70, UserID: 7}, "project=search", nil)
+	require.NoError(t, err, "spend lookup errors fail open")
+}
+
+func TestUsageTagSetBudgetRequiresDefinedKey(t *testing.T) {
+	repo := &usageTagRepoStub{keys: []UsageTagKey{{ID: 1, Key: "env", AllowedValues: []string{"prod"}}}}
+	svc := newUsageTagTestService(t, repo)
+	ctx := context.Background()
+
+	_, err := svc.SetBudget(ctx, 7, &SetUsageTagBudgetInput{TagKey: "project", TagValue: "search", MonthlyLimitUSD: 5})
+	require.ErrorIs(t, err, ErrUsageTagBudgetInvalid)
+	_, err = svc.SetBudget(ctx, 7, &SetUsageTagBudgetInput{TagKey: "env", TagValue: "dev", MonthlyLimitUSD: 5})
+	require.ErrorIs(t, err, ErrUsageTagBudgetInvalid)
+
+	budget, err := svc.SetBudget(ctx, 7, &SetUsageTagBudgetInput{TagKey: "env", TagValue: "prod", MonthlyLimitUSD: 5})
+	require.NoError(t, err)
+	require.EqualValues(t, 7, budget.UserID)
+	require.Len(t, repo.upserted, 1)
+
+	_, err = svc.CreateKey(ctx, &SetUsageTagKeyInput{Key: "Project"})
+	require.ErrorIs(t, err, ErrUsageTagKeyInvalid)
+	key, err := svc.CreateKey(ctx, &SetUsageTagKeyInput{Key: "team", AllowedValues: []string{" core ", "core", ""}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"core"}, key.AllowedValues)
+}
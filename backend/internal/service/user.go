@@ -17,7 +17,8 @@ type User struct {
 	Concurrency   int
 	Status        string
 	AllowedGroups []int64
-	TokenVersion  int64 // Incremented on password change to invalidate existing tokens
+	TokenVersion  int64  // Incremented on password change to invalidate existing tokens
+	ParentUserID  *int64 // 所属分销商（分销下级用户）
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 
@@ -33,6 +34,11 @@ func (u *User) IsActive() bool {
 	return u.Status == StatusActive
 }
 
+// IsOrganizationAccount 是否为组织付费账户
+func (u *User) IsOrganizationAccount() bool {
+	return u.Role == RoleOrganization
+}
+
 // CanBindGroup checks whether a user can bind to a given group.
 // For standard groups:
 // - If AllowedGroups is non-empty, only allow binding to IDs in that list.
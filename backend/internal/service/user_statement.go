@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 账单格式
+const (
+	UserStatementFormatPDF  = "pdf"
+	UserStatementFormatHTML = "html"
+)
+
+// 账单余额入账来源
+const (
+	UserStatementCreditRedeem     = "redeem"     // 余额兑换码
+	UserStatementCreditPromo      = "promo"      // 注册优惠码赠送
+	UserStatementCreditPayment    = "payment"    // 在线充值
+	UserStatementCreditAdjustment = "adjustment" // 管理员调整
+)
+
+// 账单订阅扣费来源
+const (
+	UserStatementChargePlan    = "plan"    // 余额购买/续费套餐
+	UserStatementChargePayment = "payment" // 在线支付购买订阅
+)
+
+// UserStatementRecipient 账单所属用户（生成时的快照）
+type UserStatementRecipient struct {
+	UserID   int64
+	Email    string
+	Username string
+}
+
+// UserStatementUsageLine 按 分组/模型/API Key 汇总的用量明细
+type UserStatementUsageLine struct {
+	GroupID             int64   `json:"group_id"`
+	GroupName           string  `json:"group_name"`
+	Model               string  `json:"model"`
+	APIKeyID            int64   `json:"api_key_id"`
+	APIKeyName          string  `json:"api_key_name"`
+	Requests            int64   `json:"requests"`
+	InputTokens         int64   `json:"input_tokens"`
+	OutputTokens        int64   `json:"output_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	CacheReadTokens     int64   `json:"cache_read_tokens"`
+	TotalCost           float64 `json:"total_cost"`
+	ActualCost          float64 `json:"actual_cost"`
+}
+
+// Tokens 返回该行的总 token 数
+func (l *UserStatementUsageLine) Tokens() int64 {
+	return l.InputTokens + l.OutputTokens + l.CacheCreationTokens + l.CacheReadTokens
+}
+
+// UserStatementSubtotal 单一维度（分组/模型/API Key）小计
+type UserStatementSubtotal struct {
+	Name       string  `json:"name"`
+	Requests   int64   `json:"requests"`
+	Tokens     int64   `json:"tokens"`
+	ActualCost float64 `json:"actual_cost"`
+}
+
+// UserStatementCredit 账期内的余额入账
+type UserStatementCredit struct {
+	Source     string    `json:"source"`
+	Reference  string    `json:"reference"`
+	Amount     float64   `json:"amount"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// UserStatementCharge 账期内的订阅扣费；在线支付按订单货币计价
+type UserStatementCharge struct {
+	Source      string    `json:"source"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"`
+	Currency    string    `json:"currency"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+// UserStatementSummary 账单内容快照（以 JSON 存储，生成后不再变化）
+type UserStatementSummary struct {
+	SiteName    string    `json:"site_name"`
+	UserEmail   string    `json:"user_email"`
+	Username    string    `json:"username"`
+	Period      string    `json:"period"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Timezone    string    `json:"timezone"`
+	GeneratedAt time.Time `json:"generated_at"`
+
+	TotalRequests int64   `json:"total_requests"`
+	TotalTokens   int64   `json:"total_tokens"`
+	StandardCost  float64 `json:"standard_cost"`
+	UsageCost     float64 `json:"usage_cost"`
+
+	Usage    []UserStatementUsageLine `json:"usage"`
+	ByGroup  []UserStatementSubtotal  `json:"by_group"`
+	ByModel  []UserStatementSubtotal  `json:"by_model"`
+	ByAPIKey []UserStatementSubtotal  `json:"by_api_key"`
+
+	Credits      []UserStatementCredit `json:"credits"`
+	CreditsTotal float64               `json:"credits_total"`
+
+	SubscriptionCharges []UserStatementCharge `json:"subscription_charges"`
+	// SubscriptionChargesTotal 仅合计 USD 计价的扣费
+	SubscriptionChargesTotal float64 `json:"subscription_charges_total"`
+}
+
+// UserStatement 不可变的月度账单记录
+type UserStatement struct {
+	ID                       int64
+	UserID                   int64
+	Period                   string
+	PeriodStart              time.Time
+	PeriodEnd                time.Time
+	Currency                 string
+	TotalRequests            int64
+	TotalTokens              int64
+	UsageCost                float64
+	CreditsTotal             float64
+	SubscriptionChargesTotal float64
+	Summary                  UserStatementSummary
+	HTML                     string
+	PDF                      []byte
+	EmailedAt                *time.Time
+	CreatedAt                time.Time
+
+	// Recipient 仅在领取待投递邮件时填充
+	Recipient *UserStatementRecipient
+}
+
+// UserStatementRepository 月度账单仓储
+type UserStatementRepository interface {
+	// ListCandidates 按 user_id 游标列出账期内有用量或余额/订阅变动、且尚未生成该账期账单的用户
+	ListCandidates(ctx context.Context, period string, start, end time.Time, afterUserID int64, limit int) ([]UserStatementRecipient, error)
+	// ListUsageLines 从 usage_dashboard_hourly_user_breakdown 汇总用户账期内的用量
+	ListUsageLines(ctx context.Context, userID int64, start, end time.Time) ([]UserStatementUsageLine, error)
+	ListCredits(ctx context.Context, userID int64, start, end time.Time) ([]UserStatementCredit, error)
+	ListSubscriptionCharges(ctx context.Context, userID int64, start, end time.Time) ([]UserStatementCharge, error)
+
+	// Create 写入账单；(user_id, period) 已存在时返回 false 且不修改已有记录
+	Create(ctx context.Context, statement *UserStatement) (bool, error)
+	// ListByUser 分页列出用户账单（不含 html/pdf 内容）
+	ListByUser(ctx context.Context, userID int64, params pagination.PaginationParams) ([]UserStatement, *pagination.PaginationResult, error)
+	GetByID(ctx context.Context, id int64) (*UserStatement, error)
+
+	// ClaimPendingEmails 领取待投递的账单（尝试次数 +1），同一账单在 claimTTL 内不会被重复领取；
+	// 跳过无邮箱或在通知偏好中关闭邮件的用户
+	ClaimPendingEmails(ctx context.Context, limit, maxAttempts int, claimTTL time.Duration) ([]UserStatement, error)
+	MarkEmailed(ctx context.Context, id int64) error
+}
@@ -0,0 +1,535 @@
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	_ "image/jpeg" // 注册 Logo 解码器
+	_ "image/png"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pdf"
+)
+
+// userStatementBranding 账单使用的站点品牌信息
+type userStatementBranding struct {
+	SiteName string
+	// Logo 站点 Logo 设置值：data URL 或 http(s) 地址
+	Logo string
+	// Font PDF 使用的 TrueType 字体；为空时使用内置 Helvetica（仅支持拉丁字符）
+	Font *pdf.TrueTypeFont
+}
+
+// buildUserStatementSummary 汇总用量明细与余额/订阅变动，生成账单快照
+func buildUserStatementSummary(recipient UserStatementRecipient, period string, start, end time.Time, usage []UserStatementUsageLine, credits []UserStatementCredit, charges []UserStatementCharge) UserStatementSummary {
+	sort.SliceStable(usage, func(i, j int) bool {
+		if usage[i].ActualCost != usage[j].ActualCost {
+			return usage[i].ActualCost > usage[j].ActualCost
+		}
+		return usage[i].Requests > usage[j].Requests
+	})
+
+	summary := UserStatementSummary{
+		UserEmail:           recipient.Email,
+		Username:            recipient.Username,
+		Period:              period,
+		PeriodStart:         start,
+		PeriodEnd:           end,
+		Timezone:            start.Location().String(),
+		Usage:               usage,
+		Credits:             credits,
+		SubscriptionCharges: charges,
+	}
+
+	byGroup := newStatementSubtotals()
+	byModel := newStatementSubtotals()
+	byKey := newStatementSubtotals()
+	for i := range usage {
+		l := &usage[i]
+		summary.TotalRequests += l.Requests
+		summary.TotalTokens += l.Tokens()
+		summary.StandardCost += l.TotalCost
+		summary.UsageCost += l.ActualCost
+		byGroup.add(userStatementGroupLabel(l), l)
+		byModel.add(l.Model, l)
+		byKey.add(userStatementAPIKeyLabel(l), l)
+	}
+	summary.ByGroup = byGroup.sorted()
+	summary.ByModel = byModel.sorted()
+	summary.ByAPIKey = byKey.sorted()
+
+	for _, c := range credits {
+		summary.CreditsTotal += c.Amount
+	}
+	for _, c := range charges {
+		if strings.EqualFold(c.Currency, "USD") {
+			summary.SubscriptionChargesTotal += c.Amount
+		}
+	}
+	return summary
+}
+
+type statementSubtotals struct {
+	order []string
+	items map[string]*UserStatementSubtotal
+}
+
+func newStatementSubtotals() *statementSubtotals {
+	return &statementSubtotals{items: map[string]*UserStatementSubtotal{}}
+}
+
+func (s *statementSubtotals) add(name string, l *UserStatementUsageLine) {
+	item, ok := s.items[name]
+	if !ok {
+		item = &UserStatementSubtotal{Name: name}
+		s.items[name] = item
+		s.order = append(s.order, name)
+	}
+	item.Requests += l.Requests
+	item.Tokens += l.Tokens()
+	item.ActualCost += l.ActualCost
+}
+
+func (s *statementSubtotals) sorted() []UserStatementSubtotal {
+	out := make([]UserStatementSubtotal, 0, len(s.order))
+	for _, name := range s.order {
+		out = append(out, *s.items[name])
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].ActualCost > out[j].ActualCost })
+	return out
+}
+
+func userStatementGroupLabel(l *UserStatementUsageLine) string {
+	if l.GroupID == 0 {
+		return "(no group)"
+	}
+	if l.GroupName == "" {
+		return "Group #" + strconv.FormatInt(l.GroupID, 10)
+	}
+	return l.GroupName
+}
+
+func userStatementAPIKeyLabel(l *UserStatementUsageLine) string {
+	if l.APIKeyName == "" {
+		return "Key #" + strconv.FormatInt(l.APIKeyID, 10)
+	}
+	return l.APIKeyName
+}
+
+func formatStatementUSD(v float64) string {
+	return "$" + strconv.FormatFloat(v, 'f', 4, 64)
+}
+
+func formatStatementAmount(v float64, currency string) string {
+	if currency == "" || strings.EqualFold(currency, "USD") {
+		return formatStatementUSD(v)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64) + " " + strings.ToUpper(currency)
+}
+
+// formatStatementInt 输出带千分位的整数
+func formatStatementInt(v int64) string {
+	s := strconv.FormatInt(v, 10)
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	if neg {
+		return "-" + b.String()
+	}
+	return b.String()
+}
+
+func userStatementSourceLabel(source string) string {
+	switch source {
+	case UserStatementCreditRedeem:
+		return "Redeem code"
+	case UserStatementCreditPromo:
+		return "Promo code"
+	case UserStatementCreditPayment:
+		return "Top-up"
+	case UserStatementCreditAdjustment:
+		return "Adjustment"
+	case UserStatementChargePlan:
+		return "Plan (balance)"
+	default:
+		return "Online payment"
+	}
+}
+
+var userStatementHTMLTemplate = template.Must(template.New("statement").Funcs(template.FuncMap{
+	"usd":    formatStatementUSD,
+	"amount": formatStatementAmount,
+	"int":    formatStatementInt,
+	"source": userStatementSourceLabel,
+	"group":  func(l UserStatementUsageLine) string { return userStatementGroupLabel(&l) },
+	"apikey": func(l UserStatementUsageLine) string { return userStatementAPIKeyLabel(&l) },
+	"date":   func(t time.Time) string { return t.Format("2006-01-02") },
+	"tokens": func(l UserStatementUsageLine) int64 { return l.Tokens() },
+	"last":   func(t time.Time) string { return t.Add(-time.Second).Format("2006-01-02") },
+	"pair": func(title string, items []UserStatementSubtotal) userStatementSubtotalSection {
+		return userStatementSubtotalSection{Title: title, Items: items}
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<title>{{.S.SiteName}} statement {{.S.Period}}</title>
+<style>
+body { font-family: Arial, Helvetica, sans-serif; color: #333; max-width: 860px; margin: 24px auto; }
+header { display: flex; align-items: center; gap: 16px; border-bottom: 2px solid #333; padding-bottom: 12px; }
+header img { max-height: 48px; max-width: 160px; }
+h1 { margin: 0; font-size: 22px; }
+h2 { font-size: 16px; margin-top: 28px; }
+table { width: 100%; border-collapse: collapse; font-size: 13px; }
+th, td { padding: 6px 8px; border-bottom: 1px solid #e5e5e5; text-align: left; }
+th { background: #f3f3f3; }
+td.num, th.num { text-align: right; }
+.meta { color: #666; font-size: 13px; }
+.empty { color: #999; font-size: 13px; }
+</style>
+</head>
+<body>
+<header>
+{{if .Logo}}<img src="{{.Logo}}" alt="{{.S.SiteName}}">{{end}}
+<div>
+<h1>{{.S.SiteName}}</h1>
+<div class="meta">Monthly statement · {{.S.Period}} ({{date .S.PeriodStart}} – {{last .S.PeriodEnd}}, {{.S.Timezone}})</div>
+</div>
+</header>
+<p class="meta">Account: {{.S.UserEmail}}{{if .S.Username}} ({{.S.Username}}){{end}}<br>Generated: {{.S.GeneratedAt.Format "2006-01-02 15:04 MST"}}</p>
+
+<h2>Summary</h2>
+<table>
+<tr><td>Requests</td><td class="num">{{int .S.TotalRequests}}</td></tr>
+<tr><td>Tokens</td><td class="num">{{int .S.TotalTokens}}</td></tr>
+<tr><td>Standard cost</td><td class="num">{{usd .S.StandardCost}}</td></tr>
+<tr><td><strong>Usage charges</strong></td><td class="num"><strong>{{usd .S.UsageCost}}</strong></td></tr>
+<tr><td>Balance credits</td><td class="num">{{usd .S.CreditsTotal}}</td></tr>
+<tr><td>Subscription charges (USD)</td><td class="num">{{usd .S.SubscriptionChargesTotal}}</td></tr>
+</table>
+{{template "subtotals" (pair "Usage by group" .S.ByGroup)}}
+{{template "subtotals" (pair "Usage by model" .S.ByModel)}}
+{{template "subtotals" (pair "Usage by API key" .S.ByAPIKey)}}
+
+<h2>Usage details</h2>
+{{if .S.Usage}}<table>
+<tr><th>Group</th><th>Model</th><th>API key</th><th class="num">Requests</th><th class="num">Tokens</th><th class="num">Cost</th></tr>
+{{range .S.Usage}}<tr><td>{{group .}}</td><td>{{.Model}}</td><td>{{apikey .}}</td><td class="num">{{int .Requests}}</td><td class="num">{{int (tokens .)}}</td><td class="num">{{usd .ActualCost}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">No usage in this period.</p>{{end}}
+
+<h2>Balance credits</h2>
+{{if .S.Credits}}<table>
+<tr><th>Date</th><th>Source</th><th>Reference</th><th class="num">Amount</th></tr>
+{{range .S.Credits}}<tr><td>{{date .OccurredAt}}</td><td>{{source .Source}}</td><td>{{.Reference}}</td><td class="num">{{usd .Amount}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">No balance credits in this period.</p>{{end}}
+
+<h2>Subscription charges</h2>
+{{if .S.SubscriptionCharges}}<table>
+<tr><th>Date</th><th>Source</th><th>Description</th><th class="num">Amount</th></tr>
+{{range .S.SubscriptionCharges}}<tr><td>{{date .OccurredAt}}</td><td>{{source .Source}}</td><td>{{.Description}}</td><td class="num">{{amount .Amount .Currency}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">No subscription charges in this period.</p>{{end}}
+</body>
+</html>
+{{define "subtotals"}}
+<h2>{{.Title}}</h2>
+{{if .Items}}<table>
+<tr><th>Name</th><th class="num">Requests</th><th class="num">Tokens</th><th class="num">Cost</th></tr>
+{{range .Items}}<tr><td>{{.Name}}</td><td class="num">{{int .Requests}}</td><td class="num">{{int .Tokens}}</td><td class="num">{{usd .ActualCost}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">No usage in this period.</p>{{end}}
+{{end}}`))
+
+type userStatementSubtotalSection struct {
+	Title string
+	Items []UserStatementSubtotal
+}
+
+// renderUserStatementHTML 渲染账单 HTML；Logo 仅接受 data:image/ 与 http(s) 地址
+func renderUserStatementHTML(summary *UserStatementSummary, branding userStatementBranding) (string, error) {
+	var logo template.URL
+	if l := strings.TrimSpace(branding.Logo); strings.HasPrefix(l, "data:image/") || strings.HasPrefix(l, "https://") || strings.HasPrefix(l, "http://") {
+		logo = template.URL(l)
+	}
+	var buf bytes.Buffer
+	if err := userStatementHTMLTemplate.Execute(&buf, struct {
+		S    *UserStatementSummary
+		Logo template.URL
+	}{S: summary, Logo: logo}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// decodeStatementLogo 解码 data URL 形式的 PNG/JPEG Logo；远程地址不在生成 PDF 时拉取
+func decodeStatementLogo(logo string) image.Image {
+	logo = strings.TrimSpace(logo)
+	if !strings.HasPrefix(logo, "data:image/") {
+		return nil
+	}
+	comma := strings.IndexByte(logo, ',')
+	if comma < 0 || !strings.HasSuffix(logo[:comma], ";base64") {
+		return nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(logo[comma+1:])
+	if err != nil {
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil
+	}
+	return img
+}
+
+// PDF 版式常量（pt）
+const (
+	statementPDFMargin     = 40.0
+	statementPDFRowHeight  = 16.0
+	statementPDFFontSize   = 9.0
+	statementPDFFooterSize = 8.0
+)
+
+// statementPDFColumn 表格列：width 为列宽，right 表示右对齐
+type statementPDFColumn struct {
+	title string
+	width float64
+	right bool
+}
+
+// statementPDFLayout 以游标方式自上而下排版，空间不足时自动换页
+type statementPDFLayout struct {
+	doc    *pdf.Document
+	page   *pdf.Page
+	font   pdf.Font
+	bold   pdf.Font
+	y      float64
+	width  float64
+	height float64
+}
+
+func newStatementPDFLayout(doc *pdf.Document, font, bold pdf.Font) *statementPDFLayout {
+	w, h := doc.PageSize()
+	l := &statementPDFLayout{doc: doc, font: font, bold: bold, width: w, height: h}
+	l.newPage()
+	return l
+}
+
+func (l *statementPDFLayout) newPage() {
+	l.page = l.doc.AddPage()
+	l.y = statementPDFMargin
+}
+
+func (l *statementPDFLayout) ensure(space float64) bool {
+	if l.y+space > l.height-statementPDFMargin {
+		l.newPage()
+		return true
+	}
+	return false
+}
+
+func (l *statementPDFLayout) heading(text string) {
+	l.ensure(statementPDFRowHeight * 3)
+	l.y += 22
+	l.page.Text(statementPDFMargin, l.y, l.bold, 12, text)
+	l.y += 8
+}
+
+func (l *statementPDFLayout) note(text string) {
+	l.ensure(statementPDFRowHeight)
+	l.y += statementPDFRowHeight
+	l.page.Text(statementPDFMargin, l.y, l.font, statementPDFFontSize, text)
+}
+
+func (l *statementPDFLayout) tableHeader(cols []statementPDFColumn) {
+	l.page.FillRect(statementPDFMargin, l.y+4, l.width-2*statementPDFMargin, statementPDFRowHeight, 0.93)
+	l.y += statementPDFRowHeight
+	l.row(cols, nil, l.bold)
+}
+
+func (l *statementPDFLayout) table(cols []statementPDFColumn, rows [][]string) {
+	l.ensure(statementPDFRowHeight * 2)
+	l.tableHeader(cols)
+	for _, r := range rows {
+		if l.ensure(statementPDFRowHeight) {
+			l.tableHeader(cols)
+		}
+		l.y += statementPDFRowHeight
+		l.row(cols, r, l.font)
+		l.page.Line(statementPDFMargin, l.y+4, l.width-statementPDFMargin, l.y+4, 0.3)
+	}
+}
+
+// row 在当前游标位置输出一行；cells 为 nil 时输出列标题
+func (l *statementPDFLayout) row(cols []statementPDFColumn, cells []string, font pdf.Font) {
+	x := statementPDFMargin
+	for i, col := range cols {
+		text := col.title
+		if cells != nil {
+			text = cells[i]
+		}
+		text = truncateStatementText(text, font, statementPDFFontSize, col.width-8)
+		if col.right {
+			l.page.TextRight(x+col.width-4, l.y, font, statementPDFFontSize, text)
+		} else {
+			l.page.Text(x+4, l.y, font, statementPDFFontSize, text)
+		}
+		x += col.width
+	}
+}
+
+func truncateStatementText(s string, font pdf.Font, size, maxWidth float64) string {
+	if font.TextWidth(s, size) <= maxWidth {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := string(runes) + "..."
+		if font.TextWidth(candidate, size) <= maxWidth {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// renderUserStatementPDF 渲染账单 PDF：配置了 TrueType 字体时嵌入该字体，支持中日韩等非拉丁字符；
+// 否则使用内置字体，仅支持拉丁字符，其余字符显示为 '?'
+func renderUserStatementPDF(summary *UserStatementSummary, branding userStatementBranding) ([]byte, error) {
+	font, bold := pdf.Helvetica, pdf.HelveticaBold
+	if branding.Font != nil {
+		font, bold = pdf.TrueType(branding.Font, false), pdf.TrueType(branding.Font, true)
+	}
+	doc := pdf.New()
+	doc.Title = fmt.Sprintf("%s statement %s", summary.SiteName, summary.Period)
+	l := newStatementPDFLayout(doc, font, bold)
+	right := l.width - statementPDFMargin
+
+	textX := statementPDFMargin
+	if img := decodeStatementLogo(branding.Logo); img != nil {
+		if im, err := doc.AddImage(img); err == nil {
+			w, h := im.Size()
+			drawH := 40.0
+			drawW := drawH * float64(w) / float64(h)
+			if drawW > 120 {
+				drawW = 120
+				drawH = drawW * float64(h) / float64(w)
+			}
+			l.page.DrawImage(im, statementPDFMargin, l.y, drawW, drawH)
+			textX += drawW + 12
+		}
+	}
+	l.page.Text(textX, l.y+18, bold, 18, summary.SiteName)
+	l.page.Text(textX, l.y+34, font, 10, fmt.Sprintf("Monthly statement - %s (%s to %s, %s)",
+		summary.Period, summary.PeriodStart.Format("2006-01-02"), summary.PeriodEnd.Add(-time.Second).Format("2006-01-02"), summary.Timezone))
+	l.y += 48
+	l.page.Line(statementPDFMargin, l.y, right, l.y, 1)
+	account := summary.UserEmail
+	if summary.Username != "" {
+		account += " (" + summary.Username + ")"
+	}
+	l.y += 16
+	l.page.Text(statementPDFMargin, l.y, font, statementPDFFontSize, "Account: "+account)
+	l.page.TextRight(right, l.y, font, statementPDFFontSize, "Generated: "+summary.GeneratedAt.Format("2006-01-02 15:04 MST"))
+
+	contentWidth := right - statementPDFMargin
+	l.heading("Summary")
+	l.table([]statementPDFColumn{{title: "Item", width: contentWidth - 140}, {title: "Value", width: 140, right: true}}, [][]string{
+		{"Requests", formatStatementInt(summary.TotalRequests)},
+		{"Tokens", formatStatementInt(summary.TotalTokens)},
+		{"Standard cost", formatStatementUSD(summary.StandardCost)},
+		{"Usage charges", formatStatementUSD(summary.UsageCost)},
+		{"Balance credits", formatStatementUSD(summary.CreditsTotal)},
+		{"Subscription charges (USD)", formatStatementUSD(summary.SubscriptionChargesTotal)},
+	})
+
+	subtotalCols := []statementPDFColumn{
+		{title: "Name", width: contentWidth - 270},
+		{title: "Requests", width: 80, right: true},
+		{title: "Tokens", width: 100, right: true},
+		{title: "Cost", width: 90, right: true},
+	}
+	for _, section := range []userStatementSubtotalSection{
+		{Title: "Usage by group", Items: summary.ByGroup},
+		{Title: "Usage by model", Items: summary.ByModel},
+		{Title: "Usage by API key", Items: summary.ByAPIKey},
+	} {
+		l.heading(section.Title)
+		if len(section.Items) == 0 {
+			l.note("No usage in this period.")
+			continue
+		}
+		rows := make([][]string, 0, len(section.Items))
+		for _, it := range section.Items {
+			rows = append(rows, []string{it.Name, formatStatementInt(it.Requests), formatStatementInt(it.Tokens), formatStatementUSD(it.ActualCost)})
+		}
+		l.table(subtotalCols, rows)
+	}
+
+	l.heading("Usage details")
+	if len(summary.Usage) == 0 {
+		l.note("No usage in this period.")
+	} else {
+		rows := make([][]string, 0, len(summary.Usage))
+		for i := range summary.Usage {
+			u := &summary.Usage[i]
+			rows = append(rows, []string{userStatementGroupLabel(u), u.Model, userStatementAPIKeyLabel(u),
+				formatStatementInt(u.Requests), formatStatementInt(u.Tokens()), formatStatementUSD(u.ActualCost)})
+		}
+		l.table([]statementPDFColumn{
+			{title: "Group", width: 100},
+			{title: "Model", width: contentWidth - 410},
+			{title: "API key", width: 100},
+			{title: "Requests", width: 60, right: true},
+			{title: "Tokens", width: 80, right: true},
+			{title: "Cost", width: 70, right: true},
+		}, rows)
+	}
+
+	ledgerCols := func(label string) []statementPDFColumn {
+		return []statementPDFColumn{
+			{title: "Date", width: 70},
+			{title: "Source", width: 90},
+			{title: label, width: contentWidth - 260},
+			{title: "Amount", width: 100, right: true},
+		}
+	}
+	l.heading("Balance credits")
+	if len(summary.Credits) == 0 {
+		l.note("No balance credits in this period.")
+	} else {
+		rows := make([][]string, 0, len(summary.Credits))
+		for _, c := range summary.Credits {
+			rows = append(rows, []string{c.OccurredAt.Format("2006-01-02"), userStatementSourceLabel(c.Source), c.Reference, formatStatementUSD(c.Amount)})
+		}
+		l.table(ledgerCols("Reference"), rows)
+	}
+
+	l.heading("Subscription charges")
+	if len(summary.SubscriptionCharges) == 0 {
+		l.note("No subscription charges in this period.")
+	} else {
+		rows := make([][]string, 0, len(summary.SubscriptionCharges))
+		for _, c := range summary.SubscriptionCharges {
+			rows = append(rows, []string{c.OccurredAt.Format("2006-01-02"), userStatementSourceLabel(c.Source), c.Description, formatStatementAmount(c.Amount, c.Currency)})
+		}
+		l.table(ledgerCols("Description"), rows)
+	}
+
+	total := doc.PageCount()
+	for i := 0; i < total; i++ {
+		doc.Page(i).TextRight(right, l.height-statementPDFMargin/2, font, statementPDFFooterSize, fmt.Sprintf("Page %d of %d", i+1, total))
+	}
+	return doc.Bytes()
+}
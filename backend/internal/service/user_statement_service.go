@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pdf"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+)
+
+const (
+	userStatementWorkerName = "user_statement_worker"
+	// userStatementEmailClaimTTL 邮件领取后多久未完成可被重新领取
+	userStatementEmailClaimTTL = 15 * time.Minute
+	// userStatementRunTimeout 单轮生成与投递的最大执行时长
+	userStatementRunTimeout = 10 * time.Minute
+)
+
+// userStatementPDFFontCandidates 未配置 statement.pdf_font_path 时依次尝试的系统中文 TrueType 字体
+var userStatementPDFFontCandidates = []string{
+	"/usr/share/fonts/truetype/wqy/wqy-zenhei.ttc",
+	"/usr/share/fonts/truetype/wqy/wqy-microhei.ttc",
+	"/usr/share/fonts/wenquanyi/wqy-zenhei/wqy-zenhei.ttc",
+	"/usr/share/fonts/wqy-zenhei/wqy-zenhei.ttc",
+	"/usr/share/fonts/truetype/droid/DroidSansFallbackFull.ttf",
+	"/usr/share/fonts/google-droid-sans-fonts/DroidSansFallbackFull.ttf",
+}
+
+var (
+	ErrUserStatementNotFound      = infraerrors.NotFound("STATEMENT_NOT_FOUND", "statement not found")
+	ErrUserStatementInvalidFormat = infraerrors.BadRequest("STATEMENT_INVALID_FORMAT", "format must be pdf or html")
+)
+
+// UserStatementEmailSender 账单邮件发送（带 PDF 附件）
+type UserStatementEmailSender interface {
+	SendEmailWithAttachments(ctx context.Context, to, subject, body string, attachments []EmailAttachment) error
+}
+
+// UserStatementService 用户月度账单
+//
+// 账期为站点时区下的自然月。每月结束并延迟 generate_delay_hours、且仪表盘预聚合水位越过账期末尾后，
+// 后台任务为有用量或余额/订阅变动的用户生成账单；账单以 (user_id, period) 唯一写入，内容生成后不再修改，
+// 多实例并发生成时只有写入成功的实例会产生新记录。邮件投递独立领取，失败后按 email_max_attempts 重试。
+type UserStatementService struct {
+	repo           UserStatementRepository
+	aggRepo        DashboardAggregationRepository
+	settingService *SettingService
+	emailSender    UserStatementEmailSender
+	timingWheel    *TimingWheelService
+	cfg            *config.Config
+
+	pdfFontOnce sync.Once
+	pdfFont     *pdf.TrueTypeFont
+
+	running   int32
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	workerCtx    context.Context
+	workerCancel context.CancelFunc
+}
+
+func NewUserStatementService(
+	repo UserStatementRepository,
+	aggRepo DashboardAggregationRepository,
+	settingService *SettingService,
+	emailSender UserStatementEmailSender,
+	timingWheel *TimingWheelService,
+	cfg *config.Config,
+) *UserStatementService {
+	workerCtx, workerCancel := context.WithCancel(context.Background())
+	return &UserStatementService{
+		repo:           repo,
+		aggRepo:        aggRepo,
+		settingService: settingService,
+		emailSender:    emailSender,
+		timingWheel:    timingWheel,
+		cfg:            cfg,
+		workerCtx:      workerCtx,
+		workerCancel:   workerCancel,
+	}
+}
+
+func (s *UserStatementService) Start() {
+	if s == nil {
+		return
+	}
+	if s.cfg == nil || !s.cfg.Statement.Enabled {
+		log.Printf("[Statement] not started (disabled)")
+		return
+	}
+	if !s.cfg.DashboardAgg.Enabled || s.aggRepo == nil {
+		log.Printf("[Statement] not started (dashboard aggregation disabled)")
+		return
+	}
+	if s.repo == nil || s.timingWheel == nil {
+		log.Printf("[Statement] not started (missing deps)")
+		return
+	}
+
+	interval := time.Duration(s.cfg.Statement.WorkerIntervalMinutes) * time.Minute
+	s.startOnce.Do(func() {
+		s.timingWheel.ScheduleRecurring(userStatementWorkerName, interval, s.runOnce)
+		log.Printf("[Statement] started (interval=%s delay=%dh email=%v)", interval, s.cfg.Statement.GenerateDelayHours, s.emailEnabled())
+	})
+}
+
+func (s *UserStatementService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.workerCancel != nil {
+			s.workerCancel()
+		}
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(userStatementWorkerName)
+		}
+		log.Printf("[Statement] stopped")
+	})
+}
+
+// UserStatementPreviousPeriod 返回 now 所在月份的上一个账期（站点时区）
+func UserStatementPreviousPeriod(now time.Time) (period string, start, end time.Time) {
+	end = timezone.StartOfMonth(now)
+	start = end.AddDate(0, -1, 0)
+	return start.Format("2006-01"), start, end
+}
+
+// ListStatements 分页列出用户的账单
+func (s *UserStatementService) ListStatements(ctx context.Context, userID int64, params pagination.PaginationParams) ([]UserStatement, *pagination.PaginationResult, error) {
+	return s.repo.ListByUser(ctx, userID, params)
+}
+
+// GetStatement 查询账单；仅允许账单所属用户访问
+func (s *UserStatementService) GetStatement(ctx context.Context, id, userID int64) (*UserStatement, error) {
+	st, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if st.UserID != userID {
+		return nil, ErrUserStatementNotFound
+	}
+	return st, nil
+}
+
+// NormalizeUserStatementFormat 规范化下载格式，空值默认为 pdf
+func NormalizeUserStatementFormat(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", UserStatementFormatPDF:
+		return UserStatementFormatPDF, nil
+	case UserStatementFormatHTML:
+		return UserStatementFormatHTML, nil
+	default:
+		return "", ErrUserStatementInvalidFormat
+	}
+}
+
+// UserStatementFileName 生成下载文件名
+func UserStatementFileName(period, format string) string {
+	return fmt.Sprintf("statement_%s.%s", period, format)
+}
+
+func (s *UserStatementService) emailEnabled() bool {
+	return s.cfg != nil && s.cfg.Statement.EmailEnabled && s.emailSender != nil
+}
+
+func (s *UserStatementService) branding(ctx context.Context) userStatementBranding {
+	if s.settingService == nil {
+		return userStatementBranding{SiteName: "Sub2API", Font: s.loadPDFFont()}
+	}
+	return userStatementBranding{
+		SiteName: s.settingService.GetSiteName(ctx),
+		Logo:     s.settingService.GetSiteLogo(ctx),
+		Font:     s.loadPDFFont(),
+	}
+}
+
+// loadPDFFont 首次使用时加载 PDF 字体：优先 statement.pdf_font_path，未配置时查找常见系统字体；
+// 都不可用时返回 nil，PDF 退回内置字体
+func (s *UserStatementService) loadPDFFont() *pdf.TrueTypeFont {
+	s.pdfFontOnce.Do(func() {
+		candidates := userStatementPDFFontCandidates
+		if s.cfg != nil && strings.TrimSpace(s.cfg.Statement.PDFFontPath) != "" {
+			candidates = []string{strings.TrimSpace(s.cfg.Statement.PDFFontPath)}
+		}
+		for _, path := range candidates {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				if len(candidates) == 1 {
+					log.Printf("[Statement] read PDF font %s failed: %v", path, err)
+				}
+				continue
+			}
+			font, err := pdf.ParseTrueType(data)
+			if err != nil {
+				log.Printf("[Statement] PDF font %s unusable: %v", path, err)
+				continue
+			}
+			s.pdfFont = font
+			log.Printf("[Statement] PDF font: %s (%s)", path, font.Name())
+			return
+		}
+		log.Printf("[Statement] no TrueType font for PDF statements, non-Latin text renders as '?' (set statement.pdf_font_path)")
+	})
+	return s.pdfFont
+}
+
+func (s *UserStatementService) runOnce() {
+	if s == nil {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&s.running, 0)
+
+	parent := context.Background()
+	if s.workerCtx != nil {
+		parent = s.workerCtx
+	}
+	ctx, cancel := context.WithTimeout(parent, userStatementRunTimeout)
+	defer cancel()
+
+	s.runAt(ctx, time.Now())
+}
+
+func (s *UserStatementService) runAt(ctx context.Context, now time.Time) {
+	period, start, end := UserStatementPreviousPeriod(now)
+	if s.periodReady(ctx, end, now) {
+		if n, err := s.generatePeriod(ctx, period, start, end, now); err != nil {
+			log.Printf("[Statement] generate failed: period=%s generated=%d err=%v", period, n, err)
+		} else if n > 0 {
+			log.Printf("[Statement] generated: period=%s count=%d", period, n)
+		}
+	}
+	if s.emailEnabled() {
+		s.deliverPending(ctx)
+	}
+}
+
+// periodReady 账期结束已超过延迟时间，且预聚合水位越过账期末尾
+func (s *UserStatementService) periodReady(ctx context.Context, end, now time.Time) bool {
+	delay := time.Duration(s.cfg.Statement.GenerateDelayHours) * time.Hour
+	if now.Before(end.Add(delay)) {
+		return false
+	}
+	if s.aggRepo == nil {
+		return true
+	}
+	watermark, err := s.aggRepo.GetAggregationWatermark(ctx)
+	if err != nil {
+		log.Printf("[Statement] read aggregation watermark failed: %v", err)
+		return false
+	}
+	return !watermark.Before(end)
+}
+
+// generatePeriod 为尚无账单的候选用户生成账单，单轮最多 batch_size 份
+func (s *UserStatementService) generatePeriod(ctx context.Context, period string, start, end, now time.Time) (int, error) {
+	limit := s.cfg.Statement.BatchSize
+	branding := s.branding(ctx)
+	generated := 0
+	var afterUserID int64
+	for generated < limit {
+		candidates, err := s.repo.ListCandidates(ctx, period, start, end, afterUserID, limit)
+		if err != nil {
+			return generated, fmt.Errorf("list candidates: %w", err)
+		}
+		for _, recipient := range candidates {
+			if err := ctx.Err(); err != nil {
+				return generated, err
+			}
+			afterUserID = recipient.UserID
+			created, err := s.generate(ctx, recipient, period, start, end, now, branding)
+			if err != nil {
+				// 单个用户失败不阻塞其他用户，下一轮重试
+				log.Printf("[Statement] generate statement failed: user=%d period=%s err=%v", recipient.UserID, period, err)
+				continue
+			}
+			if created {
+				generated++
+			}
+		}
+		if len(candidates) < limit {
+			break
+		}
+	}
+	return generated, nil
+}
+
+func (s *UserStatementService) generate(ctx context.Context, recipient UserStatementRecipient, period string, start, end, now time.Time, branding userStatementBranding) (bool, error) {
+	usage, err := s.repo.ListUsageLines(ctx, recipient.UserID, start, end)
+	if err != nil {
+		return false, fmt.Errorf("list usage: %w", err)
+	}
+	credits, err := s.repo.ListCredits(ctx, recipient.UserID, start, end)
+	if err != nil {
+		return false, fmt.Errorf("list credits: %w", err)
+	}
+	charges, err := s.repo.ListSubscriptionCharges(ctx, recipient.UserID, start, end)
+	if err != nil {
+		return false, fmt.Errorf("list subscription charges: %w", err)
+	}
+
+	summary := buildUserStatementSummary(recipient, period, start, end, usage, credits, charges)
+	summary.SiteName = branding.SiteName
+	summary.GeneratedAt = now.In(start.Location())
+
+	htmlContent, err := renderUserStatementHTML(&summary, branding)
+	if err != nil {
+		return false, fmt.Errorf("render html: %w", err)
+	}
+	pdfContent, err := renderUserStatementPDF(&summary, branding)
+	if err != nil {
+		return false, fmt.Errorf("render pdf: %w", err)
+	}
+
+	return s.repo.Create(ctx, &UserStatement{
+		UserID:                   recipient.UserID,
+		Period:                   period,
+		PeriodStart:              start,
+		PeriodEnd:                end,
+		Currency:                 "USD",
+		TotalRequests:            summary.TotalRequests,
+		TotalTokens:              summary.TotalTokens,
+		UsageCost:                summary.UsageCost,
+		CreditsTotal:             summary.CreditsTotal,
+		SubscriptionChargesTotal: summary.SubscriptionChargesTotal,
+		Summary:                  summary,
+		HTML:                     htmlContent,
+		PDF:                      pdfContent,
+	})
+}
+
+func (s *UserStatementService) deliverPending(ctx context.Context) {
+	statements, err := s.repo.ClaimPendingEmails(ctx, s.cfg.Statement.BatchSize, s.cfg.Statement.EmailMaxAttempts, userStatementEmailClaimTTL)
+	if err != nil {
+		log.Printf("[Statement] claim pending emails failed: %v", err)
+		return
+	}
+	for i := range statements {
+		st := &statements[i]
+		if st.Recipient == nil || st.Recipient.Email == "" {
+			continue
+		}
+		subject := fmt.Sprintf("[%s] Monthly statement %s", st.Summary.SiteName, st.Period)
+		attachment := EmailAttachment{
+			Filename:    UserStatementFileName(st.Period, UserStatementFormatPDF),
+			ContentType: "application/pdf",
+			Data:        st.PDF,
+		}
+		if err := s.emailSender.SendEmailWithAttachments(ctx, st.Recipient.Email, subject, buildUserStatementEmailBody(&st.Summary), []EmailAttachment{attachment}); err != nil {
+			log.Printf("[Statement] send email failed: statement=%d user=%d err=%v", st.ID, st.UserID, err)
+			continue
+		}
+		if err := s.repo.MarkEmailed(ctx, st.ID); err != nil {
+			log.Printf("[Statement] mark emailed failed: statement=%d err=%v", st.ID, err)
+		}
+	}
+}
+
+func buildUserStatementEmailBody(summary *UserStatementSummary) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+  <h2>%s monthly statement – %s</h2>
+  <p>Requests: %s<br>Tokens: %s<br>Usage charges: %s<br>Balance credits: %s<br>Subscription charges (USD): %s</p>
+  <p>The full statement is attached as a PDF and can also be downloaded from your account.</p>
+</body>
+</html>`,
+		html.EscapeString(summary.SiteName), html.EscapeString(summary.Period),
+		formatStatementInt(summary.TotalRequests), formatStatementInt(summary.TotalTokens),
+		formatStatementUSD(summary.UsageCost), formatStatementUSD(summary.CreditsTotal), formatStatementUSD(summary.SubscriptionChargesTotal))
+}
@@ -0,0 +1,345 @@
+//go:build unit
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pdf"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pdf/pdftest"
+	pdfreader "github.com/ledongthuc/pdf"
+	"github.com/stretchr/testify/require"
+)
+
+type userStatementRepoStub struct {
+	candidates []UserStatementRecipient
+	usage      map[int64][]UserStatementUsageLine
+	credits    map[int64][]UserStatementCredit
+	charges    map[int64][]UserStatementCharge
+	created    []*UserStatement
+	emailed    []int64
+}
+
+func (r *userStatementRepoStub) exists(userID int64, period string) bool {
+	for _, st := range r.created {
+		if st.UserID == userID && st.Period == period {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *userStatementRepoStub) ListCandidates(ctx context.Context, period string, start, end time.Time, afterUserID int64, limit int) ([]UserStatementRecipient, error) {
+	out := []UserStatementRecipient{}
+	for _, c := range r.candidates {
+		if c.UserID > afterUserID && !r.exists(c.UserID, period) && len(out) < limit {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (r *userStatementRepoStub) ListUsageLines(ctx context.Context, userID int64, start, end time.Time) ([]UserStatementUsageLine, error) {
+	return append([]UserStatementUsageLine(nil), r.usage[userID]...), nil
+}
+
+func (r *userStatementRepoStub) ListCredits(ctx context.Context, userID int64, start, end time.Time) ([]UserStatementCredit, error) {
+	return r.credits[userID], nil
+}
+
+func (r *userStatementRepoStub) ListSubscriptionCharges(ctx context.Context, userID int64, start, end time.Time) ([]UserStatementCharge, error) {
+	return r.charges[userID], nil
+}
+
+func (r *userStatementRepoStub) Create(ctx context.Context, st *UserStatement) (bool, error) {
+	if r.exists(st.UserID, st.Period) {
+		return false, nil
+	}
+	st.ID = int64(len(r.created) + 1)
+	r.created = append(r.created, st)
+	return true, nil
+}
+
+func (r *userStatementRepoStub) ListByUser(ctx context.Context, userID int64, params pagination.PaginationParams) ([]UserStatement, *pagination.PaginationResult, error) {
+	return nil, &pagination.PaginationResult{}, nil
+}
+
+func (r *userStatementRepoStub) GetByID(ctx context.Context, id int64) (*UserStatement, error) {
+	for _, st := range r.created {
+		if st.ID == id {
+			return st, nil
+		}
+	}
+	return nil, ErrUserStatementNotFound
+}
+
+func (r *userStatementRepoStub) ClaimPendingEmails(ctx context.Context, limit, maxAttempts int, claimTTL time.Duration) ([]UserStatement, error) {
+	out := []UserStatement{}
+	for _, st := range r.created {
+		if st.EmailedAt != nil {
+			continue
+		}
+		copied := *st
+		copied.Recipient = &UserStatementRecipient{UserID: st.UserID, Email: st.Summary.UserEmail}
+		out = append(out, copied)
+	}
+	return out, nil
+}
+
+func (r *userStatementRepoStub) MarkEmailed(ctx context.Context, id int64) error {
+	r.emailed = append(r.emailed, id)
+	now := time.Now()
+	for _, st := range r.created {
+		if st.ID == id {
+			st.EmailedAt = &now
+		}
+	}
+	return nil
+}
+
+type userStatementEmailStub struct {
+	sent []string
+	fail bool
+	pdfs [][]byte
+}
+
+func (s *userStatementEmailStub) SendEmailWithAttachments(ctx context.Context, to, subject, body string, attachments []EmailAttachment) error {
+	if s.fail {
+		return errors.New("smtp down")
+	}
+	s.sent = append(s.sent, to+"|"+subject)
+	for _, a := range attachments {
+		s.pdfs = append(s.pdfs, a.Data)
+	}
+	return nil
+}
+
+func newUserStatementTestService(repo UserStatementRepository, agg DashboardAggregationRepository, sender UserStatementEmailSender) *UserStatementService {
+	cfg := &config.Config{Statement: config.UserStatementConfig{
+		Enabled:            true,
+		EmailEnabled:       true,
+		GenerateDelayHours: 2,
+		BatchSize:          10,
+		EmailMaxAttempts:   3,
+	}}
+	return NewUserStatementService(repo, agg, nil, sender, nil, cfg)
+}
+
+func userStatementTestRepo() *userStatementRepoStub {
+	return &userStatementRepoStub{
+		candidates: []UserStatementRecipient{
+			{UserID: 7, Email: "seven@example.com", Username: "seven"},
+			{UserID: 9, Email: "nine@example.com"},
+		},
+		usage: map[int64][]UserStatementUsageLine{
+			7: {
+				{GroupID: 1, GroupName: "claude", Model: "claude-sonnet-4", APIKeyID: 3, APIKeyName: "prod", Requests: 10, InputTokens: 1000, OutputTokens: 500, TotalCost: 2, ActualCost: 1.5},
+				{GroupID: 1, GroupName: "claude", Model: "claude-haiku-4", APIKeyID: 4, APIKeyName: "dev", Requests: 5, InputTokens: 200, TotalCost: 0.2, ActualCost: 0.1},
+				{GroupID: 0, Model: "gpt-4o", APIKeyID: 3, APIKeyName: "prod", Requests: 1, OutputTokens: 50, TotalCost: 0.5, ActualCost: 0.5},
+			},
+		},
+		credits: map[int64][]UserStatementCredit{
+			9: {{Source: UserStatementCreditRedeem, Reference: "CODE1", Amount: 20}},
+		},
+		charges: map[int64][]UserStatementCharge{
+			9: {
+				{Source: UserStatementChargePlan, Description: "Pro (renew)", Amount: 10, Currency: "USD"},
+				{Source: UserStatementChargePayment, Description: "claude (30 days)", Amount: 99, Currency: "CNY"},
+			},
+		},
+	}
+}
+
+func TestUserStatementPreviousPeriod(t *testing.T) {
+	now := time.Date(2026, 1, 15, 10, 0, 0, 0, time.Local)
+	period, start, end := UserStatementPreviousPeriod(now)
+	require.Equal(t, "2025-12", period)
+	require.Equal(t, time.Date(2025, 12, 1, 0, 0, 0, 0, time.Local), start)
+	require.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local), end)
+}
+
+func TestUserStatementRunWaitsForDelayAndAggregationWatermark(t *testing.T) {
+	repo := userStatementTestRepo()
+	agg := &dashboardAggregationRepoTestStub{}
+	svc := newUserStatementTestService(repo, agg, nil)
+
+	monthStart := time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)
+	agg.watermark = monthStart.Add(3 * time.Hour)
+	svc.runAt(context.Background(), monthStart.Add(time.Hour))
+	require.Empty(t, repo.created, "generation must wait for generate_delay_hours")
+
+	agg.watermark = monthStart.Add(-time.Minute)
+	svc.runAt(context.Background(), monthStart.Add(3*time.Hour))
+	require.Empty(t, repo.created, "generation must wait for aggregation to pass period end")
+
+	agg.watermark = monthStart.Add(time.Minute)
+	svc.runAt(context.Background(), monthStart.Add(3*time.Hour))
+	require.Len(t, repo.created, 2)
+	require.Equal(t, "2026-09", repo.created[0].Period)
+}
+
+func TestUserStatementGenerateBuildsBreakdownAndRenders(t *testing.T) {
+	repo := userStatementTestRepo()
+	svc := newUserStatementTestService(repo, nil, nil)
+
+	svc.runAt(context.Background(), time.Date(2026, 10, 2, 0, 0, 0, 0, time.Local))
+	require.Len(t, repo.created, 2)
+
+	st := repo.created[0]
+	require.EqualValues(t, 7, st.UserID)
+	require.EqualValues(t, 16, st.TotalRequests)
+	require.EqualValues(t, 1750, st.TotalTokens)
+	require.InDelta(t, 2.1, st.UsageCost, 1e-9)
+	require.InDelta(t, 2.7, st.Summary.StandardCost, 1e-9)
+	require.Equal(t, []UserStatementSubtotal{
+		{Name: "claude", Requests: 15, Tokens: 1700, ActualCost: 1.6},
+		{Name: "(no group)", Requests: 1, Tokens: 50, ActualCost: 0.5},
+	}, roundSubtotals(st.Summary.ByGroup))
+	require.Equal(t, "prod", st.Summary.ByAPIKey[0].Name)
+	require.InDelta(t, 2.0, st.Summary.ByAPIKey[0].ActualCost, 1e-9)
+	require.Len(t, st.Summary.ByModel, 3)
+
+	require.Contains(t, st.HTML, "Sub2API")
+	require.Contains(t, st.HTML, "claude-sonnet-4")
+	require.Contains(t, st.HTML, "$2.1000")
+	require.True(t, bytes.HasPrefix(st.PDF, []byte("%PDF-")))
+
+	other := repo.created[1]
+	require.InDelta(t, 20, other.CreditsTotal, 1e-9)
+	require.InDelta(t, 10, other.SubscriptionChargesTotal, 1e-9, "only USD charges are totalled")
+	require.Contains(t, other.HTML, "99.00 CNY")
+	require.Contains(t, other.HTML, "No usage in this period.")
+
+	// 重复运行不会生成重复账单
+	svc.runAt(context.Background(), time.Date(2026, 10, 2, 1, 0, 0, 0, time.Local))
+	require.Len(t, repo.created, 2)
+}
+
+func TestRenderUserStatementPDFEmbedsFontForNonLatinBranding(t *testing.T) {
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	summary := &UserStatementSummary{
+		SiteName:    "星河中转站",
+		UserEmail:   "user@example.com",
+		Username:    "张三",
+		Period:      "2026-09",
+		PeriodStart: start,
+		PeriodEnd:   start.AddDate(0, 1, 0),
+		Timezone:    "UTC",
+		GeneratedAt: start.AddDate(0, 1, 1),
+		ByGroup:     []UserStatementSubtotal{{Name: "默认分组", Requests: 3, Tokens: 120, ActualCost: 0.5}},
+	}
+
+	// 内置字体无法表示中文
+	out, err := renderUserStatementPDF(summary, userStatementBranding{SiteName: summary.SiteName})
+	require.NoError(t, err)
+	require.NotContains(t, string(out), "/Identity-H")
+	require.NotContains(t, extractStatementPDFText(t, out), "星河中转站")
+
+	chars := "星河中转站张三默认分组"
+	for c := ' '; c <= '~'; c++ {
+		chars += string(c)
+	}
+	font, err := pdf.ParseTrueType(pdftest.TrueTypeFont(chars))
+	require.NoError(t, err)
+	out, err = renderUserStatementPDF(summary, userStatementBranding{SiteName: summary.SiteName, Font: font})
+	require.NoError(t, err)
+	require.Contains(t, string(out), "/Encoding /Identity-H")
+
+	text := extractStatementPDFText(t, out)
+	require.Contains(t, text, "星河中转站")
+	require.Contains(t, text, "张三")
+	require.Contains(t, text, "默认分组")
+	require.Contains(t, text, "Monthly statement")
+}
+
+func TestUserStatementLoadPDFFontFromConfig(t *testing.T) {
+	path := t.TempDir() + "/cjk.ttf"
+	require.NoError(t, os.WriteFile(path, pdftest.TrueTypeFont("星河"), 0o600))
+
+	cfg := &config.Config{}
+	cfg.Statement.PDFFontPath = path
+	svc := NewUserStatementService(nil, nil, nil, nil, nil, cfg)
+	branding := svc.branding(context.Background())
+	require.NotNil(t, branding.Font)
+	require.True(t, branding.Font.HasGlyph('星'))
+	require.Same(t, branding.Font, svc.branding(context.Background()).Font)
+
+	cfg = &config.Config{}
+	cfg.Statement.PDFFontPath = t.TempDir() + "/missing.ttf"
+	require.Nil(t, NewUserStatementService(nil, nil, nil, nil, nil, cfg).branding(context.Background()).Font)
+}
+
+func extractStatementPDFText(t *testing.T, out []byte) string {
+	t.Helper()
+	reader, err := pdfreader.NewReader(bytes.NewReader(out), int64(len(out)))
+	require.NoError(t, err)
+	var text strings.Builder
+	for i := 1; i <= reader.NumPage(); i++ {
+		s, err := reader.Page(i).GetPlainText(nil)
+		require.NoError(t, err)
+		text.WriteString(s)
+	}
+	return text.String()
+}
+
+func TestUserStatementEmailsPendingStatementsOnce(t *testing.T) {
+	repo := userStatementTestRepo()
+	sender := &userStatementEmailStub{fail: true}
+	svc := newUserStatementTestService(repo, nil, sender)
+	now := time.Date(2026, 10, 2, 0, 0, 0, 0, time.Local)
+
+	svc.runAt(context.Background(), now)
+	require.Len(t, repo.created, 2)
+	require.Empty(t, repo.emailed, "failed sends are not marked")
+
+	sender.fail = false
+	svc.runAt(context.Background(), now)
+	require.ElementsMatch(t, []int64{1, 2}, repo.emailed)
+	require.Len(t, sender.sent, 2)
+	require.True(t, strings.HasPrefix(sender.sent[0], "seven@example.com|[Sub2API] Monthly statement 2026-09"))
+	require.True(t, bytes.HasPrefix(sender.pdfs[0], []byte("%PDF-")))
+
+	svc.runAt(context.Background(), now)
+	require.Len(t, sender.sent, 2)
+}
+
+func TestUserStatementGetStatementChecksOwner(t *testing.T) {
+	repo := userStatementTestRepo()
+	svc := newUserStatementTestService(repo, nil, nil)
+	svc.runAt(context.Background(), time.Date(2026, 10, 2, 0, 0, 0, 0, time.Local))
+
+	st, err := svc.GetStatement(context.Background(), 1, 7)
+	require.NoError(t, err)
+	require.Equal(t, "2026-09", st.Period)
+
+	_, err = svc.GetStatement(context.Background(), 1, 9)
+	require.ErrorIs(t, err, ErrUserStatementNotFound)
+}
+
+func TestNormalizeUserStatementFormat(t *testing.T) {
+	f, err := NormalizeUserStatementFormat("")
+	require.NoError(t, err)
+	require.Equal(t, UserStatementFormatPDF, f)
+	f, err = NormalizeUserStatementFormat("HTML")
+	require.NoError(t, err)
+	require.Equal(t, UserStatementFormatHTML, f)
+	_, err = NormalizeUserStatementFormat("csv")
+	require.ErrorIs(t, err, ErrUserStatementInvalidFormat)
+}
+
+func roundSubtotals(items []UserStatementSubtotal) []UserStatementSubtotal {
+	out := make([]UserStatementSubtotal, len(items))
+	for i, it := range items {
+		it.ActualCost = float64(int64(it.ActualCost*1e6+0.5)) / 1e6
+		out[i] = it
+	}
+	return out
+}
@@ -23,6 +23,15 @@ type UserSubscription struct {
 	AssignedAt time.Time
 	Notes      string
 
+	// 套餐绑定（通过套餐购买时设置），限额覆盖为空时沿用分组限额
+	PlanID          *int64
+	NextPlanID      *int64
+	AutoRenew       bool
+	RenewalFailedAt *time.Time
+	DailyLimitUSD   *float64
+	WeeklyLimitUSD  *float64
+	MonthlyLimitUSD *float64
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
@@ -95,7 +104,32 @@ func (s *UserSubscription) MonthlyResetTime() *time.Time {
 	return &t
 }
 
+// HasLimitOverride 订阅是否设置了任一限额覆盖
+func (s *UserSubscription) HasLimitOverride() bool {
+	return s.DailyLimitUSD != nil || s.WeeklyLimitUSD != nil || s.MonthlyLimitUSD != nil
+}
+
+// EffectiveGroup 返回应用订阅级限额覆盖后的分组视图。
+// 无覆盖时直接返回原分组；有覆盖时返回浅拷贝，不修改原分组（可能来自缓存快照）。
+func (s *UserSubscription) EffectiveGroup(group *Group) *Group {
+	if s == nil || group == nil || !s.HasLimitOverride() {
+		return group
+	}
+	out := *group
+	if s.DailyLimitUSD != nil {
+		out.DailyLimitUSD = s.DailyLimitUSD
+	}
+	if s.WeeklyLimitUSD != nil {
+		out.WeeklyLimitUSD = s.WeeklyLimitUSD
+	}
+	if s.MonthlyLimitUSD != nil {
+		out.MonthlyLimitUSD = s.MonthlyLimitUSD
+	}
+	return &out
+}
+
 func (s *UserSubscription) CheckDailyLimit(group *Group, additionalCost float64) bool {
+	group = s.EffectiveGroup(group)
 	if !group.HasDailyLimit() {
 		return true
 	}
@@ -103,6 +137,7 @@ func (s *UserSubscription) CheckDailyLimit(group *Group, additionalCost float64)
 }
 
 func (s *UserSubscription) CheckWeeklyLimit(group *Group, additionalCost float64) bool {
+	group = s.EffectiveGroup(group)
 	if !group.HasWeeklyLimit() {
 		return true
 	}
@@ -110,6 +145,7 @@ func (s *UserSubscription) CheckWeeklyLimit(group *Group, additionalCost float64
 }
 
 func (s *UserSubscription) CheckMonthlyLimit(group *Group, additionalCost float64) bool {
+	group = s.EffectiveGroup(group)
 	if !group.HasMonthlyLimit() {
 		return true
 	}
@@ -10,6 +10,8 @@ import (
 type UserSubscriptionRepository interface {
 	Create(ctx context.Context, sub *UserSubscription) error
 	GetByID(ctx context.Context, id int64) (*UserSubscription, error)
+	// GetByIDForUpdate 在事务中加行锁读取订阅，用于套餐变更 / 续费等读-改-写操作的并发控制
+	GetByIDForUpdate(ctx context.Context, id int64) (*UserSubscription, error)
 	GetByUserIDAndGroupID(ctx context.Context, userID, groupID int64) (*UserSubscription, error)
 	GetActiveByUserIDAndGroupID(ctx context.Context, userID, groupID int64) (*UserSubscription, error)
 	Update(ctx context.Context, sub *UserSubscription) error
@@ -24,6 +26,9 @@ type UserSubscriptionRepository interface {
 	ExtendExpiry(ctx context.Context, subscriptionID int64, newExpiresAt time.Time) error
 	UpdateStatus(ctx context.Context, subscriptionID int64, status string) error
 	UpdateNotes(ctx context.Context, subscriptionID int64, notes string) error
+	UpdatePlanBinding(ctx context.Context, subscriptionID int64, binding *SubscriptionPlanBinding) error
+	SetRenewalFailedAt(ctx context.Context, subscriptionID int64, failedAt *time.Time) error
+	ListAutoRenewDue(ctx context.Context, dueBefore time.Time, limit int) ([]UserSubscription, error)
 
 	ActivateWindows(ctx context.Context, id int64, start time.Time) error
 	ResetDailyUsage(ctx context.Context, id int64, newWindowStart time.Time) error
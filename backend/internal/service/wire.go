@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"time"
 
+	dbent "github.com/Wei-Shaw/sub2api/ent"
 	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/secretbox"
 	"github.com/google/wire"
 	"github.com/redis/go-redis/v9"
 )
@@ -65,6 +67,198 @@ func ProvideUsageCleanupService(repo UsageCleanupRepository, timingWheel *Timing
 	return svc
 }
 
+// ProvideUsageExportService 创建并启动使用记录导出服务
+func ProvideUsageExportService(repo UsageExportRepository, timingWheel *TimingWheelService, cfg *config.Config) *UsageExportService {
+	svc := NewUsageExportService(repo, timingWheel, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvidePaymentService 创建并启动在线支付服务
+func ProvidePaymentService(
+	repo PaymentOrderRepository,
+	userRepo UserRepository,
+	groupRepo GroupRepository,
+	userSubRepo UserSubscriptionRepository,
+	subscriptionService *SubscriptionService,
+	billingCacheService *BillingCacheService,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	entClient *dbent.Client,
+	providers PaymentProviders,
+	timingWheel *TimingWheelService,
+	cfg *config.Config,
+) *PaymentService {
+	svc := NewPaymentService(repo, userRepo, groupRepo, userSubRepo, subscriptionService, billingCacheService, authCacheInvalidator, entClient, providers, timingWheel, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideSubscriptionPlanService 创建并启动订阅套餐服务（含自动续费任务）
+func ProvideSubscriptionPlanService(
+	planRepo SubscriptionPlanRepository,
+	userRepo UserRepository,
+	groupRepo GroupRepository,
+	userSubRepo UserSubscriptionRepository,
+	subscriptionService *SubscriptionService,
+	billingCacheService *BillingCacheService,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	emailService *EmailService,
+	entClient *dbent.Client,
+	timingWheel *TimingWheelService,
+	cfg *config.Config,
+) *SubscriptionPlanService {
+	svc := NewSubscriptionPlanService(planRepo, userRepo, groupRepo, userSubRepo, subscriptionService, billingCacheService, authCacheInvalidator, emailService, entClient, timingWheel, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideNotificationService 创建并启动用户通知服务（含定时扫描任务）
+func ProvideNotificationService(
+	repo NotificationRepository,
+	emailQueue *EmailQueueService,
+	webhookSender NotificationWebhookSender,
+	timingWheel *TimingWheelService,
+	cfg *config.Config,
+) *NotificationService {
+	svc := NewNotificationService(repo, emailQueue, webhookSender, timingWheel, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideUserStatementService 创建并启动月度账单服务（含生成与邮件投递任务）
+func ProvideUserStatementService(
+	repo UserStatementRepository,
+	aggRepo DashboardAggregationRepository,
+	settingService *SettingService,
+	emailService *EmailService,
+	timingWheel *TimingWheelService,
+	cfg *config.Config,
+) *UserStatementService {
+	svc := NewUserStatementService(repo, aggRepo, settingService, emailService, timingWheel, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideModelPriceOverrideService 创建自定义价格服务，加载价格并启动周期同步
+func ProvideModelPriceOverrideService(repo ModelPriceOverrideRepository, settingRepo SettingRepository, timingWheel *TimingWheelService) *ModelPriceOverrideService {
+	svc := NewModelPriceOverrideService(repo, settingRepo, timingWheel)
+	svc.Start()
+	return svc
+}
+
+// ProvideRateMultiplierService 创建客户级倍率服务，加载规则并启动周期同步
+func ProvideRateMultiplierService(
+	repo RateMultiplierRuleRepository,
+	groupRepo GroupRepository,
+	userRepo UserRepository,
+	apiKeyRepo APIKeyRepository,
+	timingWheel *TimingWheelService,
+	cfg *config.Config,
+) *RateMultiplierService {
+	svc := NewRateMultiplierService(repo, groupRepo, userRepo, apiKeyRepo, timingWheel, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideUsageTagService 创建成本归属标签服务，加载标签键与预算并启动周期同步
+func ProvideUsageTagService(repo UsageTagRepository, userRepo UserRepository, timingWheel *TimingWheelService) *UsageTagService {
+	svc := NewUsageTagService(repo, userRepo, timingWheel)
+	svc.Start()
+	return svc
+}
+
+// ProvideUsageAnomalyService 创建用量异常检测服务并启动后台检测任务
+func ProvideUsageAnomalyService(
+	repo UsageAnomalyRepository,
+	aggRepo DashboardAggregationRepository,
+	apiKeyService *APIKeyService,
+	notificationService *NotificationService,
+	opsService *OpsService,
+	emailQueueService *EmailQueueService,
+	timingWheel *TimingWheelService,
+	cfg *config.Config,
+) *UsageAnomalyService {
+	svc := NewUsageAnomalyService(repo, aggRepo, apiKeyService, notificationService, opsService, emailQueueService, timingWheel, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideSecretReencryptionService 创建敏感数据重加密服务，按配置在后台重写存量数据
+func ProvideSecretReencryptionService(repo SecretReencryptionRepository, keyring *secretbox.Keyring, cfg *config.Config) *SecretReencryptionService {
+	svc := NewSecretReencryptionService(repo, keyring, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideGeminiResourceService 创建 Gemini Files/cachedContents 透传服务并启动过期记录清理
+func ProvideGeminiResourceService(
+	repo GeminiResourceRepository,
+	accountRepo AccountRepository,
+	compat *GeminiMessagesCompatService,
+	gateway *GatewayService,
+	cfg *config.Config,
+) *GeminiResourceService {
+	svc := NewGeminiResourceService(repo, accountRepo, compat, gateway, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideStickyStandbyService 创建粘性会话备用账号服务并启动过期统计清理
+func ProvideStickyStandbyService(
+	repo StickySessionRepository,
+	cache StickyStandbyCache,
+	billingService *BillingService,
+	cfg *config.Config,
+) *StickyStandbyService {
+	svc := NewStickyStandbyService(repo, cache, billingService, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideGuardrailService 创建内容策略服务，加载策略并启动周期同步与命中记录清理
+func ProvideGuardrailService(
+	repo GuardrailRepository,
+	hookClient GuardrailHookClient,
+	timingWheel *TimingWheelService,
+	cfg *config.Config,
+) *GuardrailService {
+	svc := NewGuardrailService(repo, hookClient, timingWheel, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvidePromptTemplateService 创建系统提示词模板服务，加载模板并启动周期同步
+func ProvidePromptTemplateService(repo PromptTemplateRepository, timingWheel *TimingWheelService) *PromptTemplateService {
+	svc := NewPromptTemplateService(repo, timingWheel)
+	svc.Start()
+	return svc
+}
+
+// ProvideConfigReloadService 创建配置热更新服务并开始监听配置文件与 SIGHUP；TLS 指纹模板随配置刷新
+func ProvideConfigReloadService(cfg *config.Config) *ConfigReloadService {
+	svc := NewConfigReloadService(cfg)
+	applyTLSFingerprintProfiles(cfg, nil)
+	svc.OnReload(applyTLSFingerprintProfiles)
+	svc.Start()
+	return svc
+}
+
+// ProvideBillingOutboxService 创建计费发件箱服务并启动重试 worker
+func ProvideBillingOutboxService(
+	repo BillingOutboxRepository,
+	usageLogRepo UsageLogRepository,
+	userRepo UserRepository,
+	userSubRepo UserSubscriptionRepository,
+	resellerService *ResellerService,
+	billingCacheService *BillingCacheService,
+	entClient *dbent.Client,
+	timingWheel *TimingWheelService,
+) *BillingOutboxService {
+	svc := NewBillingOutboxService(repo, usageLogRepo, userRepo, userSubRepo, resellerService, billingCacheService, entClient, timingWheel)
+	svc.Start()
+	return svc
+}
+
 // ProvideAccountExpiryService creates and starts AccountExpiryService.
 func ProvideAccountExpiryService(accountRepo AccountRepository) *AccountExpiryService {
 	svc := NewAccountExpiryService(accountRepo, time.Minute)
@@ -219,9 +413,12 @@ var ProviderSet = wire.NewSet(
 	ProvidePricingService,
 	NewBillingService,
 	NewBillingCacheService,
+	NewCostHoldService,
 	NewAdminService,
 	NewGatewayService,
 	NewOpenAIGatewayService,
+	NewOpenAIRealtimeService,
+	NewAnthropicFileService,
 	NewOAuthService,
 	NewOpenAIOAuthService,
 	NewGeminiOAuthService,
@@ -259,6 +456,25 @@ var ProviderSet = wire.NewSet(
 	ProvideTimingWheelService,
 	ProvideDashboardAggregationService,
 	ProvideUsageCleanupService,
+	ProvideUsageExportService,
+	ProvidePaymentService,
+	ProvideSubscriptionPlanService,
+	ProvideNotificationService,
+	ProvideUserStatementService,
+	NewOrganizationService,
+	NewResellerService,
+	ProvideModelPriceOverrideService,
+	ProvideRateMultiplierService,
+	ProvideUsageTagService,
+	ProvideUsageAnomalyService,
+	ProvideSecretReencryptionService,
+	ProvideGeminiResourceService,
+	ProvideStickyStandbyService,
+	NewRequestHedgeService,
+	ProvideGuardrailService,
+	ProvidePromptTemplateService,
+	ProvideConfigReloadService,
+	ProvideBillingOutboxService,
 	ProvideDeferredService,
 	NewAntigravityQuotaFetcher,
 	NewUserAttributeService,
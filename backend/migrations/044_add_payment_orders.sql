@@ -0,0 +1,63 @@
+-- 044_add_payment_orders.sql
+-- 在线支付订单与支付回调事件表
+--
+-- payment_orders: 充值/订阅购买订单，金额以订单货币计，credit_amount 为到账余额（USD）。
+-- payment_webhook_events: 支付回调去重表，(provider, event_id) 唯一，
+--   与订单履约在同一事务内写入，保证回调重放时幂等。
+
+CREATE TABLE IF NOT EXISTS payment_orders (
+    id BIGSERIAL PRIMARY KEY,
+    order_no VARCHAR(64) NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    provider VARCHAR(32) NOT NULL,
+    method VARCHAR(32) NOT NULL DEFAULT '',
+    order_type VARCHAR(20) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    credit_amount DECIMAL(20,8) NOT NULL DEFAULT 0,
+    group_id BIGINT REFERENCES groups(id) ON DELETE SET NULL,
+    validity_days INT NOT NULL DEFAULT 0,
+    subscription_id BIGINT,
+    status VARCHAR(20) NOT NULL,
+    provider_order_id VARCHAR(255),
+    provider_payment_id VARCHAR(255),
+    pay_url TEXT,
+    refunded_amount DECIMAL(20,8) NOT NULL DEFAULT 0,
+    failure_reason TEXT,
+    notes TEXT,
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ,
+    paid_at TIMESTAMPTZ,
+    refunded_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_orders_order_no
+    ON payment_orders(order_no);
+
+CREATE INDEX IF NOT EXISTS idx_payment_orders_user_created_at
+    ON payment_orders(user_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_payment_orders_status_expires_at
+    ON payment_orders(status, expires_at);
+
+CREATE INDEX IF NOT EXISTS idx_payment_orders_provider_payment_id
+    ON payment_orders(provider, provider_payment_id)
+    WHERE provider_payment_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS payment_webhook_events (
+    id BIGSERIAL PRIMARY KEY,
+    provider VARCHAR(32) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    order_no VARCHAR(64),
+    payload JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_webhook_events_provider_event
+    ON payment_webhook_events(provider, event_id);
+
+CREATE INDEX IF NOT EXISTS idx_payment_webhook_events_order_no
+    ON payment_webhook_events(order_no);
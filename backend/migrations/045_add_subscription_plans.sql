@@ -0,0 +1,76 @@
+-- 045_add_subscription_plans.sql
+-- 订阅套餐目录、套餐购买记录，以及订阅上的套餐绑定/自动续费字段
+--
+-- subscription_plans: 引用订阅分组的可售套餐（价格以余额 USD 计），可覆盖分组日/周/月限额；
+--   同一分组内按 tier 区分升降级。
+-- subscription_plan_orders: 余额购买/续费/升级记录，用于审计与按比例折算。
+-- user_subscriptions: 新增 plan_id / next_plan_id / auto_renew / renewal_failed_at 及限额覆盖列。
+
+CREATE TABLE IF NOT EXISTS subscription_plans (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    group_id BIGINT NOT NULL REFERENCES groups(id) ON DELETE RESTRICT,
+    price DECIMAL(20,8) NOT NULL,
+    validity_days INT NOT NULL,
+    daily_limit_usd DECIMAL(20,8),
+    weekly_limit_usd DECIMAL(20,8),
+    monthly_limit_usd DECIMAL(20,8),
+    tier INT NOT NULL DEFAULT 0,
+    allow_upgrade BOOLEAN NOT NULL DEFAULT TRUE,
+    allow_downgrade BOOLEAN NOT NULL DEFAULT TRUE,
+    allow_auto_renew BOOLEAN NOT NULL DEFAULT TRUE,
+    sort_order INT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_plans_group_id
+    ON subscription_plans(group_id)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_subscription_plans_status_sort
+    ON subscription_plans(status, sort_order)
+    WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS subscription_plan_orders (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    plan_id BIGINT NOT NULL REFERENCES subscription_plans(id) ON DELETE RESTRICT,
+    subscription_id BIGINT,
+    action VARCHAR(20) NOT NULL,
+    price DECIMAL(20,8) NOT NULL,
+    credit DECIMAL(20,8) NOT NULL DEFAULT 0,
+    amount DECIMAL(20,8) NOT NULL,
+    expires_at TIMESTAMPTZ,
+    renewal_key VARCHAR(64),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- 自动续费幂等键：(订阅ID:到期时间戳)，防止多实例重复扣费
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscription_plan_orders_renewal_key
+    ON subscription_plan_orders(renewal_key)
+    WHERE renewal_key IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_subscription_plan_orders_user_created_at
+    ON subscription_plan_orders(user_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_plan_orders_subscription_id
+    ON subscription_plan_orders(subscription_id);
+
+ALTER TABLE user_subscriptions
+    ADD COLUMN IF NOT EXISTS plan_id BIGINT,
+    ADD COLUMN IF NOT EXISTS next_plan_id BIGINT,
+    ADD COLUMN IF NOT EXISTS auto_renew BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS renewal_failed_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS daily_limit_usd DECIMAL(20,8),
+    ADD COLUMN IF NOT EXISTS weekly_limit_usd DECIMAL(20,8),
+    ADD COLUMN IF NOT EXISTS monthly_limit_usd DECIMAL(20,8);
+
+CREATE INDEX IF NOT EXISTS usersubscription_plan_id
+    ON user_subscriptions(plan_id);
+
+CREATE INDEX IF NOT EXISTS usersubscription_auto_renew_expires_at
+    ON user_subscriptions(auto_renew, expires_at);
@@ -0,0 +1,40 @@
+-- 046_add_user_notifications.sql
+-- 用户通知偏好与通知记录
+--
+-- user_notification_settings: 每个用户一行；缺省时使用配置中的默认值（余额阈值、到期提醒天数等）。
+-- user_notifications: 已发送的通知记录，(user_id, type, dedup_key) 唯一，
+--   dedup_key 编码通知所属窗口（如额度窗口起点、订阅到期时间），同一窗口只通知一次。
+
+CREATE TABLE IF NOT EXISTS user_notification_settings (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    email_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    webhook_url TEXT NOT NULL DEFAULT '',
+    webhook_secret VARCHAR(128) NOT NULL DEFAULT '',
+    low_balance_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    low_balance_threshold DECIMAL(20,8),
+    quota_alerts_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    expiry_alerts_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    expiry_reminder_days INT,
+    api_key_alerts_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS user_notifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(32) NOT NULL,
+    dedup_key VARCHAR(128) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    email_sent BOOLEAN NOT NULL DEFAULT FALSE,
+    webhook_sent BOOLEAN NOT NULL DEFAULT FALSE,
+    delivery_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_notifications_dedup
+    ON user_notifications(user_id, type, dedup_key);
+
+CREATE INDEX IF NOT EXISTS idx_user_notifications_user_created_at
+    ON user_notifications(user_id, created_at DESC);
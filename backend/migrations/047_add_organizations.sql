@@ -0,0 +1,47 @@
+-- 047_add_organizations.sql
+-- 组织/团队：共享余额或订阅池，成员使用组织 Key 时由组织付费
+--
+-- organizations: 每个组织绑定一个付费账户（users.role = 'organization'，不可登录），
+--   组织余额与订阅均挂在该账户上，复用现有的扣费、充值与订阅逻辑。
+-- organization_members: 成员及角色（owner/admin/member），spending_limit_usd 为成员每月消费上限，
+--   spent_usd 记录 spend_period（YYYY-MM）内的累计消费，跨月后重新计数。
+-- api_keys / usage_logs: 新增 organization_id，组织 Key 产生的用量记录所属组织，user_id 仍为发起成员。
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    owner_user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    billing_user_id BIGINT NOT NULL UNIQUE REFERENCES users(id) ON DELETE RESTRICT,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_organizations_owner_user_id
+    ON organizations(owner_user_id)
+    WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    id BIGSERIAL PRIMARY KEY,
+    organization_id BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'member',
+    spending_limit_usd DECIMAL(20,8),
+    spent_usd DECIMAL(20,10) NOT NULL DEFAULT 0,
+    spend_period VARCHAR(7) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organization_members_org_user
+    ON organization_members(organization_id, user_id);
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_user_id
+    ON organization_members(user_id);
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS organization_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_api_keys_organization_id ON api_keys(organization_id);
+
+ALTER TABLE usage_logs ADD COLUMN IF NOT EXISTS organization_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_usage_logs_organization_id ON usage_logs(organization_id);
@@ -0,0 +1,41 @@
+-- 048_add_resellers.sql
+-- 分销商层级：分销商可创建下级用户、在分组倍率之上设置加价、用自身余额发行兑换码，并查看利润报表
+--
+-- users.parent_user_id: 下级用户所属的分销商，分销商本身也可以是上级分销商的下级（多级）。
+-- resellers: 分销商资格由管理员授予；markup 作用于其下级用户的余额计费（订阅计费不加价）。
+-- reseller_ledger: 每次计费时沿层级向上结算，每一级分销商一条记录：
+--   revenue 为下级支付的价格，cost 为该分销商自身的价格，profit = revenue - cost 记入分销商余额。
+-- redeem_codes.issuer_user_id: 分销商发行的兑换码，面值在发行时从分销商余额扣除，仅其下级用户可兑换。
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS parent_user_id BIGINT REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_users_parent_user_id ON users(parent_user_id);
+
+CREATE TABLE IF NOT EXISTS resellers (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    markup DECIMAL(10,4) NOT NULL DEFAULT 1,
+    max_sub_users INT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS reseller_ledger (
+    id BIGSERIAL PRIMARY KEY,
+    reseller_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    child_user_id BIGINT NOT NULL,
+    source_user_id BIGINT NOT NULL,
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    model VARCHAR(100) NOT NULL DEFAULT '',
+    total_tokens BIGINT NOT NULL DEFAULT 0,
+    revenue DECIMAL(20,10) NOT NULL DEFAULT 0,
+    cost DECIMAL(20,10) NOT NULL DEFAULT 0,
+    profit DECIMAL(20,10) NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reseller_ledger_reseller_created
+    ON reseller_ledger(reseller_id, created_at);
+
+ALTER TABLE redeem_codes ADD COLUMN IF NOT EXISTS issuer_user_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_redeem_codes_issuer_user_id ON redeem_codes(issuer_user_id);
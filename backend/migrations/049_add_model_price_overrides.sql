@@ -0,0 +1,27 @@
+-- 049_add_model_price_overrides.sql
+-- 管理员自定义模型价格：覆盖 LiteLLM 价格数据与硬编码回退价格
+--
+-- model_pattern: 模型名匹配模式（小写，与分组模型路由一致支持末尾 * 通配符），如 "claude-opus-4-*"、"gpt-5*"、"*"。
+-- group_id: 为空表示全局价格；非空时仅对该分组生效，且优先于全局价格。
+-- 每次修改价格都新增一条版本记录（effective_from 为生效时间），同一模式/分组取已生效的最新版本，
+-- 历史版本保留用于追溯；删除为软删除（deleted_at）。价格单位：USD / 百万 token。
+
+CREATE TABLE IF NOT EXISTS model_price_overrides (
+    id BIGSERIAL PRIMARY KEY,
+    model_pattern VARCHAR(200) NOT NULL,
+    group_id BIGINT REFERENCES groups(id) ON DELETE CASCADE,
+    input_price DECIMAL(20,8) NOT NULL DEFAULT 0,
+    output_price DECIMAL(20,8) NOT NULL DEFAULT 0,
+    cache_creation_price DECIMAL(20,8) NOT NULL DEFAULT 0,
+    cache_read_price DECIMAL(20,8) NOT NULL DEFAULT 0,
+    effective_from TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    notes TEXT NOT NULL DEFAULT '',
+    created_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_model_price_overrides_pattern
+    ON model_price_overrides(model_pattern, group_id, effective_from);
+CREATE INDEX IF NOT EXISTS idx_model_price_overrides_group_id
+    ON model_price_overrides(group_id);
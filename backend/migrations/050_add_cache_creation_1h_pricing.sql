@@ -0,0 +1,11 @@
+-- 050_add_cache_creation_1h_pricing.sql
+-- 5 分钟与 1 小时 prompt cache 写入分别计费
+--
+-- model_price_overrides.cache_creation_1h_price: 1 小时缓存写入价格（USD / 百万 token），0 表示按输入价格的 2 倍计算。
+-- usage_logs.cache_creation_1h_cost: 1 小时缓存写入费用（cache_creation_cost 为 5 分钟与 1 小时之和）。
+
+ALTER TABLE model_price_overrides
+    ADD COLUMN IF NOT EXISTS cache_creation_1h_price DECIMAL(20,8) NOT NULL DEFAULT 0;
+
+ALTER TABLE usage_logs
+    ADD COLUMN IF NOT EXISTS cache_creation_1h_cost DECIMAL(20,10) NOT NULL DEFAULT 0;
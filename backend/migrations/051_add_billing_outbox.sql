@@ -0,0 +1,35 @@
+-- 051_add_billing_outbox.sql
+-- 计费发件箱：使用日志与计费事件在同一事务中写入，由幂等 worker 负责扣费
+--
+-- (request_id, api_key_id) 唯一，与 usage_logs 幂等键一致，保证同一请求只计费一次。
+-- status: pending（待扣费/重试中）/ applied（已扣费）/ failed（超过最大重试次数，进入死信等待人工处理）。
+-- reseller_settlement: 分销结算明细（JSON），为空表示直接扣除付费方余额或订阅用量。
+
+CREATE TABLE IF NOT EXISTS billing_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    request_id VARCHAR(64) NOT NULL,
+    api_key_id BIGINT NOT NULL,
+    usage_log_id BIGINT,
+    payer_user_id BIGINT NOT NULL,
+    member_user_id BIGINT NOT NULL,
+    organization_id BIGINT,
+    billing_type SMALLINT NOT NULL DEFAULT 0,
+    subscription_id BIGINT,
+    group_id BIGINT,
+    amount DECIMAL(20,10) NOT NULL DEFAULT 0,
+    reseller_settlement JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    applied_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_billing_outbox_request_api_key
+    ON billing_outbox(request_id, api_key_id);
+CREATE INDEX IF NOT EXISTS idx_billing_outbox_due
+    ON billing_outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_billing_outbox_status_created
+    ON billing_outbox(status, created_at);
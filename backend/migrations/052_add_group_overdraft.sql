@@ -0,0 +1,9 @@
+-- 052_add_group_overdraft.sql
+-- 请求前费用预扣：分组透支容忍额度
+--
+-- 转发前按最大可能费用在 Redis 中预扣余额/订阅额度，预扣总额不得超过
+-- 可用余额（或订阅剩余额度）+ overdraft_usd。默认 0 表示不允许透支。
+
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS overdraft_usd DECIMAL(20,8) NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN groups.overdraft_usd IS '预扣费用时允许透支的额度 (USD)';
@@ -0,0 +1,10 @@
+-- 053_add_group_count_tokens_mode.sql
+-- count_tokens 本地计数：分组级计数方式
+--
+-- upstream: 转发到上游账号（默认，保持原有行为）
+-- local: 进程内估算，不占用上游账号
+-- local_fallback: 进程内估算，请求含 PDF/URL 图片等无法可靠估算的内容时转发上游
+
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS count_tokens_mode VARCHAR(20) NOT NULL DEFAULT 'upstream';
+
+COMMENT ON COLUMN groups.count_tokens_mode IS 'count_tokens 计数方式: upstream/local/local_fallback';
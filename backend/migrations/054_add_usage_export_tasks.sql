@@ -0,0 +1,35 @@
+-- 054_add_usage_export_tasks.sql
+-- 使用记录异步导出任务表
+--
+-- scope: user（用户自助导出，仅用户可见字段）/ admin（管理员导出，包含账号与 IP 等字段）。
+-- status: pending / running / succeeded / failed / expired（下载链接过期，文件已清理）。
+-- file_path 为 usage_export.storage_dir 下的相对路径；download_token 在任务成功后生成，随下载链接下发。
+
+CREATE TABLE IF NOT EXISTS usage_export_tasks (
+    id BIGSERIAL PRIMARY KEY,
+    scope VARCHAR(20) NOT NULL,
+    format VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    filters JSONB NOT NULL,
+    created_by BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    row_count BIGINT NOT NULL DEFAULT 0,
+    file_size BIGINT NOT NULL DEFAULT 0,
+    file_path TEXT NOT NULL DEFAULT '',
+    download_token VARCHAR(64),
+    error_message TEXT,
+    expires_at TIMESTAMPTZ,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_usage_export_tasks_download_token
+    ON usage_export_tasks(download_token)
+    WHERE download_token IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_usage_export_tasks_status_created_at
+    ON usage_export_tasks(status, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_usage_export_tasks_created_by
+    ON usage_export_tasks(created_by, created_at DESC);
@@ -0,0 +1,59 @@
+-- 055_add_user_statements.sql
+-- 用户月度账单：按用户/分组/模型/API Key 的小时级预聚合表，以及不可变的月度账单记录
+--
+-- usage_dashboard_hourly_user_breakdown: 由仪表盘预聚合任务维护（与 usage_dashboard_hourly 同步重算与清理），
+--   group_id 为 0 表示无分组；历史数据可通过仪表盘回填/重算接口补齐。
+-- user_statements: 每个用户每个账期（YYYY-MM，按站点时区）一条，生成后内容（summary/html/pdf）不再修改，
+--   仅邮件投递字段（emailed_at / email_attempts / email_claimed_at）会更新；
+--   email_claimed_at 用于多实例投递时的领取互斥，超时未完成的投递可被重新领取。
+
+CREATE TABLE IF NOT EXISTS usage_dashboard_hourly_user_breakdown (
+    bucket_start TIMESTAMPTZ NOT NULL,
+    user_id BIGINT NOT NULL,
+    api_key_id BIGINT NOT NULL,
+    group_id BIGINT NOT NULL DEFAULT 0,
+    model VARCHAR(100) NOT NULL,
+    total_requests BIGINT NOT NULL DEFAULT 0,
+    input_tokens BIGINT NOT NULL DEFAULT 0,
+    output_tokens BIGINT NOT NULL DEFAULT 0,
+    cache_creation_tokens BIGINT NOT NULL DEFAULT 0,
+    cache_read_tokens BIGINT NOT NULL DEFAULT 0,
+    total_cost DECIMAL(20, 10) NOT NULL DEFAULT 0,
+    actual_cost DECIMAL(20, 10) NOT NULL DEFAULT 0,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (bucket_start, user_id, api_key_id, group_id, model)
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_dashboard_hourly_user_breakdown_user_bucket
+    ON usage_dashboard_hourly_user_breakdown (user_id, bucket_start);
+
+CREATE TABLE IF NOT EXISTS user_statements (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    period VARCHAR(7) NOT NULL,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    currency VARCHAR(10) NOT NULL DEFAULT 'USD',
+    total_requests BIGINT NOT NULL DEFAULT 0,
+    total_tokens BIGINT NOT NULL DEFAULT 0,
+    usage_cost DECIMAL(20, 10) NOT NULL DEFAULT 0,
+    credits_total DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    subscription_charges_total DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    summary JSONB NOT NULL,
+    html TEXT NOT NULL,
+    pdf BYTEA NOT NULL,
+    emailed_at TIMESTAMPTZ,
+    email_attempts INT NOT NULL DEFAULT 0,
+    email_claimed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_statements_user_period
+    ON user_statements (user_id, period);
+
+CREATE INDEX IF NOT EXISTS idx_user_statements_period
+    ON user_statements (period);
+
+CREATE INDEX IF NOT EXISTS idx_user_statements_pending_email
+    ON user_statements (id)
+    WHERE emailed_at IS NULL;
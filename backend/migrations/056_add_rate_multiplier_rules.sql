@@ -0,0 +1,47 @@
+-- 056_add_rate_multiplier_rules.sql
+-- 客户级计费倍率：按用户 / API Key 覆盖分组倍率，并按月消费阶梯折扣
+--
+-- rate_multiplier_overrides: 在指定分组内覆盖分组倍率，user_id 与 api_key_id 二选一；
+--   API Key 覆盖优先于用户覆盖，用户覆盖优先于分组倍率。
+-- volume_discount_tiers: 用户当月（余额计费）消费达到 min_monthly_spend 后，倍率按 discount_percent 打折；
+--   group_id 为空表示适用于所有分组，分组存在专属阶梯时只使用专属阶梯。
+-- usage_logs.rate_rule: 产生 rate_multiplier 的规则，例如 group、user_override:12+volume_tier:3。
+
+CREATE TABLE IF NOT EXISTS rate_multiplier_overrides (
+    id BIGSERIAL PRIMARY KEY,
+    group_id BIGINT NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    api_key_id BIGINT REFERENCES api_keys(id) ON DELETE CASCADE,
+    rate_multiplier DECIMAL(10,4) NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    created_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT rate_multiplier_overrides_target_check CHECK ((user_id IS NULL) <> (api_key_id IS NULL)),
+    CONSTRAINT rate_multiplier_overrides_multiplier_check CHECK (rate_multiplier > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rate_multiplier_overrides_group_user
+    ON rate_multiplier_overrides(group_id, user_id) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rate_multiplier_overrides_group_api_key
+    ON rate_multiplier_overrides(group_id, api_key_id) WHERE api_key_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS volume_discount_tiers (
+    id BIGSERIAL PRIMARY KEY,
+    group_id BIGINT REFERENCES groups(id) ON DELETE CASCADE,
+    min_monthly_spend DECIMAL(20,8) NOT NULL,
+    discount_percent DECIMAL(5,2) NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    created_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT volume_discount_tiers_spend_check CHECK (min_monthly_spend > 0),
+    CONSTRAINT volume_discount_tiers_discount_check CHECK (discount_percent > 0 AND discount_percent < 100)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_volume_discount_tiers_group_spend
+    ON volume_discount_tiers(COALESCE(group_id, 0), min_monthly_spend);
+
+ALTER TABLE usage_logs ADD COLUMN IF NOT EXISTS rate_rule VARCHAR(100);
+
+COMMENT ON COLUMN usage_logs.rate_rule IS '产生 rate_multiplier 的倍率规则（NULL 表示历史数据）';
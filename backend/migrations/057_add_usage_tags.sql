@@ -0,0 +1,36 @@
+-- 057_add_usage_tags.sql
+-- 成本归属标签：客户端通过 X-Sub2api-Tags 请求头或 Anthropic metadata.tags 为请求打标签
+--
+-- usage_tag_keys: 管理员定义的标签键；allowed_values 非空时标签值必须在列表中。
+--   未定义的标签键会被拒绝（400），避免标签维度无限膨胀。
+-- usage_tag_budgets: 用户按标签值设置的月度预算（余额/订阅计费的实际消费合计），
+--   当月消费达到 monthly_limit_usd 后携带该标签值的请求被拒绝。
+-- usage_logs.tags: 请求携带的标签（JSONB，标签键 -> 标签值）。
+--   usage_logs 为分区表，无法被外键引用，因此标签直接存储在日志行上并以 GIN 索引支持 @> 查询。
+
+CREATE TABLE IF NOT EXISTS usage_tag_keys (
+    id BIGSERIAL PRIMARY KEY,
+    key VARCHAR(32) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    allowed_values JSONB NOT NULL DEFAULT '[]'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS usage_tag_budgets (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tag_key VARCHAR(32) NOT NULL REFERENCES usage_tag_keys(key) ON DELETE CASCADE ON UPDATE CASCADE,
+    tag_value VARCHAR(64) NOT NULL,
+    monthly_limit_usd DECIMAL(20,8) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT usage_tag_budgets_limit_check CHECK (monthly_limit_usd > 0),
+    CONSTRAINT usage_tag_budgets_unique UNIQUE (user_id, tag_key, tag_value)
+);
+
+ALTER TABLE usage_logs ADD COLUMN IF NOT EXISTS tags JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_usage_logs_tags ON usage_logs USING GIN (tags jsonb_path_ops);
+
+COMMENT ON COLUMN usage_logs.tags IS '成本归属标签（标签键 -> 标签值），NULL 表示未携带标签';
@@ -0,0 +1,48 @@
+-- 058_add_usage_anomaly_events.sql
+-- API Key 用量异常检测：检测结果事件表与检测水位
+--
+-- usage_anomaly_events: 后台检测任务基于 usage_dashboard_hourly_user_breakdown（以及可选的 usage_logs 来源）
+--   对每个已完成的小时桶逐 Key（及逐用户）与基线比较后写入；(user_id, api_key_id, bucket_start, kind) 唯一，
+--   多实例并发检测时只有写入成功的实例执行停用与通知。
+--   kind: spend_spike / request_spike / model_shift / new_source（Key 级），user_spend_spike（用户级，api_key_id = 0）
+--   severity 与运维告警一致：P0 严重 / P1 警告 / P2 提示
+--   status: open / acknowledged（管理员在运维面板确认）
+--   api_key_id / user_id 不设外键：Key 被删除后事件仍保留用于审计。
+-- usage_anomaly_watermark: 单行，记录已检测到的小时桶（不含），检测只在仪表盘预聚合水位之后推进。
+
+CREATE TABLE IF NOT EXISTS usage_anomaly_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    api_key_id BIGINT NOT NULL,
+    bucket_start TIMESTAMPTZ NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    severity VARCHAR(8) NOT NULL,
+    observed DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    baseline_mean DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    baseline_stddev DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    details JSONB NOT NULL DEFAULT '{}'::jsonb,
+    key_suspended BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    acknowledged_by BIGINT,
+    acknowledged_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT usage_anomaly_events_unique UNIQUE (user_id, api_key_id, bucket_start, kind)
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_anomaly_events_created_at
+    ON usage_anomaly_events (created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_usage_anomaly_events_user_id
+    ON usage_anomaly_events (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_usage_anomaly_events_open
+    ON usage_anomaly_events (created_at DESC) WHERE status = 'open';
+
+CREATE TABLE IF NOT EXISTS usage_anomaly_watermark (
+    id INT PRIMARY KEY,
+    last_bucket_end TIMESTAMPTZ NOT NULL DEFAULT TIMESTAMPTZ '1970-01-01 00:00:00+00',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO usage_anomaly_watermark (id)
+VALUES (1)
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,11 @@
+-- 059_widen_proxy_password_for_encryption.sql
+-- 敏感字段落库加密（security.secret_encryption）
+--
+-- proxies.password 加密后形如 enc:v1:<key_id>:<wrapped_dek>:<ciphertext>，长度超过原 VARCHAR(100)，扩展到 512。
+-- PostgreSQL 放宽 VARCHAR 长度只修改元数据、不重写表，可在线执行。
+--
+-- 存量数据的加密不在 SQL 迁移中完成（数据库不持有主密钥）：启用加密后由后台重加密任务
+-- （security.secret_encryption.reencrypt_on_startup）或 `server -reencrypt-secrets` 分批按 id 扫描
+-- accounts.credentials / proxies.password / settings 敏感项，以原值为条件逐行写回，服务无需停机。
+
+ALTER TABLE proxies ALTER COLUMN password TYPE VARCHAR(512);
@@ -0,0 +1,33 @@
+-- 060_add_gemini_resources.sql
+-- Gemini Files / cachedContents 资源与账号的归属关系
+--
+-- 文件与显式缓存只存在于创建它们的 AI Studio 账号上：
+--   后续 generateContent 引用 files/... 或 cachedContents/... 时按本表路由到归属账号；
+--   资源的读取、更新、删除也只转发到归属账号，并校验 user_id 防止跨用户访问。
+-- resource_type: file / cached_content / upload（可续传上传会话，name 为 uploads/<upload_id>，上传完成后删除）
+-- metadata: 上游返回的资源对象（File / CachedContent JSON），用于本地分页列出资源
+-- expires_at: 上游过期时间，过期行由后台任务清理
+-- account_id / user_id / api_key_id 不设外键：账号或 Key 删除后仍可清理并返回明确错误。
+
+CREATE TABLE IF NOT EXISTS gemini_resources (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    resource_type VARCHAR(20) NOT NULL,
+    account_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    api_key_id BIGINT NOT NULL,
+    group_id BIGINT,
+    model VARCHAR(100) NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    token_count BIGINT NOT NULL DEFAULT 0,
+    metadata JSONB NOT NULL DEFAULT '{}'::jsonb,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT gemini_resources_name_unique UNIQUE (name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_gemini_resources_user_type
+    ON gemini_resources (user_id, resource_type, id);
+CREATE INDEX IF NOT EXISTS idx_gemini_resources_expires_at
+    ON gemini_resources (expires_at) WHERE expires_at IS NOT NULL;
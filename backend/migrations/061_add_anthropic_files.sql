@@ -0,0 +1,29 @@
+-- 061_add_anthropic_files.sql
+-- Anthropic Files API 文件与上游账号的归属关系
+--
+-- 文件只存在于上传时使用的账号上：
+--   /v1/messages 引用 file_id 时按本表固定路由到归属账号；
+--   文件的读取、下载、删除只转发到归属账号，并校验 user_id 防止跨用户访问。
+-- metadata: 上游返回的文件对象（File JSON），用于本地分页列出文件
+-- account_id / user_id / api_key_id 不设外键：账号删除后仍可由管理员清理记录。
+
+CREATE TABLE IF NOT EXISTS anthropic_files (
+    id BIGSERIAL PRIMARY KEY,
+    file_id VARCHAR(255) NOT NULL,
+    account_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    api_key_id BIGINT NOT NULL,
+    group_id BIGINT,
+    filename VARCHAR(512) NOT NULL DEFAULT '',
+    mime_type VARCHAR(255) NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    downloadable BOOLEAN NOT NULL DEFAULT FALSE,
+    metadata JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT anthropic_files_file_id_unique UNIQUE (file_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_anthropic_files_user_id
+    ON anthropic_files (user_id, id);
+CREATE INDEX IF NOT EXISTS idx_anthropic_files_account_id
+    ON anthropic_files (account_id);
@@ -0,0 +1,47 @@
+-- 062_add_sticky_session_stats.sql
+-- 粘性会话缓存热度统计与账号切换记录
+--
+-- sticky_session_stats: 每个 (分组, 会话, 账号) 的累计 token 与缓存命中情况，
+--   缓存命中率 = cache_read_tokens / (input_tokens + cache_creation_tokens + cache_read_tokens)
+-- sticky_session_failovers: 粘性会话主账号不可用导致的切换记录，
+--   estimated_cost 为新账号首个请求因缓存未命中多付的费用（缓存创建价 - 缓存读取价，按实际倍率）
+-- 两表均按 gateway.sticky_standby.stats_retention_days 定期清理。
+
+CREATE TABLE IF NOT EXISTS sticky_session_stats (
+    id BIGSERIAL PRIMARY KEY,
+    group_id BIGINT NOT NULL DEFAULT 0,
+    session_hash VARCHAR(128) NOT NULL,
+    account_id BIGINT NOT NULL,
+    request_count BIGINT NOT NULL DEFAULT 0,
+    input_tokens BIGINT NOT NULL DEFAULT 0,
+    cache_creation_tokens BIGINT NOT NULL DEFAULT 0,
+    cache_read_tokens BIGINT NOT NULL DEFAULT 0,
+    last_cache_read_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT sticky_session_stats_unique UNIQUE (group_id, session_hash, account_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sticky_session_stats_updated_at
+    ON sticky_session_stats (updated_at);
+
+CREATE TABLE IF NOT EXISTS sticky_session_failovers (
+    id BIGSERIAL PRIMARY KEY,
+    group_id BIGINT NOT NULL DEFAULT 0,
+    session_hash VARCHAR(128) NOT NULL,
+    from_account_id BIGINT NOT NULL,
+    to_account_id BIGINT NOT NULL,
+    -- standby: 切换目标是预选的备用账号；warm: 切换时目标账号对该会话仍有缓存热度
+    standby BOOLEAN NOT NULL DEFAULT FALSE,
+    warm BOOLEAN NOT NULL DEFAULT FALSE,
+    model VARCHAR(100) NOT NULL DEFAULT '',
+    cache_creation_tokens BIGINT NOT NULL DEFAULT 0,
+    cache_read_tokens BIGINT NOT NULL DEFAULT 0,
+    estimated_cost DECIMAL(20, 10) NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sticky_session_failovers_session
+    ON sticky_session_failovers (group_id, session_hash, created_at);
+CREATE INDEX IF NOT EXISTS idx_sticky_session_failovers_created_at
+    ON sticky_session_failovers (created_at);
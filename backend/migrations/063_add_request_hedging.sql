@@ -0,0 +1,13 @@
+-- 063_add_request_hedging.sql
+-- 请求对冲：首字节过慢时将同一请求发往第二个账号，先返回响应头的一方胜出，另一方被取消
+--
+-- groups.hedge_enabled: 分组级开关（默认关闭）
+-- usage_logs.hedged: 该请求触发了对冲；只记录胜出账号的用量
+
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS hedge_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN groups.hedge_enabled IS '首字节过慢时是否向第二个账号发送对冲请求';
+
+ALTER TABLE usage_logs ADD COLUMN IF NOT EXISTS hedged BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN usage_logs.hedged IS '请求触发了对冲（仅计费胜出的账号）';
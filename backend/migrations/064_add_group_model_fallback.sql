@@ -0,0 +1,11 @@
+-- 064_add_group_model_fallback.sql
+-- 分组级模型降级链：容量不足时按顺序降级到后备模型（如 opus -> sonnet -> haiku，或跨平台降级到 gemini-2.5-pro）
+--
+-- groups.model_fallback 结构：
+--   {"triggers": ["no_accounts", "overloaded", "switches_exhausted"],
+--    "chains": {"claude-opus-*": [{"model": "claude-sonnet-4-5"}, {"model": "gemini-2.5-pro", "group_id": 12}]}}
+-- triggers 为空时所有触发条件均生效；group_id 为空时在本分组内降级
+
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS model_fallback JSONB;
+
+COMMENT ON COLUMN groups.model_fallback IS '模型降级链配置：触发条件 + 模型模式 -> 有序降级模型列表';
@@ -0,0 +1,50 @@
+-- 065_add_guardrails.sql
+-- 内容策略（guardrails）：在转发前检查请求内容，并可选检查响应（含流式输出）
+--
+-- guardrail_policies: 策略作用范围为全局（group_id 与 api_key_id 均为空）、分组或单个 API Key，
+--   同一请求依次执行全局、分组、API Key 策略。
+--   detectors: 内置检测器（aws_key / private_key / jwt / email / phone）；
+--   keywords: 关键词（不区分大小写）；patterns: 自定义正则；
+--   hook_url: 外部审核钩子（仅检查请求），返回是否命中（flagged）与需要脱敏的片段；
+--   action: block（拒绝请求/中断响应）、redact（替换为 [REDACTED:<检测器>]）、log（仅记录）。
+-- guardrail_events: 命中记录，仅保存检测器名称与命中次数，不保存命中的原文。
+
+CREATE TABLE IF NOT EXISTS guardrail_policies (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    group_id BIGINT REFERENCES groups(id) ON DELETE CASCADE,
+    api_key_id BIGINT REFERENCES api_keys(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    action VARCHAR(20) NOT NULL DEFAULT 'block',
+    detectors JSONB NOT NULL DEFAULT '[]'::jsonb,
+    keywords JSONB NOT NULL DEFAULT '[]'::jsonb,
+    patterns JSONB NOT NULL DEFAULT '[]'::jsonb,
+    hook_url TEXT NOT NULL DEFAULT '',
+    scan_responses BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT guardrail_policies_scope_check CHECK (group_id IS NULL OR api_key_id IS NULL),
+    CONSTRAINT guardrail_policies_action_check CHECK (action IN ('block', 'redact', 'log'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_guardrail_policies_group_id ON guardrail_policies (group_id);
+CREATE INDEX IF NOT EXISTS idx_guardrail_policies_api_key_id ON guardrail_policies (api_key_id);
+
+CREATE TABLE IF NOT EXISTS guardrail_events (
+    id BIGSERIAL PRIMARY KEY,
+    policy_id BIGINT,
+    policy_name VARCHAR(100) NOT NULL DEFAULT '',
+    user_id BIGINT,
+    api_key_id BIGINT,
+    group_id BIGINT,
+    direction VARCHAR(10) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    findings JSONB NOT NULL DEFAULT '{}'::jsonb,
+    model VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_guardrail_events_created_at ON guardrail_events (created_at);
+CREATE INDEX IF NOT EXISTS idx_guardrail_events_api_key_id ON guardrail_events (api_key_id, created_at);
+
+COMMENT ON COLUMN guardrail_events.findings IS '检测器 -> 命中次数（不保存命中原文）';
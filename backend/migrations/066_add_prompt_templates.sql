@@ -0,0 +1,23 @@
+-- 066_add_prompt_templates.sql
+-- 系统提示词模板：管理员按全局、分组或单个 API Key 配置注入到请求中的系统提示词
+--
+-- 作用范围：group_id 与 api_key_id 均为空时为全局模板；同一请求依次应用全局、分组、API Key 模板。
+-- mode: prepend（放在客户端系统提示词之前）、append（之后）、replace（替换客户端系统提示词）。
+-- content 支持变量 {{user_email}}、{{key_name}}、{{date}}。
+
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    group_id BIGINT REFERENCES groups(id) ON DELETE CASCADE,
+    api_key_id BIGINT REFERENCES api_keys(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    mode VARCHAR(20) NOT NULL DEFAULT 'prepend',
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT prompt_templates_scope_check CHECK (group_id IS NULL OR api_key_id IS NULL),
+    CONSTRAINT prompt_templates_mode_check CHECK (mode IN ('prepend', 'append', 'replace'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_prompt_templates_group_id ON prompt_templates (group_id);
+CREATE INDEX IF NOT EXISTS idx_prompt_templates_api_key_id ON prompt_templates (api_key_id);